@@ -0,0 +1,94 @@
+package main
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// demoSpaces is the dataset seeded by --dev; children follow their parent
+var demoSpaces = []struct {
+	name        string
+	parent      string
+	description string
+	posts       []string
+}{
+	{"Projects", "", "Things being built", []string{
+		"Sketched the roadmap for the next quarter",
+		"Decided to keep the stack boring: Go, SQLite and vanilla JS",
+	}},
+	{"Backthynk", "Projects", "Notes on this very app", []string{
+		"Live reload works, editing web/ refreshes the page",
+		"Idea: weekly digest of the busiest spaces #idea",
+		"Fixed the heatmap tooltip overflowing on mobile",
+	}},
+	{"Reading", "", "Books and articles", []string{
+		"Finished *A Philosophy of Software Design*, deep modules stuck with me",
+		"https://go.dev/blog/ is worth skimming every month",
+	}},
+	{"Journal", "", "", []string{
+		"Slow morning, good coffee",
+		"Walked by the river after work",
+		"Too many meetings today",
+	}},
+}
+
+// prepareDevMode points the service at a throwaway data directory with verbose logging and
+// returns that directory, removed again when the server is interrupted
+func prepareDevMode(serviceConfig *config.ServiceConfig) (string, error) {
+	dir, err := os.MkdirTemp("", config.DevDataDirPrefix)
+	if err != nil {
+		return "", fmt.Errorf("failed to create dev data directory: %w", err)
+	}
+
+	serviceConfig.Files.StoragePath = dir
+	serviceConfig.Logging.DisplayLogs = true
+	serviceConfig.Logging.EnableRequestLogs = true
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupted
+		os.RemoveAll(dir)
+		os.Exit(0)
+	}()
+
+	return dir, nil
+}
+
+// seedDemoData fills a fresh database with a few nested spaces and posts spread over the
+// last weeks, so the timeline and activity views have something to show
+func seedDemoData(db *storage.DB) error {
+	spaceIDs := make(map[string]int)
+	now := time.Now()
+	age := 0
+
+	for _, demo := range demoSpaces {
+		var parentID *int
+		if demo.parent != "" {
+			id := spaceIDs[demo.parent]
+			parentID = &id
+		}
+		space, err := db.CreateSpace(demo.name, parentID, demo.description)
+		if err != nil {
+			return fmt.Errorf("failed to seed space %s: %w", demo.name, err)
+		}
+		spaceIDs[demo.name] = space.ID
+
+		for _, content := range demo.posts {
+			// Spread posts a few days apart, oldest first in each space
+			age += 3
+			created := now.AddDate(0, 0, -age).UnixMilli()
+			if _, err := db.CreatePostWithTimestamp(context.Background(), space.ID, content, created); err != nil {
+				return fmt.Errorf("failed to seed post in %s: %w", demo.name, err)
+			}
+		}
+	}
+
+	return nil
+}
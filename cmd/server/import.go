@@ -0,0 +1,34 @@
+package main
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/features/workspace"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// runImport restores a workspace archive into the configured storage, for --import. The
+// server is not started, so nothing holds the database meanwhile. Encrypted archives are
+// opened with the passphrase set in the environment, which keeps it out of the process list.
+func runImport(archivePath string, force bool) error {
+	serviceConfig := config.GetServiceConfig()
+	dbPath := filepath.Join(serviceConfig.Files.StoragePath, serviceConfig.Files.DatabaseFilename)
+	dirs := workspace.DataDirectories(serviceConfig, config.GetOptionsConfig())
+
+	result, err := workspace.Restore(archivePath, dbPath, dirs, force, os.Getenv(config.ArchivePassphraseEnv))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported workspace exported on %s", time.UnixMilli(result.Manifest.Created).Format("2006-01-02 15:04"))
+	if result.Manifest.AppVersion != "" {
+		fmt.Printf(" by version %s", result.Manifest.AppVersion)
+	}
+	fmt.Printf(": database and %d files restored into %s\n", result.Files, serviceConfig.Files.StoragePath)
+	for _, path := range result.MovedTo {
+		fmt.Printf("Previous data moved to %s\n", path)
+	}
+	return nil
+}
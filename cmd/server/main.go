@@ -2,19 +2,92 @@ package main
 
 import (
 	"backthynk/internal/api"
+	"backthynk/internal/api/handlers"
 	"backthynk/internal/config"
 	"backthynk/internal/core/cache"
 	"backthynk/internal/core/events"
+	"backthynk/internal/core/flags"
+	"backthynk/internal/core/jobs"
 	"backthynk/internal/core/logger"
 	"backthynk/internal/core/services"
+	"backthynk/internal/embedded"
 	"backthynk/internal/features/activity"
+	"backthynk/internal/features/auth"
+	"backthynk/internal/features/board"
+	"backthynk/internal/features/changelog"
+	"backthynk/internal/features/coldstorage"
+	"backthynk/internal/features/deltaexport"
 	"backthynk/internal/features/detailedstats"
+	"backthynk/internal/features/dirimport"
+	"backthynk/internal/features/filters"
+	"backthynk/internal/features/glossary"
+	"backthynk/internal/features/graphql"
+	"backthynk/internal/features/ingest"
+	"backthynk/internal/features/links"
+	"backthynk/internal/features/livefeed"
+	"backthynk/internal/features/integrity"
+	"backthynk/internal/features/maintenance"
+	"backthynk/internal/features/memory"
+	"backthynk/internal/features/metrics"
+	"backthynk/internal/features/mobilesync"
+	"backthynk/internal/features/moderation"
+	"backthynk/internal/features/notifications"
+	"backthynk/internal/features/languagestats"
+	"backthynk/internal/features/related"
+	"backthynk/internal/features/resultcache"
+	"backthynk/internal/features/rules"
+	"backthynk/internal/features/search"
+	"backthynk/internal/features/secretscan"
+	"backthynk/internal/features/preferences"
+	"backthynk/internal/features/previews"
+	"backthynk/internal/features/publicguard"
+	"backthynk/internal/features/publishing"
+	"backthynk/internal/features/records"
+	"backthynk/internal/features/requestcost"
+	"backthynk/internal/features/reviews"
+	"backthynk/internal/features/sharelinks"
+	"backthynk/internal/features/snapshots"
+	"backthynk/internal/features/stalespaces"
+	"backthynk/internal/features/summaries"
+	"backthynk/internal/features/signedurls"
+	"backthynk/internal/features/supportbundle"
+	"backthynk/internal/features/tags"
+	"backthynk/internal/features/tasks"
+	"backthynk/internal/features/trash"
+	"backthynk/internal/features/workspace"
 	"backthynk/internal/storage"
+	"flag"
+	"fmt"
 	"log"
-	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// Stamped by release builds with -ldflags "-X main.Version=..."
+var (
+	Version   string
+	Commit    string
+	BuildDate string
 )
 
 func main() {
+	showVersion := flag.Bool("version", false, "print build information and exit")
+	devMode := flag.Bool("dev", false, "serve assets from disk with live reload, seed a throwaway database and log verbosely")
+	safeMode := flag.Bool("safe-mode", false, "start with features disabled, caches filled on demand and writes locked, to recover from corrupted state")
+	importPath := flag.String("import", "", "restore a workspace archive into the configured storage and exit")
+	force := flag.Bool("force", false, "with --import, move existing data aside instead of refusing to replace it")
+	installService := flag.Bool("install-service", false, "write systemd service and socket units running this binary from the current directory and exit")
+	serviceDir := flag.String("service-dir", config.DefaultServiceUnitDir, "with --install-service, directory the units are written to")
+	flag.Parse()
+
+	config.SetBuildInfo(Version, Commit, BuildDate, embedded.IsEmbedded())
+	if *showVersion {
+		fmt.Println(config.GetBuildInfo())
+		return
+	}
+	config.SetDevMode(*devMode)
+	config.SetSafeMode(*safeMode)
+
 	// Ensure config files exist (interactive setup if needed)
 	if err := config.EnsureConfigFiles(); err != nil {
 		log.Fatal("Failed to setup configuration:", err)
@@ -36,8 +109,31 @@ func main() {
 	// Display configuration paths
 	config.PrintConfigPaths()
 
-	// Initialize logger
+	if *importPath != "" {
+		if err := runImport(*importPath, *force); err != nil {
+			log.Fatal("Failed to import workspace:", err)
+		}
+		return
+	}
+
+	if *installService {
+		if err := runInstallService(*serviceDir); err != nil {
+			log.Fatal("Failed to install service:", err)
+		}
+		return
+	}
+
+	// Dev mode runs against a throwaway data directory
 	serviceConfig := config.GetServiceConfig()
+	if *devMode {
+		dir, err := prepareDevMode(serviceConfig)
+		if err != nil {
+			log.Fatal("Failed to prepare dev mode:", err)
+		}
+		fmt.Printf("Dev mode: demo data in %s, removed on exit\n", dir)
+	}
+
+	// Initialize logger
 	if err := logger.Initialize(
 		serviceConfig.Files.StoragePath,
 		serviceConfig.Logging.DisplayLogs,
@@ -54,6 +150,12 @@ func main() {
 	}
 	defer db.Close()
 
+	if *devMode {
+		if err := seedDemoData(db); err != nil {
+			log.Fatal("Failed to seed demo data:", err)
+		}
+	}
+
 	// Initialize event dispatcher
 	dispatcher := events.NewAsyncDispatcher()
 
@@ -65,13 +167,64 @@ func main() {
 	postService := services.NewPostService(db, spaceCache, dispatcher)
 	fileService := services.NewFileService(db, dispatcher)
 
-	// Initialize space cache
-	if err := spaceService.InitializeCache(); err != nil {
-		log.Fatal("Failed to initialize space cache:", err)
+	// Safe mode skips the startup loads: writes are locked and spaces are read on demand
+	if !*safeMode {
+		if err := postService.LoadContentLimits(); err != nil {
+			log.Fatal("Failed to load space limits:", err)
+		}
+		if err := postService.LoadFieldSchemas(); err != nil {
+			log.Fatal("Failed to load space field schemas:", err)
+		}
+		if err := postService.LoadSpaceSettings(); err != nil {
+			log.Fatal("Failed to load space settings:", err)
+		}
+
+		// Initialize space cache
+		if err := spaceService.InitializeCache(); err != nil {
+			log.Fatal("Failed to initialize space cache:", err)
+		}
+		spaceCache.SetLoader(spaceService.LoadSpace)
+		spaceService.StartReconciliation(config.SpaceCacheReconcileInterval)
+		defer spaceService.Stop()
+	} else {
+		spaceCache.SetLoader(spaceService.LoadSpace)
 	}
 
-	// Initialize features
+	// Initialize features, all of them off in safe mode
 	opts := config.GetOptionsConfig()
+	if *safeMode {
+		opts = config.SafeModeOptions(opts)
+	}
+
+	// Change log feature, numbers every change before the other features start making theirs
+	var changeLogService *changelog.Service
+	if opts.Features.ChangeLog.Enabled {
+		changeLogService = changelog.NewService(db, true, opts.Features.ChangeLog.RetentionDays)
+		if err := changeLogService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize change log:", err)
+		}
+		dispatcher.SetSequencer(changeLogService.Sequence)
+		changeLogService.StartPruning(config.ChangeLogPruneInterval)
+		defer changeLogService.Stop()
+	}
+
+	// Request cost feature, caps the spaces and posts recursive operations of a request go through
+	var requestCostService *requestcost.Service
+	if opts.Features.RequestCost.Enabled {
+		requestCostService = requestcost.NewService(true, opts.Features.RequestCost.MaxSpaces, opts.Features.RequestCost.MaxPosts)
+	}
+
+	// Resource budget shared by background jobs so they do not starve interactive requests
+	jobBudget := jobs.NewBudget(opts.Jobs.MaxConcurrent, opts.Jobs.MaxIOMBps, time.Duration(opts.Jobs.PauseLatencyMs)*time.Millisecond)
+
+	// Feature flags, consulted by experimental subsystems shipping dark
+	flagRegistry := flags.NewRegistry(db, flags.Known, opts.Flags)
+	if err := flagRegistry.Initialize(); err != nil {
+		if !*safeMode {
+			log.Fatal("Failed to load feature flag overrides:", err)
+		}
+		fmt.Println("Safe mode: feature flag overrides not loaded:", err)
+	}
 
 	// Detailed Stats feature
 	var detailedStatsService *detailedstats.Service
@@ -82,9 +235,19 @@ func main() {
 		}
 		dispatcher.Subscribe(events.FileUploaded, detailedStatsService.HandleEvent)
 		dispatcher.Subscribe(events.FileDeleted, detailedStatsService.HandleEvent)
+		dispatcher.Subscribe(events.FileDownloaded, detailedStatsService.HandleEvent)
 		dispatcher.Subscribe(events.PostDeleted, detailedStatsService.HandleEvent)
 		dispatcher.Subscribe(events.PostMoved, detailedStatsService.HandleEvent)
+		dispatcher.Subscribe(events.PostUpdated, detailedStatsService.HandleEvent)
+		dispatcher.Subscribe(events.PostMerged, detailedStatsService.HandleEvent)
+		dispatcher.Subscribe(events.PostSplit, detailedStatsService.HandleEvent)
 		dispatcher.Subscribe(events.SpaceUpdated, detailedStatsService.HandleEvent)
+		spaceService.SetFileStatsProvider(func(spaceID int, recursive bool) (int64, int64) {
+			stats := detailedStatsService.GetStats(spaceID, recursive)
+			return stats.FileCount, stats.TotalSize
+		})
+		detailedStatsService.StartSnapshots(config.StatsSnapshotInterval)
+		defer detailedStatsService.Stop()
 	}
 
 	// Activity feature
@@ -97,7 +260,845 @@ func main() {
 		dispatcher.Subscribe(events.PostCreated, activityService.HandleEvent)
 		dispatcher.Subscribe(events.PostDeleted, activityService.HandleEvent)
 		dispatcher.Subscribe(events.PostMoved, activityService.HandleEvent)
+		dispatcher.Subscribe(events.PostUpdated, activityService.HandleEvent)
+		dispatcher.Subscribe(events.PostMerged, activityService.HandleEvent)
+		dispatcher.Subscribe(events.PostSplit, activityService.HandleEvent)
+		dispatcher.Subscribe(events.PostRetimed, activityService.HandleEvent)
 		dispatcher.Subscribe(events.SpaceUpdated, activityService.HandleEvent)
+		spaceService.SetActivityProvider(activityService.LastActivity)
+	}
+
+	// Muted spaces and quiet hours of the notification center, respected by every feature
+	// raising notifications or calling webhooks
+	var deliveries *services.Deliveries
+	if opts.Features.Notifications.Enabled {
+		deliveries = services.NewDeliveries(db, spaceCache)
+		if err := deliveries.Load(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Stale Spaces feature
+	var staleSpacesService *stalespaces.Service
+	if opts.Features.StaleSpaces.Enabled {
+		staleSpacesService = stalespaces.NewService(db, spaceCache, true)
+		if err := staleSpacesService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize stale spaces:", err)
+		}
+		dispatcher.Subscribe(events.PostCreated, staleSpacesService.HandleEvent)
+		dispatcher.Subscribe(events.PostDeleted, staleSpacesService.HandleEvent)
+		dispatcher.Subscribe(events.PostMoved, staleSpacesService.HandleEvent)
+		dispatcher.Subscribe(events.PostMerged, staleSpacesService.HandleEvent)
+		dispatcher.Subscribe(events.PostSplit, staleSpacesService.HandleEvent)
+		dispatcher.Subscribe(events.PostRetimed, staleSpacesService.HandleEvent)
+		dispatcher.Subscribe(events.SpaceDeleted, staleSpacesService.HandleEvent)
+		staleSpacesService.SetNudgeWebhook(opts.Features.StaleSpaces.NudgeWebhookURL)
+		staleSpacesService.SetDeliveries(deliveries)
+		staleSpacesService.StartNudges(opts.Features.StaleSpaces.ThresholdDays, config.StaleNudgeInterval)
+		defer staleSpacesService.Stop()
+	}
+
+	// Tasks feature
+	var tasksService *tasks.Service
+	if opts.Features.Tasks.Enabled {
+		tasksService = tasks.NewService(db, spaceCache, true)
+		if err := tasksService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize tasks:", err)
+		}
+		dispatcher.Subscribe(events.PostCreated, tasksService.HandleEvent)
+		dispatcher.Subscribe(events.PostUpdated, tasksService.HandleEvent)
+		dispatcher.Subscribe(events.PostDeleted, tasksService.HandleEvent)
+		dispatcher.Subscribe(events.PostMoved, tasksService.HandleEvent)
+		dispatcher.Subscribe(events.PostMerged, tasksService.HandleEvent)
+		dispatcher.Subscribe(events.PostSplit, tasksService.HandleEvent)
+		dispatcher.Subscribe(events.SpaceDeleted, tasksService.HandleEvent)
+	}
+
+	// Metrics feature
+	var metricsService *metrics.Service
+	if opts.Features.Metrics.Enabled {
+		metricsService = metrics.NewService(db, spaceCache, true)
+		if err := metricsService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize metrics:", err)
+		}
+		dispatcher.Subscribe(events.PostCreated, metricsService.HandleEvent)
+		dispatcher.Subscribe(events.PostUpdated, metricsService.HandleEvent)
+		dispatcher.Subscribe(events.PostMerged, metricsService.HandleEvent)
+		dispatcher.Subscribe(events.PostSplit, metricsService.HandleEvent)
+	}
+
+	// Glossary feature
+	var glossaryService *glossary.Service
+	if opts.Features.Glossary.Enabled {
+		glossaryService = glossary.NewService(db, spaceCache, true)
+		if err := glossaryService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize glossary:", err)
+		}
+		dispatcher.Subscribe(events.PostCreated, glossaryService.HandleEvent)
+		dispatcher.Subscribe(events.PostUpdated, glossaryService.HandleEvent)
+		dispatcher.Subscribe(events.PostDeleted, glossaryService.HandleEvent)
+		dispatcher.Subscribe(events.PostMoved, glossaryService.HandleEvent)
+		dispatcher.Subscribe(events.PostMerged, glossaryService.HandleEvent)
+		dispatcher.Subscribe(events.PostSplit, glossaryService.HandleEvent)
+		dispatcher.Subscribe(events.SpaceUpdated, glossaryService.HandleEvent)
+		dispatcher.Subscribe(events.SpaceDeleted, glossaryService.HandleEvent)
+		postService.AddContentAnnotator(glossaryService.Annotate)
+	}
+
+	// Public endpoint guard, used by features exposing token-based public routes
+	var publicGuardService *publicguard.Service
+	if opts.Features.PublicGuard.Enabled {
+		publicGuardService = publicguard.NewService(
+			true,
+			opts.Features.PublicGuard.RequestBudget,
+			time.Duration(opts.Features.PublicGuard.BudgetWindowMinutes)*time.Minute,
+			time.Duration(opts.Features.PublicGuard.MaxClockSkewSeconds)*time.Second,
+		)
+	}
+
+	// Trash feature
+	var trashService *trash.Service
+	if opts.Features.Trash.Enabled {
+		trashService = trash.NewService(db, spaceCache, true)
+		trashService.SetRetention(
+			opts.Features.Trash.PostRetentionDays,
+			opts.Features.Trash.AttachmentRetentionDays,
+			opts.Features.Trash.SpaceRetentionDays,
+		)
+		postService.SetTrashKeeper(trashService)
+		spaceService.SetTrashKeeper(trashService)
+		trashService.SetPostRestorer(postService)
+		trashService.SetSpaceRestorer(spaceService)
+		trashService.SetBudget(jobBudget)
+		trashService.StartPurge(config.TrashPurgeInterval)
+		defer trashService.Stop()
+	}
+
+	// Live feed feature
+	var liveFeedService *livefeed.Service
+	if opts.Features.LiveFeed.Enabled {
+		liveFeedService = livefeed.NewService(spaceCache, true)
+		dispatcher.Subscribe(events.PostCreated, liveFeedService.HandleEvent)
+		dispatcher.Subscribe(events.PostUpdated, liveFeedService.HandleEvent)
+		dispatcher.Subscribe(events.PostDeleted, liveFeedService.HandleEvent)
+		dispatcher.Subscribe(events.PostMoved, liveFeedService.HandleEvent)
+		dispatcher.Subscribe(events.PostSplit, liveFeedService.HandleEvent)
+		dispatcher.Subscribe(events.PostPinned, liveFeedService.HandleEvent)
+		dispatcher.Subscribe(events.SpaceUpdated, liveFeedService.HandleEvent)
+		dispatcher.Subscribe(events.FileUploaded, liveFeedService.HandleEvent)
+		dispatcher.Subscribe(events.UploadProgress, liveFeedService.HandleEvent)
+	}
+
+	// Tags feature
+	var tagsService *tags.Service
+	if opts.Features.Tags.Enabled {
+		tagsService = tags.NewService(db, spaceCache, true)
+		if err := tagsService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize tags:", err)
+		}
+		dispatcher.Subscribe(events.PostCreated, tagsService.HandleEvent)
+		dispatcher.Subscribe(events.PostUpdated, tagsService.HandleEvent)
+		dispatcher.Subscribe(events.PostMerged, tagsService.HandleEvent)
+		dispatcher.Subscribe(events.PostSplit, tagsService.HandleEvent)
+	}
+
+	// Duplicate links feature, pointing a link saved again at the posts already holding it
+	var linksService *links.Service
+	if opts.Features.DuplicateLinks.Enabled {
+		linksService = links.NewService(db, spaceCache, true)
+		if opts.Features.DuplicateLinks.ReportDuplicates {
+			maxDuplicates := opts.Features.DuplicateLinks.MaxDuplicates
+			if maxDuplicates <= 0 {
+				maxDuplicates = config.DefaultMaxDuplicateLinks
+			}
+			linksService.SetMaxDuplicates(maxDuplicates)
+		}
+		if err := linksService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize duplicate links:", err)
+		}
+		postService.SetLinkIndex(linksService)
+		dispatcher.Subscribe(events.PostCreated, linksService.HandleEvent)
+		dispatcher.Subscribe(events.PostUpdated, linksService.HandleEvent)
+		dispatcher.Subscribe(events.PostMerged, linksService.HandleEvent)
+		dispatcher.Subscribe(events.PostSplit, linksService.HandleEvent)
+	}
+
+	// Moderation feature, gating content before it is shared
+	var moderationService *moderation.Service
+	if opts.Features.Moderation.Enabled {
+		moderationService = moderation.NewService(db, spaceCache, true)
+		ruleHook, err := moderation.NewRuleHook(opts.Features.Moderation.Rules)
+		if err != nil {
+			log.Fatal("Failed to initialize moderation:", err)
+		}
+		moderationService.AddHook(ruleHook)
+		if opts.Features.Moderation.ExternalURL != "" {
+			moderationService.AddHook(moderation.NewExternalHook(opts.Features.Moderation.ExternalURL))
+		}
+	}
+
+	// Secret scanning feature, screening posts and text attachments on ingest
+	var secretScanService *secretscan.Service
+	if opts.Features.SecretScan.Enabled {
+		secretScanService = secretscan.NewService(db, true, opts.Features.SecretScan.Mode)
+		postService.SetSecretScreener(secretScanService)
+		fileService.SetSecretScreener(secretScanService)
+	}
+
+	// Maintenance feature, vacuuming and optimizing the database during quiet hours
+	var maintenanceService *maintenance.Service
+	if opts.Features.Maintenance.Enabled {
+		maintenanceService = maintenance.NewService(db, true)
+		maintenanceService.SetQuietHours(opts.Features.Maintenance.QuietHoursStart, opts.Features.Maintenance.QuietHoursEnd)
+		maintenanceService.SetBudget(jobBudget)
+		maintenanceService.StartScheduler(config.MaintenanceCheckInterval)
+		defer maintenanceService.Stop()
+	}
+
+	// Startup check feature, quick data invariants logged before serving so corruption surfaces early
+	var integrityService *integrity.Service
+	if opts.Features.StartupCheck.Enabled {
+		integrityService = integrity.NewService(db, spaceCache, true)
+		integrityService.Run()
+	}
+
+	// Saved filters feature, reusable timeline views referenced by filter_id
+	var filtersService *filters.Service
+	if opts.Features.SavedFilters.Enabled {
+		filtersService = filters.NewService(db, spaceCache, true)
+		if err := filtersService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize saved filters:", err)
+		}
+		dispatcher.Subscribe(events.SpaceDeleted, filtersService.HandleEvent)
+		postService.SetFilterResolver(filtersService)
+	}
+
+	// Related posts feature, ranking posts by content similarity
+	var relatedService *related.Service
+	if opts.Features.RelatedPosts.Enabled {
+		relatedService = related.NewService(db, spaceCache, true)
+		if err := relatedService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize related posts:", err)
+		}
+		dispatcher.Subscribe(events.PostCreated, relatedService.HandleEvent)
+		dispatcher.Subscribe(events.PostUpdated, relatedService.HandleEvent)
+		dispatcher.Subscribe(events.PostDeleted, relatedService.HandleEvent)
+		dispatcher.Subscribe(events.PostMoved, relatedService.HandleEvent)
+		dispatcher.Subscribe(events.PostMerged, relatedService.HandleEvent)
+		dispatcher.Subscribe(events.PostSplit, relatedService.HandleEvent)
+		dispatcher.Subscribe(events.SpaceDeleted, relatedService.HandleEvent)
+	}
+
+	// Language stats feature, vocabulary of each space counted from post content
+	var languageStatsService *languagestats.Service
+	if opts.Features.LanguageStats.Enabled {
+		languageStatsService = languagestats.NewService(db, spaceCache, true)
+		if err := languageStatsService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize language stats:", err)
+		}
+		for _, eventType := range []events.EventType{
+			events.PostCreated, events.PostUpdated, events.PostDeleted, events.PostMoved,
+			events.PostMerged, events.PostSplit, events.SpaceDeleted,
+		} {
+			dispatcher.Subscribe(eventType, languageStatsService.HandleEvent)
+		}
+	}
+
+	// Delta export feature, change log of spaces and posts for incremental exports
+	var deltaExportService *deltaexport.Service
+	if opts.Features.DeltaExport.Enabled {
+		deltaExportService = deltaexport.NewService(db, spaceCache, true)
+		if err := deltaExportService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize delta export:", err)
+		}
+		for _, eventType := range []events.EventType{
+			events.PostCreated, events.PostUpdated, events.PostDeleted, events.PostMoved,
+			events.PostMerged, events.PostSplit, events.PostRetimed, events.FileUploaded, events.FileDeleted,
+			events.SpaceCreated, events.SpaceUpdated, events.SpaceDeleted,
+		} {
+			dispatcher.Subscribe(eventType, deltaExportService.HandleEvent)
+		}
+	}
+
+	// Search feature, keyword search with optional semantic search over post embeddings
+	var searchService *search.Service
+	if opts.Features.Search.Enabled {
+		searchService = search.NewService(db, spaceCache, true)
+		if semantic := opts.Features.Search.Semantic; semantic.Enabled {
+			provider, err := search.NewProvider(semantic.Provider, semantic.APIURL, semantic.APIKey, semantic.Model)
+			if err != nil {
+				log.Fatal("Failed to configure semantic search:", err)
+			}
+			searchService.SetProvider(provider)
+		}
+		searchService.SetFlags(flagRegistry)
+		if err := searchService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize search:", err)
+		}
+		dispatcher.Subscribe(events.PostCreated, searchService.HandleEvent)
+		dispatcher.Subscribe(events.PostUpdated, searchService.HandleEvent)
+		dispatcher.Subscribe(events.PostDeleted, searchService.HandleEvent)
+		dispatcher.Subscribe(events.PostMoved, searchService.HandleEvent)
+		dispatcher.Subscribe(events.PostMerged, searchService.HandleEvent)
+		dispatcher.Subscribe(events.PostSplit, searchService.HandleEvent)
+		dispatcher.Subscribe(events.SpaceDeleted, searchService.HandleEvent)
+		dispatcher.Subscribe(events.LinkPreviewSaved, searchService.HandleEvent)
+		searchService.SetDispatcher(dispatcher)
+		searchService.SetBudget(jobBudget)
+		searchService.StartWorker(config.EmbeddingRetryInterval)
+		if err := searchService.ResumeReindex(); err != nil {
+			log.Println("Failed to resume search reindex:", err)
+		}
+		defer searchService.Stop()
+	}
+
+	// Ingest feature, per-space tokens letting external systems post into a space
+	var ingestService *ingest.Service
+	if opts.Features.Ingest.Enabled {
+		ingestService = ingest.NewService(db, spaceCache, true)
+		if err := ingestService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize ingest tokens:", err)
+		}
+		ingestService.SetPostCreator(postService)
+		ingestService.SetDispatcher(dispatcher)
+		dispatcher.Subscribe(events.SpaceDeleted, ingestService.HandleEvent)
+	}
+
+	// Share links feature, expiring links letting people without an account read and post into a space
+	var shareLinksService *sharelinks.Service
+	if opts.Features.ShareLinks.Enabled {
+		shareLinksService = sharelinks.NewService(db, spaceCache, true)
+		if err := shareLinksService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize share links:", err)
+		}
+		shareLinksService.SetPosts(postService)
+		shareLinksService.SetDispatcher(dispatcher)
+		if moderationService != nil {
+			shareLinksService.SetGate(func(spaceID int) (bool, error) {
+				result, err := moderationService.Gate(spaceID, false)
+				if err != nil {
+					return false, err
+				}
+				return result.Allowed, nil
+			})
+		}
+		dispatcher.Subscribe(events.SpaceDeleted, shareLinksService.HandleEvent)
+	}
+
+	// Summaries feature, opt-in summaries of long posts and weekly space digests
+	var summariesService *summaries.Service
+	if opts.Features.Summaries.Enabled {
+		if opts.Features.Summaries.EndpointURL == "" {
+			log.Fatal(config.ErrSummarizerURLRequired)
+		}
+		summariesService = summaries.NewService(db, spaceCache, true)
+		summariesService.SetSummarizer(summaries.NewHTTPSummarizer(opts.Features.Summaries.EndpointURL, opts.Features.Summaries.APIKey))
+		summariesService.SetMinPostLength(opts.Features.Summaries.MinPostLength)
+		summariesService.SetDigestWebhook(opts.Features.Summaries.DigestWebhookURL)
+		summariesService.SetDispatcher(dispatcher)
+		summariesService.SetDeliveries(deliveries)
+		summariesService.StartDigests(config.DigestCheckInterval)
+		defer summariesService.Stop()
+	}
+
+	// Notifications feature, a notification center fed by the notifications raised by other features
+	var notificationsService *notifications.Service
+	if opts.Features.Notifications.Enabled {
+		notificationsService = notifications.NewService(db, true, opts.Features.Notifications.RetentionDays)
+		notificationsService.SetDeliveries(deliveries)
+		dispatcher.Subscribe(events.NotificationRaised, notificationsService.HandleEvent)
+		dispatcher.Subscribe(events.SpaceDeleted, notificationsService.HandleEvent)
+		notificationsService.StartPurge(config.NotificationPurgeInterval)
+		notificationsService.StartRelease(config.HeldDeliveryReleaseInterval)
+		defer notificationsService.Stop()
+	}
+
+	// Memory feature, estimated footprint of the in-memory structures with optional bounds
+	var memoryService *memory.Service
+	if opts.Features.Memory.Enabled {
+		memoryService = memory.NewService(true, opts.Features.Memory.BoundsMB)
+		memoryService.Register(memory.SubsystemSpaceCache, spaceCache)
+		if activityService != nil {
+			memoryService.Register(memory.SubsystemActivity, activityService)
+		}
+		if detailedStatsService != nil {
+			memoryService.Register(memory.SubsystemDetailedStats, detailedStatsService)
+		}
+		if searchService != nil {
+			memoryService.Register(memory.SubsystemSearchIndex, searchService)
+		}
+		memoryService.SetDispatcher(dispatcher)
+		memoryService.StartMonitor(config.MemoryCheckInterval)
+		defer memoryService.Stop()
+	}
+
+	// Snapshots feature, point-in-time copies of space subtrees
+	var snapshotsService *snapshots.Service
+	if opts.Features.Snapshots.Enabled {
+		snapshotsService = snapshots.NewService(db, spaceCache, spaceService, postService, fileService, true, opts.Features.Snapshots.MaxPerSpace)
+		snapshotsService.SetDispatcher(dispatcher)
+		snapshotsService.SetBudget(jobBudget)
+		snapshotsService.StartScheduler(config.SnapshotScheduleCheckInterval)
+		defer snapshotsService.Stop()
+	}
+
+	// Cold storage feature, attachments untouched for months moved to a slower storage root
+	var coldStorageService *coldstorage.Service
+	if opts.Features.ColdStorage.Enabled {
+		coldPath := opts.Features.ColdStorage.Path
+		if coldPath == "" {
+			coldPath = filepath.Join(serviceConfig.Files.StoragePath, config.ColdStorageSubdir)
+		}
+		coldStorageService = coldstorage.NewService(db, spaceCache, coldstorage.NewDirTier(coldPath), true, opts.Features.ColdStorage.AfterMonths)
+		dispatcher.Subscribe(events.FileDownloaded, coldStorageService.HandleEvent)
+		fileService.SetColdStorage(coldStorageService)
+		coldStorageService.SetBudget(jobBudget)
+		coldStorageService.StartArchiver(config.ColdStorageCheckInterval)
+		defer coldStorageService.Stop()
+	}
+
+	// Previews feature, reduced images of attachments with PDFs and videos rendered by external tools
+	var previewsService *previews.Service
+	if opts.Features.Previews.Enabled {
+		previewsService = previews.NewService(db, fileService, true, opts.Features.Previews.MaxSize)
+		if opts.Features.Previews.Documents {
+			previewsService.SetTools(opts.Features.Previews.PDFRenderer, opts.Features.Previews.FFmpeg)
+		}
+		if err := previewsService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize previews:", err)
+		}
+		previewsService.SetDispatcher(dispatcher)
+		previewsService.SetBudget(jobBudget)
+		dispatcher.Subscribe(events.FileUploaded, previewsService.HandleEvent)
+	}
+
+	// Rules feature, user-defined automations run on post events
+	var rulesService *rules.Service
+	if opts.Features.Rules.Enabled {
+		rulesService = rules.NewService(db, spaceCache, true)
+		if err := rulesService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize rules:", err)
+		}
+		rulesService.SetPostActor(postService)
+		rulesService.SetDispatcher(dispatcher)
+		rulesService.SetDeliveries(deliveries)
+		dispatcher.Subscribe(events.PostCreated, rulesService.HandleEvent)
+		dispatcher.Subscribe(events.PostUpdated, rulesService.HandleEvent)
+		dispatcher.Subscribe(events.PostMoved, rulesService.HandleEvent)
+		rulesService.StartReminders(config.RuleReminderInterval)
+		defer rulesService.Stop()
+	}
+
+	// Result cache feature, short-lived copies of recursive aggregation responses
+	var resultCacheService *resultcache.Service
+	if opts.Features.ResultCache.Enabled {
+		resultCacheService = resultcache.NewService(true, opts.Features.ResultCache.TTLSeconds)
+		for _, eventType := range []events.EventType{
+			events.PostCreated, events.PostUpdated, events.PostDeleted, events.PostMoved, events.PostMerged, events.PostSplit, events.PostRetimed, events.PostPinned,
+			events.SpaceCreated, events.SpaceUpdated, events.SpaceDeleted,
+			events.FileUploaded, events.FileDeleted, events.FileDownloaded,
+		} {
+			dispatcher.Subscribe(eventType, resultCacheService.HandleEvent)
+		}
+	}
+
+	// Support bundle feature, diagnostics archive for bug reports. It stays available in safe
+	// mode, where it is needed most.
+	var supportBundleService *supportbundle.Service
+	if opts.Features.SupportBundle.Enabled || config.IsSafeMode() {
+		supportBundleService = supportbundle.NewService(db, true)
+		supportBundleService.Register("space_cache", func() interface{} { return spaceCache.Stats() })
+		if resultCacheService != nil {
+			supportBundleService.Register("result_cache", func() interface{} { return resultCacheService.Stats() })
+		}
+		if memoryService != nil {
+			supportBundleService.Register("memory", func() interface{} { return memoryService.Report() })
+		}
+	}
+
+	// Signed URLs feature, expiring links to single attachment files
+	var signedURLsService *signedurls.Service
+	if opts.Features.SignedURLs.Enabled {
+		signedURLsService = signedurls.NewService(db, true)
+		if err := signedURLsService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize signed URLs:", err)
+		}
+		fileService.SetURLVerifier(signedURLsService)
+	}
+
+	// Directory import feature, mirrors a folder tree into spaces and posts
+	var dirImportService *dirimport.Service
+	if opts.Features.DirectoryImport.Enabled {
+		dirImportService = dirimport.NewService(spaceCache, opts, true)
+		dirImportService.SetTargets(spaceService, postService, fileService)
+	}
+
+	// Publishing feature, rendered posts of selected spaces sent to a static site webhook
+	var publishingService *publishing.Service
+	if opts.Features.Publishing.Enabled {
+		if opts.Features.Publishing.WebhookURL == "" {
+			log.Fatal(config.ErrPublishWebhookURLRequired)
+		}
+		publishingService = publishing.NewService(db, spaceCache, true)
+		if err := publishingService.Initialize(); err != nil {
+			log.Fatal("Failed to initialize publishing:", err)
+		}
+		publishingService.SetWebhook(opts.Features.Publishing.WebhookURL, opts.Features.Publishing.Secret)
+		publishingService.SetRenderer(postService)
+		publishingService.SetDispatcher(dispatcher)
+		for _, eventType := range []events.EventType{
+			events.PostCreated, events.PostUpdated, events.PostDeleted, events.PostMoved, events.PostMerged, events.PostSplit, events.PostRetimed,
+			events.FileUploaded, events.FileDeleted, events.SpaceDeleted,
+		} {
+			dispatcher.Subscribe(eventType, publishingService.HandleEvent)
+		}
+		publishingService.StartDelivery(config.PublishDeliverInterval)
+		defer publishingService.Stop()
+	}
+
+	// Board feature, a handful of pinned posts shown above the feed of a space
+	var boardService *board.Service
+	if opts.Features.Board.Enabled {
+		boardService = board.NewService(db, spaceCache, true)
+		boardService.SetRenderer(postService)
+	}
+
+	// Single sign-on through an OpenID Connect provider and local accounts with a password,
+	// set in service.json so they also protect the instance in safe mode
+	var authService *auth.Service
+	oidcConfig, localConfig := serviceConfig.Auth.OIDC, serviceConfig.Auth.Local
+	if oidcConfig.Enabled || localConfig.Enabled {
+		authService = auth.NewService(db, true)
+	}
+	if oidcConfig.Enabled {
+		if err := auth.ValidateOIDCConfig(oidcConfig); err != nil {
+			log.Fatal(err)
+		}
+		authService.SetProvider(auth.NewOIDCProvider(oidcConfig))
+		authService.SetRoleMapping(oidcConfig.RoleClaim, oidcConfig.RoleMapping, oidcConfig.DefaultRole)
+	}
+	if localConfig.Enabled {
+		if err := auth.ValidateLocalAuthConfig(localConfig); err != nil {
+			log.Fatal(err)
+		}
+		authService.EnableLocalAccounts(localConfig.AllowRegistration, localConfig.DefaultRole)
+	}
+
+	// Per-space access lists, only enforced when signing in is on; a nil access reaches everything
+	var spaceAccess *services.SpaceAccess
+	if authService != nil {
+		spaceAccess = services.NewSpaceAccess(db, spaceCache)
+		if err := spaceAccess.Load(); err != nil {
+			log.Fatal(err)
+		}
+		spaceService.SetSpaceAccess(spaceAccess)
+		postService.SetSpaceAccess(spaceAccess)
+		authService.SetSpaceAccess(spaceAccess)
+		if activityService != nil {
+			activityService.SetSpaceAccess(spaceAccess)
+		}
+		if linksService != nil {
+			linksService.SetSpaceAccess(spaceAccess)
+		}
+		if liveFeedService != nil {
+			liveFeedService.SetSpaceAccess(spaceAccess)
+		}
+		if changeLogService != nil {
+			changeLogService.SetSpaceAccess(spaceAccess)
+		}
+		if previewsService != nil {
+			previewsService.SetSpaceAccess(spaceAccess)
+		}
+		if resultCacheService != nil {
+			resultCacheService.SetSpaceAccess(spaceAccess)
+		}
+		if searchService != nil {
+			searchService.SetSpaceAccess(spaceAccess)
+		}
+		if tagsService != nil {
+			tagsService.SetSpaceAccess(spaceAccess)
+		}
+		if tasksService != nil {
+			tasksService.SetSpaceAccess(spaceAccess)
+		}
+		if relatedService != nil {
+			relatedService.SetSpaceAccess(spaceAccess)
+		}
+		if boardService != nil {
+			boardService.SetSpaceAccess(spaceAccess)
+		}
+		if detailedStatsService != nil {
+			detailedStatsService.SetSpaceAccess(spaceAccess)
+		}
+		if deltaExportService != nil {
+			deltaExportService.SetSpaceAccess(spaceAccess)
+		}
+		if snapshotsService != nil {
+			snapshotsService.SetSpaceAccess(spaceAccess)
+		}
+		if trashService != nil {
+			trashService.SetSpaceAccess(spaceAccess)
+		}
+		if shareLinksService != nil {
+			shareLinksService.SetSpaceAccess(spaceAccess)
+		}
+		if signedURLsService != nil {
+			signedURLsService.SetSpaceAccess(spaceAccess)
+		}
+		if summariesService != nil {
+			summariesService.SetSpaceAccess(spaceAccess)
+		}
+		if glossaryService != nil {
+			glossaryService.SetSpaceAccess(spaceAccess)
+		}
+		if metricsService != nil {
+			metricsService.SetSpaceAccess(spaceAccess)
+		}
+		if languageStatsService != nil {
+			languageStatsService.SetSpaceAccess(spaceAccess)
+		}
+		if staleSpacesService != nil {
+			staleSpacesService.SetSpaceAccess(spaceAccess)
+		}
+		if coldStorageService != nil {
+			coldStorageService.SetSpaceAccess(spaceAccess)
+		}
+		if publishingService != nil {
+			publishingService.SetSpaceAccess(spaceAccess)
+		}
+		if ingestService != nil {
+			ingestService.SetSpaceAccess(spaceAccess)
+		}
+		if rulesService != nil {
+			rulesService.SetSpaceAccess(spaceAccess)
+		}
+	}
+
+	// Preferences feature, such as the landing view, kept per user when signed in
+	var preferencesService *preferences.Service
+	if opts.Features.Preferences.Enabled {
+		preferencesService = preferences.NewService(db, spaceCache, true)
+		if authService != nil {
+			preferencesService.SetUserLookup(auth.UserFromContext)
+		}
+	}
+
+	// Record keeping feature, an immutable hash-chained copy of every prior post version
+	// in the spaces that turn it on
+	var recordsService *records.Service
+	if opts.Features.RecordKeeping.Enabled {
+		recordsService = records.NewService(db, spaceCache, true)
+		recordsService.SetSpaceAccess(spaceAccess)
+		recordsService.SetBudget(jobBudget)
+		recordsService.StartPurge(config.RecordPurgeInterval)
+		defer recordsService.Stop()
+	}
+
+	// Space reviews feature, spaces scheduled for periodic review with a notification when due
+	var reviewsService *reviews.Service
+	if opts.Features.Reviews.Enabled {
+		reviewsService = reviews.NewService(db, spaceCache, true)
+		reviewsService.SetSpaceAccess(spaceAccess)
+		reviewsService.SetDispatcher(dispatcher)
+		reviewsService.StartChecks(config.ReviewCheckInterval)
+		defer reviewsService.Stop()
+	}
+
+	// Workspace archive feature, the database and data files exported for another machine
+	var workspaceService *workspace.Service
+	if opts.Features.WorkspaceArchive.Enabled {
+		workspaceService = workspace.NewService(db, true)
+		workspaceService.SetDirectories(workspace.DataDirectories(serviceConfig, opts))
+	}
+
+	// GraphQL feature, nested spaces, posts, stats and activity fetched in one request
+	var graphqlService *graphql.Service
+	if opts.Features.GraphQL.Enabled {
+		graphqlService = graphql.NewService(db, spaceCache, true)
+		graphqlService.SetSpaceAccess(spaceAccess)
+		if detailedStatsService != nil {
+			graphqlService.SetStatsSource(func(spaceID int, recursive bool) graphql.Stats {
+				stats := detailedStatsService.GetStats(spaceID, recursive)
+				return graphql.Stats{FileCount: stats.FileCount, TotalSize: stats.TotalSize}
+			})
+		}
+		if activityService != nil {
+			graphqlService.SetActivitySource(func(spaceID int, recursive bool, periodMonths int) (*graphql.Activity, error) {
+				period, err := activityService.GetActivityPeriod(activity.ActivityPeriodRequest{
+					SpaceID:      spaceID,
+					Recursive:    recursive,
+					PeriodMonths: periodMonths,
+				})
+				if err != nil {
+					return nil, err
+				}
+				days := make([]graphql.ActivityDay, len(period.Days))
+				for i, day := range period.Days {
+					days[i] = graphql.ActivityDay{Date: day.Date, Count: day.Count}
+				}
+				return &graphql.Activity{
+					StartDate:      period.StartDate,
+					EndDate:        period.EndDate,
+					TotalPosts:     period.Stats.TotalPosts,
+					ActiveDays:     period.Stats.ActiveDays,
+					MaxDayActivity: period.Stats.MaxDayActivity,
+					Days:           days,
+				}, nil
+			})
+		}
+	}
+
+	// Mobile sync feature, the space tree and unread counts in one compact response
+	var mobileSyncService *mobilesync.Service
+	if opts.Features.MobileSync.Enabled {
+		mobileSyncService = mobilesync.NewService(db, spaceCache, true)
+		mobileSyncService.SetSpaceAccess(spaceAccess)
+		if notificationsService != nil {
+			mobileSyncService.SetNotificationSource(notificationsService.UnreadCount)
+		}
+	}
+
+	// Collect route handlers for enabled features
+	featureHandlers := []api.FeatureHandler{handlers.NewFlagsHandler(flagRegistry), handlers.NewJobsHandler(jobBudget)}
+	if detailedStatsService != nil {
+		featureHandlers = append(featureHandlers, detailedstats.NewHandler(detailedStatsService))
+	}
+	if activityService != nil {
+		featureHandlers = append(featureHandlers, activity.NewHandler(activityService))
+	}
+	if staleSpacesService != nil {
+		featureHandlers = append(featureHandlers, stalespaces.NewHandler(staleSpacesService, opts.Features.StaleSpaces.ThresholdDays))
+	}
+	if tasksService != nil {
+		featureHandlers = append(featureHandlers, tasks.NewHandler(tasksService))
+	}
+	if metricsService != nil {
+		featureHandlers = append(featureHandlers, metrics.NewHandler(metricsService))
+	}
+	if glossaryService != nil {
+		featureHandlers = append(featureHandlers, glossary.NewHandler(glossaryService))
+	}
+	if publicGuardService != nil {
+		featureHandlers = append(featureHandlers, publicguard.NewHandler(publicGuardService))
+	}
+	if trashService != nil {
+		featureHandlers = append(featureHandlers, trash.NewHandler(trashService))
+	}
+	if liveFeedService != nil {
+		featureHandlers = append(featureHandlers, livefeed.NewHandler(liveFeedService))
+	}
+	if changeLogService != nil {
+		featureHandlers = append(featureHandlers, changelog.NewHandler(changeLogService))
+	}
+	if requestCostService != nil {
+		featureHandlers = append(featureHandlers, requestcost.NewHandler(requestCostService))
+	}
+	if tagsService != nil {
+		featureHandlers = append(featureHandlers, tags.NewHandler(tagsService))
+	}
+	if linksService != nil {
+		featureHandlers = append(featureHandlers, links.NewHandler(linksService))
+	}
+	if moderationService != nil {
+		featureHandlers = append(featureHandlers, moderation.NewHandler(moderationService))
+	}
+	if secretScanService != nil {
+		featureHandlers = append(featureHandlers, secretscan.NewHandler(secretScanService))
+	}
+	if maintenanceService != nil {
+		featureHandlers = append(featureHandlers, maintenance.NewHandler(maintenanceService))
+	}
+	if integrityService != nil {
+		featureHandlers = append(featureHandlers, integrity.NewHandler(integrityService))
+	}
+	if filtersService != nil {
+		featureHandlers = append(featureHandlers, filters.NewHandler(filtersService))
+	}
+	if relatedService != nil {
+		featureHandlers = append(featureHandlers, related.NewHandler(relatedService))
+	}
+	if languageStatsService != nil {
+		featureHandlers = append(featureHandlers, languagestats.NewHandler(languageStatsService))
+	}
+	if deltaExportService != nil {
+		featureHandlers = append(featureHandlers, deltaexport.NewHandler(deltaExportService))
+	}
+	if searchService != nil {
+		featureHandlers = append(featureHandlers, search.NewHandler(searchService))
+	}
+	if summariesService != nil {
+		featureHandlers = append(featureHandlers, summaries.NewHandler(summariesService))
+	}
+	if ingestService != nil {
+		ingestHandler := ingest.NewHandler(ingestService)
+		if publicGuardService != nil {
+			ingestHandler.SetGuard(publicGuardService.Protect(config.IngestGuardRoute, "token", ingestService.SigningSecret, ingestService.RevokeValue))
+		}
+		featureHandlers = append(featureHandlers, ingestHandler)
+	}
+	if shareLinksService != nil {
+		shareLinksHandler := sharelinks.NewHandler(shareLinksService)
+		if publicGuardService != nil {
+			shareLinksHandler.SetGuard(publicGuardService.Protect(config.ShareLinkGuardRoute, "token", shareLinksService.SigningSecret, shareLinksService.RevokeValue))
+		}
+		featureHandlers = append(featureHandlers, shareLinksHandler)
+	}
+	if notificationsService != nil {
+		featureHandlers = append(featureHandlers, notifications.NewHandler(notificationsService))
+	}
+	if memoryService != nil {
+		featureHandlers = append(featureHandlers, memory.NewHandler(memoryService))
+	}
+	if snapshotsService != nil {
+		featureHandlers = append(featureHandlers, snapshots.NewHandler(snapshotsService))
+	}
+	if coldStorageService != nil {
+		featureHandlers = append(featureHandlers, coldstorage.NewHandler(coldStorageService))
+	}
+	if previewsService != nil {
+		featureHandlers = append(featureHandlers, previews.NewHandler(previewsService))
+	}
+	if rulesService != nil {
+		featureHandlers = append(featureHandlers, rules.NewHandler(rulesService))
+	}
+	if resultCacheService != nil {
+		featureHandlers = append(featureHandlers, resultcache.NewHandler(resultCacheService))
+	}
+	if supportBundleService != nil {
+		featureHandlers = append(featureHandlers, supportbundle.NewHandler(supportBundleService))
+	}
+	if signedURLsService != nil {
+		featureHandlers = append(featureHandlers, signedurls.NewHandler(signedURLsService))
+	}
+	if dirImportService != nil {
+		featureHandlers = append(featureHandlers, dirimport.NewHandler(dirImportService))
+	}
+	if publishingService != nil {
+		featureHandlers = append(featureHandlers, publishing.NewHandler(publishingService))
+	}
+	if boardService != nil {
+		featureHandlers = append(featureHandlers, board.NewHandler(boardService))
+	}
+	if authService != nil {
+		featureHandlers = append(featureHandlers, auth.NewHandler(authService))
+	}
+	if preferencesService != nil {
+		featureHandlers = append(featureHandlers, preferences.NewHandler(preferencesService))
+	}
+	if recordsService != nil {
+		featureHandlers = append(featureHandlers, records.NewHandler(recordsService))
+	}
+	if reviewsService != nil {
+		featureHandlers = append(featureHandlers, reviews.NewHandler(reviewsService))
+	}
+	if workspaceService != nil {
+		featureHandlers = append(featureHandlers, workspace.NewHandler(workspaceService))
+	}
+	if graphqlService != nil {
+		featureHandlers = append(featureHandlers, graphql.NewHandler(graphqlService))
+	}
+	if mobileSyncService != nil {
+		featureHandlers = append(featureHandlers, mobilesync.NewHandler(mobileSyncService))
 	}
 
 	// Initialize API router
@@ -105,17 +1106,27 @@ func main() {
 		spaceService,
 		postService,
 		fileService,
-		detailedStatsService,
-		activityService,
 		opts,
 		config.GetServiceConfig(),
+		featureHandlers...,
 	)
 
 	// Display startup info with features summary and RAM usage
 	config.PrintStartupInfo(serviceConfig.Server.Port, opts)
+	if *safeMode {
+		fmt.Println("Safe mode: features disabled and writes locked, only admin and diagnostic endpoints answer")
+	}
+	if oidcConfig.Enabled {
+		fmt.Printf("Single sign-on: OIDC through %s\n", oidcConfig.Issuer)
+	}
+	if localConfig.Enabled && localConfig.AllowRegistration {
+		fmt.Println("Local accounts: registration open")
+	} else if localConfig.Enabled {
+		fmt.Println("Local accounts: registration closed after the first account")
+	}
 
-	// Start server
-	if err := http.ListenAndServe(":"+serviceConfig.Server.Port, apiRouter); err != nil {
+	// Start server, on the sockets passed by systemd when socket activated
+	if err := serve(serviceConfig.Server.Port, apiRouter); err != nil {
 		log.Fatal("Server failed:", err)
 	}
 }
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/systemd"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"syscall"
+)
+
+// serve answers requests with handler on the sockets passed by systemd socket activation, or
+// on port when started without them. Readiness is notified to systemd once the sockets are
+// open; SIGINT and SIGTERM stop accepting connections and let requests in flight finish.
+func serve(port string, handler http.Handler) error {
+	listeners, err := systemd.Listeners()
+	if err != nil {
+		return err
+	}
+	if len(listeners) > 0 {
+		fmt.Printf("Socket activation: serving %d socket(s) passed by systemd\n", len(listeners))
+	} else {
+		listener, err := net.Listen("tcp", ":"+port)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, listener)
+	}
+
+	server := &http.Server{Handler: handler}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		interrupted := make(chan os.Signal, 1)
+		signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+		<-interrupted
+
+		systemd.Notify(systemd.Stopping)
+		ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Println("Shutdown did not finish in time:", err)
+		}
+	}()
+
+	for _, listener := range listeners[1:] {
+		go func(listener net.Listener) {
+			if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				log.Println("Server failed on", listener.Addr(), err)
+			}
+		}(listener)
+	}
+
+	if _, err := systemd.Notify(systemd.Ready); err != nil {
+		log.Println("Failed to notify systemd:", err)
+	}
+	stopWatchdog := systemd.StartWatchdog()
+	defer stopWatchdog()
+
+	if err := server.Serve(listeners[0]); err != http.ErrServerClosed {
+		return err
+	}
+	<-stopped
+	return nil
+}
+
+// runInstallService writes the systemd service and socket units for --install-service. The
+// service runs this binary from the current directory, where its configuration lives, as the
+// user who invoked sudo or else the current one.
+func runInstallService(dir string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the binary: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("failed to locate the binary: %w", err)
+	}
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to read the working directory: %w", err)
+	}
+
+	account := os.Getenv("SUDO_USER")
+	if account == "" {
+		if current, err := user.Current(); err == nil && current.Uid != "0" {
+			account = current.Username
+		}
+	}
+
+	opts := systemd.UnitOptions{
+		Name:             config.ServiceName,
+		ExecStart:        []string{executable},
+		WorkingDirectory: workDir,
+		User:             account,
+		Port:             config.GetServiceConfig().Server.Port,
+		WatchdogSec:      config.ServiceWatchdogSec,
+		StopTimeout:      config.ShutdownTimeout,
+	}
+	units := []struct {
+		name    string
+		content string
+	}{
+		{config.ServiceName + ".service", systemd.ServiceUnit(opts)},
+		{config.ServiceName + ".socket", systemd.SocketUnit(opts)},
+	}
+
+	if err := os.MkdirAll(dir, config.DirectoryPermissions); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for _, unit := range units {
+		path := filepath.Join(dir, unit.name)
+		if err := os.WriteFile(path, []byte(unit.content), config.FilePermissions); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+
+	fmt.Println("Enable and start the service with:")
+	fmt.Println("  systemctl daemon-reload")
+	fmt.Printf("  systemctl enable --now %s.socket %s.service\n", config.ServiceName, config.ServiceName)
+	return nil
+}
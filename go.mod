@@ -9,6 +9,7 @@ require (
 
 require (
 	go.uber.org/zap v1.27.0
+	golang.org/x/crypto v0.42.0
 	golang.org/x/net v0.44.0
 )
 
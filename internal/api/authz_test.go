@@ -0,0 +1,241 @@
+package api
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/features/auth"
+	"backthynk/internal/features/resultcache"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+)
+
+// reached marks a request that got past authorization, whatever its handler answered
+const reached = 0
+
+// authzMatrix is the expected status of each route for every role. Routes are templates as
+// registered on the router; variables are filled with an ID that does not exist.
+var authzMatrix = []struct {
+	method    string
+	route     string
+	anonymous int
+	viewer    int
+	editor    int
+	admin     int
+}{
+	{"GET", "/api/spaces", 401, reached, reached, reached},
+	{"POST", "/api/spaces", 401, 403, reached, reached},
+	{"GET", "/api/spaces/{id:[0-9]+}", 401, reached, reached, reached},
+	{"PUT", "/api/spaces/{id:[0-9]+}", 401, 403, reached, reached},
+	{"DELETE", "/api/spaces/{id:[0-9]+}", 401, 403, reached, reached},
+	{"GET", "/api/spaces/{id:[0-9]+}/posts", 401, reached, reached, reached},
+	{"POST", "/api/posts", 401, 403, reached, reached},
+	{"GET", "/api/posts/{id:[0-9]+}", 401, reached, reached, reached},
+	{"PUT", "/api/posts/{id:[0-9]+}", 401, 403, reached, reached},
+	{"DELETE", "/api/posts/{id:[0-9]+}", 401, 403, reached, reached},
+	{"GET", "/api/posts/{id:[0-9]+}/revisions", 401, reached, reached, reached},
+	{"PUT", "/api/posts/{id:[0-9]+}/move", 401, 403, reached, reached},
+	{"POST", "/api/posts/bulk-retime", 401, 403, reached, reached},
+	{"GET", "/api/settings", 401, reached, reached, reached},
+	{"PUT", "/api/settings", 401, 403, 403, reached},
+	{"GET", "/api/logs", 401, 403, 403, reached},
+	{"GET", "/api/version", 401, reached, reached, reached},
+	{"GET", "/api/ui/commands", 401, reached, reached, reached},
+	{"GET", "/uploads/{filename}", 401, reached, reached, reached},
+	{"GET", "/api/admin/space-cache", 401, 403, 403, reached},
+	{"POST", "/api/admin/space-cache/invalidate", 401, 403, 403, reached},
+	{"GET", "/api/admin/users", 401, 403, 403, reached},
+	{"GET", "/api/admin/authz-matrix", 401, 403, 403, reached},
+	{"GET", "/api/auth/me", 401, reached, reached, reached},
+	{"GET", "/api/sessions", 401, reached, reached, reached},
+	{"DELETE", "/api/sessions/{id:[0-9]+}", 401, reached, reached, reached},
+	{"GET", "/auth/oidc/login", reached, reached, reached, reached},
+}
+
+var routeVariable = regexp.MustCompile(`\{[^}]+\}`)
+
+// stubProvider logs users in with the role named by the code
+type stubProvider struct{}
+
+func (stubProvider) AuthURL(ctx context.Context, state, nonce string) (string, error) {
+	return "https://id.example.com/authorize?state=" + state, nil
+}
+
+func (stubProvider) Exchange(ctx context.Context, code, nonce string) (*auth.Identity, error) {
+	return &auth.Identity{Subject: code, Claims: map[string]interface{}{"groups": code}}, nil
+}
+
+// setupAuthzRouter builds the full router behind authentication and returns a session token
+// for each role. Extra features are given to the router ahead of authentication.
+func setupAuthzRouter(t *testing.T, features ...FeatureHandler) (http.Handler, map[string]string, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_authz_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	spaceCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	spaceService := services.NewSpaceService(db, spaceCache, dispatcher)
+	postService := services.NewPostService(db, spaceCache, dispatcher)
+	fileService := services.NewFileService(db, dispatcher)
+	if err := spaceService.InitializeCache(); err != nil {
+		t.Fatalf("Failed to initialize cache: %v", err)
+	}
+
+	authService := auth.NewService(db, true)
+	authService.SetProvider(stubProvider{})
+	mapping := make(map[string]string)
+	for _, role := range auth.Roles {
+		mapping[role] = role
+	}
+	authService.SetRoleMapping("groups", mapping, "")
+
+	tokens := make(map[string]string)
+	for _, role := range auth.Roles {
+		state, _, err := authService.BeginLogin(context.Background())
+		if err != nil {
+			t.Fatalf("BeginLogin failed: %v", err)
+		}
+		token, _, err := authService.CompleteLogin(context.Background(), state, role, "", "")
+		if err != nil {
+			t.Fatalf("Login as %s failed: %v", role, err)
+		}
+		tokens[role] = token
+	}
+
+	// The settings handler reads the global options and version
+	options := config.NewTestOptionsConfig()
+	config.SetOptionsConfigForTest(options)
+	config.SetSharedConfigForTest(&config.SharedConfig{})
+
+	features = append(features, auth.NewHandler(authService))
+	router := NewRouter(spaceService, postService, fileService, options, testConfig, features...)
+	return router, tokens, func() {
+		config.SetOptionsConfigForTest(nil)
+		config.SetSharedConfigForTest(nil)
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestAuthorizationMatrix(t *testing.T) {
+	router, tokens, cleanup := setupAuthzRouter(t)
+	defer cleanup()
+
+	checkAuthorizationMatrix(t, router, tokens)
+}
+
+// Feature middlewares registered before authentication still run behind it
+func TestAuthorizationMatrixWithResultCache(t *testing.T) {
+	resultCache := resultcache.NewService(true, 60)
+	router, tokens, cleanup := setupAuthzRouter(t, resultcache.NewHandler(resultCache))
+	defer cleanup()
+
+	checkAuthorizationMatrix(t, router, tokens)
+
+	// A listing cached for an admin is not served to anonymous clients
+	path := "/api/spaces/0/posts?recursive=true"
+	req := httptest.NewRequest("GET", path, nil)
+	req.AddCookie(&http.Cookie{Name: config.SessionCookieName, Value: tokens[models.RoleAdmin]})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get(config.ResultCacheStatusHeader) != resultcache.StatusMiss {
+		t.Fatalf("Expected the admin listing to be computed, got %d (%q)", w.Code, w.Header().Get(config.ResultCacheStatusHeader))
+	}
+	if stats := resultCache.Stats(); stats.Misses != 1 {
+		t.Errorf("Expected the request to go through the cache once, got %d misses", stats.Misses)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	if w.Code != http.StatusUnauthorized || w.Header().Get(config.ResultCacheStatusHeader) != "" {
+		t.Errorf("Expected 401 without touching the cache, got %d (%q)", w.Code, w.Header().Get(config.ResultCacheStatusHeader))
+	}
+}
+
+func checkAuthorizationMatrix(t *testing.T, router http.Handler, tokens map[string]string) {
+	t.Helper()
+
+	for _, tt := range authzMatrix {
+		path := routeVariable.ReplaceAllString(tt.route, "999")
+		for role, expected := range map[string]int{
+			"": tt.anonymous, models.RoleViewer: tt.viewer, models.RoleEditor: tt.editor, models.RoleAdmin: tt.admin,
+		} {
+			req := httptest.NewRequest(tt.method, path, nil)
+			if role != "" {
+				req.AddCookie(&http.Cookie{Name: config.SessionCookieName, Value: tokens[role]})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			denied := w.Code == http.StatusUnauthorized || w.Code == http.StatusForbidden
+			if expected == reached && denied || expected != reached && w.Code != expected {
+				t.Errorf("%s %s as %q: expected %d, got %d", tt.method, path, role, expected, w.Code)
+			}
+		}
+	}
+}
+
+func TestAuthorizationMatrixEndpoint(t *testing.T) {
+	router, tokens, cleanup := setupAuthzRouter(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/api/admin/authz-matrix", nil)
+	req.AddCookie(&http.Cookie{Name: config.SessionCookieName, Value: tokens[models.RoleAdmin]})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	var entries []auth.PolicyEntry
+	if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+		t.Fatalf("Failed to decode matrix: %v", err)
+	}
+	policy := make(map[string]auth.PolicyEntry)
+	for _, entry := range entries {
+		policy[entry.Method+" "+entry.Path] = entry
+	}
+
+	// The exported policy agrees with the enforced one
+	for _, tt := range authzMatrix {
+		entry, ok := policy[tt.method+" "+tt.route]
+		if !ok {
+			t.Errorf("%s %s missing from the matrix", tt.method, tt.route)
+			continue
+		}
+		if entry.Public != (tt.anonymous != http.StatusUnauthorized) {
+			t.Errorf("%s %s: expected public %v", tt.method, tt.route, !entry.Public)
+		}
+		for role, expected := range map[string]int{models.RoleViewer: tt.viewer, models.RoleEditor: tt.editor, models.RoleAdmin: tt.admin} {
+			if entry.Roles[role] != (expected != http.StatusForbidden) {
+				t.Errorf("%s %s: expected %s allowed %v", tt.method, tt.route, role, !entry.Roles[role])
+			}
+		}
+	}
+
+	// Admin endpoints are never open to other roles, whoever registers them
+	adminRoute := regexp.MustCompile(`^/api/admin/`)
+	for _, entry := range entries {
+		if adminRoute.MatchString(entry.Path) && (entry.Public || entry.Roles[models.RoleEditor]) {
+			t.Errorf("%s %s is open beyond admins", entry.Method, entry.Path)
+		}
+	}
+}
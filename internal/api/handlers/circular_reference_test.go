@@ -8,6 +8,7 @@ import (
 	"backthynk/internal/core/services"
 	"backthynk/internal/storage"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -116,11 +117,11 @@ func TestCircularReference_SimpleLoop(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create spaces: A -> B
-	catA, err := setup.spaceService.Create("Space A", nil, "Space A")
+	catA, err := setup.spaceService.Create(context.Background(), "Space A", nil, "Space A")
 	if err != nil {
 		t.Fatalf("Failed to create Space A: %v", err)
 	}
-	catB, err := setup.spaceService.Create("Space B", &catA.ID, "Space B")
+	catB, err := setup.spaceService.Create(context.Background(), "Space B", &catA.ID, "Space B")
 	if err != nil {
 		t.Fatalf("Failed to create Space B: %v", err)
 	}
@@ -198,15 +199,15 @@ func TestCircularReference_ComplexLoop(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create spaces: A -> B -> C (within depth limit)
-	catA, err := setup.spaceService.Create("Space A", nil, "Space A")
+	catA, err := setup.spaceService.Create(context.Background(), "Space A", nil, "Space A")
 	if err != nil {
 		t.Fatalf("Failed to create Space A: %v", err)
 	}
-	catB, err := setup.spaceService.Create("Space B", &catA.ID, "Space B")
+	catB, err := setup.spaceService.Create(context.Background(), "Space B", &catA.ID, "Space B")
 	if err != nil {
 		t.Fatalf("Failed to create Space B: %v", err)
 	}
-	catC, err := setup.spaceService.Create("Space C", &catB.ID, "Space C")
+	catC, err := setup.spaceService.Create(context.Background(), "Space C", &catB.ID, "Space C")
 	if err != nil {
 		t.Fatalf("Failed to create Space C: %v", err)
 	}
@@ -323,7 +324,7 @@ func TestCircularReference_SelfReference(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create space
-	cat, err := setup.spaceService.Create("Self Reference Space", nil, "Test space")
+	cat, err := setup.spaceService.Create(context.Background(), "Self Reference Space", nil, "Test space")
 	if err != nil {
 		t.Fatalf("Failed to create space: %v", err)
 	}
@@ -381,29 +382,29 @@ func TestCircularReference_PostOperationsWithCircularSpaces(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create spaces with potential circular reference
-	catA, err := setup.spaceService.Create("Space A", nil, "Space A")
+	catA, err := setup.spaceService.Create(context.Background(), "Space A", nil, "Space A")
 	if err != nil {
 		t.Fatalf("Failed to create Space A: %v", err)
 	}
-	catB, err := setup.spaceService.Create("Space B", &catA.ID, "Space B")
+	catB, err := setup.spaceService.Create(context.Background(), "Space B", &catA.ID, "Space B")
 	if err != nil {
 		t.Fatalf("Failed to create Space B: %v", err)
 	}
-	catC, err := setup.spaceService.Create("Space C", &catB.ID, "Space C")
+	catC, err := setup.spaceService.Create(context.Background(), "Space C", &catB.ID, "Space C")
 	if err != nil {
 		t.Fatalf("Failed to create Space C: %v", err)
 	}
 
 	// Create posts in these spaces
-	post1, err := setup.postService.Create(catA.ID, "Post in A", nil)
+	post1, err := setup.postService.Create(context.Background(), catA.ID, "Post in A", nil)
 	if err != nil {
 		t.Fatalf("Failed to create post in Space A: %v", err)
 	}
-	_, err = setup.postService.Create(catB.ID, "Post in B", nil)
+	_, err = setup.postService.Create(context.Background(), catB.ID, "Post in B", nil)
 	if err != nil {
 		t.Fatalf("Failed to create post in Space B: %v", err)
 	}
-	post3, err := setup.postService.Create(catC.ID, "Post in C", nil)
+	post3, err := setup.postService.Create(context.Background(), catC.ID, "Post in C", nil)
 	if err != nil {
 		t.Fatalf("Failed to create post in Space C: %v", err)
 	}
@@ -555,29 +556,29 @@ func TestCircularReference_SpaceDeletionWithCircularReference(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create spaces: A -> B -> C
-	catA, err := setup.spaceService.Create("Space A", nil, "Space A")
+	catA, err := setup.spaceService.Create(context.Background(), "Space A", nil, "Space A")
 	if err != nil {
 		t.Fatalf("Failed to create Space A: %v", err)
 	}
-	catB, err := setup.spaceService.Create("Space B", &catA.ID, "Space B")
+	catB, err := setup.spaceService.Create(context.Background(), "Space B", &catA.ID, "Space B")
 	if err != nil {
 		t.Fatalf("Failed to create Space B: %v", err)
 	}
-	catC, err := setup.spaceService.Create("Space C", &catB.ID, "Space C")
+	catC, err := setup.spaceService.Create(context.Background(), "Space C", &catB.ID, "Space C")
 	if err != nil {
 		t.Fatalf("Failed to create Space C: %v", err)
 	}
 
 	// Create posts in each space
-	_, err = setup.postService.Create(catA.ID, "Post in A", nil)
+	_, err = setup.postService.Create(context.Background(), catA.ID, "Post in A", nil)
 	if err != nil {
 		t.Fatalf("Failed to create post in Space A: %v", err)
 	}
-	_, err = setup.postService.Create(catB.ID, "Post in B", nil)
+	_, err = setup.postService.Create(context.Background(), catB.ID, "Post in B", nil)
 	if err != nil {
 		t.Fatalf("Failed to create post in Space B: %v", err)
 	}
-	_, err = setup.postService.Create(catC.ID, "Post in C", nil)
+	_, err = setup.postService.Create(context.Background(), catC.ID, "Post in C", nil)
 	if err != nil {
 		t.Fatalf("Failed to create post in Space C: %v", err)
 	}
@@ -654,31 +655,31 @@ func TestCircularReference_StressTestWithManyOperations(t *testing.T) {
 
 	// Create spaces within depth limit: Root -> A, Root -> B, A -> C
 	spaces := make([]*models.Space, 4)
-	spaces[0], err = setup.spaceService.Create("Root Space", nil, "Root")
+	spaces[0], err = setup.spaceService.Create(context.Background(), "Root Space", nil, "Root")
 	if err != nil {
 		t.Fatalf("Failed to create root space: %v", err)
 	}
 
 	// Create two spaces under root
-	spaces[1], err = setup.spaceService.Create("Space A", &spaces[0].ID, "Space A")
+	spaces[1], err = setup.spaceService.Create(context.Background(), "Space A", &spaces[0].ID, "Space A")
 	if err != nil {
 		t.Fatalf("Failed to create Space A: %v", err)
 	}
 
-	spaces[2], err = setup.spaceService.Create("Space B", &spaces[0].ID, "Space B")
+	spaces[2], err = setup.spaceService.Create(context.Background(), "Space B", &spaces[0].ID, "Space B")
 	if err != nil {
 		t.Fatalf("Failed to create Space B: %v", err)
 	}
 
 	// Create one more at depth 2
-	spaces[3], err = setup.spaceService.Create("Space C", &spaces[1].ID, "Space C")
+	spaces[3], err = setup.spaceService.Create(context.Background(), "Space C", &spaces[1].ID, "Space C")
 	if err != nil {
 		t.Fatalf("Failed to create Space C: %v", err)
 	}
 
 	// Create posts in each space
 	for i, cat := range spaces {
-		_, err := setup.postService.Create(cat.ID, fmt.Sprintf("Post in space %d", i), nil)
+		_, err := setup.postService.Create(context.Background(), cat.ID, fmt.Sprintf("Post in space %d", i), nil)
 		if err != nil {
 			t.Fatalf("Failed to create post in space %d: %v", i, err)
 		}
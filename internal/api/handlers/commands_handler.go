@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+)
+
+// Command kinds: api commands are requests against the server, navigate commands open a page
+const (
+	CommandKindAPI      = "api"
+	CommandKindNavigate = "navigate"
+)
+
+// CommandParam is an input of a command, read from the path, the query string or the JSON body
+type CommandParam struct {
+	Name        string   `json:"name"`
+	In          string   `json:"in"` // path, query or body
+	Type        string   `json:"type"`
+	Required    bool     `json:"required"`
+	Description string   `json:"description"`
+	Enum        []string `json:"enum,omitempty"`
+	Max         int      `json:"max,omitempty"`
+}
+
+// Command is an action a client can offer, such as an entry of a command palette. Path holds
+// {name} placeholders for path parameters.
+type Command struct {
+	ID       string         `json:"id"`
+	Title    string         `json:"title"`
+	Kind     string         `json:"kind"`
+	Method   string         `json:"method,omitempty"`
+	Path     string         `json:"path"`
+	Shortcut string         `json:"shortcut,omitempty"` // Suggested key binding
+	Params   []CommandParam `json:"params"`
+}
+
+type CommandsHandler struct {
+	options *config.OptionsConfig
+}
+
+func NewCommandsHandler(options *config.OptionsConfig) *CommandsHandler {
+	return &CommandsHandler{options: options}
+}
+
+// GetCommands handles GET /api/ui/commands
+func (h *CommandsHandler) GetCommands(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Commands())
+}
+
+// Commands returns the actions available with the current options. Parameters of disabled
+// features are left out, as are commands of disabled features.
+func (h *CommandsHandler) Commands() []Command {
+	createPost := Command{
+		ID: "post.create", Title: "Create post", Kind: CommandKindAPI,
+		Method: "POST", Path: "/api/posts", Shortcut: "n",
+		Params: []CommandParam{
+			{Name: "space_id", In: "body", Type: "integer", Required: true, Description: "Space receiving the post"},
+			{Name: "content", In: "body", Type: "string", Required: true, Description: "Text of the post", Max: h.options.Core.MaxContentLength},
+		},
+	}
+	if h.options.Features.RetroactivePosting.Enabled {
+		createPost.Params = append(createPost.Params, CommandParam{
+			Name: "custom_timestamp", In: "body", Type: "integer", Description: "Creation time in milliseconds since epoch, now when missing",
+		})
+	}
+
+	commands := []Command{
+		createPost,
+		{
+			ID: "post.move", Title: "Move post", Kind: CommandKindAPI,
+			Method: "PUT", Path: "/api/posts/{id}/move", Shortcut: "m",
+			Params: []CommandParam{
+				{Name: "id", In: "path", Type: "integer", Required: true, Description: "Post to move"},
+				{Name: "space_id", In: "body", Type: "integer", Required: true, Description: "Destination space"},
+			},
+		},
+		{
+			ID: "space.create", Title: "Create space", Kind: CommandKindAPI,
+			Method: "POST", Path: "/api/spaces", Shortcut: "shift+n",
+			Params: []CommandParam{
+				{Name: "name", In: "body", Type: "string", Required: true, Description: "Name of the space", Max: config.MaxSpaceNameLength},
+				{Name: "parent_id", In: "body", Type: "integer", Description: "Parent space, a root space when missing"},
+				{Name: "description", In: "body", Type: "string", Description: "Description of the space", Max: config.MaxSpaceDescriptionLength},
+			},
+		},
+		{
+			ID: "space.find", Title: "Find space", Kind: CommandKindAPI,
+			Method: "GET", Path: "/api/spaces/search", Shortcut: "g s",
+			Params: []CommandParam{
+				{Name: "q", In: "query", Type: "string", Required: true, Description: "Part of the space name"},
+				{Name: "limit", In: "query", Type: "integer", Description: "Maximum results", Max: config.MaxSpaceSearchLimit},
+			},
+		},
+		{
+			ID: "space.navigate", Title: "Go to space", Kind: CommandKindNavigate,
+			Path: "/{path}",
+			Params: []CommandParam{
+				{Name: "path", In: "path", Type: "string", Required: true, Description: "Slugs of the space and its ancestors, root first, joined by /"},
+			},
+		},
+	}
+
+	if h.options.Features.Search.Enabled {
+		modes := []string{config.SearchModeKeyword}
+		if h.options.Features.Search.Semantic.Enabled {
+			modes = append(modes, config.SearchModeSemantic)
+		}
+		commands = append(commands, Command{
+			ID: "post.search", Title: "Search posts", Kind: CommandKindAPI,
+			Method: "GET", Path: "/api/search", Shortcut: "/",
+			Params: []CommandParam{
+				{Name: "q", In: "query", Type: "string", Required: true, Description: "Words to look for", Max: config.MaxSearchQueryLength},
+				{Name: "mode", In: "query", Type: "string", Description: "Search mode, keyword when missing", Enum: modes},
+				{Name: "space_id", In: "query", Type: "integer", Description: "Space to search, every space when missing"},
+				{Name: "recursive", In: "query", Type: "boolean", Description: "Include the descendants of the space"},
+				{Name: "limit", In: "query", Type: "integer", Description: "Maximum results", Max: config.MaxSearchLimit},
+			},
+		})
+	}
+
+	return commands
+}
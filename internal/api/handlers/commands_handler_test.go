@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCommandsHandler_GetCommands(t *testing.T) {
+	options := config.NewTestOptionsConfig()
+	options.Features.Search.Enabled = false
+
+	get := func() map[string]Command {
+		w := httptest.NewRecorder()
+		NewCommandsHandler(options).GetCommands(w, httptest.NewRequest("GET", "/api/ui/commands", nil))
+
+		var commands []Command
+		if err := json.NewDecoder(w.Body).Decode(&commands); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		byID := make(map[string]Command)
+		for _, command := range commands {
+			byID[command.ID] = command
+		}
+		return byID
+	}
+
+	commands := get()
+	for _, id := range []string{"post.create", "post.move", "space.create", "space.find", "space.navigate"} {
+		if _, ok := commands[id]; !ok {
+			t.Errorf("Expected command %s", id)
+		}
+	}
+	if _, ok := commands["post.search"]; ok {
+		t.Error("Expected no search command with search disabled")
+	}
+	if nav := commands["space.navigate"]; nav.Kind != CommandKindNavigate || nav.Method != "" {
+		t.Errorf("Expected space.navigate to be a navigation, got %+v", nav)
+	}
+	for _, param := range commands["post.create"].Params {
+		if param.Name == "custom_timestamp" {
+			t.Error("Expected no custom timestamp with retroactive posting disabled")
+		}
+	}
+
+	options.Features.Search.Enabled = true
+	options.Features.Search.Semantic.Enabled = false
+	options.Features.RetroactivePosting.Enabled = true
+	commands = get()
+
+	search, ok := commands["post.search"]
+	if !ok {
+		t.Fatal("Expected a search command with search enabled")
+	}
+	for _, param := range search.Params {
+		if param.Name == "mode" && (len(param.Enum) != 1 || param.Enum[0] != config.SearchModeKeyword) {
+			t.Errorf("Expected keyword mode only without semantic search, got %v", param.Enum)
+		}
+	}
+	found := false
+	for _, param := range commands["post.create"].Params {
+		found = found || param.Name == "custom_timestamp"
+	}
+	if !found {
+		t.Error("Expected a custom timestamp with retroactive posting enabled")
+	}
+}
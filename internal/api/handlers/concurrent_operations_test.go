@@ -8,6 +8,7 @@ import (
 	"backthynk/internal/core/services"
 	"backthynk/internal/storage"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -122,7 +123,7 @@ func TestConcurrentSpaceCreation(t *testing.T) {
 	createdSpaces := make(chan *models.Space, numGoroutines)
 
 	// Create parent space
-	parent, err := setup.spaceService.Create("Parent Space", nil, "Parent for concurrent test")
+	parent, err := setup.spaceService.Create(context.Background(), "Parent Space", nil, "Parent for concurrent test")
 	if err != nil {
 		t.Fatalf("Failed to create parent space: %v", err)
 	}
@@ -218,7 +219,7 @@ func TestConcurrentPostCreation(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test space
-	space, err := setup.spaceService.Create("Test Space", nil, "Test space for concurrent posts")
+	space, err := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test space for concurrent posts")
 	if err != nil {
 		t.Fatalf("Failed to create test space: %v", err)
 	}
@@ -310,11 +311,11 @@ func TestConcurrentPostMoves(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test spaces
-	space1, err := setup.spaceService.Create("Space 1", nil, "Space 1")
+	space1, err := setup.spaceService.Create(context.Background(), "Space 1", nil, "Space 1")
 	if err != nil {
 		t.Fatalf("Failed to create Space 1: %v", err)
 	}
-	space2, err := setup.spaceService.Create("Space 2", nil, "Space 2")
+	space2, err := setup.spaceService.Create(context.Background(), "Space 2", nil, "Space 2")
 	if err != nil {
 		t.Fatalf("Failed to create Space 2: %v", err)
 	}
@@ -324,7 +325,7 @@ func TestConcurrentPostMoves(t *testing.T) {
 	posts := make([]*models.Post, numPosts)
 	for i := 0; i < numPosts; i++ {
 		var err error
-		posts[i], err = setup.postService.Create(space1.ID, fmt.Sprintf("Post %d", i), nil)
+		posts[i], err = setup.postService.Create(context.Background(), space1.ID, fmt.Sprintf("Post %d", i), nil)
 		if err != nil {
 			t.Fatalf("Failed to create post %d: %v", i, err)
 		}
@@ -401,7 +402,7 @@ func TestConcurrentSpaceUpdates(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test spaces
-	space, err := setup.spaceService.Create("Original Space", nil, "Original description")
+	space, err := setup.spaceService.Create(context.Background(), "Original Space", nil, "Original description")
 	if err != nil {
 		t.Fatalf("Failed to create test space: %v", err)
 	}
@@ -472,22 +473,22 @@ func TestConcurrentMixedOperations(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create initial data
-	parentSpace, err := setup.spaceService.Create("Parent Space", nil, "Parent space")
+	parentSpace, err := setup.spaceService.Create(context.Background(), "Parent Space", nil, "Parent space")
 	if err != nil {
 		t.Fatalf("Failed to create parent space: %v", err)
 	}
-	childSpace, err := setup.spaceService.Create("Child Space", &parentSpace.ID, "Child space")
+	childSpace, err := setup.spaceService.Create(context.Background(), "Child Space", &parentSpace.ID, "Child space")
 	if err != nil {
 		t.Fatalf("Failed to create child space: %v", err)
 	}
 
 	// Create some initial posts
 	for i := 0; i < 5; i++ {
-		_, err := setup.postService.Create(parentSpace.ID, fmt.Sprintf("Initial post %d", i), nil)
+		_, err := setup.postService.Create(context.Background(), parentSpace.ID, fmt.Sprintf("Initial post %d", i), nil)
 		if err != nil {
 			t.Fatalf("Failed to create initial post %d: %v", i, err)
 		}
-		_, err = setup.postService.Create(childSpace.ID, fmt.Sprintf("Initial child post %d", i), nil)
+		_, err = setup.postService.Create(context.Background(), childSpace.ID, fmt.Sprintf("Initial child post %d", i), nil)
 		if err != nil {
 			t.Fatalf("Failed to create initial child post %d: %v", i, err)
 		}
@@ -627,7 +628,7 @@ func TestConcurrentOperationsWithTimeout(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test data
-	space, err := setup.spaceService.Create("Test Space", nil, "Test space")
+	space, err := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test space")
 	if err != nil {
 		t.Fatalf("Failed to create test space: %v", err)
 	}
@@ -677,15 +678,15 @@ func TestConcurrentOperationsWithTimeout(t *testing.T) {
 			timeout: 15 * time.Second,
 			test: func() error {
 				// Create a small hierarchy
-				cat1, err := setup.spaceService.Create("Cat1", nil, "Cat1")
+				cat1, err := setup.spaceService.Create(context.Background(), "Cat1", nil, "Cat1")
 				if err != nil {
 					return fmt.Errorf("failed to create Cat1: %v", err)
 				}
-				cat2, err := setup.spaceService.Create("Cat2", &cat1.ID, "Cat2")
+				cat2, err := setup.spaceService.Create(context.Background(), "Cat2", &cat1.ID, "Cat2")
 				if err != nil {
 					return fmt.Errorf("failed to create Cat2: %v", err)
 				}
-				cat3, err := setup.spaceService.Create("Cat3", &cat2.ID, "Cat3")
+				cat3, err := setup.spaceService.Create(context.Background(), "Cat3", &cat2.ID, "Cat3")
 				if err != nil {
 					return fmt.Errorf("failed to create Cat3: %v", err)
 				}
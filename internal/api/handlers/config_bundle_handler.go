@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BundleKeyHeader carries the key used to sign and verify config bundles
+const BundleKeyHeader = "X-Backthynk-Bundle-Key"
+
+type ConfigBundleHandler struct{}
+
+func NewConfigBundleHandler() *ConfigBundleHandler {
+	return &ConfigBundleHandler{}
+}
+
+func bundleKey(r *http.Request) (string, bool) {
+	key := r.Header.Get(BundleKeyHeader)
+	return key, len(key) >= config.MinBundleKeyLength
+}
+
+// ExportConfigBundle handles GET /api/admin/config-bundle
+func (h *ConfigBundleHandler) ExportConfigBundle(w http.ResponseWriter, r *http.Request) {
+	key, ok := bundleKey(r)
+	if !ok {
+		http.Error(w, config.ErrBundleKeyRequired, http.StatusBadRequest)
+		return
+	}
+
+	payload := &config.ConfigBundlePayload{
+		Format:  config.ConfigBundleFormat,
+		Version: config.ConfigBundleVersion,
+		Created: time.Now().UnixMilli(),
+		Options: config.GetOptionsConfig().WithoutSecrets(),
+		Service: config.GetServiceConfig().WithoutSecrets(),
+	}
+	if shared := config.GetSharedConfig(); shared != nil {
+		payload.AppVersion = shared.App.Version
+	}
+
+	bundle, err := config.SignConfigBundle(payload, key)
+	if err != nil {
+		http.Error(w, config.ErrFailedToMarshalSettings, http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("backthynk-config-%s.json", time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	json.NewEncoder(w).Encode(bundle)
+}
+
+// ImportConfigBundle handles POST /api/admin/config-bundle.
+// Options are replaced entirely; from the service config only server and logging settings
+// are imported since file locations belong to the local machine. A restart applies everything.
+func (h *ConfigBundleHandler) ImportConfigBundle(w http.ResponseWriter, r *http.Request) {
+	key, ok := bundleKey(r)
+	if !ok {
+		http.Error(w, config.ErrBundleKeyRequired, http.StatusBadRequest)
+		return
+	}
+
+	var bundle config.ConfigBundle
+	if err := json.NewDecoder(r.Body).Decode(&bundle); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	payload, err := config.OpenConfigBundle(&bundle, key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	current := config.GetOptionsConfig()
+	options := payload.Options
+	options.RestoreSecrets(current)
+
+	if err := (&SettingsHandler{}).validateSettings(&options); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	service := *config.GetServiceConfig()
+	service.Server = payload.Service.Server
+	service.Logging = payload.Service.Logging
+
+	if err := writeConfigFile(service.Files.ConfigFilename, &options); err != nil {
+		http.Error(w, fmt.Sprintf(config.ErrFmtFailedToSaveSettings, err), http.StatusInternalServerError)
+		return
+	}
+	if err := writeConfigFile(config.ServiceConfigFilename, &service); err != nil {
+		http.Error(w, fmt.Sprintf(config.ErrFmtFailedToSaveSettings, err), http.StatusInternalServerError)
+		return
+	}
+
+	*current = options
+	*config.GetServiceConfig() = service
+
+	logger.Info("Imported configuration bundle", zap.String("app_version", payload.AppVersion))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"imported":         true,
+		"app_version":      payload.AppVersion,
+		"restart_required": true,
+	})
+}
+
+func writeConfigFile(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, config.FilePermissions); err != nil {
+		logger.Error("Failed to write config file", zap.String("path", path), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
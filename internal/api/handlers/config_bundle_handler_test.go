@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testBundleKey = "migration-key"
+
+func setupConfigBundleTest(t *testing.T) (*config.OptionsConfig, *config.ServiceConfig) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	serviceConfig := &config.ServiceConfig{}
+	serviceConfig.Server.Port = "8080"
+	serviceConfig.Files.ConfigFilename = filepath.Join(dir, "options.json")
+	serviceConfig.Files.DatabaseFilename = "app.db"
+	serviceConfig.Files.StoragePath = "/data/backthynk"
+	config.SetServiceConfigForTest(serviceConfig)
+
+	options := config.NewTestOptionsConfig()
+	options.Metadata.Title = "Source instance"
+	options.Features.StaleSpaces.NudgeWebhookURL = "https://hooks.example.com/secret-token"
+	config.SetOptionsConfigForTest(options)
+
+	return options, serviceConfig
+}
+
+func exportBundle(t *testing.T, handler *ConfigBundleHandler, key string) (*httptest.ResponseRecorder, config.ConfigBundle) {
+	req := httptest.NewRequest("GET", "/api/admin/config-bundle", nil)
+	if key != "" {
+		req.Header.Set(BundleKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	handler.ExportConfigBundle(w, req)
+
+	var bundle config.ConfigBundle
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &bundle); err != nil {
+			t.Fatalf("Failed to unmarshal bundle: %v", err)
+		}
+	}
+	return w, bundle
+}
+
+func TestConfigBundleHandler_Export(t *testing.T) {
+	setupConfigBundleTest(t)
+	handler := NewConfigBundleHandler()
+
+	if w, _ := exportBundle(t, handler, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected missing key to be rejected, got %d", w.Code)
+	}
+	if w, _ := exportBundle(t, handler, "short"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected short key to be rejected, got %d", w.Code)
+	}
+
+	w, bundle := exportBundle(t, handler, testBundleKey)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	payload, err := config.OpenConfigBundle(&bundle, testBundleKey)
+	if err != nil {
+		t.Fatalf("Failed to open exported bundle: %v", err)
+	}
+	if payload.Options.Metadata.Title != "Source instance" || payload.Service.Server.Port != "8080" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+	if payload.Options.Features.StaleSpaces.NudgeWebhookURL != "" {
+		t.Error("Expected webhook URL to be left out of the bundle")
+	}
+	if config.GetOptionsConfig().Features.StaleSpaces.NudgeWebhookURL == "" {
+		t.Error("Expected export not to modify the running configuration")
+	}
+}
+
+func TestConfigBundleHandler_Import(t *testing.T) {
+	setupConfigBundleTest(t)
+	handler := NewConfigBundleHandler()
+
+	// Export from a differently configured source instance
+	source := config.NewTestOptionsConfig()
+	source.Metadata.Title = "Migrated"
+	source.Features.Trash.PostRetentionDays = 90
+	sourceService := config.ServiceConfig{}
+	sourceService.Server.Port = "9090"
+	sourceService.Files.StoragePath = "/other/machine"
+	sourceService.Logging.DisplayLogs = true
+
+	valid, _ := config.SignConfigBundle(&config.ConfigBundlePayload{
+		Format: config.ConfigBundleFormat, Version: config.ConfigBundleVersion,
+		Options: *source, Service: sourceService,
+	}, testBundleKey)
+
+	invalidOptions := *source
+	invalidOptions.Metadata.Title = ""
+	invalid, _ := config.SignConfigBundle(&config.ConfigBundlePayload{
+		Format: config.ConfigBundleFormat, Version: config.ConfigBundleVersion,
+		Options: invalidOptions,
+	}, testBundleKey)
+
+	unsupported, _ := config.SignConfigBundle(&config.ConfigBundlePayload{Format: "other", Version: 1}, testBundleKey)
+
+	tampered := *valid
+	tampered.Payload = bytes.Replace(valid.Payload, []byte("Migrated"), []byte("Tampered"), 1)
+
+	tests := []struct {
+		name           string
+		key            string
+		body           interface{}
+		expectedStatus int
+	}{
+		{"Missing key", "", valid, http.StatusBadRequest},
+		{"Invalid JSON", testBundleKey, "not json", http.StatusBadRequest},
+		{"Wrong key", "another-key", valid, http.StatusBadRequest},
+		{"Tampered payload", testBundleKey, &tampered, http.StatusBadRequest},
+		{"Unsupported format", testBundleKey, unsupported, http.StatusBadRequest},
+		{"Invalid options", testBundleKey, invalid, http.StatusBadRequest},
+		{"Valid bundle", testBundleKey, valid, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			if str, ok := tt.body.(string); ok {
+				body = []byte(str)
+			} else {
+				body, _ = json.Marshal(tt.body)
+			}
+
+			req := httptest.NewRequest("POST", "/api/admin/config-bundle", bytes.NewBuffer(body))
+			if tt.key != "" {
+				req.Header.Set(BundleKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ImportConfigBundle(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	options := config.GetOptionsConfig()
+	if options.Metadata.Title != "Migrated" || options.Features.Trash.PostRetentionDays != 90 {
+		t.Errorf("Expected imported options to be applied, got %+v", options.Metadata)
+	}
+	if options.Features.StaleSpaces.NudgeWebhookURL != "https://hooks.example.com/secret-token" {
+		t.Error("Expected local webhook URL to survive the import")
+	}
+
+	service := config.GetServiceConfig()
+	if service.Server.Port != "9090" || !service.Logging.DisplayLogs {
+		t.Errorf("Expected server and logging settings to be imported, got %+v", service)
+	}
+	if service.Files.StoragePath != "/data/backthynk" {
+		t.Errorf("Expected local storage path to be kept, got %s", service.Files.StoragePath)
+	}
+
+	var saved config.ServiceConfig
+	data, err := os.ReadFile(config.ServiceConfigFilename)
+	if err != nil || json.Unmarshal(data, &saved) != nil || saved.Server.Port != "9090" {
+		t.Errorf("Expected service.json to be written, got %s (%v)", data, err)
+	}
+	if _, err := os.Stat(service.Files.ConfigFilename); err != nil {
+		t.Errorf("Expected options file to be written: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type crosspostsResponse struct {
+	PostID   int   `json:"post_id"`
+	SpaceIDs []int `json:"space_ids"`
+}
+
+// GetCrossposts handles GET /api/posts/{id}/crossposts
+func (h *PostHandler) GetCrossposts(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.fileService.GetPostWithAttachments(r.Context(), postID); err != nil {
+		http.Error(w, config.ErrPostNotFound, http.StatusNotFound)
+		return
+	}
+	if !h.reachPost(w, r, postID, false) {
+		return
+	}
+
+	spaceIDs, err := h.postService.GetCrossposts(postID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(crosspostsResponse{PostID: postID, SpaceIDs: spaceIDs})
+}
+
+// AddCrosspost handles POST /api/posts/{id}/crossposts: the post also appears in the listing
+// of the given space, while staying owned by its own space
+func (h *PostHandler) AddCrosspost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		SpaceID int `json:"space_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+	if req.SpaceID <= 0 {
+		http.Error(w, config.ErrValidSpaceIDRequired, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.fileService.GetPostWithAttachments(r.Context(), postID); err != nil {
+		http.Error(w, config.ErrPostNotFound, http.StatusNotFound)
+		return
+	}
+	if !h.reachPost(w, r, postID, true) || !h.reachSpace(w, r, req.SpaceID, true) {
+		return
+	}
+
+	spaceIDs, err := h.postService.Crosspost(postID, req.SpaceID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case config.ErrSpaceNotFound:
+			status = http.StatusNotFound
+		case config.ErrCrosspostOwnSpace, fmt.Sprintf(config.ErrFmtTooManyCrossposts, config.MaxCrosspostSpaces):
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(crosspostsResponse{PostID: postID, SpaceIDs: spaceIDs})
+}
+
+// RemoveCrosspost handles DELETE /api/posts/{id}/crossposts/{spaceId}
+func (h *PostHandler) RemoveCrosspost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+	spaceID, err := strconv.Atoi(vars["spaceId"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	if !h.reachPost(w, r, postID, true) {
+		return
+	}
+
+	if err := h.postService.RemoveCrosspost(postID, spaceID); err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == config.ErrCrosspostNotFound {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
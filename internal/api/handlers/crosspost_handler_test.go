@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"backthynk/internal/core/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func newCrosspostRouter(setup *postTestSetup) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/posts", setup.postHandler.CreatePost).Methods("POST")
+	router.HandleFunc("/api/posts/{id:[0-9]+}", setup.postHandler.GetPost).Methods("GET")
+	router.HandleFunc("/api/posts/{id:[0-9]+}/move", setup.postHandler.MovePost).Methods("PUT")
+	router.HandleFunc("/api/posts/{id:[0-9]+}/crossposts", setup.postHandler.GetCrossposts).Methods("GET")
+	router.HandleFunc("/api/posts/{id:[0-9]+}/crossposts", setup.postHandler.AddCrosspost).Methods("POST")
+	router.HandleFunc("/api/posts/{id:[0-9]+}/crossposts/{spaceId:[0-9]+}", setup.postHandler.RemoveCrosspost).Methods("DELETE")
+	router.HandleFunc("/api/spaces/{id:[0-9]+}/posts", setup.postHandler.GetPostsBySpace).Methods("GET")
+	return router
+}
+
+type crosspostListing struct {
+	Posts      []models.PostWithAttachments `json:"posts"`
+	TotalCount int                          `json:"total_count"`
+}
+
+func getListing(t *testing.T, router *mux.Router, spaceID int, query string) crosspostListing {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%d/posts?with_meta=true%s", spaceID, query), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d listing space %d, got %d: %s", http.StatusOK, spaceID, w.Code, w.Body.String())
+	}
+	var listing crosspostListing
+	if err := json.Unmarshal(w.Body.Bytes(), &listing); err != nil {
+		t.Fatalf("Failed to decode listing: %v", err)
+	}
+	return listing
+}
+
+func TestPostHandler_CreateCrosspost(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	work, _ := setup.spaceService.Create(context.Background(), "Work", nil, "")
+	side, _ := setup.spaceService.Create(context.Background(), "Side project", nil, "")
+	research, _ := setup.spaceService.Create(context.Background(), "Research", &side.ID, "")
+	setup.postService.Create(context.Background(), side.ID, "Own post", nil)
+	router := newCrosspostRouter(setup)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Cross-post to own space", fmt.Sprintf(`{"space_id": %d, "content": "x", "crosspost_space_ids": [%d]}`, work.ID, work.ID), http.StatusBadRequest},
+		{"Cross-post to unknown space", fmt.Sprintf(`{"space_id": %d, "content": "x", "crosspost_space_ids": [999]}`, work.ID), http.StatusBadRequest},
+		{"Valid cross-post", fmt.Sprintf(`{"space_id": %d, "content": "Shared note", "crosspost_space_ids": [%d]}`, work.ID, research.ID), http.StatusCreated},
+	}
+
+	var shared models.Post
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/api/posts", bytes.NewBufferString(tt.body)))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code == http.StatusCreated {
+				json.Unmarshal(w.Body.Bytes(), &shared)
+			}
+		})
+	}
+
+	if len(shared.CrosspostedTo) != 1 || shared.CrosspostedTo[0] != research.ID {
+		t.Fatalf("Expected the created post to list its cross-post, got %v", shared.CrosspostedTo)
+	}
+	total, _ := setup.fileService.GetTotalPostCount()
+	if total != 2 {
+		t.Errorf("Expected rejected cross-posts to create nothing, got %d posts", total)
+	}
+
+	// The owner lists the post without a marker
+	owner := getListing(t, router, work.ID, "")
+	if owner.TotalCount != 1 || len(owner.Posts) != 1 || owner.Posts[0].CrosspostedFrom != nil {
+		t.Errorf("Unexpected owner listing %+v", owner)
+	}
+
+	// The referencing space and its ancestors list it with the owner space
+	for _, tt := range []struct {
+		spaceID   int
+		query     string
+		wantTotal int
+	}{
+		{research.ID, "", 1},
+		{side.ID, "&recursive=true", 2},
+	} {
+		listing := getListing(t, router, tt.spaceID, tt.query)
+		if listing.TotalCount != tt.wantTotal || len(listing.Posts) != tt.wantTotal {
+			t.Errorf("Space %d: expected %d posts, got %d (total %d)", tt.spaceID, tt.wantTotal, len(listing.Posts), listing.TotalCount)
+			continue
+		}
+		for _, post := range listing.Posts {
+			if post.ID == shared.ID && (post.CrosspostedFrom == nil || *post.CrosspostedFrom != work.ID) {
+				t.Errorf("Space %d: expected crossposted_from %d, got %v", tt.spaceID, work.ID, post.CrosspostedFrom)
+			}
+		}
+	}
+
+	// Statistics count the post once, in its owner space
+	if cached, _ := setup.postService.GetSpaceFromCache(research.ID); cached.PostCount != 0 || cached.RecursivePostCount != 0 {
+		t.Errorf("Expected the cross-post to leave counts unchanged, got %+v", cached)
+	}
+	if total, _ := setup.fileService.GetTotalPostCount(); total != 2 {
+		t.Errorf("Expected the shared post counted once globally, got %d posts", total)
+	}
+	all := getListing(t, router, 0, "")
+	if len(all.Posts) != 2 {
+		t.Errorf("Expected every post once when listing all spaces, got %d", len(all.Posts))
+	}
+}
+
+func TestPostHandler_ManageCrossposts(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	work, _ := setup.spaceService.Create(context.Background(), "Work", nil, "")
+	side, _ := setup.spaceService.Create(context.Background(), "Side project", nil, "")
+	post, _ := setup.postService.Create(context.Background(), work.ID, "Shared note", nil)
+	postPath := "/api/posts/" + strconv.Itoa(post.ID)
+	router := newCrosspostRouter(setup)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"Add to own space", "POST", postPath + "/crossposts", fmt.Sprintf(`{"space_id": %d}`, work.ID), http.StatusBadRequest},
+		{"Add to unknown space", "POST", postPath + "/crossposts", `{"space_id": 999}`, http.StatusNotFound},
+		{"Add to unknown post", "POST", "/api/posts/999/crossposts", fmt.Sprintf(`{"space_id": %d}`, side.ID), http.StatusNotFound},
+		{"Add without space", "POST", postPath + "/crossposts", `{}`, http.StatusBadRequest},
+		{"Add", "POST", postPath + "/crossposts", fmt.Sprintf(`{"space_id": %d}`, side.ID), http.StatusOK},
+		{"Add again", "POST", postPath + "/crossposts", fmt.Sprintf(`{"space_id": %d}`, side.ID), http.StatusOK},
+		{"List", "GET", postPath + "/crossposts", "", http.StatusOK},
+		{"Remove", "DELETE", fmt.Sprintf("%s/crossposts/%d", postPath, side.ID), "", http.StatusNoContent},
+		{"Remove again", "DELETE", fmt.Sprintf("%s/crossposts/%d", postPath, side.ID), "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body)))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.name == "List" {
+				var response crosspostsResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+				if len(response.SpaceIDs) != 1 || response.SpaceIDs[0] != side.ID {
+					t.Errorf("Expected cross-post to space %d, got %v", side.ID, response.SpaceIDs)
+				}
+			}
+		})
+	}
+
+	// Moving a post into a space it is cross-posted to makes that space its owner
+	if _, err := setup.postService.Crosspost(post.ID, side.ID); err != nil {
+		t.Fatalf("Failed to cross-post: %v", err)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PUT", postPath+"/move", bytes.NewBufferString(fmt.Sprintf(`{"space_id": %d}`, side.ID))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected move to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	if spaceIDs, _ := setup.postService.GetCrossposts(post.ID); len(spaceIDs) != 0 {
+		t.Errorf("Expected the new owner to drop its cross-post, got %v", spaceIDs)
+	}
+	if listing := getListing(t, router, side.ID, ""); len(listing.Posts) != 1 || listing.Posts[0].CrosspostedFrom != nil {
+		t.Errorf("Expected the moved post listed once as owned, got %+v", listing.Posts)
+	}
+}
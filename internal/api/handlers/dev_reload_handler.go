@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// DevReloadHandler tells pages served in --dev mode to reload when a web asset changes
+type DevReloadHandler struct {
+	root     string
+	interval time.Duration
+}
+
+func NewDevReloadHandler(root string) *DevReloadHandler {
+	return &DevReloadHandler{root: root, interval: config.DevReloadPollInterval}
+}
+
+// StreamReloads handles GET /api/dev/reload as a server-sent event stream, sending a reload
+// event each time a file under the web directory is modified, added or removed
+func (h *DevReloadHandler) StreamReloads(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, config.ErrStreamingUnsupported, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	last := h.snapshot()
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			current := h.snapshot()
+			if current == last {
+				continue
+			}
+			last = current
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// snapshot summarizes the web directory as its latest modification time and file count, so
+// deletions are noticed too
+func (h *DevReloadHandler) snapshot() [2]int64 {
+	var latest, files int64
+	filepath.WalkDir(h.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files++
+			if modified := info.ModTime().UnixNano(); modified > latest {
+				latest = modified
+			}
+		}
+		return nil
+	})
+	return [2]int64{latest, files}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDevReloadHandler_StreamsReloadOnChange(t *testing.T) {
+	root := t.TempDir()
+	asset := filepath.Join(root, "main.js")
+	os.WriteFile(asset, []byte("// v1"), 0644)
+
+	handler := NewDevReloadHandler(root)
+	handler.interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/api/dev/reload", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		handler.StreamReloads(w, req)
+		close(done)
+	}()
+
+	// Let the stream take its first snapshot, then add a file
+	time.Sleep(50 * time.Millisecond)
+	os.WriteFile(filepath.Join(root, "extra.css"), []byte("body {}"), 0644)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-done
+
+	if !strings.Contains(w.Body.String(), "event: reload") {
+		t.Errorf("Expected a reload event after the change, got %q", w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %q", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"backthynk/internal/core/events"
 	"backthynk/internal/core/services"
 	"backthynk/internal/storage"
+	"context"
 	"fmt"
 	"os"
 	"sync"
@@ -219,17 +220,17 @@ func TestEventDispatcher_ProductionLikeScenario(t *testing.T) {
 	})
 
 	// Perform actual operations that should trigger events
-	space, err := setup.spaceService.Create("Test Space", nil, "Test description")
+	space, err := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test description")
 	if err != nil {
 		t.Fatalf("Failed to create space: %v", err)
 	}
 
-	_, err = setup.spaceService.Update(space.ID, "Updated Space", "Updated description", nil)
+	_, err = setup.spaceService.Update(context.Background(), space.ID, "Updated Space", "Updated description", nil)
 	if err != nil {
 		t.Fatalf("Failed to update space: %v", err)
 	}
 
-	_, err = setup.postService.Create(space.ID, "Test post content", nil)
+	_, err = setup.postService.Create(context.Background(), space.ID, "Test post content", nil)
 	if err != nil {
 		t.Fatalf("Failed to create post: %v", err)
 	}
@@ -287,35 +288,35 @@ func TestEventDispatcher_ConcurrentOperationsWithSubscriptions(t *testing.T) {
 			defer wg.Done()
 
 			// Create a space
-			space, err := setup.spaceService.Create(fmt.Sprintf("Space %d", i), nil, fmt.Sprintf("Description %d", i))
+			space, err := setup.spaceService.Create(context.Background(), fmt.Sprintf("Space %d", i), nil, fmt.Sprintf("Description %d", i))
 			if err != nil {
 				t.Errorf("Failed to create space %d: %v", i, err)
 				return
 			}
 
 			// Create a post
-			post, err := setup.postService.Create(space.ID, fmt.Sprintf("Post content %d", i), nil)
+			post, err := setup.postService.Create(context.Background(), space.ID, fmt.Sprintf("Post content %d", i), nil)
 			if err != nil {
 				t.Errorf("Failed to create post %d: %v", i, err)
 				return
 			}
 
 			// Update the space
-			_, err = setup.spaceService.Update(space.ID, fmt.Sprintf("Updated Space %d", i), fmt.Sprintf("Updated Description %d", i), nil)
+			_, err = setup.spaceService.Update(context.Background(), space.ID, fmt.Sprintf("Updated Space %d", i), fmt.Sprintf("Updated Description %d", i), nil)
 			if err != nil {
 				t.Errorf("Failed to update space %d: %v", i, err)
 				return
 			}
 
 			// Delete the post
-			err = setup.postService.Delete(post.ID)
+			err = setup.postService.Delete(context.Background(), post.ID)
 			if err != nil {
 				t.Errorf("Failed to delete post %d: %v", i, err)
 				return
 			}
 
 			// Delete the space
-			err = setup.spaceService.Delete(space.ID)
+			err = setup.spaceService.Delete(context.Background(), space.ID)
 			if err != nil {
 				t.Errorf("Failed to delete space %d: %v", i, err)
 				return
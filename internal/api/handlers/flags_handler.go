@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/flags"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// FlagsHandler exposes the feature flags to administrators. It registers its own routes
+// like feature handlers do, as the registry is created with the other subsystems.
+type FlagsHandler struct {
+	registry *flags.Registry
+}
+
+func NewFlagsHandler(registry *flags.Registry) *FlagsHandler {
+	return &FlagsHandler{registry: registry}
+}
+
+type flagOverrideRequest struct {
+	Enabled bool `json:"enabled"`
+	Rollout int  `json:"rollout"`
+}
+
+func (h *FlagsHandler) RegisterRoutes(router *mux.Router) {
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/admin/flags", h.GetFlags).Methods("GET")
+	api.HandleFunc("/admin/flags/{name}", h.SetOverride).Methods("PUT")
+	api.HandleFunc("/admin/flags/{name}", h.ClearOverride).Methods("DELETE")
+}
+
+// GetFlags handles GET /api/admin/flags
+func (h *FlagsHandler) GetFlags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.registry.List())
+}
+
+// SetOverride handles PUT /api/admin/flags/{name}; the override is kept across restarts
+func (h *FlagsHandler) SetOverride(w http.ResponseWriter, r *http.Request) {
+	var req flagOverrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	flag, err := h.registry.SetOverride(mux.Vars(r)["name"], req.Enabled, req.Rollout)
+	if err != nil {
+		writeFlagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(flag)
+}
+
+// ClearOverride handles DELETE /api/admin/flags/{name}, returning the flag to its configured setting
+func (h *FlagsHandler) ClearOverride(w http.ResponseWriter, r *http.Request) {
+	flag, err := h.registry.ClearOverride(mux.Vars(r)["name"])
+	if err != nil {
+		writeFlagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(flag)
+}
+
+func writeFlagError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case config.ErrFeatureFlagNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case config.ErrInvalidFlagRollout:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/flags"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestFlagsHandler(t *testing.T) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_flags_handler_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	router := mux.NewRouter()
+	NewFlagsHandler(flags.NewRegistry(db, flags.Known, nil)).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedSource string
+	}{
+		{"List", "GET", "/api/admin/flags", "", http.StatusOK, ""},
+		{"Override", "PUT", "/api/admin/flags/" + config.FlagSemanticSearch, `{"enabled":true,"rollout":10}`, http.StatusOK, models.FlagSourceOverride},
+		{"Invalid rollout", "PUT", "/api/admin/flags/" + config.FlagSemanticSearch, `{"enabled":true,"rollout":200}`, http.StatusBadRequest, ""},
+		{"Invalid JSON", "PUT", "/api/admin/flags/" + config.FlagSemanticSearch, `{`, http.StatusBadRequest, ""},
+		{"Unknown flag", "PUT", "/api/admin/flags/teleportation", `{"enabled":true}`, http.StatusNotFound, ""},
+		{"Clear", "DELETE", "/api/admin/flags/" + config.FlagSemanticSearch, "", http.StatusOK, models.FlagSourceDefault},
+		{"Clear unknown", "DELETE", "/api/admin/flags/teleportation", "", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedSource == "" {
+				return
+			}
+
+			var flag models.FeatureFlag
+			if err := json.Unmarshal(w.Body.Bytes(), &flag); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if flag.Source != tt.expectedSource {
+				t.Errorf("Expected source %s, got %s", tt.expectedSource, flag.Source)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"backthynk/internal/core/jobs"
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// JobsHandler shows the background jobs budget to administrators and feeds it the latency of
+// the requests it serves, so jobs pause while the server is slow to answer
+type JobsHandler struct {
+	budget *jobs.Budget
+}
+
+func NewJobsHandler(budget *jobs.Budget) *JobsHandler {
+	return &JobsHandler{budget: budget}
+}
+
+func (h *JobsHandler) RegisterRoutes(router *mux.Router) {
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/admin/jobs", h.GetJobs).Methods("GET")
+}
+
+// GetJobs handles GET /api/admin/jobs
+func (h *JobsHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.budget.Status())
+}
+
+// Middleware measures the time each request takes to its first byte, which leaves out how
+// long streams and server-sent events then stay open
+func (h *JobsHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timed := &firstByteWriter{ResponseWriter: w, start: time.Now(), budget: h.budget}
+		next.ServeHTTP(timed, r)
+		timed.observe()
+	})
+}
+
+type firstByteWriter struct {
+	http.ResponseWriter
+	start    time.Time
+	budget   *jobs.Budget
+	observed bool
+}
+
+func (fw *firstByteWriter) observe() {
+	if !fw.observed {
+		fw.observed = true
+		fw.budget.ObserveRequest(time.Since(fw.start))
+	}
+}
+
+func (fw *firstByteWriter) WriteHeader(status int) {
+	fw.observe()
+	fw.ResponseWriter.WriteHeader(status)
+}
+
+func (fw *firstByteWriter) Write(b []byte) (int, error) {
+	fw.observe()
+	return fw.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers (server-sent events) push data through the wrapper
+func (fw *firstByteWriter) Flush() {
+	fw.observe()
+	if flusher, ok := fw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSocket handlers take over the connection through the wrapper
+func (fw *firstByteWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	fw.observe()
+	hijacker, ok := fw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return hijacker.Hijack()
+}
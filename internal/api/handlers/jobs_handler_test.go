@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"backthynk/internal/core/jobs"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestJobsHandler(t *testing.T) {
+	budget := jobs.NewBudget(2, 10, 50*time.Millisecond)
+	handler := NewJobsHandler(budget)
+
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+	router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(80 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := handler.Middleware(router)
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest("GET", "/api/admin/jobs", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	var status jobs.Status
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if status.MaxConcurrent != 2 || status.MaxIOMBps != 10 || status.PauseLatencyMs != 50 {
+		t.Errorf("Expected configured limits, got %+v", status)
+	}
+	if !status.Paused || status.RequestLatencyMs < 80 {
+		t.Errorf("Expected slow request to pause jobs, got paused=%v latency=%d", status.Paused, status.RequestLatencyMs)
+	}
+}
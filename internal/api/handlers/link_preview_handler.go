@@ -78,7 +78,7 @@ func (h *LinkPreviewHandler) GetLinkPreviewsByPost(w http.ResponseWriter, r *htt
 		return
 	}
 
-	post, err := h.fileService.GetPostWithAttachments(postID)
+	post, err := h.fileService.GetPostWithAttachments(r.Context(), postID)
 	if err != nil {
 		http.Error(w, config.ErrPostNotFound, http.StatusNotFound)
 		return
@@ -90,7 +90,7 @@ func (h *LinkPreviewHandler) GetLinkPreviewsByPost(w http.ResponseWriter, r *htt
 
 func extractMetadata(urlStr string) (*LinkPreviewResponse, error) {
 	client := &http.Client{
-		Timeout: config.LinkPreviewHTTPTimeout,
+		Timeout: config.HTTPTimeout(config.LinkPreviewHTTPTimeout),
 	}
 
 	req, err := http.NewRequest("GET", urlStr, nil)
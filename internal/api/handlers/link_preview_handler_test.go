@@ -7,6 +7,7 @@ import (
 	"backthynk/internal/core/services"
 	"backthynk/internal/storage"
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -113,7 +114,7 @@ func TestPostHandler_CreatePostWithLinkPreviews(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test space
-	space, err := setup.spaceService.Create("Test Space", nil, "Test desc")
+	space, err := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test desc")
 	if err != nil {
 		t.Fatalf("Failed to create test space: %v", err)
 	}
@@ -241,7 +242,7 @@ func TestPostHandler_CreatePostWithLinkPreviews(t *testing.T) {
 				// Validate link preview data if expected
 				if tt.validateData {
 					// Retrieve the post with attachments to check link previews
-					postWithAttachments, err := setup.fileService.GetPostWithAttachments(createdPost.ID)
+					postWithAttachments, err := setup.fileService.GetPostWithAttachments(context.Background(), createdPost.ID)
 					if err != nil {
 						t.Fatalf("Failed to retrieve post with attachments: %v", err)
 					}
@@ -302,7 +303,7 @@ func TestLinkPreviewHandler_GetLinkPreviewsByPost(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test space and post
-	space, err := setup.spaceService.Create("Test Space", nil, "Test desc")
+	space, err := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test desc")
 	if err != nil {
 		t.Fatalf("Failed to create test space: %v", err)
 	}
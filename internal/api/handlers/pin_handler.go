@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// PinPost handles PUT /api/posts/{id}/pin: the post is listed before the others of its space
+func (h *PostHandler) PinPost(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, true)
+}
+
+// UnpinPost handles DELETE /api/posts/{id}/pin
+func (h *PostHandler) UnpinPost(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, false)
+}
+
+func (h *PostHandler) setPinned(w http.ResponseWriter, r *http.Request, pinned bool) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+	if !h.reachPost(w, r, postID, true) {
+		return
+	}
+
+	post, err := h.postService.SetPinned(r.Context(), postID, pinned)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case config.ErrPostNotFound:
+			status = http.StatusNotFound
+		case config.ErrPinningDisabled:
+			status = http.StatusForbidden
+		case fmt.Sprintf(config.ErrFmtTooManyPinnedPosts, h.maxPinnedPosts()):
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(post)
+}
+
+func (h *PostHandler) maxPinnedPosts() int {
+	if h.options == nil || h.options.Features.Pinning.MaxPerSpace <= 0 {
+		return config.DefaultMaxPinnedPosts
+	}
+	return h.options.Features.Pinning.MaxPerSpace
+}
@@ -2,16 +2,20 @@ package handlers
 
 import (
 	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
 	"backthynk/internal/core/models"
 	"backthynk/internal/core/services"
 	"backthynk/internal/core/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"go.uber.org/zap"
 )
 
 type PostHandler struct {
@@ -42,6 +46,10 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		Content         string              `json:"content"`
 		LinkPreviews    []PostLinkPreview   `json:"link_previews,omitempty"`
 		CustomTimestamp *int64              `json:"custom_timestamp,omitempty"`
+		Source          string              `json:"source,omitempty"` // Ingestion path, hand-written when empty
+		Type            string              `json:"type,omitempty"`   // note when empty
+		Fields          map[string]interface{} `json:"fields,omitempty"` // Custom field values, see the space field schema
+		CrosspostSpaceIDs []int               `json:"crosspost_space_ids,omitempty"` // Other spaces listing the post
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -58,10 +66,34 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, config.ErrValidSpaceIDRequired, http.StatusBadRequest)
 		return
 	}
+	for _, spaceID := range append([]int{req.SpaceID}, req.CrosspostSpaceIDs...) {
+		if !h.reachSpace(w, r, spaceID, true) {
+			return
+		}
+	}
+
+	if req.Source == "" {
+		req.Source = models.PostSourceManual
+	}
+	if !services.IsValidPostSource(req.Source) {
+		http.Error(w, config.ErrInvalidPostSource, http.StatusBadRequest)
+		return
+	}
+
+	if req.Type != "" && req.Type != models.PostTypeNote && req.Type != models.PostTypeJournal {
+		http.Error(w, config.ErrInvalidPostType, http.StatusBadRequest)
+		return
+	}
+
+	if req.Type == models.PostTypeJournal && len(req.Fields) > 0 {
+		http.Error(w, config.ErrFieldsNotAllowedOnJournal, http.StatusBadRequest)
+		return
+	}
 	
-	// Validate content length
-	if len(req.Content) > h.options.Core.MaxContentLength {
-		http.Error(w, fmt.Sprintf(config.ErrFmtContentExceedsMaxLength, h.options.Core.MaxContentLength), http.StatusBadRequest)
+	// Validate content length against the space override, if any
+	maxLength, _ := h.postService.MaxContentLength(req.SpaceID, h.options.Core.MaxContentLength)
+	if len(req.Content) > maxLength {
+		http.Error(w, fmt.Sprintf(config.ErrFmtContentExceedsMaxLength, maxLength), http.StatusBadRequest)
 		return
 	}
 	
@@ -78,7 +110,20 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	
-	post, err := h.postService.Create(req.SpaceID, req.Content, req.CustomTimestamp)
+	if err := h.postService.ValidateCrossposts(req.SpaceID, req.CrosspostSpaceIDs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Journal content joins the day's entry when there is one already
+	var post *models.Post
+	var err error
+	created := true
+	if req.Type == models.PostTypeJournal {
+		post, created, err = h.postService.CreateJournal(r.Context(), req.SpaceID, req.Content, req.CustomTimestamp, req.Source)
+	} else {
+		post, err = h.postService.CreateWithFields(r.Context(), req.SpaceID, req.Content, req.CustomTimestamp, req.Source, req.Fields)
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -88,9 +133,23 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	for _, preview := range req.LinkPreviews {
 		h.fileService.SaveLinkPreview(post.ID, preview)
 	}
+	post.DuplicateLinks = h.postService.VisibleReferences(r.Context(), post.DuplicateLinks)
+
+	if len(req.CrosspostSpaceIDs) > 0 {
+		post.CrosspostedTo, err = h.postService.Crosspost(post.ID, req.CrosspostSpaceIDs...)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(post)
 }
 
@@ -101,20 +160,53 @@ func (h *PostHandler) GetPost(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
 		return
 	}
+	if !h.reachPost(w, r, id, false) {
+		return
+	}
+
+	compact, err := h.compactProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	post, err := h.fileService.GetPostWithAttachments(id)
+	post, err := h.fileService.GetPostWithAttachments(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	// Process content on-the-fly for the response
-	if h.options != nil && h.options.Features.Markdown.Enabled {
-		post.Content = utils.ProcessMarkdown(post.Content)
+	// Process content on-the-fly for the response, compact responses keep the source
+	if h.options != nil && h.options.Features.Markdown.Enabled && !compact {
+		post.Content = h.postService.RenderContent(post.SpaceID, post.Content)
+	}
+
+	fields, err := h.postService.GetPostFields(post.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	post.Fields = fields
+
+	author, err := h.postService.GetPostAuthor(post.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	post.Author = author
+
+	crossposts, err := h.postService.GetCrossposts(post.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+	post.CrosspostedTo = crossposts
 
 	// Filter attachments by allowed extensions
 	h.filterAttachments(post)
+	if compact {
+		post.Compact(0)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(post)
@@ -127,8 +219,11 @@ func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
 		return
 	}
+	if !h.reachPost(w, r, id, true) {
+		return
+	}
 	
-	if err := h.postService.Delete(id); err != nil {
+	if err := h.postService.Delete(r.Context(), id); err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
@@ -157,23 +252,194 @@ func (h *PostHandler) MovePost(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, config.ErrValidSpaceIDRequired, http.StatusBadRequest)
 		return
 	}
+	if !h.reachPost(w, r, postID, true) || !h.reachSpace(w, r, req.SpaceID, true) {
+		return
+	}
 
-	if err := h.postService.Move(postID, req.SpaceID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err := h.postService.Move(r.Context(), postID, req.SpaceID); err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == config.ErrJournalDayTaken {
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// Return updated post
+	post, err := h.fileService.GetPostWithAttachments(r.Context(), postID)
+	if err != nil {
+		http.Error(w, config.ErrFailedToRetrievePost, http.StatusInternalServerError)
+		return
+	}
+
+	// Process content on-the-fly for the response
+	if h.options != nil && h.options.Features.Markdown.Enabled {
+		post.Content = h.postService.RenderContent(post.SpaceID, post.Content)
+	}
+
+	// Filter attachments by allowed extensions
+	h.filterAttachments(post)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(post)
+}
+
+// UpdatePost handles PUT /api/posts/{id}. link_previews replace the previews of the post
+// when given, an empty list removes them.
+func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Content      string             `json:"content"`
+		LinkPreviews *[]PostLinkPreview `json:"link_previews,omitempty"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	if req.Content == "" {
+		http.Error(w, config.ErrContentRequired, http.StatusBadRequest)
+		return
+	}
+	if !h.reachPost(w, r, postID, true) {
+		return
+	}
+
+	var previews []models.LinkPreview
+	if req.LinkPreviews != nil {
+		previews = []models.LinkPreview{}
+		for _, p := range *req.LinkPreviews {
+			previews = append(previews, models.LinkPreview{
+				PostID:      postID,
+				URL:         p.URL,
+				Title:       p.Title,
+				Description: p.Description,
+				ImageURL:    p.ImageURL,
+				SiteName:    p.SiteName,
+			})
+		}
+	}
+
+	updated, err := h.postService.Update(r.Context(), postID, req.Content, previews)
+	if err != nil {
+		if err.Error() == config.ErrPostNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Return updated post
-	post, err := h.fileService.GetPostWithAttachments(postID)
+	post, err := h.fileService.GetPostWithAttachments(r.Context(), postID)
 	if err != nil {
 		http.Error(w, config.ErrFailedToRetrievePost, http.StatusInternalServerError)
 		return
 	}
+	post.Warnings = updated.Warnings
 
 	// Process content on-the-fly for the response
 	if h.options != nil && h.options.Features.Markdown.Enabled {
-		post.Content = utils.ProcessMarkdown(post.Content)
+		post.Content = h.postService.RenderContent(post.SpaceID, post.Content)
+	}
+
+	// Filter attachments by allowed extensions
+	h.filterAttachments(post)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(post)
+}
+
+// GetPostRevisions handles GET /api/posts/{id}/revisions, the content a post had before each
+// of its edits, most recent first
+func (h *PostHandler) GetPostRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+	if !h.reachPost(w, r, postID, false) {
+		return
+	}
+
+	revisions, err := h.postService.GetRevisions(postID)
+	if err != nil {
+		switch err.Error() {
+		case config.ErrPostNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case config.ErrEditHistoryDisabled:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(revisions)
+}
+
+func (h *PostHandler) MergePosts(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PostIDs []int `json:"post_ids"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	if len(req.PostIDs) < 2 {
+		http.Error(w, config.ErrMergeRequiresTwoPosts, http.StatusBadRequest)
+		return
+	}
+	for _, postID := range req.PostIDs {
+		if !h.reachPost(w, r, postID, true) {
+			return
+		}
+	}
+
+	// Report what would be merged without changing anything
+	if r.URL.Query().Get("dry_run") == "true" {
+		summary, err := h.postService.PreviewMerge(req.PostIDs)
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, config.ErrUnknownPost) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+		return
+	}
+
+	merged, err := h.postService.Merge(r.Context(), req.PostIDs)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, config.ErrUnknownPost) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// Return merged post with its attachments
+	post, err := h.fileService.GetPostWithAttachments(r.Context(), merged.ID)
+	if err != nil {
+		http.Error(w, config.ErrFailedToRetrievePost, http.StatusInternalServerError)
+		return
 	}
+	post.Content = merged.Content
 
 	// Filter attachments by allowed extensions
 	h.filterAttachments(post)
@@ -182,6 +448,168 @@ func (h *PostHandler) MovePost(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(post)
 }
 
+// BulkRetime shifts the timestamps of a selection of posts by delta_ms, or spreads them over
+// range_start..range_end, e.g. to fix posts imported with the wrong timezone. The selection
+// is made by space_id (with recursive) and/or post_ids, narrowed by the current creation
+// date (start_date, end_date as YYYY-MM-DD), source and tags. With dry_run=true it only
+// reports the posts that would move.
+func (h *PostHandler) BulkRetime(w http.ResponseWriter, r *http.Request) {
+	if h.options == nil || !h.options.Features.RetroactivePosting.Enabled {
+		http.Error(w, config.ErrRetroactivePostingDisabled, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		SpaceID    *int     `json:"space_id,omitempty"`
+		Recursive  bool     `json:"recursive"`
+		PostIDs    []int    `json:"post_ids,omitempty"`
+		StartDate  string   `json:"start_date,omitempty"`
+		EndDate    string   `json:"end_date,omitempty"`
+		Source     string   `json:"source,omitempty"`
+		Tags       []string `json:"tags,omitempty"`
+		DeltaMs    int64    `json:"delta_ms,omitempty"`
+		RangeStart int64    `json:"range_start,omitempty"`
+		RangeEnd   int64    `json:"range_end,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	if req.Source != "" && !services.IsValidPostSource(req.Source) {
+		http.Error(w, config.ErrInvalidPostSource, http.StatusBadRequest)
+		return
+	}
+	if req.SpaceID != nil && !h.reachSpace(w, r, *req.SpaceID, true) {
+		return
+	}
+	for _, postID := range req.PostIDs {
+		if !h.reachPost(w, r, postID, true) {
+			return
+		}
+	}
+
+	after, before, err := services.DateRangeBounds(req.StartDate, req.EndDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := models.PostFilter{Source: req.Source, After: after, Before: before}
+	for _, tag := range req.Tags {
+		normalized, ok := utils.NormalizeTag(tag)
+		if !ok {
+			http.Error(w, config.ErrInvalidTag, http.StatusBadRequest)
+			return
+		}
+		filter.Tags = append(filter.Tags, normalized)
+	}
+
+	opts := services.RetimeOptions{
+		SpaceID:    req.SpaceID,
+		Recursive:  req.Recursive,
+		PostIDs:    req.PostIDs,
+		Filter:     filter,
+		DeltaMs:    req.DeltaMs,
+		RangeStart: req.RangeStart,
+		RangeEnd:   req.RangeEnd,
+	}
+
+	// Report the posts that would move without changing anything
+	var result interface{}
+	if r.URL.Query().Get("dry_run") == "true" {
+		result, err = h.postService.PreviewRetime(r.Context(), opts)
+	} else {
+		result, err = h.postService.Retime(r.Context(), opts)
+	}
+	if err != nil {
+		switch err.Error() {
+		case config.ErrSpaceNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case config.ErrRetimeSelectionRequired, config.ErrRetimeModeRequired, config.ErrRetimeInvalidRange,
+			config.ErrRetimeInFuture, config.ErrTimestampTooEarly:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (h *PostHandler) SplitPost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Delimiter   string         `json:"delimiter"`
+		LineOffsets []int          `json:"line_offsets"`
+		Attachments map[string]int `json:"attachments"` // attachment ID -> part index
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	if !h.reachPost(w, r, postID, true) {
+		return
+	}
+
+	mapping := make(map[int]int, len(req.Attachments))
+	for key, partIndex := range req.Attachments {
+		attachmentID, err := strconv.Atoi(key)
+		if err != nil {
+			http.Error(w, config.ErrSplitInvalidAttachment, http.StatusBadRequest)
+			return
+		}
+		mapping[attachmentID] = partIndex
+	}
+
+	posts, err := h.postService.Split(r.Context(), postID, services.SplitOptions{
+		Delimiter:   req.Delimiter,
+		LineOffsets: req.LineOffsets,
+		Attachments: mapping,
+	})
+	if err != nil {
+		status := http.StatusBadRequest
+		if err.Error() == "post not found" {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// Return the resulting posts in order
+	result := make([]*models.PostWithAttachments, 0, len(posts))
+	for _, p := range posts {
+		post, err := h.fileService.GetPostWithAttachments(r.Context(), p.ID)
+		if err != nil {
+			http.Error(w, config.ErrFailedToRetrievePost, http.StatusInternalServerError)
+			return
+		}
+
+		// Process content on-the-fly for the response
+		if h.options != nil && h.options.Features.Markdown.Enabled {
+			post.Content = h.postService.RenderContent(post.SpaceID, post.Content)
+		}
+
+		// Filter attachments by allowed extensions
+		h.filterAttachments(post)
+		result = append(result, post)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
 func (h *PostHandler) GetPostsBySpace(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	spaceID, err := strconv.Atoi(vars["id"])
@@ -195,6 +623,24 @@ func (h *PostHandler) GetPostsBySpace(w http.ResponseWriter, r *http.Request) {
 	offsetStr := r.URL.Query().Get("offset")
 	withMeta := r.URL.Query().Get("with_meta") == "true"
 	recursive := r.URL.Query().Get("recursive") == "true"
+	source := r.URL.Query().Get("source")
+
+	if source != "" && !services.IsValidPostSource(source) {
+		http.Error(w, config.ErrInvalidPostSource, http.StatusBadRequest)
+		return
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if sort != "" && !models.IsPostSort(sort) {
+		http.Error(w, config.ErrInvalidPostSort, http.StatusBadRequest)
+		return
+	}
+
+	compact, err := h.compactProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	limit := config.DefaultPostLimit
 	if limitStr != "" {
@@ -210,18 +656,84 @@ func (h *PostHandler) GetPostsBySpace(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	var posts []models.PostWithAttachments
-	var totalCount int
-
-	if spaceID == 0 { // All spaces
-		posts, err = h.postService.GetAllPosts(limit, offset)
-		if withMeta {
-			totalCount, _ = h.fileService.GetTotalPostCount()
+	// A cursor replaces the offset: its pages stay put while posts are added before them
+	var cursor *models.PostCursor
+	if cursorStr := r.URL.Query().Get("cursor"); cursorStr != "" {
+		if cursor, err = models.ParsePostCursor(cursorStr); err != nil {
+			http.Error(w, config.ErrInvalidPostCursor, http.StatusBadRequest)
+			return
 		}
-	} else {
-		posts, err = h.postService.GetBySpace(spaceID, recursive, limit, offset)
-		if withMeta {
-			// Get count from cache
+		offset = 0
+	}
+
+	filter := models.PostFilter{Source: source}
+
+	// A saved filter brings its own space scope, the path space is only used without one
+	if filterIDStr := r.URL.Query().Get("filter_id"); filterIDStr != "" {
+		filterID, err := strconv.Atoi(filterIDStr)
+		if err != nil {
+			http.Error(w, config.ErrInvalidSavedFilterID, http.StatusBadRequest)
+			return
+		}
+
+		saved, savedFilter, err := h.postService.ResolveSavedFilter(filterID)
+		if err != nil {
+			status := http.StatusBadRequest
+			if err.Error() == config.ErrSavedFilterNotFound {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		spaceID, recursive = saved.SpaceID, saved.Recursive
+		savedFilter.Source = source
+		filter = savedFilter
+	}
+
+	if spaceID != 0 && !h.reachSpace(w, r, spaceID, false) {
+		return
+	}
+
+	// Without a requested order the space lists in its own default order
+	filter.Sort = sort
+	if sort == "" && spaceID != 0 {
+		filter.Sort = h.postService.DefaultSort(spaceID)
+	}
+	if cursor != nil && !models.IsCursorSort(filter.Sort) {
+		http.Error(w, config.ErrPostCursorSort, http.StatusBadRequest)
+		return
+	}
+	filter.Cursor = cursor
+
+	// Cursor pages read one post more to tell whether another page follows
+	fetch := limit
+	if cursor != nil {
+		fetch++
+	}
+
+	var posts []models.PostWithAttachments
+	var totalCount int
+
+	if !filter.IsEmpty() || h.postService.HidesSpaces(r.Context()) {
+		// Cached counts cover every post, hidden spaces included; count the listed posts instead
+		if spaceID == 0 {
+			posts, err = h.postService.GetAllPosts(r.Context(), fetch, offset, filter)
+		} else {
+			posts, err = h.postService.GetBySpace(r.Context(), spaceID, recursive, fetch, offset, filter)
+		}
+		if err == nil && withMeta {
+			totalCount, err = h.postService.CountFiltered(r.Context(), spaceID, recursive, filter)
+		}
+	} else if spaceID == 0 { // All spaces
+		posts, err = h.postService.GetAllPosts(r.Context(), fetch, offset, filter)
+		if withMeta {
+			totalCount, _ = h.fileService.GetTotalPostCount()
+		}
+	} else {
+		posts, err = h.postService.GetBySpace(r.Context(), spaceID, recursive, fetch, offset, filter)
+		if withMeta {
+			// Get count from cache, which leaves out posts cross-posted into the space
 			if cat, ok := h.postService.GetSpaceFromCache(spaceID); ok {
 				if recursive {
 					totalCount = cat.RecursivePostCount
@@ -229,26 +741,59 @@ func (h *PostHandler) GetPostsBySpace(w http.ResponseWriter, r *http.Request) {
 					totalCount = cat.PostCount
 				}
 			}
+			if err == nil {
+				var crossposted int
+				crossposted, err = h.postService.CountCrosspostsInto(r.Context(), spaceID, recursive)
+				totalCount += crossposted
+			}
 		}
 	}
 
 	if err != nil {
+		if r.Context().Err() != nil {
+			// The client went away, nobody is left to answer
+			return
+		}
+		if err.Error() == config.ErrRequestCostExceeded {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
 		http.Error(w, config.ErrFailedToGetPosts, http.StatusInternalServerError)
 		return
 	}
 
+	hasMore := offset+len(posts) < totalCount
+	if cursor != nil {
+		hasMore = len(posts) > limit
+		if hasMore {
+			posts = posts[:limit]
+		}
+	}
+
 	// Filter attachments for all posts
 	for i := range posts {
 		h.filterAttachments(&posts[i])
+		if compact {
+			posts[i].Compact(config.MobileContentPreviewLength)
+		}
 	}
 
 	if withMeta {
+		// The cursor of the last post continues the listing, whether it was paged by offset or cursor
+		var nextCursor *string
+		if hasMore && len(posts) > 0 && models.IsCursorSort(filter.Sort) {
+			last := posts[len(posts)-1]
+			token := models.PostCursor{Created: last.Created, ID: last.ID, Pinned: last.Pinned}.Encode()
+			nextCursor = &token
+		}
+
 		response := map[string]interface{}{
 			"posts":       posts,
 			"total_count": totalCount,
 			"offset":      offset,
 			"limit":       limit,
-			"has_more":    offset+len(posts) < totalCount,
+			"has_more":    hasMore,
+			"next_cursor": nextCursor,
 		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
@@ -258,6 +803,479 @@ func (h *PostHandler) GetPostsBySpace(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// StreamPostsBySpace handles GET /api/spaces/{id}/posts/stream
+// Every post of the space is written as one JSON object per line (NDJSON), newest first. Posts
+// are read from the database as the client consumes them, so a slow client slows the query
+// down instead of growing the memory of the server.
+// Query parameters:
+// - recursive: include the posts of descendant spaces (default: false)
+// - profile: mobile for compact posts with truncated content (default: full posts)
+func (h *PostHandler) StreamPostsBySpace(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+	recursive := r.URL.Query().Get("recursive") == "true"
+	compact, err := h.compactProfile(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := h.postService.GetSpaceFromCache(spaceID); !ok {
+		http.Error(w, config.ErrSpaceNotFound, http.StatusNotFound)
+		return
+	}
+	if !h.reachSpace(w, r, spaceID, false) {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, config.ErrStreamingUnsupported, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	encoder := json.NewEncoder(w)
+	written := 0
+	err = h.postService.StreamBySpace(r.Context(), spaceID, recursive, func(post models.PostWithAttachments) error {
+		h.filterAttachments(&post)
+		if compact {
+			post.Compact(config.MobileContentPreviewLength)
+		}
+		if err := encoder.Encode(post); err != nil {
+			return err
+		}
+		written++
+		if written%config.PostStreamFlushInterval == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+
+	if err != nil {
+		if r.Context().Err() != nil {
+			// The client went away, nobody is left to answer
+			return
+		}
+		if written == 0 {
+			if err.Error() == config.ErrRequestCostExceeded {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			http.Error(w, config.ErrFailedToGetPosts, http.StatusInternalServerError)
+			return
+		}
+		// The status is already sent, the truncated stream is all the client can notice
+		logger.Warning("Posts stream interrupted", zap.Int("space_id", spaceID), zap.Int("written", written), zap.Error(err))
+		return
+	}
+
+	if written == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	flusher.Flush()
+}
+
+// GetSpaceMedia lists the image and video attachments of a space for gallery views
+func (h *PostHandler) GetSpaceMedia(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	if spaceID != 0 {
+		if _, ok := h.postService.GetSpaceFromCache(spaceID); !ok {
+			http.Error(w, config.ErrSpaceNotFound, http.StatusNotFound)
+			return
+		}
+		if !h.reachSpace(w, r, spaceID, false) {
+			return
+		}
+	}
+
+	mediaType := r.URL.Query().Get("type")
+	if mediaType == "" {
+		mediaType = "all"
+	}
+	if !services.IsValidMediaType(mediaType) {
+		http.Error(w, config.ErrInvalidMediaType, http.StatusBadRequest)
+		return
+	}
+
+	recursive := r.URL.Query().Get("recursive") == "true"
+
+	limit := config.DefaultPostLimit
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= config.MaxPostLimit {
+		limit = l
+	}
+
+	offset := 0
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	items, totalCount, err := h.postService.GetSpaceMedia(r.Context(), spaceID, mediaType, recursive, limit, offset)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		http.Error(w, config.ErrFailedToGetMedia, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":       items,
+		"total_count": totalCount,
+		"offset":      offset,
+		"limit":       limit,
+		"has_more":    offset+len(items) < totalCount,
+	})
+}
+
+// AppendToDailyLog appends a snippet to today's daily log post of a space
+func (h *PostHandler) AppendToDailyLog(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	snippet := strings.TrimRight(req.Content, " \t\r\n")
+	if strings.TrimSpace(snippet) == "" {
+		http.Error(w, config.ErrContentRequired, http.StatusBadRequest)
+		return
+	}
+
+	if !h.reachSpace(w, r, spaceID, true) {
+		return
+	}
+
+	post, created, err := h.postService.AppendToDailyLog(r.Context(), spaceID, snippet)
+	if err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"post":    post,
+		"created": created,
+	})
+}
+
+// GetJournalEntry returns the journal post of a space for the day in the path (YYYY-MM-DD),
+// starting it when the day has none yet
+func (h *PostHandler) GetJournalEntry(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	if !h.reachSpace(w, r, spaceID, true) {
+		return
+	}
+
+	// Starting a past day's entry is a retroactive post
+	allowPast := h.options != nil && h.options.Features.RetroactivePosting.Enabled
+	post, created, err := h.postService.GetOrCreateJournal(r.Context(), spaceID, vars["date"], allowPast)
+	if err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"post":    post,
+		"created": created,
+	})
+}
+
+// GetLimits returns the posting limits that apply to a space so the composer can show
+// the remaining characters. Content length is counted in bytes.
+func (h *PostHandler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(r.URL.Query().Get("space_id"))
+	if err != nil || spaceID <= 0 {
+		http.Error(w, config.ErrValidSpaceIDRequired, http.StatusBadRequest)
+		return
+	}
+
+	if !h.reachSpace(w, r, spaceID, false) {
+		return
+	}
+
+	limits, err := h.postService.ResolveLimits(spaceID, h.options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(limits)
+}
+
+// SetSpaceLimits sets or clears the max content length override of a space
+func (h *PostHandler) SetSpaceLimits(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		MaxContentLength *int `json:"max_content_length"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	if !h.reachSpace(w, r, spaceID, true) {
+		return
+	}
+
+	if err := h.postService.SetSpaceContentLimit(spaceID, req.MaxContentLength); err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err.Error() == config.ErrValidationMaxContentLengthRange {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	limits, err := h.postService.ResolveLimits(spaceID, h.options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(limits)
+}
+
+// GetSpaceFields returns the custom field schema of a space
+func (h *PostHandler) GetSpaceFields(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	if !h.reachSpace(w, r, spaceID, false) {
+		return
+	}
+
+	fields, err := h.postService.GetSpaceFieldSchema(spaceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"space_id": spaceID,
+		"fields":   fields,
+	})
+}
+
+// SetSpaceFields replaces the custom field schema of a space; an empty list removes it
+func (h *PostHandler) SetSpaceFields(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Fields []models.FieldDef `json:"fields"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	if !h.reachSpace(w, r, spaceID, true) {
+		return
+	}
+
+	if err := h.postService.SetSpaceFieldSchema(spaceID, req.Fields); err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.GetSpaceFields(w, r)
+}
+
+// GetSpaceSettings returns the listing preferences of a space
+func (h *PostHandler) GetSpaceSettings(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	if !h.reachSpace(w, r, spaceID, false) {
+		return
+	}
+
+	settings, err := h.postService.GetSpaceSettings(spaceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// SetSpaceSettings replaces the listing preferences of a space
+func (h *PostHandler) SetSpaceSettings(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req models.SpaceSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	if !h.reachSpace(w, r, spaceID, true) {
+		return
+	}
+
+	if err := h.postService.SetSpaceSettings(spaceID, req); err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.GetSpaceSettings(w, r)
+}
+
+// UpdatePostFields replaces the custom field values of a post
+func (h *PostHandler) UpdatePostFields(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Fields map[string]interface{} `json:"fields"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	if !h.reachPost(w, r, id, true) {
+		return
+	}
+
+	fields, err := h.postService.UpdatePostFields(id, req.Fields)
+	if err != nil {
+		if err.Error() == config.ErrPostNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"post_id": id,
+		"fields":  fields,
+	})
+}
+
+// reachSpace reports whether the viewer may read a space, or change it when write is set,
+// answering the request when it may not
+func (h *PostHandler) reachSpace(w http.ResponseWriter, r *http.Request, spaceID int, write bool) bool {
+	if err := h.postService.CheckSpace(r.Context(), spaceID, write); err != nil {
+		writeSpaceAccessError(w, err)
+		return false
+	}
+	return true
+}
+
+// reachPost is reachSpace for the space owning a post
+func (h *PostHandler) reachPost(w http.ResponseWriter, r *http.Request, postID int, write bool) bool {
+	if err := h.postService.CheckPost(r.Context(), postID, write); err != nil {
+		writeSpaceAccessError(w, err)
+		return false
+	}
+	return true
+}
+
+// compactProfile reports whether a request asks for compact posts with ?profile=mobile, which
+// only applies while mobile sync is enabled
+func (h *PostHandler) compactProfile(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("profile") {
+	case "":
+		return false, nil
+	case config.ProfileMobile:
+		return h.options != nil && h.options.Features.MobileSync.Enabled, nil
+	}
+	return false, fmt.Errorf(config.ErrInvalidProfile)
+}
+
 // filterAttachments filters attachments based on allowed extensions when file upload is enabled
 func (h *PostHandler) filterAttachments(post *models.PostWithAttachments) {
 	if !h.options.Features.FileUpload.Enabled || len(h.options.Features.FileUpload.AllowedExtensions) == 0 {
@@ -8,7 +8,9 @@ import (
 	"backthynk/internal/core/services"
 	"backthynk/internal/storage"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -119,7 +121,7 @@ func TestPostHandler_CreatePost(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test space
-	space, err := setup.spaceService.Create("Test Space", nil, "Test desc")
+	space, err := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test desc")
 	if err != nil {
 		t.Fatalf("Failed to create test space: %v", err)
 	}
@@ -274,11 +276,11 @@ func TestPostHandler_GetPost(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test data
-	space, err := setup.spaceService.Create("Test Space", nil, "Test desc")
+	space, err := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test desc")
 	if err != nil {
 		t.Fatalf("Failed to create test space: %v", err)
 	}
-	post, _ := setup.postService.Create(space.ID, "Test post content", nil)
+	post, _ := setup.postService.Create(context.Background(), space.ID, "Test post content", nil)
 
 	tests := []struct {
 		name           string
@@ -343,11 +345,11 @@ func TestPostHandler_DeletePost(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test data
-	space, err := setup.spaceService.Create("Test Space", nil, "Test desc")
+	space, err := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test desc")
 	if err != nil {
 		t.Fatalf("Failed to create test space: %v", err)
 	}
-	post, _ := setup.postService.Create(space.ID, "Test post content", nil)
+	post, _ := setup.postService.Create(context.Background(), space.ID, "Test post content", nil)
 
 	tests := []struct {
 		name           string
@@ -410,9 +412,9 @@ func TestPostHandler_MovePost(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test data
-	space1, _ := setup.spaceService.Create("Space 1", nil, "Space 1 desc")
-	space2, _ := setup.spaceService.Create("Space 2", nil, "Space 2 desc")
-	post, _ := setup.postService.Create(space1.ID, "Test post content", nil)
+	space1, _ := setup.spaceService.Create(context.Background(), "Space 1", nil, "Space 1 desc")
+	space2, _ := setup.spaceService.Create(context.Background(), "Space 2", nil, "Space 2 desc")
+	post, _ := setup.postService.Create(context.Background(), space1.ID, "Test post content", nil)
 
 	tests := []struct {
 		name           string
@@ -521,6 +523,295 @@ func TestPostHandler_MovePost(t *testing.T) {
 	}
 }
 
+func TestPostHandler_MergePosts(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	// Create test data
+	space1, _ := setup.spaceService.Create(context.Background(), "Space 1", nil, "Space 1 desc")
+	space2, _ := setup.spaceService.Create(context.Background(), "Space 2", nil, "Space 2 desc")
+	first, _ := setup.postService.Create(context.Background(), space1.ID, "First part", nil)
+	second, _ := setup.postService.Create(context.Background(), space1.ID, "Second part", nil)
+	third, _ := setup.postService.Create(context.Background(), space1.ID, "Third part", nil)
+	other, _ := setup.postService.Create(context.Background(), space2.ID, "Other space", nil)
+
+	tests := []struct {
+		name           string
+		requestBody    interface{}
+		expectedStatus int
+	}{
+		{
+			name:           "Invalid JSON",
+			requestBody:    "invalid json",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Single post",
+			requestBody:    map[string]interface{}{"post_ids": []int{first.ID}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Duplicate IDs only",
+			requestBody:    map[string]interface{}{"post_ids": []int{first.ID, first.ID}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Different spaces",
+			requestBody:    map[string]interface{}{"post_ids": []int{first.ID, other.ID}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Non-existent post",
+			requestBody:    map[string]interface{}{"post_ids": []int{first.ID, 999}},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Valid merge",
+			requestBody:    map[string]interface{}{"post_ids": []int{third.ID, first.ID, second.ID}},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			if str, ok := tt.requestBody.(string); ok {
+				body = []byte(str)
+			} else {
+				body, _ = json.Marshal(tt.requestBody)
+			}
+
+			req := httptest.NewRequest("POST", "/api/posts/merge", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			setup.postHandler.MergePosts(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// The oldest post survives and holds all content in chronological order
+	merged, err := setup.db.GetPost(first.ID)
+	if err != nil {
+		t.Fatalf("Surviving post missing: %v", err)
+	}
+	firstIdx := strings.Index(merged.Content, "First part")
+	secondIdx := strings.Index(merged.Content, "Second part")
+	thirdIdx := strings.Index(merged.Content, "Third part")
+	if firstIdx < 0 || secondIdx < firstIdx || thirdIdx < secondIdx {
+		t.Errorf("Unexpected merged content order: %q", merged.Content)
+	}
+	if !strings.HasPrefix(merged.Content, "[") {
+		t.Errorf("Expected merged content to start with a timestamp separator, got %q", merged.Content)
+	}
+
+	for _, id := range []int{second.ID, third.ID} {
+		if _, err := setup.db.GetPost(id); err == nil {
+			t.Errorf("Expected post %d to be deleted after merge", id)
+		}
+	}
+
+	space, _ := setup.cache.Get(space1.ID)
+	if space.PostCount != 1 {
+		t.Errorf("Expected space post count 1 after merge, got %d", space.PostCount)
+	}
+
+	// A post gone meanwhile is reported as such, not as a bad request
+	if _, err := setup.postService.Merge(context.Background(), []int{first.ID, second.ID}); !errors.Is(err, config.ErrUnknownPost) {
+		t.Errorf("Expected ErrUnknownPost merging a deleted post, got %v", err)
+	}
+}
+
+func TestPostHandler_MergePostsDryRun(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Space 1", nil, "Space 1 desc")
+	first, _ := setup.postService.Create(context.Background(), space.ID, "First", nil)
+	second, _ := setup.postService.Create(context.Background(), space.ID, "Second", nil)
+	setup.db.CreateAttachment(second.ID, "a.txt", "a.txt", "text/plain", 10)
+
+	body, _ := json.Marshal(map[string]interface{}{"post_ids": []int{first.ID, second.ID}})
+	req := httptest.NewRequest("POST", "/api/posts/merge?dry_run=true", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	setup.postHandler.MergePosts(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var summary models.DryRunSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if summary.SurvivorPostID != first.ID || summary.PostCount != 1 || summary.PostIDs[0] != second.ID {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if summary.AttachmentCount != 1 || summary.AttachmentBytes != 10 {
+		t.Errorf("Expected 1 attachment of 10 bytes, got %d / %d", summary.AttachmentCount, summary.AttachmentBytes)
+	}
+
+	if _, err := setup.db.GetPost(second.ID); err != nil {
+		t.Error("Expected post to still exist after dry run")
+	}
+}
+
+func TestPostHandler_SplitPost(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	// Create test data
+	space, _ := setup.spaceService.Create(context.Background(), "Space 1", nil, "Space 1 desc")
+	post, _ := setup.postService.Create(context.Background(), space.ID, "Intro\n---\nMiddle\n---\nOutro", nil)
+	attachment, err := setup.db.CreateAttachment(post.ID, "notes.txt", "notes.txt", "text/plain", 100)
+	if err != nil {
+		t.Fatalf("Failed to create attachment: %v", err)
+	}
+	postID := strconv.Itoa(post.ID)
+
+	tests := []struct {
+		name           string
+		postID         string
+		requestBody    interface{}
+		expectedStatus int
+	}{
+		{
+			name:           "Invalid JSON",
+			postID:         postID,
+			requestBody:    "invalid json",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "No split markers",
+			postID:         postID,
+			requestBody:    map[string]interface{}{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Both split markers",
+			postID: postID,
+			requestBody: map[string]interface{}{
+				"delimiter":    "---",
+				"line_offsets": []int{2},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Line offset out of range",
+			postID:         postID,
+			requestBody:    map[string]interface{}{"line_offsets": []int{10}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Delimiter not found",
+			postID:         postID,
+			requestBody:    map[string]interface{}{"delimiter": "==="},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Unknown attachment",
+			postID: postID,
+			requestBody: map[string]interface{}{
+				"delimiter":   "---",
+				"attachments": map[string]int{"999": 1},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Attachment mapped to missing part",
+			postID: postID,
+			requestBody: map[string]interface{}{
+				"delimiter":   "---",
+				"attachments": map[string]int{strconv.Itoa(attachment.ID): 5},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Non-existent post",
+			postID:         "999",
+			requestBody:    map[string]interface{}{"delimiter": "---"},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "Valid split",
+			postID: postID,
+			requestBody: map[string]interface{}{
+				"delimiter":   "---",
+				"attachments": map[string]int{strconv.Itoa(attachment.ID): 2},
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	var splitPosts []models.PostWithAttachments
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			if str, ok := tt.requestBody.(string); ok {
+				body = []byte(str)
+			} else {
+				body, _ = json.Marshal(tt.requestBody)
+			}
+
+			req := httptest.NewRequest("POST", "/api/posts/"+tt.postID+"/split", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req = mux.SetURLVars(req, map[string]string{"id": tt.postID})
+			w := httptest.NewRecorder()
+
+			setup.postHandler.SplitPost(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if w.Code == http.StatusOK {
+				if err := json.Unmarshal(w.Body.Bytes(), &splitPosts); err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+			}
+		})
+	}
+
+	if len(splitPosts) != 3 {
+		t.Fatalf("Expected 3 posts after split, got %d", len(splitPosts))
+	}
+
+	expected := []string{"Intro", "Middle", "Outro"}
+	for i, p := range splitPosts {
+		if p.Content != expected[i] {
+			t.Errorf("Part %d: expected content %q, got %q", i, expected[i], p.Content)
+		}
+		if i > 0 && p.Created <= splitPosts[i-1].Created {
+			t.Errorf("Part %d: expected timestamp after previous part", i)
+		}
+	}
+
+	if splitPosts[0].ID != post.ID {
+		t.Errorf("Expected original post to keep the first part")
+	}
+	if len(splitPosts[0].Attachments) != 0 || len(splitPosts[2].Attachments) != 1 {
+		t.Errorf("Expected attachment to move to the last part")
+	}
+
+	cached, _ := setup.cache.Get(space.ID)
+	if cached.PostCount != 3 {
+		t.Errorf("Expected space post count 3 after split, got %d", cached.PostCount)
+	}
+}
+
 func TestPostHandler_GetPostsBySpace(t *testing.T) {
 	setup, err := setupPostTest()
 	if err != nil {
@@ -529,13 +820,13 @@ func TestPostHandler_GetPostsBySpace(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test data
-	parent, _ := setup.spaceService.Create("Parent Space", nil, "Parent desc")
-	child, _ := setup.spaceService.Create("Child Space", &parent.ID, "Child desc")
+	parent, _ := setup.spaceService.Create(context.Background(), "Parent Space", nil, "Parent desc")
+	child, _ := setup.spaceService.Create(context.Background(), "Child Space", &parent.ID, "Child desc")
 
 	// Create posts
-	setup.postService.Create(parent.ID, "Post in parent", nil)
-	setup.postService.Create(child.ID, "Post in child", nil)
-	setup.postService.Create(child.ID, "Another post in child", nil)
+	setup.postService.Create(context.Background(), parent.ID, "Post in parent", nil)
+	setup.postService.Create(context.Background(), child.ID, "Post in child", nil)
+	setup.postService.Create(context.Background(), child.ID, "Another post in child", nil)
 
 	tests := []struct {
 		name           string
@@ -684,7 +975,7 @@ func TestPostHandler_ConcurrentOperations(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test space
-	space, err := setup.spaceService.Create("Test Space", nil, "Test desc")
+	space, err := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test desc")
 	if err != nil {
 		t.Fatalf("Failed to create test space: %v", err)
 	}
@@ -744,13 +1035,13 @@ func TestPostHandler_DataConsistency(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test spaces
-	space1, _ := setup.spaceService.Create("Space 1", nil, "Space 1 desc")
-	space2, _ := setup.spaceService.Create("Space 2", nil, "Space 2 desc")
+	space1, _ := setup.spaceService.Create(context.Background(), "Space 1", nil, "Space 1 desc")
+	space2, _ := setup.spaceService.Create(context.Background(), "Space 2", nil, "Space 2 desc")
 
 	// Create posts
-	post1, _ := setup.postService.Create(space1.ID, "Post 1", nil)
-	post2, _ := setup.postService.Create(space1.ID, "Post 2", nil)
-	post3, _ := setup.postService.Create(space2.ID, "Post 3", nil)
+	post1, _ := setup.postService.Create(context.Background(), space1.ID, "Post 1", nil)
+	post2, _ := setup.postService.Create(context.Background(), space1.ID, "Post 2", nil)
+	post3, _ := setup.postService.Create(context.Background(), space2.ID, "Post 3", nil)
 
 	// Test 1: Verify space post counts are updated correctly
 	cat1, _ := setup.spaceService.Get(space1.ID)
@@ -855,7 +1146,7 @@ func TestPostHandler_EventDispatchingWithFeatures(t *testing.T) {
 	})
 
 	// Create test space
-	space, err := setup.spaceService.Create("Test Space", nil, "Test desc")
+	space, err := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test desc")
 	if err != nil {
 		t.Fatalf("Failed to create test space: %v", err)
 	}
@@ -887,4 +1178,1658 @@ func TestPostHandler_EventDispatchingWithFeatures(t *testing.T) {
 	if len(dispatchedEvents) != 2 || dispatchedEvents[1].Type != events.PostDeleted {
 		t.Error("Expected PostDeleted event to be dispatched")
 	}
-}
\ No newline at end of file
+}
+func TestPostHandler_GetSpaceMedia(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	parent, _ := setup.spaceService.Create(context.Background(), "Photos", nil, "")
+	child, _ := setup.spaceService.Create(context.Background(), "Trips", &parent.ID, "")
+
+	parentPost, _ := setup.postService.Create(context.Background(), parent.ID, "Parent post", nil)
+	childPost, _ := setup.postService.Create(context.Background(), child.ID, "Child post", nil)
+	setup.db.CreateAttachment(parentPost.ID, "a.png", "1_a.png", "image/png", 100)
+	setup.db.CreateAttachment(parentPost.ID, "notes.txt", "1_notes.txt", "text/plain", 100)
+	setup.db.CreateAttachment(childPost.ID, "b.jpg", "2_b.jpg", "image/jpeg", 200)
+	setup.db.CreateAttachment(childPost.ID, "clip.mp4", "2_clip.mp4", "video/mp4", 300)
+
+	tests := []struct {
+		name           string
+		spaceID        string
+		query          string
+		expectedStatus int
+		expectedFiles  []string
+		expectedTotal  int
+	}{
+		{"Invalid space ID", "abc", "", http.StatusBadRequest, nil, 0},
+		{"Unknown space", "999", "", http.StatusNotFound, nil, 0},
+		{"Invalid type", strconv.Itoa(parent.ID), "type=audio", http.StatusBadRequest, nil, 0},
+		{"Direct images", strconv.Itoa(parent.ID), "type=image", http.StatusOK, []string{"a.png"}, 1},
+		{"Recursive images newest first", strconv.Itoa(parent.ID), "type=image&recursive=true", http.StatusOK, []string{"b.jpg", "a.png"}, 2},
+		{"Recursive all media", strconv.Itoa(parent.ID), "recursive=true", http.StatusOK, []string{"clip.mp4", "b.jpg", "a.png"}, 3},
+		{"Paginated", strconv.Itoa(parent.ID), "recursive=true&limit=1&offset=1", http.StatusOK, []string{"b.jpg"}, 3},
+		{"Videos of every space", "0", "type=video", http.StatusOK, []string{"clip.mp4"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/spaces/"+tt.spaceID+"/media?"+tt.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.spaceID})
+			w := httptest.NewRecorder()
+
+			setup.postHandler.GetSpaceMedia(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Items      []models.MediaItem `json:"items"`
+				TotalCount int                `json:"total_count"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			if response.TotalCount != tt.expectedTotal {
+				t.Errorf("Expected total %d, got %d", tt.expectedTotal, response.TotalCount)
+			}
+			if len(response.Items) != len(tt.expectedFiles) {
+				t.Fatalf("Expected %d items, got %d", len(tt.expectedFiles), len(response.Items))
+			}
+			for i, item := range response.Items {
+				if item.Filename != tt.expectedFiles[i] {
+					t.Errorf("Item %d: expected %s, got %s", i, tt.expectedFiles[i], item.Filename)
+				}
+				if item.URL != "/uploads/"+item.FilePath {
+					t.Errorf("Item %d: unexpected URL %s", i, item.URL)
+				}
+				if (item.MediaType == "image") != (item.ThumbnailURL != "") {
+					t.Errorf("Item %d: expected thumbnails only for images, got %+v", i, item)
+				}
+			}
+		})
+	}
+}
+
+func TestPostHandler_SpaceLimits(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	journal, _ := setup.spaceService.Create(context.Background(), "Journal", nil, "")
+	daily, _ := setup.spaceService.Create(context.Background(), "Daily", &journal.ID, "")
+	other, _ := setup.spaceService.Create(context.Background(), "Other", nil, "")
+
+	setLimit := func(spaceID int, body string) int {
+		req := httptest.NewRequest("PUT", "/api/spaces/"+strconv.Itoa(spaceID)+"/limits", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(spaceID)})
+		w := httptest.NewRecorder()
+		setup.postHandler.SetSpaceLimits(w, req)
+		return w.Code
+	}
+
+	if code := setLimit(journal.ID, `{"max_content_length": 200}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200 setting limit, got %d", code)
+	}
+	if code := setLimit(journal.ID, `{"max_content_length": 10}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for out of range limit, got %d", code)
+	}
+	if code := setLimit(999, `{"max_content_length": 200}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown space, got %d", code)
+	}
+
+	tests := []struct {
+		name             string
+		query            string
+		expectedStatus   int
+		expectedLength   int
+		expectedOverride *int
+	}{
+		{"Missing space", "", http.StatusBadRequest, 0, nil},
+		{"Unknown space", "space_id=999", http.StatusNotFound, 0, nil},
+		{"Own override", "space_id=" + strconv.Itoa(journal.ID), http.StatusOK, 200, &journal.ID},
+		{"Inherited override", "space_id=" + strconv.Itoa(daily.ID), http.StatusOK, 200, &journal.ID},
+		{"Global limit", "space_id=" + strconv.Itoa(other.ID), http.StatusOK, 1000, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/limits?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			setup.postHandler.GetLimits(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var limits models.EffectiveLimits
+			if err := json.Unmarshal(w.Body.Bytes(), &limits); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if limits.MaxContentLength != tt.expectedLength || limits.GlobalMaxContentLength != 1000 {
+				t.Errorf("Unexpected limits: %+v", limits)
+			}
+			if (limits.OverrideSpaceID == nil) != (tt.expectedOverride == nil) ||
+				(tt.expectedOverride != nil && *limits.OverrideSpaceID != *tt.expectedOverride) {
+				t.Errorf("Unexpected override space: %v", limits.OverrideSpaceID)
+			}
+		})
+	}
+
+	createPost := func(spaceID int, length int) int {
+		body, _ := json.Marshal(map[string]interface{}{
+			"space_id": spaceID,
+			"content":  strings.Repeat("a", length),
+		})
+		req := httptest.NewRequest("POST", "/api/posts", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		setup.postHandler.CreatePost(w, req)
+		return w.Code
+	}
+
+	if code := createPost(daily.ID, 201); code != http.StatusBadRequest {
+		t.Errorf("Expected inherited override to reject long content, got %d", code)
+	}
+	if code := createPost(daily.ID, 200); code != http.StatusCreated {
+		t.Errorf("Expected content within override to be accepted, got %d", code)
+	}
+	if code := createPost(other.ID, 500); code != http.StatusCreated {
+		t.Errorf("Expected global limit to apply to other spaces, got %d", code)
+	}
+
+	// Clearing the override restores the global limit
+	if code := setLimit(journal.ID, `{"max_content_length": null}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200 clearing limit, got %d", code)
+	}
+	if code := createPost(daily.ID, 500); code != http.StatusCreated {
+		t.Errorf("Expected global limit after clearing override, got %d", code)
+	}
+}
+
+func TestPostHandler_SpaceFields(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	books, _ := setup.spaceService.Create(context.Background(), "Books", nil, "")
+	other, _ := setup.spaceService.Create(context.Background(), "Other", nil, "")
+
+	setFields := func(spaceID int, body string) int {
+		req := httptest.NewRequest("PUT", "/api/spaces/"+strconv.Itoa(spaceID)+"/fields", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(spaceID)})
+		w := httptest.NewRecorder()
+		setup.postHandler.SetSpaceFields(w, req)
+		return w.Code
+	}
+
+	schemaTests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Invalid name", `{"fields": [{"name": "Rating", "type": "number"}]}`, http.StatusBadRequest},
+		{"Unknown type", `{"fields": [{"name": "rating", "type": "bool"}]}`, http.StatusBadRequest},
+		{"Duplicate field", `{"fields": [{"name": "rating", "type": "number"}, {"name": "rating", "type": "text"}]}`, http.StatusBadRequest},
+		{"Select without options", `{"fields": [{"name": "status", "type": "select"}]}`, http.StatusBadRequest},
+		{"Options on text", `{"fields": [{"name": "author", "type": "text", "options": ["a"]}]}`, http.StatusBadRequest},
+		{"Valid schema", `{"fields": [
+			{"name": "author", "type": "text", "required": true},
+			{"name": "rating", "type": "number"},
+			{"name": "finished", "type": "date"},
+			{"name": "status", "type": "select", "options": ["reading", "done"]}
+		]}`, http.StatusOK},
+	}
+	for _, tt := range schemaTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := setFields(books.ID, tt.body); code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, code)
+			}
+		})
+	}
+	if code := setFields(999, `{"fields": []}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown space, got %d", code)
+	}
+
+	createPost := func(spaceID int, fields map[string]interface{}) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{
+			"space_id": spaceID,
+			"content":  "A post",
+			"fields":   fields,
+		})
+		req := httptest.NewRequest("POST", "/api/posts", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		setup.postHandler.CreatePost(w, req)
+		return w
+	}
+
+	valueTests := []struct {
+		name           string
+		spaceID        int
+		fields         map[string]interface{}
+		expectedStatus int
+	}{
+		{"Missing required", books.ID, map[string]interface{}{"rating": 4}, http.StatusBadRequest},
+		{"Unknown field", books.ID, map[string]interface{}{"author": "Le Guin", "pages": 300}, http.StatusBadRequest},
+		{"Bad number", books.ID, map[string]interface{}{"author": "Le Guin", "rating": "high"}, http.StatusBadRequest},
+		{"Bad date", books.ID, map[string]interface{}{"author": "Le Guin", "finished": "03/05/2024"}, http.StatusBadRequest},
+		{"Bad option", books.ID, map[string]interface{}{"author": "Le Guin", "status": "lost"}, http.StatusBadRequest},
+		{"Fields without schema", other.ID, map[string]interface{}{"author": "Le Guin"}, http.StatusBadRequest},
+		{"Valid values", books.ID, map[string]interface{}{"author": "Le Guin", "rating": "4.50", "finished": "2024-05-03", "status": "done"}, http.StatusCreated},
+	}
+	for _, tt := range valueTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := createPost(tt.spaceID, tt.fields); w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := createPost(books.ID, map[string]interface{}{"author": "Le Guin", "rating": 5})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var post models.Post
+	json.Unmarshal(w.Body.Bytes(), &post)
+
+	// Values are normalized and listed with the post
+	posts, err := setup.postService.GetBySpace(context.Background(), books.ID, false, 10, 0, models.PostFilter{})
+	if err != nil {
+		t.Fatalf("Failed to list posts: %v", err)
+	}
+	found := false
+	for _, p := range posts {
+		if p.ID == post.ID {
+			found = true
+			if p.Fields["author"] != "Le Guin" || p.Fields["rating"] != "5" {
+				t.Errorf("Unexpected listed fields: %v", p.Fields)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("Created post not listed")
+	}
+
+	updateFields := func(postID int, body string) int {
+		req := httptest.NewRequest("PUT", "/api/posts/"+strconv.Itoa(postID)+"/fields", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(postID)})
+		w := httptest.NewRecorder()
+		setup.postHandler.UpdatePostFields(w, req)
+		return w.Code
+	}
+
+	if code := updateFields(post.ID, `{"fields": {"rating": 3}}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 when dropping a required field, got %d", code)
+	}
+	if code := updateFields(post.ID, `{"fields": {"author": "Butler", "status": "reading"}}`); code != http.StatusOK {
+		t.Errorf("Expected status 200 updating fields, got %d", code)
+	}
+	if code := updateFields(999, `{"fields": {}}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown post, got %d", code)
+	}
+
+	req := httptest.NewRequest("GET", "/api/posts/"+strconv.Itoa(post.ID), nil)
+	req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(post.ID)})
+	w = httptest.NewRecorder()
+	setup.postHandler.GetPost(w, req)
+	var fetched models.PostWithAttachments
+	json.Unmarshal(w.Body.Bytes(), &fetched)
+	if len(fetched.Fields) != 2 || fetched.Fields["author"] != "Butler" || fetched.Fields["status"] != "reading" {
+		t.Errorf("Expected updated fields to replace the old ones, got %v", fetched.Fields)
+	}
+}
+
+func TestPostHandler_AppendToDailyLog(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	clips, _ := setup.spaceService.Create(context.Background(), "Clipboard", nil, "")
+	archive, _ := setup.spaceService.Create(context.Background(), "Archive", nil, "")
+	spaceID := strconv.Itoa(clips.ID)
+
+	var updates []events.PostEvent
+	setup.dispatcher.Subscribe(events.PostUpdated, func(event events.Event) error {
+		updates = append(updates, event.Data.(events.PostEvent))
+		return nil
+	})
+
+	appendSnippet := func(id, body string) (*httptest.ResponseRecorder, *models.Post) {
+		req := httptest.NewRequest("POST", "/api/spaces/"+id+"/append", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		w := httptest.NewRecorder()
+		setup.postHandler.AppendToDailyLog(w, req)
+
+		var response struct {
+			Post *models.Post `json:"post"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response.Post
+	}
+
+	w, first := appendSnippet(spaceID, `{"content": "git rebase -i HEAD~3\n"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for first snippet, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w, second := appendSnippet(spaceID, `{"content": "https://example.com/docs"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for appended snippet, got %d: %s", w.Code, w.Body.String())
+	}
+	if second.ID != first.ID {
+		t.Errorf("Expected snippet appended to post %d, got post %d", first.ID, second.ID)
+	}
+
+	stored, _ := setup.db.GetPost(first.ID)
+	if stored.Content != "git rebase -i HEAD~3\nhttps://example.com/docs" {
+		t.Errorf("Unexpected daily log content: %q", stored.Content)
+	}
+	if len(updates) != 1 || updates[0].PostID != first.ID || updates[0].SpaceID != clips.ID {
+		t.Errorf("Expected one post.updated event, got %+v", updates)
+	}
+
+	// Once today's log is moved away, a new one is started
+	setup.postService.Move(context.Background(), first.ID, archive.ID)
+	w, third := appendSnippet(spaceID, `{"content": "after move"}`)
+	if w.Code != http.StatusCreated || third.ID == first.ID {
+		t.Errorf("Expected a new daily log after move, got status %d and post %+v", w.Code, third)
+	}
+
+	tests := []struct {
+		name           string
+		spaceID        string
+		body           string
+		expectedStatus int
+	}{
+		{"Empty content", spaceID, `{"content": "  \n"}`, http.StatusBadRequest},
+		{"Invalid JSON", spaceID, `{`, http.StatusBadRequest},
+		{"Unknown space", "999", `{"content": "x"}`, http.StatusNotFound},
+		{"Exceeds max length", spaceID, `{"content": "` + strings.Repeat("a", 150) + `"}`, http.StatusBadRequest},
+	}
+
+	limit := 100
+	if err := setup.postService.SetSpaceContentLimit(clips.ID, &limit); err != nil {
+		t.Fatalf("Failed to set space limit: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, _ := appendSnippet(tt.spaceID, tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestPostHandler_SecretScreening(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	screener := &stubScreener{}
+	setup.postService.SetSecretScreener(screener)
+	space, _ := setup.spaceService.Create(context.Background(), "Ops", nil, "")
+
+	tests := []struct {
+		name            string
+		content         string
+		expectedStatus  int
+		expectedContent string
+		expectWarnings  bool
+	}{
+		{"Clean post", "nothing here", http.StatusCreated, "nothing here", false},
+		{"Redacted post", "the secret is out", http.StatusCreated, "the [REDACTED] is out", true},
+		{"Rejected post", "forbidden key", http.StatusBadRequest, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]interface{}{"space_id": space.ID, "content": tt.content})
+			req := httptest.NewRequest("POST", "/api/posts", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			setup.postHandler.CreatePost(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			var post models.Post
+			json.NewDecoder(w.Body).Decode(&post)
+			if (len(post.Warnings) > 0) != tt.expectWarnings {
+				t.Errorf("Expected warnings %v, got %v", tt.expectWarnings, post.Warnings)
+			}
+
+			stored, err := setup.db.GetPost(post.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Content != tt.expectedContent {
+				t.Errorf("Expected stored content %q, got %q", tt.expectedContent, stored.Content)
+			}
+		})
+	}
+
+	if len(screener.recorded) != 1 || screener.recorded[0] != config.SecretSourcePost {
+		t.Errorf("Expected findings recorded for the redacted post only, got %v", screener.recorded)
+	}
+}
+
+func TestPostHandler_PostSources(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Inbox", nil, "")
+	spaceID := strconv.Itoa(space.ID)
+
+	createTests := []struct {
+		name           string
+		source         string
+		expectedStatus int
+		expectedSource string
+	}{
+		{"Hand-written", "", http.StatusCreated, models.PostSourceManual},
+		{"API", "api", http.StatusCreated, models.PostSourceAPI},
+		{"Email", "email", http.StatusCreated, models.PostSourceEmail},
+		{"Import tool", "import:obsidian", http.StatusCreated, "import:obsidian"},
+		{"Unknown source", "fax", http.StatusBadRequest, ""},
+		{"Import without tool", "import:", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range createTests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]interface{}{"space_id": space.ID, "content": "note from " + tt.name, "source": tt.source})
+			req := httptest.NewRequest("POST", "/api/posts", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			setup.postHandler.CreatePost(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			var post models.Post
+			json.NewDecoder(w.Body).Decode(&post)
+			if post.Source != tt.expectedSource {
+				t.Errorf("Expected source %s, got %s", tt.expectedSource, post.Source)
+			}
+		})
+	}
+
+	// Daily log posts come from quick capture
+	req := httptest.NewRequest("POST", "/api/spaces/"+spaceID+"/append", strings.NewReader(`{"content":"snippet"}`))
+	req = mux.SetURLVars(req, map[string]string{"id": spaceID})
+	w := httptest.NewRecorder()
+	setup.postHandler.AppendToDailyLog(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for append, got %d", w.Code)
+	}
+
+	listTests := []struct {
+		name           string
+		spaceID        string
+		query          string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{"All sources", spaceID, "?with_meta=true", http.StatusOK, 5},
+		{"Manual only", spaceID, "?with_meta=true&source=manual", http.StatusOK, 1},
+		{"Capture only", spaceID, "?with_meta=true&source=capture", http.StatusOK, 1},
+		{"Import tool", "0", "?with_meta=true&source=import:obsidian", http.StatusOK, 1},
+		{"No match", spaceID, "?with_meta=true&source=webhook", http.StatusOK, 0},
+		{"Invalid source", spaceID, "?source=fax", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range listTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/spaces/"+tt.spaceID+"/posts"+tt.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": tt.spaceID})
+			w := httptest.NewRecorder()
+			setup.postHandler.GetPostsBySpace(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Posts      []models.PostWithAttachments `json:"posts"`
+				TotalCount int                          `json:"total_count"`
+			}
+			json.NewDecoder(w.Body).Decode(&response)
+			if len(response.Posts) != tt.expectedCount || response.TotalCount != tt.expectedCount {
+				t.Errorf("Expected %d posts, got %d (total %d)", tt.expectedCount, len(response.Posts), response.TotalCount)
+			}
+			for _, post := range response.Posts {
+				if post.Source == "" {
+					t.Errorf("Expected post %d to have a source", post.ID)
+				}
+			}
+		})
+	}
+
+	// Split parts keep the source of the original post
+	post, _ := setup.postService.CreateWithSource(context.Background(), space.ID, "first\n---\nsecond", nil, models.PostSourceEmail)
+	parts, err := setup.postService.Split(context.Background(), post.ID, services.SplitOptions{Delimiter: "---"})
+	if err != nil {
+		t.Fatalf("Failed to split post: %v", err)
+	}
+	for _, part := range parts {
+		stored, _ := setup.db.GetPost(part.ID)
+		if stored.Source != models.PostSourceEmail {
+			t.Errorf("Expected split part %d to keep source email, got %s", part.ID, stored.Source)
+		}
+	}
+}
+
+func TestPostHandler_CanceledListing(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	parent, _ := setup.spaceService.Create(context.Background(), "Parent", nil, "")
+	child, _ := setup.spaceService.Create(context.Background(), "Child", &parent.ID, "")
+	for i := 0; i < 5; i++ {
+		setup.postService.Create(context.Background(), child.ID, fmt.Sprintf("post %d", i), nil)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := setup.postService.GetBySpace(ctx, parent.ID, true, 10, 0, models.PostFilter{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected recursive listing to be canceled, got %v", err)
+	}
+	if _, err := setup.postService.GetAllPosts(ctx, 10, 0, models.PostFilter{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected listing of all posts to be canceled, got %v", err)
+	}
+
+	// Nothing is written back to a client that went away
+	spaceID := strconv.Itoa(parent.ID)
+	req := httptest.NewRequest("GET", "/api/spaces/"+spaceID+"/posts?recursive=true", nil).WithContext(ctx)
+	req = mux.SetURLVars(req, map[string]string{"id": spaceID})
+	w := httptest.NewRecorder()
+	setup.postHandler.GetPostsBySpace(w, req)
+
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected no response for a canceled request, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The same listing still works for a live request
+	posts, err := setup.postService.GetBySpace(context.Background(), parent.ID, true, 10, 0, models.PostFilter{})
+	if err != nil || len(posts) != 5 {
+		t.Errorf("Expected 5 posts, got %d (%v)", len(posts), err)
+	}
+}
+
+type stubFilterResolver map[int]*models.SavedFilter
+
+func (s stubFilterResolver) GetFilter(id int) (*models.SavedFilter, bool) {
+	filter, ok := s[id]
+	return filter, ok
+}
+
+func TestPostHandler_SavedFilters(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	parent, _ := setup.spaceService.Create(context.Background(), "Projects", nil, "")
+	child, _ := setup.spaceService.Create(context.Background(), "Reports", &parent.ID, "")
+	other, _ := setup.spaceService.Create(context.Background(), "Other", nil, "")
+
+	at := func(date string) *int64 {
+		day, _ := time.ParseInLocation("2006-01-02", date, time.Local)
+		ms := day.Add(12 * time.Hour).UnixMilli()
+		return &ms
+	}
+
+	report, _ := setup.postService.Create(context.Background(), child.ID, "Quarterly report #review", at("2024-03-10"))
+	setup.db.SetPostTags(report.ID, []string{"review"})
+	setup.db.CreateAttachment(report.ID, "Q1.PDF", "q1.pdf", "application/pdf", 10)
+
+	draft, _ := setup.postService.Create(context.Background(), parent.ID, "Draft #review", at("2024-05-02"))
+	setup.db.SetPostTags(draft.ID, []string{"review"})
+	setup.db.CreateAttachment(draft.ID, "draft.docx", "draft.docx", "application/msword", 10)
+
+	old, _ := setup.postService.Create(context.Background(), child.ID, "Old report #review", at("2023-11-20"))
+	setup.db.SetPostTags(old.ID, []string{"review"})
+	setup.db.CreateAttachment(old.ID, "old.pdf", "old.pdf", "application/pdf", 10)
+
+	stray, _ := setup.postService.Create(context.Background(), other.ID, "Unrelated #review", at("2024-03-11"))
+	setup.db.SetPostTags(stray.ID, []string{"review"})
+
+	setup.postService.SetFilterResolver(stubFilterResolver{
+		1: {ID: 1, SpaceID: parent.ID, Recursive: true, Tags: []string{"review"}, Extensions: []string{"pdf"}, StartDate: "2024-01-01", EndDate: "2024-12-31"},
+		2: {ID: 2, Tags: []string{"review"}, StartDate: "2024-03-01", EndDate: "2024-03-31"},
+		3: {ID: 3, SpaceID: parent.ID, Tags: []string{"review"}},
+		4: {ID: 4, StartDate: "not-a-date"},
+	})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []int
+	}{
+		{"Subtree, tag, extension and range", "?with_meta=true&filter_id=1", http.StatusOK, []int{report.ID}},
+		{"Every space within a month", "?with_meta=true&filter_id=2", http.StatusOK, []int{stray.ID, report.ID}},
+		{"Direct posts only", "?with_meta=true&filter_id=3", http.StatusOK, []int{draft.ID}},
+		{"Explicit source refines the filter", "?with_meta=true&filter_id=2&source=api", http.StatusOK, []int{}},
+		{"Corrupt saved range", "?filter_id=4", http.StatusBadRequest, nil},
+		{"Unknown filter", "?filter_id=99", http.StatusNotFound, nil},
+		{"Invalid filter ID", "?filter_id=abc", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The path space is replaced by the saved scope
+			spaceID := strconv.Itoa(other.ID)
+			req := httptest.NewRequest("GET", "/api/spaces/"+spaceID+"/posts"+tt.query, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": spaceID})
+			w := httptest.NewRecorder()
+			setup.postHandler.GetPostsBySpace(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Posts      []models.PostWithAttachments `json:"posts"`
+				TotalCount int                          `json:"total_count"`
+			}
+			json.NewDecoder(w.Body).Decode(&response)
+			if len(response.Posts) != len(tt.expectedIDs) || response.TotalCount != len(tt.expectedIDs) {
+				t.Fatalf("Expected %d posts, got %d (total %d)", len(tt.expectedIDs), len(response.Posts), response.TotalCount)
+			}
+			for i, post := range response.Posts {
+				if post.ID != tt.expectedIDs[i] {
+					t.Errorf("Expected post %d at position %d, got %d", tt.expectedIDs[i], i, post.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestPostHandler_JournalEntries(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	diary, _ := setup.spaceService.Create(context.Background(), "Diary", nil, "")
+	work, _ := setup.spaceService.Create(context.Background(), "Work", nil, "")
+	empty, _ := setup.spaceService.Create(context.Background(), "Empty", nil, "")
+
+	createJournal := func(spaceID int, body map[string]interface{}) *httptest.ResponseRecorder {
+		body["space_id"] = spaceID
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/api/posts", bytes.NewReader(payload))
+		w := httptest.NewRecorder()
+		setup.postHandler.CreatePost(w, req)
+		return w
+	}
+
+	getJournal := func(spaceID, date string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/spaces/"+spaceID+"/journal/"+date, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": spaceID, "date": date})
+		w := httptest.NewRecorder()
+		setup.postHandler.GetJournalEntry(w, req)
+		return w
+	}
+
+	// Journal content for the same day is appended to a single post
+	w := createJournal(diary.ID, map[string]interface{}{"content": "Morning run", "type": "journal"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var first models.Post
+	json.NewDecoder(w.Body).Decode(&first)
+	if first.Type != models.PostTypeJournal {
+		t.Errorf("Expected journal type, got %q", first.Type)
+	}
+
+	w = createJournal(diary.ID, map[string]interface{}{"content": "Evening read", "type": "journal"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 when appending, got %d: %s", w.Code, w.Body.String())
+	}
+	var second models.Post
+	json.NewDecoder(w.Body).Decode(&second)
+	if second.ID != first.ID || second.Content != "Morning run\nEvening read" {
+		t.Errorf("Expected content appended to post %d, got %+v", first.ID, second)
+	}
+
+	// Notes are unaffected
+	w = createJournal(diary.ID, map[string]interface{}{"content": "A plain note"})
+	var note models.Post
+	json.NewDecoder(w.Body).Decode(&note)
+	if w.Code != http.StatusCreated || note.Type != models.PostTypeNote {
+		t.Errorf("Expected a new note, got %d %+v", w.Code, note)
+	}
+
+	if w := createJournal(diary.ID, map[string]interface{}{"content": "x", "type": "diary"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown type, got %d", w.Code)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	diaryID := strconv.Itoa(diary.ID)
+
+	tests := []struct {
+		name            string
+		spaceID         string
+		date            string
+		expectedStatus  int
+		expectedCreated bool
+		expectedPostID  int
+	}{
+		{"Today's existing entry", diaryID, today, http.StatusOK, false, first.ID},
+		{"Past day is started", diaryID, "2024-06-01", http.StatusCreated, true, 0},
+		{"Past day is reused", diaryID, "2024-06-01", http.StatusOK, false, 0},
+		{"Other space gets its own entry", strconv.Itoa(work.ID), "2024-06-01", http.StatusCreated, true, 0},
+		{"Future day", diaryID, tomorrow, http.StatusBadRequest, false, 0},
+		{"Invalid date", diaryID, "2024-13-01", http.StatusBadRequest, false, 0},
+		{"Before the minimum date", diaryID, "1999-12-31", http.StatusBadRequest, false, 0},
+		{"Unknown space", "999", today, http.StatusNotFound, false, 0},
+	}
+
+	pastEntries := make(map[string]int)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := getJournal(tt.spaceID, tt.date)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code >= http.StatusBadRequest {
+				return
+			}
+
+			var response struct {
+				Post    models.Post `json:"post"`
+				Created bool        `json:"created"`
+			}
+			json.NewDecoder(w.Body).Decode(&response)
+			if response.Created != tt.expectedCreated || response.Post.Type != models.PostTypeJournal {
+				t.Errorf("Unexpected response %+v", response)
+			}
+			if tt.expectedPostID != 0 && response.Post.ID != tt.expectedPostID {
+				t.Errorf("Expected post %d, got %d", tt.expectedPostID, response.Post.ID)
+			}
+
+			key := tt.spaceID + "/" + tt.date
+			if previous, ok := pastEntries[key]; ok && previous != response.Post.ID {
+				t.Errorf("Expected the same entry %d for %s, got %d", previous, key, response.Post.ID)
+			}
+			pastEntries[key] = response.Post.ID
+
+			if tt.expectedCreated && response.Post.Content != "# "+tt.date {
+				t.Errorf("Expected heading content, got %q", response.Post.Content)
+			}
+		})
+	}
+
+	// A journal post cannot move into a space that has an entry for its day
+	diaryPast := pastEntries[diaryID+"/2024-06-01"]
+	move := func(postID, spaceID int) int {
+		body := strings.NewReader(`{"space_id":` + strconv.Itoa(spaceID) + `}`)
+		req := httptest.NewRequest("PUT", "/api/posts/"+strconv.Itoa(postID)+"/move", body)
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(postID)})
+		w := httptest.NewRecorder()
+		setup.postHandler.MovePost(w, req)
+		return w.Code
+	}
+
+	if code := move(diaryPast, work.ID); code != http.StatusConflict {
+		t.Errorf("Expected status 409 moving into a taken day, got %d", code)
+	}
+	if code := move(diaryPast, empty.ID); code != http.StatusOK {
+		t.Fatalf("Expected status 200 moving into a free day, got %d", code)
+	}
+
+	// The entry follows its post
+	w = getJournal(strconv.Itoa(empty.ID), "2024-06-01")
+	var moved struct {
+		Post    models.Post `json:"post"`
+		Created bool        `json:"created"`
+	}
+	json.NewDecoder(w.Body).Decode(&moved)
+	if w.Code != http.StatusOK || moved.Created || moved.Post.ID != diaryPast {
+		t.Errorf("Expected moved entry %d in the new space, got %d %+v", diaryPast, w.Code, moved)
+	}
+	if w := getJournal(diaryID, "2024-06-01"); w.Code != http.StatusCreated {
+		t.Errorf("Expected a new entry in the original space, got %d", w.Code)
+	}
+}
+
+func TestPostHandler_StreamPostsBySpace(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	parent, _ := setup.spaceService.Create(context.Background(), "Parent", nil, "")
+	child, _ := setup.spaceService.Create(context.Background(), "Child", &parent.ID, "")
+	now := time.Now().UnixMilli()
+	for i := 0; i < config.PostStreamFlushInterval+5; i++ {
+		created := now - int64(i)*1000
+		spaceID := parent.ID
+		if i%2 == 1 {
+			spaceID = child.ID
+		}
+		setup.postService.Create(context.Background(), spaceID, fmt.Sprintf("post %d", i), &created)
+	}
+
+	stream := func(ctx context.Context, spaceID, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/spaces/"+spaceID+"/posts/stream"+query, nil).WithContext(ctx)
+		req = mux.SetURLVars(req, map[string]string{"id": spaceID})
+		w := httptest.NewRecorder()
+		setup.postHandler.StreamPostsBySpace(w, req)
+		return w
+	}
+
+	w := stream(context.Background(), strconv.Itoa(parent.ID), "?recursive=true")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("Expected an NDJSON stream, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != config.PostStreamFlushInterval+5 {
+		t.Fatalf("Expected %d lines, got %d", config.PostStreamFlushInterval+5, len(lines))
+	}
+	var first, second models.PostWithAttachments
+	json.Unmarshal([]byte(lines[0]), &first)
+	json.Unmarshal([]byte(lines[1]), &second)
+	if first.Content != "post 0" || second.Content != "post 1" || second.SpaceID != child.ID {
+		t.Errorf("Expected newest posts first across the subtree, got %+v then %+v", first, second)
+	}
+
+	w = stream(context.Background(), strconv.Itoa(parent.ID), "")
+	if lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n"); len(lines) != config.PostStreamFlushInterval/2+3 {
+		t.Errorf("Expected only the posts of the space without recursive, got %d", len(lines))
+	}
+
+	w = stream(context.Background(), strconv.Itoa(child.ID), "")
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+
+	empty, _ := setup.spaceService.Create(context.Background(), "Empty", nil, "")
+	if w = stream(context.Background(), strconv.Itoa(empty.ID), ""); w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("Expected an empty stream, got %d: %s", w.Code, w.Body.String())
+	}
+	if w = stream(context.Background(), "999", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown space, got %d", w.Code)
+	}
+
+	// Nothing is written back to a client that went away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if w = stream(ctx, strconv.Itoa(parent.ID), "?recursive=true"); w.Body.Len() != 0 {
+		t.Errorf("Expected no response for a canceled request, got %d bytes", w.Body.Len())
+	}
+
+	// The cursor stops as soon as the consumer does
+	read := 0
+	stop := errors.New("stop")
+	err = setup.postService.StreamBySpace(context.Background(), parent.ID, true, func(post models.PostWithAttachments) error {
+		read++
+		if read == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || read != 3 {
+		t.Errorf("Expected streaming to stop after 3 posts, got %d (%v)", read, err)
+	}
+}
+
+func TestPostHandler_PostSorting(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	logbook, _ := setup.spaceService.Create(context.Background(), "Logbook", nil, "")
+	first, _ := setup.db.CreatePostWithTimestamp(context.Background(), logbook.ID, "First", 1700000000000)
+	second, _ := setup.db.CreatePostWithTimestamp(context.Background(), logbook.ID, "Second", 1700000001000)
+	third, _ := setup.db.CreatePostWithTimestamp(context.Background(), logbook.ID, "Third", 1700000002000)
+
+	// Editing the oldest post makes it the most recently active
+	if err := setup.db.UpdatePostContent(first.ID, "First, revised"); err != nil {
+		t.Fatalf("Failed to update post: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/spaces/{id:[0-9]+}/posts", setup.postHandler.GetPostsBySpace).Methods("GET")
+	router.HandleFunc("/api/spaces/{id:[0-9]+}/settings", setup.postHandler.GetSpaceSettings).Methods("GET")
+	router.HandleFunc("/api/spaces/{id:[0-9]+}/settings", setup.postHandler.SetSpaceSettings).Methods("PUT")
+
+	listing := func(query string) ([]int, int) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%d/posts%s", logbook.ID, query), nil))
+		var posts []models.PostWithAttachments
+		json.Unmarshal(w.Body.Bytes(), &posts)
+		var ids []int
+		for _, post := range posts {
+			ids = append(ids, post.ID)
+		}
+		return ids, w.Code
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedIDs    []int
+	}{
+		{"Default order", "", http.StatusOK, []int{third.ID, second.ID, first.ID}},
+		{"Newest first", "?sort=created_desc", http.StatusOK, []int{third.ID, second.ID, first.ID}},
+		{"Oldest first", "?sort=created_asc", http.StatusOK, []int{first.ID, second.ID, third.ID}},
+		{"Last activity", "?sort=updated_desc", http.StatusOK, []int{first.ID, third.ID, second.ID}},
+		{"Oldest first page", "?sort=created_asc&limit=1&offset=1", http.StatusOK, []int{second.ID}},
+		{"Invalid sort", "?sort=title", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, code := listing(tt.query)
+			if code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, code)
+			}
+			if code == http.StatusOK && fmt.Sprint(ids) != fmt.Sprint(tt.expectedIDs) {
+				t.Errorf("Expected posts %v, got %v", tt.expectedIDs, ids)
+			}
+		})
+	}
+
+	setSettings := func(spaceID int, body string) (int, models.SpaceSettings) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("PUT", fmt.Sprintf("/api/spaces/%d/settings", spaceID), strings.NewReader(body)))
+		var settings models.SpaceSettings
+		json.Unmarshal(w.Body.Bytes(), &settings)
+		return w.Code, settings
+	}
+
+	if code, _ := setSettings(logbook.ID, `{"default_sort": "title"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid default sort, got %d", code)
+	}
+	if code, _ := setSettings(999, `{"default_sort": "created_asc"}`); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown space, got %d", code)
+	}
+	code, settings := setSettings(logbook.ID, `{"default_sort": "created_asc"}`)
+	if code != http.StatusOK || settings.DefaultSort != models.PostSortCreatedAsc {
+		t.Fatalf("Expected default sort to be stored, got %d %+v", code, settings)
+	}
+
+	// The space default applies when no sort is requested, a requested sort still wins
+	if ids, _ := listing(""); fmt.Sprint(ids) != fmt.Sprint([]int{first.ID, second.ID, third.ID}) {
+		t.Errorf("Expected space default to list oldest first, got %v", ids)
+	}
+	if ids, _ := listing("?sort=created_desc"); fmt.Sprint(ids) != fmt.Sprint([]int{third.ID, second.ID, first.ID}) {
+		t.Errorf("Expected requested sort to override the space default, got %v", ids)
+	}
+
+	// The default survives a reload from the database
+	if err := setup.postService.LoadSpaceSettings(); err != nil {
+		t.Fatalf("Failed to reload space settings: %v", err)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", fmt.Sprintf("/api/spaces/%d/settings", logbook.ID), nil))
+	json.Unmarshal(w.Body.Bytes(), &settings)
+	if settings.DefaultSort != models.PostSortCreatedAsc {
+		t.Errorf("Expected stored default sort after reload, got %+v", settings)
+	}
+
+	if code, settings := setSettings(logbook.ID, `{"default_sort": ""}`); code != http.StatusOK || settings.DefaultSort != models.PostSortCreatedDesc {
+		t.Errorf("Expected cleared default sort to list newest first, got %d %+v", code, settings)
+	}
+}
+
+func TestPostHandler_BulkRetime(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	var retimed []events.PostEvent
+	setup.dispatcher.Subscribe(events.PostRetimed, func(event events.Event) error {
+		retimed = append(retimed, event.Data.(events.PostEvent))
+		return nil
+	})
+
+	parent, _ := setup.spaceService.Create(context.Background(), "Parent", nil, "")
+	child, _ := setup.spaceService.Create(context.Background(), "Child", &parent.ID, "")
+	day := int64(24 * time.Hour / time.Millisecond)
+	base := time.Now().Add(-30 * 24 * time.Hour).UnixMilli()
+	ts1, ts2, ts3 := base, base+day, base+3*day
+	first, _ := setup.postService.Create(context.Background(), parent.ID, "First", &ts1)
+	second, _ := setup.postService.Create(context.Background(), parent.ID, "Second", &ts2)
+	nested, _ := setup.postService.Create(context.Background(), child.ID, "Nested", &ts3)
+
+	retime := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/posts/bulk-retime", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		setup.postHandler.BulkRetime(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Invalid JSON", "invalid json", http.StatusBadRequest},
+		{"No selection", `{"delta_ms": 1000}`, http.StatusBadRequest},
+		{"No mode", fmt.Sprintf(`{"space_id": %d}`, parent.ID), http.StatusBadRequest},
+		{"Both modes", fmt.Sprintf(`{"space_id": %d, "delta_ms": 1000, "range_start": %d, "range_end": %d}`, parent.ID, ts1, ts2), http.StatusBadRequest},
+		{"Reversed range", fmt.Sprintf(`{"space_id": %d, "range_start": %d, "range_end": %d}`, parent.ID, ts2, ts1), http.StatusBadRequest},
+		{"Into the future", fmt.Sprintf(`{"space_id": %d, "delta_ms": %d}`, parent.ID, 60*day), http.StatusBadRequest},
+		{"Unknown space", `{"space_id": 999, "delta_ms": 1000}`, http.StatusNotFound},
+		{"Invalid source", fmt.Sprintf(`{"space_id": %d, "delta_ms": 1000, "source": "fax"}`, parent.ID), http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := retime(tt.body); w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+	if len(retimed) != 0 {
+		t.Fatalf("Expected no retime events from rejected requests, got %d", len(retimed))
+	}
+
+	// A dry run reports the selection without moving anything
+	req := httptest.NewRequest("POST", "/api/posts/bulk-retime?dry_run=true", strings.NewReader(fmt.Sprintf(`{"space_id": %d, "recursive": true, "delta_ms": %d}`, parent.ID, -day)))
+	w := httptest.NewRecorder()
+	setup.postHandler.BulkRetime(w, req)
+	var summary models.DryRunSummary
+	json.Unmarshal(w.Body.Bytes(), &summary)
+	if w.Code != http.StatusOK || !summary.DryRun || summary.PostCount != 3 || summary.SpaceCount != 2 || len(summary.DescendantSpaces) != 1 {
+		t.Errorf("Unexpected dry run %d: %+v", w.Code, summary)
+	}
+	if post, _ := setup.db.GetPost(first.ID); post.Created != ts1 || len(retimed) != 0 {
+		t.Errorf("Expected a dry run to change nothing, got %d and %d events", post.Created, len(retimed))
+	}
+
+	// Shifting the parent alone leaves the child space untouched
+	w = retime(fmt.Sprintf(`{"space_id": %d, "delta_ms": %d}`, parent.ID, -day))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result models.RetimeResult
+	json.Unmarshal(w.Body.Bytes(), &result)
+	if result.PostsChanged != 2 || result.AuditID == 0 {
+		t.Errorf("Expected 2 posts changed with an audit entry, got %+v", result)
+	}
+	if len(retimed) != 2 || retimed[0].OldTimestamp != ts1 || retimed[0].Timestamp != ts1-day {
+		t.Errorf("Expected a retime event per post with old and new timestamps, got %+v", retimed)
+	}
+	if post, _ := setup.db.GetPost(nested.ID); post.Created != ts3 {
+		t.Errorf("Expected nested post to keep its timestamp, got %d", post.Created)
+	}
+
+	// Mapping onto a range keeps the order and relative gaps of the selection
+	rangeStart := base - 10*day
+	w = retime(fmt.Sprintf(`{"space_id": %d, "recursive": true, "range_start": %d, "range_end": %d}`, parent.ID, rangeStart, rangeStart+8*day))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	expected := map[int]int64{first.ID: rangeStart, second.ID: rangeStart + 2*day, nested.ID: rangeStart + 8*day}
+	for id, created := range expected {
+		post, err := setup.db.GetPost(id)
+		if err != nil {
+			t.Fatalf("Failed to get post %d: %v", id, err)
+		}
+		if post.Created != created {
+			t.Errorf("Expected post %d at %d, got %d", id, created, post.Created)
+		}
+	}
+
+	// Disabled retroactive posting turns the endpoint off
+	setup.options.Features.RetroactivePosting.Enabled = false
+	if w := retime(fmt.Sprintf(`{"post_ids": [%d], "delta_ms": -1000}`, first.ID)); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 with retroactive posting disabled, got %d", w.Code)
+	}
+}
+
+func TestPostHandler_BulkRetimeSpaceAccess(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	parent, _ := setup.spaceService.Create(context.Background(), "Parent", nil, "")
+	readable, _ := setup.spaceService.Create(context.Background(), "Readable", &parent.ID, "")
+	hidden, _ := setup.spaceService.Create(context.Background(), "Hidden", &parent.ID, "")
+	base := time.Now().Add(-30 * 24 * time.Hour).UnixMilli()
+	own, _ := setup.postService.Create(context.Background(), parent.ID, "Own", &base)
+	shared, _ := setup.postService.Create(context.Background(), readable.ID, "Shared", &base)
+	secret, _ := setup.postService.Create(context.Background(), hidden.ID, "Secret", &base)
+
+	alice, _ := setup.db.ProvisionUser("alice", "", "Alice", models.RoleEditor, 1)
+	bob, _ := setup.db.ProvisionUser("bob", "", "Bob", models.RoleEditor, 1)
+	access := services.NewSpaceAccess(setup.db, setup.cache)
+	access.SetACL(readable.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}})
+	access.SetACL(hidden.ID, []models.SpaceACLEntry{{UserID: bob.ID, Permission: models.SpacePermissionWrite}})
+	setup.postService.SetSpaceAccess(access)
+
+	retime := func(query string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"space_id": %d, "recursive": true, "delta_ms": -1000}`, parent.ID)
+		req := httptest.NewRequest("POST", "/api/posts/bulk-retime"+query, strings.NewReader(body))
+		req = req.WithContext(services.WithViewer(req.Context(), services.Viewer{UserID: alice.ID}))
+		w := httptest.NewRecorder()
+		setup.postHandler.BulkRetime(w, req)
+		return w
+	}
+
+	// Descendants the viewer may not see or may only read are left out of the selection
+	w := retime("?dry_run=true")
+	var summary models.DryRunSummary
+	json.Unmarshal(w.Body.Bytes(), &summary)
+	if w.Code != http.StatusOK || summary.PostCount != 1 || len(summary.DescendantSpaces) != 0 {
+		t.Errorf("Expected only the parent post in the dry run, got %d: %+v", w.Code, summary)
+	}
+
+	if w := retime(""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if post, _ := setup.db.GetPost(own.ID); post.Created != base-1000 {
+		t.Errorf("Expected the parent post to be retimed, got %d", post.Created)
+	}
+	for _, id := range []int{shared.ID, secret.ID} {
+		if post, _ := setup.db.GetPost(id); post.Created != base {
+			t.Errorf("Expected post %d to keep its timestamp, got %d", id, post.Created)
+		}
+	}
+}
+
+func TestPostHandler_UpdatePost(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	// Edit history is read from the options when the post service is built
+	setup.options.Features.EditHistory.MaxRevisions = 2
+	config.SetOptionsConfigForTest(setup.options)
+	defer config.SetOptionsConfigForTest(nil)
+	postService := services.NewPostService(setup.db, setup.cache, setup.dispatcher)
+	handler := NewPostHandler(postService, setup.fileService, setup.options)
+
+	var updated []events.PostEvent
+	setup.dispatcher.Subscribe(events.PostUpdated, func(event events.Event) error {
+		updated = append(updated, event.Data.(events.PostEvent))
+		return nil
+	})
+
+	space, _ := setup.spaceService.Create(context.Background(), "Notes", nil, "")
+	post, _ := postService.Create(context.Background(), space.ID, "First draft", nil)
+	setup.fileService.SaveLinkPreview(post.ID, map[string]interface{}{"url": "https://example.com/a", "title": "A"})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/posts/{id:[0-9]+}", handler.UpdatePost).Methods("PUT")
+	router.HandleFunc("/api/posts/{id:[0-9]+}/revisions", handler.GetPostRevisions).Methods("GET")
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+	path := fmt.Sprintf("/api/posts/%d", post.ID)
+
+	// Without link_previews, the previews of the post are kept
+	w := serve("PUT", path, `{"content": "Second draft"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.PostWithAttachments
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Content != "Second draft" || len(response.LinkPreviews) != 1 {
+		t.Errorf("Expected the new content with the previews kept, got %+v", response)
+	}
+	if len(updated) != 1 || updated[0].PostID != post.ID || updated[0].SpaceID != space.ID {
+		t.Errorf("Expected one PostUpdated event, got %+v", updated)
+	}
+
+	w = serve("PUT", path, `{"content": "Final", "link_previews": [{"url": "https://example.com/b", "title": "B"}]}`)
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.LinkPreviews) != 1 || response.LinkPreviews[0].URL != "https://example.com/b" {
+		t.Errorf("Expected the previews to be replaced, got %+v", response.LinkPreviews)
+	}
+	serve("PUT", path, `{"content": "Final", "link_previews": []}`)
+
+	// Only the two most recent revisions are kept, newest first
+	w = serve("GET", path+"/revisions", "")
+	var revisions []models.PostRevision
+	json.Unmarshal(w.Body.Bytes(), &revisions)
+	if len(revisions) != 2 || revisions[0].Content != "Final" || revisions[1].Content != "Second draft" {
+		t.Fatalf("Expected the two latest revisions, got %+v", revisions)
+	}
+	if len(revisions[0].LinkPreviews) != 1 || revisions[0].LinkPreviews[0].Title != "B" {
+		t.Errorf("Expected the replaced previews in the revision, got %+v", revisions[0].LinkPreviews)
+	}
+	if previews, _ := setup.db.GetLinkPreviewsByPostID(post.ID); len(previews) != 0 {
+		t.Errorf("Expected an empty list to remove the previews, got %+v", previews)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"Missing content", "PUT", path, `{"content": ""}`, http.StatusBadRequest},
+		{"Invalid JSON", "PUT", path, `{`, http.StatusBadRequest},
+		{"Content too long", "PUT", path, `{"content": "` + strings.Repeat("a", 1001) + `"}`, http.StatusBadRequest},
+		{"Unknown post", "PUT", "/api/posts/999", `{"content": "Hello"}`, http.StatusNotFound},
+		{"Revisions of unknown post", "GET", "/api/posts/999/revisions", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(tt.method, tt.path, tt.body); w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	setup.options.Features.EditHistory.Enabled = false
+	handler = NewPostHandler(services.NewPostService(setup.db, setup.cache, setup.dispatcher), setup.fileService, setup.options)
+	router = mux.NewRouter()
+	router.HandleFunc("/api/posts/{id:[0-9]+}/revisions", handler.GetPostRevisions).Methods("GET")
+	if w := serve("GET", path+"/revisions", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 with edit history disabled, got %d", w.Code)
+	}
+}
+
+func TestPostHandler_MobileProfile(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+	setup.options.Features.Markdown.Enabled = true
+
+	space, _ := setup.spaceService.Create(context.Background(), "Reading", nil, "")
+	long := "**Bold** " + strings.Repeat("é", config.MobileContentPreviewLength)
+	post, _ := setup.db.CreatePost(context.Background(), space.ID, long)
+	setup.db.CreateLinkPreview(&models.LinkPreview{PostID: post.ID, URL: "https://example.com", Title: "Example", Description: "A long description", ImageURL: "https://example.com/large.png"})
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/posts/{id:[0-9]+}", setup.postHandler.GetPost).Methods("GET")
+	router.HandleFunc("/api/spaces/{id:[0-9]+}/posts", setup.postHandler.GetPostsBySpace).Methods("GET")
+	router.HandleFunc("/api/spaces/{id:[0-9]+}/posts/stream", setup.postHandler.StreamPostsBySpace).Methods("GET")
+
+	get := func(path string) (models.PostWithAttachments, int) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		body := strings.TrimSpace(w.Body.String())
+		if strings.HasPrefix(body, "[") {
+			body = strings.TrimSuffix(strings.TrimPrefix(body, "["), "]")
+		}
+		var decoded models.PostWithAttachments
+		json.Unmarshal([]byte(body), &decoded)
+		return decoded, w.Code
+	}
+
+	// Listings cut the content and keep the link without its image
+	listed, code := get(fmt.Sprintf("/api/spaces/%d/posts?profile=mobile", space.ID))
+	if code != http.StatusOK || !listed.Truncated || len([]rune(listed.Content)) != config.MobileContentPreviewLength {
+		t.Errorf("Expected truncated content, got %d: %+v", code, listed.Post)
+	}
+	if len(listed.LinkPreviews) != 1 || listed.LinkPreviews[0].Title != "Example" || listed.LinkPreviews[0].ImageURL != "" || listed.LinkPreviews[0].Description != "" {
+		t.Errorf("Expected the link preview without image or description, got %+v", listed.LinkPreviews)
+	}
+	streamed, code := get(fmt.Sprintf("/api/spaces/%d/posts/stream?profile=mobile", space.ID))
+	if code != http.StatusOK || !streamed.Truncated {
+		t.Errorf("Expected truncated streamed posts, got %d: %+v", code, streamed.Post)
+	}
+
+	// A single post keeps its whole content as source instead of rendered HTML
+	single, code := get(fmt.Sprintf("/api/posts/%d?profile=mobile", post.ID))
+	if code != http.StatusOK || single.Content != long || single.Truncated || single.Hash != "" {
+		t.Errorf("Expected the whole unrendered content, got %d: %+v", code, single.Post)
+	}
+	full, _ := get(fmt.Sprintf("/api/posts/%d", post.ID))
+	if full.Hash == "" || full.LinkPreviews[0].ImageURL == "" {
+		t.Errorf("Expected full posts unchanged, got %+v", full)
+	}
+
+	// The profile only applies while mobile sync is enabled
+	setup.options.Features.MobileSync.Enabled = false
+	if listed, _ := get(fmt.Sprintf("/api/spaces/%d/posts?profile=mobile", space.ID)); listed.Truncated {
+		t.Error("Expected full posts with mobile sync disabled")
+	}
+	if _, code := get(fmt.Sprintf("/api/posts/%d?profile=tablet", post.ID)); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown profile, got %d", code)
+	}
+}
+
+func TestPostHandler_GetPostsBySpaceCursor(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Cursor Space", nil, "")
+	for i := 0; i < 5; i++ {
+		setup.postService.Create(context.Background(), space.ID, fmt.Sprintf("Post %d", i), nil)
+	}
+
+	type page struct {
+		Posts      []models.PostWithAttachments `json:"posts"`
+		HasMore    bool                         `json:"has_more"`
+		NextCursor *string                      `json:"next_cursor"`
+	}
+	list := func(query string) (int, page) {
+		req := httptest.NewRequest("GET", "/api/spaces/"+strconv.Itoa(space.ID)+"/posts?with_meta=true&limit=2"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(space.ID)})
+		w := httptest.NewRecorder()
+		setup.postHandler.GetPostsBySpace(w, req)
+
+		var p page
+		json.Unmarshal(w.Body.Bytes(), &p)
+		return w.Code, p
+	}
+
+	for _, sort := range []string{models.PostSortCreatedDesc, models.PostSortCreatedAsc} {
+		t.Run(sort, func(t *testing.T) {
+			seen := map[int]bool{}
+			var previous *models.PostWithAttachments
+			query := "&sort=" + sort
+			for pages := 0; ; pages++ {
+				if pages > 5 {
+					t.Fatal("Expected the listing to end")
+				}
+				code, p := list(query)
+				if code != http.StatusOK {
+					t.Fatalf("Expected status 200, got %d", code)
+				}
+
+				for i := range p.Posts {
+					post := p.Posts[i]
+					if seen[post.ID] {
+						t.Errorf("Post %d listed twice", post.ID)
+					}
+					seen[post.ID] = true
+
+					if previous != nil {
+						before := post.Created < previous.Created || (post.Created == previous.Created && post.ID < previous.ID)
+						if before != (sort == models.PostSortCreatedDesc) {
+							t.Errorf("Post %d listed out of order after post %d", post.ID, previous.ID)
+						}
+					}
+					previous = &post
+				}
+
+				if !p.HasMore {
+					if p.NextCursor != nil {
+						t.Error("Expected no cursor on the last page")
+					}
+					break
+				}
+				if p.NextCursor == nil {
+					t.Fatal("Expected a cursor while more posts follow")
+				}
+				query = "&sort=" + sort + "&cursor=" + *p.NextCursor
+
+				// Posts added meanwhile do not shift the following pages
+				if pages == 0 && sort == models.PostSortCreatedAsc {
+					setup.postService.Create(context.Background(), space.ID, "Late post", nil)
+				}
+			}
+
+			expected := 5
+			if sort == models.PostSortCreatedAsc {
+				expected = 6
+			}
+			if len(seen) != expected {
+				t.Errorf("Expected %d posts over all pages, got %d", expected, len(seen))
+			}
+		})
+	}
+
+	t.Run("invalid cursors", func(t *testing.T) {
+		if code, _ := list("&cursor=not-a-cursor"); code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a malformed cursor, got %d", code)
+		}
+		cursor := models.PostCursor{Created: 1, ID: 1}.Encode()
+		if code, _ := list("&sort=updated_desc&cursor=" + cursor); code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for a cursor in activity order, got %d", code)
+		}
+	})
+}
+
+func TestPostHandler_GetPostsBySpaceCostCaps(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	parent, _ := setup.spaceService.Create(context.Background(), "Parent Space", nil, "")
+	child, _ := setup.spaceService.Create(context.Background(), "Child Space", &parent.ID, "")
+	setup.postService.Create(context.Background(), child.ID, "Deep post", nil)
+
+	tests := []struct {
+		name           string
+		path           string
+		maxSpaces      int
+		expectedStatus int
+		expectedCost   string
+	}{
+		{"Within the caps", "/posts?recursive=true", 2, http.StatusOK, "spaces=2; posts=1"},
+		{"Over the space cap", "/posts?recursive=true", 1, http.StatusUnprocessableEntity, "spaces=2; posts=1"},
+		{"Flat listings are not charged", "/posts", 1, http.StatusOK, "spaces=0; posts=0"},
+		{"Over the cap while streaming", "/posts/stream?recursive=true", 1, http.StatusUnprocessableEntity, "spaces=2; posts=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meter := services.NewRequestCost(tt.maxSpaces, 0)
+			req := httptest.NewRequest("GET", "/api/spaces/"+strconv.Itoa(parent.ID)+tt.path, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(parent.ID)})
+			req = req.WithContext(services.WithRequestCost(req.Context(), meter))
+			w := httptest.NewRecorder()
+
+			if strings.Contains(tt.path, "stream") {
+				setup.postHandler.StreamPostsBySpace(w, req)
+			} else {
+				setup.postHandler.GetPostsBySpace(w, req)
+			}
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			spaces, posts, _ := meter.Totals()
+			if got := fmt.Sprintf("spaces=%d; posts=%d", spaces, posts); got != tt.expectedCost {
+				t.Errorf("Expected cost %s, got %s", tt.expectedCost, got)
+			}
+		})
+	}
+}
+
+func TestPostHandler_PinPost(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	// Pinning is read from the options when the post service is built
+	setup.options.Features.Pinning.MaxPerSpace = 2
+	config.SetOptionsConfigForTest(setup.options)
+	defer config.SetOptionsConfigForTest(nil)
+	postService := services.NewPostService(setup.db, setup.cache, setup.dispatcher)
+	handler := NewPostHandler(postService, setup.fileService, setup.options)
+
+	var pinEvents []events.PostEvent
+	setup.dispatcher.Subscribe(events.PostPinned, func(event events.Event) error {
+		pinEvents = append(pinEvents, event.Data.(events.PostEvent))
+		return nil
+	})
+
+	space, _ := setup.spaceService.Create(context.Background(), "Pinned Space", nil, "")
+	other, _ := setup.spaceService.Create(context.Background(), "Other Space", nil, "")
+	var posts []*models.Post
+	for i := 0; i < 5; i++ {
+		post, _ := postService.Create(context.Background(), space.ID, fmt.Sprintf("Post %d", i), nil)
+		posts = append(posts, post)
+	}
+	crossposted, _ := postService.Create(context.Background(), other.ID, "Cross-posted", nil)
+	postService.Crosspost(crossposted.ID, space.ID)
+
+	pin := func(method string, postID int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, fmt.Sprintf("/api/posts/%d/pin", postID), nil)
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(postID)})
+		w := httptest.NewRecorder()
+		if method == "PUT" {
+			handler.PinPost(w, req)
+		} else {
+			handler.UnpinPost(w, req)
+		}
+		return w
+	}
+
+	type page struct {
+		Posts      []models.PostWithAttachments `json:"posts"`
+		HasMore    bool                         `json:"has_more"`
+		NextCursor *string                      `json:"next_cursor"`
+	}
+	list := func(query string) []models.PostWithAttachments {
+		req := httptest.NewRequest("GET", "/api/spaces/"+strconv.Itoa(space.ID)+"/posts?with_meta=true"+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(space.ID)})
+
+		var listed []models.PostWithAttachments
+		for pages := 0; pages < 10; pages++ {
+			w := httptest.NewRecorder()
+			handler.GetPostsBySpace(w, req)
+			var p page
+			json.Unmarshal(w.Body.Bytes(), &p)
+			listed = append(listed, p.Posts...)
+			if p.NextCursor == nil {
+				break
+			}
+			req = httptest.NewRequest("GET", "/api/spaces/"+strconv.Itoa(space.ID)+"/posts?with_meta=true"+query+"&cursor="+*p.NextCursor, nil)
+			req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(space.ID)})
+		}
+		return listed
+	}
+
+	t.Run("pinned posts lead the listing", func(t *testing.T) {
+		for _, post := range []*models.Post{posts[1], posts[3], crossposted} {
+			if w := pin("PUT", post.ID); w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200 pinning post %d, got %d: %s", post.ID, w.Code, w.Body.String())
+			}
+		}
+		if w := pin("PUT", posts[1].ID); w.Code != http.StatusOK {
+			t.Errorf("Expected pinning a pinned post to succeed, got %d", w.Code)
+		}
+		if len(pinEvents) != 3 || !pinEvents[0].Pinned || pinEvents[2].SpaceID != other.ID {
+			t.Errorf("Expected one event per change, got %+v", pinEvents)
+		}
+
+		for _, sort := range []string{models.PostSortCreatedDesc, models.PostSortCreatedAsc, models.PostSortUpdatedDesc} {
+			// Activity order has no cursor, its single page holds every post
+			query := "&limit=2&sort=" + sort
+			if sort == models.PostSortUpdatedDesc {
+				query = "&limit=10&sort=" + sort
+			}
+			listed := list(query)
+			seen := map[int]bool{}
+			for _, post := range listed {
+				seen[post.ID] = true
+			}
+			if len(listed) != 6 || len(seen) != 6 {
+				t.Fatalf("%s: expected 6 distinct posts over all pages, got %d", sort, len(listed))
+			}
+			if !listed[0].Pinned || !listed[1].Pinned || listed[2].Pinned {
+				t.Errorf("%s: expected the two posts pinned in the space first, got %+v", sort, listed[:3])
+			}
+			for _, post := range listed {
+				if post.ID == crossposted.ID && post.Pinned {
+					t.Errorf("%s: expected a cross-posted post to stay pinned to its own space only", sort)
+				}
+			}
+		}
+	})
+
+	t.Run("pins are capped per space", func(t *testing.T) {
+		w := pin("PUT", posts[0].ID)
+		if w.Code != http.StatusConflict {
+			t.Errorf("Expected status 409 past the cap, got %d", w.Code)
+		}
+		if w := pin("PUT", 999); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for an unknown post, got %d", w.Code)
+		}
+	})
+
+	t.Run("unpinning", func(t *testing.T) {
+		w := pin("DELETE", posts[3].ID)
+		var post models.Post
+		json.Unmarshal(w.Body.Bytes(), &post)
+		if w.Code != http.StatusOK || post.Pinned {
+			t.Fatalf("Expected the post to be unpinned, got %d: %s", w.Code, w.Body.String())
+		}
+		if last := pinEvents[len(pinEvents)-1]; last.Pinned || last.PostID != posts[3].ID {
+			t.Errorf("Expected an unpin event, got %+v", last)
+		}
+		if w := pin("PUT", posts[0].ID); w.Code != http.StatusOK {
+			t.Errorf("Expected room for another pin, got %d", w.Code)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		options := config.NewTestOptionsConfig()
+		options.Features.Pinning.Enabled = false
+		config.SetOptionsConfigForTest(options)
+		disabled := NewPostHandler(services.NewPostService(setup.db, setup.cache, setup.dispatcher), setup.fileService, options)
+
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/api/posts/%d/pin", posts[2].ID), nil)
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(posts[2].ID)})
+		w := httptest.NewRecorder()
+		disabled.PinPost(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 with pinning disabled, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/render"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type printPage struct {
+	Title       string
+	Description string
+	Summary     string
+	Printed     int64
+	NextURL     string
+	Posts       []printPost
+}
+
+type printPost struct {
+	Created int64
+	Space   string // Breadcrumb, only set when posts of several spaces are printed
+	Content template.HTML
+	Plain   bool
+	Images  []printAttachment
+	Files   []printAttachment
+	Links   []models.LinkPreview
+}
+
+type printAttachment struct {
+	Name string
+	URL  string
+	Size string
+}
+
+// PrintPost handles GET /api/posts/{id}/print, the post as a standalone page for printing
+func (h *PostHandler) PrintPost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+	if !h.reachPost(w, r, id, false) {
+		return
+	}
+
+	post, err := h.fileService.GetPostWithAttachments(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	h.filterAttachments(post)
+
+	page := printPage{
+		Title:   h.printBreadcrumb(post.SpaceID),
+		Summary: render.FormatDate(render.RequestLocale(r), post.Created),
+		Posts:   []printPost{h.printPost(*post, false)},
+	}
+	h.writePrintPage(w, r, page)
+}
+
+// PrintSpace handles GET /api/spaces/{id}/print, the posts of a space oldest first as a
+// standalone page for printing. Space 0 prints every space.
+// Query parameters:
+// - recursive: include descendant spaces (default: false)
+// - limit: posts per page (default 200, max 1000)
+// - offset: posts to skip, the footer links to the next page
+func (h *PostHandler) PrintSpace(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	recursive := query.Get("recursive") == "true"
+
+	limit := config.DefaultPrintPostLimit
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= config.MaxPrintPostLimit {
+		limit = l
+	}
+	offset := 0
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	page := printPage{Title: h.options.Metadata.Title}
+	total := 0
+	var posts []models.PostWithAttachments
+	if spaceID == 0 {
+		recursive = true
+		if h.postService.HidesSpaces(r.Context()) {
+			total, _ = h.postService.CountFiltered(r.Context(), 0, true, models.PostFilter{})
+		} else {
+			total, _ = h.fileService.GetTotalPostCount()
+		}
+		posts, err = h.postService.GetAllPosts(r.Context(), limit, offset, models.PostFilter{})
+	} else {
+		space, ok := h.postService.GetSpaceFromCache(spaceID)
+		if !ok {
+			http.Error(w, config.ErrSpaceNotFound, http.StatusNotFound)
+			return
+		}
+		if !h.reachSpace(w, r, spaceID, false) {
+			return
+		}
+		page.Title = h.printBreadcrumb(spaceID)
+		page.Description = space.Description
+		total = space.PostCount
+		if recursive && h.postService.HidesSpaces(r.Context()) {
+			total, _ = h.postService.CountFiltered(r.Context(), spaceID, true, models.PostFilter{})
+		} else if recursive {
+			total = space.RecursivePostCount
+		}
+		posts, err = h.postService.GetBySpace(r.Context(), spaceID, recursive, limit, offset, models.PostFilter{})
+	}
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		http.Error(w, config.ErrFailedToGetPosts, http.StatusInternalServerError)
+		return
+	}
+
+	// Listings are newest first, printed pages read in order
+	for i := len(posts) - 1; i >= 0; i-- {
+		h.filterAttachments(&posts[i])
+		page.Posts = append(page.Posts, h.printPost(posts[i], recursive))
+	}
+
+	switch {
+	case len(posts) == 0:
+		page.Summary = "No posts"
+	case offset == 0 && len(posts) >= total:
+		page.Summary = fmt.Sprintf("%d posts", len(posts))
+	default:
+		page.Summary = fmt.Sprintf("Newest posts %d to %d of %d", offset+1, offset+len(posts), total)
+	}
+	if offset+len(posts) < total && len(posts) == limit {
+		next := url.Values{"limit": {strconv.Itoa(limit)}, "offset": {strconv.Itoa(offset + limit)}}
+		if recursive && spaceID != 0 {
+			next.Set("recursive", "true")
+		}
+		page.NextURL = r.URL.Path + "?" + next.Encode()
+	}
+
+	h.writePrintPage(w, r, page)
+}
+
+func (h *PostHandler) printPost(post models.PostWithAttachments, withSpace bool) printPost {
+	printed := printPost{
+		Created: post.Created,
+		Links:   post.LinkPreviews,
+	}
+	if withSpace {
+		printed.Space = h.printBreadcrumb(post.SpaceID)
+	}
+
+	// Same rendering as the post API, raw content is kept as preformatted text
+	if h.options != nil && h.options.Features.Markdown.Enabled {
+		printed.Content = template.HTML(h.postService.RenderContent(post.SpaceID, post.Content))
+	} else {
+		printed.Content = template.HTML(html.EscapeString(post.Content))
+		printed.Plain = true
+	}
+
+	for _, attachment := range post.Attachments {
+		item := printAttachment{
+			Name: attachment.Filename,
+			URL:  "/uploads/" + url.PathEscape(attachment.FilePath),
+			Size: formatPrintSize(attachment.FileSize),
+		}
+		if strings.HasPrefix(attachment.FileType, "image/") {
+			printed.Images = append(printed.Images, item)
+		} else {
+			printed.Files = append(printed.Files, item)
+		}
+	}
+
+	return printed
+}
+
+// printBreadcrumb returns "Parent > Child" for a space from the cache
+func (h *PostHandler) printBreadcrumb(spaceID int) string {
+	var names []string
+	for current := spaceID; ; {
+		space, ok := h.postService.GetSpaceFromCache(current)
+		if !ok {
+			break
+		}
+		names = append([]string{space.Name}, names...)
+		if space.ParentID == nil {
+			break
+		}
+		current = *space.ParentID
+	}
+	return strings.Join(names, " > ")
+}
+
+func (h *PostHandler) writePrintPage(w http.ResponseWriter, r *http.Request, page printPage) {
+	page.Printed = time.Now().UnixMilli()
+	render.Write(w, r, render.PagePrint, page.Title, page)
+}
+
+func formatPrintSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGT"[exp])
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPostHandler_PrintPost(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	parent, _ := setup.spaceService.Create(context.Background(), "Travel", nil, "")
+	space, _ := setup.spaceService.Create(context.Background(), "Japan", &parent.ID, "")
+	post, _ := setup.postService.Create(context.Background(), space.ID, "Day one <script>alert(1)</script>", nil)
+	setup.db.CreateAttachment(post.ID, "temple.jpg", "1_temple.jpg", "image/jpeg", 2048)
+	setup.db.CreateAttachment(post.ID, "tickets.pdf", "1_tickets.pdf", "application/pdf", 3*1024*1024)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/posts/{id:[0-9]+}/print", setup.postHandler.PrintPost).Methods("GET")
+
+	tests := []struct {
+		name           string
+		postID         string
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "Valid post",
+			postID:         strconv.Itoa(post.ID),
+			expectedStatus: http.StatusOK,
+			expectedBody: []string{
+				"<title>Travel &gt; Japan</title>",
+				"Day one &lt;script&gt;",
+				`<img src="/uploads/1_temple.jpg" alt="temple.jpg">`,
+				"tickets.pdf (3.0 MB)",
+				"@page",
+			},
+		},
+		{
+			name:           "Non-existent post",
+			postID:         "999",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/posts/"+tt.postID+"/print", nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			for _, expected := range tt.expectedBody {
+				if !strings.Contains(w.Body.String(), expected) {
+					t.Errorf("Expected body to contain %q", expected)
+				}
+			}
+		})
+	}
+}
+
+func TestPostHandler_PrintSpace(t *testing.T) {
+	setup, err := setupPostTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	parent, _ := setup.spaceService.Create(context.Background(), "Recipes", nil, "Family recipes")
+	child, _ := setup.spaceService.Create(context.Background(), "Desserts", &parent.ID, "")
+	for i, content := range []string{"First soup", "Second soup", "Third soup"} {
+		created := int64(1700000000000 + i*1000)
+		setup.postService.Create(context.Background(), parent.ID, content, &created)
+	}
+	setup.postService.Create(context.Background(), child.ID, "Lemon tart", nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/spaces/{id:[0-9]+}/print", setup.postHandler.PrintSpace).Methods("GET")
+	parentPath := "/api/spaces/" + strconv.Itoa(parent.ID) + "/print"
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   []string
+		unexpectedBody []string
+	}{
+		{
+			name:           "Direct posts oldest first",
+			path:           parentPath,
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"Family recipes", "3 posts", "First soup"},
+			unexpectedBody: []string{"Lemon tart", "Next posts"},
+		},
+		{
+			name:           "Recursive",
+			path:           parentPath + "?recursive=true",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"Lemon tart", "Recipes &gt; Desserts"},
+		},
+		{
+			name:           "Paginated",
+			path:           parentPath + "?limit=2",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"Newest posts 1 to 2 of 3", "Third soup", "offset=2"},
+			unexpectedBody: []string{"First soup"},
+		},
+		{
+			name:           "Non-existent space",
+			path:           "/api/spaces/999/print",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			body := w.Body.String()
+			for _, expected := range tt.expectedBody {
+				if !strings.Contains(body, expected) {
+					t.Errorf("Expected body to contain %q", expected)
+				}
+			}
+			for _, unexpected := range tt.unexpectedBody {
+				if strings.Contains(body, unexpected) {
+					t.Errorf("Expected body not to contain %q", unexpected)
+				}
+			}
+		})
+	}
+
+	// Printed pages read in chronological order
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", parentPath, nil))
+	body := w.Body.String()
+	if strings.Index(body, "First soup") > strings.Index(body, "Third soup") {
+		t.Error("Expected posts oldest first")
+	}
+}
@@ -3,6 +3,8 @@ package handlers
 import (
 	"backthynk/internal/config"
 	"backthynk/internal/core/logger"
+	"backthynk/internal/core/render"
+	"backthynk/internal/core/utils"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -19,6 +21,7 @@ func NewSettingsHandler() *SettingsHandler {
 
 func (h *SettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
 	options := config.GetOptionsConfig()
+	requestLocale := utils.NegotiateLocale(r.Header.Get("Accept-Language"), options.Metadata.Locale)
 
 	// Convert to frontend format
 	response := map[string]interface{}{
@@ -35,12 +38,25 @@ func (h *SettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
 		"fileUploadEnabled":                options.Features.FileUpload.Enabled,
 		"maxFileSizeMB":                    options.Features.FileUpload.MaxFileSizeMB,
 		"maxFilesPerPost":                  options.Features.FileUpload.MaxFilesPerPost,
+		"maxVersionsPerFile":               options.Features.FileUpload.MaxVersionsPerFile,
+		"keepRedactedOriginals":            options.Features.FileUpload.KeepRedactedOriginals,
 		"allowedFileExtensions":            options.Features.FileUpload.AllowedExtensions,
+
+		//locale
+		"locale":           options.Metadata.Locale,
+		"requestLocale":    requestLocale,
+		"localeInfo":       utils.GetLocaleInfo(requestLocale),
+		"supportedLocales": utils.SupportedLocaleCodes(),
+
+		//theme of server rendered pages
+		"theme":  options.Metadata.Theme,
+		"accent": options.Metadata.Accent,
 		
 		//version
 		"version": config.GetSharedConfig().App.Version,
 	}
 
+	w.Header().Set("Content-Language", requestLocale)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
@@ -67,6 +83,15 @@ func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request)
 	if val, ok := req["siteDescription"].(string); ok {
 		options.Metadata.Description = val
 	}
+	if val, ok := req["locale"].(string); ok {
+		options.Metadata.Locale = val
+	}
+	if val, ok := req["theme"].(string); ok {
+		options.Metadata.Theme = val
+	}
+	if val, ok := req["accent"].(string); ok {
+		options.Metadata.Accent = val
+	}
 
 	// Update feature settings
 	if val, ok := req["activityEnabled"].(bool); ok {
@@ -104,6 +129,12 @@ func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request)
 	if val, ok := req["maxFilesPerPost"].(float64); ok {
 		options.Features.FileUpload.MaxFilesPerPost = int(val)
 	}
+	if val, ok := req["maxVersionsPerFile"].(float64); ok {
+		options.Features.FileUpload.MaxVersionsPerFile = int(val)
+	}
+	if val, ok := req["keepRedactedOriginals"].(bool); ok {
+		options.Features.FileUpload.KeepRedactedOriginals = val
+	}
 	if val, ok := req["allowedFileExtensions"].([]interface{}); ok {
 		extensions := make([]string, 0, len(val))
 		for _, ext := range val {
@@ -150,7 +181,13 @@ func (h *SettingsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request)
 		"fileUploadEnabled":                options.Features.FileUpload.Enabled,
 		"maxFileSizeMB":                    options.Features.FileUpload.MaxFileSizeMB,
 		"maxFilesPerPost":                  options.Features.FileUpload.MaxFilesPerPost,
+		"maxVersionsPerFile":               options.Features.FileUpload.MaxVersionsPerFile,
+		"keepRedactedOriginals":            options.Features.FileUpload.KeepRedactedOriginals,
 		"allowedFileExtensions":            options.Features.FileUpload.AllowedExtensions,
+		"locale":                           options.Metadata.Locale,
+		"localeInfo":                       utils.GetLocaleInfo(options.Metadata.Locale),
+		"theme":                            options.Metadata.Theme,
+		"accent":                           options.Metadata.Accent,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -182,6 +219,15 @@ func (h *SettingsHandler) validateSettings(options *config.OptionsConfig) error
 		return fmt.Errorf(config.ErrValidationMaxFilesPerPostRange)
 	}
 
+	// Unset in options files written before versions were kept, the default applies
+	if versions := options.Features.FileUpload.MaxVersionsPerFile; versions != 0 && (versions < config.MinVersionsPerFile || versions > config.MaxVersionsPerFile) {
+		logger.Warning("Invalid max versions per file setting",
+			zap.Int("value", versions),
+			zap.Int("min", config.MinVersionsPerFile),
+			zap.Int("max", config.MaxVersionsPerFile))
+		return fmt.Errorf(config.ErrValidationMaxVersionsPerFileRange)
+	}
+
 	if len(options.Metadata.Title) < config.MinTitleLength || len(options.Metadata.Title) > config.MaxTitleLength {
 		logger.Warning("Invalid site title length",
 			zap.Int("length", len(options.Metadata.Title)),
@@ -197,5 +243,33 @@ func (h *SettingsHandler) validateSettings(options *config.OptionsConfig) error
 		return fmt.Errorf(config.ErrValidationSiteDescriptionMax)
 	}
 
+	if !utils.IsSupportedLocale(options.Metadata.Locale) {
+		logger.Warning("Unsupported locale setting",
+			zap.String("locale", options.Metadata.Locale))
+		return fmt.Errorf(config.ErrValidationLocaleUnsupported)
+	}
+
+	// Unset theme and accent fall back to the defaults
+	if theme := options.Metadata.Theme; theme != "" && !render.IsTheme(theme) {
+		logger.Warning("Unsupported theme setting",
+			zap.String("theme", theme))
+		return fmt.Errorf(config.ErrValidationThemeUnsupported)
+	}
+
+	if accent := options.Metadata.Accent; accent != "" && !render.IsAccent(accent) {
+		logger.Warning("Invalid accent setting",
+			zap.String("accent", accent))
+		return fmt.Errorf(config.ErrValidationAccentInvalid)
+	}
+
+	// Zero lifts a limit of the background jobs budget
+	if jobs := options.Jobs; jobs.MaxConcurrent < 0 || jobs.MaxIOMBps < 0 || jobs.PauseLatencyMs < 0 {
+		logger.Warning("Invalid background jobs budget",
+			zap.Int("max_concurrent", jobs.MaxConcurrent),
+			zap.Float64("max_io_mbps", jobs.MaxIOMBps),
+			zap.Int("pause_latency_ms", jobs.PauseLatencyMs))
+		return fmt.Errorf(config.ErrValidationJobBudgetNegative)
+	}
+
 	return nil
 }
\ No newline at end of file
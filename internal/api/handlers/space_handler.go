@@ -23,7 +23,7 @@ func NewSpaceHandler(service *services.SpaceService) *SpaceHandler {
 }
 
 func (h *SpaceHandler) GetSpaces(w http.ResponseWriter, r *http.Request) {
-	spaces := h.service.GetAll()
+	spaces := h.service.GetAllVisible(r.Context())
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(spaces)
@@ -37,7 +37,7 @@ func (h *SpaceHandler) GetSpace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	space, err := h.service.Get(id)
+	space, err := h.service.GetVisible(r.Context(), id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
@@ -60,7 +60,7 @@ func (h *SpaceHandler) GetSpacesByParent(w http.ResponseWriter, r *http.Request)
 		parentID = &id
 	}
 	
-	allSpaces := h.service.GetAll()
+	allSpaces := h.service.GetAllVisible(r.Context())
 	var filtered []*models.Space
 
 	for _, cat := range allSpaces {
@@ -98,7 +98,14 @@ func (h *SpaceHandler) CreateSpace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	space, err := h.service.Create(req.Name, req.ParentID, req.Description)
+	if req.ParentID != nil {
+		if err := h.service.CheckWrite(r.Context(), *req.ParentID); err != nil {
+			writeSpaceAccessError(w, err)
+			return
+		}
+	}
+
+	space, err := h.service.Create(r.Context(), req.Name, req.ParentID, req.Description)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -139,7 +146,18 @@ func (h *SpaceHandler) UpdateSpace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	space, err := h.service.Update(id, req.Name, req.Description, req.ParentID)
+	if err := h.service.CheckWrite(r.Context(), id); err != nil {
+		writeSpaceAccessError(w, err)
+		return
+	}
+	if req.ParentID != nil {
+		if err := h.service.CheckWrite(r.Context(), *req.ParentID); err != nil {
+			writeSpaceAccessError(w, err)
+			return
+		}
+	}
+
+	space, err := h.service.Update(r.Context(), id, req.Name, req.Description, req.ParentID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -157,10 +175,104 @@ func (h *SpaceHandler) DeleteSpace(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Delete(id); err != nil {
+	if err := h.service.CheckWrite(r.Context(), id); err != nil {
+		writeSpaceAccessError(w, err)
+		return
+	}
+
+	// Report what would be deleted without changing anything
+	if r.URL.Query().Get("dry_run") == "true" {
+		summary, err := h.service.PreviewDelete(r.Context(), id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
+// SearchSpaces handles GET /api/spaces/search for space pickers and autocompletion.
+// Query parameters:
+// - q: text to match against space names (empty lists the most recently active spaces)
+// - limit: maximum number of spaces returned (default 10, max 50)
+func (h *SpaceHandler) SearchSpaces(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := config.DefaultSpaceSearchLimit
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= config.MaxSpaceSearchLimit {
+		limit = l
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.Search(r.Context(), query.Get("q"), limit))
+}
+
+// GetCacheStats handles GET /api/admin/space-cache
+func (h *SpaceHandler) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.GetCacheStats())
+}
+
+// InvalidateCache handles POST /api/admin/space-cache/invalidate; without space_id the
+// whole cache is reconciled with the database
+func (h *SpaceHandler) InvalidateCache(w http.ResponseWriter, r *http.Request) {
+	var spaceID *int
+	if raw := r.URL.Query().Get("space_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+			return
+		}
+		spaceID = &id
+	}
+
+	if err := h.service.InvalidateCache(spaceID); err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == config.ErrSpaceNotFound {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.GetCacheStats())
+}
+
+// ReconcileCache handles POST /api/admin/space-cache/reconcile
+func (h *SpaceHandler) ReconcileCache(w http.ResponseWriter, r *http.Request) {
+	fixes, err := h.service.ReconcileCache()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"fixes": fixes,
+		"stats": h.service.GetCacheStats(),
+	})
+}
+
+// writeSpaceAccessError answers a request on a space the viewer may not reach: 404 when the
+// space is hidden from it, 403 when it may only read it
+func writeSpaceAccessError(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), spaceAccessStatus(err))
+}
+
+// spaceAccessStatus is the status answering an access check error
+func spaceAccessStatus(err error) int {
+	if err.Error() == config.ErrSpaceAccessDenied {
+		return http.StatusForbidden
+	}
+	return http.StatusNotFound
+}
@@ -8,6 +8,7 @@ import (
 	"backthynk/internal/core/services"
 	"backthynk/internal/storage"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -107,9 +108,9 @@ func TestSpaceHandler_GetSpaces(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test spaces
-	cat1, _ := setup.service.Create("Space 1", nil, "Description 1")
-	setup.service.Create("Space 2", nil, "Description 2")
-	setup.service.Create("Subspace", &cat1.ID, "Subspace desc")
+	cat1, _ := setup.service.Create(context.Background(), "Space 1", nil, "Description 1")
+	setup.service.Create(context.Background(), "Space 2", nil, "Description 2")
+	setup.service.Create(context.Background(), "Subspace", &cat1.ID, "Subspace desc")
 
 	req := httptest.NewRequest("GET", "/api/spaces", nil)
 	w := httptest.NewRecorder()
@@ -162,7 +163,7 @@ func TestSpaceHandler_GetSpace(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test space
-	cat, _ := setup.service.Create("Test Space", nil, "Test Description")
+	cat, _ := setup.service.Create(context.Background(), "Test Space", nil, "Test Description")
 
 	tests := []struct {
 		name           string
@@ -229,11 +230,11 @@ func TestSpaceHandler_GetSpacesByParent(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test spaces
-	parent1, _ := setup.service.Create("Parent 1", nil, "Parent 1 desc")
-	parent2, _ := setup.service.Create("Parent 2", nil, "Parent 2 desc")
-	setup.service.Create("Child 1", &parent1.ID, "Child 1 desc")
-	setup.service.Create("Child 2", &parent1.ID, "Child 2 desc")
-	setup.service.Create("Child 3", &parent2.ID, "Child 3 desc")
+	parent1, _ := setup.service.Create(context.Background(), "Parent 1", nil, "Parent 1 desc")
+	parent2, _ := setup.service.Create(context.Background(), "Parent 2", nil, "Parent 2 desc")
+	setup.service.Create(context.Background(), "Child 1", &parent1.ID, "Child 1 desc")
+	setup.service.Create(context.Background(), "Child 2", &parent1.ID, "Child 2 desc")
+	setup.service.Create(context.Background(), "Child 3", &parent2.ID, "Child 3 desc")
 
 	tests := []struct {
 		name           string
@@ -309,6 +310,55 @@ func TestSpaceHandler_GetSpacesByParent(t *testing.T) {
 	}
 }
 
+func TestSpaceHandler_SearchSpaces(t *testing.T) {
+	setup, err := setupSpaceTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	setup.service.Create(context.Background(), "Work", nil, "")
+	setup.service.Create(context.Background(), "Homework", nil, "")
+	setup.service.Create(context.Background(), "Garden", nil, "")
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedNames []string
+	}{
+		{"Best match first", "?q=work", []string{"Work", "Homework"}},
+		{"Limit", "?q=work&limit=1", []string{"Work"}},
+		{"Invalid limit uses default", "?q=gar&limit=abc", []string{"Garden"}},
+		{"No match", "?q=zzz", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/spaces/search"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			setup.handler.SearchSpaces(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+
+			var matches []models.SpaceMatch
+			if err := json.Unmarshal(w.Body.Bytes(), &matches); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(matches) != len(tt.expectedNames) {
+				t.Fatalf("Expected %d spaces, got %d", len(tt.expectedNames), len(matches))
+			}
+			for i, name := range tt.expectedNames {
+				if matches[i].Name != name {
+					t.Errorf("Expected %s at position %d, got %s", name, i, matches[i].Name)
+				}
+			}
+		})
+	}
+}
+
 func TestSpaceHandler_CreateSpace(t *testing.T) {
 	setup, err := setupSpaceTest()
 	if err != nil {
@@ -317,7 +367,7 @@ func TestSpaceHandler_CreateSpace(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create a parent space for testing
-	parent, _ := setup.service.Create("Parent Space", nil, "Parent desc")
+	parent, _ := setup.service.Create(context.Background(), "Parent Space", nil, "Parent desc")
 
 	tests := []struct {
 		name           string
@@ -482,8 +532,8 @@ func TestSpaceHandler_UpdateSpace(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test spaces
-	cat, _ := setup.service.Create("Original Space", nil, "Original desc")
-	parent, _ := setup.service.Create("Parent Space", nil, "Parent desc")
+	cat, _ := setup.service.Create(context.Background(), "Original Space", nil, "Original desc")
+	parent, _ := setup.service.Create(context.Background(), "Parent Space", nil, "Parent desc")
 
 	tests := []struct {
 		name           string
@@ -619,8 +669,8 @@ func TestSpaceHandler_DeleteSpace(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create test spaces
-	parent, _ := setup.service.Create("Parent Space", nil, "Parent desc")
-	setup.service.Create("Child Space", &parent.ID, "Child desc")
+	parent, _ := setup.service.Create(context.Background(), "Parent Space", nil, "Parent desc")
+	setup.service.Create(context.Background(), "Child Space", &parent.ID, "Child desc")
 
 	tests := []struct {
 		name           string
@@ -672,6 +722,62 @@ func TestSpaceHandler_DeleteSpace(t *testing.T) {
 	}
 }
 
+func TestSpaceHandler_DeleteSpaceDryRun(t *testing.T) {
+	setup, err := setupSpaceTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	// Create test data
+	parent, _ := setup.service.Create(context.Background(), "Parent Space", nil, "Parent desc")
+	child, _ := setup.service.Create(context.Background(), "Child Space", &parent.ID, "Child desc")
+	postService := services.NewPostService(setup.db, setup.cache, setup.dispatcher)
+	postService.Create(context.Background(), parent.ID, "Parent post", nil)
+	childPost, _ := postService.Create(context.Background(), child.ID, "Child post", nil)
+	setup.db.CreateAttachment(childPost.ID, "a.pdf", "a.pdf", "application/pdf", 2048)
+
+	parentID := strconv.Itoa(parent.ID)
+	req := httptest.NewRequest("DELETE", "/api/spaces/"+parentID+"?dry_run=true", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": parentID})
+	w := httptest.NewRecorder()
+
+	setup.handler.DeleteSpace(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var summary models.DryRunSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	if !summary.DryRun || summary.SpaceCount != 2 || summary.PostCount != 2 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if len(summary.DescendantSpaces) != 1 || summary.DescendantSpaces[0] != child.ID {
+		t.Errorf("Expected child as descendant, got %v", summary.DescendantSpaces)
+	}
+	if summary.AttachmentCount != 1 || summary.AttachmentBytes != 2048 {
+		t.Errorf("Expected 1 attachment of 2048 bytes, got %d / %d", summary.AttachmentCount, summary.AttachmentBytes)
+	}
+
+	// Nothing must have been deleted
+	if _, err := setup.service.Get(parent.ID); err != nil {
+		t.Error("Expected space to still exist after dry run")
+	}
+
+	// Unknown spaces are reported as not found
+	req = httptest.NewRequest("DELETE", "/api/spaces/999?dry_run=true", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "999"})
+	w = httptest.NewRecorder()
+	setup.handler.DeleteSpace(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for unknown space, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
 func TestSpaceHandler_CircularReferenceProtection(t *testing.T) {
 	setup, err := setupSpaceTest()
 	if err != nil {
@@ -680,9 +786,9 @@ func TestSpaceHandler_CircularReferenceProtection(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create a chain of spaces
-	cat1, _ := setup.service.Create("Space 1", nil, "Cat 1")
-	cat2, _ := setup.service.Create("Space 2", &cat1.ID, "Cat 2")
-	cat3, _ := setup.service.Create("Space 3", &cat2.ID, "Cat 3")
+	cat1, _ := setup.service.Create(context.Background(), "Space 1", nil, "Cat 1")
+	cat2, _ := setup.service.Create(context.Background(), "Space 2", &cat1.ID, "Cat 2")
+	cat3, _ := setup.service.Create(context.Background(), "Space 3", &cat2.ID, "Cat 3")
 
 	// Try to create a circular reference: cat1 -> cat3 (which would create cat1 -> cat3 -> cat2 -> cat1)
 	requestBody := map[string]interface{}{
@@ -727,7 +833,7 @@ func TestSpaceHandler_ConcurrentOperations(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create initial space
-	parent, _ := setup.service.Create("Parent", nil, "Parent desc")
+	parent, _ := setup.service.Create(context.Background(), "Parent", nil, "Parent desc")
 
 	// Test concurrent creates
 	numGoroutines := 10
@@ -785,9 +891,9 @@ func TestSpaceHandler_DataConsistency(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create spaces and verify consistent state
-	parent, _ := setup.service.Create("Parent", nil, "Parent desc")
-	child1, _ := setup.service.Create("Child 1", &parent.ID, "Child 1 desc")
-	setup.service.Create("Child 2", &parent.ID, "Child 2 desc")
+	parent, _ := setup.service.Create(context.Background(), "Parent", nil, "Parent desc")
+	child1, _ := setup.service.Create(context.Background(), "Child 1", &parent.ID, "Child 1 desc")
+	setup.service.Create(context.Background(), "Child 2", &parent.ID, "Child 2 desc")
 
 	// Test 1: Verify hierarchy consistency
 	req := httptest.NewRequest("GET", "/api/spaces/by-parent?parent_id="+strconv.Itoa(parent.ID), nil)
@@ -802,7 +908,7 @@ func TestSpaceHandler_DataConsistency(t *testing.T) {
 	}
 
 	// Test 2: Verify depth calculation
-	grandchild, _ := setup.service.Create("Grandchild", &child1.ID, "Grandchild desc")
+	grandchild, _ := setup.service.Create(context.Background(), "Grandchild", &child1.ID, "Grandchild desc")
 
 	req = httptest.NewRequest("GET", "/api/spaces/"+strconv.Itoa(grandchild.ID), nil)
 	req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(grandchild.ID)})
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http/httptest"
@@ -100,8 +101,8 @@ func TestSpaceSlugUniquenessWithHierarchy(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create parent spaces
-	parent1, _ := setup.service.Create("Parent 1", nil, "Parent 1")
-	parent2, _ := setup.service.Create("Parent 2", nil, "Parent 2")
+	parent1, _ := setup.service.Create(context.Background(), "Parent 1", nil, "Parent 1")
+	parent2, _ := setup.service.Create(context.Background(), "Parent 2", nil, "Parent 2")
 
 	tests := []struct {
 		name        string
@@ -171,8 +172,8 @@ func TestSpaceUpdateSlugUniqueness(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create initial spaces
-	space1, _ := setup.service.Create("Space One", nil, "First space")
-	space2, _ := setup.service.Create("Space Two", nil, "Second space")
+	space1, _ := setup.service.Create(context.Background(), "Space One", nil, "First space")
+	space2, _ := setup.service.Create(context.Background(), "Space Two", nil, "Second space")
 
 	tests := []struct {
 		name        string
@@ -4,6 +4,7 @@ import (
 	"backthynk/internal/config"
 	"backthynk/internal/core/models"
 	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
 	"backthynk/internal/embedded"
 	"html/template"
 	"io/fs"
@@ -35,9 +36,11 @@ type PageData struct {
 	SpaceBreadcrumb string
 	MarkdownEnabled    bool
 	Dev                bool
+	LiveReload         bool
 	Version            string
 	GithubURL          string
 	DiscordURL        string
+	Locale             string
 }
 
 func (h *TemplateHandler) ServePage(w http.ResponseWriter, r *http.Request) {
@@ -50,9 +53,11 @@ func (h *TemplateHandler) ServePage(w http.ResponseWriter, r *http.Request) {
 		URL:             r.Host + path,
 		MarkdownEnabled: h.options.Features.Markdown.Enabled,
 		Dev:             config.GetAppMode() == config.APP_MODE_DEV,
+		LiveReload:      config.IsDevMode(),
 		Version:         sharedCfg.App.Version,
 		GithubURL:       sharedCfg.URLs.GithubURL,
 		DiscordURL:     sharedCfg.URLs.NewIssueURL,
+		Locale:          utils.NegotiateLocale(r.Header.Get("Accept-Language"), h.options.Metadata.Locale),
 	}
 
 	// Check if this is a space path
@@ -79,6 +84,9 @@ func (h *TemplateHandler) ServePage(w http.ResponseWriter, r *http.Request) {
 	if config.GetAppMode() == config.APP_MODE_PROD {
 		h.renderEmbeddedTemplate(w, pageData)
 	} else {
+		if pageData.LiveReload {
+			w.Header().Set("Cache-Control", "no-store")
+		}
 		templatePath := filepath.Join(sharedCfg.GetWebTemplatesPath(), "index.html")
 		h.renderTemplate(w, templatePath, pageData)
 	}
@@ -104,6 +112,7 @@ func (h *TemplateHandler) renderEmbeddedTemplate(w http.ResponseWriter, data Pag
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Language", data.Locale)
 	if err := tmpl.Execute(w, data); err != nil {
 		http.Error(w, config.ErrTemplateExecutionError, http.StatusInternalServerError)
 		return
@@ -118,6 +127,7 @@ func (h *TemplateHandler) renderTemplate(w http.ResponseWriter, templatePath str
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Language", data.Locale)
 	if err := tmpl.Execute(w, data); err != nil {
 		http.Error(w, config.ErrTemplateExecutionError, http.StatusInternalServerError)
 		return
@@ -2,28 +2,40 @@ package handlers
 
 import (
 	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
 	"backthynk/internal/core/services"
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"go.uber.org/zap"
 )
 
 type UploadHandler struct {
+	postService *services.PostService
 	fileService *services.FileService
 	options     *config.OptionsConfig
 }
 
-func NewUploadHandler(fileService *services.FileService, options *config.OptionsConfig) *UploadHandler {
+func NewUploadHandler(postService *services.PostService, fileService *services.FileService, options *config.OptionsConfig) *UploadHandler {
 	return &UploadHandler{
+		postService: postService,
 		fileService: fileService,
 		options:     options,
 	}
 }
 
+// UploadFile handles POST /api/upload.
+// Clients following the upload set the X-Upload-Session header to an ID of their choice
+// and listen to its progress events before sending the request.
 func (h *UploadHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 	// Check if file upload is enabled
 	if !h.options.Features.FileUpload.Enabled {
@@ -31,24 +43,260 @@ func (h *UploadHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var progress *services.UploadProgress
+	if sessionID := r.Header.Get(config.UploadSessionHeader); sessionID != "" {
+		if !services.ValidUploadSession(sessionID) {
+			http.Error(w, config.ErrInvalidUploadSession, http.StatusBadRequest)
+			return
+		}
+		progress = h.fileService.NewUploadProgress(sessionID, r.ContentLength)
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{progress.Reader(r.Body), r.Body}
+	}
+	fail := func(message string, status int) {
+		progress.Fail(message)
+		http.Error(w, message, status)
+	}
+
 	maxFileSizeMB := int64(h.options.Features.FileUpload.MaxFileSizeMB)
 	if err := r.ParseMultipartForm(maxFileSizeMB << 20); err != nil {
-		http.Error(w, config.ErrFailedToParseForm, http.StatusBadRequest)
+		fail(config.ErrFailedToParseForm, http.StatusBadRequest)
 		return
 	}
 
 	postIDStr := r.FormValue("post_id")
 	if postIDStr == "" {
-		http.Error(w, config.ErrPostIDRequired, http.StatusBadRequest)
+		fail(config.ErrPostIDRequired, http.StatusBadRequest)
 		return
 	}
 
 	postID, err := strconv.Atoi(postIDStr)
+	if err != nil {
+		fail(config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+	if err := h.postService.CheckPost(r.Context(), postID, true); err != nil {
+		fail(err.Error(), spaceAccessStatus(err))
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		fail(config.ErrFailedToGetFile, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// Check size, extension, files of the post and disk space; unknown posts fail on save
+	files, _ := h.fileService.UploadTarget(postID, 0)
+	if rejections := h.checkUpload(fileHeader.Filename, fileHeader.Size, files); len(rejections) > 0 {
+		fail(rejections[0].Message, http.StatusBadRequest)
+		return
+	}
+
+	progress.Processing(postID, fileHeader.Filename)
+	attachment, err := h.fileService.UploadFile(r.Context(), postID, file, fileHeader.Filename, fileHeader.Size)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), config.ErrContentContainsSecrets) {
+			fail(err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err.Error() == config.ErrFilenameCollisions {
+			fail(err.Error(), http.StatusConflict)
+			return
+		}
+		fail(err.Error(), http.StatusInternalServerError)
+		return
+	}
+	progress.Done(attachment.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// ValidateUpload handles POST /api/upload/validate: it tells whether an upload would be
+// accepted, and why not, from its filename and size alone, so clients can fail before sending
+// the file. The target is either an existing post or a space for a post about to be created.
+func (h *UploadHandler) ValidateUpload(w http.ResponseWriter, r *http.Request) {
+	if !h.options.Features.FileUpload.Enabled {
+		http.Error(w, config.ErrFileUploadDisabled, http.StatusForbidden)
+		return
+	}
+
+	var req struct {
+		Filename string `json:"filename"`
+		Size     int64  `json:"size"`
+		PostID   int    `json:"post_id"`
+		SpaceID  int    `json:"space_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Filename) == "" {
+		http.Error(w, config.ErrUploadFilenameRequired, http.StatusBadRequest)
+		return
+	}
+	if req.Size < 0 {
+		http.Error(w, config.ErrInvalidUploadSize, http.StatusBadRequest)
+		return
+	}
+	if req.PostID == 0 && req.SpaceID == 0 {
+		http.Error(w, config.ErrUploadTargetRequired, http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if req.PostID != 0 {
+		err = h.postService.CheckPost(r.Context(), req.PostID, true)
+	} else {
+		err = h.postService.CheckSpace(r.Context(), req.SpaceID, true)
+	}
+	if err != nil {
+		writeSpaceAccessError(w, err)
+		return
+	}
+
+	files, err := h.fileService.UploadTarget(req.PostID, req.SpaceID)
+	if err != nil {
+		if err.Error() == config.ErrPostNotFound || err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rejections := h.checkUpload(req.Filename, req.Size, files)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.UploadValidation{Accepted: len(rejections) == 0, Rejections: rejections})
+}
+
+// checkUpload returns the checks failed by a file of size bytes added to a post that already
+// has files attached, in the order uploads check them
+func (h *UploadHandler) checkUpload(filename string, size int64, files int) []models.UploadRejection {
+	rejections := []models.UploadRejection{}
+	reject := func(check, message string) {
+		rejections = append(rejections, models.UploadRejection{Check: check, Message: message})
+	}
+
+	maxFileSizeMB := h.options.Features.FileUpload.MaxFileSizeMB
+	if size > int64(maxFileSizeMB)<<20 {
+		reject(models.UploadCheckSize, fmt.Sprintf(config.ErrFmtFileSizeExceedsMax, maxFileSizeMB))
+	}
+
+	ext := filepath.Ext(filename)
+	if ext != "" {
+		ext = ext[1:] // Remove the leading dot
+	}
+	if !h.isExtensionAllowed(ext) {
+		reject(models.UploadCheckExtension, fmt.Sprintf(config.ErrFmtFileExtensionNotAllowed, ext))
+	}
+
+	if maxFiles := h.options.Features.FileUpload.MaxFilesPerPost; maxFiles > 0 && files >= maxFiles {
+		reject(models.UploadCheckMaxFiles, fmt.Sprintf(config.ErrFmtMaxFilesPerPostReached, maxFiles))
+	}
+
+	if free, ok := h.fileService.AvailableSpace(); ok && size > free {
+		reject(models.UploadCheckQuota, fmt.Sprintf(config.ErrFmtNotEnoughDiskSpace, free>>20))
+	}
+
+	return rejections
+}
+
+// AttachURL handles POST /api/posts/{id}/attach-url: the server downloads the file at the
+// given URL and attaches it to the post, with the same limits as uploads
+func (h *UploadHandler) AttachURL(w http.ResponseWriter, r *http.Request) {
+	if !h.options.Features.FileUpload.Enabled {
+		http.Error(w, config.ErrFileUploadDisabled, http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+	postID, err := strconv.Atoi(vars["id"])
 	if err != nil {
 		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
 		return
 	}
 
+	var req struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.fileService.GetPostWithAttachments(r.Context(), postID); err != nil {
+		http.Error(w, config.ErrPostNotFound, http.StatusNotFound)
+		return
+	}
+	if err := h.postService.CheckPost(r.Context(), postID, true); err != nil {
+		writeSpaceAccessError(w, err)
+		return
+	}
+
+	maxFileSizeMB := h.options.Features.FileUpload.MaxFileSizeMB
+	remote, err := h.fileService.FetchRemoteFile(r.Context(), req.URL, int64(maxFileSizeMB)<<20, maxFileSizeMB)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), fmt.Sprintf(config.ErrFmtRemoteFetchFailed, "")) {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ext := filepath.Ext(remote.Filename)
+	if ext != "" {
+		ext = ext[1:] // Remove the leading dot
+	}
+	if !h.isExtensionAllowed(ext) {
+		http.Error(w, fmt.Sprintf(config.ErrFmtFileExtensionNotAllowed, ext), http.StatusBadRequest)
+		return
+	}
+
+	attachment, err := h.fileService.UploadFile(r.Context(), postID, bytes.NewReader(remote.Data), remote.Filename, int64(len(remote.Data)))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), config.ErrContentContainsSecrets) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// UploadVersion handles POST /api/files/{id}/versions: the uploaded file becomes the current
+// version of the attachment, the replaced one stays available in its versions
+func (h *UploadHandler) UploadVersion(w http.ResponseWriter, r *http.Request) {
+	if !h.options.Features.FileUpload.Enabled {
+		http.Error(w, config.ErrFileUploadDisabled, http.StatusForbidden)
+		return
+	}
+
+	attachmentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidAttachmentID, http.StatusBadRequest)
+		return
+	}
+	if !h.reachAttachment(w, r, attachmentID, true) {
+		return
+	}
+
+	maxFileSizeMB := int64(h.options.Features.FileUpload.MaxFileSizeMB)
+	if err := r.ParseMultipartForm(maxFileSizeMB << 20); err != nil {
+		http.Error(w, config.ErrFailedToParseForm, http.StatusBadRequest)
+		return
+	}
+
 	file, fileHeader, err := r.FormFile("file")
 	if err != nil {
 		http.Error(w, config.ErrFailedToGetFile, http.StatusBadRequest)
@@ -56,13 +304,11 @@ func (h *UploadHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	// Check file size
 	if fileHeader.Size > maxFileSizeMB<<20 {
 		http.Error(w, fmt.Sprintf(config.ErrFmtFileSizeExceedsMax, h.options.Features.FileUpload.MaxFileSizeMB), http.StatusBadRequest)
 		return
 	}
 
-	// Validate file extension
 	ext := filepath.Ext(fileHeader.Filename)
 	if ext != "" {
 		ext = ext[1:] // Remove the leading dot
@@ -72,8 +318,25 @@ func (h *UploadHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	attachment, err := h.fileService.UploadFile(postID, file, fileHeader.Filename, fileHeader.Size)
+	keep := h.options.Features.FileUpload.MaxVersionsPerFile
+	if keep == 0 {
+		keep = config.DefaultVersionsPerFile
+	}
+
+	attachment, err := h.fileService.UploadVersion(r.Context(), attachmentID, file, fileHeader.Filename, keep)
 	if err != nil {
+		if err.Error() == "attachment not found" {
+			http.Error(w, config.ErrAttachmentNotFound, http.StatusNotFound)
+			return
+		}
+		if strings.HasPrefix(err.Error(), config.ErrContentContainsSecrets) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err.Error() == config.ErrFilenameCollisions {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -83,6 +346,104 @@ func (h *UploadHandler) UploadFile(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(attachment)
 }
 
+// RedactImage handles POST /api/files/{id}/redact: the regions given are blurred or blacked out
+// and the result becomes the current version of the attachment. Whether the original is kept
+// as a prior version is set by the keepRedactedOriginals upload policy.
+func (h *UploadHandler) RedactImage(w http.ResponseWriter, r *http.Request) {
+	if !h.options.Features.FileUpload.Enabled {
+		http.Error(w, config.ErrFileUploadDisabled, http.StatusForbidden)
+		return
+	}
+
+	attachmentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidAttachmentID, http.StatusBadRequest)
+		return
+	}
+	if !h.reachAttachment(w, r, attachmentID, true) {
+		return
+	}
+
+	var req models.RedactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidRedactRequest, http.StatusBadRequest)
+		return
+	}
+
+	keep := h.options.Features.FileUpload.MaxVersionsPerFile
+	if keep == 0 {
+		keep = config.DefaultVersionsPerFile
+	}
+
+	attachment, err := h.fileService.RedactImage(r.Context(), attachmentID, req.Regions, h.options.Features.FileUpload.KeepRedactedOriginals, keep)
+	if err != nil {
+		switch err.Error() {
+		case "attachment not found":
+			http.Error(w, config.ErrAttachmentNotFound, http.StatusNotFound)
+		case config.ErrInvalidRedactRegions, config.ErrImageTooLargeToRedact:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case config.ErrImageNotRedactable:
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		case config.ErrFilenameCollisions:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// GetVersions handles GET /api/files/{id}/versions. Prior versions are served from
+// /uploads/{file_path} like the current file.
+func (h *UploadHandler) GetVersions(w http.ResponseWriter, r *http.Request) {
+	attachmentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidAttachmentID, http.StatusBadRequest)
+		return
+	}
+	if !h.reachAttachment(w, r, attachmentID, false) {
+		return
+	}
+
+	versions, err := h.fileService.GetVersions(attachmentID)
+	if err != nil {
+		if err.Error() == "attachment not found" {
+			http.Error(w, config.ErrAttachmentNotFound, http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(versions)
+}
+
+// reachAttachment writes the error and returns false when the viewer may not read the post of
+// an attachment, or change it when write is set. Attachments of hidden spaces are not found.
+func (h *UploadHandler) reachAttachment(w http.ResponseWriter, r *http.Request, attachmentID int, write bool) bool {
+	postID, err := h.fileService.AttachmentPost(attachmentID)
+	if err == nil {
+		err = h.postService.CheckPost(r.Context(), postID, write)
+	}
+	if err == nil {
+		return true
+	}
+	switch err.Error() {
+	case "attachment not found", config.ErrPostNotFound:
+		http.Error(w, config.ErrAttachmentNotFound, http.StatusNotFound)
+	case config.ErrSpaceAccessDenied:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return false
+}
+
 func (h *UploadHandler) isExtensionAllowed(ext string) bool {
 	ext = filepath.Ext("." + ext)
 	if ext != "" {
@@ -98,9 +459,23 @@ func (h *UploadHandler) isExtensionAllowed(ext string) bool {
 	return false
 }
 
+// ServeFile handles GET and HEAD /uploads/{filename}
+// Query parameters:
+// - download: 1 to save the file under its original filename instead of displaying it
+// - expires, signature: set on signed URLs, the file is refused once they no longer match
 func (h *UploadHandler) ServeFile(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	filename := vars["filename"]
+
+	if signature := r.URL.Query().Get("signature"); signature != "" {
+		if err := h.fileService.VerifySignedURL(filename, r.URL.Query().Get("expires"), signature); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+	} else if !h.reachFile(r, filename) {
+		http.NotFound(w, r)
+		return
+	}
 	
 	filePath := filepath.Join(config.GetServiceConfig().Files.StoragePath, config.GetServiceConfig().Files.UploadsSubdir, filename)
 	
@@ -113,5 +488,56 @@ func (h *UploadHandler) ServeFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	http.ServeFile(w, r, filePath)
+	// Files without an attachment record are served without checksum or original filename
+	if attachment, err := h.fileService.GetDownload(filename); err == nil {
+		w.Header().Set(config.ChecksumHeader, attachment.SHA256)
+		if r.URL.Query().Get("download") == "1" {
+			w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+		}
+	}
+
+	recorder := &downloadRecorder{ResponseWriter: w}
+	http.ServeFile(recorder, r, filePath)
+
+	// HEAD requests, resumed transfers and cache revalidations are not counted as new downloads
+	if r.Method == http.MethodHead {
+		return
+	}
+	rangeHeader := r.Header.Get("Range")
+	if recorder.status == http.StatusOK || (recorder.status == http.StatusPartialContent && strings.HasPrefix(rangeHeader, "bytes=0-")) {
+		if err := h.fileService.RecordDownload(filename); err != nil {
+			logger.Warning("Failed to record download", zap.String("filename", filename), zap.Error(err))
+		}
+	}
+}
+
+// reachFile tells whether the viewer of a request may read a stored file. Signed URLs carry
+// their own grant and files without an attachment record belong to no space.
+func (h *UploadHandler) reachFile(r *http.Request, filename string) bool {
+	if _, ok := services.ViewerFromContext(r.Context()); !ok {
+		return true
+	}
+	postID, err := h.fileService.FilePost(filename)
+	if err != nil {
+		return err.Error() == "attachment not found"
+	}
+	return h.postService.CheckPost(r.Context(), postID, false) == nil
+}
+
+// downloadRecorder captures the status written by http.ServeFile
+type downloadRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *downloadRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *downloadRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
 }
\ No newline at end of file
@@ -8,14 +8,24 @@ import (
 	"backthynk/internal/core/services"
 	"backthynk/internal/storage"
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -85,7 +95,7 @@ func setupUploadTest(t *testing.T) (*uploadTestSetup, func()) {
 	}
 
 	// Create a test space
-	if _, err := spaceService.Create("Test Space", nil, ""); err != nil {
+	if _, err := spaceService.Create(context.Background(), "Test Space", nil, ""); err != nil {
 		t.Fatal(err)
 	}
 
@@ -93,7 +103,7 @@ func setupUploadTest(t *testing.T) (*uploadTestSetup, func()) {
 	options := config.NewTestOptionsConfig()
 
 	// Create handler
-	handler := NewUploadHandler(fileService, options)
+	handler := NewUploadHandler(postService, fileService, options)
 
 	setup := &uploadTestSetup{
 		handler:       handler,
@@ -150,7 +160,7 @@ func TestUploadFile_Success(t *testing.T) {
 	defer cleanup()
 
 	// Create a test post
-	post, err := setup.postService.Create(1, "Test post", nil)
+	post, err := setup.postService.Create(context.Background(), 1, "Test post", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -178,7 +188,7 @@ func TestUploadFile_DisabledFeature(t *testing.T) {
 
 	// Create a test post
 	postService := setup.postService
-	post, err := postService.Create(1, "Test post", nil)
+	post, err := postService.Create(context.Background(), 1, "Test post", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -212,7 +222,7 @@ func TestUploadFile_DisallowedExtension(t *testing.T) {
 
 	// Create a test post
 	postService := setup.postService
-	post, err := postService.Create(1, "Test post", nil)
+	post, err := postService.Create(context.Background(), 1, "Test post", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -246,7 +256,7 @@ func TestUploadFile_AllowedExtension(t *testing.T) {
 
 	// Create a test post
 	postService := setup.postService
-	post, err := postService.Create(1, "Test post", nil)
+	post, err := postService.Create(context.Background(), 1, "Test post", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -275,7 +285,7 @@ func TestUploadFile_ExceedsMaxSize(t *testing.T) {
 
 	// Create a test post
 	postService := setup.postService
-	post, err := postService.Create(1, "Test post", nil)
+	post, err := postService.Create(context.Background(), 1, "Test post", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -309,7 +319,7 @@ func TestUploadFile_WithinMaxSize(t *testing.T) {
 
 	// Create a test post
 	postService := setup.postService
-	post, err := postService.Create(1, "Test post", nil)
+	post, err := postService.Create(context.Background(), 1, "Test post", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -377,7 +387,7 @@ func TestUploadFile_MissingFile(t *testing.T) {
 
 	// Create a test post
 	postService := setup.postService
-	post, err := postService.Create(1, "Test post", nil)
+	post, err := postService.Create(context.Background(), 1, "Test post", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -407,7 +417,7 @@ func TestServeFile_Success(t *testing.T) {
 
 	// Create a test post
 	postService := setup.postService
-	post, err := postService.Create(1, "Test post", nil)
+	post, err := postService.Create(context.Background(), 1, "Test post", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -447,6 +457,232 @@ func TestServeFile_Success(t *testing.T) {
 	}
 }
 
+func TestServeFile_RecordsDownloads(t *testing.T) {
+	setup, cleanup := setupUploadTest(t)
+	defer cleanup()
+
+	post, err := setup.postService.Create(context.Background(), 1, "Test post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uploadReq, _ := createMultipartRequest(t, strconv.Itoa(post.ID), "manual.pdf", []byte("reference document content"))
+	uploadRR := httptest.NewRecorder()
+	setup.handler.UploadFile(uploadRR, uploadReq)
+
+	var attachment models.Attachment
+	if err := parseJSON(uploadRR.Body, &attachment); err != nil {
+		t.Fatal(err)
+	}
+
+	var downloads []events.PostEvent
+	setup.dispatcher.Subscribe(events.FileDownloaded, func(event events.Event) error {
+		downloads = append(downloads, event.Data.(events.PostEvent))
+		return nil
+	})
+
+	tests := []struct {
+		name           string
+		filename       string
+		rangeHeader    string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{"Full download", attachment.FilePath, "", http.StatusOK, 1},
+		{"Range from start", attachment.FilePath, "bytes=0-9", http.StatusPartialContent, 2},
+		{"Resumed transfer", attachment.FilePath, "bytes=10-", http.StatusPartialContent, 2},
+		{"Missing file", "missing.pdf", "", http.StatusNotFound, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/uploads/"+tt.filename, nil)
+			req = mux.SetURLVars(req, map[string]string{"filename": tt.filename})
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+
+			rr := httptest.NewRecorder()
+			setup.handler.ServeFile(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if len(downloads) != tt.expectedCount {
+				t.Errorf("Expected %d recorded downloads, got %d", tt.expectedCount, len(downloads))
+			}
+		})
+	}
+
+	if downloads[0].AttachmentID != attachment.ID || downloads[0].SpaceID != 1 {
+		t.Errorf("Unexpected download event: %+v", downloads[0])
+	}
+}
+
+func TestServeFile_ChecksumAndDisposition(t *testing.T) {
+	setup, cleanup := setupUploadTest(t)
+	defer cleanup()
+
+	post, err := setup.postService.Create(context.Background(), 1, "Test post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("quarterly report content")
+	uploadReq, _ := createMultipartRequest(t, strconv.Itoa(post.ID), "rapport trimestriel é.pdf", content)
+	uploadRR := httptest.NewRecorder()
+	setup.handler.UploadFile(uploadRR, uploadReq)
+
+	var attachment models.Attachment
+	if err := parseJSON(uploadRR.Body, &attachment); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	expected := hex.EncodeToString(sum[:])
+	if attachment.SHA256 != expected {
+		t.Errorf("Expected upload response checksum %s, got %q", expected, attachment.SHA256)
+	}
+
+	var downloads int
+	setup.dispatcher.Subscribe(events.FileDownloaded, func(event events.Event) error {
+		downloads++
+		return nil
+	})
+
+	serve := func(method, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/uploads/"+url.PathEscape(attachment.FilePath)+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"filename": attachment.FilePath})
+		rr := httptest.NewRecorder()
+		setup.handler.ServeFile(rr, req)
+		return rr
+	}
+
+	rr := serve("GET", "")
+	if rr.Header().Get(config.ChecksumHeader) != expected || rr.Header().Get("Content-Disposition") != "" {
+		t.Errorf("Expected checksum without disposition, got %v", rr.Header())
+	}
+
+	rr = serve("GET", "?download=1")
+	disposition, params, err := mime.ParseMediaType(rr.Header().Get("Content-Disposition"))
+	if err != nil || disposition != "attachment" || params["filename"] != attachment.Filename {
+		t.Errorf("Expected attachment disposition with the original filename, got %q", rr.Header().Get("Content-Disposition"))
+	}
+
+	rr = serve("HEAD", "")
+	if rr.Code != http.StatusOK || rr.Body.Len() != 0 || rr.Header().Get(config.ChecksumHeader) != expected {
+		t.Errorf("Expected HEAD to answer headers only, got %d with %d bytes", rr.Code, rr.Body.Len())
+	}
+	if downloads != 2 {
+		t.Errorf("Expected HEAD requests not to count as downloads, got %d", downloads)
+	}
+
+	// Files uploaded before checksums were kept get one on their next download
+	setup.db.Exec("DELETE FROM attachment_checksums")
+	if rr = serve("GET", ""); rr.Header().Get(config.ChecksumHeader) != expected {
+		t.Errorf("Expected checksum to be backfilled, got %q", rr.Header().Get(config.ChecksumHeader))
+	}
+	var stored string
+	setup.db.QueryRow("SELECT sha256 FROM attachment_checksums WHERE attachment_id = ?", attachment.ID).Scan(&stored)
+	if stored != expected {
+		t.Errorf("Expected backfilled checksum to be stored, got %q", stored)
+	}
+}
+
+// stubScreener redacts "secret" and rejects content containing "forbidden"
+type stubScreener struct {
+	recorded []string
+}
+
+func (s *stubScreener) Screen(spaceID int, source, content string) (string, []string, error) {
+	if strings.Contains(content, "forbidden") {
+		return "", nil, fmt.Errorf(config.ErrFmtContentContainsSecret, "stub")
+	}
+	if strings.Contains(content, "secret") {
+		return strings.ReplaceAll(content, "secret", "[REDACTED]"), []string{"Redacted stub"}, nil
+	}
+	return content, nil, nil
+}
+
+func (s *stubScreener) RecordFindings(spaceID, postID int, attachmentID *int, source, content string) error {
+	s.recorded = append(s.recorded, source)
+	return nil
+}
+
+func TestUploadFile_SecretScreening(t *testing.T) {
+	setup, cleanup := setupUploadTest(t)
+	defer cleanup()
+
+	screener := &stubScreener{}
+	setup.fileService.SetSecretScreener(screener)
+
+	post, err := setup.postService.Create(context.Background(), 1, "Test post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		filename        string
+		content         string
+		expectedStatus  int
+		expectedContent string
+		expectWarnings  bool
+	}{
+		{"Clean text file", "notes.txt", "nothing here", http.StatusCreated, "nothing here", false},
+		{"Redacted text file", "env.txt", "token=secret", http.StatusCreated, "token=[REDACTED]", true},
+		{"Rejected text file", "keys.txt", "forbidden key", http.StatusBadRequest, "", false},
+		{"Binary file not scanned", "doc.pdf", "forbidden secret", http.StatusCreated, "forbidden secret", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := createMultipartRequest(t, strconv.Itoa(post.ID), tt.filename, []byte(tt.content))
+			rr := httptest.NewRecorder()
+			setup.handler.UploadFile(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if rr.Code != http.StatusCreated {
+				return
+			}
+
+			var attachment models.Attachment
+			if err := parseJSON(rr.Body, &attachment); err != nil {
+				t.Fatal(err)
+			}
+			if (len(attachment.Warnings) > 0) != tt.expectWarnings {
+				t.Errorf("Expected warnings %v, got %v", tt.expectWarnings, attachment.Warnings)
+			}
+
+			stored, err := os.ReadFile(filepath.Join(setup.uploadsDir, attachment.FilePath))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(stored) != tt.expectedContent {
+				t.Errorf("Expected stored content %q, got %q", tt.expectedContent, stored)
+			}
+			if attachment.FileSize != int64(len(tt.expectedContent)) {
+				t.Errorf("Expected file size %d, got %d", len(tt.expectedContent), attachment.FileSize)
+			}
+			if sum := sha256.Sum256(stored); attachment.SHA256 != hex.EncodeToString(sum[:]) {
+				t.Errorf("Expected the checksum of the stored content, got %q", attachment.SHA256)
+			}
+		})
+	}
+
+	if len(screener.recorded) != 1 || screener.recorded[0] != "env.txt" {
+		t.Errorf("Expected findings recorded for env.txt only, got %v", screener.recorded)
+	}
+
+	entries, _ := os.ReadDir(setup.uploadsDir)
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), "keys.txt") {
+			t.Error("Expected rejected file to be removed")
+		}
+	}
+}
+
 func TestIsExtensionAllowed(t *testing.T) {
 	setup, cleanup := setupUploadTest(t)
 	defer cleanup()
@@ -497,3 +733,705 @@ func parseJSON(body io.Reader, v interface{}) error {
 	}
 	return json.Unmarshal(data, v)
 }
+
+func attachURLRequest(postID int, rawURL string) *http.Request {
+	body, _ := json.Marshal(map[string]string{"url": rawURL})
+	req := httptest.NewRequest("POST", "/api/posts/"+strconv.Itoa(postID)+"/attach-url", bytes.NewBuffer(body))
+	return mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(postID)})
+}
+
+func TestAttachURL(t *testing.T) {
+	setup, cleanup := setupUploadTest(t)
+	defer cleanup()
+
+	pngData := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/photo.png", "/files/image":
+			w.Write(pngData)
+		case "/files/report":
+			w.Header().Set("Content-Disposition", `attachment; filename="../report.txt"`)
+			w.Write([]byte("quarterly numbers"))
+		case "/files/login.pdf":
+			w.Write([]byte("<!DOCTYPE html><html><body>Please sign in</body></html>"))
+		case "/files/script.sh":
+			w.Write([]byte("#!/bin/sh\necho hi\n"))
+		case "/files/huge.zip":
+			w.Write(bytes.Repeat([]byte("a"), 6<<20))
+		case "/moved":
+			http.Redirect(w, r, "/files/photo.png", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer remote.Close()
+
+	post, err := setup.postService.Create(context.Background(), 1, "Test post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The default client refuses the test server as it listens on loopback
+	rr := httptest.NewRecorder()
+	setup.handler.AttachURL(rr, attachURLRequest(post.ID, remote.URL+"/files/photo.png"))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), config.ErrRemoteURLForbidden) {
+		t.Fatalf("Expected loopback URL to be refused, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	setup.fileService.SetRemoteClient(remote.Client())
+
+	tests := []struct {
+		name             string
+		postID           int
+		path             string
+		expectedStatus   int
+		expectedFilename string
+	}{
+		{"Named file", post.ID, "/files/photo.png", http.StatusCreated, "photo.png"},
+		{"Extension from content", post.ID, "/files/image", http.StatusCreated, "image.png"},
+		{"Content-Disposition filename", post.ID, "/files/report", http.StatusCreated, "report.txt"},
+		{"Redirect", post.ID, "/moved", http.StatusCreated, "photo.png"},
+		{"Web page instead of file", post.ID, "/files/login.pdf", http.StatusBadRequest, ""},
+		{"Disallowed extension", post.ID, "/files/script.sh", http.StatusBadRequest, ""},
+		{"Too large", post.ID, "/files/huge.zip", http.StatusBadRequest, ""},
+		{"Remote error", post.ID, "/missing.png", http.StatusBadGateway, ""},
+		{"Unknown post", 999, "/files/photo.png", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			setup.handler.AttachURL(rr, attachURLRequest(tt.postID, remote.URL+tt.path))
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if tt.expectedFilename == "" {
+				return
+			}
+
+			var attachment models.Attachment
+			if err := json.Unmarshal(rr.Body.Bytes(), &attachment); err != nil {
+				t.Fatalf("Failed to decode attachment: %v", err)
+			}
+			if attachment.Filename != tt.expectedFilename || attachment.PostID != post.ID {
+				t.Errorf("Unexpected attachment: %+v", attachment)
+			}
+		})
+	}
+
+	for _, rawURL := range []string{"ftp://example.com/file.txt", "file:///etc/passwd", "not a url"} {
+		rr := httptest.NewRecorder()
+		setup.handler.AttachURL(rr, attachURLRequest(post.ID, rawURL))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %q, got %d", rawURL, rr.Code)
+		}
+	}
+
+	setup.options.Features.FileUpload.Enabled = false
+	rr = httptest.NewRecorder()
+	setup.handler.AttachURL(rr, attachURLRequest(post.ID, remote.URL+"/files/photo.png"))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 with uploads disabled, got %d", rr.Code)
+	}
+}
+
+func TestUploadFile_ProgressEvents(t *testing.T) {
+	setup, cleanup := setupUploadTest(t)
+	defer cleanup()
+
+	var progress []events.UploadProgressEvent
+	setup.dispatcher.Subscribe(events.UploadProgress, func(event events.Event) error {
+		progress = append(progress, event.Data.(events.UploadProgressEvent))
+		return nil
+	})
+
+	post, err := setup.postService.Create(context.Background(), 1, "Test post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Stages of a successful upload", func(t *testing.T) {
+		progress = nil
+		req, body := createMultipartRequest(t, strconv.Itoa(post.ID), "test.jpg", []byte("test image content"))
+		total := int64(body.Len())
+		req.Header.Set(config.UploadSessionHeader, "session-ok")
+
+		rr := httptest.NewRecorder()
+		setup.handler.UploadFile(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+		var attachment models.Attachment
+		parseJSON(rr.Body, &attachment)
+
+		if len(progress) < 3 {
+			t.Fatalf("Expected receiving, processing and done events, got %+v", progress)
+		}
+		if progress[0].Stage != events.UploadStageReceiving || progress[0].TotalBytes != total {
+			t.Errorf("Expected first event to count received bytes of %d, got %+v", total, progress[0])
+		}
+		processing, done := progress[len(progress)-2], progress[len(progress)-1]
+		if processing.Stage != events.UploadStageProcessing || processing.PostID != post.ID || processing.Filename != "test.jpg" {
+			t.Errorf("Unexpected processing event %+v", processing)
+		}
+		if processing.BytesReceived == 0 || processing.BytesReceived > total {
+			t.Errorf("Expected processing to report the received bytes, got %d of %d", processing.BytesReceived, total)
+		}
+		if done.Stage != events.UploadStageDone || done.AttachmentID != attachment.ID || done.SessionID != "session-ok" {
+			t.Errorf("Unexpected done event %+v", done)
+		}
+		for i := 1; i < len(progress); i++ {
+			if progress[i].Sequence <= progress[i-1].Sequence {
+				t.Errorf("Expected increasing sequence numbers, got %+v", progress)
+			}
+		}
+	})
+
+	t.Run("Rejected upload reports the failure", func(t *testing.T) {
+		progress = nil
+		req, _ := createMultipartRequest(t, strconv.Itoa(post.ID), "test.exe", []byte("binary"))
+		req.Header.Set(config.UploadSessionHeader, "session-rejected")
+
+		rr := httptest.NewRecorder()
+		setup.handler.UploadFile(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		last := progress[len(progress)-1]
+		if last.Stage != events.UploadStageFailed || !strings.Contains(last.Error, "exe") {
+			t.Errorf("Expected failed event naming the extension, got %+v", last)
+		}
+	})
+
+	t.Run("Invalid session ID", func(t *testing.T) {
+		progress = nil
+		req, _ := createMultipartRequest(t, strconv.Itoa(post.ID), "test.jpg", []byte("content"))
+		req.Header.Set(config.UploadSessionHeader, "bad id")
+
+		rr := httptest.NewRecorder()
+		setup.handler.UploadFile(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if len(progress) != 0 {
+			t.Errorf("Expected no progress events, got %+v", progress)
+		}
+	})
+
+	t.Run("Uploads without a session report nothing", func(t *testing.T) {
+		progress = nil
+		req, _ := createMultipartRequest(t, strconv.Itoa(post.ID), "test.jpg", []byte("content"))
+
+		rr := httptest.NewRecorder()
+		setup.handler.UploadFile(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Errorf("Expected status %d, got %d", http.StatusCreated, rr.Code)
+		}
+		if len(progress) != 0 {
+			t.Errorf("Expected no progress events, got %+v", progress)
+		}
+	})
+}
+
+func TestUploadVersion(t *testing.T) {
+	setup, cleanup := setupUploadTest(t)
+	defer cleanup()
+
+	post, err := setup.postService.Create(context.Background(), 1, "Test post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uploadReq, _ := createMultipartRequest(t, strconv.Itoa(post.ID), "report.txt", []byte("v1"))
+	uploadRR := httptest.NewRecorder()
+	setup.handler.UploadFile(uploadRR, uploadReq)
+	var first models.Attachment
+	if err := parseJSON(uploadRR.Body, &first); err != nil {
+		t.Fatal(err)
+	}
+
+	var sizeDelta int64
+	setup.dispatcher.Subscribe(events.FileUploaded, func(event events.Event) error {
+		sizeDelta += event.Data.(events.PostEvent).FileSize
+		return nil
+	})
+	setup.dispatcher.Subscribe(events.FileDeleted, func(event events.Event) error {
+		sizeDelta -= event.Data.(events.PostEvent).FileSize
+		return nil
+	})
+
+	upload := func(id int, filename, content string) *httptest.ResponseRecorder {
+		req, _ := createMultipartRequest(t, "", filename, []byte(content))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(id)})
+		rr := httptest.NewRecorder()
+		setup.handler.UploadVersion(rr, req)
+		return rr
+	}
+	list := func(id int) (*httptest.ResponseRecorder, models.AttachmentVersions) {
+		req := mux.SetURLVars(httptest.NewRequest("GET", "/api/files/"+strconv.Itoa(id)+"/versions", nil), map[string]string{"id": strconv.Itoa(id)})
+		rr := httptest.NewRecorder()
+		setup.handler.GetVersions(rr, req)
+		var versions models.AttachmentVersions
+		json.Unmarshal(rr.Body.Bytes(), &versions)
+		return rr, versions
+	}
+
+	// Five versions with the test cap of three prior versions: the first one is dropped
+	contents := []string{"v2", "version 3", "v4", "version five"}
+	var current models.Attachment
+	for i, content := range contents {
+		filename := "report.txt"
+		if i == len(contents)-1 {
+			filename = "report-final.txt"
+		}
+		rr := upload(first.ID, filename, content)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+		parseJSON(rr.Body, &current)
+	}
+	sum := sha256.Sum256([]byte("version five"))
+	if current.ID != first.ID || current.Filename != "report-final.txt" || current.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("Unexpected current version: %+v", current)
+	}
+	if sizeDelta != int64(len("version five")-len("v1")) {
+		t.Errorf("Expected file events to account for the size change only, got %d", sizeDelta)
+	}
+
+	rr, versions := list(first.ID)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if versions.Version != 5 || len(versions.Versions) != 3 {
+		t.Fatalf("Expected version 5 with 3 prior versions, got %+v", versions)
+	}
+	for i, expected := range []int{4, 3, 2} {
+		if versions.Versions[i].Version != expected {
+			t.Errorf("Expected version %d at position %d, got %d", expected, i, versions.Versions[i].Version)
+		}
+	}
+	if versions.Versions[1].FileSize != int64(len("version 3")) || versions.TotalSize != int64(len("version five")+len("v4")+len("version 3")+len("v2")) {
+		t.Errorf("Unexpected storage accounting: %+v", versions)
+	}
+	if _, err := os.Stat(filepath.Join(setup.uploadsDir, first.FilePath)); !os.IsNotExist(err) {
+		t.Error("Expected the file of the dropped version to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(setup.uploadsDir, versions.Versions[2].FilePath)); err != nil {
+		t.Errorf("Expected prior version file to be kept: %v", err)
+	}
+
+	if rr := upload(999, "report.txt", "x"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown attachment, got %d", rr.Code)
+	}
+	if rr := upload(first.ID, "report.exe", "x"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for disallowed extension, got %d", rr.Code)
+	}
+	if rr, _ := list(999); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown attachment, got %d", rr.Code)
+	}
+}
+
+func TestRedactImage(t *testing.T) {
+	setup, cleanup := setupUploadTest(t)
+	defer cleanup()
+
+	post, err := setup.postService.Create(context.Background(), 1, "Test post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A 40x20 screenshot, white on the left half and red on the right
+	screenshot := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 20; y++ {
+			pixel := color.RGBA{255, 255, 255, 255}
+			if x >= 20 {
+				pixel = color.RGBA{255, 0, 0, 255}
+			}
+			screenshot.Set(x, y, pixel)
+		}
+	}
+	var encoded bytes.Buffer
+	png.Encode(&encoded, screenshot)
+
+	attach := func(filename string, content []byte) models.Attachment {
+		req, _ := createMultipartRequest(t, strconv.Itoa(post.ID), filename, content)
+		rr := httptest.NewRecorder()
+		setup.handler.UploadFile(rr, req)
+		var attachment models.Attachment
+		if err := parseJSON(rr.Body, &attachment); err != nil {
+			t.Fatal(err)
+		}
+		return attachment
+	}
+	redact := func(id int, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/files/"+strconv.Itoa(id)+"/redact", strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"id": strconv.Itoa(id)})
+		rr := httptest.NewRecorder()
+		setup.handler.RedactImage(rr, req)
+		return rr
+	}
+	pixels := func(attachment models.Attachment) image.Image {
+		file, err := os.Open(filepath.Join(setup.uploadsDir, attachment.FilePath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		img, err := png.Decode(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+
+	original := attach("screenshot.png", encoded.Bytes())
+
+	t.Run("regions are blacked out or blurred", func(t *testing.T) {
+		rr := redact(original.ID, `{"regions":[{"x":0,"y":0,"width":10,"height":10},{"x":10,"y":10,"width":20,"height":100,"mode":"blur"}]}`)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var redacted models.Attachment
+		parseJSON(rr.Body, &redacted)
+		if redacted.ID != original.ID || redacted.FilePath == original.FilePath || redacted.SHA256 == "" {
+			t.Errorf("Expected a new file for the attachment, got %+v", redacted)
+		}
+
+		img := pixels(redacted)
+		if r, g, b, _ := img.At(5, 5).RGBA(); r != 0 || g != 0 || b != 0 {
+			t.Errorf("Expected a black pixel, got %v", img.At(5, 5))
+		}
+		// The blur mixes the white and red halves inside the region only
+		if _, g, _, _ := img.At(20, 15).RGBA(); g == 0 || g == 0xffff {
+			t.Errorf("Expected a blurred pixel, got %v", img.At(20, 15))
+		}
+		if img.At(35, 15) != (color.RGBA{255, 0, 0, 255}) || img.At(5, 15) != (color.RGBA{255, 255, 255, 255}) {
+			t.Error("Expected pixels outside the regions to be left alone")
+		}
+
+		// Originals are not kept by default
+		if _, err := os.Stat(filepath.Join(setup.uploadsDir, original.FilePath)); !os.IsNotExist(err) {
+			t.Error("Expected the original file to be removed")
+		}
+		if versions, _ := setup.fileService.GetVersions(original.ID); len(versions.Versions) != 0 {
+			t.Errorf("Expected no prior version, got %+v", versions.Versions)
+		}
+		original = redacted
+	})
+
+	t.Run("originals are kept as versions when the policy says so", func(t *testing.T) {
+		setup.options.Features.FileUpload.KeepRedactedOriginals = true
+		defer func() { setup.options.Features.FileUpload.KeepRedactedOriginals = false }()
+
+		if rr := redact(original.ID, `{"regions":[{"x":30,"y":0,"width":10,"height":10}]}`); rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+		versions, _ := setup.fileService.GetVersions(original.ID)
+		if len(versions.Versions) != 1 || versions.Versions[0].FilePath != original.FilePath {
+			t.Errorf("Expected the original as prior version, got %+v", versions.Versions)
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		text := attach("notes.txt", []byte("not an image"))
+		tests := []struct {
+			name     string
+			id       int
+			body     string
+			expected int
+		}{
+			{"No regions", original.ID, `{"regions":[]}`, http.StatusBadRequest},
+			{"Empty region", original.ID, `{"regions":[{"x":0,"y":0,"width":0,"height":5}]}`, http.StatusBadRequest},
+			{"Region outside the image", original.ID, `{"regions":[{"x":100,"y":100,"width":5,"height":5}]}`, http.StatusBadRequest},
+			{"Unknown mode", original.ID, `{"regions":[{"x":0,"y":0,"width":5,"height":5,"mode":"swirl"}]}`, http.StatusBadRequest},
+			{"Invalid body", original.ID, `regions`, http.StatusBadRequest},
+			{"Not an image", text.ID, `{"regions":[{"x":0,"y":0,"width":5,"height":5}]}`, http.StatusUnsupportedMediaType},
+			{"Unknown attachment", 999, `{"regions":[{"x":0,"y":0,"width":5,"height":5}]}`, http.StatusNotFound},
+		}
+		for _, tt := range tests {
+			if rr := redact(tt.id, tt.body); rr.Code != tt.expected {
+				t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.expected, rr.Code, rr.Body.String())
+			}
+		}
+	})
+}
+
+// stubVerifier accepts the signature "valid" for every file
+type stubVerifier struct{}
+
+func (stubVerifier) Verify(filePath, expires, signature string) error {
+	if signature != "valid" {
+		return fmt.Errorf(config.ErrSignedURLInvalid)
+	}
+	return nil
+}
+
+func TestServeFile_SignedURLs(t *testing.T) {
+	setup, cleanup := setupUploadTest(t)
+	defer cleanup()
+
+	post, err := setup.postService.Create(context.Background(), 1, "Test post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uploadReq, _ := createMultipartRequest(t, strconv.Itoa(post.ID), "diagram.png", []byte("diagram content"))
+	uploadRR := httptest.NewRecorder()
+	setup.handler.UploadFile(uploadRR, uploadReq)
+
+	var attachment models.Attachment
+	if err := parseJSON(uploadRR.Body, &attachment); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(query string) int {
+		req := httptest.NewRequest("GET", "/uploads/"+attachment.FilePath+query, nil)
+		req = mux.SetURLVars(req, map[string]string{"filename": attachment.FilePath})
+		rr := httptest.NewRecorder()
+		setup.handler.ServeFile(rr, req)
+		return rr.Code
+	}
+
+	// Without a verifier no signature is accepted
+	if code := serve("?expires=1&signature=valid"); code != http.StatusForbidden {
+		t.Errorf("Expected signed URL to be refused without a verifier, got %d", code)
+	}
+
+	setup.fileService.SetURLVerifier(stubVerifier{})
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{"Unsigned", "", http.StatusOK},
+		{"Valid signature", "?expires=1&signature=valid", http.StatusOK},
+		{"Invalid signature", "?expires=1&signature=forged", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := serve(tt.query); code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, code)
+			}
+		})
+	}
+}
+
+func TestValidateUpload(t *testing.T) {
+	setup, cleanup := setupUploadTest(t)
+	defer cleanup()
+
+	setup.handler.options = config.NewTestOptionsConfig().WithAllowedExtensions([]string{"jpg", "txt"}).WithMaxFileSizeMB(1).WithMaxFilesPerPost(1)
+
+	post, err := setup.postService.Create(context.Background(), 1, "Test post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := setup.postService.Create(context.Background(), 1, "Full post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := setup.fileService.UploadFile(context.Background(), full.ID, strings.NewReader("first"), "first.txt", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedChecks []string
+	}{
+		{"Accepted on a post", fmt.Sprintf(`{"filename":"photo.jpg","size":1024,"post_id":%d}`, post.ID), http.StatusOK, nil},
+		{"Accepted on a new post", `{"filename":"notes.txt","size":10,"space_id":1}`, http.StatusOK, nil},
+		{"Extension and size", fmt.Sprintf(`{"filename":"setup.exe","size":2097152,"post_id":%d}`, post.ID), http.StatusOK, []string{models.UploadCheckSize, models.UploadCheckExtension}},
+		{"Post has max files", fmt.Sprintf(`{"filename":"second.txt","size":10,"post_id":%d}`, full.ID), http.StatusOK, []string{models.UploadCheckMaxFiles}},
+		{"Missing filename", `{"size":10,"space_id":1}`, http.StatusBadRequest, nil},
+		{"Negative size", `{"filename":"a.txt","size":-1,"space_id":1}`, http.StatusBadRequest, nil},
+		{"Missing target", `{"filename":"a.txt","size":10}`, http.StatusBadRequest, nil},
+		{"Unknown post", `{"filename":"a.txt","size":10,"post_id":999}`, http.StatusNotFound, nil},
+		{"Unknown space", `{"filename":"a.txt","size":10,"space_id":999}`, http.StatusNotFound, nil},
+		{"Invalid JSON", `nope`, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/upload/validate", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			setup.handler.ValidateUpload(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var validation models.UploadValidation
+			if err := parseJSON(rr.Body, &validation); err != nil {
+				t.Fatal(err)
+			}
+			checks := []string{}
+			for _, rejection := range validation.Rejections {
+				checks = append(checks, rejection.Check)
+			}
+			if validation.Accepted != (len(tt.expectedChecks) == 0) || strings.Join(checks, ",") != strings.Join(tt.expectedChecks, ",") {
+				t.Errorf("Expected rejections %v, got %+v", tt.expectedChecks, validation)
+			}
+		})
+	}
+
+	// Uploads enforce the same limits
+	req, _ := createMultipartRequest(t, strconv.Itoa(full.ID), "second.txt", []byte("second"))
+	rr := httptest.NewRecorder()
+	setup.handler.UploadFile(rr, req)
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "maximum of 1 files") {
+		t.Errorf("Expected the upload to be refused for max files, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestUploadFile_FilenameCollisions(t *testing.T) {
+	setup, cleanup := setupUploadTest(t)
+	defer cleanup()
+
+	post, err := setup.postService.Create(context.Background(), 1, "Test post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func(filename, content string) models.Attachment {
+		req, _ := createMultipartRequest(t, strconv.Itoa(post.ID), filename, []byte(content))
+		rr := httptest.NewRecorder()
+		setup.handler.UploadFile(rr, req)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, rr.Code, rr.Body.String())
+		}
+		var attachment models.Attachment
+		if err := parseJSON(rr.Body, &attachment); err != nil {
+			t.Fatal(err)
+		}
+		return attachment
+	}
+
+	// Identical filenames keep the original name and get numbered, each file keeps its content
+	for i, expected := range []string{"notes.txt", "notes-1.txt", "notes-2.txt"} {
+		content := fmt.Sprintf("version %d", i)
+		attachment := upload("notes.txt", content)
+		if attachment.FilePath != expected || attachment.Filename != "notes.txt" {
+			t.Errorf("Expected notes.txt stored as %s, got %q stored as %q", expected, attachment.Filename, attachment.FilePath)
+		}
+		if data, _ := os.ReadFile(filepath.Join(setup.uploadsDir, attachment.FilePath)); string(data) != content {
+			t.Errorf("Expected %s to hold %q, got %q", attachment.FilePath, content, data)
+		}
+	}
+
+	// A trashed attachment keeps its name for when it is restored
+	setup.db.Exec(
+		"INSERT INTO trash_items (item_type, item_id, payload, deleted_at, purge_at) VALUES (?, 1, ?, 0, 0)",
+		models.TrashItemAttachment, `{"file_path": "Report.pdf"}`,
+	)
+	if attachment := upload("report.pdf", "report"); attachment.FilePath != "report-1.pdf" {
+		t.Errorf("Expected the trashed name to be skipped, got %q", attachment.FilePath)
+	}
+
+	// Unsafe characters are replaced on disk only
+	attachment := upload("what?#now.txt", "unsafe")
+	if attachment.FilePath != "what__now.txt" || attachment.Filename != "what?#now.txt" {
+		t.Errorf("Expected a safe stored name and the original filename, got %q stored as %q", attachment.Filename, attachment.FilePath)
+	}
+}
+
+func TestUploadHandler_SpaceAccess(t *testing.T) {
+	setup, cleanup := setupUploadTest(t)
+	defer cleanup()
+
+	post, err := setup.postService.Create(context.Background(), 1, "Private post", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadReq, _ := createMultipartRequest(t, strconv.Itoa(post.ID), "report.txt", []byte("v1"))
+	uploadRR := httptest.NewRecorder()
+	setup.handler.UploadFile(uploadRR, uploadReq)
+	var first models.Attachment
+	if err := parseJSON(uploadRR.Body, &first); err != nil {
+		t.Fatal(err)
+	}
+	versionReq, _ := createMultipartRequest(t, "", "report.txt", []byte("v2"))
+	versionRR := httptest.NewRecorder()
+	setup.handler.UploadVersion(versionRR, mux.SetURLVars(versionReq, map[string]string{"id": strconv.Itoa(first.ID)}))
+	if versionRR.Code != http.StatusCreated {
+		t.Fatalf("Expected the version to be uploaded, got %d: %s", versionRR.Code, versionRR.Body.String())
+	}
+
+	var current models.Attachment
+	if err := parseJSON(versionRR.Body, &current); err != nil {
+		t.Fatal(err)
+	}
+
+	alice, _ := setup.db.ProvisionUser("alice", "", "Alice", models.RoleEditor, 1)
+	bob, _ := setup.db.ProvisionUser("bob", "", "Bob", models.RoleEditor, 1)
+	access := services.NewSpaceAccess(setup.db, setup.spaceCache)
+	if err := access.SetACL(1, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatal(err)
+	}
+	setup.postService.SetSpaceAccess(access)
+
+	as := func(req *http.Request, userID int) *http.Request {
+		return req.WithContext(services.WithViewer(req.Context(), services.Viewer{UserID: userID}))
+	}
+	fileID := strconv.Itoa(first.ID)
+
+	tests := []struct {
+		name    string
+		request func(userID int) *httptest.ResponseRecorder
+		reader  int // Status for alice, who may only read the space
+		other   int // Status for bob, who may not reach it
+	}{
+		{"upload", func(userID int) *httptest.ResponseRecorder {
+			req, _ := createMultipartRequest(t, strconv.Itoa(post.ID), "notes.txt", []byte("notes"))
+			rr := httptest.NewRecorder()
+			setup.handler.UploadFile(rr, as(req, userID))
+			return rr
+		}, http.StatusForbidden, http.StatusNotFound},
+		{"version", func(userID int) *httptest.ResponseRecorder {
+			req, _ := createMultipartRequest(t, "", "report.txt", []byte("v3"))
+			rr := httptest.NewRecorder()
+			setup.handler.UploadVersion(rr, as(mux.SetURLVars(req, map[string]string{"id": fileID}), userID))
+			return rr
+		}, http.StatusForbidden, http.StatusNotFound},
+		{"redact", func(userID int) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/api/files/"+fileID+"/redact", strings.NewReader(`{"regions":[]}`))
+			rr := httptest.NewRecorder()
+			setup.handler.RedactImage(rr, as(mux.SetURLVars(req, map[string]string{"id": fileID}), userID))
+			return rr
+		}, http.StatusForbidden, http.StatusNotFound},
+		{"versions", func(userID int) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/api/files/"+fileID+"/versions", nil)
+			rr := httptest.NewRecorder()
+			setup.handler.GetVersions(rr, as(mux.SetURLVars(req, map[string]string{"id": fileID}), userID))
+			return rr
+		}, http.StatusOK, http.StatusNotFound},
+		{"current file", func(userID int) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/uploads/"+current.FilePath, nil)
+			rr := httptest.NewRecorder()
+			setup.handler.ServeFile(rr, as(mux.SetURLVars(req, map[string]string{"filename": current.FilePath}), userID))
+			return rr
+		}, http.StatusOK, http.StatusNotFound},
+		{"prior version", func(userID int) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "/uploads/"+first.FilePath, nil)
+			rr := httptest.NewRecorder()
+			setup.handler.ServeFile(rr, as(mux.SetURLVars(req, map[string]string{"filename": first.FilePath}), userID))
+			return rr
+		}, http.StatusOK, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := tt.request(alice.ID); rr.Code != tt.reader {
+				t.Errorf("Expected status %d for a reader, got %d: %s", tt.reader, rr.Code, rr.Body.String())
+			}
+			if rr := tt.request(bob.ID); rr.Code != tt.other {
+				t.Errorf("Expected status %d for an unlisted viewer, got %d: %s", tt.other, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
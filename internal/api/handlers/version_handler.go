@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+)
+
+type VersionHandler struct{}
+
+func NewVersionHandler() *VersionHandler {
+	return &VersionHandler{}
+}
+
+// GetVersion handles GET /api/version
+func (h *VersionHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(config.GetBuildInfo())
+}
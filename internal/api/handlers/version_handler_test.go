@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestVersionHandler_GetVersion(t *testing.T) {
+	config.SetBuildInfo("1.2.3", "abc1234", "2025-10-01T12:00:00Z", false)
+	defer config.SetBuildInfo("dev", "", "", false)
+
+	w := httptest.NewRecorder()
+	NewVersionHandler().GetVersion(w, httptest.NewRequest("GET", "/api/version", nil))
+
+	var info config.BuildInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if info.Version != "1.2.3" || info.Commit != "abc1234" || info.BuildDate != "2025-10-01T12:00:00Z" {
+		t.Errorf("Expected the stamped build values, got %+v", info)
+	}
+	if info.Platform != runtime.GOOS+"/"+runtime.GOARCH || info.GoVersion != runtime.Version() {
+		t.Errorf("Expected the runtime platform and Go version, got %+v", info)
+	}
+	if line := info.String(); !strings.Contains(line, "1.2.3") || !strings.Contains(line, "commit abc1234") {
+		t.Errorf("Unexpected version line %q", line)
+	}
+}
@@ -1,14 +1,23 @@
 package middleware
 
 import (
+	"backthynk/internal/config"
 	"net/http"
+	"strings"
 )
 
+// exposedHeaders are the response headers cross-origin clients may read
+var exposedHeaders = []string{
+	config.HeaderAPIVersion, "Deprecation", "Sunset", "Warning",
+	config.RequestCostHeader, config.ChangeSeqHeader, config.ChecksumHeader,
+}
+
 func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, API-Version")
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(exposedHeaders, ", "))
 		
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"backthynk/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORSExposesCustomHeaders(t *testing.T) {
+	handler := CORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/spaces", nil))
+
+	exposed := strings.Split(w.Header().Get("Access-Control-Expose-Headers"), ", ")
+	for _, header := range []string{config.HeaderAPIVersion, config.RequestCostHeader, config.ChangeSeqHeader, config.ChecksumHeader} {
+		found := false
+		for _, name := range exposed {
+			found = found || name == header
+		}
+		if !found {
+			t.Errorf("Expected %s to be exposed, got %v", header, exposed)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"backthynk/internal/core/logger"
+	"bufio"
+	"net"
 	"net/http"
 	"time"
 )
@@ -23,6 +25,23 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return size, err
 }
 
+// Flush lets streaming handlers (server-sent events) push data through the wrapper
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSocket handlers take over the connection through the wrapper
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	rw.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
 func Logging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"backthynk/internal/config"
+	"net/http"
+	"strings"
+)
+
+// safeModeReadRoutes are the diagnostic endpoints readable in safe mode, besides /api/admin
+var safeModeReadRoutes = map[string]bool{
+	"/api/version":  true,
+	"/api/logs":     true,
+	"/api/settings": true,
+}
+
+// SafeMode restricts the API to admin and diagnostic endpoints while the server runs with
+// --safe-mode. Only admin endpoints accept writes, so recovery actions such as a cache
+// reconcile or a config bundle import remain possible. Pages and assets are still served.
+func SafeMode(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if path != "/api" && !strings.HasPrefix(path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		admin := strings.HasPrefix(path, "/api/admin/")
+		read := r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+		if admin || (read && safeModeReadRoutes[path]) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set(config.HeaderSafeMode, "true")
+		if read {
+			http.Error(w, config.ErrSafeModeEndpoint, http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, config.ErrSafeModeWriteLocked, http.StatusServiceUnavailable)
+	})
+}
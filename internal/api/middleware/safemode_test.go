@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"backthynk/internal/config"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestSafeMode(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(SafeMode)
+	ok := func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "ok") }
+	r.HandleFunc("/api/version", ok).Methods("GET")
+	r.HandleFunc("/api/settings", ok).Methods("GET", "PUT")
+	r.HandleFunc("/api/spaces", ok).Methods("GET", "POST")
+	r.HandleFunc("/api/admin/space-cache/reconcile", ok).Methods("POST")
+	r.HandleFunc("/api/admin/memory", ok).Methods("GET")
+	r.PathPrefix("/").HandlerFunc(ok).Methods("GET")
+	router := Versioning(DefaultAPIVersions())(r)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{"Diagnostic endpoint", "GET", "/api/version", http.StatusOK},
+		{"Versioned diagnostic endpoint", "GET", "/api/v1/version", http.StatusOK},
+		{"Settings read", "GET", "/api/settings", http.StatusOK},
+		{"Settings write", "PUT", "/api/settings", http.StatusServiceUnavailable},
+		{"Admin read", "GET", "/api/admin/memory", http.StatusOK},
+		{"Admin recovery action", "POST", "/api/admin/space-cache/reconcile", http.StatusOK},
+		{"Content read", "GET", "/api/spaces", http.StatusServiceUnavailable},
+		{"Content write", "POST", "/api/spaces", http.StatusServiceUnavailable},
+		{"Page", "GET", "/", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if refused := w.Header().Get(config.HeaderSafeMode) == "true"; refused != (tt.expectedStatus != http.StatusOK) {
+				t.Errorf("Expected %s header only on refused requests, got %q", config.HeaderSafeMode, w.Header().Get(config.HeaderSafeMode))
+			}
+		})
+	}
+}
@@ -27,7 +27,10 @@ func CreateStaticFileHandler() http.Handler {
 			return
 		}
 
-		// Development mode
+		// Development mode; --dev also keeps browsers from caching edited assets
+		if config.IsDevMode() {
+			w.Header().Set("Cache-Control", "no-store")
+		}
 		http.FileServer(http.Dir(sharedCfg.GetWebStaticPath())).ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"backthynk/internal/config"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// APIVersion describes how an API version differs from the current one
+type APIVersion struct {
+	Deprecated bool
+	Sunset     string // HTTP date after which a deprecated version is removed, optional
+	// FieldRenames maps JSON field names of this version to their current names. Request
+	// bodies are translated to the current names and responses back to this version's.
+	FieldRenames map[string]string
+}
+
+// APIVersions lists the versions served under /api/v{N}
+type APIVersions struct {
+	Current  int
+	Versions map[int]APIVersion
+}
+
+// DefaultAPIVersions returns the versions served by this build
+func DefaultAPIVersions() APIVersions {
+	return APIVersions{
+		Current: config.CurrentAPIVersion,
+		Versions: map[int]APIVersion{
+			1: {},
+		},
+	}
+}
+
+type apiVersionKey struct{}
+
+// APIVersionFromContext returns the API version negotiated for a request, or 0 outside the API
+func APIVersionFromContext(ctx context.Context) int {
+	version, _ := ctx.Value(apiVersionKey{}).(int)
+	return version
+}
+
+var versionedPathPattern = regexp.MustCompile(`^/api/v([0-9]+)(/.*)?$`)
+
+// Versioning serves the API under /api/v{N} prefixes. Unprefixed /api requests use the
+// version asked for in the API-Version header, or the current one. Routes are registered
+// once without prefix: the prefix is stripped before routing, so it must wrap the router.
+func Versioning(versions APIVersions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api" && !strings.HasPrefix(r.URL.Path, "/api/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			version := versions.Current
+			if match := versionedPathPattern.FindStringSubmatch(r.URL.Path); match != nil {
+				version, _ = strconv.Atoi(match[1])
+				if _, ok := versions.Versions[version]; !ok {
+					http.Error(w, fmt.Sprintf(config.ErrFmtUnsupportedAPIVersion, match[1]), http.StatusNotFound)
+					return
+				}
+				r.URL.Path = "/api" + match[2]
+				r.URL.RawPath = ""
+			} else if requested := r.Header.Get(config.HeaderAPIVersion); requested != "" {
+				parsed, err := strconv.Atoi(strings.TrimPrefix(requested, "v"))
+				if _, ok := versions.Versions[parsed]; err != nil || !ok {
+					http.Error(w, fmt.Sprintf(config.ErrFmtUnsupportedAPIVersion, requested), http.StatusBadRequest)
+					return
+				}
+				version = parsed
+			}
+
+			spec := versions.Versions[version]
+			w.Header().Set(config.HeaderAPIVersion, strconv.Itoa(version))
+			if spec.Deprecated {
+				w.Header().Set("Deprecation", "true")
+				if spec.Sunset != "" {
+					w.Header().Set("Sunset", spec.Sunset)
+				}
+				w.Header().Set("Warning", fmt.Sprintf(`299 - "`+config.ErrFmtDeprecatedAPIVersion+`"`, version, versions.Current))
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, version))
+
+			if len(spec.FieldRenames) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			toCurrent := spec.FieldRenames
+			toVersion := make(map[string]string, len(toCurrent))
+			for old, current := range toCurrent {
+				toVersion[current] = old
+			}
+
+			if r.Body != nil && isJSON(r.Header.Get("Content-Type")) {
+				body, err := io.ReadAll(r.Body)
+				r.Body.Close()
+				if err != nil {
+					http.Error(w, config.ErrInvalidRequestBody, http.StatusBadRequest)
+					return
+				}
+				body = renameJSONFields(body, toCurrent)
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				r.ContentLength = int64(len(body))
+				r.Header.Del("Content-Length")
+			}
+
+			rw := &renamingWriter{ResponseWriter: w, renames: toVersion, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+			rw.finish()
+		})
+	}
+}
+
+// renamingWriter buffers JSON responses to rename their fields; other responses, such as
+// server-sent events, are passed through untouched
+type renamingWriter struct {
+	http.ResponseWriter
+	renames     map[string]string
+	status      int
+	wroteHeader bool
+	buffering   bool
+	buf         bytes.Buffer
+}
+
+func (rw *renamingWriter) WriteHeader(status int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+	rw.status = status
+	rw.buffering = isJSON(rw.Header().Get("Content-Type"))
+	if !rw.buffering {
+		rw.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (rw *renamingWriter) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.buffering {
+		return rw.buf.Write(b)
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Flush lets streaming handlers push data through the wrapper
+func (rw *renamingWriter) Flush() {
+	if rw.buffering {
+		return
+	}
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSocket handlers take over the connection through the wrapper
+func (rw *renamingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	return hijacker.Hijack()
+}
+
+func (rw *renamingWriter) finish() {
+	if !rw.buffering {
+		return
+	}
+	body := renameJSONFields(rw.buf.Bytes(), rw.renames)
+	rw.Header().Del("Content-Length")
+	rw.ResponseWriter.WriteHeader(rw.status)
+	rw.ResponseWriter.Write(body)
+}
+
+func isJSON(contentType string) bool {
+	return strings.HasPrefix(strings.TrimSpace(contentType), "application/json")
+}
+
+// renameJSONFields renames object keys at any depth; invalid JSON is returned unchanged
+func renameJSONFields(body []byte, renames map[string]string) []byte {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return body
+	}
+
+	renamed, err := json.Marshal(renameFields(value, renames))
+	if err != nil {
+		return body
+	}
+	if bytes.HasSuffix(body, []byte("\n")) {
+		renamed = append(renamed, '\n')
+	}
+	return renamed
+}
+
+func renameFields(value interface{}, renames map[string]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			if renamed, ok := renames[key]; ok {
+				key = renamed
+			}
+			out[key] = renameFields(field, renames)
+		}
+		return out
+	case []interface{}:
+		for i, item := range v {
+			v[i] = renameFields(item, renames)
+		}
+		return v
+	default:
+		return value
+	}
+}
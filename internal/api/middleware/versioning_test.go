@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func newVersionedTestRouter() http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/api/posts", func(w http.ResponseWriter, r *http.Request) {
+		// Echoed as a string so the response renaming leaves it alone
+		received, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"space_id": 7,
+			"received": string(received),
+			"version":  APIVersionFromContext(r.Context()),
+		})
+	})
+	r.HandleFunc("/api/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"space_id":7}`)
+	})
+	r.HandleFunc("/static/app.js", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	})
+
+	versions := APIVersions{
+		Current: 2,
+		Versions: map[int]APIVersion{
+			1: {Deprecated: true, Sunset: "Wed, 01 Jan 2031 00:00:00 GMT", FieldRenames: map[string]string{"category_id": "space_id"}},
+			2: {},
+		},
+	}
+	return Versioning(versions)(r)
+}
+
+func TestVersioning(t *testing.T) {
+	router := newVersionedTestRouter()
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		header             string
+		body               string
+		expectedStatus     int
+		expectedVersion    string
+		expectedDeprecated bool
+		expectedBody       []string
+	}{
+		{"Current version prefix", "GET", "/api/v2/posts", "", "", http.StatusOK, "2", false, []string{`"space_id":7`, `"version":2`}},
+		{"Unprefixed uses current", "GET", "/api/posts", "", "", http.StatusOK, "2", false, []string{`"space_id":7`}},
+		{"Header negotiation", "GET", "/api/posts", "1", "", http.StatusOK, "1", true, []string{`"category_id":7`, `"version":1`}},
+		{"Header with v prefix", "GET", "/api/posts", "v2", "", http.StatusOK, "2", false, []string{`"space_id":7`}},
+		{"Old version renames request and response", "POST", "/api/v1/posts", "", `{"category_id":3,"nested":[{"category_id":4}]}`, http.StatusOK, "1", true,
+			[]string{`"category_id":7`, `"received":"{\"nested\":[{\"space_id\":4}],\"space_id\":3}`}},
+		{"Path wins over header", "GET", "/api/v2/posts", "1", "", http.StatusOK, "2", false, []string{`"space_id":7`}},
+		{"Streams pass through", "GET", "/api/v1/events", "", "", http.StatusOK, "1", true, []string{`data: {"space_id":7}`}},
+		{"Unknown path version", "GET", "/api/v3/posts", "", "", http.StatusNotFound, "", false, nil},
+		{"Unknown header version", "GET", "/api/posts", "abc", "", http.StatusBadRequest, "", false, nil},
+		{"Outside the API", "GET", "/static/app.js", "", "", http.StatusOK, "", false, []string{"ok"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tt.header != "" {
+				req.Header.Set(config.HeaderAPIVersion, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if got := w.Header().Get(config.HeaderAPIVersion); got != tt.expectedVersion {
+				t.Errorf("Expected %s header %q, got %q", config.HeaderAPIVersion, tt.expectedVersion, got)
+			}
+			if deprecated := w.Header().Get("Deprecation") == "true"; deprecated != tt.expectedDeprecated {
+				t.Errorf("Expected deprecated %v, got %v", tt.expectedDeprecated, deprecated)
+			}
+			if tt.expectedDeprecated && (w.Header().Get("Sunset") == "" || !strings.Contains(w.Header().Get("Warning"), "deprecated")) {
+				t.Errorf("Expected sunset and warning headers, got %v", w.Header())
+			}
+			for _, expected := range tt.expectedBody {
+				if !strings.Contains(w.Body.String(), expected) {
+					t.Errorf("Expected body to contain %s, got %s", expected, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultAPIVersionsServesCurrent(t *testing.T) {
+	versions := DefaultAPIVersions()
+	if _, ok := versions.Versions[versions.Current]; !ok {
+		t.Fatalf("Expected current version %d to be served", versions.Current)
+	}
+	if versions.Versions[versions.Current].Deprecated {
+		t.Error("Expected current version not to be deprecated")
+	}
+}
@@ -5,35 +5,70 @@ import (
 	"backthynk/internal/api/middleware"
 	"backthynk/internal/config"
 	"backthynk/internal/core/services"
-	"backthynk/internal/features/activity"
-	"backthynk/internal/features/detailedstats"
 	"net/http"
 
 	"github.com/gorilla/mux"
 )
 
+// FeatureHandler is implemented by optional feature handlers that register their own routes
+type FeatureHandler interface {
+	RegisterRoutes(router *mux.Router)
+}
+
+// FeatureMiddleware is implemented by feature handlers that also wrap every routed request,
+// such as the login check of authentication
+type FeatureMiddleware interface {
+	Middleware(next http.Handler) http.Handler
+}
+
+// FeatureGate is implemented by feature middlewares that decide whether a request goes through
+// at all, such as authentication. Gates wrap every other feature middleware, whatever the order
+// features are given in, so that caches and loggers never see a request that is turned away.
+type FeatureGate interface {
+	FeatureMiddleware
+	IsGate() bool
+}
+
 func NewRouter(
 	spaceService *services.SpaceService,
 	postService *services.PostService,
 	fileService *services.FileService,
-	detailedStats *detailedstats.Service,
-	activityService *activity.Service,
 	opts *config.OptionsConfig,
 	serviceConfig *config.ServiceConfig,
+	features ...FeatureHandler,
 ) http.Handler {
 	r := mux.NewRouter()
 	
 	// Middleware
 	r.Use(middleware.CORS)
 	r.Use(middleware.Logging)
+	if config.IsSafeMode() {
+		r.Use(middleware.SafeMode)
+	}
+	for _, feature := range features {
+		if gate, ok := feature.(FeatureGate); ok && gate.IsGate() {
+			r.Use(gate.Middleware)
+		}
+	}
+	for _, feature := range features {
+		if gate, ok := feature.(FeatureGate); ok && gate.IsGate() {
+			continue
+		}
+		if wrapper, ok := feature.(FeatureMiddleware); ok {
+			r.Use(wrapper.Middleware)
+		}
+	}
 	
 	// Initialize handlers
 	spaceHandler := handlers.NewSpaceHandler(spaceService)
 	postHandler := handlers.NewPostHandler(postService, fileService, opts)
-	uploadHandler := handlers.NewUploadHandler(fileService, opts)
+	uploadHandler := handlers.NewUploadHandler(postService, fileService, opts)
 	linkPreviewHandler := handlers.NewLinkPreviewHandler(fileService)
 	settingsHandler := handlers.NewSettingsHandler()
+	configBundleHandler := handlers.NewConfigBundleHandler()
 	logsHandler := handlers.NewLogsHandler()
+	versionHandler := handlers.NewVersionHandler()
+	commandsHandler := handlers.NewCommandsHandler(opts)
 	templateHandler := handlers.NewTemplateHandler(spaceService, opts, serviceConfig)
 	
 	// API routes
@@ -43,46 +78,87 @@ func NewRouter(
 	api.HandleFunc("/spaces", spaceHandler.GetSpaces).Methods("GET")
 	api.HandleFunc("/spaces", spaceHandler.CreateSpace).Methods("POST")
 	api.HandleFunc("/spaces/by-parent", spaceHandler.GetSpacesByParent).Methods("GET")
-	api.HandleFunc("/spaces/{id}", spaceHandler.GetSpace).Methods("GET")
-	api.HandleFunc("/spaces/{id}", spaceHandler.UpdateSpace).Methods("PUT")
-	api.HandleFunc("/spaces/{id}", spaceHandler.DeleteSpace).Methods("DELETE")
+	api.HandleFunc("/spaces/search", spaceHandler.SearchSpaces).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}", spaceHandler.GetSpace).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}", spaceHandler.UpdateSpace).Methods("PUT")
+	api.HandleFunc("/spaces/{id:[0-9]+}", spaceHandler.DeleteSpace).Methods("DELETE")
+	api.HandleFunc("/admin/space-cache", spaceHandler.GetCacheStats).Methods("GET")
+	api.HandleFunc("/admin/space-cache/invalidate", spaceHandler.InvalidateCache).Methods("POST")
+	api.HandleFunc("/admin/space-cache/reconcile", spaceHandler.ReconcileCache).Methods("POST")
 	
 	// Posts
 	api.HandleFunc("/posts", postHandler.CreatePost).Methods("POST")
-	api.HandleFunc("/posts/{id}", postHandler.GetPost).Methods("GET")
-	api.HandleFunc("/posts/{id}", postHandler.DeletePost).Methods("DELETE")
-	api.HandleFunc("/posts/{id}/move", postHandler.MovePost).Methods("PUT")
-	api.HandleFunc("/spaces/{id}/posts", postHandler.GetPostsBySpace).Methods("GET")
+	api.HandleFunc("/posts/merge", postHandler.MergePosts).Methods("POST")
+	api.HandleFunc("/posts/bulk-retime", postHandler.BulkRetime).Methods("POST")
+	api.HandleFunc("/posts/{id:[0-9]+}", postHandler.GetPost).Methods("GET")
+	api.HandleFunc("/posts/{id:[0-9]+}", postHandler.UpdatePost).Methods("PUT")
+	api.HandleFunc("/posts/{id:[0-9]+}", postHandler.DeletePost).Methods("DELETE")
+	api.HandleFunc("/posts/{id:[0-9]+}/revisions", postHandler.GetPostRevisions).Methods("GET")
+	api.HandleFunc("/posts/{id:[0-9]+}/move", postHandler.MovePost).Methods("PUT")
+	api.HandleFunc("/posts/{id:[0-9]+}/split", postHandler.SplitPost).Methods("POST")
+	api.HandleFunc("/posts/{id:[0-9]+}/fields", postHandler.UpdatePostFields).Methods("PUT")
+	api.HandleFunc("/posts/{id:[0-9]+}/print", postHandler.PrintPost).Methods("GET")
+	api.HandleFunc("/posts/{id:[0-9]+}/crossposts", postHandler.GetCrossposts).Methods("GET")
+	api.HandleFunc("/posts/{id:[0-9]+}/crossposts", postHandler.AddCrosspost).Methods("POST")
+	api.HandleFunc("/posts/{id:[0-9]+}/crossposts/{spaceId:[0-9]+}", postHandler.RemoveCrosspost).Methods("DELETE")
+	api.HandleFunc("/posts/{id:[0-9]+}/pin", postHandler.PinPost).Methods("PUT")
+	api.HandleFunc("/posts/{id:[0-9]+}/pin", postHandler.UnpinPost).Methods("DELETE")
+	api.HandleFunc("/spaces/{id:[0-9]+}/posts", postHandler.GetPostsBySpace).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/posts/stream", postHandler.StreamPostsBySpace).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/media", postHandler.GetSpaceMedia).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/print", postHandler.PrintSpace).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/limits", postHandler.SetSpaceLimits).Methods("PUT")
+	api.HandleFunc("/spaces/{id:[0-9]+}/fields", postHandler.GetSpaceFields).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/fields", postHandler.SetSpaceFields).Methods("PUT")
+	api.HandleFunc("/spaces/{id:[0-9]+}/settings", postHandler.GetSpaceSettings).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/settings", postHandler.SetSpaceSettings).Methods("PUT")
+	api.HandleFunc("/spaces/{id:[0-9]+}/append", postHandler.AppendToDailyLog).Methods("POST")
+	api.HandleFunc("/spaces/{id:[0-9]+}/journal/{date}", postHandler.GetJournalEntry).Methods("GET")
+	api.HandleFunc("/limits", postHandler.GetLimits).Methods("GET")
 	
 	// Files
 	api.HandleFunc("/upload", uploadHandler.UploadFile).Methods("POST")
+	api.HandleFunc("/upload/validate", uploadHandler.ValidateUpload).Methods("POST")
+	api.HandleFunc("/posts/{id:[0-9]+}/attach-url", uploadHandler.AttachURL).Methods("POST")
+	api.HandleFunc("/files/{id:[0-9]+}/versions", uploadHandler.GetVersions).Methods("GET")
+	api.HandleFunc("/files/{id:[0-9]+}/versions", uploadHandler.UploadVersion).Methods("POST")
+	api.HandleFunc("/files/{id:[0-9]+}/redact", uploadHandler.RedactImage).Methods("POST")
 	api.HandleFunc("/link-preview", handlers.FetchLinkPreview).Methods("POST")
-	api.HandleFunc("/posts/{id}/link-previews", linkPreviewHandler.GetLinkPreviewsByPost).Methods("GET")
+	api.HandleFunc("/posts/{id:[0-9]+}/link-previews", linkPreviewHandler.GetLinkPreviewsByPost).Methods("GET")
 	
 	// Settings
 	api.HandleFunc("/settings", settingsHandler.GetSettings).Methods("GET")
 	api.HandleFunc("/settings", settingsHandler.UpdateSettings).Methods("PUT")
+	api.HandleFunc("/admin/config-bundle", configBundleHandler.ExportConfigBundle).Methods("GET")
+	api.HandleFunc("/admin/config-bundle", configBundleHandler.ImportConfigBundle).Methods("POST")
 
 	// Logs
 	api.HandleFunc("/logs", logsHandler.GetLogs).Methods("GET")
-	
-	// Feature routes (registered only if enabled)
-	if detailedStats != nil {
-		detailedStatsHandler := detailedstats.NewHandler(detailedStats)
-		detailedStatsHandler.RegisterRoutes(r)
+
+	// Build info
+	api.HandleFunc("/version", versionHandler.GetVersion).Methods("GET")
+
+	// Actions offered to command palettes and alternative clients
+	api.HandleFunc("/ui/commands", commandsHandler.GetCommands).Methods("GET")
+
+	// Live reload of edited assets, --dev only
+	if config.IsDevMode() {
+		devReloadHandler := handlers.NewDevReloadHandler(config.GetSharedConfig().GetRessourcesRootPath())
+		api.HandleFunc("/dev/reload", devReloadHandler.StreamReloads).Methods("GET")
 	}
 	
-	if activityService != nil {
-		activityHandler := activity.NewHandler(activityService)
-		activityHandler.RegisterRoutes(r)
+	// Feature routes (only enabled features are passed in)
+	for _, feature := range features {
+		feature.RegisterRoutes(r)
 	}
 	
 	// Static files
 	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", middleware.CreateStaticFileHandler()))
-	r.HandleFunc("/uploads/{filename}", uploadHandler.ServeFile).Methods("GET")
+	r.HandleFunc("/uploads/{filename}", uploadHandler.ServeFile).Methods("GET", "HEAD")
 	
 	// SPA routes
 	r.PathPrefix("/").HandlerFunc(templateHandler.ServePage).Methods("GET")
 	
-	return r
+	// Versioned API prefixes are resolved before routing
+	return middleware.Versioning(middleware.DefaultAPIVersions())(r)
 }
\ No newline at end of file
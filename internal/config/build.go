@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// BuildInfo describes the running binary. Release builds stamp the version, commit and date
+// through -ldflags; other builds report "dev".
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	Mode      string `json:"mode"`
+	Embedded  bool   `json:"embedded_assets"`
+	SafeMode  bool   `json:"safe_mode,omitempty"` // Started with --safe-mode
+}
+
+var buildInfo = BuildInfo{Version: "dev"}
+
+// SetBuildInfo records the stamped build values, called once from main
+func SetBuildInfo(version, commit, buildDate string, embedded bool) {
+	if version != "" {
+		buildInfo.Version = version
+	}
+	buildInfo.Commit = commit
+	buildInfo.BuildDate = buildDate
+	buildInfo.Embedded = embedded
+}
+
+// GetBuildInfo returns the build values completed with the runtime ones
+func GetBuildInfo() BuildInfo {
+	info := buildInfo
+	info.GoVersion = runtime.Version()
+	info.Platform = runtime.GOOS + "/" + runtime.GOARCH
+	info.Mode = GetAppMode()
+	info.SafeMode = safeMode
+	return info
+}
+
+// String renders the build info on one line, as printed by --version
+func (b BuildInfo) String() string {
+	s := fmt.Sprintf("backthynk %s (%s, %s", b.Version, b.Platform, b.GoVersion)
+	if b.Commit != "" {
+		s += ", commit " + b.Commit
+	}
+	if b.BuildDate != "" {
+		s += ", built " + b.BuildDate
+	}
+	return s + ")"
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// ConfigBundleFormat identifies configuration bundles exported by this application
+const (
+	ConfigBundleFormat  = "backthynk-config-bundle"
+	ConfigBundleVersion = 1
+)
+
+// ConfigBundle is a signed export of the instance configuration.
+// Signature is the hex HMAC-SHA256 of the raw Payload bytes.
+type ConfigBundle struct {
+	Payload   json.RawMessage `json:"payload"`
+	Signature string          `json:"signature"`
+}
+
+// ConfigBundlePayload holds the exported configuration, without secrets
+type ConfigBundlePayload struct {
+	Format     string        `json:"format"`
+	Version    int           `json:"version"`
+	AppVersion string        `json:"app_version"`
+	Created    int64         `json:"created"`
+	Options    OptionsConfig `json:"options"`
+	Service    ServiceConfig `json:"service"`
+}
+
+// WithoutSecrets returns a copy of the options with secret values cleared
+func (o *OptionsConfig) WithoutSecrets() OptionsConfig {
+	clean := *o
+	clean.Features.FileUpload.AllowedExtensions = append([]string(nil), o.Features.FileUpload.AllowedExtensions...)
+	clean.Features.StaleSpaces.NudgeWebhookURL = ""
+	clean.Features.Moderation.Rules = append([]ModerationRule(nil), o.Features.Moderation.Rules...)
+	clean.Features.Moderation.ExternalURL = ""
+	clean.Features.Publishing.WebhookURL = ""
+	clean.Features.Publishing.Secret = ""
+	return clean
+}
+
+// WithoutSecrets returns a copy of the service config with secret values cleared
+func (c *ServiceConfig) WithoutSecrets() ServiceConfig {
+	clean := *c
+	clean.Auth.OIDC.ClientSecret = ""
+	return clean
+}
+
+// RestoreSecrets copies secret values from current into o where o has none,
+// so importing a bundle does not wipe secrets that were never exported
+func (o *OptionsConfig) RestoreSecrets(current *OptionsConfig) {
+	if o.Features.StaleSpaces.NudgeWebhookURL == "" {
+		o.Features.StaleSpaces.NudgeWebhookURL = current.Features.StaleSpaces.NudgeWebhookURL
+	}
+	if o.Features.Moderation.ExternalURL == "" {
+		o.Features.Moderation.ExternalURL = current.Features.Moderation.ExternalURL
+	}
+	if o.Features.Publishing.WebhookURL == "" {
+		o.Features.Publishing.WebhookURL = current.Features.Publishing.WebhookURL
+	}
+	if o.Features.Publishing.Secret == "" {
+		o.Features.Publishing.Secret = current.Features.Publishing.Secret
+	}
+}
+
+// SignConfigBundle wraps payload in a bundle signed with key
+func SignConfigBundle(payload *ConfigBundlePayload, key string) (*ConfigBundle, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConfigBundle{
+		Payload:   data,
+		Signature: hex.EncodeToString(bundleMAC(data, key)),
+	}, nil
+}
+
+// OpenConfigBundle verifies the bundle signature with key and decodes its payload
+func OpenConfigBundle(bundle *ConfigBundle, key string) (*ConfigBundlePayload, error) {
+	signature, err := hex.DecodeString(bundle.Signature)
+	if err != nil || !hmac.Equal(signature, bundleMAC(bundle.Payload, key)) {
+		return nil, fmt.Errorf(ErrBundleSignatureInvalid)
+	}
+
+	var payload ConfigBundlePayload
+	if err := json.Unmarshal(bundle.Payload, &payload); err != nil {
+		return nil, fmt.Errorf(ErrBundleFormatUnsupported)
+	}
+	if payload.Format != ConfigBundleFormat || payload.Version != ConfigBundleVersion {
+		return nil, fmt.Errorf(ErrBundleFormatUnsupported)
+	}
+
+	return &payload, nil
+}
+
+func bundleMAC(data []byte, key string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
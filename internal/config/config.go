@@ -25,6 +25,16 @@ const (
 	DefaultPostLimit            = 20
 	MaxPostLimit                = 100
 	MinRetroactivePostTimestamp = 946684800000 // 01/01/2000
+	PostStreamFlushInterval     = 100          // Posts written between flushes of a posts stream
+	DefaultPrintPostLimit       = 200          // Posts per printed space page
+	MaxPrintPostLimit           = 1000
+	MaxCrosspostSpaces          = 20           // Spaces a post can be cross-posted to, besides its own
+
+	// Custom Fields
+	MaxSpaceFields        = 20
+	MaxFieldSelectOptions = 50
+	MaxFieldOptionLength  = 100
+	MaxFieldTextLength    = 500
 
 	// Validation Limits
 	MinFileSizeMB        = 1
@@ -40,12 +50,408 @@ const (
 
 	MaxFilesPerPost      = 50
 
+	ChecksumHeader = "X-Checksum-SHA256" // Hex SHA-256 of a served upload
+
+	// Prior versions kept for a re-uploaded attachment, besides the current one
+	MinVersionsPerFile     = 1
+	MaxVersionsPerFile     = 100
+	DefaultVersionsPerFile = 10 // When maxVersionsPerFile is not set
+
+	// Image redaction
+	MaxRedactRegions    = 50
+	RedactModeBlur      = "blur"
+	RedactModeBlackout  = "blackout"
+	MinRedactBlurRadius = 8 // Pixels; larger regions are blurred over a quarter of their smaller side
+	RedactBlurPasses    = 3 // Box blurs applied in a row, close to a gaussian blur
+	RedactJPEGQuality   = 90
+	MaxRedactPixels     = 50_000_000 // Larger images are not decoded
+
+	// Attachment Previews
+	PreviewsSubdir        = "previews" // Preview images, named by attachment ID
+	PreviewKindImage      = "image"
+	PreviewKindPDF        = "pdf"   // First page
+	PreviewKindVideo      = "video" // Poster frame
+	DefaultPreviewMaxSize = 480     // Pixels on the longer side
+	PreviewJPEGQuality    = 80
+	PreviewToolTimeout    = 30 * time.Second
+	MaxPreviewPixels      = 50_000_000 // Larger images are not decoded
+	DefaultPDFRenderer    = "pdftoppm"
+	DefaultFFmpeg         = "ffmpeg"
+	PreviewKindHeader     = "X-Preview-Kind"
+
+	// Previous revisions kept for an edited post, besides the current content
+	DefaultMaxPostRevisions = 50 // When maxRevisions is not set
+
+	// Posts pinned at the top of the listing of their space
+	DefaultMaxPinnedPosts = 5 // When maxPerSpace is not set
+
 	MinTitleLength       = 1 //page title
 	MaxTitleLength       = 100 //page title
 	MaxDescriptionLength = 160 //page description : meta
 
+	// Locale
+	DefaultLocale = "en-US"
+
+	// Page Themes
+	ThemeLight    = "light"
+	ThemeDark     = "dark"
+	DefaultTheme  = ThemeLight
+	DefaultAccent = "#2563eb"
+
+	// Stale Spaces
+	DefaultStaleDays   = 30
+	MaxStaleDays       = 3650
+	StaleNudgeInterval = 24 * time.Hour
+
+	// Stats History
+	DefaultStatsHistoryDays = 90
+	MaxStatsHistoryDays     = 730
+	StatsSnapshotInterval   = time.Hour
+
+	// Activity Breakdown
+	DefaultActivityBreakdownTop = 5 // Spaces shown individually before grouping into "other"
+	MaxActivityBreakdownTop     = 20
+	DataVersionHeader           = "X-Data-Version" // Version of the activity data a response was computed from
+
+	// Download Stats
+	DefaultTopDownloadsLimit = 10
+	MaxTopDownloadsLimit     = 100
+
+	// Trash
+	DefaultPostRetentionDays       = 30
+	DefaultAttachmentRetentionDays = 7
+	DefaultSpaceRetentionDays      = 30
+	DefaultTrashUpcomingDays       = 7
+	MaxTrashUpcomingDays           = 365
+	DefaultSpaceTrashLimit         = 20
+	MaxSpaceTrashLimit             = 100
+	TrashPurgeInterval             = time.Hour
+
+	// Live Feed
+	LiveFeedBufferSize        = 200 // Recent events kept for Last-Event-ID replay
+	LiveFeedKeepAliveInterval = 30 * time.Second
+	LiveFeedWriteTimeout      = 10 * time.Second // Before a WebSocket client that stopped reading is dropped
+	LiveFeedMaxMessageBytes   = 4096             // Largest message accepted from a WebSocket client
+
+	// Change Log
+	ChangeSeqHeader               = "X-Change-Seq" // Changes made by a mutating request, e.g. "12,15-18"
+	DefaultChangeLogRetentionDays = 30
+	DefaultChangesLimit           = 500
+	MaxChangesLimit               = 1000
+	ChangeLogPruneInterval        = time.Hour
+
+	// Request Cost
+	RequestCostHeader       = "X-Request-Cost" // Spaces traversed and posts scanned by the recursive operations of a request
+	DefaultMaxRequestSpaces = 2000
+	DefaultMaxRequestPosts  = 1000000
+	RequestCostLogSpaces    = 100 // Requests traversing at least this many spaces are logged
+
+	// Upload Progress
+	UploadSessionHeader     = "X-Upload-Session"
+	UploadSessionPattern    = `^[A-Za-z0-9_-]{8,64}$`
+	UploadProgressInterval  = 250 * time.Millisecond // Minimum delay between two byte counts of a session
+	MaxTrackedUploadSessions = 256                   // Finished sessions kept so late listeners still get the outcome
+
+	// Tags
+	DefaultTagAuditLimit = 50
+	MaxTagAuditLimit     = 500
+
+	// Secret Scanning
+	SecretScanModeWarn         = "warn"
+	SecretScanModeRedact       = "redact"
+	SecretScanModeReject       = "reject"
+	SecretSourcePost           = "post"
+	MaxSecretScanBytes         = 1024 * 1024 // Larger text attachments are not scanned
+	DefaultSecretFindingsLimit = 100
+	MaxSecretFindingsLimit     = 1000
+
+	// Maintenance
+	DefaultMaintenanceQuietStart = 3 // Local hour
+	DefaultMaintenanceQuietEnd   = 5
+	MaintenanceCheckInterval     = 15 * time.Minute
+	MaintenanceMinRunGap         = 20 * time.Hour // Keeps scheduled runs to one per quiet window
+
+	// Integrity Check
+	MaxIntegritySamples = 10 // Offending IDs or files listed per check
+
+	// Journal
+	JournalHeadingFormat = "# %s" // Content of journal entries started from the journal endpoint
+
+	// Related Posts
+	DefaultRelatedPostsLimit = 5
+	MaxRelatedPostsLimit     = 50
+	RelatedSnippetLength     = 160 // Characters of content returned with each related post
+
+	// Language Stats
+	DefaultLanguageMonths = 12  // Months in the vocabulary trend
+	MaxLanguageMonths     = 120
+	LanguageTopWords      = 10  // Most used words returned with the stats
+
+	// Search
+	SearchModeKeyword        = "keyword"
+	SearchModeSemantic       = "semantic"
+	EmbeddingProviderLocal   = "local" // Dependency-free hashed embeddings computed in process
+	EmbeddingProviderAPI     = "api"   // External embeddings API
+	DefaultSearchLimit       = 20
+	MaxSearchLimit           = 100
+	MaxSearchQueryLength     = 500
+	SearchSnippetLength      = 160
+	SearchSnippetTokens      = 24  // Words of the passage returned around a keyword match
+	SearchContentWeight      = 1.0 // Weight of matches in post content when ranking keyword results
+	SearchLinkTitleWeight    = 0.5 // Weight of matches in link preview titles
+	LocalEmbeddingDimensions = 256
+	EmbeddingBatchSize       = 16 // Posts vectorized per provider call
+	EmbeddingRetryInterval   = time.Minute
+
+	// Search Reindex, rebuilding the full-text index of the search endpoint in batches
+	SearchReindexBatchSize   = 500 // Posts reindexed per batch, the checkpoint is saved after each
+	ReindexKeepAliveInterval = 30 * time.Second
+	ReindexStatusIdle        = "idle" // The index was never rebuilt
+	ReindexStatusRunning     = "running"
+	ReindexStatusDone        = "done"
+	ReindexStatusFailed      = "failed"
+
+	// Quick Search, scoped to a space subtree through the full-text index
+	QuickSearchCandidates          = 200 // Most recent matching posts ranked per query
+	QuickSearchMaxTerms            = 8
+	QuickSearchMatchWeight         = 0.6 // Share of the score given to how well words match
+	QuickSearchRecencyWeight       = 0.4 // Share of the score given to the post age
+	QuickSearchRecencyHalfLifeDays = 30  // Days after which the recency part of the score halves
+
+	// Summaries
+	SummaryKindPost             = "post"
+	SummaryKindDigest           = "digest"
+	DefaultSummaryMinPostLength = 1500 // Characters below which posts are not summarized
+	MaxDigestPosts              = 200  // Posts of a week sent to the summarizer for a digest
+	DigestCheckInterval         = time.Hour
+
+	// Ingest Tokens
+	IngestTokenPrefix          = "bti_"
+	IngestTokenBytes           = 24 // Random bytes of a minted token
+	IngestTokenDisplayLength   = 10 // Characters of a token kept to recognize it
+	IngestRateWindow           = time.Hour
+	DefaultIngestRateLimit     = 60 // Requests per token and window
+	MaxIngestRateLimit         = 3600
+	MaxIngestTokenNameLength   = 100
+	MaxIngestBodyBytes         = 1 << 20
+	MaxIngestEventsPerToken    = 200 // Activity log entries kept per token
+	DefaultIngestActivityLimit = 50
+	IngestGuardRoute           = "ingest"
+
+	// Feature Flags
+	FlagSemanticSearch = "semantic_search"
+	MaxFlagRollout     = 100
+
+	// Share Links
+	ShareLinkTokenPrefix      = "bts_"
+	ShareLinkTokenBytes       = 24 // Random bytes of a minted link token
+	ShareLinkDisplayLength    = 10 // Characters of a token kept to recognize it
+	ShareLinkRateWindow       = time.Hour
+	DefaultShareLinkRateLimit = 30 // Posts per link and window
+	MaxShareLinkRateLimit     = 600
+	MaxShareLinkNameLength    = 100
+	MaxShareAuthorLength      = 50
+	DefaultShareLinkHours     = 24
+	MaxShareLinkHours         = 24 * 30
+	DefaultSharedPostsLimit   = 50
+	MaxSharedPostsLimit       = 100
+	ShareLinkGuardRoute       = "share"
+
+	// Notifications
+	DefaultNotificationRetentionDays = 30
+	DefaultNotificationsLimit        = 50
+	MaxNotificationsLimit            = 200
+	NotificationPurgeInterval        = time.Hour
+	HeldDeliveryReleaseInterval      = time.Minute
+	MaxHeldDeliveryAttempts          = 5 // Failed releases of a held webhook call before it is dropped
+	QuietHoursTimeLayout             = "15:04"
+
+	// Space Cache
+	SpaceCacheReconcileInterval = 10 * time.Minute
+
+	// Space Search
+	DefaultSpaceSearchLimit        = 10
+	MaxSpaceSearchLimit            = 50
+	SpaceSearchMatchWeight         = 0.7 // Share of the score given to name match quality
+	SpaceSearchRecencyWeight       = 0.3 // Share of the score given to recent activity
+	SpaceSearchRecencyHalfLifeDays = 14  // Days after which the recency part of the score halves
+
+	// Result Cache
+	DefaultResultCacheTTLSeconds = 30
+	MaxResultCacheTTLSeconds     = 600
+	MaxResultCacheEntries        = 500
+	MaxResultCacheEntryBytes     = 1 << 20
+	ResultCacheStatusHeader      = "X-Backthynk-Cache" // hit, miss or bypass
+
+	// Memory Reporting
+	MemoryCheckInterval = 5 * time.Minute
+
+	// Support Bundle
+	SupportBundleFormat         = "backthynk-support-bundle"
+	SupportBundleVersion        = 1
+	SupportBundleLogLines       = 500 // Last lines of each log file included
+	SupportBundleIntegrityLimit = 100 // Integrity check messages reported at most
+
+	// Workspace Archives
+	WorkspaceArchiveFormat   = "backthynk-workspace"
+	WorkspaceArchiveVersion  = 1
+	WorkspaceArchiveDatabase = "database.db" // Archive entry holding the database
+	TrashSubdir              = "trash"
+
+	// Workspace Archive Encryption
+	ArchivePassphraseEnv       = "BACKTHYNK_ARCHIVE_PASSPHRASE" // Passphrase of encrypted archives given to --import
+	MinArchivePassphraseLength = 12
+	ArchiveChunkSize           = 64 * 1024 // Bytes sealed at a time
+	ArchiveScryptLogN          = 15        // scrypt cost of the key derivation, as a power of two
+
+	// Mobile Sync
+	ProfileMobile              = "mobile" // Value of the profile query parameter asking for compact responses
+	MobileContentPreviewLength = 280      // Characters of content kept per listed post in compact responses
+
+	// Duplicate Links
+	DefaultMaxDuplicateLinks = 5 // Earlier posts listed in create responses when maxDuplicates is not set
+	DefaultLinksLimit        = 50
+	MaxLinksLimit            = 500
+
+	// GraphQL
+	MaxGraphQLQueryLength = 16 * 1024 // Bytes of query text
+	MaxGraphQLDepth       = 8         // Nested selection sets below the query root
+
+	// Signed URLs
+	DefaultSignedURLTTLSeconds = 3600
+	MaxSignedURLTTLSeconds     = 30 * 24 * 3600
+	SignedURLKeyName           = "signed_urls"
+
+	// Directory Import
+	MaxDirImportFiles = 5000
+
+	// Publishing
+	MaxPublishAttempts     = 6                // Deliveries of a post dropped after this many failures
+	PublishRetryBaseDelay  = 30 * time.Second // Doubled after every failed attempt
+	PublishDeliverInterval = 5 * time.Second
+
+	// Board
+	MaxBoardPins = 12 // Posts pinned to the board of a space
+
+	// Record Keeping
+	DefaultRecordRetentionDays = 7 * 365 // Records of a space cannot be deleted before
+	MaxRecordRetentionDays     = 100 * 365
+	RecordPurgeInterval        = 24 * time.Hour
+
+	// Space Reviews
+	DefaultReviewIntervalDays = 91 // Quarterly
+	MaxReviewIntervalDays     = 3650
+	MaxReviewChangedPosts     = 10 // Changed posts listed with a due review
+	ReviewCheckInterval       = time.Hour
+
+	// Landing Views, the page opened on the root path
+	LandingViewSpace     = "space"
+	LandingViewTimeline  = "timeline" // Posts of every space
+	LandingViewInbox     = "inbox"
+	LandingViewDashboard = "dashboard"
+	DefaultLandingView   = LandingViewTimeline
+
+	// Authentication
+	SessionCookieName    = "backthynk_session"
+	LoginStateCookieName = "backthynk_login"
+	SessionTTL           = 30 * 24 * time.Hour
+	LoginStateTTL        = 10 * time.Minute // Time allowed to come back from the provider
+	SessionTokenBytes    = 32
+	DefaultOIDCRoleClaim = "groups"
+	OIDCRequestTimeout   = 10 * time.Second
+	SessionTouchInterval = time.Minute // Last seen time of a session is written at most this often
+	MaxUserAgentLength   = 512
+	LocalSubjectPrefix   = "local:" // Subject of the accounts kept by the instance, followed by the username
+	UsernamePattern      = `^[a-z0-9][a-z0-9._-]{2,31}$`
+	MinPasswordLength    = 8
+	MaxPasswordLength    = 72 // Bytes, bcrypt ignores the rest
+	PasswordHashCost     = 12
+
+	// Background Job Budget
+	DefaultMaxConcurrentJobs = 2
+	DefaultJobMaxIOMBps      = 20
+	DefaultJobPauseLatencyMs = 500
+	JobLatencyWindow         = 10 * time.Second // Requests averaged to decide whether jobs pause
+	JobPauseCheckInterval    = time.Second
+	MaxJobLatencySamples     = 1024
+	MaxRecentJobs            = 20
+
+	// Rough per-item costs used by memory estimates
+	MemoryMapEntryOverhead = 48 // Bucket slot, tophash and spare capacity of a map entry
+	MemoryStringHeader     = 16
+	MemorySliceHeader      = 24
+
+	// Saved Filters
+	MaxSavedFilterNameLength = 100
+	MaxSavedFilterTerms      = 20 // Tags or extensions per filter
+
+	// Space Snapshots
+	DefaultMaxSnapshotsPerSpace   = 20
+	MaxSnapshotNameLength         = 100
+	MaxSnapshotIntervalHours      = 24 * 30
+	DefaultScheduledSnapshotsKept = 7
+	MaxScheduledSnapshotsKept     = 100
+	SnapshotScheduleCheckInterval = 10 * time.Minute
+
+	// Cold Storage
+	DefaultColdStorageAfterMonths = 6
+	MaxColdStorageAfterMonths     = 120
+	ColdStorageSubdir             = "cold"
+	ColdStorageCheckInterval      = 24 * time.Hour
+	ColdStorageBatchSize          = 200 // Attachments moved per archival pass
+	SnapshotStoreSubdir           = "snapshots" // Attachment files kept by snapshots, named by their hash
+
+	// Automation Rules
+	MaxRuleNameLength        = 100
+	MaxRuleActions           = 10
+	MaxRuleTags              = 20
+	MaxRulePatternLength     = 200
+	MaxRuleReminderHours     = 24 * 365
+	MaxRuleMessageLength     = 500
+	MaxRuleExecutionsPerRule = 200 // Execution log entries kept per rule
+	DefaultRuleLogLimit      = 50
+	MaxRuleLogLimit          = 200
+	MaxRuleRunsPerPost       = 10 // Rule executions on one post within the loop window before rules stop acting on it
+	RuleLoopWindow           = time.Minute
+	RuleReminderInterval     = time.Minute
+
+	// Glossary
+	MaxGlossaryTermLength       = 100
+	MaxGlossaryDefinitionLength = 1000
+
+	// Public Endpoint Guard
+	DefaultPublicRequestBudget = 600
+	DefaultPublicBudgetWindow  = time.Hour
+	DefaultSignatureMaxSkew    = 5 * time.Minute
+	SigningSecretBytes         = 32 // Random bytes of the secret of a token requiring signed requests
+
 	// HTTP Timeouts
 	LinkPreviewHTTPTimeout = 10 * time.Second
+	WebhookHTTPTimeout     = 10 * time.Second
+	EmbeddingHTTPTimeout   = 30 * time.Second
+	SummaryHTTPTimeout     = 60 * time.Second
+	RemoteFileHTTPTimeout  = 60 * time.Second
+
+	// Remote Attachments
+	MaxRemoteFetchRedirects = 5
+	ContentSniffBytes       = 512 // Bytes looked at by http.DetectContentType
+	DefaultRemoteFilename   = "download"
+
+	// Stored Filenames
+	MaxStoredFilenameLength = 200  // Bytes, leaving room for a collision suffix under the usual 255
+	MaxFilenameCollisions   = 1000 // Numbered names tried before an upload is refused
+	DefaultStoredFilename   = "file"
+
+	// Dev Mode
+	DevTimeoutFactor      = 10 // Outbound timeouts are multiplied by this in dev mode
+	DevReloadPollInterval = time.Second
+	DevDataDirPrefix      = "backthynk-dev-*"
+
+	// Service Integration
+	ServiceName           = "backthynk"            // systemd unit names, without suffix
+	DefaultServiceUnitDir = "/etc/systemd/system"  // Written by --install-service
+	ServiceWatchdogSec    = 60                     // Watchdog timeout of the generated unit
+	ShutdownTimeout       = 15 * time.Second       // Requests in flight finish within this on SIGTERM
 
 	// Permissions
 	DirectoryPermissions = 0755
@@ -54,6 +460,14 @@ const (
 	// Patterns (updated to allow more flexible display names)
 	// Must start AND end with letter or number, then allow letters, numbers, spaces, hyphens, underscores, apostrophes, and periods in between
 	SpaceNamePattern = `^[a-zA-Z0-9]([a-zA-Z0-9\s\-_'.])*[a-zA-Z0-9]$|^[a-zA-Z0-9]$`
+	FieldNamePattern = `^[a-z][a-z0-9_]{0,39}$`
+
+	// API Versioning
+	CurrentAPIVersion = 1
+	HeaderAPIVersion  = "API-Version" // Version requested by clients and served in responses
+
+	// Safe Mode
+	HeaderSafeMode = "X-Safe-Mode" // Set on requests refused because of --safe-mode
 
 	// Route Names
 	RouteAPI      = "api"
@@ -63,8 +477,34 @@ const (
 
 	// Logging
 	MaxLogFileSizeKB = 1024 // 1MB
+
+	// Config bundles
+	ServiceConfigFilename = "service.json"
+	MinBundleKeyLength    = 8
 )
 
+// ModerationRule is a named regular expression flagging content before it is shared
+type ModerationRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// DefaultModerationRules flag common secrets and credentials
+func DefaultModerationRules() []ModerationRule {
+	return []ModerationRule{
+		{Name: "aws-access-key", Pattern: `\b(AKIA|ASIA)[0-9A-Z]{16}\b`},
+		{Name: "private-key", Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY-----`},
+		{Name: "bearer-token", Pattern: `(?i)\bbearer\s+[a-z0-9\-._~+/]{20,}=*`},
+		{Name: "password", Pattern: `(?i)\b(password|passwd|pwd)\s*[:=]\s*\S{4,}`},
+	}
+}
+
+// FeatureFlag turns an experimental subsystem on, optionally for a share of the spaces only
+type FeatureFlag struct {
+	Enabled bool `json:"enabled"`
+	Rollout int  `json:"rollout,omitempty"` // Percent of spaces the flag is on for; 0 means every space
+}
+
 type ServiceConfig struct {
 	Server struct {
 		Port string `json:"port"`
@@ -79,15 +519,50 @@ type ServiceConfig struct {
 		DisplayLogs       bool `json:"displayLogs"`
 		EnableRequestLogs bool `json:"enableRequestLogs"`
 	} `json:"logging"`
+	Auth struct {
+		OIDC  OIDCConfig      `json:"oidc"`
+		Local LocalAuthConfig `json:"local"`
+	} `json:"auth"`
+}
+
+// OIDCConfig enables single sign-on through an OpenID Connect provider. Users are created on
+// their first login with the role mapped from their claims.
+type OIDCConfig struct {
+	Enabled      bool              `json:"enabled"`
+	Issuer       string            `json:"issuer"`      // Discovery is read from <issuer>/.well-known/openid-configuration
+	ClientID     string            `json:"clientID"`
+	ClientSecret string            `json:"clientSecret"`
+	RedirectURL  string            `json:"redirectURL"` // Public URL of /auth/oidc/callback
+	Scopes       []string          `json:"scopes"`      // Defaults to openid, profile and email
+	RoleClaim    string            `json:"roleClaim"`   // Claim holding the groups or roles of the user, defaults to groups
+	RoleMapping  map[string]string `json:"roleMapping"` // Claim value -> admin, editor or viewer; the highest role matched wins
+	DefaultRole  string            `json:"defaultRole"` // Role of users no mapping matches; empty refuses them
+}
+
+// LocalAuthConfig enables accounts with a username and password kept by the instance. The
+// first account registered becomes admin; later ones are only registered while registration
+// is open.
+type LocalAuthConfig struct {
+	Enabled           bool   `json:"enabled"`
+	AllowRegistration bool   `json:"allowRegistration"` // Anyone reaching the login page may create an account
+	DefaultRole       string `json:"defaultRole"`       // Role of registered accounts, defaults to viewer
 }
 
 type OptionsConfig struct {
 	Core struct {
 		MaxContentLength int `json:"maxContentLength"`
 	} `json:"core"`
+	Jobs struct {
+		MaxConcurrent  int     `json:"maxConcurrent"`  // Background jobs running at once, 0 for no limit
+		MaxIOMBps      float64 `json:"maxIOMBps"`      // Estimated disk throughput of background jobs, 0 for no limit
+		PauseLatencyMs int     `json:"pauseLatencyMs"` // Jobs wait while requests average longer than this, 0 never pauses
+	} `json:"jobs"`
 	Metadata struct {
 		Title       string `json:"title"`
 		Description string `json:"description"`
+		Locale      string `json:"locale"`
+		Theme       string `json:"theme"`  // light or dark, for server rendered pages
+		Accent      string `json:"accent"` // Hex color, empty for the default accent
 	} `json:"metadata"`
 	Features struct {
 		Activity struct {
@@ -109,8 +584,183 @@ type OptionsConfig struct {
 			MaxFileSizeMB     int      `json:"maxFileSizeMB"`
 			MaxFilesPerPost   int      `json:"maxFilesPerPost"`
 			AllowedExtensions []string `json:"allowedExtensions"`
+			MaxVersionsPerFile int     `json:"maxVersionsPerFile"` // Prior versions kept per attachment
+			KeepRedactedOriginals bool `json:"keepRedactedOriginals"` // Redacted images keep their original as a prior version
 		} `json:"fileUpload"`
+		StaleSpaces struct {
+			Enabled         bool   `json:"enabled"`
+			ThresholdDays   int    `json:"thresholdDays"`
+			NudgeWebhookURL string `json:"nudgeWebhookURL"`
+		} `json:"staleSpaces"`
+		Tasks struct {
+			Enabled bool `json:"enabled"`
+		} `json:"tasks"`
+		Metrics struct {
+			Enabled bool `json:"enabled"`
+		} `json:"metrics"`
+		Glossary struct {
+			Enabled bool `json:"enabled"`
+		} `json:"glossary"`
+		PublicGuard struct {
+			Enabled             bool `json:"enabled"`
+			RequestBudget       int  `json:"requestBudget"`       // Requests allowed per token and window
+			BudgetWindowMinutes int  `json:"budgetWindowMinutes"`
+			MaxClockSkewSeconds int  `json:"maxClockSkewSeconds"` // Accepted age of signed request timestamps
+		} `json:"publicGuard"`
+		Trash struct {
+			Enabled                 bool `json:"enabled"`
+			PostRetentionDays       int  `json:"postRetentionDays"`
+			AttachmentRetentionDays int  `json:"attachmentRetentionDays"`
+			SpaceRetentionDays      int  `json:"spaceRetentionDays"`
+		} `json:"trash"`
+		LiveFeed struct {
+			Enabled bool `json:"enabled"`
+		} `json:"liveFeed"`
+		ChangeLog struct {
+			Enabled       bool `json:"enabled"`
+			RetentionDays int  `json:"retentionDays"` // Changes older than this can no longer be listed
+		} `json:"changeLog"`
+		RequestCost struct {
+			Enabled   bool `json:"enabled"`
+			MaxSpaces int  `json:"maxSpaces"` // Spaces a request may traverse, 0 for no cap
+			MaxPosts  int  `json:"maxPosts"`  // Posts a request may scan, 0 for no cap
+		} `json:"requestCost"`
+		Tags struct {
+			Enabled bool `json:"enabled"`
+		} `json:"tags"`
+		Moderation struct {
+			Enabled     bool             `json:"enabled"`
+			Rules       []ModerationRule `json:"rules"`
+			ExternalURL string           `json:"externalURL"` // Optional moderation API receiving each post
+		} `json:"moderation"`
+		SecretScan struct {
+			Enabled bool   `json:"enabled"`
+			Mode    string `json:"mode"` // warn, redact or reject
+		} `json:"secretScan"`
+		Maintenance struct {
+			Enabled         bool `json:"enabled"`
+			QuietHoursStart int  `json:"quietHoursStart"` // Local hour, 0-23
+			QuietHoursEnd   int  `json:"quietHoursEnd"`
+		} `json:"maintenance"`
+		StartupCheck struct {
+			Enabled bool `json:"enabled"`
+		} `json:"startupCheck"`
+		SavedFilters struct {
+			Enabled bool `json:"enabled"`
+		} `json:"savedFilters"`
+		RelatedPosts struct {
+			Enabled bool `json:"enabled"`
+		} `json:"relatedPosts"`
+		LanguageStats struct {
+			Enabled bool `json:"enabled"`
+		} `json:"languageStats"`
+		DeltaExport struct {
+			Enabled bool `json:"enabled"`
+		} `json:"deltaExport"`
+		Search struct {
+			Enabled  bool `json:"enabled"`
+			Semantic struct {
+				Enabled  bool   `json:"enabled"`
+				Provider string `json:"provider"` // local or api
+				APIURL   string `json:"apiURL"`   // Embeddings endpoint of the api provider
+				APIKey   string `json:"apiKey"`
+				Model    string `json:"model"`
+			} `json:"semantic"`
+		} `json:"search"`
+		Summaries struct {
+			Enabled          bool   `json:"enabled"`
+			EndpointURL      string `json:"endpointURL"` // Summarizer receiving {kind, title, content}
+			APIKey           string `json:"apiKey"`
+			MinPostLength    int    `json:"minPostLength"`
+			DigestWebhookURL string `json:"digestWebhookURL"` // Optional receiver of weekly space digests
+		} `json:"summaries"`
+		Ingest struct {
+			Enabled bool `json:"enabled"`
+		} `json:"ingest"`
+		ShareLinks struct {
+			Enabled bool `json:"enabled"`
+		} `json:"shareLinks"`
+		ResultCache struct {
+			Enabled    bool `json:"enabled"`
+			TTLSeconds int  `json:"ttlSeconds"` // How long recursive aggregations are served from memory
+		} `json:"resultCache"`
+		Notifications struct {
+			Enabled       bool `json:"enabled"`
+			RetentionDays int  `json:"retentionDays"` // Notifications untouched for longer are removed
+		} `json:"notifications"`
+		Snapshots struct {
+			Enabled     bool `json:"enabled"`
+			MaxPerSpace int  `json:"maxPerSpace"` // Snapshots taken by hand kept per space
+		} `json:"snapshots"`
+		Previews struct {
+			Enabled     bool   `json:"enabled"`
+			Documents   bool   `json:"documents"`   // Also previews PDFs and videos, with the external tools below
+			PDFRenderer string `json:"pdfRenderer"` // pdftoppm binary, looked up in PATH by default
+			FFmpeg      string `json:"ffmpeg"`      // ffmpeg binary, looked up in PATH by default
+			MaxSize     int    `json:"maxSize"`     // Pixels on the longer side of previews
+		} `json:"previews"`
+		ColdStorage struct {
+			Enabled     bool   `json:"enabled"`
+			AfterMonths int    `json:"afterMonths"` // Attachments untouched for longer move to the cold tier
+			Path        string `json:"path"`        // Root of the cold tier, defaults to the cold subdirectory of the storage path
+		} `json:"coldStorage"`
+		Rules struct {
+			Enabled bool `json:"enabled"`
+		} `json:"rules"`
+		Memory struct {
+			Enabled  bool           `json:"enabled"`
+			BoundsMB map[string]int `json:"boundsMB"` // Subsystem -> estimated size above which a warning is raised
+		} `json:"memory"`
+		SupportBundle struct {
+			Enabled bool `json:"enabled"`
+		} `json:"supportBundle"`
+		SignedURLs struct {
+			Enabled bool `json:"enabled"`
+		} `json:"signedURLs"`
+		DirectoryImport struct {
+			Enabled bool `json:"enabled"`
+		} `json:"directoryImport"`
+		Publishing struct {
+			Enabled    bool   `json:"enabled"`
+			WebhookURL string `json:"webhookURL"` // Receiver of the rendered posts of published spaces
+			Secret     string `json:"secret"`     // Signs deliveries when set
+		} `json:"publishing"`
+		Board struct {
+			Enabled bool `json:"enabled"`
+		} `json:"board"`
+		Preferences struct {
+			Enabled bool `json:"enabled"`
+		} `json:"preferences"`
+		EditHistory struct {
+			Enabled      bool `json:"enabled"`
+			MaxRevisions int  `json:"maxRevisions"` // Previous revisions kept per post
+		} `json:"editHistory"`
+		Pinning struct {
+			Enabled     bool `json:"enabled"`
+			MaxPerSpace int  `json:"maxPerSpace"` // Posts pinned at once in a space
+		} `json:"pinning"`
+		RecordKeeping struct {
+			Enabled bool `json:"enabled"`
+		} `json:"recordKeeping"`
+		Reviews struct {
+			Enabled bool `json:"enabled"`
+		} `json:"reviews"`
+		WorkspaceArchive struct {
+			Enabled bool `json:"enabled"`
+		} `json:"workspaceArchive"`
+		GraphQL struct {
+			Enabled bool `json:"enabled"`
+		} `json:"graphql"`
+		MobileSync struct {
+			Enabled bool `json:"enabled"`
+		} `json:"mobileSync"`
+		DuplicateLinks struct {
+			Enabled          bool `json:"enabled"`
+			ReportDuplicates bool `json:"reportDuplicates"` // Create responses point at earlier posts holding the same links
+			MaxDuplicates    int  `json:"maxDuplicates"`    // Earlier posts listed per created post
+		} `json:"duplicateLinks"`
 	} `json:"features"`
+	Flags map[string]FeatureFlag `json:"flags"` // Feature flag name -> setting, see flags.Known
 }
 
 type SharedConfig struct {
@@ -173,7 +823,7 @@ func LoadSharedConfig() error {
 }
 
 func LoadServiceConfig() error {
-	data, err := os.ReadFile("service.json")
+	data, err := os.ReadFile(ServiceConfigFilename)
 	if err != nil {
 		return err
 	}
@@ -203,6 +853,13 @@ func LoadOptionsConfig() error {
 	}
 
 	optionsConfig = &config
+	// Older options.json files predate the locale setting
+	if optionsConfig.Metadata.Locale == "" {
+		optionsConfig.Metadata.Locale = DefaultLocale
+	}
+	if optionsConfig.Metadata.Theme == "" {
+		optionsConfig.Metadata.Theme = DefaultTheme
+	}
 	//06/10/2025
 	//Force disable markdown
 	optionsConfig.WithMarkdownEnabled(false)
@@ -267,6 +924,11 @@ func SetOptionsConfigForTest(config *OptionsConfig) {
 	optionsConfig = config
 }
 
+// SetSharedConfigForTest sets the shared config for testing purposes
+func SetSharedConfigForTest(config *SharedConfig) {
+	sharedConfig = config
+}
+
 // ANSI color codes
 const (
 	colorReset  = "\033[0m"
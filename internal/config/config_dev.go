@@ -10,8 +10,12 @@ func getEmbeddedConfig() []byte {
 }
 
 // GetAppMode returns the app mode for development builds
-// In dev builds, check the APP_ENV environment variable
+// In dev builds, check the APP_ENV environment variable; --dev always serves assets from disk
 func GetAppMode() string {
+	if devMode {
+		return APP_MODE_DEV
+	}
+
 	mode := os.Getenv("APP_ENV")
 
 	switch mode {
@@ -0,0 +1,25 @@
+package config
+
+import "time"
+
+var devMode bool
+
+// SetDevMode turns on the --dev mode: assets served from disk without caching and reloaded
+// live, verbose logging and relaxed outbound timeouts
+func SetDevMode(enabled bool) {
+	devMode = enabled
+}
+
+// IsDevMode reports whether the server was started with --dev
+func IsDevMode() bool {
+	return devMode
+}
+
+// HTTPTimeout returns an outbound request timeout, relaxed in dev mode where requests are
+// often stepped through a debugger
+func HTTPTimeout(timeout time.Duration) time.Duration {
+	if devMode {
+		return timeout * DevTimeoutFactor
+	}
+	return timeout
+}
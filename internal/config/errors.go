@@ -1,5 +1,7 @@
 package config
 
+import "errors"
+
 // Error messages for API handlers
 const (
 	// JSON and Request Errors
@@ -20,24 +22,64 @@ const (
 	// Feature Disabled Errors
 	ErrFileUploadDisabled        = "File upload is disabled"
 	ErrRetroactivePostingDisabled = "Retroactive posting is disabled"
+	ErrEditHistoryDisabled        = "Edit history is disabled"
+	ErrPinningDisabled            = "Post pinning is disabled"
 
 	// File Upload Errors
 	ErrFailedToParseForm = "Failed to parse multipart form"
 	ErrFailedToGetFile   = "Failed to get file"
 	ErrAccessDenied      = "Access denied"
+	ErrInvalidMediaType  = "Invalid media type. Must be image, video or all"
+	ErrFailedToGetMedia  = "Failed to get media"
+	ErrInvalidUploadSession = "Upload session ID must be 8 to 64 letters, digits, '-' or '_'"
+	ErrInvalidAttachmentID  = "Invalid attachment ID"
+	ErrAttachmentNotFound   = "Attachment not found"
+	ErrUploadFilenameRequired = "Filename is required"
+	ErrInvalidUploadSize      = "Size must be a non-negative number of bytes"
+	ErrUploadTargetRequired   = "Either post_id or space_id is required"
+	ErrFilenameCollisions     = "Too many files already stored under this filename"
+	ErrInvalidRedactRequest   = "Invalid request body. Must be JSON with regions"
+	ErrInvalidRedactRegions   = "Regions must be 1 to 50 rectangles of positive size overlapping the image, to blur or blackout"
+	ErrImageNotRedactable     = "Only PNG and JPEG images can be redacted"
+	ErrImageTooLargeToRedact  = "Image is too large to be redacted"
+
+	// Attachment Preview Errors
+	ErrPreviewUnavailable = "No preview is available for this file"
 
 	// Post Errors
 	ErrPostNotFound            = "Post not found"
 	ErrFailedToRetrievePost    = "Failed to retrieve updated post"
 	ErrFailedToGetPosts        = "Failed to get posts"
 	ErrTimestampTooEarly       = "Custom timestamp cannot be earlier than 01/01/2000"
+	ErrMergeRequiresTwoPosts   = "At least two distinct post IDs are required"
+	ErrMergeDifferentSpaces    = "Posts to merge must belong to the same space"
+	ErrSplitMarkersRequired    = "Either a delimiter or line offsets are required, but not both"
+	ErrSplitInvalidLineOffsets = "Line offsets must be increasing and within the post content"
+	ErrSplitRequiresTwoParts   = "Split must produce at least two non-empty parts"
+	ErrSplitInvalidAttachment  = "Attachment mapping references an unknown attachment or part"
+	ErrInvalidPostSource       = "Invalid source. Must be manual, api, email, webhook, cli, capture or import:<tool>"
+	ErrInvalidPostSort         = "Invalid sort. Must be created_desc, created_asc or updated_desc"
+	ErrInvalidPostCursor       = "Invalid cursor"
+	ErrPostCursorSort          = "Cursors only paginate the created_desc and created_asc orders"
+	ErrRetimeSelectionRequired = "A space or post IDs are required to select the posts to retime"
+	ErrRetimeModeRequired      = "Either a non-zero delta or a target range is required, but not both"
+	ErrRetimeInvalidRange      = "Target range must start no earlier than 01/01/2000, end no later than now and not end before it starts"
+	ErrRetimeInFuture          = "Retimed posts cannot be dated in the future"
 
 	// Space Errors
 	ErrSpaceNotFound          = "Space not found"
 	ErrSpaceNameInvalidFormat = "Space name must start with a letter or number, and can only contain letters, numbers, spaces, hyphens, underscores, apostrophes, and periods"
 
+	// Safe Mode Errors
+	ErrSafeModeEndpoint    = "Server is running in safe mode: only admin and diagnostic endpoints are available"
+	ErrSafeModeWriteLocked = "Server is running in safe mode: writes are locked"
+
 	// Settings Errors
 	ErrFailedToMarshalSettings = "Failed to marshal settings"
+	ErrBundleKeyRequired       = "A bundle key of at least 8 characters is required in the X-Backthynk-Bundle-Key header"
+	ErrBundleSignatureInvalid  = "Config bundle signature is invalid"
+	ErrBundleFormatUnsupported = "Config bundle format is not supported"
+	ErrSupportBundleFailed     = "Failed to build support bundle"
 
 	// Template Errors
 	ErrTemplateParsingError   = "Template parsing error"
@@ -47,8 +89,315 @@ const (
 	ErrInvalidURL      = "Invalid URL"
 	ErrURLNotHTML      = "URL does not return HTML content"
 
+	// Remote Attachment Errors
+	ErrRemoteURLInvalid   = "URL must be an absolute http or https URL"
+	ErrRemoteURLForbidden = "URL does not resolve to a public address"
+	ErrTooManyRedirects   = "Too many redirects"
+	ErrRemoteFileIsHTML   = "URL returned a web page instead of a file"
+
 	// Activity Feature Errors
-	ErrFailedToGetActivity = "Failed to get activity data: "
+	ErrFailedToGetActivity  = "Failed to get activity data: "
+	ErrInvalidComparePeriod = "Invalid comparison period. Must be 0 (current period) or more periods back"
+	ErrInvalidBreakdownTop = "Invalid top parameter. Must be between 1 and 20"
+	ErrInvalidSinceVersion = "Invalid since_version parameter. Must be a data version"
+
+	// Detailed Stats Feature Errors
+	ErrInvalidHistoryDays    = "Invalid days parameter. Must be between 1 and 730"
+	ErrInvalidDownloadsLimit = "Invalid limit parameter. Must be between 1 and 100"
+
+	// Stale Spaces Feature Errors
+	ErrInvalidStaleDays = "Invalid days parameter. Must be between 1 and 3650"
+
+	// Tasks Feature Errors
+	ErrInvalidTaskIndex = "Invalid task index"
+	ErrTaskNotFound     = "Task not found"
+
+	// Metrics Feature Errors
+	ErrInvalidMetricName = "Invalid metric name"
+
+	// Glossary Feature Errors
+	ErrInvalidGlossaryTermID  = "Invalid glossary term ID"
+	ErrGlossaryTermNotFound   = "Glossary term not found"
+	ErrGlossaryTermRequired   = "Term is required"
+	ErrGlossaryDefinitionRequired = "Definition is required"
+
+	// Live Feed Errors
+	ErrStreamingUnsupported = "Streaming is not supported by this connection"
+	ErrCrossOriginSocket    = "WebSocket connections from other sites are not allowed"
+	ErrInvalidFeedEventType = "Invalid event type. Must be post.created, post.updated, post.deleted, post.moved, space.updated or file.uploaded"
+
+	// Change Log Errors
+	ErrInvalidChangeSeq    = "Invalid since parameter. Must be a change sequence number"
+	ErrInvalidChangesLimit = "Invalid limit parameter. Must be between 1 and 1000"
+	ErrChangesExpired      = "Changes since this sequence number are no longer kept, reload everything"
+
+	// Snapshots Feature Errors
+	ErrInvalidSnapshotID        = "Invalid snapshot ID"
+	ErrSnapshotNotFound         = "Snapshot not found"
+	ErrSnapshotNameRequired     = "Snapshot name is required and must be 100 characters or less"
+	ErrInvalidSnapshotSchedule  = "Invalid schedule. Interval must be between 0 and 720 hours and keep between 1 and 100"
+	ErrInvalidRestoreMode       = "Invalid restore mode. Must be new or replace"
+	ErrRestoreNameRequired      = "A space name is required to restore into a new space"
+	ErrSnapshotSpaceGone        = "The snapshot space no longer exists, restore it into a new space instead"
+	ErrSnapshotTooDeep          = "The restored spaces would exceed the maximum space depth"
+	ErrFmtSnapshotLimitReached  = "A space keeps at most %d snapshots, delete one first"
+	ErrFmtSnapshotFileMissing   = "Snapshot file %q is missing from the snapshot store"
+
+	// Cold Storage Feature Errors
+	ErrFmtColdFileMissing = "Attachment %q is missing from the cold tier"
+
+	// Trash Feature Errors
+	ErrInvalidTrashDays     = "Invalid days parameter. Must be between 1 and 365"
+	ErrInvalidTrashItemID   = "Invalid trash item ID"
+	ErrTrashItemNotFound    = "Trash item not found"
+	ErrTrashItemNotPost     = "Only deleted posts can be restored"
+	ErrTrashSpaceGone       = "The space this post was deleted from no longer exists"
+	ErrTrashSpaceNotFound   = "Deleted space not found in the trash"
+	ErrTrashParentGone      = "The parent this space was deleted from no longer exists, restore it first"
+	ErrTrashSpaceNameTaken  = "A space with the same name now exists in its place, rename it first"
+	ErrTrashSpaceTooDeep    = "The restored spaces would exceed the maximum space depth"
+
+	// Tags Feature Errors
+	ErrInvalidTag              = "Invalid tag"
+	ErrTagNotFound             = "Tag not found"
+	ErrTagAlreadyExists        = "Target tag already exists, merge the tags instead"
+	ErrTagMergeSourcesRequired = "At least one source tag different from the target is required"
+	ErrInvalidBulkTagAction    = "Invalid action. Must be add or remove"
+	ErrBulkTagFilterRequired   = "A space_id, post_ids or contains filter is required"
+	ErrInvalidAuditLimit       = "Invalid limit parameter. Must be between 1 and 500"
+
+	// Moderation Feature Errors
+	ErrInvalidModerationFlagID = "Invalid moderation flag ID"
+	ErrModerationFlagNotFound  = "Moderation flag not found"
+	ErrInvalidModerationStatus = "Invalid status. Must be approved or rejected"
+	ErrFmtInvalidModerationRule = "Invalid moderation rule %q: %v"
+
+	// Secret Scanning Errors
+	ErrContentContainsSecrets     = "Content contains secrets"
+	ErrFmtContentContainsSecret   = "Content contains secrets: %s"
+	ErrInvalidSecretFindingsLimit = "Invalid limit parameter. Must be between 1 and 1000"
+
+	// Maintenance Errors
+	ErrMaintenanceRunning = "Maintenance is already running"
+
+	// Integrity Check Errors
+	ErrStartupReportUnavailable = "Startup integrity check has not run"
+
+	// Journal Errors
+	ErrInvalidPostType     = "Invalid post type. Must be note or journal"
+	ErrInvalidJournalDate  = "Invalid journal date. Must be YYYY-MM-DD"
+	ErrJournalDateInFuture = "Journal entries cannot be created for future days"
+	ErrJournalDayTaken     = "The target space already has a journal entry for this day"
+
+	// Cross-post Errors
+	ErrCrosspostOwnSpace    = "A post cannot be cross-posted to its own space"
+	ErrCrosspostNotFound    = "Post is not cross-posted to this space"
+	ErrFmtTooManyCrossposts = "A post can be cross-posted to at most %d spaces"
+
+	// Pinned Post Errors
+	ErrFmtTooManyPinnedPosts = "A space can have at most %d pinned posts, unpin one first"
+
+	// Related Posts Errors
+	ErrInvalidRelatedLimit = "Invalid limit parameter. Must be between 1 and 50"
+
+	// Language Stats Errors
+	ErrInvalidLanguageMonths = "Invalid months parameter. Must be between 1 and 120"
+
+	// Delta Export Errors
+	ErrInvalidExportSince = "Invalid since parameter. Must be a unix timestamp in milliseconds"
+
+	// Search Errors
+	ErrSearchQueryRequired         = "Search query is required"
+	ErrSearchQueryTooLong          = "Search query cannot exceed 500 characters"
+	ErrInvalidSearchMode           = "Invalid search mode. Must be keyword or semantic"
+	ErrInvalidSearchLimit          = "Invalid limit parameter. Must be between 1 and 100"
+	ErrFmtUnknownEmbeddingProvider = "Unknown embeddings provider %q"
+	ErrEmbeddingAPIURLRequired     = "Embeddings API URL is required for the api provider"
+	ErrReindexRunning              = "A search reindex is already running"
+
+	// Summary Errors
+	ErrSummarizerURLRequired   = "Summarizer endpoint URL is required when summaries are enabled"
+	ErrPostTooShortToSummarize = "Post is too short to summarize"
+	ErrInvalidDigestWeek       = "Invalid week. Must be a YYYY-MM-DD date that is not in the future"
+
+	// Ingest Token Errors
+	ErrInvalidIngestTokenID       = "Invalid ingest token ID"
+	ErrIngestTokenNotFound        = "Ingest token not found"
+	ErrIngestTokenRevoked         = "This ingest token has been revoked"
+	ErrIngestRateLimited          = "Rate limit exceeded for this ingest token"
+	ErrIngestTokenNameRequired    = "Ingest token name is required"
+	ErrIngestTokenNameTooLong     = "Ingest token name cannot exceed 100 characters"
+	ErrInvalidIngestRateLimit     = "Invalid rate limit. Must be between 1 and 3600 requests per hour"
+	ErrInvalidIngestActivityLimit = "Invalid limit parameter. Must be between 1 and 200"
+	ErrIngestPayloadTooLarge      = "Payload cannot exceed 1MB"
+
+	// Feature Flag Errors
+	ErrFeatureFlagNotFound = "Feature flag not found"
+	ErrInvalidFlagRollout  = "Invalid rollout. Must be between 0 and 100 percent"
+
+	// Share Link Errors
+	ErrInvalidShareLinkID        = "Invalid share link ID"
+	ErrShareLinkNotFound         = "Share link not found"
+	ErrShareLinkRevoked          = "This share link has been revoked"
+	ErrShareLinkExpired          = "This share link has expired"
+	ErrShareLinkRateLimited      = "Rate limit exceeded for this share link"
+	ErrShareLinkNameRequired     = "Share link name is required"
+	ErrShareLinkNameTooLong      = "Share link name cannot exceed 100 characters"
+	ErrInvalidShareLinkRateLimit = "Invalid rate limit. Must be between 1 and 600 posts per hour"
+	ErrInvalidShareLinkExpiry    = "Invalid expiry. Must be between 1 and 720 hours"
+	ErrShareAuthorRequired       = "Author name is required"
+	ErrShareAuthorTooLong        = "Author name cannot exceed 50 characters"
+	ErrShareBlockedByModeration  = "The space has flagged content awaiting review and cannot be shared"
+	ErrInvalidSharedPostsLimit   = "Invalid limit parameter. Must be between 1 and 100"
+
+	// Notification Errors
+	ErrInvalidNotificationID     = "Invalid notification ID"
+	ErrNotificationNotFound      = "Notification not found"
+	ErrInvalidNotificationsLimit = "Invalid limit parameter. Must be between 1 and 200"
+	ErrInvalidNotificationKind   = "Invalid notification kind"
+	ErrInvalidQuietHours         = "Quiet hours must have a different HH:MM start and end"
+
+	// Saved Filter Errors
+	ErrInvalidSavedFilterID    = "Invalid saved filter ID"
+	ErrSavedFilterNotFound     = "Saved filter not found"
+	ErrSavedFilterNameRequired = "Filter name is required"
+	ErrSavedFilterExists       = "A saved filter with this name already exists"
+	ErrInvalidFilterExtension  = "Invalid file extension in filter"
+	ErrInvalidDateRange        = "Invalid date range. Dates must be YYYY-MM-DD with start before end"
+
+	// Custom Field Errors
+	ErrFieldsNotAllowedOnJournal = "Custom fields cannot be set on journal posts"
+
+	// Public Endpoint Guard Errors
+	ErrPublicTokenRevoked   = "This link has been revoked"
+	ErrPublicTokenNotFound  = "Public token not found"
+	ErrPublicBudgetExceeded = "Request budget exceeded for this link"
+	ErrSignatureRequired    = "Signed request required"
+	ErrSignatureExpired     = "Request timestamp is missing or outside the allowed window"
+	ErrSignatureInvalid     = "Invalid request signature"
+	ErrSignatureReplayed    = "Request nonce has already been used"
+
+	// Signed URL Errors
+	ErrInvalidSignedURLTTL = "Invalid ttl parameter. Must be between 1 and 2592000 seconds"
+	ErrSignedURLInvalid    = "Signed URL is invalid"
+	ErrSignedURLExpired    = "Signed URL has expired"
+
+	// Directory Import Errors
+	ErrDirImportPathRequired  = "An absolute directory path is required"
+	ErrDirImportNotDirectory  = "Path is not a readable directory"
+	ErrDirImportParentTooDeep = "Parent space is too deep to receive a directory"
+	ErrDirImportTooManyFiles  = "Directory has more than 5000 files to import, narrow it with include or exclude patterns"
+	ErrInvalidDirImportGlob   = "Invalid include or exclude pattern"
+
+	// Publishing Errors
+	ErrPublishWebhookURLRequired = "Publishing webhook URL is required when publishing is enabled"
+
+	// Board Errors
+	ErrBoardFull          = "A space board holds at most 12 posts, unpin one first"
+	ErrBoardPinNotFound   = "Post is not pinned to the board"
+	ErrBoardOrderMismatch = "Board order must list every post pinned to the space once"
+
+	// Preference Errors
+	ErrInvalidLandingView     = "Landing view must be space, timeline, inbox or dashboard"
+	ErrLandingSpaceRequired   = "landing_space_id is required for the space view"
+	ErrLandingSpaceNotAllowed = "landing_space_id is only used by the space view"
+
+	// Record Keeping Errors
+	ErrInvalidRecordRetention = "retention_days must be between 1 and 36500"
+
+	// Space Review Errors
+	ErrInvalidReviewInterval = "interval_days must be between 1 and 3650"
+	ErrReviewNotScheduled    = "Space is not scheduled for review"
+
+	// Request Cost Errors
+	ErrRequestCostExceeded = "Request goes through more spaces or posts than allowed, narrow it down or raise the request cost caps"
+
+	// Workspace Archive Errors
+	ErrWorkspaceExportFailed       = "Failed to export workspace"
+	ErrWorkspaceArchiveInvalid     = "Not a Backthynk workspace archive"
+	ErrWorkspaceArchiveUnsupported = "Workspace archive was written by a newer version of Backthynk"
+	ErrWorkspaceArchiveUnsafePath  = "Workspace archive holds a path outside its data directories"
+	ErrWorkspaceNotEmpty           = "Storage already holds data, pass -force to move it aside first"
+	ErrWorkspaceArchiveEncrypted   = "Workspace archive is encrypted, set " + ArchivePassphraseEnv + " to its passphrase"
+	ErrWorkspaceArchivePassphrase  = "Wrong passphrase or damaged workspace archive"
+	ErrArchivePassphraseTooShort   = "Archive passphrase must be at least 12 characters"
+	ErrInvalidWorkspaceExport      = "Invalid workspace export request"
+
+	// Mobile Sync Errors
+	ErrInvalidProfile   = "profile must be mobile"
+	ErrInvalidSyncSince = "since must be a timestamp in milliseconds"
+
+	// Duplicate Links Errors
+	ErrInvalidLinksLimit = "Invalid limit parameter. Must be between 1 and 500"
+
+	// GraphQL Errors
+	ErrGraphQLQueryRequired       = "A query is required"
+	ErrGraphQLQueryTooLong        = "Query is longer than 16384 bytes"
+	ErrGraphQLTooDeep             = "Query nests selections deeper than 8 levels"
+	ErrGraphQLOnlyQueries         = "Only query operations are supported"
+	ErrGraphQLOperationNeeded     = "operationName is required when the document holds several operations"
+	ErrGraphQLInvalidLimit        = "limit must be between 1 and 100"
+	ErrGraphQLInvalidOffset       = "offset must not be negative"
+	ErrGraphQLInvalidPeriodMonths = "period_months must be at least 1"
+	ErrFailedToGetAttachments     = "Failed to get attachments"
+	ErrFailedToGetLinkPreviews    = "Failed to get link previews"
+
+	ErrFmtGraphQLSyntax              = "Syntax error at offset %d: %s"
+	ErrFmtGraphQLUnknownOperation    = "Unknown operation %q"
+	ErrFmtGraphQLUnknownType         = "Unknown type %q"
+	ErrFmtGraphQLUnknownField        = "Cannot query field %q on type %s"
+	ErrFmtGraphQLUnknownArgument     = "Unknown argument %q on field %s.%s"
+	ErrFmtGraphQLInvalidArgument     = "Argument %q on field %s.%s must be %s"
+	ErrFmtGraphQLSelectionRequired   = "Field %s.%s of type %s needs a selection of subfields"
+	ErrFmtGraphQLSelectionNotAllowed = "Field %s.%s of type %s has no subfields"
+	ErrFmtGraphQLFieldConflict       = "Fields named %q select different fields or arguments"
+	ErrFmtGraphQLUnknownFragment     = "Unknown fragment %q"
+	ErrFmtGraphQLFragmentCycle       = "Fragment %q spreads itself"
+	ErrFmtGraphQLUnknownDirective    = "Unknown directive @%s"
+	ErrFmtGraphQLInvalidDirective    = "Directive @%s needs a Boolean if argument"
+	ErrFmtGraphQLUnknownVariable     = "Variable $%s is not defined"
+	ErrFmtGraphQLInvalidVariable     = "Variable $%s must be %s"
+
+	// Authentication Errors
+	ErrOIDCConfigIncomplete = "OIDC login requires issuer, clientID and redirectURL"
+	ErrOIDCInvalidRole      = "OIDC roles must be admin, editor or viewer"
+	ErrOIDCUnavailable      = "Login provider is unavailable, try again later"
+	ErrLoginStateInvalid    = "Login expired or was not started here, sign in again"
+	ErrLoginFailed          = "Login failed"
+	ErrLoginNoRole          = "Your account has no role on this instance"
+	ErrAuthRequired         = "Authentication required"
+	ErrRoleForbidden        = "Your role does not allow this action"
+	ErrInvalidSessionID     = "Invalid session ID"
+	ErrSessionNotFound      = "Session not found"
+	ErrLocalAuthInvalidRole = "Local account roles must be admin, editor or viewer"
+	ErrInvalidUsername      = "Username must be 3 to 32 lowercase letters, digits, '.', '-' or '_'"
+	ErrPasswordTooShort     = "Password must be at least 8 characters"
+	ErrPasswordTooLong      = "Password cannot exceed 72 bytes"
+	ErrUsernameTaken        = "Username is already taken"
+	ErrRegistrationClosed   = "Registration is closed on this instance"
+	ErrInvalidCredentials   = "Invalid username or password"
+
+	// Space Access Errors
+	ErrSpaceAccessDenied      = "You do not have write access to this space"
+	ErrInvalidSpacePermission = "Space permission must be read or write"
+	ErrDuplicateSpaceACLUser  = "Each user can only be listed once in a space access list"
+	ErrACLUserNotFound        = "User not found"
+
+	// Automation Rule Errors
+	ErrInvalidRuleID         = "Invalid rule ID"
+	ErrRuleNotFound          = "Rule not found"
+	ErrRuleNameRequired      = "Rule name is required"
+	ErrRuleNameTooLong       = "Rule name cannot exceed 100 characters"
+	ErrRuleExists            = "A rule with this name already exists"
+	ErrInvalidRuleTrigger    = "Invalid trigger. Must be post.created, post.updated or post.moved"
+	ErrRuleActionsRequired   = "At least one action is required"
+	ErrInvalidRuleAction     = "Invalid action type. Must be add_tag, move, webhook or reminder"
+	ErrInvalidRulePattern    = "Invalid content pattern"
+	ErrInvalidRuleWebhookURL = "Webhook URL must be an http or https URL"
+	ErrInvalidRuleReminder   = "Reminder delay must be between 1 and 8760 hours"
+	ErrRuleMessageTooLong    = "Reminder message cannot exceed 500 characters"
+	ErrRuleTestPostRequired  = "A post_id to test the rule against is required"
+	ErrInvalidRuleLogLimit   = "Invalid limit parameter. Must be between 1 and 200"
 )
 
 // Error message format strings (for dynamic error messages)
@@ -57,6 +406,20 @@ const (
 	ErrFmtContentExceedsMaxLength  = "Content exceeds maximum length of %d characters"
 	ErrFmtFileSizeExceedsMax       = "File size exceeds maximum allowed (%dMB)"
 	ErrFmtFileExtensionNotAllowed  = "File extension '%s' is not allowed"
+	ErrFmtMaxFilesPerPostReached   = "Post already has the maximum of %d files"
+	ErrFmtNotEnoughDiskSpace       = "Not enough free disk space for this file (%dMB available)"
+	ErrFmtRemoteFetchFailed        = "Failed to fetch remote file: %s"
+	ErrFmtGlossaryTermTooLong      = "Term exceeds maximum length of %d characters"
+	ErrFmtGlossaryDefinitionTooLong = "Definition exceeds maximum length of %d characters"
+	ErrFmtUnsupportedAPIVersion    = "Unsupported API version %s"
+	ErrFmtDeprecatedAPIVersion     = "API version %d is deprecated, use version %d"
+	ErrFmtSavedFilterNameTooLong   = "Filter name exceeds maximum length of %d characters"
+	ErrFmtTooManyFilterTerms       = "Filters accept at most %d tags and at most %d extensions"
+	ErrFmtInvalidFieldSchema       = "Invalid field schema: %s"
+	ErrFmtInvalidFieldValue        = "Invalid value for field %q: %s"
+	ErrFmtUnknownField             = "Unknown field %q"
+	ErrFmtTooManyRuleActions       = "A rule can have at most %d actions"
+	ErrFmtTooManyRuleTags          = "A rule can require at most %d tags"
 )
 
 // Validation error messages
@@ -64,6 +427,16 @@ const (
 	ErrValidationMaxFileSizeRange     = "maxFileSizeMB must be between 1 and 10240"
 	ErrValidationMaxContentLengthRange = "maxContentLength must be between 100 and 50000"
 	ErrValidationMaxFilesPerPostRange  = "maxFilesPerPost must be between 1 and 50"
+	ErrValidationMaxVersionsPerFileRange = "maxVersionsPerFile must be between 1 and 100"
 	ErrValidationSiteTitleRange        = "siteTitle must be between 1 and 100 characters"
 	ErrValidationSiteDescriptionMax    = "siteDescription must not exceed 160 characters"
+	ErrValidationLocaleUnsupported     = "locale is not supported"
+	ErrValidationThemeUnsupported      = "theme must be light or dark"
+	ErrValidationAccentInvalid         = "accent must be a hex color such as #2563eb"
+	ErrValidationJobBudgetNegative     = "jobs budget values must not be negative"
+)
+
+// Sentinel errors, matched with errors.Is
+var (
+	ErrUnknownPost = errors.New(ErrPostNotFound)
 )
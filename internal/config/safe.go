@@ -0,0 +1,33 @@
+package config
+
+import "reflect"
+
+var safeMode bool
+
+// SetSafeMode turns on the --safe-mode startup: feature services are left off, caches are
+// filled on demand and only admin and diagnostic endpoints answer, with writes locked
+func SetSafeMode(enabled bool) {
+	safeMode = enabled
+}
+
+// IsSafeMode reports whether the server was started with --safe-mode
+func IsSafeMode() bool {
+	return safeMode
+}
+
+// SafeModeOptions returns a copy of o with every feature disabled. The loaded options are left
+// untouched, so the settings shown and saved are still the configured ones.
+func SafeModeOptions(o *OptionsConfig) *OptionsConfig {
+	safe := *o
+	features := reflect.ValueOf(&safe.Features).Elem()
+	for i := 0; i < features.NumField(); i++ {
+		feature := features.Field(i)
+		if feature.Kind() != reflect.Struct {
+			continue
+		}
+		if enabled := feature.FieldByName("Enabled"); enabled.IsValid() && enabled.Kind() == reflect.Bool {
+			enabled.SetBool(false)
+		}
+	}
+	return &safe
+}
@@ -10,6 +10,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // EnsureConfigFiles checks if service.json and options.json exist,
@@ -104,12 +105,21 @@ func createOptionsConfig() error {
 			Metadata: struct {
 				Title       string `json:"title"`
 				Description string `json:"description"`
+				Locale      string `json:"locale"`
+				Theme       string `json:"theme"`
+				Accent      string `json:"accent"`
 			}{
 				Title:       "Backthynk",
 				Description: "A simple, lightweight micro-blogging service for people who think too fast.",
+				Locale:      DefaultLocale,
+				Theme:       DefaultTheme,
 			},
 		}
 
+		defaultConfig.Jobs.MaxConcurrent = DefaultMaxConcurrentJobs
+		defaultConfig.Jobs.MaxIOMBps = DefaultJobMaxIOMBps
+		defaultConfig.Jobs.PauseLatencyMs = DefaultJobPauseLatencyMs
+
 		// Initialize features
 		defaultConfig.Features.Activity.Enabled = true
 		defaultConfig.Features.Activity.PeriodMonths = 4
@@ -119,12 +129,85 @@ func createOptionsConfig() error {
 		defaultConfig.Features.FileUpload.Enabled = true
 		defaultConfig.Features.FileUpload.MaxFileSizeMB = 100
 		defaultConfig.Features.FileUpload.MaxFilesPerPost = 25
+		defaultConfig.Features.FileUpload.MaxVersionsPerFile = DefaultVersionsPerFile
+		defaultConfig.Features.FileUpload.KeepRedactedOriginals = false
 		defaultConfig.Features.FileUpload.AllowedExtensions = []string{
 			"jpg", "jpeg", "png", "gif", "webp", "pdf", "doc", "docx",
 			"xls", "xlsx", "txt", "zip", "mp4", "mov", "avi", "rar",
 			"7z", "mp3", "wav", "ogg", "flac", "m4a", "json", "csv",
 			"yaml", "yml", "md", "xml", "ppt", "pptx", "odt", "ods", "odp",
 		}
+		defaultConfig.Features.StaleSpaces.Enabled = true
+		defaultConfig.Features.StaleSpaces.ThresholdDays = DefaultStaleDays
+		defaultConfig.Features.Tasks.Enabled = true
+		defaultConfig.Features.Metrics.Enabled = true
+		defaultConfig.Features.Glossary.Enabled = true
+		defaultConfig.Features.PublicGuard.Enabled = true
+		defaultConfig.Features.PublicGuard.RequestBudget = DefaultPublicRequestBudget
+		defaultConfig.Features.PublicGuard.BudgetWindowMinutes = int(DefaultPublicBudgetWindow / time.Minute)
+		defaultConfig.Features.PublicGuard.MaxClockSkewSeconds = int(DefaultSignatureMaxSkew / time.Second)
+		defaultConfig.Features.Trash.Enabled = true
+		defaultConfig.Features.Trash.PostRetentionDays = DefaultPostRetentionDays
+		defaultConfig.Features.Trash.AttachmentRetentionDays = DefaultAttachmentRetentionDays
+		defaultConfig.Features.Trash.SpaceRetentionDays = DefaultSpaceRetentionDays
+		defaultConfig.Features.LiveFeed.Enabled = true
+		defaultConfig.Features.ChangeLog.Enabled = true
+		defaultConfig.Features.ChangeLog.RetentionDays = DefaultChangeLogRetentionDays
+		defaultConfig.Features.RequestCost.Enabled = true
+		defaultConfig.Features.RequestCost.MaxSpaces = DefaultMaxRequestSpaces
+		defaultConfig.Features.RequestCost.MaxPosts = DefaultMaxRequestPosts
+		defaultConfig.Features.Tags.Enabled = true
+		defaultConfig.Features.Moderation.Enabled = true
+		defaultConfig.Features.Moderation.Rules = DefaultModerationRules()
+		defaultConfig.Features.SecretScan.Enabled = true
+		defaultConfig.Features.SecretScan.Mode = SecretScanModeWarn
+		defaultConfig.Features.Maintenance.Enabled = true
+		defaultConfig.Features.Maintenance.QuietHoursStart = DefaultMaintenanceQuietStart
+		defaultConfig.Features.Maintenance.QuietHoursEnd = DefaultMaintenanceQuietEnd
+		defaultConfig.Features.StartupCheck.Enabled = true
+		defaultConfig.Features.SavedFilters.Enabled = true
+		defaultConfig.Features.RelatedPosts.Enabled = true
+		defaultConfig.Features.LanguageStats.Enabled = true
+		defaultConfig.Features.DeltaExport.Enabled = true
+		defaultConfig.Features.Search.Enabled = true
+		defaultConfig.Features.Search.Semantic.Enabled = false
+		defaultConfig.Features.Search.Semantic.Provider = EmbeddingProviderLocal
+		defaultConfig.Features.Summaries.Enabled = false
+		defaultConfig.Features.Summaries.MinPostLength = DefaultSummaryMinPostLength
+		defaultConfig.Features.Ingest.Enabled = true
+		defaultConfig.Features.ShareLinks.Enabled = true
+		defaultConfig.Features.ResultCache.Enabled = true
+		defaultConfig.Features.ResultCache.TTLSeconds = DefaultResultCacheTTLSeconds
+		defaultConfig.Flags = map[string]FeatureFlag{}
+		defaultConfig.Features.Notifications.Enabled = true
+		defaultConfig.Features.Notifications.RetentionDays = DefaultNotificationRetentionDays
+		defaultConfig.Features.Memory.Enabled = true
+		defaultConfig.Features.Snapshots.Enabled = true
+		defaultConfig.Features.Snapshots.MaxPerSpace = DefaultMaxSnapshotsPerSpace
+		defaultConfig.Features.Previews.Enabled = true
+		defaultConfig.Features.Previews.Documents = false
+		defaultConfig.Features.Previews.MaxSize = DefaultPreviewMaxSize
+		defaultConfig.Features.ColdStorage.Enabled = false
+		defaultConfig.Features.ColdStorage.AfterMonths = DefaultColdStorageAfterMonths
+		defaultConfig.Features.Rules.Enabled = true
+		defaultConfig.Features.SupportBundle.Enabled = true
+		defaultConfig.Features.SignedURLs.Enabled = true
+		defaultConfig.Features.DirectoryImport.Enabled = true
+		defaultConfig.Features.Publishing.Enabled = false
+		defaultConfig.Features.Board.Enabled = true
+		defaultConfig.Features.Preferences.Enabled = true
+		defaultConfig.Features.EditHistory.Enabled = true
+		defaultConfig.Features.EditHistory.MaxRevisions = DefaultMaxPostRevisions
+		defaultConfig.Features.Pinning.Enabled = true
+		defaultConfig.Features.Pinning.MaxPerSpace = DefaultMaxPinnedPosts
+		defaultConfig.Features.RecordKeeping.Enabled = true
+		defaultConfig.Features.Reviews.Enabled = true
+		defaultConfig.Features.WorkspaceArchive.Enabled = true
+		defaultConfig.Features.GraphQL.Enabled = false
+		defaultConfig.Features.MobileSync.Enabled = false
+		defaultConfig.Features.DuplicateLinks.Enabled = true
+		defaultConfig.Features.DuplicateLinks.ReportDuplicates = true
+		defaultConfig.Features.DuplicateLinks.MaxDuplicates = DefaultMaxDuplicateLinks
 
 		data, err = json.MarshalIndent(defaultConfig, "", "  ")
 		if err != nil {
@@ -272,6 +355,50 @@ func PrintStartupInfo(port string, opts *OptionsConfig) {
 		{"Detailed Statistics", opts.Features.DetailedStats.Enabled},
 		{"Retroactive Posting", opts.Features.RetroactivePosting.Enabled},
 		{"File Uploads", opts.Features.FileUpload.Enabled},
+		{"Stale Space Detection", opts.Features.StaleSpaces.Enabled},
+		{"Task Checklists", opts.Features.Tasks.Enabled},
+		{"Post Metrics", opts.Features.Metrics.Enabled},
+		{"Glossary", opts.Features.Glossary.Enabled},
+		{"Public Endpoint Guard", opts.Features.PublicGuard.Enabled},
+		{"Trash", opts.Features.Trash.Enabled},
+		{"Live Space Feed", opts.Features.LiveFeed.Enabled},
+		{"Change Log", opts.Features.ChangeLog.Enabled},
+		{"Request Cost Caps", opts.Features.RequestCost.Enabled},
+		{"Tag Management", opts.Features.Tags.Enabled},
+		{"Share Moderation", opts.Features.Moderation.Enabled},
+		{"Secret Scanning", opts.Features.SecretScan.Enabled},
+		{"Database Maintenance", opts.Features.Maintenance.Enabled},
+		{"Startup Integrity Check", opts.Features.StartupCheck.Enabled},
+		{"Saved Filters", opts.Features.SavedFilters.Enabled},
+		{"Related Posts", opts.Features.RelatedPosts.Enabled},
+		{"Language Stats", opts.Features.LanguageStats.Enabled},
+		{"Delta Export", opts.Features.DeltaExport.Enabled},
+		{"Search", opts.Features.Search.Enabled},
+		{"Semantic Search", opts.Features.Search.Enabled && opts.Features.Search.Semantic.Enabled},
+		{"Summaries", opts.Features.Summaries.Enabled},
+		{"Space Ingest Tokens", opts.Features.Ingest.Enabled},
+		{"Collaborative Share Links", opts.Features.ShareLinks.Enabled},
+		{"Aggregation Result Cache", opts.Features.ResultCache.Enabled},
+		{"Notification Center", opts.Features.Notifications.Enabled},
+		{"Memory Reporting", opts.Features.Memory.Enabled},
+		{"Space Snapshots", opts.Features.Snapshots.Enabled},
+		{"Attachment Previews", opts.Features.Previews.Enabled},
+		{"Attachment Cold Storage", opts.Features.ColdStorage.Enabled},
+		{"Automation Rules", opts.Features.Rules.Enabled},
+		{"Support Bundle", opts.Features.SupportBundle.Enabled},
+		{"Signed URLs", opts.Features.SignedURLs.Enabled},
+		{"Directory Import", opts.Features.DirectoryImport.Enabled},
+		{"Publishing", opts.Features.Publishing.Enabled},
+		{"Board", opts.Features.Board.Enabled},
+		{"Preferences", opts.Features.Preferences.Enabled},
+		{"Edit History", opts.Features.EditHistory.Enabled},
+		{"Post Pinning", opts.Features.Pinning.Enabled},
+		{"Record Keeping", opts.Features.RecordKeeping.Enabled},
+		{"Space Reviews", opts.Features.Reviews.Enabled},
+		{"Workspace Archive", opts.Features.WorkspaceArchive.Enabled},
+		{"GraphQL", opts.Features.GraphQL.Enabled},
+		{"Mobile Sync", opts.Features.MobileSync.Enabled},
+		{"Duplicate Links", opts.Features.DuplicateLinks.Enabled},
 	}
 
 	for _, f := range features {
@@ -3,71 +3,90 @@ package config
 // NewTestOptionsConfig creates a default OptionsConfig for testing.
 // You can override specific values by modifying the returned config.
 func NewTestOptionsConfig() *OptionsConfig {
-	return &OptionsConfig{
-		Core: struct {
-			MaxContentLength int `json:"maxContentLength"`
-		}{
-			MaxContentLength: 10000,
-		},
-		Features: struct {
-			Activity struct {
-				Enabled      bool `json:"enabled"`
-				PeriodMonths int  `json:"periodMonths"`
-			} `json:"activity"`
-			DetailedStats struct {
-				Enabled bool `json:"enabled"`
-			} `json:"detailedStats"`
-			RetroactivePosting struct {
-				Enabled    bool   `json:"enabled"`
-				TimeFormat string `json:"timeFormat"`
-			} `json:"retroactivePosting"`
-			Markdown struct {
-				Enabled bool `json:"enabled"`
-			} `json:"markdown"`
-			FileUpload struct {
-				Enabled           bool     `json:"enabled"`
-				MaxFileSizeMB     int      `json:"maxFileSizeMB"`
-				MaxFilesPerPost   int      `json:"maxFilesPerPost"`
-				AllowedExtensions []string `json:"allowedExtensions"`
-			} `json:"fileUpload"`
-		}{
-			Activity: struct {
-				Enabled      bool `json:"enabled"`
-				PeriodMonths int  `json:"periodMonths"`
-			}{
-				Enabled:      true,
-				PeriodMonths: 4,
-			},
-			DetailedStats: struct {
-				Enabled bool `json:"enabled"`
-			}{
-				Enabled: true,
-			},
-			RetroactivePosting: struct {
-				Enabled    bool   `json:"enabled"`
-				TimeFormat string `json:"timeFormat"`
-			}{
-				Enabled:    false,
-				TimeFormat: "24h",
-			},
-			Markdown: struct {
-				Enabled bool `json:"enabled"`
-			}{
-				Enabled: false,
-			},
-			FileUpload: struct {
-				Enabled           bool     `json:"enabled"`
-				MaxFileSizeMB     int      `json:"maxFileSizeMB"`
-				MaxFilesPerPost   int      `json:"maxFilesPerPost"`
-				AllowedExtensions []string `json:"allowedExtensions"`
-			}{
-				Enabled:           true,
-				MaxFileSizeMB:     5,
-				MaxFilesPerPost:   25,
-				AllowedExtensions: []string{"jpg", "jpeg", "png", "gif", "webp", "pdf", "doc", "docx", "xls", "xlsx", "txt", "zip", "mp4", "mov", "avi"},
-			},
-		},
-	}
+	options := &OptionsConfig{}
+	options.Core.MaxContentLength = 10000
+	options.Metadata.Title = "Backthynk"
+	options.Metadata.Locale = DefaultLocale
+	options.Metadata.Theme = DefaultTheme
+
+	options.Features.Activity.Enabled = true
+	options.Features.Activity.PeriodMonths = 4
+	options.Features.DetailedStats.Enabled = true
+	options.Features.RetroactivePosting.Enabled = false
+	options.Features.RetroactivePosting.TimeFormat = "24h"
+	options.Features.Markdown.Enabled = false
+	options.Features.FileUpload.Enabled = true
+	options.Features.FileUpload.MaxFileSizeMB = 5
+	options.Features.FileUpload.MaxFilesPerPost = 25
+	options.Features.FileUpload.MaxVersionsPerFile = 3
+	options.Features.FileUpload.AllowedExtensions = []string{"jpg", "jpeg", "png", "gif", "webp", "pdf", "doc", "docx", "xls", "xlsx", "txt", "zip", "mp4", "mov", "avi"}
+	options.Features.StaleSpaces.Enabled = true
+	options.Features.StaleSpaces.ThresholdDays = DefaultStaleDays
+	options.Features.Tasks.Enabled = true
+	options.Features.Metrics.Enabled = true
+	options.Features.Glossary.Enabled = true
+	options.Features.PublicGuard.Enabled = true
+	options.Features.PublicGuard.RequestBudget = DefaultPublicRequestBudget
+	options.Features.Trash.Enabled = true
+	options.Features.Trash.PostRetentionDays = DefaultPostRetentionDays
+	options.Features.Trash.AttachmentRetentionDays = DefaultAttachmentRetentionDays
+	options.Features.Trash.SpaceRetentionDays = DefaultSpaceRetentionDays
+	options.Features.LiveFeed.Enabled = true
+	options.Features.ChangeLog.Enabled = true
+	options.Features.ChangeLog.RetentionDays = DefaultChangeLogRetentionDays
+	options.Features.RequestCost.Enabled = true
+	options.Features.RequestCost.MaxSpaces = DefaultMaxRequestSpaces
+	options.Features.RequestCost.MaxPosts = DefaultMaxRequestPosts
+	options.Features.Tags.Enabled = true
+	options.Features.Moderation.Enabled = true
+	options.Features.Moderation.Rules = DefaultModerationRules()
+	options.Features.SecretScan.Enabled = true
+	options.Features.SecretScan.Mode = SecretScanModeWarn
+	options.Features.Maintenance.Enabled = true
+	options.Features.Maintenance.QuietHoursStart = DefaultMaintenanceQuietStart
+	options.Features.Maintenance.QuietHoursEnd = DefaultMaintenanceQuietEnd
+	options.Features.StartupCheck.Enabled = true
+	options.Features.SavedFilters.Enabled = true
+	options.Features.RelatedPosts.Enabled = true
+	options.Features.LanguageStats.Enabled = true
+	options.Features.DeltaExport.Enabled = true
+	options.Features.Search.Enabled = true
+	options.Features.Summaries.Enabled = false
+	options.Features.Summaries.MinPostLength = DefaultSummaryMinPostLength
+	options.Features.Ingest.Enabled = true
+	options.Features.ShareLinks.Enabled = true
+	options.Features.ResultCache.Enabled = true
+	options.Features.ResultCache.TTLSeconds = DefaultResultCacheTTLSeconds
+	options.Features.Notifications.Enabled = true
+	options.Features.Notifications.RetentionDays = DefaultNotificationRetentionDays
+	options.Features.Memory.Enabled = true
+	options.Features.Snapshots.Enabled = true
+	options.Features.Snapshots.MaxPerSpace = DefaultMaxSnapshotsPerSpace
+	options.Features.Previews.Enabled = true
+	options.Features.Previews.MaxSize = DefaultPreviewMaxSize
+	options.Features.ColdStorage.Enabled = true
+	options.Features.ColdStorage.AfterMonths = DefaultColdStorageAfterMonths
+	options.Features.Rules.Enabled = true
+	options.Features.SupportBundle.Enabled = true
+	options.Features.SignedURLs.Enabled = true
+	options.Features.DirectoryImport.Enabled = true
+	options.Features.Publishing.Enabled = false
+	options.Features.Board.Enabled = true
+	options.Features.Preferences.Enabled = true
+	options.Features.EditHistory.Enabled = true
+	options.Features.EditHistory.MaxRevisions = DefaultMaxPostRevisions
+	options.Features.Pinning.Enabled = true
+	options.Features.Pinning.MaxPerSpace = DefaultMaxPinnedPosts
+	options.Features.RecordKeeping.Enabled = true
+	options.Features.Reviews.Enabled = true
+	options.Features.WorkspaceArchive.Enabled = true
+	options.Features.GraphQL.Enabled = true
+	options.Features.MobileSync.Enabled = true
+	options.Features.DuplicateLinks.Enabled = true
+	options.Features.DuplicateLinks.ReportDuplicates = true
+	options.Features.DuplicateLinks.MaxDuplicates = DefaultMaxDuplicateLinks
+
+	return options
 }
 
 // WithMaxContentLength sets the MaxContentLength for tests
@@ -88,6 +107,12 @@ func (o *OptionsConfig) WithMaxFilesPerPost(val int) *OptionsConfig {
 	return o
 }
 
+// WithMaxVersionsPerFile sets the FileUpload.MaxVersionsPerFile for tests
+func (o *OptionsConfig) WithMaxVersionsPerFile(val int) *OptionsConfig {
+	o.Features.FileUpload.MaxVersionsPerFile = val
+	return o
+}
+
 // WithFileUploadEnabled sets the FileUpload.Enabled feature for tests
 func (o *OptionsConfig) WithFileUploadEnabled(enabled bool) *OptionsConfig {
 	o.Features.FileUpload.Enabled = enabled
@@ -130,6 +155,12 @@ func (o *OptionsConfig) WithRetroactivePostingTimeFormat(format string) *Options
 	return o
 }
 
+// WithLocale sets the Metadata.Locale for tests
+func (o *OptionsConfig) WithLocale(locale string) *OptionsConfig {
+	o.Metadata.Locale = locale
+	return o
+}
+
 // WithMarkdownEnabled sets the Markdown.Enabled feature for tests
 func (o *OptionsConfig) WithMarkdownEnabled(enabled bool) *OptionsConfig {
 	o.Features.Markdown.Enabled = enabled
@@ -68,3 +68,18 @@ func TestOptionsConfigChaining(t *testing.T) {
 		t.Error("Expected Markdown to be disabled")
 	}
 }
+
+func TestSafeModeOptions(t *testing.T) {
+	options := NewTestOptionsConfig()
+	safe := SafeModeOptions(options)
+
+	if safe.Features.FileUpload.Enabled || safe.Features.Activity.Enabled || safe.Features.Search.Enabled || safe.Features.Rules.Enabled {
+		t.Error("Expected every feature to be disabled in safe mode")
+	}
+	if safe.Features.FileUpload.MaxFileSizeMB != options.Features.FileUpload.MaxFileSizeMB {
+		t.Error("Expected feature settings other than enabled to be kept")
+	}
+	if !options.Features.FileUpload.Enabled || !options.Features.Activity.Enabled {
+		t.Error("Expected the loaded options to be left untouched")
+	}
+}
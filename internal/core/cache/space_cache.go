@@ -1,14 +1,39 @@
 package cache
 
 import (
+	"backthynk/internal/config"
 	"backthynk/internal/core/models"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
 )
 
+// SpaceLoader reads a space missing from the cache from the database, post count included
+type SpaceLoader func(id int) (*models.Space, error)
+
+// CacheStats counts lookups and repairs of the space cache since startup
+type CacheStats struct {
+	Hits            int64 `json:"hits"`
+	Misses          int64 `json:"misses"`
+	Loads           int64 `json:"loads"` // Misses served by the loader
+	Invalidations   int64 `json:"invalidations"`
+	Reconciliations int64 `json:"reconciliations"`
+	ReconcileFixes  int64 `json:"reconcile_fixes"`
+	LastReconciled  int64 `json:"last_reconciled,omitempty"` // Unix ms
+}
+
 type SpaceCache struct {
 	spaces map[int]*models.Space
 	hierarchy  map[int][]int // parentID -> []childIDs
 	mu         sync.RWMutex
+
+	loader         SpaceLoader
+	hits           atomic.Int64
+	misses         atomic.Int64
+	loads          atomic.Int64
+	invalidations  atomic.Int64
+	reconciliation CacheStats // Reconciliation counters, guarded by mu
 }
 
 func NewSpaceCache() *SpaceCache {
@@ -21,7 +46,10 @@ func NewSpaceCache() *SpaceCache {
 func (c *SpaceCache) Set(space *models.Space) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.setUnlocked(space)
+}
 
+func (c *SpaceCache) setUnlocked(space *models.Space) {
 	// Check if this is an update to an existing space
 	if existingCat, exists := c.spaces[space.ID]; exists {
 		// Remove from old parent hierarchy if parent changed
@@ -49,11 +77,147 @@ func (c *SpaceCache) Set(space *models.Space) {
 	c.spaces[space.ID] = space
 }
 
+// SetLoader makes lookups of spaces missing from the cache read through to the database
+func (c *SpaceCache) SetLoader(loader SpaceLoader) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loader = loader
+}
+
 func (c *SpaceCache) Get(id int) (*models.Space, bool) {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
 	cat, ok := c.spaces[id]
-	return cat, ok
+	loader := c.loader
+	c.mu.RUnlock()
+
+	if ok {
+		c.hits.Add(1)
+		return cat, true
+	}
+	c.misses.Add(1)
+	if loader == nil {
+		return nil, false
+	}
+
+	// Read through; the loader runs unlocked as it queries the database
+	loaded, err := loader(id)
+	if err != nil || loaded == nil {
+		return nil, false
+	}
+	c.loads.Add(1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.spaces[id]; ok {
+		// Another lookup loaded it meanwhile
+		return cached, true
+	}
+	c.setUnlocked(loaded)
+	loaded.RecursivePostCount = c.getDescendantPostCountUnlocked(id)
+	return loaded, true
+}
+
+// Invalidate drops a cached space so the next lookup reads it back from the database. The
+// hierarchy below it is kept, its children still being cached.
+func (c *SpaceCache) Invalidate(spaceID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cat, ok := c.spaces[spaceID]; ok {
+		if cat.ParentID != nil {
+			c.removeFromHierarchyUnlocked(*cat.ParentID, spaceID)
+		}
+		delete(c.spaces, spaceID)
+	}
+	c.invalidations.Add(1)
+}
+
+// Reconcile replaces the cache content with spaces, the database state with post counts set,
+// and returns the number of spaces that were missing, stale or no longer exist. Cached
+// pointers are updated in place so holders see the fixed values.
+func (c *SpaceCache) Reconcile(spaces []*models.Space) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fixes := 0
+	fixed := make(map[int]bool)
+	fresh := make(map[int]bool, len(spaces))
+	previousRecursive := make(map[int]int, len(c.spaces))
+	for id, cat := range c.spaces {
+		previousRecursive[id] = cat.RecursivePostCount
+	}
+
+	for _, space := range spaces {
+		fresh[space.ID] = true
+		cached, ok := c.spaces[space.ID]
+		if !ok {
+			c.spaces[space.ID] = space
+			fixed[space.ID] = true
+			fixes++
+			continue
+		}
+		if !sameSpace(cached, space) {
+			recursive := cached.RecursivePostCount
+			*cached = *space
+			cached.RecursivePostCount = recursive
+			fixed[space.ID] = true
+			fixes++
+		}
+	}
+	for id := range c.spaces {
+		if !fresh[id] {
+			delete(c.spaces, id)
+			fixes++
+		}
+	}
+
+	c.hierarchy = make(map[int][]int)
+	for _, cat := range c.spaces {
+		if cat.ParentID != nil {
+			c.hierarchy[*cat.ParentID] = append(c.hierarchy[*cat.ParentID], cat.ID)
+		}
+	}
+
+	for id, cat := range c.spaces {
+		cat.RecursivePostCount = c.getDescendantPostCountUnlocked(id)
+		if previous, ok := previousRecursive[id]; ok && !fixed[id] && previous != cat.RecursivePostCount {
+			fixes++
+		}
+	}
+
+	c.reconciliation.Reconciliations++
+	c.reconciliation.ReconcileFixes += int64(fixes)
+	c.reconciliation.LastReconciled = time.Now().UnixMilli()
+	return fixes
+}
+
+// sameSpace reports whether a cached space matches its database state, ignoring the
+// recursive post count which is derived from the hierarchy
+func sameSpace(cached, space *models.Space) bool {
+	if (cached.ParentID == nil) != (space.ParentID == nil) {
+		return false
+	}
+	if cached.ParentID != nil && *cached.ParentID != *space.ParentID {
+		return false
+	}
+	return cached.Name == space.Name &&
+		cached.Description == space.Description &&
+		cached.Depth == space.Depth &&
+		cached.Created == space.Created &&
+		cached.PostCount == space.PostCount
+}
+
+// Stats returns the lookup and reconciliation counters
+func (c *SpaceCache) Stats() CacheStats {
+	c.mu.RLock()
+	stats := c.reconciliation
+	c.mu.RUnlock()
+
+	stats.Hits = c.hits.Load()
+	stats.Misses = c.misses.Load()
+	stats.Loads = c.loads.Load()
+	stats.Invalidations = c.invalidations.Load()
+	return stats
 }
 
 func (c *SpaceCache) GetAll() []*models.Space {
@@ -282,4 +446,18 @@ func (c *SpaceCache) updateAncestorRecursiveCountsUnlocked(spaceID int, delta in
 			cat.RecursivePostCount += delta
 		}
 	}
-}
\ No newline at end of file
+}
+// MemoryUsage estimates the memory held by the cached spaces and their hierarchy
+func (c *SpaceCache) MemoryUsage() models.MemoryUsage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	usage := models.MemoryUsage{Entries: len(c.spaces)}
+	for _, space := range c.spaces {
+		usage.Bytes += config.MemoryMapEntryOverhead + int64(unsafe.Sizeof(*space)) + int64(len(space.Name)+len(space.Description))
+	}
+	for _, children := range c.hierarchy {
+		usage.Bytes += config.MemoryMapEntryOverhead + config.MemorySliceHeader + int64(cap(children))*8
+	}
+	return usage
+}
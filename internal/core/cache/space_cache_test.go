@@ -2,6 +2,7 @@ package cache
 
 import (
 	"backthynk/internal/core/models"
+	"fmt"
 	"testing"
 )
 
@@ -271,4 +272,101 @@ func TestSpaceCache_PostCountPreservationDuringHierarchyChange(t *testing.T) {
 			t.Errorf("Expected space 4 recursive count to be 2 after move, got %d", finalCat4.RecursivePostCount)
 		}
 	})
-}
\ No newline at end of file
+}
+func TestSpaceCache_MemoryUsage(t *testing.T) {
+	cache := NewSpaceCache()
+	if usage := cache.MemoryUsage(); usage.Entries != 0 || usage.Bytes != 0 {
+		t.Errorf("Expected an empty cache to use nothing, got %+v", usage)
+	}
+
+	parentID := 1
+	cache.Set(&models.Space{ID: 1, Name: "Parent"})
+	before := cache.MemoryUsage()
+	cache.Set(&models.Space{ID: 2, Name: "Child", Description: "A longer description", ParentID: &parentID})
+	after := cache.MemoryUsage()
+
+	if before.Entries != 1 || after.Entries != 2 {
+		t.Errorf("Expected 1 then 2 entries, got %d and %d", before.Entries, after.Entries)
+	}
+	if after.Bytes <= before.Bytes {
+		t.Errorf("Expected usage to grow with spaces, got %d then %d", before.Bytes, after.Bytes)
+	}
+}
+
+func TestSpaceCache_ReadThroughAndInvalidate(t *testing.T) {
+	cache := NewSpaceCache()
+	parentID := 1
+	cache.Set(&models.Space{ID: 1, Name: "Parent", PostCount: 1, RecursivePostCount: 1})
+
+	db := map[int]*models.Space{2: {ID: 2, Name: "Child", ParentID: &parentID, PostCount: 4}}
+	loaded := 0
+	cache.SetLoader(func(id int) (*models.Space, error) {
+		loaded++
+		space, ok := db[id]
+		if !ok {
+			return nil, fmt.Errorf("space not found")
+		}
+		clone := *space
+		return &clone, nil
+	})
+
+	child, ok := cache.Get(2)
+	if !ok || child.Name != "Child" || child.RecursivePostCount != 4 {
+		t.Fatalf("Expected the child read through from the loader, got %+v", child)
+	}
+	if children := cache.GetChildren(1); len(children) != 1 || children[0] != 2 {
+		t.Errorf("Expected the loaded child in the hierarchy, got %v", children)
+	}
+	if _, ok := cache.Get(2); !ok || loaded != 1 {
+		t.Errorf("Expected the second lookup to hit the cache, loader ran %d times", loaded)
+	}
+	if _, ok := cache.Get(3); ok {
+		t.Error("Expected unknown spaces to stay missing")
+	}
+
+	db[2].Name = "Renamed"
+	cache.Invalidate(2)
+	if child, _ := cache.Get(2); child.Name != "Renamed" {
+		t.Errorf("Expected the invalidated space to be read back, got %q", child.Name)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 3 || stats.Loads != 2 || stats.Invalidations != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestSpaceCache_Reconcile(t *testing.T) {
+	cache := NewSpaceCache()
+	parentID := 1
+	parent := &models.Space{ID: 1, Name: "Parent", PostCount: 2, RecursivePostCount: 5}
+	cache.Set(parent)
+	cache.Set(&models.Space{ID: 2, Name: "Child", ParentID: &parentID, PostCount: 3, RecursivePostCount: 3})
+	cache.Set(&models.Space{ID: 9, Name: "Gone"})
+
+	// Child gained a post behind the cache's back, a new space exists and space 9 was deleted
+	fixes := cache.Reconcile([]*models.Space{
+		{ID: 1, Name: "Parent", PostCount: 2},
+		{ID: 2, Name: "Child", ParentID: &parentID, PostCount: 4},
+		{ID: 3, Name: "Added", ParentID: &parentID, PostCount: 1},
+	})
+	if fixes != 4 {
+		t.Errorf("Expected 4 fixes, got %d", fixes)
+	}
+	if parent.RecursivePostCount != 7 {
+		t.Errorf("Expected the held parent pointer updated to 7 recursive posts, got %d", parent.RecursivePostCount)
+	}
+	if _, ok := cache.Get(9); ok {
+		t.Error("Expected the deleted space to be dropped")
+	}
+	if children := cache.GetChildren(1); len(children) != 2 {
+		t.Errorf("Expected 2 children after reconciliation, got %v", children)
+	}
+
+	if fixes := cache.Reconcile(cache.GetAll()); fixes != 0 {
+		t.Errorf("Expected nothing to fix on a consistent cache, got %d", fixes)
+	}
+	if stats := cache.Stats(); stats.Reconciliations != 2 || stats.ReconcileFixes != 4 || stats.LastReconciled == 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
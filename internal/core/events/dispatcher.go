@@ -2,6 +2,7 @@ package events
 
 import (
 	"backthynk/internal/core/logger"
+	"context"
 	"sync"
 
 	"go.uber.org/zap"
@@ -9,10 +10,15 @@ import (
 
 type Handler func(event Event) error
 
+// Sequencer numbers an event in the caller's goroutine, before any handler runs. ctx is the one
+// the event was dispatched with.
+type Sequencer func(ctx context.Context, event *Event)
+
 type Dispatcher struct {
-	handlers map[EventType][]Handler
-	mu       sync.RWMutex
-	async    bool
+	handlers  map[EventType][]Handler
+	sequencer Sequencer
+	mu        sync.RWMutex
+	async     bool
 }
 
 func NewDispatcher() *Dispatcher {
@@ -35,11 +41,30 @@ func (d *Dispatcher) Subscribe(eventType EventType, handler Handler) {
 	d.handlers[eventType] = append(d.handlers[eventType], handler)
 }
 
+// SetSequencer numbers the events dispatched from now on, e.g. to log them in order. Events
+// are numbered even when no handler listens to them.
+func (d *Dispatcher) SetSequencer(sequencer Sequencer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sequencer = sequencer
+}
+
 func (d *Dispatcher) Dispatch(event Event) {
+	d.DispatchContext(context.Background(), event)
+}
+
+// DispatchContext is Dispatch for a change made on behalf of ctx, e.g. a request. Only the
+// sequencer sees ctx; handlers may run after the request is gone.
+func (d *Dispatcher) DispatchContext(ctx context.Context, event Event) {
 	d.mu.RLock()
 	handlers := d.handlers[event.Type]
+	sequencer := d.sequencer
 	d.mu.RUnlock()
 
+	if sequencer != nil {
+		sequencer(ctx, &event)
+	}
+
 	if d.async {
 		// Asynchronous execution - don't block the caller
 		for _, handler := range handlers {
@@ -53,6 +78,15 @@ func (d *Dispatcher) Dispatch(event Event) {
 	}
 }
 
+// Notify raises a notification for the notification center. It is a no-op on a nil
+// dispatcher, so features can notify without checking whether they were given one.
+func (d *Dispatcher) Notify(notification NotificationEvent) {
+	if d == nil {
+		return
+	}
+	d.Dispatch(Event{Type: NotificationRaised, Data: notification})
+}
+
 func (d *Dispatcher) executeHandler(handler Handler, event Event) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -7,6 +7,11 @@ const (
 	PostCreated EventType = "post.created"
 	PostDeleted EventType = "post.deleted"
 	PostMoved   EventType = "post.moved"
+	PostMerged  EventType = "post.merged"
+	PostSplit   EventType = "post.split"
+	PostUpdated EventType = "post.updated" // Content changed in place
+	PostRetimed EventType = "post.retimed" // Creation time changed in place
+	PostPinned  EventType = "post.pinned"  // Pinned or unpinned within its space
 	
 	// Space events
 	SpaceCreated EventType = "space.created"
@@ -16,11 +21,19 @@ const (
 	// File events
 	FileUploaded EventType = "file.uploaded"
 	FileDeleted  EventType = "file.deleted"
+	FileDownloaded EventType = "file.downloaded"
+	UploadProgress EventType = "upload.progress" // Stages of an upload session, before the file is attached
+	LinkPreviewSaved EventType = "linkpreview.saved" // Preview stored for a link of a post
+	PreviewGenerated EventType = "preview.generated" // Preview image rendered for an attachment
+
+	// Notification events, raised by features for the notification center
+	NotificationRaised EventType = "notification.raised"
 )
 
 type Event struct {
 	Type EventType
 	Data interface{}
+	Seq  int64 // Position in the change log, set by the dispatcher's sequencer; 0 when not logged
 }
 
 // Event data structures
@@ -29,8 +42,50 @@ type PostEvent struct {
 	SpaceID int
 	OldSpaceID *int // For move events
 	Timestamp  int64
+	OldTimestamp int64 // For retime events
 	FileSize   int64  // For file events
 	FileCount  int    // For file events
+	AttachmentID int  // For file upload, download and preview events
+	Pinned     bool   // For pin events: whether the post is now pinned
+	MergedPosts []MergedPost // For merge events: posts folded into PostID
+	SplitPosts  []SplitPost  // For split events: posts created from PostID
+}
+
+// MergedPost describes a post that was removed by a merge
+type MergedPost struct {
+	PostID    int
+	Timestamp int64
+	FileSize  int64 // Attachments moved onto the surviving post
+	FileCount int
+}
+
+// SplitPost describes a post created by splitting another one
+type SplitPost struct {
+	PostID    int
+	Timestamp int64
+	FileSize  int64 // Attachments moved from the original post
+	FileCount int
+}
+
+// Upload session stages, in order; an upload ends with done or failed
+const (
+	UploadStageReceiving  = "receiving"  // Request body being received
+	UploadStageProcessing = "processing" // Storing, checksum and secret scanning
+	UploadStageDone       = "done"
+	UploadStageFailed     = "failed"
+)
+
+// UploadProgressEvent reports the state of an upload session chosen by the client
+type UploadProgressEvent struct {
+	SessionID     string
+	Stage         string
+	BytesReceived int64
+	TotalBytes    int64 // 0 when the client did not send a Content-Length
+	PostID        int
+	AttachmentID  int    // Set once done
+	Filename      string
+	Error         string // Set when failed
+	Sequence      int64  // Increases across all sessions, for listeners receiving events out of order
 }
 
 type SpaceEvent struct {
@@ -38,4 +93,14 @@ type SpaceEvent struct {
 	OldParentID   *int
 	NewParentID   *int
 	AffectedPosts []int
-}
\ No newline at end of file
+}
+
+// NotificationEvent asks the notification center to show something to the user
+type NotificationEvent struct {
+	Kind    string // One of the models.Notification* kinds
+	Title   string
+	Message string
+	SpaceID int    // 0 when not about a space
+	PostID  int    // 0 when not about a post
+	Key     string // Unread notifications with the same key are updated instead of repeated
+}
@@ -0,0 +1,211 @@
+package flags
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Definition declares a feature flag and its setting when neither options.json nor an
+// override mentions it
+type Definition struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+// Known lists the flags subsystems consult. Flags of subsystems shipping dark default to off.
+var Known = []Definition{
+	{
+		Name:        config.FlagSemanticSearch,
+		Description: "Semantic search over post embeddings, when a provider is configured",
+		Default:     true,
+	},
+}
+
+// Registry answers whether a flag is on. Settings come from the flag defaults, then
+// options.json, then runtime overrides, which are kept in the database.
+type Registry struct {
+	db         *storage.DB
+	defs       map[string]Definition
+	configured map[string]config.FeatureFlag
+	overrides  map[string]models.FeatureFlag
+	mu         sync.RWMutex
+	now        func() time.Time
+}
+
+// NewRegistry creates a registry of the given definitions with the flags set in options.json.
+// Settings of unknown flags or with an invalid rollout are ignored.
+func NewRegistry(db *storage.DB, defs []Definition, configured map[string]config.FeatureFlag) *Registry {
+	r := &Registry{
+		db:         db,
+		defs:       make(map[string]Definition, len(defs)),
+		configured: make(map[string]config.FeatureFlag),
+		overrides:  make(map[string]models.FeatureFlag),
+		now:        time.Now,
+	}
+	for _, def := range defs {
+		r.defs[def.Name] = def
+	}
+
+	for name, flag := range configured {
+		if _, ok := r.defs[name]; !ok {
+			logger.Warning("Ignoring unknown feature flag", zap.String("flag", name))
+			continue
+		}
+		if flag.Rollout < 0 || flag.Rollout > config.MaxFlagRollout {
+			logger.Warning("Ignoring feature flag with an invalid rollout", zap.String("flag", name), zap.Int("rollout", flag.Rollout))
+			continue
+		}
+		r.configured[name] = flag
+	}
+
+	return r
+}
+
+// Initialize loads the runtime overrides
+func (r *Registry) Initialize() error {
+	overrides, err := r.db.GetFlagOverrides()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, flag := range overrides {
+		if _, ok := r.defs[flag.Name]; ok {
+			r.overrides[flag.Name] = flag
+		}
+	}
+
+	return nil
+}
+
+// IsOn reports whether a flag is on for the whole instance, i.e. enabled for every space
+func (r *Registry) IsOn(name string) bool {
+	flag, ok := r.Get(name)
+	return ok && flag.Enabled && (flag.Rollout == 0 || flag.Rollout == config.MaxFlagRollout)
+}
+
+// IsOnFor reports whether a flag is on for a space. A partial rollout picks the same spaces
+// every time, so raising it only adds spaces.
+func (r *Registry) IsOnFor(name string, spaceID int) bool {
+	flag, ok := r.Get(name)
+	if !ok || !flag.Enabled {
+		return false
+	}
+	if flag.Rollout == 0 || flag.Rollout == config.MaxFlagRollout {
+		return true
+	}
+	return bucket(name, spaceID) < flag.Rollout
+}
+
+// Get returns the effective setting of a flag
+func (r *Registry) Get(name string) (models.FeatureFlag, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.getUnlocked(name)
+}
+
+// List returns the effective setting of every known flag, by name
+func (r *Registry) List() []models.FeatureFlag {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	flags := make([]models.FeatureFlag, 0, len(r.defs))
+	for name := range r.defs {
+		flag, _ := r.getUnlocked(name)
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	return flags
+}
+
+// SetOverride overrides the setting of a flag until the override is cleared, across restarts
+func (r *Registry) SetOverride(name string, enabled bool, rollout int) (models.FeatureFlag, error) {
+	def, ok := r.defs[name]
+	if !ok {
+		return models.FeatureFlag{}, fmt.Errorf(config.ErrFeatureFlagNotFound)
+	}
+	if rollout < 0 || rollout > config.MaxFlagRollout {
+		return models.FeatureFlag{}, fmt.Errorf(config.ErrInvalidFlagRollout)
+	}
+
+	flag := models.FeatureFlag{
+		Name:        name,
+		Description: def.Description,
+		Enabled:     enabled,
+		Rollout:     rollout,
+		Source:      models.FlagSourceOverride,
+		Updated:     r.now().UnixMilli(),
+	}
+	if err := r.db.SetFlagOverride(flag); err != nil {
+		return models.FeatureFlag{}, err
+	}
+
+	r.mu.Lock()
+	r.overrides[name] = flag
+	r.mu.Unlock()
+
+	logger.Info("Feature flag overridden", zap.String("flag", name), zap.Bool("enabled", enabled), zap.Int("rollout", rollout))
+	return flag, nil
+}
+
+// ClearOverride drops the runtime override of a flag, returning it to its configured setting
+func (r *Registry) ClearOverride(name string) (models.FeatureFlag, error) {
+	if _, ok := r.defs[name]; !ok {
+		return models.FeatureFlag{}, fmt.Errorf(config.ErrFeatureFlagNotFound)
+	}
+
+	if err := r.db.DeleteFlagOverride(name); err != nil {
+		return models.FeatureFlag{}, err
+	}
+
+	r.mu.Lock()
+	delete(r.overrides, name)
+	flag, _ := r.getUnlocked(name)
+	r.mu.Unlock()
+
+	return flag, nil
+}
+
+// getUnlocked resolves the setting of a flag. Caller must hold r.mu.
+func (r *Registry) getUnlocked(name string) (models.FeatureFlag, bool) {
+	def, ok := r.defs[name]
+	if !ok {
+		return models.FeatureFlag{}, false
+	}
+
+	if flag, ok := r.overrides[name]; ok {
+		flag.Description = def.Description
+		return flag, true
+	}
+
+	flag := models.FeatureFlag{Name: name, Description: def.Description, Enabled: def.Default, Source: models.FlagSourceDefault}
+	if configured, ok := r.configured[name]; ok {
+		flag.Enabled = configured.Enabled
+		flag.Rollout = configured.Rollout
+		flag.Source = models.FlagSourceConfig
+	}
+
+	return flag, true
+}
+
+// bucket places a space in one of 100 buckets, differently for each flag so the same spaces
+// do not get every experiment first
+func bucket(name string, spaceID int) int {
+	h := fnv.New32a()
+	h.Write([]byte(name + ":" + strconv.Itoa(spaceID)))
+	return int(h.Sum32() % config.MaxFlagRollout)
+}
@@ -0,0 +1,132 @@
+package flags
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"os"
+	"testing"
+)
+
+func setupFlagsTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_flags_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+var testDefs = []Definition{
+	{Name: "replication", Description: "Replicate posts to a peer", Default: false},
+	{Name: "encryption", Description: "Encrypt attachments at rest", Default: true},
+}
+
+func TestPrecedence(t *testing.T) {
+	db, cleanup := setupFlagsTestDB(t)
+	defer cleanup()
+
+	registry := NewRegistry(db, testDefs, map[string]config.FeatureFlag{
+		"encryption": {Enabled: false},
+		"unknown":    {Enabled: true},
+	})
+	if err := registry.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	if registry.IsOn("replication") {
+		t.Error("Expected replication to ship dark")
+	}
+	if registry.IsOn("encryption") {
+		t.Error("Expected options.json to turn encryption off")
+	}
+	if registry.IsOn("unknown") {
+		t.Error("Expected unknown flags to be off")
+	}
+
+	if _, err := registry.SetOverride("replication", true, 0); err != nil {
+		t.Fatalf("SetOverride failed: %v", err)
+	}
+	if !registry.IsOn("replication") || !registry.IsOnFor("replication", 42) {
+		t.Error("Expected override to turn replication on")
+	}
+
+	// Overrides survive restarts until cleared
+	restarted := NewRegistry(db, testDefs, nil)
+	if err := restarted.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	flag, _ := restarted.Get("replication")
+	if !flag.Enabled || flag.Source != models.FlagSourceOverride {
+		t.Errorf("Expected the override to be reloaded, got %+v", flag)
+	}
+
+	flag, err := restarted.ClearOverride("replication")
+	if err != nil {
+		t.Fatalf("ClearOverride failed: %v", err)
+	}
+	if flag.Enabled || flag.Source != models.FlagSourceDefault {
+		t.Errorf("Expected the default back, got %+v", flag)
+	}
+
+	if _, err := registry.SetOverride("unknown", true, 0); err == nil || err.Error() != config.ErrFeatureFlagNotFound {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+	if _, err := registry.SetOverride("replication", true, 101); err == nil || err.Error() != config.ErrInvalidFlagRollout {
+		t.Errorf("Expected invalid rollout error, got %v", err)
+	}
+
+	flags := registry.List()
+	if len(flags) != 2 || flags[0].Name != "encryption" || flags[0].Source != models.FlagSourceConfig {
+		t.Errorf("Unexpected flag list: %+v", flags)
+	}
+}
+
+func TestGradualRollout(t *testing.T) {
+	db, cleanup := setupFlagsTestDB(t)
+	defer cleanup()
+
+	registry := NewRegistry(db, testDefs, map[string]config.FeatureFlag{
+		"replication": {Enabled: true, Rollout: 25},
+	})
+
+	if registry.IsOn("replication") {
+		t.Error("Expected a partial rollout not to be on instance-wide")
+	}
+
+	enabled := map[int]bool{}
+	for spaceID := 1; spaceID <= 1000; spaceID++ {
+		if registry.IsOnFor("replication", spaceID) {
+			enabled[spaceID] = true
+		}
+	}
+	if len(enabled) < 150 || len(enabled) > 350 {
+		t.Errorf("Expected about a quarter of the spaces, got %d of 1000", len(enabled))
+	}
+
+	// Raising the rollout keeps the spaces already enabled
+	registry.SetOverride("replication", true, 60)
+	for spaceID := range enabled {
+		if !registry.IsOnFor("replication", spaceID) {
+			t.Fatalf("Expected space %d to stay enabled when the rollout grows", spaceID)
+		}
+	}
+
+	registry.SetOverride("replication", false, 60)
+	if registry.IsOnFor("replication", 1) {
+		t.Error("Expected a disabled flag to be off for every space")
+	}
+}
@@ -0,0 +1,248 @@
+package jobs
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Budget keeps background jobs (indexing, archival, purges, maintenance) from starving
+// interactive requests on small servers. At most maxConcurrent jobs run at once, the disk
+// throughput jobs report is held under maxIOBytesPerSec, and no job starts while the average
+// time to first byte of recent requests exceeds pauseLatency. Zero values lift a limit. A nil
+// Budget lets every job run immediately.
+type Budget struct {
+	maxConcurrent    int
+	maxIOBytesPerSec float64
+	pauseLatency     time.Duration
+
+	mu        sync.Mutex
+	changed   chan struct{} // Closed when a slot frees up
+	nextID    int
+	running   map[int]*JobInfo
+	waiting   map[int]*JobInfo
+	recent    []JobInfo // Newest first
+	ioFreeAt  time.Time // When the IO charged so far is paid off
+	latencies []latencySample
+	paused    bool
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+type latencySample struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// Job is a background job holding a slot of the budget
+type Job struct {
+	budget *Budget
+	id     int
+}
+
+func NewBudget(maxConcurrent int, maxIOMBps float64, pauseLatency time.Duration) *Budget {
+	return &Budget{
+		maxConcurrent:    maxConcurrent,
+		maxIOBytesPerSec: maxIOMBps * 1024 * 1024,
+		pauseLatency:     pauseLatency,
+		changed:          make(chan struct{}),
+		running:          make(map[int]*JobInfo),
+		waiting:          make(map[int]*JobInfo),
+		now:              time.Now,
+		sleep:            sleepContext,
+	}
+}
+
+// Run waits for a slot, runs fn and records the outcome of the job under name
+func (b *Budget) Run(ctx context.Context, name string, fn func(job *Job) error) error {
+	job, err := b.Acquire(ctx, name)
+	if err != nil {
+		return err
+	}
+	err = fn(job)
+	job.Release(err)
+	return err
+}
+
+// Acquire blocks until a job may start, or ctx is done. The job must be released.
+func (b *Budget) Acquire(ctx context.Context, name string) (*Job, error) {
+	if b == nil {
+		return &Job{}, nil
+	}
+
+	b.mu.Lock()
+	b.nextID++
+	id := b.nextID
+	info := &JobInfo{Name: name, Queued: b.now().UnixMilli()}
+	b.waiting[id] = info
+	b.mu.Unlock()
+
+	for {
+		b.mu.Lock()
+		slow := b.slowLocked()
+		if slow != b.paused {
+			b.paused = slow
+			if slow {
+				logger.Info("Background jobs paused, requests are slow", zap.Duration("threshold", b.pauseLatency))
+			} else {
+				logger.Info("Background jobs resumed")
+			}
+		}
+		if !slow && (b.maxConcurrent <= 0 || len(b.running) < b.maxConcurrent) {
+			delete(b.waiting, id)
+			info.Started = b.now().UnixMilli()
+			b.running[id] = info
+			b.mu.Unlock()
+			return &Job{budget: b, id: id}, nil
+		}
+		changed := b.changed
+		b.mu.Unlock()
+
+		// Latency is checked again as samples age out of the window
+		select {
+		case <-changed:
+		case <-time.After(config.JobPauseCheckInterval):
+		case <-ctx.Done():
+			b.mu.Lock()
+			delete(b.waiting, id)
+			b.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// ChargeIO records bytes read or written by the job and waits as long as needed to keep the
+// throughput of all jobs under the budget
+func (j *Job) ChargeIO(ctx context.Context, bytes int64) error {
+	b := j.budget
+	if b == nil || bytes <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	info := b.running[j.id]
+	if info != nil {
+		info.IOBytes += bytes
+	}
+	if b.maxIOBytesPerSec <= 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	now := b.now()
+	if b.ioFreeAt.Before(now) {
+		b.ioFreeAt = now
+	}
+	b.ioFreeAt = b.ioFreeAt.Add(time.Duration(float64(bytes) / b.maxIOBytesPerSec * float64(time.Second)))
+	wait := b.ioFreeAt.Sub(now)
+	if info != nil {
+		info.ThrottledMs += wait.Milliseconds()
+	}
+	b.mu.Unlock()
+
+	return b.sleep(ctx, wait)
+}
+
+// Release frees the slot of the job, recording err as its outcome
+func (j *Job) Release(err error) {
+	b := j.budget
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	info, ok := b.running[j.id]
+	if !ok {
+		return
+	}
+	delete(b.running, j.id)
+
+	info.Finished = b.now().UnixMilli()
+	if err != nil {
+		info.Error = err.Error()
+	}
+	b.recent = append([]JobInfo{*info}, b.recent...)
+	if len(b.recent) > config.MaxRecentJobs {
+		b.recent = b.recent[:config.MaxRecentJobs]
+	}
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// ObserveRequest records the time an interactive request took to answer
+func (b *Budget) ObserveRequest(duration time.Duration) {
+	if b == nil || b.pauseLatency <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latencies = append(b.latencies, latencySample{at: b.now(), duration: duration})
+	if len(b.latencies) > config.MaxJobLatencySamples {
+		b.latencies = b.latencies[len(b.latencies)-config.MaxJobLatencySamples:]
+	}
+}
+
+// averageLatencyLocked drops the samples older than the latency window and averages the rest
+func (b *Budget) averageLatencyLocked() time.Duration {
+	cutoff := b.now().Add(-config.JobLatencyWindow)
+	kept := 0
+	for kept < len(b.latencies) && b.latencies[kept].at.Before(cutoff) {
+		kept++
+	}
+	b.latencies = b.latencies[kept:]
+
+	if len(b.latencies) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, sample := range b.latencies {
+		total += sample.duration
+	}
+	return total / time.Duration(len(b.latencies))
+}
+
+func (b *Budget) slowLocked() bool {
+	return b.pauseLatency > 0 && b.averageLatencyLocked() > b.pauseLatency
+}
+
+// Status returns the limits of the budget, the current request latency and the jobs
+func (b *Budget) Status() Status {
+	if b == nil {
+		return Status{Running: []JobInfo{}, Waiting: []JobInfo{}, Recent: []JobInfo{}}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := Status{
+		MaxConcurrent:    b.maxConcurrent,
+		MaxIOMBps:        b.maxIOBytesPerSec / 1024 / 1024,
+		PauseLatencyMs:   b.pauseLatency.Milliseconds(),
+		RequestLatencyMs: b.averageLatencyLocked().Milliseconds(),
+		Paused:           b.slowLocked(),
+		Running:          sortedJobs(b.running),
+		Waiting:          sortedJobs(b.waiting),
+		Recent:           append([]JobInfo{}, b.recent...),
+	}
+	return status
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
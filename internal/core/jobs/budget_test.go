@@ -0,0 +1,142 @@
+package jobs
+
+import (
+	"backthynk/internal/config"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBudgetLimitsConcurrency(t *testing.T) {
+	budget := NewBudget(1, 0, 0)
+
+	first, err := budget.Acquire(context.Background(), "first")
+	if err != nil {
+		t.Fatalf("Failed to acquire first slot: %v", err)
+	}
+
+	acquired := make(chan *Job)
+	go func() {
+		job, err := budget.Acquire(context.Background(), "second")
+		if err != nil {
+			t.Errorf("Failed to acquire second slot: %v", err)
+		}
+		acquired <- job
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Second job started while the only slot was taken")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	status := budget.Status()
+	if len(status.Running) != 1 || len(status.Waiting) != 1 || status.Waiting[0].Name != "second" {
+		t.Errorf("Expected one running and one waiting job, got %+v", status)
+	}
+
+	first.Release(nil)
+	select {
+	case second := <-acquired:
+		second.Release(errors.New("boom"))
+	case <-time.After(time.Second):
+		t.Fatal("Second job did not start after the slot was released")
+	}
+
+	recent := budget.Status().Recent
+	if len(recent) != 2 || recent[0].Name != "second" || recent[0].Error != "boom" || recent[1].Error != "" {
+		t.Errorf("Expected both jobs in history, newest first, got %+v", recent)
+	}
+}
+
+func TestBudgetAcquireCanceled(t *testing.T) {
+	budget := NewBudget(1, 0, 0)
+	job, _ := budget.Acquire(context.Background(), "holder")
+	defer job.Release(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := budget.Acquire(ctx, "waiter"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if waiting := budget.Status().Waiting; len(waiting) != 0 {
+		t.Errorf("Expected canceled job to leave the queue, got %+v", waiting)
+	}
+}
+
+func TestBudgetPausesOnSlowRequests(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	budget := NewBudget(0, 0, 100*time.Millisecond)
+	budget.now = func() time.Time { return now }
+
+	budget.ObserveRequest(50 * time.Millisecond)
+	budget.ObserveRequest(300 * time.Millisecond)
+	status := budget.Status()
+	if !status.Paused || status.RequestLatencyMs != 175 {
+		t.Fatalf("Expected paused budget at 175ms, got paused=%v latency=%d", status.Paused, status.RequestLatencyMs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := budget.Acquire(ctx, "indexing"); err == nil {
+		t.Fatal("Expected job to wait while requests are slow")
+	}
+
+	// Slow samples age out of the window
+	now = now.Add(config.JobLatencyWindow + time.Second)
+	if budget.Status().Paused {
+		t.Error("Expected budget to resume once the slow requests left the window")
+	}
+	job, err := budget.Acquire(context.Background(), "indexing")
+	if err != nil {
+		t.Fatalf("Expected job to start, got %v", err)
+	}
+	job.Release(nil)
+}
+
+func TestBudgetThrottlesIO(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var slept []time.Duration
+	budget := NewBudget(0, 1, 0) // 1 MB/s
+	budget.now = func() time.Time { return now }
+	budget.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	err := budget.Run(context.Background(), "archive", func(job *Job) error {
+		if err := job.ChargeIO(context.Background(), 512*1024); err != nil {
+			return err
+		}
+		return job.ChargeIO(context.Background(), 512*1024)
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// The second charge waits for the first one to be paid off too
+	if len(slept) != 2 || slept[0] != 500*time.Millisecond || slept[1] != time.Second {
+		t.Errorf("Expected waits of 500ms and 1s, got %v", slept)
+	}
+	recent := budget.Status().Recent
+	if len(recent) != 1 || recent[0].IOBytes != 1024*1024 || recent[0].ThrottledMs != 1500 {
+		t.Errorf("Expected IO and throttling recorded, got %+v", recent)
+	}
+}
+
+func TestNilBudgetRunsImmediately(t *testing.T) {
+	var budget *Budget
+	ran := false
+	err := budget.Run(context.Background(), "job", func(job *Job) error {
+		ran = true
+		return job.ChargeIO(context.Background(), 1<<30)
+	})
+	if err != nil || !ran {
+		t.Errorf("Expected job to run without a budget, ran=%v err=%v", ran, err)
+	}
+	budget.ObserveRequest(time.Hour)
+	if status := budget.Status(); status.Paused || status.Running == nil {
+		t.Errorf("Expected empty status, got %+v", status)
+	}
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import "sort"
+
+// JobInfo describes a background job waiting for, holding or done with a slot of the budget
+type JobInfo struct {
+	Name        string `json:"name"`
+	Queued      int64  `json:"queued"`
+	Started     int64  `json:"started,omitempty"`
+	Finished    int64  `json:"finished,omitempty"`
+	IOBytes     int64  `json:"io_bytes"`
+	ThrottledMs int64  `json:"throttled_ms"` // Time spent waiting for the IO budget
+	Error       string `json:"error,omitempty"`
+}
+
+// Status is the state of the budget shown by the jobs admin endpoint
+type Status struct {
+	MaxConcurrent    int       `json:"max_concurrent"`   // 0 when unlimited
+	MaxIOMBps        float64   `json:"max_io_mbps"`      // 0 when unlimited
+	PauseLatencyMs   int64     `json:"pause_latency_ms"` // 0 when jobs never pause
+	RequestLatencyMs int64     `json:"request_latency_ms"`
+	Paused           bool      `json:"paused"` // No job starts until requests are fast again
+	Running          []JobInfo `json:"running"`
+	Waiting          []JobInfo `json:"waiting"`
+	Recent           []JobInfo `json:"recent"` // Newest first
+}
+
+func sortedJobs(jobs map[int]*JobInfo) []JobInfo {
+	list := make([]JobInfo, 0, len(jobs))
+	for _, job := range jobs {
+		list = append(list, *job)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Queued < list[j].Queued })
+	return list
+}
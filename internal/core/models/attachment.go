@@ -7,6 +7,64 @@ type Attachment struct {
 	FilePath string `json:"file_path" db:"file_path"`
 	FileType string `json:"file_type" db:"file_type"`
 	FileSize int64  `json:"file_size" db:"file_size"`
+	Warnings []string `json:"warnings,omitempty" db:"-"` // Ingest warnings, only set when the file is uploaded
+	SHA256   string   `json:"sha256,omitempty" db:"-"`   // Hex checksum of the stored file, empty until computed
+}
+
+// AttachmentPreview is a reduced JPEG image standing for an attachment: the image itself, the
+// first page of a PDF or a frame of a video
+type AttachmentPreview struct {
+	AttachmentID int    `json:"attachment_id" db:"attachment_id"`
+	Kind         string `json:"kind" db:"kind"` // image, pdf or video
+	Width        int    `json:"width" db:"width"`
+	Height       int    `json:"height" db:"height"`
+	Created      int64  `json:"created" db:"created"`
+}
+
+// AttachmentVersion is a prior file of an attachment that was replaced by a re-upload
+type AttachmentVersion struct {
+	ID           int    `json:"id" db:"id"`
+	AttachmentID int    `json:"attachment_id" db:"attachment_id"`
+	Version      int    `json:"version" db:"version"` // Starts at 1 for the first upload
+	Filename     string `json:"filename" db:"filename"`
+	FilePath     string `json:"file_path" db:"file_path"`
+	FileType     string `json:"file_type" db:"file_type"`
+	FileSize     int64  `json:"file_size" db:"file_size"`
+	SHA256       string `json:"sha256,omitempty" db:"sha256"`
+	Replaced     int64  `json:"replaced" db:"replaced"` // When the next version was uploaded, in milliseconds
+}
+
+// AttachmentVersions lists the current file of an attachment and the prior versions kept,
+// newest first, with the storage used by all of them
+type AttachmentVersions struct {
+	Current   Attachment          `json:"current"`
+	Version   int                 `json:"version"` // Version number of the current file
+	Versions  []AttachmentVersion `json:"versions"`
+	TotalSize int64               `json:"total_size"` // Current file included
+}
+
+// RedactRegion is a rectangle of an image to blur or black out, in pixels from the top left corner
+type RedactRegion struct {
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Mode   string `json:"mode"` // blur or blackout, blackout when empty
+}
+
+// RedactRequest is the body of an image redaction
+type RedactRequest struct {
+	Regions []RedactRegion `json:"regions"`
+}
+
+// MediaItem is an image or video attachment listed in a space media gallery
+type MediaItem struct {
+	Attachment
+	SpaceID      int    `json:"space_id"`
+	PostCreated  int64  `json:"post_created"`
+	MediaType    string `json:"media_type"` // "image" or "video"
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
 }
 
 type LinkPreview struct {
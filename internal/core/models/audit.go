@@ -0,0 +1,11 @@
+package models
+
+import "encoding/json"
+
+// AuditEntry records a bulk or destructive operation for later review
+type AuditEntry struct {
+	ID      int             `json:"id" db:"id"`
+	Action  string          `json:"action" db:"action"`
+	Details json.RawMessage `json:"details" db:"details"`
+	Created int64           `json:"created" db:"created"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// Change is an entry of the change log: a change made to spaces or posts, numbered in the order
+// it was made so clients can catch up on what they missed in between two connections
+type Change struct {
+	Seq        int64  `json:"seq"`
+	Type       string `json:"type"` // Event type, e.g. post.created or space.deleted
+	SpaceID    int    `json:"space_id,omitempty"`
+	PostID     int    `json:"post_id,omitempty"`
+	OldSpaceID int    `json:"old_space_id,omitempty"` // Space a moved post left, or former parent of a moved space
+	PostIDs    []int  `json:"post_ids,omitempty"`     // Posts merged into PostID, or split from it
+	Created    int64  `json:"created"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// ColdAttachment is the stub kept for an attachment file moved to the cold tier
+type ColdAttachment struct {
+	FilePath   string `json:"file_path"`
+	FileSize   int64  `json:"file_size"`
+	ArchivedAt int64  `json:"archived_at"`
+}
+
+// TierUsage counts the attachments of a space kept in each storage tier
+type TierUsage struct {
+	SpaceID   int   `json:"space_id"`
+	Recursive bool  `json:"recursive"`
+	HotFiles  int64 `json:"hot_files"`
+	HotBytes  int64 `json:"hot_bytes"`
+	ColdFiles int64 `json:"cold_files"`
+	ColdBytes int64 `json:"cold_bytes"`
+}
+
+// Add accumulates the counts of other
+func (u *TierUsage) Add(other TierUsage) {
+	u.HotFiles += other.HotFiles
+	u.HotBytes += other.HotBytes
+	u.ColdFiles += other.ColdFiles
+	u.ColdBytes += other.ColdBytes
+}
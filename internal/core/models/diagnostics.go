@@ -0,0 +1,15 @@
+package models
+
+// TableInfo is a table of the database with its number of rows
+type TableInfo struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+}
+
+// SchemaInfo describes the database schema without any of its data
+type SchemaInfo struct {
+	SQLiteVersion string      `json:"sqlite_version"`
+	UserVersion   int         `json:"user_version"`
+	SchemaHash    string      `json:"schema_hash"` // SHA-256 of the schema statements, equal on instances with the same schema
+	Tables        []TableInfo `json:"tables"`
+}
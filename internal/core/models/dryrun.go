@@ -0,0 +1,14 @@
+package models
+
+// DryRunSummary describes what a destructive operation would affect without performing it
+type DryRunSummary struct {
+	Operation        string `json:"operation"`
+	DryRun           bool   `json:"dry_run"`
+	SpaceCount       int    `json:"space_count"`
+	DescendantSpaces []int  `json:"descendant_spaces"`
+	PostCount        int    `json:"post_count"`
+	AttachmentCount  int64  `json:"attachment_count"`
+	AttachmentBytes  int64  `json:"attachment_bytes"`
+	PostIDs          []int  `json:"post_ids,omitempty"`         // Posts that would be removed, or changed by bulk edits
+	SurvivorPostID   int    `json:"survivor_post_id,omitempty"` // Post receiving merged content
+}
@@ -0,0 +1,16 @@
+package models
+
+// Entities tracked for delta exports
+const (
+	ExportEntitySpace = "space"
+	ExportEntityPost  = "post"
+)
+
+// ExportChange is the last change of a space or post, kept for delta exports. Deleted
+// entities stay as tombstones so incremental exports can report the deletion.
+type ExportChange struct {
+	Entity   string `json:"type" db:"entity"`
+	EntityID int    `json:"id" db:"entity_id"`
+	Deleted  bool   `json:"deleted" db:"deleted"`
+	Changed  int64  `json:"changed" db:"changed"` // Milliseconds
+}
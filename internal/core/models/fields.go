@@ -0,0 +1,19 @@
+package models
+
+// Custom field types of a space field schema
+const (
+	FieldTypeText   = "text"
+	FieldTypeNumber = "number"
+	FieldTypeDate   = "date" // YYYY-MM-DD
+	FieldTypeSelect = "select"
+)
+
+// FieldDef is a named field of the custom field schema of a space. Posts of the space store
+// one value per field, normalized to a string.
+type FieldDef struct {
+	Name     string   `json:"name"`
+	Label    string   `json:"label,omitempty"` // Shown by the composer, Name when empty
+	Type     string   `json:"type"`
+	Required bool     `json:"required,omitempty"`
+	Options  []string `json:"options,omitempty"` // Allowed values of select fields
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Post listing orders. Activity is the last edit, move, merge or attachment of a post, or its
+// creation when it was never touched since.
+const (
+	PostSortCreatedDesc = "created_desc"
+	PostSortCreatedAsc  = "created_asc"
+	PostSortUpdatedDesc = "updated_desc"
+)
+
+// IsPostSort reports whether sort is a known listing order
+func IsPostSort(sort string) bool {
+	return sort == PostSortCreatedDesc || sort == PostSortCreatedAsc || sort == PostSortUpdatedDesc
+}
+
+// PostFilter narrows post listings. Zero values leave a criterion unused.
+type PostFilter struct {
+	Source     string
+	Tags       []string // Posts must carry every tag
+	Extensions []string // Posts must have an attachment with any of these extensions
+	After      int64    // Inclusive lower bound on creation time, in milliseconds
+	Before     int64    // Exclusive upper bound on creation time, in milliseconds
+	Sort       string   // Listing order, one of the PostSort values; newest first when empty
+
+	// Cursor lists the posts coming after it in the order, only for the creation orders
+	Cursor *PostCursor
+
+	// ExcludeSpaces leaves out the posts of spaces hidden from the viewer by access lists,
+	// even when cross-posted elsewhere. It is set by the post service, not by clients.
+	ExcludeSpaces []int
+}
+
+// IsEmpty reports whether the filter lets every post through. The order and the cursor are not
+// criteria.
+func (f PostFilter) IsEmpty() bool {
+	return f.Source == "" && len(f.Tags) == 0 && len(f.Extensions) == 0 && f.After == 0 && f.Before == 0
+}
+
+// PostCursor marks a position in a listing ordered by creation, for keyset pagination. Unlike
+// an offset it stays valid while posts are added or deleted before it.
+type PostCursor struct {
+	Created int64
+	ID      int
+	Pinned  bool // Among the pinned posts leading the listing of a space
+}
+
+// Encode turns the cursor into the opaque token handed to clients
+func (c PostCursor) Encode() string {
+	token := fmt.Sprintf("%d:%d", c.Created, c.ID)
+	if c.Pinned {
+		token += ":" + postCursorPinned
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(token))
+}
+
+// postCursorPinned ends the tokens of cursors on pinned posts
+const postCursorPinned = "pinned"
+
+// ParsePostCursor reads a token made by Encode
+func ParsePostCursor(token string) (*PostCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	created, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, fmt.Errorf("missing cursor separator")
+	}
+
+	var cursor PostCursor
+	if postID, flag, ok := strings.Cut(id, ":"); ok {
+		if flag != postCursorPinned {
+			return nil, fmt.Errorf("invalid cursor flag")
+		}
+		id = postID
+		cursor.Pinned = true
+	}
+	if cursor.Created, err = strconv.ParseInt(created, 10, 64); err != nil {
+		return nil, err
+	}
+	if cursor.ID, err = strconv.Atoi(id); err != nil || cursor.ID <= 0 {
+		return nil, fmt.Errorf("invalid cursor post ID")
+	}
+	return &cursor, nil
+}
+
+// IsCursorSort reports whether listings in this order can be paginated with a PostCursor
+func IsCursorSort(sort string) bool {
+	return sort == "" || sort == PostSortCreatedDesc || sort == PostSortCreatedAsc
+}
+
+// SavedFilter is a named timeline view that can be reused through its ID
+type SavedFilter struct {
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	SpaceID    int      `json:"space_id"` // 0 for every space
+	Recursive  bool     `json:"recursive"`
+	Tags       []string `json:"tags"`
+	Extensions []string `json:"extensions"`
+	StartDate  string   `json:"start_date,omitempty"` // YYYY-MM-DD, inclusive
+	EndDate    string   `json:"end_date,omitempty"`   // YYYY-MM-DD, inclusive
+	Created    int64    `json:"created"`
+	Updated    int64    `json:"updated"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// Where the current setting of a feature flag comes from, in increasing precedence
+const (
+	FlagSourceDefault  = "default"
+	FlagSourceConfig   = "config"
+	FlagSourceOverride = "override"
+)
+
+// FeatureFlag is the effective setting of a feature flag
+type FeatureFlag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Rollout     int    `json:"rollout"` // Percent of spaces the flag is on for; 0 means every space
+	Source      string `json:"source"`
+	Updated     int64  `json:"updated,omitempty"` // Time of the runtime override
+}
@@ -0,0 +1,9 @@
+package models
+
+type GlossaryTerm struct {
+	ID         int    `json:"id" db:"id"`
+	SpaceID    int    `json:"space_id" db:"space_id"`
+	Term       string `json:"term" db:"term"`
+	Definition string `json:"definition" db:"definition"`
+	Created    int64  `json:"created" db:"created"`
+}
@@ -0,0 +1,35 @@
+package models
+
+// Outcomes recorded in the activity log of an ingest token
+const (
+	IngestOutcomeAccepted    = "accepted"
+	IngestOutcomeRejected    = "rejected"
+	IngestOutcomeRateLimited = "rate_limited"
+	IngestOutcomeRevoked     = "revoked"
+)
+
+// IngestToken lets an external system post into one space. Only a hash of the token is
+// stored; the full value is shown once, when the token is minted.
+type IngestToken struct {
+	ID        int    `json:"id"`
+	SpaceID   int    `json:"space_id"`
+	Name      string `json:"name"`
+	Prefix    string `json:"prefix"`     // First characters of the token, to recognize it
+	RateLimit int    `json:"rate_limit"` // Requests accepted per hour
+	Created   int64  `json:"created"`
+	LastUsed  int64  `json:"last_used,omitempty"`
+	Revoked   int64  `json:"revoked,omitempty"` // Revocation time, 0 while active
+	Signed    bool   `json:"signed"`            // Requests must be signed with the token secret
+	Hash      string `json:"-"`
+	Secret    string `json:"-"` // Signing secret, empty when requests need no signature
+}
+
+// IngestEvent is an entry of the activity log of an ingest token
+type IngestEvent struct {
+	ID      int    `json:"id"`
+	TokenID int    `json:"token_id"`
+	Created int64  `json:"created"`
+	Outcome string `json:"outcome"`
+	PostID  int    `json:"post_id,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
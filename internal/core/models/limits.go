@@ -0,0 +1,11 @@
+package models
+
+// EffectiveLimits are the posting limits that apply to a space once overrides are resolved
+type EffectiveLimits struct {
+	SpaceID                int  `json:"space_id"`
+	MaxContentLength       int  `json:"max_content_length"`
+	GlobalMaxContentLength int  `json:"global_max_content_length"`
+	OverrideSpaceID        *int `json:"override_space_id"` // Space defining the override, nil when the global limit applies
+	MaxFileSizeMB          int  `json:"max_file_size_mb"`
+	MaxFilesPerPost        int  `json:"max_files_per_post"`
+}
@@ -0,0 +1,7 @@
+package models
+
+// MemoryUsage is the estimated footprint of an in-memory structure
+type MemoryUsage struct {
+	Entries int   `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// Moderation flag review statuses
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved" // Reviewed as safe to share
+	ModerationRejected = "rejected" // Confirmed problem, blocks sharing until the content changes
+)
+
+// ModerationFlag is a finding of a moderation hook on a post, queued for review
+type ModerationFlag struct {
+	ID          int    `json:"id" db:"id"`
+	PostID      int    `json:"post_id" db:"post_id"`
+	SpaceID     int    `json:"space_id"`
+	Rule        string `json:"rule" db:"rule"`
+	Source      string `json:"source" db:"source"`
+	Excerpt     string `json:"excerpt" db:"excerpt"` // Masked match, never the full sensitive value
+	Fingerprint string `json:"-" db:"fingerprint"`
+	Status      string `json:"status" db:"status"`
+	Created     int64  `json:"created" db:"created"`
+	Reviewed    *int64 `json:"reviewed,omitempty" db:"reviewed"`
+}
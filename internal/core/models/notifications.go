@@ -0,0 +1,48 @@
+package models
+
+// Notification kinds
+const (
+	NotificationReminderDue  = "reminder_due"
+	NotificationDigestReady  = "digest_ready"
+	NotificationQuotaWarning = "quota_warning"
+	NotificationSyncFailure  = "sync_failure"
+	NotificationReviewDue    = "review_due"
+)
+
+// Notification is an in-app notification raised by a feature
+type Notification struct {
+	ID      int    `json:"id"`
+	Kind    string `json:"kind"`
+	Title   string `json:"title"`
+	Message string `json:"message,omitempty"`
+	SpaceID int    `json:"space_id,omitempty"`
+	PostID  int    `json:"post_id,omitempty"`
+	Key     string `json:"-"`
+	Count   int    `json:"count"` // Times raised while unread
+	Created int64  `json:"created"`
+	Updated int64  `json:"updated"`
+	Read    int64  `json:"read,omitempty"` // Time it was marked read, 0 while unread
+}
+
+// Channels of held deliveries
+const (
+	DeliveryNotification = "notification" // A notification for the notification center
+	DeliveryWebhook      = "webhook"      // A webhook call
+)
+
+// QuietHours is a daily period, in server time, during which notifications and webhook calls
+// are held until it ends. It spans midnight when End is before Start.
+type QuietHours struct {
+	Start string `json:"start"` // HH:MM
+	End   string `json:"end"`   // HH:MM
+}
+
+// HeldDelivery is a notification or webhook call waiting for quiet hours to end
+type HeldDelivery struct {
+	ID       int    `json:"id"`
+	Channel  string `json:"channel"`
+	Target   string `json:"target,omitempty"` // Webhook URL
+	Payload  []byte `json:"-"`
+	Attempts int    `json:"attempts"`
+	Created  int64  `json:"created"`
+}
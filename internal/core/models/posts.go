@@ -1,14 +1,91 @@
 package models
 
+import "unicode/utf8"
+
+// Post sources record the ingestion path a post came from. Imported posts use
+// PostSourceImportPrefix followed by the importing tool, e.g. "import:obsidian".
+const (
+	PostSourceManual       = "manual"
+	PostSourceAPI          = "api"
+	PostSourceEmail        = "email"
+	PostSourceWebhook      = "webhook"
+	PostSourceCLI          = "cli"
+	PostSourceCapture      = "capture"
+	PostSourceShareLink    = "share"
+	PostSourceImportPrefix = "import:"
+)
+
+// Post types. Journal posts are unique per space and day, further entries are appended to them.
+const (
+	PostTypeNote    = "note"
+	PostTypeJournal = "journal"
+)
+
 type Post struct {
 	ID               int    `json:"id" db:"id"`
 	SpaceID       int    `json:"space_id" db:"space_id"`
 	Content          string `json:"content" db:"content"`
 	Created          int64  `json:"created" db:"created"`
+	Source           string `json:"source,omitempty" db:"source"`
+	Type             string `json:"type,omitempty" db:"type"`
+	Hash             string `json:"hash,omitempty" db:"-"` // Row hash of space, timestamp and content, see storage.PostHash
+	Warnings         []string `json:"warnings,omitempty" db:"-"` // Ingest warnings, only set when the post is created
+	Fields           map[string]string `json:"fields,omitempty" db:"-"` // Custom field values, see FieldDef
+	Author           string `json:"author,omitempty" db:"-"` // Set for posts written through a share link
+	CrosspostedFrom  *int   `json:"crossposted_from,omitempty" db:"-"` // Owner space, set when listed through a cross-post
+	CrosspostedTo    []int  `json:"crossposted_to,omitempty" db:"-"`   // Spaces the post is cross-posted to, set on single posts
+	Truncated        bool   `json:"truncated,omitempty" db:"-"`        // Content was cut by a compact response, the full post is at GET /api/posts/{id}
+	DuplicateLinks   []LinkReference `json:"duplicate_links,omitempty" db:"-"` // Earlier posts holding links of the post, only set when the post is created
+	Pinned           bool   `json:"pinned,omitempty" db:"-"`           // Pinned at the top of the listing of its space
+}
+
+// LinkReference points at a post holding a link
+type LinkReference struct {
+	URL     string `json:"url"`
+	PostID  int    `json:"post_id"`
+	SpaceID int    `json:"space_id"`
+	Created int64  `json:"created"`
 }
 
 type PostWithAttachments struct {
 	Post
 	Attachments  []Attachment  `json:"attachments"`
 	LinkPreviews []LinkPreview `json:"link_previews"`
-}
\ No newline at end of file
+}
+
+// Compact drops what a client on a metered connection can do without: link preview images
+// and descriptions, checksums and other metadata. Content longer than maxContent characters
+// is cut and flagged as truncated; 0 keeps it whole.
+func (p *PostWithAttachments) Compact(maxContent int) {
+	p.Hash = ""
+	p.Warnings = nil
+	p.Fields = nil
+	if maxContent > 0 && utf8.RuneCountInString(p.Content) > maxContent {
+		p.Content = string([]rune(p.Content)[:maxContent])
+		p.Truncated = true
+	}
+	for i := range p.Attachments {
+		p.Attachments[i].Warnings = nil
+		p.Attachments[i].SHA256 = ""
+	}
+	for i := range p.LinkPreviews {
+		p.LinkPreviews[i].Description = ""
+		p.LinkPreviews[i].ImageURL = ""
+	}
+}
+// RetimeResult reports the outcome of a bulk timestamp adjustment
+type RetimeResult struct {
+	AuditID        int   `json:"audit_id"`
+	PostsChanged   int   `json:"posts_changed"`
+	PostIDs        []int `json:"post_ids"`
+	SkippedJournal int   `json:"skipped_journal"` // Journal posts selected but kept on their day
+}
+
+// PostRevision is the content and link previews of a post before one of its edits
+type PostRevision struct {
+	ID           int           `json:"id"`
+	PostID       int           `json:"post_id"`
+	Content      string        `json:"content"`
+	LinkPreviews []LinkPreview `json:"link_previews"`
+	Edited       int64         `json:"edited"` // When this content was replaced
+}
@@ -0,0 +1,16 @@
+package models
+
+// Preference scopes, from the most to the least specific
+const (
+	PreferenceScopeUser     = "user"     // Set by the signed in user
+	PreferenceScopeInstance = "instance" // Set for everyone by an admin, or by the only user without login
+	PreferenceScopeDefault  = "default"  // Nothing set
+)
+
+// Preferences follow a user across browsers. LandingSpaceID is only set for the space view.
+type Preferences struct {
+	LandingView    string `json:"landing_view"`
+	LandingSpaceID *int   `json:"landing_space_id,omitempty"`
+	Scope          string `json:"scope"`
+	Updated        int64  `json:"updated,omitempty"`
+}
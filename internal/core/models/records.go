@@ -0,0 +1,26 @@
+package models
+
+import "encoding/json"
+
+// RecordKeeping is the record keeping setting of a space. While enabled, every edit of a post
+// of the space first stores an immutable copy of the prior version.
+type RecordKeeping struct {
+	SpaceID       int   `json:"space_id"`
+	Enabled       bool  `json:"enabled"`
+	RetentionDays int   `json:"retention_days,omitempty"` // Records cannot be deleted before
+	Since         int64 `json:"since,omitempty"`          // When record keeping was enabled
+}
+
+// PostRecord is an immutable copy of a post version replaced by an edit, chained by hash to
+// the previous record of its space
+type PostRecord struct {
+	ID           int             `json:"id"`
+	SpaceID      int             `json:"space_id"`
+	PostID       int             `json:"post_id"`
+	Content      string          `json:"content"`
+	LinkPreviews json.RawMessage `json:"link_previews"` // Kept as stored, the hash covers these bytes
+	Recorded     int64           `json:"recorded"`
+	RetainUntil  int64           `json:"retain_until"`
+	PrevHash     string          `json:"prev_hash"` // Empty for the first record of a space
+	Hash         string          `json:"hash"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// SpaceReview is the review schedule of a space
+type SpaceReview struct {
+	SpaceID      int   `json:"space_id"`
+	IntervalDays int   `json:"interval_days"`
+	LastReviewed int64 `json:"last_reviewed"` // Last completed review, or when the schedule started
+	Due          int64 `json:"due"`
+	Notified     int64 `json:"-"` // Due time a notification was raised for
+}
+
+// ReviewChanges summarizes what changed in a space since a time
+type ReviewChanges struct {
+	NewPosts     int   `json:"new_posts"`
+	UpdatedPosts int   `json:"updated_posts"` // Older posts edited, moved in or given files since
+	LastActivity int64 `json:"last_activity,omitempty"`
+	PostIDs      []int `json:"post_ids"` // Most recently changed posts first, limited
+}
@@ -0,0 +1,75 @@
+package models
+
+// Automation rule action types
+const (
+	RuleActionAddTag   = "add_tag"
+	RuleActionMove     = "move"
+	RuleActionWebhook  = "webhook"
+	RuleActionReminder = "reminder"
+)
+
+// Outcomes recorded in the execution log of a rule
+const (
+	RuleOutcomeApplied = "applied" // Every action succeeded
+	RuleOutcomeFailed  = "failed"  // At least one action failed
+	RuleOutcomeSkipped = "skipped" // The loop guard stopped the rule from acting on the post
+)
+
+// RuleConditions must all hold for a rule to act on a post; empty conditions always hold
+type RuleConditions struct {
+	SpaceID   int      `json:"space_id,omitempty"` // 0 for every space
+	Recursive bool     `json:"recursive,omitempty"`
+	Tags      []string `json:"tags,omitempty"`     // Hashtags the post must all carry
+	Contains  string   `json:"contains,omitempty"` // Case-insensitive text the content must contain
+	Pattern   string   `json:"pattern,omitempty"`  // Regular expression the content must match
+}
+
+// RuleAction is one step run when a rule matches; the fields used depend on the type
+type RuleAction struct {
+	Type       string `json:"type"`
+	Tag        string `json:"tag,omitempty"`         // add_tag
+	SpaceID    int    `json:"space_id,omitempty"`    // move
+	URL        string `json:"url,omitempty"`         // webhook
+	AfterHours int    `json:"after_hours,omitempty"` // reminder
+	Message    string `json:"message,omitempty"`     // reminder
+}
+
+// Rule runs its actions on the post of every trigger event matching its conditions
+type Rule struct {
+	ID         int            `json:"id"`
+	Name       string         `json:"name"`
+	Enabled    bool           `json:"enabled"`
+	Trigger    string         `json:"trigger"` // Post event type, e.g. post.created
+	Conditions RuleConditions `json:"conditions"`
+	Actions    []RuleAction   `json:"actions"`
+	Created    int64          `json:"created"`
+	Updated    int64          `json:"updated"`
+}
+
+// RuleActionResult reports how one action of a rule execution went
+type RuleActionResult struct {
+	Type   string `json:"type"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// RuleExecution is an entry of the execution log of a rule
+type RuleExecution struct {
+	ID      int                `json:"id"`
+	RuleID  int                `json:"rule_id"`
+	Created int64              `json:"created"`
+	Trigger string             `json:"trigger"`
+	PostID  int                `json:"post_id"`
+	Outcome string             `json:"outcome"`
+	Results []RuleActionResult `json:"results"`
+}
+
+// RuleReminder is a reminder set by a rule, raised as a notification once due
+type RuleReminder struct {
+	ID      int    `json:"id"`
+	RuleID  int    `json:"rule_id"`
+	PostID  int    `json:"post_id"`
+	SpaceID int    `json:"space_id"`
+	Message string `json:"message"`
+	Due     int64  `json:"due"`
+}
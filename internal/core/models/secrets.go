@@ -0,0 +1,14 @@
+package models
+
+// SecretFinding records a likely secret detected in ingested content
+type SecretFinding struct {
+	ID           int    `json:"id" db:"id"`
+	PostID       *int   `json:"post_id,omitempty" db:"post_id"` // Nil when the content was rejected
+	AttachmentID *int   `json:"attachment_id,omitempty" db:"attachment_id"`
+	SpaceID      int    `json:"space_id" db:"space_id"`
+	Source       string `json:"source" db:"source"` // "post" or the attachment filename
+	Kind         string `json:"kind" db:"kind"`
+	Excerpt      string `json:"excerpt" db:"excerpt"` // Masked secret
+	Action       string `json:"action" db:"action"`   // warn, redact or reject
+	Created      int64  `json:"created" db:"created"`
+}
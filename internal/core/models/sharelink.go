@@ -0,0 +1,26 @@
+package models
+
+// ShareLink lets anyone holding it read a space and post into it until it expires, without
+// an account. Only a hash of the token is stored; the full value is shown once, when the link
+// is created.
+type ShareLink struct {
+	ID        int    `json:"id"`
+	SpaceID   int    `json:"space_id"`
+	Name      string `json:"name"`
+	Prefix    string `json:"prefix"`     // First characters of the token, to recognize it
+	RateLimit int    `json:"rate_limit"` // Posts accepted per hour
+	Created   int64  `json:"created"`
+	Expires   int64  `json:"expires"`
+	LastUsed  int64  `json:"last_used,omitempty"`
+	Revoked   int64  `json:"revoked,omitempty"` // Revocation time, 0 while active
+	Signed    bool   `json:"signed"`            // Requests must be signed with the link secret
+	Hash      string `json:"-"`
+	Secret    string `json:"-"` // Signing secret, empty when requests need no signature
+}
+
+// PostAuthor attributes a post to the person who wrote it through a share link
+type PostAuthor struct {
+	PostID      int    `json:"post_id"`
+	Author      string `json:"author"`
+	ShareLinkID int    `json:"share_link_id,omitempty"` // 0 once the link is gone
+}
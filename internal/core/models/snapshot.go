@@ -0,0 +1,45 @@
+package models
+
+// SpaceSnapshot is a point-in-time copy of a space subtree that can be restored later
+type SpaceSnapshot struct {
+	ID              int    `json:"id" db:"id"`
+	SpaceID         int    `json:"space_id" db:"space_id"` // Root space of the snapshot, may no longer exist
+	Name            string `json:"name" db:"name"`
+	Scheduled       bool   `json:"scheduled" db:"scheduled"` // Taken by the space schedule rather than by hand
+	SpaceCount      int    `json:"space_count" db:"space_count"`
+	PostCount       int    `json:"post_count" db:"post_count"`
+	AttachmentCount int    `json:"attachment_count" db:"attachment_count"`
+	Size            int64  `json:"size" db:"size"` // Total size of the attachments
+	Created         int64  `json:"created" db:"created"`
+	Manifest        string `json:"-" db:"manifest"` // JSON SnapshotManifest
+}
+
+// SnapshotManifest is the content of a snapshot; spaces are ordered parents first
+type SnapshotManifest struct {
+	Spaces []Space        `json:"spaces"`
+	Posts  []SnapshotPost `json:"posts"`
+}
+
+// SnapshotPost is a post of a snapshot with what belongs to it
+type SnapshotPost struct {
+	Post
+	LinkPreviews []LinkPreview        `json:"link_previews,omitempty"`
+	Attachments  []SnapshotAttachment `json:"attachments,omitempty"`
+}
+
+// SnapshotAttachment refers to the attachment file kept in the snapshot store by its hash
+type SnapshotAttachment struct {
+	Filename string `json:"filename"`
+	FileType string `json:"file_type"`
+	FileSize int64  `json:"file_size"`
+	SHA256   string `json:"sha256"`
+}
+
+// SnapshotSchedule makes a space snapshot itself every IntervalHours, keeping the last Keep
+// scheduled snapshots
+type SnapshotSchedule struct {
+	SpaceID       int   `json:"space_id" db:"space_id"`
+	IntervalHours int   `json:"interval_hours" db:"interval_hours"`
+	Keep          int   `json:"keep" db:"keep"`
+	LastRun       int64 `json:"last_run,omitempty" db:"last_run"`
+}
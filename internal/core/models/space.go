@@ -9,12 +9,18 @@ type Space struct {
 	ParentID    *int   `json:"parent_id" db:"parent_id"`
 	Depth       int    `json:"depth" db:"depth"`
 	Created     int64  `json:"created" db:"created"`
+	Hash        string `json:"hash,omitempty" db:"-"` // Row hash of parent, name and description, see storage.SpaceHash
 
 	// Cached fields
 	PostCount          int `json:"post_count"`
 	RecursivePostCount int `json:"recursive_post_count"`
 }
 
+// SpaceSettings holds the display preferences of a space
+type SpaceSettings struct {
+	DefaultSort string `json:"default_sort"` // Listing order when none is requested, see PostSort
+}
+
 // GetSlug generates a URL-safe slug from the space name
 func (s *Space) GetSlug() string {
 	return utils.GenerateSlug(s.Name)
@@ -23,4 +29,10 @@ func (s *Space) GetSlug() string {
 type SpaceTree struct {
 	Space
 	Children []*SpaceTree `json:"children,omitempty"`
-}
\ No newline at end of file
+}
+// SpaceMatch is a space returned by a space search, best matches first
+type SpaceMatch struct {
+	Space
+	Score        float64 `json:"score"`
+	LastActivity int64   `json:"last_activity"` // Latest post time in the space or its descendants, 0 if none
+}
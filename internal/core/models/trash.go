@@ -0,0 +1,38 @@
+package models
+
+// Trash item types
+const (
+	TrashItemPost       = "post"
+	TrashItemAttachment = "attachment"
+	TrashItemSpace      = "space"
+)
+
+// TrashItem is a snapshot of a deleted post, attachment or space, kept until PurgeAt
+type TrashItem struct {
+	ID            int    `json:"id" db:"id"`
+	ItemType      string `json:"item_type" db:"item_type"`
+	ItemID        int    `json:"item_id" db:"item_id"`   // ID the item had before deletion
+	SpaceID       int    `json:"space_id" db:"space_id"` // Space the item belonged to (parent space for spaces, 0 for roots)
+	ParentTrashID *int   `json:"parent_trash_id,omitempty" db:"parent_trash_id"`
+	Label         string `json:"label" db:"label"`
+	Payload       string `json:"-" db:"payload"` // JSON snapshot used to restore the item
+	Size          int64  `json:"size" db:"size"`
+	DeletedAt     int64  `json:"deleted_at" db:"deleted_at"`
+	PurgeAt       int64  `json:"purge_at" db:"purge_at"`
+}
+
+// TrashedPost is the payload of a trashed post
+type TrashedPost struct {
+	Post         Post          `json:"post"`
+	LinkPreviews []LinkPreview `json:"link_previews"`
+	Pinned       int64         `json:"pinned,omitempty"` // When the post was pinned, 0 when it was not
+}
+
+// TrashedSpace is the payload of a trashed space subtree; spaces are ordered parents first
+type TrashedSpace struct {
+	Spaces       []Space                 `json:"spaces"`
+	Posts        []Post                  `json:"posts"`
+	LinkPreviews []LinkPreview           `json:"link_previews"`
+	Pins         map[int]int64           `json:"pins,omitempty"` // When the pinned posts were pinned, by post ID
+	ACLs         map[int][]SpaceACLEntry `json:"acls,omitempty"` // Access lists of the private spaces, by space ID
+}
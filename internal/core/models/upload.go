@@ -0,0 +1,21 @@
+package models
+
+// Checks an upload can fail, reported by upload validation
+const (
+	UploadCheckExtension = "extension"
+	UploadCheckSize      = "size"
+	UploadCheckMaxFiles  = "max_files"
+	UploadCheckQuota     = "quota" // Free disk space of the uploads directory
+)
+
+// UploadRejection is a check an upload fails
+type UploadRejection struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// UploadValidation tells whether an upload would be accepted, before its bytes are sent
+type UploadValidation struct {
+	Accepted   bool              `json:"accepted"`
+	Rejections []UploadRejection `json:"rejections"`
+}
@@ -0,0 +1,60 @@
+package models
+
+// Roles granted to users. Viewers only read, editors change content and admins may also use
+// the /api/admin endpoints.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// RoleRank orders roles from the least to the most privileged; unknown roles rank 0
+func RoleRank(role string) int {
+	switch role {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// User is an account provisioned on its first single sign-on login. Subject is the stable
+// identifier given by the provider; email and name are refreshed on every login.
+type User struct {
+	ID        int    `json:"id"`
+	Subject   string `json:"subject"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	Created   int64  `json:"created"`
+	LastLogin int64  `json:"last_login"`
+}
+
+// Session is a login of a user on one device. Device is a short description of the browser
+// and system read from the user agent.
+type Session struct {
+	ID        int    `json:"id"`
+	UserID    int    `json:"-"`
+	Device    string `json:"device"`
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+	Created   int64  `json:"created"`
+	Expires   int64  `json:"expires"`
+	LastSeen  int64  `json:"last_seen"`
+	Current   bool   `json:"current"` // Set on the session of the request listing them
+}
+
+// Permissions granted on a private space. Write implies read.
+const (
+	SpacePermissionRead  = "read"
+	SpacePermissionWrite = "write"
+)
+
+// SpaceACLEntry grants a user access to a private space and its descendants
+type SpaceACLEntry struct {
+	UserID     int    `json:"user_id"`
+	Permission string `json:"permission"`
+}
@@ -0,0 +1,182 @@
+// Package render turns page data into standalone HTML documents for the pages served
+// outside the web app: print views, share pages, digests and the login page. Every page goes
+// through one layout with the instance theme and its styles inlined, so a saved page needs
+// nothing else. Dates and the document language follow the locale of the page, negotiated from
+// the Accept-Language header of the request with the instance locale as fallback.
+package render
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/utils"
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//go:embed templates/*.html styles/*.css
+var assets embed.FS
+
+// Pages rendered through the layout, each with templates/<name>.html and optional styles/<name>.css
+const (
+	PagePrint  = "print"
+	PageShare  = "share"
+	PageDigest = "digest"
+	PageLogin  = "login"
+)
+
+// funcs are replaced for each document by those of its locale, see localeFuncs
+var funcs = localeFuncs(config.DefaultLocale)
+
+func localeFuncs(locale string) template.FuncMap {
+	return template.FuncMap{
+		// date formats a timestamp in milliseconds
+		"date": func(millis int64) string {
+			return FormatDate(locale, millis)
+		},
+	}
+}
+
+// dateLayouts turns the date format of a locale into a time layout, e.g. DD/MM/YYYY into 02/01/2006
+var dateLayouts = strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02")
+
+// FormatDate formats a timestamp in milliseconds with the date and time formats of locale
+func FormatDate(locale string, millis int64) string {
+	info := utils.GetLocaleInfo(locale)
+	clock := "15:04"
+	if info.TimeFormat == "12h" {
+		clock = "3:04 PM"
+	}
+	return time.UnixMilli(millis).Format(dateLayouts.Replace(info.DateFormat) + " " + clock)
+}
+
+var pages = func() map[string]*template.Template {
+	parsed := make(map[string]*template.Template)
+	for _, name := range []string{PagePrint, PageShare, PageDigest, PageLogin} {
+		parsed[name] = template.Must(template.New("layout.html").Funcs(funcs).ParseFS(assets, "templates/layout.html", "templates/"+name+".html"))
+	}
+	return parsed
+}()
+
+// styles holds the base styles followed by those of each page
+var styles = func() map[string]string {
+	base, _ := assets.ReadFile("styles/base.css")
+	combined := make(map[string]string)
+	for name := range pages {
+		css := string(base)
+		if page, err := assets.ReadFile("styles/" + name + ".css"); err == nil {
+			css += "\n" + string(page)
+		}
+		combined[name] = css
+	}
+	return combined
+}()
+
+var accentPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Theme is the look of rendered pages, set per instance in the options
+type Theme struct {
+	Mode   string // light or dark
+	Accent string // Hex color
+}
+
+// IsTheme reports whether mode is a supported theme mode
+func IsTheme(mode string) bool {
+	return mode == config.ThemeLight || mode == config.ThemeDark
+}
+
+// IsAccent reports whether accent is a hex color usable as the accent
+func IsAccent(accent string) bool {
+	return accentPattern.MatchString(accent)
+}
+
+// CurrentTheme returns the theme of the instance options, with defaults for unset or
+// invalid values
+func CurrentTheme() Theme {
+	theme := Theme{Mode: config.DefaultTheme, Accent: config.DefaultAccent}
+	options := config.GetOptionsConfig()
+	if options == nil {
+		return theme
+	}
+	if IsTheme(options.Metadata.Theme) {
+		theme.Mode = options.Metadata.Theme
+	}
+	if IsAccent(options.Metadata.Accent) {
+		theme.Accent = options.Metadata.Accent
+	}
+	return theme
+}
+
+// InstanceLocale returns the locale of the instance options, the default one when unset or
+// unsupported
+func InstanceLocale() string {
+	options := config.GetOptionsConfig()
+	if options == nil || !utils.IsSupportedLocale(options.Metadata.Locale) {
+		return config.DefaultLocale
+	}
+	return options.Metadata.Locale
+}
+
+// RequestLocale returns the locale of a page requested with r, the best match of its
+// Accept-Language header or the instance locale
+func RequestLocale(r *http.Request) string {
+	return utils.NegotiateLocale(r.Header.Get("Accept-Language"), InstanceLocale())
+}
+
+// document is what the layout receives, the page data goes to the body of the page
+type document struct {
+	Lang   string
+	Title  string
+	Theme  Theme
+	Styles template.CSS
+	Data   interface{}
+}
+
+// Render writes the page name with data as a full HTML document in locale
+func Render(w io.Writer, locale, name, title string, data interface{}) error {
+	parsed, ok := pages[name]
+	if !ok {
+		return fmt.Errorf("unknown page %q", name)
+	}
+	page, err := parsed.Clone()
+	if err != nil {
+		return err
+	}
+	page.Funcs(localeFuncs(locale))
+
+	// The accent is validated, so it can go in the styles as is
+	theme := CurrentTheme()
+	css := styles[name]
+	if theme.Accent != config.DefaultAccent {
+		css += "\n:root {\n  --accent: " + theme.Accent + ";\n}\n"
+	}
+
+	lang := utils.GetLocaleInfo(locale).Code
+	return page.Execute(w, document{Lang: lang, Title: title, Theme: theme, Styles: template.CSS(css), Data: data})
+}
+
+// Write renders the page name as the response to r. Nothing is written before the page is
+// complete, so a template error still gets a clean error response.
+func Write(w http.ResponseWriter, r *http.Request, name, title string, data interface{}) {
+	WriteStatus(w, r, http.StatusOK, name, title, data)
+}
+
+// WriteStatus renders the page name as the response to r with status, for pages reporting an error
+func WriteStatus(w http.ResponseWriter, r *http.Request, status int, name, title string, data interface{}) {
+	locale := RequestLocale(r)
+	var buf bytes.Buffer
+	if err := Render(&buf, locale, name, title, data); err != nil {
+		http.Error(w, config.ErrTemplateExecutionError, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Language", locale)
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
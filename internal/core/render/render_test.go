@@ -0,0 +1,128 @@
+package render
+
+import (
+	"backthynk/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testDigest struct {
+	SpaceName string
+	PostCount int
+	Summary   string
+}
+
+type testPayload struct {
+	Week    string
+	Digests []testDigest
+}
+
+func TestCurrentTheme(t *testing.T) {
+	defer config.SetOptionsConfigForTest(nil)
+
+	tests := []struct {
+		name     string
+		mode     string
+		accent   string
+		expected Theme
+	}{
+		{"Defaults", "", "", Theme{Mode: config.ThemeLight, Accent: config.DefaultAccent}},
+		{"Dark with accent", config.ThemeDark, "#ff8800", Theme{Mode: config.ThemeDark, Accent: "#ff8800"}},
+		{"Short accent", config.ThemeLight, "#f80", Theme{Mode: config.ThemeLight, Accent: "#f80"}},
+		{"Invalid values", "sepia", "red;}body{display:none", Theme{Mode: config.ThemeLight, Accent: config.DefaultAccent}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := config.NewTestOptionsConfig()
+			options.Metadata.Theme = tt.mode
+			options.Metadata.Accent = tt.accent
+			config.SetOptionsConfigForTest(options)
+
+			if theme := CurrentTheme(); theme != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, theme)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	options := config.NewTestOptionsConfig()
+	options.Metadata.Theme = config.ThemeDark
+	options.Metadata.Accent = "#ff8800"
+	config.SetOptionsConfigForTest(options)
+	defer config.SetOptionsConfigForTest(nil)
+
+	payload := testPayload{Week: "2026-10-05", Digests: []testDigest{{SpaceName: "Notes <b>", PostCount: 1, Summary: "Short week"}}}
+	w := httptest.NewRecorder()
+	Write(w, httptest.NewRequest("GET", "/", nil), PageDigest, "Weekly digest", payload)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("Unexpected response %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	for _, expected := range []string{
+		`<html lang="en-US" data-theme="dark">`,
+		"<title>Weekly digest</title>",
+		"--accent: #ff8800;",
+		"Notes &lt;b&gt;",
+		"1 post<",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected body to contain %q", expected)
+		}
+	}
+	if strings.Contains(body, "@page") {
+		t.Error("Expected the print styles only on print pages")
+	}
+}
+
+func TestRenderUnknownPage(t *testing.T) {
+	var out strings.Builder
+	if err := Render(&out, config.DefaultLocale, "missing", "Title", nil); err == nil {
+		t.Error("Expected an error for an unknown page")
+	}
+}
+
+func TestLocale(t *testing.T) {
+	options := config.NewTestOptionsConfig()
+	options.Metadata.Locale = "de-DE"
+	config.SetOptionsConfigForTest(options)
+	defer config.SetOptionsConfigForTest(nil)
+
+	created := time.Date(2026, 3, 4, 17, 5, 0, 0, time.Local).UnixMilli()
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expectedLang   string
+		expectedDate   string
+	}{
+		{"Instance locale without a preference", "", "de-DE", "04.03.2026 17:05"},
+		{"Negotiated locale", "fr-CH, fr;q=0.9", "fr-FR", "04/03/2026 17:05"},
+		{"Twelve-hour clock", "en-US", "en-US", "03/04/2026 5:05 PM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if locale := RequestLocale(req); locale != tt.expectedLang {
+				t.Fatalf("Expected locale %s, got %s", tt.expectedLang, locale)
+			}
+			if date := FormatDate(tt.expectedLang, created); date != tt.expectedDate {
+				t.Errorf("Expected date %q, got %q", tt.expectedDate, date)
+			}
+
+			w := httptest.NewRecorder()
+			Write(w, req, PageDigest, "Weekly digest", testPayload{Week: "2026-03-02"})
+			if !strings.Contains(w.Body.String(), `<html lang="`+tt.expectedLang+`"`) || w.Header().Get("Content-Language") != tt.expectedLang {
+				t.Errorf("Expected the page in %s, got %q", tt.expectedLang, w.Header().Get("Content-Language"))
+			}
+		})
+	}
+}
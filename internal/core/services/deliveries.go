@@ -0,0 +1,195 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Deliveries holds back the notifications and webhook calls of the instance. Those about a
+// muted space, or one of its descendants, are dropped; those sent during quiet hours are held
+// until they end. A nil Deliveries lets everything through right away.
+type Deliveries struct {
+	db    *storage.DB
+	cache *cache.SpaceCache
+	now   func() time.Time
+	muted map[int]bool
+	quiet *models.QuietHours
+	mu    sync.RWMutex
+}
+
+func NewDeliveries(db *storage.DB, cache *cache.SpaceCache) *Deliveries {
+	return &Deliveries{
+		db:    db,
+		cache: cache,
+		now:   time.Now,
+		muted: make(map[int]bool),
+	}
+}
+
+// Load reads the muted spaces and the quiet hours from the database
+func (d *Deliveries) Load() error {
+	spaceIDs, err := d.db.GetNotificationMutes()
+	if err != nil {
+		return fmt.Errorf("failed to load notification mutes: %w", err)
+	}
+	quiet, err := d.db.GetQuietHours()
+	if err != nil {
+		return fmt.Errorf("failed to load quiet hours: %w", err)
+	}
+
+	muted := make(map[int]bool, len(spaceIDs))
+	for _, id := range spaceIDs {
+		muted[id] = true
+	}
+
+	d.mu.Lock()
+	d.muted = muted
+	d.quiet = quiet
+	d.mu.Unlock()
+	return nil
+}
+
+// MutedSpaces returns the IDs of the muted spaces, sorted
+func (d *Deliveries) MutedSpaces() []int {
+	if d == nil {
+		return []int{}
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	ids := make([]int, 0, len(d.muted))
+	for id := range d.muted {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// SetMuted mutes or unmutes the notifications of a space
+func (d *Deliveries) SetMuted(spaceID int, muted bool) error {
+	if _, ok := d.cache.Get(spaceID); !ok {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if err := d.db.SetNotificationMute(spaceID, muted, d.now().UnixMilli()); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if muted {
+		d.muted[spaceID] = true
+	} else {
+		delete(d.muted, spaceID)
+	}
+	return nil
+}
+
+// QuietHours returns the quiet hours, nil when none are set
+func (d *Deliveries) QuietHours() *models.QuietHours {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.quiet == nil {
+		return nil
+	}
+	quiet := *d.quiet
+	return &quiet
+}
+
+// SetQuietHours replaces the quiet hours; nil removes them
+func (d *Deliveries) SetQuietHours(quiet *models.QuietHours) error {
+	if quiet != nil {
+		start, errStart := time.Parse(config.QuietHoursTimeLayout, quiet.Start)
+		end, errEnd := time.Parse(config.QuietHoursTimeLayout, quiet.End)
+		if errStart != nil || errEnd != nil || start.Equal(end) {
+			return fmt.Errorf(config.ErrInvalidQuietHours)
+		}
+		quiet = &models.QuietHours{Start: start.Format(config.QuietHoursTimeLayout), End: end.Format(config.QuietHoursTimeLayout)}
+	}
+	if err := d.db.SetQuietHours(quiet); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.quiet = quiet
+	d.mu.Unlock()
+	return nil
+}
+
+// Muted reports whether notifications about a space are dropped. Notifications about no
+// particular space (spaceID 0) are never muted.
+func (d *Deliveries) Muted(spaceID int) bool {
+	if d == nil || spaceID == 0 {
+		return false
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if len(d.muted) == 0 {
+		return false
+	}
+	for _, id := range append([]int{spaceID}, d.cache.GetAncestors(spaceID)...) {
+		if d.muted[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// Quiet reports whether quiet hours are in effect
+func (d *Deliveries) Quiet() bool {
+	if d == nil {
+		return false
+	}
+
+	d.mu.RLock()
+	quiet := d.quiet
+	d.mu.RUnlock()
+	if quiet == nil {
+		return false
+	}
+
+	// Both times were validated when set
+	start, _ := time.Parse(config.QuietHoursTimeLayout, quiet.Start)
+	end, _ := time.Parse(config.QuietHoursTimeLayout, quiet.End)
+	now := d.now()
+	minute := now.Hour()*60 + now.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from < to {
+		return minute >= from && minute < to
+	}
+	return minute >= from || minute < to
+}
+
+// Hold stores a delivery while quiet hours are in effect and reports whether it did; a
+// delivery that was not held is for the caller to send now
+func (d *Deliveries) Hold(channel, target string, payload []byte) (bool, error) {
+	if !d.Quiet() {
+		return false, nil
+	}
+
+	err := d.db.HoldDelivery(&models.HeldDelivery{
+		Channel: channel,
+		Target:  target,
+		Payload: payload,
+		Created: d.now().UnixMilli(),
+	})
+	return err == nil, err
+}
+
+// HoldWebhook holds a JSON webhook call while quiet hours are in effect, see Hold
+func (d *Deliveries) HoldWebhook(url string, body []byte) (bool, error) {
+	return d.Hold(models.DeliveryWebhook, url, body)
+}
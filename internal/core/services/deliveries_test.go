@@ -0,0 +1,96 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDeliveries(t *testing.T) {
+	setup, err := setupSpaceDeletionTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	work, _ := setup.spaceService.Create(context.Background(), "Work", nil, "")
+	nested, _ := setup.spaceService.Create(context.Background(), "Nested", &work.ID, "")
+	home, _ := setup.spaceService.Create(context.Background(), "Home", nil, "")
+
+	deliveries := NewDeliveries(setup.db, setup.cache)
+	at := func(clock string) func() time.Time {
+		parsed, _ := time.Parse(config.QuietHoursTimeLayout, clock)
+		return func() time.Time {
+			return time.Date(2026, 10, 5, parsed.Hour(), parsed.Minute(), 0, 0, time.Local)
+		}
+	}
+
+	t.Run("muting covers descendants", func(t *testing.T) {
+		if err := deliveries.SetMuted(work.ID, true); err != nil {
+			t.Fatalf("SetMuted failed: %v", err)
+		}
+		if !deliveries.Muted(work.ID) || !deliveries.Muted(nested.ID) || deliveries.Muted(home.ID) || deliveries.Muted(0) {
+			t.Error("Expected Work and its descendants only to be muted")
+		}
+		if err := deliveries.SetMuted(999, true); err == nil || err.Error() != config.ErrSpaceNotFound {
+			t.Errorf("Expected unknown spaces to be refused, got %v", err)
+		}
+	})
+
+	t.Run("quiet hours may span midnight", func(t *testing.T) {
+		if err := deliveries.SetQuietHours(&models.QuietHours{Start: "22:00", End: "7:00"}); err != nil {
+			t.Fatalf("SetQuietHours failed: %v", err)
+		}
+		if quiet := deliveries.QuietHours(); quiet.End != "07:00" {
+			t.Errorf("Expected times to be normalized, got %+v", quiet)
+		}
+
+		tests := map[string]bool{"21:59": false, "22:00": true, "03:30": true, "06:59": true, "07:00": false, "12:00": false}
+		for clock, quiet := range tests {
+			deliveries.now = at(clock)
+			if deliveries.Quiet() != quiet {
+				t.Errorf("At %s: expected quiet %v", clock, quiet)
+			}
+		}
+
+		for _, invalid := range []models.QuietHours{{Start: "22:00", End: "22:00"}, {Start: "25:00", End: "07:00"}, {Start: "", End: "07:00"}} {
+			if err := deliveries.SetQuietHours(&invalid); err == nil || err.Error() != config.ErrInvalidQuietHours {
+				t.Errorf("Expected %+v to be refused, got %v", invalid, err)
+			}
+		}
+	})
+
+	t.Run("deliveries are held while quiet", func(t *testing.T) {
+		deliveries.now = at("12:00")
+		if held, err := deliveries.HoldWebhook("https://example.com/hook", []byte(`{}`)); held || err != nil {
+			t.Errorf("Expected nothing held outside quiet hours, got %v (%v)", held, err)
+		}
+		deliveries.now = at("23:00")
+		if held, err := deliveries.HoldWebhook("https://example.com/hook", []byte(`{}`)); !held || err != nil {
+			t.Errorf("Expected the call to be held, got %v (%v)", held, err)
+		}
+		if count, _ := setup.db.CountHeldDeliveries(); count != 1 {
+			t.Errorf("Expected 1 held delivery, got %d", count)
+		}
+	})
+
+	t.Run("settings survive a restart", func(t *testing.T) {
+		reloaded := NewDeliveries(setup.db, setup.cache)
+		if err := reloaded.Load(); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if muted := reloaded.MutedSpaces(); len(muted) != 1 || muted[0] != work.ID {
+			t.Errorf("Expected Work muted, got %v", muted)
+		}
+		if quiet := reloaded.QuietHours(); quiet == nil || quiet.Start != "22:00" {
+			t.Errorf("Expected quiet hours to be kept, got %+v", quiet)
+		}
+
+		var none *Deliveries
+		if none.Muted(work.ID) || none.Quiet() {
+			t.Error("Expected a nil Deliveries to let everything through")
+		}
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package services
+
+// freeDiskSpace is not known on this platform
+func freeDiskSpace(path string) (int64, bool) {
+	return 0, false
+}
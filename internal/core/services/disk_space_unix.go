@@ -0,0 +1,14 @@
+//go:build unix
+
+package services
+
+import "syscall"
+
+// freeDiskSpace returns the bytes available to unprivileged users on the filesystem of path
+func freeDiskSpace(path string) (int64, bool) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, false
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), true
+}
@@ -5,22 +5,45 @@ import (
 	"backthynk/internal/core/events"
 	"backthynk/internal/core/logger"
 	"backthynk/internal/core/models"
+	"backthynk/internal/core/utils"
 	"backthynk/internal/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+// ColdStorage keeps attachment files that were not accessed for a long time on a slower
+// storage tier. Restore brings a file back into the uploads directory and reports whether
+// it was in the cold tier at all. The cold storage feature provides one.
+type ColdStorage interface {
+	Restore(filePath string) (bool, error)
+}
+
+// URLVerifier checks the expiry and signature of signed upload URLs. The signed URLs feature
+// provides one.
+type URLVerifier interface {
+	Verify(filePath, expires, signature string) error
+}
+
 type FileService struct {
 	db         *storage.DB
 	dispatcher *events.Dispatcher
 	uploadPath string
+	secrets    SecretScreener
+	cold       ColdStorage
+	signer     URLVerifier
+	remote     *http.Client
 }
 
 func NewFileService(db *storage.DB, dispatcher *events.Dispatcher) *FileService {
@@ -29,15 +52,97 @@ func NewFileService(db *storage.DB, dispatcher *events.Dispatcher) *FileService
 		db:         db,
 		dispatcher: dispatcher,
 		uploadPath: uploadPath,
+		remote:     utils.NewPublicHTTPClient(config.HTTPTimeout(config.RemoteFileHTTPTimeout)),
+	}
+}
+
+// SetSecretScreener makes uploaded text files go through secret detection
+func (s *FileService) SetSecretScreener(screener SecretScreener) {
+	s.secrets = screener
+}
+
+// SetColdStorage makes downloads restore attachment files moved to the cold tier
+func (s *FileService) SetColdStorage(cold ColdStorage) {
+	s.cold = cold
+}
+
+// SetURLVerifier makes downloads accept signed URLs
+func (s *FileService) SetURLVerifier(verifier URLVerifier) {
+	s.signer = verifier
+}
+
+// VerifySignedURL checks a signed URL to an upload; no signature is valid without a verifier
+func (s *FileService) VerifySignedURL(filePath, expires, signature string) error {
+	if s.signer == nil {
+		return fmt.Errorf(config.ErrSignedURLInvalid)
 	}
+	return s.signer.Verify(filePath, expires, signature)
 }
 
-func (s *FileService) UploadFile(postID int, file io.Reader, filename string, fileSize int64) (*models.Attachment, error) {
-	// Create unique filename
-	timestamp := time.Now().Unix()
-	storedFilename := fmt.Sprintf("%d_%s", timestamp, filename)
-	filePath := filepath.Join(s.uploadPath, storedFilename)
+// UploadTarget returns the number of files attached to the post an upload targets, or 0 for
+// a post about to be created in a space when postID is 0
+func (s *FileService) UploadTarget(postID, spaceID int) (int, error) {
+	if postID == 0 {
+		if _, err := s.db.GetSpace(spaceID); err != nil {
+			if err.Error() == "space not found" {
+				return 0, fmt.Errorf(config.ErrSpaceNotFound)
+			}
+			return 0, err
+		}
+		return 0, nil
+	}
 
+	if _, err := s.db.GetPost(postID); err != nil {
+		if err.Error() == "post not found" {
+			return 0, fmt.Errorf(config.ErrPostNotFound)
+		}
+		return 0, err
+	}
+	attachments, err := s.db.GetAttachmentsByPost(postID)
+	if err != nil {
+		return 0, err
+	}
+	return len(attachments), nil
+}
+
+// AvailableSpace returns the free bytes of the filesystem holding the uploads directory,
+// false when they cannot be told
+func (s *FileService) AvailableSpace() (int64, bool) {
+	if free, ok := freeDiskSpace(s.uploadPath); ok {
+		return free, true
+	}
+	// The uploads directory is created by the first upload
+	return freeDiskSpace(filepath.Dir(s.uploadPath))
+}
+
+// isTextFile reports whether an attachment holds text worth scanning for secrets
+func isTextFile(fileType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(fileType)
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml",
+		"application/toml", "application/x-sh", "application/javascript":
+		return true
+	}
+	return false
+}
+
+// storedFile is an uploaded file written to the uploads directory, after secret screening
+type storedFile struct {
+	name     string // File path relative to the uploads directory
+	fileType string
+	size     int64
+	checksum string
+	warnings []string
+	original string       // Content before redaction, only kept when secrets were found
+	post     *models.Post // Loaded by the secret scan, nil otherwise
+}
+
+// storeFile writes file to the uploads directory under its original filename, numbered when
+// taken, screening text files for secrets. The file is removed again when an error is returned.
+func (s *FileService) storeFile(postID int, file io.Reader, filename string) (*storedFile, error) {
 	// Ensure upload directory exists
 	if err := os.MkdirAll(s.uploadPath, config.DirectoryPermissions); err != nil {
 		logger.Error("Failed to create upload directory", zap.String("path", s.uploadPath), zap.Error(err))
@@ -45,45 +150,121 @@ func (s *FileService) UploadFile(postID int, file io.Reader, filename string, fi
 	}
 
 	// Save file
-	dst, err := os.Create(filePath)
+	dst, storedName, err := s.createStoredFile(filename)
 	if err != nil {
-		logger.Error("Failed to create file for upload", zap.String("path", filePath), zap.String("filename", filename), zap.Error(err))
+		logger.Error("Failed to create file for upload", zap.String("filename", filename), zap.Error(err))
+		if err.Error() == config.ErrFilenameCollisions {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to create file: %w", err)
 	}
 	defer dst.Close()
+	filePath := filepath.Join(s.uploadPath, storedName)
 
-	written, err := io.Copy(dst, file)
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(dst, hash), file)
 	if err != nil {
 		os.Remove(filePath)
 		logger.Error("Failed to save file", zap.String("filename", filename), zap.Int("post_id", postID), zap.Error(err))
 		return nil, fmt.Errorf("failed to save file: %w", err)
 	}
+	dst.Close()
+	stored := &storedFile{name: storedName, size: written, checksum: hex.EncodeToString(hash.Sum(nil))}
 
 	// Detect file type
-	fileType := mime.TypeByExtension(filepath.Ext(filename))
-	if fileType == "" {
-		fileType = "application/octet-stream"
+	stored.fileType = mime.TypeByExtension(filepath.Ext(filename))
+	if stored.fileType == "" {
+		stored.fileType = "application/octet-stream"
+	}
+
+	// Scan text files for secrets
+	if s.secrets != nil && written <= config.MaxSecretScanBytes && isTextFile(stored.fileType) {
+		stored.post, err = s.db.GetPost(postID)
+		if err != nil {
+			os.Remove(filePath)
+			return nil, err
+		}
+
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			os.Remove(filePath)
+			logger.Error("Failed to read file for secret scan", zap.String("path", filePath), zap.Error(err))
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		original := string(data)
+
+		screened, found, err := s.secrets.Screen(stored.post.SpaceID, filename, original)
+		if err != nil {
+			os.Remove(filePath)
+			return nil, err
+		}
+		if len(found) > 0 {
+			stored.warnings = found
+			stored.original = original
+		}
+
+		if screened != original {
+			if err := os.WriteFile(filePath, []byte(screened), config.FilePermissions); err != nil {
+				os.Remove(filePath)
+				logger.Error("Failed to write redacted file", zap.String("path", filePath), zap.Error(err))
+				return nil, fmt.Errorf("failed to write redacted file: %w", err)
+			}
+			stored.size = int64(len(screened))
+			sum := sha256.Sum256([]byte(screened))
+			stored.checksum = hex.EncodeToString(sum[:])
+		}
+	}
+
+	return stored, nil
+}
+
+// recordFindings keeps the secrets found in a stored file for the attachment it became
+func (s *FileService) recordFindings(stored *storedFile, attachment *models.Attachment, filename string) {
+	if len(stored.warnings) == 0 {
+		return
+	}
+	attachment.Warnings = stored.warnings
+	if err := s.secrets.RecordFindings(stored.post.SpaceID, attachment.PostID, &attachment.ID, filename, stored.original); err != nil {
+		logger.Warning("Failed to record secret findings", zap.Int("attachment_id", attachment.ID), zap.Error(err))
+	}
+}
+
+func (s *FileService) UploadFile(ctx context.Context, postID int, file io.Reader, filename string, fileSize int64) (*models.Attachment, error) {
+	stored, err := s.storeFile(postID, file, filename)
+	if err != nil {
+		return nil, err
 	}
 
 	// Save to database
-	attachment, err := s.db.CreateAttachment(postID, filename, storedFilename, fileType, written)
+	attachment, err := s.db.CreateAttachment(postID, filename, stored.name, stored.fileType, stored.size)
 	if err != nil {
-		os.Remove(filePath)
+		os.Remove(filepath.Join(s.uploadPath, stored.name))
 		logger.Error("Failed to save attachment info to database", zap.String("filename", filename), zap.Int("post_id", postID), zap.Error(err))
 		return nil, fmt.Errorf("failed to save attachment info: %w", err)
 	}
+
+	// A missing checksum is computed again on the first download
+	if err := s.db.SetAttachmentChecksum(attachment.ID, stored.checksum); err == nil {
+		attachment.SHA256 = stored.checksum
+	}
 	
+	s.recordFindings(stored, attachment, filename)
+
 	// Get post to find space for event
-	post, err := s.db.GetPost(postID)
+	post := stored.post
+	if post == nil {
+		post, err = s.db.GetPost(postID)
+	}
 	if err == nil {
 		// Dispatch event
-		s.dispatcher.Dispatch(events.Event{
+		s.dispatcher.DispatchContext(ctx, events.Event{
 			Type: events.FileUploaded,
 			Data: events.PostEvent{
 				PostID:     postID,
 				SpaceID: post.SpaceID,
-				FileSize:   written,
+				FileSize:   stored.size,
 				FileCount:  1,
+				AttachmentID: attachment.ID,
 			},
 		})
 	}
@@ -91,18 +272,194 @@ func (s *FileService) UploadFile(postID int, file io.Reader, filename string, fi
 	return attachment, nil
 }
 
-func (s *FileService) GetPostWithAttachments(postID int) (*models.PostWithAttachments, error) {
-	post, err := s.db.GetPost(postID)
+// UploadVersion replaces the file of an attachment with a new version of the same document.
+// The replaced file is kept as a prior version; beyond keep prior versions, the oldest ones
+// are dropped along with their files.
+func (s *FileService) UploadVersion(ctx context.Context, attachmentID int, file io.Reader, filename string, keep int) (*models.Attachment, error) {
+	previous, spaceID, err := s.db.GetAttachment(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.db.GetAttachmentVersions(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.storeFile(previous.PostID, file, filename)
+	if err != nil {
+		return nil, err
+	}
+	return s.replaceFile(ctx, previous, spaceID, currentVersion(versions), stored, filename, keep)
+}
+
+// replaceFile makes stored the current file of an attachment. The replaced file becomes prior
+// version number version, unless version is 0 in which case it is removed.
+func (s *FileService) replaceFile(ctx context.Context, previous *models.Attachment, spaceID, version int, stored *storedFile, filename string, keep int) (*models.Attachment, error) {
+	now := time.Now()
+	attachment := &models.Attachment{
+		ID:       previous.ID,
+		PostID:   previous.PostID,
+		Filename: filename,
+		FilePath: stored.name,
+		FileType: stored.fileType,
+		FileSize: stored.size,
+	}
+	var dropped []string
+	var err error
+	if version == 0 {
+		err = s.db.RewriteAttachmentFile(attachment)
+		dropped = []string{previous.FilePath}
+	} else {
+		dropped, err = s.db.ReplaceAttachmentFile(models.AttachmentVersion{
+			Version:  version,
+			Filename: previous.Filename,
+			FilePath: previous.FilePath,
+			FileType: previous.FileType,
+			FileSize: previous.FileSize,
+			SHA256:   previous.SHA256,
+			Replaced: now.UnixMilli(),
+		}, attachment, keep)
+	}
+	if err != nil {
+		os.Remove(filepath.Join(s.uploadPath, stored.name))
+		return nil, err
+	}
+	for _, filePath := range dropped {
+		os.Remove(filepath.Join(s.uploadPath, filePath)) // Ignore errors like the regular delete
+	}
+
+	if err := s.db.SetAttachmentChecksum(attachment.ID, stored.checksum); err == nil {
+		attachment.SHA256 = stored.checksum
+	}
+	s.recordFindings(stored, attachment, filename)
+
+	// The attachment count stays the same, only the size of the current file changes
+	s.dispatcher.DispatchContext(ctx, events.Event{
+		Type: events.FileDeleted,
+		Data: events.PostEvent{PostID: attachment.PostID, SpaceID: spaceID, FileSize: previous.FileSize, FileCount: 1},
+	})
+	s.dispatcher.DispatchContext(ctx, events.Event{
+		Type: events.FileUploaded,
+		Data: events.PostEvent{PostID: attachment.PostID, SpaceID: spaceID, FileSize: stored.size, FileCount: 1, AttachmentID: attachment.ID},
+	})
+
+	return attachment, nil
+}
+
+// GetVersions returns the current file of an attachment with the prior versions kept
+func (s *FileService) GetVersions(attachmentID int) (*models.AttachmentVersions, error) {
+	current, _, err := s.db.GetAttachment(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.db.GetAttachmentVersions(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.AttachmentVersions{
+		Current:   *current,
+		Version:   currentVersion(versions),
+		Versions:  versions,
+		TotalSize: current.FileSize,
+	}
+	for _, version := range versions {
+		result.TotalSize += version.FileSize
+	}
+	return result, nil
+}
+
+// currentVersion is the version number of the current file, given its prior versions newest first
+func currentVersion(versions []models.AttachmentVersion) int {
+	if len(versions) == 0 {
+		return 1
+	}
+	return versions[0].Version + 1
+}
+
+// AttachmentPost returns the ID of the post an attachment belongs to
+func (s *FileService) AttachmentPost(attachmentID int) (int, error) {
+	attachment, _, err := s.db.GetAttachment(attachmentID)
+	if err != nil {
+		return 0, err
+	}
+	return attachment.PostID, nil
+}
+
+// FilePost returns the ID of the post a stored file belongs to, whether it is the current
+// file of an attachment or one of its prior versions
+func (s *FileService) FilePost(filePath string) (int, error) {
+	return s.db.GetFilePostID(filePath)
+}
+
+// GetDownload returns the attachment stored under filePath with the checksum of its file,
+// computing and storing the checksum of files uploaded before checksums were kept. A file
+// in the cold tier is restored first, so it can be read from the uploads directory.
+func (s *FileService) GetDownload(filePath string) (*models.Attachment, error) {
+	attachment, _, err := s.db.GetAttachmentByFilePath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if s.cold != nil {
+		if _, err := s.cold.Restore(filePath); err != nil {
+			return nil, err
+		}
+	}
+	if attachment.SHA256 != "" {
+		return attachment, nil
+	}
+
+	file, err := os.Open(filepath.Join(s.uploadPath, filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, fmt.Errorf("failed to hash file: %w", err)
+	}
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	if err := s.db.SetAttachmentChecksum(attachment.ID, attachment.SHA256); err != nil {
+		logger.Warning("Failed to backfill attachment checksum", zap.Int("attachment_id", attachment.ID), zap.Error(err))
+	}
+	return attachment, nil
+}
+
+// RecordDownload notifies listeners that the attachment stored under filePath was downloaded
+func (s *FileService) RecordDownload(filePath string) error {
+	attachment, spaceID, err := s.db.GetAttachmentByFilePath(filePath)
+	if err != nil {
+		return err
+	}
+
+	s.dispatcher.Dispatch(events.Event{
+		Type: events.FileDownloaded,
+		Data: events.PostEvent{
+			PostID:       attachment.PostID,
+			SpaceID:      spaceID,
+			AttachmentID: attachment.ID,
+			Timestamp:    time.Now().UnixMilli(),
+			FileSize:     attachment.FileSize,
+		},
+	})
+
+	return nil
+}
+
+func (s *FileService) GetPostWithAttachments(ctx context.Context, postID int) (*models.PostWithAttachments, error) {
+	post, err := s.db.GetPostContext(ctx, postID)
 	if err != nil {
 		return nil, err
 	}
 	
-	attachments, err := s.db.GetAttachmentsByPost(postID)
+	attachments, err := s.db.GetAttachmentsByPostContext(ctx, postID)
 	if err != nil {
 		return nil, err
 	}
 	
-	linkPreviews, err := s.db.GetLinkPreviewsByPostID(postID)
+	linkPreviews, err := s.db.GetLinkPreviewsByPostIDContext(ctx, postID)
 	if err != nil {
 		return nil, err
 	}
@@ -116,46 +473,46 @@ func (s *FileService) GetPostWithAttachments(postID int) (*models.PostWithAttach
 
 func (s *FileService) SaveLinkPreview(postID int, preview interface{}) error {
 	// Convert preview data to LinkPreview model
+	var previewMap map[string]interface{}
 	switch p := preview.(type) {
 	case map[string]interface{}:
-		linkPreview := &models.LinkPreview{
-			PostID:      postID,
-			URL:         getString(p, "url"),
-			Title:       getString(p, "title"),
-			Description: getString(p, "description"),
-			ImageURL:    getString(p, "image_url"),
-			SiteName:    getString(p, "site_name"),
-		}
-		return s.db.CreateLinkPreview(linkPreview)
+		previewMap = p
 	default:
 		// Try reflection for any struct with proper field names
-		if preview != nil {
-			// Use JSON marshaling/unmarshaling to convert
-			jsonData, err := json.Marshal(preview)
-			if err != nil {
-				logger.Error("Failed to marshal link preview", zap.Int("post_id", postID), zap.Error(err))
-				return fmt.Errorf("failed to marshal preview: %w", err)
-			}
-
-			var previewMap map[string]interface{}
-			if err := json.Unmarshal(jsonData, &previewMap); err != nil {
-				logger.Error("Failed to unmarshal link preview", zap.Int("post_id", postID), zap.Error(err))
-				return fmt.Errorf("failed to unmarshal preview: %w", err)
-			}
+		if preview == nil {
+			logger.Warning("Unsupported link preview type", zap.Int("post_id", postID), zap.String("type", fmt.Sprintf("%T", preview)))
+			return fmt.Errorf("unsupported preview type: %T", preview)
+		}
 
-			linkPreview := &models.LinkPreview{
-				PostID:      postID,
-				URL:         getString(previewMap, "url"),
-				Title:       getString(previewMap, "title"),
-				Description: getString(previewMap, "description"),
-				ImageURL:    getString(previewMap, "image_url"),
-				SiteName:    getString(previewMap, "site_name"),
-			}
-			return s.db.CreateLinkPreview(linkPreview)
+		// Use JSON marshaling/unmarshaling to convert
+		jsonData, err := json.Marshal(preview)
+		if err != nil {
+			logger.Error("Failed to marshal link preview", zap.Int("post_id", postID), zap.Error(err))
+			return fmt.Errorf("failed to marshal preview: %w", err)
+		}
+		if err := json.Unmarshal(jsonData, &previewMap); err != nil {
+			logger.Error("Failed to unmarshal link preview", zap.Int("post_id", postID), zap.Error(err))
+			return fmt.Errorf("failed to unmarshal preview: %w", err)
 		}
-		logger.Warning("Unsupported link preview type", zap.Int("post_id", postID), zap.String("type", fmt.Sprintf("%T", preview)))
-		return fmt.Errorf("unsupported preview type: %T", preview)
 	}
+
+	linkPreview := &models.LinkPreview{
+		PostID:      postID,
+		URL:         getString(previewMap, "url"),
+		Title:       getString(previewMap, "title"),
+		Description: getString(previewMap, "description"),
+		ImageURL:    getString(previewMap, "image_url"),
+		SiteName:    getString(previewMap, "site_name"),
+	}
+	if err := s.db.CreateLinkPreview(linkPreview); err != nil {
+		return err
+	}
+
+	s.dispatcher.Dispatch(events.Event{
+		Type: events.LinkPreviewSaved,
+		Data: events.PostEvent{PostID: postID},
+	})
+	return nil
 }
 
 func getString(m map[string]interface{}, key string) string {
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+// RedactImage blurs or blacks out regions of an image attachment and makes the result its
+// current file. With keepOriginal the original becomes a prior version, as on a re-upload;
+// otherwise its file is removed. Re-encoding also drops the metadata of the image.
+func (s *FileService) RedactImage(ctx context.Context, attachmentID int, regions []models.RedactRegion, keepOriginal bool, keep int) (*models.Attachment, error) {
+	previous, spaceID, err := s.db.GetAttachment(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if s.cold != nil {
+		if _, err := s.cold.Restore(previous.FilePath); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.uploadPath, previous.FilePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	header, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "png" && format != "jpeg") {
+		return nil, fmt.Errorf(config.ErrImageNotRedactable)
+	}
+	if header.Width*header.Height > config.MaxRedactPixels {
+		return nil, fmt.Errorf(config.ErrImageTooLargeToRedact)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf(config.ErrImageNotRedactable)
+	}
+
+	redacted, err := redact(img, regions)
+	if err != nil {
+		return nil, err
+	}
+	var encoded bytes.Buffer
+	if format == "png" {
+		err = png.Encode(&encoded, redacted)
+	} else {
+		err = jpeg.Encode(&encoded, redacted, &jpeg.Options{Quality: config.RedactJPEGQuality})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode redacted image: %w", err)
+	}
+
+	version := 0
+	if keepOriginal {
+		versions, err := s.db.GetAttachmentVersions(attachmentID)
+		if err != nil {
+			return nil, err
+		}
+		version = currentVersion(versions)
+	}
+
+	stored, err := s.storeFile(previous.PostID, &encoded, previous.Filename)
+	if err != nil {
+		return nil, err
+	}
+	return s.replaceFile(ctx, previous, spaceID, version, stored, previous.Filename, keep)
+}
+
+// redact returns a copy of img with regions blurred or blacked out. Regions are checked
+// before any is applied; parts outside the image are ignored.
+func redact(img image.Image, regions []models.RedactRegion) (*image.RGBA, error) {
+	if len(regions) == 0 || len(regions) > config.MaxRedactRegions {
+		return nil, fmt.Errorf(config.ErrInvalidRedactRegions)
+	}
+
+	bounds := img.Bounds()
+	rects := make([]image.Rectangle, len(regions))
+	for i, region := range regions {
+		if region.Width <= 0 || region.Height <= 0 {
+			return nil, fmt.Errorf(config.ErrInvalidRedactRegions)
+		}
+		if region.Mode != "" && region.Mode != config.RedactModeBlur && region.Mode != config.RedactModeBlackout {
+			return nil, fmt.Errorf(config.ErrInvalidRedactRegions)
+		}
+		rects[i] = image.Rect(region.X, region.Y, region.X+region.Width, region.Y+region.Height).Add(bounds.Min).Intersect(bounds)
+		if rects[i].Empty() {
+			return nil, fmt.Errorf(config.ErrInvalidRedactRegions)
+		}
+	}
+
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Src)
+	for i, region := range regions {
+		if region.Mode == config.RedactModeBlur {
+			blur(out, rects[i])
+		} else {
+			draw.Draw(out, rects[i], image.Black, image.Point{}, draw.Src)
+		}
+	}
+	return out, nil
+}
+
+// blur blurs a rectangle of img with repeated box blurs. Only pixels of the rectangle are
+// averaged, so the blur neither bleeds out of it nor picks up its surroundings. The radius
+// grows with the rectangle so that large text cannot be read through it.
+func blur(img *image.RGBA, rect image.Rectangle) {
+	radius := max(config.MinRedactBlurRadius, min(rect.Dx(), rect.Dy())/4)
+	for pass := 0; pass < config.RedactBlurPasses; pass++ {
+		boxBlur(img, rect, radius, true)
+		boxBlur(img, rect, radius, false)
+	}
+}
+
+// boxBlur averages every pixel of rect with the radius pixels on each side of it, along rows
+// when horizontal is set and along columns otherwise. Lines are extended by their edge pixels.
+func boxBlur(img *image.RGBA, rect image.Rectangle, radius int, horizontal bool) {
+	lines, length := rect.Dy(), rect.Dx()
+	if !horizontal {
+		lines, length = rect.Dx(), rect.Dy()
+	}
+	offset := func(line, i int) int {
+		if horizontal {
+			return img.PixOffset(rect.Min.X+i, rect.Min.Y+line)
+		}
+		return img.PixOffset(rect.Min.X+line, rect.Min.Y+i)
+	}
+	clamp := func(i int) int {
+		return max(0, min(length-1, i))
+	}
+
+	window := 2*radius + 1
+	src := make([]int, length*4)
+	for line := 0; line < lines; line++ {
+		for i := 0; i < length; i++ {
+			o := offset(line, i)
+			for c := 0; c < 4; c++ {
+				src[i*4+c] = int(img.Pix[o+c])
+			}
+		}
+
+		var sum [4]int
+		for k := -radius; k <= radius; k++ {
+			j := clamp(k)
+			for c := 0; c < 4; c++ {
+				sum[c] += src[j*4+c]
+			}
+		}
+		for i := 0; i < length; i++ {
+			o := offset(line, i)
+			for c := 0; c < 4; c++ {
+				img.Pix[o+c] = uint8(sum[c] / window)
+			}
+			leaving, entering := clamp(i-radius), clamp(i+radius+1)
+			for c := 0; c < 4; c++ {
+				sum[c] += src[entering*4+c] - src[leaving*4+c]
+			}
+		}
+	}
+}
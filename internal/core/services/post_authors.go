@@ -0,0 +1,33 @@
+package services
+
+import "backthynk/internal/core/models"
+
+// GetPostAuthor returns who wrote a post through a share link, empty for posts written by hand
+func (s *PostService) GetPostAuthor(postID int) (string, error) {
+	authors, err := s.db.GetPostAuthorsByPosts([]int{postID})
+	if err != nil {
+		return "", err
+	}
+	return authors[postID], nil
+}
+
+// attachAuthors sets the share link author of listed posts
+func (s *PostService) attachAuthors(posts []models.PostWithAttachments) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	authors, err := s.db.GetPostAuthorsByPosts(ids)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Author = authors[posts[i].ID]
+	}
+
+	return nil
+}
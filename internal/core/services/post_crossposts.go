@@ -0,0 +1,79 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"context"
+	"fmt"
+)
+
+// Cross-posts make a post appear in the listings of other spaces. The post keeps a single
+// owner space: cached counts and statistics only include it there, so it is counted once.
+
+// ValidateCrossposts checks that a post owned by ownerSpaceID can be cross-posted to spaceIDs
+func (s *PostService) ValidateCrossposts(ownerSpaceID int, spaceIDs []int) error {
+	seen := make(map[int]bool, len(spaceIDs))
+	for _, spaceID := range spaceIDs {
+		if spaceID == ownerSpaceID {
+			return fmt.Errorf(config.ErrCrosspostOwnSpace)
+		}
+		if _, ok := s.cache.Get(spaceID); !ok {
+			return fmt.Errorf(config.ErrSpaceNotFound)
+		}
+		seen[spaceID] = true
+	}
+	if len(seen) > config.MaxCrosspostSpaces {
+		return fmt.Errorf(config.ErrFmtTooManyCrossposts, config.MaxCrosspostSpaces)
+	}
+	return nil
+}
+
+// Crosspost makes a post appear in the given spaces and returns every space it is cross-posted to
+func (s *PostService) Crosspost(postID int, spaceIDs ...int) ([]int, error) {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.db.GetCrosspostSpaces(postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ValidateCrossposts(post.SpaceID, append(current, spaceIDs...)); err != nil {
+		return nil, err
+	}
+
+	for _, spaceID := range spaceIDs {
+		if err := s.db.AddCrosspost(postID, spaceID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.db.GetCrosspostSpaces(postID)
+}
+
+// RemoveCrosspost takes a post out of a space it was cross-posted to
+func (s *PostService) RemoveCrosspost(postID, spaceID int) error {
+	found, err := s.db.RemoveCrosspost(postID, spaceID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf(config.ErrCrosspostNotFound)
+	}
+	return nil
+}
+
+// GetCrossposts returns the spaces a post is cross-posted to
+func (s *PostService) GetCrossposts(postID int) ([]int, error) {
+	return s.db.GetCrosspostSpaces(postID)
+}
+
+// CountCrosspostsInto counts the posts listed in a space only through a cross-post, which
+// cached post counts leave out
+func (s *PostService) CountCrosspostsInto(ctx context.Context, spaceID int, recursive bool) (int, error) {
+	spaceIDs := []int{spaceID}
+	if recursive {
+		spaceIDs = append(spaceIDs, visibleSpaces(s.cache.GetDescendants(spaceID), s.access.Hidden(ctx))...)
+	}
+	return s.db.CountCrosspostsInto(ctx, spaceIDs)
+}
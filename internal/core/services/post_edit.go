@@ -0,0 +1,91 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// Update replaces the content of a post, and its link previews unless linkPreviews is nil. With
+// edit history on, the replaced content and link previews are kept as a revision.
+func (s *PostService) Update(ctx context.Context, postID int, content string, linkPreviews []models.LinkPreview) (*models.Post, error) {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return nil, postLookupError(err)
+	}
+
+	if err := s.checkContentLength(post.SpaceID, content); err != nil {
+		return nil, err
+	}
+
+	original := content
+	var warnings []string
+	if s.secrets != nil {
+		screened, found, err := s.secrets.Screen(post.SpaceID, config.SecretSourcePost, content)
+		if err != nil {
+			return nil, err
+		}
+		content, warnings = screened, found
+	}
+
+	update := storage.PostUpdate{Content: content, LinkPreviews: linkPreviews, Edited: s.now().UnixMilli()}
+	if s.options != nil && s.options.Features.EditHistory.Enabled {
+		previews, err := s.db.GetLinkPreviewsByPostID(postID)
+		if err != nil {
+			return nil, err
+		}
+		update.Revision = &models.PostRevision{Content: post.Content, LinkPreviews: previews, Edited: update.Edited}
+		update.KeepRevisions = s.options.Features.EditHistory.MaxRevisions
+		if update.KeepRevisions <= 0 {
+			update.KeepRevisions = config.DefaultMaxPostRevisions
+		}
+	}
+
+	if err := s.db.UpdatePost(postID, update); err != nil {
+		return nil, postLookupError(err)
+	}
+	post.Content = content
+
+	if len(warnings) > 0 {
+		post.Warnings = warnings
+		if err := s.secrets.RecordFindings(post.SpaceID, post.ID, nil, config.SecretSourcePost, original); err != nil {
+			logger.Warning("Failed to record secret findings", zap.Int("post_id", post.ID), zap.Error(err))
+		}
+	}
+
+	s.dispatcher.DispatchContext(ctx, events.Event{
+		Type: events.PostUpdated,
+		Data: events.PostEvent{
+			PostID:    post.ID,
+			SpaceID:   post.SpaceID,
+			Timestamp: post.Created,
+		},
+	})
+
+	return post, nil
+}
+
+// GetRevisions returns the previous revisions of a post, most recent first
+func (s *PostService) GetRevisions(postID int) ([]models.PostRevision, error) {
+	if s.options == nil || !s.options.Features.EditHistory.Enabled {
+		return nil, fmt.Errorf(config.ErrEditHistoryDisabled)
+	}
+	if _, err := s.db.GetPost(postID); err != nil {
+		return nil, postLookupError(err)
+	}
+	return s.db.GetPostRevisions(postID)
+}
+
+// postLookupError turns the not found error of storage into the one of the API
+func postLookupError(err error) error {
+	if err.Error() == "post not found" {
+		return fmt.Errorf(config.ErrPostNotFound)
+	}
+	return err
+}
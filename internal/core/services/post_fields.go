@@ -0,0 +1,263 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var fieldNameRegex = regexp.MustCompile(config.FieldNamePattern)
+
+// LoadFieldSchemas loads the custom field schemas of the spaces from the database
+func (s *PostService) LoadFieldSchemas() error {
+	schemas, err := s.db.GetSpaceFieldSchemas()
+	if err != nil {
+		return err
+	}
+
+	s.fieldsMu.Lock()
+	s.fieldSchemas = schemas
+	s.fieldsMu.Unlock()
+	return nil
+}
+
+// GetSpaceFieldSchema returns the custom field schema of a space, empty when it has none
+func (s *PostService) GetSpaceFieldSchema(spaceID int) ([]models.FieldDef, error) {
+	if _, ok := s.cache.Get(spaceID); !ok {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	s.fieldsMu.RLock()
+	defer s.fieldsMu.RUnlock()
+	return append([]models.FieldDef{}, s.fieldSchemas[spaceID]...), nil
+}
+
+// SetSpaceFieldSchema replaces the custom field schema of a space; an empty schema removes it.
+// Values already stored on posts are kept even when their field goes away.
+func (s *PostService) SetSpaceFieldSchema(spaceID int, fields []models.FieldDef) error {
+	if _, ok := s.cache.Get(spaceID); !ok {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if err := validateFieldSchema(fields); err != nil {
+		return err
+	}
+
+	if err := s.db.SetSpaceFieldSchema(spaceID, fields); err != nil {
+		return err
+	}
+
+	s.fieldsMu.Lock()
+	if len(fields) == 0 {
+		delete(s.fieldSchemas, spaceID)
+	} else {
+		s.fieldSchemas[spaceID] = fields
+	}
+	s.fieldsMu.Unlock()
+	return nil
+}
+
+// validateFieldSchema checks field names, types and select options
+func validateFieldSchema(fields []models.FieldDef) error {
+	if len(fields) > config.MaxSpaceFields {
+		return fmt.Errorf(config.ErrFmtInvalidFieldSchema, fmt.Sprintf("at most %d fields are allowed", config.MaxSpaceFields))
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if !fieldNameRegex.MatchString(field.Name) {
+			return fmt.Errorf(config.ErrFmtInvalidFieldSchema, fmt.Sprintf("invalid field name %q", field.Name))
+		}
+		if seen[field.Name] {
+			return fmt.Errorf(config.ErrFmtInvalidFieldSchema, fmt.Sprintf("duplicate field %q", field.Name))
+		}
+		seen[field.Name] = true
+
+		switch field.Type {
+		case models.FieldTypeText, models.FieldTypeNumber, models.FieldTypeDate:
+			if len(field.Options) > 0 {
+				return fmt.Errorf(config.ErrFmtInvalidFieldSchema, fmt.Sprintf("field %q cannot have options", field.Name))
+			}
+		case models.FieldTypeSelect:
+			if len(field.Options) == 0 || len(field.Options) > config.MaxFieldSelectOptions {
+				return fmt.Errorf(config.ErrFmtInvalidFieldSchema,
+					fmt.Sprintf("field %q needs between 1 and %d options", field.Name, config.MaxFieldSelectOptions))
+			}
+			options := make(map[string]bool, len(field.Options))
+			for _, option := range field.Options {
+				if strings.TrimSpace(option) == "" || len(option) > config.MaxFieldOptionLength || options[option] {
+					return fmt.Errorf(config.ErrFmtInvalidFieldSchema, fmt.Sprintf("invalid option %q of field %q", option, field.Name))
+				}
+				options[option] = true
+			}
+		default:
+			return fmt.Errorf(config.ErrFmtInvalidFieldSchema, fmt.Sprintf("unknown type %q of field %q", field.Type, field.Name))
+		}
+	}
+
+	return nil
+}
+
+// ValidateFields checks custom field values against the schema of a space and returns them
+// normalized to strings. Empty values are dropped; required fields must have a value.
+func (s *PostService) ValidateFields(spaceID int, values map[string]interface{}) (map[string]string, error) {
+	s.fieldsMu.RLock()
+	schema := s.fieldSchemas[spaceID]
+	s.fieldsMu.RUnlock()
+
+	defs := make(map[string]models.FieldDef, len(schema))
+	for _, field := range schema {
+		defs[field.Name] = field
+	}
+	for name := range values {
+		if _, ok := defs[name]; !ok {
+			return nil, fmt.Errorf(config.ErrFmtUnknownField, name)
+		}
+	}
+
+	normalized := make(map[string]string, len(values))
+	for _, field := range schema {
+		value, err := normalizeFieldValue(field, values[field.Name])
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			if field.Required {
+				return nil, fmt.Errorf(config.ErrFmtInvalidFieldValue, field.Name, "a value is required")
+			}
+			continue
+		}
+		normalized[field.Name] = value
+	}
+
+	return normalized, nil
+}
+
+// normalizeFieldValue converts a decoded JSON value of a field to its stored form
+func normalizeFieldValue(field models.FieldDef, raw interface{}) (string, error) {
+	var value string
+	switch v := raw.(type) {
+	case nil:
+		return "", nil
+	case string:
+		value = strings.TrimSpace(v)
+	case float64:
+		if field.Type != models.FieldTypeNumber {
+			return "", fmt.Errorf(config.ErrFmtInvalidFieldValue, field.Name, "expected a string")
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf(config.ErrFmtInvalidFieldValue, field.Name, "unsupported value")
+	}
+	if value == "" {
+		return "", nil
+	}
+
+	switch field.Type {
+	case models.FieldTypeText:
+		if len(value) > config.MaxFieldTextLength {
+			return "", fmt.Errorf(config.ErrFmtInvalidFieldValue, field.Name,
+				fmt.Sprintf("at most %d characters are allowed", config.MaxFieldTextLength))
+		}
+	case models.FieldTypeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf(config.ErrFmtInvalidFieldValue, field.Name, "expected a number")
+		}
+		value = strconv.FormatFloat(number, 'f', -1, 64)
+	case models.FieldTypeDate:
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "", fmt.Errorf(config.ErrFmtInvalidFieldValue, field.Name, "expected a YYYY-MM-DD date")
+		}
+	case models.FieldTypeSelect:
+		for _, option := range field.Options {
+			if value == option {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf(config.ErrFmtInvalidFieldValue, field.Name, "not one of the options")
+	}
+
+	return value, nil
+}
+
+// CreateWithFields creates a post and stores its custom field values, validated against the
+// schema of the space before anything is written
+func (s *PostService) CreateWithFields(ctx context.Context, spaceID int, content string, customTimestamp *int64, source string, values map[string]interface{}) (*models.Post, error) {
+	if _, ok := s.cache.Get(spaceID); !ok {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	fields, err := s.ValidateFields(spaceID, values)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.CreateWithSource(ctx, spaceID, content, customTimestamp, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		if err := s.db.SetPostFields(post.ID, fields); err != nil {
+			return nil, err
+		}
+		post.Fields = fields
+	}
+
+	return post, nil
+}
+
+// UpdatePostFields replaces the custom field values of a post, validated against the schema
+// of its space
+func (s *PostService) UpdatePostFields(postID int, values map[string]interface{}) (map[string]string, error) {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return nil, fmt.Errorf(config.ErrPostNotFound)
+	}
+	if post.Type == models.PostTypeJournal {
+		return nil, fmt.Errorf(config.ErrFieldsNotAllowedOnJournal)
+	}
+
+	fields, err := s.ValidateFields(post.SpaceID, values)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.SetPostFields(postID, fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// GetPostFields returns the custom field values of a post
+func (s *PostService) GetPostFields(postID int) (map[string]string, error) {
+	values, err := s.db.GetPostFieldsByPosts([]int{postID})
+	if err != nil {
+		return nil, err
+	}
+	return values[postID], nil
+}
+
+// attachFields sets the custom field values of listed posts
+func (s *PostService) attachFields(posts []models.PostWithAttachments) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	values, err := s.db.GetPostFieldsByPosts(ids)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Fields = values[posts[i].ID]
+	}
+
+	return nil
+}
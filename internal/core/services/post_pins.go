@@ -0,0 +1,48 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"context"
+	"fmt"
+)
+
+// SetPinned pins a post at the top of the listing of its space, or unpins it, and returns the
+// post. A space holds a limited number of pinned posts; a pinned post moved to another space
+// stays pinned there, even past that limit.
+func (s *PostService) SetPinned(ctx context.Context, postID int, pinned bool) (*models.Post, error) {
+	if s.options == nil || !s.options.Features.Pinning.Enabled {
+		return nil, fmt.Errorf(config.ErrPinningDisabled)
+	}
+	max := s.options.Features.Pinning.MaxPerSpace
+	if max <= 0 {
+		max = config.DefaultMaxPinnedPosts
+	}
+
+	spaceID, changed, err := s.db.SetPostPinned(postID, pinned, s.now().UnixMilli(), max)
+	if err != nil {
+		if err.Error() == "too many pinned posts" {
+			return nil, fmt.Errorf(config.ErrFmtTooManyPinnedPosts, max)
+		}
+		return nil, postLookupError(err)
+	}
+
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return nil, postLookupError(err)
+	}
+	if changed {
+		s.dispatcher.DispatchContext(ctx, events.Event{
+			Type: events.PostPinned,
+			Data: events.PostEvent{
+				PostID:    post.ID,
+				SpaceID:   spaceID,
+				Timestamp: post.Created,
+				Pinned:    pinned,
+			},
+		})
+	}
+
+	return post, nil
+}
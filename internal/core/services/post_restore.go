@@ -0,0 +1,64 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// RestorePost puts a post back from the trash under its original ID, in the space it was
+// deleted from, pinned if it was. A journal post is the entry of its day again unless the space
+// started a new one.
+func (s *PostService) RestorePost(ctx context.Context, trashed models.TrashedPost, attachments []models.Attachment) (*models.Post, error) {
+	post := trashed.Post
+	if _, ok := s.cache.Get(post.SpaceID); !ok {
+		return nil, fmt.Errorf(config.ErrTrashSpaceGone)
+	}
+
+	journalDay := ""
+	if post.Type == models.PostTypeJournal {
+		day := time.UnixMilli(post.Created).Format("2006-01-02")
+		if _, taken, err := s.db.GetJournalPostID(post.SpaceID, day); err != nil {
+			return nil, err
+		} else if !taken {
+			journalDay = day
+		}
+	}
+
+	if err := s.db.RestorePost(post, journalDay, trashed.Pinned, trashed.LinkPreviews, attachments); err != nil {
+		return nil, err
+	}
+
+	// Update cache
+	s.cache.UpdatePostCount(post.SpaceID, 1)
+
+	// Listeners account for the post and its files as if they were new
+	s.dispatcher.DispatchContext(ctx, events.Event{
+		Type: events.PostCreated,
+		Data: events.PostEvent{
+			PostID:    post.ID,
+			SpaceID:   post.SpaceID,
+			Timestamp: post.Created,
+		},
+	})
+	if len(attachments) > 0 {
+		var totalSize int64
+		for _, attachment := range attachments {
+			totalSize += attachment.FileSize
+		}
+		s.dispatcher.DispatchContext(ctx, events.Event{
+			Type: events.FileUploaded,
+			Data: events.PostEvent{
+				PostID:    post.ID,
+				SpaceID:   post.SpaceID,
+				FileSize:  totalSize,
+				FileCount: len(attachments),
+			},
+		})
+	}
+
+	return s.db.GetPost(post.ID)
+}
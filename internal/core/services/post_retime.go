@@ -0,0 +1,190 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// AuditActionPostRetime is recorded for every bulk timestamp adjustment
+const AuditActionPostRetime = "posts.retime"
+
+// RetimeOptions selects posts by space (with its descendants when Recursive), by ID or both,
+// narrowed by Filter on their current creation time, source and tags. The selection is either
+// shifted by DeltaMs, or spread over RangeStart..RangeEnd keeping the order and relative gaps of
+// the posts. Exactly one of the two must be set.
+type RetimeOptions struct {
+	SpaceID    *int
+	Recursive  bool
+	PostIDs    []int
+	Filter     models.PostFilter
+	DeltaMs    int64
+	RangeStart int64
+	RangeEnd   int64
+}
+
+func (o RetimeOptions) hasRange() bool {
+	return o.RangeStart != 0 || o.RangeEnd != 0
+}
+
+// retimePlan holds the timestamp changes of a retime before they are applied
+type retimePlan struct {
+	spaceIDs []int // Selected spaces, the first one being the requested space
+	changes  []storage.PostTimeChange
+	retimed  []events.PostEvent
+	result   *models.RetimeResult
+	now      int64
+}
+
+// Retime changes the creation time of the selected posts in one transaction, recording an
+// audit entry, and dispatches a PostRetimed event per post so activity buckets follow. Journal
+// posts are keyed on their day and keep their timestamp.
+func (s *PostService) Retime(ctx context.Context, opts RetimeOptions) (*models.RetimeResult, error) {
+	plan, err := s.planRetime(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	result := plan.result
+
+	details := map[string]interface{}{
+		"space_id":  opts.SpaceID,
+		"recursive": opts.Recursive,
+		"filter":    opts.Filter,
+		"post_ids":  result.PostIDs,
+	}
+	if opts.hasRange() {
+		details["range_start"] = opts.RangeStart
+		details["range_end"] = opts.RangeEnd
+	} else {
+		details["delta_ms"] = opts.DeltaMs
+	}
+	data, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.AuditEntry{Action: AuditActionPostRetime, Details: data, Created: plan.now}
+	if err := s.db.RetimePosts(plan.changes, entry); err != nil {
+		return nil, err
+	}
+
+	for _, data := range plan.retimed {
+		s.dispatcher.DispatchContext(ctx, events.Event{Type: events.PostRetimed, Data: data})
+	}
+
+	result.AuditID = entry.ID
+	result.PostsChanged = len(plan.changes)
+	return result, nil
+}
+
+// PreviewRetime reports the posts Retime would move and their spaces without changing anything
+func (s *PostService) PreviewRetime(ctx context.Context, opts RetimeOptions) (*models.DryRunSummary, error) {
+	plan, err := s.planRetime(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.DryRunSummary{
+		Operation:        "retime_posts",
+		DryRun:           true,
+		DescendantSpaces: []int{},
+		PostCount:        len(plan.changes),
+		PostIDs:          plan.result.PostIDs,
+	}
+	if len(plan.spaceIDs) > 1 {
+		summary.DescendantSpaces = plan.spaceIDs[1:]
+	}
+	spaces := make(map[int]bool)
+	for _, data := range plan.retimed {
+		spaces[data.SpaceID] = true
+	}
+	summary.SpaceCount = len(spaces)
+
+	return summary, nil
+}
+
+// planRetime validates opts and computes the new timestamps of the selected posts
+func (s *PostService) planRetime(ctx context.Context, opts RetimeOptions) (*retimePlan, error) {
+	if opts.SpaceID == nil && len(opts.PostIDs) == 0 {
+		return nil, fmt.Errorf(config.ErrRetimeSelectionRequired)
+	}
+	if (opts.DeltaMs != 0) == opts.hasRange() {
+		return nil, fmt.Errorf(config.ErrRetimeModeRequired)
+	}
+
+	now := time.Now().UnixMilli()
+	if opts.hasRange() && (opts.RangeStart < config.MinRetroactivePostTimestamp || opts.RangeEnd > now || opts.RangeEnd < opts.RangeStart) {
+		return nil, fmt.Errorf(config.ErrRetimeInvalidRange)
+	}
+
+	var spaceIDs []int
+	if opts.SpaceID != nil {
+		if _, ok := s.cache.Get(*opts.SpaceID); !ok || !s.access.CanRead(ctx, *opts.SpaceID) {
+			return nil, fmt.Errorf(config.ErrSpaceNotFound)
+		}
+		spaceIDs = []int{*opts.SpaceID}
+		if opts.Recursive {
+			// Descendants the viewer may see but not change are left as they are
+			for _, id := range visibleSpaces(s.cache.GetDescendants(*opts.SpaceID), s.access.Hidden(ctx)) {
+				if s.access.CanWrite(ctx, id) {
+					spaceIDs = append(spaceIDs, id)
+				}
+			}
+		}
+	}
+
+	posts, err := s.db.GetPostsForRetime(spaceIDs, opts.PostIDs, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.RetimeResult{PostIDs: []int{}}
+	plan := &retimePlan{spaceIDs: spaceIDs, result: result, now: now}
+	var selected []models.Post
+	for _, post := range posts {
+		if post.Type == models.PostTypeJournal {
+			result.SkippedJournal++
+			continue
+		}
+		selected = append(selected, post)
+	}
+
+	// Posts are sorted oldest first, so the range keeps their order
+	for _, post := range selected {
+		created := post.Created + opts.DeltaMs
+		if opts.hasRange() {
+			created = spreadTimestamp(post.Created, selected[0].Created, selected[len(selected)-1].Created, opts.RangeStart, opts.RangeEnd)
+		}
+		if created < config.MinRetroactivePostTimestamp {
+			return nil, fmt.Errorf(config.ErrTimestampTooEarly)
+		}
+		if created > now {
+			return nil, fmt.Errorf(config.ErrRetimeInFuture)
+		}
+		if created == post.Created {
+			continue
+		}
+
+		plan.changes = append(plan.changes, storage.PostTimeChange{PostID: post.ID, Created: created})
+		plan.retimed = append(plan.retimed, events.PostEvent{PostID: post.ID, SpaceID: post.SpaceID, Timestamp: created, OldTimestamp: post.Created})
+		result.PostIDs = append(result.PostIDs, post.ID)
+	}
+
+	return plan, nil
+}
+
+// spreadTimestamp maps created from first..last onto start..end linearly. A selection made of
+// a single instant lands on start.
+func spreadTimestamp(created, first, last, start, end int64) int64 {
+	if last == first {
+		return start
+	}
+	ratio := float64(created-first) / float64(last-first)
+	return start + int64(math.Round(ratio*float64(end-start)))
+}
@@ -4,17 +4,77 @@ import (
 	"backthynk/internal/config"
 	"backthynk/internal/core/cache"
 	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
 	"backthynk/internal/core/models"
 	"backthynk/internal/core/utils"
 	"backthynk/internal/storage"
+	"context"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
 )
 
+// ContentAnnotator decorates rendered post content of a space, e.g. with glossary tooltips
+type ContentAnnotator func(spaceID int, content string) string
+
+// TrashKeeper keeps a restorable copy of posts and spaces right before they are deleted.
+// Space IDs are passed parents first. Deletion is aborted when the copy cannot be made.
+type TrashKeeper interface {
+	KeepPost(postID int) error
+	KeepSpaces(spaceIDs []int) error
+}
+
+// SecretScreener inspects ingested content for secrets. Screen returns the content to store
+// and the warnings to report, or an error when the content must be rejected.
+// RecordFindings stores the findings of the original content once it is saved.
+type SecretScreener interface {
+	Screen(spaceID int, source, content string) (string, []string, error)
+	RecordFindings(spaceID, postID int, attachmentID *int, source, content string) error
+}
+
+// LinkIndex keeps track of the links posts reference. IndexLinks records the links of a
+// created post and returns the earlier posts already holding them.
+type LinkIndex interface {
+	IndexLinks(postID int, content string) ([]models.LinkReference, error)
+}
+
+// FilterResolver looks up saved timeline filters referenced by listings
+type FilterResolver interface {
+	GetFilter(id int) (*models.SavedFilter, bool)
+}
+
 type PostService struct {
 	db         *storage.DB
 	cache      *cache.SpaceCache
 	dispatcher *events.Dispatcher
 	options    *config.OptionsConfig
+	annotators []ContentAnnotator
+	trash      TrashKeeper
+	secrets    SecretScreener
+	filters    FilterResolver
+	links      LinkIndex
+	access     *SpaceAccess
+
+	// contentLimits holds the per-space max content length overrides
+	contentLimits map[int]int
+	limitsMu      sync.RWMutex
+
+	// fieldSchemas holds the custom field schemas of the spaces
+	fieldSchemas map[int][]models.FieldDef
+	fieldsMu     sync.RWMutex
+
+	// defaultSorts holds the listing orders of the spaces that do not list newest first
+	defaultSorts map[int]string
+	settingsMu   sync.RWMutex
+
+	// appendMu serializes daily log appends so concurrent snippets never start two posts
+	appendMu sync.Mutex
+	now      func() time.Time
 }
 
 func NewPostService(db *storage.DB, cache *cache.SpaceCache, dispatcher *events.Dispatcher) *PostService {
@@ -23,38 +83,279 @@ func NewPostService(db *storage.DB, cache *cache.SpaceCache, dispatcher *events.
 		cache:      cache,
 		dispatcher: dispatcher,
 		options:    config.GetOptionsConfig(),
+
+		contentLimits: make(map[int]int),
+		fieldSchemas:  make(map[int][]models.FieldDef),
+		defaultSorts:  make(map[int]string),
+		now:           time.Now,
+	}
+}
+
+// LoadContentLimits loads the per-space max content length overrides from the database
+func (s *PostService) LoadContentLimits() error {
+	limits, err := s.db.GetSpaceContentLimits()
+	if err != nil {
+		return err
+	}
+
+	s.limitsMu.Lock()
+	s.contentLimits = limits
+	s.limitsMu.Unlock()
+	return nil
+}
+
+// SetSpaceContentLimit overrides the max content length of a space and its descendants
+// without their own override; nil falls back to the inherited limit
+func (s *PostService) SetSpaceContentLimit(spaceID int, maxLength *int) error {
+	if _, ok := s.cache.Get(spaceID); !ok {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if maxLength != nil && (*maxLength < config.MinContentLength || *maxLength > config.MaxContentLength) {
+		return fmt.Errorf(config.ErrValidationMaxContentLengthRange)
+	}
+
+	if err := s.db.SetSpaceContentLimit(spaceID, maxLength); err != nil {
+		return err
+	}
+
+	s.limitsMu.Lock()
+	if maxLength == nil {
+		delete(s.contentLimits, spaceID)
+	} else {
+		s.contentLimits[spaceID] = *maxLength
+	}
+	s.limitsMu.Unlock()
+	return nil
+}
+
+// MaxContentLength resolves the max content length of a space: its own override, else the
+// closest ancestor override, else the global limit. The space defining the override is
+// returned, or nil when the global limit applies.
+func (s *PostService) MaxContentLength(spaceID int, global int) (int, *int) {
+	s.limitsMu.RLock()
+	defer s.limitsMu.RUnlock()
+
+	current := spaceID
+	for {
+		if maxLength, ok := s.contentLimits[current]; ok {
+			source := current
+			return maxLength, &source
+		}
+
+		space, ok := s.cache.Get(current)
+		if !ok || space.ParentID == nil {
+			return global, nil
+		}
+		current = *space.ParentID
+	}
+}
+
+// ResolveLimits returns the posting limits that apply to a space
+func (s *PostService) ResolveLimits(spaceID int, options *config.OptionsConfig) (*models.EffectiveLimits, error) {
+	if _, ok := s.cache.Get(spaceID); !ok {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	maxLength, source := s.MaxContentLength(spaceID, options.Core.MaxContentLength)
+	return &models.EffectiveLimits{
+		SpaceID:                spaceID,
+		MaxContentLength:       maxLength,
+		GlobalMaxContentLength: options.Core.MaxContentLength,
+		OverrideSpaceID:        source,
+		MaxFileSizeMB:          options.Features.FileUpload.MaxFileSizeMB,
+		MaxFilesPerPost:        options.Features.FileUpload.MaxFilesPerPost,
+	}, nil
+}
+
+// checkContentLength enforces the effective max content length of a space
+func (s *PostService) checkContentLength(spaceID int, content string) error {
+	global := config.MaxContentLength
+	if s.options != nil {
+		global = s.options.Core.MaxContentLength
+	}
+
+	maxLength, _ := s.MaxContentLength(spaceID, global)
+	if len(content) > maxLength {
+		return fmt.Errorf(config.ErrFmtContentExceedsMaxLength, maxLength)
+	}
+	return nil
+}
+
+// AddContentAnnotator registers an annotator applied after markdown rendering.
+// Annotators must be registered before the server starts handling requests.
+func (s *PostService) AddContentAnnotator(annotator ContentAnnotator) {
+	s.annotators = append(s.annotators, annotator)
+}
+
+// SetTrashKeeper makes deleted posts go to the trash instead of being lost
+func (s *PostService) SetTrashKeeper(trash TrashKeeper) {
+	s.trash = trash
+}
+
+// SetSecretScreener makes created posts go through secret detection
+func (s *PostService) SetSecretScreener(screener SecretScreener) {
+	s.secrets = screener
+}
+
+// SetFilterResolver lets listings reference saved filters by ID
+func (s *PostService) SetFilterResolver(resolver FilterResolver) {
+	s.filters = resolver
+}
+
+// SetLinkIndex makes created posts report the earlier posts holding the same links
+func (s *PostService) SetLinkIndex(links LinkIndex) {
+	s.links = links
+}
+
+// ResolveSavedFilter returns a saved filter and the post filter it stands for
+func (s *PostService) ResolveSavedFilter(id int) (*models.SavedFilter, models.PostFilter, error) {
+	if s.filters == nil {
+		return nil, models.PostFilter{}, fmt.Errorf(config.ErrSavedFilterNotFound)
+	}
+
+	saved, ok := s.filters.GetFilter(id)
+	if !ok {
+		return nil, models.PostFilter{}, fmt.Errorf(config.ErrSavedFilterNotFound)
+	}
+
+	after, before, err := DateRangeBounds(saved.StartDate, saved.EndDate)
+	if err != nil {
+		return nil, models.PostFilter{}, err
+	}
+
+	return saved, models.PostFilter{
+		Tags:       saved.Tags,
+		Extensions: saved.Extensions,
+		After:      after,
+		Before:     before,
+	}, nil
+}
+
+// DateRangeBounds converts an inclusive YYYY-MM-DD range in local time to creation time
+// bounds in milliseconds; an empty date leaves that side open
+func DateRangeBounds(startDate, endDate string) (int64, int64, error) {
+	var after, before int64
+
+	if startDate != "" {
+		start, err := time.ParseInLocation("2006-01-02", startDate, time.Local)
+		if err != nil {
+			return 0, 0, fmt.Errorf(config.ErrInvalidDateRange)
+		}
+		after = start.UnixMilli()
+	}
+
+	if endDate != "" {
+		end, err := time.ParseInLocation("2006-01-02", endDate, time.Local)
+		if err != nil {
+			return 0, 0, fmt.Errorf(config.ErrInvalidDateRange)
+		}
+		before = end.AddDate(0, 0, 1).UnixMilli()
+	}
+
+	if after > 0 && before > 0 && after >= before {
+		return 0, 0, fmt.Errorf(config.ErrInvalidDateRange)
+	}
+
+	return after, before, nil
+}
+
+// RenderContent converts post content to HTML and applies the registered annotators
+func (s *PostService) RenderContent(spaceID int, content string) string {
+	rendered := utils.ProcessMarkdown(content)
+	for _, annotate := range s.annotators {
+		rendered = annotate(spaceID, rendered)
+	}
+	return rendered
+}
+
+// postSourceToolPattern restricts the tool name of import sources
+var postSourceToolPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,31}$`)
+
+// IsValidPostSource reports whether source names a known ingestion path
+func IsValidPostSource(source string) bool {
+	switch source {
+	case models.PostSourceManual, models.PostSourceAPI, models.PostSourceEmail, models.PostSourceWebhook,
+		models.PostSourceCLI, models.PostSourceCapture, models.PostSourceShareLink:
+		return true
+	}
+	tool, ok := strings.CutPrefix(source, models.PostSourceImportPrefix)
+	return ok && postSourceToolPattern.MatchString(tool)
+}
+
+func (s *PostService) Create(ctx context.Context, spaceID int, content string, customTimestamp *int64) (*models.Post, error) {
+	return s.CreateWithSource(ctx, spaceID, content, customTimestamp, models.PostSourceManual)
+}
+
+// CreateWithSource creates a post recording the ingestion path it came from
+func (s *PostService) CreateWithSource(ctx context.Context, spaceID int, content string, customTimestamp *int64, source string) (*models.Post, error) {
+	created := time.Now().UnixMilli()
+	if customTimestamp != nil {
+		created = *customTimestamp
 	}
+	return s.createPost(ctx, spaceID, content, created, source, "")
 }
 
-func (s *PostService) Create(spaceID int, content string, customTimestamp *int64) (*models.Post, error) {
+// createPost validates and stores a post; journalDay makes it the journal entry of that day
+func (s *PostService) createPost(ctx context.Context, spaceID int, content string, created int64, source, journalDay string) (*models.Post, error) {
+	if !IsValidPostSource(source) {
+		return nil, fmt.Errorf(config.ErrInvalidPostSource)
+	}
+
 	// Validate space exists using cache
 	if _, ok := s.cache.Get(spaceID); !ok {
 		return nil, fmt.Errorf(config.ErrSpaceNotFound)
 	}
 
+	if err := s.checkContentLength(spaceID, content); err != nil {
+		return nil, err
+	}
+
+	original := content
+	var warnings []string
+	if s.secrets != nil {
+		screened, found, err := s.secrets.Screen(spaceID, config.SecretSourcePost, content)
+		if err != nil {
+			return nil, err
+		}
+		content, warnings = screened, found
+	}
+
 	var post *models.Post
 	var err error
-
-	if customTimestamp != nil {
-		post, err = s.db.CreatePostWithTimestamp(spaceID, content, *customTimestamp)
+	if journalDay != "" {
+		post, err = s.db.CreateJournalPost(ctx, spaceID, content, created, source, journalDay)
 	} else {
-		post, err = s.db.CreatePost(spaceID, content)
+		post, err = s.db.CreatePostWithSource(ctx, spaceID, content, created, source)
 	}
-
 	if err != nil {
 		return nil, err
 	}
 
+	if len(warnings) > 0 {
+		post.Warnings = warnings
+		if err := s.secrets.RecordFindings(spaceID, post.ID, nil, config.SecretSourcePost, original); err != nil {
+			logger.Warning("Failed to record secret findings", zap.Int("post_id", post.ID), zap.Error(err))
+		}
+	}
+
+	if s.links != nil {
+		references, err := s.links.IndexLinks(post.ID, post.Content)
+		if err != nil {
+			logger.Warning("Failed to index post links", zap.Int("post_id", post.ID), zap.Error(err))
+		}
+		post.DuplicateLinks = references
+	}
+
 	// Process content on-the-fly for the response
 	if s.options != nil && s.options.Features.Markdown.Enabled {
-		post.Content = utils.ProcessMarkdown(post.Content)
+		post.Content = s.RenderContent(spaceID, post.Content)
 	}
 
 	// Update cache
 	s.cache.UpdatePostCount(spaceID, 1)
 	
 	// Dispatch event
-	s.dispatcher.Dispatch(events.Event{
+	s.dispatcher.DispatchContext(ctx, events.Event{
 		Type: events.PostCreated,
 		Data: events.PostEvent{
 			PostID:     post.ID,
@@ -66,7 +367,169 @@ func (s *PostService) Create(spaceID int, content string, customTimestamp *int64
 	return post, nil
 }
 
-func (s *PostService) Delete(id int) error {
+// AppendToDailyLog appends a snippet on a new line of today's daily log post of a space,
+// starting the post when the day has none yet. created reports whether a post was started.
+func (s *PostService) AppendToDailyLog(ctx context.Context, spaceID int, snippet string) (post *models.Post, created bool, err error) {
+	if _, ok := s.cache.Get(spaceID); !ok {
+		return nil, false, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	s.appendMu.Lock()
+	defer s.appendMu.Unlock()
+
+	day := s.now().Format("2006-01-02")
+	postID, found, err := s.db.GetDailyLogPostID(spaceID, day)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var existing *models.Post
+	if found {
+		existing, err = s.db.GetPost(postID)
+		// A daily log moved to another space no longer belongs to this one
+		if err != nil || existing.SpaceID != spaceID {
+			existing = nil
+		}
+	}
+
+	if existing == nil {
+		post, err := s.CreateWithSource(ctx, spaceID, snippet, nil, models.PostSourceCapture)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := s.db.SetDailyLogPost(spaceID, day, post.ID); err != nil {
+			return nil, false, err
+		}
+		return post, true, nil
+	}
+
+	if err := s.appendToPost(ctx, existing, snippet); err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+// appendToPost adds a snippet on a new line of an existing post
+func (s *PostService) appendToPost(ctx context.Context, existing *models.Post, snippet string) error {
+	content := strings.TrimRight(existing.Content, "\n") + "\n" + snippet
+	if err := s.checkContentLength(existing.SpaceID, content); err != nil {
+		return err
+	}
+	if err := s.db.UpdatePostContent(existing.ID, content); err != nil {
+		return err
+	}
+	existing.Content = content
+
+	s.dispatcher.DispatchContext(ctx, events.Event{
+		Type: events.PostUpdated,
+		Data: events.PostEvent{
+			PostID:    existing.ID,
+			SpaceID:   existing.SpaceID,
+			Timestamp: existing.Created,
+		},
+	})
+
+	if s.options != nil && s.options.Features.Markdown.Enabled {
+		existing.Content = s.RenderContent(existing.SpaceID, existing.Content)
+	}
+
+	return nil
+}
+
+// CreateJournal adds content to the journal post of a space for the day of the timestamp
+// (today when nil). The content is appended when the day already has a journal post;
+// created reports whether a post was started.
+func (s *PostService) CreateJournal(ctx context.Context, spaceID int, content string, customTimestamp *int64, source string) (post *models.Post, created bool, err error) {
+	timestamp := s.now()
+	if customTimestamp != nil {
+		timestamp = time.UnixMilli(*customTimestamp)
+	}
+
+	s.appendMu.Lock()
+	defer s.appendMu.Unlock()
+
+	day := timestamp.Format("2006-01-02")
+	existing, err := s.journalPost(spaceID, day)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if existing == nil {
+		post, err := s.createPost(ctx, spaceID, content, timestamp.UnixMilli(), source, day)
+		if err != nil {
+			return nil, false, err
+		}
+		return post, true, nil
+	}
+
+	if err := s.appendToPost(ctx, existing, content); err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+// GetOrCreateJournal returns the journal post of a space for a day (YYYY-MM-DD), starting it
+// with a heading when the day has none. Days in the future are rejected, and past days are
+// only started when allowPast is set.
+func (s *PostService) GetOrCreateJournal(ctx context.Context, spaceID int, day string, allowPast bool) (post *models.Post, created bool, err error) {
+	date, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return nil, false, fmt.Errorf(config.ErrInvalidJournalDate)
+	}
+
+	now := s.now()
+	if day > now.Format("2006-01-02") {
+		return nil, false, fmt.Errorf(config.ErrJournalDateInFuture)
+	}
+	if date.UnixMilli() < config.MinRetroactivePostTimestamp {
+		return nil, false, fmt.Errorf(config.ErrTimestampTooEarly)
+	}
+
+	s.appendMu.Lock()
+	defer s.appendMu.Unlock()
+
+	existing, err := s.journalPost(spaceID, day)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		if s.options != nil && s.options.Features.Markdown.Enabled {
+			existing.Content = s.RenderContent(spaceID, existing.Content)
+		}
+		return existing, false, nil
+	}
+
+	// Today's entry is dated now, past entries at the start of their day
+	timestamp := date.UnixMilli()
+	if day == now.Format("2006-01-02") {
+		timestamp = now.UnixMilli()
+	} else if !allowPast {
+		return nil, false, fmt.Errorf(config.ErrRetroactivePostingDisabled)
+	}
+
+	post, err = s.createPost(ctx, spaceID, fmt.Sprintf(config.JournalHeadingFormat, day), timestamp, models.PostSourceManual, day)
+	if err != nil {
+		return nil, false, err
+	}
+	return post, true, nil
+}
+
+// journalPost returns the journal post of a space for a day, or nil when there is none.
+// Caller must hold s.appendMu.
+func (s *PostService) journalPost(spaceID int, day string) (*models.Post, error) {
+	if _, ok := s.cache.Get(spaceID); !ok {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	postID, found, err := s.db.GetJournalPostID(spaceID, day)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return s.db.GetPost(postID)
+}
+
+func (s *PostService) Delete(ctx context.Context, id int) error {
 	post, err := s.db.GetPost(id)
 	if err != nil {
 		return err
@@ -75,8 +538,15 @@ func (s *PostService) Delete(id int) error {
 	// Get attachments before deletion
 	attachments, _ := s.db.GetAttachmentsByPost(id)
 	
+	// Keep a copy in the trash; attachment files are moved there before the delete removes them
+	if s.trash != nil {
+		if err := s.trash.KeepPost(id); err != nil {
+			return err
+		}
+	}
+	
 	// Delete post
-	if err := s.db.DeletePost(id); err != nil {
+	if err := s.db.DeletePost(ctx, id); err != nil {
 		return err
 	}
 	
@@ -90,7 +560,7 @@ func (s *PostService) Delete(id int) error {
 	}
 	
 	// Dispatch event
-	s.dispatcher.Dispatch(events.Event{
+	s.dispatcher.DispatchContext(ctx, events.Event{
 		Type: events.PostDeleted,
 		Data: events.PostEvent{
 			PostID:     id,
@@ -105,7 +575,7 @@ func (s *PostService) Delete(id int) error {
 }
 
 
-func (s *PostService) Move(postID int, newSpaceID int) error {
+func (s *PostService) Move(ctx context.Context, postID int, newSpaceID int) error {
 	// Validate new space exists using cache
 	if _, ok := s.cache.Get(newSpaceID); !ok {
 		return fmt.Errorf(config.ErrSpaceNotFound)
@@ -118,8 +588,21 @@ func (s *PostService) Move(postID int, newSpaceID int) error {
 
 	oldSpaceID := post.SpaceID
 
+	// A journal post cannot join a space that already has an entry for its day
+	if post.Type == models.PostTypeJournal && newSpaceID != oldSpaceID {
+		day, _, err := s.db.GetJournalDay(postID)
+		if err != nil {
+			return err
+		}
+		if _, taken, err := s.db.GetJournalPostID(newSpaceID, day); err != nil {
+			return err
+		} else if taken {
+			return fmt.Errorf(config.ErrJournalDayTaken)
+		}
+	}
+
 	// Update in database
-	if err := s.db.UpdatePostSpace(postID, newSpaceID); err != nil {
+	if err := s.db.UpdatePostSpace(ctx, postID, newSpaceID); err != nil {
 		return err
 	}
 	
@@ -135,7 +618,7 @@ func (s *PostService) Move(postID int, newSpaceID int) error {
 	}
 	
 	// Dispatch event
-	s.dispatcher.Dispatch(events.Event{
+	s.dispatcher.DispatchContext(ctx, events.Event{
 		Type: events.PostMoved,
 		Data: events.PostEvent{
 			PostID:        postID,
@@ -150,42 +633,441 @@ func (s *PostService) Move(postID int, newSpaceID int) error {
 	return nil
 }
 
-func (s *PostService) GetBySpace(spaceID int, recursive bool, limit, offset int) ([]models.PostWithAttachments, error) {
+// mergePlan holds the validated inputs of a merge
+type mergePlan struct {
+	survivor    *models.Post
+	spaceID     int
+	content     string
+	mergedIDs   []int
+	mergedPosts []events.MergedPost
+}
+
+// planMerge validates the posts to merge and computes the merged content
+func (s *PostService) planMerge(postIDs []int) (*mergePlan, error) {
+	seen := make(map[int]bool)
+	var posts []*models.Post
+	for _, id := range postIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		post, err := s.db.GetPost(id)
+		if err != nil {
+			if err.Error() == "post not found" {
+				return nil, config.ErrUnknownPost
+			}
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	if len(posts) < 2 {
+		return nil, fmt.Errorf(config.ErrMergeRequiresTwoPosts)
+	}
+
+	spaceID := posts[0].SpaceID
+	for _, post := range posts[1:] {
+		if post.SpaceID != spaceID {
+			return nil, fmt.Errorf(config.ErrMergeDifferentSpaces)
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		if posts[i].Created != posts[j].Created {
+			return posts[i].Created < posts[j].Created
+		}
+		return posts[i].ID < posts[j].ID
+	})
+
+	sections := make([]string, len(posts))
+	for i, post := range posts {
+		sections[i] = formatMergeSeparator(post.Created) + "\n" + post.Content
+	}
+	content := strings.Join(sections, "\n\n")
+
+	if err := s.checkContentLength(spaceID, content); err != nil {
+		return nil, err
+	}
+
+	plan := &mergePlan{
+		survivor:    posts[0],
+		spaceID:     spaceID,
+		content:     content,
+		mergedIDs:   make([]int, 0, len(posts)-1),
+		mergedPosts: make([]events.MergedPost, 0, len(posts)-1),
+	}
+	for _, post := range posts[1:] {
+		attachments, err := s.db.GetAttachmentsByPost(post.ID)
+		if err != nil {
+			return nil, err
+		}
+		var totalSize int64
+		for _, att := range attachments {
+			totalSize += att.FileSize
+		}
+
+		plan.mergedIDs = append(plan.mergedIDs, post.ID)
+		plan.mergedPosts = append(plan.mergedPosts, events.MergedPost{
+			PostID:    post.ID,
+			Timestamp: post.Created,
+			FileSize:  totalSize,
+			FileCount: len(attachments),
+		})
+	}
+
+	return plan, nil
+}
+
+// Merge folds several posts of the same space into the oldest one. Contents are concatenated
+// in chronological order with timestamp separators and attachments move onto the surviving post.
+func (s *PostService) Merge(ctx context.Context, postIDs []int) (*models.Post, error) {
+	plan, err := s.planMerge(postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.MergePosts(plan.survivor.ID, plan.mergedIDs, plan.content); err != nil {
+		return nil, err
+	}
+
+	// Update cache
+	s.cache.UpdatePostCount(plan.spaceID, -len(plan.mergedIDs))
+
+	// Dispatch event
+	s.dispatcher.DispatchContext(ctx, events.Event{
+		Type: events.PostMerged,
+		Data: events.PostEvent{
+			PostID:      plan.survivor.ID,
+			SpaceID:     plan.spaceID,
+			Timestamp:   plan.survivor.Created,
+			MergedPosts: plan.mergedPosts,
+		},
+	})
+
+	merged, err := s.db.GetPost(plan.survivor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.options != nil && s.options.Features.Markdown.Enabled {
+		merged.Content = s.RenderContent(plan.spaceID, merged.Content)
+	}
+
+	return merged, nil
+}
+
+// PreviewMerge reports what Merge would do without changing anything.
+// Attachments counted in the summary are moved onto the surviving post, not deleted.
+func (s *PostService) PreviewMerge(postIDs []int) (*models.DryRunSummary, error) {
+	plan, err := s.planMerge(postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.DryRunSummary{
+		Operation:        "merge_posts",
+		DryRun:           true,
+		DescendantSpaces: []int{},
+		PostCount:        len(plan.mergedIDs),
+		PostIDs:          plan.mergedIDs,
+		SurvivorPostID:   plan.survivor.ID,
+	}
+	for _, merged := range plan.mergedPosts {
+		summary.AttachmentCount += int64(merged.FileCount)
+		summary.AttachmentBytes += merged.FileSize
+	}
+
+	return summary, nil
+}
+
+// formatMergeSeparator renders the header placed above each section of a merged post
+func formatMergeSeparator(timestampMillis int64) string {
+	return "[" + time.UnixMilli(timestampMillis).UTC().Format("2006-01-02 15:04 UTC") + "]"
+}
+
+// SplitOptions describes where a post should be cut and which part receives each attachment.
+// Exactly one of Delimiter or LineOffsets must be set. LineOffsets are 0-based line numbers
+// at which a new part starts. Unmapped attachments stay on the first part.
+type SplitOptions struct {
+	Delimiter   string
+	LineOffsets []int
+	Attachments map[int]int
+}
+
+// Split cuts a post into several posts in the same space. The original post keeps the first
+// part; following parts are created one millisecond apart so they keep their order.
+func (s *PostService) Split(ctx context.Context, postID int, opts SplitOptions) ([]*models.Post, error) {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := splitContent(post.Content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.db.GetAttachmentsByPost(postID)
+	if err != nil {
+		return nil, err
+	}
+	attachmentSizes := make(map[int]int64, len(attachments))
+	for _, att := range attachments {
+		attachmentSizes[att.ID] = att.FileSize
+	}
+	for attachmentID, partIndex := range opts.Attachments {
+		if _, ok := attachmentSizes[attachmentID]; !ok || partIndex < 0 || partIndex >= len(parts) {
+			return nil, fmt.Errorf(config.ErrSplitInvalidAttachment)
+		}
+	}
+
+	timestamps := make([]int64, len(parts))
+	for i := range parts {
+		timestamps[i] = post.Created + int64(i)
+	}
+
+	ids, err := s.db.SplitPost(postID, post.SpaceID, parts, timestamps, opts.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update cache
+	s.cache.UpdatePostCount(post.SpaceID, len(ids)-1)
+
+	splitPosts := make([]events.SplitPost, 0, len(ids)-1)
+	for i := 1; i < len(ids); i++ {
+		split := events.SplitPost{PostID: ids[i], Timestamp: timestamps[i]}
+		for attachmentID, partIndex := range opts.Attachments {
+			if partIndex == i {
+				split.FileSize += attachmentSizes[attachmentID]
+				split.FileCount++
+			}
+		}
+		splitPosts = append(splitPosts, split)
+	}
+
+	// Dispatch event
+	s.dispatcher.DispatchContext(ctx, events.Event{
+		Type: events.PostSplit,
+		Data: events.PostEvent{
+			PostID:     postID,
+			SpaceID:    post.SpaceID,
+			Timestamp:  post.Created,
+			SplitPosts: splitPosts,
+		},
+	})
+
+	posts := make([]*models.Post, 0, len(ids))
+	for _, id := range ids {
+		p, err := s.db.GetPost(id)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+
+	return posts, nil
+}
+
+// splitContent cuts content at a delimiter or at line offsets, dropping empty parts
+func splitContent(content string, opts SplitOptions) ([]string, error) {
+	hasDelimiter := opts.Delimiter != ""
+	hasOffsets := len(opts.LineOffsets) > 0
+	if hasDelimiter == hasOffsets {
+		return nil, fmt.Errorf(config.ErrSplitMarkersRequired)
+	}
+
+	var chunks []string
+	if hasDelimiter {
+		chunks = strings.Split(content, opts.Delimiter)
+	} else {
+		lines := strings.Split(content, "\n")
+		start := 0
+		for _, offset := range opts.LineOffsets {
+			if offset <= start || offset >= len(lines) {
+				return nil, fmt.Errorf(config.ErrSplitInvalidLineOffsets)
+			}
+			chunks = append(chunks, strings.Join(lines[start:offset], "\n"))
+			start = offset
+		}
+		chunks = append(chunks, strings.Join(lines[start:], "\n"))
+	}
+
+	var parts []string
+	for _, chunk := range chunks {
+		if trimmed := strings.TrimSpace(chunk); trimmed != "" {
+			parts = append(parts, trimmed)
+		}
+	}
+
+	if len(parts) < 2 {
+		return nil, fmt.Errorf(config.ErrSplitRequiresTwoParts)
+	}
+
+	return parts, nil
+}
+
+// GetBySpace lists the posts of a space matching filter
+func (s *PostService) GetBySpace(ctx context.Context, spaceID int, recursive bool, limit, offset int, filter models.PostFilter) ([]models.PostWithAttachments, error) {
+	if !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	hidden := s.access.Hidden(ctx)
+	filter.ExcludeSpaces = hiddenSpaces(hidden)
+
 	var descendants []int
 	if recursive {
-		descendants = s.cache.GetDescendants(spaceID)
+		descendants = visibleSpaces(s.cache.GetDescendants(spaceID), hidden)
+		if err := ChargeSpaces(ctx, s.cache, append([]int{spaceID}, descendants...)); err != nil {
+			return nil, err
+		}
 	}
-	posts, err := s.db.GetPostsBySpaceRecursive(spaceID, recursive, limit, offset, descendants)
+	posts, err := s.db.GetPostsBySpaceRecursive(ctx, spaceID, recursive, limit, offset, descendants, filter)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.attachFields(posts); err != nil {
+		return nil, err
+	}
+	if err := s.attachAuthors(posts); err != nil {
+		return nil, err
+	}
+
 	// Process content on-the-fly for each post
 	if s.options != nil && s.options.Features.Markdown.Enabled {
 		for i := range posts {
-			posts[i].Content = utils.ProcessMarkdown(posts[i].Content)
+			posts[i].Content = s.RenderContent(posts[i].SpaceID, posts[i].Content)
 		}
 	}
 
 	return posts, nil
 }
 
-func (s *PostService) GetAllPosts(limit, offset int) ([]models.PostWithAttachments, error) {
-	posts, err := s.db.GetAllPosts(limit, offset)
+// GetAllPosts lists the posts of every space matching filter
+func (s *PostService) GetAllPosts(ctx context.Context, limit, offset int, filter models.PostFilter) ([]models.PostWithAttachments, error) {
+	filter.ExcludeSpaces = hiddenSpaces(s.access.Hidden(ctx))
+	posts, err := s.db.GetAllPosts(ctx, limit, offset, filter)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.attachFields(posts); err != nil {
+		return nil, err
+	}
+	if err := s.attachAuthors(posts); err != nil {
+		return nil, err
+	}
+
 	// Process content on-the-fly for each post
 	if s.options != nil && s.options.Features.Markdown.Enabled {
 		for i := range posts {
-			posts[i].Content = utils.ProcessMarkdown(posts[i].Content)
+			posts[i].Content = s.RenderContent(posts[i].SpaceID, posts[i].Content)
 		}
 	}
 
 	return posts, nil
 }
 
+// StreamBySpace hands every post of a space, and of its descendants when recursive, to fn
+// without loading them all in memory
+func (s *PostService) StreamBySpace(ctx context.Context, spaceID int, recursive bool, fn func(post models.PostWithAttachments) error) error {
+	if _, ok := s.cache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	spaceIDs := []int{spaceID}
+	if recursive {
+		spaceIDs = append(spaceIDs, visibleSpaces(s.cache.GetDescendants(spaceID), s.access.Hidden(ctx))...)
+		if err := ChargeSpaces(ctx, s.cache, spaceIDs); err != nil {
+			return err
+		}
+	}
+
+	render := s.options != nil && s.options.Features.Markdown.Enabled
+	return s.db.StreamPosts(ctx, spaceIDs, func(post models.PostWithAttachments) error {
+		if render {
+			post.Content = s.RenderContent(post.SpaceID, post.Content)
+		}
+		return fn(post)
+	})
+}
+
+// CountFiltered counts the posts of a space (every space when spaceID is 0) matching filter
+func (s *PostService) CountFiltered(ctx context.Context, spaceID int, recursive bool, filter models.PostFilter) (int, error) {
+	hidden := s.access.Hidden(ctx)
+	filter.ExcludeSpaces = hiddenSpaces(hidden)
+
+	var spaceIDs []int
+	if spaceID != 0 {
+		spaceIDs = []int{spaceID}
+		if recursive {
+			spaceIDs = append(spaceIDs, visibleSpaces(s.cache.GetDescendants(spaceID), hidden)...)
+		}
+	}
+	return s.db.CountPosts(ctx, spaceIDs, filter)
+}
+
+// mediaTypePrefixes maps gallery media types to the attachment MIME prefixes they include
+var mediaTypePrefixes = map[string][]string{
+	"image": {"image/"},
+	"video": {"video/"},
+	"all":   {"image/", "video/"},
+}
+
+// IsValidMediaType reports whether mediaType can be used to filter a media gallery
+func IsValidMediaType(mediaType string) bool {
+	_, ok := mediaTypePrefixes[mediaType]
+	return ok
+}
+
+// GetSpaceMedia lists image and video attachments of a space (every space when spaceID is 0),
+// newest upload first, along with the total number of matching attachments
+func (s *PostService) GetSpaceMedia(ctx context.Context, spaceID int, mediaType string, recursive bool, limit, offset int) ([]models.MediaItem, int, error) {
+	prefixes, ok := mediaTypePrefixes[mediaType]
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid media type %q", mediaType)
+	}
+
+	// Every space but the hidden ones is listed explicitly when some are hidden
+	hidden := s.access.Hidden(ctx)
+	var spaceIDs []int
+	if spaceID != 0 {
+		spaceIDs = []int{spaceID}
+		if recursive {
+			spaceIDs = append(spaceIDs, visibleSpaces(s.cache.GetDescendants(spaceID), hidden)...)
+		}
+	} else if hidden != nil {
+		for _, space := range s.cache.GetAll() {
+			if !hidden[space.ID] {
+				spaceIDs = append(spaceIDs, space.ID)
+			}
+		}
+		if spaceIDs == nil {
+			spaceIDs = []int{}
+		}
+	}
+
+	items, total, err := s.db.GetMediaAttachments(ctx, spaceIDs, prefixes, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range items {
+		items[i].URL = "/uploads/" + items[i].FilePath
+		if strings.HasPrefix(items[i].FileType, "video/") {
+			items[i].MediaType = "video"
+		} else {
+			// Images are small enough to be scaled by the browser, so they act as their own thumbnail
+			items[i].MediaType = "image"
+			items[i].ThumbnailURL = items[i].URL
+		}
+	}
+
+	return items, total, nil
+}
+
 func (s *PostService) GetSpaceFromCache(spaceID int) (*models.Space, bool) {
 	return s.cache.Get(spaceID)
 }
\ No newline at end of file
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/utils"
+	"context"
+	"fmt"
+)
+
+// AddTag appends a hashtag to the content of a post; added is false when the post already has it
+func (s *PostService) AddTag(ctx context.Context, postID int, tag string) (added bool, err error) {
+	tag, ok := utils.NormalizeTag(tag)
+	if !ok {
+		return false, fmt.Errorf(config.ErrInvalidTag)
+	}
+
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return false, err
+	}
+
+	content, changed := utils.AddTag(post.Content, tag)
+	if !changed {
+		return false, nil
+	}
+	if err := s.checkContentLength(post.SpaceID, content); err != nil {
+		return false, err
+	}
+	if err := s.db.UpdatePostContent(postID, content); err != nil {
+		return false, err
+	}
+
+	s.dispatcher.DispatchContext(ctx, events.Event{
+		Type: events.PostUpdated,
+		Data: events.PostEvent{
+			PostID:    postID,
+			SpaceID:   post.SpaceID,
+			Timestamp: post.Created,
+		},
+	})
+
+	return true, nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/utils"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// RemoteFile is a file downloaded from a URL, ready to be stored as an attachment
+type RemoteFile struct {
+	Filename    string
+	ContentType string // Sniffed from the content
+	Data        []byte
+}
+
+// SetRemoteClient replaces the client remote files are fetched with. The default client only
+// connects to public addresses.
+func (s *FileService) SetRemoteClient(client *http.Client) {
+	s.remote = client
+}
+
+// FetchRemoteFile downloads the file at rawURL, refusing files larger than maxBytes and web
+// pages served in place of a file. The filename comes from the response or the URL path; an
+// extension matching the sniffed content type is added when it has none.
+func (s *FileService) FetchRemoteFile(ctx context.Context, rawURL string, maxBytes int64, maxSizeMB int) (*RemoteFile, error) {
+	parsed, err := utils.ValidateRemoteURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", parsed.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf(config.ErrRemoteURLInvalid)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; Backthynk/1.0)")
+
+	resp, err := s.remote.Do(req)
+	if err != nil {
+		if strings.Contains(err.Error(), config.ErrRemoteURLForbidden) {
+			logger.Warning("Refused remote file on a non-public address", zap.String("url", rawURL))
+			return nil, fmt.Errorf(config.ErrRemoteURLForbidden)
+		}
+		logger.Warning("Failed to fetch remote file", zap.String("url", rawURL), zap.Error(err))
+		return nil, fmt.Errorf(config.ErrFmtRemoteFetchFailed, "request failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf(config.ErrFmtRemoteFetchFailed, resp.Status)
+	}
+	if resp.ContentLength > maxBytes {
+		return nil, fmt.Errorf(config.ErrFmtFileSizeExceedsMax, maxSizeMB)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		logger.Warning("Failed to read remote file", zap.String("url", rawURL), zap.Error(err))
+		return nil, fmt.Errorf(config.ErrFmtRemoteFetchFailed, "download interrupted")
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf(config.ErrFmtFileSizeExceedsMax, maxSizeMB)
+	}
+
+	sniffed := http.DetectContentType(data[:min(len(data), config.ContentSniffBytes)])
+	filename := remoteFilename(resp, req.URL)
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	// Login walls and error pages often answer with HTML instead of the expected file
+	if strings.HasPrefix(sniffed, "text/html") && ext != ".html" && ext != ".htm" {
+		return nil, fmt.Errorf(config.ErrRemoteFileIsHTML)
+	}
+
+	if ext == "" {
+		contentType := sniffed
+		if strings.HasPrefix(contentType, "application/octet-stream") || strings.HasPrefix(contentType, "text/plain") {
+			if declared := resp.Header.Get("Content-Type"); declared != "" {
+				contentType = declared
+			}
+		}
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			filename += extensionForType(mediaType)
+		}
+	}
+
+	return &RemoteFile{
+		Filename:    filename,
+		ContentType: sniffed,
+		Data:        data,
+	}, nil
+}
+
+// preferredExtensions picks the usual extension of types mime knows several extensions for
+var preferredExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"text/plain":      ".txt",
+	"video/mp4":       ".mp4",
+	"video/quicktime": ".mov",
+}
+
+// extensionForType returns the extension of a media type, empty when it is unknown
+func extensionForType(mediaType string) string {
+	if ext, ok := preferredExtensions[mediaType]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// remoteFilename picks the filename of a downloaded file: the Content-Disposition filename,
+// else the last segment of the final URL path
+func remoteFilename(resp *http.Response, requested *url.URL) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := filepath.Base(params["filename"]); name != "." && name != "/" && name != "" {
+			return name
+		}
+	}
+
+	final := requested
+	if resp.Request != nil && resp.Request.URL != nil {
+		final = resp.Request.URL
+	}
+	if name := path.Base(final.Path); name != "." && name != "/" {
+		return name
+	}
+	return config.DefaultRemoteFilename
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// RequestCost accounts for the spaces traversed and the posts scanned by the recursive
+// operations of a request, and refuses those going over its caps. Refused work is still
+// counted, so the totals tell how far over the caps a request went. A nil RequestCost, as in
+// background jobs, counts and refuses nothing.
+type RequestCost struct {
+	maxSpaces int // 0 for no cap
+	maxPosts  int
+	spaces    int
+	posts     int
+	exceeded  bool
+	mu        sync.Mutex
+}
+
+func NewRequestCost(maxSpaces, maxPosts int) *RequestCost {
+	return &RequestCost{maxSpaces: maxSpaces, maxPosts: maxPosts}
+}
+
+type requestCostContextKey struct{}
+
+// WithRequestCost returns a context whose recursive operations are charged to cost
+func WithRequestCost(ctx context.Context, cost *RequestCost) context.Context {
+	return context.WithValue(ctx, requestCostContextKey{}, cost)
+}
+
+// RequestCostFromContext returns the cost of the request of a context, nil when it has none
+func RequestCostFromContext(ctx context.Context) *RequestCost {
+	cost, _ := ctx.Value(requestCostContextKey{}).(*RequestCost)
+	return cost
+}
+
+// Charge counts work about to be done, refusing it when the totals go over a cap
+func (c *RequestCost) Charge(spaces, posts int) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.spaces += spaces
+	c.posts += posts
+	if (c.maxSpaces > 0 && c.spaces > c.maxSpaces) || (c.maxPosts > 0 && c.posts > c.maxPosts) {
+		c.exceeded = true
+		return fmt.Errorf(config.ErrRequestCostExceeded)
+	}
+	return nil
+}
+
+// Totals returns the spaces traversed and posts scanned so far, and whether a cap was hit
+func (c *RequestCost) Totals() (spaces, posts int, exceeded bool) {
+	if c == nil {
+		return 0, 0, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.spaces, c.posts, c.exceeded
+}
+
+// ChargeSpaces charges the request of ctx for going through spaceIDs, counting the posts they
+// hold from the cache
+func ChargeSpaces(ctx context.Context, spaceCache *cache.SpaceCache, spaceIDs []int) error {
+	cost := RequestCostFromContext(ctx)
+	if cost == nil {
+		return nil
+	}
+
+	posts := 0
+	for _, id := range spaceIDs {
+		if space, ok := spaceCache.Get(id); ok {
+			posts += space.PostCount
+		}
+	}
+	return cost.Charge(len(spaceIDs), posts)
+}
@@ -0,0 +1,412 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Viewer is the user a request reads and writes spaces for
+type Viewer struct {
+	UserID int
+	Admin  bool // Admins reach every space, private or not
+}
+
+type viewerContextKey struct{}
+
+// WithViewer returns a context reaching spaces as viewer. Without a viewer, as in single-user
+// instances and background jobs, every space is reachable.
+func WithViewer(ctx context.Context, viewer Viewer) context.Context {
+	return context.WithValue(ctx, viewerContextKey{}, viewer)
+}
+
+// ViewerFromContext returns the viewer of a context, false when it has none
+func ViewerFromContext(ctx context.Context) (Viewer, bool) {
+	viewer, ok := ctx.Value(viewerContextKey{}).(Viewer)
+	return viewer, ok
+}
+
+// access levels of a viewer on a space
+const (
+	accessNone = iota
+	accessRead
+	accessWrite
+)
+
+// SpaceAccess holds the access lists of private spaces. A space with an access list is only
+// reached by the users listed in it, and so are its descendants: a viewer needs an entry in
+// the list of the space and of every private ancestor, and write entries to change content.
+// A nil SpaceAccess lets everyone reach every space.
+type SpaceAccess struct {
+	db    *storage.DB
+	cache *cache.SpaceCache
+	acls  map[int][]models.SpaceACLEntry
+	mu    sync.RWMutex
+}
+
+func NewSpaceAccess(db *storage.DB, cache *cache.SpaceCache) *SpaceAccess {
+	return &SpaceAccess{
+		db:    db,
+		cache: cache,
+		acls:  make(map[int][]models.SpaceACLEntry),
+	}
+}
+
+// Load reads the access lists from the database
+func (a *SpaceAccess) Load() error {
+	acls, err := a.db.GetSpaceACLs()
+	if err != nil {
+		return fmt.Errorf("failed to load space access lists: %w", err)
+	}
+
+	a.mu.Lock()
+	a.acls = acls
+	a.mu.Unlock()
+	return nil
+}
+
+// GetACL returns the access list of a space, empty for public spaces
+func (a *SpaceAccess) GetACL(spaceID int) []models.SpaceACLEntry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	entries := make([]models.SpaceACLEntry, len(a.acls[spaceID]))
+	copy(entries, a.acls[spaceID])
+	return entries
+}
+
+// SetACL replaces the access list of a space; an empty list makes it public again
+func (a *SpaceAccess) SetACL(spaceID int, entries []models.SpaceACLEntry) error {
+	if _, ok := a.cache.Get(spaceID); !ok {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	seen := make(map[int]bool, len(entries))
+	for _, entry := range entries {
+		if entry.Permission != models.SpacePermissionRead && entry.Permission != models.SpacePermissionWrite {
+			return fmt.Errorf(config.ErrInvalidSpacePermission)
+		}
+		if seen[entry.UserID] {
+			return fmt.Errorf(config.ErrDuplicateSpaceACLUser)
+		}
+		seen[entry.UserID] = true
+	}
+
+	if err := a.db.SetSpaceACL(spaceID, entries); err != nil {
+		if err.Error() == "user not found" {
+			return fmt.Errorf(config.ErrACLUserNotFound)
+		}
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(entries) == 0 {
+		delete(a.acls, spaceID)
+	} else {
+		a.acls[spaceID] = append([]models.SpaceACLEntry(nil), entries...)
+	}
+	return nil
+}
+
+// Forget drops the access lists of deleted spaces, whose rows the database already removed
+func (a *SpaceAccess) Forget(spaceIDs ...int) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, id := range spaceIDs {
+		delete(a.acls, id)
+	}
+}
+
+// Restore takes back the access lists of spaces restored from the trash, whose rows the
+// database already put back. Entries of users deleted since match no viewer.
+func (a *SpaceAccess) Restore(acls map[int][]models.SpaceACLEntry) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id, entries := range acls {
+		a.acls[id] = append([]models.SpaceACLEntry(nil), entries...)
+	}
+}
+
+// CanRead reports whether the viewer of ctx may see a space and its posts
+func (a *SpaceAccess) CanRead(ctx context.Context, spaceID int) bool {
+	return a.level(ctx, spaceID) >= accessRead
+}
+
+// CanWrite reports whether the viewer of ctx may change a space and its posts. The role of the
+// viewer is checked separately; a write entry does not let a viewer role edit.
+func (a *SpaceAccess) CanWrite(ctx context.Context, spaceID int) bool {
+	return a.level(ctx, spaceID) >= accessWrite
+}
+
+// Hidden returns the spaces the viewer of ctx may not see, nil when it sees them all
+func (a *SpaceAccess) Hidden(ctx context.Context) map[int]bool {
+	viewer, restricted := a.restricted(ctx)
+	if !restricted {
+		return nil
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.acls) == 0 {
+		return nil
+	}
+
+	var hidden map[int]bool
+	for _, space := range a.cache.GetAll() {
+		if a.levelUnlocked(viewer, space.ID) == accessNone {
+			if hidden == nil {
+				hidden = make(map[int]bool)
+			}
+			hidden[space.ID] = true
+		}
+	}
+	return hidden
+}
+
+// Scope restricts spaceIDs to the spaces the viewer of ctx may see. A nil spaceIDs stands for
+// every space; it stays nil when the viewer sees them all and becomes the list of visible
+// spaces otherwise, empty rather than nil when none is.
+func (a *SpaceAccess) Scope(ctx context.Context, spaceIDs []int) []int {
+	hidden := a.Hidden(ctx)
+	if hidden == nil {
+		return spaceIDs
+	}
+
+	if spaceIDs == nil {
+		spaceIDs = []int{}
+		for _, space := range a.cache.GetAll() {
+			spaceIDs = append(spaceIDs, space.ID)
+		}
+		sort.Ints(spaceIDs)
+	}
+	return visibleSpaces(spaceIDs, hidden)
+}
+
+// Filter returns the spaces the viewer of ctx may see. Recursive post counts of the copies
+// returned leave out the posts of hidden descendants.
+func (a *SpaceAccess) Filter(ctx context.Context, spaces []*models.Space) []*models.Space {
+	hidden := a.Hidden(ctx)
+	if hidden == nil {
+		return spaces
+	}
+
+	visible := make([]*models.Space, 0, len(spaces))
+	for _, space := range spaces {
+		if !hidden[space.ID] {
+			visible = append(visible, a.withoutHidden(space, hidden))
+		}
+	}
+	return visible
+}
+
+// Visible returns a space as the viewer of ctx sees it, false when it is hidden
+func (a *SpaceAccess) Visible(ctx context.Context, space *models.Space) (*models.Space, bool) {
+	hidden := a.Hidden(ctx)
+	if hidden == nil {
+		return space, true
+	}
+	if hidden[space.ID] {
+		return nil, false
+	}
+	return a.withoutHidden(space, hidden), true
+}
+
+// withoutHidden returns space, or a copy of it when hidden descendants have posts
+func (a *SpaceAccess) withoutHidden(space *models.Space, hidden map[int]bool) *models.Space {
+	hiddenPosts := 0
+	for _, id := range a.cache.GetDescendants(space.ID) {
+		if !hidden[id] {
+			continue
+		}
+		if descendant, ok := a.cache.Get(id); ok {
+			hiddenPosts += descendant.PostCount
+		}
+	}
+	if hiddenPosts == 0 {
+		return space
+	}
+
+	copied := *space
+	copied.RecursivePostCount -= hiddenPosts
+	return &copied
+}
+
+// restricted returns the viewer of ctx, and whether access lists apply to it
+func (a *SpaceAccess) restricted(ctx context.Context) (Viewer, bool) {
+	if a == nil {
+		return Viewer{}, false
+	}
+	viewer, ok := ViewerFromContext(ctx)
+	return viewer, ok && !viewer.Admin
+}
+
+func (a *SpaceAccess) level(ctx context.Context, spaceID int) int {
+	viewer, restricted := a.restricted(ctx)
+	if !restricted {
+		return accessWrite
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.levelUnlocked(viewer, spaceID)
+}
+
+// levelUnlocked is the lowest access granted to viewer by the lists of a space and of its
+// ancestors. Caller must hold a.mu.
+func (a *SpaceAccess) levelUnlocked(viewer Viewer, spaceID int) int {
+	level := accessWrite
+	for _, id := range append([]int{spaceID}, a.cache.GetAncestors(spaceID)...) {
+		entries, private := a.acls[id]
+		if !private {
+			continue
+		}
+
+		granted := accessNone
+		for _, entry := range entries {
+			if entry.UserID != viewer.UserID {
+				continue
+			}
+			granted = accessRead
+			if entry.Permission == models.SpacePermissionWrite {
+				granted = accessWrite
+			}
+		}
+		if granted < level {
+			level = granted
+		}
+		if level == accessNone {
+			break
+		}
+	}
+	return level
+}
+
+// SetSpaceAccess makes listings and changes of spaces follow their access lists
+func (s *SpaceService) SetSpaceAccess(access *SpaceAccess) {
+	s.access = access
+}
+
+// GetAllVisible returns the spaces the viewer of ctx may see
+func (s *SpaceService) GetAllVisible(ctx context.Context) []*models.Space {
+	return s.access.Filter(ctx, s.cache.GetAll())
+}
+
+// GetVisible returns a space as the viewer of ctx sees it; hidden spaces are not found
+func (s *SpaceService) GetVisible(ctx context.Context, id int) (*models.Space, error) {
+	space, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	visible, ok := s.access.Visible(ctx, space)
+	if !ok {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	return visible, nil
+}
+
+// CheckWrite fails when the viewer of ctx may not change a space: not found when the space is
+// hidden from it, denied when it may only read it
+func (s *SpaceService) CheckWrite(ctx context.Context, id int) error {
+	return checkSpaceAccess(ctx, s.access, id, true)
+}
+
+// SetSpaceAccess makes post listings and changes follow the access lists of their spaces
+func (s *PostService) SetSpaceAccess(access *SpaceAccess) {
+	s.access = access
+}
+
+// CheckSpace fails when the viewer of ctx may not read a space, or change it when write is
+// set. Hidden spaces are not found; spaces it may only read are denied.
+func (s *PostService) CheckSpace(ctx context.Context, spaceID int, write bool) error {
+	return checkSpaceAccess(ctx, s.access, spaceID, write)
+}
+
+// HidesSpaces reports whether some spaces are hidden from the viewer of ctx, so that cached
+// post counts do not match what it sees
+func (s *PostService) HidesSpaces(ctx context.Context) bool {
+	return s.access.Hidden(ctx) != nil
+}
+
+// CheckPost is CheckSpace for the space owning a post; posts of hidden spaces are not found
+func (s *PostService) CheckPost(ctx context.Context, postID int, write bool) error {
+	if _, restricted := s.access.restricted(ctx); !restricted {
+		return nil
+	}
+
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return fmt.Errorf(config.ErrPostNotFound)
+	}
+	if err := s.CheckSpace(ctx, post.SpaceID, write); err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			return fmt.Errorf(config.ErrPostNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// VisibleReferences drops the link references to posts of spaces hidden from the viewer of ctx
+func (s *PostService) VisibleReferences(ctx context.Context, references []models.LinkReference) []models.LinkReference {
+	hidden := s.access.Hidden(ctx)
+	if hidden == nil {
+		return references
+	}
+	var visible []models.LinkReference
+	for _, ref := range references {
+		if !hidden[ref.SpaceID] {
+			visible = append(visible, ref)
+		}
+	}
+	return visible
+}
+
+func checkSpaceAccess(ctx context.Context, access *SpaceAccess, spaceID int, write bool) error {
+	if !access.CanRead(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if write && !access.CanWrite(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+	return nil
+}
+
+// visibleSpaces drops the spaces hidden from a viewer from spaceIDs
+func visibleSpaces(spaceIDs []int, hidden map[int]bool) []int {
+	if hidden == nil {
+		return spaceIDs
+	}
+	visible := make([]int, 0, len(spaceIDs))
+	for _, id := range spaceIDs {
+		if !hidden[id] {
+			visible = append(visible, id)
+		}
+	}
+	return visible
+}
+
+// hiddenSpaces lists the spaces of hidden in ID order
+func hiddenSpaces(hidden map[int]bool) []int {
+	if len(hidden) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(hidden))
+	for id := range hidden {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSpaceAccess(t *testing.T) {
+	setup, err := setupSpaceDeletionTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	now := time.Now().UnixMilli()
+	alice, err := setup.db.ProvisionUser("alice", "", "Alice", models.RoleEditor, now)
+	if err != nil {
+		t.Fatalf("Failed to provision user: %v", err)
+	}
+	bob, _ := setup.db.ProvisionUser("bob", "", "Bob", models.RoleEditor, now)
+
+	// Work -> Private -> Nested, Work -> Public
+	work, _ := setup.spaceService.Create(context.Background(), "Work", nil, "")
+	private, _ := setup.spaceService.Create(context.Background(), "Private", &work.ID, "")
+	nested, _ := setup.spaceService.Create(context.Background(), "Nested", &private.ID, "")
+	public, _ := setup.spaceService.Create(context.Background(), "Public", &work.ID, "")
+	for _, spaceID := range []int{work.ID, private.ID, nested.ID, nested.ID, public.ID} {
+		if _, err := setup.postService.Create(context.Background(), spaceID, "post", nil); err != nil {
+			t.Fatalf("Failed to create post: %v", err)
+		}
+	}
+
+	access := NewSpaceAccess(setup.db, setup.cache)
+	setup.spaceService.SetSpaceAccess(access)
+	setup.postService.SetSpaceAccess(access)
+
+	err = access.SetACL(private.ID, []models.SpaceACLEntry{
+		{UserID: alice.ID, Permission: models.SpacePermissionWrite},
+	})
+	if err != nil {
+		t.Fatalf("Failed to set access list: %v", err)
+	}
+
+	asAlice := WithViewer(context.Background(), Viewer{UserID: alice.ID})
+	asBob := WithViewer(context.Background(), Viewer{UserID: bob.ID})
+	asAdmin := WithViewer(context.Background(), Viewer{UserID: bob.ID, Admin: true})
+
+	t.Run("private spaces and their descendants are hidden", func(t *testing.T) {
+		names := func(ctx context.Context) map[string]int {
+			found := make(map[string]int)
+			for _, space := range setup.spaceService.GetAllVisible(ctx) {
+				found[space.Name] = space.RecursivePostCount
+			}
+			return found
+		}
+
+		if got := names(asBob); len(got) != 2 || got["Work"] != 2 || got["Public"] != 1 {
+			t.Errorf("Expected Work with 2 visible posts and Public, got %v", got)
+		}
+		if got := names(asAlice); len(got) != 4 || got["Work"] != 5 {
+			t.Errorf("Expected every space for a listed user, got %v", got)
+		}
+		if got := names(asAdmin); len(got) != 4 {
+			t.Errorf("Expected every space for an admin, got %v", got)
+		}
+		if got := names(context.Background()); len(got) != 4 {
+			t.Errorf("Expected every space without a viewer, got %v", got)
+		}
+		if cached, _ := setup.cache.Get(work.ID); cached.RecursivePostCount != 5 {
+			t.Errorf("Expected the cached count to stay 5, got %d", cached.RecursivePostCount)
+		}
+
+		if _, err := setup.spaceService.GetVisible(asBob, nested.ID); err == nil || err.Error() != config.ErrSpaceNotFound {
+			t.Errorf("Expected a nested private space not to be found, got %v", err)
+		}
+		if matches := setup.spaceService.Search(asBob, "nested", 10); len(matches) != 0 {
+			t.Errorf("Expected search to skip hidden spaces, got %v", matches)
+		}
+
+		if scope := access.Scope(asBob, nil); len(scope) != 2 || scope[0] != work.ID || scope[1] != public.ID {
+			t.Errorf("Expected every space to narrow down to Work and Public, got %v", scope)
+		}
+		if scope := access.Scope(asBob, []int{private.ID, nested.ID}); scope == nil || len(scope) != 0 {
+			t.Errorf("Expected an empty scope, got %#v", scope)
+		}
+		if scope := access.Scope(asAlice, nil); scope != nil {
+			t.Errorf("Expected every space to stay unrestricted, got %v", scope)
+		}
+	})
+
+	t.Run("recursive listings leave out hidden descendants", func(t *testing.T) {
+		posts, err := setup.postService.GetBySpace(asBob, work.ID, true, 50, 0, models.PostFilter{})
+		if err != nil {
+			t.Fatalf("Failed to list posts: %v", err)
+		}
+		if len(posts) != 2 {
+			t.Errorf("Expected 2 visible posts, got %d", len(posts))
+		}
+
+		all, _ := setup.postService.GetAllPosts(asBob, 50, 0, models.PostFilter{})
+		count, _ := setup.postService.CountFiltered(asBob, 0, false, models.PostFilter{})
+		if len(all) != 2 || count != 2 {
+			t.Errorf("Expected 2 posts across spaces, got %d listed and %d counted", len(all), count)
+		}
+
+		if _, err := setup.postService.GetBySpace(asBob, nested.ID, false, 50, 0, models.PostFilter{}); err == nil {
+			t.Error("Expected listing a hidden space to fail")
+		}
+		if posts, _ := setup.postService.GetBySpace(asAlice, work.ID, true, 50, 0, models.PostFilter{}); len(posts) != 5 {
+			t.Errorf("Expected 5 posts for a listed user, got %d", len(posts))
+		}
+	})
+
+	t.Run("read entries do not allow changes", func(t *testing.T) {
+		err := access.SetACL(nested.ID, []models.SpaceACLEntry{
+			{UserID: alice.ID, Permission: models.SpacePermissionRead},
+		})
+		if err != nil {
+			t.Fatalf("Failed to set access list: %v", err)
+		}
+
+		if err := setup.postService.CheckSpace(asAlice, nested.ID, false); err != nil {
+			t.Errorf("Expected read access, got %v", err)
+		}
+		if err := setup.postService.CheckSpace(asAlice, nested.ID, true); err == nil || err.Error() != config.ErrSpaceAccessDenied {
+			t.Errorf("Expected write access to be denied, got %v", err)
+		}
+		if err := setup.spaceService.CheckWrite(asAlice, private.ID); err != nil {
+			t.Errorf("Expected write access to the parent, got %v", err)
+		}
+		if err := setup.spaceService.CheckWrite(asBob, public.ID); err != nil {
+			t.Errorf("Expected write access to a public space, got %v", err)
+		}
+	})
+
+	t.Run("invalid access lists are rejected", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			spaceID int
+			entries []models.SpaceACLEntry
+			want    string
+		}{
+			{"unknown permission", public.ID, []models.SpaceACLEntry{{UserID: bob.ID, Permission: "admin"}}, config.ErrInvalidSpacePermission},
+			{"duplicate user", public.ID, []models.SpaceACLEntry{
+				{UserID: bob.ID, Permission: models.SpacePermissionRead},
+				{UserID: bob.ID, Permission: models.SpacePermissionWrite},
+			}, config.ErrDuplicateSpaceACLUser},
+			{"unknown user", public.ID, []models.SpaceACLEntry{{UserID: 999, Permission: models.SpacePermissionRead}}, config.ErrACLUserNotFound},
+			{"unknown space", 999, nil, config.ErrSpaceNotFound},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := access.SetACL(tt.spaceID, tt.entries); err == nil || err.Error() != tt.want {
+					t.Errorf("Expected %q, got %v", tt.want, err)
+				}
+			})
+		}
+		if len(access.GetACL(public.ID)) != 0 {
+			t.Error("Expected a rejected list not to be stored")
+		}
+	})
+
+	t.Run("access lists survive a reload and go with deleted spaces", func(t *testing.T) {
+		reloaded := NewSpaceAccess(setup.db, setup.cache)
+		if err := reloaded.Load(); err != nil {
+			t.Fatalf("Failed to load access lists: %v", err)
+		}
+		if acl := reloaded.GetACL(private.ID); len(acl) != 1 || acl[0].UserID != alice.ID {
+			t.Errorf("Expected the access list to be reloaded, got %v", acl)
+		}
+
+		if err := setup.spaceService.Delete(context.Background(), private.ID); err != nil {
+			t.Fatalf("Failed to delete space: %v", err)
+		}
+		if len(access.GetACL(private.ID)) != 0 || len(access.GetACL(nested.ID)) != 0 {
+			t.Error("Expected the access lists of deleted spaces to be dropped")
+		}
+		if acls, _ := setup.db.GetSpaceACLs(); len(acls) != 0 {
+			t.Errorf("Expected no access list left in the database, got %v", acls)
+		}
+	})
+}
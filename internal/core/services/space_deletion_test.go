@@ -5,6 +5,7 @@ import (
 	"backthynk/internal/core/cache"
 	"backthynk/internal/core/events"
 	"backthynk/internal/storage"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -112,33 +113,33 @@ func TestSpaceDeletionWithPostsAndAttachments(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create space hierarchy: Parent -> Child1, Child2
-	parent, err := setup.spaceService.Create("Parent Space", nil, "Parent desc")
+	parent, err := setup.spaceService.Create(context.Background(), "Parent Space", nil, "Parent desc")
 	if err != nil {
 		t.Fatalf("Failed to create parent space: %v", err)
 	}
 
-	child1, err := setup.spaceService.Create("Child 1", &parent.ID, "Child 1 desc")
+	child1, err := setup.spaceService.Create(context.Background(), "Child 1", &parent.ID, "Child 1 desc")
 	if err != nil {
 		t.Fatalf("Failed to create child1 space: %v", err)
 	}
 
-	child2, err := setup.spaceService.Create("Child 2", &parent.ID, "Child 2 desc")
+	child2, err := setup.spaceService.Create(context.Background(), "Child 2", &parent.ID, "Child 2 desc")
 	if err != nil {
 		t.Fatalf("Failed to create child2 space: %v", err)
 	}
 
 	// Create posts with attachments in each space
-	post1, err := setup.postService.Create(parent.ID, "Post in parent", nil)
+	post1, err := setup.postService.Create(context.Background(), parent.ID, "Post in parent", nil)
 	if err != nil {
 		t.Fatalf("Failed to create post1: %v", err)
 	}
 
-	post2, err := setup.postService.Create(child1.ID, "Post in child1", nil)
+	post2, err := setup.postService.Create(context.Background(), child1.ID, "Post in child1", nil)
 	if err != nil {
 		t.Fatalf("Failed to create post2: %v", err)
 	}
 
-	post3, err := setup.postService.Create(child2.ID, "Post in child2", nil)
+	post3, err := setup.postService.Create(context.Background(), child2.ID, "Post in child2", nil)
 	if err != nil {
 		t.Fatalf("Failed to create post3: %v", err)
 	}
@@ -217,7 +218,7 @@ func TestSpaceDeletionWithPostsAndAttachments(t *testing.T) {
 	})
 
 	// Delete parent space (should cascade to children and all posts)
-	err = setup.spaceService.Delete(parent.ID)
+	err = setup.spaceService.Delete(context.Background(), parent.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete parent space: %v", err)
 	}
@@ -335,21 +336,21 @@ func TestSpaceDeletionCacheConsistency(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create a complex hierarchy: Root -> Branch1 -> Leaf1, Branch2 -> Leaf2, Leaf3
-	root, _ := setup.spaceService.Create("Root", nil, "Root space")
-	branch1, _ := setup.spaceService.Create("Branch1", &root.ID, "Branch 1")
-	branch2, _ := setup.spaceService.Create("Branch2", &root.ID, "Branch 2")
-	leaf1, _ := setup.spaceService.Create("Leaf1", &branch1.ID, "Leaf 1")
-	leaf2, _ := setup.spaceService.Create("Leaf2", &branch2.ID, "Leaf 2")
-	leaf3, _ := setup.spaceService.Create("Leaf3", &branch2.ID, "Leaf 3")
+	root, _ := setup.spaceService.Create(context.Background(), "Root", nil, "Root space")
+	branch1, _ := setup.spaceService.Create(context.Background(), "Branch1", &root.ID, "Branch 1")
+	branch2, _ := setup.spaceService.Create(context.Background(), "Branch2", &root.ID, "Branch 2")
+	leaf1, _ := setup.spaceService.Create(context.Background(), "Leaf1", &branch1.ID, "Leaf 1")
+	leaf2, _ := setup.spaceService.Create(context.Background(), "Leaf2", &branch2.ID, "Leaf 2")
+	leaf3, _ := setup.spaceService.Create(context.Background(), "Leaf3", &branch2.ID, "Leaf 3")
 
 	// Add posts to each space
-	setup.postService.Create(root.ID, "Root post", nil)
-	setup.postService.Create(branch1.ID, "Branch1 post", nil)
-	setup.postService.Create(branch2.ID, "Branch2 post", nil)
-	setup.postService.Create(leaf1.ID, "Leaf1 post", nil)
-	setup.postService.Create(leaf2.ID, "Leaf2 post 1", nil)
-	setup.postService.Create(leaf2.ID, "Leaf2 post 2", nil)
-	setup.postService.Create(leaf3.ID, "Leaf3 post", nil)
+	setup.postService.Create(context.Background(), root.ID, "Root post", nil)
+	setup.postService.Create(context.Background(), branch1.ID, "Branch1 post", nil)
+	setup.postService.Create(context.Background(), branch2.ID, "Branch2 post", nil)
+	setup.postService.Create(context.Background(), leaf1.ID, "Leaf1 post", nil)
+	setup.postService.Create(context.Background(), leaf2.ID, "Leaf2 post 1", nil)
+	setup.postService.Create(context.Background(), leaf2.ID, "Leaf2 post 2", nil)
+	setup.postService.Create(context.Background(), leaf3.ID, "Leaf3 post", nil)
 
 	// Verify initial recursive counts
 	rootCat, _ := setup.cache.Get(root.ID)
@@ -363,7 +364,7 @@ func TestSpaceDeletionCacheConsistency(t *testing.T) {
 	}
 
 	// Delete branch2 (should remove branch2, leaf2, leaf3 and their posts)
-	err = setup.spaceService.Delete(branch2.ID)
+	err = setup.spaceService.Delete(context.Background(), branch2.ID)
 	if err != nil {
 		t.Fatalf("Failed to delete branch2: %v", err)
 	}
@@ -408,8 +409,8 @@ func TestSpaceDeletionFileCleanupWithMissingFiles(t *testing.T) {
 	defer setup.cleanup()
 
 	// Create space and post
-	cat, _ := setup.spaceService.Create("Test Space", nil, "Test desc")
-	post, _ := setup.postService.Create(cat.ID, "Test post", nil)
+	cat, _ := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test desc")
+	post, _ := setup.postService.Create(context.Background(), cat.ID, "Test post", nil)
 
 	// Create attachment in database but don't create physical file
 	_, err = setup.db.CreateAttachment(post.ID, "missing.txt", "missing.txt", "text/plain", 100)
@@ -418,7 +419,7 @@ func TestSpaceDeletionFileCleanupWithMissingFiles(t *testing.T) {
 	}
 
 	// Delete space - should not fail even if physical file is missing
-	err = setup.spaceService.Delete(cat.ID)
+	err = setup.spaceService.Delete(context.Background(), cat.ID)
 	if err != nil {
 		t.Errorf("Space deletion should not fail when physical files are missing: %v", err)
 	}
@@ -427,4 +428,29 @@ func TestSpaceDeletionFileCleanupWithMissingFiles(t *testing.T) {
 	if _, exists := setup.cache.Get(cat.ID); exists {
 		t.Error("Space should be deleted from cache")
 	}
-}
\ No newline at end of file
+}
+func TestSpaceDeletionStopsWhenCancelled(t *testing.T) {
+	setup, err := setupSpaceDeletionTest()
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer setup.cleanup()
+
+	cat, _ := setup.spaceService.Create(context.Background(), "Test Space", nil, "Test desc")
+	post, _ := setup.postService.Create(context.Background(), cat.ID, "Test post", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := setup.spaceService.Delete(ctx, cat.ID); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	// Nothing was removed
+	if _, exists := setup.cache.Get(cat.ID); !exists {
+		t.Error("Space should still be in cache")
+	}
+	if _, err := setup.db.GetPost(post.ID); err != nil {
+		t.Errorf("Post should still exist: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// RestoreSpaces puts a deleted space subtree back from the trash under its original IDs, below
+// the parent it was deleted from, with its access lists, posts, pins and the given attachments,
+// and returns its root. Listeners hear about the spaces, posts and files as if they were new so
+// they rebuild their stats.
+func (s *SpaceService) RestoreSpaces(ctx context.Context, snapshot models.TrashedSpace, attachments []models.Attachment) (*models.Space, error) {
+	if len(snapshot.Spaces) == 0 {
+		return nil, fmt.Errorf(config.ErrTrashSpaceNotFound)
+	}
+	root := snapshot.Spaces[0]
+
+	depth := 0
+	if root.ParentID != nil {
+		parent, ok := s.cache.Get(*root.ParentID)
+		if !ok {
+			return nil, fmt.Errorf(config.ErrTrashParentGone)
+		}
+		depth = parent.Depth + 1
+	}
+
+	// The name may have been taken at that level since
+	slug := root.GetSlug()
+	for _, space := range s.cache.GetAll() {
+		if sameParent(space.ParentID, root.ParentID) && space.GetSlug() == slug {
+			return nil, fmt.Errorf(config.ErrTrashSpaceNameTaken)
+		}
+	}
+
+	// The parent may have moved since; the subtree follows it
+	shift := depth - root.Depth
+	spaces := make([]models.Space, len(snapshot.Spaces))
+	for i, space := range snapshot.Spaces {
+		space.Depth += shift
+		if space.Depth > config.MaxSpaceDepth {
+			return nil, fmt.Errorf(config.ErrTrashSpaceTooDeep)
+		}
+		spaces[i] = space
+	}
+
+	journalDays := make(map[int]string)
+	for _, post := range snapshot.Posts {
+		if post.Type == models.PostTypeJournal {
+			journalDays[post.ID] = time.UnixMilli(post.Created).Format("2006-01-02")
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := s.db.RestoreSpaces(spaces, snapshot.ACLs, snapshot.Posts, journalDays, snapshot.Pins, snapshot.LinkPreviews, attachments); err != nil {
+		return nil, err
+	}
+	s.access.Restore(snapshot.ACLs)
+
+	// Update cache, parents first so that post counts reach every ancestor
+	for i := range spaces {
+		space := spaces[i]
+		space.PostCount = 0
+		space.RecursivePostCount = 0
+		s.cache.Set(&space)
+	}
+	for _, post := range snapshot.Posts {
+		s.cache.UpdatePostCount(post.SpaceID, 1)
+	}
+
+	for _, space := range spaces {
+		s.dispatcher.DispatchContext(ctx, events.Event{
+			Type: events.SpaceCreated,
+			Data: events.SpaceEvent{SpaceID: space.ID},
+		})
+	}
+
+	fileSizes := make(map[int]int64)
+	fileCounts := make(map[int]int)
+	for _, attachment := range attachments {
+		fileSizes[attachment.PostID] += attachment.FileSize
+		fileCounts[attachment.PostID]++
+	}
+	for _, post := range snapshot.Posts {
+		s.dispatcher.DispatchContext(ctx, events.Event{
+			Type: events.PostCreated,
+			Data: events.PostEvent{
+				PostID:    post.ID,
+				SpaceID:   post.SpaceID,
+				Timestamp: post.Created,
+			},
+		})
+		if fileCounts[post.ID] > 0 {
+			s.dispatcher.DispatchContext(ctx, events.Event{
+				Type: events.FileUploaded,
+				Data: events.PostEvent{
+					PostID:    post.ID,
+					SpaceID:   post.SpaceID,
+					FileSize:  fileSizes[post.ID],
+					FileCount: fileCounts[post.ID],
+				},
+			})
+		}
+	}
+
+	restored, ok := s.cache.Get(root.ID)
+	if !ok {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	return restored, nil
+}
+
+func sameParent(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
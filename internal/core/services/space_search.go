@@ -0,0 +1,125 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ActivityRecencyProvider returns the latest post time (ms) of a space or its descendants,
+// 0 when it has none. The activity feature provides one from its in-memory activity.
+type ActivityRecencyProvider func(spaceID int) int64
+
+// Name match qualities, from best to worst
+const (
+	matchExact       = 1.0
+	matchPrefix      = 0.8
+	matchWordPrefix  = 0.6
+	matchSubstring   = 0.4
+	matchSubsequence = 0.2
+)
+
+// SetActivityProvider makes space searches favor recently active spaces
+func (s *SpaceService) SetActivityProvider(provider ActivityRecencyProvider) {
+	s.activity = provider
+}
+
+// NameMatchQuality rates how well name matches query, case insensitively: 1 for an exact
+// match down to 0.2 when the query letters only appear in order, 0 when it does not match.
+// An empty query matches every name equally.
+func NameMatchQuality(name, query string) float64 {
+	name = strings.ToLower(strings.TrimSpace(name))
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	switch {
+	case query == "" || name == query:
+		return matchExact
+	case strings.HasPrefix(name, query):
+		return matchPrefix
+	case strings.Contains(" "+strings.Join(strings.FieldsFunc(name, isWordSeparator), " "), " "+query):
+		return matchWordPrefix
+	case strings.Contains(name, query):
+		return matchSubstring
+	case isSubsequence(name, query):
+		return matchSubsequence
+	}
+	return 0
+}
+
+// RecencyScore decays from 1 for activity at now to 0.5 after the configured half-life,
+// and is 0 for spaces that never had a post
+func RecencyScore(lastActivity int64, now time.Time) float64 {
+	if lastActivity <= 0 {
+		return 0
+	}
+
+	days := float64(now.UnixMilli()-lastActivity) / float64(24*time.Hour/time.Millisecond)
+	if days < 0 {
+		days = 0
+	}
+	return math.Pow(0.5, days/config.SpaceSearchRecencyHalfLifeDays)
+}
+
+// ScoreSpaceMatch blends name match quality with recent activity. It is 0 when the name does
+// not match, so recency only orders spaces that match at all.
+func ScoreSpaceMatch(name, query string, lastActivity int64, now time.Time) float64 {
+	quality := NameMatchQuality(name, query)
+	if quality == 0 {
+		return 0
+	}
+
+	return config.SpaceSearchMatchWeight*quality + config.SpaceSearchRecencyWeight*RecencyScore(lastActivity, now)
+}
+
+// Search returns up to limit spaces the viewer of ctx may see whose name matches query, best
+// first. Ties are broken by name so results are stable.
+func (s *SpaceService) Search(ctx context.Context, query string, limit int) []models.SpaceMatch {
+	now := time.Now()
+	matches := []models.SpaceMatch{}
+	for _, space := range s.GetAllVisible(ctx) {
+		var lastActivity int64
+		if s.activity != nil {
+			lastActivity = s.activity(space.ID)
+		}
+
+		score := ScoreSpaceMatch(space.Name, query, lastActivity, now)
+		if score == 0 {
+			continue
+		}
+		matches = append(matches, models.SpaceMatch{Space: *space, Score: score, LastActivity: lastActivity})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return strings.ToLower(matches[i].Name) < strings.ToLower(matches[j].Name)
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func isWordSeparator(r rune) bool {
+	return r == ' ' || r == '-' || r == '_' || r == '.' || r == '\''
+}
+
+// isSubsequence reports whether the letters of query appear in name in order
+func isSubsequence(name, query string) bool {
+	remaining := []rune(query)
+	for _, r := range name {
+		if len(remaining) == 0 {
+			break
+		}
+		if r == remaining[0] {
+			remaining = remaining[1:]
+		}
+	}
+	return len(remaining) == 0
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNameMatchQuality(t *testing.T) {
+	tests := []struct {
+		name     string
+		space    string
+		query    string
+		expected float64
+	}{
+		{"Exact ignores case", "Recipes", "recipes", matchExact},
+		{"Empty query", "Recipes", "", matchExact},
+		{"Prefix", "Recipes", "rec", matchPrefix},
+		{"Word prefix", "Work notes", "not", matchWordPrefix},
+		{"Word prefix after hyphen", "side-projects", "proj", matchWordPrefix},
+		{"Substring", "Notebook", "book", matchSubstring},
+		{"Subsequence", "Journal", "jrnl", matchSubsequence},
+		{"No match", "Journal", "xyz", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NameMatchQuality(tt.space, tt.query); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRecencyScore(t *testing.T) {
+	now := time.Now()
+	halfLife := now.Add(-config.SpaceSearchRecencyHalfLifeDays * 24 * time.Hour).UnixMilli()
+
+	if got := RecencyScore(now.UnixMilli(), now); got != 1 {
+		t.Errorf("Expected 1 for activity now, got %v", got)
+	}
+	if got := RecencyScore(halfLife, now); got < 0.49 || got > 0.51 {
+		t.Errorf("Expected 0.5 after the half-life, got %v", got)
+	}
+	if got := RecencyScore(0, now); got != 0 {
+		t.Errorf("Expected 0 without activity, got %v", got)
+	}
+}
+
+func TestScoreSpaceMatch(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Hour).UnixMilli()
+	old := now.Add(-365 * 24 * time.Hour).UnixMilli()
+
+	// Activity orders spaces of equal match quality
+	if ScoreSpaceMatch("Recipes", "rec", recent, now) <= ScoreSpaceMatch("Records", "rec", old, now) {
+		t.Error("Expected the recently active prefix match to rank first")
+	}
+
+	// But does not lift a weak match above a much better one
+	if ScoreSpaceMatch("Streets", "tree", recent, now) >= ScoreSpaceMatch("Tree", "tree", 0, now) {
+		t.Error("Expected an exact match to beat an active substring match")
+	}
+
+	if ScoreSpaceMatch("Journal", "xyz", recent, now) != 0 {
+		t.Error("Expected activity not to make a non-matching space score")
+	}
+}
+
+func TestSpaceServiceSearch(t *testing.T) {
+	spaceCache := cache.NewSpaceCache()
+	for id, name := range map[int]string{1: "Arctic trip", 2: "Art", 3: "Artemis", 4: "Party", 5: "Music"} {
+		spaceCache.Set(&models.Space{ID: id, Name: name})
+	}
+
+	now := time.Now()
+	lastActivity := map[int]int64{
+		3: now.Add(-time.Hour).UnixMilli(),
+		4: now.Add(-time.Hour).UnixMilli(),
+		1: now.Add(-200 * 24 * time.Hour).UnixMilli(),
+	}
+
+	service := NewSpaceService(nil, spaceCache, nil)
+	service.SetActivityProvider(func(spaceID int) int64 { return lastActivity[spaceID] })
+
+	tests := []struct {
+		name     string
+		query    string
+		limit    int
+		expected []int
+	}{
+		// Artemis is a recently active prefix, Art an inactive exact match, Party an active
+		// substring and Arctic trip a stale subsequence
+		{"Blends match and activity", "art", 10, []int{3, 2, 4, 1}},
+		{"Limit", "art", 2, []int{3, 2}},
+		{"Empty query by activity", "", 3, []int{3, 4, 1}},
+		{"No match", "zzz", 10, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := service.Search(context.Background(), tt.query, tt.limit)
+			if len(matches) != len(tt.expected) {
+				t.Fatalf("Expected %d matches, got %d: %+v", len(tt.expected), len(matches), matches)
+			}
+			for i, id := range tt.expected {
+				if matches[i].ID != id {
+					t.Errorf("Expected space %d at position %d, got %d (%s)", id, i, matches[i].ID, matches[i].Name)
+				}
+			}
+		})
+	}
+}
@@ -1,20 +1,35 @@
 package services
 
 import (
+	"backthynk/internal/config"
 	"backthynk/internal/core/cache"
 	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
 	"backthynk/internal/core/models"
 	"backthynk/internal/storage"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"go.uber.org/zap"
 )
 
+// FileStatsProvider returns the attachment count and total size of a space, optionally
+// including its descendants. The detailed stats feature provides one from its in-memory stats.
+type FileStatsProvider func(spaceID int, recursive bool) (fileCount int64, totalSize int64)
+
 type SpaceService struct {
 	db         *storage.DB
 	cache      *cache.SpaceCache
 	dispatcher *events.Dispatcher
+	fileStats  FileStatsProvider
+	activity   ActivityRecencyProvider
+	trash      TrashKeeper
+	access     *SpaceAccess
+	stop       chan struct{}
 }
 
 func NewSpaceService(db *storage.DB, cache *cache.SpaceCache, dispatcher *events.Dispatcher) *SpaceService {
@@ -56,6 +71,96 @@ func (s *SpaceService) InitializeCache() error {
 	return nil
 }
 
+// LoadSpace reads a space and its post count from the database; it is the read-through
+// loader of the space cache
+func (s *SpaceService) LoadSpace(id int) (*models.Space, error) {
+	space, err := s.db.GetSpace(id)
+	if err != nil {
+		return nil, err
+	}
+	if space.PostCount, err = s.db.GetSpacePostCount(id); err != nil {
+		return nil, err
+	}
+	return space, nil
+}
+
+// ReconcileCache compares the cache with the database and repairs what diverged, returning
+// the number of fixed spaces
+func (s *SpaceService) ReconcileCache() (int, error) {
+	spaces, err := s.db.GetSpaces()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load spaces: %w", err)
+	}
+	postCounts, err := s.db.GetAllSpacePostCounts()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load post counts: %w", err)
+	}
+
+	fresh := make([]*models.Space, len(spaces))
+	for i := range spaces {
+		spaces[i].PostCount = postCounts[spaces[i].ID]
+		fresh[i] = &spaces[i]
+	}
+
+	fixes := s.cache.Reconcile(fresh)
+	if fixes > 0 {
+		logger.Warning("Space cache diverged from the database", zap.Int("fixes", fixes))
+	}
+	return fixes, nil
+}
+
+// InvalidateCache drops a space from the cache and reads it back from the database, or
+// reconciles the whole cache when spaceID is nil
+func (s *SpaceService) InvalidateCache(spaceID *int) error {
+	if spaceID == nil {
+		_, err := s.ReconcileCache()
+		return err
+	}
+
+	s.cache.Invalidate(*spaceID)
+	if _, err := s.Get(*spaceID); err != nil {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	return nil
+}
+
+// GetCacheStats returns the space cache counters
+func (s *SpaceService) GetCacheStats() cache.CacheStats {
+	return s.cache.Stats()
+}
+
+// StartReconciliation reconciles the cache with the database once per interval until Stop is called
+func (s *SpaceService) StartReconciliation(interval time.Duration) {
+	if s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.ReconcileCache(); err != nil {
+					logger.Warning("Failed to reconcile space cache", zap.Error(err))
+				}
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// Stop ends the reconciliation loop
+func (s *SpaceService) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
 func (s *SpaceService) calculateRecursivePostCount(spaceID int) int {
 	cat, ok := s.cache.Get(spaceID)
 	if !ok {
@@ -83,8 +188,8 @@ func (s *SpaceService) Get(id int) (*models.Space, error) {
 		return cat, nil
 	}
 	
-	// Fallback to database
-	cat, err := s.db.GetSpace(id)
+	// Fallback to database when the cache does not read through
+	cat, err := s.LoadSpace(id)
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +199,11 @@ func (s *SpaceService) Get(id int) (*models.Space, error) {
 	return cat, nil
 }
 
-func (s *SpaceService) Create(name string, parentID *int, description string) (*models.Space, error) {
+func (s *SpaceService) Create(ctx context.Context, name string, parentID *int, description string) (*models.Space, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	cat, err := s.db.CreateSpace(name, parentID, description)
 	if err != nil {
 		return nil, err
@@ -106,7 +215,7 @@ func (s *SpaceService) Create(name string, parentID *int, description string) (*
 	s.cache.Set(cat)
 	
 	// Dispatch event
-	s.dispatcher.Dispatch(events.Event{
+	s.dispatcher.DispatchContext(ctx, events.Event{
 		Type: events.SpaceCreated,
 		Data: events.SpaceEvent{SpaceID: cat.ID},
 	})
@@ -114,7 +223,11 @@ func (s *SpaceService) Create(name string, parentID *int, description string) (*
 	return cat, nil
 }
 
-func (s *SpaceService) Update(id int, name, description string, parentID *int) (*models.Space, error) {
+func (s *SpaceService) Update(ctx context.Context, id int, name, description string, parentID *int) (*models.Space, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	oldCat, _ := s.cache.Get(id)
 	
 	cat, err := s.db.UpdateSpace(id, name, description, parentID)
@@ -148,7 +261,7 @@ func (s *SpaceService) Update(id int, name, description string, parentID *int) (
 	}
 	
 	// Dispatch event
-	s.dispatcher.Dispatch(events.Event{
+	s.dispatcher.DispatchContext(ctx, events.Event{
 		Type: events.SpaceUpdated,
 		Data: events.SpaceEvent{
 			SpaceID:  cat.ID,
@@ -207,7 +320,56 @@ func (s *SpaceService) GetSpaceBreadcrumb(spaceID int) string {
 	return breadcrumb
 }
 
-func (s *SpaceService) Delete(id int) error {
+// SetFileStatsProvider makes previews use in-memory file statistics instead of querying the database
+func (s *SpaceService) SetFileStatsProvider(provider FileStatsProvider) {
+	s.fileStats = provider
+}
+
+// SetTrashKeeper makes deleted spaces go to the trash instead of being lost
+func (s *SpaceService) SetTrashKeeper(trash TrashKeeper) {
+	s.trash = trash
+}
+
+// PreviewDelete reports what deleting a space would remove without changing anything
+func (s *SpaceService) PreviewDelete(ctx context.Context, id int) (*models.DryRunSummary, error) {
+	space, ok := s.cache.Get(id)
+	if !ok {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	descendants := s.cache.GetDescendants(id)
+	if descendants == nil {
+		descendants = []int{}
+	}
+
+	summary := &models.DryRunSummary{
+		Operation:        "delete_space",
+		DryRun:           true,
+		SpaceCount:       len(descendants) + 1,
+		DescendantSpaces: descendants,
+		PostCount:        space.RecursivePostCount,
+	}
+
+	if s.fileStats != nil {
+		summary.AttachmentCount, summary.AttachmentBytes = s.fileStats(id, true)
+		return summary, nil
+	}
+
+	stats, err := s.db.GetAllFileStatsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, spaceID := range append([]int{id}, descendants...) {
+		summary.AttachmentCount += stats[spaceID].FileCount
+		summary.AttachmentBytes += stats[spaceID].TotalSize
+	}
+
+	return summary, nil
+}
+
+// Delete removes a space with its descendants and their posts. Cancelling ctx stops it while it
+// gathers the posts; once files start being removed it runs to the end.
+func (s *SpaceService) Delete(ctx context.Context, id int) error {
 	// Get parent information before deletion for event
 	var parentID *int
 	if cat, ok := s.cache.Get(id); ok {
@@ -218,17 +380,32 @@ func (s *SpaceService) Delete(id int) error {
 	descendants := s.cache.GetDescendants(id)
 	allSpaces := append([]int{id}, descendants...)
 
+	spacePosts := make([][]int, len(allSpaces))
+	for i, catID := range allSpaces {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		spacePosts[i], _ = s.db.GetPostIDsBySpace(catID)
+	}
+
+	// Keep a copy in the trash; attachment files are moved there before posts are cleaned up
+	if s.trash != nil {
+		if err := s.trash.KeepSpaces(allSpaces); err != nil {
+			return err
+		}
+	}
+
 	// Fire PostDeleted events and handle file cleanup for all posts
 	// This must happen BEFORE database deletion so detailed stats service gets the events
 	var affectedPosts []int
-	for _, catID := range allSpaces {
-		postIDs, _ := s.db.GetPostIDsBySpace(catID)
+	for i, catID := range allSpaces {
+		postIDs := spacePosts[i]
 		affectedPosts = append(affectedPosts, postIDs...)
 
 		// For each post, handle file cleanup and fire PostDeleted event
 		for _, postID := range postIDs {
 			// Fire PostDeleted event for statistics
-			if err := s.firePostDeletedEvent(postID, catID); err != nil {
+			if err := s.firePostDeletedEvent(ctx, postID, catID); err != nil {
 				// Log error but continue with other posts
 				// TODO: Add proper logging
 				continue
@@ -240,7 +417,7 @@ func (s *SpaceService) Delete(id int) error {
 	}
 
 	// Delete from database (CASCADE will handle posts and attachments at DB level)
-	if err := s.db.DeleteSpace(id); err != nil {
+	if err := s.db.DeleteSpace(ctx, id); err != nil {
 		return err
 	}
 
@@ -248,9 +425,10 @@ func (s *SpaceService) Delete(id int) error {
 	for _, catID := range allSpaces {
 		s.cache.Delete(catID)
 	}
+	s.access.Forget(allSpaces...)
 
 	// Dispatch SpaceDeleted event (for any services that need to know about space deletion itself)
-	s.dispatcher.Dispatch(events.Event{
+	s.dispatcher.DispatchContext(ctx, events.Event{
 		Type: events.SpaceDeleted,
 		Data: events.SpaceEvent{
 			SpaceID:    id,
@@ -263,7 +441,7 @@ func (s *SpaceService) Delete(id int) error {
 }
 
 // firePostDeletedEvent fires a PostDeleted event for a specific post, including file information
-func (s *SpaceService) firePostDeletedEvent(postID, spaceID int) error {
+func (s *SpaceService) firePostDeletedEvent(ctx context.Context, postID, spaceID int) error {
 	// Get post details
 	post, err := s.db.GetPost(postID)
 	if err != nil {
@@ -276,12 +454,20 @@ func (s *SpaceService) firePostDeletedEvent(postID, spaceID int) error {
 		return err
 	}
 
+	versionPaths, err := s.db.GetAttachmentVersionPathsByPost(postID)
+	if err != nil {
+		return err
+	}
+
 	// Delete physical files (same pattern as in storage/posts.go)
 	uploadsDir := filepath.Join(s.db.GetStoragePath(), "uploads")
 	for _, attachment := range attachments {
 		fullPath := filepath.Join(uploadsDir, attachment.FilePath)
 		os.Remove(fullPath) // Ignore errors like in posts.go
 	}
+	for _, versionPath := range versionPaths {
+		os.Remove(filepath.Join(uploadsDir, versionPath))
+	}
 
 
 
@@ -292,7 +478,7 @@ func (s *SpaceService) firePostDeletedEvent(postID, spaceID int) error {
 	}
 
 	// Dispatch PostDeleted event (same as PostService.Delete does)
-	s.dispatcher.Dispatch(events.Event{
+	s.dispatcher.DispatchContext(ctx, events.Event{
 		Type: events.PostDeleted,
 		Data: events.PostEvent{
 			PostID:     postID,
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"fmt"
+)
+
+// LoadSpaceSettings loads the per-space listing preferences from the database
+func (s *PostService) LoadSpaceSettings() error {
+	sorts, err := s.db.GetSpaceDefaultSorts()
+	if err != nil {
+		return err
+	}
+
+	s.settingsMu.Lock()
+	s.defaultSorts = sorts
+	s.settingsMu.Unlock()
+	return nil
+}
+
+// GetSpaceSettings returns the listing preferences of a space
+func (s *PostService) GetSpaceSettings(spaceID int) (*models.SpaceSettings, error) {
+	if _, ok := s.cache.Get(spaceID); !ok {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	return &models.SpaceSettings{DefaultSort: s.DefaultSort(spaceID)}, nil
+}
+
+// SetSpaceSettings replaces the listing preferences of a space; an empty default sort goes
+// back to newest first
+func (s *PostService) SetSpaceSettings(spaceID int, settings models.SpaceSettings) error {
+	if _, ok := s.cache.Get(spaceID); !ok {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if settings.DefaultSort != "" && !models.IsPostSort(settings.DefaultSort) {
+		return fmt.Errorf(config.ErrInvalidPostSort)
+	}
+
+	// Newest first needs no row
+	sort := settings.DefaultSort
+	if sort == models.PostSortCreatedDesc {
+		sort = ""
+	}
+	if err := s.db.SetSpaceDefaultSort(spaceID, sort); err != nil {
+		return err
+	}
+
+	s.settingsMu.Lock()
+	if sort == "" {
+		delete(s.defaultSorts, spaceID)
+	} else {
+		s.defaultSorts[spaceID] = sort
+	}
+	s.settingsMu.Unlock()
+	return nil
+}
+
+// DefaultSort returns the listing order of a space when none is requested
+func (s *PostService) DefaultSort(spaceID int) string {
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+
+	if sort, ok := s.defaultSorts[spaceID]; ok {
+		return sort
+	}
+	return models.PostSortCreatedDesc
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// unsafeFilenameChars are replaced in stored filenames: path separators, characters refused
+// by some filesystems and characters with a meaning in URLs
+const unsafeFilenameChars = `/\<>:"|?*#%`
+
+// reservedFilenames cannot be used as file names on Windows, whatever the extension
+var reservedFilenames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// storedFilename returns the name a file uploaded as filename is stored under, before
+// collisions. It is the original name whenever that is safe on disk and in URLs.
+func storedFilename(filename string) string {
+	name := strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(unsafeFilenameChars, r) {
+			return '_'
+		}
+		return r
+	}, strings.ToValidUTF8(filename, "_"))
+
+	// No hidden files, and no trailing dots or spaces which Windows drops
+	name = strings.TrimLeft(name, ". ")
+	name = strings.TrimRight(name, ". ")
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	if reservedFilenames[strings.ToUpper(stem)] {
+		stem = "_" + stem
+	}
+	if len(ext) > config.MaxStoredFilenameLength/2 {
+		ext = ""
+		stem = name
+	}
+	stem = truncateUTF8(stem, config.MaxStoredFilenameLength-len(ext))
+
+	if stem == "" {
+		stem = config.DefaultStoredFilename
+	}
+	return stem + ext
+}
+
+// numberedFilename returns name for n = 0, otherwise name with -n before its extension
+func numberedFilename(name string, n int) string {
+	if n == 0 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), n, ext)
+}
+
+// truncateUTF8 cuts s to at most max bytes without splitting a character
+func truncateUTF8(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
+
+// createStoredFile creates the file of an upload in the uploads directory under its stored
+// filename, adding a numeric suffix while the name is taken on disk or by a file away from the
+// uploads directory, such as a trashed or cold attachment. It returns the name it picked.
+func (s *FileService) createStoredFile(filename string) (*os.File, string, error) {
+	base := storedFilename(filename)
+
+	for n := 0; n <= config.MaxFilenameCollisions; n++ {
+		name := numberedFilename(base, n)
+		inUse, err := s.db.FilePathInUse(name)
+		if err != nil {
+			return nil, "", err
+		}
+		if inUse {
+			continue
+		}
+
+		// Exclusive creation keeps concurrent uploads of the same name apart
+		file, err := os.OpenFile(filepath.Join(s.uploadPath, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, config.FilePermissions)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		return file, name, nil
+	}
+
+	return nil, "", fmt.Errorf(config.ErrFilenameCollisions)
+}
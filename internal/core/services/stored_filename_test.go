@@ -0,0 +1,55 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"strings"
+	"testing"
+)
+
+func TestStoredFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"rapport trimestriel é.pdf", "rapport trimestriel é.pdf"},
+		{"what?#now.txt", "what__now.txt"},
+		{"../../etc/passwd", "_.._etc_passwd"},
+		{".hidden", "hidden"},
+		{"trailing. ", "trailing"},
+		{"con.txt", "_con.txt"},
+		{"...", config.DefaultStoredFilename},
+		{"", config.DefaultStoredFilename},
+		{"bad\xffbyte.txt", "bad_byte.txt"},
+	}
+
+	for _, tt := range tests {
+		if got := storedFilename(tt.filename); got != tt.expected {
+			t.Errorf("storedFilename(%q) = %q, expected %q", tt.filename, got, tt.expected)
+		}
+	}
+
+	long := storedFilename(strings.Repeat("é", 200) + ".pdf")
+	if len(long) > config.MaxStoredFilenameLength || !strings.HasSuffix(long, "é.pdf") {
+		t.Errorf("Expected a long name cut on a character boundary keeping its extension, got %d bytes", len(long))
+	}
+}
+
+func TestNumberedFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int
+		expected string
+	}{
+		{"notes.txt", 0, "notes.txt"},
+		{"notes.txt", 2, "notes-2.txt"},
+		{"README", 1, "README-1"},
+		{"archive.tar.gz", 1, "archive.tar-1.gz"},
+	}
+
+	for _, tt := range tests {
+		if got := numberedFilename(tt.name, tt.n); got != tt.expected {
+			t.Errorf("numberedFilename(%q, %d) = %q, expected %q", tt.name, tt.n, got, tt.expected)
+		}
+	}
+}
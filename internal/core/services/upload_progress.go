@@ -0,0 +1,133 @@
+package services
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"io"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var uploadSessionRegex = regexp.MustCompile(config.UploadSessionPattern)
+
+// uploadSequence orders the progress events of every session
+var uploadSequence int64
+
+// ValidUploadSession reports whether id can be used as an upload session ID
+func ValidUploadSession(id string) bool {
+	return uploadSessionRegex.MatchString(id)
+}
+
+// UploadProgress publishes the stages of one upload session as events.UploadProgress.
+// A nil *UploadProgress is valid and reports nothing, for uploads without a session.
+type UploadProgress struct {
+	dispatcher *events.Dispatcher
+	interval   time.Duration
+	mu         sync.Mutex
+	state      events.UploadProgressEvent
+	lastSent   time.Time
+}
+
+// NewUploadProgress starts reporting an upload session of totalBytes (0 when unknown)
+func (s *FileService) NewUploadProgress(sessionID string, totalBytes int64) *UploadProgress {
+	if totalBytes < 0 {
+		totalBytes = 0
+	}
+	return &UploadProgress{
+		dispatcher: s.dispatcher,
+		interval:   config.UploadProgressInterval,
+		state: events.UploadProgressEvent{
+			SessionID:  sessionID,
+			Stage:      events.UploadStageReceiving,
+			TotalBytes: totalBytes,
+		},
+	}
+}
+
+// Reader counts the bytes read from r, reporting them at most once per interval
+func (p *UploadProgress) Reader(r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+	return &progressReader{reader: r, progress: p}
+}
+
+// Processing reports that the whole file was received and is being stored
+func (p *UploadProgress) Processing(postID int, filename string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.state.Stage = events.UploadStageProcessing
+	p.state.PostID = postID
+	p.state.Filename = filename
+	p.mu.Unlock()
+	p.send()
+}
+
+// Done reports the attachment created by the upload
+func (p *UploadProgress) Done(attachmentID int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.state.Stage = events.UploadStageDone
+	p.state.AttachmentID = attachmentID
+	p.mu.Unlock()
+	p.send()
+}
+
+// Fail reports why the upload was rejected
+func (p *UploadProgress) Fail(reason string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.state.Stage = events.UploadStageFailed
+	p.state.Error = reason
+	p.mu.Unlock()
+	p.send()
+}
+
+func (p *UploadProgress) received(n int64, eof bool) {
+	p.mu.Lock()
+	p.state.BytesReceived += n
+	due := eof || time.Since(p.lastSent) >= p.interval
+	p.mu.Unlock()
+	if due {
+		p.send()
+	}
+}
+
+func (p *UploadProgress) send() {
+	p.mu.Lock()
+	p.lastSent = time.Now()
+	p.state.Sequence = atomic.AddInt64(&uploadSequence, 1)
+	state := p.state
+	p.mu.Unlock()
+
+	if p.dispatcher != nil {
+		p.dispatcher.Dispatch(events.Event{Type: events.UploadProgress, Data: state})
+	}
+}
+
+type progressReader struct {
+	reader   io.Reader
+	progress *UploadProgress
+	eof      bool
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	// The final count is sent once, even if the reader is read again after EOF
+	eof := err == io.EOF && !r.eof
+	if err == io.EOF {
+		r.eof = true
+	}
+	if n > 0 || eof {
+		r.progress.received(int64(n), eof)
+	}
+	return n, err
+}
@@ -0,0 +1,57 @@
+// Package systemd integrates the server with the systemd service manager: sockets passed by
+// socket activation, readiness and watchdog notifications, and the unit files installed by
+// --install-service. Outside systemd every function does nothing.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation
+const listenFDsStart = 3
+
+// Listeners returns the sockets systemd passed to this process through socket activation, in
+// the order of the ListenStream lines of the socket unit, and nil when started without it.
+// The activation variables are cleared so child processes do not take the sockets as theirs.
+func Listeners() ([]net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	listeners := make([]net.Listener, 0, count)
+	for fd := listenFDsStart; fd < listenFDsStart+count; fd++ {
+		name := fmt.Sprintf("LISTEN_FD_%d", fd)
+		if i := fd - listenFDsStart; i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		// FileListener works on a duplicate, the passed descriptor is closed afterwards
+		file := os.NewFile(uintptr(fd), name)
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("socket %s passed by systemd is not a stream socket: %w", name, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
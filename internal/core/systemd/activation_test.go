@@ -0,0 +1,25 @@
+package systemd
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestListenersWithoutActivation(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	t.Setenv("LISTEN_FDS", "")
+	if listeners, err := Listeners(); listeners != nil || err != nil {
+		t.Errorf("Expected no sockets outside systemd, got %v, %v", listeners, err)
+	}
+
+	// Sockets passed to another process, e.g. the parent, are not ours
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	if listeners, err := Listeners(); listeners != nil || err != nil {
+		t.Errorf("Expected sockets of another process ignored, got %v, %v", listeners, err)
+	}
+	if os.Getenv("LISTEN_FDS") != "" || os.Getenv("LISTEN_PID") != "" {
+		t.Error("Expected the activation variables cleared")
+	}
+}
@@ -0,0 +1,78 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Notification states, see sd_notify(3)
+const (
+	Ready    = "READY=1"    // Startup finished, units ordered after this one may start
+	Stopping = "STOPPING=1" // Shutdown started
+	Watchdog = "WATCHDOG=1" // Still alive, sent within the watchdog timeout of the unit
+)
+
+// Notify sends state to the service manager. It reports false without error when the process
+// was not started by systemd with a notification socket.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	// A leading @ names a socket in the abstract namespace
+	addr := &net.UnixAddr{Name: socket, Net: "unixgram"}
+	if socket[0] == '@' {
+		addr.Name = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns how often the watchdog must be notified, half the timeout set by
+// WatchdogSec in the unit, and 0 when the watchdog is off for this process
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// StartWatchdog notifies the watchdog until the returned function is called. It does nothing
+// when the watchdog is off.
+func StartWatchdog() (stop func()) {
+	interval := WatchdogInterval()
+	if interval == 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				Notify(Watchdog)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,56 @@
+package systemd
+
+import (
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := Notify(Ready); sent || err != nil {
+		t.Errorf("Expected nothing sent outside systemd, got %v, %v", sent, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to open notification socket: %v", err)
+	}
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	if sent, err := Notify(Ready); !sent || err != nil {
+		t.Fatalf("Expected the state sent, got %v, %v", sent, err)
+	}
+
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("Failed to read notification: %v", err)
+	}
+	if got := string(buf[:n]); got != Ready {
+		t.Errorf("Expected %q, got %q", Ready, got)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	if interval := WatchdogInterval(); interval != 0 {
+		t.Errorf("Expected no watchdog, got %v", interval)
+	}
+
+	t.Setenv("WATCHDOG_USEC", "60000000")
+	t.Setenv("WATCHDOG_PID", "")
+	if interval := WatchdogInterval(); interval != 30*time.Second {
+		t.Errorf("Expected half the timeout, got %v", interval)
+	}
+
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(1<<30))
+	if interval := WatchdogInterval(); interval != 0 {
+		t.Errorf("Expected no watchdog for another process, got %v", interval)
+	}
+}
@@ -0,0 +1,83 @@
+package systemd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UnitOptions describes the service written by --install-service
+type UnitOptions struct {
+	Name             string   // Unit name without suffix, the socket unit shares it
+	ExecStart        []string // Absolute path of the binary, then its arguments
+	WorkingDirectory string   // Directory holding the configuration files
+	User             string   // Account the server runs as, root when empty
+	Port             string
+	WatchdogSec      int
+	StopTimeout      time.Duration // Time given to requests in flight on stop
+}
+
+// ServiceUnit returns the service unit. The server notifies readiness and the watchdog, and
+// always starts with its socket unit so restarts keep the port bound and queue connections.
+func ServiceUnit(opts UnitOptions) string {
+	socket := opts.Name + ".socket"
+	args := make([]string, len(opts.ExecStart))
+	for i, arg := range opts.ExecStart {
+		args[i] = quoteArg(arg)
+	}
+
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Backthynk personal knowledge base\n")
+	b.WriteString("Documentation=https://github.com/Backthynk/backthynk\n")
+	fmt.Fprintf(&b, "Requires=%s\n", socket)
+	fmt.Fprintf(&b, "After=network-online.target %s\n", socket)
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=notify\n")
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(args, " "))
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", escapeSpecifiers(opts.WorkingDirectory))
+	if opts.User != "" {
+		fmt.Fprintf(&b, "User=%s\n", opts.User)
+	}
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=2\n")
+	if opts.WatchdogSec > 0 {
+		fmt.Fprintf(&b, "WatchdogSec=%d\n", opts.WatchdogSec)
+	}
+	// Leave the server time to drain before systemd kills it
+	fmt.Fprintf(&b, "TimeoutStopSec=%d\n", int((opts.StopTimeout+5*time.Second)/time.Second))
+	b.WriteString("NoNewPrivileges=yes\n")
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	fmt.Fprintf(&b, "Also=%s\n", socket)
+	return b.String()
+}
+
+// SocketUnit returns the socket unit binding the port of the server
+func SocketUnit(opts UnitOptions) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=Backthynk socket\n")
+	b.WriteString("\n[Socket]\n")
+	fmt.Fprintf(&b, "ListenStream=%s\n", opts.Port)
+	fmt.Fprintf(&b, "Service=%s.service\n", opts.Name)
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=sockets.target\n")
+	return b.String()
+}
+
+// escapeSpecifiers keeps systemd from expanding % in a setting value
+func escapeSpecifiers(value string) string {
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// quoteArg quotes a command line argument for ExecStart when it holds spaces or quotes
+func quoteArg(arg string) string {
+	arg = escapeSpecifiers(arg)
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + replacer.Replace(arg) + `"`
+}
@@ -0,0 +1,47 @@
+package systemd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServiceUnit(t *testing.T) {
+	unit := ServiceUnit(UnitOptions{
+		Name:             "backthynk",
+		ExecStart:        []string{"/opt/back thynk/backthynk", "--safe-mode"},
+		WorkingDirectory: "/srv/100%",
+		User:             "notes",
+		WatchdogSec:      60,
+		StopTimeout:      15 * time.Second,
+	})
+
+	for _, expected := range []string{
+		"Requires=backthynk.socket\n",
+		"After=network-online.target backthynk.socket\n",
+		"Type=notify\n",
+		`ExecStart="/opt/back thynk/backthynk" --safe-mode` + "\n",
+		"WorkingDirectory=/srv/100%%\n",
+		"User=notes\n",
+		"WatchdogSec=60\n",
+		"TimeoutStopSec=20\n",
+		"Also=backthynk.socket\n",
+	} {
+		if !strings.Contains(unit, expected) {
+			t.Errorf("Expected the service unit to contain %q, got:\n%s", expected, unit)
+		}
+	}
+
+	if unit := ServiceUnit(UnitOptions{Name: "backthynk", ExecStart: []string{"/bin/backthynk"}}); strings.Contains(unit, "User=") || strings.Contains(unit, "WatchdogSec=") {
+		t.Errorf("Expected no user or watchdog when unset, got:\n%s", unit)
+	}
+}
+
+func TestSocketUnit(t *testing.T) {
+	unit := SocketUnit(UnitOptions{Name: "backthynk", Port: "1369"})
+	for _, expected := range []string{"ListenStream=1369\n", "Service=backthynk.service\n", "WantedBy=sockets.target\n"} {
+		if !strings.Contains(unit, expected) {
+			t.Errorf("Expected the socket unit to contain %q, got:\n%s", expected, unit)
+		}
+	}
+}
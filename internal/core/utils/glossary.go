@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GlossaryEntry is a term and its definition used to annotate rendered content
+type GlossaryEntry struct {
+	Term       string
+	Definition string
+}
+
+// glossarySkipTags lists elements whose text must never be annotated
+var glossarySkipTags = map[string]bool{
+	"a":    true,
+	"code": true,
+	"pre":  true,
+	"abbr": true,
+}
+
+// buildTermPattern compiles a case-insensitive matcher for the given terms, longest first so
+// that "machine learning" wins over "machine". Word boundaries are only enforced on edges
+// that are word characters, allowing terms such as "C++".
+func buildTermPattern(terms []string) *regexp.Regexp {
+	sorted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if strings.TrimSpace(term) != "" {
+			sorted = append(sorted, term)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+
+	parts := make([]string, len(sorted))
+	for i, term := range sorted {
+		part := regexp.QuoteMeta(term)
+		if first, _ := utf8.DecodeRuneInString(term); isWordRune(first) {
+			part = `\b` + part
+		}
+		if last, _ := utf8.DecodeLastRuneInString(term); isWordRune(last) {
+			part = part + `\b`
+		}
+		parts[i] = part
+	}
+
+	return regexp.MustCompile(`(?i)(?:` + strings.Join(parts, "|") + `)`)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// MatchGlossaryTerms returns the distinct terms (lowercased) occurring in text
+func MatchGlossaryTerms(text string, terms []string) []string {
+	pattern := buildTermPattern(terms)
+	if pattern == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var matched []string
+	for _, match := range pattern.FindAllString(text, -1) {
+		key := strings.ToLower(match)
+		if !seen[key] {
+			seen[key] = true
+			matched = append(matched, key)
+		}
+	}
+
+	sort.Strings(matched)
+	return matched
+}
+
+// AnnotateGlossaryTerms wraps term occurrences in the text of an HTML fragment with
+// <abbr class="glossary-term" data-term="..." title="..."> so the frontend can show a tooltip.
+// Tags, attributes and the content of links and code blocks are left untouched.
+func AnnotateGlossaryTerms(content string, entries []GlossaryEntry) string {
+	definitions := make(map[string]string, len(entries))
+	terms := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		key := strings.ToLower(entry.Term)
+		if _, exists := definitions[key]; exists {
+			continue
+		}
+		definitions[key] = entry.Definition
+		terms = append(terms, entry.Term)
+	}
+
+	pattern := buildTermPattern(terms)
+	if pattern == nil {
+		return content
+	}
+
+	annotate := func(text string) string {
+		return pattern.ReplaceAllStringFunc(text, func(match string) string {
+			key := strings.ToLower(match)
+			return `<abbr class="glossary-term" data-term="` + html.EscapeString(key) +
+				`" title="` + html.EscapeString(definitions[key]) + `">` + match + `</abbr>`
+		})
+	}
+
+	var result strings.Builder
+	skipDepth := 0
+	rest := content
+
+	for len(rest) > 0 {
+		start := strings.IndexByte(rest, '<')
+		if start < 0 {
+			start = len(rest)
+		}
+
+		if start > 0 {
+			if skipDepth == 0 {
+				result.WriteString(annotate(rest[:start]))
+			} else {
+				result.WriteString(rest[:start])
+			}
+			rest = rest[start:]
+			continue
+		}
+
+		end := strings.IndexByte(rest, '>')
+		if end < 0 {
+			// Unterminated tag, copy the remainder verbatim
+			result.WriteString(rest)
+			break
+		}
+
+		tag := rest[:end+1]
+		result.WriteString(tag)
+		rest = rest[end+1:]
+
+		name, closing := parseTagName(tag)
+		if !glossarySkipTags[name] || strings.HasSuffix(tag, "/>") {
+			continue
+		}
+		if closing {
+			if skipDepth > 0 {
+				skipDepth--
+			}
+		} else {
+			skipDepth++
+		}
+	}
+
+	return result.String()
+}
+
+// parseTagName extracts the lowercased element name of an HTML tag and whether it is a closing tag
+func parseTagName(tag string) (string, bool) {
+	inner := strings.TrimPrefix(tag, "<")
+	closing := strings.HasPrefix(inner, "/")
+	inner = strings.TrimPrefix(inner, "/")
+
+	end := strings.IndexFunc(inner, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '>' || r == '/'
+	})
+	if end < 0 {
+		end = len(inner)
+	}
+
+	return strings.ToLower(inner[:end]), closing
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchGlossaryTerms(t *testing.T) {
+	terms := []string{"API", "machine learning", "C++", "ML"}
+
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"No terms", "Nothing to see", nil},
+		{"Case insensitive", "the api is down", []string{"api"}},
+		{"Word boundaries", "rapid APIs", nil},
+		{"Longest match wins", "Machine learning and ML", []string{"machine learning", "ml"}},
+		{"Symbol term", "I write C++ daily", []string{"c++"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MatchGlossaryTerms(tt.text, terms)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("MatchGlossaryTerms(%q) = %v, expected %v", tt.text, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAnnotateGlossaryTerms(t *testing.T) {
+	entries := []GlossaryEntry{
+		{Term: "API", Definition: `Application "Programming" Interface`},
+		{Term: "href", Definition: "Link target"},
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			"Plain text",
+			"Call the API",
+			`Call the <abbr class="glossary-term" data-term="api" title="Application &#34;Programming&#34; Interface">API</abbr>`,
+		},
+		{
+			"Attributes untouched",
+			`<p class="href">See href</p>`,
+			`<p class="href">See <abbr class="glossary-term" data-term="href" title="Link target">href</abbr></p>`,
+		},
+		{
+			"Code and links skipped",
+			`<code>API</code> <a href="/x">API</a>`,
+			`<code>API</code> <a href="/x">API</a>`,
+		},
+		{
+			"Nested skip tags",
+			`<pre><code>API</code></pre>api`,
+			`<pre><code>API</code></pre><abbr class="glossary-term" data-term="api" title="Application &#34;Programming&#34; Interface">api</abbr>`,
+		},
+		{"No entries match", "Nothing here", "Nothing here"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AnnotateGlossaryTerms(tt.content, entries)
+			if result != tt.expected {
+				t.Errorf("AnnotateGlossaryTerms(%q) =\n%s\nexpected\n%s", tt.content, result, tt.expected)
+			}
+		})
+	}
+
+	if result := AnnotateGlossaryTerms("API", nil); result != "API" {
+		t.Errorf("Expected content unchanged without entries, got %q", result)
+	}
+}
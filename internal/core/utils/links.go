@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MaxLinkLength is the longest URL kept by ParseLinks
+const MaxLinkLength = 2048
+
+// linkPattern matches the http and https links of plain text and markdown
+var linkPattern = regexp.MustCompile("(?i)https?://[^\\s<>\"'`]+")
+
+// ParseLinks returns the distinct links of content, normalized by NormalizeLink and sorted
+func ParseLinks(content string) []string {
+	seen := make(map[string]bool)
+	for _, match := range linkPattern.FindAllString(content, -1) {
+		if link, ok := NormalizeLink(trimLink(match)); ok {
+			seen[link] = true
+		}
+	}
+
+	links := make([]string, 0, len(seen))
+	for link := range seen {
+		links = append(links, link)
+	}
+	sort.Strings(links)
+	return links
+}
+
+// NormalizeLink returns the form under which two spellings of the same link compare equal:
+// lowercased scheme and host, no default port, fragment, tracking parameters or trailing
+// slash, and query parameters in key order
+func NormalizeLink(raw string) (string, bool) {
+	if len(raw) > MaxLinkLength {
+		return "", false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.User != nil {
+		return "", false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = strings.TrimRight(u.RawPath, "/")
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			if strings.HasPrefix(strings.ToLower(key), "utm_") {
+				query.Del(key)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	u.ForceQuery = false
+
+	return u.String(), true
+}
+
+// trimLink drops the punctuation ending a sentence or a markdown link from a matched link.
+// A closing parenthesis is kept when the link opened one, as in wiki URLs.
+func trimLink(link string) string {
+	for link != "" {
+		last := link[len(link)-1]
+		switch {
+		case strings.IndexByte(".,;:!?*_", last) >= 0:
+		case last == ')' && strings.Count(link, "(") < strings.Count(link, ")"):
+		case last == ']' && strings.Count(link, "[") < strings.Count(link, "]"):
+		default:
+			return link
+		}
+		link = link[:len(link)-1]
+	}
+	return link
+}
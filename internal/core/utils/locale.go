@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LocaleInfo describes how dates and times should be presented for a locale
+type LocaleInfo struct {
+	Code           string `json:"code"`
+	Language       string `json:"language"`
+	DateFormat     string `json:"dateFormat"`
+	TimeFormat     string `json:"timeFormat"`
+	FirstDayOfWeek int    `json:"firstDayOfWeek"` // 0 = Sunday, 1 = Monday
+}
+
+var supportedLocales = map[string]LocaleInfo{
+	"en-US": {Code: "en-US", Language: "en", DateFormat: "MM/DD/YYYY", TimeFormat: "12h", FirstDayOfWeek: 0},
+	"en-GB": {Code: "en-GB", Language: "en", DateFormat: "DD/MM/YYYY", TimeFormat: "24h", FirstDayOfWeek: 1},
+	"fr-FR": {Code: "fr-FR", Language: "fr", DateFormat: "DD/MM/YYYY", TimeFormat: "24h", FirstDayOfWeek: 1},
+	"de-DE": {Code: "de-DE", Language: "de", DateFormat: "DD.MM.YYYY", TimeFormat: "24h", FirstDayOfWeek: 1},
+	"es-ES": {Code: "es-ES", Language: "es", DateFormat: "DD/MM/YYYY", TimeFormat: "24h", FirstDayOfWeek: 1},
+	"it-IT": {Code: "it-IT", Language: "it", DateFormat: "DD/MM/YYYY", TimeFormat: "24h", FirstDayOfWeek: 1},
+	"pt-BR": {Code: "pt-BR", Language: "pt", DateFormat: "DD/MM/YYYY", TimeFormat: "24h", FirstDayOfWeek: 0},
+	"ja-JP": {Code: "ja-JP", Language: "ja", DateFormat: "YYYY/MM/DD", TimeFormat: "24h", FirstDayOfWeek: 0},
+	"zh-CN": {Code: "zh-CN", Language: "zh", DateFormat: "YYYY-MM-DD", TimeFormat: "24h", FirstDayOfWeek: 1},
+}
+
+// defaultLocaleByLanguage resolves language-only tags (e.g. "en") to a supported region
+var defaultLocaleByLanguage = map[string]string{
+	"en": "en-US",
+	"fr": "fr-FR",
+	"de": "de-DE",
+	"es": "es-ES",
+	"it": "it-IT",
+	"pt": "pt-BR",
+	"ja": "ja-JP",
+	"zh": "zh-CN",
+}
+
+// IsSupportedLocale reports whether the given locale code is known
+func IsSupportedLocale(code string) bool {
+	_, ok := supportedLocales[code]
+	return ok
+}
+
+// GetLocaleInfo returns the formatting metadata for a locale, falling back to en-US
+func GetLocaleInfo(code string) LocaleInfo {
+	if info, ok := supportedLocales[code]; ok {
+		return info
+	}
+	return supportedLocales["en-US"]
+}
+
+// SupportedLocaleCodes returns the sorted list of supported locale codes
+func SupportedLocaleCodes() []string {
+	codes := make([]string, 0, len(supportedLocales))
+	for code := range supportedLocales {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// NegotiateLocale picks the best supported locale for an Accept-Language header.
+// Exact matches win over language-only matches; fallback is returned when nothing matches.
+// Example: "fr-CH, fr;q=0.9, en;q=0.8" -> "fr-FR"
+func NegotiateLocale(acceptLanguage, fallback string) string {
+	type candidate struct {
+		tag     string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		tag := part
+		quality := 1.0
+		if idx := strings.Index(part, ";"); idx >= 0 {
+			tag = strings.TrimSpace(part[:idx])
+			param := strings.TrimSpace(part[idx+1:])
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if tag == "*" || quality <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{tag: tag, quality: quality})
+	}
+
+	// Stable sort keeps header order for equal quality values
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, c := range candidates {
+		if code := matchLocale(c.tag); code != "" {
+			return code
+		}
+	}
+
+	return fallback
+}
+
+func matchLocale(tag string) string {
+	tag = strings.ReplaceAll(tag, "_", "-")
+	for code := range supportedLocales {
+		if strings.EqualFold(code, tag) {
+			return code
+		}
+	}
+
+	language := strings.ToLower(strings.Split(tag, "-")[0])
+	if code, ok := defaultLocaleByLanguage[language]; ok {
+		return code
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package utils
+
+import "testing"
+
+func TestNegotiateLocale(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		fallback       string
+		expected       string
+	}{
+		{"Empty header uses fallback", "", "en-US", "en-US"},
+		{"Exact match", "fr-FR", "en-US", "fr-FR"},
+		{"Case insensitive match", "de-de", "en-US", "de-DE"},
+		{"Underscore separator", "pt_BR", "en-US", "pt-BR"},
+		{"Language only match", "ja", "en-US", "ja-JP"},
+		{"Region fallback to language", "fr-CH, en;q=0.5", "en-US", "fr-FR"},
+		{"Quality ordering", "en;q=0.3, de;q=0.9", "fr-FR", "de-DE"},
+		{"Exact region preferred", "en-GB,en;q=0.9", "en-US", "en-GB"},
+		{"Unsupported languages use fallback", "ko-KR, ru;q=0.8", "fr-FR", "fr-FR"},
+		{"Wildcard ignored", "*", "it-IT", "it-IT"},
+		{"Zero quality ignored", "es;q=0, zh;q=0.2", "en-US", "zh-CN"},
+		{"Malformed quality defaults to 1", "it;q=abc", "en-US", "it-IT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NegotiateLocale(tt.acceptLanguage, tt.fallback)
+			if result != tt.expected {
+				t.Errorf("NegotiateLocale(%q, %q) = %q, expected %q", tt.acceptLanguage, tt.fallback, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetLocaleInfo(t *testing.T) {
+	info := GetLocaleInfo("de-DE")
+	if info.DateFormat != "DD.MM.YYYY" {
+		t.Errorf("Expected German date format DD.MM.YYYY, got %s", info.DateFormat)
+	}
+	if info.FirstDayOfWeek != 1 {
+		t.Errorf("Expected Monday as first day of week, got %d", info.FirstDayOfWeek)
+	}
+
+	fallback := GetLocaleInfo("xx-XX")
+	if fallback.Code != "en-US" {
+		t.Errorf("Expected unknown locale to fall back to en-US, got %s", fallback.Code)
+	}
+}
+
+func TestSupportedLocaleCodes(t *testing.T) {
+	codes := SupportedLocaleCodes()
+	if len(codes) == 0 {
+		t.Fatal("Expected at least one supported locale")
+	}
+	for i := 1; i < len(codes); i++ {
+		if codes[i-1] > codes[i] {
+			t.Errorf("Expected sorted locale codes, got %v", codes)
+			break
+		}
+	}
+	for _, code := range codes {
+		if !IsSupportedLocale(code) {
+			t.Errorf("Expected %s to be supported", code)
+		}
+	}
+}
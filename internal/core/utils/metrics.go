@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// metricLinePattern matches a whole line such as "weight: 82.4" or "sleep: 7.5h"
+	metricLinePattern = regexp.MustCompile(`^\s*([A-Za-z][\w-]{0,63})\s*:\s*(-?\d+(?:\.\d+)?)\s*[A-Za-z%]*\s*$`)
+	// metricTagPattern matches inline tags such as "#metric mood=7"
+	metricTagPattern = regexp.MustCompile(`#metric\s+([A-Za-z][\w-]{0,63})\s*=\s*(-?\d+(?:\.\d+)?)`)
+)
+
+// Metric is a named numeric value declared in post content
+type Metric struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// ParseMetrics extracts metrics declared as "name: value" lines or "#metric name=value" tags.
+// Names are lowercased and fenced code blocks are ignored.
+func ParseMetrics(content string) []Metric {
+	var metrics []Metric
+	inFence := false
+
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if match := metricLinePattern.FindStringSubmatch(line); match != nil {
+			if value, err := strconv.ParseFloat(match[2], 64); err == nil {
+				metrics = append(metrics, Metric{Name: strings.ToLower(match[1]), Value: value})
+			}
+			continue
+		}
+
+		for _, match := range metricTagPattern.FindAllStringSubmatch(line, -1) {
+			if value, err := strconv.ParseFloat(match[2], 64); err == nil {
+				metrics = append(metrics, Metric{Name: strings.ToLower(match[1]), Value: value})
+			}
+		}
+	}
+
+	return metrics
+}
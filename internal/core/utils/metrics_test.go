@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []Metric
+	}{
+		{"No metrics", "Just a thought", nil},
+		{"Line metric", "weight: 82.4", []Metric{{"weight", 82.4}}},
+		{"Line metric with unit", "Sleep: 7.5h", []Metric{{"sleep", 7.5}}},
+		{"Negative value", "balance: -12", []Metric{{"balance", -12}}},
+		{"Tag metric", "Feeling ok #metric mood=7 today", []Metric{{"mood", 7}}},
+		{"Multiple tags", "#metric mood=7 #metric energy=3.5", []Metric{{"mood", 7}, {"energy", 3.5}}},
+		{"Mixed", "weight: 80\nNotes\n#metric mood=6", []Metric{{"weight", 80}, {"mood", 6}}},
+		{"Sentence is not a metric", "Meeting at: 10 with Bob", nil},
+		{"Time is not a metric", "start: 10:30", nil},
+		{"Fenced code ignored", "```\nweight: 90\n```\nweight: 80", []Metric{{"weight", 80}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseMetrics(tt.content)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ParseMetrics(%q) = %v, expected %v", tt.content, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"backthynk/internal/config"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// IsPublicIP reports whether ip is routable on the public internet, rejecting loopback,
+// private, link-local, multicast and unspecified addresses
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		isSharedAddress(ip))
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), not covered by IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isSharedAddress(ip net.IP) bool {
+	return sharedAddressSpace.Contains(ip)
+}
+
+// ValidateRemoteURL checks that rawURL is an absolute http or https URL
+func ValidateRemoteURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return nil, fmt.Errorf(config.ErrRemoteURLInvalid)
+	}
+	return parsed, nil
+}
+
+// NewPublicHTTPClient returns an HTTP client that only connects to public addresses. The
+// check runs on the resolved address of every connection, redirects included, so DNS names
+// pointing inside the network are refused too.
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublicIP(net.ParseIP(host)) {
+				return fmt.Errorf(config.ErrRemoteURLForbidden)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // A proxy would connect on our behalf, bypassing the address check
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= config.MaxRemoteFetchRedirects {
+				return fmt.Errorf(config.ErrTooManyRedirects)
+			}
+			if _, err := ValidateRemoteURL(req.URL.String()); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+}
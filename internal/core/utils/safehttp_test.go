@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPublicIP(net.ParseIP(tt.ip)); got != tt.expected {
+			t.Errorf("IsPublicIP(%s) = %v, expected %v", tt.ip, got, tt.expected)
+		}
+	}
+	if IsPublicIP(nil) {
+		t.Error("Expected nil IP not to be public")
+	}
+}
+
+func TestValidateRemoteURL(t *testing.T) {
+	valid := []string{"http://example.com/a.png", "https://example.com:8443/file"}
+	invalid := []string{"", "example.com/a.png", "ftp://example.com/a", "file:///etc/passwd", "https:///path"}
+
+	for _, rawURL := range valid {
+		if _, err := ValidateRemoteURL(rawURL); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", rawURL, err)
+		}
+	}
+	for _, rawURL := range invalid {
+		if _, err := ValidateRemoteURL(rawURL); err == nil {
+			t.Errorf("Expected %q to be invalid", rawURL)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SecretMatch locates a likely secret in content
+type SecretMatch struct {
+	Kind  string `json:"kind"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+var secretPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws_?secret_?access_?key\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}\b`)},
+	{"github-token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"bearer-token", regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{20,}=*`)},
+}
+
+// FindSecrets returns the likely secrets of content ordered by position. Overlapping matches
+// are merged into the earliest one.
+func FindSecrets(content string) []SecretMatch {
+	var matches []SecretMatch
+	for _, p := range secretPatterns {
+		for _, loc := range p.pattern.FindAllStringIndex(content, -1) {
+			matches = append(matches, SecretMatch{Kind: p.kind, Start: loc[0], End: loc[1]})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+
+	merged := matches[:0]
+	for _, m := range matches {
+		if n := len(merged); n > 0 && m.Start < merged[n-1].End {
+			if m.End > merged[n-1].End {
+				merged[n-1].End = m.End
+			}
+			continue
+		}
+		merged = append(merged, m)
+	}
+	return merged
+}
+
+// RedactSecrets replaces each match with a [REDACTED:kind] placeholder
+func RedactSecrets(content string, matches []SecretMatch) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(content[last:m.Start])
+		b.WriteString("[REDACTED:" + m.Kind + "]")
+		last = m.End
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// MaskSecret keeps the first characters of a secret so it can be recognized without being exposed
+func MaskSecret(secret string) string {
+	const visible = 4
+	runes := []rune(strings.TrimSpace(secret))
+	if len(runes) <= visible {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:visible]) + strings.Repeat("*", min(len(runes)-visible, 12))
+}
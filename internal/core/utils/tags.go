@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxTagLength is the longest accepted hashtag, without the leading "#"
+const MaxTagLength = 64
+
+// tagSpan locates a hashtag within a line; start points at the "#"
+type tagSpan struct {
+	start int
+	end   int
+	tag   string // lowercased, without "#"
+}
+
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '/'
+}
+
+// findTags returns the hashtags of a single line. A tag starts with a letter and must be
+// preceded by whitespace, "(" or the start of the line, so "a#b" and "# Heading" are not tags.
+// "#metric name=value" declarations belong to metrics and are skipped.
+func findTags(line string) []tagSpan {
+	var spans []tagSpan
+
+	for i := 0; i < len(line); i++ {
+		if line[i] != '#' {
+			continue
+		}
+		if i > 0 {
+			prev, _ := utf8.DecodeLastRuneInString(line[:i])
+			if !unicode.IsSpace(prev) && prev != '(' {
+				continue
+			}
+		}
+
+		first, _ := utf8.DecodeRuneInString(line[i+1:])
+		if !unicode.IsLetter(first) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(line) {
+			r, size := utf8.DecodeRuneInString(line[end:])
+			if !isTagRune(r) {
+				break
+			}
+			end += size
+		}
+		// Trailing separators are punctuation, not part of the tag
+		for end > i+1 && (line[end-1] == '-' || line[end-1] == '/') {
+			end--
+		}
+
+		tag := strings.ToLower(line[i+1 : end])
+		if tag == "metric" {
+			if loc := metricTagPattern.FindStringIndex(line[i:]); loc != nil && loc[0] == 0 {
+				i = end - 1
+				continue
+			}
+		}
+		if utf8.RuneCountInString(tag) <= MaxTagLength {
+			spans = append(spans, tagSpan{start: i, end: end, tag: tag})
+		}
+		i = end - 1
+	}
+
+	return spans
+}
+
+// rewriteTagLines applies fn to every line outside fenced code blocks that contains hashtags
+func rewriteTagLines(content string, fn func(line string, spans []tagSpan) string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	changed := false
+	inFence := false
+
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		spans := findTags(line)
+		if len(spans) == 0 {
+			continue
+		}
+		if updated := fn(line, spans); updated != line {
+			lines[i] = updated
+			changed = true
+		}
+	}
+
+	return strings.Join(lines, "\n"), changed
+}
+
+// NormalizeTag lowercases a tag and strips its leading "#"; ok is false when it is not a valid tag
+func NormalizeTag(tag string) (string, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	spans := findTags("#" + tag)
+	if len(spans) != 1 || spans[0].end != len(tag)+1 {
+		return "", false
+	}
+	return spans[0].tag, true
+}
+
+// ParseTags returns the distinct lowercased hashtags of content, sorted, ignoring fenced code blocks
+func ParseTags(content string) []string {
+	seen := make(map[string]bool)
+	rewriteTagLines(content, func(line string, spans []tagSpan) string {
+		for _, span := range spans {
+			seen[span.tag] = true
+		}
+		return line
+	})
+
+	tags := make([]string, 0, len(seen))
+	for tag := range seen {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// ReplaceTag renames every occurrence of the hashtag from to to; tags are matched case-insensitively
+func ReplaceTag(content, from, to string) (string, bool) {
+	return rewriteTagLines(content, func(line string, spans []tagSpan) string {
+		var b strings.Builder
+		last := 0
+		for _, span := range spans {
+			if span.tag != from {
+				continue
+			}
+			b.WriteString(line[last:span.start])
+			b.WriteString("#" + to)
+			last = span.end
+		}
+		b.WriteString(line[last:])
+		return b.String()
+	})
+}
+
+// RemoveTag deletes every occurrence of the hashtag; lines left empty by the removal are dropped
+func RemoveTag(content, tag string) (string, bool) {
+	const removedLine = "\x00"
+
+	updated, changed := rewriteTagLines(content, func(line string, spans []tagSpan) string {
+		var b strings.Builder
+		last := 0
+		for _, span := range spans {
+			if span.tag != tag {
+				continue
+			}
+			b.WriteString(strings.TrimRight(line[last:span.start], " \t"))
+			last = span.end
+		}
+		if last == 0 {
+			return line
+		}
+
+		rest := line[last:]
+		if b.Len() == 0 {
+			rest = strings.TrimLeft(rest, " \t")
+		}
+		b.WriteString(rest)
+
+		result := b.String()
+		if strings.TrimSpace(result) == "" {
+			return removedLine
+		}
+		return result
+	})
+	if !changed {
+		return content, false
+	}
+
+	lines := strings.Split(updated, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line != removedLine {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimRight(strings.Join(kept, "\n"), "\n"), true
+}
+
+// AddTag appends the hashtag to content unless it is already present. It joins a trailing
+// line made only of hashtags, otherwise it goes on a new paragraph.
+func AddTag(content, tag string) (string, bool) {
+	for _, existing := range ParseTags(content) {
+		if existing == tag {
+			return content, false
+		}
+	}
+
+	trimmed := strings.TrimRight(content, " \t\n")
+	if trimmed == "" {
+		return "#" + tag, true
+	}
+
+	lastLine := trimmed[strings.LastIndex(trimmed, "\n")+1:]
+	if spans := findTags(lastLine); len(spans) > 0 && isTagOnlyLine(lastLine, spans) {
+		return trimmed + " #" + tag, true
+	}
+	return trimmed + "\n\n#" + tag, true
+}
+
+func isTagOnlyLine(line string, spans []tagSpan) bool {
+	last := 0
+	for _, span := range spans {
+		if strings.TrimSpace(line[last:span.start]) != "" {
+			return false
+		}
+		last = span.end
+	}
+	return strings.TrimSpace(line[last:]) == ""
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"No tags", "Just some text", []string{}},
+		{"Simple tags", "Reading #Books and #go-lang", []string{"books", "go-lang"}},
+		{"Duplicates collapse", "#idea then #IDEA again", []string{"idea"}},
+		{"Nested tag", "#projects/backthynk done", []string{"projects/backthynk"}},
+		{"Trailing punctuation", "Done with #work. Next: (#home)", []string{"home", "work"}},
+		{"Not tags", "# Heading\nissue #42\nemail a#b\nurl.com/#anchor", []string{}},
+		{"Unicode", "Café #réunion", []string{"réunion"}},
+		{"Metric declaration skipped", "#metric mood=7 #metric", []string{"metric"}},
+		{"Fenced code ignored", "```\n#code\n```\n#real", []string{"real"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTags(tt.content); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ParseTags(%q) = %v, expected %v", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{"#Books", "books", true},
+		{"travel/2024", "travel/2024", true},
+		{"", "", false},
+		{"42", "", false},
+		{"two words", "", false},
+		{"bad!", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeTag(tt.input)
+		if got != tt.expected || ok != tt.valid {
+			t.Errorf("NormalizeTag(%q) = (%q, %v), expected (%q, %v)", tt.input, got, ok, tt.expected, tt.valid)
+		}
+	}
+}
+
+func TestReplaceTag(t *testing.T) {
+	content := "#Todo buy milk #todo-later\n```\n#todo\n```\n(#todo)"
+	updated, changed := ReplaceTag(content, "todo", "tasks")
+
+	if !changed {
+		t.Fatal("Expected content to change")
+	}
+	expected := "#tasks buy milk #todo-later\n```\n#todo\n```\n(#tasks)"
+	if updated != expected {
+		t.Errorf("Unexpected content: %q", updated)
+	}
+
+	if _, changed := ReplaceTag(content, "missing", "tasks"); changed {
+		t.Error("Expected no change for a missing tag")
+	}
+}
+
+func TestRemoveTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"Inline tag", "Read #draft this", "Read this"},
+		{"Leading tag", "#draft Read this", "Read this"},
+		{"Tag-only line dropped", "Note\n\n#draft", "Note"},
+		{"Other tags kept", "Note\n\n#draft #keep", "Note\n\n#keep"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, changed := RemoveTag(tt.content, "draft")
+			if !changed || updated != tt.expected {
+				t.Errorf("RemoveTag(%q) = (%q, %v), expected %q", tt.content, updated, changed, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAddTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		changed  bool
+	}{
+		{"New paragraph", "Some note", "Some note\n\n#review", true},
+		{"Joins tag line", "Some note\n\n#work", "Some note\n\n#work #review", true},
+		{"Already present", "Some #Review note", "Some #Review note", false},
+		{"Empty content", "", "#review", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, changed := AddTag(tt.content, "review")
+			if updated != tt.expected || changed != tt.changed {
+				t.Errorf("AddTag(%q) = (%q, %v), expected (%q, %v)", tt.content, updated, changed, tt.expected, tt.changed)
+			}
+		})
+	}
+}
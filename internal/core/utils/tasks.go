@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// taskItemPattern matches markdown checklist items such as "- [ ] todo" or "* [x] done"
+var taskItemPattern = regexp.MustCompile(`^(\s*[-*+]\s+\[)([ xX])\](\s|$)`)
+
+// TaskItem is a single checklist entry found in post content
+type TaskItem struct {
+	Index int    `json:"index"` // Position among the tasks of the post, starting at 0
+	Line  int    `json:"line"`  // 0-based line number in the content
+	Done  bool   `json:"done"`
+	Text  string `json:"text"`
+}
+
+// ParseTasks extracts checklist items from content, ignoring fenced code blocks
+func ParseTasks(content string) []TaskItem {
+	var tasks []TaskItem
+	inFence := false
+
+	for i, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		match := taskItemPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		tasks = append(tasks, TaskItem{
+			Index: len(tasks),
+			Line:  i,
+			Done:  match[2] != " ",
+			Text:  strings.TrimSpace(line[len(match[0]):]),
+		})
+	}
+
+	return tasks
+}
+
+// CountTasks returns the total and completed number of checklist items in content
+func CountTasks(content string) (total, done int) {
+	for _, task := range ParseTasks(content) {
+		total++
+		if task.Done {
+			done++
+		}
+	}
+	return total, done
+}
+
+// ToggleTask flips the checkbox of the task at index and returns the updated content
+// and the new state of the task. found is false when index does not exist.
+func ToggleTask(content string, index int) (updated string, done bool, found bool) {
+	tasks := ParseTasks(content)
+	if index < 0 || index >= len(tasks) {
+		return content, false, false
+	}
+
+	task := tasks[index]
+	lines := strings.Split(content, "\n")
+	line := lines[task.Line]
+
+	// The checkbox character sits right after the "[" captured by the first group
+	pos := len(taskItemPattern.FindStringSubmatch(line)[1])
+	mark := "x"
+	if task.Done {
+		mark = " "
+	}
+	lines[task.Line] = line[:pos] + mark + line[pos+1:]
+
+	return strings.Join(lines, "\n"), !task.Done, true
+}
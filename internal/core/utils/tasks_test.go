@@ -0,0 +1,66 @@
+package utils
+
+import "testing"
+
+func TestCountTasks(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedTotal int
+		expectedDone  int
+	}{
+		{"No tasks", "Just some text", 0, 0},
+		{"Open and done", "- [ ] one\n- [x] two\n- [X] three", 3, 2},
+		{"Other bullets", "* [ ] star\n+ [x] plus", 2, 1},
+		{"Indented task", "  - [ ] nested", 1, 0},
+		{"Empty task text", "- [ ]", 1, 0},
+		{"Not a task", "- [] missing space\n-[ ] no space\n[ ] no bullet", 0, 0},
+		{"Fenced code ignored", "```\n- [ ] code\n```\n- [ ] real", 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, done := CountTasks(tt.content)
+			if total != tt.expectedTotal || done != tt.expectedDone {
+				t.Errorf("CountTasks(%q) = (%d, %d), expected (%d, %d)", tt.content, total, done, tt.expectedTotal, tt.expectedDone)
+			}
+		})
+	}
+}
+
+func TestParseTasksText(t *testing.T) {
+	tasks := ParseTasks("Intro\n- [ ] buy milk\n- [x] call Bob")
+	if len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(tasks))
+	}
+	if tasks[0].Text != "buy milk" || tasks[0].Line != 1 || tasks[0].Done {
+		t.Errorf("Unexpected first task: %+v", tasks[0])
+	}
+	if tasks[1].Index != 1 || !tasks[1].Done {
+		t.Errorf("Unexpected second task: %+v", tasks[1])
+	}
+}
+
+func TestToggleTask(t *testing.T) {
+	content := "Todo\n- [ ] first\n- [x] second"
+
+	updated, done, found := ToggleTask(content, 0)
+	if !found || !done {
+		t.Fatalf("Expected task 0 to be found and checked, got found=%v done=%v", found, done)
+	}
+	if updated != "Todo\n- [x] first\n- [x] second" {
+		t.Errorf("Unexpected content after toggle: %q", updated)
+	}
+
+	updated, done, found = ToggleTask(updated, 1)
+	if !found || done {
+		t.Fatalf("Expected task 1 to be found and unchecked, got found=%v done=%v", found, done)
+	}
+	if updated != "Todo\n- [x] first\n- [ ] second" {
+		t.Errorf("Unexpected content after toggle: %q", updated)
+	}
+
+	if _, _, found := ToggleTask(content, 5); found {
+		t.Error("Expected out of range index to be reported as not found")
+	}
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// urlPattern matches links, which carry little meaning for content similarity
+var urlPattern = regexp.MustCompile(`https?://\S+`)
+
+// stopWords are frequent English words ignored when comparing content
+var stopWords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "an": true, "and": true,
+	"any": true, "are": true, "as": true, "at": true, "be": true, "been": true, "but": true,
+	"by": true, "can": true, "could": true, "did": true, "do": true, "does": true, "for": true,
+	"from": true, "had": true, "has": true, "have": true, "he": true, "her": true, "his": true,
+	"how": true, "if": true, "in": true, "into": true, "is": true, "it": true, "its": true,
+	"just": true, "me": true, "more": true, "my": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "our": true, "out": true, "she": true, "so": true, "some": true,
+	"than": true, "that": true, "the": true, "their": true, "them": true, "then": true,
+	"there": true, "these": true, "they": true, "this": true, "to": true, "up": true, "us": true,
+	"was": true, "we": true, "were": true, "what": true, "when": true, "which": true, "who": true,
+	"will": true, "with": true, "would": true, "you": true, "your": true,
+}
+
+// ContentTerms returns how often each word of content occurs. Words are lowercased runs of
+// letters and digits of at least two characters; links and stop words are skipped.
+func ContentTerms(content string) map[string]int {
+	terms := make(map[string]int)
+	content = urlPattern.ReplaceAllString(content, " ")
+
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if len([]rune(word)) < 2 || stopWords[word] {
+			continue
+		}
+		terms[word]++
+	}
+
+	return terms
+}
+
+// CountWords returns the number of words of content, stop words included; links are skipped
+func CountWords(content string) int {
+	content = urlPattern.ReplaceAllString(content, " ")
+	return len(strings.FieldsFunc(content, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}))
+}
+
+// Snippet shortens content to its first length characters on a single line
+func Snippet(content string, length int) string {
+	text := strings.Join(strings.Fields(content), " ")
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:length])) + "…"
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContentTerms(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]int
+	}{
+		{"Empty", "", map[string]int{}},
+		{"Counts and lowercases", "Kafka lag, kafka LAG and more lag", map[string]int{"kafka": 2, "lag": 3}},
+		{"Stop words and short words", "it is a b c of the db", map[string]int{"db": 1}},
+		{"Links skipped", "see https://example.com/kafka-docs for kafka", map[string]int{"see": 1, "kafka": 1}},
+		{"Markdown and tags", "## Deploy #release\n- [x] rollout", map[string]int{"deploy": 1, "release": 1, "rollout": 1}},
+		{"Unicode letters", "Café crème", map[string]int{"café": 1, "crème": 1}},
+		{"Digits kept", "v2 release 2024", map[string]int{"v2": 1, "release": 1, "2024": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ContentTerms(tt.content)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ContentTerms(%q) = %v, expected %v", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected int
+	}{
+		{"", 0},
+		{"Kafka lag, kafka LAG and more lag", 7},
+		{"it's a short one", 4},
+		{"see https://example.com/kafka-docs for kafka", 3},
+		{"## Deploy #release\n- [x] rollout", 4},
+	}
+
+	for _, tt := range tests {
+		if got := CountWords(tt.content); got != tt.expected {
+			t.Errorf("CountWords(%q) = %d, expected %d", tt.content, got, tt.expected)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		length   int
+		expected string
+	}{
+		{"Short", "kafka lag", 20, "kafka lag"},
+		{"Whitespace collapsed", "# Title\n\n  body  text", 40, "# Title body text"},
+		{"Truncated", "consumer lag spiked", 9, "consumer…"},
+		{"Unicode", "crème brûlée", 5, "crème…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Snippet(tt.content, tt.length); got != tt.expected {
+				t.Errorf("Snippet(%q, %d) = %q, expected %q", tt.content, tt.length, got, tt.expected)
+			}
+		})
+	}
+}
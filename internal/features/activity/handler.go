@@ -3,8 +3,11 @@ package activity
 import (
 	"backthynk/internal/config"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
@@ -23,9 +26,13 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	}
 	
 	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/activity/compare", h.ComparePeriods).Methods("GET")
 	api.HandleFunc("/activity/{id}", h.GetActivityPeriod).Methods("GET")
 }
 
+// GetActivityPeriod handles GET /api/activity/{id}
+// Responses carry the data version of the space as ETag and X-Data-Version; clients holding
+// that version get 304 with If-None-Match, or 204 with the since_version query parameter.
 func (h *Handler) GetActivityPeriod(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	spaceID, err := strconv.Atoi(vars["id"])
@@ -46,20 +53,20 @@ func (h *Handler) GetActivityPeriod(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	
-	// Get period months from options config, fallback to query param or default
-	periodMonths := 4 // Default fallback
-	options := config.GetOptionsConfig()
-	if options != nil && options.Features.Activity.PeriodMonths > 0 {
-		periodMonths = options.Features.Activity.PeriodMonths
-	}
-
-	// Allow override via query parameter
-	if monthsStr := query.Get("period_months"); monthsStr != "" {
-		if m, err := strconv.Atoi(monthsStr); err == nil && m > 0 {
-			periodMonths = m
+	periodMonths := periodMonths(query)
+	
+	// Per-space breakdown only applies to the global heatmap
+	breakdown := spaceID == 0 && query.Get("breakdown") == "true"
+	breakdownTop := config.DefaultActivityBreakdownTop
+	if topStr := query.Get("top"); breakdown && topStr != "" {
+		t, err := strconv.Atoi(topStr)
+		if err != nil || t < 1 || t > config.MaxActivityBreakdownTop {
+			http.Error(w, config.ErrInvalidBreakdownTop, http.StatusBadRequest)
+			return
 		}
+		breakdownTop = t
 	}
-	
+
 	req := ActivityPeriodRequest{
 		SpaceID:   spaceID,
 		Recursive:    recursive,
@@ -67,8 +74,19 @@ func (h *Handler) GetActivityPeriod(w http.ResponseWriter, r *http.Request) {
 		EndDate:      query.Get("end_date"),
 		Period:       period,
 		PeriodMonths: periodMonths,
+		Breakdown:    breakdown,
+		BreakdownTop: breakdownTop,
+		Hidden:       h.service.access.Hidden(r.Context()),
+	}
+	if spaceID != 0 && req.Hidden[spaceID] {
+		http.Error(w, config.ErrSpaceNotFound, http.StatusNotFound)
+		return
 	}
 	
+	if h.notModified(w, r, spaceID) {
+		return
+	}
+
 	response, err := h.service.GetActivityPeriod(req)
 	if err != nil {
 		http.Error(w, config.ErrFailedToGetActivity+err.Error(), http.StatusInternalServerError)
@@ -77,4 +95,103 @@ func (h *Handler) GetActivityPeriod(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
+}
+// ComparePeriods handles GET /api/spaces/{id}/activity/compare
+// Space 0 compares the activity of every space.
+// Query parameters:
+// - period_a: periods back from the current one (default: 0, the current period)
+// - period_b: periods back from the current one (default: 1, the previous period)
+// - recursive: include descendant spaces (default: false)
+// - period_months: length of a period (default: the activity setting)
+// - since_version: data version the client already has, answered with 204 when unchanged
+func (h *Handler) ComparePeriods(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	periods := [2]int{0, 1}
+	for i, name := range []string{"period_a", "period_b"} {
+		if value := query.Get(name); value != "" {
+			p, err := strconv.Atoi(value)
+			if err != nil || p < 0 {
+				http.Error(w, config.ErrInvalidComparePeriod, http.StatusBadRequest)
+				return
+			}
+			periods[i] = p
+		}
+	}
+
+	if h.notModified(w, r, spaceID) {
+		return
+	}
+
+	comparison, err := h.service.ComparePeriods(spaceID, query.Get("recursive") == "true", periods[0], periods[1], periodMonths(query), h.service.access.Hidden(r.Context()))
+	if err != nil {
+		switch err.Error() {
+		case config.ErrSpaceNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case config.ErrInvalidComparePeriod:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, config.ErrFailedToGetActivity+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comparison)
+}
+
+// periodMonths reads the period length from the query, falling back to the activity setting
+func periodMonths(query url.Values) int {
+	// Get period months from options config, fallback to query param or default
+	months := 4 // Default fallback
+	options := config.GetOptionsConfig()
+	if options != nil && options.Features.Activity.PeriodMonths > 0 {
+		months = options.Features.Activity.PeriodMonths
+	}
+
+	// Allow override via query parameter
+	if monthsStr := query.Get("period_months"); monthsStr != "" {
+		if m, err := strconv.Atoi(monthsStr); err == nil && m > 0 {
+			months = m
+		}
+	}
+	return months
+}
+
+// notModified sets the data version headers of an activity response and reports whether it
+// already answered: 204 when since_version matches the data version, 304 when If-None-Match
+// does, 400 when since_version is not a version
+func (h *Handler) notModified(w http.ResponseWriter, r *http.Request, spaceID int) bool {
+	sinceStr := r.URL.Query().Get("since_version")
+	var since int64
+	if sinceStr != "" {
+		var err error
+		if since, err = strconv.ParseInt(sinceStr, 10, 64); err != nil || since <= 0 {
+			http.Error(w, config.ErrInvalidSinceVersion, http.StatusBadRequest)
+			return true
+		}
+	}
+
+	version := h.service.DataVersion(spaceID)
+	etag := fmt.Sprintf(`"%d"`, version)
+	w.Header().Set("ETag", etag)
+	w.Header().Set(config.DataVersionHeader, strconv.FormatInt(version, 10))
+
+	if sinceStr != "" && since == version {
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -1,10 +1,15 @@
 package activity
 
 import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -386,4 +391,193 @@ func containsSubstring(s, substr string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+func TestGetActivityPeriodBreakdownParameters(t *testing.T) {
+	service := &Service{
+		enabled:  true,
+		activity: make(map[int]*SpaceActivity),
+	}
+	service.updateActivity(1, time.Now().Unix()*1000, 2)
+
+	handler := NewHandler(service)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	testCases := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectTop      bool
+	}{
+		{"Global breakdown", "/api/activity/0?breakdown=true", http.StatusOK, true},
+		{"Global breakdown with top", "/api/activity/0?breakdown=true&top=3", http.StatusOK, true},
+		{"Top without breakdown is ignored", "/api/activity/0?top=abc", http.StatusOK, false},
+		{"Breakdown ignored for a space", "/api/activity/1?breakdown=true&top=abc", http.StatusOK, false},
+		{"Invalid top", "/api/activity/0?breakdown=true&top=abc", http.StatusBadRequest, false},
+		{"Top too small", "/api/activity/0?breakdown=true&top=0", http.StatusBadRequest, false},
+		{"Top too large", "/api/activity/0?breakdown=true&top=21", http.StatusBadRequest, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.url, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response ActivityPeriodResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if tc.expectTop && len(response.TopSpaces) != 1 {
+				t.Errorf("Expected 1 top space, got %d", len(response.TopSpaces))
+			}
+			if !tc.expectTop && len(response.TopSpaces) != 0 {
+				t.Errorf("Expected no top spaces, got %d", len(response.TopSpaces))
+			}
+		})
+	}
+}
+
+func TestComparePeriods(t *testing.T) {
+	catCache := cache.NewSpaceCache()
+	catCache.Set(&models.Space{ID: 1, Name: "Work"})
+	service := &Service{
+		enabled:  true,
+		catCache: catCache,
+		activity: make(map[int]*SpaceActivity),
+	}
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	now := time.Now().UTC()
+	lastMonth := time.Date(now.Year(), now.Month()-1, 15, 12, 0, 0, 0, time.UTC)
+	post := func(at time.Time, count int) {
+		for i := 0; i < count; i++ {
+			service.HandleEvent(events.Event{
+				Type: events.PostCreated,
+				Data: events.PostEvent{SpaceID: 1, Timestamp: at.UnixMilli()},
+			})
+		}
+	}
+	post(now, 4)
+	post(lastMonth, 2)
+	post(lastMonth.AddDate(0, 0, 1), 1)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/api/spaces/1/activity/compare?period_a=0&period_b=1&period_months=1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var comparison ActivityComparison
+	json.Unmarshal(w.Body.Bytes(), &comparison)
+
+	if comparison.PeriodA.Stats != (PeriodStats{TotalPosts: 4, ActiveDays: 1, MaxDayActivity: 4}) {
+		t.Errorf("Unexpected current period stats %+v", comparison.PeriodA.Stats)
+	}
+	if comparison.PeriodB.Stats != (PeriodStats{TotalPosts: 3, ActiveDays: 2, MaxDayActivity: 2}) {
+		t.Errorf("Unexpected previous period stats %+v", comparison.PeriodB.Stats)
+	}
+	if comparison.PeriodB.StartDate != lastMonth.Format("2006-01")+"-01" {
+		t.Errorf("Expected the previous period to start on the first of last month, got %s", comparison.PeriodB.StartDate)
+	}
+	deltas := comparison.Deltas
+	if deltas.TotalPosts == nil || *deltas.TotalPosts != 33.3 ||
+		deltas.ActiveDays == nil || *deltas.ActiveDays != -50 ||
+		deltas.MaxDayActivity == nil || *deltas.MaxDayActivity != 100 {
+		t.Errorf("Unexpected deltas %s", w.Body.String())
+	}
+
+	// Nothing to compare with in an empty period
+	w = get("/api/spaces/1/activity/compare?period_b=6&period_months=1")
+	comparison = ActivityComparison{}
+	json.Unmarshal(w.Body.Bytes(), &comparison)
+	if comparison.PeriodA.Period != 0 || comparison.Deltas.TotalPosts != nil {
+		t.Errorf("Expected null deltas against an empty period, got %s", w.Body.String())
+	}
+
+	if w = get("/api/spaces/0/activity/compare?period_months=1"); w.Code != http.StatusOK {
+		t.Errorf("Expected global comparison to succeed, got %d", w.Code)
+	}
+	if w = get("/api/spaces/9/activity/compare"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown space, got %d", w.Code)
+	}
+	for _, query := range []string{"period_a=-1", "period_b=x"} {
+		if w = get("/api/spaces/1/activity/compare?" + query); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestActivityConditionalFetch(t *testing.T) {
+	catCache := cache.NewSpaceCache()
+	catCache.Set(&models.Space{ID: 1, Name: "Work"})
+	service := &Service{
+		enabled:  true,
+		catCache: catCache,
+		activity: make(map[int]*SpaceActivity),
+		versions: newDataVersions(time.Now),
+	}
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/api/activity/1", "")
+	etag := w.Header().Get("ETag")
+	version := w.Header().Get(config.DataVersionHeader)
+	if w.Code != http.StatusOK || etag != `"`+version+`"` {
+		t.Fatalf("Expected 200 with the data version as ETag, got %d with %q and %q", w.Code, etag, version)
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{"Matching ETag", "/api/activity/1", etag, http.StatusNotModified},
+		{"Weak ETag in a list", "/api/activity/1", `"1", W/` + etag, http.StatusNotModified},
+		{"Other ETag", "/api/activity/1", `"1"`, http.StatusOK},
+		{"Current since_version", "/api/activity/1?since_version=" + version, "", http.StatusNoContent},
+		{"Older since_version", "/api/activity/1?since_version=1", "", http.StatusOK},
+		{"Invalid since_version", "/api/activity/1?since_version=abc", "", http.StatusBadRequest},
+		{"Comparison since_version", "/api/spaces/1/activity/compare?since_version=" + version, "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := get(tt.path, tt.ifNoneMatch); w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	service.HandleEvent(events.Event{
+		Type: events.PostCreated,
+		Data: events.PostEvent{SpaceID: 1, Timestamp: time.Now().UnixMilli()},
+	})
+	if w := get("/api/activity/1?since_version="+version, ""); w.Code != http.StatusOK || w.Header().Get(config.DataVersionHeader) == version {
+		t.Errorf("Expected new data after a post, got %d with version %s", w.Code, w.Header().Get(config.DataVersionHeader))
+	}
+}
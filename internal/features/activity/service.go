@@ -1,27 +1,52 @@
 package activity
 
 import (
+	"backthynk/internal/config"
 	"backthynk/internal/core/cache"
 	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
 	"backthynk/internal/storage"
+	"fmt"
+	"math"
+	"sort"
 	"sync"
 	"time"
+	"unsafe"
 )
 
 type Service struct {
 	db       *storage.DB
 	catCache *cache.SpaceCache
 	activity map[int]*SpaceActivity // spaceID -> activity
+	versions *dataVersions
+	access   *services.SpaceAccess
 	mu       sync.RWMutex
 	enabled  bool
 }
 
 type SpaceActivity struct {
-	Days       map[string]int     // YYYY-MM-DD -> count
-	Recursive  map[string]int     // Recursive activity
-	Timestamps map[string][]int64 // YYYY-MM-DD -> list of timestamps for that day
-	Stats      ActivityStats
-	mu         sync.RWMutex
+	Days      map[string]int       // YYYY-MM-DD -> count
+	Recursive map[string]int       // Recursive activity
+	Bounds    map[string]DayBounds // YYYY-MM-DD -> earliest and latest post of that day
+	Stats     ActivityStats
+	mu        sync.RWMutex
+}
+
+// DayBounds are the earliest and latest post times of a day. The timestamps in between are
+// not kept in memory; they are read back from the database when a boundary post goes away.
+type DayBounds struct {
+	First int64
+	Last  int64
+}
+
+func newSpaceActivity() *SpaceActivity {
+	return &SpaceActivity{
+		Days:      make(map[string]int),
+		Recursive: make(map[string]int),
+		Bounds:    make(map[string]DayBounds),
+		Stats:     ActivityStats{},
+	}
 }
 
 type ActivityStats struct {
@@ -41,10 +66,16 @@ func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Servi
 		db:       db,
 		catCache: catCache,
 		activity: make(map[int]*SpaceActivity),
+		versions: newDataVersions(time.Now),
 		enabled:  enabled,
 	}
 }
 
+// SetSpaceAccess makes activity leave out the spaces hidden from the viewer of a request
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
 func (s *Service) Initialize() error {
 	if !s.enabled {
 		return nil
@@ -81,17 +112,12 @@ func (s *Service) refreshSpace(spaceID int, posts []storage.PostData) {
 		return
 	}
 
-	activity := &SpaceActivity{
-		Days:       make(map[string]int),
-		Recursive:  make(map[string]int),
-		Timestamps: make(map[string][]int64),
-		Stats:      ActivityStats{},
-	}
+	activity := newSpaceActivity()
 
 	for _, post := range posts {
 		date := time.Unix(post.Created/1000, 0).Format("2006-01-02")
 		activity.Days[date]++
-		activity.Timestamps[date] = append(activity.Timestamps[date], post.Created)
+		activity.Bounds[date] = widenBounds(activity.Bounds[date], post.Created)
 		activity.Stats.TotalPosts++
 
 		if activity.Stats.FirstPostTime == 0 || post.Created < activity.Stats.FirstPostTime {
@@ -126,12 +152,7 @@ func (s *Service) calculateRecursiveActivity(spaceID int) {
 	s.mu.RUnlock()
 
 	if !ok {
-		activity = &SpaceActivity{
-			Days:       make(map[string]int),
-			Recursive:  make(map[string]int),
-			Timestamps: make(map[string][]int64),
-			Stats:      ActivityStats{},
-		}
+		activity = newSpaceActivity()
 		s.mu.Lock()
 		s.activity[spaceID] = activity
 		s.mu.Unlock()
@@ -201,12 +222,7 @@ func (s *Service) updateActivity(spaceID int, timestamp int64, delta int) {
 	s.mu.Lock()
 	activity, ok := s.activity[spaceID]
 	if !ok {
-		activity = &SpaceActivity{
-			Days:       make(map[string]int),
-			Recursive:  make(map[string]int),
-			Timestamps: make(map[string][]int64),
-			Stats:      ActivityStats{},
-		}
+		activity = newSpaceActivity()
 		s.activity[spaceID] = activity
 	}
 	s.mu.Unlock()
@@ -219,37 +235,13 @@ func (s *Service) updateActivity(spaceID int, timestamp int64, delta int) {
 
 	if newCount <= 0 {
 		delete(activity.Days, date)
-		delete(activity.Timestamps, date)
+		delete(activity.Bounds, date)
 	} else {
 		activity.Days[date] = newCount
-	}
-
-	// Update timestamp tracking
-	if delta > 0 {
-		// Adding posts
-		for i := 0; i < delta; i++ {
-			activity.Timestamps[date] = append(activity.Timestamps[date], timestamp)
-		}
-	} else if delta < 0 {
-		// Removing posts
-		timestamps := activity.Timestamps[date]
-		// Remove matching timestamps (most recent occurrences)
-		toRemove := -delta
-		for i := len(timestamps) - 1; i >= 0 && toRemove > 0; i-- {
-			if timestamps[i] == timestamp {
-				timestamps = append(timestamps[:i], timestamps[i+1:]...)
-				toRemove--
-			}
-		}
-		// If no exact matches found, remove the last ones
-		for toRemove > 0 && len(timestamps) > 0 {
-			timestamps = timestamps[:len(timestamps)-1]
-			toRemove--
-		}
-		if len(timestamps) > 0 {
-			activity.Timestamps[date] = timestamps
-		} else {
-			delete(activity.Timestamps, date)
+		if delta > 0 {
+			activity.Bounds[date] = widenBounds(activity.Bounds[date], timestamp)
+		} else if bounds := activity.Bounds[date]; timestamp <= bounds.First || timestamp >= bounds.Last {
+			s.reloadDayBounds(spaceID, date, activity)
 		}
 	}
 
@@ -262,17 +254,23 @@ func (s *Service) updateActivity(spaceID int, timestamp int64, delta int) {
 		activity.Stats.TotalActiveDays--
 	}
 
-	// Recalculate FirstPostTime and LastPostTime from all timestamps
-	activity.Stats.FirstPostTime = 0
-	activity.Stats.LastPostTime = 0
-
-	for _, timestamps := range activity.Timestamps {
-		for _, ts := range timestamps {
-			if activity.Stats.FirstPostTime == 0 || ts < activity.Stats.FirstPostTime {
-				activity.Stats.FirstPostTime = ts
+	if delta > 0 {
+		if activity.Stats.FirstPostTime == 0 || timestamp < activity.Stats.FirstPostTime {
+			activity.Stats.FirstPostTime = timestamp
+		}
+		if timestamp > activity.Stats.LastPostTime {
+			activity.Stats.LastPostTime = timestamp
+		}
+	} else if timestamp <= activity.Stats.FirstPostTime || timestamp >= activity.Stats.LastPostTime {
+		// A boundary post went away, the next one is the boundary of the first or last active day
+		activity.Stats.FirstPostTime = 0
+		activity.Stats.LastPostTime = 0
+		for _, bounds := range activity.Bounds {
+			if activity.Stats.FirstPostTime == 0 || bounds.First < activity.Stats.FirstPostTime {
+				activity.Stats.FirstPostTime = bounds.First
 			}
-			if ts > activity.Stats.LastPostTime {
-				activity.Stats.LastPostTime = ts
+			if bounds.Last > activity.Stats.LastPostTime {
+				activity.Stats.LastPostTime = bounds.Last
 			}
 		}
 	}
@@ -283,6 +281,36 @@ func (s *Service) updateActivity(spaceID int, timestamp int64, delta int) {
 	s.updateRecursiveActivity(spaceID, date, delta, timestamp)
 }
 
+// widenBounds extends the bounds of a day to include timestamp
+func widenBounds(bounds DayBounds, timestamp int64) DayBounds {
+	if bounds.First == 0 || timestamp < bounds.First {
+		bounds.First = timestamp
+	}
+	if timestamp > bounds.Last {
+		bounds.Last = timestamp
+	}
+	return bounds
+}
+
+// reloadDayBounds reads the bounds of a day back from the database after one of its boundary
+// posts was removed. Without a database the stale bounds are kept; they still fall within the
+// day. Caller must hold activity.mu.
+func (s *Service) reloadDayBounds(spaceID int, date string, activity *SpaceActivity) {
+	if s.db == nil || s.db.DB == nil {
+		return
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return
+	}
+	first, last, found, err := s.db.GetPostTimeBounds(spaceID, day.UnixMilli(), day.AddDate(0, 0, 1).UnixMilli())
+	if err != nil || !found {
+		return
+	}
+	activity.Bounds[date] = DayBounds{First: first, Last: last}
+}
+
 // updateRecursiveActivity updates recursive activity stats for a space and all its ancestors.
 // It performs incremental updates to avoid full recalculation on every post addition/deletion.
 // The timestamp parameter is used to efficiently update RecursiveFirstPostTime and RecursiveLastPostTime
@@ -345,12 +373,7 @@ func (s *Service) updateRecursiveActivity(spaceID int, date string, delta int, t
 		parentActivity, ok := s.activity[parentID]
 		if !ok {
 			// Create activity record for parent if it doesn't exist
-			parentActivity = &SpaceActivity{
-				Days:       make(map[string]int),
-				Recursive:  make(map[string]int),
-				Timestamps: make(map[string][]int64),
-				Stats:      ActivityStats{},
-			}
+			parentActivity = newSpaceActivity()
 			s.activity[parentID] = parentActivity
 		}
 		s.mu.Unlock()
@@ -399,6 +422,14 @@ func (s *Service) GetActivityPeriod(req ActivityPeriodRequest) (*ActivityPeriodR
 	if req.SpaceID == 0 {
 		return s.getGlobalActivityPeriod(req)
 	}
+
+	// Recursive activity kept in memory counts hidden descendants, sum the visible ones instead
+	if req.Recursive && len(req.Hidden) > 0 {
+		s.mu.RLock()
+		dayData, firstPostTime := s.visibleRecursiveUnlocked(req.SpaceID, req.Hidden)
+		s.mu.RUnlock()
+		return s.periodResponse(req, dayData, firstPostTime), nil
+	}
 	
 	s.mu.RLock()
 	activity, ok := s.activity[req.SpaceID]
@@ -419,7 +450,26 @@ func (s *Service) GetActivityPeriod(req ActivityPeriodRequest) (*ActivityPeriodR
 	
 	activity.mu.RLock()
 	defer activity.mu.RUnlock()
-	
+
+	// Get activity data
+	var dayData map[string]int
+	if req.Recursive {
+		dayData = activity.Recursive
+	} else {
+		dayData = activity.Days
+	}
+
+	// Use recursive first post time when in recursive mode
+	firstPostTime := activity.Stats.FirstPostTime
+	if req.Recursive && activity.Stats.RecursiveFirstPostTime > 0 {
+		firstPostTime = activity.Stats.RecursiveFirstPostTime
+	}
+
+	return s.periodResponse(req, dayData, firstPostTime), nil
+}
+
+// periodResponse keeps the days of dayData falling in the requested period
+func (s *Service) periodResponse(req ActivityPeriodRequest, dayData map[string]int, firstPostTime int64) *ActivityPeriodResponse {
 	// Calculate period dates
 	startDate, endDate := s.calculatePeriodDates(req.Period, req.PeriodMonths)
 	if req.StartDate != "" {
@@ -428,15 +478,7 @@ func (s *Service) GetActivityPeriod(req ActivityPeriodRequest) (*ActivityPeriodR
 	if req.EndDate != "" {
 		endDate = req.EndDate
 	}
-	
-	// Get activity data
-	var dayData map[string]int
-	if req.Recursive {
-		dayData = activity.Recursive
-	} else {
-		dayData = activity.Days
-	}
-	
+
 	// Filter for period
 	days := []ActivityDay{}
 	stats := PeriodStats{}
@@ -455,11 +497,6 @@ func (s *Service) GetActivityPeriod(req ActivityPeriodRequest) (*ActivityPeriodR
 		}
 	}
 
-	// Use recursive first post time when in recursive mode
-	firstPostTime := activity.Stats.FirstPostTime
-	if req.Recursive && activity.Stats.RecursiveFirstPostTime > 0 {
-		firstPostTime = activity.Stats.RecursiveFirstPostTime
-	}
 	maxPeriods := s.calculateMaxPeriods(firstPostTime, req.PeriodMonths)
 
 	return &ActivityPeriodResponse{
@@ -470,7 +507,90 @@ func (s *Service) GetActivityPeriod(req ActivityPeriodRequest) (*ActivityPeriodR
 		Days:       days,
 		Stats:      stats,
 		MaxPeriods: maxPeriods,
-	}, nil
+	}
+}
+
+// visibleRecursiveUnlocked sums the daily activity of a space and of its descendants that
+// are not hidden, along with their earliest post time. Caller must hold s.mu.
+func (s *Service) visibleRecursiveUnlocked(spaceID int, hidden map[int]bool) (map[string]int, int64) {
+	spaceIDs := []int{spaceID}
+	if s.catCache != nil {
+		spaceIDs = append(spaceIDs, s.catCache.GetDescendants(spaceID)...)
+	}
+
+	days := make(map[string]int)
+	var firstPostTime int64
+	for _, id := range spaceIDs {
+		activity, ok := s.activity[id]
+		if !ok || hidden[id] {
+			continue
+		}
+		activity.mu.RLock()
+		for date, count := range activity.Days {
+			days[date] += count
+		}
+		if first := activity.Stats.FirstPostTime; first > 0 && (firstPostTime == 0 || first < firstPostTime) {
+			firstPostTime = first
+		}
+		activity.mu.RUnlock()
+	}
+	return days, firstPostTime
+}
+
+// ComparePeriods returns the stats of two periods and the change between them. Periods count
+// back from the current one (0), which runs until today and is therefore usually incomplete.
+func (s *Service) ComparePeriods(spaceID int, recursive bool, periodA, periodB, periodMonths int, hidden map[int]bool) (*ActivityComparison, error) {
+	if periodA < 0 || periodB < 0 {
+		return nil, fmt.Errorf(config.ErrInvalidComparePeriod)
+	}
+	if spaceID != 0 {
+		if _, ok := s.catCache.Get(spaceID); !ok || hidden[spaceID] {
+			return nil, fmt.Errorf(config.ErrSpaceNotFound)
+		}
+	}
+
+	comparison := &ActivityComparison{SpaceID: spaceID, Recursive: recursive, PeriodMonths: periodMonths}
+	for _, side := range []struct {
+		period int
+		target *ComparedPeriod
+	}{{periodA, &comparison.PeriodA}, {periodB, &comparison.PeriodB}} {
+		// GetActivityPeriod numbers past periods with negative values
+		response, err := s.GetActivityPeriod(ActivityPeriodRequest{
+			SpaceID:      spaceID,
+			Recursive:    recursive,
+			Period:       -side.period,
+			PeriodMonths: periodMonths,
+			Hidden:       hidden,
+		})
+		if err != nil {
+			return nil, err
+		}
+		*side.target = ComparedPeriod{
+			Period:    side.period,
+			StartDate: response.StartDate,
+			EndDate:   response.EndDate,
+			Stats:     response.Stats,
+		}
+	}
+
+	a, b := comparison.PeriodA.Stats, comparison.PeriodB.Stats
+	comparison.Deltas = ComparisonDeltas{
+		TotalPosts:     percentChange(a.TotalPosts, b.TotalPosts),
+		ActiveDays:     percentChange(a.ActiveDays, b.ActiveDays),
+		MaxDayActivity: percentChange(a.MaxDayActivity, b.MaxDayActivity),
+	}
+
+	return comparison, nil
+}
+
+// percentChange is the change from before to after in percent, rounded to one decimal, or nil
+// when there is nothing to compare with
+func percentChange(after, before int) *float64 {
+	if before == 0 {
+		return nil
+	}
+	change := math.Round(float64(after-before)/float64(before)*1000) / 10
+	return &change
 }
 
 func (s *Service) getGlobalActivityPeriod(req ActivityPeriodRequest) (*ActivityPeriodResponse, error) {
@@ -488,7 +608,17 @@ func (s *Service) getGlobalActivityPeriod(req ActivityPeriodRequest) (*ActivityP
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	
-	for _, activity := range s.activity {
+	for spaceID, activity := range s.activity {
+		if req.Hidden[spaceID] {
+			continue
+		}
+
+		// Recursive activity kept in memory counts hidden descendants
+		var visible map[string]int
+		if req.Recursive && len(req.Hidden) > 0 {
+			visible, _ = s.visibleRecursiveUnlocked(spaceID, req.Hidden)
+		}
+
 		activity.mu.RLock()
 		
 		if activity.Stats.FirstPostTime > 0 && (earliestTime == 0 || activity.Stats.FirstPostTime < earliestTime) {
@@ -496,7 +626,9 @@ func (s *Service) getGlobalActivityPeriod(req ActivityPeriodRequest) (*ActivityP
 		}
 		
 		activityData := activity.Days
-		if req.Recursive {
+		if visible != nil {
+			activityData = visible
+		} else if req.Recursive {
 			activityData = activity.Recursive
 		}
 		
@@ -529,7 +661,7 @@ func (s *Service) getGlobalActivityPeriod(req ActivityPeriodRequest) (*ActivityP
 	
 	maxPeriods := s.calculateMaxPeriods(earliestTime, req.PeriodMonths)
 	
-	return &ActivityPeriodResponse{
+	response := &ActivityPeriodResponse{
 		SpaceID: 0,
 		StartDate:  startDate,
 		EndDate:    endDate,
@@ -537,7 +669,86 @@ func (s *Service) getGlobalActivityPeriod(req ActivityPeriodRequest) (*ActivityP
 		Days:       days,
 		Stats:      stats,
 		MaxPeriods: maxPeriods,
-	}, nil
+	}
+
+	if req.Breakdown {
+		s.applySpaceBreakdown(response, req.BreakdownTop, req.Hidden)
+	}
+
+	return response, nil
+}
+
+// applySpaceBreakdown splits each day of a global response into per-space
+// segments for the busiest spaces of the period, folding the rest into Other.
+// Direct (non-recursive) counts are used so segments never double count posts
+// from nested spaces. Caller must hold s.mu.
+func (s *Service) applySpaceBreakdown(response *ActivityPeriodResponse, top int, hidden map[int]bool) {
+	if top <= 0 {
+		top = config.DefaultActivityBreakdownTop
+	}
+
+	totals := make(map[int]int)
+	perDay := make(map[string]map[int]int)
+
+	for spaceID, activity := range s.activity {
+		if hidden[spaceID] {
+			continue
+		}
+		activity.mu.RLock()
+		for date, count := range activity.Days {
+			if date < response.StartDate || date > response.EndDate || count <= 0 {
+				continue
+			}
+			totals[spaceID] += count
+			if perDay[date] == nil {
+				perDay[date] = make(map[int]int)
+			}
+			perDay[date][spaceID] = count
+		}
+		activity.mu.RUnlock()
+	}
+
+	ranked := make([]int, 0, len(totals))
+	for spaceID := range totals {
+		ranked = append(ranked, spaceID)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if totals[ranked[i]] != totals[ranked[j]] {
+			return totals[ranked[i]] > totals[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	if len(ranked) > top {
+		ranked = ranked[:top]
+	}
+
+	response.TopSpaces = make([]ActivityTopSpace, 0, len(ranked))
+	for _, spaceID := range ranked {
+		topSpace := ActivityTopSpace{SpaceID: spaceID, Count: totals[spaceID]}
+		if s.catCache != nil {
+			if space, ok := s.catCache.Get(spaceID); ok {
+				topSpace.Name = space.Name
+			}
+		}
+		response.TopSpaces = append(response.TopSpaces, topSpace)
+	}
+
+	for i := range response.Days {
+		day := &response.Days[i]
+		counts := perDay[day.Date]
+		day.Spaces = []ActivitySpaceSegment{}
+		shown := 0
+		for _, spaceID := range ranked {
+			if count := counts[spaceID]; count > 0 {
+				day.Spaces = append(day.Spaces, ActivitySpaceSegment{SpaceID: spaceID, Count: count})
+				shown += count
+			}
+		}
+		// Remainder also absorbs recursive totals when the heatmap is recursive
+		if day.Count > shown {
+			day.Other = day.Count - shown
+		}
+	}
 }
 
 func (s *Service) calculatePeriodDates(period, periodMonths int) (string, string) {
@@ -579,21 +790,51 @@ func (s *Service) HandleEvent(event events.Event) error {
 	case events.PostCreated:
 		data := event.Data.(events.PostEvent)
 		s.updateActivity(data.SpaceID, data.Timestamp, 1)
+		s.bumpVersion(data.SpaceID)
 
 	case events.PostDeleted:
 		data := event.Data.(events.PostEvent)
 		s.updateActivity(data.SpaceID, data.Timestamp, -1)
+		s.bumpVersion(data.SpaceID)
 
 	case events.PostMoved:
 		data := event.Data.(events.PostEvent)
 		if data.OldSpaceID != nil {
 			s.updateActivity(*data.OldSpaceID, data.Timestamp, -1)
+			s.bumpVersion(*data.OldSpaceID)
 		}
 		s.updateActivity(data.SpaceID, data.Timestamp, 1)
+		s.bumpVersion(data.SpaceID)
+
+	case events.PostUpdated:
+		// Edits keep the post on its day, but clients revalidate the views built on it
+		data := event.Data.(events.PostEvent)
+		s.bumpVersion(data.SpaceID)
+
+	case events.PostRetimed:
+		data := event.Data.(events.PostEvent)
+		s.updateActivity(data.SpaceID, data.OldTimestamp, -1)
+		s.updateActivity(data.SpaceID, data.Timestamp, 1)
+		s.bumpVersion(data.SpaceID)
+
+	case events.PostMerged:
+		data := event.Data.(events.PostEvent)
+		for _, merged := range data.MergedPosts {
+			s.updateActivity(data.SpaceID, merged.Timestamp, -1)
+		}
+		s.bumpVersion(data.SpaceID)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		for _, split := range data.SplitPosts {
+			s.updateActivity(data.SpaceID, split.Timestamp, 1)
+		}
+		s.bumpVersion(data.SpaceID)
 
 	case events.SpaceUpdated:
 		data := event.Data.(events.SpaceEvent)
 		s.handleSpaceHierarchyChange(data.SpaceID, data.OldParentID, data.NewParentID)
+		s.versions.bumpAll()
 	}
 
 	return nil
@@ -650,4 +891,53 @@ func (s *Service) recalculateDescendantActivity(spaceID int) {
 	for _, descID := range descendants {
 		s.calculateRecursiveActivity(descID)
 	}
-}
\ No newline at end of file
+}
+
+// DataVersion returns the version of the activity data of a space (0 for all spaces). It
+// changes whenever an activity response of the space may change.
+func (s *Service) DataVersion(spaceID int) int64 {
+	return s.versions.get(spaceID)
+}
+
+// bumpVersion marks the activity of a space, its ancestors and the global view as changed
+func (s *Service) bumpVersion(spaceID int) {
+	spaceIDs := []int{0, spaceID}
+	if s.catCache != nil {
+		spaceIDs = append(spaceIDs, s.catCache.GetAncestors(spaceID)...)
+	}
+	s.versions.bump(spaceIDs...)
+}
+
+// LastActivity returns the latest post time of a space or its descendants, 0 if none
+func (s *Service) LastActivity(spaceID int) int64 {
+	s.mu.RLock()
+	activity, ok := s.activity[spaceID]
+	s.mu.RUnlock()
+	if !ok {
+		return 0
+	}
+
+	activity.mu.RLock()
+	defer activity.mu.RUnlock()
+	return activity.Stats.RecursiveLastPostTime
+}
+
+// MemoryUsage estimates the memory held by the activity maps; entries are space days
+func (s *Service) MemoryUsage() models.MemoryUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// A day key is a YYYY-MM-DD string
+	const dayKey = config.MemoryStringHeader + 10
+
+	var usage models.MemoryUsage
+	for _, activity := range s.activity {
+		activity.mu.RLock()
+		usage.Entries += len(activity.Days) + len(activity.Recursive)
+		usage.Bytes += config.MemoryMapEntryOverhead + int64(unsafe.Sizeof(*activity))
+		usage.Bytes += int64(len(activity.Days)+len(activity.Recursive)) * (config.MemoryMapEntryOverhead + dayKey + 8)
+		usage.Bytes += int64(len(activity.Bounds)) * (config.MemoryMapEntryOverhead + dayKey + int64(unsafe.Sizeof(DayBounds{})))
+		activity.mu.RUnlock()
+	}
+	return usage
+}
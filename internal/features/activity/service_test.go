@@ -1,9 +1,13 @@
 package activity
 
 import (
+	"backthynk/internal/config"
 	"backthynk/internal/core/cache"
 	"backthynk/internal/core/events"
 	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"context"
+	"os"
 	"testing"
 	"time"
 )
@@ -224,6 +228,35 @@ func TestUpdateActivity(t *testing.T) {
 	activity.mu.RUnlock()
 }
 
+func TestPostRetimedEvent(t *testing.T) {
+	service := &Service{
+		enabled:  true,
+		activity: make(map[int]*SpaceActivity),
+	}
+
+	now := time.Now().Unix() * 1000
+	lastWeek := now - 7*86400000
+	service.updateActivity(1, now, 1)
+
+	err := service.HandleEvent(events.Event{
+		Type: events.PostRetimed,
+		Data: events.PostEvent{PostID: 1, SpaceID: 1, Timestamp: lastWeek, OldTimestamp: now},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error handling PostRetimed event, got %v", err)
+	}
+
+	activity := service.activity[1]
+	activity.mu.RLock()
+	defer activity.mu.RUnlock()
+	if activity.Stats.TotalPosts != 1 || activity.Stats.TotalActiveDays != 1 {
+		t.Errorf("Expected the post to move between days, got %+v", activity.Stats)
+	}
+	if _, exists := activity.Days[time.Unix(lastWeek/1000, 0).Format("2006-01-02")]; !exists {
+		t.Error("Expected activity on the new day")
+	}
+}
+
 func TestCalculatePeriodDates(t *testing.T) {
 	service := &Service{}
 
@@ -778,4 +811,279 @@ func TestIncrementalRecursiveTimestampUpdates(t *testing.T) {
 		grandparentActivity.Stats.RecursiveFirstPostTime,
 		grandparentActivity.Stats.RecursiveLastPostTime,
 		response.MaxPeriods)
-}
\ No newline at end of file
+}
+func TestGlobalActivitySpaceBreakdown(t *testing.T) {
+	spaceCache := cache.NewSpaceCache()
+	for id, name := range map[int]string{1: "Work", 2: "Home", 3: "Reading"} {
+		spaceCache.Set(&models.Space{ID: id, Name: name})
+	}
+
+	service := &Service{
+		enabled:  true,
+		catCache: spaceCache,
+		activity: make(map[int]*SpaceActivity),
+	}
+
+	day1 := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	posts := []struct {
+		spaceID int
+		when    time.Time
+		count   int
+	}{
+		{1, day1, 3},
+		{1, day2, 2},
+		{2, day1, 1},
+		{2, day2, 2},
+		{3, day2, 1},
+	}
+	for _, p := range posts {
+		service.updateActivity(p.spaceID, p.when.Unix()*1000, p.count)
+	}
+
+	date1 := time.Unix(day1.Unix(), 0).Format("2006-01-02")
+	date2 := time.Unix(day2.Unix(), 0).Format("2006-01-02")
+
+	resp, err := service.GetActivityPeriod(ActivityPeriodRequest{
+		SpaceID:      0,
+		StartDate:    "2024-01-01",
+		EndDate:      "2024-12-31",
+		PeriodMonths: 1,
+		Breakdown:    true,
+		BreakdownTop: 2,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(resp.TopSpaces) != 2 {
+		t.Fatalf("Expected 2 top spaces, got %d", len(resp.TopSpaces))
+	}
+	if resp.TopSpaces[0].SpaceID != 1 || resp.TopSpaces[0].Count != 5 || resp.TopSpaces[0].Name != "Work" {
+		t.Errorf("Unexpected first top space: %+v", resp.TopSpaces[0])
+	}
+	if resp.TopSpaces[1].SpaceID != 2 || resp.TopSpaces[1].Count != 3 {
+		t.Errorf("Unexpected second top space: %+v", resp.TopSpaces[1])
+	}
+
+	byDate := make(map[string]ActivityDay)
+	for _, day := range resp.Days {
+		byDate[day.Date] = day
+	}
+
+	tests := []struct {
+		date   string
+		total  int
+		spaces []ActivitySpaceSegment
+		other  int
+	}{
+		{date1, 4, []ActivitySpaceSegment{{SpaceID: 1, Count: 3}, {SpaceID: 2, Count: 1}}, 0},
+		{date2, 5, []ActivitySpaceSegment{{SpaceID: 1, Count: 2}, {SpaceID: 2, Count: 2}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			day, ok := byDate[tt.date]
+			if !ok {
+				t.Fatalf("Expected activity for %s", tt.date)
+			}
+			if day.Count != tt.total {
+				t.Errorf("Expected count %d, got %d", tt.total, day.Count)
+			}
+			if day.Other != tt.other {
+				t.Errorf("Expected other %d, got %d", tt.other, day.Other)
+			}
+			if len(day.Spaces) != len(tt.spaces) {
+				t.Fatalf("Expected %d segments, got %d", len(tt.spaces), len(day.Spaces))
+			}
+			for i, seg := range tt.spaces {
+				if day.Spaces[i] != seg {
+					t.Errorf("Segment %d: expected %+v, got %+v", i, seg, day.Spaces[i])
+				}
+			}
+		})
+	}
+
+	// Without the flag the response keeps its original shape
+	resp, err = service.GetActivityPeriod(ActivityPeriodRequest{
+		SpaceID:      0,
+		StartDate:    "2024-01-01",
+		EndDate:      "2024-12-31",
+		PeriodMonths: 1,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.TopSpaces != nil {
+		t.Errorf("Expected no top spaces without breakdown, got %+v", resp.TopSpaces)
+	}
+	for _, day := range resp.Days {
+		if day.Spaces != nil || day.Other != 0 {
+			t.Errorf("Expected no breakdown for %s, got %+v", day.Date, day)
+		}
+	}
+}
+
+// TestDayBoundsReloadedFromDatabase deletes the earliest and latest posts of a busy day and
+// checks the space boundaries are read back from the database
+func TestDayBoundsReloadedFromDatabase(t *testing.T) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_activity_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	space, _ := db.CreateSpace("Journal", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.Local)
+	var created []*models.Post
+	for _, hour := range []int{8, 12, 16, 20} {
+		post, err := db.CreatePostWithTimestamp(context.Background(), space.ID, "entry", day.Add(time.Duration(hour)*time.Hour).UnixMilli())
+		if err != nil {
+			t.Fatalf("Failed to create post: %v", err)
+		}
+		created = append(created, post)
+	}
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	remove := func(post *models.Post) {
+		if err := db.DeletePost(context.Background(), post.ID); err != nil {
+			t.Fatalf("Failed to delete post: %v", err)
+		}
+		service.HandleEvent(events.Event{
+			Type: events.PostDeleted,
+			Data: events.PostEvent{PostID: post.ID, SpaceID: space.ID, Timestamp: post.Created},
+		})
+	}
+	remove(created[0])
+	remove(created[3])
+
+	activity := service.activity[space.ID]
+	if activity.Stats.FirstPostTime != created[1].Created {
+		t.Errorf("Expected FirstPostTime %d, got %d", created[1].Created, activity.Stats.FirstPostTime)
+	}
+	if activity.Stats.LastPostTime != created[2].Created {
+		t.Errorf("Expected LastPostTime %d, got %d", created[2].Created, activity.Stats.LastPostTime)
+	}
+	if activity.Days[day.Format("2006-01-02")] != 2 {
+		t.Errorf("Expected 2 posts left on the day, got %d", activity.Days[day.Format("2006-01-02")])
+	}
+}
+
+// TestMemoryUsageIndependentOfPostsPerDay checks that only per-day data is held in memory
+func TestMemoryUsageIndependentOfPostsPerDay(t *testing.T) {
+	usage := func(postsPerDay int) int64 {
+		service := NewService(nil, nil, true)
+		service.refreshSpace(1, benchmarkPosts(365, postsPerDay))
+		return service.MemoryUsage().Bytes
+	}
+
+	if sparse, dense := usage(1), usage(100); sparse != dense {
+		t.Errorf("Expected the same memory for 1 and 100 posts a day, got %d and %d bytes", sparse, dense)
+	}
+}
+
+// BenchmarkRefreshSpace loads a space with a few years of busy history; bytes/post is the
+// estimated memory retained per post
+func BenchmarkRefreshSpace(b *testing.B) {
+	posts := benchmarkPosts(3*365, 200)
+	service := NewService(nil, nil, true)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		service.refreshSpace(1, posts)
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(service.MemoryUsage().Bytes)/float64(len(posts)), "bytes/post")
+}
+
+// benchmarkPosts spreads postsPerDay posts over each of days consecutive days
+func benchmarkPosts(days, postsPerDay int) []storage.PostData {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)
+	posts := make([]storage.PostData, 0, days*postsPerDay)
+	for d := 0; d < days; d++ {
+		day := start.AddDate(0, 0, d)
+		for p := 0; p < postsPerDay; p++ {
+			posts = append(posts, storage.PostData{
+				ID:      len(posts) + 1,
+				SpaceID: 1,
+				Created: day.Add(time.Duration(p) * time.Minute).UnixMilli(),
+			})
+		}
+	}
+	return posts
+}
+
+func TestDataVersions(t *testing.T) {
+	catCache := cache.NewSpaceCache()
+	catCache.Set(&models.Space{ID: 1, Name: "Parent"})
+	catCache.Set(&models.Space{ID: 2, Name: "Child", ParentID: &[]int{1}[0]})
+	catCache.Set(&models.Space{ID: 3, Name: "Other"})
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	service := &Service{
+		enabled:  true,
+		catCache: catCache,
+		activity: make(map[int]*SpaceActivity),
+		versions: newDataVersions(func() time.Time { return now }),
+	}
+
+	before := map[int]int64{}
+	for _, id := range []int{0, 1, 2, 3} {
+		before[id] = service.DataVersion(id)
+	}
+
+	service.HandleEvent(events.Event{
+		Type: events.PostCreated,
+		Data: events.PostEvent{SpaceID: 2, Timestamp: now.UnixMilli()},
+	})
+
+	// The space, its ancestors and the global view change, unrelated spaces do not
+	for _, id := range []int{0, 1, 2} {
+		if service.DataVersion(id) == before[id] {
+			t.Errorf("Expected space %d to get a new version", id)
+		}
+	}
+	if service.DataVersion(3) != before[3] {
+		t.Error("Expected an unrelated space to keep its version")
+	}
+
+	// An edit changes versions without changing counts
+	edited := service.DataVersion(2)
+	service.HandleEvent(events.Event{
+		Type: events.PostUpdated,
+		Data: events.PostEvent{SpaceID: 2, Timestamp: now.UnixMilli()},
+	})
+	if service.DataVersion(2) == edited || service.activity[2].Stats.TotalPosts != 1 {
+		t.Errorf("Expected a new version with the count kept, got %+v", service.activity[2].Stats)
+	}
+
+	// A hierarchy change or a new day changes every version
+	unchanged := service.DataVersion(3)
+	service.HandleEvent(events.Event{Type: events.SpaceUpdated, Data: events.SpaceEvent{SpaceID: 3}})
+	if service.DataVersion(3) == unchanged {
+		t.Error("Expected a space update to change every version")
+	}
+	unchanged = service.DataVersion(3)
+	now = now.Add(24 * time.Hour)
+	if service.DataVersion(3) == unchanged {
+		t.Error("Expected a new day to change every version")
+	}
+}
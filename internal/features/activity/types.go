@@ -1,8 +1,24 @@
 package activity
 
 type ActivityDay struct {
-	Date  string `json:"date"`
-	Count int    `json:"count"`
+	Date   string                 `json:"date"`
+	Count  int                    `json:"count"`
+	Spaces []ActivitySpaceSegment `json:"spaces,omitempty"`
+	Other  int                    `json:"other,omitempty"`
+}
+
+// ActivitySpaceSegment is one space's share of a day in a global breakdown
+type ActivitySpaceSegment struct {
+	SpaceID int `json:"space_id"`
+	Count   int `json:"count"`
+}
+
+// ActivityTopSpace describes a space that is broken out individually,
+// ordered by its post count over the requested period
+type ActivityTopSpace struct {
+	SpaceID int    `json:"space_id"`
+	Name    string `json:"name"`
+	Count   int    `json:"count"`
 }
 
 type ActivityPeriodRequest struct {
@@ -12,6 +28,11 @@ type ActivityPeriodRequest struct {
 	EndDate      string `json:"end_date"`
 	Period       int    `json:"period"`
 	PeriodMonths int    `json:"period_months"`
+	Breakdown    bool   `json:"breakdown"`
+	BreakdownTop int    `json:"breakdown_top"`
+
+	// Hidden are the spaces left out for the viewer of the request, see services.SpaceAccess
+	Hidden map[int]bool `json:"-"`
 }
 
 type ActivityPeriodResponse struct {
@@ -22,10 +43,36 @@ type ActivityPeriodResponse struct {
 	Days       []ActivityDay `json:"days"`
 	Stats      PeriodStats   `json:"stats"`
 	MaxPeriods int           `json:"max_periods"`
+	TopSpaces  []ActivityTopSpace `json:"top_spaces,omitempty"`
 }
 
 type PeriodStats struct {
 	TotalPosts     int `json:"total_posts"`
 	ActiveDays     int `json:"active_days"`
 	MaxDayActivity int `json:"max_day_activity"`
-}
\ No newline at end of file
+}
+
+// ActivityComparison puts the stats of two periods side by side
+type ActivityComparison struct {
+	SpaceID      int              `json:"space_id"`
+	Recursive    bool             `json:"recursive"`
+	PeriodMonths int              `json:"period_months"`
+	PeriodA      ComparedPeriod   `json:"period_a"`
+	PeriodB      ComparedPeriod   `json:"period_b"`
+	Deltas       ComparisonDeltas `json:"deltas"`
+}
+
+// ComparedPeriod is one side of a comparison. Periods count back from the current one, which is 0.
+type ComparedPeriod struct {
+	Period    int         `json:"period"`
+	StartDate string      `json:"start_date"`
+	EndDate   string      `json:"end_date"`
+	Stats     PeriodStats `json:"stats"`
+}
+
+// ComparisonDeltas are the percentage changes from period B to period A, null when period B is 0
+type ComparisonDeltas struct {
+	TotalPosts     *float64 `json:"total_posts"`
+	ActiveDays     *float64 `json:"active_days"`
+	MaxDayActivity *float64 `json:"max_day_activity"`
+}
@@ -0,0 +1,85 @@
+package activity
+
+import (
+	"sync"
+	"time"
+)
+
+// dataVersions hands out a version per space that changes whenever an activity response of
+// the space may change: on post events of the space or its descendants, on hierarchy changes,
+// and when the day changes, as periods are relative to the current date.
+// A nil *dataVersions is valid: every space stays at version 0.
+type dataVersions struct {
+	mu     sync.Mutex
+	next   int64
+	base   int64 // Version of spaces without a change since the last reset
+	day    string
+	spaces map[int]int64
+	now    func() time.Time
+}
+
+func newDataVersions(now func() time.Time) *dataVersions {
+	// Versions start from the clock so they do not repeat across restarts
+	start := now().UnixMilli() * 1000
+	return &dataVersions{
+		next:   start + 1,
+		base:   start,
+		day:    now().Format("2006-01-02"),
+		spaces: make(map[int]int64),
+		now:    now,
+	}
+}
+
+// get returns the current version of a space
+func (v *dataVersions) get(spaceID int) int64 {
+	if v == nil {
+		return 0
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.rollDayUnlocked()
+	if version, ok := v.spaces[spaceID]; ok {
+		return version
+	}
+	return v.base
+}
+
+// bump gives new versions to the given spaces
+func (v *dataVersions) bump(spaceIDs ...int) {
+	if v == nil {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.rollDayUnlocked()
+	for _, id := range spaceIDs {
+		v.spaces[id] = v.next
+	}
+	v.next++
+}
+
+// bumpAll gives a new version to every space
+func (v *dataVersions) bumpAll() {
+	if v == nil {
+		return
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.resetUnlocked()
+}
+
+func (v *dataVersions) rollDayUnlocked() {
+	if day := v.now().Format("2006-01-02"); day != v.day {
+		v.day = day
+		v.resetUnlocked()
+	}
+}
+
+func (v *dataVersions) resetUnlocked() {
+	v.base = v.next
+	v.next++
+	v.spaces = make(map[int]int64)
+}
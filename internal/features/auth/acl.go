@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// SetSpaceAccess lets admins manage the access lists of spaces, which then apply to the
+// requests of signed-in users
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// GetSpaceACL returns the access list of a space, empty when everyone may reach it
+func (s *Service) GetSpaceACL(spaceID int) (*SpaceACL, error) {
+	if s.access == nil {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	return &SpaceACL{SpaceID: spaceID, Entries: s.access.GetACL(spaceID)}, nil
+}
+
+// SetSpaceACL replaces the access list of a space; an empty list makes it public again
+func (s *Service) SetSpaceACL(spaceID int, entries []models.SpaceACLEntry) (*SpaceACL, error) {
+	if s.access == nil {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if entries == nil {
+		entries = []models.SpaceACLEntry{}
+	}
+	if err := s.access.SetACL(spaceID, entries); err != nil {
+		return nil, err
+	}
+	logger.Info("Space access list updated", zap.Int("space_id", spaceID), zap.Int("entries", len(entries)))
+	return &SpaceACL{SpaceID: spaceID, Entries: entries}, nil
+}
@@ -0,0 +1,460 @@
+package auth
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/render"
+	"backthynk/internal/core/services"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+	router  *mux.Router // Root router, walked for the authorization matrix
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	h.router = router
+	router.HandleFunc("/auth/login", h.LoginPage).Methods("GET")
+	if h.service.provider != nil {
+		router.HandleFunc("/auth/oidc/login", h.Login).Methods("GET")
+		router.HandleFunc("/auth/oidc/callback", h.Callback).Methods("GET")
+	}
+	if h.service.LocalAccounts() {
+		router.HandleFunc("/auth/login", h.PasswordLogin).Methods("POST")
+		router.HandleFunc("/auth/register", h.Register).Methods("POST")
+	}
+	router.HandleFunc("/auth/logout", h.Logout).Methods("POST")
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/auth/me", h.GetMe).Methods("GET")
+	api.HandleFunc("/sessions", h.GetSessions).Methods("GET")
+	api.HandleFunc("/sessions/others", h.RevokeOtherSessions).Methods("DELETE")
+	api.HandleFunc("/sessions/{id:[0-9]+}", h.RevokeSession).Methods("DELETE")
+	api.HandleFunc("/admin/users", h.GetUsers).Methods("GET")
+	api.HandleFunc("/admin/authz-matrix", h.GetPolicy).Methods("GET")
+	if h.service.access != nil {
+		api.HandleFunc("/admin/spaces/{id:[0-9]+}/acl", h.GetSpaceACL).Methods("GET")
+		api.HandleFunc("/admin/spaces/{id:[0-9]+}/acl", h.SetSpaceACL).Methods("PUT")
+	}
+}
+
+// Middleware requires a session on every request outside publicPrefixes and checks the role
+// of its user. Pages redirect to the login, API calls get 401. Uploads carrying a signature
+// are left to the signed URL check of the file handler.
+func (h *Handler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if publicPath(path) || (strings.HasPrefix(path, signedPrefix) && r.URL.Query().Get("signature") != "") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		api := path == "/api" || strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, signedPrefix)
+		cookie, err := r.Cookie(config.SessionCookieName)
+		if err != nil {
+			h.unauthenticated(w, r, api)
+			return
+		}
+		session, user, err := h.service.Authenticate(cookie.Value, r.UserAgent(), clientIP(r))
+		if err != nil {
+			h.unauthenticated(w, r, api)
+			return
+		}
+
+		if !Allowed(user.Role, r.Method, path) {
+			http.Error(w, config.ErrRoleForbidden, http.StatusForbidden)
+			return
+		}
+		ctx := withLogin(r.Context(), session, user)
+		ctx = services.WithViewer(ctx, services.Viewer{UserID: user.ID, Admin: user.Role == models.RoleAdmin})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// IsGate makes the login check run before the middlewares of other features
+func (h *Handler) IsGate() bool {
+	return true
+}
+
+func (h *Handler) unauthenticated(w http.ResponseWriter, r *http.Request, api bool) {
+	if !api && r.Method == http.MethodGet {
+		http.Redirect(w, r, "/auth/login", http.StatusFound)
+		return
+	}
+	http.Error(w, config.ErrAuthRequired, http.StatusUnauthorized)
+}
+
+// LoginPage handles GET /auth/login
+// Without local accounts the browser goes straight to the provider.
+func (h *Handler) LoginPage(w http.ResponseWriter, r *http.Request) {
+	if !h.service.LocalAccounts() {
+		http.Redirect(w, r, "/auth/oidc/login", http.StatusFound)
+		return
+	}
+	h.writeLoginPage(w, r, http.StatusOK, "", "")
+}
+
+// PasswordLogin handles POST /auth/login
+// Accepts the login form, which is sent back to the app, or JSON, which gets the user.
+func (h *Handler) PasswordLogin(w http.ResponseWriter, r *http.Request) {
+	creds, form, err := readCredentials(r)
+	if err != nil {
+		h.writeLoginError(w, r, form, fmt.Errorf(config.ErrInvalidRequestBody), "")
+		return
+	}
+
+	token, user, err := h.service.PasswordLogin(creds.Username, creds.Password, r.UserAgent(), clientIP(r))
+	if err != nil {
+		h.writeLoginError(w, r, form, err, creds.Username)
+		return
+	}
+	h.writeLogin(w, r, form, http.StatusOK, token, user)
+}
+
+// Register handles POST /auth/register
+// Creates a local account and signs it in, from the login page form or JSON.
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	creds, form, err := readCredentials(r)
+	if err != nil {
+		h.writeLoginError(w, r, form, fmt.Errorf(config.ErrInvalidRequestBody), "")
+		return
+	}
+
+	token, user, err := h.service.Register(creds.Username, creds.Password, r.UserAgent(), clientIP(r))
+	if err != nil {
+		h.writeLoginError(w, r, form, err, "")
+		return
+	}
+	h.writeLogin(w, r, form, http.StatusCreated, token, user)
+}
+
+// writeLogin sets the session cookie of a password login, then sends forms to the app and
+// answers JSON with the user
+func (h *Handler) writeLogin(w http.ResponseWriter, r *http.Request, form bool, status int, token string, user *models.User) {
+	setSessionCookie(w, r, token)
+	if form {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(user)
+}
+
+// writeLoginError shows a failed password login or registration on the login page for forms
+func (h *Handler) writeLoginError(w http.ResponseWriter, r *http.Request, form bool, err error, username string) {
+	if !form {
+		writeServiceError(w, err)
+		return
+	}
+	h.writeLoginPage(w, r, serviceErrorStatus(err), err.Error(), username)
+}
+
+func (h *Handler) writeLoginPage(w http.ResponseWriter, r *http.Request, status int, message, username string) {
+	registration, err := h.service.RegistrationOpen()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.WriteStatus(w, r, status, render.PageLogin, "Sign in", loginPage{
+		Error:             message,
+		Username:          username,
+		SingleSignOn:      h.service.provider != nil,
+		Registration:      registration,
+		MinPasswordLength: config.MinPasswordLength,
+	})
+}
+
+// Login handles GET /auth/oidc/login
+// The browser is sent to the provider, with the state of the login kept in a cookie so the
+// callback only completes logins started by the same browser.
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	state, target, err := h.service.BeginLogin(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     config.LoginStateCookieName,
+		Value:    state,
+		Path:     "/auth/oidc",
+		MaxAge:   int(config.LoginStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// Callback handles GET /auth/oidc/callback?state=...&code=...
+func (h *Handler) Callback(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	cookie, err := r.Cookie(config.LoginStateCookieName)
+	if err != nil || state == "" || cookie.Value != state {
+		http.Error(w, config.ErrLoginStateInvalid, http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: config.LoginStateCookieName, Path: "/auth/oidc", MaxAge: -1})
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		// The provider reports a refused or cancelled login with an error parameter
+		http.Error(w, config.ErrLoginFailed, http.StatusUnauthorized)
+		return
+	}
+
+	token, _, err := h.service.CompleteLogin(r.Context(), state, code, r.UserAgent(), clientIP(r))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	setSessionCookie(w, r, token)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// Logout handles POST /auth/logout
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(config.SessionCookieName); err == nil {
+		if err := h.service.Logout(cookie.Value); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{Name: config.SessionCookieName, Path: "/", MaxAge: -1})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMe handles GET /api/auth/me
+func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, config.ErrAuthRequired, http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// GetSessions handles GET /api/sessions
+// Lists the active sessions of the current user with their device and last seen time.
+func (h *Handler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, config.ErrAuthRequired, http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := h.service.GetSessions(session.UserID, session.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSession handles DELETE /api/sessions/{id}
+// Revoking the current session logs the caller out.
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, config.ErrAuthRequired, http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSessionID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevokeSession(session.UserID, id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if id == session.ID {
+		http.SetCookie(w, &http.Cookie{Name: config.SessionCookieName, Path: "/", MaxAge: -1})
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOtherSessions handles DELETE /api/sessions/others
+// Every session of the current user but the one making the request is revoked.
+func (h *Handler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	session := SessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, config.ErrAuthRequired, http.StatusUnauthorized)
+		return
+	}
+
+	revoked, err := h.service.RevokeOtherSessions(session.UserID, session.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"revoked": revoked})
+}
+
+// GetUsers handles GET /api/admin/users
+func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.service.GetUsers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(users)
+}
+
+// GetPolicy handles GET /api/admin/authz-matrix
+// Exports the access of every role to each registered route, as enforced by Middleware.
+func (h *Handler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	entries, err := Policy(h.router)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GetSpaceACL handles GET /api/admin/spaces/{id}/acl
+func (h *Handler) GetSpaceACL(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	acl, err := h.service.GetSpaceACL(spaceID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(acl)
+}
+
+// SetSpaceACL handles PUT /api/admin/spaces/{id}/acl
+// The entries replace the access list of the space; an empty list makes it public again.
+func (h *Handler) SetSpaceACL(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Entries []models.SpaceACLEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	acl, err := h.service.SetSpaceACL(spaceID, req.Entries)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(acl)
+}
+
+func setSessionCookie(w http.ResponseWriter, r *http.Request, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     config.SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(config.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   secureRequest(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// readCredentials reads a login or registration from a JSON body or a form, reporting
+// whether it came from a form
+func readCredentials(r *http.Request) (Credentials, bool, error) {
+	var creds Credentials
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		err := json.NewDecoder(r.Body).Decode(&creds)
+		return creds, false, err
+	}
+	if err := r.ParseForm(); err != nil {
+		return creds, true, err
+	}
+	creds.Username = r.PostForm.Get("username")
+	creds.Password = r.PostForm.Get("password")
+	return creds, true, nil
+}
+
+// secureRequest reports whether the browser reached the server over HTTPS, directly or
+// through a reverse proxy
+func secureRequest(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+// clientIP returns the address of the browser, from the first X-Forwarded-For entry set by a
+// reverse proxy when there is one. It is only shown in the session list, never trusted.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), serviceErrorStatus(err))
+}
+
+func serviceErrorStatus(err error) int {
+	switch err.Error() {
+	case config.ErrSessionNotFound, config.ErrSpaceNotFound:
+		return http.StatusNotFound
+	case config.ErrLoginStateInvalid, config.ErrInvalidRequestBody, config.ErrInvalidUsername,
+		config.ErrPasswordTooShort, config.ErrPasswordTooLong, config.ErrInvalidSpacePermission, config.ErrDuplicateSpaceACLUser, config.ErrACLUserNotFound:
+		return http.StatusBadRequest
+	case config.ErrLoginFailed, config.ErrInvalidCredentials:
+		return http.StatusUnauthorized
+	case config.ErrLoginNoRole, config.ErrRegistrationClosed:
+		return http.StatusForbidden
+	case config.ErrUsernameTaken:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
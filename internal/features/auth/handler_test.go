@@ -0,0 +1,335 @@
+package auth
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/auth/oidc/login", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected auth routes NOT to be registered when disabled")
+	}
+}
+
+func TestLoginHandlers(t *testing.T) {
+	service, issuer, cleanup := setupAuthTest(t)
+	defer cleanup()
+
+	handler := NewHandler(service)
+	router := mux.NewRouter()
+	router.Use(handler.Middleware)
+	handler.RegisterRoutes(router)
+
+	// Login sends the browser to the provider with the state in a cookie
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/auth/oidc/login", nil))
+	if w.Code != http.StatusFound || len(w.Result().Cookies()) != 1 {
+		t.Fatalf("Expected a redirect setting the state cookie, got %d", w.Code)
+	}
+	stateCookie := w.Result().Cookies()[0]
+	location, _ := w.Result().Location()
+	issuer.nonce = location.Query().Get("nonce")
+
+	// A callback from another browser is refused
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/auth/oidc/callback?code=good-code&state="+stateCookie.Value, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without the state cookie, got %d", w.Code)
+	}
+
+	issuer.claims["groups"] = []interface{}{"notes-users"}
+	req := httptest.NewRequest("GET", "/auth/oidc/callback?code=good-code&state="+stateCookie.Value, nil)
+	req.AddCookie(stateCookie)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusFound {
+		t.Fatalf("Expected a redirect after login, got %d: %s", w.Code, w.Body.String())
+	}
+	var session *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == config.SessionCookieName {
+			session = cookie
+		}
+	}
+	if session == nil || !session.HttpOnly {
+		t.Fatal("Expected an HTTP-only session cookie")
+	}
+
+	req = httptest.NewRequest("GET", "/api/auth/me", nil)
+	req.AddCookie(session)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the current user, got %d", w.Code)
+	}
+
+	// Editors do not reach admin endpoints
+	req = httptest.NewRequest("GET", "/api/admin/users", nil)
+	req.AddCookie(session)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for an editor, got %d", w.Code)
+	}
+
+	// Sessions of the current user
+	req = httptest.NewRequest("GET", "/api/sessions", nil)
+	req.AddCookie(session)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var sessions []models.Session
+	json.NewDecoder(w.Body).Decode(&sessions)
+	if w.Code != http.StatusOK || len(sessions) != 1 || !sessions[0].Current {
+		t.Fatalf("Expected the current session listed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("DELETE", "/api/sessions/others", nil)
+	req.AddCookie(session)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"revoked":0`) {
+		t.Errorf("Expected no other session revoked, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("DELETE", "/api/sessions/9999", nil)
+	req.AddCookie(session)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown session, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/auth/logout", nil)
+	req.AddCookie(session)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 on logout, got %d", w.Code)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	service, _, cleanup := setupAuthTest(t)
+	defer cleanup()
+
+	viewer, _ := service.db.ProvisionUser("viewer-1", "", "Viewer", models.RoleViewer, time.Now().UnixMilli())
+	service.db.CreateSession(hashToken("viewer-token"), viewer.ID, "", "", time.Now().UnixMilli(), time.Now().Add(time.Hour).UnixMilli())
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	middleware := NewHandler(service).Middleware(ok)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		session  string
+		expected int
+	}{
+		{"API without session", "GET", "/api/spaces", "", http.StatusUnauthorized},
+		{"page without session", "GET", "/", "", http.StatusFound},
+		{"unknown session", "GET", "/api/spaces", "forged", http.StatusUnauthorized},
+		{"share link", "GET", "/api/shared/bts_abc", "", http.StatusOK},
+		{"ingest", "POST", "/api/spaces/ingest/bti_abc", "", http.StatusOK},
+		{"signed upload", "GET", "/uploads/file.png?expires=1&signature=abc", "", http.StatusOK},
+		{"upload without signature", "GET", "/uploads/file.png", "", http.StatusUnauthorized},
+		{"viewer read", "GET", "/api/spaces", "viewer-token", http.StatusOK},
+		{"viewer write", "POST", "/api/posts", "viewer-token", http.StatusForbidden},
+		{"viewer sessions", "DELETE", "/api/sessions/others", "viewer-token", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.session != "" {
+				req.AddCookie(&http.Cookie{Name: config.SessionCookieName, Value: tt.session})
+			}
+			w := httptest.NewRecorder()
+			middleware.ServeHTTP(w, req)
+			if w.Code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestPasswordLoginHandlers(t *testing.T) {
+	service, _, cleanup := setupAuthTest(t)
+	defer cleanup()
+	enableLocalAccounts(service, false, "")
+
+	handler := NewHandler(service)
+	router := mux.NewRouter()
+	router.Use(handler.Middleware)
+	handler.RegisterRoutes(router)
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("/api/posts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}).Methods("POST")
+
+	// Pages without a session go to the login page, which offers the first registration
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if location, _ := w.Result().Location(); w.Code != http.StatusFound || location.Path != "/auth/login" {
+		t.Fatalf("Expected a redirect to the login page, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/auth/login", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `action="/auth/register"`) || !strings.Contains(w.Body.String(), "/auth/oidc/login") {
+		t.Fatalf("Expected the login page with registration and single sign-on, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Registering from the form signs the first account in
+	req := httptest.NewRequest("POST", "/auth/register", strings.NewReader("username=ada&password=correct+horse"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusSeeOther || len(w.Result().Cookies()) != 1 || !w.Result().Cookies()[0].HttpOnly {
+		t.Fatalf("Expected a redirect setting the session cookie, got %d: %s", w.Code, w.Body.String())
+	}
+	session := w.Result().Cookies()[0]
+
+	req = httptest.NewRequest("POST", "/api/posts", nil)
+	req.AddCookie(session)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected the admin to write, got %d", w.Code)
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"JSON login", "/auth/login", "application/json", `{"username":"Ada","password":"correct horse"}`, http.StatusOK, `"role":"admin"`},
+		{"Wrong password", "/auth/login", "application/json", `{"username":"ada","password":"wrong horse"}`, http.StatusUnauthorized, config.ErrInvalidCredentials},
+		{"Invalid JSON", "/auth/login", "application/json", `nope`, http.StatusBadRequest, config.ErrInvalidRequestBody},
+		{"Wrong password form", "/auth/login", "application/x-www-form-urlencoded", "username=ada&password=wrong", http.StatusUnauthorized, `value="ada"`},
+		{"Registration closed", "/auth/register", "application/json", `{"username":"bob","password":"correct horse"}`, http.StatusForbidden, config.ErrRegistrationClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body to contain %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	// Registered viewers read but do not write
+	enableLocalAccounts(service, true, "")
+	req = httptest.NewRequest("POST", "/auth/register", strings.NewReader(`{"username":"bob","password":"correct horse"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"role":"viewer"`) {
+		t.Fatalf("Expected a viewer registered, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/api/posts", nil)
+	req.AddCookie(w.Result().Cookies()[0])
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a viewer write, got %d", w.Code)
+	}
+}
+
+func TestSpaceACLHandlers(t *testing.T) {
+	service, _, cleanup := setupAuthTest(t)
+	defer cleanup()
+
+	spaceCache := cache.NewSpaceCache()
+	space, err := service.db.CreateSpace("Private", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	spaceCache.Set(space)
+	access := services.NewSpaceAccess(service.db, spaceCache)
+	service.SetSpaceAccess(access)
+
+	now := time.Now()
+	admin, _ := service.db.ProvisionUser("admin-1", "", "Admin", models.RoleAdmin, now.UnixMilli())
+	editor, _ := service.db.ProvisionUser("editor-1", "", "Editor", models.RoleEditor, now.UnixMilli())
+	service.db.CreateSession(hashToken("admin-token"), admin.ID, "", "", now.UnixMilli(), now.Add(time.Hour).UnixMilli())
+	service.db.CreateSession(hashToken("editor-token"), editor.ID, "", "", now.UnixMilli(), now.Add(time.Hour).UnixMilli())
+
+	handler := NewHandler(service)
+	router := mux.NewRouter()
+	router.Use(handler.Middleware)
+	handler.RegisterRoutes(router)
+	router.HandleFunc("/api/spaces/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		if !access.CanRead(r.Context(), space.ID) {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}).Methods("GET")
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(&http.Cookie{Name: config.SessionCookieName, Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	aclPath := fmt.Sprintf("/api/admin/spaces/%d/acl", space.ID)
+	spacePath := fmt.Sprintf("/api/spaces/%d", space.ID)
+
+	if w := send("PUT", aclPath, "editor-token", `{"entries":[]}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for an editor, got %d", w.Code)
+	}
+	if w := send("PUT", aclPath, "admin-token", `{"entries":[{"user_id":1,"permission":"owner"}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown permission, got %d", w.Code)
+	}
+	if w := send("GET", "/api/admin/spaces/999/acl", "admin-token", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a space without access list, got %d", w.Code)
+	}
+	if w := send("GET", spacePath, "editor-token", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected the public space to be readable, got %d", w.Code)
+	}
+
+	body := fmt.Sprintf(`{"entries":[{"user_id":%d,"permission":"write"}]}`, admin.ID)
+	w := send("PUT", aclPath, "admin-token", body)
+	var acl SpaceACL
+	json.NewDecoder(w.Body).Decode(&acl)
+	if w.Code != http.StatusOK || acl.SpaceID != space.ID || len(acl.Entries) != 1 {
+		t.Fatalf("Expected the access list to be set, got %d: %+v", w.Code, acl)
+	}
+
+	// Requests carry the viewer, so the editor left out of the list no longer sees the space
+	if w := send("GET", spacePath, "editor-token", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected the private space to be hidden from the editor, got %d", w.Code)
+	}
+	if w := send("GET", spacePath, "admin-token", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the private space to be readable by the admin, got %d", w.Code)
+	}
+	if w := send("GET", aclPath, "admin-token", ""); !strings.Contains(w.Body.String(), `"permission":"write"`) {
+		t.Errorf("Expected the stored access list, got %s", w.Body.String())
+	}
+}
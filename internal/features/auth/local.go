@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// localAccounts are the settings of the accounts signing in with a password kept by the
+// instance
+type localAccounts struct {
+	allowRegistration bool
+	role              string // Of registered accounts but the first one
+	hashCost          int
+	register          sync.Mutex // Registrations run one at a time so only the first account becomes admin
+	dummyOnce         sync.Once
+	dummyHash         []byte // Checked for unknown usernames, so they take as long as wrong passwords
+}
+
+var usernamePattern = regexp.MustCompile(config.UsernamePattern)
+
+// ValidateLocalAuthConfig checks the settings of local accounts
+func ValidateLocalAuthConfig(cfg config.LocalAuthConfig) error {
+	if cfg.DefaultRole != "" && models.RoleRank(cfg.DefaultRole) == 0 {
+		return fmt.Errorf(config.ErrLocalAuthInvalidRole)
+	}
+	return nil
+}
+
+// EnableLocalAccounts turns on accounts signing in with a password. The first account
+// registered becomes admin; later ones are only registered when allowRegistration is set, with
+// role, or viewer when it is empty.
+func (s *Service) EnableLocalAccounts(allowRegistration bool, role string) {
+	if role == "" {
+		role = models.RoleViewer
+	}
+	s.local = &localAccounts{
+		allowRegistration: allowRegistration,
+		role:              role,
+		hashCost:          config.PasswordHashCost,
+	}
+}
+
+// LocalAccounts reports whether users may sign in with a password
+func (s *Service) LocalAccounts() bool {
+	return s.local != nil
+}
+
+// RegistrationOpen reports whether an account may be registered now: while registration is
+// allowed, or until the instance has its first user
+func (s *Service) RegistrationOpen() (bool, error) {
+	if s.local == nil {
+		return false, nil
+	}
+	if s.local.allowRegistration {
+		return true, nil
+	}
+	count, err := s.db.CountUsers()
+	if err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// Register creates a local account and opens a session for it on the device of userAgent and
+// ip, like a login. Usernames are case insensitive.
+func (s *Service) Register(username, password, userAgent, ip string) (string, *models.User, error) {
+	if s.local == nil {
+		return "", nil, fmt.Errorf(config.ErrRegistrationClosed)
+	}
+	username = normalizeUsername(username)
+	if !usernamePattern.MatchString(username) {
+		return "", nil, fmt.Errorf(config.ErrInvalidUsername)
+	}
+	if len(password) < config.MinPasswordLength {
+		return "", nil, fmt.Errorf(config.ErrPasswordTooShort)
+	}
+	if len(password) > config.MaxPasswordLength {
+		return "", nil, fmt.Errorf(config.ErrPasswordTooLong)
+	}
+
+	s.local.register.Lock()
+	defer s.local.register.Unlock()
+
+	count, err := s.db.CountUsers()
+	if err != nil {
+		return "", nil, err
+	}
+	role := s.local.role
+	if count == 0 {
+		role = models.RoleAdmin
+	} else if !s.local.allowRegistration {
+		return "", nil, fmt.Errorf(config.ErrRegistrationClosed)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.local.hashCost)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	user, err := s.db.CreateLocalUser(config.LocalSubjectPrefix+username, username, role, string(hash), s.now().UnixMilli())
+	if err != nil {
+		if err.Error() == "user already exists" {
+			return "", nil, fmt.Errorf(config.ErrUsernameTaken)
+		}
+		return "", nil, err
+	}
+	logger.Info("Account registered", zap.Int("user_id", user.ID), zap.String("role", user.Role))
+
+	token, err := s.openSession(user, userAgent, ip)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, user, nil
+}
+
+// PasswordLogin checks the password of a local account and opens a session for it on the
+// device of userAgent and ip. Unknown usernames and wrong passwords fail alike.
+func (s *Service) PasswordLogin(username, password, userAgent, ip string) (string, *models.User, error) {
+	if s.local == nil {
+		return "", nil, fmt.Errorf(config.ErrInvalidCredentials)
+	}
+
+	user, hash, err := s.db.GetUserPassword(config.LocalSubjectPrefix + normalizeUsername(username))
+	if err != nil {
+		if err.Error() != "user not found" {
+			return "", nil, err
+		}
+		bcrypt.CompareHashAndPassword(s.local.dummy(), []byte(password))
+		return "", nil, fmt.Errorf(config.ErrInvalidCredentials)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		logger.Warning("Wrong password", zap.Int("user_id", user.ID))
+		return "", nil, fmt.Errorf(config.ErrInvalidCredentials)
+	}
+
+	now := s.now().UnixMilli()
+	if err := s.db.RecordLogin(user.ID, now); err != nil {
+		return "", nil, err
+	}
+	user.LastLogin = now
+
+	token, err := s.openSession(user, userAgent, ip)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, user, nil
+}
+
+// dummy returns a hash of the cost of real ones, made on first use
+func (l *localAccounts) dummy() []byte {
+	l.dummyOnce.Do(func() {
+		l.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("backthynk"), l.hashCost)
+	})
+	return l.dummyHash
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
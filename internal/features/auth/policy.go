@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"backthynk/internal/core/models"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Roles lists every role, from the least to the most privileged
+var Roles = []string{models.RoleViewer, models.RoleEditor, models.RoleAdmin}
+
+// publicPrefixes are reachable without a session: the login flow, assets and the endpoints
+// that authenticate requests with their own token
+var publicPrefixes = []string{
+	"/auth/",
+	"/static/",
+	"/api/shared/",
+	"/api/spaces/ingest/",
+}
+
+// signedPrefix serves files that signed URLs also reach without a session
+const signedPrefix = "/uploads/"
+
+// PolicyEntry is the effective access to one route
+type PolicyEntry struct {
+	Method string          `json:"method"` // * when the route accepts any method
+	Path   string          `json:"path"`   // Route template
+	Public bool            `json:"public"` // Reachable without a session
+	Signed bool            `json:"signed"` // Reachable without a session through a signed URL
+	Roles  map[string]bool `json:"roles"`  // Role -> allowed
+}
+
+// publicPath reports whether path is reachable without a session
+func publicPath(path string) bool {
+	for _, prefix := range publicPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy returns the effective access of every role to each route of router, in path and
+// method order. It is read from the router itself so routes added later show up on their own.
+func Policy(router *mux.Router) ([]PolicyEntry, error) {
+	var entries []PolicyEntry
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil // Subrouter prefixes
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"*"}
+		}
+
+		for _, method := range methods {
+			entry := PolicyEntry{
+				Method: method,
+				Path:   path,
+				Public: publicPath(path),
+				Signed: strings.HasPrefix(path, signedPrefix),
+				Roles:  make(map[string]bool, len(Roles)),
+			}
+			for _, role := range Roles {
+				entry.Roles[role] = entry.Public || Allowed(role, method, path)
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		return entries[i].Method < entries[j].Method
+	})
+	return entries, nil
+}
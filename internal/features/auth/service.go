@@ -0,0 +1,386 @@
+package auth
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service signs users in through a provider or with a local account, and keeps their
+// sessions. Provider users are provisioned on their first login and get their role from the
+// claims of every login, so a change of group at the provider applies on the next sign in.
+// Only hashes of session tokens and passwords are stored.
+type Service struct {
+	db          *storage.DB
+	provider    Provider
+	roleClaim   string
+	roleMapping map[string]string
+	defaultRole string
+	mu          sync.Mutex
+	logins      map[string]pendingLogin // By state
+	local       *localAccounts          // Nil unless local accounts are on
+	access      *services.SpaceAccess   // Nil unless space access lists apply
+	now         func() time.Time
+	enabled     bool
+}
+
+// pendingLogin is a login sent to the provider and not back yet
+type pendingLogin struct {
+	nonce   string
+	expires time.Time
+}
+
+// requestLogin is the session a request was authenticated with
+type requestLogin struct {
+	session *models.Session
+	user    *models.User
+}
+
+type loginContextKey struct{}
+
+func NewService(db *storage.DB, enabled bool) *Service {
+	return &Service{
+		db:        db,
+		roleClaim: config.DefaultOIDCRoleClaim,
+		logins:    make(map[string]pendingLogin),
+		now:       time.Now,
+		enabled:   enabled,
+	}
+}
+
+// ValidateOIDCConfig checks the settings OIDC login cannot work without
+func ValidateOIDCConfig(cfg config.OIDCConfig) error {
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return fmt.Errorf(config.ErrOIDCConfigIncomplete)
+	}
+	for _, role := range cfg.RoleMapping {
+		if models.RoleRank(role) == 0 {
+			return fmt.Errorf(config.ErrOIDCInvalidRole)
+		}
+	}
+	if cfg.DefaultRole != "" && models.RoleRank(cfg.DefaultRole) == 0 {
+		return fmt.Errorf(config.ErrOIDCInvalidRole)
+	}
+	return nil
+}
+
+// SetProvider sets the login method
+func (s *Service) SetProvider(provider Provider) {
+	s.provider = provider
+}
+
+// SetRoleMapping sets how roles are read from claims: values of claim are looked up in
+// mapping, and users matching none get defaultRole
+func (s *Service) SetRoleMapping(claim string, mapping map[string]string, defaultRole string) {
+	if claim != "" {
+		s.roleClaim = claim
+	}
+	s.roleMapping = mapping
+	s.defaultRole = defaultRole
+}
+
+// BeginLogin starts a login and returns its state along with the URL of the provider
+func (s *Service) BeginLogin(ctx context.Context) (string, string, error) {
+	state, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	target, err := s.provider.AuthURL(ctx, state, nonce)
+	if err != nil {
+		logger.Error("Failed to start login", zap.Error(err))
+		return "", "", fmt.Errorf(config.ErrOIDCUnavailable)
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	for key, login := range s.logins {
+		if now.After(login.expires) {
+			delete(s.logins, key)
+		}
+	}
+	s.logins[state] = pendingLogin{nonce: nonce, expires: now.Add(config.LoginStateTTL)}
+	s.mu.Unlock()
+
+	return state, target, nil
+}
+
+// CompleteLogin finishes the login of state with the code returned by the provider, and
+// opens a session for its user on the device of userAgent and ip. The session token is only
+// returned here.
+func (s *Service) CompleteLogin(ctx context.Context, state, code, userAgent, ip string) (string, *models.User, error) {
+	s.mu.Lock()
+	login, ok := s.logins[state]
+	delete(s.logins, state)
+	s.mu.Unlock()
+	if !ok || s.now().After(login.expires) {
+		return "", nil, fmt.Errorf(config.ErrLoginStateInvalid)
+	}
+
+	identity, err := s.provider.Exchange(ctx, code, login.nonce)
+	if err != nil {
+		logger.Warning("Login refused by provider", zap.Error(err))
+		return "", nil, fmt.Errorf(config.ErrLoginFailed)
+	}
+
+	role := s.MapRole(identity.Claims)
+	if role == "" {
+		logger.Warning("Login without a mapped role", zap.String("subject", identity.Subject))
+		return "", nil, fmt.Errorf(config.ErrLoginNoRole)
+	}
+
+	// Subjects of local accounts are never taken from a provider
+	if strings.HasPrefix(identity.Subject, config.LocalSubjectPrefix) {
+		logger.Warning("Login with a local account subject refused", zap.String("subject", identity.Subject))
+		return "", nil, fmt.Errorf(config.ErrLoginFailed)
+	}
+
+	user, err := s.db.ProvisionUser(identity.Subject, identity.Email, identity.Name, role, s.now().UnixMilli())
+	if err != nil {
+		return "", nil, err
+	}
+
+	token, err := s.openSession(user, userAgent, ip)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, user, nil
+}
+
+// openSession opens a session for user on the device of userAgent and ip and returns its
+// token, clearing expired sessions on the way
+func (s *Service) openSession(user *models.User, userAgent, ip string) (string, error) {
+	now := s.now()
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.db.DeleteExpiredSessions(now.UnixMilli()); err != nil {
+		return "", err
+	}
+	err = s.db.CreateSession(hashToken(token), user.ID, truncateUserAgent(userAgent), ip, now.UnixMilli(), now.Add(config.SessionTTL).UnixMilli())
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("User logged in", zap.Int("user_id", user.ID), zap.String("role", user.Role))
+	return token, nil
+}
+
+// MapRole returns the highest role mapped from the values of the role claim, the default
+// role when none matches, or an empty string when the user gets no role
+func (s *Service) MapRole(claims map[string]interface{}) string {
+	var values []string
+	switch v := claims[s.roleClaim].(type) {
+	case string:
+		values = strings.Fields(v)
+	case []interface{}:
+		for _, item := range v {
+			if value, ok := item.(string); ok {
+				values = append(values, value)
+			}
+		}
+	}
+
+	role := ""
+	for _, value := range values {
+		if mapped := s.roleMapping[value]; models.RoleRank(mapped) > models.RoleRank(role) {
+			role = mapped
+		}
+	}
+	if role == "" {
+		return s.defaultRole
+	}
+	return role
+}
+
+// Authenticate returns the session of a token and its user. The device and last seen time of
+// the session are refreshed at most every SessionTouchInterval.
+func (s *Service) Authenticate(token, userAgent, ip string) (*models.Session, *models.User, error) {
+	now := s.now()
+	session, user, err := s.db.GetSession(hashToken(token), now.UnixMilli())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if now.UnixMilli()-session.LastSeen >= config.SessionTouchInterval.Milliseconds() {
+		session.UserAgent = truncateUserAgent(userAgent)
+		session.IP = ip
+		session.LastSeen = now.UnixMilli()
+		if err := s.db.TouchSession(session.ID, session.UserAgent, session.IP, session.LastSeen); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return session, user, nil
+}
+
+// Logout ends the session of a token
+func (s *Service) Logout(token string) error {
+	return s.db.DeleteSession(hashToken(token))
+}
+
+// GetSessions returns the active sessions of a user, flagging currentID as the current one
+func (s *Service) GetSessions(userID, currentID int) ([]models.Session, error) {
+	sessions, err := s.db.GetUserSessions(userID, s.now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Device = deviceName(sessions[i].UserAgent)
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one session of a user, who may end the current one as well
+func (s *Service) RevokeSession(userID, id int) error {
+	if err := s.db.DeleteUserSession(userID, id); err != nil {
+		if err.Error() == "session not found" {
+			return fmt.Errorf(config.ErrSessionNotFound)
+		}
+		return err
+	}
+	logger.Info("Session revoked", zap.Int("user_id", userID), zap.Int("session_id", id))
+	return nil
+}
+
+// RevokeOtherSessions ends every session of a user but the current one, returning how many
+// were ended
+func (s *Service) RevokeOtherSessions(userID, currentID int) (int, error) {
+	revoked, err := s.db.DeleteOtherUserSessions(userID, currentID)
+	if err != nil {
+		return 0, err
+	}
+	logger.Info("Other sessions revoked", zap.Int("user_id", userID), zap.Int("count", revoked))
+	return revoked, nil
+}
+
+// GetUsers returns the provisioned users
+func (s *Service) GetUsers() ([]models.User, error) {
+	return s.db.GetUsers()
+}
+
+// Allowed reports whether role may send method to path: viewers only read, and only admins
+// reach the /api/admin endpoints, the server logs and changes to the server settings. Every
+// role manages its own sessions and preferences, and sends GraphQL queries, which only read.
+func Allowed(role, method, path string) bool {
+	if path == "/api/sessions" || strings.HasPrefix(path, "/api/sessions/") || path == "/api/preferences" || path == "/api/graphql" {
+		return models.RoleRank(role) >= models.RoleRank(models.RoleViewer)
+	}
+	if strings.HasPrefix(path, "/api/admin/") || path == "/api/logs" {
+		return role == models.RoleAdmin
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return models.RoleRank(role) >= models.RoleRank(models.RoleViewer)
+	}
+	if path == "/api/settings" {
+		return role == models.RoleAdmin
+	}
+	return models.RoleRank(role) >= models.RoleRank(models.RoleEditor)
+}
+
+// UserFromContext returns the user signed in for a request, nil without login
+func UserFromContext(ctx context.Context) *models.User {
+	login, _ := ctx.Value(loginContextKey{}).(*requestLogin)
+	if login == nil {
+		return nil
+	}
+	return login.user
+}
+
+// SessionFromContext returns the session of a request, nil without login
+func SessionFromContext(ctx context.Context) *models.Session {
+	login, _ := ctx.Value(loginContextKey{}).(*requestLogin)
+	if login == nil {
+		return nil
+	}
+	return login.session
+}
+
+func withLogin(ctx context.Context, session *models.Session, user *models.User) context.Context {
+	return context.WithValue(ctx, loginContextKey{}, &requestLogin{session: session, user: user})
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, config.SessionTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) > config.MaxUserAgentLength {
+		return userAgent[:config.MaxUserAgentLength]
+	}
+	return userAgent
+}
+
+// deviceName describes the browser and system of a user agent, such as "Firefox on Linux"
+func deviceName(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, candidate := range []struct{ token, name string }{
+		// Order matters: Edge and Opera also announce Chrome, and Chrome announces Safari
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	system := ""
+	for _, candidate := range []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, candidate.token) {
+			system = candidate.name
+			break
+		}
+	}
+
+	if system == "" {
+		return browser
+	}
+	return browser + " on " + system
+}
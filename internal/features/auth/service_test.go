@@ -0,0 +1,413 @@
+package auth
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const firefoxLinux = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+
+// fakeIssuer is an OpenID Connect provider issuing ID tokens with the claims set by the test
+type fakeIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	nonce  string                 // Nonce of the last authorization request
+	claims map[string]interface{} // Extra claims of the next ID token
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	issuer := &fakeIssuer{key: key, claims: map[string]interface{}{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.server.URL,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"jwks_uri":               issuer.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "backthynk" || secret != "s3cret" || r.FormValue("code") != "good-code" {
+			http.Error(w, "invalid_grant", http.StatusBadRequest)
+			return
+		}
+		claims := map[string]interface{}{
+			"iss":   issuer.server.URL,
+			"aud":   "backthynk",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": issuer.nonce,
+			"sub":   "user-1",
+			"email": "ada@example.com",
+			"name":  "Ada",
+		}
+		for name, value := range issuer.claims {
+			claims[name] = value
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": issuer.sign(t, claims)})
+	})
+	issuer.server = httptest.NewServer(mux)
+	return issuer
+}
+
+func (f *fakeIssuer) sign(t *testing.T, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func setupAuthTest(t *testing.T) (*Service, *fakeIssuer, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_auth_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	issuer := newFakeIssuer(t)
+	service := NewService(db, true)
+	service.SetProvider(NewOIDCProvider(config.OIDCConfig{
+		Issuer:       issuer.server.URL,
+		ClientID:     "backthynk",
+		ClientSecret: "s3cret",
+		RedirectURL:  "https://notes.example.com/auth/oidc/callback",
+	}))
+	service.SetRoleMapping("groups", map[string]string{"notes-admins": models.RoleAdmin, "notes-users": models.RoleEditor}, "")
+
+	return service, issuer, func() {
+		issuer.server.Close()
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+// login runs the flow a browser goes through and returns the session token
+func login(t *testing.T, service *Service, issuer *fakeIssuer, code string) (string, *models.User, error) {
+	t.Helper()
+	state, target, err := service.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatalf("BeginLogin failed: %v", err)
+	}
+	parsed, _ := url.Parse(target)
+	if parsed.Query().Get("state") != state || parsed.Query().Get("client_id") != "backthynk" {
+		t.Fatalf("Unexpected authorization URL %s", target)
+	}
+	issuer.nonce = parsed.Query().Get("nonce")
+	return service.CompleteLogin(context.Background(), state, code, firefoxLinux, "192.0.2.1")
+}
+
+func TestLogin(t *testing.T) {
+	service, issuer, cleanup := setupAuthTest(t)
+	defer cleanup()
+
+	// Provisioned with the highest role mapped from the groups claim
+	issuer.claims["groups"] = []interface{}{"notes-users", "notes-admins", "other"}
+	token, user, err := login(t, service, issuer, "good-code")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if user.Subject != "user-1" || user.Email != "ada@example.com" || user.Name != "Ada" || user.Role != models.RoleAdmin {
+		t.Errorf("Unexpected user %+v", user)
+	}
+	if _, authenticated, err := service.Authenticate(token, firefoxLinux, "192.0.2.1"); err != nil || authenticated.ID != user.ID {
+		t.Errorf("Expected the session to authenticate the user, got %+v, %v", authenticated, err)
+	}
+
+	// The role follows the claims of every login
+	issuer.claims["groups"] = []interface{}{"notes-users"}
+	_, again, err := login(t, service, issuer, "good-code")
+	if err != nil || again.ID != user.ID || again.Role != models.RoleEditor {
+		t.Errorf("Expected the same user demoted to editor, got %+v, %v", again, err)
+	}
+	if users, _ := service.GetUsers(); len(users) != 1 {
+		t.Errorf("Expected one provisioned user, got %d", len(users))
+	}
+
+	// Logout ends the session
+	if err := service.Logout(token); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if _, _, err := service.Authenticate(token, firefoxLinux, "192.0.2.1"); err == nil {
+		t.Error("Expected the session to be gone after logout")
+	}
+}
+
+func TestLoginRefused(t *testing.T) {
+	service, issuer, cleanup := setupAuthTest(t)
+	defer cleanup()
+
+	if _, _, err := service.CompleteLogin(context.Background(), "unknown", "good-code", "", ""); err == nil || err.Error() != config.ErrLoginStateInvalid {
+		t.Errorf("Expected an unknown state to be refused, got %v", err)
+	}
+	if _, _, err := login(t, service, issuer, "bad-code"); err == nil || err.Error() != config.ErrLoginFailed {
+		t.Errorf("Expected a refused code to fail, got %v", err)
+	}
+
+	// Tokens for another client or replayed with another nonce are refused
+	issuer.claims["groups"] = "notes-admins"
+	issuer.claims["aud"] = "other-client"
+	if _, _, err := login(t, service, issuer, "good-code"); err == nil || err.Error() != config.ErrLoginFailed {
+		t.Errorf("Expected a token for another audience to be refused, got %v", err)
+	}
+	delete(issuer.claims, "aud")
+	issuer.claims["nonce"] = "replayed"
+	if _, _, err := login(t, service, issuer, "good-code"); err == nil || err.Error() != config.ErrLoginFailed {
+		t.Errorf("Expected a token with another nonce to be refused, got %v", err)
+	}
+	delete(issuer.claims, "nonce")
+
+	// Users without a mapped role need a default role
+	issuer.claims["groups"] = "guests"
+	if _, _, err := login(t, service, issuer, "good-code"); err == nil || err.Error() != config.ErrLoginNoRole {
+		t.Errorf("Expected a user without role to be refused, got %v", err)
+	}
+	service.SetRoleMapping("groups", service.roleMapping, models.RoleViewer)
+	if _, user, err := login(t, service, issuer, "good-code"); err != nil || user.Role != models.RoleViewer {
+		t.Errorf("Expected the default role, got %+v, %v", user, err)
+	}
+}
+
+// enableLocalAccounts turns on local accounts with cheap hashes
+func enableLocalAccounts(service *Service, allowRegistration bool, role string) {
+	service.EnableLocalAccounts(allowRegistration, role)
+	service.local.hashCost = bcrypt.MinCost
+}
+
+func TestLocalAccounts(t *testing.T) {
+	service, _, cleanup := setupAuthTest(t)
+	defer cleanup()
+	enableLocalAccounts(service, false, "")
+
+	// The first account becomes admin, then registration closes
+	if open, err := service.RegistrationOpen(); err != nil || !open {
+		t.Errorf("Expected registration open for the first account, got %v, %v", open, err)
+	}
+	token, user, err := service.Register(" Ada ", "correct horse", firefoxLinux, "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if user.Subject != config.LocalSubjectPrefix+"ada" || user.Name != "ada" || user.Role != models.RoleAdmin {
+		t.Errorf("Unexpected user %+v", user)
+	}
+	if _, authenticated, err := service.Authenticate(token, firefoxLinux, "192.0.2.1"); err != nil || authenticated.ID != user.ID {
+		t.Errorf("Expected the session to authenticate the user, got %+v, %v", authenticated, err)
+	}
+	if _, _, err := service.Register("bob", "correct horse", "", ""); err == nil || err.Error() != config.ErrRegistrationClosed {
+		t.Errorf("Expected registration closed after the first account, got %v", err)
+	}
+
+	// Later accounts get the configured role
+	enableLocalAccounts(service, true, models.RoleEditor)
+	if _, bob, err := service.Register("bob", "correct horse", "", ""); err != nil || bob.Role != models.RoleEditor {
+		t.Errorf("Expected an editor, got %+v, %v", bob, err)
+	}
+
+	refused := []struct {
+		username, password, expected string
+	}{
+		{"ADA", "correct horse", config.ErrUsernameTaken},
+		{"a", "correct horse", config.ErrInvalidUsername},
+		{"ada lovelace", "correct horse", config.ErrInvalidUsername},
+		{"carol", "short", config.ErrPasswordTooShort},
+		{"carol", strings.Repeat("x", config.MaxPasswordLength+1), config.ErrPasswordTooLong},
+	}
+	for _, tt := range refused {
+		if _, _, err := service.Register(tt.username, tt.password, "", ""); err == nil || err.Error() != tt.expected {
+			t.Errorf("Register(%q) expected %q, got %v", tt.username, tt.expected, err)
+		}
+	}
+
+	// Logins are case insensitive on the username only
+	if _, again, err := service.PasswordLogin("Ada", "correct horse", firefoxLinux, "192.0.2.1"); err != nil || again.ID != user.ID {
+		t.Errorf("Expected the login of ada, got %+v, %v", again, err)
+	}
+	for _, username := range []string{"ada", "nobody"} {
+		if _, _, err := service.PasswordLogin(username, "Correct horse", "", ""); err == nil || err.Error() != config.ErrInvalidCredentials {
+			t.Errorf("Expected invalid credentials for %s, got %v", username, err)
+		}
+	}
+}
+
+func TestLocalSubjectFromProvider(t *testing.T) {
+	service, issuer, cleanup := setupAuthTest(t)
+	defer cleanup()
+
+	// A provider cannot sign in as a local account
+	issuer.claims["groups"] = "notes-admins"
+	issuer.claims["sub"] = config.LocalSubjectPrefix + "ada"
+	if _, _, err := login(t, service, issuer, "good-code"); err == nil || err.Error() != config.ErrLoginFailed {
+		t.Errorf("Expected a local subject to be refused, got %v", err)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	service, issuer, cleanup := setupAuthTest(t)
+	defer cleanup()
+
+	issuer.claims["groups"] = "notes-users"
+	laptop, user, err := login(t, service, issuer, "good-code")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	phone, _, _ := login(t, service, issuer, "good-code")
+	tablet, _, _ := login(t, service, issuer, "good-code")
+
+	// The device of a session follows the requests made with it, at most every touch interval
+	clock := time.Now().Add(time.Hour)
+	service.now = func() time.Time { return clock }
+	const android = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Mobile Safari/537.36"
+	current, _, err := service.Authenticate(phone, android, "198.51.100.7")
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+
+	sessions, err := service.GetSessions(user.ID, current.ID)
+	if err != nil || len(sessions) != 3 {
+		t.Fatalf("Expected 3 sessions, got %d: %v", len(sessions), err)
+	}
+	first := sessions[0]
+	if first.ID != current.ID || !first.Current || first.Device != "Chrome on Android" || first.IP != "198.51.100.7" || first.LastSeen != clock.UnixMilli() {
+		t.Errorf("Expected the current session first with its new device, got %+v", first)
+	}
+	if sessions[1].Current || sessions[1].Device != "Firefox on Linux" {
+		t.Errorf("Unexpected other session %+v", sessions[1])
+	}
+
+	// Sessions of other users are out of reach
+	if err := service.RevokeSession(user.ID+1, sessions[1].ID); err == nil || err.Error() != config.ErrSessionNotFound {
+		t.Errorf("Expected another user's session to be not found, got %v", err)
+	}
+
+	laptopSession, _, _ := service.Authenticate(laptop, firefoxLinux, "192.0.2.1")
+	if err := service.RevokeSession(user.ID, laptopSession.ID); err != nil {
+		t.Fatalf("RevokeSession failed: %v", err)
+	}
+	if _, _, err := service.Authenticate(laptop, firefoxLinux, "192.0.2.1"); err == nil {
+		t.Error("Expected the revoked session to be gone")
+	}
+
+	if revoked, err := service.RevokeOtherSessions(user.ID, current.ID); err != nil || revoked != 1 {
+		t.Errorf("Expected one other session revoked, got %d: %v", revoked, err)
+	}
+	if _, _, err := service.Authenticate(tablet, firefoxLinux, "192.0.2.1"); err == nil {
+		t.Error("Expected the other sessions to be gone")
+	}
+	if _, _, err := service.Authenticate(phone, android, "198.51.100.7"); err != nil {
+		t.Errorf("Expected the current session to remain, got %v", err)
+	}
+}
+
+func TestDeviceName(t *testing.T) {
+	tests := map[string]string{
+		firefoxLinux: "Firefox on Linux",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36 Edg/126.0":                   "Edge on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1": "Safari on iOS",
+		"curl/8.5.0": "curl",
+		"":           "Unknown device",
+	}
+	for userAgent, expected := range tests {
+		if got := deviceName(userAgent); got != expected {
+			t.Errorf("deviceName(%q) = %q, expected %q", userAgent, got, expected)
+		}
+	}
+}
+
+func TestValidateOIDCConfig(t *testing.T) {
+	valid := config.OIDCConfig{Issuer: "https://id.example.com", ClientID: "backthynk", RedirectURL: "https://notes.example.com/auth/oidc/callback"}
+	if err := ValidateOIDCConfig(valid); err != nil {
+		t.Errorf("Expected a valid config, got %v", err)
+	}
+
+	missing := valid
+	missing.ClientID = ""
+	if err := ValidateOIDCConfig(missing); err == nil {
+		t.Error("Expected a config without client ID to be refused")
+	}
+
+	badRole := valid
+	badRole.RoleMapping = map[string]string{"staff": "owner"}
+	if err := ValidateOIDCConfig(badRole); err == nil {
+		t.Error("Expected an unknown role to be refused")
+	}
+}
+
+func TestValidateLocalAuthConfig(t *testing.T) {
+	if err := ValidateLocalAuthConfig(config.LocalAuthConfig{Enabled: true}); err != nil {
+		t.Errorf("Expected a valid config, got %v", err)
+	}
+	if err := ValidateLocalAuthConfig(config.LocalAuthConfig{Enabled: true, DefaultRole: "owner"}); err == nil {
+		t.Error("Expected an unknown role to be refused")
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		role, method, path string
+		allowed            bool
+	}{
+		{models.RoleViewer, "GET", "/api/spaces", true},
+		{models.RoleViewer, "POST", "/api/posts", false},
+		{models.RoleEditor, "POST", "/api/posts", true},
+		{models.RoleEditor, "GET", "/api/admin/users", false},
+		{models.RoleAdmin, "POST", "/api/admin/space-cache/reconcile", true},
+		{models.RoleViewer, "DELETE", "/api/sessions/others", true},
+		{models.RoleViewer, "PUT", "/api/preferences", true},
+		{models.RoleViewer, "POST", "/api/graphql", true},
+		{models.RoleEditor, "PUT", "/api/admin/preferences", false},
+		{models.RoleEditor, "GET", "/api/logs", false},
+		{models.RoleAdmin, "GET", "/api/logs", true},
+		{models.RoleViewer, "GET", "/api/settings", true},
+		{models.RoleEditor, "PUT", "/api/settings", false},
+		{models.RoleAdmin, "PUT", "/api/settings", true},
+		{"", "GET", "/api/spaces", false},
+	}
+	for _, tt := range tests {
+		if got := Allowed(tt.role, tt.method, tt.path); got != tt.allowed {
+			t.Errorf("Allowed(%q, %s, %s) = %v, expected %v", tt.role, tt.method, tt.path, got, tt.allowed)
+		}
+	}
+}
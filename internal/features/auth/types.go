@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"backthynk/internal/core/models"
+	"context"
+)
+
+// Identity is what a provider asserts about the user who just logged in
+type Identity struct {
+	Subject string
+	Email   string
+	Name    string
+	Claims  map[string]interface{} // Every claim of the login, roles are mapped from one of them
+}
+
+// Provider is a login method. AuthURL is where the browser is sent to sign in; the provider
+// sends it back to the callback with a code that Exchange turns into the identity of the user.
+// State and nonce are checked by the service and the provider respectively.
+type Provider interface {
+	AuthURL(ctx context.Context, state, nonce string) (string, error)
+	Exchange(ctx context.Context, code, nonce string) (*Identity, error)
+}
+
+// Credentials is the body of a password login or registration, sent as JSON or as a form
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// loginPage is the data of the login page
+type loginPage struct {
+	Error             string
+	Username          string // Kept after a failed login
+	SingleSignOn      bool
+	Registration      bool
+	MinPasswordLength int
+}
+
+// SpaceACL is the access list of a space, see services.SpaceAccess
+type SpaceACL struct {
+	SpaceID int                    `json:"space_id"`
+	Entries []models.SpaceACLEntry `json:"entries"`
+}
@@ -0,0 +1,119 @@
+package board
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/board", h.GetBoard).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/board", h.ReorderBoard).Methods("PUT")
+	api.HandleFunc("/posts/{id:[0-9]+}/board", h.PinPost).Methods("PUT")
+	api.HandleFunc("/posts/{id:[0-9]+}/board", h.UnpinPost).Methods("DELETE")
+}
+
+// GetBoard handles GET /api/spaces/{id}/board
+func (h *Handler) GetBoard(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	board, err := h.service.GetBoard(r.Context(), spaceID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+// ReorderBoard handles PUT /api/spaces/{id}/board
+func (h *Handler) ReorderBoard(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req ReorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	board, err := h.service.Reorder(r.Context(), spaceID, req.PostIDs)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+// PinPost handles PUT /api/posts/{id}/board
+func (h *Handler) PinPost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Pin(r.Context(), postID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnpinPost handles DELETE /api/posts/{id}/board
+func (h *Handler) UnpinPost(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Unpin(r.Context(), postID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case config.ErrSpaceNotFound, config.ErrPostNotFound, config.ErrBoardPinNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case config.ErrSpaceAccessDenied:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case config.ErrBoardFull:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case config.ErrBoardOrderMismatch:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
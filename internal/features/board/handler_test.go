@@ -0,0 +1,71 @@
+package board
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/spaces/1/board", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected board routes NOT to be registered when disabled")
+	}
+}
+
+func TestBoardHandlers(t *testing.T) {
+	setup := setupBoardTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Project", nil, "")
+	first, _ := setup.postService.Create(context.Background(), space.ID, "First", nil)
+	second, _ := setup.postService.Create(context.Background(), space.ID, "Second", nil)
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	boardPath := fmt.Sprintf("/api/spaces/%d/board", space.ID)
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Empty board", "GET", boardPath, "", http.StatusOK, `"posts":[]`},
+		{"Pin first", "PUT", fmt.Sprintf("/api/posts/%d/board", first.ID), "", http.StatusNoContent, ""},
+		{"Pin second", "PUT", fmt.Sprintf("/api/posts/%d/board", second.ID), "", http.StatusNoContent, ""},
+		{"Pin unknown post", "PUT", "/api/posts/999/board", "", http.StatusNotFound, ""},
+		{"Board", "GET", boardPath, "", http.StatusOK, `"html":"First","attachments":[]`},
+		{"Reorder", "PUT", boardPath, fmt.Sprintf(`{"post_ids":[%d,%d]}`, second.ID, first.ID), http.StatusOK, fmt.Sprintf(`"posts":[{"id":%d`, second.ID)},
+		{"Reorder missing post", "PUT", boardPath, fmt.Sprintf(`{"post_ids":[%d]}`, second.ID), http.StatusBadRequest, ""},
+		{"Reorder invalid JSON", "PUT", boardPath, `nope`, http.StatusBadRequest, ""},
+		{"Unpin", "DELETE", fmt.Sprintf("/api/posts/%d/board", first.ID), "", http.StatusNoContent, ""},
+		{"Unpin again", "DELETE", fmt.Sprintf("/api/posts/%d/board", first.ID), "", http.StatusNotFound, ""},
+		{"Unknown space", "GET", "/api/spaces/999/board", "", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !bytes.Contains(w.Body.Bytes(), []byte(tt.expectedBody)) {
+				t.Errorf("Expected body to contain %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package board
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Renderer turns post content into HTML, e.g. the core PostService
+type Renderer interface {
+	RenderContent(spaceID int, content string) string
+}
+
+// Service keeps a handful of posts per space pinned to its board, a persistent overview of
+// links, references and decisions. A pin belongs to the post: it goes with the post when the
+// post moves to another space and is dropped when the post is deleted.
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	renderer Renderer
+	access   *services.SpaceAccess
+	now      func() time.Time
+	enabled  bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		now:      time.Now,
+		enabled:  enabled,
+	}
+}
+
+// SetRenderer renders board posts like the app does; without one plain markdown is used
+func (s *Service) SetRenderer(renderer Renderer) {
+	s.renderer = renderer
+}
+
+// SetSpaceAccess hides the boards of spaces hidden from the viewer of a request and keeps it
+// from changing the boards of spaces it may only read
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// GetBoard returns the pinned posts of a space in board order
+func (s *Service) GetBoard(ctx context.Context, spaceID int) (*Board, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	pins, err := s.db.GetBoardPins(spaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	board := &Board{SpaceID: spaceID, Posts: make([]BoardPost, 0, len(pins))}
+	for _, pin := range pins {
+		attachments, err := s.db.GetAttachmentsByPost(pin.Post.ID)
+		if err != nil {
+			return nil, err
+		}
+		if attachments == nil {
+			attachments = []models.Attachment{}
+		}
+		board.Posts = append(board.Posts, BoardPost{
+			Post:        pin.Post,
+			HTML:        s.render(pin.Post.SpaceID, pin.Post.Content),
+			Attachments: attachments,
+			Position:    pin.Position,
+			Pinned:      pin.Pinned,
+		})
+	}
+
+	return board, nil
+}
+
+// Pin adds a post at the end of the board of its space
+func (s *Service) Pin(ctx context.Context, postID int) error {
+	if err := s.checkPost(ctx, postID); err != nil {
+		return err
+	}
+	if err := s.db.PinPost(postID, s.now().UnixMilli(), config.MaxBoardPins); err != nil {
+		switch err.Error() {
+		case "post not found":
+			return fmt.Errorf(config.ErrPostNotFound)
+		case "board is full":
+			return fmt.Errorf(config.ErrBoardFull)
+		}
+		return err
+	}
+	return nil
+}
+
+// Unpin removes a post from the board of its space
+func (s *Service) Unpin(ctx context.Context, postID int) error {
+	if err := s.checkPost(ctx, postID); err != nil {
+		return err
+	}
+	if err := s.db.UnpinPost(postID); err != nil {
+		if err.Error() == "board pin not found" {
+			return fmt.Errorf(config.ErrBoardPinNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// Reorder puts the pinned posts of a space in the order of postIDs, which must list each of
+// them once
+func (s *Service) Reorder(ctx context.Context, spaceID int, postIDs []int) (*Board, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if !s.access.CanWrite(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+
+	pins, err := s.db.GetBoardPins(spaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := make(map[int]bool, len(pins))
+	for _, pin := range pins {
+		pinned[pin.Post.ID] = true
+	}
+	if len(postIDs) != len(pins) {
+		return nil, fmt.Errorf(config.ErrBoardOrderMismatch)
+	}
+	for _, postID := range postIDs {
+		if !pinned[postID] {
+			return nil, fmt.Errorf(config.ErrBoardOrderMismatch)
+		}
+		delete(pinned, postID) // Listed twice fails on the second occurrence
+	}
+
+	if err := s.db.SetBoardOrder(postIDs); err != nil {
+		return nil, err
+	}
+	return s.GetBoard(ctx, spaceID)
+}
+
+// checkPost lets the viewer of ctx change the board of the space of a post only if it may
+// change that space; a post it may not see is not found
+func (s *Service) checkPost(ctx context.Context, postID int) error {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		if err.Error() == "post not found" {
+			return fmt.Errorf(config.ErrPostNotFound)
+		}
+		return err
+	}
+	if !s.access.CanRead(ctx, post.SpaceID) {
+		return fmt.Errorf(config.ErrPostNotFound)
+	}
+	if !s.access.CanWrite(ctx, post.SpaceID) {
+		return fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+	return nil
+}
+
+func (s *Service) render(spaceID int, content string) string {
+	if s.renderer != nil {
+		return s.renderer.RenderContent(spaceID, content)
+	}
+	return utils.ProcessMarkdown(content)
+}
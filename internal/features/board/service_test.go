@@ -0,0 +1,165 @@
+package board
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"testing"
+)
+
+type boardTestSetup struct {
+	db           *storage.DB
+	spaceService *services.SpaceService
+	postService  *services.PostService
+	service      *Service
+	cleanup      func()
+}
+
+func setupBoardTest(t *testing.T) *boardTestSetup {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_board_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	setup := &boardTestSetup{
+		db:           db,
+		spaceService: services.NewSpaceService(db, catCache, dispatcher),
+		postService:  services.NewPostService(db, catCache, dispatcher),
+		service:      NewService(db, catCache, true),
+		cleanup: func() {
+			db.Close()
+			os.RemoveAll(tempDir)
+		},
+	}
+	setup.service.SetRenderer(setup.postService)
+	return setup
+}
+
+func boardPostIDs(board *Board) []int {
+	ids := []int{}
+	for _, post := range board.Posts {
+		ids = append(ids, post.ID)
+	}
+	return ids
+}
+
+func TestBoardPinOrderAndRendering(t *testing.T) {
+	setup := setupBoardTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Project", nil, "")
+	links, _ := setup.postService.Create(context.Background(), space.ID, "**Links** to the staging server", nil)
+	decision, _ := setup.postService.Create(context.Background(), space.ID, "Decision: ship on Fridays", nil)
+	setup.postService.Create(context.Background(), space.ID, "Just a note", nil)
+	setup.db.CreateAttachment(links.ID, "diagram.png", "diagram.png", "image/png", 1024)
+
+	for _, id := range []int{decision.ID, links.ID, links.ID} {
+		if err := setup.service.Pin(context.Background(), id); err != nil {
+			t.Fatalf("Failed to pin post %d: %v", id, err)
+		}
+	}
+
+	board, err := setup.service.GetBoard(context.Background(), space.ID)
+	if err != nil {
+		t.Fatalf("Failed to get board: %v", err)
+	}
+	if ids := boardPostIDs(board); len(ids) != 2 || ids[0] != decision.ID || ids[1] != links.ID {
+		t.Fatalf("Expected pinned posts in pin order without duplicates, got %v", ids)
+	}
+	if want := setup.postService.RenderContent(space.ID, links.Content); board.Posts[1].HTML != want {
+		t.Errorf("Expected content rendered like the feed, got %q", board.Posts[1].HTML)
+	}
+	if len(board.Posts[1].Attachments) != 1 || board.Posts[1].Attachments[0].Filename != "diagram.png" {
+		t.Errorf("Expected attachment on pinned post, got %+v", board.Posts[1].Attachments)
+	}
+
+	board, err = setup.service.Reorder(context.Background(), space.ID, []int{links.ID, decision.ID})
+	if err != nil {
+		t.Fatalf("Failed to reorder board: %v", err)
+	}
+	if ids := boardPostIDs(board); ids[0] != links.ID || ids[1] != decision.ID {
+		t.Errorf("Expected reordered board, got %v", ids)
+	}
+
+	for _, order := range [][]int{{links.ID}, {links.ID, links.ID}, {links.ID, decision.ID, 999}} {
+		if _, err := setup.service.Reorder(context.Background(), space.ID, order); err == nil || err.Error() != config.ErrBoardOrderMismatch {
+			t.Errorf("Expected order mismatch for %v, got %v", order, err)
+		}
+	}
+
+	if err := setup.service.Unpin(context.Background(), links.ID); err != nil {
+		t.Fatalf("Failed to unpin post: %v", err)
+	}
+	if err := setup.service.Unpin(context.Background(), links.ID); err == nil || err.Error() != config.ErrBoardPinNotFound {
+		t.Errorf("Expected pin not found, got %v", err)
+	}
+}
+
+func TestBoardFollowsPosts(t *testing.T) {
+	setup := setupBoardTest(t)
+	defer setup.cleanup()
+
+	first, _ := setup.spaceService.Create(context.Background(), "First", nil, "")
+	second, _ := setup.spaceService.Create(context.Background(), "Second", nil, "")
+	moved, _ := setup.postService.Create(context.Background(), first.ID, "Moves along", nil)
+	deleted, _ := setup.postService.Create(context.Background(), first.ID, "Goes away", nil)
+	setup.service.Pin(context.Background(), moved.ID)
+	setup.service.Pin(context.Background(), deleted.ID)
+
+	if err := setup.postService.Move(context.Background(), moved.ID, second.ID); err != nil {
+		t.Fatalf("Failed to move post: %v", err)
+	}
+	if err := setup.postService.Delete(context.Background(), deleted.ID); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+
+	board, _ := setup.service.GetBoard(context.Background(), first.ID)
+	if len(board.Posts) != 0 {
+		t.Errorf("Expected empty board after move and delete, got %v", boardPostIDs(board))
+	}
+	board, _ = setup.service.GetBoard(context.Background(), second.ID)
+	if ids := boardPostIDs(board); len(ids) != 1 || ids[0] != moved.ID {
+		t.Errorf("Expected moved post on the board of its new space, got %v", ids)
+	}
+
+	if err := setup.service.Pin(context.Background(), deleted.ID); err == nil || err.Error() != config.ErrPostNotFound {
+		t.Errorf("Expected post not found, got %v", err)
+	}
+	if _, err := setup.service.GetBoard(context.Background(), 999); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected space not found, got %v", err)
+	}
+}
+
+func TestBoardLimit(t *testing.T) {
+	setup := setupBoardTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Busy", nil, "")
+	for i := 0; i < config.MaxBoardPins; i++ {
+		post, _ := setup.postService.Create(context.Background(), space.ID, "Pinned", nil)
+		if err := setup.service.Pin(context.Background(), post.ID); err != nil {
+			t.Fatalf("Failed to pin post %d: %v", i, err)
+		}
+	}
+
+	extra, _ := setup.postService.Create(context.Background(), space.ID, "One too many", nil)
+	if err := setup.service.Pin(context.Background(), extra.ID); err == nil || err.Error() != config.ErrBoardFull {
+		t.Errorf("Expected full board, got %v", err)
+	}
+}
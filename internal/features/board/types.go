@@ -0,0 +1,22 @@
+package board
+
+import "backthynk/internal/core/models"
+
+// BoardPost is a post pinned to the board of its space, with its content rendered
+type BoardPost struct {
+	models.Post
+	HTML        string              `json:"html"`
+	Attachments []models.Attachment `json:"attachments"`
+	Position    int                 `json:"position"`
+	Pinned      int64               `json:"pinned"`
+}
+
+// Board is the overview of a space shown above its chronological feed
+type Board struct {
+	SpaceID int         `json:"space_id"`
+	Posts   []BoardPost `json:"posts"`
+}
+
+type ReorderRequest struct {
+	PostIDs []int `json:"post_ids"`
+}
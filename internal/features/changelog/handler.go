@@ -0,0 +1,124 @@
+package changelog
+
+import (
+	"backthynk/internal/config"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/changes", h.GetChanges).Methods("GET")
+}
+
+// Middleware sends the changes a request made with its response. They are numbered before
+// it answers, so a client can tell the events it already applied optimistically from those it
+// still has to apply, even when other requests change things at the same time.
+func (h *Handler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.service.enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		changes := &requestChanges{}
+		ctx := context.WithValue(r.Context(), requestChangesContextKey{}, changes)
+		next.ServeHTTP(&seqWriter{ResponseWriter: w, changes: changes}, r.WithContext(ctx))
+	})
+}
+
+// GetChanges handles GET /api/changes
+//
+// Query parameters:
+// - since: sequence number of the last change the client has (default: 0)
+// - limit: changes returned at most (default: 500, max: 1000)
+func (h *Handler) GetChanges(w http.ResponseWriter, r *http.Request) {
+	var since int64
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		value, err := strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil || value < 0 {
+			http.Error(w, config.ErrInvalidChangeSeq, http.StatusBadRequest)
+			return
+		}
+		since = value
+	}
+
+	limit := config.DefaultChangesLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > config.MaxChangesLimit {
+			http.Error(w, config.ErrInvalidChangesLimit, http.StatusBadRequest)
+			return
+		}
+		limit = value
+	}
+
+	changes, err := h.service.Changes(r.Context(), since, limit)
+	if err != nil {
+		if err.Error() == config.ErrChangesExpired {
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+// seqWriter sets the change sequence header once the handler is done changing things, when
+// it starts answering
+type seqWriter struct {
+	http.ResponseWriter
+	changes *requestChanges
+	wrote   bool
+}
+
+func (w *seqWriter) WriteHeader(status int) {
+	if !w.wrote {
+		w.wrote = true
+		if seqs := w.changes.header(); seqs != "" {
+			w.Header().Set(config.ChangeSeqHeader, seqs)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *seqWriter) Write(b []byte) (int, error) {
+	if !w.wrote {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps streamed responses working through the wrapper
+func (w *seqWriter) Flush() {
+	if !w.wrote {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
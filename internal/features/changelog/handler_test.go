@@ -0,0 +1,104 @@
+package changelog
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/changes", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected change log routes NOT to be registered when disabled")
+	}
+}
+
+func TestChangeHandlers(t *testing.T) {
+	db, cleanup := setupChangeLogTestDB(t)
+	defer cleanup()
+
+	service := NewService(db, true, 0)
+	service.Initialize()
+	dispatcher := events.NewDispatcher()
+	dispatcher.SetSequencer(service.Sequence)
+
+	handler := NewHandler(service)
+	router := mux.NewRouter()
+	router.Use(handler.Middleware)
+	handler.RegisterRoutes(router)
+	router.HandleFunc("/api/posts", func(w http.ResponseWriter, r *http.Request) {
+		dispatcher.DispatchContext(r.Context(), events.Event{Type: events.PostCreated, Data: events.PostEvent{SpaceID: 1, PostID: 1}})
+		w.WriteHeader(http.StatusCreated)
+	}).Methods("POST")
+	router.HandleFunc("/api/posts/merge", func(w http.ResponseWriter, r *http.Request) {
+		dispatcher.DispatchContext(r.Context(), events.Event{Type: events.PostMerged, Data: events.PostEvent{SpaceID: 1, PostID: 1}})
+		// Made by someone else meanwhile
+		dispatcher.Dispatch(events.Event{Type: events.PostCreated, Data: events.PostEvent{SpaceID: 1, PostID: 2}})
+		dispatcher.DispatchContext(r.Context(), events.Event{Type: events.PostDeleted, Data: events.PostEvent{SpaceID: 1, PostID: 3}})
+		dispatcher.DispatchContext(r.Context(), events.Event{Type: events.PostDeleted, Data: events.PostEvent{SpaceID: 1, PostID: 4}})
+		w.WriteHeader(http.StatusOK)
+	}).Methods("POST")
+	router.HandleFunc("/api/posts/noop", func(w http.ResponseWriter, r *http.Request) {
+		dispatcher.Dispatch(events.Event{Type: events.PostCreated, Data: events.PostEvent{SpaceID: 1, PostID: 5}})
+		w.WriteHeader(http.StatusOK)
+	}).Methods("POST")
+
+	t.Run("mutating responses carry the changes they made", func(t *testing.T) {
+		for _, want := range []string{"1", "2"} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/api/posts", nil))
+			if got := w.Header().Get(config.ChangeSeqHeader); w.Code != http.StatusCreated || got != want {
+				t.Errorf("Expected change %s, got %q (status %d)", want, got, w.Code)
+			}
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/posts/merge", nil))
+		if got := w.Header().Get(config.ChangeSeqHeader); got != "3,5-6" {
+			t.Errorf("Expected changes 3,5-6, got %q", got)
+		}
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/posts/noop", nil))
+		if got := w.Header().Get(config.ChangeSeqHeader); got != "" {
+			t.Errorf("Expected no change header for changes made by others, got %q", got)
+		}
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/changes", nil))
+		if w.Header().Get(config.ChangeSeqHeader) != "" {
+			t.Error("Expected reads not to carry the change header")
+		}
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"List changes", "/api/changes", http.StatusOK},
+		{"List changes since", "/api/changes?since=1&limit=10", http.StatusOK},
+		{"List changes past the head", "/api/changes?since=8", http.StatusGone},
+		{"List changes invalid since", "/api/changes?since=-1", http.StatusBadRequest},
+		{"List changes invalid limit", "/api/changes?limit=1001", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
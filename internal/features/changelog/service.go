@@ -0,0 +1,209 @@
+package changelog
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service numbers every change made to spaces and posts and keeps them in a log, so clients
+// applying their own changes optimistically can tell which of the changes they hear about came
+// before or after theirs, and catch up on those they missed while disconnected.
+type Service struct {
+	db        *storage.DB
+	access    *services.SpaceAccess
+	retention time.Duration
+	now       func() time.Time
+	enabled   bool
+	mu        sync.Mutex // Keeps the log in the order sequence numbers were handed out
+	head      atomic.Int64
+	stop      chan struct{}
+}
+
+func NewService(db *storage.DB, enabled bool, retentionDays int) *Service {
+	if retentionDays <= 0 {
+		retentionDays = config.DefaultChangeLogRetentionDays
+	}
+
+	return &Service{
+		db:        db,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+		now:       time.Now,
+		enabled:   enabled,
+	}
+}
+
+// SetSpaceAccess hides the changes of spaces from the viewers that may not read them
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// Initialize reads the last sequence number handed out
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	_, head, err := s.db.GetChangeLogBounds()
+	if err != nil {
+		return err
+	}
+	s.head.Store(head)
+	return nil
+}
+
+// Sequence records a change in the log and numbers its event. It is the sequencer of the
+// dispatcher, so the number is known before the request making the change answers; the
+// request of ctx, if any, gets it in its response.
+func (s *Service) Sequence(ctx context.Context, event *events.Event) {
+	if !s.enabled || !loggedEvents[event.Type] {
+		return
+	}
+
+	change := models.Change{Type: string(event.Type), Created: s.now().UnixMilli()}
+	switch data := event.Data.(type) {
+	case events.PostEvent:
+		change.SpaceID = data.SpaceID
+		change.PostID = data.PostID
+		if data.OldSpaceID != nil {
+			change.OldSpaceID = *data.OldSpaceID
+		}
+		for _, merged := range data.MergedPosts {
+			change.PostIDs = append(change.PostIDs, merged.PostID)
+		}
+		for _, split := range data.SplitPosts {
+			change.PostIDs = append(change.PostIDs, split.PostID)
+		}
+	case events.SpaceEvent:
+		change.SpaceID = data.SpaceID
+		if data.OldParentID != nil {
+			change.OldSpaceID = *data.OldParentID
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.db.RecordChange(&change); err != nil {
+		logger.Warning("Failed to log change", zap.String("event_type", change.Type), zap.Error(err))
+		return
+	}
+	event.Seq = change.Seq
+	s.head.Store(change.Seq)
+	if changes, ok := ctx.Value(requestChangesContextKey{}).(*requestChanges); ok {
+		changes.add(change.Seq)
+	}
+}
+
+// Head returns the sequence number of the last change made
+func (s *Service) Head() int64 {
+	return s.head.Load()
+}
+
+// Changes returns at most limit changes made after since that the viewer of ctx may see.
+// Changes already pruned cannot be listed, and a since past the head comes from another
+// database; both are refused so the client reloads everything instead.
+func (s *Service) Changes(ctx context.Context, since int64, limit int) (*ChangesResponse, error) {
+	floor, head, err := s.db.GetChangeLogBounds()
+	if err != nil {
+		return nil, err
+	}
+	if since < floor || since > head {
+		return nil, fmt.Errorf(config.ErrChangesExpired)
+	}
+
+	changes, err := s.db.GetChanges(since, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ChangesResponse{Changes: []models.Change{}, Next: since, Head: head}
+	if len(changes) > limit {
+		changes = changes[:limit]
+		response.HasMore = true
+	}
+	for _, change := range changes {
+		response.Next = change.Seq
+		if visible, ok := s.visible(ctx, change); ok {
+			response.Changes = append(response.Changes, visible)
+		}
+	}
+	if !response.HasMore && response.Next < head {
+		response.Next = head
+	}
+	if response.Next > response.Head {
+		response.Head = response.Next
+	}
+	return response, nil
+}
+
+// visible returns a change as the viewer of ctx sees it. A post moved out of its sight is seen
+// deleted from the space it left.
+func (s *Service) visible(ctx context.Context, change models.Change) (models.Change, bool) {
+	oldVisible := change.OldSpaceID != 0 && s.access.CanRead(ctx, change.OldSpaceID)
+	if !oldVisible {
+		change.OldSpaceID = 0
+	}
+	if s.access.CanRead(ctx, change.SpaceID) {
+		return change, true
+	}
+	if change.Type != string(events.PostMoved) || !oldVisible {
+		return models.Change{}, false
+	}
+
+	change.Type = string(events.PostDeleted)
+	change.SpaceID = change.OldSpaceID
+	change.OldSpaceID = 0
+	return change, true
+}
+
+// StartPruning removes the changes older than the retention now and then every interval
+func (s *Service) StartPruning(interval time.Duration) {
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	s.runPrune()
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.runPrune()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func (s *Service) runPrune() {
+	count, err := s.db.PruneChanges(s.now().Add(-s.retention).UnixMilli())
+	if err != nil {
+		logger.Warning("Failed to prune change log", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		logger.Info("Pruned change log", zap.Int64("count", count))
+	}
+}
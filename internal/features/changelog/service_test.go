@@ -0,0 +1,155 @@
+package changelog
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func setupChangeLogTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_changelog_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	db, cleanup := setupChangeLogTestDB(t)
+	defer cleanup()
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	spaceService := services.NewSpaceService(db, catCache, dispatcher)
+	postService := services.NewPostService(db, catCache, dispatcher)
+
+	service := NewService(db, true, 1)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	dispatcher.SetSequencer(service.Sequence)
+
+	var seen []int64
+	dispatcher.Subscribe(events.PostCreated, func(event events.Event) error {
+		seen = append(seen, event.Seq)
+		return nil
+	})
+
+	work, _ := spaceService.Create(context.Background(), "Work", nil, "")
+	private, _ := spaceService.Create(context.Background(), "Private", nil, "")
+	post, _ := postService.Create(context.Background(), work.ID, "Draft", nil)
+	second, _ := postService.Create(context.Background(), work.ID, "Notes", nil)
+	if err := postService.Move(context.Background(), post.ID, private.ID); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if err := postService.Delete(context.Background(), second.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	t.Run("changes are numbered as they are made", func(t *testing.T) {
+		if len(seen) != 2 || seen[0] != 3 || seen[1] != 4 {
+			t.Errorf("Expected the created posts numbered 3 and 4, got %v", seen)
+		}
+		if service.Head() != 6 {
+			t.Errorf("Expected head 6, got %d", service.Head())
+		}
+
+		response, err := service.Changes(context.Background(), 0, 10)
+		if err != nil {
+			t.Fatalf("Changes failed: %v", err)
+		}
+		types := []string{"space.created", "space.created", "post.created", "post.created", "post.moved", "post.deleted"}
+		if len(response.Changes) != len(types) {
+			t.Fatalf("Expected %d changes, got %+v", len(types), response.Changes)
+		}
+		for i, change := range response.Changes {
+			if change.Seq != int64(i+1) || change.Type != types[i] {
+				t.Errorf("Change %d: expected %s, got %+v", i+1, types[i], change)
+			}
+		}
+		if moved := response.Changes[4]; moved.PostID != post.ID || moved.SpaceID != private.ID || moved.OldSpaceID != work.ID {
+			t.Errorf("Expected the move from Work to Private, got %+v", moved)
+		}
+		if response.Next != 6 || response.Head != 6 || response.HasMore {
+			t.Errorf("Expected the whole log, got next %d head %d more %v", response.Next, response.Head, response.HasMore)
+		}
+	})
+
+	t.Run("pages continue from next", func(t *testing.T) {
+		page, err := service.Changes(context.Background(), 2, 2)
+		if err != nil {
+			t.Fatalf("Changes failed: %v", err)
+		}
+		if len(page.Changes) != 2 || page.Changes[0].Seq != 3 || page.Next != 4 || !page.HasMore {
+			t.Errorf("Expected changes 3 and 4 with more to come, got %+v", page)
+		}
+		if _, err := service.Changes(context.Background(), 7, 10); err == nil || err.Error() != config.ErrChangesExpired {
+			t.Errorf("Expected a since past the head to be refused, got %v", err)
+		}
+	})
+
+	t.Run("hidden spaces are left out", func(t *testing.T) {
+		bob, err := db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+		if err != nil {
+			t.Fatalf("Failed to provision user: %v", err)
+		}
+		alice, _ := db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+		access := services.NewSpaceAccess(db, catCache)
+		if err := access.SetACL(private.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionWrite}}); err != nil {
+			t.Fatalf("SetACL failed: %v", err)
+		}
+		service.SetSpaceAccess(access)
+
+		asBob := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+		response, err := service.Changes(asBob, 0, 10)
+		if err != nil {
+			t.Fatalf("Changes failed: %v", err)
+		}
+		if len(response.Changes) != 5 || response.Next != 6 {
+			t.Fatalf("Expected Private's creation to be left out, got %+v", response)
+		}
+		if moved := response.Changes[3]; moved.Type != "post.deleted" || moved.SpaceID != work.ID || moved.OldSpaceID != 0 {
+			t.Errorf("Expected the move to be seen as a deletion from Work, got %+v", moved)
+		}
+	})
+
+	t.Run("pruned changes can no longer be listed", func(t *testing.T) {
+		service.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+		service.runPrune()
+
+		if _, err := service.Changes(context.Background(), 0, 10); err == nil || err.Error() != config.ErrChangesExpired {
+			t.Errorf("Expected pruned changes to be refused, got %v", err)
+		}
+		response, err := service.Changes(context.Background(), 6, 10)
+		if err != nil || len(response.Changes) != 0 || response.Head != 6 {
+			t.Errorf("Expected an empty log keeping its head, got %+v (%v)", response, err)
+		}
+
+		// Numbering goes on after a restart
+		restarted := NewService(db, true, 1)
+		if err := restarted.Initialize(); err != nil || restarted.Head() != 6 {
+			t.Errorf("Expected head 6 after a restart, got %d (%v)", restarted.Head(), err)
+		}
+	})
+}
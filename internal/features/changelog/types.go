@@ -0,0 +1,71 @@
+package changelog
+
+import (
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// loggedEvents are the events recorded in the change log, those changing spaces or posts
+var loggedEvents = map[events.EventType]bool{
+	events.PostCreated:  true,
+	events.PostUpdated:  true,
+	events.PostDeleted:  true,
+	events.PostMoved:    true,
+	events.PostMerged:   true,
+	events.PostSplit:    true,
+	events.PostRetimed:  true,
+	events.PostPinned:   true,
+	events.SpaceCreated: true,
+	events.SpaceUpdated: true,
+	events.SpaceDeleted: true,
+	events.FileUploaded: true,
+	events.FileDeleted:  true,
+}
+
+// ChangesResponse is a page of the change log. Clients pass Next as the since of the next
+// request; it may be past the last change listed when the following ones are hidden from them.
+type ChangesResponse struct {
+	Changes []models.Change `json:"changes"`
+	Next    int64           `json:"next"`
+	Head    int64           `json:"head"` // Last change made
+	HasMore bool            `json:"has_more"`
+}
+
+// requestChanges collects the sequence numbers of the changes a request made
+type requestChanges struct {
+	mu   sync.Mutex
+	seqs []int64
+}
+
+type requestChangesContextKey struct{}
+
+func (c *requestChanges) add(seq int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seqs = append(c.seqs, seq)
+}
+
+// header lists the changes in the order they were numbered, runs of consecutive numbers
+// written as first-last, e.g. "12,15-18". It is empty when the request changed nothing.
+func (c *requestChanges) header() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var parts []string
+	for i := 0; i < len(c.seqs); {
+		j := i
+		for j+1 < len(c.seqs) && c.seqs[j+1] == c.seqs[j]+1 {
+			j++
+		}
+		part := strconv.FormatInt(c.seqs[i], 10)
+		if j > i {
+			part += "-" + strconv.FormatInt(c.seqs[j], 10)
+		}
+		parts = append(parts, part)
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,77 @@
+package coldstorage
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/storage-tiers", h.GetUsage).Methods("GET")
+	api.HandleFunc("/admin/cold-storage", h.GetStatus).Methods("GET")
+	api.HandleFunc("/admin/cold-storage/run", h.Run).Methods("POST")
+}
+
+// GetUsage handles GET /api/spaces/{id}/storage-tiers
+// Query parameters:
+// - recursive: include descendant spaces (default: false)
+func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	usage, err := h.service.GetUsage(r.Context(), spaceID, r.URL.Query().Get("recursive") == "true")
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == config.ErrSpaceNotFound {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+// GetStatus handles GET /api/admin/cold-storage
+func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.Status()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// Run handles POST /api/admin/cold-storage/run, an archival pass outside the daily schedule
+func (h *Handler) Run(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.Run()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
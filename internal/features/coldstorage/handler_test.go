@@ -0,0 +1,73 @@
+package coldstorage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	setup, cleanup := setupColdTest(t)
+	defer cleanup()
+
+	router := mux.NewRouter()
+	NewHandler(NewService(setup.db, nil, nil, false, 0)).RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/admin/cold-storage", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected cold storage routes NOT to be registered when disabled")
+	}
+}
+
+func TestHandlerRoutes(t *testing.T) {
+	setup, cleanup := setupColdTest(t)
+	defer cleanup()
+	root, _, _ := setup.seed(t)
+
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+	rootPath := "/api/spaces/" + strconv.Itoa(root.ID) + "/storage-tiers"
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Usage before run", "GET", rootPath + "?recursive=true", http.StatusOK, `"hot_bytes":10`},
+		{"Run", "POST", "/api/admin/cold-storage/run", http.StatusOK, `"archived":1`},
+		{"Usage after run", "GET", rootPath + "?recursive=true", http.StatusOK, `"cold_bytes":10`},
+		{"Status", "GET", "/api/admin/cold-storage", http.StatusOK, `"after_months":6`},
+		{"Unknown space", "GET", "/api/spaces/999/storage-tiers", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body to contain %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	var status Status
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/admin/cold-storage", nil))
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if status.LastRun == nil || status.Usage.ColdFiles != 1 {
+		t.Errorf("Expected the last run and cold usage in the status, got %+v", status)
+	}
+}
@@ -0,0 +1,298 @@
+package coldstorage
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/jobs"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service moves attachment files untouched for a number of months to a cold tier, keeping a
+// stub record for each, and brings them back when they are accessed. An attachment counts as
+// touched when its post is created, when its file is written and when it is downloaded.
+type Service struct {
+	db          *storage.DB
+	catCache    *cache.SpaceCache
+	access      *services.SpaceAccess
+	tier        Tier
+	afterMonths int
+	uploadsDir  string
+	lastRun     *RunResult
+	mu          sync.Mutex // Serializes moves between tiers
+	budget      *jobs.Budget
+	stop        chan struct{}
+	now         func() time.Time
+	enabled     bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, tier Tier, enabled bool, afterMonths int) *Service {
+	if afterMonths <= 0 {
+		afterMonths = config.DefaultColdStorageAfterMonths
+	}
+	afterMonths = min(afterMonths, config.MaxColdStorageAfterMonths)
+
+	return &Service{
+		db:          db,
+		catCache:    catCache,
+		tier:        tier,
+		afterMonths: afterMonths,
+		uploadsDir:  filepath.Join(config.GetServiceConfig().Files.StoragePath, config.GetServiceConfig().Files.UploadsSubdir),
+		now:         time.Now,
+		enabled:     enabled,
+	}
+}
+
+// SetSpaceAccess keeps the attachments of spaces hidden from the viewer of a request out of
+// its tier usage
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// HandleEvent records attachment downloads as accesses
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled || event.Type != events.FileDownloaded {
+		return nil
+	}
+
+	data, ok := event.Data.(events.PostEvent)
+	if !ok || data.AttachmentID == 0 {
+		return nil
+	}
+	return s.db.TouchAttachment(data.AttachmentID, data.Timestamp)
+}
+
+// Run moves a batch of untouched attachments to the cold tier and removes the cold files of
+// attachments that were deleted for good. Attachments in the trash keep their cold file.
+func (s *Service) Run() (*RunResult, error) {
+	now := s.now()
+	cutoff := now.AddDate(0, -s.afterMonths, 0)
+	result := &RunResult{Ran: now.UnixMilli()}
+
+	candidates, err := s.db.GetColdCandidates(cutoff.UnixMilli(), config.ColdStorageBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		archived, err := s.archive(candidate, cutoff)
+		if err != nil {
+			logger.Warning("Failed to move attachment to cold storage", zap.String("file", candidate.FilePath), zap.Error(err))
+			continue
+		}
+		if archived {
+			result.Archived++
+			result.ArchivedBytes += candidate.FileSize
+		}
+	}
+
+	orphans, err := s.db.GetOrphanColdAttachments()
+	if err != nil {
+		return nil, err
+	}
+	for _, filePath := range orphans {
+		if err := s.remove(filePath); err != nil {
+			logger.Warning("Failed to remove cold file", zap.String("file", filePath), zap.Error(err))
+			continue
+		}
+		result.Removed++
+	}
+
+	s.mu.Lock()
+	s.lastRun = result
+	s.mu.Unlock()
+	return result, nil
+}
+
+// archive moves one file to the cold tier. Files written after cutoff, e.g. attached to a
+// post backdated by retroactive posting, and missing files are marked as accessed instead,
+// so they do not hold up later batches.
+func (s *Service) archive(candidate models.ColdAttachment, cutoff time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	local := filepath.Join(s.uploadsDir, candidate.FilePath)
+	info, err := os.Stat(local)
+	if err != nil {
+		return false, s.db.TouchAttachmentFile(candidate.FilePath, s.now().UnixMilli())
+	}
+	if info.ModTime().After(cutoff) {
+		return false, s.db.TouchAttachmentFile(candidate.FilePath, info.ModTime().UnixMilli())
+	}
+
+	if err := s.tier.Put(candidate.FilePath, local); err != nil {
+		return false, err
+	}
+
+	candidate.ArchivedAt = s.now().UnixMilli()
+	if err := s.db.AddColdAttachment(candidate); err != nil {
+		// Without its stub the file could not be found again, bring it back
+		if restoreErr := s.tier.Get(candidate.FilePath, local); restoreErr != nil {
+			logger.Error("Failed to bring back unrecorded cold file", zap.String("file", candidate.FilePath), zap.Error(restoreErr))
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Restore brings a file back from the cold tier into the uploads directory. It returns false
+// without error for files that are not in the cold tier.
+func (s *Service) Restore(filePath string) (bool, error) {
+	if !s.enabled {
+		return false, nil
+	}
+
+	local := filepath.Join(s.uploadsDir, filePath)
+	if _, err := os.Stat(local); err == nil {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cold, err := s.db.GetColdAttachment(filePath)
+	if err != nil || cold == nil {
+		return false, err
+	}
+
+	if err := s.tier.Get(filePath, local); err != nil {
+		return false, err
+	}
+	if err := s.db.RemoveColdAttachment(filePath); err != nil {
+		return false, err
+	}
+
+	// A restored file counts as accessed, or the next run would move it right back
+	if err := s.db.TouchAttachmentFile(filePath, s.now().UnixMilli()); err != nil {
+		logger.Warning("Failed to record restored attachment access", zap.String("file", filePath), zap.Error(err))
+	}
+
+	logger.Info("Restored attachment from cold storage", zap.String("file", filePath), zap.Int64("size", cold.FileSize))
+	return true, nil
+}
+
+func (s *Service) remove(filePath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.tier.Remove(filePath); err != nil {
+		return err
+	}
+	return s.db.RemoveColdAttachment(filePath)
+}
+
+// GetUsage returns the hot and cold attachment bytes of a space, optionally including its
+// descendants. Space 0 covers every space the viewer of ctx may see.
+func (s *Service) GetUsage(ctx context.Context, spaceID int, recursive bool) (*models.TierUsage, error) {
+	usage := &models.TierUsage{SpaceID: spaceID, Recursive: recursive}
+
+	var spaceIDs []int
+	if spaceID != 0 {
+		if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+			return nil, fmt.Errorf(config.ErrSpaceNotFound)
+		}
+		spaceIDs = []int{spaceID}
+		if recursive {
+			spaceIDs = append(spaceIDs, s.catCache.GetDescendants(spaceID)...)
+		}
+	}
+	spaceIDs = s.access.Scope(ctx, spaceIDs)
+
+	bySpace, err := s.db.GetTierUsageBySpace()
+	if err != nil {
+		return nil, err
+	}
+
+	if spaceIDs == nil {
+		for _, u := range bySpace {
+			usage.Add(u)
+		}
+		return usage, nil
+	}
+	for _, id := range spaceIDs {
+		usage.Add(bySpace[id])
+	}
+	return usage, nil
+}
+
+// Status returns the policy, the usage of every space and the result of the last pass
+func (s *Service) Status() (*Status, error) {
+	usage, err := s.GetUsage(context.Background(), 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &Status{AfterMonths: s.afterMonths, Usage: *usage, LastRun: s.lastRun}, nil
+}
+
+// StartArchiver runs an archival pass immediately and then once per interval until Stop is called
+func (s *Service) StartArchiver(interval time.Duration) {
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	s.runArchiver()
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.runArchiver()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// SetBudget makes the archival loop share the resource budget of background jobs
+func (s *Service) SetBudget(budget *jobs.Budget) {
+	s.budget = budget
+}
+
+func (s *Service) runArchiver() {
+	var result *RunResult
+	err := s.budget.Run(context.Background(), "cold-storage", func(job *jobs.Job) error {
+		var err error
+		if result, err = s.Run(); err != nil {
+			return err
+		}
+		// Each archived file was read from the uploads and written to the cold tier
+		return job.ChargeIO(context.Background(), 2*result.ArchivedBytes)
+	})
+	if err != nil {
+		logger.Warning("Failed to run cold storage archival", zap.Error(err))
+		return
+	}
+	if result.Archived > 0 || result.Removed > 0 {
+		logger.Info("Ran cold storage archival",
+			zap.Int("archived", result.Archived),
+			zap.Int64("archived_bytes", result.ArchivedBytes),
+			zap.Int("removed", result.Removed))
+	}
+}
+
+// Stop ends the archival loop
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
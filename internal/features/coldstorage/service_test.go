@@ -0,0 +1,231 @@
+package coldstorage
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type coldTestSetup struct {
+	db      *storage.DB
+	cache   *cache.SpaceCache
+	dir     string
+	spaces  *services.SpaceService
+	posts   *services.PostService
+	files   *services.FileService
+	service *Service
+}
+
+func setupColdTest(t *testing.T) (*coldTestSetup, func()) {
+	tempDir, err := os.MkdirTemp("", "backthynk_coldstorage_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	testConfig.Files.StoragePath = tempDir
+	testConfig.Files.UploadsSubdir = "uploads"
+	config.SetServiceConfigForTest(testConfig)
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	spaceCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	spaceService := services.NewSpaceService(db, spaceCache, dispatcher)
+	if err := spaceService.InitializeCache(); err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to initialize cache: %v", err)
+	}
+
+	service := NewService(db, spaceCache, NewDirTier(filepath.Join(tempDir, config.ColdStorageSubdir)), true, 6)
+	// Files written now are old enough once the clock is a year ahead
+	later := time.Now().AddDate(1, 0, 0)
+	service.now = func() time.Time { return later }
+
+	fileService := services.NewFileService(db, dispatcher)
+	fileService.SetColdStorage(service)
+
+	return &coldTestSetup{
+		db:      db,
+		cache:   spaceCache,
+		dir:     tempDir,
+		spaces:  spaceService,
+		posts:   services.NewPostService(db, spaceCache, dispatcher),
+		files:   fileService,
+		service: service,
+	}, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+// seed creates a space with a subspace holding an old post with one attachment
+func (setup *coldTestSetup) seed(t *testing.T) (*models.Space, *models.Post, *models.Attachment) {
+	root, err := setup.spaces.Create(context.Background(), "Archive", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	child, err := setup.spaces.Create(context.Background(), "Scans", &root.ID, "")
+	if err != nil {
+		t.Fatalf("Failed to create subspace: %v", err)
+	}
+
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC).UnixMilli()
+	post, err := setup.posts.Create(context.Background(), child.ID, "Old scan", &created)
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	attachment, err := setup.files.UploadFile(context.Background(), post.ID, strings.NewReader("scan bytes"), "scan.pdf", 10)
+	if err != nil {
+		t.Fatalf("Failed to upload file: %v", err)
+	}
+
+	return root, post, attachment
+}
+
+func (setup *coldTestSetup) exists(parts ...string) bool {
+	_, err := os.Stat(filepath.Join(append([]string{setup.dir}, parts...)...))
+	return err == nil
+}
+
+func TestRunArchivesAndRestores(t *testing.T) {
+	setup, cleanup := setupColdTest(t)
+	defer cleanup()
+	root, _, attachment := setup.seed(t)
+
+	result, err := setup.service.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Archived != 1 || result.ArchivedBytes != 10 {
+		t.Fatalf("Expected one archived attachment, got %+v", result)
+	}
+	if setup.exists("uploads", attachment.FilePath) || !setup.exists(config.ColdStorageSubdir, attachment.FilePath) {
+		t.Fatal("Expected the file to move to the cold tier")
+	}
+
+	usage, err := setup.service.GetUsage(context.Background(), root.ID, true)
+	if err != nil {
+		t.Fatalf("GetUsage failed: %v", err)
+	}
+	if usage.ColdFiles != 1 || usage.ColdBytes != 10 || usage.HotBytes != 0 {
+		t.Errorf("Expected the attachment to count as cold, got %+v", usage)
+	}
+	if direct, _ := setup.service.GetUsage(context.Background(), root.ID, false); direct.ColdFiles != 0 {
+		t.Errorf("Expected no cold files directly in the parent space, got %+v", direct)
+	}
+
+	// Downloads bring the file back transparently
+	if _, err := setup.files.GetDownload(attachment.FilePath); err != nil {
+		t.Fatalf("GetDownload failed: %v", err)
+	}
+	if !setup.exists("uploads", attachment.FilePath) {
+		t.Fatal("Expected the file to be restored to the uploads directory")
+	}
+	if cold, _ := setup.db.GetColdAttachment(attachment.FilePath); cold != nil {
+		t.Error("Expected the cold stub to be removed")
+	}
+
+	// The restore counts as an access, so the file stays hot
+	result, err = setup.service.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Archived != 0 {
+		t.Errorf("Expected the restored file to stay hot, got %+v", result)
+	}
+}
+
+func TestRunSkipsRecentlyDownloaded(t *testing.T) {
+	setup, cleanup := setupColdTest(t)
+	defer cleanup()
+	_, post, attachment := setup.seed(t)
+
+	setup.service.HandleEvent(events.Event{
+		Type: events.FileDownloaded,
+		Data: events.PostEvent{PostID: post.ID, AttachmentID: attachment.ID, Timestamp: setup.service.now().UnixMilli()},
+	})
+
+	result, err := setup.service.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Archived != 0 || !setup.exists("uploads", attachment.FilePath) {
+		t.Errorf("Expected a recently downloaded file to stay hot, got %+v", result)
+	}
+}
+
+func TestRunRemovesDeletedColdFiles(t *testing.T) {
+	setup, cleanup := setupColdTest(t)
+	defer cleanup()
+	_, post, attachment := setup.seed(t)
+
+	if _, err := setup.service.Run(); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if err := setup.posts.Delete(context.Background(), post.ID); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+
+	result, err := setup.service.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Removed != 1 || setup.exists(config.ColdStorageSubdir, attachment.FilePath) {
+		t.Errorf("Expected the cold file of the deleted post to be removed, got %+v", result)
+	}
+}
+
+func TestRestoreHotFile(t *testing.T) {
+	setup, cleanup := setupColdTest(t)
+	defer cleanup()
+	_, _, attachment := setup.seed(t)
+
+	restored, err := setup.service.Restore(attachment.FilePath)
+	if err != nil || restored {
+		t.Errorf("Expected a hot file not to be restored, got %v %v", restored, err)
+	}
+}
+
+func TestGetUsageHidesPrivateSpaces(t *testing.T) {
+	setup, cleanup := setupColdTest(t)
+	defer cleanup()
+	root, post, _ := setup.seed(t)
+
+	bob, _ := setup.db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	alice, _ := setup.db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(setup.db, setup.cache)
+	if err := access.SetACL(post.SpaceID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+	setup.service.SetSpaceAccess(access)
+
+	asBob := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+	if usage, _ := setup.service.GetUsage(asBob, root.ID, true); usage.HotFiles != 0 {
+		t.Errorf("Expected the files of the private subspace to be left out, got %+v", usage)
+	}
+	if usage, _ := setup.service.GetUsage(asBob, 0, true); usage.HotFiles != 0 {
+		t.Errorf("Expected the files of the private subspace to be left out of the total, got %+v", usage)
+	}
+	if _, err := setup.service.GetUsage(asBob, post.SpaceID, false); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+	asAlice := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	if usage, _ := setup.service.GetUsage(asAlice, root.ID, true); usage.HotFiles != 1 {
+		t.Errorf("Expected a listed viewer to count the file, got %+v", usage)
+	}
+}
@@ -0,0 +1,85 @@
+package coldstorage
+
+import (
+	"backthynk/internal/config"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Tier stores the attachment files moved out of the uploads directory, under their stored
+// filename. DirTier keeps them in a directory that may live on a slower or cheaper disk;
+// object stores with archival classes can implement the same interface.
+type Tier interface {
+	Put(name, source string) error // Moves the local file at source into the tier
+	Get(name, target string) error // Moves the file back to the local path target
+	Remove(name string) error
+}
+
+type DirTier struct {
+	root string
+}
+
+func NewDirTier(root string) *DirTier {
+	return &DirTier{root: root}
+}
+
+func (t *DirTier) Put(name, source string) error {
+	if err := os.MkdirAll(t.root, config.DirectoryPermissions); err != nil {
+		return fmt.Errorf("failed to create cold storage directory: %w", err)
+	}
+	return moveFile(source, filepath.Join(t.root, name))
+}
+
+func (t *DirTier) Get(name, target string) error {
+	source := filepath.Join(t.root, name)
+	if _, err := os.Stat(source); os.IsNotExist(err) {
+		return fmt.Errorf(config.ErrFmtColdFileMissing, name)
+	}
+	return moveFile(source, target)
+}
+
+func (t *DirTier) Remove(name string) error {
+	if err := os.Remove(filepath.Join(t.root, name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cold file: %w", err)
+	}
+	return nil
+}
+
+// moveFile renames source to target, copying it when they are on different devices. The
+// copy goes through a temporary file so target never holds a partial file.
+func moveFile(source, target string) error {
+	if err := os.Rename(source, target); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".move-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+
+	return os.Remove(source)
+}
@@ -0,0 +1,18 @@
+package coldstorage
+
+import "backthynk/internal/core/models"
+
+// RunResult describes an archival pass
+type RunResult struct {
+	Archived      int   `json:"archived"`
+	ArchivedBytes int64 `json:"archived_bytes"`
+	Removed       int   `json:"removed"` // Cold files of attachments deleted for good
+	Ran           int64 `json:"ran"`
+}
+
+// Status is the cold storage policy with the current tier usage of all spaces
+type Status struct {
+	AfterMonths int              `json:"after_months"`
+	Usage       models.TierUsage `json:"usage"`
+	LastRun     *RunResult       `json:"last_run"`
+}
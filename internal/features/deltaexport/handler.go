@@ -0,0 +1,52 @@
+package deltaexport
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/export/delta", h.GetDelta).Methods("GET")
+}
+
+// GetDelta handles GET /api/export/delta
+//
+// Query parameters:
+// - since: unix timestamp in milliseconds, inclusive (default: 0, everything)
+func (h *Handler) GetDelta(w http.ResponseWriter, r *http.Request) {
+	var since int64
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		value, err := strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil || value < 0 {
+			http.Error(w, config.ErrInvalidExportSince, http.StatusBadRequest)
+			return
+		}
+		since = value
+	}
+
+	export, err := h.service.Delta(r.Context(), since)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(export)
+}
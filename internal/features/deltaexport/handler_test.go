@@ -0,0 +1,73 @@
+package deltaexport
+
+import (
+	"backthynk/internal/core/cache"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/export/delta", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected delta export routes NOT to be registered when disabled")
+	}
+}
+
+func TestGetDelta(t *testing.T) {
+	db, cleanup := setupDeltaTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Journal", nil, "")
+	db.CreatePostWithTimestamp(context.Background(), space.ID, "First entry", 1000)
+
+	service := NewService(db, cache.NewSpaceCache(), true)
+	service.Initialize()
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedPosts  int
+	}{
+		{"Everything", "/api/export/delta", http.StatusOK, 1},
+		{"Since start", "/api/export/delta?since=0", http.StatusOK, 1},
+		{"Since later", "/api/export/delta?since=1001", http.StatusOK, 0},
+		{"Invalid since", "/api/export/delta?since=yesterday", http.StatusBadRequest, 0},
+		{"Negative since", "/api/export/delta?since=-1", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var export DeltaExport
+			if err := json.Unmarshal(w.Body.Bytes(), &export); err != nil {
+				t.Fatal(err)
+			}
+			if len(export.Posts) != tt.expectedPosts || len(export.Spaces) != 1 || export.Until == 0 {
+				t.Errorf("Unexpected export: %+v", export)
+			}
+		})
+	}
+}
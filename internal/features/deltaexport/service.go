@@ -0,0 +1,185 @@
+package deltaexport
+
+import (
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"time"
+)
+
+// Service keeps a change log of spaces and posts, one entry per entity holding its last
+// change, so exports can return what changed since a given time without a full export.
+// Entries of deleted entities are kept as tombstones.
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	access   *services.SpaceAccess
+	now      func() time.Time
+	enabled  bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		now:      time.Now,
+		enabled:  enabled,
+	}
+}
+
+// SetSpaceAccess leaves the spaces hidden from the viewer of a request and their posts out of
+// its exports
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// Initialize records the existing spaces and posts the first time the feature runs, and
+// catches up on deletions made while it was disabled
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	if err := s.db.BackfillExportChanges(); err != nil {
+		return err
+	}
+	return s.db.SweepExportTombstones(s.now().UnixMilli())
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	changed := s.now().UnixMilli()
+	switch event.Type {
+	case events.PostCreated, events.PostUpdated, events.PostRetimed, events.PostMoved, events.FileUploaded, events.FileDeleted:
+		data := event.Data.(events.PostEvent)
+		return s.db.RecordExportChange(models.ExportEntityPost, data.PostID, false, changed)
+
+	case events.PostMerged:
+		data := event.Data.(events.PostEvent)
+		for _, merged := range data.MergedPosts {
+			if err := s.db.RecordExportChange(models.ExportEntityPost, merged.PostID, true, changed); err != nil {
+				return err
+			}
+		}
+		return s.db.RecordExportChange(models.ExportEntityPost, data.PostID, false, changed)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		if err := s.db.RecordExportChange(models.ExportEntityPost, data.PostID, false, changed); err != nil {
+			return err
+		}
+		for _, split := range data.SplitPosts {
+			if err := s.db.RecordExportChange(models.ExportEntityPost, split.PostID, false, changed); err != nil {
+				return err
+			}
+		}
+
+	case events.PostDeleted:
+		data := event.Data.(events.PostEvent)
+		return s.db.RecordExportChange(models.ExportEntityPost, data.PostID, true, changed)
+
+	case events.SpaceCreated:
+		data := event.Data.(events.SpaceEvent)
+		return s.db.RecordExportChange(models.ExportEntitySpace, data.SpaceID, false, changed)
+
+	case events.SpaceUpdated:
+		// A move changes the depth of every descendant
+		data := event.Data.(events.SpaceEvent)
+		for _, id := range append([]int{data.SpaceID}, s.catCache.GetDescendants(data.SpaceID)...) {
+			if err := s.db.RecordExportChange(models.ExportEntitySpace, id, false, changed); err != nil {
+				return err
+			}
+		}
+
+	case events.SpaceDeleted:
+		// Descendant spaces are gone without events of their own, the sweep catches them
+		data := event.Data.(events.SpaceEvent)
+		if err := s.db.RecordExportChange(models.ExportEntitySpace, data.SpaceID, true, changed); err != nil {
+			return err
+		}
+		return s.db.SweepExportTombstones(changed)
+	}
+
+	return nil
+}
+
+// Delta returns the spaces and posts changed from since, in milliseconds, up to now.
+// An entity deleted after it was recorded as changed is reported as a tombstone. Spaces hidden
+// from the viewer of ctx and their posts are left out.
+func (s *Service) Delta(ctx context.Context, since int64) (*DeltaExport, error) {
+	until := s.now().UnixMilli()
+	changes, err := s.db.GetExportChanges(since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden := s.access.Hidden(ctx)
+
+	export := &DeltaExport{
+		Since:      since,
+		Until:      until,
+		Spaces:     []ExportSpace{},
+		Posts:      []ExportPost{},
+		Tombstones: []Tombstone{},
+	}
+	for _, change := range changes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if change.Deleted {
+			export.Tombstones = append(export.Tombstones, Tombstone{Type: change.Entity, ID: change.EntityID, Deleted: change.Changed})
+			continue
+		}
+
+		switch change.Entity {
+		case models.ExportEntitySpace:
+			space, err := s.db.GetSpace(change.EntityID)
+			if err != nil {
+				if err.Error() == "space not found" {
+					export.Tombstones = append(export.Tombstones, Tombstone{Type: change.Entity, ID: change.EntityID, Deleted: change.Changed})
+					continue
+				}
+				return nil, err
+			}
+			if hidden[space.ID] {
+				continue
+			}
+			export.Spaces = append(export.Spaces, ExportSpace{
+				ID:          space.ID,
+				Name:        space.Name,
+				Description: space.Description,
+				ParentID:    space.ParentID,
+				Depth:       space.Depth,
+				Created:     space.Created,
+				Hash:        space.Hash,
+				Changed:     change.Changed,
+			})
+
+		case models.ExportEntityPost:
+			post, err := s.db.GetPost(change.EntityID)
+			if err != nil {
+				if err.Error() == "post not found" {
+					export.Tombstones = append(export.Tombstones, Tombstone{Type: change.Entity, ID: change.EntityID, Deleted: change.Changed})
+					continue
+				}
+				return nil, err
+			}
+			if hidden[post.SpaceID] {
+				continue
+			}
+			attachments, err := s.db.GetAttachmentManifest(post.ID)
+			if err != nil {
+				return nil, err
+			}
+			export.Posts = append(export.Posts, ExportPost{Post: *post, Changed: change.Changed, Attachments: attachments})
+		}
+	}
+
+	return export, nil
+}
@@ -0,0 +1,143 @@
+package deltaexport
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func setupDeltaTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_delta_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestDelta(t *testing.T) {
+	db, cleanup := setupDeltaTestDB(t)
+	defer cleanup()
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	spaceService := services.NewSpaceService(db, catCache, dispatcher)
+	postService := services.NewPostService(db, catCache, dispatcher)
+
+	// Existing content is backfilled as changed when created
+	journal, _ := spaceService.Create(context.Background(), "Journal", nil, "")
+	old, _ := db.CreatePostWithTimestamp(context.Background(), journal.ID, "Old entry", 1000)
+
+	clock := time.Now().Add(time.Hour).UnixMilli()
+	service := NewService(db, catCache, true)
+	service.now = func() time.Time { return time.UnixMilli(clock) }
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	for _, eventType := range []events.EventType{
+		events.PostCreated, events.PostUpdated, events.PostDeleted, events.PostMoved,
+		events.PostMerged, events.PostSplit, events.FileUploaded, events.FileDeleted,
+		events.SpaceCreated, events.SpaceUpdated, events.SpaceDeleted,
+	} {
+		dispatcher.Subscribe(eventType, service.HandleEvent)
+	}
+
+	full, err := service.Delta(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Delta failed: %v", err)
+	}
+	if len(full.Spaces) != 1 || len(full.Posts) != 1 || len(full.Tombstones) != 0 || full.Until != clock {
+		t.Fatalf("Unexpected full export: %+v", full)
+	}
+	if full.Posts[0].Changed != 1000 || full.Posts[0].Attachments == nil {
+		t.Errorf("Expected the post changed when created with an empty manifest, got %+v", full.Posts[0])
+	}
+	if recent, _ := service.Delta(context.Background(), 2000); len(recent.Posts) != 0 || len(recent.Spaces) != 1 {
+		t.Errorf("Expected only the space created after the post, got %+v", recent)
+	}
+
+	// Changes
+	clock += 1000
+	changed := clock
+	ideas, _ := spaceService.Create(context.Background(), "Ideas", nil, "")
+	drafts, _ := spaceService.Create(context.Background(), "Drafts", &ideas.ID, "")
+	archive, _ := spaceService.Create(context.Background(), "Archive", nil, "")
+	idea, _ := postService.Create(context.Background(), drafts.ID, "New idea", nil)
+	postService.Move(context.Background(), old.ID, archive.ID)
+
+	delta, _ := service.Delta(context.Background(), changed)
+	if len(delta.Spaces) != 3 || len(delta.Posts) != 2 || len(delta.Tombstones) != 0 {
+		t.Fatalf("Unexpected delta: %+v", delta)
+	}
+	for _, post := range delta.Posts {
+		if post.ID == old.ID && post.SpaceID != archive.ID {
+			t.Errorf("Expected the moved post in its new space, got %+v", post)
+		}
+	}
+	for _, space := range delta.Spaces {
+		parentID := 0
+		if space.ParentID != nil {
+			parentID = *space.ParentID
+		}
+		if space.Hash != storage.SpaceHash(parentID, space.Name, space.Description) {
+			t.Errorf("Expected the row hash of space %d, got %q", space.ID, space.Hash)
+		}
+	}
+
+	// The row hash follows the canonical fields of a post
+	for _, post := range delta.Posts {
+		if post.ID == old.ID && post.Hash != storage.PostHash(archive.ID, 1000, "Old entry") {
+			t.Errorf("Expected the row hash of the moved post, got %q", post.Hash)
+		}
+	}
+	if full.Posts[0].Hash == "" || full.Posts[0].Hash == storage.PostHash(archive.ID, 1000, "Old entry") {
+		t.Errorf("Expected the hash to change when the post moved, got %q", full.Posts[0].Hash)
+	}
+
+	// Deleting a space leaves tombstones for its descendants and their posts
+	clock += 1000
+	deleted := clock
+	if err := spaceService.Delete(context.Background(), ideas.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	delta, _ = service.Delta(context.Background(), deleted)
+	expected := []Tombstone{
+		{Type: models.ExportEntityPost, ID: idea.ID, Deleted: deleted},
+		{Type: models.ExportEntitySpace, ID: ideas.ID, Deleted: deleted},
+		{Type: models.ExportEntitySpace, ID: drafts.ID, Deleted: deleted},
+	}
+	if len(delta.Spaces) != 0 || len(delta.Posts) != 0 || len(delta.Tombstones) != len(expected) {
+		t.Fatalf("Unexpected delta after delete: %+v", delta)
+	}
+	for i, tombstone := range expected {
+		if delta.Tombstones[i] != tombstone {
+			t.Errorf("Tombstone %d: expected %+v, got %+v", i, tombstone, delta.Tombstones[i])
+		}
+	}
+
+	delta, _ = service.Delta(context.Background(), changed)
+	if len(delta.Spaces) != 1 || delta.Spaces[0].ID != archive.ID || len(delta.Posts) != 1 || len(delta.Tombstones) != 3 {
+		t.Errorf("Expected the archive, its post and the tombstones, got %+v", delta)
+	}
+}
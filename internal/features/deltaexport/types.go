@@ -0,0 +1,40 @@
+package deltaexport
+
+import "backthynk/internal/core/models"
+
+// ExportSpace is a space created or changed since the export start
+type ExportSpace struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ParentID    *int   `json:"parent_id"`
+	Depth       int    `json:"depth"`
+	Created     int64  `json:"created"`
+	Hash        string `json:"hash"`
+	Changed     int64  `json:"changed"`
+}
+
+// ExportPost is a post created or changed since the export start, with the manifest of its
+// attachments so files can be fetched or verified by checksum
+type ExportPost struct {
+	models.Post
+	Changed     int64               `json:"changed"`
+	Attachments []models.Attachment `json:"attachments"`
+}
+
+// Tombstone reports a space or post deleted since the export start
+type Tombstone struct {
+	Type    string `json:"type"` // space or post
+	ID      int    `json:"id"`
+	Deleted int64  `json:"deleted"`
+}
+
+// DeltaExport holds everything that changed from Since up to Until. Passing Until as the
+// since of the next export continues from where this one stopped.
+type DeltaExport struct {
+	Since      int64         `json:"since"`
+	Until      int64         `json:"until"`
+	Spaces     []ExportSpace `json:"spaces"`
+	Posts      []ExportPost  `json:"posts"`
+	Tombstones []Tombstone   `json:"tombstones"`
+}
@@ -0,0 +1,41 @@
+package detailedstats
+
+import (
+	"backthynk/internal/storage"
+	"context"
+)
+
+// DownloadStats summarizes how often the attachments of a space were downloaded
+type DownloadStats struct {
+	TotalDownloads int64                         `json:"total_downloads"`
+	TopFiles       []storage.AttachmentDownloads `json:"top_files"`
+}
+
+func (s *Service) recordDownload(attachmentID int, timestamp int64) error {
+	return s.db.IncrementAttachmentDownloads(attachmentID, timestamp)
+}
+
+// GetDownloadStats returns download totals and the most downloaded files of a space,
+// optionally including its descendants. Space 0 covers every space. Spaces hidden from the viewer
+// of ctx are left out.
+func (s *Service) GetDownloadStats(ctx context.Context, spaceID int, recursive bool, limit int) (*DownloadStats, error) {
+	var spaceIDs []int
+	if spaceID == 0 {
+		for _, space := range s.catCache.GetAll() {
+			spaceIDs = append(spaceIDs, space.ID)
+		}
+	} else {
+		spaceIDs = []int{spaceID}
+		if recursive {
+			spaceIDs = append(spaceIDs, s.catCache.GetDescendants(spaceID)...)
+		}
+	}
+	spaceIDs = s.access.Scope(ctx, spaceIDs)
+
+	total, top, err := s.db.GetDownloadStats(spaceIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DownloadStats{TotalDownloads: total, TopFiles: top}, nil
+}
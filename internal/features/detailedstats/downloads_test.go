@@ -0,0 +1,104 @@
+package detailedstats
+
+import (
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestDownloadStats(t *testing.T) {
+	db, cleanup := setupHistoryTestDB(t)
+	defer cleanup()
+
+	parent, _ := db.CreateSpace("Reference", nil, "")
+	child, _ := db.CreateSpace("Manuals", &parent.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(parent)
+	catCache.Set(child)
+
+	parentPost, _ := db.CreatePost(context.Background(), parent.ID, "Specs")
+	childPost, _ := db.CreatePost(context.Background(), child.ID, "Manual")
+	spec, _ := db.CreateAttachment(parentPost.ID, "spec.pdf", "spec.pdf", "application/pdf", 100)
+	manual, _ := db.CreateAttachment(childPost.ID, "manual.pdf", "manual.pdf", "application/pdf", 200)
+	db.CreateAttachment(childPost.ID, "unused.pdf", "unused.pdf", "application/pdf", 300)
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	download := func(attachmentID int, timestamp int64) {
+		if err := service.HandleEvent(events.Event{
+			Type: events.FileDownloaded,
+			Data: events.PostEvent{AttachmentID: attachmentID, Timestamp: timestamp},
+		}); err != nil {
+			t.Fatalf("HandleEvent failed: %v", err)
+		}
+	}
+	download(spec.ID, 1000)
+	download(manual.ID, 2000)
+	download(manual.ID, 3000)
+	download(manual.ID, 4000)
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedTotal  int64
+		expectedTop    []string
+	}{
+		{"Direct", "/api/spaces/1/stats/downloads", http.StatusOK, 1, []string{"spec.pdf"}},
+		{"Recursive", "/api/spaces/1/stats/downloads?recursive=true", http.StatusOK, 4, []string{"manual.pdf", "spec.pdf"}},
+		{"Limited", "/api/spaces/1/stats/downloads?recursive=true&limit=1", http.StatusOK, 4, []string{"manual.pdf"}},
+		{"Global", "/api/spaces/0/stats/downloads", http.StatusOK, 4, []string{"manual.pdf", "spec.pdf"}},
+		{"Invalid limit", "/api/spaces/1/stats/downloads?limit=1000", http.StatusBadRequest, 0, nil},
+		{"Unknown space", "/api/spaces/999/stats/downloads", http.StatusNotFound, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response DownloadsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response.TotalDownloads != tt.expectedTotal {
+				t.Errorf("Expected %d downloads, got %d", tt.expectedTotal, response.TotalDownloads)
+			}
+			if len(response.TopFiles) != len(tt.expectedTop) {
+				t.Fatalf("Expected top files %v, got %+v", tt.expectedTop, response.TopFiles)
+			}
+			for i, filename := range tt.expectedTop {
+				if response.TopFiles[i].Filename != filename {
+					t.Errorf("Expected top file %d to be %s, got %s", i, filename, response.TopFiles[i].Filename)
+				}
+			}
+		})
+	}
+
+	// Deleting an attachment drops its counter
+	db.DeletePost(context.Background(), childPost.ID)
+	stats, _ := service.GetDownloadStats(context.Background(), 0, false, 10)
+	if stats.TotalDownloads != 1 {
+		t.Errorf("Expected 1 download after delete, got %d", stats.TotalDownloads)
+	}
+}
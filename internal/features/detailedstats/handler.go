@@ -2,6 +2,7 @@ package detailedstats
 
 import (
 	"backthynk/internal/config"
+	"backthynk/internal/storage"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -23,9 +24,23 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	}
 	
 	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/stats/history", h.GetSpaceStatsHistory).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/stats/downloads", h.GetSpaceDownloadStats).Methods("GET")
 	api.HandleFunc("/space-stats/{id}", h.GetSpaceStats).Methods("GET")
 }
 
+type HistoryResponse struct {
+	SpaceID   int                     `json:"space_id"`
+	Days      int                     `json:"days"`
+	Snapshots []storage.StatsSnapshot `json:"snapshots"`
+}
+
+type DownloadsResponse struct {
+	SpaceID   int  `json:"space_id"`
+	Recursive bool `json:"recursive"`
+	DownloadStats
+}
+
 type StatsResponse struct {
 	SpaceID int   `json:"space_id"`
 	Recursive  bool  `json:"recursive"`
@@ -43,12 +58,7 @@ func (h *Handler) GetSpaceStats(w http.ResponseWriter, r *http.Request) {
 	
 	recursive := r.URL.Query().Get("recursive") == "true"
 	
-	var stats *Stats
-	if spaceID == 0 { // Global stats
-		stats = h.service.GetGlobalStats()
-	} else {
-		stats = h.service.GetStats(spaceID, recursive)
-	}
+	stats := h.service.GetVisibleStats(r.Context(), spaceID, recursive) // Space 0 is global
 	
 	response := StatsResponse{
 		SpaceID: spaceID,
@@ -59,4 +69,85 @@ func (h *Handler) GetSpaceStats(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
+}
+
+// GetSpaceStatsHistory returns daily post count and storage snapshots of a space, oldest first
+func (h *Handler) GetSpaceStatsHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := h.service.catCache.Get(spaceID); !ok || !h.service.access.CanRead(r.Context(), spaceID) {
+		http.Error(w, config.ErrSpaceNotFound, http.StatusNotFound)
+		return
+	}
+
+	days := config.DefaultStatsHistoryDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days < 1 || days > config.MaxStatsHistoryDays {
+			http.Error(w, config.ErrInvalidHistoryDays, http.StatusBadRequest)
+			return
+		}
+	}
+
+	snapshots, err := h.service.GetHistory(spaceID, days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HistoryResponse{
+		SpaceID:   spaceID,
+		Days:      days,
+		Snapshots: snapshots,
+	})
+}
+
+// GetSpaceDownloadStats returns attachment download totals and the most downloaded files of a space
+// Query parameters:
+// - recursive: include descendant spaces (default: false)
+// - limit: number of top files to return (default: 10, max: 100)
+func (h *Handler) GetSpaceDownloadStats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	if spaceID != 0 {
+		if _, ok := h.service.catCache.Get(spaceID); !ok || !h.service.access.CanRead(r.Context(), spaceID) {
+			http.Error(w, config.ErrSpaceNotFound, http.StatusNotFound)
+			return
+		}
+	}
+
+	limit := config.DefaultTopDownloadsLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > config.MaxTopDownloadsLimit {
+			http.Error(w, config.ErrInvalidDownloadsLimit, http.StatusBadRequest)
+			return
+		}
+	}
+
+	recursive := r.URL.Query().Get("recursive") == "true"
+
+	stats, err := h.service.GetDownloadStats(r.Context(), spaceID, recursive, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DownloadsResponse{
+		SpaceID:       spaceID,
+		Recursive:     recursive,
+		DownloadStats: *stats,
+	})
+}
@@ -0,0 +1,86 @@
+package detailedstats
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/storage"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const snapshotDayFormat = "2006-01-02"
+
+// RecordSnapshot stores today's post count and storage for every space.
+// Running it several times a day overwrites the day's snapshot with the latest values.
+func (s *Service) RecordSnapshot() error {
+	if !s.enabled {
+		return nil
+	}
+
+	day := s.now().UTC().Format(snapshotDayFormat)
+	spaces := s.catCache.GetAll()
+	snapshots := make([]storage.StatsSnapshot, 0, len(spaces))
+
+	for _, space := range spaces {
+		direct := s.GetStats(space.ID, false)
+		recursive := s.GetStats(space.ID, true)
+		snapshots = append(snapshots, storage.StatsSnapshot{
+			SpaceID:            space.ID,
+			Day:                day,
+			PostCount:          space.PostCount,
+			RecursivePostCount: space.RecursivePostCount,
+			FileCount:          direct.FileCount,
+			TotalSize:          direct.TotalSize,
+			RecursiveFileCount: recursive.FileCount,
+			RecursiveTotalSize: recursive.TotalSize,
+		})
+	}
+
+	return s.db.SaveStatsSnapshots(snapshots)
+}
+
+// StartSnapshots records a snapshot immediately and then once per interval until Stop is called
+func (s *Service) StartSnapshots(interval time.Duration) {
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	if err := s.RecordSnapshot(); err != nil {
+		logger.Warning("Failed to record stats snapshot", zap.Error(err))
+	}
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.RecordSnapshot(); err != nil {
+					logger.Warning("Failed to record stats snapshot", zap.Error(err))
+				}
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// Stop ends the periodic snapshot loop
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// GetHistory returns the daily snapshots of a space for the last days days, oldest first
+func (s *Service) GetHistory(spaceID int, days int) ([]storage.StatsSnapshot, error) {
+	if !s.enabled {
+		return []storage.StatsSnapshot{}, nil
+	}
+
+	from := s.now().UTC().AddDate(0, 0, -(days - 1)).Format(snapshotDayFormat)
+	return s.db.GetStatsSnapshots(spaceID, from)
+}
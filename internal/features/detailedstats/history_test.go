@@ -0,0 +1,147 @@
+package detailedstats
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/storage"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func setupHistoryTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_stats_history_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestRecordSnapshotHistory(t *testing.T) {
+	db, cleanup := setupHistoryTestDB(t)
+	defer cleanup()
+
+	parent, _ := db.CreateSpace("Parent", nil, "")
+	child, _ := db.CreateSpace("Child", &parent.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(parent)
+	catCache.Set(child)
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	day := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return day }
+
+	// Day 1: one post with a file in the child
+	catCache.UpdatePostCount(child.ID, 1)
+	service.HandleEvent(events.Event{Type: events.FileUploaded, Data: events.PostEvent{SpaceID: child.ID, PostID: 1, FileSize: 500}})
+	if err := service.RecordSnapshot(); err != nil {
+		t.Fatalf("RecordSnapshot failed: %v", err)
+	}
+
+	// Day 2: the snapshot is recorded twice, the latest values win
+	day = day.AddDate(0, 0, 1)
+	catCache.UpdatePostCount(parent.ID, 1)
+	service.RecordSnapshot()
+	catCache.UpdatePostCount(child.ID, 1)
+	service.HandleEvent(events.Event{Type: events.FileUploaded, Data: events.PostEvent{SpaceID: child.ID, PostID: 2, FileSize: 300}})
+	service.RecordSnapshot()
+
+	history, err := service.GetHistory(parent.ID, 30)
+	if err != nil {
+		t.Fatalf("GetHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 snapshots, got %d", len(history))
+	}
+
+	first, second := history[0], history[1]
+	if first.Day != "2024-03-10" || first.PostCount != 0 || first.RecursivePostCount != 1 || first.RecursiveTotalSize != 500 {
+		t.Errorf("Unexpected first snapshot: %+v", first)
+	}
+	if second.Day != "2024-03-11" || second.PostCount != 1 || second.RecursivePostCount != 3 ||
+		second.TotalSize != 0 || second.RecursiveFileCount != 2 || second.RecursiveTotalSize != 800 {
+		t.Errorf("Unexpected second snapshot: %+v", second)
+	}
+
+	// Only the last day falls in a one day window
+	history, _ = service.GetHistory(parent.ID, 1)
+	if len(history) != 1 || history[0].Day != "2024-03-11" {
+		t.Errorf("Expected only the latest snapshot, got %+v", history)
+	}
+}
+
+func TestGetSpaceStatsHistoryHandler(t *testing.T) {
+	db, cleanup := setupHistoryTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Space", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	service := NewService(db, catCache, true)
+	service.Initialize()
+	service.RecordSnapshot()
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{"Default window", "/api/spaces/" + strconv.Itoa(space.ID) + "/stats/history", http.StatusOK, 1},
+		{"Custom window", "/api/spaces/" + strconv.Itoa(space.ID) + "/stats/history?days=7", http.StatusOK, 1},
+		{"Invalid days", "/api/spaces/" + strconv.Itoa(space.ID) + "/stats/history?days=0", http.StatusBadRequest, 0},
+		{"Too many days", "/api/spaces/" + strconv.Itoa(space.ID) + "/stats/history?days=9999", http.StatusBadRequest, 0},
+		{"Unknown space", "/api/spaces/999/stats/history", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.url, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response HistoryResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response.SpaceID != space.ID || len(response.Snapshots) != tt.expectedCount {
+				t.Errorf("Unexpected response: %+v", response)
+			}
+		})
+	}
+}
@@ -1,10 +1,16 @@
 package detailedstats
 
 import (
+	"backthynk/internal/config"
 	"backthynk/internal/core/cache"
 	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
 	"backthynk/internal/storage"
+	"context"
 	"sync"
+	"time"
+	"unsafe"
 )
 
 type Stats struct {
@@ -18,7 +24,10 @@ type Service struct {
 	stats     map[int]*SpaceStats     // spaceID -> stats
 	postFiles map[int]map[int]*FileInfo  // spaceID -> postID -> file info
 	mu        sync.RWMutex
+	access    *services.SpaceAccess
 	enabled   bool
+	stop      chan struct{}
+	now       func() time.Time
 }
 
 type FileInfo struct {
@@ -39,6 +48,7 @@ func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Servi
 		stats:     make(map[int]*SpaceStats),
 		postFiles: make(map[int]map[int]*FileInfo),
 		enabled:   enabled,
+		now:       time.Now,
 	}
 }
 
@@ -136,12 +146,20 @@ func (s *Service) HandleEvent(event events.Event) error {
 		s.updateStats(data.SpaceID, -data.FileSize, -1)
 		s.trackFileByPost(data.SpaceID, data.PostID, -data.FileSize, -1)
 		
+	case events.FileDownloaded:
+		data := event.Data.(events.PostEvent)
+		return s.recordDownload(data.AttachmentID, data.Timestamp)
+
 	case events.PostDeleted:
 		data := event.Data.(events.PostEvent)
 		if data.FileCount > 0 {
 			s.updateStats(data.SpaceID, -data.FileSize, -data.FileCount)
 		}
 
+	case events.PostUpdated:
+		data := event.Data.(events.PostEvent)
+		return s.handlePostUpdated(data.SpaceID, data.PostID)
+
 	case events.PostMoved:
 		data := event.Data.(events.PostEvent)
 		if data.OldSpaceID != nil {
@@ -150,6 +168,14 @@ func (s *Service) HandleEvent(event events.Event) error {
 			s.handlePostMoved(data.PostID, *data.OldSpaceID, data.SpaceID)
 		}
 
+	case events.PostMerged:
+		data := event.Data.(events.PostEvent)
+		s.handlePostMerged(data.SpaceID, data.PostID, data.MergedPosts)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		s.handlePostSplit(data.SpaceID, data.PostID, data.SplitPosts)
+
 	case events.SpaceUpdated:
 		data := event.Data.(events.SpaceEvent)
 		// When a space is moved, we need to recalculate recursive stats
@@ -295,6 +321,52 @@ func (s *Service) GetGlobalStats() *Stats {
 	return total
 }
 
+// SetSpaceAccess leaves the spaces hidden from the viewer of a request out of the stats it reads
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// GetVisibleStats returns the stats of a space as the viewer of ctx sees them: a hidden space is
+// empty and recursive stats leave out hidden descendants. Space 0 covers every visible space.
+func (s *Service) GetVisibleStats(ctx context.Context, spaceID int, recursive bool) *Stats {
+	hidden := s.access.Hidden(ctx)
+	if hidden == nil {
+		if spaceID == 0 {
+			return s.GetGlobalStats()
+		}
+		return s.GetStats(spaceID, recursive)
+	}
+	if !s.enabled || hidden[spaceID] {
+		return &Stats{}
+	}
+
+	var spaceIDs []int
+	if spaceID == 0 {
+		spaceIDs = s.access.Scope(ctx, nil)
+	} else {
+		spaceIDs = []int{spaceID}
+		if recursive {
+			spaceIDs = append(spaceIDs, s.catCache.GetDescendants(spaceID)...)
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	total := &Stats{}
+	for _, id := range spaceIDs {
+		stats, ok := s.stats[id]
+		if !ok || hidden[id] {
+			continue
+		}
+		stats.mu.RLock()
+		total.FileCount += stats.Direct.FileCount
+		total.TotalSize += stats.Direct.TotalSize
+		stats.mu.RUnlock()
+	}
+	return total
+}
+
 // handleSpaceHierarchyChange handles when a space is moved to a different parent
 func (s *Service) handleSpaceHierarchyChange(spaceID int, oldParentID, newParentID *int) {
 	if !s.enabled {
@@ -486,6 +558,97 @@ func (s *Service) handleSpaceDeleted(spaceID int, affectedPosts []int, parentID
 	// for each subspace, so this method will be called for each one individually
 }
 
+// handlePostMerged reassigns file tracking of merged posts to the surviving post.
+// Space totals are unchanged since the files stay in the same space.
+func (s *Service) handlePostMerged(spaceID, survivorID int, merged []events.MergedPost) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	postFiles, ok := s.postFiles[spaceID]
+	if !ok {
+		return
+	}
+
+	for _, m := range merged {
+		fileInfo, ok := postFiles[m.PostID]
+		if !ok {
+			continue
+		}
+		delete(postFiles, m.PostID)
+
+		if survivor, ok := postFiles[survivorID]; ok {
+			survivor.FileCount += fileInfo.FileCount
+			survivor.TotalSize += fileInfo.TotalSize
+		} else {
+			postFiles[survivorID] = fileInfo
+		}
+	}
+}
+
+// handlePostSplit moves file tracking from the original post to the posts created by a split.
+// Space totals are unchanged since the files stay in the same space.
+func (s *Service) handlePostSplit(spaceID, originalID int, splits []events.SplitPost) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	postFiles, ok := s.postFiles[spaceID]
+	if !ok {
+		return
+	}
+
+	original, ok := postFiles[originalID]
+	if !ok {
+		return
+	}
+
+	for _, split := range splits {
+		if split.FileCount == 0 {
+			continue
+		}
+		original.FileCount -= int64(split.FileCount)
+		original.TotalSize -= split.FileSize
+		postFiles[split.PostID] = &FileInfo{
+			FileCount: int64(split.FileCount),
+			TotalSize: split.FileSize,
+		}
+	}
+
+	if original.FileCount <= 0 {
+		delete(postFiles, originalID)
+	}
+}
+
+// handlePostUpdated recounts the files of an edited post from the database, correcting the
+// stats of its space when they drifted from what the post holds
+func (s *Service) handlePostUpdated(spaceID, postID int) error {
+	attachments, err := s.db.GetAttachmentsByPost(postID)
+	if err != nil {
+		return err
+	}
+
+	var fileCount, totalSize int64
+	for _, att := range attachments {
+		fileCount++
+		totalSize += att.FileSize
+	}
+
+	s.mu.Lock()
+	var tracked FileInfo
+	if fileInfo, ok := s.postFiles[spaceID][postID]; ok {
+		tracked = *fileInfo
+	}
+	countDelta, sizeDelta := fileCount-tracked.FileCount, totalSize-tracked.TotalSize
+	if countDelta != 0 || sizeDelta != 0 {
+		s.trackFileByPost(spaceID, postID, sizeDelta, int(countDelta))
+	}
+	s.mu.Unlock()
+
+	if countDelta != 0 || sizeDelta != 0 {
+		s.updateStats(spaceID, sizeDelta, int(countDelta))
+	}
+	return nil
+}
+
 // handlePostMoved handles when a post is moved between spaces
 func (s *Service) handlePostMoved(postID, oldSpaceID, newSpaceID int) {
 	// Find the files for this post in the old space
@@ -521,4 +684,18 @@ func (s *Service) handlePostMoved(postID, oldSpaceID, newSpaceID int) {
 		s.updateStats(oldSpaceID, -totalSize, -int(fileCount))
 		s.updateStats(newSpaceID, totalSize, int(fileCount))
 	}
-}
\ No newline at end of file
+}
+// MemoryUsage estimates the memory held by the space stats and the per-post file index;
+// entries are spaces and posts with files
+func (s *Service) MemoryUsage() models.MemoryUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage := models.MemoryUsage{Entries: len(s.stats)}
+	usage.Bytes = int64(len(s.stats)) * (config.MemoryMapEntryOverhead + int64(unsafe.Sizeof(SpaceStats{})))
+	for _, posts := range s.postFiles {
+		usage.Entries += len(posts)
+		usage.Bytes += config.MemoryMapEntryOverhead + int64(len(posts))*(config.MemoryMapEntryOverhead+int64(unsafe.Sizeof(FileInfo{})))
+	}
+	return usage
+}
@@ -1,7 +1,9 @@
 package detailedstats
 
 import (
+	"backthynk/internal/core/cache"
 	"backthynk/internal/core/events"
+	"context"
 	"testing"
 )
 
@@ -697,3 +699,39 @@ func TestSpaceDeletionWithPostDeletedEvents(t *testing.T) {
 	}
 }
 
+
+func TestPostUpdatedRecountsFiles(t *testing.T) {
+	db, cleanup := setupHistoryTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	post, _ := db.CreatePost(context.Background(), space.ID, "With files")
+	db.CreateAttachment(post.ID, "a.txt", "a.txt", "text/plain", 100)
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	// A file attached without its event leaves the stats behind until the post is edited
+	db.CreateAttachment(post.ID, "b.txt", "b.txt", "text/plain", 50)
+	if stats := service.GetStats(space.ID, false); stats.FileCount != 1 {
+		t.Fatalf("Expected the missed file to be unknown, got %+v", stats)
+	}
+
+	service.HandleEvent(events.Event{Type: events.PostUpdated, Data: events.PostEvent{PostID: post.ID, SpaceID: space.ID}})
+	if stats := service.GetStats(space.ID, false); stats.FileCount != 2 || stats.TotalSize != 150 {
+		t.Errorf("Expected the files of the post to be recounted, got %+v", stats)
+	}
+	if info := service.postFiles[space.ID][post.ID]; info == nil || info.FileCount != 2 {
+		t.Errorf("Expected the post files to be tracked, got %+v", info)
+	}
+
+	// Recounting an up to date post changes nothing
+	service.HandleEvent(events.Event{Type: events.PostUpdated, Data: events.PostEvent{PostID: post.ID, SpaceID: space.ID}})
+	if stats := service.GetStats(space.ID, true); stats.FileCount != 2 || stats.TotalSize != 150 {
+		t.Errorf("Expected stats to stay the same, got %+v", stats)
+	}
+}
@@ -0,0 +1,53 @@
+package dirimport
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/admin/import/directory", h.ImportDirectory).Methods("POST")
+}
+
+// ImportDirectory handles POST /api/admin/import/directory. With dry_run set, the report
+// lists the spaces and posts the import would create without creating them.
+func (h *Handler) ImportDirectory(w http.ResponseWriter, r *http.Request) {
+	var req ImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.Import(r.Context(), req)
+	if err != nil {
+		switch err.Error() {
+		case config.ErrSpaceNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case config.ErrDirImportPathRequired, config.ErrDirImportNotDirectory, config.ErrDirImportParentTooDeep,
+			config.ErrDirImportTooManyFiles, config.ErrInvalidDirImportGlob:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,70 @@
+package dirimport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("POST", "/api/admin/import/directory", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected directory import routes NOT to be registered when disabled")
+	}
+}
+
+func TestImportDirectoryHandler(t *testing.T) {
+	setup := setupDirImportTest(t)
+	defer setup.cleanup()
+
+	root := filepath.Join(t.TempDir(), "Notes")
+	writeTree(t, root, map[string]string{"Ideas/one.md": "First idea"}, time.Now())
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedPosts  int
+	}{
+		{"Invalid JSON", `nope`, http.StatusBadRequest, 0},
+		{"Relative path", `{"path": "notes"}`, http.StatusBadRequest, 0},
+		{"Unknown parent", fmt.Sprintf(`{"path": %q, "parent_id": 999}`, root), http.StatusNotFound, 0},
+		{"Dry run", fmt.Sprintf(`{"path": %q, "dry_run": true}`, root), http.StatusOK, 1},
+		{"Import", fmt.Sprintf(`{"path": %q}`, root), http.StatusOK, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/api/admin/import/directory", bytes.NewBufferString(tt.body)))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var report ImportReport
+			json.Unmarshal(w.Body.Bytes(), &report)
+			if len(report.Posts) != tt.expectedPosts || len(report.Spaces) != 2 {
+				t.Errorf("Unexpected report: %+v", report)
+			}
+		})
+	}
+
+	if len(setup.catCache.GetAll()) != 2 {
+		t.Errorf("Expected the import to create 2 spaces, got %d", len(setup.catCache.GetAll()))
+	}
+}
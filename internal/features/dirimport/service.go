@@ -0,0 +1,405 @@
+package dirimport
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// PostSource is recorded on the posts created by directory imports
+const PostSource = models.PostSourceImportPrefix + "directory"
+
+// Reasons given for skipped files
+const (
+	reasonUnreadable      = "not readable"
+	reasonEmptyNote       = "empty note"
+	reasonUploadsDisabled = "file uploads are disabled"
+	reasonTypeNotAllowed  = "file type is not allowed"
+	reasonTooLarge        = "file is too large"
+)
+
+// noteExtensions are read as the content of their post, other files are attached to theirs
+var noteExtensions = map[string]bool{"md": true, "markdown": true, "txt": true, "text": true}
+
+// SpaceCreator creates spaces, e.g. the core SpaceService
+type SpaceCreator interface {
+	Create(ctx context.Context, name string, parentID *int, description string) (*models.Space, error)
+}
+
+// PostCreator creates posts with the validation of regular posting, e.g. the core PostService
+type PostCreator interface {
+	CreateWithSource(ctx context.Context, spaceID int, content string, customTimestamp *int64, source string) (*models.Post, error)
+}
+
+// FileUploader attaches files to posts, e.g. the core FileService
+type FileUploader interface {
+	UploadFile(ctx context.Context, postID int, file io.Reader, filename string, fileSize int64) (*models.Attachment, error)
+}
+
+// folder is a directory of the imported tree along with the space receiving its files
+type folder struct {
+	rel      string
+	name     string
+	parent   *folder
+	depth    int
+	target   *folder // Itself, or its closest ancestor when the folder is too deep to be a space
+	spaceID  int
+	existing bool
+}
+
+// file is a file of the imported tree planned as a post
+type file struct {
+	rel     string
+	folder  *folder
+	size    int64
+	created int64
+	content string // Set for notes
+	attach  bool
+}
+
+// Service mirrors a directory tree into spaces: folders become spaces and files become posts
+// dated by their modification time. Notes become the content of their post, other files are
+// attached to a post named after them.
+type Service struct {
+	catCache *cache.SpaceCache
+	options  *config.OptionsConfig
+	spaces   SpaceCreator
+	posts    PostCreator
+	files    FileUploader
+	enabled  bool
+}
+
+func NewService(catCache *cache.SpaceCache, options *config.OptionsConfig, enabled bool) *Service {
+	return &Service{
+		catCache: catCache,
+		options:  options,
+		enabled:  enabled,
+	}
+}
+
+// SetTargets sets the services creating the spaces, posts and attachments of imports
+func (s *Service) SetTargets(spaces SpaceCreator, posts PostCreator, files FileUploader) {
+	s.spaces = spaces
+	s.posts = posts
+	s.files = files
+}
+
+// Import walks the directory of req and mirrors it, or only reports what it would create
+// when req.DryRun is set. Folders of an existing space with the same name are merged into it,
+// folders without any file to import are left out. Cancelling ctx stops the import between
+// two spaces or files, keeping what was imported so far.
+func (s *Service) Import(ctx context.Context, req ImportRequest) (*ImportReport, error) {
+	if req.Path == "" || !filepath.IsAbs(req.Path) {
+		return nil, fmt.Errorf(config.ErrDirImportPathRequired)
+	}
+	if info, err := os.Stat(req.Path); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf(config.ErrDirImportNotDirectory)
+	}
+	for _, pattern := range append(append([]string{}, req.Include...), req.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf(config.ErrInvalidDirImportGlob)
+		}
+	}
+
+	depth := 0
+	if req.ParentID != nil {
+		parent, ok := s.catCache.Get(*req.ParentID)
+		if !ok {
+			return nil, fmt.Errorf(config.ErrSpaceNotFound)
+		}
+		depth = parent.Depth + 1
+		if depth > config.MaxSpaceDepth {
+			return nil, fmt.Errorf(config.ErrDirImportParentTooDeep)
+		}
+	}
+
+	report := &ImportReport{
+		DryRun:    req.DryRun,
+		Spaces:    []ImportedSpace{},
+		Posts:     []ImportedPost{},
+		Skipped:   []SkippedFile{},
+		Flattened: []string{},
+	}
+	folders, files, err := s.plan(req, depth, report)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only folders leading to an imported file become spaces
+	used := make(map[*folder]bool)
+	for _, f := range files {
+		for current := f.folder.target; current != nil && !used[current]; {
+			used[current] = true
+			if current.parent == nil {
+				break
+			}
+			current = current.parent.target
+		}
+	}
+
+	for _, f := range folders {
+		if f.target != f || !used[f] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !f.existing && !req.DryRun {
+			space, err := s.spaces.Create(ctx, f.name, s.parentID(f, req.ParentID), "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to create space for %s: %w", f.rel, err)
+			}
+			f.spaceID = space.ID
+		}
+		report.Spaces = append(report.Spaces, ImportedSpace{Path: f.rel, Name: f.name, Existing: f.existing, SpaceID: f.spaceID})
+	}
+
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		imported := ImportedPost{Path: f.rel, Space: f.folder.target.rel, Created: f.created, Attachment: f.attach}
+		if !req.DryRun {
+			postID, err := s.importFile(ctx, req.Path, f)
+			if err != nil {
+				report.Skipped = append(report.Skipped, SkippedFile{Path: f.rel, Reason: err.Error()})
+				continue
+			}
+			imported.PostID = postID
+		}
+		report.Posts = append(report.Posts, imported)
+	}
+
+	return report, nil
+}
+
+// plan walks the directory, resolving the space of each folder and the post of each file
+func (s *Service) plan(req ImportRequest, depth int, report *ImportReport) ([]*folder, []*file, error) {
+	maxLength := config.MaxContentLength
+	if s.options != nil && s.options.Core.MaxContentLength > 0 {
+		maxLength = s.options.Core.MaxContentLength
+	}
+
+	root := &folder{rel: ".", name: spaceName(filepath.Base(req.Path)), depth: depth}
+	root.target = root
+	if root.name == "" {
+		return nil, nil, fmt.Errorf(config.ErrDirImportNotDirectory)
+	}
+	s.resolveExisting(root, req.ParentID)
+
+	folders := []*folder{root}
+	byPath := map[string]*folder{".": root}
+	byName := make(map[string]*folder) // parent target path + lowercased name -> folder
+	var files []*file
+
+	err := filepath.WalkDir(req.Path, func(p string, d fs.DirEntry, err error) error {
+		rel, _ := filepath.Rel(req.Path, p)
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return err
+		}
+		if err != nil {
+			report.Skipped = append(report.Skipped, SkippedFile{Path: rel, Reason: reasonUnreadable})
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || matchesAny(req.Exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		parent := byPath[path.Dir(rel)]
+		if d.IsDir() {
+			f := &folder{rel: rel, name: spaceName(d.Name()), parent: parent, depth: parent.depth + 1}
+			if f.depth > config.MaxSpaceDepth || f.name == "" {
+				f.target = parent.target
+				report.Flattened = append(report.Flattened, rel)
+			} else if same, ok := byName[parent.target.rel+"/"+strings.ToLower(f.name)]; ok {
+				// Folders whose names only differ by the characters spaces cannot hold share a space
+				f.target = same.target
+			} else {
+				f.target = f
+				byName[parent.target.rel+"/"+strings.ToLower(f.name)] = f
+				s.resolveExisting(f, s.parentID(f, req.ParentID))
+			}
+			byPath[rel] = f
+			folders = append(folders, f)
+			return nil
+		}
+
+		if !d.Type().IsRegular() || (len(req.Include) > 0 && !matchesAny(req.Include, rel)) {
+			return nil
+		}
+		if len(files) >= config.MaxDirImportFiles {
+			return fmt.Errorf(config.ErrDirImportTooManyFiles)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			report.Skipped = append(report.Skipped, SkippedFile{Path: rel, Reason: reasonUnreadable})
+			return nil
+		}
+		f := &file{
+			rel:     rel,
+			folder:  parent,
+			size:    info.Size(),
+			created: max(info.ModTime().UnixMilli(), int64(config.MinRetroactivePostTimestamp)),
+		}
+
+		if noteExtensions[extension(d.Name())] {
+			data, err := os.ReadFile(p)
+			if err != nil {
+				report.Skipped = append(report.Skipped, SkippedFile{Path: rel, Reason: reasonUnreadable})
+				return nil
+			}
+			f.content = strings.TrimSpace(string(data))
+			if f.content == "" {
+				report.Skipped = append(report.Skipped, SkippedFile{Path: rel, Reason: reasonEmptyNote})
+				return nil
+			}
+			// Notes too long for a post are attached to one instead
+			f.attach = utf8.RuneCountInString(f.content) > maxLength || !utf8.ValidString(f.content)
+		} else {
+			f.attach = true
+		}
+
+		if f.attach {
+			if reason := s.attachmentProblem(d.Name(), f.size); reason != "" {
+				report.Skipped = append(report.Skipped, SkippedFile{Path: rel, Reason: reason})
+				return nil
+			}
+		}
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return folders, files, nil
+}
+
+// resolveExisting reuses the space of the same name under the parent of f, if any
+func (s *Service) resolveExisting(f *folder, parentID *int) {
+	if f.parent != nil && !f.parent.target.existing {
+		return
+	}
+	for _, space := range s.catCache.GetAll() {
+		sameParent := (parentID == nil && space.ParentID == nil) ||
+			(parentID != nil && space.ParentID != nil && *parentID == *space.ParentID)
+		if sameParent && strings.EqualFold(space.Name, f.name) {
+			f.existing = true
+			f.spaceID = space.ID
+			return
+		}
+	}
+}
+
+// parentID returns the space receiving the space of f, nil for top level spaces
+func (s *Service) parentID(f *folder, rootParentID *int) *int {
+	if f.parent == nil {
+		return rootParentID
+	}
+	id := f.parent.target.spaceID
+	return &id
+}
+
+// attachmentProblem tells why a file cannot be attached, or returns an empty string
+func (s *Service) attachmentProblem(name string, size int64) string {
+	if s.options == nil || !s.options.Features.FileUpload.Enabled {
+		return reasonUploadsDisabled
+	}
+	allowed := false
+	for _, ext := range s.options.Features.FileUpload.AllowedExtensions {
+		if strings.EqualFold(ext, extension(name)) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return reasonTypeNotAllowed
+	}
+	if size > int64(s.options.Features.FileUpload.MaxFileSizeMB)<<20 {
+		return reasonTooLarge
+	}
+	return ""
+}
+
+// importFile creates the post of a file and returns its ID
+func (s *Service) importFile(ctx context.Context, root string, f *file) (int, error) {
+	content := f.content
+	if f.attach {
+		content = path.Base(f.rel)
+	}
+	post, err := s.posts.CreateWithSource(ctx, f.folder.target.spaceID, content, &f.created, PostSource)
+	if err != nil {
+		return 0, err
+	}
+	if !f.attach {
+		return post.ID, nil
+	}
+
+	data, err := os.Open(filepath.Join(root, filepath.FromSlash(f.rel)))
+	if err != nil {
+		return 0, fmt.Errorf(reasonUnreadable)
+	}
+	defer data.Close()
+	if _, err := s.files.UploadFile(ctx, post.ID, data, path.Base(f.rel), f.size); err != nil {
+		return 0, err
+	}
+	return post.ID, nil
+}
+
+// matchesAny reports whether a relative path, or its last element, matches one of patterns
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// extension returns the lowercased extension of a file name, without its dot
+func extension(name string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+}
+
+// spaceName turns a folder name into a valid space name, empty when nothing is left.
+// Characters spaces cannot hold become spaces.
+func spaceName(dir string) string {
+	var b strings.Builder
+	for _, r := range dir {
+		switch {
+		case r < utf8.RuneSelf && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'),
+			r == '-', r == '_', r == '\'', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+
+	name := strings.Join(strings.Fields(b.String()), " ")
+	if len(name) > config.MaxSpaceNameLength {
+		name = name[:config.MaxSpaceNameLength]
+	}
+	return strings.TrimFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	})
+}
@@ -0,0 +1,235 @@
+package dirimport
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dirImportTestSetup struct {
+	db           *storage.DB
+	catCache     *cache.SpaceCache
+	spaceService *services.SpaceService
+	postService  *services.PostService
+	service      *Service
+	cleanup      func()
+}
+
+func setupDirImportTest(t *testing.T) *dirImportTestSetup {
+	tempDir, err := os.MkdirTemp("", "backthynk_dirimport_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	testConfig.Files.StoragePath = tempDir
+	testConfig.Files.UploadsSubdir = "uploads"
+	config.SetServiceConfigForTest(testConfig)
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	options := config.NewTestOptionsConfig().WithMaxContentLength(100)
+	options.Features.FileUpload.Enabled = true
+	options.Features.FileUpload.AllowedExtensions = []string{"png", "md"}
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	setup := &dirImportTestSetup{
+		db:           db,
+		catCache:     catCache,
+		spaceService: services.NewSpaceService(db, catCache, dispatcher),
+		postService:  services.NewPostService(db, catCache, dispatcher),
+		service:      NewService(catCache, options, true),
+		cleanup: func() {
+			db.Close()
+			os.RemoveAll(tempDir)
+		},
+	}
+	setup.service.SetTargets(setup.spaceService, setup.postService, services.NewFileService(db, dispatcher))
+	return setup
+}
+
+// writeTree creates files under root, all modified at mtime
+func writeTree(t *testing.T, root string, files map[string]string, mtime time.Time) {
+	for rel, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", rel, err)
+		}
+		os.Chtimes(p, mtime, mtime)
+	}
+}
+
+func TestImport(t *testing.T) {
+	setup := setupDirImportTest(t)
+	defer setup.cleanup()
+
+	root := filepath.Join(t.TempDir(), "My notes")
+	mtime := time.Date(2023, 5, 4, 10, 0, 0, 0, time.UTC)
+	writeTree(t, root, map[string]string{
+		"Work/plan.md":              "Ship the importer",
+		"Work/diagram.png":          "png bytes",
+		"Work/Deep/Deeper/idea.txt": "Flattened idea",
+		"Work/long.md":              strings.Repeat("a", 101),
+		"Drafts!/draft.md":          "A draft",
+		"Drafts!/scratch.md":        "Scratch",
+		"empty.md":                  "  ",
+		"tool.exe":                  "binary",
+		".git/config":               "hidden",
+		"Archive/old.md":            "Old",
+	}, mtime)
+
+	request := ImportRequest{Path: root, Exclude: []string{"Archive", "scratch.*"}, DryRun: true}
+	dryRun, err := setup.service.Import(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Failed dry run: %v", err)
+	}
+	if len(setup.catCache.GetAll()) != 0 {
+		t.Fatal("Expected dry run not to create spaces")
+	}
+
+	var spaces []string
+	for _, space := range dryRun.Spaces {
+		spaces = append(spaces, space.Path+"="+space.Name)
+	}
+	if strings.Join(spaces, ",") != ".=My notes,Drafts!=Drafts,Work=Work,Work/Deep=Deep" {
+		t.Errorf("Unexpected planned spaces: %v", spaces)
+	}
+	if len(dryRun.Flattened) != 1 || dryRun.Flattened[0] != "Work/Deep/Deeper" {
+		t.Errorf("Expected too deep folder to be flattened, got %v", dryRun.Flattened)
+	}
+	if len(dryRun.Posts) != 5 || len(dryRun.Skipped) != 2 {
+		t.Fatalf("Expected 5 posts and 2 skipped files, got %+v", dryRun)
+	}
+	posts := make(map[string]ImportedPost)
+	for _, post := range dryRun.Posts {
+		posts[post.Path] = post
+	}
+	if post := posts["Work/Deep/Deeper/idea.txt"]; post.Space != "Work/Deep" || post.Created != mtime.UnixMilli() {
+		t.Errorf("Unexpected flattened post: %+v", post)
+	}
+	if !posts["Work/diagram.png"].Attachment || !posts["Work/long.md"].Attachment || posts["Work/plan.md"].Attachment {
+		t.Errorf("Expected files and long notes to be attached, got %+v", posts)
+	}
+
+	request.DryRun = false
+	report, err := setup.service.Import(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Failed import: %v", err)
+	}
+	if len(report.Posts) != 5 || len(report.Skipped) != 2 {
+		t.Fatalf("Expected 5 posts and 2 skipped files, got %+v", report)
+	}
+
+	work, ok := setup.catCache.Get(report.Spaces[2].SpaceID)
+	if !ok || work.Name != "Work" || work.ParentID == nil || *work.ParentID != report.Spaces[0].SpaceID {
+		t.Fatalf("Unexpected Work space: %+v", work)
+	}
+	listed, _ := setup.postService.GetBySpace(context.Background(), work.ID, false, 10, 0, models.PostFilter{})
+	if len(listed) != 3 {
+		t.Fatalf("Expected 3 posts in Work, got %d", len(listed))
+	}
+	for _, post := range listed {
+		if post.Source != PostSource || post.Created != mtime.UnixMilli() {
+			t.Errorf("Unexpected imported post: %+v", post.Post)
+		}
+		if (post.Content == "diagram.png" || post.Content == "long.md") != (len(post.Attachments) == 1) {
+			t.Errorf("Unexpected attachments on %q: %+v", post.Content, post.Attachments)
+		}
+	}
+
+	// Importing again reuses the spaces
+	again, err := setup.service.Import(context.Background(), ImportRequest{Path: root, Include: []string{"Work/plan.md"}})
+	if err != nil {
+		t.Fatalf("Failed second import: %v", err)
+	}
+	for _, space := range again.Spaces {
+		if !space.Existing {
+			t.Errorf("Expected %s to reuse its space, got %+v", space.Path, space)
+		}
+	}
+	if len(again.Posts) != 1 || len(setup.catCache.GetAll()) != 4 {
+		t.Errorf("Expected one post and no new space, got %+v", again)
+	}
+}
+
+func TestImportValidation(t *testing.T) {
+	setup := setupDirImportTest(t)
+	defer setup.cleanup()
+
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"note.md": "Note"}, time.Now())
+	top, _ := setup.spaceService.Create(context.Background(), "Top", nil, "")
+	child, _ := setup.spaceService.Create(context.Background(), "Child", &top.ID, "")
+	leaf, _ := setup.spaceService.Create(context.Background(), "Leaf", &child.ID, "")
+	unknown := 999
+
+	tests := []struct {
+		name        string
+		request     ImportRequest
+		expectedErr string
+	}{
+		{"Relative path", ImportRequest{Path: "notes"}, config.ErrDirImportPathRequired},
+		{"Missing directory", ImportRequest{Path: filepath.Join(root, "missing")}, config.ErrDirImportNotDirectory},
+		{"File path", ImportRequest{Path: filepath.Join(root, "note.md")}, config.ErrDirImportNotDirectory},
+		{"Invalid glob", ImportRequest{Path: root, Include: []string{"[md"}}, config.ErrInvalidDirImportGlob},
+		{"Unknown parent", ImportRequest{Path: root, ParentID: &unknown}, config.ErrSpaceNotFound},
+		{"Parent too deep", ImportRequest{Path: root, ParentID: &leaf.ID}, config.ErrDirImportParentTooDeep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := setup.service.Import(context.Background(), tt.request)
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Errorf("Expected %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	// Under a parent, folders deeper than the max depth are flattened sooner
+	writeTree(t, root, map[string]string{"sub/nested.md": "Nested"}, time.Now())
+	report, err := setup.service.Import(context.Background(), ImportRequest{Path: root, ParentID: &child.ID, DryRun: true})
+	if err != nil {
+		t.Fatalf("Failed dry run: %v", err)
+	}
+	if len(report.Spaces) != 1 || len(report.Flattened) != 1 || report.Flattened[0] != "sub" {
+		t.Errorf("Expected sub folder to be flattened, got %+v", report)
+	}
+}
+
+func TestSpaceName(t *testing.T) {
+	tests := []struct {
+		dir      string
+		expected string
+	}{
+		{"Notes", "Notes"},
+		{"2023 (old) notes", "2023 old notes"},
+		{"_drafts", "drafts"},
+		{"Café", "Caf"},
+		{"!!!", ""},
+		{strings.Repeat("a", 40), strings.Repeat("a", 30)},
+	}
+
+	for _, tt := range tests {
+		if got := spaceName(tt.dir); got != tt.expected {
+			t.Errorf("spaceName(%q) = %q, expected %q", tt.dir, got, tt.expected)
+		}
+	}
+}
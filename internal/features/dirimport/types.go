@@ -0,0 +1,42 @@
+package dirimport
+
+// ImportRequest describes a directory tree to mirror into spaces
+type ImportRequest struct {
+	Path     string   `json:"path"`      // Absolute path of the directory on the server
+	ParentID *int     `json:"parent_id"` // Space receiving the root directory, top level when nil
+	Include  []string `json:"include"`   // Globs a file must match, on its relative path or its name; every file when empty
+	Exclude  []string `json:"exclude"`   // Globs of files and folders left out, on their relative path or their name
+	DryRun   bool     `json:"dry_run"`
+}
+
+// ImportedSpace is a folder mirrored as a space
+type ImportedSpace struct {
+	Path     string `json:"path"` // Relative to the imported directory, "." for the directory itself
+	Name     string `json:"name"`
+	Existing bool   `json:"existing"`           // A space of that name was already there and is reused
+	SpaceID  int    `json:"space_id,omitempty"` // Unset in dry runs for new spaces
+}
+
+// ImportedPost is a file mirrored as a post
+type ImportedPost struct {
+	Path       string `json:"path"`
+	Space      string `json:"space"`   // Path of the folder whose space receives the post
+	Created    int64  `json:"created"` // File modification time, in milliseconds
+	Attachment bool   `json:"attachment"`
+	PostID     int    `json:"post_id,omitempty"` // Unset in dry runs
+}
+
+// SkippedFile is a file or folder that could not be imported
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport lists what an import created, or would create in a dry run
+type ImportReport struct {
+	DryRun    bool            `json:"dry_run"`
+	Spaces    []ImportedSpace `json:"spaces"`
+	Posts     []ImportedPost  `json:"posts"`
+	Skipped   []SkippedFile   `json:"skipped"`
+	Flattened []string        `json:"flattened"` // Folders too deep or without a usable name, their files go to the closest space
+}
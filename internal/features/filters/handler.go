@@ -0,0 +1,124 @@
+package filters
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/filters", h.ListFilters).Methods("GET")
+	api.HandleFunc("/filters", h.CreateFilter).Methods("POST")
+	api.HandleFunc("/filters/{id:[0-9]+}", h.GetFilter).Methods("GET")
+	api.HandleFunc("/filters/{id:[0-9]+}", h.UpdateFilter).Methods("PUT")
+	api.HandleFunc("/filters/{id:[0-9]+}", h.DeleteFilter).Methods("DELETE")
+}
+
+// ListFilters handles GET /api/filters
+func (h *Handler) ListFilters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(FiltersResponse{Filters: h.service.List()})
+}
+
+// GetFilter handles GET /api/filters/{id}
+func (h *Handler) GetFilter(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSavedFilterID, http.StatusBadRequest)
+		return
+	}
+
+	filter, ok := h.service.GetFilter(id)
+	if !ok {
+		http.Error(w, config.ErrSavedFilterNotFound, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(filter)
+}
+
+// CreateFilter handles POST /api/filters
+func (h *Handler) CreateFilter(w http.ResponseWriter, r *http.Request) {
+	var req FilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	filter, err := h.service.Create(req)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(filter)
+}
+
+// UpdateFilter handles PUT /api/filters/{id}
+func (h *Handler) UpdateFilter(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSavedFilterID, http.StatusBadRequest)
+		return
+	}
+
+	var req FilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	filter, err := h.service.Update(id, req)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(filter)
+}
+
+// DeleteFilter handles DELETE /api/filters/{id}
+func (h *Handler) DeleteFilter(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSavedFilterID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func errorStatus(err error) int {
+	switch err.Error() {
+	case config.ErrSavedFilterNotFound:
+		return http.StatusNotFound
+	case config.ErrSavedFilterExists:
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,85 @@
+package filters
+
+import (
+	"backthynk/internal/core/cache"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/filters", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected filter routes NOT to be registered when disabled")
+	}
+}
+
+func TestFilterHandlers(t *testing.T) {
+	db, cleanup := setupFiltersTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	router := mux.NewRouter()
+	NewHandler(NewService(db, catCache, true)).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"Create filter", "POST", "/api/filters", `{"name":"Reviews","space_id":1,"recursive":true,"tags":["review"]}`, http.StatusCreated},
+		{"Invalid JSON", "POST", "/api/filters", `nope`, http.StatusBadRequest},
+		{"Duplicate name", "POST", "/api/filters", `{"name":"reviews"}`, http.StatusConflict},
+		{"Unknown space", "POST", "/api/filters", `{"name":"Other","space_id":999}`, http.StatusBadRequest},
+		{"List filters", "GET", "/api/filters", "", http.StatusOK},
+		{"Get filter", "GET", "/api/filters/1", "", http.StatusOK},
+		{"Get unknown filter", "GET", "/api/filters/999", "", http.StatusNotFound},
+		{"Update filter", "PUT", "/api/filters/1", `{"name":"Reviews","extensions":["pdf"]}`, http.StatusOK},
+		{"Update invalid dates", "PUT", "/api/filters/1", `{"name":"Reviews","start_date":"yesterday"}`, http.StatusBadRequest},
+		{"Update unknown filter", "PUT", "/api/filters/999", `{"name":"x"}`, http.StatusNotFound},
+		{"Delete unknown filter", "DELETE", "/api/filters/999", "", http.StatusNotFound},
+		{"Delete filter", "DELETE", "/api/filters/1", "", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.name == "List filters" {
+				var response FiltersResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+				if len(response.Filters) != 1 || response.Filters[0].Name != "Reviews" {
+					t.Errorf("Unexpected filters: %+v", response)
+				}
+			}
+			if tt.name == "Update filter" {
+				var response struct {
+					SpaceID    int      `json:"space_id"`
+					Extensions []string `json:"extensions"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &response)
+				if response.SpaceID != 0 || len(response.Extensions) != 1 {
+					t.Errorf("Unexpected updated filter: %+v", response)
+				}
+			}
+		})
+	}
+}
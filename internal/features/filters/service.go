@@ -0,0 +1,252 @@
+package filters
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// extensionPattern restricts filter extensions to plain file suffixes
+var extensionPattern = regexp.MustCompile(`^[a-z0-9]{1,10}$`)
+
+// Service keeps the saved timeline filters; listings resolve them through GetFilter
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	filters  map[int]*models.SavedFilter // filterID -> filter
+	mu       sync.RWMutex
+	enabled  bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		filters:  make(map[int]*models.SavedFilter),
+		enabled:  enabled,
+	}
+}
+
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	filters, err := s.db.GetSavedFilters()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for i := range filters {
+		s.filters[filters[i].ID] = &filters[i]
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	if event.Type == events.SpaceDeleted {
+		// Filters scoped to deleted spaces are removed by the database cascade
+		s.mu.Lock()
+		for id, filter := range s.filters {
+			if filter.SpaceID == 0 {
+				continue
+			}
+			if _, ok := s.catCache.Get(filter.SpaceID); !ok {
+				delete(s.filters, id)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// List returns every saved filter sorted by name
+func (s *Service) List() []models.SavedFilter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	filters := make([]models.SavedFilter, 0, len(s.filters))
+	for _, filter := range s.filters {
+		filters = append(filters, *filter)
+	}
+
+	sort.Slice(filters, func(i, j int) bool {
+		return strings.ToLower(filters[i].Name) < strings.ToLower(filters[j].Name)
+	})
+
+	return filters
+}
+
+// GetFilter returns a copy of a saved filter. It is registered as the filter resolver of the
+// post service so listings accept filter_id.
+func (s *Service) GetFilter(id int) (*models.SavedFilter, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	filter, ok := s.filters[id]
+	if !ok {
+		return nil, false
+	}
+
+	copied := *filter
+	return &copied, true
+}
+
+// Create saves a new named filter
+func (s *Service) Create(req FilterRequest) (*models.SavedFilter, error) {
+	filter, err := s.validate(0, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.CreateSavedFilter(filter); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.filters[filter.ID] = filter
+	s.mu.Unlock()
+
+	copied := *filter
+	return &copied, nil
+}
+
+// Update replaces the name and criteria of a saved filter
+func (s *Service) Update(id int, req FilterRequest) (*models.SavedFilter, error) {
+	s.mu.RLock()
+	existing, ok := s.filters[id]
+	var created int64
+	if ok {
+		created = existing.Created
+	}
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf(config.ErrSavedFilterNotFound)
+	}
+
+	filter, err := s.validate(id, req)
+	if err != nil {
+		return nil, err
+	}
+	filter.ID = id
+	filter.Created = created
+
+	if err := s.db.UpdateSavedFilter(filter); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.filters[id] = filter
+	s.mu.Unlock()
+
+	copied := *filter
+	return &copied, nil
+}
+
+// Delete removes a saved filter
+func (s *Service) Delete(id int) error {
+	s.mu.RLock()
+	_, ok := s.filters[id]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf(config.ErrSavedFilterNotFound)
+	}
+
+	if err := s.db.DeleteSavedFilter(id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.filters, id)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// validate checks a request and returns the normalized filter it describes; id is the filter
+// being updated, or 0 on creation
+func (s *Service) validate(id int, req FilterRequest) (*models.SavedFilter, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf(config.ErrSavedFilterNameRequired)
+	}
+	if len(name) > config.MaxSavedFilterNameLength {
+		return nil, fmt.Errorf(config.ErrFmtSavedFilterNameTooLong, config.MaxSavedFilterNameLength)
+	}
+
+	if req.SpaceID != 0 {
+		if _, ok := s.catCache.Get(req.SpaceID); !ok {
+			return nil, fmt.Errorf(config.ErrSpaceNotFound)
+		}
+	}
+
+	if len(req.Tags) > config.MaxSavedFilterTerms || len(req.Extensions) > config.MaxSavedFilterTerms {
+		return nil, fmt.Errorf(config.ErrFmtTooManyFilterTerms, config.MaxSavedFilterTerms, config.MaxSavedFilterTerms)
+	}
+
+	tags := []string{}
+	seenTags := make(map[string]bool)
+	for _, raw := range req.Tags {
+		tag, ok := utils.NormalizeTag(raw)
+		if !ok {
+			return nil, fmt.Errorf(config.ErrInvalidTag)
+		}
+		if !seenTags[tag] {
+			seenTags[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	extensions := []string{}
+	seenExtensions := make(map[string]bool)
+	for _, raw := range req.Extensions {
+		ext := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(raw), "."))
+		if !extensionPattern.MatchString(ext) {
+			return nil, fmt.Errorf(config.ErrInvalidFilterExtension)
+		}
+		if !seenExtensions[ext] {
+			seenExtensions[ext] = true
+			extensions = append(extensions, ext)
+		}
+	}
+
+	if _, _, err := services.DateRangeBounds(req.StartDate, req.EndDate); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	for otherID, other := range s.filters {
+		if otherID != id && strings.EqualFold(other.Name, name) {
+			s.mu.RUnlock()
+			return nil, fmt.Errorf(config.ErrSavedFilterExists)
+		}
+	}
+	s.mu.RUnlock()
+
+	return &models.SavedFilter{
+		Name:       name,
+		SpaceID:    req.SpaceID,
+		Recursive:  req.Recursive && req.SpaceID != 0,
+		Tags:       tags,
+		Extensions: extensions,
+		StartDate:  req.StartDate,
+		EndDate:    req.EndDate,
+	}, nil
+}
@@ -0,0 +1,175 @@
+package filters
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func setupFiltersTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_filters_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestSavedFilterLifecycle(t *testing.T) {
+	db, cleanup := setupFiltersTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	created, err := service.Create(FilterRequest{
+		Name:       "  Work PDFs ",
+		SpaceID:    work.ID,
+		Recursive:  true,
+		Tags:       []string{"#Review", "review", "urgent"},
+		Extensions: []string{".PDF", "pdf"},
+		StartDate:  "2024-01-01",
+		EndDate:    "2024-06-30",
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.Name != "Work PDFs" || !created.Recursive {
+		t.Errorf("Unexpected filter %+v", created)
+	}
+	if strings.Join(created.Tags, ",") != "review,urgent" {
+		t.Errorf("Expected normalized tags, got %v", created.Tags)
+	}
+	if strings.Join(created.Extensions, ",") != "pdf" {
+		t.Errorf("Expected normalized extensions, got %v", created.Extensions)
+	}
+
+	// Filters survive a restart
+	reloaded := NewService(db, catCache, true)
+	if err := reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	got, ok := reloaded.GetFilter(created.ID)
+	if !ok || got.SpaceID != work.ID || got.EndDate != "2024-06-30" || len(got.Tags) != 2 {
+		t.Fatalf("Expected filter to be reloaded, got %+v", got)
+	}
+
+	updated, err := service.Update(created.ID, FilterRequest{Name: "Everything", Tags: []string{"review"}})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if updated.SpaceID != 0 || updated.Recursive || len(updated.Extensions) != 0 || updated.Created != created.Created {
+		t.Errorf("Unexpected updated filter %+v", updated)
+	}
+
+	if _, err := service.Create(FilterRequest{Name: "everything"}); err == nil || err.Error() != config.ErrSavedFilterExists {
+		t.Errorf("Expected duplicate name error, got %v", err)
+	}
+
+	if err := service.Delete(created.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(service.List()) != 0 {
+		t.Errorf("Expected no filters after delete")
+	}
+	if err := service.Delete(created.ID); err == nil || err.Error() != config.ErrSavedFilterNotFound {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestSavedFilterValidation(t *testing.T) {
+	db, cleanup := setupFiltersTestDB(t)
+	defer cleanup()
+
+	service := NewService(db, cache.NewSpaceCache(), true)
+
+	tooMany := make([]string, config.MaxSavedFilterTerms+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+
+	tests := []struct {
+		name    string
+		req     FilterRequest
+		wantErr string
+	}{
+		{"missing name", FilterRequest{Name: "  "}, config.ErrSavedFilterNameRequired},
+		{"long name", FilterRequest{Name: strings.Repeat("a", config.MaxSavedFilterNameLength+1)}, fmt.Sprintf(config.ErrFmtSavedFilterNameTooLong, config.MaxSavedFilterNameLength)},
+		{"unknown space", FilterRequest{Name: "x", SpaceID: 42}, config.ErrSpaceNotFound},
+		{"invalid tag", FilterRequest{Name: "x", Tags: []string{"not a tag"}}, config.ErrInvalidTag},
+		{"invalid extension", FilterRequest{Name: "x", Extensions: []string{"tar.gz"}}, config.ErrInvalidFilterExtension},
+		{"too many tags", FilterRequest{Name: "x", Tags: tooMany}, fmt.Sprintf(config.ErrFmtTooManyFilterTerms, config.MaxSavedFilterTerms, config.MaxSavedFilterTerms)},
+		{"bad date", FilterRequest{Name: "x", StartDate: "01/02/2024"}, config.ErrInvalidDateRange},
+		{"reversed range", FilterRequest{Name: "x", StartDate: "2024-05-01", EndDate: "2024-04-01"}, config.ErrInvalidDateRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Create(tt.req)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// A single day range is valid
+	if _, err := service.Create(FilterRequest{Name: "Today", StartDate: "2024-05-01", EndDate: "2024-05-01"}); err != nil {
+		t.Errorf("Expected single day range to be valid, got %v", err)
+	}
+}
+
+func TestSavedFiltersDroppedWithSpace(t *testing.T) {
+	db, cleanup := setupFiltersTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+
+	service := NewService(db, catCache, true)
+	scoped, _ := service.Create(FilterRequest{Name: "Work", SpaceID: work.ID})
+	global, _ := service.Create(FilterRequest{Name: "All"})
+
+	if err := db.DeleteSpace(context.Background(), work.ID); err != nil {
+		t.Fatalf("DeleteSpace failed: %v", err)
+	}
+	catCache.Delete(work.ID)
+	service.HandleEvent(events.Event{Type: events.SpaceDeleted, Data: events.SpaceEvent{SpaceID: work.ID}})
+
+	if _, ok := service.GetFilter(scoped.ID); ok {
+		t.Error("Expected filter of the deleted space to be dropped")
+	}
+	if _, ok := service.GetFilter(global.ID); !ok {
+		t.Error("Expected global filter to be kept")
+	}
+
+	stored, _ := db.GetSavedFilters()
+	if len(stored) != 1 || stored[0].ID != global.ID {
+		t.Errorf("Expected only the global filter to remain stored, got %+v", stored)
+	}
+}
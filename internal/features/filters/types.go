@@ -0,0 +1,18 @@
+package filters
+
+import "backthynk/internal/core/models"
+
+// FilterRequest is the body of filter creation and update requests
+type FilterRequest struct {
+	Name       string   `json:"name"`
+	SpaceID    int      `json:"space_id"` // 0 for every space
+	Recursive  bool     `json:"recursive"`
+	Tags       []string `json:"tags"`
+	Extensions []string `json:"extensions"`
+	StartDate  string   `json:"start_date"` // YYYY-MM-DD, inclusive
+	EndDate    string   `json:"end_date"`   // YYYY-MM-DD, inclusive
+}
+
+type FiltersResponse struct {
+	Filters []models.SavedFilter `json:"filters"`
+}
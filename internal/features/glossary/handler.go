@@ -0,0 +1,113 @@
+package glossary
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/glossary", h.GetGlossary).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/glossary", h.CreateTerm).Methods("POST")
+	api.HandleFunc("/spaces/{id:[0-9]+}/glossary/{termId}", h.DeleteTerm).Methods("DELETE")
+}
+
+// GetGlossary handles GET /api/spaces/{id}/glossary
+func (h *Handler) GetGlossary(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	terms, err := h.service.GetGlossary(r.Context(), spaceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GlossaryResponse{SpaceID: spaceID, Terms: terms})
+}
+
+// CreateTerm handles POST /api/spaces/{id}/glossary
+func (h *Handler) CreateTerm(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Term       string `json:"term"`
+		Definition string `json:"definition"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	term, err := h.service.CreateTerm(r.Context(), spaceID, req.Term, req.Definition)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch err.Error() {
+		case config.ErrSpaceNotFound:
+			status = http.StatusNotFound
+		case config.ErrSpaceAccessDenied:
+			status = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(term)
+}
+
+// DeleteTerm handles DELETE /api/spaces/{id}/glossary/{termId}
+func (h *Handler) DeleteTerm(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	termID, err := strconv.Atoi(vars["termId"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidGlossaryTermID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeleteTerm(r.Context(), spaceID, termID); err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case config.ErrGlossaryTermNotFound:
+			status = http.StatusNotFound
+		case config.ErrSpaceAccessDenied:
+			status = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
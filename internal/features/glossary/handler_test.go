@@ -0,0 +1,85 @@
+package glossary
+
+import (
+	"backthynk/internal/core/cache"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/spaces/1/glossary", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected glossary routes NOT to be registered when disabled")
+	}
+}
+
+func TestGlossaryHandlers(t *testing.T) {
+	db, cleanup := setupGlossaryTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	router := mux.NewRouter()
+	NewHandler(NewService(db, catCache, true)).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"Create term", "POST", "/api/spaces/1/glossary", `{"term":"API","definition":"Interface"}`, http.StatusCreated},
+		{"Invalid JSON", "POST", "/api/spaces/1/glossary", `nope`, http.StatusBadRequest},
+		{"Missing definition", "POST", "/api/spaces/1/glossary", `{"term":"SLO"}`, http.StatusBadRequest},
+		{"Unknown space", "POST", "/api/spaces/999/glossary", `{"term":"SLO","definition":"x"}`, http.StatusNotFound},
+		{"Get glossary", "GET", "/api/spaces/1/glossary", "", http.StatusOK},
+		{"Get unknown space", "GET", "/api/spaces/999/glossary", "", http.StatusNotFound},
+		{"Delete invalid ID", "DELETE", "/api/spaces/1/glossary/abc", "", http.StatusBadRequest},
+		{"Delete unknown term", "DELETE", "/api/spaces/1/glossary/999", "", http.StatusNotFound},
+	}
+
+	var created struct {
+		ID int `json:"id"`
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.name == "Create term" {
+				json.Unmarshal(w.Body.Bytes(), &created)
+			}
+			if tt.name == "Get glossary" {
+				var response GlossaryResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+				if len(response.Terms) != 1 || response.Terms[0].Term != "API" {
+					t.Errorf("Unexpected glossary: %+v", response)
+				}
+			}
+		})
+	}
+
+	req := httptest.NewRequest("DELETE", "/api/spaces/1/glossary/"+strconv.Itoa(created.ID), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d on delete, got %d", http.StatusNoContent, w.Code)
+	}
+}
@@ -0,0 +1,361 @@
+package glossary
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Service struct {
+	db          *storage.DB
+	catCache    *cache.SpaceCache
+	access      *services.SpaceAccess
+	terms       map[int]*models.GlossaryTerm // termID -> term
+	postMatches map[int][]int                // postID -> IDs of terms mentioned by the post
+	usage       map[int]int                  // termID -> number of posts mentioning it
+	mu          sync.RWMutex
+	enabled     bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:          db,
+		catCache:    catCache,
+		terms:       make(map[int]*models.GlossaryTerm),
+		postMatches: make(map[int][]int),
+		usage:       make(map[int]int),
+		enabled:     enabled,
+	}
+}
+
+// SetSpaceAccess keeps the glossaries of spaces hidden from the viewer of a request out of its
+// reach, and their terms to the viewers that may change the space
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	terms, err := s.db.GetAllGlossaryTerms()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for i := range terms {
+		s.terms[terms[i].ID] = &terms[i]
+	}
+	s.mu.Unlock()
+
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var spaceIDs []int
+	for _, space := range s.catCache.GetAll() {
+		spaceIDs = append(spaceIDs, space.ID)
+	}
+	return s.rescanSpaces(spaceIDs)
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	switch event.Type {
+	case events.PostCreated, events.PostMoved, events.PostUpdated:
+		data := event.Data.(events.PostEvent)
+		return s.indexPostByID(data.PostID)
+
+	case events.PostMerged:
+		data := event.Data.(events.PostEvent)
+		for _, merged := range data.MergedPosts {
+			s.forgetPost(merged.PostID)
+		}
+		return s.indexPostByID(data.PostID)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		if err := s.indexPostByID(data.PostID); err != nil {
+			return err
+		}
+		for _, split := range data.SplitPosts {
+			if err := s.indexPostByID(split.PostID); err != nil {
+				return err
+			}
+		}
+
+	case events.PostDeleted:
+		data := event.Data.(events.PostEvent)
+		s.forgetPost(data.PostID)
+
+	case events.SpaceUpdated:
+		// Moving a space changes which ancestor terms apply to its subtree
+		data := event.Data.(events.SpaceEvent)
+		return s.rescanSpaces(s.subtree(data.SpaceID))
+
+	case events.SpaceDeleted:
+		data := event.Data.(events.SpaceEvent)
+		for _, postID := range data.AffectedPosts {
+			s.forgetPost(postID)
+		}
+
+		// Terms of deleted spaces are removed by the database cascade
+		s.mu.Lock()
+		for id, term := range s.terms {
+			if _, ok := s.catCache.Get(term.SpaceID); !ok {
+				delete(s.terms, id)
+				delete(s.usage, id)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// CreateTerm defines a term on a space; it applies to the space and all its descendants
+func (s *Service) CreateTerm(ctx context.Context, spaceID int, term, definition string) (*models.GlossaryTerm, error) {
+	if err := s.checkSpace(ctx, spaceID, true); err != nil {
+		return nil, err
+	}
+
+	term = strings.TrimSpace(term)
+	definition = strings.TrimSpace(definition)
+	if term == "" {
+		return nil, fmt.Errorf(config.ErrGlossaryTermRequired)
+	}
+	if len(term) > config.MaxGlossaryTermLength {
+		return nil, fmt.Errorf(config.ErrFmtGlossaryTermTooLong, config.MaxGlossaryTermLength)
+	}
+	if definition == "" {
+		return nil, fmt.Errorf(config.ErrGlossaryDefinitionRequired)
+	}
+	if len(definition) > config.MaxGlossaryDefinitionLength {
+		return nil, fmt.Errorf(config.ErrFmtGlossaryDefinitionTooLong, config.MaxGlossaryDefinitionLength)
+	}
+
+	created, err := s.db.CreateGlossaryTerm(spaceID, term, definition)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.terms[created.ID] = created
+	s.mu.Unlock()
+
+	if err := s.rescanSpaces(s.subtree(spaceID)); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// DeleteTerm removes a term defined on the given space
+func (s *Service) DeleteTerm(ctx context.Context, spaceID, termID int) error {
+	if err := s.checkSpace(ctx, spaceID, true); err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			return fmt.Errorf(config.ErrGlossaryTermNotFound)
+		}
+		return err
+	}
+
+	s.mu.RLock()
+	term, ok := s.terms[termID]
+	s.mu.RUnlock()
+	if !ok || term.SpaceID != spaceID {
+		return fmt.Errorf(config.ErrGlossaryTermNotFound)
+	}
+
+	if err := s.db.DeleteGlossaryTerm(termID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.terms, termID)
+	delete(s.usage, termID)
+	s.mu.Unlock()
+
+	// A term with the same name on an ancestor may now apply to the subtree
+	return s.rescanSpaces(s.subtree(spaceID))
+}
+
+// GetGlossary lists the terms in effect for a space, sorted alphabetically
+func (s *Service) GetGlossary(ctx context.Context, spaceID int) ([]Entry, error) {
+	if err := s.checkSpace(ctx, spaceID, false); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := []Entry{}
+	for _, term := range s.effectiveTermsUnlocked(spaceID) {
+		entries = append(entries, Entry{
+			ID:         term.ID,
+			SpaceID:    term.SpaceID,
+			Term:       term.Term,
+			Definition: term.Definition,
+			Inherited:  term.SpaceID != spaceID,
+			UsageCount: s.usage[term.ID],
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Term) < strings.ToLower(entries[j].Term)
+	})
+
+	return entries, nil
+}
+
+// Annotate marks glossary terms of a space in rendered post content.
+// It is registered as a content annotator on the post service.
+func (s *Service) Annotate(spaceID int, content string) string {
+	if !s.enabled {
+		return content
+	}
+
+	s.mu.RLock()
+	terms := s.effectiveTermsUnlocked(spaceID)
+	s.mu.RUnlock()
+
+	if len(terms) == 0 {
+		return content
+	}
+
+	entries := make([]utils.GlossaryEntry, len(terms))
+	for i, term := range terms {
+		entries[i] = utils.GlossaryEntry{Term: term.Term, Definition: term.Definition}
+	}
+
+	return utils.AnnotateGlossaryTerms(content, entries)
+}
+
+// effectiveTermsUnlocked returns the terms applying to a space. When several spaces of the
+// ancestry define the same term, the nearest definition wins. Caller must hold s.mu.
+func (s *Service) effectiveTermsUnlocked(spaceID int) []*models.GlossaryTerm {
+	if len(s.terms) == 0 {
+		return nil
+	}
+
+	chain := append([]int{spaceID}, s.catCache.GetAncestors(spaceID)...)
+	rank := make(map[int]int, len(chain))
+	for i, id := range chain {
+		rank[id] = i
+	}
+
+	nearest := make(map[string]*models.GlossaryTerm)
+	for _, term := range s.terms {
+		r, ok := rank[term.SpaceID]
+		if !ok {
+			continue
+		}
+		key := strings.ToLower(term.Term)
+		if existing, ok := nearest[key]; !ok || r < rank[existing.SpaceID] {
+			nearest[key] = term
+		}
+	}
+
+	terms := make([]*models.GlossaryTerm, 0, len(nearest))
+	for _, term := range nearest {
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// checkSpace fails with ErrSpaceNotFound when the space is unknown or hidden from the viewer
+// of ctx, and with ErrSpaceAccessDenied when write is set and the viewer may only read it
+func (s *Service) checkSpace(ctx context.Context, spaceID int, write bool) error {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if write && !s.access.CanWrite(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+	return nil
+}
+
+func (s *Service) subtree(spaceID int) []int {
+	return append([]int{spaceID}, s.catCache.GetDescendants(spaceID)...)
+}
+
+func (s *Service) rescanSpaces(spaceIDs []int) error {
+	posts, err := s.db.GetPostsBySpaces(spaceIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		s.indexPost(post.ID, post.SpaceID, post.Content)
+	}
+	return nil
+}
+
+func (s *Service) indexPostByID(postID int) error {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return err
+	}
+
+	s.indexPost(post.ID, post.SpaceID, post.Content)
+	return nil
+}
+
+func (s *Service) indexPost(postID, spaceID int, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	terms := s.effectiveTermsUnlocked(spaceID)
+	byName := make(map[string]int, len(terms))
+	names := make([]string, 0, len(terms))
+	for _, term := range terms {
+		byName[strings.ToLower(term.Term)] = term.ID
+		names = append(names, term.Term)
+	}
+
+	var matched []int
+	for _, name := range utils.MatchGlossaryTerms(content, names) {
+		matched = append(matched, byName[name])
+	}
+
+	s.setPostMatchesUnlocked(postID, matched)
+}
+
+func (s *Service) forgetPost(postID int) {
+	s.mu.Lock()
+	s.setPostMatchesUnlocked(postID, nil)
+	s.mu.Unlock()
+}
+
+func (s *Service) setPostMatchesUnlocked(postID int, termIDs []int) {
+	for _, id := range s.postMatches[postID] {
+		if s.usage[id] > 1 {
+			s.usage[id]--
+		} else {
+			delete(s.usage, id)
+		}
+	}
+
+	if len(termIDs) == 0 {
+		delete(s.postMatches, postID)
+		return
+	}
+
+	s.postMatches[postID] = termIDs
+	for _, id := range termIDs {
+		s.usage[id]++
+	}
+}
@@ -0,0 +1,222 @@
+package glossary
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupGlossaryTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_glossary_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestGlossaryInheritanceAndUsage(t *testing.T) {
+	db, cleanup := setupGlossaryTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	infra, _ := db.CreateSpace("Infra", &work.ID, "")
+	personal, _ := db.CreateSpace("Personal", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+	catCache.Set(infra)
+	catCache.Set(personal)
+
+	db.CreatePost(context.Background(), infra.ID, "The SLO was missed again")
+	db.CreatePost(context.Background(), personal.ID, "No SLO here")
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	slo, err := service.CreateTerm(context.Background(), work.ID, "SLO", "Service level objective")
+	if err != nil {
+		t.Fatalf("CreateTerm failed: %v", err)
+	}
+
+	terms, _ := service.GetGlossary(context.Background(), infra.ID)
+	if len(terms) != 1 || !terms[0].Inherited || terms[0].UsageCount != 1 {
+		t.Fatalf("Expected inherited SLO term used once, got %+v", terms)
+	}
+
+	terms, _ = service.GetGlossary(context.Background(), personal.ID)
+	if len(terms) != 0 {
+		t.Errorf("Expected no terms outside the defining subtree, got %+v", terms)
+	}
+
+	// A closer definition overrides the inherited one
+	override, _ := service.CreateTerm(context.Background(), infra.ID, "slo", "Infra specific objective")
+	terms, _ = service.GetGlossary(context.Background(), infra.ID)
+	if len(terms) != 1 || terms[0].ID != override.ID || terms[0].Inherited || terms[0].UsageCount != 1 {
+		t.Errorf("Expected infra definition to override, got %+v", terms)
+	}
+
+	// New posts are indexed incrementally
+	post, _ := db.CreatePost(context.Background(), infra.ID, "Another slo review")
+	service.HandleEvent(events.Event{
+		Type: events.PostCreated,
+		Data: events.PostEvent{PostID: post.ID, SpaceID: infra.ID},
+	})
+	terms, _ = service.GetGlossary(context.Background(), infra.ID)
+	if terms[0].UsageCount != 2 {
+		t.Errorf("Expected usage 2 after new post, got %d", terms[0].UsageCount)
+	}
+
+	db.DeletePost(context.Background(), post.ID)
+	service.HandleEvent(events.Event{
+		Type: events.PostDeleted,
+		Data: events.PostEvent{PostID: post.ID, SpaceID: infra.ID},
+	})
+	terms, _ = service.GetGlossary(context.Background(), infra.ID)
+	if terms[0].UsageCount != 1 {
+		t.Errorf("Expected usage 1 after delete, got %d", terms[0].UsageCount)
+	}
+
+	// Removing the override falls back to the ancestor definition
+	if err := service.DeleteTerm(context.Background(), infra.ID, override.ID); err != nil {
+		t.Fatalf("DeleteTerm failed: %v", err)
+	}
+	terms, _ = service.GetGlossary(context.Background(), infra.ID)
+	if len(terms) != 1 || terms[0].ID != slo.ID || terms[0].UsageCount != 1 {
+		t.Errorf("Expected fallback to ancestor term, got %+v", terms)
+	}
+
+	if err := service.DeleteTerm(context.Background(), infra.ID, slo.ID); err == nil {
+		t.Error("Expected deleting an inherited term from a descendant to fail")
+	}
+}
+
+func TestCreateTermValidation(t *testing.T) {
+	db, cleanup := setupGlossaryTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	service := NewService(db, catCache, true)
+
+	tests := []struct {
+		name        string
+		spaceID     int
+		term        string
+		definition  string
+		expectedErr string
+	}{
+		{"Unknown space", 999, "API", "Interface", config.ErrSpaceNotFound},
+		{"Empty term", space.ID, "  ", "Interface", config.ErrGlossaryTermRequired},
+		{"Empty definition", space.ID, "API", "", config.ErrGlossaryDefinitionRequired},
+		{"Term too long", space.ID, strings.Repeat("a", config.MaxGlossaryTermLength+1), "x", "Term exceeds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateTerm(context.Background(), tt.spaceID, tt.term, tt.definition)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expectedErr) {
+				t.Errorf("Expected error %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	service.CreateTerm(context.Background(), space.ID, "API", "Interface")
+	if _, err := service.CreateTerm(context.Background(), space.ID, "api", "Duplicate"); err == nil {
+		t.Error("Expected duplicate term in the same space to fail")
+	}
+}
+
+func TestGlossaryHidesPrivateSpaces(t *testing.T) {
+	db, cleanup := setupGlossaryTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+
+	alice, _ := db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	bob, _ := db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(db, catCache)
+	if err := access.SetACL(work.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+
+	service := NewService(db, catCache, true)
+	service.SetSpaceAccess(access)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	slo, err := service.CreateTerm(context.Background(), work.ID, "SLO", "Service level objective")
+	if err != nil {
+		t.Fatalf("CreateTerm failed: %v", err)
+	}
+
+	asAlice := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	asBob := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+
+	if entries, err := service.GetGlossary(asAlice, work.ID); err != nil || len(entries) != 1 {
+		t.Errorf("Expected the term for a listed viewer, got %v (%v)", entries, err)
+	}
+	if _, err := service.CreateTerm(asAlice, work.ID, "SLA", "Service level agreement"); err == nil || err.Error() != config.ErrSpaceAccessDenied {
+		t.Errorf("Expected a reader to be refused new terms, got %v", err)
+	}
+	if err := service.DeleteTerm(asAlice, work.ID, slo.ID); err == nil || err.Error() != config.ErrSpaceAccessDenied {
+		t.Errorf("Expected a reader to be refused deleting terms, got %v", err)
+	}
+
+	if _, err := service.GetGlossary(asBob, work.ID); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+	if _, err := service.CreateTerm(asBob, work.ID, "SLA", "Service level agreement"); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+	if err := service.DeleteTerm(asBob, work.ID, slo.ID); err == nil || err.Error() != config.ErrGlossaryTermNotFound {
+		t.Errorf("Expected the term of a hidden space to be not found, got %v", err)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	db, cleanup := setupGlossaryTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	other, _ := db.CreateSpace("Other", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	catCache.Set(other)
+	service := NewService(db, catCache, true)
+	service.CreateTerm(context.Background(), space.ID, "API", "Interface")
+
+	annotated := service.Annotate(space.ID, "Use the API")
+	if !strings.Contains(annotated, `<abbr class="glossary-term" data-term="api" title="Interface">API</abbr>`) {
+		t.Errorf("Expected API to be annotated, got %q", annotated)
+	}
+
+	if result := service.Annotate(other.ID, "Use the API"); result != "Use the API" {
+		t.Errorf("Expected no annotation outside the subtree, got %q", result)
+	}
+}
@@ -0,0 +1,16 @@
+package glossary
+
+// Entry is a glossary term in effect for a space, either defined on it or inherited from an ancestor
+type Entry struct {
+	ID         int    `json:"id"`
+	SpaceID    int    `json:"space_id"` // Space where the term is defined
+	Term       string `json:"term"`
+	Definition string `json:"definition"`
+	Inherited  bool   `json:"inherited"`
+	UsageCount int    `json:"usage_count"` // Posts mentioning the term within the defining subtree
+}
+
+type GlossaryResponse struct {
+	SpaceID int     `json:"space_id"`
+	Terms   []Entry `json:"terms"`
+}
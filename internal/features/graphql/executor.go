@@ -0,0 +1,540 @@
+package graphql
+
+import (
+	"backthynk/internal/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// resolver returns the value of a field for every parent object at once, in parent order, so
+// one call can load the field of a whole list with a single query. Values of object fields are
+// nil, an object, or a []interface{} of objects for list fields.
+type resolver func(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error)
+
+type objectType struct {
+	name   string
+	fields map[string]*fieldDef
+	order  []string // Field names as listed in the schema
+}
+
+type fieldDef struct {
+	typ     string // GraphQL type, e.g. "Int!" or "[Post!]!"
+	args    []argDef
+	resolve resolver
+}
+
+type argDef struct {
+	name         string
+	typ          string // Int, Boolean or String, with ! when required
+	defaultValue interface{}
+}
+
+type schema struct {
+	types map[string]*objectType
+	order []string
+}
+
+func (s *schema) add(name string) *objectType {
+	typ := &objectType{name: name, fields: make(map[string]*fieldDef)}
+	s.types[name] = typ
+	s.order = append(s.order, name)
+	return typ
+}
+
+func (t *objectType) field(name, typ string, resolve resolver, args ...argDef) {
+	t.fields[name] = &fieldDef{typ: typ, args: args, resolve: resolve}
+	t.order = append(t.order, name)
+}
+
+// namedType strips the list and non-null markers of a type
+func namedType(typ string) string {
+	return strings.Trim(typ, "[]!")
+}
+
+// SDL returns the schema in the GraphQL schema definition language
+func (s *schema) SDL() string {
+	var b strings.Builder
+	b.WriteString("schema {\n  query: Query\n}\n")
+	for _, name := range s.order {
+		typ := s.types[name]
+		fmt.Fprintf(&b, "\ntype %s {\n", name)
+		for _, fieldName := range typ.order {
+			def := typ.fields[fieldName]
+			b.WriteString("  " + fieldName)
+			if len(def.args) > 0 {
+				args := make([]string, len(def.args))
+				for i, arg := range def.args {
+					args[i] = arg.name + ": " + arg.typ
+					if arg.defaultValue != nil {
+						args[i] += fmt.Sprintf(" = %v", arg.defaultValue)
+					}
+				}
+				b.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			b.WriteString(": " + def.typ + "\n")
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// plan is a validated field selection with its arguments coerced
+type plan struct {
+	key      string
+	name     string
+	def      *fieldDef // nil for __typename
+	args     map[string]interface{}
+	children []*plan
+}
+
+// planner validates the selections of an operation against the schema
+type planner struct {
+	schema    *schema
+	fragments map[string]*fragment
+	variables map[string]interface{}
+}
+
+// coerceVariables checks the request variables against the definitions of an operation and
+// applies their defaults
+func coerceVariables(op *operation, given map[string]interface{}) (map[string]interface{}, error) {
+	variables := make(map[string]interface{}, len(op.variables))
+	for _, def := range op.variables {
+		value, ok := given[def.name]
+		if !ok && def.hasDefault {
+			value, ok = def.defaultValue, true
+		}
+		if !ok || value == nil {
+			if strings.HasSuffix(def.typ, "!") {
+				return nil, fmt.Errorf(config.ErrFmtGraphQLInvalidVariable, def.name, def.typ)
+			}
+			variables[def.name] = nil
+			continue
+		}
+
+		coerced, ok := coerceInput(value, def.typ)
+		if !ok {
+			return nil, fmt.Errorf(config.ErrFmtGraphQLInvalidVariable, def.name, def.typ)
+		}
+		variables[def.name] = coerced
+	}
+	return variables, nil
+}
+
+// coerceInput converts a literal or JSON value to typ, reporting whether it fits. Only the
+// scalar types of the schema are known; other named types pass through unchanged.
+func coerceInput(value interface{}, typ string) (interface{}, bool) {
+	if value == nil {
+		return nil, !strings.HasSuffix(typ, "!")
+	}
+	typ = strings.TrimSuffix(typ, "!")
+
+	if strings.HasPrefix(typ, "[") {
+		inner := typ[1 : len(typ)-1]
+		list, ok := value.([]interface{})
+		if !ok {
+			// A single value stands for a list of one
+			item, ok := coerceInput(value, inner)
+			return []interface{}{item}, ok
+		}
+		coerced := make([]interface{}, len(list))
+		for i, item := range list {
+			if coerced[i], ok = coerceInput(item, inner); !ok {
+				return nil, false
+			}
+		}
+		return coerced, true
+	}
+
+	switch typ {
+	case "Int":
+		switch v := value.(type) {
+		case int64:
+			if v < math.MinInt32 || v > math.MaxInt32 {
+				return nil, false
+			}
+			return int(v), true
+		case float64:
+			if v != math.Trunc(v) || v < math.MinInt32 || v > math.MaxInt32 {
+				return nil, false
+			}
+			return int(v), true
+		case json.Number:
+			n, err := v.Int64()
+			if err != nil || n < math.MinInt32 || n > math.MaxInt32 {
+				return nil, false
+			}
+			return int(n), true
+		case int:
+			return v, true
+		}
+		return nil, false
+	case "Boolean":
+		b, ok := value.(bool)
+		return b, ok
+	case "String":
+		s, ok := value.(string)
+		return s, ok
+	}
+	return value, true
+}
+
+// resolveValue replaces the variable references of an argument value with their values
+func (p *planner) resolveValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case variableRef:
+		resolved, ok := p.variables[string(v)]
+		if !ok {
+			return nil, fmt.Errorf(config.ErrFmtGraphQLUnknownVariable, string(v))
+		}
+		return resolved, nil
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := p.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = resolved
+		}
+		return list, nil
+	}
+	return value, nil
+}
+
+func (p *planner) coerceArguments(typ *objectType, f *field, def *fieldDef) (map[string]interface{}, error) {
+	for name := range f.arguments {
+		known := false
+		for _, arg := range def.args {
+			known = known || arg.name == name
+		}
+		if !known {
+			return nil, fmt.Errorf(config.ErrFmtGraphQLUnknownArgument, name, typ.name, f.name)
+		}
+	}
+
+	args := make(map[string]interface{}, len(def.args))
+	for _, arg := range def.args {
+		value, given := f.arguments[arg.name]
+		if given {
+			resolved, err := p.resolveValue(value)
+			if err != nil {
+				return nil, err
+			}
+			value = resolved
+		}
+		if value == nil {
+			value = arg.defaultValue
+		}
+
+		coerced, ok := coerceInput(value, arg.typ)
+		if !ok {
+			return nil, fmt.Errorf(config.ErrFmtGraphQLInvalidArgument, arg.name, typ.name, f.name, arg.typ)
+		}
+		args[arg.name] = coerced
+	}
+	return args, nil
+}
+
+// included evaluates the @skip and @include directives of a selection
+func (p *planner) included(directives []*directive) (bool, error) {
+	for _, d := range directives {
+		if d.name != "skip" && d.name != "include" {
+			return false, fmt.Errorf(config.ErrFmtGraphQLUnknownDirective, d.name)
+		}
+		value, err := p.resolveValue(d.arguments["if"])
+		if err != nil {
+			return false, err
+		}
+		condition, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf(config.ErrFmtGraphQLInvalidDirective, d.name)
+		}
+		if condition == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// plan validates selections made on typ, merging the fields selected more than once under the
+// same name and expanding fragments. depth counts the selection sets above this one.
+func (p *planner) plan(typ *objectType, selections []selection, depth int, spreading map[string]bool) ([]*plan, error) {
+	if depth > config.MaxGraphQLDepth {
+		return nil, errors.New(config.ErrGraphQLTooDeep)
+	}
+
+	var plans []*plan
+	add := func(next []*plan) error {
+		var err error
+		plans, err = mergePlans(plans, next)
+		return err
+	}
+
+	for _, sel := range selections {
+		ok, err := p.included(sel.directives)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		switch {
+		case sel.field != nil:
+			fieldPlan, err := p.planField(typ, sel.field, depth)
+			if err != nil {
+				return nil, err
+			}
+			if err := add([]*plan{fieldPlan}); err != nil {
+				return nil, err
+			}
+		case sel.spread != "":
+			frag, ok := p.fragments[sel.spread]
+			if !ok {
+				return nil, fmt.Errorf(config.ErrFmtGraphQLUnknownFragment, sel.spread)
+			}
+			if spreading[frag.name] {
+				return nil, fmt.Errorf(config.ErrFmtGraphQLFragmentCycle, frag.name)
+			}
+			applies, err := p.appliesTo(frag.typeName, typ)
+			if err != nil {
+				return nil, err
+			}
+			if !applies {
+				continue
+			}
+			spreading[frag.name] = true
+			fragPlans, err := p.plan(typ, frag.selections, depth, spreading)
+			delete(spreading, frag.name)
+			if err != nil {
+				return nil, err
+			}
+			if err := add(fragPlans); err != nil {
+				return nil, err
+			}
+		case sel.inline != nil:
+			applies, err := p.appliesTo(sel.inline.typeName, typ)
+			if err != nil {
+				return nil, err
+			}
+			if !applies {
+				continue
+			}
+			inlinePlans, err := p.plan(typ, sel.inline.selections, depth, spreading)
+			if err != nil {
+				return nil, err
+			}
+			if err := add(inlinePlans); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return plans, nil
+}
+
+// appliesTo reports whether a fragment with the type condition typeName applies to typ
+func (p *planner) appliesTo(typeName string, typ *objectType) (bool, error) {
+	if typeName == "" {
+		return true, nil
+	}
+	if _, ok := p.schema.types[typeName]; !ok {
+		return false, fmt.Errorf(config.ErrFmtGraphQLUnknownType, typeName)
+	}
+	return typeName == typ.name, nil
+}
+
+func (p *planner) planField(typ *objectType, f *field, depth int) (*plan, error) {
+	if f.name == "__typename" {
+		if len(f.arguments) > 0 {
+			for name := range f.arguments {
+				return nil, fmt.Errorf(config.ErrFmtGraphQLUnknownArgument, name, typ.name, f.name)
+			}
+		}
+		if f.selections != nil {
+			return nil, fmt.Errorf(config.ErrFmtGraphQLSelectionNotAllowed, typ.name, f.name, "String!")
+		}
+		return &plan{key: f.responseKey(), name: f.name}, nil
+	}
+
+	def, ok := typ.fields[f.name]
+	if !ok {
+		return nil, fmt.Errorf(config.ErrFmtGraphQLUnknownField, f.name, typ.name)
+	}
+	args, err := p.coerceArguments(typ, f, def)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldPlan := &plan{key: f.responseKey(), name: f.name, def: def, args: args}
+	child, isObject := p.schema.types[namedType(def.typ)]
+	switch {
+	case isObject && f.selections == nil:
+		return nil, fmt.Errorf(config.ErrFmtGraphQLSelectionRequired, typ.name, f.name, def.typ)
+	case !isObject && f.selections != nil:
+		return nil, fmt.Errorf(config.ErrFmtGraphQLSelectionNotAllowed, typ.name, f.name, def.typ)
+	case isObject:
+		if fieldPlan.children, err = p.plan(child, f.selections, depth+1, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	return fieldPlan, nil
+}
+
+// mergePlans adds next to plans; fields selected again under the same name must select the
+// same field with the same arguments and have their subfields merged
+func mergePlans(plans, next []*plan) ([]*plan, error) {
+	for _, np := range next {
+		merged := false
+		for _, existing := range plans {
+			if existing.key != np.key {
+				continue
+			}
+			if existing.name != np.name || !reflect.DeepEqual(existing.args, np.args) {
+				return nil, fmt.Errorf(config.ErrFmtGraphQLFieldConflict, np.key)
+			}
+			children, err := mergePlans(existing.children, np.children)
+			if err != nil {
+				return nil, err
+			}
+			existing.children = children
+			merged = true
+			break
+		}
+		if !merged {
+			plans = append(plans, np)
+		}
+	}
+	return plans, nil
+}
+
+// object is a result object that keeps its fields in selection order
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]interface{})}
+}
+
+func (o *object) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// executor runs validated plans, level by level. Every field is resolved once for all the
+// objects of its level, then the objects it returns are resolved together at the next level.
+type executor struct {
+	schema *schema
+	errors []Error
+}
+
+func (e *executor) execute(ctx context.Context, typ *objectType, plans []*plan, parents []interface{}, paths [][]interface{}) []*object {
+	results := make([]*object, len(parents))
+	for i := range results {
+		results[i] = newObject()
+	}
+	if len(parents) == 0 {
+		return results
+	}
+
+	for _, p := range plans {
+		if p.def == nil {
+			for _, result := range results {
+				result.set(p.key, typ.name)
+			}
+			continue
+		}
+
+		values, err := p.def.resolve(ctx, parents, p.args)
+		if err != nil {
+			e.errors = append(e.errors, Error{Message: err.Error(), Path: appendPath(paths[0], p.key)})
+			for _, result := range results {
+				result.set(p.key, nil)
+			}
+			continue
+		}
+
+		child, isObject := e.schema.types[namedType(p.def.typ)]
+		if !isObject {
+			for i, result := range results {
+				result.set(p.key, values[i])
+			}
+			continue
+		}
+
+		// Gather the objects of every parent so the next level resolves them together
+		var children []interface{}
+		var childPaths [][]interface{}
+		for i, value := range values {
+			switch v := value.(type) {
+			case nil:
+			case []interface{}:
+				for j, item := range v {
+					children = append(children, item)
+					childPaths = append(childPaths, appendPath(paths[i], p.key, j))
+				}
+			default:
+				children = append(children, v)
+				childPaths = append(childPaths, appendPath(paths[i], p.key))
+			}
+		}
+		objects := e.execute(ctx, child, p.children, children, childPaths)
+
+		next := 0
+		for i, value := range values {
+			switch v := value.(type) {
+			case nil:
+				results[i].set(p.key, nil)
+			case []interface{}:
+				list := make([]*object, len(v))
+				for j := range v {
+					list[j] = objects[next]
+					next++
+				}
+				results[i].set(p.key, list)
+			default:
+				results[i].set(p.key, objects[next])
+				next++
+			}
+		}
+	}
+	return results
+}
+
+func appendPath(path []interface{}, elements ...interface{}) []interface{} {
+	result := make([]interface{}, 0, len(path)+len(elements))
+	result = append(result, path...)
+	return append(result, elements...)
+}
@@ -0,0 +1,63 @@
+package graphql
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/graphql", h.Query).Methods("GET", "POST")
+	api.HandleFunc("/graphql/schema", h.GetSchema).Methods("GET")
+}
+
+// Query handles POST /api/graphql with {"query", "operationName", "variables"}, and GET
+// /api/graphql with the same fields as query parameters, variables JSON encoded
+func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+				return
+			}
+		}
+	} else {
+		body := http.MaxBytesReader(w, r.Body, 2*config.MaxGraphQLQueryLength)
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			http.Error(w, config.ErrInvalidRequestBody, http.StatusBadRequest)
+			return
+		}
+	}
+
+	response := h.service.Execute(r.Context(), req)
+	w.Header().Set("Content-Type", "application/json")
+	if response.Data == nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetSchema handles GET /api/graphql/schema
+func (h *Handler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(h.service.Schema()))
+}
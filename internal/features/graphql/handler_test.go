@@ -0,0 +1,70 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("POST", "/api/graphql", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected GraphQL routes NOT to be registered when disabled")
+	}
+}
+
+func TestGraphQLHandlers(t *testing.T) {
+	setup := setupGraphQLTest(t)
+	defer setup.cleanup()
+
+	setup.spaceService.Create(context.Background(), "Projects", nil, "")
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	getPath := "/api/graphql?" + url.Values{
+		"query":     {"query($parent: Int) { spaces(parent_id: $parent) { name } }"},
+		"variables": {`{"parent": null}`},
+	}.Encode()
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Query", "POST", "/api/graphql", `{"query":"{ spaces { name } }"}`, http.StatusOK, `{"data":{"spaces":[{"name":"Projects"}]}}`},
+		{"Variables", "POST", "/api/graphql", `{"query":"query($id: Int!) { space(id: $id) { name } }","variables":{"id":999}}`, http.StatusOK, `{"data":{"space":null}}`},
+		{"Operation name", "POST", "/api/graphql", `{"query":"query A { spaces { name } } query B { spaces { __typename } }","operationName":"B"}`, http.StatusOK, `"__typename":"Space"`},
+		{"GET query", "GET", getPath, "", http.StatusOK, `"name":"Projects"`},
+		{"Invalid query", "POST", "/api/graphql", `{"query":"{ nothing }"}`, http.StatusBadRequest, `"errors":[{"message":"Cannot query field`},
+		{"Invalid JSON", "POST", "/api/graphql", `nope`, http.StatusBadRequest, ""},
+		{"Invalid GET variables", "GET", "/api/graphql?query=%7Bspaces%7Bname%7D%7D&variables=nope", "", http.StatusBadRequest, ""},
+		{"Schema", "GET", "/api/graphql/schema", "", http.StatusOK, "type Space {"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedBody != "" && !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body to contain %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,742 @@
+package graphql
+
+import (
+	"backthynk/internal/config"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed GraphQL request document
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string // query, mutation or subscription
+	name       string
+	variables  []*variableDefinition
+	selections []selection
+}
+
+type variableDefinition struct {
+	name         string
+	typ          string // As written, e.g. "Int!" or "[Int]"
+	defaultValue interface{}
+	hasDefault   bool
+}
+
+type fragment struct {
+	name       string
+	typeName   string
+	selections []selection
+}
+
+// selection is a field, a fragment spread or an inline fragment
+type selection struct {
+	field      *field
+	spread     string // Fragment name for a fragment spread
+	inline     *fragment
+	directives []*directive
+}
+
+type field struct {
+	alias      string
+	name       string
+	arguments  map[string]interface{} // Literal values, variable references are variableRef
+	selections []selection
+	offset     int
+}
+
+type directive struct {
+	name      string
+	arguments map[string]interface{}
+}
+
+// variableRef is a $name argument value
+type variableRef string
+
+// enumValue is a bare name argument value
+type enumValue string
+
+// responseKey is the name of a field in the result
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind   tokenKind
+	value  string
+	offset int
+}
+
+// syntaxError is a query that is not valid GraphQL
+type syntaxError struct {
+	offset  int
+	message string
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf(config.ErrFmtGraphQLSyntax, e.offset, e.message)
+}
+
+type parser struct {
+	source string
+	pos    int
+	tok    token
+}
+
+// parse reads a GraphQL document holding operations and fragments
+func parse(source string) (*document, error) {
+	p := &parser{source: source}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.isPunct("{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: selections})
+		case p.tok.kind == tokenName && p.tok.value == "fragment":
+			frag, err := p.parseFragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[frag.name]; ok {
+				return nil, &syntaxError{p.tok.offset, fmt.Sprintf("fragment %q is defined twice", frag.name)}
+			}
+			doc.fragments[frag.name] = frag
+		case p.tok.kind == tokenName && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, &syntaxError{0, "the document holds no operation"}
+	}
+	return doc, nil
+}
+
+func (p *parser) parseOperation() (*operation, error) {
+	op := &operation{kind: p.tok.value}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenName {
+		op.name = p.tok.value
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isPunct("(") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		for !p.isPunct(")") {
+			def, err := p.parseVariableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = append(op.variables, def)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinition() (*variableDefinition, error) {
+	if err := p.expectPunct("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectPunct(":"); err != nil {
+		return nil, err
+	}
+	typ, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	def := &variableDefinition{name: name, typ: typ}
+	if p.isPunct("=") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(true)
+		if err != nil {
+			return nil, err
+		}
+		def.defaultValue = value
+		def.hasDefault = true
+	}
+	return def, nil
+}
+
+func (p *parser) parseType() (string, error) {
+	var typ string
+	if p.isPunct("[") {
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		inner, err := p.parseType()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+
+	if p.isPunct("!") {
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		typ += "!"
+	}
+	return typ, nil
+}
+
+func (p *parser) parseFragmentDefinition() (*fragment, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, &syntaxError{p.tok.offset, "a fragment cannot be named on"}
+	}
+	if err := p.expectKeyword("on"); err != nil {
+		return nil, err
+	}
+	typeName, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name, typeName: typeName, selections: selections}, nil
+}
+
+func (p *parser) parseSelectionSet() ([]selection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []selection
+	for !p.isPunct("}") {
+		sel, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	if len(selections) == 0 {
+		return nil, &syntaxError{p.tok.offset, "a selection set cannot be empty"}
+	}
+	return selections, p.next()
+}
+
+func (p *parser) parseSelection() (selection, error) {
+	if p.isPunct("...") {
+		if err := p.next(); err != nil {
+			return selection{}, err
+		}
+
+		// Fragment spread
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			name := p.tok.value
+			if err := p.next(); err != nil {
+				return selection{}, err
+			}
+			directives, err := p.parseDirectives()
+			if err != nil {
+				return selection{}, err
+			}
+			return selection{spread: name, directives: directives}, nil
+		}
+
+		// Inline fragment, with an optional type condition
+		inline := &fragment{}
+		if p.tok.kind == tokenName {
+			if err := p.next(); err != nil {
+				return selection{}, err
+			}
+			typeName, err := p.expectName()
+			if err != nil {
+				return selection{}, err
+			}
+			inline.typeName = typeName
+		}
+		directives, err := p.parseDirectives()
+		if err != nil {
+			return selection{}, err
+		}
+		selections, err := p.parseSelectionSet()
+		if err != nil {
+			return selection{}, err
+		}
+		inline.selections = selections
+		return selection{inline: inline, directives: directives}, nil
+	}
+
+	f := &field{offset: p.tok.offset}
+	name, err := p.expectName()
+	if err != nil {
+		return selection{}, err
+	}
+	if p.isPunct(":") {
+		if err := p.next(); err != nil {
+			return selection{}, err
+		}
+		f.alias = name
+		if name, err = p.expectName(); err != nil {
+			return selection{}, err
+		}
+	}
+	f.name = name
+
+	if f.arguments, err = p.parseArguments(); err != nil {
+		return selection{}, err
+	}
+	directives, err := p.parseDirectives()
+	if err != nil {
+		return selection{}, err
+	}
+	if p.isPunct("{") {
+		if f.selections, err = p.parseSelectionSet(); err != nil {
+			return selection{}, err
+		}
+	}
+	return selection{field: f, directives: directives}, nil
+}
+
+func (p *parser) parseArguments() (map[string]interface{}, error) {
+	if !p.isPunct("(") {
+		return nil, nil
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	arguments := make(map[string]interface{})
+	for !p.isPunct(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := arguments[name]; ok {
+			return nil, &syntaxError{p.tok.offset, fmt.Sprintf("argument %q is given twice", name)}
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+		arguments[name] = value
+	}
+	return arguments, p.next()
+}
+
+func (p *parser) parseDirectives() ([]*directive, error) {
+	var directives []*directive
+	for p.isPunct("@") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, &directive{name: name, arguments: arguments})
+	}
+	return directives, nil
+}
+
+// parseValue reads an argument value. Constant values, as in variable defaults, cannot
+// reference variables.
+func (p *parser) parseValue(constant bool) (interface{}, error) {
+	tok := p.tok
+	switch {
+	case p.isPunct("$") && !constant:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		return variableRef(name), nil
+	case p.isPunct("["):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		list := []interface{}{}
+		for !p.isPunct("]") {
+			value, err := p.parseValue(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, p.next()
+	case p.isPunct("{"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		object := make(map[string]interface{})
+		for !p.isPunct("}") {
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(":"); err != nil {
+				return nil, err
+			}
+			value, err := p.parseValue(constant)
+			if err != nil {
+				return nil, err
+			}
+			object[name] = value
+		}
+		return object, p.next()
+	case tok.kind == tokenInt:
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return nil, &syntaxError{tok.offset, fmt.Sprintf("integer %s is out of range", tok.value)}
+		}
+		return n, p.next()
+	case tok.kind == tokenFloat:
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			return nil, &syntaxError{tok.offset, fmt.Sprintf("invalid number %s", tok.value)}
+		}
+		return f, p.next()
+	case tok.kind == tokenString:
+		return tok.value, p.next()
+	case tok.kind == tokenName:
+		var value interface{}
+		switch tok.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			value = enumValue(tok.value)
+		}
+		return value, p.next()
+	}
+	return nil, p.unexpected()
+}
+
+func (p *parser) isPunct(value string) bool {
+	return p.tok.kind == tokenPunct && p.tok.value == value
+}
+
+func (p *parser) expectPunct(value string) error {
+	if !p.isPunct(value) {
+		return &syntaxError{p.tok.offset, fmt.Sprintf("expected %q, found %s", value, p.describe())}
+	}
+	return p.next()
+}
+
+func (p *parser) expectName() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", &syntaxError{p.tok.offset, fmt.Sprintf("expected a name, found %s", p.describe())}
+	}
+	name := p.tok.value
+	return name, p.next()
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if p.tok.kind != tokenName || p.tok.value != keyword {
+		return &syntaxError{p.tok.offset, fmt.Sprintf("expected %q, found %s", keyword, p.describe())}
+	}
+	return p.next()
+}
+
+func (p *parser) unexpected() error {
+	return &syntaxError{p.tok.offset, "unexpected " + p.describe()}
+}
+
+func (p *parser) describe() string {
+	switch p.tok.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return "string"
+	}
+	return strconv.Quote(p.tok.value)
+}
+
+// next reads the following token, skipping whitespace, commas and comments
+func (p *parser) next() error {
+	src := p.source
+	for p.pos < len(src) {
+		c := src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else if c == '#' {
+			for p.pos < len(src) && src[p.pos] != '\n' && src[p.pos] != '\r' {
+				p.pos++
+			}
+		} else if strings.HasPrefix(src[p.pos:], "\ufeff") {
+			p.pos += len("\ufeff")
+		} else {
+			break
+		}
+	}
+
+	start := p.pos
+	if p.pos >= len(src) {
+		p.tok = token{kind: tokenEOF, offset: start}
+		return nil
+	}
+
+	c := src[p.pos]
+	switch {
+	case strings.HasPrefix(src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokenPunct, value: "...", offset: start}
+	case strings.IndexByte("!$()[]{}:=@|&", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenPunct, value: string(c), offset: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(src) && (src[p.pos] == '_' || isLetter(src[p.pos]) || isDigit(src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenName, value: src[start:p.pos], offset: start}
+	case c == '-' || isDigit(c):
+		return p.readNumber()
+	case c == '"':
+		return p.readString()
+	default:
+		r, _ := utf8.DecodeRuneInString(src[p.pos:])
+		return &syntaxError{start, fmt.Sprintf("unexpected character %q", r)}
+	}
+	return nil
+}
+
+func (p *parser) readNumber() error {
+	src := p.source
+	start := p.pos
+	kind := tokenInt
+	if src[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() int {
+		from := p.pos
+		for p.pos < len(src) && isDigit(src[p.pos]) {
+			p.pos++
+		}
+		return p.pos - from
+	}
+
+	if digits() == 0 {
+		return &syntaxError{start, "invalid number"}
+	}
+	if p.pos < len(src) && src[p.pos] == '.' {
+		kind = tokenFloat
+		p.pos++
+		if digits() == 0 {
+			return &syntaxError{start, "invalid number"}
+		}
+	}
+	if p.pos < len(src) && (src[p.pos] == 'e' || src[p.pos] == 'E') {
+		kind = tokenFloat
+		p.pos++
+		if p.pos < len(src) && (src[p.pos] == '+' || src[p.pos] == '-') {
+			p.pos++
+		}
+		if digits() == 0 {
+			return &syntaxError{start, "invalid number"}
+		}
+	}
+	if p.pos < len(src) && (src[p.pos] == '_' || isLetter(src[p.pos]) || src[p.pos] == '.') {
+		return &syntaxError{start, "invalid number"}
+	}
+
+	p.tok = token{kind: kind, value: src[start:p.pos], offset: start}
+	return nil
+}
+
+func (p *parser) readString() error {
+	src := p.source
+	start := p.pos
+
+	// Block strings keep their content as written, minus the common indentation
+	if strings.HasPrefix(src[p.pos:], `"""`) {
+		end := p.pos + 3
+		for {
+			i := strings.Index(src[end:], `"""`)
+			if i < 0 {
+				return &syntaxError{start, "unterminated string"}
+			}
+			end += i
+			if src[end-1] != '\\' {
+				break
+			}
+			end += 3 // Escaped \""" inside the string
+		}
+		raw := src[p.pos+3 : end]
+		p.pos = end + 3
+		p.tok = token{kind: tokenString, value: blockStringValue(raw), offset: start}
+		return nil
+	}
+
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.pos >= len(src) || src[p.pos] == '\n' || src[p.pos] == '\r' {
+			return &syntaxError{start, "unterminated string"}
+		}
+		c := src[p.pos]
+		if c == '"' {
+			p.pos++
+			break
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		if p.pos+1 >= len(src) {
+			return &syntaxError{start, "unterminated string"}
+		}
+		escape := src[p.pos+1]
+		p.pos += 2
+		switch escape {
+		case '"', '\\', '/':
+			b.WriteByte(escape)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if p.pos+4 > len(src) {
+				return &syntaxError{p.pos, "invalid unicode escape"}
+			}
+			code, err := strconv.ParseUint(src[p.pos:p.pos+4], 16, 32)
+			if err != nil {
+				return &syntaxError{p.pos, "invalid unicode escape"}
+			}
+			b.WriteRune(rune(code))
+			p.pos += 4
+		default:
+			return &syntaxError{p.pos - 2, fmt.Sprintf("invalid escape \\%c", escape)}
+		}
+	}
+
+	p.tok = token{kind: tokenString, value: b.String(), offset: start}
+	return nil
+}
+
+// blockStringValue removes the common indentation and the blank first and last lines of a
+// block string
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.ReplaceAll(strings.Join(lines, "\n"), `\"""`, `"""`)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,384 @@
+package graphql
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// buildSchema declares the types served by the service. Field names follow the JSON keys of
+// the REST API.
+func (s *Service) buildSchema() *schema {
+	sc := &schema{types: make(map[string]*objectType)}
+	limitArgs := []argDef{
+		{name: "limit", typ: "Int", defaultValue: config.DefaultPostLimit},
+		{name: "offset", typ: "Int", defaultValue: 0},
+		{name: "recursive", typ: "Boolean", defaultValue: false},
+	}
+
+	query := sc.add("Query")
+	query.field("spaces", "[Space!]!", s.resolveSpaces, argDef{name: "parent_id", typ: "Int"})
+	query.field("space", "Space", s.resolveSpace, argDef{name: "id", typ: "Int!"})
+	query.field("post", "Post", s.resolvePost, argDef{name: "id", typ: "Int!"})
+	query.field("posts", "[Post!]", s.resolvePosts, append([]argDef{{name: "space_id", typ: "Int"}}, limitArgs...)...)
+
+	space := sc.add("Space")
+	space.field("id", "Int!", spaceField(func(sp *models.Space) interface{} { return sp.ID }))
+	space.field("name", "String!", spaceField(func(sp *models.Space) interface{} { return sp.Name }))
+	space.field("description", "String!", spaceField(func(sp *models.Space) interface{} { return sp.Description }))
+	space.field("parent_id", "Int", spaceField(func(sp *models.Space) interface{} { return sp.ParentID }))
+	space.field("depth", "Int!", spaceField(func(sp *models.Space) interface{} { return sp.Depth }))
+	space.field("created", "Int!", spaceField(func(sp *models.Space) interface{} { return sp.Created }))
+	space.field("post_count", "Int!", spaceField(func(sp *models.Space) interface{} { return sp.PostCount }))
+	space.field("recursive_post_count", "Int!", spaceField(func(sp *models.Space) interface{} { return sp.RecursivePostCount }))
+	space.field("parent", "Space", s.resolveParent)
+	space.field("children", "[Space!]!", s.resolveChildren)
+	space.field("posts", "[Post!]", s.resolveSpacePosts, limitArgs...)
+	space.field("stats", "Stats", s.resolveStats, argDef{name: "recursive", typ: "Boolean", defaultValue: false})
+	space.field("activity", "Activity", s.resolveActivity,
+		argDef{name: "recursive", typ: "Boolean", defaultValue: false},
+		argDef{name: "period_months", typ: "Int"},
+	)
+
+	post := sc.add("Post")
+	post.field("id", "Int!", postField(func(p *models.Post) interface{} { return p.ID }))
+	post.field("space_id", "Int!", postField(func(p *models.Post) interface{} { return p.SpaceID }))
+	post.field("content", "String!", postField(func(p *models.Post) interface{} { return p.Content }))
+	post.field("created", "Int!", postField(func(p *models.Post) interface{} { return p.Created }))
+	post.field("source", "String!", postField(func(p *models.Post) interface{} { return p.Source }))
+	post.field("type", "String!", postField(func(p *models.Post) interface{} { return p.Type }))
+	post.field("hash", "String!", postField(func(p *models.Post) interface{} { return p.Hash }))
+	post.field("crossposted_from", "Int", postField(func(p *models.Post) interface{} { return p.CrosspostedFrom }))
+	post.field("space", "Space", s.resolvePostSpace)
+	post.field("attachments", "[Attachment!]", s.resolveAttachments)
+	post.field("link_previews", "[LinkPreview!]", s.resolveLinkPreviews)
+
+	attachment := sc.add("Attachment")
+	attachment.field("id", "Int!", each(func(v interface{}) interface{} { return v.(*models.Attachment).ID }))
+	attachment.field("post_id", "Int!", each(func(v interface{}) interface{} { return v.(*models.Attachment).PostID }))
+	attachment.field("filename", "String!", each(func(v interface{}) interface{} { return v.(*models.Attachment).Filename }))
+	attachment.field("file_path", "String!", each(func(v interface{}) interface{} { return v.(*models.Attachment).FilePath }))
+	attachment.field("file_type", "String!", each(func(v interface{}) interface{} { return v.(*models.Attachment).FileType }))
+	attachment.field("file_size", "Int!", each(func(v interface{}) interface{} { return v.(*models.Attachment).FileSize }))
+
+	preview := sc.add("LinkPreview")
+	preview.field("id", "Int!", each(func(v interface{}) interface{} { return v.(*models.LinkPreview).ID }))
+	preview.field("post_id", "Int!", each(func(v interface{}) interface{} { return v.(*models.LinkPreview).PostID }))
+	preview.field("url", "String!", each(func(v interface{}) interface{} { return v.(*models.LinkPreview).URL }))
+	preview.field("title", "String!", each(func(v interface{}) interface{} { return v.(*models.LinkPreview).Title }))
+	preview.field("description", "String!", each(func(v interface{}) interface{} { return v.(*models.LinkPreview).Description }))
+	preview.field("image_url", "String!", each(func(v interface{}) interface{} { return v.(*models.LinkPreview).ImageURL }))
+	preview.field("site_name", "String!", each(func(v interface{}) interface{} { return v.(*models.LinkPreview).SiteName }))
+
+	stats := sc.add("Stats")
+	stats.field("file_count", "Int!", each(func(v interface{}) interface{} { return v.(*Stats).FileCount }))
+	stats.field("total_size", "Int!", each(func(v interface{}) interface{} { return v.(*Stats).TotalSize }))
+
+	activity := sc.add("Activity")
+	activity.field("start_date", "String!", each(func(v interface{}) interface{} { return v.(*Activity).StartDate }))
+	activity.field("end_date", "String!", each(func(v interface{}) interface{} { return v.(*Activity).EndDate }))
+	activity.field("total_posts", "Int!", each(func(v interface{}) interface{} { return v.(*Activity).TotalPosts }))
+	activity.field("active_days", "Int!", each(func(v interface{}) interface{} { return v.(*Activity).ActiveDays }))
+	activity.field("max_day_activity", "Int!", each(func(v interface{}) interface{} { return v.(*Activity).MaxDayActivity }))
+	activity.field("days", "[ActivityDay!]!", each(func(v interface{}) interface{} {
+		days := v.(*Activity).Days
+		list := make([]interface{}, len(days))
+		for i := range days {
+			list[i] = &days[i]
+		}
+		return list
+	}))
+
+	day := sc.add("ActivityDay")
+	day.field("date", "String!", each(func(v interface{}) interface{} { return v.(*ActivityDay).Date }))
+	day.field("count", "Int!", each(func(v interface{}) interface{} { return v.(*ActivityDay).Count }))
+
+	return sc
+}
+
+// each resolves a field from its parent alone
+func each(get func(parent interface{}) interface{}) resolver {
+	return func(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+		values := make([]interface{}, len(parents))
+		for i, parent := range parents {
+			values[i] = get(parent)
+		}
+		return values, nil
+	}
+}
+
+func spaceField(get func(*models.Space) interface{}) resolver {
+	return each(func(parent interface{}) interface{} { return get(parent.(*models.Space)) })
+}
+
+func postField(get func(*models.Post) interface{}) resolver {
+	return each(func(parent interface{}) interface{} { return get(parent.(*models.Post)) })
+}
+
+// spaceList returns the cached spaces of ids the viewer of ctx may see, by name
+func (s *Service) spaceList(ctx context.Context, ids []int) []interface{} {
+	spaces := make([]*models.Space, 0, len(ids))
+	for _, id := range ids {
+		if space, ok := s.visibleSpace(ctx, id); ok {
+			spaces = append(spaces, space)
+		}
+	}
+	sort.Slice(spaces, func(i, j int) bool {
+		if a, b := strings.ToLower(spaces[i].Name), strings.ToLower(spaces[j].Name); a != b {
+			return a < b
+		}
+		return spaces[i].ID < spaces[j].ID
+	})
+
+	list := make([]interface{}, len(spaces))
+	for i, space := range spaces {
+		list[i] = space
+	}
+	return list
+}
+
+// resolveSpaces lists the children of parent_id, or the top-level spaces without it
+func (s *Service) resolveSpaces(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	var ids []int
+	if parentID, ok := args["parent_id"].(int); ok {
+		ids = s.catCache.GetChildren(parentID)
+	} else {
+		for _, space := range s.catCache.GetAll() {
+			if space.ParentID == nil {
+				ids = append(ids, space.ID)
+			}
+		}
+	}
+	return []interface{}{s.spaceList(ctx, ids)}, nil
+}
+
+// visibleSpace returns a cached space as the viewer of ctx sees it, false when it is hidden
+func (s *Service) visibleSpace(ctx context.Context, id int) (*models.Space, bool) {
+	space, ok := s.catCache.Get(id)
+	if !ok {
+		return nil, false
+	}
+	return s.access.Visible(ctx, space)
+}
+
+func (s *Service) resolveSpace(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	if space, ok := s.visibleSpace(ctx, args["id"].(int)); ok {
+		return []interface{}{space}, nil
+	}
+	return []interface{}{nil}, nil
+}
+
+func (s *Service) resolvePost(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	post, err := s.db.GetPostContext(ctx, args["id"].(int))
+	if err != nil {
+		if err.Error() == "post not found" {
+			return []interface{}{nil}, nil
+		}
+		return nil, errors.New(config.ErrFailedToGetPosts)
+	}
+	if !s.access.CanRead(ctx, post.SpaceID) {
+		return []interface{}{nil}, nil
+	}
+	return []interface{}{post}, nil
+}
+
+// resolvePosts lists the latest posts of space_id, or of every space without it
+func (s *Service) resolvePosts(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	var spaceIDs []int
+	if spaceID, ok := args["space_id"].(int); ok {
+		if _, ok := s.visibleSpace(ctx, spaceID); !ok {
+			return nil, errors.New(config.ErrSpaceNotFound)
+		}
+		spaceIDs = append(spaceIDs, spaceID)
+		if args["recursive"].(bool) {
+			spaceIDs = append(spaceIDs, s.catCache.GetDescendants(spaceID)...)
+		}
+	} else {
+		for _, space := range s.catCache.GetAll() {
+			spaceIDs = append(spaceIDs, space.ID)
+		}
+	}
+
+	posts, err := s.latestPosts(ctx, map[int][]int{0: s.access.Scope(ctx, spaceIDs)}, args)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{posts[0]}, nil
+}
+
+func (s *Service) resolveParent(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	values := make([]interface{}, len(parents))
+	for i, parent := range parents {
+		if parentID := parent.(*models.Space).ParentID; parentID != nil {
+			if space, ok := s.visibleSpace(ctx, *parentID); ok {
+				values[i] = space
+			}
+		}
+	}
+	return values, nil
+}
+
+func (s *Service) resolveChildren(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	values := make([]interface{}, len(parents))
+	for i, parent := range parents {
+		values[i] = s.spaceList(ctx, s.catCache.GetChildren(parent.(*models.Space).ID))
+	}
+	return values, nil
+}
+
+// resolveSpacePosts loads the latest posts of every parent space in one query
+func (s *Service) resolveSpacePosts(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	scopes := make(map[int][]int, len(parents))
+	for _, parent := range parents {
+		spaceID := parent.(*models.Space).ID
+		scopes[spaceID] = []int{spaceID}
+		if args["recursive"].(bool) {
+			scopes[spaceID] = append(scopes[spaceID], s.catCache.GetDescendants(spaceID)...)
+		}
+		scopes[spaceID] = s.access.Scope(ctx, scopes[spaceID])
+	}
+
+	posts, err := s.latestPosts(ctx, scopes, args)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(parents))
+	for i, parent := range parents {
+		values[i] = posts[parent.(*models.Space).ID]
+	}
+	return values, nil
+}
+
+// latestPosts loads the posts of scopes as GraphQL lists, applying the limit and offset arguments
+func (s *Service) latestPosts(ctx context.Context, scopes map[int][]int, args map[string]interface{}) (map[int]interface{}, error) {
+	limit, offset := args["limit"].(int), args["offset"].(int)
+	if limit < 1 || limit > config.MaxPostLimit {
+		return nil, errors.New(config.ErrGraphQLInvalidLimit)
+	}
+	if offset < 0 {
+		return nil, errors.New(config.ErrGraphQLInvalidOffset)
+	}
+
+	posts, err := s.db.GetLatestPostsByScopes(ctx, scopes, limit, offset)
+	if err != nil {
+		return nil, errors.New(config.ErrFailedToGetPosts)
+	}
+	// Posts of hidden spaces cross-posted into visible ones stay hidden
+	hidden := s.access.Hidden(ctx)
+	lists := make(map[int]interface{}, len(posts))
+	for key, scopePosts := range posts {
+		list := make([]interface{}, 0, len(scopePosts))
+		for i := range scopePosts {
+			if !hidden[scopePosts[i].SpaceID] {
+				list = append(list, &scopePosts[i])
+			}
+		}
+		lists[key] = list
+	}
+	return lists, nil
+}
+
+func (s *Service) resolvePostSpace(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	values := make([]interface{}, len(parents))
+	for i, parent := range parents {
+		if space, ok := s.visibleSpace(ctx, parent.(*models.Post).SpaceID); ok {
+			values[i] = space
+		}
+	}
+	return values, nil
+}
+
+// postIDs lists the distinct IDs of parent posts
+func postIDs(parents []interface{}) []int {
+	seen := make(map[int]bool, len(parents))
+	var ids []int
+	for _, parent := range parents {
+		if id := parent.(*models.Post).ID; !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// resolveAttachments loads the attachments of every parent post in one query
+func (s *Service) resolveAttachments(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	attachments, err := s.db.GetAttachmentsByPosts(ctx, postIDs(parents))
+	if err != nil {
+		return nil, errors.New(config.ErrFailedToGetAttachments)
+	}
+
+	values := make([]interface{}, len(parents))
+	for i, parent := range parents {
+		postAttachments := attachments[parent.(*models.Post).ID]
+		list := make([]interface{}, len(postAttachments))
+		for j := range postAttachments {
+			list[j] = &postAttachments[j]
+		}
+		values[i] = list
+	}
+	return values, nil
+}
+
+// resolveLinkPreviews loads the link previews of every parent post in one query
+func (s *Service) resolveLinkPreviews(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	previews, err := s.db.GetLinkPreviewsByPosts(ctx, postIDs(parents))
+	if err != nil {
+		return nil, errors.New(config.ErrFailedToGetLinkPreviews)
+	}
+
+	values := make([]interface{}, len(parents))
+	for i, parent := range parents {
+		postPreviews := previews[parent.(*models.Post).ID]
+		list := make([]interface{}, len(postPreviews))
+		for j := range postPreviews {
+			list[j] = &postPreviews[j]
+		}
+		values[i] = list
+	}
+	return values, nil
+}
+
+func (s *Service) resolveStats(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	values := make([]interface{}, len(parents))
+	if s.stats == nil {
+		return values, nil
+	}
+	for i, parent := range parents {
+		stats := s.stats(parent.(*models.Space).ID, args["recursive"].(bool))
+		values[i] = &stats
+	}
+	return values, nil
+}
+
+// resolveActivity returns the activity of the current period, period_months long or as long
+// as the activity setting without it
+func (s *Service) resolveActivity(ctx context.Context, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	values := make([]interface{}, len(parents))
+	if s.activity == nil {
+		return values, nil
+	}
+
+	months, ok := args["period_months"].(int)
+	if !ok {
+		months = 4
+		if options := config.GetOptionsConfig(); options != nil && options.Features.Activity.PeriodMonths > 0 {
+			months = options.Features.Activity.PeriodMonths
+		}
+	}
+	if months < 1 {
+		return nil, errors.New(config.ErrGraphQLInvalidPeriodMonths)
+	}
+
+	for i, parent := range parents {
+		activity, err := s.activity(parent.(*models.Space).ID, args["recursive"].(bool), months)
+		if err != nil {
+			return nil, errors.New(config.ErrFailedToGetActivity + err.Error())
+		}
+		values[i] = activity
+	}
+	return values, nil
+}
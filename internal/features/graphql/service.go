@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Service answers GraphQL queries over spaces, posts, attachments, stats and activity. Fields
+// are resolved for all the objects of a nesting level at once, so the latest posts of every
+// space in a tree, or the attachments of every listed post, each take a single query.
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	stats    StatsSource
+	activity ActivitySource
+	access   *services.SpaceAccess
+	schema   *schema
+	enabled  bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	s := &Service{
+		db:       db,
+		catCache: catCache,
+		enabled:  enabled,
+	}
+	s.schema = s.buildSchema()
+	return s
+}
+
+// SetStatsSource resolves the stats field of spaces; without one it is null
+func (s *Service) SetStatsSource(source StatsSource) {
+	s.stats = source
+}
+
+// SetActivitySource resolves the activity field of spaces; without one it is null
+func (s *Service) SetActivitySource(source ActivitySource) {
+	s.activity = source
+}
+
+// SetSpaceAccess keeps the spaces hidden from the viewer of a request, and their posts, out of
+// its results
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// Schema returns the schema in the GraphQL schema definition language
+func (s *Service) Schema() string {
+	return s.schema.SDL()
+}
+
+// Execute runs the query operation of a request. Requests that cannot run, being malformed or
+// invalid against the schema, get a response without data.
+func (s *Service) Execute(ctx context.Context, req Request) *Response {
+	if req.Query == "" {
+		return failed(errors.New(config.ErrGraphQLQueryRequired))
+	}
+	if len(req.Query) > config.MaxGraphQLQueryLength {
+		return failed(errors.New(config.ErrGraphQLQueryTooLong))
+	}
+
+	doc, err := parse(req.Query)
+	if err != nil {
+		return failed(err)
+	}
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return failed(err)
+	}
+	if op.kind != "query" {
+		return failed(errors.New(config.ErrGraphQLOnlyQueries))
+	}
+
+	variables, err := coerceVariables(op, req.Variables)
+	if err != nil {
+		return failed(err)
+	}
+	p := &planner{schema: s.schema, fragments: doc.fragments, variables: variables}
+	query := s.schema.types["Query"]
+	plans, err := p.plan(query, op.selections, 0, map[string]bool{})
+	if err != nil {
+		return failed(err)
+	}
+
+	e := &executor{schema: s.schema}
+	data := e.execute(ctx, query, plans, []interface{}{nil}, [][]interface{}{{}})
+	return &Response{Data: data[0], Errors: e.errors}
+}
+
+// selectOperation picks the operation named name, or the only one of the document
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, errors.New(config.ErrGraphQLOperationNeeded)
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf(config.ErrFmtGraphQLUnknownOperation, name)
+}
+
+func failed(err error) *Response {
+	return &Response{Errors: []Error{{Message: err.Error()}}}
+}
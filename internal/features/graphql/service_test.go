@@ -0,0 +1,349 @@
+package graphql
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+type graphqlTestSetup struct {
+	db           *storage.DB
+	spaceService *services.SpaceService
+	postService  *services.PostService
+	service      *Service
+	cleanup      func()
+}
+
+func setupGraphQLTest(t *testing.T) *graphqlTestSetup {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+	config.SetOptionsConfigForTest(config.NewTestOptionsConfig().WithRetroactivePostingEnabled(true))
+
+	tempDir, err := os.MkdirTemp("", "backthynk_graphql_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	return &graphqlTestSetup{
+		db:           db,
+		spaceService: services.NewSpaceService(db, catCache, dispatcher),
+		postService:  services.NewPostService(db, catCache, dispatcher),
+		service:      NewService(db, catCache, true),
+		cleanup: func() {
+			db.Close()
+			os.RemoveAll(tempDir)
+		},
+	}
+}
+
+// run executes query and decodes the response as generic JSON
+func (s *graphqlTestSetup) run(t *testing.T, query string, variables map[string]interface{}) (map[string]interface{}, []Error) {
+	t.Helper()
+	response := s.service.Execute(context.Background(), Request{Query: query, Variables: variables})
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Failed to encode response: %v", err)
+	}
+
+	var decoded struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []Error                `json:"errors"`
+	}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return decoded.Data, decoded.Errors
+}
+
+func createPost(t *testing.T, setup *graphqlTestSetup, spaceID int, content string, created int64) *models.Post {
+	t.Helper()
+	post, err := setup.postService.Create(context.Background(), spaceID, content, &created)
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	return post
+}
+
+func TestSpaceTreeWithPostsAndAttachments(t *testing.T) {
+	setup := setupGraphQLTest(t)
+	defer setup.cleanup()
+
+	work, _ := setup.spaceService.Create(context.Background(), "Work", nil, "")
+	home, _ := setup.spaceService.Create(context.Background(), "Home", nil, "")
+	meetings, _ := setup.spaceService.Create(context.Background(), "Meetings", &work.ID, "")
+
+	base := int64(1700000000000)
+	createPost(t, setup, work.ID, "work one", base)
+	second := createPost(t, setup, work.ID, "work two", base+1000)
+	createPost(t, setup, meetings.ID, "standup", base+2000)
+	createPost(t, setup, home.ID, "groceries", base+3000)
+	if _, err := setup.db.CreateAttachment(second.ID, "notes.txt", "notes.txt", "text/plain", 12); err != nil {
+		t.Fatalf("Failed to create attachment: %v", err)
+	}
+
+	data, errs := setup.run(t, `{
+		spaces {
+			name
+			children { name post_count }
+			posts(limit: 1) { content attachments { filename file_size } }
+			all: posts(recursive: true) { content }
+		}
+	}`, nil)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	spaces := data["spaces"].([]interface{})
+	if len(spaces) != 2 {
+		t.Fatalf("Expected the 2 top-level spaces, got %d", len(spaces))
+	}
+	homeResult, workResult := spaces[0].(map[string]interface{}), spaces[1].(map[string]interface{})
+	if homeResult["name"] != "Home" || workResult["name"] != "Work" {
+		t.Fatalf("Expected spaces by name, got %v and %v", homeResult["name"], workResult["name"])
+	}
+
+	children := workResult["children"].([]interface{})
+	if len(children) != 1 || children[0].(map[string]interface{})["name"] != "Meetings" {
+		t.Errorf("Expected Meetings under Work, got %v", children)
+	}
+
+	latest := workResult["posts"].([]interface{})
+	if len(latest) != 1 {
+		t.Fatalf("Expected the limit to keep 1 post, got %d", len(latest))
+	}
+	post := latest[0].(map[string]interface{})
+	if post["content"] != "work two" {
+		t.Errorf("Expected the latest post first, got %v", post["content"])
+	}
+	attachments := post["attachments"].([]interface{})
+	if len(attachments) != 1 || attachments[0].(map[string]interface{})["filename"] != "notes.txt" {
+		t.Errorf("Expected the attachment of the post, got %v", attachments)
+	}
+
+	all := workResult["all"].([]interface{})
+	if len(all) != 3 || all[0].(map[string]interface{})["content"] != "standup" {
+		t.Errorf("Expected the recursive posts newest first, got %v", all)
+	}
+	if home := homeResult["posts"].([]interface{}); len(home) != 1 || home[0].(map[string]interface{})["attachments"] == nil {
+		t.Errorf("Expected the Home post with an empty attachment list, got %v", home)
+	}
+}
+
+func TestStatsAndActivitySources(t *testing.T) {
+	setup := setupGraphQLTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Projects", nil, "")
+	query := fmt.Sprintf(`{ space(id: %d) { stats(recursive: true) { file_count } activity(period_months: 2) { total_posts days { date } } } }`, space.ID)
+
+	data, errs := setup.run(t, query, nil)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	result := data["space"].(map[string]interface{})
+	if result["stats"] != nil || result["activity"] != nil {
+		t.Errorf("Expected null stats and activity without sources, got %v", result)
+	}
+
+	var asked []string
+	setup.service.SetStatsSource(func(spaceID int, recursive bool) Stats {
+		asked = append(asked, fmt.Sprintf("stats %d %v", spaceID, recursive))
+		return Stats{FileCount: 3, TotalSize: 300}
+	})
+	setup.service.SetActivitySource(func(spaceID int, recursive bool, periodMonths int) (*Activity, error) {
+		asked = append(asked, fmt.Sprintf("activity %d %v %d", spaceID, recursive, periodMonths))
+		return &Activity{TotalPosts: 5, Days: []ActivityDay{{Date: "2024-01-02", Count: 5}}}, nil
+	})
+
+	data, errs = setup.run(t, query, nil)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	result = data["space"].(map[string]interface{})
+	if stats := result["stats"].(map[string]interface{}); stats["file_count"] != float64(3) {
+		t.Errorf("Expected file_count 3, got %v", stats["file_count"])
+	}
+	activity := result["activity"].(map[string]interface{})
+	if activity["total_posts"] != float64(5) || len(activity["days"].([]interface{})) != 1 {
+		t.Errorf("Expected the activity of the source, got %v", activity)
+	}
+	expected := []string{fmt.Sprintf("stats %d true", space.ID), fmt.Sprintf("activity %d false 2", space.ID)}
+	if fmt.Sprint(asked) != fmt.Sprint(expected) {
+		t.Errorf("Expected sources called with %v, got %v", expected, asked)
+	}
+}
+
+func TestPostQueries(t *testing.T) {
+	setup := setupGraphQLTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Inbox", nil, "")
+	other, _ := setup.spaceService.Create(context.Background(), "Reading", nil, "")
+	post := createPost(t, setup, space.ID, "hello", 1700000000000)
+	if err := setup.db.AddCrosspost(post.ID, other.ID); err != nil {
+		t.Fatalf("Failed to cross-post: %v", err)
+	}
+
+	data, errs := setup.run(t, `query Post($id: Int!, $space: Int) {
+		post(id: $id) { __typename id content space { name } link_previews { url } }
+		missing: post(id: 999) { id }
+		posts(space_id: $space) { id crossposted_from }
+	}`, map[string]interface{}{"id": float64(post.ID), "space": float64(other.ID)})
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+
+	result := data["post"].(map[string]interface{})
+	if result["__typename"] != "Post" || result["content"] != "hello" {
+		t.Errorf("Expected the post, got %v", result)
+	}
+	if result["space"].(map[string]interface{})["name"] != "Inbox" {
+		t.Errorf("Expected the space of the post, got %v", result["space"])
+	}
+	if data["missing"] != nil {
+		t.Errorf("Expected null for an unknown post, got %v", data["missing"])
+	}
+	posts := data["posts"].([]interface{})
+	if len(posts) != 1 || posts[0].(map[string]interface{})["crossposted_from"] != float64(space.ID) {
+		t.Errorf("Expected the cross-posted post with its owner space, got %v", posts)
+	}
+}
+
+func TestResultKeepsSelectionOrder(t *testing.T) {
+	setup := setupGraphQLTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Ordered", nil, "")
+	response := setup.service.Execute(context.Background(), Request{
+		Query: fmt.Sprintf(`{ space(id: %d) { name id ...Counts } } fragment Counts on Space { post_count id }`, space.ID),
+	})
+	encoded, _ := json.Marshal(response)
+
+	expected := fmt.Sprintf(`{"data":{"space":{"name":"Ordered","id":%d,"post_count":0}}}`, space.ID)
+	if string(encoded) != expected {
+		t.Errorf("Expected %s, got %s", expected, encoded)
+	}
+}
+
+func TestFieldErrorsKeepOtherFields(t *testing.T) {
+	setup := setupGraphQLTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Limits", nil, "")
+	data, errs := setup.run(t, fmt.Sprintf(`{ space(id: %d) { name posts(limit: 500) { id } } }`, space.ID), nil)
+
+	if len(errs) != 1 || errs[0].Message != config.ErrGraphQLInvalidLimit {
+		t.Fatalf("Expected the limit error, got %v", errs)
+	}
+	if fmt.Sprint(errs[0].Path) != "[space posts]" {
+		t.Errorf("Expected the error path to lead to the field, got %v", errs[0].Path)
+	}
+	result := data["space"].(map[string]interface{})
+	if result["name"] != "Limits" || result["posts"] != nil {
+		t.Errorf("Expected the name kept and posts null, got %v", result)
+	}
+}
+
+func TestInvalidRequests(t *testing.T) {
+	setup := setupGraphQLTest(t)
+	defer setup.cleanup()
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		expected  string
+	}{
+		{"Empty", "", nil, config.ErrGraphQLQueryRequired},
+		{"Too long", "{ spaces { name } }" + strings.Repeat(" ", config.MaxGraphQLQueryLength), nil, config.ErrGraphQLQueryTooLong},
+		{"Syntax", "{ spaces { name }", nil, "Syntax error"},
+		{"Mutation", "mutation { spaces { name } }", nil, config.ErrGraphQLOnlyQueries},
+		{"Unknown field", "{ spaces { title } }", nil, `Cannot query field "title" on type Space`},
+		{"Unknown argument", "{ spaces(first: 2) { name } }", nil, `Unknown argument "first"`},
+		{"Missing argument", "{ space { name } }", nil, `Argument "id" on field Query.space must be Int!`},
+		{"Wrong argument type", `{ space(id: "one") { name } }`, nil, `Argument "id"`},
+		{"Subfields required", "{ spaces }", nil, "needs a selection of subfields"},
+		{"No subfields", "{ spaces { name { x } } }", nil, "has no subfields"},
+		{"Unknown fragment", "{ spaces { ...Missing } }", nil, `Unknown fragment "Missing"`},
+		{"Fragment cycle", "{ spaces { ...A } } fragment A on Space { ...A }", nil, `Fragment "A" spreads itself`},
+		{"Conflict", "{ spaces { x: name x: id } }", nil, `Fields named "x"`},
+		{"Undefined variable", "{ space(id: $id) { name } }", nil, "Variable $id is not defined"},
+		{"Missing variable", "query($id: Int!) { space(id: $id) { name } }", nil, "Variable $id must be Int!"},
+		{"Several operations", "query A { spaces { name } } query B { spaces { id } }", nil, config.ErrGraphQLOperationNeeded},
+		{"Too deep", "{ spaces { " + strings.Repeat("children { ", 9) + "name" + strings.Repeat(" }", 9) + " } }", nil, config.ErrGraphQLTooDeep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := setup.service.Execute(context.Background(), Request{Query: tt.query, Variables: tt.variables})
+			if response.Data != nil {
+				t.Errorf("Expected no data, got %v", response.Data)
+			}
+			if len(response.Errors) != 1 || !strings.Contains(response.Errors[0].Message, tt.expected) {
+				t.Errorf("Expected an error containing %q, got %v", tt.expected, response.Errors)
+			}
+		})
+	}
+}
+
+func TestDirectivesAndVariables(t *testing.T) {
+	setup := setupGraphQLTest(t)
+	defer setup.cleanup()
+
+	setup.spaceService.Create(context.Background(), "Only", nil, "")
+	query := `query Tree($withId: Boolean = false) {
+		spaces { name id @include(if: $withId) ... on Space @skip(if: true) { depth } }
+	}`
+
+	data, errs := setup.run(t, query, nil)
+	if len(errs) > 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	space := data["spaces"].([]interface{})[0].(map[string]interface{})
+	if _, ok := space["id"]; ok {
+		t.Error("Expected id skipped by the default variable")
+	}
+	if _, ok := space["depth"]; ok {
+		t.Error("Expected the skipped inline fragment left out")
+	}
+
+	data, _ = setup.run(t, query, map[string]interface{}{"withId": true})
+	if _, ok := data["spaces"].([]interface{})[0].(map[string]interface{})["id"]; !ok {
+		t.Error("Expected id included by the variable")
+	}
+}
+
+func TestSchemaListsTypes(t *testing.T) {
+	service := NewService(nil, cache.NewSpaceCache(), true)
+	sdl := service.Schema()
+
+	for _, expected := range []string{
+		"schema {\n  query: Query\n}",
+		"  space(id: Int!): Space\n",
+		"  posts(limit: Int = 20, offset: Int = 0, recursive: Boolean = false): [Post!]\n",
+		"type Attachment {",
+		"type ActivityDay {",
+	} {
+		if !strings.Contains(sdl, expected) {
+			t.Errorf("Expected the schema to contain %q, got:\n%s", expected, sdl)
+		}
+	}
+}
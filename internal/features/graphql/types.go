@@ -0,0 +1,50 @@
+package graphql
+
+// Request is a GraphQL request sent to /api/graphql
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is the result of a request. Data is left out when the request failed before it
+// was executed, and set with errors when some fields could not be resolved.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// Error is a request or field error; Path leads to the field that failed
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Stats is the file usage of a space, see StatsSource
+type Stats struct {
+	FileCount int64 `json:"file_count"`
+	TotalSize int64 `json:"total_size"`
+}
+
+// Activity is the posting activity of a space over a period, see ActivitySource
+type Activity struct {
+	StartDate      string        `json:"start_date"`
+	EndDate        string        `json:"end_date"`
+	TotalPosts     int           `json:"total_posts"`
+	ActiveDays     int           `json:"active_days"`
+	MaxDayActivity int           `json:"max_day_activity"`
+	Days           []ActivityDay `json:"days"`
+}
+
+// ActivityDay is the post count of a day with activity
+type ActivityDay struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+// StatsSource returns the file usage of a space, with its descendants when recursive
+type StatsSource func(spaceID int, recursive bool) Stats
+
+// ActivitySource returns the activity of a space over the current period of periodMonths,
+// with its descendants when recursive
+type ActivitySource func(spaceID int, recursive bool, periodMonths int) (*Activity, error)
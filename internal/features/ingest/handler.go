@@ -0,0 +1,185 @@
+package ingest
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+	guard   mux.MiddlewareFunc
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// SetGuard wraps the public ingest endpoint, e.g. with the public endpoint guard
+func (h *Handler) SetGuard(guard mux.MiddlewareFunc) {
+	h.guard = guard
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/ingest-tokens", h.GetTokens).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/ingest-tokens", h.CreateToken).Methods("POST")
+	api.HandleFunc("/ingest-tokens/{id:[0-9]+}", h.RevokeToken).Methods("DELETE")
+	api.HandleFunc("/ingest-tokens/{id:[0-9]+}/activity", h.GetActivity).Methods("GET")
+
+	public := api.PathPrefix("/spaces/ingest").Subrouter()
+	if h.guard != nil {
+		public.Use(h.guard)
+	}
+	public.HandleFunc("/{token}", h.Ingest).Methods("POST")
+}
+
+// GetTokens handles GET /api/spaces/{id}/ingest-tokens
+func (h *Handler) GetTokens(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.service.GetTokens(r.Context(), spaceID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// CreateToken handles POST /api/spaces/{id}/ingest-tokens
+// The full token is only part of this response.
+func (h *Handler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	minted, err := h.service.Mint(r.Context(), spaceID, req.Name, req.RateLimit, req.Signed)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(minted)
+}
+
+// RevokeToken handles DELETE /api/ingest-tokens/{id}
+// Revoked tokens keep their activity log.
+func (h *Handler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidIngestTokenID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Revoke(r.Context(), tokenID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetActivity handles GET /api/ingest-tokens/{id}/activity?limit=50
+func (h *Handler) GetActivity(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidIngestTokenID, http.StatusBadRequest)
+		return
+	}
+
+	limit := config.DefaultIngestActivityLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > config.MaxIngestEventsPerToken {
+			http.Error(w, config.ErrInvalidIngestActivityLimit, http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	activity, err := h.service.GetActivity(r.Context(), tokenID, limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(activity)
+}
+
+// Ingest handles POST /api/spaces/ingest/{token}
+// JSON bodies carry the content in a "content" field; any other body is posted as is.
+func (h *Handler) Ingest(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, config.MaxIngestBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, config.ErrIngestPayloadTooLarge, http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, config.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	content := string(body)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		var payload Payload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+			return
+		}
+		content = payload.Content
+	}
+
+	post, err := h.service.Ingest(r.Context(), mux.Vars(r)["token"], content)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(IngestResponse{PostID: post.ID, SpaceID: post.SpaceID, Created: post.Created})
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case config.ErrSpaceNotFound, config.ErrIngestTokenNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case config.ErrSpaceAccessDenied:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case config.ErrIngestTokenRevoked:
+		http.Error(w, err.Error(), http.StatusGone)
+	case config.ErrIngestRateLimited:
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		// Validation errors of the token request or of the posted content
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
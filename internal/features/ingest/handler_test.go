@@ -0,0 +1,207 @@
+package ingest
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"backthynk/internal/features/publicguard"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("POST", "/api/spaces/ingest/bti_token", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected ingest routes NOT to be registered when disabled")
+	}
+}
+
+func serve(router *mux.Router, method, path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIngestHandlers(t *testing.T) {
+	db, cleanup := setupIngestTestDB(t)
+	defer cleanup()
+	service, space := setupIngestService(t, db)
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	spacePath := "/api/spaces/" + strconv.Itoa(space.ID) + "/ingest-tokens"
+	w := serve(router, "POST", spacePath, "application/json", `{"name":"CI","rate_limit":5}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var minted MintedToken
+	json.Unmarshal(w.Body.Bytes(), &minted)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{"Ingest JSON", "POST", minted.URL, "application/json; charset=utf-8", `{"content":"Build passed"}`, http.StatusCreated},
+		{"Ingest text", "POST", minted.URL, "text/plain", "Deploy done", http.StatusCreated},
+		{"Ingest invalid JSON", "POST", minted.URL, "application/json", `{`, http.StatusBadRequest},
+		{"Ingest empty", "POST", minted.URL, "text/plain", "", http.StatusBadRequest},
+		{"Ingest too large", "POST", minted.URL, "text/plain", strings.Repeat("a", config.MaxIngestBodyBytes+1), http.StatusRequestEntityTooLarge},
+		{"Ingest unknown token", "POST", "/api/spaces/ingest/bti_unknown", "text/plain", "Hello", http.StatusNotFound},
+		{"Create missing name", "POST", spacePath, "application/json", `{}`, http.StatusBadRequest},
+		{"Create invalid JSON", "POST", spacePath, "application/json", `{`, http.StatusBadRequest},
+		{"Create unknown space", "POST", "/api/spaces/999/ingest-tokens", "application/json", `{"name":"CI"}`, http.StatusNotFound},
+		{"List unknown space", "GET", "/api/spaces/999/ingest-tokens", "", "", http.StatusNotFound},
+		{"Activity", "GET", "/api/ingest-tokens/" + strconv.Itoa(minted.ID) + "/activity?limit=2", "", "", http.StatusOK},
+		{"Activity invalid limit", "GET", "/api/ingest-tokens/" + strconv.Itoa(minted.ID) + "/activity?limit=0", "", "", http.StatusBadRequest},
+		{"Activity unknown token", "GET", "/api/ingest-tokens/999/activity", "", "", http.StatusNotFound},
+		{"Revoke", "DELETE", "/api/ingest-tokens/" + strconv.Itoa(minted.ID), "", "", http.StatusNoContent},
+		{"Ingest revoked", "POST", minted.URL, "text/plain", "Hello", http.StatusGone},
+		{"Revoke unknown", "DELETE", "/api/ingest-tokens/999", "", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, tt.method, tt.path, tt.contentType, tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w = serve(router, "GET", spacePath, "", "")
+	var tokens []models.IngestToken
+	json.Unmarshal(w.Body.Bytes(), &tokens)
+	if len(tokens) != 1 || tokens[0].Revoked == 0 || bytes.Contains(w.Body.Bytes(), []byte(minted.Token)) {
+		t.Errorf("Expected the revoked token without its value, got %s", w.Body.String())
+	}
+}
+
+func TestIngestRateLimitStatus(t *testing.T) {
+	db, cleanup := setupIngestTestDB(t)
+	defer cleanup()
+	service, space := setupIngestService(t, db)
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	minted, _ := service.Mint(context.Background(), space.ID, "CI", 1, false)
+	serve(router, "POST", minted.URL, "text/plain", "First")
+	if w := serve(router, "POST", minted.URL, "text/plain", "Second"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429, got %d", w.Code)
+	}
+}
+
+func TestIngestGuard(t *testing.T) {
+	db, cleanup := setupIngestTestDB(t)
+	defer cleanup()
+	service, space := setupIngestService(t, db)
+
+	guard := publicguard.NewService(true, 100, time.Hour, time.Minute)
+	handler := NewHandler(service)
+	handler.SetGuard(guard.Protect(config.IngestGuardRoute, "token", service.SigningSecret, service.RevokeValue))
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	minted, _ := service.Mint(context.Background(), space.ID, "CI", 0, false)
+	serve(router, "POST", minted.URL, "text/plain", "Hello")
+
+	// Revoking from the guard's traffic view revokes the ingest token itself
+	if err := guard.Revoke(minted.Token); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if w := serve(router, "POST", minted.URL, "text/plain", "Hello"); w.Code != http.StatusGone {
+		t.Errorf("Expected 410, got %d", w.Code)
+	}
+	tokens, _ := service.GetTokens(context.Background(), space.ID)
+	if tokens[0].Revoked == 0 {
+		t.Error("Expected guard revocation to revoke the ingest token")
+	}
+}
+
+func TestIngestSignedRequests(t *testing.T) {
+	db, cleanup := setupIngestTestDB(t)
+	defer cleanup()
+	service, space := setupIngestService(t, db)
+
+	guard := publicguard.NewService(true, 100, time.Hour, time.Minute)
+	handler := NewHandler(service)
+	handler.SetGuard(guard.Protect(config.IngestGuardRoute, "token", service.SigningSecret, service.RevokeValue))
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	w := serve(router, "POST", "/api/spaces/"+strconv.Itoa(space.ID)+"/ingest-tokens", "application/json", `{"name":"CI","signed":true}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var minted MintedToken
+	json.Unmarshal(w.Body.Bytes(), &minted)
+	if !minted.Signed || minted.SigningSecret == "" {
+		t.Fatalf("Expected a signing secret for a signed token, got %+v", minted)
+	}
+
+	signed := func(secret, nonce, body string) *http.Request {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req := httptest.NewRequest("POST", minted.URL, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "text/plain")
+		req.Header.Set(publicguard.HeaderTimestamp, timestamp)
+		req.Header.Set(publicguard.HeaderNonce, nonce)
+		req.Header.Set(publicguard.HeaderSignature, hex.EncodeToString(publicguard.Sign(secret, timestamp, nonce, "POST", minted.URL, []byte(body))))
+		return req
+	}
+
+	tests := []struct {
+		name           string
+		req            *http.Request
+		expectedStatus int
+	}{
+		{"Unsigned request", httptest.NewRequest("POST", minted.URL, strings.NewReader("Hello")), http.StatusUnauthorized},
+		{"Wrong secret", signed("wrong", "n1", "Hello"), http.StatusUnauthorized},
+		{"Signed request", signed(minted.SigningSecret, "n2", "Hello"), http.StatusCreated},
+		{"Replayed request", signed(minted.SigningSecret, "n2", "Hello"), http.StatusUnauthorized},
+		{"Unknown token", httptest.NewRequest("POST", "/api/spaces/ingest/bti_unknown", strings.NewReader("Hello")), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tt.req)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if traffic := guard.GetTraffic(); len(traffic) != 1 || traffic[0].Requests != 4 {
+		t.Errorf("Expected only the minted token to be tracked, got %+v", traffic)
+	}
+
+	// The secret survives a restart
+	restarted := NewService(db, service.catCache, true)
+	if err := restarted.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if secret, known := restarted.SigningSecret(minted.Token); !known || secret != minted.SigningSecret {
+		t.Errorf("Expected the signing secret to be loaded again, got %q, %v", secret, known)
+	}
+}
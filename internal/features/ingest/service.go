@@ -0,0 +1,364 @@
+package ingest
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PostCreator creates posts with the validation of regular posting, e.g. the core PostService
+type PostCreator interface {
+	CreateWithSource(ctx context.Context, spaceID int, content string, customTimestamp *int64, source string) (*models.Post, error)
+}
+
+// window counts the requests of a token in the current rate limit window
+type window struct {
+	start  time.Time
+	count  int
+	warned bool // A quota warning was raised for this window
+}
+
+// Service mints per-space ingest tokens and posts the content sent with them into their space.
+// Tokens are independent of each other: each has its own rate limit, activity log and revocation.
+type Service struct {
+	db         *storage.DB
+	catCache   *cache.SpaceCache
+	access     *services.SpaceAccess
+	creator    PostCreator
+	dispatcher *events.Dispatcher
+	tokens     map[string]*models.IngestToken // token hash -> token
+	windows    map[int]*window                // token ID -> current window
+	mu         sync.Mutex
+	now        func() time.Time
+	enabled    bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		tokens:   make(map[string]*models.IngestToken),
+		windows:  make(map[int]*window),
+		now:      time.Now,
+		enabled:  enabled,
+	}
+}
+
+func (s *Service) SetPostCreator(creator PostCreator) {
+	s.creator = creator
+}
+
+// SetDispatcher lets the service raise a quota warning when a token hits its rate limit
+func (s *Service) SetDispatcher(dispatcher *events.Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// SetSpaceAccess lets only the viewers that may change a space mint and revoke its tokens, and
+// hides the tokens of spaces a viewer may not read
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	tokens, err := s.db.GetIngestTokens()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range tokens {
+		s.tokens[tokens[i].Hash] = &tokens[i]
+	}
+
+	return nil
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	if event.Type == events.SpaceDeleted {
+		// Tokens of deleted spaces are removed by the database cascade
+		s.mu.Lock()
+		for hash, token := range s.tokens {
+			if _, ok := s.catCache.Get(token.SpaceID); !ok {
+				delete(s.windows, token.ID)
+				delete(s.tokens, hash)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Mint creates a token posting into spaceID. rateLimit 0 uses the default. A signed token gets
+// a secret its requests must be signed with, returned along with the token.
+func (s *Service) Mint(ctx context.Context, spaceID int, name string, rateLimit int, signed bool) (*MintedToken, error) {
+	if err := s.checkSpace(ctx, spaceID, true); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf(config.ErrIngestTokenNameRequired)
+	}
+	if len([]rune(name)) > config.MaxIngestTokenNameLength {
+		return nil, fmt.Errorf(config.ErrIngestTokenNameTooLong)
+	}
+	if rateLimit == 0 {
+		rateLimit = config.DefaultIngestRateLimit
+	}
+	if rateLimit < 1 || rateLimit > config.MaxIngestRateLimit {
+		return nil, fmt.Errorf(config.ErrInvalidIngestRateLimit)
+	}
+
+	secret := make([]byte, config.IngestTokenBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate ingest token: %w", err)
+	}
+	value := config.IngestTokenPrefix + hex.EncodeToString(secret)
+
+	token := &models.IngestToken{
+		SpaceID:   spaceID,
+		Name:      name,
+		Prefix:    value[:config.IngestTokenDisplayLength],
+		RateLimit: rateLimit,
+		Hash:      hashToken(value),
+	}
+	if signed {
+		key := make([]byte, config.SigningSecretBytes)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate ingest token secret: %w", err)
+		}
+		token.Secret = hex.EncodeToString(key)
+	}
+	if err := s.db.CreateIngestToken(token); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.tokens[token.Hash] = token
+	s.mu.Unlock()
+
+	return &MintedToken{IngestToken: *token, Token: value, URL: "/api/spaces/ingest/" + value, SigningSecret: token.Secret}, nil
+}
+
+// GetTokens lists the tokens of a space, newest first
+func (s *Service) GetTokens(ctx context.Context, spaceID int) ([]models.IngestToken, error) {
+	if err := s.checkSpace(ctx, spaceID, false); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens := []models.IngestToken{}
+	for _, token := range s.tokens {
+		if token.SpaceID == spaceID {
+			tokens = append(tokens, *token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID > tokens[j].ID })
+
+	return tokens, nil
+}
+
+// Revoke disables a token for good; revoking a revoked token is a no-op
+func (s *Service) Revoke(ctx context.Context, tokenID int) error {
+	s.mu.Lock()
+	token := s.findUnlocked(tokenID)
+	if token == nil || !s.access.CanRead(ctx, token.SpaceID) {
+		s.mu.Unlock()
+		return fmt.Errorf(config.ErrIngestTokenNotFound)
+	}
+	if !s.access.CanWrite(ctx, token.SpaceID) {
+		s.mu.Unlock()
+		return fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+	if token.Revoked != 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	revoked := s.now().UnixMilli()
+	s.mu.Unlock()
+
+	if err := s.db.RevokeIngestToken(tokenID, revoked); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	token.Revoked = revoked
+	delete(s.windows, tokenID)
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeValue revokes a token from its full value, for revocations coming from the public
+// endpoint guard. Unknown values are ignored.
+func (s *Service) RevokeValue(value string) error {
+	s.mu.Lock()
+	token, ok := s.tokens[hashToken(value)]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return s.Revoke(context.Background(), token.ID)
+}
+
+// SigningSecret is the secret lookup of the public endpoint guard: it tells whether a token
+// value exists and returns its signing secret, empty for unsigned tokens
+func (s *Service) SigningSecret(value string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[hashToken(value)]
+	if !ok {
+		return "", false
+	}
+	return token.Secret, true
+}
+
+// GetActivity returns the most recent activity log entries of a token
+func (s *Service) GetActivity(ctx context.Context, tokenID, limit int) ([]models.IngestEvent, error) {
+	s.mu.Lock()
+	token := s.findUnlocked(tokenID)
+	s.mu.Unlock()
+	if token == nil || !s.access.CanRead(ctx, token.SpaceID) {
+		return nil, fmt.Errorf(config.ErrIngestTokenNotFound)
+	}
+
+	return s.db.GetIngestEvents(tokenID, limit)
+}
+
+// Ingest posts content into the space of the token. Every request made with a known token is
+// recorded in its activity log, including refused ones.
+func (s *Service) Ingest(ctx context.Context, value, content string) (*models.Post, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	token, ok := s.tokens[hashToken(value)]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf(config.ErrIngestTokenNotFound)
+	}
+	tokenID, spaceID, name, rateLimit := token.ID, token.SpaceID, token.Name, token.RateLimit
+
+	var refusal error
+	var outcome string
+	var warn bool
+	if token.Revoked != 0 {
+		refusal, outcome = fmt.Errorf(config.ErrIngestTokenRevoked), models.IngestOutcomeRevoked
+	} else {
+		w, ok := s.windows[tokenID]
+		if !ok || now.Sub(w.start) >= config.IngestRateWindow {
+			w = &window{start: now}
+			s.windows[tokenID] = w
+		}
+		if w.count >= token.RateLimit {
+			refusal, outcome = fmt.Errorf(config.ErrIngestRateLimited), models.IngestOutcomeRateLimited
+			warn = !w.warned
+			w.warned = true
+		} else {
+			w.count++
+		}
+	}
+	s.mu.Unlock()
+
+	if refusal != nil {
+		s.record(tokenID, now, outcome, 0, "")
+		if warn {
+			s.dispatcher.Notify(events.NotificationEvent{
+				Kind:    models.NotificationQuotaWarning,
+				Title:   "Ingest token rate limit reached",
+				Message: fmt.Sprintf("Token %q reached its limit of %d requests per hour; further requests are refused until the hour is over.", name, rateLimit),
+				SpaceID: spaceID,
+				Key:     fmt.Sprintf("ingest.rate_limit.%d", tokenID),
+			})
+		}
+		return nil, refusal
+	}
+
+	if strings.TrimSpace(content) == "" {
+		s.record(tokenID, now, models.IngestOutcomeRejected, 0, config.ErrContentRequired)
+		return nil, fmt.Errorf(config.ErrContentRequired)
+	}
+
+	post, err := s.creator.CreateWithSource(ctx, spaceID, content, nil, models.PostSourceWebhook)
+	if err != nil {
+		s.record(tokenID, now, models.IngestOutcomeRejected, 0, err.Error())
+		return nil, err
+	}
+	s.record(tokenID, now, models.IngestOutcomeAccepted, post.ID, "")
+
+	if err := s.db.TouchIngestToken(tokenID, now.UnixMilli()); err == nil {
+		s.mu.Lock()
+		token.LastUsed = now.UnixMilli()
+		s.mu.Unlock()
+	}
+
+	return post, nil
+}
+
+// record appends to the activity log of a token; failures are logged, not returned, so they
+// never change the answer given to the sender
+func (s *Service) record(tokenID int, at time.Time, outcome string, postID int, detail string) {
+	event := &models.IngestEvent{
+		TokenID: tokenID,
+		Created: at.UnixMilli(),
+		Outcome: outcome,
+		PostID:  postID,
+		Detail:  detail,
+	}
+	if err := s.db.AddIngestEvent(event, config.MaxIngestEventsPerToken); err != nil {
+		logger.Warning("Failed to record ingest activity", zap.Int("token_id", tokenID), zap.Error(err))
+	}
+}
+
+// findUnlocked returns the token with the given ID. Caller must hold s.mu.
+// checkSpace refuses spaces unknown or hidden from the viewer of ctx as not found, and spaces it
+// may only read as denied when write is set
+func (s *Service) checkSpace(ctx context.Context, spaceID int, write bool) error {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if write && !s.access.CanWrite(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+	return nil
+}
+
+func (s *Service) findUnlocked(tokenID int) *models.IngestToken {
+	for _, token := range s.tokens {
+		if token.ID == tokenID {
+			return token
+		}
+	}
+	return nil
+}
+
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,300 @@
+package ingest
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupIngestTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_ingest_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+// stubCreator stores posts directly, refusing content longer than maxLength like the post service
+type stubCreator struct {
+	db        *storage.DB
+	maxLength int
+}
+
+func (c *stubCreator) CreateWithSource(ctx context.Context, spaceID int, content string, customTimestamp *int64, source string) (*models.Post, error) {
+	if len(content) > c.maxLength {
+		return nil, fmt.Errorf(config.ErrFmtContentExceedsMaxLength, c.maxLength)
+	}
+	return c.db.CreatePostWithSource(context.Background(), spaceID, content, time.Now().UnixMilli(), source)
+}
+
+func setupIngestService(t *testing.T, db *storage.DB) (*Service, *models.Space) {
+	space, _ := db.CreateSpace("Inbox", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	service := NewService(db, catCache, true)
+	service.SetPostCreator(&stubCreator{db: db, maxLength: 20})
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	return service, space
+}
+
+func TestMint(t *testing.T) {
+	db, cleanup := setupIngestTestDB(t)
+	defer cleanup()
+	service, space := setupIngestService(t, db)
+
+	minted, err := service.Mint(context.Background(), space.ID, "  CI pipeline ", 0, false)
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	if minted.Name != "CI pipeline" || minted.RateLimit != config.DefaultIngestRateLimit ||
+		!strings.HasPrefix(minted.Token, config.IngestTokenPrefix) || !strings.HasPrefix(minted.Token, minted.Prefix) ||
+		minted.URL != "/api/spaces/ingest/"+minted.Token {
+		t.Errorf("Unexpected minted token %+v", minted)
+	}
+
+	other, _ := service.Mint(context.Background(), space.ID, "Other", 10, false)
+	if other.Token == minted.Token {
+		t.Error("Expected distinct token values")
+	}
+
+	tests := []struct {
+		name      string
+		spaceID   int
+		tokenName string
+		rateLimit int
+		expected  string
+	}{
+		{"Unknown space", 999, "CI", 0, config.ErrSpaceNotFound},
+		{"Missing name", space.ID, " ", 0, config.ErrIngestTokenNameRequired},
+		{"Long name", space.ID, strings.Repeat("a", config.MaxIngestTokenNameLength+1), 0, config.ErrIngestTokenNameTooLong},
+		{"Negative rate limit", space.ID, "CI", -1, config.ErrInvalidIngestRateLimit},
+		{"Rate limit too high", space.ID, "CI", config.MaxIngestRateLimit + 1, config.ErrInvalidIngestRateLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Mint(context.Background(), tt.spaceID, tt.tokenName, tt.rateLimit, false); err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	// Only hashes are stored, and tokens survive a restart
+	stored, _ := db.GetIngestTokens()
+	for _, token := range stored {
+		if strings.Contains(token.Hash, minted.Token) || token.Hash == minted.Token {
+			t.Error("Expected the token value not to be stored")
+		}
+	}
+	restarted := NewService(db, service.catCache, true)
+	restarted.Initialize()
+	if tokens, _ := restarted.GetTokens(context.Background(), space.ID); len(tokens) != 2 || tokens[0].ID != other.ID {
+		t.Errorf("Expected tokens newest first after restart, got %+v", tokens)
+	}
+}
+
+func TestIngest(t *testing.T) {
+	db, cleanup := setupIngestTestDB(t)
+	defer cleanup()
+	service, space := setupIngestService(t, db)
+
+	minted, _ := service.Mint(context.Background(), space.ID, "CI", 2, false)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	post, err := service.Ingest(context.Background(), minted.Token, "Build 42 passed")
+	if err != nil {
+		t.Fatalf("Ingest failed: %v", err)
+	}
+	if post.SpaceID != space.ID || post.Source != models.PostSourceWebhook {
+		t.Errorf("Unexpected post %+v", post)
+	}
+
+	if _, err := service.Ingest(context.Background(), minted.Token, "This content is far too long"); err == nil {
+		t.Error("Expected post validation errors to surface")
+	}
+	if _, err := service.Ingest(context.Background(), minted.Token, "Third"); err == nil || err.Error() != config.ErrIngestRateLimited {
+		t.Errorf("Expected rate limit, got %v", err)
+	}
+
+	now = now.Add(config.IngestRateWindow)
+	if _, err := service.Ingest(context.Background(), minted.Token, "Next window"); err != nil {
+		t.Errorf("Expected a new window to accept requests, got %v", err)
+	}
+	if _, err := service.Ingest(context.Background(), minted.Token, " "); err == nil || err.Error() != config.ErrContentRequired {
+		t.Errorf("Expected empty content to fail, got %v", err)
+	}
+	if _, err := service.Ingest(context.Background(), "bti_unknown", "Hello"); err == nil || err.Error() != config.ErrIngestTokenNotFound {
+		t.Errorf("Expected unknown token to fail, got %v", err)
+	}
+
+	tokens, _ := service.GetTokens(context.Background(), space.ID)
+	if tokens[0].LastUsed != now.UnixMilli() {
+		t.Errorf("Expected last use to be recorded, got %d", tokens[0].LastUsed)
+	}
+
+	activity, _ := service.GetActivity(context.Background(), minted.ID, 10)
+	var outcomes []string
+	for _, event := range activity {
+		outcomes = append(outcomes, event.Outcome)
+	}
+	expected := []string{
+		models.IngestOutcomeRejected, models.IngestOutcomeAccepted, models.IngestOutcomeRateLimited,
+		models.IngestOutcomeRejected, models.IngestOutcomeAccepted,
+	}
+	if strings.Join(outcomes, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected activity %v, got %v", expected, outcomes)
+	}
+	if activity[4].PostID != post.ID || activity[3].Detail == "" {
+		t.Errorf("Unexpected activity details %+v", activity)
+	}
+}
+
+func TestIngestRateLimitNotification(t *testing.T) {
+	db, cleanup := setupIngestTestDB(t)
+	defer cleanup()
+	service, space := setupIngestService(t, db)
+
+	var raised []events.NotificationEvent
+	dispatcher := events.NewDispatcher()
+	dispatcher.Subscribe(events.NotificationRaised, func(event events.Event) error {
+		raised = append(raised, event.Data.(events.NotificationEvent))
+		return nil
+	})
+	service.SetDispatcher(dispatcher)
+
+	minted, _ := service.Mint(context.Background(), space.ID, "CI", 1, false)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	service.Ingest(context.Background(), minted.Token, "First")
+	service.Ingest(context.Background(), minted.Token, "Second")
+	service.Ingest(context.Background(), minted.Token, "Third")
+	if len(raised) != 1 {
+		t.Fatalf("Expected one warning per window, got %d", len(raised))
+	}
+	if raised[0].Kind != models.NotificationQuotaWarning || raised[0].SpaceID != space.ID ||
+		raised[0].Key != fmt.Sprintf("ingest.rate_limit.%d", minted.ID) {
+		t.Errorf("Unexpected notification %+v", raised[0])
+	}
+
+	now = now.Add(config.IngestRateWindow)
+	service.Ingest(context.Background(), minted.Token, "Fourth")
+	service.Ingest(context.Background(), minted.Token, "Fifth")
+	if len(raised) != 2 {
+		t.Errorf("Expected a new warning in the next window, got %d", len(raised))
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	db, cleanup := setupIngestTestDB(t)
+	defer cleanup()
+	service, space := setupIngestService(t, db)
+
+	revoked, _ := service.Mint(context.Background(), space.ID, "Old", 0, false)
+	kept, _ := service.Mint(context.Background(), space.ID, "New", 0, false)
+
+	if err := service.Revoke(context.Background(), revoked.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if err := service.Revoke(context.Background(), revoked.ID); err != nil {
+		t.Errorf("Expected revoking twice to be a no-op, got %v", err)
+	}
+	if err := service.Revoke(context.Background(), 999); err == nil || err.Error() != config.ErrIngestTokenNotFound {
+		t.Errorf("Expected unknown token to fail, got %v", err)
+	}
+
+	if _, err := service.Ingest(context.Background(), revoked.Token, "Hello"); err == nil || err.Error() != config.ErrIngestTokenRevoked {
+		t.Errorf("Expected revoked token to fail, got %v", err)
+	}
+	if _, err := service.Ingest(context.Background(), kept.Token, "Hello"); err != nil {
+		t.Errorf("Expected other tokens to keep working, got %v", err)
+	}
+
+	// Revocations coming from the public endpoint guard use the token value
+	service.RevokeValue(kept.Token)
+	if _, err := service.Ingest(context.Background(), kept.Token, "Hello"); err == nil {
+		t.Error("Expected token revoked by value to fail")
+	}
+
+	activity, _ := service.GetActivity(context.Background(), revoked.ID, 10)
+	if len(activity) != 1 || activity[0].Outcome != models.IngestOutcomeRevoked {
+		t.Errorf("Expected revoked attempt in the activity log, got %+v", activity)
+	}
+}
+
+func TestTokensFollowSpaceAccess(t *testing.T) {
+	db, cleanup := setupIngestTestDB(t)
+	defer cleanup()
+	service, space := setupIngestService(t, db)
+	minted, _ := service.Mint(context.Background(), space.ID, "CI", 0, false)
+
+	alice, _ := db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	bob, _ := db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(db, service.catCache)
+	if err := access.SetACL(space.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+	service.SetSpaceAccess(access)
+	asAlice := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	asBob := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+
+	if _, err := service.Mint(asAlice, space.ID, "Sneaky", 0, false); err == nil || err.Error() != config.ErrSpaceAccessDenied {
+		t.Errorf("Expected a reader to be refused minting, got %v", err)
+	}
+	if err := service.Revoke(asAlice, minted.ID); err == nil || err.Error() != config.ErrSpaceAccessDenied {
+		t.Errorf("Expected a reader to be refused revoking, got %v", err)
+	}
+	if tokens, err := service.GetTokens(asAlice, space.ID); err != nil || len(tokens) != 1 {
+		t.Errorf("Expected a reader to list the tokens, got %v, %v", tokens, err)
+	}
+	if _, err := service.Mint(asBob, space.ID, "Sneaky", 0, false); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+	if _, err := service.GetActivity(asBob, minted.ID, 10); err == nil || err.Error() != config.ErrIngestTokenNotFound {
+		t.Errorf("Expected the token of a hidden space to be not found, got %v", err)
+	}
+}
+
+func TestSpaceDeletedDropsTokens(t *testing.T) {
+	db, cleanup := setupIngestTestDB(t)
+	defer cleanup()
+	service, space := setupIngestService(t, db)
+
+	minted, _ := service.Mint(context.Background(), space.ID, "CI", 0, false)
+	db.DeleteSpace(context.Background(), space.ID)
+	service.catCache.Delete(space.ID)
+	service.HandleEvent(events.Event{Type: events.SpaceDeleted, Data: events.SpaceEvent{SpaceID: space.ID}})
+
+	if _, err := service.Ingest(context.Background(), minted.Token, "Hello"); err == nil || err.Error() != config.ErrIngestTokenNotFound {
+		t.Errorf("Expected token of deleted space to be gone, got %v", err)
+	}
+	if stored, _ := db.GetIngestTokens(); len(stored) != 0 {
+		t.Errorf("Expected cascade to remove stored tokens, got %d", len(stored))
+	}
+}
@@ -0,0 +1,29 @@
+package ingest
+
+import "backthynk/internal/core/models"
+
+type CreateTokenRequest struct {
+	Name      string `json:"name"`
+	RateLimit int    `json:"rate_limit,omitempty"` // Requests per hour, defaults to 60
+	Signed    bool   `json:"signed,omitempty"`     // Only accept requests signed with the token secret
+}
+
+// MintedToken is returned once, when a token is created; neither the full value nor the
+// signing secret can be read again
+type MintedToken struct {
+	models.IngestToken
+	Token         string `json:"token"`
+	URL           string `json:"url"`
+	SigningSecret string `json:"signing_secret,omitempty"`
+}
+
+// Payload is the JSON body accepted by the ingest endpoint; other content types are posted as is
+type Payload struct {
+	Content string `json:"content"`
+}
+
+type IngestResponse struct {
+	PostID  int   `json:"post_id"`
+	SpaceID int   `json:"space_id"`
+	Created int64 `json:"created"`
+}
@@ -0,0 +1,39 @@
+package integrity
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/admin/startup-report", h.GetReport).Methods("GET")
+}
+
+// GetReport handles GET /api/admin/startup-report, the outcome of the integrity self-check
+// run at startup
+func (h *Handler) GetReport(w http.ResponseWriter, r *http.Request) {
+	report := h.service.GetReport()
+	if report == nil {
+		http.Error(w, config.ErrStartupReportUnavailable, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,53 @@
+package integrity
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/admin/startup-report", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected startup report route NOT to be registered when disabled")
+	}
+}
+
+func TestGetReport(t *testing.T) {
+	db, catCache, cleanup := setupIntegrityTest(t)
+	defer cleanup()
+
+	service := NewService(db, catCache, true)
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/admin/startup-report", nil))
+		return w
+	}
+
+	if w := serve(); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 before the check ran, got %d", w.Code)
+	}
+
+	service.Run()
+	w := serve()
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var report Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if !report.OK || len(report.Checks) == 0 {
+		t.Errorf("Expected a passing report on an empty database, got %+v", report)
+	}
+}
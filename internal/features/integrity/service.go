@@ -0,0 +1,213 @@
+package integrity
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/storage"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service checks quick data invariants, meant to run once at startup so silent corruption
+// surfaces early. It only reports; repairs are left to the features owning the data.
+type Service struct {
+	db         *storage.DB
+	catCache   *cache.SpaceCache
+	enabled    bool
+	uploadsDir string
+	mu         sync.Mutex
+	report     *Report
+	now        func() time.Time
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:         db,
+		catCache:   catCache,
+		enabled:    enabled,
+		uploadsDir: filepath.Join(db.GetStoragePath(), "uploads"),
+		now:        time.Now,
+	}
+}
+
+// Run checks every invariant, logs the report and keeps it for GetReport
+func (s *Service) Run() *Report {
+	started := s.now()
+	report := &Report{Started: started.UnixMilli(), OK: true}
+
+	report.Checks = append(report.Checks, s.checkOrphanAttachments())
+	report.Checks = append(report.Checks, s.checkFiles()...)
+	report.Checks = append(report.Checks,
+		s.checkPostsWithoutSpace(),
+		s.checkCacheSpaces(),
+		s.checkCachePostCounts(),
+		s.checkSearchIndex(),
+	)
+	for _, check := range report.Checks {
+		report.OK = report.OK && check.OK()
+	}
+	report.DurationMs = s.now().Sub(started).Milliseconds()
+
+	s.log(report)
+
+	s.mu.Lock()
+	s.report = report
+	s.mu.Unlock()
+	return report
+}
+
+// GetReport returns the report of the last run, nil before the first one
+func (s *Service) GetReport() *Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.report
+}
+
+func (s *Service) log(report *Report) {
+	for _, check := range report.Checks {
+		if check.Error != "" {
+			logger.Warning("Integrity check failed to run", zap.String("check", check.Name), zap.String("error", check.Error))
+		} else if check.Count > 0 {
+			logger.Warning("Integrity check found problems", zap.String("check", check.Name), zap.Int("count", check.Count), zap.Strings("samples", check.Samples))
+		}
+	}
+	logger.Info("Startup integrity check done", zap.Bool("ok", report.OK), zap.Int("checks", len(report.Checks)), zap.Int64("duration_ms", report.DurationMs))
+}
+
+func (s *Service) checkOrphanAttachments() Check {
+	ids, err := s.db.GetOrphanAttachmentIDs()
+	return idCheck(CheckOrphanAttachments, ids, err)
+}
+
+func (s *Service) checkPostsWithoutSpace() Check {
+	ids, err := s.db.GetPostIDsWithoutSpace()
+	return idCheck(CheckPostsWithoutSpace, ids, err)
+}
+
+// checkFiles compares the uploads directory with the attachment records in one pass, for both
+// missing and orphan files. Files in cold storage are expected to be away.
+func (s *Service) checkFiles() []Check {
+	failed := func(err error) []Check {
+		return []Check{{Name: CheckMissingFiles, Error: err.Error()}, {Name: CheckOrphanFiles, Error: err.Error()}}
+	}
+
+	paths, err := s.db.GetAttachmentFilePaths()
+	if err != nil {
+		return failed(err)
+	}
+	entries, err := os.ReadDir(s.uploadsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return failed(err)
+	}
+	onDisk := make(map[string]bool, len(entries))
+	var orphans []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		onDisk[entry.Name()] = true
+		if _, ok := paths[entry.Name()]; !ok {
+			orphans = append(orphans, entry.Name())
+		}
+	}
+
+	var missing []string
+	for path, cold := range paths {
+		if !cold && !onDisk[path] {
+			missing = append(missing, path)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(orphans)
+
+	return []Check{
+		{Name: CheckMissingFiles, Count: len(missing), Samples: samples(missing)},
+		{Name: CheckOrphanFiles, Count: len(orphans), Samples: samples(orphans)},
+	}
+}
+
+func (s *Service) checkCacheSpaces() Check {
+	spaces, err := s.db.GetSpaces()
+	if err != nil {
+		return Check{Name: CheckCacheSpaces, Error: err.Error()}
+	}
+
+	// Get would load a missing space, hiding the delta
+	cached := make(map[int]bool)
+	for _, space := range s.catCache.GetAll() {
+		cached[space.ID] = true
+	}
+
+	var ids []int
+	for _, space := range spaces {
+		if !cached[space.ID] {
+			ids = append(ids, space.ID)
+		}
+		delete(cached, space.ID)
+	}
+	for id := range cached {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return idCheck(CheckCacheSpaces, ids, nil)
+}
+
+func (s *Service) checkCachePostCounts() Check {
+	counts, err := s.db.GetAllSpacePostCounts()
+	if err != nil {
+		return Check{Name: CheckCachePostCounts, Error: err.Error()}
+	}
+
+	var ids []int
+	for _, space := range s.catCache.GetAll() {
+		if space.PostCount != counts[space.ID] {
+			ids = append(ids, space.ID)
+		}
+	}
+	sort.Ints(ids)
+	return idCheck(CheckCachePostCounts, ids, nil)
+}
+
+// checkSearchIndex counts posts missing from the full-text index and index rows left by
+// deleted posts
+func (s *Service) checkSearchIndex() Check {
+	missing, err := s.db.GetPostIDsMissingFromIndex()
+	if err != nil {
+		return Check{Name: CheckSearchIndex, Error: err.Error()}
+	}
+	posts, indexed, err := s.db.GetIndexRowCounts()
+	if err != nil {
+		return Check{Name: CheckSearchIndex, Error: err.Error()}
+	}
+
+	check := idCheck(CheckSearchIndex, missing, nil)
+	if stale := indexed - (posts - len(missing)); stale > 0 {
+		check.Count += stale
+	}
+	return check
+}
+
+func idCheck(name string, ids []int, err error) Check {
+	if err != nil {
+		return Check{Name: name, Error: err.Error()}
+	}
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+	return Check{Name: name, Count: len(ids), Samples: samples(values)}
+}
+
+func samples(values []string) []string {
+	if len(values) > config.MaxIntegritySamples {
+		return values[:config.MaxIntegritySamples]
+	}
+	return values
+}
@@ -0,0 +1,122 @@
+package integrity
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func setupIntegrityTest(t *testing.T) (*storage.DB, *cache.SpaceCache, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_integrity_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, cache.NewSpaceCache(), func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func checksByName(report *Report) map[string]Check {
+	checks := make(map[string]Check)
+	for _, check := range report.Checks {
+		checks[check.Name] = check
+	}
+	return checks
+}
+
+func TestRunHealthyDatabase(t *testing.T) {
+	db, catCache, cleanup := setupIntegrityTest(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	post, _ := db.CreatePost(context.Background(), space.ID, "Hello")
+	space.PostCount = 1
+	catCache.Set(space)
+
+	service := NewService(db, catCache, true)
+	os.MkdirAll(service.uploadsDir, config.DirectoryPermissions)
+	os.WriteFile(filepath.Join(service.uploadsDir, "a.txt"), []byte("a"), config.FilePermissions)
+	db.CreateAttachment(post.ID, "a.txt", "a.txt", "text/plain", 1)
+
+	if service.GetReport() != nil {
+		t.Fatal("Expected no report before the first run")
+	}
+	report := service.Run()
+	if !report.OK || len(report.Checks) != 7 {
+		t.Errorf("Expected 7 passing checks, got %+v", report)
+	}
+	if service.GetReport() != report {
+		t.Error("Expected the report to be kept")
+	}
+}
+
+func TestRunFindsProblems(t *testing.T) {
+	db, catCache, cleanup := setupIntegrityTest(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	post, _ := db.CreatePost(context.Background(), space.ID, "Hello")
+	db.CreatePost(context.Background(), space.ID, "World")
+	space.PostCount = 1 // Stale count
+	catCache.Set(space)
+	catCache.Set(&models.Space{ID: 99, Name: "Ghost"})
+
+	service := NewService(db, catCache, true)
+	os.MkdirAll(service.uploadsDir, config.DirectoryPermissions)
+	os.WriteFile(filepath.Join(service.uploadsDir, "stray.txt"), []byte("x"), config.FilePermissions)
+	db.CreateAttachment(post.ID, "gone.txt", "gone.txt", "text/plain", 1)
+	db.CreateAttachment(post.ID, "cold.txt", "cold.txt", "text/plain", 1)
+	db.AddColdAttachment(models.ColdAttachment{FilePath: "cold.txt", FileSize: 1, ArchivedAt: 1})
+	db.Exec("DELETE FROM posts_fts WHERE docid = ?", post.ID)
+
+	// Foreign keys keep these from happening through the application
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.ExecContext(context.Background(), "PRAGMA foreign_keys = OFF")
+	conn.ExecContext(context.Background(), "INSERT INTO posts (space_id, content, created) VALUES (42, 'Lost', 0)")
+	conn.ExecContext(context.Background(), "INSERT INTO attachments (post_id, filename, file_path, file_type, file_size) VALUES (4242, 'x', 'x', 'text/plain', 0)")
+	conn.Close()
+
+	report := service.Run()
+	if report.OK {
+		t.Fatal("Expected the report to fail")
+	}
+
+	expected := map[string]int{
+		CheckOrphanAttachments: 1,
+		CheckMissingFiles:      2, // gone.txt and the file of the orphan attachment, not the cold file
+		CheckOrphanFiles:       1,
+		CheckPostsWithoutSpace: 1,
+		CheckCacheSpaces:       1,
+		CheckCachePostCounts:   1,
+		CheckSearchIndex:       1,
+	}
+	checks := checksByName(report)
+	for name, count := range expected {
+		if checks[name].Count != count {
+			t.Errorf("Expected %s to count %d, got %+v", name, count, checks[name])
+		}
+	}
+	if samples := checks[CheckOrphanFiles].Samples; len(samples) != 1 || samples[0] != "stray.txt" {
+		t.Errorf("Expected the stray file as sample, got %v", samples)
+	}
+}
@@ -0,0 +1,34 @@
+package integrity
+
+// Checks run at startup
+const (
+	CheckOrphanAttachments = "orphan_attachments"       // Attachment records whose post is gone
+	CheckMissingFiles      = "missing_attachment_files" // Attachment records whose file is gone
+	CheckOrphanFiles       = "orphan_upload_files"      // Uploaded files no attachment refers to
+	CheckPostsWithoutSpace = "posts_without_space"
+	CheckCacheSpaces       = "cache_space_delta"      // Spaces missing from or extra in the cache
+	CheckCachePostCounts   = "cache_post_count_delta" // Cached post counts differing from the database
+	CheckSearchIndex       = "search_index_delta"     // Posts missing from or stale in the full-text index
+)
+
+// Check is the outcome of one invariant. Count is the number of violations; Samples holds
+// the first offending IDs or file names.
+type Check struct {
+	Name    string   `json:"name"`
+	Count   int      `json:"count"`
+	Samples []string `json:"samples,omitempty"`
+	Error   string   `json:"error,omitempty"` // The check could not run
+}
+
+// OK reports whether the invariant holds
+func (c Check) OK() bool {
+	return c.Count == 0 && c.Error == ""
+}
+
+// Report is the outcome of a self-check
+type Report struct {
+	Started    int64   `json:"started"`
+	DurationMs int64   `json:"duration_ms"`
+	OK         bool    `json:"ok"`
+	Checks     []Check `json:"checks"`
+}
@@ -0,0 +1,59 @@
+package languagestats
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/stats/language", h.GetLanguageStats).Methods("GET")
+}
+
+// GetLanguageStats handles GET /api/spaces/{id}/stats/language
+//
+// Query parameters:
+// - recursive: true to include descendant spaces
+// - months: number of months in the trend, ending with the current one (default: 12, max: 120)
+func (h *Handler) GetLanguageStats(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	months := config.DefaultLanguageMonths
+	if monthsStr := r.URL.Query().Get("months"); monthsStr != "" {
+		m, err := strconv.Atoi(monthsStr)
+		if err != nil || m < 1 || m > config.MaxLanguageMonths {
+			http.Error(w, config.ErrInvalidLanguageMonths, http.StatusBadRequest)
+			return
+		}
+		months = m
+	}
+
+	stats, err := h.service.Stats(r.Context(), spaceID, r.URL.Query().Get("recursive") == "true", months)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,80 @@
+package languagestats
+
+import (
+	"backthynk/internal/core/cache"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/spaces/1/stats/language", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected language stats routes NOT to be registered when disabled")
+	}
+}
+
+func TestGetLanguageStats(t *testing.T) {
+	db, cleanup := setupLanguageTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Journal", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	db.CreatePost(context.Background(), space.ID, "First entry of the journal")
+
+	service := NewService(db, catCache, true)
+	service.Initialize()
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	base := "/api/spaces/" + strconv.Itoa(space.ID) + "/stats/language"
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedMonths int
+	}{
+		{"Default months", base, http.StatusOK, 12},
+		{"Custom months", base + "?months=6&recursive=true", http.StatusOK, 6},
+		{"Invalid months", base + "?months=0", http.StatusBadRequest, 0},
+		{"Too many months", base + "?months=121", http.StatusBadRequest, 0},
+		{"Unknown space", "/api/spaces/999/stats/language", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var stats LanguageStats
+			if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+				t.Fatal(err)
+			}
+			if len(stats.Months) != tt.expectedMonths || stats.Posts != 1 || stats.UniqueWords != 3 {
+				t.Errorf("Unexpected stats: %+v", stats)
+			}
+			if last := stats.Months[len(stats.Months)-1]; last.Posts != 1 || last.NewWords != 3 {
+				t.Errorf("Expected the post in the current month, got %+v", last)
+			}
+		})
+	}
+}
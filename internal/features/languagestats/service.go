@@ -0,0 +1,309 @@
+package languagestats
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// document is the counted form of a post
+type document struct {
+	spaceID int
+	month   string
+	words   int
+	terms   map[string]int
+}
+
+// vocabulary aggregates the documents of one space
+type vocabulary struct {
+	posts  map[string]int            // month -> posts
+	words  map[string]int            // month -> words
+	terms  map[string]map[string]int // word -> month -> occurrences
+}
+
+func newVocabulary() *vocabulary {
+	return &vocabulary{
+		posts: make(map[string]int),
+		words: make(map[string]int),
+		terms: make(map[string]map[string]int),
+	}
+}
+
+// Service keeps word counts of every post in memory, aggregated per space and month.
+// Aggregates follow post events, so stats are read without going through the posts again.
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	access   *services.SpaceAccess
+	docs     map[int]*document   // postID -> document
+	spaces   map[int]*vocabulary // spaceID -> vocabulary
+	mu       sync.RWMutex
+	now      func() time.Time
+	enabled  bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		docs:     make(map[int]*document),
+		spaces:   make(map[int]*vocabulary),
+		now:      time.Now,
+		enabled:  enabled,
+	}
+}
+
+// SetSpaceAccess keeps the posts of spaces hidden from the viewer of a request out of its stats
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	var spaceIDs []int
+	for _, space := range s.catCache.GetAll() {
+		spaceIDs = append(spaceIDs, space.ID)
+	}
+	if len(spaceIDs) == 0 {
+		return nil
+	}
+
+	posts, err := s.db.GetPostsBySpaces(spaceIDs)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, post := range posts {
+		s.countUnlocked(post.ID, post.SpaceID, post.Created, post.Content)
+	}
+
+	return nil
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	switch event.Type {
+	case events.PostCreated, events.PostUpdated, events.PostMoved:
+		data := event.Data.(events.PostEvent)
+		return s.countPostByID(data.PostID)
+
+	case events.PostMerged:
+		data := event.Data.(events.PostEvent)
+		for _, merged := range data.MergedPosts {
+			s.removePost(merged.PostID)
+		}
+		return s.countPostByID(data.PostID)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		if err := s.countPostByID(data.PostID); err != nil {
+			return err
+		}
+		for _, split := range data.SplitPosts {
+			if err := s.countPostByID(split.PostID); err != nil {
+				return err
+			}
+		}
+
+	case events.PostDeleted:
+		data := event.Data.(events.PostEvent)
+		s.removePost(data.PostID)
+
+	case events.SpaceDeleted:
+		data := event.Data.(events.SpaceEvent)
+		for _, postID := range data.AffectedPosts {
+			s.removePost(postID)
+		}
+	}
+
+	return nil
+}
+
+// Stats returns the vocabulary of a space, with its descendants when recursive, and the
+// trend of the last months months
+func (s *Service) Stats(ctx context.Context, spaceID int, recursive bool, months int) (*LanguageStats, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	spaceIDs := []int{spaceID}
+	if recursive {
+		spaceIDs = append(spaceIDs, s.catCache.GetDescendants(spaceID)...)
+	}
+	spaceIDs = s.access.Scope(ctx, spaceIDs)
+
+	stats := &LanguageStats{
+		SpaceID:   spaceID,
+		Recursive: recursive,
+		TopWords:  []WordCount{},
+		Months:    make([]MonthStats, 0, months),
+	}
+	posts := make(map[string]int)
+	words := make(map[string]int)
+	firstUse := make(map[string]string) // word -> first month written
+	occurrences := make(map[string]int)
+
+	s.mu.RLock()
+	for _, id := range spaceIDs {
+		vocab, ok := s.spaces[id]
+		if !ok {
+			continue
+		}
+		for month, count := range vocab.posts {
+			posts[month] += count
+			stats.Posts += count
+		}
+		for month, count := range vocab.words {
+			words[month] += count
+			stats.Words += count
+		}
+		for word, byMonth := range vocab.terms {
+			for month, count := range byMonth {
+				occurrences[word] += count
+				if first, ok := firstUse[word]; !ok || month < first {
+					firstUse[word] = month
+				}
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	stats.UniqueWords = len(firstUse)
+	stats.AveragePostLength = average(stats.Words, stats.Posts)
+
+	newWords := make(map[string]int)
+	for _, month := range firstUse {
+		newWords[month]++
+	}
+
+	current := s.now()
+	start := time.Date(current.Year(), current.Month()-time.Month(months-1), 1, 0, 0, 0, 0, current.Location())
+	for i := 0; i < months; i++ {
+		month := start.AddDate(0, i, 0).Format("2006-01")
+		stats.Months = append(stats.Months, MonthStats{
+			Month:             month,
+			Posts:             posts[month],
+			Words:             words[month],
+			NewWords:          newWords[month],
+			AveragePostLength: average(words[month], posts[month]),
+		})
+	}
+
+	for word, count := range occurrences {
+		stats.TopWords = append(stats.TopWords, WordCount{Word: word, Count: count})
+	}
+	sort.Slice(stats.TopWords, func(i, j int) bool {
+		if stats.TopWords[i].Count != stats.TopWords[j].Count {
+			return stats.TopWords[i].Count > stats.TopWords[j].Count
+		}
+		return stats.TopWords[i].Word < stats.TopWords[j].Word
+	})
+	if len(stats.TopWords) > config.LanguageTopWords {
+		stats.TopWords = stats.TopWords[:config.LanguageTopWords]
+	}
+
+	return stats, nil
+}
+
+// average is words per post, rounded to one decimal
+func average(words, posts int) float64 {
+	if posts == 0 {
+		return 0
+	}
+	return float64(words*10/posts) / 10
+}
+
+func (s *Service) countPostByID(postID int) error {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.countUnlocked(post.ID, post.SpaceID, post.Created, post.Content)
+	s.mu.Unlock()
+	return nil
+}
+
+// countUnlocked replaces the counted content of a post. Caller must hold s.mu.
+func (s *Service) countUnlocked(postID, spaceID int, created int64, content string) {
+	s.removeUnlocked(postID)
+
+	doc := &document{
+		spaceID: spaceID,
+		month:   time.Unix(created/1000, 0).Format("2006-01"),
+		words:   utils.CountWords(content),
+		terms:   utils.ContentTerms(content),
+	}
+	s.docs[postID] = doc
+
+	vocab, ok := s.spaces[spaceID]
+	if !ok {
+		vocab = newVocabulary()
+		s.spaces[spaceID] = vocab
+	}
+	vocab.posts[doc.month]++
+	vocab.words[doc.month] += doc.words
+	for term, count := range doc.terms {
+		byMonth, ok := vocab.terms[term]
+		if !ok {
+			byMonth = make(map[string]int)
+			vocab.terms[term] = byMonth
+		}
+		byMonth[doc.month] += count
+	}
+}
+
+func (s *Service) removePost(postID int) {
+	s.mu.Lock()
+	s.removeUnlocked(postID)
+	s.mu.Unlock()
+}
+
+// removeUnlocked takes a post out of the counts. Caller must hold s.mu.
+func (s *Service) removeUnlocked(postID int) {
+	doc, ok := s.docs[postID]
+	if !ok {
+		return
+	}
+	delete(s.docs, postID)
+
+	vocab := s.spaces[doc.spaceID]
+	decrement(vocab.posts, doc.month, 1)
+	decrement(vocab.words, doc.month, doc.words)
+	for term, count := range doc.terms {
+		byMonth := vocab.terms[term]
+		decrement(byMonth, doc.month, count)
+		if len(byMonth) == 0 {
+			delete(vocab.terms, term)
+		}
+	}
+	if len(vocab.posts) == 0 {
+		delete(s.spaces, doc.spaceID)
+	}
+}
+
+// decrement lowers counts[key] by delta, dropping keys that reach zero
+func decrement(counts map[string]int, key string, delta int) {
+	if counts[key] > delta {
+		counts[key] -= delta
+	} else {
+		delete(counts, key)
+	}
+}
@@ -0,0 +1,178 @@
+package languagestats
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func setupLanguageTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_language_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func millis(year int, month time.Month, day int) int64 {
+	return time.Date(year, month, day, 12, 0, 0, 0, time.Local).UnixMilli()
+}
+
+func TestLanguageStats(t *testing.T) {
+	db, cleanup := setupLanguageTestDB(t)
+	defer cleanup()
+
+	journal, _ := db.CreateSpace("Journal", nil, "")
+	dreams, _ := db.CreateSpace("Dreams", &journal.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(journal)
+	catCache.Set(dreams)
+
+	db.CreatePostWithTimestamp(context.Background(), journal.ID, "Morning run along the river", millis(2026, time.January, 5))
+	db.CreatePostWithTimestamp(context.Background(), journal.ID, "Evening run, then reading", millis(2026, time.January, 20))
+	db.CreatePostWithTimestamp(context.Background(), journal.ID, "Reading about river birds and the run", millis(2026, time.March, 2))
+	db.CreatePostWithTimestamp(context.Background(), dreams.ID, "Flying over the river", millis(2026, time.March, 9))
+
+	service := NewService(db, catCache, true)
+	service.now = func() time.Time { return time.Date(2026, time.March, 15, 0, 0, 0, 0, time.Local) }
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	stats, err := service.Stats(context.Background(), journal.ID, false, 3)
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	// Vocabulary without stop words: morning run along river evening reading birds
+	if stats.Posts != 3 || stats.Words != 16 || stats.UniqueWords != 7 || stats.AveragePostLength != 5.3 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	if len(stats.TopWords) == 0 || stats.TopWords[0] != (WordCount{Word: "run", Count: 3}) {
+		t.Errorf("Expected run to be the top word, got %v", stats.TopWords)
+	}
+
+	expected := []MonthStats{
+		{Month: "2026-01", Posts: 2, Words: 9, NewWords: 6, AveragePostLength: 4.5},
+		{Month: "2026-02"},
+		{Month: "2026-03", Posts: 1, Words: 7, NewWords: 1, AveragePostLength: 7},
+	}
+	if len(stats.Months) != len(expected) {
+		t.Fatalf("Expected %d months, got %+v", len(expected), stats.Months)
+	}
+	for i, month := range expected {
+		if stats.Months[i] != month {
+			t.Errorf("Month %d: expected %+v, got %+v", i, month, stats.Months[i])
+		}
+	}
+
+	// Descendants add their words, "river" is not new to the subtree
+	stats, _ = service.Stats(context.Background(), journal.ID, true, 1)
+	if stats.Posts != 4 || stats.UniqueWords != 9 || len(stats.Months) != 1 || stats.Months[0].NewWords != 3 {
+		t.Errorf("Unexpected recursive stats: %+v", stats)
+	}
+
+	if _, err := service.Stats(context.Background(), 9999, false, 12); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected space not found, got %v", err)
+	}
+}
+
+func TestLanguageStatsHidePrivateSpaces(t *testing.T) {
+	db, cleanup := setupLanguageTestDB(t)
+	defer cleanup()
+
+	journal, _ := db.CreateSpace("Journal", nil, "")
+	dreams, _ := db.CreateSpace("Dreams", &journal.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(journal)
+	catCache.Set(dreams)
+	db.CreatePostWithTimestamp(context.Background(), journal.ID, "Morning run along the river", millis(2026, time.March, 5))
+	db.CreatePostWithTimestamp(context.Background(), dreams.ID, "Flying over the river", millis(2026, time.March, 9))
+
+	bob, _ := db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	alice, _ := db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(db, catCache)
+	if err := access.SetACL(dreams.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+
+	service := NewService(db, catCache, true)
+	service.SetSpaceAccess(access)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	asBob := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+	if stats, _ := service.Stats(asBob, journal.ID, true, 1); stats.Posts != 1 {
+		t.Errorf("Expected the private post to be left out, got %+v", stats)
+	}
+	if _, err := service.Stats(asBob, dreams.ID, false, 1); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+	asAlice := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	if stats, _ := service.Stats(asAlice, journal.ID, true, 1); stats.Posts != 2 {
+		t.Errorf("Expected both posts for a listed viewer, got %+v", stats)
+	}
+}
+
+func TestLanguageStatsIncrementalUpdates(t *testing.T) {
+	db, cleanup := setupLanguageTestDB(t)
+	defer cleanup()
+
+	journal, _ := db.CreateSpace("Journal", nil, "")
+	other, _ := db.CreateSpace("Other", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(journal)
+	catCache.Set(other)
+
+	service := NewService(db, catCache, true)
+	service.Initialize()
+
+	post, _ := db.CreatePost(context.Background(), journal.ID, "Quiet morning")
+	service.HandleEvent(events.Event{Type: events.PostCreated, Data: events.PostEvent{PostID: post.ID, SpaceID: journal.ID}})
+
+	db.UpdatePostContent(post.ID, "Quiet morning with coffee and rain")
+	service.HandleEvent(events.Event{Type: events.PostUpdated, Data: events.PostEvent{PostID: post.ID, SpaceID: journal.ID}})
+
+	stats, _ := service.Stats(context.Background(), journal.ID, false, 1)
+	if stats.Posts != 1 || stats.Words != 6 || stats.UniqueWords != 4 {
+		t.Fatalf("Expected edited content to be counted once, got %+v", stats)
+	}
+
+	db.UpdatePostSpace(context.Background(), post.ID, other.ID)
+	service.HandleEvent(events.Event{Type: events.PostMoved, Data: events.PostEvent{PostID: post.ID, SpaceID: other.ID}})
+
+	if stats, _ := service.Stats(context.Background(), journal.ID, false, 1); stats.Posts != 0 || stats.UniqueWords != 0 {
+		t.Errorf("Expected moved post to leave its space, got %+v", stats)
+	}
+	if stats, _ := service.Stats(context.Background(), other.ID, false, 1); stats.Posts != 1 || stats.Months[0].NewWords != 4 {
+		t.Errorf("Expected moved post in its new space, got %+v", stats)
+	}
+
+	db.DeletePost(context.Background(), post.ID)
+	service.HandleEvent(events.Event{Type: events.PostDeleted, Data: events.PostEvent{PostID: post.ID, SpaceID: other.ID}})
+
+	if len(service.docs) != 0 || len(service.spaces) != 0 {
+		t.Errorf("Expected deletion to clear the counts, got %d docs and %d spaces", len(service.docs), len(service.spaces))
+	}
+}
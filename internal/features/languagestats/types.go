@@ -0,0 +1,29 @@
+package languagestats
+
+// WordCount is a word with the number of times it was written
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// MonthStats is the writing of one month: words are counted in posts created that month
+type MonthStats struct {
+	Month             string  `json:"month"` // YYYY-MM
+	Posts             int     `json:"posts"`
+	Words             int     `json:"words"`
+	NewWords          int     `json:"new_words"` // Words of the vocabulary first written that month
+	AveragePostLength float64 `json:"average_post_length"`
+}
+
+// LanguageStats describes the vocabulary of a space. Post lengths count every word, while the
+// vocabulary leaves out links, stop words and single characters.
+type LanguageStats struct {
+	SpaceID           int          `json:"space_id"`
+	Recursive         bool         `json:"recursive"`
+	Posts             int          `json:"posts"`
+	Words             int          `json:"words"`
+	UniqueWords       int          `json:"unique_words"`
+	AveragePostLength float64      `json:"average_post_length"` // Words per post
+	TopWords          []WordCount  `json:"top_words"`
+	Months            []MonthStats `json:"months"` // Oldest first, ending with the current month
+}
@@ -0,0 +1,73 @@
+package links
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/links", h.GetLinks).Methods("GET")
+}
+
+// GetLinks handles GET /api/links
+// Query parameters:
+// - space_id: only count posts of this space (default: all spaces)
+// - recursive: include descendant spaces of space_id (default: false)
+// - limit: number of links to return (default: 50, max: 500)
+func (h *Handler) GetLinks(w http.ResponseWriter, r *http.Request) {
+	var spaceID *int
+	if idStr := r.URL.Query().Get("space_id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+			return
+		}
+		spaceID = &id
+	}
+
+	recursive := r.URL.Query().Get("recursive") == "true"
+
+	limit := config.DefaultLinksLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > config.MaxLinksLimit {
+			http.Error(w, config.ErrInvalidLinksLimit, http.StatusBadRequest)
+			return
+		}
+	}
+
+	links, err := h.service.GetLinks(r.Context(), spaceID, recursive, limit)
+	if err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(LinksResponse{
+		SpaceID:   spaceID,
+		Recursive: recursive,
+		Links:     links,
+	})
+}
@@ -0,0 +1,64 @@
+package links
+
+import (
+	"backthynk/internal/core/cache"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/links", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected links routes NOT to be registered when disabled")
+	}
+}
+
+func TestLinkHandlers(t *testing.T) {
+	db, cleanup := setupLinksTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Reading", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	db.CreatePost(context.Background(), space.ID, "https://example.com/a")
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"List links", "/api/links", http.StatusOK},
+		{"List links of space", "/api/links?space_id=1&recursive=true&limit=5", http.StatusOK},
+		{"List links of unknown space", "/api/links?space_id=999", http.StatusNotFound},
+		{"List links invalid space", "/api/links?space_id=abc", http.StatusBadRequest},
+		{"List links invalid limit", "/api/links?limit=0", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
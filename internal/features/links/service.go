@@ -0,0 +1,140 @@
+package links
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"sort"
+)
+
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	enabled  bool
+	access   *services.SpaceAccess
+
+	// maxDuplicates is the number of earlier posts reported when a post is created, 0 reports none
+	maxDuplicates int
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		enabled:  enabled,
+	}
+}
+
+// SetMaxDuplicates sets how many earlier posts holding the links of a created post are
+// reported; 0 only indexes the links
+func (s *Service) SetMaxDuplicates(maxDuplicates int) {
+	s.maxDuplicates = maxDuplicates
+}
+
+// SetSpaceAccess leaves the posts of spaces hidden from a viewer out of its link counts
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// Initialize re-parses all posts so links written before the feature was enabled are indexed
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	return s.db.RebuildPostLinks(utils.ParseLinks)
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	// Deleted posts cascade their links. Created posts are indexed by IndexLinks already,
+	// restored ones are not.
+	switch event.Type {
+	case events.PostCreated, events.PostMerged, events.PostUpdated:
+		data := event.Data.(events.PostEvent)
+		return s.indexPost(data.PostID)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		if err := s.indexPost(data.PostID); err != nil {
+			return err
+		}
+		for _, split := range data.SplitPosts {
+			if err := s.indexPost(split.PostID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// IndexLinks records the links of a created post and returns the earlier posts already
+// holding them, newest first
+func (s *Service) IndexLinks(postID int, content string) ([]models.LinkReference, error) {
+	if !s.enabled {
+		return nil, nil
+	}
+
+	links := utils.ParseLinks(content)
+	if len(links) == 0 {
+		return nil, nil
+	}
+	if err := s.db.SetPostLinks(postID, links); err != nil {
+		return nil, err
+	}
+
+	if s.maxDuplicates <= 0 {
+		return nil, nil
+	}
+	return s.db.GetLinkReferences(links, postID, s.maxDuplicates)
+}
+
+// GetLinks returns the links referenced by the most posts, of every space or of a space and
+// optionally its descendants. Spaces hidden from the viewer of ctx are left out.
+func (s *Service) GetLinks(ctx context.Context, spaceID *int, recursive bool, limit int) ([]storage.LinkCount, error) {
+	hidden := s.access.Hidden(ctx)
+	if spaceID == nil {
+		return s.db.GetLinkCounts(nil, hiddenSpaces(hidden), limit)
+	}
+
+	if _, ok := s.catCache.Get(*spaceID); !ok || hidden[*spaceID] {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	spaceIDs := []int{*spaceID}
+	if recursive {
+		for _, id := range s.catCache.GetDescendants(*spaceID) {
+			if !hidden[id] {
+				spaceIDs = append(spaceIDs, id)
+			}
+		}
+	}
+	return s.db.GetLinkCounts(spaceIDs, nil, limit)
+}
+
+func (s *Service) indexPost(postID int) error {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.SetPostLinks(postID, utils.ParseLinks(post.Content))
+}
+
+func hiddenSpaces(hidden map[int]bool) []int {
+	ids := make([]int, 0, len(hidden))
+	for id := range hidden {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,121 @@
+package links
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"testing"
+)
+
+func setupLinksTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_links_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestDuplicateLinks(t *testing.T) {
+	db, cleanup := setupLinksTestDB(t)
+	defer cleanup()
+
+	catCache := cache.NewSpaceCache()
+	reading, _ := db.CreateSpace("Reading", nil, "")
+	catCache.Set(reading)
+	work, _ := db.CreateSpace("Work", nil, "")
+	catCache.Set(work)
+
+	// Written before the feature was enabled
+	first, _ := db.CreatePost(context.Background(), reading.ID, "Great read https://example.com/article")
+
+	service := NewService(db, catCache, true)
+	service.SetMaxDuplicates(config.DefaultMaxDuplicateLinks)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	postService := services.NewPostService(db, catCache, events.NewDispatcher())
+	postService.SetLinkIndex(service)
+
+	second, err := postService.Create(context.Background(), work.ID, "Saving https://EXAMPLE.com/article/?utm_source=feed again", nil)
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	if len(second.DuplicateLinks) != 1 || second.DuplicateLinks[0].PostID != first.ID ||
+		second.DuplicateLinks[0].URL != "https://example.com/article" || second.DuplicateLinks[0].SpaceID != reading.ID {
+		t.Fatalf("Expected the earlier post to be reported, got %+v", second.DuplicateLinks)
+	}
+
+	third, _ := postService.Create(context.Background(), work.ID, "Third time https://example.com/article and https://example.org", nil)
+	if len(third.DuplicateLinks) != 2 || third.DuplicateLinks[0].PostID != second.ID {
+		t.Errorf("Expected both earlier posts, newest first, got %+v", third.DuplicateLinks)
+	}
+
+	unrelated, _ := postService.Create(context.Background(), work.ID, "Nothing saved yet https://example.net", nil)
+	if len(unrelated.DuplicateLinks) != 0 {
+		t.Errorf("Expected no duplicates for a new link, got %+v", unrelated.DuplicateLinks)
+	}
+
+	t.Run("reporting can be turned off", func(t *testing.T) {
+		service.SetMaxDuplicates(0)
+		defer service.SetMaxDuplicates(config.DefaultMaxDuplicateLinks)
+
+		post, _ := postService.Create(context.Background(), work.ID, "Once more https://example.com/article", nil)
+		if len(post.DuplicateLinks) != 0 {
+			t.Errorf("Expected no duplicates reported, got %+v", post.DuplicateLinks)
+		}
+		counts, _ := service.GetLinks(context.Background(), nil, false, 10)
+		if len(counts) == 0 || counts[0].Count != 4 {
+			t.Errorf("Expected the link to be indexed anyway, got %+v", counts)
+		}
+		db.DeletePost(context.Background(), post.ID)
+	})
+
+	t.Run("most referenced links", func(t *testing.T) {
+		counts, err := service.GetLinks(context.Background(), nil, false, 10)
+		if err != nil {
+			t.Fatalf("GetLinks failed: %v", err)
+		}
+		if len(counts) != 3 || counts[0].URL != "https://example.com/article" || counts[0].Count != 3 {
+			t.Errorf("Expected the article first with 3 posts, got %+v", counts)
+		}
+
+		counts, _ = service.GetLinks(context.Background(), &reading.ID, false, 10)
+		if len(counts) != 1 || counts[0].Count != 1 {
+			t.Errorf("Expected one link in Reading, got %+v", counts)
+		}
+
+		missing := 999
+		if _, err := service.GetLinks(context.Background(), &missing, false, 10); err == nil {
+			t.Error("Expected an unknown space to fail")
+		}
+	})
+
+	t.Run("edited posts are re-indexed", func(t *testing.T) {
+		db.UpdatePostContent(first.ID, "Changed my mind")
+		service.HandleEvent(events.Event{Type: events.PostUpdated, Data: events.PostEvent{PostID: first.ID}})
+
+		counts, _ := service.GetLinks(context.Background(), &reading.ID, false, 10)
+		if len(counts) != 0 {
+			t.Errorf("Expected the edited post to hold no link, got %+v", counts)
+		}
+	})
+}
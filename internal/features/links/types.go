@@ -0,0 +1,9 @@
+package links
+
+import "backthynk/internal/storage"
+
+type LinksResponse struct {
+	SpaceID   *int                `json:"space_id,omitempty"`
+	Recursive bool                `json:"recursive"`
+	Links     []storage.LinkCount `json:"links"`
+}
@@ -0,0 +1,302 @@
+package livefeed
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+)
+
+type Handler struct {
+	service   *Service
+	keepAlive time.Duration
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service, keepAlive: config.LiveFeedKeepAliveInterval}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/events", h.StreamSpaceEvents).Methods("GET")
+	api.HandleFunc("/uploads/{session}/events", h.StreamUploadEvents).Methods("GET")
+	api.HandleFunc("/events", h.StreamEvents).Methods("GET")
+	api.HandleFunc("/ws", h.StreamSocket).Methods("GET")
+}
+
+// StreamSpaceEvents handles GET /api/spaces/{id}/events as a server-sent event stream.
+// Clients resume after a disconnect with the Last-Event-ID header (or the last_event_id
+// query parameter); buffered events newer than it are replayed first.
+func (h *Handler) StreamSpaceEvents(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	h.streamFeed(w, r, spaceID, nil)
+}
+
+// StreamEvents handles GET /api/events as a server-sent event stream, for clients that cannot
+// open the WebSocket of /api/ws. Resuming works as for StreamSpaceEvents.
+// Query parameters:
+// - space_id: space to follow with its descendants (default: every space)
+// - types: comma-separated event types to follow (default: every type)
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	spaceID, types, ok := feedFilter(w, r)
+	if !ok {
+		return
+	}
+
+	h.streamFeed(w, r, spaceID, types)
+}
+
+func (h *Handler) streamFeed(w http.ResponseWriter, r *http.Request, spaceID int, types map[string]bool) {
+	if !h.service.CanFollow(r.Context(), spaceID) {
+		http.Error(w, config.ErrSpaceNotFound, http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, config.ErrStreamingUnsupported, http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	lastID, _ := strconv.ParseInt(lastEventID, 10, 64)
+
+	replay, sub := h.service.SubscribeTypes(spaceID, types, lastID)
+	defer h.service.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, event := range replay {
+		if event, ok := h.visible(r.Context(), sub, event); ok {
+			writeEvent(w, event)
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, open := <-sub.Events:
+			if !open {
+				return
+			}
+			if event, ok := h.visible(r.Context(), sub, event); ok {
+				writeEvent(w, event)
+				flusher.Flush()
+			}
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// visible returns event as the viewer of ctx sees it, false when it is hidden or of a type
+// sub does not follow
+func (h *Handler) visible(ctx context.Context, sub *Subscription, event FeedEvent) (FeedEvent, bool) {
+	event, ok := h.service.Visible(ctx, event)
+	return event, ok && sub.Wants(event.Type)
+}
+
+// feedFilter reads the space_id and types query parameters, answering 400 when they are invalid
+func feedFilter(w http.ResponseWriter, r *http.Request) (int, map[string]bool, bool) {
+	spaceID := 0
+	if idStr := r.URL.Query().Get("space_id"); idStr != "" {
+		var err error
+		spaceID, err = strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+			return 0, nil, false
+		}
+	}
+
+	types, err := ParseEventTypes(r.URL.Query().Get("types"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return 0, nil, false
+	}
+	return spaceID, types, true
+}
+
+// StreamUploadEvents handles GET /api/uploads/{session}/events as a server-sent event stream
+// of the progress of an upload sent with the same X-Upload-Session ID. The latest known state
+// is sent first; the stream ends once the upload is done or failed.
+func (h *Handler) StreamUploadEvents(w http.ResponseWriter, r *http.Request) {
+	sessionID := mux.Vars(r)["session"]
+	if !services.ValidUploadSession(sessionID) {
+		http.Error(w, config.ErrInvalidUploadSession, http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, config.ErrStreamingUnsupported, http.StatusInternalServerError)
+		return
+	}
+
+	last, sub := h.service.SubscribeUpload(sessionID)
+	defer h.service.UnsubscribeUpload(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if last != nil {
+		writeUploadEvent(w, *last)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, open := <-sub.Events:
+			if !open {
+				return
+			}
+			writeUploadEvent(w, event)
+			flusher.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// StreamSocket handles GET /api/ws as a WebSocket sending the events of a space and its
+// descendants as JSON messages, so clients update post lists and activity without polling.
+// Query parameters:
+// - space_id: space to follow (default: every space)
+// - types: comma-separated event types to follow (default: every type)
+// - last_event_id: buffered events newer than it are replayed first, to resume after a disconnect
+// The client is disconnected when it falls too far behind and should reconnect; messages it
+// sends are ignored.
+func (h *Handler) StreamSocket(w http.ResponseWriter, r *http.Request) {
+	spaceID, types, ok := feedFilter(w, r)
+	if !ok {
+		return
+	}
+
+	if !h.service.CanFollow(r.Context(), spaceID) {
+		http.Error(w, config.ErrSpaceNotFound, http.StatusNotFound)
+		return
+	}
+
+	lastID, _ := strconv.ParseInt(r.URL.Query().Get("last_event_id"), 10, 64)
+
+	server := websocket.Server{
+		Handshake: sameOrigin,
+		Handler: func(ws *websocket.Conn) {
+			h.serveSocket(r.Context(), ws, spaceID, types, lastID)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+func (h *Handler) serveSocket(ctx context.Context, ws *websocket.Conn, spaceID int, types map[string]bool, lastID int64) {
+	replay, sub := h.service.SubscribeTypes(spaceID, types, lastID)
+	defer h.service.Unsubscribe(sub)
+
+	// Reading answers the pings of the client and notices when it goes away
+	ws.MaxPayloadBytes = config.LiveFeedMaxMessageBytes
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		var message []byte
+		for websocket.Message.Receive(ws, &message) == nil {
+		}
+	}()
+
+	send := func(event FeedEvent) bool {
+		event, ok := h.visible(ctx, sub, event)
+		if !ok {
+			return true
+		}
+		ws.SetWriteDeadline(time.Now().Add(config.LiveFeedWriteTimeout))
+		return websocket.JSON.Send(ws, event) == nil
+	}
+
+	for _, event := range replay {
+		if !send(event) {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(h.keepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, open := <-sub.Events:
+			if !open || !send(event) {
+				return
+			}
+		case <-keepAlive.C:
+			ws.SetWriteDeadline(time.Now().Add(config.LiveFeedWriteTimeout))
+			ws.PayloadType = websocket.PingFrame
+			_, err := ws.Write(nil)
+			ws.PayloadType = websocket.TextFrame
+			if err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+// sameOrigin refuses WebSocket connections opened by pages of other sites, which the browser
+// would send the session cookie of the user. Clients other than browsers send no Origin.
+func sameOrigin(_ *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || !strings.EqualFold(u.Host, r.Host) {
+		return fmt.Errorf(config.ErrCrossOriginSocket)
+	}
+	return nil
+}
+
+func writeEvent(w http.ResponseWriter, event FeedEvent) {
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
+
+func writeUploadEvent(w http.ResponseWriter, event UploadEvent) {
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+}
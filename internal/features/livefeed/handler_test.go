@@ -0,0 +1,257 @@
+package livefeed
+
+import (
+	"backthynk/internal/core/events"
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	router := mux.NewRouter()
+	NewHandler(NewService(newTestCache(), false)).RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/spaces/1/events", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected events route NOT to be registered when disabled")
+	}
+}
+
+func TestStreamSpaceEventsUnknownSpace(t *testing.T) {
+	router := mux.NewRouter()
+	NewHandler(NewService(newTestCache(), true)).RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/spaces/999/events", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestStreamSpaceEvents(t *testing.T) {
+	service := NewService(newTestCache(), true)
+	service.HandleEvent(postCreated(1, 1))
+	service.HandleEvent(postCreated(2, 2))
+
+	router := mux.NewRouter()
+	handler := NewHandler(service)
+	handler.keepAlive = 20 * time.Millisecond
+	handler.RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL+"/api/spaces/1/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected event stream, got %s", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Failed to read stream: %v", err)
+			}
+			line = strings.TrimRight(line, "\n")
+			if line == "" {
+				if len(lines) == 0 {
+					continue
+				}
+				return strings.Join(lines, "\n")
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	// Event 2 is replayed from the buffer
+	if event := readEvent(); !strings.HasPrefix(event, "id: 2\nevent: post.created\ndata: {") {
+		t.Errorf("Unexpected replayed event: %q", event)
+	}
+
+	// Keep-alive comments flow while idle, then live events are delivered
+	if event := readEvent(); event != ": keep-alive" {
+		t.Errorf("Expected keep-alive comment, got %q", event)
+	}
+
+	service.HandleEvent(postCreated(3, 3))
+	for {
+		event := readEvent()
+		if event == ": keep-alive" {
+			continue
+		}
+		if !strings.HasPrefix(event, "id: 3\nevent: post.created\n") || !strings.Contains(event, `"post_id":3`) {
+			t.Errorf("Unexpected live event: %q", event)
+		}
+		break
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	service := NewService(newTestCache(), true)
+	router := mux.NewRouter()
+	handler := NewHandler(service)
+	handler.keepAlive = time.Hour
+	handler.RegisterRoutes(router)
+
+	for path, status := range map[string]int{
+		"/api/events?types=bogus":  http.StatusBadRequest,
+		"/api/events?space_id=abc": http.StatusBadRequest,
+		"/api/events?space_id=999": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != status {
+			t.Errorf("%s: expected status %d, got %d", path, status, w.Code)
+		}
+	}
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events?space_id=1&types=post.deleted")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Wait for the stream to be open before publishing
+	for {
+		service.mu.Lock()
+		open := len(service.subscribers) > 0
+		service.mu.Unlock()
+		if open {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	service.HandleEvent(postCreated(2, 1))
+	service.HandleEvent(postCreated(4, 2))
+	service.HandleEvent(events.Event{Type: events.PostDeleted, Data: events.PostEvent{SpaceID: 3, PostID: 1}})
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil || line != "id: 3\n" {
+		t.Fatalf("Expected only the deletion in the followed subtree, got %q (%v)", line, err)
+	}
+	if line, _ = reader.ReadString('\n'); line != "event: post.deleted\n" {
+		t.Errorf("Unexpected event line %q", line)
+	}
+}
+
+func TestStreamUploadEvents(t *testing.T) {
+	service := NewService(newTestCache(), true)
+	router := mux.NewRouter()
+	handler := NewHandler(service)
+	handler.keepAlive = time.Hour
+	handler.RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/uploads/bad!/events", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid session, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/uploads/upload-1234/events")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Wait for the stream to be open before reporting progress
+	reader := bufio.NewReader(resp.Body)
+	for {
+		service.mu.Lock()
+		open := len(service.uploads) > 0
+		service.mu.Unlock()
+		if open {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	service.HandleEvent(uploadProgress("upload-1234", events.UploadStageProcessing, 100, 1))
+	service.HandleEvent(uploadProgress("upload-1234", events.UploadStageDone, 100, 2))
+
+	// The stream ends with the upload
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read stream: %v", err)
+	}
+	stream := string(body)
+	if !strings.HasPrefix(stream, "event: upload.progress\ndata: {") ||
+		!strings.Contains(stream, `"stage":"processing"`) || !strings.Contains(stream, `"stage":"done"`) {
+		t.Errorf("Unexpected upload stream: %q", stream)
+	}
+}
+
+func TestStreamSocket(t *testing.T) {
+	service := NewService(newTestCache(), true)
+	service.HandleEvent(postCreated(1, 1))
+	service.HandleEvent(postCreated(2, 2))
+
+	router := mux.NewRouter()
+	handler := NewHandler(service)
+	handler.keepAlive = 20 * time.Millisecond
+	handler.RegisterRoutes(router)
+
+	for path, status := range map[string]int{
+		"/api/ws?space_id=abc": http.StatusBadRequest,
+		"/api/ws?space_id=999": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != status {
+			t.Errorf("%s: expected status %d, got %d", path, status, w.Code)
+		}
+	}
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+	socketURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws?space_id=1&last_event_id=1"
+
+	if _, err := websocket.Dial(socketURL, "", "https://elsewhere.example"); err == nil {
+		t.Error("Expected a connection from another site to be refused")
+	}
+
+	ws, err := websocket.Dial(socketURL, "", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// Event 2 is replayed from the buffer
+	var event FeedEvent
+	if err := websocket.JSON.Receive(ws, &event); err != nil || event.ID != 2 || event.Type != EventPostCreated {
+		t.Fatalf("Unexpected replayed event %+v: %v", event, err)
+	}
+
+	// Live events are delivered past the keep-alive pings
+	time.Sleep(50 * time.Millisecond)
+	oldSpaceID := 4
+	service.HandleEvent(events.Event{Type: events.PostMoved, Data: events.PostEvent{PostID: 5, SpaceID: 3, OldSpaceID: &oldSpaceID}})
+	event = FeedEvent{}
+	if err := websocket.JSON.Receive(ws, &event); err != nil {
+		t.Fatalf("Failed to receive live event: %v", err)
+	}
+	if event != (FeedEvent{ID: 3, Type: EventPostMoved, SpaceID: 3, PostID: 5, OldSpaceID: 4}) {
+		t.Errorf("Unexpected live event: %+v", event)
+	}
+}
@@ -0,0 +1,379 @@
+package livefeed
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/services"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// subscriberBuffer is how many events may be pending for a client before it is disconnected
+const subscriberBuffer = 32
+
+// Subscription receives the events of a followed space subtree.
+// Events is closed when the client falls too far behind; it should reconnect with its last event ID.
+type Subscription struct {
+	Events  <-chan FeedEvent
+	spaceID int
+	types   map[string]bool // nil follows every type
+	events  chan FeedEvent
+}
+
+// Wants reports whether the client follows events of a type
+func (sub *Subscription) Wants(eventType string) bool {
+	return sub.types == nil || sub.types[eventType]
+}
+
+// receives reports whether an event published to the spaces of chain is delivered to sub.
+// Moves are delivered to clients following deletions, as Visible may turn them into one.
+func (sub *Subscription) receives(eventType string, chain []int) bool {
+	if !sub.Wants(eventType) && !(eventType == EventPostMoved && sub.Wants(EventPostDeleted)) {
+		return false
+	}
+	return containsSpace(chain, sub.spaceID)
+}
+
+// ParseEventTypes reads a comma-separated list of event types to follow; an empty list
+// follows every type and returns nil
+func ParseEventTypes(list string) (map[string]bool, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	types := make(map[string]bool)
+	for _, eventType := range strings.Split(list, ",") {
+		eventType = strings.TrimSpace(eventType)
+		if !feedEventTypes[eventType] {
+			return nil, fmt.Errorf(config.ErrInvalidFeedEventType)
+		}
+		types[eventType] = true
+	}
+	return types, nil
+}
+
+// UploadSubscription receives the events of one upload session.
+// Events is closed once the upload finished, or when the client falls too far behind.
+type UploadSubscription struct {
+	Events    <-chan UploadEvent
+	sessionID string
+	events    chan UploadEvent
+}
+
+// bufferedEvent is a recent event with the spaces whose followers received it
+type bufferedEvent struct {
+	event  FeedEvent
+	spaces []int
+}
+
+// uploadSession holds the latest state of a session and the clients following it
+type uploadSession struct {
+	last     *UploadEvent
+	watchers map[*UploadSubscription]struct{}
+}
+
+type Service struct {
+	catCache    *cache.SpaceCache
+	enabled     bool
+	access      *services.SpaceAccess
+	bufferSize  int
+	mu          sync.Mutex
+	nextID      int64
+	recent      []bufferedEvent
+	subscribers map[*Subscription]struct{}
+	uploads     map[string]*uploadSession
+	finished    []string // Finished session IDs, oldest first
+}
+
+func NewService(catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		catCache:    catCache,
+		enabled:     enabled,
+		bufferSize:  config.LiveFeedBufferSize,
+		subscribers: make(map[*Subscription]struct{}),
+		uploads:     make(map[string]*uploadSession),
+	}
+}
+
+// SetSpaceAccess keeps the events of spaces hidden from a client out of its streams
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	switch event.Type {
+	case events.PostCreated:
+		data := event.Data.(events.PostEvent)
+		s.publish(FeedEvent{Seq: event.Seq, Type: EventPostCreated, SpaceID: data.SpaceID, PostID: data.PostID, Timestamp: data.Timestamp})
+
+	case events.PostUpdated:
+		data := event.Data.(events.PostEvent)
+		s.publish(FeedEvent{Seq: event.Seq, Type: EventPostUpdated, SpaceID: data.SpaceID, PostID: data.PostID, Timestamp: data.Timestamp})
+
+	case events.PostDeleted:
+		data := event.Data.(events.PostEvent)
+		s.publish(FeedEvent{Seq: event.Seq, Type: EventPostDeleted, SpaceID: data.SpaceID, PostID: data.PostID, Timestamp: data.Timestamp})
+
+	case events.PostMoved:
+		// Followers of the space the post left are told as well
+		data := event.Data.(events.PostEvent)
+		feedEvent := FeedEvent{Seq: event.Seq, Type: EventPostMoved, SpaceID: data.SpaceID, PostID: data.PostID, Timestamp: data.Timestamp}
+		if data.OldSpaceID == nil {
+			s.publish(feedEvent)
+			break
+		}
+		feedEvent.OldSpaceID = *data.OldSpaceID
+		s.publish(feedEvent, *data.OldSpaceID)
+
+	case events.PostPinned:
+		data := event.Data.(events.PostEvent)
+		s.publish(FeedEvent{Seq: event.Seq, Type: EventPostPinned, SpaceID: data.SpaceID, PostID: data.PostID, Timestamp: data.Timestamp, Pinned: data.Pinned})
+
+	case events.SpaceUpdated:
+		// A space moved to another parent is also announced under its former one
+		data := event.Data.(events.SpaceEvent)
+		feedEvent := FeedEvent{Seq: event.Seq, Type: EventSpaceUpdated, SpaceID: data.SpaceID}
+		if data.OldParentID == nil {
+			s.publish(feedEvent)
+			break
+		}
+		s.publish(feedEvent, *data.OldParentID)
+
+	case events.PostSplit:
+		// Parts split off a post are new posts of the same space
+		data := event.Data.(events.PostEvent)
+		for _, part := range data.SplitPosts {
+			s.publish(FeedEvent{Seq: event.Seq, Type: EventPostCreated, SpaceID: data.SpaceID, PostID: part.PostID, Timestamp: part.Timestamp})
+		}
+
+	case events.FileUploaded:
+		data := event.Data.(events.PostEvent)
+		s.publish(FeedEvent{Seq: event.Seq, Type: EventFileUploaded, SpaceID: data.SpaceID, PostID: data.PostID, FileSize: data.FileSize})
+
+	case events.UploadProgress:
+		data := event.Data.(events.UploadProgressEvent)
+		s.publishUpload(UploadEvent{
+			Type:          EventUploadProgress,
+			SessionID:     data.SessionID,
+			Stage:         data.Stage,
+			BytesReceived: data.BytesReceived,
+			TotalBytes:    data.TotalBytes,
+			PostID:        data.PostID,
+			AttachmentID:  data.AttachmentID,
+			Filename:      data.Filename,
+			Error:         data.Error,
+			Sequence:      data.Sequence,
+		})
+	}
+
+	return nil
+}
+
+// publish delivers event to the followers of its space, of the also spaces, and of their
+// ancestors
+func (s *Service) publish(event FeedEvent, also ...int) {
+	// Ancestors are resolved once; followers then only need to be found in the chain
+	chain := []int{0}
+	for _, spaceID := range append([]int{event.SpaceID}, also...) {
+		chain = append(chain, spaceID)
+		chain = append(chain, s.catCache.GetAncestors(spaceID)...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	event.ID = s.nextID
+	s.recent = append(s.recent, bufferedEvent{event: event, spaces: chain})
+	if len(s.recent) > s.bufferSize {
+		s.recent = s.recent[len(s.recent)-s.bufferSize:]
+	}
+
+	for sub := range s.subscribers {
+		if !sub.receives(event.Type, chain) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Too slow: drop the client, it resumes from its last event ID on reconnect
+			close(sub.events)
+			delete(s.subscribers, sub)
+		}
+	}
+}
+
+// Subscribe follows a space and its descendants (every space when spaceID is 0).
+// Buffered events newer than lastEventID are returned for replay; both steps happen
+// atomically so no event is missed between replay and live delivery.
+func (s *Service) Subscribe(spaceID int, lastEventID int64) ([]FeedEvent, *Subscription) {
+	return s.SubscribeTypes(spaceID, nil, lastEventID)
+}
+
+// SubscribeTypes follows the events of the given types of a space and its descendants,
+// see Subscribe. nil types follows every type.
+func (s *Service) SubscribeTypes(spaceID int, types map[string]bool, lastEventID int64) ([]FeedEvent, *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan FeedEvent, subscriberBuffer)
+	sub := &Subscription{Events: ch, spaceID: spaceID, types: types, events: ch}
+
+	var replay []FeedEvent
+	if lastEventID > 0 {
+		for _, buffered := range s.recent {
+			if buffered.event.ID > lastEventID && sub.receives(buffered.event.Type, buffered.spaces) {
+				replay = append(replay, buffered.event)
+			}
+		}
+	}
+
+	s.subscribers[sub] = struct{}{}
+
+	return replay, sub
+}
+
+// Unsubscribe stops delivering events to sub
+func (s *Service) Unsubscribe(sub *Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[sub]; ok {
+		close(sub.events)
+		delete(s.subscribers, sub)
+	}
+}
+
+func (s *Service) publishUpload(event UploadEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.uploads[event.SessionID]
+	if !ok {
+		session = &uploadSession{watchers: make(map[*UploadSubscription]struct{})}
+		s.uploads[event.SessionID] = session
+	}
+	// Events are dispatched asynchronously; an older state never replaces a newer one
+	if session.last != nil && event.Sequence <= session.last.Sequence {
+		return
+	}
+	session.last = &event
+
+	for sub := range session.watchers {
+		select {
+		case sub.events <- event:
+			if !event.Finished() {
+				continue
+			}
+		default:
+			// Too slow: the client gets the latest state again when it reconnects
+		}
+		close(sub.events)
+		delete(session.watchers, sub)
+	}
+
+	if !event.Finished() {
+		return
+	}
+	// The outcome is kept for clients that connect late, up to a bounded number of sessions
+	s.finished = append(s.finished, event.SessionID)
+	for len(s.finished) > config.MaxTrackedUploadSessions {
+		oldest := s.finished[0]
+		s.finished = s.finished[1:]
+		if old, ok := s.uploads[oldest]; ok && old.last != nil && old.last.Finished() && len(old.watchers) == 0 {
+			delete(s.uploads, oldest)
+		}
+	}
+}
+
+// SubscribeUpload follows an upload session, which may not have started yet.
+// The latest known state of the session is returned, nil when there is none.
+func (s *Service) SubscribeUpload(sessionID string) (*UploadEvent, *UploadSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.uploads[sessionID]
+	if !ok {
+		session = &uploadSession{watchers: make(map[*UploadSubscription]struct{})}
+		s.uploads[sessionID] = session
+	}
+
+	var last *UploadEvent
+	if session.last != nil {
+		state := *session.last
+		last = &state
+	}
+
+	ch := make(chan UploadEvent, subscriberBuffer)
+	sub := &UploadSubscription{Events: ch, sessionID: sessionID, events: ch}
+	if last == nil || !last.Finished() {
+		session.watchers[sub] = struct{}{}
+	} else {
+		close(ch)
+	}
+
+	return last, sub
+}
+
+// UnsubscribeUpload stops delivering events to sub
+func (s *Service) UnsubscribeUpload(sub *UploadSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.uploads[sub.sessionID]
+	if !ok {
+		return
+	}
+	if _, ok := session.watchers[sub]; ok {
+		close(sub.events)
+		delete(session.watchers, sub)
+	}
+	// Sessions nobody reported on are forgotten with their last client
+	if session.last == nil && len(session.watchers) == 0 {
+		delete(s.uploads, sub.sessionID)
+	}
+}
+
+// CanFollow reports whether the viewer of ctx may follow a space; 0 follows every space
+func (s *Service) CanFollow(ctx context.Context, spaceID int) bool {
+	if spaceID == 0 {
+		return true
+	}
+	_, ok := s.catCache.Get(spaceID)
+	return ok && s.access.CanRead(ctx, spaceID)
+}
+
+// Visible returns event as the viewer of ctx may see it, false when its space is hidden. A
+// post moved out of a hidden space comes without it; one moved into a hidden space is seen
+// as deleted.
+func (s *Service) Visible(ctx context.Context, event FeedEvent) (FeedEvent, bool) {
+	oldVisible := event.OldSpaceID != 0 && s.access.CanRead(ctx, event.OldSpaceID)
+	if !s.access.CanRead(ctx, event.SpaceID) {
+		if !oldVisible {
+			return event, false
+		}
+		event.Type = EventPostDeleted
+		event.SpaceID = event.OldSpaceID
+		oldVisible = false
+	}
+	if !oldVisible {
+		event.OldSpaceID = 0
+	}
+	return event, true
+}
+
+func containsSpace(ids []int, spaceID int) bool {
+	for _, id := range ids {
+		if id == spaceID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,280 @@
+package livefeed
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"fmt"
+	"testing"
+)
+
+func newTestCache() *cache.SpaceCache {
+	parentID, childID := 1, 2
+	catCache := cache.NewSpaceCache()
+	catCache.Set(&models.Space{ID: parentID, Name: "Parent"})
+	catCache.Set(&models.Space{ID: childID, Name: "Child", ParentID: &parentID, Depth: 1})
+	catCache.Set(&models.Space{ID: 3, Name: "Grandchild", ParentID: &childID, Depth: 2})
+	catCache.Set(&models.Space{ID: 4, Name: "Other"})
+	return catCache
+}
+
+func postCreated(spaceID, postID int) events.Event {
+	return events.Event{Type: events.PostCreated, Data: events.PostEvent{SpaceID: spaceID, PostID: postID, Timestamp: 1000}}
+}
+
+func TestSubscriptionFollowsSubtree(t *testing.T) {
+	service := NewService(newTestCache(), true)
+	_, child := service.Subscribe(2, 0)
+	_, all := service.Subscribe(0, 0)
+	defer service.Unsubscribe(child)
+	defer service.Unsubscribe(all)
+
+	service.HandleEvent(postCreated(1, 10)) // parent: outside the child subtree
+	service.HandleEvent(postCreated(3, 11)) // grandchild: inside
+	service.HandleEvent(events.Event{Type: events.FileUploaded, Data: events.PostEvent{SpaceID: 2, PostID: 11, FileSize: 42}})
+	service.HandleEvent(events.Event{Type: events.PostSplit, Data: events.PostEvent{
+		SpaceID: 2, PostID: 11, SplitPosts: []events.SplitPost{{PostID: 12}, {PostID: 13}},
+	}})
+
+	expected := []FeedEvent{
+		{ID: 2, Type: EventPostCreated, SpaceID: 3, PostID: 11, Timestamp: 1000},
+		{ID: 3, Type: EventFileUploaded, SpaceID: 2, PostID: 11, FileSize: 42},
+		{ID: 4, Type: EventPostCreated, SpaceID: 2, PostID: 12},
+		{ID: 5, Type: EventPostCreated, SpaceID: 2, PostID: 13},
+	}
+	if len(child.Events) != len(expected) {
+		t.Fatalf("Expected %d events for the child subtree, got %d", len(expected), len(child.Events))
+	}
+	for i, want := range expected {
+		if got := <-child.Events; got != want {
+			t.Errorf("Event %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+
+	if len(all.Events) != 5 {
+		t.Errorf("Expected every event when following all spaces, got %d", len(all.Events))
+	}
+}
+
+func TestSubscribeReplaysMissedEvents(t *testing.T) {
+	service := NewService(newTestCache(), true)
+	service.bufferSize = 3
+
+	for i := 1; i <= 5; i++ {
+		service.HandleEvent(postCreated(1, i))
+	}
+	service.HandleEvent(postCreated(4, 6))
+
+	tests := []struct {
+		name        string
+		spaceID     int
+		lastEventID int64
+		expectedIDs []int64
+	}{
+		{"New client gets no replay", 1, 0, nil},
+		{"Resume after event 4", 1, 4, []int64{5}},
+		{"Older events fell out of the buffer", 1, 1, []int64{4, 5}},
+		{"Replay is filtered by space", 4, 1, []int64{6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay, sub := service.Subscribe(tt.spaceID, tt.lastEventID)
+			defer service.Unsubscribe(sub)
+
+			if len(replay) != len(tt.expectedIDs) {
+				t.Fatalf("Expected %d replayed events, got %+v", len(tt.expectedIDs), replay)
+			}
+			for i, id := range tt.expectedIDs {
+				if replay[i].ID != id {
+					t.Errorf("Replay %d: expected ID %d, got %d", i, id, replay[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestMovesReachBothSubtrees(t *testing.T) {
+	service := NewService(newTestCache(), true)
+	service.HandleEvent(postCreated(4, 9))
+
+	_, parent := service.Subscribe(1, 0)
+	_, other := service.Subscribe(4, 0)
+	defer service.Unsubscribe(parent)
+	defer service.Unsubscribe(other)
+
+	oldSpaceID, oldParentID := 3, 1
+	service.HandleEvent(events.Event{Type: events.PostMoved, Data: events.PostEvent{PostID: 10, SpaceID: 4, OldSpaceID: &oldSpaceID, Timestamp: 1000}})
+	service.HandleEvent(events.Event{Type: events.SpaceUpdated, Data: events.SpaceEvent{SpaceID: 4, OldParentID: &oldParentID}})
+	service.HandleEvent(events.Event{Type: events.PostDeleted, Data: events.PostEvent{PostID: 11, SpaceID: 4, Timestamp: 2000}})
+
+	moved := FeedEvent{ID: 2, Type: EventPostMoved, SpaceID: 4, PostID: 10, OldSpaceID: 3, Timestamp: 1000}
+	updated := FeedEvent{ID: 3, Type: EventSpaceUpdated, SpaceID: 4}
+	deleted := FeedEvent{ID: 4, Type: EventPostDeleted, SpaceID: 4, PostID: 11, Timestamp: 2000}
+	tests := []struct {
+		name     string
+		sub      *Subscription
+		expected []FeedEvent
+	}{
+		{"Former spaces hear of moves", parent, []FeedEvent{moved, updated}},
+		{"New spaces hear of everything", other, []FeedEvent{moved, updated, deleted}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.sub.Events) != len(tt.expected) {
+				t.Fatalf("Expected %d events, got %d", len(tt.expected), len(tt.sub.Events))
+			}
+			for i, want := range tt.expected {
+				if got := <-tt.sub.Events; got != want {
+					t.Errorf("Event %d: expected %+v, got %+v", i, want, got)
+				}
+			}
+		})
+	}
+
+	// Replay reaches the same spaces as live delivery
+	replay, sub := service.Subscribe(3, 1)
+	defer service.Unsubscribe(sub)
+	if len(replay) != 1 || replay[0] != moved {
+		t.Errorf("Expected the move to be replayed to its former space, got %+v", replay)
+	}
+}
+
+func TestSubscribeTypes(t *testing.T) {
+	if _, err := ParseEventTypes("post.created, bogus"); err == nil || err.Error() != config.ErrInvalidFeedEventType {
+		t.Errorf("Expected unknown types to be refused, got %v", err)
+	}
+	if _, err := ParseEventTypes(EventUploadProgress); err == nil {
+		t.Error("Expected upload progress not to be followed through the feed")
+	}
+	types, err := ParseEventTypes("post.deleted,space.updated")
+	if err != nil || len(types) != 2 {
+		t.Fatalf("Expected 2 types, got %v (%v)", types, err)
+	}
+
+	service := NewService(newTestCache(), true)
+	_, sub := service.SubscribeTypes(0, types, 0)
+	defer service.Unsubscribe(sub)
+
+	oldSpaceID := 1
+	service.HandleEvent(postCreated(1, 10))
+	service.HandleEvent(events.Event{Type: events.PostDeleted, Data: events.PostEvent{SpaceID: 1, PostID: 10}})
+	service.HandleEvent(events.Event{Type: events.PostMoved, Data: events.PostEvent{SpaceID: 4, PostID: 11, OldSpaceID: &oldSpaceID}})
+
+	// Moves reach clients following deletions, as they may be seen as one
+	if len(sub.Events) != 2 {
+		t.Fatalf("Expected the deletion and the move, got %d events", len(sub.Events))
+	}
+	if event := <-sub.Events; event.Type != EventPostDeleted || !sub.Wants(event.Type) {
+		t.Errorf("Expected the deletion first, got %+v", event)
+	}
+	if event := <-sub.Events; event.Type != EventPostMoved || sub.Wants(event.Type) {
+		t.Errorf("Expected the move to be left to the caller, got %+v", event)
+	}
+
+	replay, filtered := service.SubscribeTypes(1, map[string]bool{EventPostCreated: true}, 1)
+	defer service.Unsubscribe(filtered)
+	if len(replay) != 0 {
+		t.Errorf("Expected no created post to replay after event 1, got %+v", replay)
+	}
+}
+
+func TestEventsCarryChangeSeq(t *testing.T) {
+	service := NewService(newTestCache(), true)
+	_, sub := service.Subscribe(0, 0)
+	defer service.Unsubscribe(sub)
+
+	// Every part of a split shares the change of the split
+	service.HandleEvent(events.Event{Type: events.PostSplit, Seq: 7, Data: events.PostEvent{
+		SpaceID: 1, PostID: 10, SplitPosts: []events.SplitPost{{PostID: 11}, {PostID: 12}},
+	}})
+	for i := 0; i < 2; i++ {
+		if event := <-sub.Events; event.Seq != 7 || event.ID != int64(i+1) {
+			t.Errorf("Expected event %d of change 7, got %+v", i+1, event)
+		}
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	service := NewService(newTestCache(), true)
+	_, sub := service.Subscribe(1, 0)
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		service.HandleEvent(postCreated(1, i))
+	}
+
+	for range sub.Events {
+		// Drain until the channel is closed
+	}
+	if len(service.subscribers) != 0 {
+		t.Error("Expected slow subscriber to be removed")
+	}
+
+	// Unsubscribing a dropped subscriber is harmless
+	service.Unsubscribe(sub)
+}
+
+func uploadProgress(sessionID, stage string, received, sequence int64) events.Event {
+	return events.Event{Type: events.UploadProgress, Data: events.UploadProgressEvent{
+		SessionID: sessionID, Stage: stage, BytesReceived: received, TotalBytes: 100, Sequence: sequence,
+	}}
+}
+
+func TestUploadSubscriptionFollowsSession(t *testing.T) {
+	service := NewService(newTestCache(), true)
+	last, sub := service.SubscribeUpload("session-a")
+	if last != nil {
+		t.Fatalf("Expected no state before the upload started, got %+v", last)
+	}
+
+	service.HandleEvent(uploadProgress("session-a", events.UploadStageReceiving, 40, 1))
+	service.HandleEvent(uploadProgress("session-b", events.UploadStageReceiving, 10, 2))
+	service.HandleEvent(uploadProgress("session-a", events.UploadStageDone, 100, 4))
+	// Dispatched out of order: older than the outcome, so ignored
+	service.HandleEvent(uploadProgress("session-a", events.UploadStageReceiving, 80, 3))
+
+	var stages []string
+	for event := range sub.Events {
+		if event.SessionID != "session-a" || event.Type != EventUploadProgress {
+			t.Errorf("Unexpected event %+v", event)
+		}
+		stages = append(stages, event.Stage)
+	}
+	if len(stages) != 2 || stages[0] != events.UploadStageReceiving || stages[1] != events.UploadStageDone {
+		t.Errorf("Expected receiving then done, got %v", stages)
+	}
+	service.UnsubscribeUpload(sub)
+
+	// A client connecting late gets the outcome and an already closed stream
+	last, late := service.SubscribeUpload("session-a")
+	if last == nil || last.Stage != events.UploadStageDone || last.BytesReceived != 100 {
+		t.Errorf("Expected the finished state, got %+v", last)
+	}
+	if _, open := <-late.Events; open {
+		t.Error("Expected the stream of a finished session to be closed")
+	}
+	service.UnsubscribeUpload(late)
+}
+
+func TestUploadSessionsAreBounded(t *testing.T) {
+	service := NewService(newTestCache(), true)
+
+	// Following a session nobody reports on leaves nothing behind
+	_, sub := service.SubscribeUpload("unused-session")
+	service.UnsubscribeUpload(sub)
+	if len(service.uploads) != 0 {
+		t.Errorf("Expected unused session to be forgotten, got %d sessions", len(service.uploads))
+	}
+
+	for i := 0; i < config.MaxTrackedUploadSessions+5; i++ {
+		service.HandleEvent(uploadProgress(fmt.Sprintf("session-%d", i), events.UploadStageFailed, 0, int64(i+1)))
+	}
+	if len(service.uploads) != config.MaxTrackedUploadSessions {
+		t.Errorf("Expected %d finished sessions kept, got %d", config.MaxTrackedUploadSessions, len(service.uploads))
+	}
+	if _, ok := service.uploads["session-0"]; ok {
+		t.Error("Expected the oldest finished session to be dropped")
+	}
+}
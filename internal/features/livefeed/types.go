@@ -0,0 +1,58 @@
+package livefeed
+
+import "backthynk/internal/core/events"
+
+// Feed event types sent to clients
+const (
+	EventPostCreated  = "post.created"
+	EventPostUpdated  = "post.updated"
+	EventPostDeleted  = "post.deleted"
+	EventPostMoved    = "post.moved"
+	EventPostPinned   = "post.pinned"
+	EventSpaceUpdated = "space.updated"
+	EventFileUploaded = "file.uploaded"
+	EventUploadProgress = "upload.progress"
+)
+
+// feedEventTypes are the event types clients may filter on; upload progress has streams of its own
+var feedEventTypes = map[string]bool{
+	EventPostCreated:  true,
+	EventPostUpdated:  true,
+	EventPostDeleted:  true,
+	EventPostMoved:    true,
+	EventPostPinned:   true,
+	EventSpaceUpdated: true,
+	EventFileUploaded: true,
+}
+
+// FeedEvent is a notification streamed to clients following a space
+type FeedEvent struct {
+	ID         int64  `json:"id"`
+	Seq        int64  `json:"seq,omitempty"` // Change log sequence number, shared by the events of one change
+	Type       string `json:"type"`
+	SpaceID    int    `json:"space_id"`
+	PostID     int    `json:"post_id,omitempty"`
+	OldSpaceID int    `json:"old_space_id,omitempty"` // For moved posts
+	Pinned     bool   `json:"pinned,omitempty"`       // For pinned posts, false once unpinned
+	Timestamp  int64  `json:"timestamp,omitempty"`
+	FileSize   int64  `json:"file_size,omitempty"`
+}
+
+// UploadEvent is the state of an upload session, streamed to the clients following it
+type UploadEvent struct {
+	Type          string `json:"type"`
+	SessionID     string `json:"session_id"`
+	Stage         string `json:"stage"`
+	BytesReceived int64  `json:"bytes_received"`
+	TotalBytes    int64  `json:"total_bytes,omitempty"`
+	PostID        int    `json:"post_id,omitempty"`
+	AttachmentID  int    `json:"attachment_id,omitempty"`
+	Filename      string `json:"filename,omitempty"`
+	Error         string `json:"error,omitempty"`
+	Sequence      int64  `json:"-"`
+}
+
+// Finished reports whether no further event follows for the session
+func (e UploadEvent) Finished() bool {
+	return e.Stage == events.UploadStageDone || e.Stage == events.UploadStageFailed
+}
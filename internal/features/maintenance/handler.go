@@ -0,0 +1,51 @@
+package maintenance
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/admin/maintenance", h.GetStatus).Methods("GET")
+	api.HandleFunc("/admin/maintenance", h.TriggerRun).Methods("POST")
+}
+
+// GetStatus handles GET /api/admin/maintenance, reporting the progress of a running job
+// and the space reclaimed by the last one
+func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.GetStatus())
+}
+
+// TriggerRun handles POST /api/admin/maintenance, starting a run in the background.
+// Progress is polled with GET.
+func (h *Handler) TriggerRun(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Trigger(); err != nil {
+		if err.Error() == config.ErrMaintenanceRunning {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(h.service.GetStatus())
+}
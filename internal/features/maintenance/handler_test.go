@@ -0,0 +1,66 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/admin/maintenance", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected maintenance routes NOT to be registered when disabled")
+	}
+}
+
+func TestMaintenanceHandlers(t *testing.T) {
+	db, cleanup := setupMaintenanceTestDB(t)
+	defer cleanup()
+
+	service := NewService(db, true)
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	serve := func(method string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/api/admin/maintenance", nil))
+		return w
+	}
+
+	if w := serve("GET"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	service.status.Running = true
+	if w := serve("POST"); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 while running, got %d", w.Code)
+	}
+	service.status.Running = false
+
+	if w := serve("POST"); w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Poll until the background run finishes
+	var status Status
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		json.NewDecoder(serve("GET").Body).Decode(&status)
+		if !status.Running || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if status.Running || status.LastRun == nil || status.LastRun.Trigger != TriggerManual {
+		t.Errorf("Expected a finished manual run, got %+v", status)
+	}
+}
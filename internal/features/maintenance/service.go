@@ -0,0 +1,245 @@
+package maintenance
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/jobs"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	stepAnalyze     = "analyze"
+	stepVacuum      = "vacuum"
+	stepFTSOptimize = "fts-optimize:"
+)
+
+// Service runs database housekeeping (ANALYZE, full-text index optimization and VACUUM),
+// either on demand or once a day during the configured quiet hours
+type Service struct {
+	db         *storage.DB
+	enabled    bool
+	quietHours QuietHours
+	mu         sync.Mutex
+	status     Status
+	budget     *jobs.Budget
+	stop       chan struct{}
+	now        func() time.Time
+}
+
+func NewService(db *storage.DB, enabled bool) *Service {
+	return &Service{
+		db:      db,
+		enabled: enabled,
+		quietHours: QuietHours{
+			Start: config.DefaultMaintenanceQuietStart,
+			End:   config.DefaultMaintenanceQuietEnd,
+		},
+		now: time.Now,
+	}
+}
+
+// SetQuietHours configures the window for scheduled runs; out of range hours keep the default
+func (s *Service) SetQuietHours(start, end int) {
+	if start < 0 || start > 23 || end < 0 || end > 23 {
+		logger.Warning("Invalid maintenance quiet hours, keeping defaults", zap.Int("start", start), zap.Int("end", end))
+		return
+	}
+	s.quietHours = QuietHours{Start: start, End: end}
+}
+
+// GetStatus returns the progress of the current run and the report of the last one
+func (s *Service) GetStatus() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.status
+	status.QuietHours = s.quietHours
+	return status
+}
+
+// Trigger starts a run in the background, failing when one is already in progress
+func (s *Service) Trigger() error {
+	if err := s.begin(); err != nil {
+		return err
+	}
+	go s.run(TriggerManual)
+	return nil
+}
+
+// Run performs a maintenance run and returns its report
+func (s *Service) Run(trigger string) (*RunReport, error) {
+	if err := s.begin(); err != nil {
+		return nil, err
+	}
+	return s.run(trigger), nil
+}
+
+func (s *Service) begin() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status.Running {
+		return fmt.Errorf(config.ErrMaintenanceRunning)
+	}
+	s.status.Running = true
+	s.status.Step = ""
+	s.status.StepsDone = 0
+	s.status.StepsTotal = 0
+	s.status.Started = s.now().UnixMilli()
+	return nil
+}
+
+func (s *Service) run(trigger string) *RunReport {
+	report := &RunReport{Trigger: trigger, Started: s.now().UnixMilli(), Steps: []string{}}
+
+	err := s.budget.Run(context.Background(), "maintenance", func(job *jobs.Job) error {
+		return s.runSteps(report, job)
+	})
+	if err != nil {
+		report.Error = err.Error()
+		logger.Warning("Database maintenance failed", zap.String("trigger", trigger), zap.Error(err))
+	} else {
+		logger.Info("Database maintenance completed",
+			zap.String("trigger", trigger),
+			zap.Int64("reclaimed_bytes", report.Reclaimed))
+	}
+	report.Finished = s.now().UnixMilli()
+
+	s.mu.Lock()
+	s.status.Running = false
+	s.status.Step = ""
+	s.status.LastRun = report
+	s.mu.Unlock()
+
+	return report
+}
+
+func (s *Service) runSteps(report *RunReport, job *jobs.Job) error {
+	ftsTables, err := s.db.GetFTSTables()
+	if err != nil {
+		return err
+	}
+
+	steps := []string{stepAnalyze}
+	for _, table := range ftsTables {
+		steps = append(steps, stepFTSOptimize+table)
+	}
+	steps = append(steps, stepVacuum)
+
+	s.mu.Lock()
+	s.status.StepsTotal = len(steps)
+	s.mu.Unlock()
+
+	report.SizeBefore, err = s.db.DatabaseSize()
+	if err != nil {
+		return err
+	}
+
+	for _, step := range steps {
+		s.mu.Lock()
+		s.status.Step = step
+		s.mu.Unlock()
+
+		switch {
+		case step == stepAnalyze:
+			err = s.db.Analyze()
+		case step == stepVacuum:
+			// VACUUM reads the whole database and writes it back
+			if err = job.ChargeIO(context.Background(), 2*report.SizeBefore); err == nil {
+				err = s.db.Vacuum()
+			}
+		default:
+			err = s.db.OptimizeFTS(step[len(stepFTSOptimize):])
+		}
+		if err != nil {
+			return err
+		}
+
+		report.Steps = append(report.Steps, step)
+		s.mu.Lock()
+		s.status.StepsDone++
+		s.mu.Unlock()
+	}
+
+	report.SizeAfter, err = s.db.DatabaseSize()
+	if err != nil {
+		return err
+	}
+	if report.SizeBefore > report.SizeAfter {
+		report.Reclaimed = report.SizeBefore - report.SizeAfter
+	}
+	return nil
+}
+
+// SetBudget makes runs share the resource budget of background jobs
+func (s *Service) SetBudget(budget *jobs.Budget) {
+	s.budget = budget
+}
+
+// InQuietHours reports whether t falls in the quiet hours window
+func (s *Service) InQuietHours(t time.Time) bool {
+	hour := t.Hour()
+	start, end := s.quietHours.Start, s.quietHours.End
+	if start <= end {
+		return hour >= start && hour < end
+	}
+	return hour >= start || hour < end
+}
+
+// dueForRun reports whether a scheduled run should start now: in quiet hours, and no run
+// started within the minimum gap so the window sees a single run per day
+func (s *Service) dueForRun(now time.Time) bool {
+	if !s.InQuietHours(now) {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status.Running {
+		return false
+	}
+	if s.status.LastRun == nil {
+		return true
+	}
+	return now.Sub(time.UnixMilli(s.status.LastRun.Started)) >= config.MaintenanceMinRunGap
+}
+
+// StartScheduler checks once per interval whether a scheduled run is due until Stop is called
+func (s *Service) StartScheduler(interval time.Duration) {
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if s.dueForRun(s.now()) {
+					if _, err := s.Run(TriggerScheduled); err != nil {
+						logger.Warning("Failed to start scheduled maintenance", zap.Error(err))
+					}
+				}
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// Stop ends the scheduler loop
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
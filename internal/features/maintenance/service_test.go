@@ -0,0 +1,165 @@
+package maintenance
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupMaintenanceTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_maintenance_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestRunReclaimsSpace(t *testing.T) {
+	db, cleanup := setupMaintenanceTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Bulk", nil, "")
+	content := strings.Repeat("x", 4000)
+	for i := 0; i < 200; i++ {
+		if _, err := db.CreatePost(context.Background(), space.ID, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec("DELETE FROM posts"); err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewService(db, true)
+	report, err := service.Run(TriggerManual)
+	if err != nil {
+		t.Fatalf("Failed to run maintenance: %v", err)
+	}
+
+	if report.Error != "" {
+		t.Fatalf("Unexpected run error: %s", report.Error)
+	}
+	if report.Reclaimed <= 0 || report.SizeAfter >= report.SizeBefore {
+		t.Errorf("Expected space to be reclaimed, got %+v", report)
+	}
+	if strings.Join(report.Steps, ",") != "analyze,fts-optimize:posts_fts,fts-optimize:search_fts,vacuum" {
+		t.Errorf("Unexpected steps %v", report.Steps)
+	}
+
+	status := service.GetStatus()
+	if status.Running || status.LastRun != report || status.StepsDone != status.StepsTotal {
+		t.Errorf("Unexpected status after run: %+v", status)
+	}
+}
+
+func TestRunOptimizesFTSTables(t *testing.T) {
+	db, cleanup := setupMaintenanceTestDB(t)
+	defer cleanup()
+
+	if _, err := db.Exec("CREATE VIRTUAL TABLE notes_fts USING fts4(content)"); err != nil {
+		t.Skipf("Full-text search not available: %v", err)
+	}
+	db.Exec("INSERT INTO notes_fts (content) VALUES ('hello world')")
+
+	report, err := NewService(db, true).Run(TriggerManual)
+	if err != nil || report.Error != "" {
+		t.Fatalf("Failed to run maintenance: %v %s", err, report.Error)
+	}
+	if strings.Join(report.Steps, ",") != "analyze,fts-optimize:notes_fts,fts-optimize:posts_fts,fts-optimize:search_fts,vacuum" {
+		t.Errorf("Unexpected steps %v", report.Steps)
+	}
+}
+
+func TestRunRejectsConcurrentRuns(t *testing.T) {
+	db, cleanup := setupMaintenanceTestDB(t)
+	defer cleanup()
+
+	service := NewService(db, true)
+	service.status.Running = true
+
+	if _, err := service.Run(TriggerManual); err == nil || err.Error() != config.ErrMaintenanceRunning {
+		t.Errorf("Expected %q, got %v", config.ErrMaintenanceRunning, err)
+	}
+	if err := service.Trigger(); err == nil {
+		t.Error("Expected trigger to fail while running")
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2024, 5, 1, hour, 30, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name     string
+		start    int
+		end      int
+		hour     int
+		expected bool
+	}{
+		{"Inside window", 3, 5, 4, true},
+		{"Window start", 3, 5, 3, true},
+		{"Window end excluded", 3, 5, 5, false},
+		{"Outside window", 3, 5, 12, false},
+		{"Wrapping before midnight", 23, 2, 23, true},
+		{"Wrapping after midnight", 23, 2, 1, true},
+		{"Wrapping outside", 23, 2, 10, false},
+		{"Empty window", 4, 4, 4, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(nil, true)
+			service.SetQuietHours(tt.start, tt.end)
+			if got := service.InQuietHours(at(tt.hour)); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetQuietHoursInvalid(t *testing.T) {
+	service := NewService(nil, true)
+	service.SetQuietHours(-1, 30)
+
+	if service.quietHours.Start != config.DefaultMaintenanceQuietStart || service.quietHours.End != config.DefaultMaintenanceQuietEnd {
+		t.Errorf("Expected default quiet hours, got %+v", service.quietHours)
+	}
+}
+
+func TestDueForRun(t *testing.T) {
+	service := NewService(nil, true)
+	night := time.Date(2024, 5, 1, 3, 15, 0, 0, time.Local)
+
+	if service.dueForRun(night.Add(8 * time.Hour)) {
+		t.Error("Expected no run outside quiet hours")
+	}
+	if !service.dueForRun(night) {
+		t.Error("Expected first run in quiet hours")
+	}
+
+	service.status.LastRun = &RunReport{Started: night.UnixMilli()}
+	if service.dueForRun(night.Add(30 * time.Minute)) {
+		t.Error("Expected a single run per quiet window")
+	}
+	if !service.dueForRun(night.AddDate(0, 0, 1)) {
+		t.Error("Expected a run the next night")
+	}
+}
@@ -0,0 +1,37 @@
+package maintenance
+
+// Run triggers
+const (
+	TriggerManual    = "manual"
+	TriggerScheduled = "scheduled"
+)
+
+// QuietHours is the local time window, in hours, in which scheduled maintenance runs.
+// A window whose start is after its end wraps around midnight.
+type QuietHours struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// RunReport describes a maintenance run
+type RunReport struct {
+	Trigger    string   `json:"trigger"`
+	Started    int64    `json:"started"`
+	Finished   int64    `json:"finished"`
+	Steps      []string `json:"steps"`
+	SizeBefore int64    `json:"size_before"`
+	SizeAfter  int64    `json:"size_after"`
+	Reclaimed  int64    `json:"reclaimed"` // Bytes freed by the run
+	Error      string   `json:"error,omitempty"`
+}
+
+// Status reports the progress of the current run and the outcome of the last one
+type Status struct {
+	Running    bool       `json:"running"`
+	Step       string     `json:"step,omitempty"`
+	StepsDone  int        `json:"steps_done"`
+	StepsTotal int        `json:"steps_total"`
+	Started    int64      `json:"started,omitempty"`
+	LastRun    *RunReport `json:"last_run,omitempty"`
+	QuietHours QuietHours `json:"quiet_hours"`
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"backthynk/internal/core/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(NewService(false, nil))
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/admin/memory", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected memory routes NOT to be registered when disabled")
+	}
+}
+
+func TestGetReport(t *testing.T) {
+	service := NewService(true, nil)
+	service.Register(SubsystemSpaceCache, &stubReporter{models.MemoryUsage{Entries: 2, Bytes: 400}})
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/admin/memory", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	var report Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if len(report.Subsystems) != 1 || report.Subsystems[0].Entries != 2 || report.Warnings == nil {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Reporter is an in-memory structure able to estimate its own footprint
+type Reporter interface {
+	MemoryUsage() models.MemoryUsage
+}
+
+type subsystem struct {
+	name     string
+	reporter Reporter
+}
+
+// Service reports the estimated memory of the registered in-memory structures and warns when
+// one grows past its configured bound. Estimates count entries with fixed per-item costs, they
+// are meant to spot growth, not to match the runtime figures.
+type Service struct {
+	subsystems []subsystem
+	bounds     map[string]int64 // name -> bytes
+	dispatcher *events.Dispatcher
+	over       map[string]bool // Subsystems over their bound at the last check
+	stop       chan struct{}
+	mu         sync.Mutex
+	enabled    bool
+}
+
+func NewService(enabled bool, boundsMB map[string]int) *Service {
+	bounds := make(map[string]int64, len(boundsMB))
+	for name, mb := range boundsMB {
+		if mb > 0 {
+			bounds[name] = int64(mb) << 20
+		}
+	}
+
+	return &Service{
+		bounds:  bounds,
+		over:    make(map[string]bool),
+		enabled: enabled,
+	}
+}
+
+// Register adds a structure to the report, in registration order
+func (s *Service) Register(name string, reporter Reporter) {
+	s.subsystems = append(s.subsystems, subsystem{name: name, reporter: reporter})
+}
+
+// SetDispatcher lets the service raise a quota warning when a subsystem exceeds its bound
+func (s *Service) SetDispatcher(dispatcher *events.Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// Report estimates the usage of every registered subsystem
+func (s *Service) Report() *Report {
+	report := &Report{Subsystems: []SubsystemUsage{}, Warnings: []string{}}
+
+	for _, sub := range s.subsystems {
+		usage := sub.reporter.MemoryUsage()
+		entry := SubsystemUsage{
+			Name:       sub.name,
+			Entries:    usage.Entries,
+			Bytes:      usage.Bytes,
+			BoundBytes: s.bounds[sub.name],
+		}
+		if entry.BoundBytes > 0 && entry.Bytes > entry.BoundBytes {
+			entry.OverBound = true
+			report.Warnings = append(report.Warnings, warning(entry))
+		}
+		report.Subsystems = append(report.Subsystems, entry)
+	}
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	report.Runtime = RuntimeUsage{
+		HeapAllocBytes: m.HeapAlloc,
+		SysBytes:       m.Sys,
+		HeapObjects:    m.HeapObjects,
+		NumGC:          m.NumGC,
+	}
+
+	return report
+}
+
+// Check logs and notifies subsystems that went over their bound since the previous check
+func (s *Service) Check() {
+	report := s.Report()
+
+	s.mu.Lock()
+	var crossed []SubsystemUsage
+	for _, entry := range report.Subsystems {
+		if entry.OverBound && !s.over[entry.Name] {
+			crossed = append(crossed, entry)
+		}
+		s.over[entry.Name] = entry.OverBound
+	}
+	s.mu.Unlock()
+
+	for _, entry := range crossed {
+		logger.Warning("Subsystem memory over bound",
+			zap.String("subsystem", entry.Name),
+			zap.Int64("bytes", entry.Bytes),
+			zap.Int64("bound_bytes", entry.BoundBytes),
+			zap.Int("entries", entry.Entries))
+		s.dispatcher.Notify(events.NotificationEvent{
+			Kind:    models.NotificationQuotaWarning,
+			Title:   "Memory bound exceeded",
+			Message: warning(entry),
+			Key:     "memory." + entry.Name,
+		})
+	}
+}
+
+// StartMonitor checks the bounds once per interval until Stop is called
+func (s *Service) StartMonitor(interval time.Duration) {
+	if !s.enabled || len(s.bounds) == 0 || s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.Check()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// Stop ends the periodic bound checks
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+func warning(entry SubsystemUsage) string {
+	return fmt.Sprintf("%s uses an estimated %.1f MB for %d entries, over its bound of %.1f MB",
+		entry.Name, float64(entry.Bytes)/(1<<20), entry.Entries, float64(entry.BoundBytes)/(1<<20))
+}
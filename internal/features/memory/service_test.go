@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"testing"
+)
+
+type stubReporter struct {
+	usage models.MemoryUsage
+}
+
+func (r *stubReporter) MemoryUsage() models.MemoryUsage {
+	return r.usage
+}
+
+func TestReport(t *testing.T) {
+	service := NewService(true, map[string]int{SubsystemSearchIndex: 1, SubsystemActivity: 0})
+	service.Register(SubsystemSpaceCache, &stubReporter{models.MemoryUsage{Entries: 3, Bytes: 900}})
+	service.Register(SubsystemActivity, &stubReporter{models.MemoryUsage{Entries: 10, Bytes: 5 << 20}})
+	service.Register(SubsystemSearchIndex, &stubReporter{models.MemoryUsage{Entries: 2000, Bytes: 2 << 20}})
+
+	report := service.Report()
+	if len(report.Subsystems) != 3 || report.Subsystems[0].Name != SubsystemSpaceCache {
+		t.Fatalf("Expected subsystems in registration order, got %+v", report.Subsystems)
+	}
+	if report.Subsystems[0].Entries != 3 || report.Subsystems[0].Bytes != 900 || report.Subsystems[0].BoundBytes != 0 {
+		t.Errorf("Unexpected space cache usage %+v", report.Subsystems[0])
+	}
+	if report.Subsystems[1].OverBound {
+		t.Error("Expected a zero bound to mean no bound")
+	}
+	if !report.Subsystems[2].OverBound || report.Subsystems[2].BoundBytes != 1<<20 {
+		t.Errorf("Expected the search index over its bound, got %+v", report.Subsystems[2])
+	}
+	if len(report.Warnings) != 1 {
+		t.Errorf("Expected one warning, got %v", report.Warnings)
+	}
+	if report.Runtime.HeapAllocBytes == 0 || report.Runtime.SysBytes == 0 {
+		t.Errorf("Expected runtime figures, got %+v", report.Runtime)
+	}
+}
+
+func TestCheckNotifiesOncePerCrossing(t *testing.T) {
+	index := &stubReporter{models.MemoryUsage{Entries: 10, Bytes: 2 << 20}}
+	service := NewService(true, map[string]int{SubsystemSearchIndex: 1})
+	service.Register(SubsystemSearchIndex, index)
+
+	var raised []events.NotificationEvent
+	dispatcher := events.NewDispatcher()
+	dispatcher.Subscribe(events.NotificationRaised, func(event events.Event) error {
+		raised = append(raised, event.Data.(events.NotificationEvent))
+		return nil
+	})
+	service.SetDispatcher(dispatcher)
+
+	service.Check()
+	service.Check()
+	if len(raised) != 1 {
+		t.Fatalf("Expected one notification while over bound, got %d", len(raised))
+	}
+	if raised[0].Kind != models.NotificationQuotaWarning || raised[0].Key != "memory."+SubsystemSearchIndex {
+		t.Errorf("Unexpected notification %+v", raised[0])
+	}
+
+	index.usage.Bytes = 1 << 10
+	service.Check()
+	index.usage.Bytes = 3 << 20
+	service.Check()
+	if len(raised) != 2 {
+		t.Errorf("Expected a new notification after going back over the bound, got %d", len(raised))
+	}
+}
@@ -0,0 +1,32 @@
+package memory
+
+// Subsystem names, also used as keys of the configured bounds
+const (
+	SubsystemSpaceCache    = "space_cache"
+	SubsystemActivity      = "activity"
+	SubsystemDetailedStats = "detailed_stats"
+	SubsystemSearchIndex   = "search_index"
+)
+
+// SubsystemUsage is the estimated footprint of one in-memory structure
+type SubsystemUsage struct {
+	Name       string `json:"name"`
+	Entries    int    `json:"entries"`
+	Bytes      int64  `json:"bytes"`
+	BoundBytes int64  `json:"bound_bytes,omitempty"` // 0 when no bound is configured
+	OverBound  bool   `json:"over_bound"`
+}
+
+// RuntimeUsage is what the Go runtime reports for the whole process
+type RuntimeUsage struct {
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	SysBytes       uint64 `json:"sys_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	NumGC          uint32 `json:"num_gc"`
+}
+
+type Report struct {
+	Subsystems []SubsystemUsage `json:"subsystems"`
+	Runtime    RuntimeUsage     `json:"runtime"`
+	Warnings   []string         `json:"warnings"`
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+var metricNamePattern = regexp.MustCompile(`^[A-Za-z][\w-]{0,63}$`)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/metrics", h.GetMetricNames).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/metrics/{name}/series", h.GetMetricSeries).Methods("GET")
+}
+
+// GetMetricNames handles GET /api/spaces/{id}/metrics
+// Query parameters:
+// - recursive: include metrics from descendant spaces (default: false)
+func (h *Handler) GetMetricNames(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	recursive := r.URL.Query().Get("recursive") == "true"
+
+	names, err := h.service.GetNames(r.Context(), spaceID, recursive)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := NamesResponse{
+		SpaceID:   spaceID,
+		Recursive: recursive,
+		Names:     names,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetMetricSeries handles GET /api/spaces/{id}/metrics/{name}/series
+// Query parameters:
+// - recursive: include values from descendant spaces (default: false)
+func (h *Handler) GetMetricSeries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	spaceID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	name := vars["name"]
+	if !metricNamePattern.MatchString(name) {
+		http.Error(w, config.ErrInvalidMetricName, http.StatusBadRequest)
+		return
+	}
+
+	recursive := r.URL.Query().Get("recursive") == "true"
+
+	points, err := h.service.GetSeries(r.Context(), spaceID, name, recursive)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	response := SeriesResponse{
+		SpaceID:   spaceID,
+		Name:      name,
+		Recursive: recursive,
+		Points:    points,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	if err.Error() == config.ErrSpaceNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"backthynk/internal/core/cache"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/spaces/1/metrics/weight/series", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected metrics routes NOT to be registered when disabled")
+	}
+}
+
+func TestGetMetricSeriesHandler(t *testing.T) {
+	db, cleanup := setupMetricsTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Log", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	db.CreatePost(context.Background(), space.ID, "steps: 9000")
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedPoints int
+	}{
+		{"Existing metric", "/api/spaces/1/metrics/steps/series", http.StatusOK, 1},
+		{"Unknown metric", "/api/spaces/1/metrics/weight/series", http.StatusOK, 0},
+		{"Invalid metric name", "/api/spaces/1/metrics/9lives/series", http.StatusBadRequest, 0},
+		{"Unknown space", "/api/spaces/999/metrics/steps/series", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response SeriesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(response.Points) != tt.expectedPoints {
+				t.Errorf("Expected %d points, got %d", tt.expectedPoints, len(response.Points))
+			}
+		})
+	}
+}
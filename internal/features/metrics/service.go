@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"strings"
+)
+
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	access   *services.SpaceAccess
+	enabled  bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		enabled:  enabled,
+	}
+}
+
+// SetSpaceAccess keeps the metrics of spaces hidden from the viewer of a request out of its
+// series and names
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// Initialize re-parses all posts so metrics declared before the feature was enabled are available
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	return s.db.RebuildPostMetrics(utils.ParseMetrics)
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	// Deleted posts cascade their metrics and moved posts are resolved through
+	// their current space at query time, so only content changes need indexing
+	switch event.Type {
+	case events.PostCreated, events.PostMerged, events.PostUpdated:
+		data := event.Data.(events.PostEvent)
+		return s.indexPost(data.PostID)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		if err := s.indexPost(data.PostID); err != nil {
+			return err
+		}
+		for _, split := range data.SplitPosts {
+			if err := s.indexPost(split.PostID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetSeries returns the values of a metric in a space, optionally including its descendants
+func (s *Service) GetSeries(ctx context.Context, spaceID int, name string, recursive bool) ([]storage.MetricPoint, error) {
+	spaceIDs, err := s.resolveSpaces(ctx, spaceID, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.GetMetricSeries(strings.ToLower(name), spaceIDs)
+}
+
+// GetNames returns the metric names used in a space, optionally including its descendants
+func (s *Service) GetNames(ctx context.Context, spaceID int, recursive bool) ([]string, error) {
+	spaceIDs, err := s.resolveSpaces(ctx, spaceID, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.GetMetricNames(spaceIDs)
+}
+
+func (s *Service) resolveSpaces(ctx context.Context, spaceID int, recursive bool) ([]int, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	spaceIDs := []int{spaceID}
+	if recursive {
+		spaceIDs = append(spaceIDs, s.catCache.GetDescendants(spaceID)...)
+	}
+	return s.access.Scope(ctx, spaceIDs), nil
+}
+
+func (s *Service) indexPost(postID int) error {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.SetPostMetrics(postID, utils.ParseMetrics(post.Content))
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func setupMetricsTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_metrics_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestMetricSeries(t *testing.T) {
+	db, cleanup := setupMetricsTestDB(t)
+	defer cleanup()
+
+	journal, _ := db.CreateSpace("Journal", nil, "")
+	health, _ := db.CreateSpace("Health", &journal.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(journal)
+	catCache.Set(health)
+
+	// Posts written before startup are indexed by Initialize
+	db.CreatePostWithTimestamp(context.Background(), health.ID, "weight: 82.4", 1000)
+	db.CreatePostWithTimestamp(context.Background(), journal.ID, "Good day #metric mood=7", 2000)
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	post, _ := db.CreatePostWithTimestamp(context.Background(), health.ID, "Weight: 81.9kg\n#metric mood=5", 3000)
+	service.HandleEvent(events.Event{
+		Type: events.PostCreated,
+		Data: events.PostEvent{PostID: post.ID, SpaceID: health.ID, Timestamp: post.Created},
+	})
+
+	weights, err := service.GetSeries(context.Background(), health.ID, "weight", false)
+	if err != nil {
+		t.Fatalf("GetSeries failed: %v", err)
+	}
+	if len(weights) != 2 || weights[0].Value != 82.4 || weights[1].Value != 81.9 {
+		t.Errorf("Unexpected weight series: %+v", weights)
+	}
+
+	moods, _ := service.GetSeries(context.Background(), journal.ID, "mood", false)
+	if len(moods) != 1 {
+		t.Errorf("Expected 1 direct mood value, got %d", len(moods))
+	}
+
+	moods, _ = service.GetSeries(context.Background(), journal.ID, "MOOD", true)
+	if len(moods) != 2 || moods[0].Timestamp != 2000 || moods[1].Timestamp != 3000 {
+		t.Errorf("Unexpected recursive mood series: %+v", moods)
+	}
+
+	names, _ := service.GetNames(context.Background(), journal.ID, true)
+	if len(names) != 2 || names[0] != "mood" || names[1] != "weight" {
+		t.Errorf("Unexpected metric names: %v", names)
+	}
+
+	// Deleting a post drops its metrics
+	db.DeletePost(context.Background(), post.ID)
+	weights, _ = service.GetSeries(context.Background(), health.ID, "weight", false)
+	if len(weights) != 1 {
+		t.Errorf("Expected 1 weight value after delete, got %d", len(weights))
+	}
+
+	if _, err := service.GetSeries(context.Background(), 999, "weight", false); err == nil {
+		t.Error("Expected error for unknown space")
+	}
+}
+
+func TestMetricsHidePrivateSpaces(t *testing.T) {
+	db, cleanup := setupMetricsTestDB(t)
+	defer cleanup()
+
+	journal, _ := db.CreateSpace("Journal", nil, "")
+	health, _ := db.CreateSpace("Health", &journal.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(journal)
+	catCache.Set(health)
+	db.CreatePostWithTimestamp(context.Background(), journal.ID, "#metric mood=7", 1000)
+	db.CreatePostWithTimestamp(context.Background(), health.ID, "weight: 82.4", 2000)
+
+	bob, _ := db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	alice, _ := db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(db, catCache)
+	if err := access.SetACL(health.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+
+	service := NewService(db, catCache, true)
+	service.SetSpaceAccess(access)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	asBob := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+	if names, _ := service.GetNames(asBob, journal.ID, true); len(names) != 1 || names[0] != "mood" {
+		t.Errorf("Expected only the metrics of visible spaces, got %v", names)
+	}
+	if _, err := service.GetSeries(asBob, health.ID, "weight", false); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+	asAlice := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	if weights, _ := service.GetSeries(asAlice, journal.ID, "weight", true); len(weights) != 1 {
+		t.Errorf("Expected a listed viewer to see the private metric, got %v", weights)
+	}
+}
@@ -0,0 +1,16 @@
+package metrics
+
+import "backthynk/internal/storage"
+
+type SeriesResponse struct {
+	SpaceID   int                   `json:"space_id"`
+	Name      string                `json:"name"`
+	Recursive bool                  `json:"recursive"`
+	Points    []storage.MetricPoint `json:"points"`
+}
+
+type NamesResponse struct {
+	SpaceID   int      `json:"space_id"`
+	Recursive bool     `json:"recursive"`
+	Names     []string `json:"names"`
+}
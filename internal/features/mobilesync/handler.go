@@ -0,0 +1,53 @@
+package mobilesync
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/mobile/sync", h.Sync).Methods("GET")
+}
+
+// Sync handles GET /api/mobile/sync
+// Returns the space tree and unread counts in one response. Posts are fetched separately with
+// ?profile=mobile for compact listings.
+// Query parameters:
+// - since: server_time of the previous sync, posts created after it count as unread (default: 0, first sync)
+func (h *Handler) Sync(w http.ResponseWriter, r *http.Request) {
+	var since int64
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		parsed, err := strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil || parsed < 0 {
+			http.Error(w, config.ErrInvalidSyncSince, http.StatusBadRequest)
+			return
+		}
+		since = parsed
+	}
+
+	sync, err := h.service.Sync(r.Context(), since)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sync)
+}
@@ -0,0 +1,61 @@
+package mobilesync
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/mobile/sync", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected mobile sync routes NOT to be registered when disabled")
+	}
+}
+
+func TestSyncHandler(t *testing.T) {
+	setup := setupMobileSyncTest(t)
+	defer setup.cleanup()
+
+	setup.spaceService.Create(context.Background(), "Projects", nil, "A long description the tree leaves out")
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"First sync", "", http.StatusOK, `"name":"Projects","post_count":0`},
+		{"Since", "?since=1700000000000", http.StatusOK, `"since":1700000000000`},
+		{"Invalid since", "?since=yesterday", http.StatusBadRequest, ""},
+		{"Negative since", "?since=-1", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/mobile/sync"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body to contain %s, got %s", tt.expectedBody, w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), "description") {
+				t.Errorf("Expected no description in the tree, got %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package mobilesync
+
+import (
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"sort"
+	"time"
+)
+
+// Service batches the space tree and the unread counts a mobile client polls for, so a
+// refresh over a metered connection is one small response instead of several calls.
+type Service struct {
+	db            *storage.DB
+	catCache      *cache.SpaceCache
+	notifications CountSource
+	access        *services.SpaceAccess
+	now           func() time.Time
+	enabled       bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		now:      time.Now,
+		enabled:  enabled,
+	}
+}
+
+// SetNotificationSource counts the unread notifications; without one they count as 0
+func (s *Service) SetNotificationSource(source CountSource) {
+	s.notifications = source
+}
+
+// SetSpaceAccess leaves the spaces hidden from the viewer of a request out of its tree and
+// unread counts
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// Sync returns the space tree the viewer of ctx may see with the posts created after since in
+// each space, and the unread notifications. A since of 0 is a first sync, which leaves the post
+// counts out.
+func (s *Service) Sync(ctx context.Context, since int64) (*Sync, error) {
+	sync := &Sync{Since: since, ServerTime: s.now().UnixMilli()}
+
+	recent := map[int]int{}
+	if since > 0 {
+		var err error
+		if recent, err = s.db.CountPostsCreatedAfter(since); err != nil {
+			return nil, err
+		}
+	}
+	for spaceID := range s.access.Hidden(ctx) {
+		delete(recent, spaceID)
+	}
+	for _, count := range recent {
+		sync.Unread.Posts += count
+	}
+
+	if s.notifications != nil {
+		unread, err := s.notifications()
+		if err != nil {
+			return nil, err
+		}
+		sync.Unread.Notifications = unread
+	}
+
+	sync.Spaces = s.tree(s.access.Filter(ctx, s.catCache.GetAll()), recent)
+	return sync, nil
+}
+
+// tree builds the space tree of spaces in name order, with unread counts summed up from
+// descendants
+func (s *Service) tree(spaces []*models.Space, recent map[int]int) []*Space {
+	sort.Slice(spaces, func(i, j int) bool {
+		if spaces[i].Name != spaces[j].Name {
+			return spaces[i].Name < spaces[j].Name
+		}
+		return spaces[i].ID < spaces[j].ID
+	})
+
+	nodes := make(map[int]*Space, len(spaces))
+	for _, space := range spaces {
+		nodes[space.ID] = &Space{
+			ID:                 space.ID,
+			Name:               space.Name,
+			PostCount:          space.PostCount,
+			RecursivePostCount: space.RecursivePostCount,
+		}
+	}
+
+	roots := []*Space{}
+	for _, space := range spaces {
+		node := nodes[space.ID]
+		if parent, ok := nodes[parentID(space)]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	for _, root := range roots {
+		sumUnread(root, recent)
+	}
+	return roots
+}
+
+func sumUnread(node *Space, recent map[int]int) int {
+	node.Unread = recent[node.ID]
+	for _, child := range node.Children {
+		node.Unread += sumUnread(child, recent)
+	}
+	return node.Unread
+}
+
+func parentID(space *models.Space) int {
+	if space.ParentID == nil {
+		return 0
+	}
+	return *space.ParentID
+}
@@ -0,0 +1,98 @@
+package mobilesync
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+type mobileSyncTestSetup struct {
+	spaceService *services.SpaceService
+	postService  *services.PostService
+	service      *Service
+	cleanup      func()
+}
+
+func setupMobileSyncTest(t *testing.T) *mobileSyncTestSetup {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+	config.SetOptionsConfigForTest(config.NewTestOptionsConfig().WithRetroactivePostingEnabled(true))
+
+	tempDir, err := os.MkdirTemp("", "backthynk_mobilesync_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	return &mobileSyncTestSetup{
+		spaceService: services.NewSpaceService(db, catCache, dispatcher),
+		postService:  services.NewPostService(db, catCache, dispatcher),
+		service:      NewService(db, catCache, true),
+		cleanup: func() {
+			db.Close()
+			os.RemoveAll(tempDir)
+		},
+	}
+}
+
+func TestSync(t *testing.T) {
+	setup := setupMobileSyncTest(t)
+	defer setup.cleanup()
+
+	work, _ := setup.spaceService.Create(context.Background(), "Work", nil, "Everything about work")
+	notes, _ := setup.spaceService.Create(context.Background(), "Notes", &work.ID, "")
+	home, _ := setup.spaceService.Create(context.Background(), "Home", nil, "")
+
+	since := time.Now().Add(-time.Hour).UnixMilli()
+	old := time.Now().Add(-2 * time.Hour).UnixMilli()
+	setup.postService.Create(context.Background(), work.ID, "Before the last sync", &old)
+	setup.postService.Create(context.Background(), notes.ID, "New in notes", nil)
+	setup.postService.Create(context.Background(), notes.ID, "Also new in notes", nil)
+	setup.postService.Create(context.Background(), home.ID, "New at home", nil)
+
+	setup.service.SetNotificationSource(func() (int, error) { return 3, nil })
+	sync, err := setup.service.Sync(context.Background(), since)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	if sync.Unread.Posts != 3 || sync.Unread.Notifications != 3 || sync.Since != since || sync.ServerTime == 0 {
+		t.Errorf("Unexpected sync %+v", sync)
+	}
+	if len(sync.Spaces) != 2 || sync.Spaces[0].Name != "Home" || sync.Spaces[1].Name != "Work" {
+		t.Fatalf("Expected the roots in name order, got %+v", sync.Spaces)
+	}
+	root := sync.Spaces[1]
+	if root.Unread != 2 || root.RecursivePostCount != 3 || len(root.Children) != 1 {
+		t.Errorf("Expected the unread posts of Notes counted in Work, got %+v", root)
+	}
+	if child := root.Children[0]; child.ID != notes.ID || child.Unread != 2 || child.PostCount != 2 {
+		t.Errorf("Unexpected child %+v", child)
+	}
+
+	// A first sync counts nothing as unread
+	first, err := setup.service.Sync(context.Background(), 0)
+	if err != nil || first.Unread.Posts != 0 || first.Spaces[1].Unread != 0 {
+		t.Errorf("Expected no unread posts on a first sync, got %+v, %v", first, err)
+	}
+
+	setup.service.SetNotificationSource(func() (int, error) { return 0, errors.New("unavailable") })
+	if _, err := setup.service.Sync(context.Background(), since); err == nil {
+		t.Error("Expected the notification source error")
+	}
+}
@@ -0,0 +1,28 @@
+package mobilesync
+
+// Sync is what a mobile client needs to refresh its navigation in one call
+type Sync struct {
+	Spaces     []*Space `json:"spaces"` // Root spaces, each with its descendants
+	Unread     Unread   `json:"unread"`
+	Since      int64    `json:"since"`
+	ServerTime int64    `json:"server_time"` // Pass as since on the next sync
+}
+
+// Space is a node of the space tree without its description and other display metadata
+type Space struct {
+	ID                 int      `json:"id"`
+	Name               string   `json:"name"`
+	PostCount          int      `json:"post_count"`
+	RecursivePostCount int      `json:"recursive_post_count"`
+	Unread             int      `json:"unread,omitempty"` // Posts created in the space after since, descendants included
+	Children           []*Space `json:"children,omitempty"`
+}
+
+// Unread totals what changed since the previous sync
+type Unread struct {
+	Posts         int `json:"posts"`         // Posts created after since in every space
+	Notifications int `json:"notifications"` // Notifications not read yet, 0 without notifications
+}
+
+// CountSource counts unread items held by another feature
+type CountSource func() (int, error)
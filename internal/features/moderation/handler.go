@@ -0,0 +1,108 @@
+package moderation
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/moderation/check", h.CheckSpace).Methods("POST")
+	api.HandleFunc("/moderation/queue", h.GetQueue).Methods("GET")
+	api.HandleFunc("/moderation/queue/{id:[0-9]+}/review", h.ReviewFlag).Methods("POST")
+}
+
+// CheckSpace handles POST /api/moderation/check, scanning a space before it is shared
+func (h *Handler) CheckSpace(w http.ResponseWriter, r *http.Request) {
+	var req CheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.Gate(req.SpaceID, req.Recursive)
+	if err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		// Hook failures (e.g. the external API being down) are not the client's fault
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetQueue handles GET /api/moderation/queue
+// Query parameters:
+// - status: pending (default), approved, rejected or all
+func (h *Handler) GetQueue(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = models.ModerationPending
+	case "all":
+		status = ""
+	case models.ModerationPending, models.ModerationApproved, models.ModerationRejected:
+	default:
+		http.Error(w, config.ErrInvalidModerationStatus, http.StatusBadRequest)
+		return
+	}
+
+	flags, err := h.service.GetQueue(status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(flags)
+}
+
+// ReviewFlag handles POST /api/moderation/queue/{id}/review
+func (h *Handler) ReviewFlag(w http.ResponseWriter, r *http.Request) {
+	flagID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidModerationFlagID, http.StatusBadRequest)
+		return
+	}
+
+	var req ReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Review(flagID, req.Status); err != nil {
+		switch err.Error() {
+		case config.ErrInvalidModerationStatus:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case config.ErrModerationFlagNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,109 @@
+package moderation
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// Hook scans a post before it is shared. Hooks are pluggable through Service.AddHook.
+type Hook interface {
+	Source() string
+	Scan(post models.Post) ([]Finding, error)
+}
+
+type compiledRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// RuleHook flags content matching configured regular expressions
+type RuleHook struct {
+	rules []compiledRule
+}
+
+// NewRuleHook compiles the rules; an invalid pattern is reported with its rule name
+func NewRuleHook(rules []config.ModerationRule) (*RuleHook, error) {
+	hook := &RuleHook{}
+	for _, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf(config.ErrFmtInvalidModerationRule, rule.Name, err)
+		}
+		hook.rules = append(hook.rules, compiledRule{name: rule.Name, pattern: pattern})
+	}
+	return hook, nil
+}
+
+func (h *RuleHook) Source() string {
+	return SourceRules
+}
+
+func (h *RuleHook) Scan(post models.Post) ([]Finding, error) {
+	var findings []Finding
+	for _, rule := range h.rules {
+		for _, match := range rule.pattern.FindAllString(post.Content, -1) {
+			findings = append(findings, Finding{Rule: rule.name, Match: match})
+		}
+	}
+	return findings, nil
+}
+
+// ExternalHook asks an external moderation API about each post
+type ExternalHook struct {
+	url    string
+	client *http.Client
+}
+
+func NewExternalHook(url string) *ExternalHook {
+	return &ExternalHook{
+		url:    url,
+		client: &http.Client{Timeout: config.HTTPTimeout(config.WebhookHTTPTimeout)},
+	}
+}
+
+func (h *ExternalHook) Source() string {
+	return SourceExternal
+}
+
+func (h *ExternalHook) Scan(post models.Post) ([]Finding, error) {
+	body, err := json.Marshal(ExternalRequest{
+		PostID:  post.ID,
+		SpaceID: post.SpaceID,
+		Content: post.Content,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal moderation request: %w", err)
+	}
+
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to call moderation API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("moderation API returned status %d", resp.StatusCode)
+	}
+
+	var verdict ExternalResponse
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+	if !verdict.Flagged {
+		return nil, nil
+	}
+
+	if len(verdict.Reasons) == 0 {
+		verdict.Reasons = []string{"flagged"}
+	}
+	findings := make([]Finding, len(verdict.Reasons))
+	for i, reason := range verdict.Reasons {
+		findings[i] = Finding{Rule: reason, Match: verdict.Match}
+	}
+	return findings, nil
+}
@@ -0,0 +1,174 @@
+package moderation
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maskedPrefixLength is how much of a match is kept readable in flag excerpts
+const maskedPrefixLength = 4
+
+// Service scans content about to be shared and keeps the findings in a review queue.
+// Sharing features call Gate before activating a link.
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	enabled  bool
+	hooks    []Hook
+	mu       sync.Mutex // serializes scans so flags of a post are reconciled once at a time
+	now      func() time.Time
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		enabled:  enabled,
+		now:      time.Now,
+	}
+}
+
+// AddHook registers a moderation hook. Hooks must be registered before the server starts.
+func (s *Service) AddHook(hook Hook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+// Gate scans the posts of a space, optionally with its descendants, and reports whether
+// they may be shared. Content is blocked while it has pending or rejected flags.
+// A failing hook blocks sharing rather than letting unchecked content through.
+func (s *Service) Gate(spaceID int, recursive bool) (*GateResult, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	spaceIDs := []int{spaceID}
+	if recursive {
+		spaceIDs = append(spaceIDs, s.catCache.GetDescendants(spaceID)...)
+	}
+
+	posts, err := s.db.GetPostsBySpaces(spaceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, post := range posts {
+		if err := s.scanPost(post); err != nil {
+			return nil, err
+		}
+	}
+
+	flags, err := s.db.GetModerationFlags("", spaceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	blocking := []models.ModerationFlag{}
+	for _, flag := range flags {
+		if flag.Status != models.ModerationApproved {
+			blocking = append(blocking, flag)
+		}
+	}
+
+	return &GateResult{
+		SpaceID:   spaceID,
+		Recursive: recursive,
+		Allowed:   len(blocking) == 0,
+		Scanned:   len(posts),
+		Flags:     blocking,
+	}, nil
+}
+
+// GetQueue returns the flags with the given status, or every flag when status is empty
+func (s *Service) GetQueue(status string) ([]models.ModerationFlag, error) {
+	return s.db.GetModerationFlags(status, nil)
+}
+
+// Review records the decision on a flag
+func (s *Service) Review(flagID int, status string) error {
+	if status != models.ModerationApproved && status != models.ModerationRejected {
+		return fmt.Errorf(config.ErrInvalidModerationStatus)
+	}
+
+	if err := s.db.UpdateModerationFlagStatus(flagID, status, s.now().UnixMilli()); err != nil {
+		if err.Error() == "moderation flag not found" {
+			return fmt.Errorf(config.ErrModerationFlagNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// scanPost runs every hook on a post and reconciles its flags: findings that disappeared
+// drop their flag, new findings are queued and reviewed findings keep their decision
+func (s *Service) scanPost(post models.Post) error {
+	current := make(map[string]models.ModerationFlag)
+	for _, hook := range s.hooks {
+		findings, err := hook.Scan(post)
+		if err != nil {
+			return err
+		}
+		for _, finding := range findings {
+			fingerprint := fingerprintFinding(hook.Source(), finding)
+			current[fingerprint] = models.ModerationFlag{
+				PostID:      post.ID,
+				SpaceID:     post.SpaceID,
+				Rule:        finding.Rule,
+				Source:      hook.Source(),
+				Excerpt:     maskMatch(finding.Match),
+				Fingerprint: fingerprint,
+				Status:      models.ModerationPending,
+			}
+		}
+	}
+
+	existing, err := s.db.GetModerationFlagsByPost(post.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, flag := range existing {
+		if _, ok := current[flag.Fingerprint]; ok {
+			delete(current, flag.Fingerprint)
+			continue
+		}
+		if err := s.db.DeleteModerationFlag(flag.ID); err != nil {
+			return err
+		}
+	}
+
+	created := s.now().UnixMilli()
+	for _, flag := range current {
+		flag.Created = created
+		if err := s.db.CreateModerationFlag(&flag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fingerprintFinding(source string, finding Finding) string {
+	sum := sha256.Sum256([]byte(source + "\x00" + finding.Rule + "\x00" + finding.Match))
+	return hex.EncodeToString(sum[:16])
+}
+
+// maskMatch keeps the start of a match readable so reviewers can locate it without
+// the queue itself exposing the secret
+func maskMatch(match string) string {
+	runes := []rune(match)
+	if len(runes) <= maskedPrefixLength {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:maskedPrefixLength]) + strings.Repeat("*", len(runes)-maskedPrefixLength)
+}
@@ -0,0 +1,47 @@
+package moderation
+
+import "backthynk/internal/core/models"
+
+// Sources of moderation findings
+const (
+	SourceRules    = "rules"
+	SourceExternal = "external"
+)
+
+// Finding is a problem reported by a hook on a post
+type Finding struct {
+	Rule  string
+	Match string
+}
+
+type CheckRequest struct {
+	SpaceID   int  `json:"space_id"`
+	Recursive bool `json:"recursive"`
+}
+
+// GateResult tells whether content may be shared and lists the flags blocking it
+type GateResult struct {
+	SpaceID   int                     `json:"space_id"`
+	Recursive bool                    `json:"recursive"`
+	Allowed   bool                    `json:"allowed"`
+	Scanned   int                     `json:"scanned"`
+	Flags     []models.ModerationFlag `json:"flags"`
+}
+
+type ReviewRequest struct {
+	Status string `json:"status"`
+}
+
+// ExternalRequest is the payload posted to the external moderation API for each post
+type ExternalRequest struct {
+	PostID  int    `json:"post_id"`
+	SpaceID int    `json:"space_id"`
+	Content string `json:"content"`
+}
+
+// ExternalResponse is the verdict expected back from the external moderation API
+type ExternalResponse struct {
+	Flagged bool     `json:"flagged"`
+	Reasons []string `json:"reasons"`
+	Match   string   `json:"match,omitempty"`
+}
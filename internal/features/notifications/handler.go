@@ -0,0 +1,192 @@
+package notifications
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/notifications", h.GetNotifications).Methods("GET")
+	api.HandleFunc("/notifications/read-all", h.MarkAllRead).Methods("POST")
+	api.HandleFunc("/notifications/{id:[0-9]+}/read", h.MarkRead).Methods("POST")
+
+	if h.service.deliveries == nil {
+		return
+	}
+	api.HandleFunc("/notifications/settings", h.GetSettings).Methods("GET")
+	api.HandleFunc("/notifications/quiet-hours", h.SetQuietHours).Methods("PUT")
+	api.HandleFunc("/notifications/quiet-hours", h.ClearQuietHours).Methods("DELETE")
+	api.HandleFunc("/notifications/mutes/{space_id:[0-9]+}", h.MuteSpace).Methods("PUT")
+	api.HandleFunc("/notifications/mutes/{space_id:[0-9]+}", h.UnmuteSpace).Methods("DELETE")
+}
+
+// GetNotifications handles GET /api/notifications
+// Query parameters:
+// - unread: only unread notifications (default: false)
+// - before_id: page past this notification ID
+// - limit: maximum notifications (default: 50, max: 200)
+func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	beforeID := 0
+	if beforeStr := r.URL.Query().Get("before_id"); beforeStr != "" {
+		id, err := strconv.Atoi(beforeStr)
+		if err != nil || id < 1 {
+			http.Error(w, config.ErrInvalidNotificationID, http.StatusBadRequest)
+			return
+		}
+		beforeID = id
+	}
+
+	limit := config.DefaultNotificationsLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > config.MaxNotificationsLimit {
+			http.Error(w, config.ErrInvalidNotificationsLimit, http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	response, err := h.service.List(unreadOnly, beforeID, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// MarkRead handles POST /api/notifications/{id}/read
+func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidNotificationID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.MarkRead(id); err != nil {
+		if err.Error() == config.ErrNotificationNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MarkAllRead handles POST /api/notifications/read-all?kind=
+func (h *Handler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	marked, err := h.service.MarkAllRead(r.URL.Query().Get("kind"))
+	if err != nil {
+		if err.Error() == config.ErrInvalidNotificationKind {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ReadAllResponse{Marked: marked})
+}
+
+// GetSettings handles GET /api/notifications/settings
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	h.writeSettings(w)
+}
+
+// SetQuietHours handles PUT /api/notifications/quiet-hours
+// Body: {"start": "22:00", "end": "07:00"} in server time; the hours span midnight when end
+// is before start.
+func (h *Handler) SetQuietHours(w http.ResponseWriter, r *http.Request) {
+	var quiet models.QuietHours
+	if err := json.NewDecoder(r.Body).Decode(&quiet); err != nil {
+		http.Error(w, config.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetQuietHours(&quiet); err != nil {
+		if err.Error() == config.ErrInvalidQuietHours {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.writeSettings(w)
+}
+
+// ClearQuietHours handles DELETE /api/notifications/quiet-hours
+// Held notifications and webhook calls are released.
+func (h *Handler) ClearQuietHours(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.SetQuietHours(nil); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.writeSettings(w)
+}
+
+// MuteSpace handles PUT /api/notifications/mutes/{space_id}
+// Notifications, digests and webhook calls about the space and its descendants are dropped.
+func (h *Handler) MuteSpace(w http.ResponseWriter, r *http.Request) {
+	h.setMuted(w, r, true)
+}
+
+// UnmuteSpace handles DELETE /api/notifications/mutes/{space_id}
+func (h *Handler) UnmuteSpace(w http.ResponseWriter, r *http.Request) {
+	h.setMuted(w, r, false)
+}
+
+func (h *Handler) setMuted(w http.ResponseWriter, r *http.Request, muted bool) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["space_id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetMuted(spaceID, muted); err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.writeSettings(w)
+}
+
+func (h *Handler) writeSettings(w http.ResponseWriter) {
+	settings, err := h.service.Settings()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
@@ -0,0 +1,142 @@
+package notifications
+
+import (
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/notifications", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected notification routes NOT to be registered when disabled")
+	}
+}
+
+func serve(router *mux.Router, method, path string) *httptest.ResponseRecorder {
+	return serveBody(router, method, path, "")
+}
+
+func serveBody(router *mux.Router, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestNotificationHandlers(t *testing.T) {
+	db, cleanup := setupNotificationsTestDB(t)
+	defer cleanup()
+	service, dispatcher := setupNotificationsService(t, db)
+
+	raise(t, dispatcher, models.NotificationDigestReady, "digest.2026-10-05")
+	raise(t, dispatcher, models.NotificationQuotaWarning, "ingest.rate_limit.1")
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	w := serve(router, "GET", "/api/notifications?limit=1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list ListResponse
+	json.NewDecoder(w.Body).Decode(&list)
+	if len(list.Notifications) != 1 || list.UnreadCount != 2 || list.NextBeforeID == 0 {
+		t.Fatalf("Unexpected list %+v", list)
+	}
+
+	w = serve(router, "POST", "/api/notifications/"+strconv.Itoa(list.Notifications[0].ID)+"/read")
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", w.Code)
+	}
+	if w = serve(router, "POST", "/api/notifications/999/read"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown notification, got %d", w.Code)
+	}
+
+	w = serve(router, "GET", "/api/notifications?unread=true")
+	json.NewDecoder(w.Body).Decode(&list)
+	if len(list.Notifications) != 1 || list.Notifications[0].Kind != models.NotificationDigestReady {
+		t.Errorf("Expected only the digest notification unread, got %+v", list.Notifications)
+	}
+
+	if w = serve(router, "POST", "/api/notifications/read-all?kind=bogus"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown kind, got %d", w.Code)
+	}
+	w = serve(router, "POST", "/api/notifications/read-all?kind="+models.NotificationDigestReady)
+	var readAll ReadAllResponse
+	json.NewDecoder(w.Body).Decode(&readAll)
+	if w.Code != http.StatusOK || readAll.Marked != 1 {
+		t.Errorf("Expected 1 notification marked read, got %d %+v", w.Code, readAll)
+	}
+
+	for _, path := range []string{"/api/notifications?limit=0", "/api/notifications?limit=201", "/api/notifications?before_id=x"} {
+		if w = serve(router, "GET", path); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestSettingsHandlers(t *testing.T) {
+	db, cleanup := setupNotificationsTestDB(t)
+	defer cleanup()
+	service, _ := setupNotificationsService(t, db)
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+	if w := serve(router, "GET", "/api/notifications/settings"); w.Code != http.StatusNotFound && w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected no settings routes without deliveries, got %d", w.Code)
+	}
+
+	catCache := cache.NewSpaceCache()
+	space, _ := db.CreateSpace("Work", nil, "")
+	catCache.Set(space)
+	service.SetDeliveries(services.NewDeliveries(db, catCache))
+	router = mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	var settings SettingsResponse
+	w := serve(router, "PUT", "/api/notifications/mutes/"+strconv.Itoa(space.ID))
+	json.NewDecoder(w.Body).Decode(&settings)
+	if w.Code != http.StatusOK || len(settings.MutedSpaces) != 1 || settings.MutedSpaces[0] != space.ID {
+		t.Errorf("Expected the space muted, got %d %+v", w.Code, settings)
+	}
+	if w = serve(router, "PUT", "/api/notifications/mutes/999"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown space, got %d", w.Code)
+	}
+
+	w = serveBody(router, "PUT", "/api/notifications/quiet-hours", `{"start":"22:00","end":"07:00"}`)
+	json.NewDecoder(w.Body).Decode(&settings)
+	if w.Code != http.StatusOK || settings.QuietHours == nil || settings.QuietHours.Start != "22:00" {
+		t.Errorf("Expected quiet hours set, got %d %+v", w.Code, settings)
+	}
+	for _, body := range []string{`{"start":"22:00","end":"22:00"}`, `{"start":"late"}`, `not json`} {
+		if w = serveBody(router, "PUT", "/api/notifications/quiet-hours", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+
+	settings = SettingsResponse{}
+	w = serve(router, "DELETE", "/api/notifications/quiet-hours")
+	json.NewDecoder(w.Body).Decode(&settings)
+	if w.Code != http.StatusOK || settings.QuietHours != nil {
+		t.Errorf("Expected quiet hours cleared, got %d %+v", w.Code, settings)
+	}
+
+	w = serve(router, "DELETE", "/api/notifications/mutes/"+strconv.Itoa(space.ID))
+	json.NewDecoder(w.Body).Decode(&settings)
+	if w.Code != http.StatusOK || len(settings.MutedSpaces) != 0 {
+		t.Errorf("Expected the space unmuted, got %d %+v", w.Code, settings)
+	}
+}
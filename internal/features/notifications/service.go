@@ -0,0 +1,313 @@
+package notifications
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service is the notification center. Features raise notifications by dispatching
+// events.NotificationRaised; the service stores them and expires them after the retention period.
+// Notifications about muted spaces are dropped and those raised during quiet hours are held,
+// along with the webhook calls of other features, until the hours end.
+type Service struct {
+	db          *storage.DB
+	retention   time.Duration
+	stop        chan struct{}
+	stopRelease chan struct{}
+	now         func() time.Time
+	enabled     bool
+	deliveries  *services.Deliveries
+	client      *http.Client
+}
+
+func NewService(db *storage.DB, enabled bool, retentionDays int) *Service {
+	if retentionDays <= 0 {
+		retentionDays = config.DefaultNotificationRetentionDays
+	}
+
+	return &Service{
+		db:        db,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+		now:       time.Now,
+		enabled:   enabled,
+		client:    &http.Client{Timeout: config.HTTPTimeout(config.WebhookHTTPTimeout)},
+	}
+}
+
+// SetDeliveries applies the muted spaces and quiet hours of deliveries to notifications
+func (s *Service) SetDeliveries(deliveries *services.Deliveries) {
+	s.deliveries = deliveries
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	switch event.Type {
+	case events.NotificationRaised:
+		data := event.Data.(events.NotificationEvent)
+		if !IsValidKind(data.Kind) {
+			return fmt.Errorf(config.ErrInvalidNotificationKind)
+		}
+		if s.deliveries.Muted(data.SpaceID) {
+			return nil
+		}
+
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal notification: %w", err)
+		}
+		if held, err := s.deliveries.Hold(models.DeliveryNotification, "", payload); held || err != nil {
+			return err
+		}
+		return s.raise(data)
+
+	case events.SpaceDeleted:
+		// The database dropped the mutes of the deleted spaces
+		if s.deliveries != nil {
+			return s.deliveries.Load()
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) raise(data events.NotificationEvent) error {
+	now := s.now().UnixMilli()
+	return s.db.RaiseNotification(&models.Notification{
+		Kind:    data.Kind,
+		Title:   data.Title,
+		Message: data.Message,
+		SpaceID: data.SpaceID,
+		PostID:  data.PostID,
+		Key:     data.Key,
+		Created: now,
+		Updated: now,
+	})
+}
+
+// IsValidKind reports whether kind is a known notification kind
+func IsValidKind(kind string) bool {
+	switch kind {
+	case models.NotificationReminderDue, models.NotificationDigestReady,
+		models.NotificationQuotaWarning, models.NotificationSyncFailure, models.NotificationReviewDue:
+		return true
+	}
+	return false
+}
+
+// List returns notifications newest first along with the unread count
+func (s *Service) List(unreadOnly bool, beforeID, limit int) (*ListResponse, error) {
+	notifications, err := s.db.GetNotifications(unreadOnly, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.db.CountUnreadNotifications()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ListResponse{Notifications: notifications, UnreadCount: unread}
+	if len(notifications) == limit {
+		response.NextBeforeID = notifications[len(notifications)-1].ID
+	}
+	return response, nil
+}
+
+// UnreadCount returns how many notifications are not read
+func (s *Service) UnreadCount() (int, error) {
+	return s.db.CountUnreadNotifications()
+}
+
+// MarkRead marks a notification read; marking a read notification again is a no-op
+func (s *Service) MarkRead(id int) error {
+	if err := s.db.MarkNotificationRead(id, s.now().UnixMilli()); err != nil {
+		if err.Error() == "notification not found" {
+			return fmt.Errorf(config.ErrNotificationNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification, or those of one kind, read
+func (s *Service) MarkAllRead(kind string) (int, error) {
+	if kind != "" && !IsValidKind(kind) {
+		return 0, fmt.Errorf(config.ErrInvalidNotificationKind)
+	}
+	return s.db.MarkAllNotificationsRead(kind, s.now().UnixMilli())
+}
+
+// Settings returns the muted spaces and the quiet hours
+func (s *Service) Settings() (*SettingsResponse, error) {
+	held, err := s.db.CountHeldDeliveries()
+	if err != nil {
+		return nil, err
+	}
+
+	return &SettingsResponse{
+		MutedSpaces: s.deliveries.MutedSpaces(),
+		QuietHours:  s.deliveries.QuietHours(),
+		Quiet:       s.deliveries.Quiet(),
+		Held:        held,
+	}, nil
+}
+
+// SetMuted mutes or unmutes the notifications and webhook calls about a space and its descendants
+func (s *Service) SetMuted(spaceID int, muted bool) error {
+	return s.deliveries.SetMuted(spaceID, muted)
+}
+
+// SetQuietHours replaces the quiet hours; nil removes them and releases what they held
+func (s *Service) SetQuietHours(quiet *models.QuietHours) error {
+	if err := s.deliveries.SetQuietHours(quiet); err != nil {
+		return err
+	}
+	s.ReleaseHeld()
+	return nil
+}
+
+// ReleaseHeld delivers the held notifications and webhook calls once quiet hours are over.
+// Webhook calls that fail are retried on the next release, up to MaxHeldDeliveryAttempts.
+func (s *Service) ReleaseHeld() {
+	if s.deliveries.Quiet() {
+		return
+	}
+
+	held, err := s.db.GetHeldDeliveries()
+	if err != nil {
+		logger.Warning("Failed to load held deliveries", zap.Error(err))
+		return
+	}
+
+	for _, delivery := range held {
+		if err := s.release(delivery); err != nil {
+			logger.Warning("Failed to release held delivery", zap.Int("delivery_id", delivery.ID), zap.String("channel", delivery.Channel), zap.Error(err))
+			if delivery.Attempts+1 < config.MaxHeldDeliveryAttempts {
+				s.db.RecordHeldDeliveryAttempt(delivery.ID)
+				continue
+			}
+			s.raise(events.NotificationEvent{
+				Kind:    models.NotificationSyncFailure,
+				Title:   "Held delivery dropped",
+				Message: fmt.Sprintf("A %s held during quiet hours failed %d times and was dropped (%v).", delivery.Channel, config.MaxHeldDeliveryAttempts, err),
+				Key:     "notifications.held_webhook",
+			})
+		}
+		if err := s.db.DeleteHeldDelivery(delivery.ID); err != nil {
+			logger.Warning("Failed to clear released delivery", zap.Int("delivery_id", delivery.ID), zap.Error(err))
+		}
+	}
+}
+
+func (s *Service) release(delivery models.HeldDelivery) error {
+	switch delivery.Channel {
+	case models.DeliveryNotification:
+		var data events.NotificationEvent
+		if err := json.Unmarshal(delivery.Payload, &data); err != nil {
+			return fmt.Errorf("failed to read held notification: %w", err)
+		}
+		return s.raise(data)
+
+	case models.DeliveryWebhook:
+		resp, err := s.client.Post(delivery.Target, "application/json", bytes.NewReader(delivery.Payload))
+		if err != nil {
+			return fmt.Errorf("failed to call webhook: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// StartRelease releases held deliveries immediately and then once per interval until Stop is called
+func (s *Service) StartRelease(interval time.Duration) {
+	if !s.enabled || s.deliveries == nil || s.stopRelease != nil {
+		return
+	}
+
+	s.ReleaseHeld()
+
+	s.stopRelease = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.ReleaseHeld()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stopRelease)
+}
+
+// Purge removes notifications untouched for longer than the retention period
+func (s *Service) Purge() (int, error) {
+	return s.db.DeleteNotificationsBefore(s.now().Add(-s.retention).UnixMilli())
+}
+
+// StartPurge purges expired notifications immediately and then once per interval until Stop is called
+func (s *Service) StartPurge(interval time.Duration) {
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	s.runPurge()
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.runPurge()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+func (s *Service) runPurge() {
+	count, err := s.Purge()
+	if err != nil {
+		logger.Warning("Failed to purge notifications", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		logger.Info("Purged expired notifications", zap.Int("count", count))
+	}
+}
+
+// Stop ends the periodic purge and release
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	if s.stopRelease != nil {
+		close(s.stopRelease)
+		s.stopRelease = nil
+	}
+}
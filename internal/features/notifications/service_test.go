@@ -0,0 +1,271 @@
+package notifications
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func setupNotificationsTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_notifications_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func raise(t *testing.T, dispatcher *events.Dispatcher, kind, key string) {
+	t.Helper()
+	dispatcher.Notify(events.NotificationEvent{Kind: kind, Title: "Title", Message: "Message", Key: key})
+}
+
+func setupNotificationsService(t *testing.T, db *storage.DB) (*Service, *events.Dispatcher) {
+	service := NewService(db, true, 7)
+	dispatcher := events.NewDispatcher()
+	dispatcher.Subscribe(events.NotificationRaised, service.HandleEvent)
+	return service, dispatcher
+}
+
+func TestRaiseDeduplicatesUnreadByKey(t *testing.T) {
+	db, cleanup := setupNotificationsTestDB(t)
+	defer cleanup()
+	service, dispatcher := setupNotificationsService(t, db)
+
+	raise(t, dispatcher, models.NotificationQuotaWarning, "ingest.rate_limit.1")
+	raise(t, dispatcher, models.NotificationQuotaWarning, "ingest.rate_limit.1")
+	raise(t, dispatcher, models.NotificationSyncFailure, "")
+	raise(t, dispatcher, models.NotificationSyncFailure, "")
+
+	list, err := service.List(false, 0, 10)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list.Notifications) != 3 || list.UnreadCount != 3 {
+		t.Fatalf("Expected 3 unread notifications, got %+v", list)
+	}
+	if last := list.Notifications[2]; last.Kind != models.NotificationQuotaWarning || last.Count != 2 {
+		t.Errorf("Expected the keyed notification to be counted twice, got %+v", last)
+	}
+
+	// Once read, the same key raises a new notification
+	service.MarkRead(list.Notifications[2].ID)
+	raise(t, dispatcher, models.NotificationQuotaWarning, "ingest.rate_limit.1")
+	list, _ = service.List(false, 0, 10)
+	if len(list.Notifications) != 4 || list.Notifications[0].Count != 1 {
+		t.Errorf("Expected a fresh notification after reading, got %+v", list.Notifications)
+	}
+
+	if err := service.HandleEvent(events.Event{
+		Type: events.NotificationRaised,
+		Data: events.NotificationEvent{Kind: "unknown"},
+	}); err == nil {
+		t.Error("Expected unknown kinds to be refused")
+	}
+}
+
+func TestListPaging(t *testing.T) {
+	db, cleanup := setupNotificationsTestDB(t)
+	defer cleanup()
+	service, dispatcher := setupNotificationsService(t, db)
+
+	for i := 0; i < 5; i++ {
+		raise(t, dispatcher, models.NotificationReminderDue, "")
+	}
+
+	first, _ := service.List(false, 0, 3)
+	if len(first.Notifications) != 3 || first.NextBeforeID == 0 {
+		t.Fatalf("Expected a full first page with a cursor, got %+v", first)
+	}
+	second, _ := service.List(false, first.NextBeforeID, 3)
+	if len(second.Notifications) != 2 || second.NextBeforeID != 0 {
+		t.Fatalf("Expected a last page of 2 without cursor, got %+v", second)
+	}
+	if second.Notifications[0].ID >= first.Notifications[2].ID {
+		t.Error("Expected pages to continue newest first")
+	}
+
+	service.MarkRead(first.Notifications[0].ID)
+	unread, _ := service.List(true, 0, 10)
+	if len(unread.Notifications) != 4 || unread.UnreadCount != 4 {
+		t.Errorf("Expected 4 unread notifications, got %+v", unread)
+	}
+}
+
+func TestMarkRead(t *testing.T) {
+	db, cleanup := setupNotificationsTestDB(t)
+	defer cleanup()
+	service, dispatcher := setupNotificationsService(t, db)
+
+	raise(t, dispatcher, models.NotificationDigestReady, "digest.2026-10-05")
+	raise(t, dispatcher, models.NotificationSyncFailure, "search.embeddings")
+	raise(t, dispatcher, models.NotificationSyncFailure, "summaries.digest_webhook")
+	list, _ := service.List(false, 0, 10)
+
+	if err := service.MarkRead(list.Notifications[0].ID); err != nil {
+		t.Fatalf("MarkRead failed: %v", err)
+	}
+	if err := service.MarkRead(list.Notifications[0].ID); err != nil {
+		t.Errorf("Expected marking a read notification to be a no-op, got %v", err)
+	}
+	if err := service.MarkRead(999); err == nil || err.Error() != config.ErrNotificationNotFound {
+		t.Errorf("Expected not found, got %v", err)
+	}
+
+	if _, err := service.MarkAllRead("unknown"); err == nil || err.Error() != config.ErrInvalidNotificationKind {
+		t.Errorf("Expected invalid kind, got %v", err)
+	}
+	marked, err := service.MarkAllRead(models.NotificationSyncFailure)
+	if err != nil || marked != 1 {
+		t.Errorf("Expected 1 sync failure marked read, got %d (%v)", marked, err)
+	}
+	marked, _ = service.MarkAllRead("")
+	if marked != 1 {
+		t.Errorf("Expected the digest notification marked read, got %d", marked)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	db, cleanup := setupNotificationsTestDB(t)
+	defer cleanup()
+	service, dispatcher := setupNotificationsService(t, db)
+
+	now := time.Now()
+	service.now = func() time.Time { return now.AddDate(0, 0, -8) }
+	raise(t, dispatcher, models.NotificationQuotaWarning, "old")
+	raise(t, dispatcher, models.NotificationQuotaWarning, "refreshed")
+	service.now = func() time.Time { return now }
+	raise(t, dispatcher, models.NotificationQuotaWarning, "refreshed")
+	raise(t, dispatcher, models.NotificationReminderDue, "")
+
+	purged, err := service.Purge()
+	if err != nil || purged != 1 {
+		t.Fatalf("Expected 1 expired notification purged, got %d (%v)", purged, err)
+	}
+	list, _ := service.List(false, 0, 10)
+	if len(list.Notifications) != 2 {
+		t.Errorf("Expected refreshed notifications to be kept, got %+v", list.Notifications)
+	}
+}
+
+// quietNow returns quiet hours in effect for the next hour
+func quietNow() *models.QuietHours {
+	now := time.Now()
+	return &models.QuietHours{
+		Start: now.Add(-time.Hour).Format(config.QuietHoursTimeLayout),
+		End:   now.Add(time.Hour).Format(config.QuietHoursTimeLayout),
+	}
+}
+
+func TestMutesAndQuietHours(t *testing.T) {
+	db, cleanup := setupNotificationsTestDB(t)
+	defer cleanup()
+	service, dispatcher := setupNotificationsService(t, db)
+
+	catCache := cache.NewSpaceCache()
+	work, _ := db.CreateSpace("Work", nil, "")
+	catCache.Set(work)
+	home, _ := db.CreateSpace("Home", nil, "")
+	catCache.Set(home)
+	deliveries := services.NewDeliveries(db, catCache)
+	service.SetDeliveries(deliveries)
+
+	t.Run("muted spaces raise nothing", func(t *testing.T) {
+		service.SetMuted(work.ID, true)
+		defer service.SetMuted(work.ID, false)
+
+		dispatcher.Notify(events.NotificationEvent{Kind: models.NotificationReviewDue, Title: "Review Work", SpaceID: work.ID})
+		dispatcher.Notify(events.NotificationEvent{Kind: models.NotificationReviewDue, Title: "Review Home", SpaceID: home.ID})
+		list, _ := service.List(false, 0, 10)
+		if len(list.Notifications) != 1 || list.Notifications[0].SpaceID != home.ID {
+			t.Errorf("Expected only the Home notification, got %+v", list.Notifications)
+		}
+		service.MarkAllRead("")
+	})
+
+	t.Run("quiet hours hold deliveries until they end", func(t *testing.T) {
+		calls := 0
+		hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer hook.Close()
+
+		if err := service.SetQuietHours(quietNow()); err != nil {
+			t.Fatalf("SetQuietHours failed: %v", err)
+		}
+		raise(t, dispatcher, models.NotificationReminderDue, "held")
+		if held, err := deliveries.HoldWebhook(hook.URL, []byte(`{"event":"test"}`)); !held || err != nil {
+			t.Fatalf("Expected the webhook call to be held, got %v (%v)", held, err)
+		}
+
+		settings, _ := service.Settings()
+		if !settings.Quiet || settings.Held != 2 {
+			t.Fatalf("Expected 2 held deliveries during quiet hours, got %+v", settings)
+		}
+		if unread, _ := service.UnreadCount(); unread != 0 {
+			t.Errorf("Expected the held notification not to be raised yet, got %d unread", unread)
+		}
+
+		service.ReleaseHeld()
+		if calls != 0 {
+			t.Error("Expected nothing released during quiet hours")
+		}
+
+		if err := service.SetQuietHours(nil); err != nil {
+			t.Fatalf("Failed to clear quiet hours: %v", err)
+		}
+		settings, _ = service.Settings()
+		if unread, _ := service.UnreadCount(); unread != 1 || calls != 1 || settings.Held != 0 {
+			t.Errorf("Expected everything released, got %d unread, %d calls, %+v", unread, calls, settings)
+		}
+	})
+
+	t.Run("failing webhook calls are dropped after retries", func(t *testing.T) {
+		hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer hook.Close()
+
+		deliveries.SetQuietHours(quietNow())
+		deliveries.HoldWebhook(hook.URL, []byte(`{}`))
+		deliveries.SetQuietHours(nil)
+
+		for i := 1; i < config.MaxHeldDeliveryAttempts; i++ {
+			service.ReleaseHeld()
+			if held, _ := db.CountHeldDeliveries(); held != 1 {
+				t.Fatalf("Expected the call kept for a retry after %d attempts", i)
+			}
+		}
+		service.ReleaseHeld()
+		if held, _ := db.CountHeldDeliveries(); held != 0 {
+			t.Errorf("Expected the call dropped after %d attempts", config.MaxHeldDeliveryAttempts)
+		}
+
+		list, _ := service.List(true, 0, 10)
+		if len(list.Notifications) == 0 || list.Notifications[0].Kind != models.NotificationSyncFailure {
+			t.Errorf("Expected a sync failure notification, got %+v", list.Notifications)
+		}
+	})
+}
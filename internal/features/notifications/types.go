@@ -0,0 +1,20 @@
+package notifications
+
+import "backthynk/internal/core/models"
+
+type ListResponse struct {
+	Notifications []models.Notification `json:"notifications"`
+	UnreadCount   int                   `json:"unread_count"`
+	NextBeforeID  int                   `json:"next_before_id,omitempty"` // Pass as before_id for the next page
+}
+
+type ReadAllResponse struct {
+	Marked int `json:"marked"`
+}
+
+type SettingsResponse struct {
+	MutedSpaces []int              `json:"muted_spaces"`
+	QuietHours  *models.QuietHours `json:"quiet_hours"` // null when deliveries are never held
+	Quiet       bool               `json:"quiet"`       // Whether quiet hours are in effect now
+	Held        int                `json:"held"`        // Notifications and webhook calls waiting for quiet hours to end
+}
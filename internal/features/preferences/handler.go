@@ -0,0 +1,100 @@
+package preferences
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/preferences", h.GetPreferences).Methods("GET")
+	api.HandleFunc("/preferences", h.UpdatePreferences).Methods("PUT")
+	api.HandleFunc("/preferences", h.ResetPreferences).Methods("DELETE")
+	api.HandleFunc("/admin/preferences", h.UpdateInstancePreferences).Methods("PUT")
+}
+
+// GetPreferences handles GET /api/preferences, the effective preferences of the caller and
+// the scope they come from
+func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	prefs, err := h.service.Get(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// UpdatePreferences handles PUT /api/preferences, for the signed in user or, without login,
+// for the instance
+func (h *Handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	var req UpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := h.service.Set(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+// ResetPreferences handles DELETE /api/preferences, falling back to the instance preferences
+func (h *Handler) ResetPreferences(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Reset(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateInstancePreferences handles PUT /api/admin/preferences, the preferences of users who
+// did not set their own
+func (h *Handler) UpdateInstancePreferences(w http.ResponseWriter, r *http.Request) {
+	var req UpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	prefs, err := h.service.SetInstance(req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prefs)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case config.ErrSpaceNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case config.ErrInvalidLandingView, config.ErrLandingSpaceRequired, config.ErrLandingSpaceNotAllowed:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,70 @@
+package preferences
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/preferences", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected preferences routes NOT to be registered when disabled")
+	}
+}
+
+func TestPreferencesHandlers(t *testing.T) {
+	service, space, cleanup := setupPreferencesTest(t)
+	defer cleanup()
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	serve := func(method, path, body string) (*httptest.ResponseRecorder, models.Preferences) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		var prefs models.Preferences
+		json.Unmarshal(w.Body.Bytes(), &prefs)
+		return w, prefs
+	}
+
+	if w, _ := serve("PUT", "/api/preferences", "invalid json"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for invalid JSON, got %d", w.Code)
+	}
+	if w, _ := serve("PUT", "/api/preferences", `{"landing_view": "space", "landing_space_id": 999}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown space, got %d", w.Code)
+	}
+	if w, _ := serve("PUT", "/api/preferences", `{"landing_view": "calendar"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown view, got %d", w.Code)
+	}
+
+	// Without login, preferences are the instance ones
+	body := fmt.Sprintf(`{"landing_view": "space", "landing_space_id": %d}`, space.ID)
+	if w, prefs := serve("PUT", "/api/preferences", body); w.Code != http.StatusOK || prefs.Scope != models.PreferenceScopeInstance {
+		t.Fatalf("Expected status 200 with instance scope, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, prefs := serve("GET", "/api/preferences", ""); prefs.LandingView != config.LandingViewSpace || prefs.LandingSpaceID == nil || *prefs.LandingSpaceID != space.ID {
+		t.Errorf("Expected the landing space to be returned, got %+v", prefs)
+	}
+
+	if w, _ := serve("PUT", "/api/admin/preferences", `{"landing_view": "inbox"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for instance preferences, got %d", w.Code)
+	}
+	if w, _ := serve("DELETE", "/api/preferences", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	if _, prefs := serve("GET", "/api/preferences", ""); prefs.Scope != models.PreferenceScopeDefault {
+		t.Errorf("Expected defaults after reset, got %+v", prefs)
+	}
+}
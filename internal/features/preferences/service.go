@@ -0,0 +1,129 @@
+package preferences
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"time"
+)
+
+// UserLookup returns the user signed in for a request, nil without login. The auth feature
+// provides one; without it every request shares the instance preferences.
+type UserLookup func(ctx context.Context) *models.User
+
+// Service keeps preferences server-side so they follow a user across browsers. A user's own
+// preferences override the instance ones, which override the defaults.
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	enabled  bool
+	user     UserLookup
+	now      func() time.Time
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		enabled:  enabled,
+		now:      time.Now,
+	}
+}
+
+// SetUserLookup makes preferences personal to the signed in user
+func (s *Service) SetUserLookup(lookup UserLookup) {
+	s.user = lookup
+}
+
+// ownerID returns the user whose preferences a request reads and writes, the instance ones
+// without login
+func (s *Service) ownerID(ctx context.Context) int {
+	if s.user != nil {
+		if user := s.user(ctx); user != nil {
+			return user.ID
+		}
+	}
+	return storage.InstancePreferencesID
+}
+
+// Get returns the effective preferences of a request. Stored preferences landing on a space
+// that no longer exists are skipped for the next scope.
+func (s *Service) Get(ctx context.Context) (*models.Preferences, error) {
+	owners := []int{s.ownerID(ctx)}
+	if owners[0] != storage.InstancePreferencesID {
+		owners = append(owners, storage.InstancePreferencesID)
+	}
+
+	for _, ownerID := range owners {
+		prefs, err := s.db.GetPreferences(ownerID)
+		if err != nil {
+			if err.Error() == "preferences not found" {
+				continue
+			}
+			return nil, err
+		}
+		if prefs.LandingSpaceID != nil {
+			if _, ok := s.catCache.Get(*prefs.LandingSpaceID); !ok {
+				continue
+			}
+		}
+		prefs.Scope = scopeOf(ownerID)
+		return prefs, nil
+	}
+
+	return &models.Preferences{LandingView: config.DefaultLandingView, Scope: models.PreferenceScopeDefault}, nil
+}
+
+// Set stores the preferences of the signed in user, or the instance ones without login
+func (s *Service) Set(ctx context.Context, req UpdateRequest) (*models.Preferences, error) {
+	return s.set(s.ownerID(ctx), req)
+}
+
+// SetInstance stores the preferences of users who did not set their own
+func (s *Service) SetInstance(req UpdateRequest) (*models.Preferences, error) {
+	return s.set(storage.InstancePreferencesID, req)
+}
+
+// Reset drops the preferences of the signed in user, or the instance ones without login
+func (s *Service) Reset(ctx context.Context) error {
+	return s.db.DeletePreferences(s.ownerID(ctx))
+}
+
+func (s *Service) set(ownerID int, req UpdateRequest) (*models.Preferences, error) {
+	switch req.LandingView {
+	case config.LandingViewSpace:
+		if req.LandingSpaceID == nil {
+			return nil, fmt.Errorf(config.ErrLandingSpaceRequired)
+		}
+		if _, ok := s.catCache.Get(*req.LandingSpaceID); !ok {
+			return nil, fmt.Errorf(config.ErrSpaceNotFound)
+		}
+	case config.LandingViewTimeline, config.LandingViewInbox, config.LandingViewDashboard:
+		if req.LandingSpaceID != nil {
+			return nil, fmt.Errorf(config.ErrLandingSpaceNotAllowed)
+		}
+	default:
+		return nil, fmt.Errorf(config.ErrInvalidLandingView)
+	}
+
+	prefs := models.Preferences{
+		LandingView:    req.LandingView,
+		LandingSpaceID: req.LandingSpaceID,
+		Scope:          scopeOf(ownerID),
+		Updated:        s.now().UnixMilli(),
+	}
+	if err := s.db.SetPreferences(ownerID, prefs); err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+func scopeOf(ownerID int) string {
+	if ownerID == storage.InstancePreferencesID {
+		return models.PreferenceScopeInstance
+	}
+	return models.PreferenceScopeUser
+}
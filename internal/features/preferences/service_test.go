@@ -0,0 +1,125 @@
+package preferences
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"testing"
+)
+
+func setupPreferencesTest(t *testing.T) (*Service, *models.Space, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_preferences_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	catCache := cache.NewSpaceCache()
+	space, _ := db.CreateSpace("Inbox", nil, "")
+	catCache.Set(space)
+
+	return NewService(db, catCache, true), space, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+type userKey struct{}
+
+// withUser signs a request in for the user lookup set by setupUsers
+func withUser(id int) context.Context {
+	return context.WithValue(context.Background(), userKey{}, &models.User{ID: id})
+}
+
+func setupUsers(service *Service) {
+	service.SetUserLookup(func(ctx context.Context) *models.User {
+		user, _ := ctx.Value(userKey{}).(*models.User)
+		return user
+	})
+}
+
+func TestGetDefaults(t *testing.T) {
+	service, _, cleanup := setupPreferencesTest(t)
+	defer cleanup()
+
+	prefs, err := service.Get(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefs.LandingView != config.DefaultLandingView || prefs.Scope != models.PreferenceScopeDefault {
+		t.Errorf("Expected default landing view, got %+v", prefs)
+	}
+}
+
+func TestSetValidation(t *testing.T) {
+	service, space, cleanup := setupPreferencesTest(t)
+	defer cleanup()
+
+	missing := 999
+	tests := []struct {
+		name     string
+		req      UpdateRequest
+		expected string
+	}{
+		{"unknown view", UpdateRequest{LandingView: "calendar"}, config.ErrInvalidLandingView},
+		{"space without ID", UpdateRequest{LandingView: config.LandingViewSpace}, config.ErrLandingSpaceRequired},
+		{"missing space", UpdateRequest{LandingView: config.LandingViewSpace, LandingSpaceID: &missing}, config.ErrSpaceNotFound},
+		{"timeline with space", UpdateRequest{LandingView: config.LandingViewTimeline, LandingSpaceID: &space.ID}, config.ErrLandingSpaceNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.Set(context.Background(), tt.req); err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestUserOverridesInstance(t *testing.T) {
+	service, space, cleanup := setupPreferencesTest(t)
+	defer cleanup()
+	setupUsers(service)
+
+	if _, err := service.SetInstance(UpdateRequest{LandingView: config.LandingViewDashboard}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Set(withUser(1), UpdateRequest{LandingView: config.LandingViewSpace, LandingSpaceID: &space.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	prefs, _ := service.Get(withUser(1))
+	if prefs.LandingView != config.LandingViewSpace || *prefs.LandingSpaceID != space.ID || prefs.Scope != models.PreferenceScopeUser {
+		t.Errorf("Expected the user's landing space, got %+v", prefs)
+	}
+	prefs, _ = service.Get(withUser(2))
+	if prefs.LandingView != config.LandingViewDashboard || prefs.Scope != models.PreferenceScopeInstance {
+		t.Errorf("Expected the instance landing view for another user, got %+v", prefs)
+	}
+
+	// A landing space deleted since falls back to the instance preferences
+	service.catCache.Delete(space.ID)
+	prefs, _ = service.Get(withUser(1))
+	if prefs.Scope != models.PreferenceScopeInstance {
+		t.Errorf("Expected the instance preferences once the space is gone, got %+v", prefs)
+	}
+
+	if err := service.Reset(withUser(1)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.db.GetPreferences(1); err == nil {
+		t.Error("Expected the user's preferences to be dropped")
+	}
+}
@@ -0,0 +1,7 @@
+package preferences
+
+// UpdateRequest sets the landing view; LandingSpaceID goes with the space view only
+type UpdateRequest struct {
+	LandingView    string `json:"landing_view"`
+	LandingSpaceID *int   `json:"landing_space_id,omitempty"`
+}
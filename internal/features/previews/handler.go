@@ -0,0 +1,70 @@
+package previews
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/files/{id:[0-9]+}/preview", h.GetPreview).Methods("GET", "HEAD")
+	api.HandleFunc("/previews/status", h.GetStatus).Methods("GET")
+}
+
+// GetPreview handles GET and HEAD /api/files/{id}/preview, the JPEG preview of an attachment.
+// Files without preview, or whose preview could not be generated, answer 404.
+func (h *Handler) GetPreview(w http.ResponseWriter, r *http.Request) {
+	attachmentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidAttachmentID, http.StatusBadRequest)
+		return
+	}
+
+	preview, path, err := h.service.Get(r.Context(), attachmentID)
+	if err != nil {
+		switch err.Error() {
+		case "attachment not found":
+			http.Error(w, config.ErrAttachmentNotFound, http.StatusNotFound)
+		case config.ErrPreviewUnavailable:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		http.Error(w, config.ErrPreviewUnavailable, http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set(config.PreviewKindHeader, preview.Kind)
+	http.ServeContent(w, r, "", time.UnixMilli(preview.Created), file)
+}
+
+// GetStatus handles GET /api/previews/status
+func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.Status())
+}
@@ -0,0 +1,66 @@
+package previews
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/files/1/preview", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected preview routes NOT to be registered when disabled")
+	}
+}
+
+func TestPreviewHandlers(t *testing.T) {
+	setup, cleanup := setupPreviewTest(t)
+	defer cleanup()
+
+	image := setup.upload(t, "photo.png", encodePNG(t, 600, 900))
+	document := setup.upload(t, "report.pdf", []byte("%PDF-1.4"))
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"Image", fmt.Sprintf("/api/files/%d/preview", image), http.StatusOK},
+		{"Document without renderer", fmt.Sprintf("/api/files/%d/preview", document), http.StatusNotFound},
+		{"Unknown attachment", "/api/files/999/preview", http.StatusNotFound},
+		{"Status", "/api/previews/status", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			switch tt.name {
+			case "Image":
+				if w.Header().Get("Content-Type") != "image/jpeg" || w.Header().Get(config.PreviewKindHeader) != config.PreviewKindImage || w.Body.Len() == 0 {
+					t.Errorf("Expected a JPEG image preview, got %v", w.Header())
+				}
+			case "Status":
+				var status Status
+				json.Unmarshal(w.Body.Bytes(), &status)
+				if !status.Images || status.PDFs || status.MaxSize != config.DefaultPreviewMaxSize {
+					t.Errorf("Unexpected status: %+v", status)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package previews
+
+import (
+	"backthynk/internal/config"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+)
+
+// reduceImage writes a JPEG copy of the image at source, reduced to fit maxSize, at out and
+// returns its size. Smaller images keep their size.
+func (s *Service) reduceImage(source, out string) (int, int, error) {
+	file, err := os.Open(source)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	header, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read image: %w", err)
+	}
+	if header.Width*header.Height > config.MaxPreviewPixels {
+		return 0, 0, fmt.Errorf("image of %dx%d pixels is too large to preview", header.Width, header.Height)
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return 0, 0, err
+	}
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	reduced := reduce(img, s.maxSize)
+	dst, err := os.OpenFile(out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, config.FilePermissions)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create preview: %w", err)
+	}
+	if err := jpeg.Encode(dst, reduced, &jpeg.Options{Quality: config.PreviewJPEGQuality}); err != nil {
+		dst.Close()
+		return 0, 0, fmt.Errorf("failed to encode preview: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return 0, 0, fmt.Errorf("failed to write preview: %w", err)
+	}
+
+	bounds := reduced.Bounds()
+	return bounds.Dx(), bounds.Dy(), nil
+}
+
+// reduce returns img scaled down to fit maxSize on its longer side, every pixel the average
+// of the box of source pixels it covers. Transparent parts are laid over white, as JPEG
+// has no alpha.
+func reduce(img image.Image, maxSize int) *image.RGBA {
+	bounds := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Over)
+
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	if width <= maxSize && height <= maxSize {
+		return src
+	}
+	outWidth, outHeight := maxSize, max(1, height*maxSize/width)
+	if height > width {
+		outWidth, outHeight = max(1, width*maxSize/height), maxSize
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, outWidth, outHeight))
+	for y := 0; y < outHeight; y++ {
+		y0, y1 := y*height/outHeight, max((y+1)*height/outHeight, y*height/outHeight+1)
+		for x := 0; x < outWidth; x++ {
+			x0, x1 := x*width/outWidth, max((x+1)*width/outWidth, x*width/outWidth+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[src.PixOffset(x0, sy):src.PixOffset(x1, sy)]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			offset := out.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				out.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,306 @@
+package previews
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/jobs"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Extensions of the videos previewed when their MIME type is not known to the system
+var videoExtensions = map[string]bool{".mp4": true, ".m4v": true, ".mov": true, ".webm": true, ".mkv": true, ".avi": true}
+
+// Service generates the preview images of attachments when they are uploaded, or when one is
+// first asked for. Images are reduced in process; the first page of PDFs and a frame of videos
+// are rendered by pdftoppm and ffmpeg when the documents option is on. Without those tools the
+// kinds they handle have no preview, everything else keeps working.
+type Service struct {
+	db         *storage.DB
+	files      *services.FileService
+	dispatcher *events.Dispatcher
+	budget     *jobs.Budget
+	access     *services.SpaceAccess
+	uploadsDir string
+	dir        string
+	maxSize    int
+	pdftoppm   string // Resolved paths, empty when unavailable
+	ffmpeg     string
+	missing    []string
+	mu         sync.Mutex // Serializes generation
+	now        func() time.Time
+	enabled    bool
+}
+
+func NewService(db *storage.DB, files *services.FileService, enabled bool, maxSize int) *Service {
+	if maxSize <= 0 {
+		maxSize = config.DefaultPreviewMaxSize
+	}
+
+	return &Service{
+		db:         db,
+		files:      files,
+		uploadsDir: filepath.Join(config.GetServiceConfig().Files.StoragePath, config.GetServiceConfig().Files.UploadsSubdir),
+		dir:        filepath.Join(config.GetServiceConfig().Files.StoragePath, config.PreviewsSubdir),
+		maxSize:    maxSize,
+		now:        time.Now,
+		enabled:    enabled,
+	}
+}
+
+// SetDispatcher lets the service announce the previews it generates
+func (s *Service) SetDispatcher(dispatcher *events.Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// SetBudget runs the generation of previews on upload as background jobs
+func (s *Service) SetBudget(budget *jobs.Budget) {
+	s.budget = budget
+}
+
+// SetSpaceAccess hides the previews of files in spaces the viewer may not read
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// SetTools enables PDF and video previews with the given binaries, looked up in PATH when
+// not absolute. A missing tool is reported once and its kind is left without preview.
+func (s *Service) SetTools(pdfRenderer, ffmpeg string) {
+	s.pdftoppm = s.lookTool(pdfRenderer, config.DefaultPDFRenderer)
+	s.ffmpeg = s.lookTool(ffmpeg, config.DefaultFFmpeg)
+}
+
+func (s *Service) lookTool(name, fallback string) string {
+	if name == "" {
+		name = fallback
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		logger.Warning("Preview tool not found, its files get no preview", zap.String("tool", name), zap.Error(err))
+		s.missing = append(s.missing, name)
+		return ""
+	}
+	return path
+}
+
+// Initialize creates the previews directory and removes the files of previews whose
+// attachment was deleted
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	if err := os.MkdirAll(s.dir, config.DirectoryPermissions); err != nil {
+		return fmt.Errorf("failed to create previews directory: %w", err)
+	}
+	ids, err := s.db.GetAttachmentPreviewIDs()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read previews directory: %w", err)
+	}
+	for _, entry := range entries {
+		id, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".jpg"))
+		if err == nil && ids[id] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil {
+			logger.Warning("Failed to remove orphan preview", zap.String("file", entry.Name()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// HandleEvent generates the preview of uploaded and replaced files
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled || event.Type != events.FileUploaded {
+		return nil
+	}
+
+	data, ok := event.Data.(events.PostEvent)
+	if !ok || data.AttachmentID == 0 {
+		return nil
+	}
+	return s.budget.Run(context.Background(), "attachment-preview", func(job *jobs.Job) error {
+		_, err := s.Generate(data.AttachmentID)
+		return err
+	})
+}
+
+// Get returns the preview of an attachment and the path of its image, generating it when
+// missing
+func (s *Service) Get(ctx context.Context, attachmentID int) (*models.AttachmentPreview, string, error) {
+	attachment, spaceID, err := s.db.GetAttachment(attachmentID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !s.access.CanRead(ctx, spaceID) {
+		return nil, "", fmt.Errorf("attachment not found")
+	}
+
+	preview, err := s.db.GetAttachmentPreview(attachment.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	path := s.path(attachment.ID)
+	if preview != nil {
+		if _, err := os.Stat(path); err == nil {
+			return preview, path, nil
+		}
+	}
+
+	preview, err = s.Generate(attachment.ID)
+	if err != nil {
+		logger.Warning("Failed to generate preview", zap.Int("attachment_id", attachment.ID), zap.Error(err))
+		return nil, "", fmt.Errorf(config.ErrPreviewUnavailable)
+	}
+	if preview == nil {
+		return nil, "", fmt.Errorf(config.ErrPreviewUnavailable)
+	}
+	return preview, path, nil
+}
+
+// Generate renders the preview of an attachment from its current file. Files of a kind
+// without preview lose the one they may have had; nil is returned for them.
+func (s *Service) Generate(attachmentID int) (*models.AttachmentPreview, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attachment, spaceID, err := s.db.GetAttachment(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	kind := s.kindOf(attachment)
+	if kind == "" {
+		os.Remove(s.path(attachment.ID))
+		return nil, s.db.DeleteAttachmentPreview(attachment.ID)
+	}
+
+	// Brings the file back from the cold tier
+	if _, err := s.files.GetDownload(attachment.FilePath); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.dir, config.DirectoryPermissions); err != nil {
+		return nil, fmt.Errorf("failed to create previews directory: %w", err)
+	}
+
+	source := filepath.Join(s.uploadsDir, attachment.FilePath)
+	temp := filepath.Join(s.dir, strconv.Itoa(attachment.ID)+".tmp.jpg")
+	defer os.Remove(temp)
+
+	var width, height int
+	switch kind {
+	case config.PreviewKindImage:
+		width, height, err = s.reduceImage(source, temp)
+	case config.PreviewKindPDF:
+		width, height, err = s.runTool(temp, s.pdftoppm,
+			"-f", "1", "-l", "1", "-singlefile", "-jpeg", "-jpegopt", fmt.Sprintf("quality=%d", config.PreviewJPEGQuality),
+			"-scale-to", strconv.Itoa(s.maxSize), source, strings.TrimSuffix(temp, ".jpg"))
+	case config.PreviewKindVideo:
+		// thumbnail picks a representative frame among the first ones rather than a black
+		// opening frame
+		scale := fmt.Sprintf("thumbnail,scale=w='min(%d,iw)':h='min(%d,ih)':force_original_aspect_ratio=decrease", s.maxSize, s.maxSize)
+		width, height, err = s.runTool(temp, s.ffmpeg, "-v", "error", "-i", source, "-vf", scale, "-frames:v", "1", "-q:v", "3", "-y", temp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(temp, s.path(attachment.ID)); err != nil {
+		return nil, fmt.Errorf("failed to store preview: %w", err)
+	}
+
+	preview := &models.AttachmentPreview{
+		AttachmentID: attachment.ID,
+		Kind:         kind,
+		Width:        width,
+		Height:       height,
+		Created:      s.now().UnixMilli(),
+	}
+	if err := s.db.SaveAttachmentPreview(preview); err != nil {
+		return nil, err
+	}
+
+	if s.dispatcher != nil {
+		s.dispatcher.Dispatch(events.Event{
+			Type: events.PreviewGenerated,
+			Data: events.PostEvent{
+				PostID:       attachment.PostID,
+				SpaceID:      spaceID,
+				AttachmentID: attachment.ID,
+				Timestamp:    preview.Created,
+			},
+		})
+	}
+	return preview, nil
+}
+
+// Status tells which kinds of files get a preview
+func (s *Service) Status() *Status {
+	missing := s.missing
+	if missing == nil {
+		missing = []string{}
+	}
+	return &Status{
+		Images:  true,
+		PDFs:    s.pdftoppm != "",
+		Videos:  s.ffmpeg != "",
+		MaxSize: s.maxSize,
+		Missing: missing,
+	}
+}
+
+func (s *Service) path(attachmentID int) string {
+	return filepath.Join(s.dir, strconv.Itoa(attachmentID)+".jpg")
+}
+
+// kindOf returns the kind of preview of an attachment, empty when it gets none
+func (s *Service) kindOf(attachment *models.Attachment) string {
+	fileType := strings.ToLower(attachment.FileType)
+	switch {
+	case fileType == "image/png" || fileType == "image/jpeg" || fileType == "image/gif":
+		return config.PreviewKindImage
+	case fileType == "application/pdf" && s.pdftoppm != "":
+		return config.PreviewKindPDF
+	case (strings.HasPrefix(fileType, "video/") || videoExtensions[strings.ToLower(filepath.Ext(attachment.Filename))]) && s.ffmpeg != "":
+		return config.PreviewKindVideo
+	}
+	return ""
+}
+
+// runTool runs an external renderer expected to write a JPEG image at out, and returns the
+// size of that image
+func (s *Service) runTool(out, tool string, args ...string) (int, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.PreviewToolTimeout)
+	defer cancel()
+
+	if output, err := exec.CommandContext(ctx, tool, args...).CombinedOutput(); err != nil {
+		return 0, 0, fmt.Errorf("%s failed: %w: %s", filepath.Base(tool), err, strings.TrimSpace(string(output)))
+	}
+
+	file, err := os.Open(out)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s wrote no image: %w", filepath.Base(tool), err)
+	}
+	defer file.Close()
+	header, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s wrote an unreadable image: %w", filepath.Base(tool), err)
+	}
+	return header.Width, header.Height, nil
+}
@@ -0,0 +1,185 @@
+package previews
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type previewTestSetup struct {
+	db         *storage.DB
+	dir        string
+	dispatcher *events.Dispatcher
+	files      *services.FileService
+	service    *Service
+	postID     int
+}
+
+func setupPreviewTest(t *testing.T) (*previewTestSetup, func()) {
+	tempDir, err := os.MkdirTemp("", "backthynk_previews_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	testConfig.Files.StoragePath = tempDir
+	testConfig.Files.UploadsSubdir = "uploads"
+	config.SetServiceConfigForTest(testConfig)
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(tempDir, "uploads"), config.DirectoryPermissions); err != nil {
+		t.Fatalf("Failed to create uploads directory: %v", err)
+	}
+
+	space, err := db.CreateSpace("Media", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	post, err := db.CreatePost(context.Background(), space.ID, "Screenshots")
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	dispatcher := events.NewDispatcher()
+	files := services.NewFileService(db, dispatcher)
+	service := NewService(db, files, true, 0)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	service.SetDispatcher(dispatcher)
+	dispatcher.Subscribe(events.FileUploaded, service.HandleEvent)
+
+	setup := &previewTestSetup{db: db, dir: tempDir, dispatcher: dispatcher, files: files, service: service, postID: post.ID}
+	return setup, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+// upload attaches a file to the test post and returns the attachment ID
+func (s *previewTestSetup) upload(t *testing.T, filename string, data []byte) int {
+	attachment, err := s.files.UploadFile(context.Background(), s.postID, bytes.NewReader(data), filename, int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to upload %s: %v", filename, err)
+	}
+	return attachment.ID
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestImagePreview(t *testing.T) {
+	setup, cleanup := setupPreviewTest(t)
+	defer cleanup()
+
+	var generated []events.PostEvent
+	setup.dispatcher.Subscribe(events.PreviewGenerated, func(event events.Event) error {
+		generated = append(generated, event.Data.(events.PostEvent))
+		return nil
+	})
+
+	attachmentID := setup.upload(t, "wide.png", encodePNG(t, 1000, 500))
+	if len(generated) != 1 || generated[0].AttachmentID != attachmentID || generated[0].PostID != setup.postID {
+		t.Fatalf("Expected a preview to be generated on upload, got %+v", generated)
+	}
+
+	preview, path, err := setup.service.Get(context.Background(), attachmentID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if preview.Kind != config.PreviewKindImage || preview.Width != config.DefaultPreviewMaxSize || preview.Height != config.DefaultPreviewMaxSize/2 {
+		t.Errorf("Unexpected preview: %+v", preview)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Expected the preview file to exist: %v", err)
+	}
+	header, format, err := image.DecodeConfig(file)
+	file.Close()
+	if err != nil || format != "jpeg" || header.Width != preview.Width {
+		t.Errorf("Expected a %dpx wide JPEG image, got %s %+v (%v)", preview.Width, format, header, err)
+	}
+
+	// A lost file is generated again on demand
+	os.Remove(path)
+	if _, _, err := setup.service.Get(context.Background(), attachmentID); err != nil || len(generated) != 2 {
+		t.Errorf("Expected the preview to be generated again, got %v after %d events", err, len(generated))
+	}
+
+	small := setup.upload(t, "icon.png", encodePNG(t, 40, 30))
+	if preview, _, err := setup.service.Get(context.Background(), small); err != nil || preview.Width != 40 || preview.Height != 30 {
+		t.Errorf("Expected small images to keep their size, got %+v (%v)", preview, err)
+	}
+}
+
+func TestUnavailablePreviews(t *testing.T) {
+	setup, cleanup := setupPreviewTest(t)
+	defer cleanup()
+
+	setup.service.SetTools(filepath.Join(setup.dir, "no-pdftoppm"), filepath.Join(setup.dir, "no-ffmpeg"))
+	status := setup.service.Status()
+	if !status.Images || status.PDFs || status.Videos || len(status.Missing) != 2 {
+		t.Errorf("Expected missing tools to disable their kinds, got %+v", status)
+	}
+
+	for _, filename := range []string{"report.pdf", "clip.mp4", "notes.txt"} {
+		attachmentID := setup.upload(t, filename, []byte("not an image"))
+		if _, _, err := setup.service.Get(context.Background(), attachmentID); err == nil || err.Error() != config.ErrPreviewUnavailable {
+			t.Errorf("Expected no preview for %s, got %v", filename, err)
+		}
+	}
+
+	broken := setup.upload(t, "broken.png", []byte("not an image"))
+	if _, _, err := setup.service.Get(context.Background(), broken); err == nil || err.Error() != config.ErrPreviewUnavailable {
+		t.Errorf("Expected no preview for an unreadable image, got %v", err)
+	}
+	if _, _, err := setup.service.Get(context.Background(), 999); err == nil || err.Error() != "attachment not found" {
+		t.Errorf("Expected unknown attachments to be reported, got %v", err)
+	}
+}
+
+func TestInitializeRemovesOrphans(t *testing.T) {
+	setup, cleanup := setupPreviewTest(t)
+	defer cleanup()
+
+	attachmentID := setup.upload(t, "kept.png", encodePNG(t, 20, 20))
+	orphan := filepath.Join(setup.service.dir, "12345.jpg")
+	if err := os.WriteFile(orphan, []byte("stale"), config.FilePermissions); err != nil {
+		t.Fatalf("Failed to write orphan: %v", err)
+	}
+
+	if err := setup.service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Error("Expected the orphan preview to be removed")
+	}
+	if _, err := os.Stat(setup.service.path(attachmentID)); err != nil {
+		t.Errorf("Expected the preview of a live attachment to be kept: %v", err)
+	}
+}
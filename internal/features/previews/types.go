@@ -0,0 +1,10 @@
+package previews
+
+// Status tells which kinds of attachments get a preview on this instance
+type Status struct {
+	Images  bool     `json:"images"`
+	PDFs    bool     `json:"pdfs"`   // Needs the documents option and the PDF renderer
+	Videos  bool     `json:"videos"` // Needs the documents option and ffmpeg
+	MaxSize int      `json:"max_size"`
+	Missing []string `json:"missing"` // External tools enabled but not found, their kinds get no preview
+}
@@ -0,0 +1,56 @@
+package publicguard
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/public-traffic", h.GetTraffic).Methods("GET")
+	api.HandleFunc("/public-traffic/{token}/revoke", h.RevokeToken).Methods("POST")
+}
+
+// GetTraffic handles GET /api/public-traffic
+func (h *Handler) GetTraffic(w http.ResponseWriter, r *http.Request) {
+	response := TrafficResponse{
+		Budget:        h.service.budget,
+		WindowSeconds: int(h.service.window.Seconds()),
+		Tokens:        h.service.GetTraffic(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// RevokeToken handles POST /api/public-traffic/{token}/revoke
+// The token may be given in full or as the hash returned by GetTraffic.
+func (h *Handler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	token, ok := h.service.lookupToken(mux.Vars(r)["token"])
+	if !ok {
+		http.Error(w, config.ErrPublicTokenNotFound, http.StatusNotFound)
+		return
+	}
+
+	if err := h.service.Revoke(token); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
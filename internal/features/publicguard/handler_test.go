@@ -0,0 +1,70 @@
+package publicguard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(NewService(false, 0, 0, 0))
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/public-traffic", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected traffic route NOT to be registered when disabled")
+	}
+}
+
+func TestTrafficHandlers(t *testing.T) {
+	service := NewService(true, 10, time.Hour, time.Minute)
+	router := newProtectedRouter(service, nil, nil)
+	NewHandler(service).RegisterRoutes(router)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/public/abcdefghij", nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/public-traffic", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response TrafficResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.Budget != 10 || response.WindowSeconds != 3600 || len(response.Tokens) != 1 {
+		t.Fatalf("Unexpected traffic response: %+v", response)
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{"Unknown token", "zzz", http.StatusNotFound},
+		{"Masked token is ambiguous", response.Tokens[0].Token, http.StatusNotFound},
+		{"Revoke by hash", response.Tokens[0].Hash, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/api/public-traffic/"+tt.token+"/revoke", nil))
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/public/abcdefghij", nil))
+	if w.Code != http.StatusGone {
+		t.Errorf("Expected revoked token to be refused, got %d", w.Code)
+	}
+}
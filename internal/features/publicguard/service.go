@@ -0,0 +1,301 @@
+package publicguard
+
+import (
+	"backthynk/internal/config"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// SecretLookup tells whether the owner of a route knows a token and returns its signing secret,
+// empty when the token does not require signed requests
+type SecretLookup func(token string) (secret string, known bool)
+
+// RevokeFunc persists the revocation of a token in the feature that owns it
+type RevokeFunc func(token string) error
+
+type tokenState struct {
+	route       string
+	requests    int64
+	rejected    int64
+	windowStart time.Time
+	windowCount int
+	lastSeen    time.Time
+	revoked     bool
+}
+
+// Service guards public endpoints (share links, inbound webhooks) against replayed requests
+// and hammering of leaked tokens. Owners of public endpoints wrap their routes with Protect.
+type Service struct {
+	enabled  bool
+	budget   int
+	window   time.Duration
+	maxSkew  time.Duration
+	tokens   map[string]*tokenState
+	nonces   map[string]time.Time  // token + nonce -> expiry
+	revokers map[string]RevokeFunc // route -> owner revocation hook
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+func NewService(enabled bool, budget int, window, maxSkew time.Duration) *Service {
+	if budget <= 0 {
+		budget = config.DefaultPublicRequestBudget
+	}
+	if window <= 0 {
+		window = config.DefaultPublicBudgetWindow
+	}
+	if maxSkew <= 0 {
+		maxSkew = config.DefaultSignatureMaxSkew
+	}
+
+	return &Service{
+		enabled:  enabled,
+		budget:   budget,
+		window:   window,
+		maxSkew:  maxSkew,
+		tokens:   make(map[string]*tokenState),
+		nonces:   make(map[string]time.Time),
+		revokers: make(map[string]RevokeFunc),
+		now:      time.Now,
+	}
+}
+
+// Protect returns a middleware checking revocation, request budget and, when the token
+// requires it, the request signature. tokenVar names the mux variable holding the token.
+// Tokens the secrets lookup does not know are left for the owner to refuse and are not
+// tracked, so made-up tokens cannot fill the traffic table; a nil lookup tracks every token.
+func (s *Service) Protect(route, tokenVar string, secrets SecretLookup, revoke RevokeFunc) mux.MiddlewareFunc {
+	if revoke != nil {
+		s.mu.Lock()
+		s.revokers[route] = revoke
+		s.mu.Unlock()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token := mux.Vars(r)[tokenVar]
+			var secret string
+			if secrets != nil {
+				var known bool
+				if secret, known = secrets(token); !known {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			if status, msg := s.admit(route, token); status != 0 {
+				http.Error(w, msg, status)
+				return
+			}
+
+			if secret != "" {
+				if err := s.verifySignature(r, token, secret); err != nil {
+					s.reject(token)
+					http.Error(w, err.Error(), http.StatusUnauthorized)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// admit records a request and returns a non-zero status when it must be refused
+func (s *Service) admit(route, token string) (int, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	state, ok := s.tokens[token]
+	if !ok {
+		state = &tokenState{route: route, windowStart: now}
+		s.tokens[token] = state
+	}
+
+	state.requests++
+	state.lastSeen = now
+
+	if state.revoked {
+		state.rejected++
+		return http.StatusGone, config.ErrPublicTokenRevoked
+	}
+
+	if now.Sub(state.windowStart) >= s.window {
+		state.windowStart = now
+		state.windowCount = 0
+	}
+	if state.windowCount >= s.budget {
+		state.rejected++
+		return http.StatusTooManyRequests, config.ErrPublicBudgetExceeded
+	}
+	state.windowCount++
+
+	return 0, ""
+}
+
+func (s *Service) reject(token string) {
+	s.mu.Lock()
+	if state, ok := s.tokens[token]; ok {
+		state.rejected++
+	}
+	s.mu.Unlock()
+}
+
+// verifySignature checks the timestamp, the nonce and the HMAC of a signed request
+func (s *Service) verifySignature(r *http.Request, token, secret string) error {
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	signature := r.Header.Get(HeaderSignature)
+	if timestamp == "" || nonce == "" || signature == "" {
+		return fmt.Errorf(config.ErrSignatureRequired)
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf(config.ErrSignatureExpired)
+	}
+	now := s.now()
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew < -s.maxSkew || skew > s.maxSkew {
+		return fmt.Errorf(config.ErrSignatureExpired)
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(r.Body)
+		if err != nil {
+			return fmt.Errorf(config.ErrInvalidRequestBody)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	expected := Sign(secret, timestamp, nonce, r.Method, r.URL.Path, body)
+	provided, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(provided, expected) {
+		return fmt.Errorf(config.ErrSignatureInvalid)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Forget nonces that can no longer pass the timestamp check
+	for key, expiry := range s.nonces {
+		if now.After(expiry) {
+			delete(s.nonces, key)
+		}
+	}
+
+	key := token + "\x00" + nonce
+	if _, seen := s.nonces[key]; seen {
+		return fmt.Errorf(config.ErrSignatureReplayed)
+	}
+	s.nonces[key] = now.Add(2 * s.maxSkew)
+
+	return nil
+}
+
+// Sign computes the request signature expected in HeaderSignature
+func Sign(secret, timestamp, nonce, method, path string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join([]string{timestamp, nonce, method, path}, "\n")))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// Revoke blocks a token immediately and asks the owning feature to persist the revocation
+func (s *Service) Revoke(token string) error {
+	s.mu.Lock()
+	state, ok := s.tokens[token]
+	if !ok {
+		state = &tokenState{}
+		s.tokens[token] = state
+	}
+	state.revoked = true
+	revoke := s.revokers[state.route]
+	s.mu.Unlock()
+
+	if revoke != nil {
+		return revoke(token)
+	}
+	return nil
+}
+
+// GetTraffic lists observed tokens, most recently used first
+func (s *Service) GetTraffic() []TokenTraffic {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	traffic := []TokenTraffic{}
+	for token, state := range s.tokens {
+		var lastSeen int64
+		if !state.lastSeen.IsZero() {
+			lastSeen = state.lastSeen.UnixMilli()
+		}
+		traffic = append(traffic, TokenTraffic{
+			Token:          maskToken(token),
+			Hash:           hashToken(token),
+			Route:          state.route,
+			Requests:       state.requests,
+			Rejected:       state.rejected,
+			WindowRequests: state.windowCount,
+			LastSeen:       lastSeen,
+			Revoked:        state.revoked,
+		})
+	}
+
+	sort.Slice(traffic, func(i, j int) bool {
+		if traffic[i].LastSeen != traffic[j].LastSeen {
+			return traffic[i].LastSeen > traffic[j].LastSeen
+		}
+		return traffic[i].Token < traffic[j].Token
+	})
+
+	return traffic
+}
+
+// lookupToken resolves a full token or the hash of one to a known token
+func (s *Service) lookupToken(value string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tokens[value]; ok {
+		return value, true
+	}
+	for token := range s.tokens {
+		if hashToken(token) == value {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func maskToken(token string) string {
+	if len(token) <= 6 {
+		return token
+	}
+	return token[:6] + "..."
+}
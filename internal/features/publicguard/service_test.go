@@ -0,0 +1,171 @@
+package publicguard
+
+import (
+	"backthynk/internal/config"
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func newProtectedRouter(service *Service, secrets SecretLookup, revoke RevokeFunc) *mux.Router {
+	router := mux.NewRouter()
+	public := router.PathPrefix("/public/{token}").Subrouter()
+	public.Use(service.Protect("share", "token", secrets, revoke))
+	public.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Methods("GET", "POST")
+	return router
+}
+
+func TestRequestBudget(t *testing.T) {
+	service := NewService(true, 2, time.Hour, time.Minute)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	router := newProtectedRouter(service, nil, nil)
+
+	expected := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for i, status := range expected {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/public/abc", nil))
+		if w.Code != status {
+			t.Errorf("Request %d: expected status %d, got %d", i, status, w.Code)
+		}
+	}
+
+	// Other tokens have their own budget
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/public/other", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected independent budget per token, got %d", w.Code)
+	}
+
+	// The budget resets with the next window
+	now = now.Add(time.Hour)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/public/abc", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected budget to reset after the window, got %d", w.Code)
+	}
+}
+
+func TestSignedRequests(t *testing.T) {
+	service := NewService(true, 100, time.Hour, time.Minute)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	secrets := func(token string) (string, bool) {
+		switch token {
+		case "signed":
+			return "s3cret", true
+		case "plain":
+			return "", true
+		}
+		return "", false
+	}
+	router := newProtectedRouter(service, secrets, nil)
+
+	signedRequest := func(timestamp time.Time, nonce, secret string, body string) *http.Request {
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		req := httptest.NewRequest("POST", "/public/signed", bytes.NewBufferString(body))
+		req.Header.Set(HeaderTimestamp, ts)
+		req.Header.Set(HeaderNonce, nonce)
+		req.Header.Set(HeaderSignature, hex.EncodeToString(Sign(secret, ts, nonce, "POST", "/public/signed", []byte(body))))
+		return req
+	}
+
+	tests := []struct {
+		name           string
+		req            *http.Request
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Unsigned token needs no signature", httptest.NewRequest("POST", "/public/plain", nil), http.StatusOK, ""},
+		{"Missing signature", httptest.NewRequest("POST", "/public/signed", nil), http.StatusUnauthorized, config.ErrSignatureRequired},
+		{"Valid signature", signedRequest(now, "n1", "s3cret", "hello"), http.StatusOK, ""},
+		{"Replayed nonce", signedRequest(now, "n1", "s3cret", "hello"), http.StatusUnauthorized, config.ErrSignatureReplayed},
+		{"Wrong secret", signedRequest(now, "n2", "wrong", "hello"), http.StatusUnauthorized, config.ErrSignatureInvalid},
+		{"Expired timestamp", signedRequest(now.Add(-2*time.Minute), "n3", "s3cret", ""), http.StatusUnauthorized, config.ErrSignatureExpired},
+		{"Future timestamp", signedRequest(now.Add(2*time.Minute), "n4", "s3cret", ""), http.StatusUnauthorized, config.ErrSignatureExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, tt.req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedBody != "" && w.Body.String() != tt.expectedBody+"\n" {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUnknownTokensAreNotTracked(t *testing.T) {
+	service := NewService(true, 1, time.Hour, time.Minute)
+	router := newProtectedRouter(service, func(token string) (string, bool) {
+		return "", token == "known"
+	}, nil)
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/public/guess"+strconv.Itoa(i), nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected unknown tokens to be left to the owner, got %d", w.Code)
+		}
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/public/known", nil))
+
+	if traffic := service.GetTraffic(); len(traffic) != 1 || traffic[0].Token != "known" {
+		t.Errorf("Expected only the known token to be tracked, got %+v", traffic)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	service := NewService(true, 100, time.Hour, time.Minute)
+	var persisted string
+	router := newProtectedRouter(service, nil, func(token string) error {
+		persisted = token
+		return nil
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/public/leakedtoken", nil))
+
+	if err := service.Revoke("leakedtoken"); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if persisted != "leakedtoken" {
+		t.Errorf("Expected owner revocation hook to be called, got %q", persisted)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/public/leakedtoken", nil))
+	if w.Code != http.StatusGone {
+		t.Errorf("Expected revoked token to be refused with %d, got %d", http.StatusGone, w.Code)
+	}
+
+	traffic := service.GetTraffic()
+	if len(traffic) != 1 || traffic[0].Token != "leaked..." || !traffic[0].Revoked || traffic[0].Requests != 2 || traffic[0].Rejected != 1 {
+		t.Errorf("Unexpected traffic: %+v", traffic)
+	}
+}
+
+func TestDisabledGuardPassesThrough(t *testing.T) {
+	service := NewService(false, 1, time.Hour, time.Minute)
+	router := newProtectedRouter(service, nil, nil)
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/public/abc", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected disabled guard to let requests through, got %d", w.Code)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package publicguard
+
+// Headers used by signed requests to public endpoints
+const (
+	HeaderTimestamp = "X-Backthynk-Timestamp" // Unix seconds
+	HeaderNonce     = "X-Backthynk-Nonce"
+	HeaderSignature = "X-Backthynk-Signature" // hex(HMAC-SHA256(secret, canonical request))
+)
+
+// TokenTraffic summarizes requests received with a public token
+type TokenTraffic struct {
+	Token          string `json:"token"` // Masked, only the first characters are shown
+	Hash           string `json:"hash"`  // SHA-256 of the token, to revoke it without the full value
+	Route          string `json:"route"`
+	Requests       int64  `json:"requests"`
+	Rejected       int64  `json:"rejected"`
+	WindowRequests int    `json:"window_requests"` // Requests counted against the current budget window
+	LastSeen       int64  `json:"last_seen"`
+	Revoked        bool   `json:"revoked"`
+}
+
+type TrafficResponse struct {
+	Budget        int            `json:"budget"`
+	WindowSeconds int            `json:"window_seconds"`
+	Tokens        []TokenTraffic `json:"tokens"`
+}
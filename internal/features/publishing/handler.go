@@ -0,0 +1,83 @@
+package publishing
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/publishing", h.GetStatus).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/publish", h.GetSpacePublishing).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/publish", h.SetSpacePublishing).Methods("PUT")
+}
+
+// GetStatus handles GET /api/publishing
+func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.Status(r.Context()))
+}
+
+// GetSpacePublishing handles GET /api/spaces/{id}/publish
+func (h *Handler) GetSpacePublishing(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+	if _, ok := h.service.catCache.Get(spaceID); !ok || !h.service.access.CanRead(r.Context(), spaceID) {
+		http.Error(w, config.ErrSpaceNotFound, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SpacePublishing{SpaceID: spaceID, Published: h.service.IsPublished(spaceID)})
+}
+
+// SetSpacePublishing handles PUT /api/spaces/{id}/publish with {"published": bool}
+func (h *Handler) SetSpacePublishing(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req SpacePublishing
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	publishing, err := h.service.SetPublished(r.Context(), spaceID, req.Published)
+	if err != nil {
+		switch err.Error() {
+		case config.ErrSpaceNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case config.ErrSpaceAccessDenied:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(publishing)
+}
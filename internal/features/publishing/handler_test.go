@@ -0,0 +1,65 @@
+package publishing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/publishing", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected publishing routes NOT to be registered when disabled")
+	}
+}
+
+func TestPublishingHandlers(t *testing.T) {
+	setup := setupPublishingTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Blog", nil, "")
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	path := fmt.Sprintf("/api/spaces/%d/publish", space.ID)
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Get unpublished space", "GET", path, "", http.StatusOK, `"published":false`},
+		{"Publish space", "PUT", path, `{"published":true}`, http.StatusOK, `"published":true`},
+		{"Get published space", "GET", path, "", http.StatusOK, `"published":true`},
+		{"Invalid JSON", "PUT", path, `nope`, http.StatusBadRequest, ""},
+		{"Unknown space", "PUT", "/api/spaces/999/publish", `{"published":true}`, http.StatusNotFound, ""},
+		{"Get unknown space", "GET", "/api/spaces/999/publish", "", http.StatusNotFound, ""},
+		{"Status", "GET", "/api/publishing", "", http.StatusOK, fmt.Sprintf(`"spaces":[%d]`, space.ID)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !bytes.Contains(w.Body.Bytes(), []byte(tt.expectedBody)) {
+				t.Errorf("Expected body to contain %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
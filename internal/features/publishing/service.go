@@ -0,0 +1,455 @@
+package publishing
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Renderer turns post content into HTML, e.g. the core PostService
+type Renderer interface {
+	RenderContent(spaceID int, content string) string
+}
+
+// delivery is the next webhook call owed for a post
+type delivery struct {
+	event    string
+	spaceID  int
+	attempts int // Failed attempts so far
+	due      time.Time
+}
+
+// Service sends the rendered posts of published spaces to a webhook, so static site
+// generators can republish them. Post events only queue a delivery per post; the queue is
+// sent in the background, with the post as it is at that time, and failed deliveries are
+// retried with a doubling delay. The queue is kept in memory, so a restart drops it.
+type Service struct {
+	db         *storage.DB
+	catCache   *cache.SpaceCache
+	access     *services.SpaceAccess
+	renderer   Renderer
+	dispatcher *events.Dispatcher
+	client     *http.Client
+	webhookURL string
+	secret     string
+	published  map[int]bool      // spaceID -> publishing on
+	pending    map[int]*delivery // postID -> delivery
+	mu         sync.Mutex
+	stop       chan struct{}
+	now        func() time.Time
+	enabled    bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:        db,
+		catCache:  catCache,
+		client:    &http.Client{Timeout: config.HTTPTimeout(config.WebhookHTTPTimeout)},
+		published: make(map[int]bool),
+		pending:   make(map[int]*delivery),
+		now:       time.Now,
+		enabled:   enabled,
+	}
+}
+
+// SetWebhook configures the receiver of deliveries and the secret signing them, if any
+func (s *Service) SetWebhook(webhookURL, secret string) {
+	s.webhookURL = webhookURL
+	s.secret = secret
+}
+
+// SetRenderer renders published posts like the app does; without one plain markdown is used
+func (s *Service) SetRenderer(renderer Renderer) {
+	s.renderer = renderer
+}
+
+// SetDispatcher lets deliveries that keep failing raise a notification
+func (s *Service) SetDispatcher(dispatcher *events.Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// SetSpaceAccess lets only the viewers that may change a space turn its publishing on or off,
+// and leaves the spaces hidden from a viewer out of its status
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	spaceIDs, err := s.db.GetPublishedSpaces()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, spaceID := range spaceIDs {
+		s.published[spaceID] = true
+	}
+
+	return nil
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	switch event.Type {
+	case events.PostCreated, events.PostUpdated, events.PostRetimed, events.FileUploaded, events.FileDeleted:
+		data := event.Data.(events.PostEvent)
+		s.queueIfPublished(data.PostID, data.SpaceID, EventPublished)
+
+	case events.PostMoved:
+		data := event.Data.(events.PostEvent)
+		if s.IsPublished(data.SpaceID) {
+			s.queue(data.PostID, data.SpaceID, EventPublished)
+		} else if data.OldSpaceID != nil {
+			s.queueIfPublished(data.PostID, *data.OldSpaceID, EventUnpublished)
+		}
+
+	case events.PostDeleted:
+		data := event.Data.(events.PostEvent)
+		s.queueIfPublished(data.PostID, data.SpaceID, EventUnpublished)
+
+	case events.PostMerged:
+		data := event.Data.(events.PostEvent)
+		if s.IsPublished(data.SpaceID) {
+			for _, merged := range data.MergedPosts {
+				s.queue(merged.PostID, data.SpaceID, EventUnpublished)
+			}
+			s.queue(data.PostID, data.SpaceID, EventPublished)
+		}
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		s.queueIfPublished(data.PostID, data.SpaceID, EventPublished)
+		for _, split := range data.SplitPosts {
+			s.queueIfPublished(split.PostID, data.SpaceID, EventPublished)
+		}
+
+	case events.SpaceDeleted:
+		// The rows went with the space; its posts were announced by their own deletions
+		data := event.Data.(events.SpaceEvent)
+		s.mu.Lock()
+		for spaceID := range s.published {
+			if _, ok := s.catCache.Get(spaceID); !ok || spaceID == data.SpaceID {
+				delete(s.published, spaceID)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// IsPublished reports whether the posts of a space are sent to the webhook
+func (s *Service) IsPublished(spaceID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.published[spaceID]
+}
+
+// SetPublished turns publishing of a space on or off. Every post of the space is queued,
+// to be published or unpublished, so the receiver catches up with the change.
+func (s *Service) SetPublished(ctx context.Context, spaceID int, published bool) (*SpacePublishing, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if !s.access.CanWrite(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+
+	if s.IsPublished(spaceID) != published {
+		if err := s.db.SetSpacePublished(spaceID, published); err != nil {
+			return nil, err
+		}
+		postIDs, err := s.db.GetPostIDsBySpace(spaceID)
+		if err != nil {
+			return nil, err
+		}
+
+		event := EventUnpublished
+		if published {
+			event = EventPublished
+		}
+
+		s.mu.Lock()
+		if published {
+			s.published[spaceID] = true
+		} else {
+			delete(s.published, spaceID)
+		}
+		s.mu.Unlock()
+		for _, postID := range postIDs {
+			s.queue(postID, spaceID, event)
+		}
+	}
+
+	return &SpacePublishing{SpaceID: spaceID, Published: published}, nil
+}
+
+// Status returns the published spaces the viewer of ctx may see and the number of queued
+// deliveries
+func (s *Service) Status(ctx context.Context) *Status {
+	hidden := s.access.Hidden(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := &Status{Spaces: make([]int, 0, len(s.published)), Pending: len(s.pending)}
+	for spaceID := range s.published {
+		if !hidden[spaceID] {
+			status.Spaces = append(status.Spaces, spaceID)
+		}
+	}
+	sort.Ints(status.Spaces)
+	return status
+}
+
+func (s *Service) queueIfPublished(postID, spaceID int, event string) {
+	if s.IsPublished(spaceID) {
+		s.queue(postID, spaceID, event)
+	}
+}
+
+// queue replaces any delivery owed for a post, so only its latest state is sent
+func (s *Service) queue(postID, spaceID int, event string) {
+	s.mu.Lock()
+	s.pending[postID] = &delivery{event: event, spaceID: spaceID, due: s.now()}
+	s.mu.Unlock()
+}
+
+// StartDelivery sends the due deliveries once per interval until Stop is called
+func (s *Service) StartDelivery(interval time.Duration) {
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.Deliver()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// Stop ends the delivery loop
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Deliver sends the deliveries that are due and returns how many succeeded. A failed delivery
+// is retried after PublishRetryBaseDelay, doubled on every failure, and dropped with a
+// notification after MaxPublishAttempts.
+func (s *Service) Deliver() int {
+	now := s.now()
+	due := make(map[int]*delivery)
+	s.mu.Lock()
+	for postID, d := range s.pending {
+		if !d.due.After(now) {
+			due[postID] = d
+		}
+	}
+	s.mu.Unlock()
+
+	postIDs := make([]int, 0, len(due))
+	for postID := range due {
+		postIDs = append(postIDs, postID)
+	}
+	sort.Ints(postIDs)
+
+	delivered := 0
+	for _, postID := range postIDs {
+		d := due[postID]
+		err := s.send(postID, d)
+
+		s.mu.Lock()
+		if s.pending[postID] != d {
+			// A newer change was queued while sending, it goes out on its own
+			s.mu.Unlock()
+			if err == nil {
+				delivered++
+			}
+			continue
+		}
+		if err == nil {
+			delete(s.pending, postID)
+			s.mu.Unlock()
+			delivered++
+			continue
+		}
+		d.attempts++
+		dropped := d.attempts >= config.MaxPublishAttempts
+		if dropped {
+			delete(s.pending, postID)
+		} else {
+			d.due = now.Add(config.PublishRetryBaseDelay << (d.attempts - 1))
+		}
+		s.mu.Unlock()
+
+		logger.Warning("Failed to deliver post to the publishing webhook",
+			zap.Int("post_id", postID), zap.Int("attempt", d.attempts), zap.Bool("dropped", dropped), zap.Error(err))
+		if dropped && s.dispatcher != nil {
+			s.dispatcher.Notify(events.NotificationEvent{
+				Kind:    models.NotificationSyncFailure,
+				Title:   "Publishing webhook failed",
+				Message: fmt.Sprintf("Post %d could not be delivered after %d attempts (%v); it is sent again on its next change.", postID, d.attempts, err),
+				SpaceID: d.spaceID,
+				PostID:  postID,
+				Key:     "publishing.webhook",
+			})
+		}
+	}
+
+	return delivered
+}
+
+func (s *Service) send(postID int, d *delivery) error {
+	payload, err := s.payload(postID, d)
+	if err != nil {
+		return err
+	}
+	if payload == nil {
+		return nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal publishing payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create publishing request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.secret != "" {
+		timestamp := strconv.FormatInt(payload.Sent/1000, 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, hex.EncodeToString(Sign(s.secret, timestamp, body)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call publishing webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("publishing webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// payload builds the body of a delivery from the post as it is now. A post that is gone is
+// unpublished; nil is returned when nothing is owed anymore, e.g. its space stopped publishing.
+func (s *Service) payload(postID int, d *delivery) (*Payload, error) {
+	payload := &Payload{
+		Event:   EventUnpublished,
+		PostID:  postID,
+		SpaceID: d.spaceID,
+		Attempt: d.attempts + 1,
+		Sent:    s.now().UnixMilli(),
+	}
+	if d.event == EventUnpublished {
+		return payload, nil
+	}
+
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		if err.Error() == "post not found" {
+			return payload, nil
+		}
+		return nil, err
+	}
+	if !s.IsPublished(post.SpaceID) {
+		return nil, nil
+	}
+
+	attachments, err := s.db.GetAttachmentsByPost(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.Event = EventPublished
+	payload.SpaceID = post.SpaceID
+	payload.SpacePath = s.spacePath(post.SpaceID)
+	payload.Content = post.Content
+	payload.HTML = s.render(post.SpaceID, post.Content)
+	payload.Tags = utils.ParseTags(post.Content)
+	payload.Created = post.Created
+	for _, attachment := range attachments {
+		payload.Attachments = append(payload.Attachments, Attachment{
+			Filename: attachment.Filename,
+			FileType: attachment.FileType,
+			FileSize: attachment.FileSize,
+			URL:      "/uploads/" + url.PathEscape(attachment.FilePath),
+		})
+	}
+
+	return payload, nil
+}
+
+func (s *Service) render(spaceID int, content string) string {
+	if s.renderer != nil {
+		return s.renderer.RenderContent(spaceID, content)
+	}
+	return utils.ProcessMarkdown(content)
+}
+
+// spacePath returns the names of a space and its ancestors, top-level space first
+func (s *Service) spacePath(spaceID int) []string {
+	ids := append([]int{spaceID}, s.catCache.GetAncestors(spaceID)...)
+	path := make([]string, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		if space, ok := s.catCache.Get(ids[i]); ok {
+			path = append(path, space.Name)
+		}
+	}
+	return path
+}
+
+// Sign computes the signature sent in HeaderSignature, so receivers can check deliveries
+// with the shared secret
+func Sign(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,284 @@
+package publishing
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver records the deliveries of the publishing webhook
+type receiver struct {
+	server   *httptest.Server
+	payloads []Payload
+	headers  []http.Header
+	status   int
+	mu       sync.Mutex
+}
+
+func newReceiver() *receiver {
+	rec := &receiver{status: http.StatusOK}
+	rec.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		if rec.status == http.StatusOK {
+			var payload Payload
+			json.Unmarshal(body, &payload)
+			rec.payloads = append(rec.payloads, payload)
+			rec.headers = append(rec.headers, r.Header.Clone())
+			// Keep the raw body in a header for signature checks
+			rec.headers[len(rec.headers)-1].Set("X-Test-Body", string(body))
+		}
+		w.WriteHeader(rec.status)
+	}))
+	return rec
+}
+
+type publishingTestSetup struct {
+	db            *storage.DB
+	spaceService  *services.SpaceService
+	postService   *services.PostService
+	service       *Service
+	receiver      *receiver
+	clock         time.Time
+	notifications []events.NotificationEvent
+	cleanup       func()
+}
+
+func setupPublishingTest(t *testing.T) *publishingTestSetup {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_publishing_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	rec := newReceiver()
+	setup := &publishingTestSetup{
+		db:           db,
+		spaceService: services.NewSpaceService(db, catCache, dispatcher),
+		postService:  services.NewPostService(db, catCache, dispatcher),
+		service:      NewService(db, catCache, true),
+		receiver:     rec,
+		clock:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		cleanup: func() {
+			rec.server.Close()
+			db.Close()
+			os.RemoveAll(tempDir)
+		},
+	}
+	setup.service.now = func() time.Time { return setup.clock }
+	setup.service.SetWebhook(rec.server.URL, "s3cret")
+	setup.service.SetRenderer(setup.postService)
+	setup.service.SetDispatcher(dispatcher)
+	for _, eventType := range []events.EventType{
+		events.PostCreated, events.PostUpdated, events.PostDeleted, events.PostMoved,
+		events.FileUploaded, events.FileDeleted, events.SpaceDeleted,
+	} {
+		dispatcher.Subscribe(eventType, setup.service.HandleEvent)
+	}
+	dispatcher.Subscribe(events.NotificationRaised, func(event events.Event) error {
+		setup.notifications = append(setup.notifications, event.Data.(events.NotificationEvent))
+		return nil
+	})
+
+	return setup
+}
+
+func TestPublishedSpaceDeliveries(t *testing.T) {
+	setup := setupPublishingTest(t)
+	defer setup.cleanup()
+
+	blog, _ := setup.spaceService.Create(context.Background(), "Blog", nil, "")
+	drafts, _ := setup.spaceService.Create(context.Background(), "Drafts", &blog.ID, "")
+	private, _ := setup.spaceService.Create(context.Background(), "Private", nil, "")
+
+	existing, _ := setup.postService.Create(context.Background(), drafts.ID, "Written before publishing", nil)
+	setup.postService.Create(context.Background(), private.ID, "Never sent", nil)
+	if delivered := setup.service.Deliver(); delivered != 0 {
+		t.Fatalf("Expected nothing delivered before publishing, got %d", delivered)
+	}
+
+	// Turning publishing on sends the posts already in the space
+	if _, err := setup.service.SetPublished(context.Background(), drafts.ID, true); err != nil {
+		t.Fatalf("Failed to publish space: %v", err)
+	}
+	post, _ := setup.postService.Create(context.Background(), drafts.ID, "# Hello\n\nFirst post #news", nil)
+	// Changes queued before a delivery are sent once, with the latest content
+	setup.postService.AddTag(context.Background(), post.ID, "featured")
+
+	if delivered := setup.service.Deliver(); delivered != 2 {
+		t.Fatalf("Expected 2 deliveries, got %d", delivered)
+	}
+	payloads := setup.receiver.payloads
+	if payloads[0].PostID != existing.ID || payloads[1].PostID != post.ID {
+		t.Fatalf("Expected the existing then the new post, got %+v", payloads)
+	}
+	published := payloads[1]
+	if published.Event != EventPublished || published.SpaceID != drafts.ID || published.Attempt != 1 {
+		t.Errorf("Unexpected delivery: %+v", published)
+	}
+	if strings.Join(published.SpacePath, "/") != "Blog/Drafts" {
+		t.Errorf("Expected space path Blog/Drafts, got %v", published.SpacePath)
+	}
+	if !strings.Contains(published.Content, "#featured") || published.HTML != setup.postService.RenderContent(drafts.ID, published.Content) {
+		t.Errorf("Expected the rendered latest content, got %q / %q", published.HTML, published.Content)
+	}
+	if strings.Join(published.Tags, ",") != "featured,news" {
+		t.Errorf("Expected tags news and featured, got %v", published.Tags)
+	}
+
+	// Deliveries are signed with the secret
+	header := setup.receiver.headers[1]
+	expected := hex.EncodeToString(Sign("s3cret", header.Get(HeaderTimestamp), []byte(header.Get("X-Test-Body"))))
+	if header.Get(HeaderSignature) != expected {
+		t.Errorf("Expected signature %s, got %s", expected, header.Get(HeaderSignature))
+	}
+
+	// Leaving a published space and deleting a published post unpublish them
+	setup.postService.Move(context.Background(), existing.ID, private.ID)
+	setup.postService.Delete(context.Background(), post.ID)
+	if delivered := setup.service.Deliver(); delivered != 2 {
+		t.Fatalf("Expected 2 deliveries, got %d", delivered)
+	}
+	for _, payload := range setup.receiver.payloads[2:] {
+		if payload.Event != EventUnpublished || payload.SpaceID != drafts.ID || payload.HTML != "" {
+			t.Errorf("Expected an unpublish from the drafts space, got %+v", payload)
+		}
+	}
+
+	status := setup.service.Status(context.Background())
+	if len(status.Spaces) != 1 || status.Spaces[0] != drafts.ID || status.Pending != 0 {
+		t.Errorf("Unexpected status: %+v", status)
+	}
+}
+
+func TestPublishingToggleOff(t *testing.T) {
+	setup := setupPublishingTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Blog", nil, "")
+	setup.service.SetPublished(context.Background(), space.ID, true)
+	post, _ := setup.postService.Create(context.Background(), space.ID, "Short lived", nil)
+
+	// Turning publishing off before the delivery unpublishes the post instead
+	setup.service.SetPublished(context.Background(), space.ID, false)
+	setup.service.Deliver()
+	if len(setup.receiver.payloads) != 1 || setup.receiver.payloads[0].Event != EventUnpublished || setup.receiver.payloads[0].PostID != post.ID {
+		t.Fatalf("Expected a single unpublish, got %+v", setup.receiver.payloads)
+	}
+
+	// The toggle is stored
+	reloaded := NewService(setup.db, setup.service.catCache, true)
+	if err := reloaded.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	if reloaded.IsPublished(space.ID) {
+		t.Error("Expected publishing to stay off after a reload")
+	}
+
+	if _, err := setup.service.SetPublished(context.Background(), 999, true); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected space not found, got %v", err)
+	}
+}
+
+func TestPublishingHidesPrivateSpaces(t *testing.T) {
+	setup := setupPublishingTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Blog", nil, "")
+	setup.service.SetPublished(context.Background(), space.ID, true)
+
+	alice, _ := setup.db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	bob, _ := setup.db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(setup.db, setup.service.catCache)
+	if err := access.SetACL(space.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+	setup.service.SetSpaceAccess(access)
+	asAlice := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	asBob := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+
+	if _, err := setup.service.SetPublished(asAlice, space.ID, false); err == nil || err.Error() != config.ErrSpaceAccessDenied {
+		t.Errorf("Expected a reader to be refused the toggle, got %v", err)
+	}
+	if _, err := setup.service.SetPublished(asBob, space.ID, false); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+	if !setup.service.IsPublished(space.ID) {
+		t.Error("Expected publishing to stay on")
+	}
+
+	if status := setup.service.Status(asAlice); len(status.Spaces) != 1 {
+		t.Errorf("Expected the published space for a listed viewer, got %+v", status)
+	}
+	if status := setup.service.Status(asBob); len(status.Spaces) != 0 {
+		t.Errorf("Expected the hidden space to be left out of the status, got %+v", status)
+	}
+}
+
+func TestPublishingRetries(t *testing.T) {
+	setup := setupPublishingTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Blog", nil, "")
+	setup.service.SetPublished(context.Background(), space.ID, true)
+	post, _ := setup.postService.Create(context.Background(), space.ID, "Retried", nil)
+
+	setup.receiver.status = http.StatusServiceUnavailable
+	if delivered := setup.service.Deliver(); delivered != 0 {
+		t.Fatalf("Expected the delivery to fail, got %d", delivered)
+	}
+
+	// The retry waits for its delay
+	setup.receiver.status = http.StatusOK
+	setup.clock = setup.clock.Add(config.PublishRetryBaseDelay / 2)
+	if delivered := setup.service.Deliver(); delivered != 0 {
+		t.Fatalf("Expected no delivery before the retry delay, got %d", delivered)
+	}
+	setup.clock = setup.clock.Add(config.PublishRetryBaseDelay)
+	if delivered := setup.service.Deliver(); delivered != 1 {
+		t.Fatalf("Expected the retry to succeed, got %d", delivered)
+	}
+	if setup.receiver.payloads[0].Attempt != 2 || setup.receiver.payloads[0].PostID != post.ID {
+		t.Errorf("Expected the second attempt of the post, got %+v", setup.receiver.payloads[0])
+	}
+
+	// Deliveries failing every time are dropped with a notification
+	setup.postService.AddTag(context.Background(), post.ID, "again")
+	setup.receiver.status = http.StatusInternalServerError
+	for i := 0; i < config.MaxPublishAttempts; i++ {
+		setup.service.Deliver()
+		setup.clock = setup.clock.Add(config.PublishRetryBaseDelay << i)
+	}
+	if pending := setup.service.Status(context.Background()).Pending; pending != 0 {
+		t.Errorf("Expected the delivery to be dropped, %d pending", pending)
+	}
+	if len(setup.notifications) != 1 || setup.notifications[0].PostID != post.ID {
+		t.Errorf("Expected a failure notification for the post, got %+v", setup.notifications)
+	}
+}
@@ -0,0 +1,49 @@
+package publishing
+
+// Delivery events
+const (
+	EventPublished   = "post.published"   // The post is new or changed in a published space
+	EventUnpublished = "post.unpublished" // The post was deleted or left the published spaces
+)
+
+// Delivery headers, set when a secret is configured
+const (
+	HeaderTimestamp = "X-Backthynk-Timestamp" // Unix seconds of the attempt
+	HeaderSignature = "X-Backthynk-Signature" // hex(HMAC-SHA256(secret, timestamp + "\n" + body))
+)
+
+// Attachment describes a file of a published post
+type Attachment struct {
+	Filename string `json:"filename"`
+	FileType string `json:"file_type"`
+	FileSize int64  `json:"file_size"`
+	URL      string `json:"url"` // Path of the file on this instance
+}
+
+// Payload is the body posted to the publishing webhook. Unpublished posts only carry
+// their identifiers.
+type Payload struct {
+	Event       string       `json:"event"`
+	PostID      int          `json:"post_id"`
+	SpaceID     int          `json:"space_id"`
+	SpacePath   []string     `json:"space_path,omitempty"` // Space names from the top-level space down
+	Content     string       `json:"content,omitempty"`    // Markdown source
+	HTML        string       `json:"html,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Created     int64        `json:"created,omitempty"`
+	Attempt     int          `json:"attempt"` // 1 for the first delivery, higher on retries
+	Sent        int64        `json:"sent"`
+}
+
+// SpacePublishing is the publish toggle of a space
+type SpacePublishing struct {
+	SpaceID   int  `json:"space_id"`
+	Published bool `json:"published"`
+}
+
+// Status lists the published spaces and the deliveries waiting to be sent or retried
+type Status struct {
+	Spaces  []int `json:"spaces"`
+	Pending int   `json:"pending"`
+}
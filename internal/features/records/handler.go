@@ -0,0 +1,122 @@
+package records
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/record-keeping", h.GetSettings).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/record-keeping", h.SetSettings).Methods("PUT")
+	api.HandleFunc("/spaces/{id:[0-9]+}/records/verify", h.Verify).Methods("GET")
+	api.HandleFunc("/posts/{id:[0-9]+}/records", h.GetPostRecords).Methods("GET")
+}
+
+// GetSettings handles GET /api/spaces/{id}/record-keeping
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	setting, err := h.service.GetSettings(r.Context(), spaceID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(setting)
+}
+
+// SetSettings handles PUT /api/spaces/{id}/record-keeping with {"enabled": bool, "retention_days": int}
+func (h *Handler) SetSettings(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req SettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	setting, err := h.service.SetSettings(r.Context(), spaceID, req)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(setting)
+}
+
+// Verify handles GET /api/spaces/{id}/records/verify
+func (h *Handler) Verify(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	// Records outlive their space, so a deleted space can still be verified
+	result, err := h.service.Verify(r.Context(), spaceID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetPostRecords handles GET /api/posts/{id}/records
+func (h *Handler) GetPostRecords(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+
+	records, err := h.service.GetPostRecords(r.Context(), postID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case config.ErrSpaceNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case config.ErrSpaceAccessDenied:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case config.ErrInvalidRecordRetention:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,67 @@
+package records
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/spaces/1/record-keeping", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected record keeping routes NOT to be registered when disabled")
+	}
+}
+
+func TestRecordsHandlers(t *testing.T) {
+	setup := setupRecordsTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Contracts", nil, "")
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	path := fmt.Sprintf("/api/spaces/%d/record-keeping", space.ID)
+	post, _ := setup.postService.Create(context.Background(), space.ID, "Signed terms", nil)
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Get disabled", "GET", path, "", http.StatusOK, `"enabled":false`},
+		{"Enable", "PUT", path, `{"enabled":true,"retention_days":365}`, http.StatusOK, `"retention_days":365`},
+		{"Invalid retention", "PUT", path, `{"enabled":true,"retention_days":-5}`, http.StatusBadRequest, ""},
+		{"Invalid JSON", "PUT", path, `nope`, http.StatusBadRequest, ""},
+		{"Unknown space", "PUT", "/api/spaces/999/record-keeping", `{"enabled":true}`, http.StatusNotFound, ""},
+		{"Get unknown space", "GET", "/api/spaces/999/record-keeping", "", http.StatusNotFound, ""},
+		{"Post records", "GET", fmt.Sprintf("/api/posts/%d/records", post.ID), "", http.StatusOK, `[]`},
+		{"Verify", "GET", fmt.Sprintf("/api/spaces/%d/records/verify", space.ID), "", http.StatusOK, `"valid":true`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !bytes.Contains(w.Body.Bytes(), []byte(tt.expectedBody)) {
+				t.Errorf("Expected body to contain %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package records
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/jobs"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service lets spaces keep an immutable copy of every prior version of their posts. The copies
+// are taken by the database as part of each edit and chained by hash within their space; the
+// database refuses to change them, or to delete them before their retention ends, so neither
+// post nor space deletion takes them along. Expired records are purged in the background.
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	access   *services.SpaceAccess
+	budget   *jobs.Budget
+	stop     chan struct{}
+	now      func() time.Time
+	enabled  bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		now:      time.Now,
+		enabled:  enabled,
+	}
+}
+
+// SetBudget makes the purge loop share the resource budget of background jobs
+func (s *Service) SetBudget(budget *jobs.Budget) {
+	s.budget = budget
+}
+
+// SetSpaceAccess hides the records of private spaces from the viewers that may not read them;
+// record keeping of a space is only set by the viewers that may change it
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// GetSettings returns the record keeping setting of a space
+func (s *Service) GetSettings(ctx context.Context, spaceID int) (*models.RecordKeeping, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	return s.db.GetRecordKeeping(spaceID)
+}
+
+// SetSettings turns record keeping of a space on or off or changes its retention. A new
+// retention applies to the records taken from then on, and turning record keeping off keeps
+// the records taken so far.
+func (s *Service) SetSettings(ctx context.Context, spaceID int, req SettingsRequest) (*models.RecordKeeping, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if !s.access.CanWrite(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+
+	setting := &models.RecordKeeping{SpaceID: spaceID, Enabled: req.Enabled}
+	if req.Enabled {
+		setting.RetentionDays = req.RetentionDays
+		if setting.RetentionDays == 0 {
+			setting.RetentionDays = config.DefaultRecordRetentionDays
+		}
+		if setting.RetentionDays < 1 || setting.RetentionDays > config.MaxRecordRetentionDays {
+			return nil, fmt.Errorf(config.ErrInvalidRecordRetention)
+		}
+	}
+
+	now := s.now().UnixMilli()
+	details, err := json.Marshal(map[string]interface{}{
+		"space_id":       spaceID,
+		"enabled":        setting.Enabled,
+		"retention_days": setting.RetentionDays,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit details: %w", err)
+	}
+	entry := &models.AuditEntry{Action: AuditActionSettings, Details: details, Created: now}
+
+	if !setting.Enabled {
+		if err := s.db.DisableRecordKeeping(spaceID, entry); err != nil {
+			return nil, err
+		}
+		return setting, nil
+	}
+
+	setting.Since = now
+	if err := s.db.SetRecordKeeping(setting, entry); err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+// GetPostRecords returns the records taken of a post, most recent first. They outlive the
+// post, so a deleted post may still have records. Records taken in spaces hidden from the
+// viewer are left out.
+func (s *Service) GetPostRecords(ctx context.Context, postID int) ([]models.PostRecord, error) {
+	records, err := s.db.GetPostRecords(postID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]models.PostRecord, 0, len(records))
+	for _, record := range records {
+		if s.access.CanRead(ctx, record.SpaceID) {
+			visible = append(visible, record)
+		}
+	}
+	return visible, nil
+}
+
+// Verify recomputes the hash chain of the records of a space. The oldest record kept anchors
+// the chain, since older records may have been purged after their retention; a record whose
+// content or hash changed, or that went missing in between, breaks it.
+func (s *Service) Verify(ctx context.Context, spaceID int) (*Verification, error) {
+	if !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	records, err := s.db.GetSpaceRecords(spaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Verification{SpaceID: spaceID, Records: len(records), Valid: true}
+	if len(records) == 0 {
+		return result, nil
+	}
+	result.Anchor = records[0].PrevHash
+	result.Head = records[len(records)-1].Hash
+
+	for i, record := range records {
+		problem := ""
+		if i > 0 && record.PrevHash != records[i-1].Hash {
+			problem = "record does not follow the previous one"
+		} else if storage.PostRecordHash(record) != record.Hash {
+			problem = "record does not match its hash"
+		}
+		if problem != "" {
+			id := record.ID
+			result.Valid = false
+			result.BrokenRecordID = &id
+			result.Problem = problem
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// Purge deletes the records whose retention has ended and returns how many were deleted
+func (s *Service) Purge() (int64, error) {
+	return s.db.PurgeExpiredPostRecords()
+}
+
+// StartPurge purges expired records immediately and then once per interval until Stop is called
+func (s *Service) StartPurge(interval time.Duration) {
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	s.runPurge()
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.runPurge()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+func (s *Service) runPurge() {
+	var count int64
+	err := s.budget.Run(context.Background(), "records-purge", func(job *jobs.Job) error {
+		var err error
+		count, err = s.Purge()
+		return err
+	})
+	if err != nil {
+		logger.Warning("Failed to purge expired post records", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		logger.Info("Purged expired post records", zap.Int64("count", count))
+	}
+}
+
+// Stop ends the periodic purge loop
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
@@ -0,0 +1,294 @@
+package records
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordsTestSetup struct {
+	db           *storage.DB
+	catCache     *cache.SpaceCache
+	spaceService *services.SpaceService
+	postService  *services.PostService
+	service      *Service
+	cleanup      func()
+}
+
+func setupRecordsTest(t *testing.T) *recordsTestSetup {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+	config.SetOptionsConfigForTest(config.NewTestOptionsConfig())
+
+	tempDir, err := os.MkdirTemp("", "backthynk_records_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	return &recordsTestSetup{
+		db:           db,
+		catCache:     catCache,
+		spaceService: services.NewSpaceService(db, catCache, dispatcher),
+		postService:  services.NewPostService(db, catCache, dispatcher),
+		service:      NewService(db, catCache, true),
+		cleanup: func() {
+			db.Close()
+			os.RemoveAll(tempDir)
+		},
+	}
+}
+
+func TestRecordsTakenOnEdit(t *testing.T) {
+	setup := setupRecordsTest(t)
+	defer setup.cleanup()
+
+	kept, _ := setup.spaceService.Create(context.Background(), "Contracts", nil, "")
+	other, _ := setup.spaceService.Create(context.Background(), "Notes", nil, "")
+	if _, err := setup.service.SetSettings(context.Background(), kept.ID, SettingsRequest{Enabled: true}); err != nil {
+		t.Fatalf("Failed to enable record keeping: %v", err)
+	}
+
+	post, _ := setup.postService.Create(context.Background(), kept.ID, "Version one", nil)
+	untracked, _ := setup.postService.Create(context.Background(), other.ID, "Not kept", nil)
+	previews := []models.LinkPreview{{URL: "https://example.com", Title: "Example"}}
+	if _, err := setup.postService.Update(context.Background(), post.ID, "Version two", previews); err != nil {
+		t.Fatalf("Failed to edit post: %v", err)
+	}
+	if _, err := setup.postService.Update(context.Background(), post.ID, "Version three", nil); err != nil {
+		t.Fatalf("Failed to edit post: %v", err)
+	}
+	setup.postService.Update(context.Background(), untracked.ID, "Still not kept", nil)
+
+	records, err := setup.service.GetPostRecords(context.Background(), post.ID)
+	if err != nil {
+		t.Fatalf("Failed to get records: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].Content != "Version two" || records[1].Content != "Version one" {
+		t.Errorf("Expected prior versions most recent first, got %q and %q", records[0].Content, records[1].Content)
+	}
+	var recorded []models.LinkPreview
+	if err := json.Unmarshal(records[0].LinkPreviews, &recorded); err != nil || len(recorded) != 1 || recorded[0].Title != "Example" {
+		t.Errorf("Expected the replaced link previews to be recorded, got %s", records[0].LinkPreviews)
+	}
+	if records[1].PrevHash != "" || records[0].PrevHash != records[1].Hash {
+		t.Errorf("Expected records to be chained, got %+v", records)
+	}
+	expectedRetention := int64(config.DefaultRecordRetentionDays) * 24 * int64(time.Hour/time.Millisecond)
+	if records[0].RetainUntil-records[0].Recorded != expectedRetention {
+		t.Errorf("Expected the default retention, got %d ms", records[0].RetainUntil-records[0].Recorded)
+	}
+	if got, _ := setup.service.GetPostRecords(context.Background(), untracked.ID); len(got) != 0 {
+		t.Errorf("Expected no records outside record keeping spaces, got %d", len(got))
+	}
+
+	result, err := setup.service.Verify(context.Background(), kept.ID)
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if !result.Valid || result.Records != 2 || result.Anchor != "" || result.Head != records[0].Hash {
+		t.Errorf("Expected a valid chain of 2 records, got %+v", result)
+	}
+}
+
+func TestRecordsCannotBeChangedOrDeleted(t *testing.T) {
+	setup := setupRecordsTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Contracts", nil, "")
+	setup.service.SetSettings(context.Background(), space.ID, SettingsRequest{Enabled: true, RetentionDays: 30})
+	post, _ := setup.postService.Create(context.Background(), space.ID, "Signed terms", nil)
+	setup.postService.Update(context.Background(), post.ID, "Amended terms", nil)
+
+	if _, err := setup.db.Exec("UPDATE post_records SET content = 'Forged'"); err == nil {
+		t.Error("Expected records to refuse changes")
+	}
+	if _, err := setup.db.Exec("DELETE FROM post_records"); err == nil {
+		t.Error("Expected records under retention to refuse deletion")
+	}
+
+	if err := setup.postService.Delete(context.Background(), post.ID); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+	if err := setup.spaceService.Delete(context.Background(), space.ID); err != nil {
+		t.Fatalf("Failed to delete space: %v", err)
+	}
+	records, _ := setup.service.GetPostRecords(context.Background(), post.ID)
+	if len(records) != 1 || records[0].Content != "Signed terms" {
+		t.Fatalf("Expected the record to outlive its post and space, got %+v", records)
+	}
+	if result, _ := setup.service.Verify(context.Background(), space.ID); !result.Valid || result.Records != 1 {
+		t.Errorf("Expected the records of a deleted space to verify, got %+v", result)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	setup := setupRecordsTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Contracts", nil, "")
+	setup.service.SetSettings(context.Background(), space.ID, SettingsRequest{Enabled: true})
+	post, _ := setup.postService.Create(context.Background(), space.ID, "One", nil)
+	for _, content := range []string{"Two", "Three", "Four"} {
+		setup.postService.Update(context.Background(), post.ID, content, nil)
+	}
+	records, _ := setup.db.GetSpaceRecords(space.ID)
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+
+	// Someone with access to the database file can drop the triggers
+	setup.db.Exec("DROP TRIGGER post_records_immutable")
+	setup.db.Exec("DROP TRIGGER post_records_retained")
+
+	setup.db.Exec("UPDATE post_records SET content = 'Forged' WHERE id = ?", records[1].ID)
+	result, _ := setup.service.Verify(context.Background(), space.ID)
+	if result.Valid || result.BrokenRecordID == nil || *result.BrokenRecordID != records[1].ID {
+		t.Errorf("Expected the changed record to break the chain, got %+v", result)
+	}
+
+	setup.db.Exec("DELETE FROM post_records WHERE id = ?", records[1].ID)
+	result, _ = setup.service.Verify(context.Background(), space.ID)
+	if result.Valid || result.BrokenRecordID == nil || *result.BrokenRecordID != records[2].ID {
+		t.Errorf("Expected the removed record to break the chain, got %+v", result)
+	}
+
+	// Dropping the oldest records looks like an expiry, the chain then starts at the anchor
+	setup.db.Exec("DELETE FROM post_records WHERE id < ?", records[2].ID)
+	result, _ = setup.service.Verify(context.Background(), space.ID)
+	if !result.Valid || result.Anchor != records[1].Hash {
+		t.Errorf("Expected the remaining record to verify from its anchor, got %+v", result)
+	}
+}
+
+func TestPurgeExpiredRecords(t *testing.T) {
+	setup := setupRecordsTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Contracts", nil, "")
+	setup.service.SetSettings(context.Background(), space.ID, SettingsRequest{Enabled: true, RetentionDays: 1})
+	post, _ := setup.postService.Create(context.Background(), space.ID, "One", nil)
+	setup.postService.Update(context.Background(), post.ID, "Two", nil)
+	setup.postService.Update(context.Background(), post.ID, "Three", nil)
+
+	if purged, err := setup.service.Purge(); err != nil || purged != 0 {
+		t.Fatalf("Expected nothing to purge within retention, got %d, %v", purged, err)
+	}
+
+	// Age the oldest record past its retention
+	records, _ := setup.db.GetSpaceRecords(space.ID)
+	setup.db.Exec("DROP TRIGGER post_records_immutable")
+	setup.db.Exec("UPDATE post_records SET retain_until = 1 WHERE id = ?", records[0].ID)
+
+	if purged, err := setup.service.Purge(); err != nil || purged != 1 {
+		t.Fatalf("Expected 1 expired record to be purged, got %d, %v", purged, err)
+	}
+	if result, _ := setup.service.Verify(context.Background(), space.ID); !result.Valid || result.Records != 1 || result.Anchor != records[0].Hash {
+		t.Errorf("Expected the chain to verify after the purge, got %+v", result)
+	}
+}
+
+func TestSetSettings(t *testing.T) {
+	setup := setupRecordsTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Contracts", nil, "")
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	setup.service.now = func() time.Time { return clock }
+
+	for _, days := range []int{-1, config.MaxRecordRetentionDays + 1} {
+		if _, err := setup.service.SetSettings(context.Background(), space.ID, SettingsRequest{Enabled: true, RetentionDays: days}); err == nil || err.Error() != config.ErrInvalidRecordRetention {
+			t.Errorf("Expected retention %d to be rejected, got %v", days, err)
+		}
+	}
+	if _, err := setup.service.SetSettings(context.Background(), 999, SettingsRequest{Enabled: true}); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected unknown space to be rejected, got %v", err)
+	}
+
+	setting, err := setup.service.SetSettings(context.Background(), space.ID, SettingsRequest{Enabled: true})
+	if err != nil || setting.RetentionDays != config.DefaultRecordRetentionDays || setting.Since != clock.UnixMilli() {
+		t.Fatalf("Expected record keeping on with the default retention, got %+v, %v", setting, err)
+	}
+
+	clock = clock.Add(time.Hour)
+	setting, _ = setup.service.SetSettings(context.Background(), space.ID, SettingsRequest{Enabled: true, RetentionDays: 90})
+	if setting.RetentionDays != 90 || setting.Since != clock.Add(-time.Hour).UnixMilli() {
+		t.Errorf("Expected a new retention to keep the original start, got %+v", setting)
+	}
+
+	setup.service.SetSettings(context.Background(), space.ID, SettingsRequest{Enabled: false})
+	setting, _ = setup.service.GetSettings(context.Background(), space.ID)
+	if setting.Enabled || setting.RetentionDays != 0 {
+		t.Errorf("Expected record keeping off, got %+v", setting)
+	}
+	post, _ := setup.postService.Create(context.Background(), space.ID, "One", nil)
+	setup.postService.Update(context.Background(), post.ID, "Two", nil)
+	if records, _ := setup.service.GetPostRecords(context.Background(), post.ID); len(records) != 0 {
+		t.Errorf("Expected no records once record keeping is off, got %d", len(records))
+	}
+
+	entries, _ := setup.db.GetAuditEntries(AuditActionSettings, 10)
+	if len(entries) != 3 || !strings.Contains(string(entries[0].Details), `"enabled":false`) {
+		t.Errorf("Expected every change in the audit log, got %+v", entries)
+	}
+}
+
+func TestRecordsHidePrivateSpaces(t *testing.T) {
+	setup := setupRecordsTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Contracts", nil, "")
+	setup.service.SetSettings(context.Background(), space.ID, SettingsRequest{Enabled: true})
+	post, _ := setup.postService.Create(context.Background(), space.ID, "Version one", nil)
+	setup.postService.Update(context.Background(), post.ID, "Version two", nil)
+
+	alice, _ := setup.db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	bob, _ := setup.db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(setup.db, setup.catCache)
+	if err := access.SetACL(space.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+	setup.service.SetSpaceAccess(access)
+	asAlice := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	asBob := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+
+	if records, _ := setup.service.GetPostRecords(asAlice, post.ID); len(records) != 1 {
+		t.Errorf("Expected the record for a listed viewer, got %d", len(records))
+	}
+	if _, err := setup.service.Verify(asAlice, space.ID); err != nil {
+		t.Errorf("Expected a listed viewer to verify the chain, got %v", err)
+	}
+	if _, err := setup.service.SetSettings(asAlice, space.ID, SettingsRequest{Enabled: false}); err == nil || err.Error() != config.ErrSpaceAccessDenied {
+		t.Errorf("Expected a reader to be refused the settings, got %v", err)
+	}
+
+	if records, _ := setup.service.GetPostRecords(asBob, post.ID); len(records) != 0 {
+		t.Errorf("Expected no records for an unlisted viewer, got %d", len(records))
+	}
+	if _, err := setup.service.Verify(asBob, space.ID); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+	if _, err := setup.service.GetSettings(asBob, space.ID); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+}
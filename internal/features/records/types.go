@@ -0,0 +1,23 @@
+package records
+
+// AuditActionSettings is the audit log action of record keeping being turned on, off or
+// given another retention
+const AuditActionSettings = "records.settings"
+
+// SettingsRequest is the body of PUT /api/spaces/{id}/record-keeping. A zero retention uses
+// the default one.
+type SettingsRequest struct {
+	Enabled       bool `json:"enabled"`
+	RetentionDays int  `json:"retention_days"`
+}
+
+// Verification is the outcome of checking the hash chain of the records of a space
+type Verification struct {
+	SpaceID        int    `json:"space_id"`
+	Records        int    `json:"records"`
+	Valid          bool   `json:"valid"`
+	Anchor         string `json:"anchor"`                     // prev_hash of the oldest record kept, empty unless older ones expired
+	Head           string `json:"head"`                       // Hash of the latest record, to note down and compare later
+	BrokenRecordID *int   `json:"broken_record_id,omitempty"` // First record failing the check
+	Problem        string `json:"problem,omitempty"`
+}
@@ -0,0 +1,57 @@
+package related
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/posts/{id:[0-9]+}/related", h.GetRelated).Methods("GET")
+}
+
+// GetRelated handles GET /api/posts/{id}/related?limit=5&subtree=true
+func (h *Handler) GetRelated(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+
+	limit := config.DefaultRelatedPostsLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > config.MaxRelatedPostsLimit {
+			http.Error(w, config.ErrInvalidRelatedLimit, http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	subtree := r.URL.Query().Get("subtree") == "true"
+
+	related, err := h.service.Related(r.Context(), postID, limit, subtree)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RelatedResponse{PostID: postID, Related: related})
+}
@@ -0,0 +1,79 @@
+package related
+
+import (
+	"backthynk/internal/core/cache"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/posts/1/related", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected related routes NOT to be registered when disabled")
+	}
+}
+
+func TestGetRelated(t *testing.T) {
+	db, cleanup := setupRelatedTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	target, _ := db.CreatePost(context.Background(), space.ID, "Weekly review of reading notes")
+	db.CreatePost(context.Background(), space.ID, "Reading notes from the weekly book club")
+	db.CreatePost(context.Background(), space.ID, "Reading list")
+
+	service := NewService(db, catCache, true)
+	service.Initialize()
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	id := strconv.Itoa(target.ID)
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{"Default limit", "/api/posts/" + id + "/related", http.StatusOK, 2},
+		{"Custom limit", "/api/posts/" + id + "/related?limit=1", http.StatusOK, 1},
+		{"Subtree scope", "/api/posts/" + id + "/related?subtree=true", http.StatusOK, 2},
+		{"Invalid limit", "/api/posts/" + id + "/related?limit=abc", http.StatusBadRequest, 0},
+		{"Limit too large", "/api/posts/" + id + "/related?limit=51", http.StatusBadRequest, 0},
+		{"Unknown post", "/api/posts/999/related", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response RelatedResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if response.PostID != target.ID || len(response.Related) != tt.expectedCount {
+				t.Errorf("Unexpected response %+v", response)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package related
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// document is the indexed form of a post
+type document struct {
+	spaceID int
+	created int64
+	snippet string
+	terms   map[string]int
+}
+
+// Service keeps a TF-IDF index of post content in memory and ranks posts by cosine similarity.
+// Document frequencies are maintained incrementally, so weights always reflect the current posts.
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	docs     map[int]*document // postID -> document
+	df       map[string]int    // term -> number of posts containing it
+	access   *services.SpaceAccess
+	mu       sync.RWMutex
+	enabled  bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		docs:     make(map[int]*document),
+		df:       make(map[string]int),
+		enabled:  enabled,
+	}
+}
+
+// SetSpaceAccess keeps the posts of spaces hidden from the viewer of a request out of its
+// related posts
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	var spaceIDs []int
+	for _, space := range s.catCache.GetAll() {
+		spaceIDs = append(spaceIDs, space.ID)
+	}
+	if len(spaceIDs) == 0 {
+		return nil
+	}
+
+	posts, err := s.db.GetPostsBySpaces(spaceIDs)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, post := range posts {
+		s.indexUnlocked(post.ID, post.SpaceID, post.Created, post.Content)
+	}
+
+	return nil
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	switch event.Type {
+	case events.PostCreated, events.PostUpdated, events.PostMoved:
+		data := event.Data.(events.PostEvent)
+		return s.indexPostByID(data.PostID)
+
+	case events.PostMerged:
+		data := event.Data.(events.PostEvent)
+		for _, merged := range data.MergedPosts {
+			s.removePost(merged.PostID)
+		}
+		return s.indexPostByID(data.PostID)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		if err := s.indexPostByID(data.PostID); err != nil {
+			return err
+		}
+		for _, split := range data.SplitPosts {
+			if err := s.indexPostByID(split.PostID); err != nil {
+				return err
+			}
+		}
+
+	case events.PostDeleted:
+		data := event.Data.(events.PostEvent)
+		s.removePost(data.PostID)
+
+	case events.SpaceDeleted:
+		data := event.Data.(events.SpaceEvent)
+		for _, postID := range data.AffectedPosts {
+			s.removePost(postID)
+		}
+	}
+
+	return nil
+}
+
+// Related returns up to limit posts most similar to postID, best first. With subtree set only
+// posts of the post's space and its descendants are considered.
+func (s *Service) Related(ctx context.Context, postID, limit int, subtree bool) ([]RelatedPost, error) {
+	hidden := s.access.Hidden(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	target, ok := s.docs[postID]
+	if !ok || hidden[target.spaceID] {
+		return nil, fmt.Errorf(config.ErrPostNotFound)
+	}
+
+	var scope map[int]bool
+	if subtree {
+		scope = map[int]bool{target.spaceID: true}
+		for _, id := range s.catCache.GetDescendants(target.spaceID) {
+			scope[id] = true
+		}
+	}
+
+	weights := s.weightsUnlocked(target)
+	targetNorm := norm(weights)
+	related := []RelatedPost{}
+	if targetNorm == 0 {
+		return related, nil
+	}
+
+	for id, doc := range s.docs {
+		if id == postID || (scope != nil && !scope[doc.spaceID]) || hidden[doc.spaceID] {
+			continue
+		}
+
+		var dot float64
+		for term, weight := range weights {
+			if tf, ok := doc.terms[term]; ok {
+				dot += weight * s.weightUnlocked(term, tf)
+			}
+		}
+		if dot == 0 {
+			continue
+		}
+
+		related = append(related, RelatedPost{
+			PostID:  id,
+			SpaceID: doc.spaceID,
+			Created: doc.created,
+			Score:   dot / (targetNorm * norm(s.weightsUnlocked(doc))),
+			Snippet: doc.snippet,
+		})
+	}
+
+	sort.Slice(related, func(i, j int) bool {
+		if related[i].Score != related[j].Score {
+			return related[i].Score > related[j].Score
+		}
+		return related[i].Created > related[j].Created
+	})
+	if len(related) > limit {
+		related = related[:limit]
+	}
+
+	return related, nil
+}
+
+// weightUnlocked is the TF-IDF weight of a term occurring tf times in a post, using a
+// sublinear term frequency and a smoothed inverse document frequency. Caller must hold s.mu.
+func (s *Service) weightUnlocked(term string, tf int) float64 {
+	idf := math.Log(1 + float64(len(s.docs))/float64(s.df[term]))
+	return (1 + math.Log(float64(tf))) * idf
+}
+
+func (s *Service) weightsUnlocked(doc *document) map[string]float64 {
+	weights := make(map[string]float64, len(doc.terms))
+	for term, tf := range doc.terms {
+		weights[term] = s.weightUnlocked(term, tf)
+	}
+	return weights
+}
+
+func norm(weights map[string]float64) float64 {
+	var sum float64
+	for _, weight := range weights {
+		sum += weight * weight
+	}
+	return math.Sqrt(sum)
+}
+
+func (s *Service) indexPostByID(postID int) error {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.indexUnlocked(post.ID, post.SpaceID, post.Created, post.Content)
+	s.mu.Unlock()
+	return nil
+}
+
+// indexUnlocked replaces the indexed content of a post. Caller must hold s.mu.
+func (s *Service) indexUnlocked(postID, spaceID int, created int64, content string) {
+	s.removeUnlocked(postID)
+
+	doc := &document{
+		spaceID: spaceID,
+		created: created,
+		snippet: utils.Snippet(content, config.RelatedSnippetLength),
+		terms:   utils.ContentTerms(content),
+	}
+	for term := range doc.terms {
+		s.df[term]++
+	}
+	s.docs[postID] = doc
+}
+
+func (s *Service) removePost(postID int) {
+	s.mu.Lock()
+	s.removeUnlocked(postID)
+	s.mu.Unlock()
+}
+
+// removeUnlocked drops a post from the index. Caller must hold s.mu.
+func (s *Service) removeUnlocked(postID int) {
+	doc, ok := s.docs[postID]
+	if !ok {
+		return
+	}
+
+	for term := range doc.terms {
+		if s.df[term] > 1 {
+			s.df[term]--
+		} else {
+			delete(s.df, term)
+		}
+	}
+	delete(s.docs, postID)
+}
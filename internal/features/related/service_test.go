@@ -0,0 +1,162 @@
+package related
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func setupRelatedTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_related_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func relatedIDs(related []RelatedPost) []int {
+	ids := make([]int, len(related))
+	for i, r := range related {
+		ids[i] = r.PostID
+	}
+	return ids
+}
+
+func TestRelatedRanking(t *testing.T) {
+	db, cleanup := setupRelatedTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	infra, _ := db.CreateSpace("Infra", &work.ID, "")
+	home, _ := db.CreateSpace("Home", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+	catCache.Set(infra)
+	catCache.Set(home)
+
+	target, _ := db.CreatePost(context.Background(), work.ID, "Kafka consumer lag keeps growing on the billing cluster")
+	close1, _ := db.CreatePost(context.Background(), infra.ID, "Billing cluster kafka consumer lag alert tuned")
+	close2, _ := db.CreatePost(context.Background(), home.ID, "Kafka consumer lag explained in a blog post")
+	weak, _ := db.CreatePost(context.Background(), work.ID, "The billing invoice template changed")
+	db.CreatePost(context.Background(), home.ID, "Bake sourdough bread on sunday")
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	related, err := service.Related(context.Background(), target.ID, 10, false)
+	if err != nil {
+		t.Fatalf("Related failed: %v", err)
+	}
+	ids := relatedIDs(related)
+	if len(ids) != 3 || ids[0] != close1.ID || ids[2] != weak.ID {
+		t.Fatalf("Unexpected ranking %v (close %d/%d, weak %d)", ids, close1.ID, close2.ID, weak.ID)
+	}
+	for i, r := range related {
+		if r.Score <= 0 || r.Score > 1.0000001 {
+			t.Errorf("Score out of range: %+v", r)
+		}
+		if i > 0 && r.Score > related[i-1].Score {
+			t.Errorf("Expected scores in descending order, got %v", related)
+		}
+	}
+
+	// Subtree scope keeps the post's space and its descendants
+	related, _ = service.Related(context.Background(), target.ID, 10, true)
+	ids = relatedIDs(related)
+	if len(ids) != 2 || ids[0] != close1.ID || ids[1] != weak.ID {
+		t.Errorf("Expected only work subtree posts, got %v", ids)
+	}
+
+	related, _ = service.Related(context.Background(), target.ID, 1, false)
+	if len(related) != 1 {
+		t.Errorf("Expected limit to apply, got %d", len(related))
+	}
+
+	if _, err := service.Related(context.Background(), 9999, 5, false); err == nil || err.Error() != config.ErrPostNotFound {
+		t.Errorf("Expected post not found, got %v", err)
+	}
+}
+
+func TestRelatedIncrementalUpdates(t *testing.T) {
+	db, cleanup := setupRelatedTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	service := NewService(db, catCache, true)
+	service.Initialize()
+
+	target, _ := db.CreatePost(context.Background(), space.ID, "Postgres vacuum tuning notes")
+	other, _ := db.CreatePost(context.Background(), space.ID, "Garden planting schedule")
+	for _, id := range []int{target.ID, other.ID} {
+		service.HandleEvent(events.Event{Type: events.PostCreated, Data: events.PostEvent{PostID: id, SpaceID: space.ID}})
+	}
+
+	if related, _ := service.Related(context.Background(), target.ID, 5, false); len(related) != 0 {
+		t.Fatalf("Expected no related posts, got %+v", related)
+	}
+
+	// Edited content is reindexed
+	db.UpdatePostContent(other.ID, "Postgres vacuum settings for large tables")
+	service.HandleEvent(events.Event{Type: events.PostUpdated, Data: events.PostEvent{PostID: other.ID, SpaceID: space.ID}})
+
+	related, _ := service.Related(context.Background(), target.ID, 5, false)
+	if len(related) != 1 || related[0].PostID != other.ID || !strings.HasPrefix(related[0].Snippet, "Postgres vacuum settings") {
+		t.Fatalf("Expected updated post to be related, got %+v", related)
+	}
+
+	// Deleted posts leave the index and its document frequencies
+	db.DeletePost(context.Background(), other.ID)
+	service.HandleEvent(events.Event{Type: events.PostDeleted, Data: events.PostEvent{PostID: other.ID, SpaceID: space.ID}})
+
+	if related, _ := service.Related(context.Background(), target.ID, 5, false); len(related) != 0 {
+		t.Errorf("Expected deleted post to be gone, got %+v", related)
+	}
+	if service.df["postgres"] != 1 || service.df["settings"] != 0 {
+		t.Errorf("Expected document frequencies to follow deletions, got %v", service.df)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("word ", config.RelatedSnippetLength)
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"Short", "one\n\ntwo", "one two"},
+		{"Truncated", long, strings.TrimSpace(long[:config.RelatedSnippetLength]) + "…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := utils.Snippet(tt.content, config.RelatedSnippetLength); got != tt.expected {
+				t.Errorf("Snippet() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
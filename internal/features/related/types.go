@@ -0,0 +1,15 @@
+package related
+
+// RelatedPost is a post whose content resembles the requested one
+type RelatedPost struct {
+	PostID  int     `json:"post_id"`
+	SpaceID int     `json:"space_id"`
+	Created int64   `json:"created"`
+	Score   float64 `json:"score"` // Cosine similarity between 0 and 1
+	Snippet string  `json:"snippet"`
+}
+
+type RelatedResponse struct {
+	PostID  int           `json:"post_id"`
+	Related []RelatedPost `json:"related"`
+}
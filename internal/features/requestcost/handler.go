@@ -0,0 +1,85 @@
+package requestcost
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/services"
+	"bufio"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// RegisterRoutes adds no routes, request costs are only reported through Middleware
+func (h *Handler) RegisterRoutes(router *mux.Router) {}
+
+// Middleware charges the recursive operations of each request to a meter of its own, and
+// reports their cost in the request cost header of responses that had any
+func (h *Handler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !h.service.enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		meter := h.service.NewMeter()
+		next.ServeHTTP(&costWriter{ResponseWriter: w, meter: meter}, r.WithContext(services.WithRequestCost(r.Context(), meter)))
+
+		if cost := costOf(meter); cost.Spaces > 0 {
+			h.service.Report(r.Method, r.URL.Path, cost)
+		}
+	})
+}
+
+// costWriter sets the request cost header when the handler starts answering, once the work
+// is charged
+type costWriter struct {
+	http.ResponseWriter
+	meter *services.RequestCost
+	wrote bool
+}
+
+func (w *costWriter) WriteHeader(status int) {
+	if !w.wrote {
+		w.wrote = true
+		if cost := costOf(w.meter); cost.Spaces > 0 {
+			w.Header().Set(config.RequestCostHeader, cost.Header())
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *costWriter) Write(b []byte) (int, error) {
+	if !w.wrote {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps streamed responses working through the wrapper
+func (w *costWriter) Flush() {
+	if !w.wrote {
+		w.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSocket handlers take over the connection through the wrapper
+func (w *costWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	w.wrote = true
+	return hijacker.Hijack()
+}
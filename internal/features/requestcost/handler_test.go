@@ -0,0 +1,55 @@
+package requestcost
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestMiddleware(t *testing.T) {
+	charging := func(spaces, posts int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if err := services.RequestCostFromContext(r.Context()).Charge(spaces, posts); err != nil {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+				return
+			}
+			w.Write([]byte("ok"))
+		}
+	}
+
+	tests := []struct {
+		name           string
+		enabled        bool
+		spaces         int
+		expectedStatus int
+		expectedHeader string
+	}{
+		{"Recursive request", true, 3, http.StatusOK, "spaces=3; posts=10"},
+		{"Flat request", true, 0, http.StatusOK, ""},
+		{"Over the cap", true, 6, http.StatusUnprocessableEntity, "spaces=6; posts=10"},
+		{"Disabled", false, 6, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(NewService(tt.enabled, 5, 0))
+			router := mux.NewRouter()
+			router.Use(handler.Middleware)
+			handler.RegisterRoutes(router)
+			router.HandleFunc("/api/spaces/1/posts", charging(tt.spaces, 10))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/spaces/1/posts", nil))
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get(config.RequestCostHeader); got != tt.expectedHeader {
+				t.Errorf("Expected cost header %q, got %q", tt.expectedHeader, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package requestcost
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/services"
+
+	"go.uber.org/zap"
+)
+
+// Service caps the spaces traversed and posts scanned by the recursive operations of each
+// request, such as recursive post listings and searches, so a pathological hierarchy cannot
+// tie the server up. Requests going over a cap are refused; costly ones are logged, and every
+// response tells its cost so users can see why a view is slow.
+type Service struct {
+	maxSpaces int
+	maxPosts  int
+	enabled   bool
+}
+
+func NewService(enabled bool, maxSpaces, maxPosts int) *Service {
+	return &Service{
+		maxSpaces: max(maxSpaces, 0),
+		maxPosts:  max(maxPosts, 0),
+		enabled:   enabled,
+	}
+}
+
+// NewMeter returns the meter a request charges its recursive operations to
+func (s *Service) NewMeter() *services.RequestCost {
+	return services.NewRequestCost(s.maxSpaces, s.maxPosts)
+}
+
+// Report logs the cost of a request when it went over a cap or traversed many spaces
+func (s *Service) Report(method, path string, cost Cost) {
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("path", path),
+		zap.Int("spaces", cost.Spaces),
+		zap.Int("posts", cost.Posts),
+	}
+	switch {
+	case cost.Exceeded:
+		logger.Warning("Request refused over its cost caps", append(fields, zap.Int("max_spaces", s.maxSpaces), zap.Int("max_posts", s.maxPosts))...)
+	case cost.Spaces >= config.RequestCostLogSpaces:
+		logger.Info("Costly recursive request", fields...)
+	}
+}
+
+// costOf reads the totals of a meter
+func costOf(meter *services.RequestCost) Cost {
+	spaces, posts, exceeded := meter.Totals()
+	return Cost{Spaces: spaces, Posts: posts, Exceeded: exceeded}
+}
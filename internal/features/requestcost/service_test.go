@@ -0,0 +1,54 @@
+package requestcost
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"context"
+	"testing"
+)
+
+func TestMeterCaps(t *testing.T) {
+	service := NewService(true, 3, 100)
+
+	spaceCache := cache.NewSpaceCache()
+	parentID := 1
+	spaceCache.Set(&models.Space{ID: 1, PostCount: 40})
+	spaceCache.Set(&models.Space{ID: 2, ParentID: &parentID, PostCount: 50})
+	spaceCache.Set(&models.Space{ID: 3, ParentID: &parentID, PostCount: 30})
+
+	t.Run("charges add up to the caps", func(t *testing.T) {
+		meter := service.NewMeter()
+		ctx := services.WithRequestCost(context.Background(), meter)
+		if err := services.ChargeSpaces(ctx, spaceCache, []int{1, 2}); err != nil {
+			t.Fatalf("Expected 2 spaces and 90 posts to be allowed, got %v", err)
+		}
+		if err := services.ChargeSpaces(ctx, spaceCache, []int{3}); err == nil || err.Error() != config.ErrRequestCostExceeded {
+			t.Fatalf("Expected 120 posts to be refused, got %v", err)
+		}
+		if cost := costOf(meter); cost.Spaces != 3 || cost.Posts != 120 || !cost.Exceeded {
+			t.Errorf("Expected the refused work to be counted, got %+v", cost)
+		}
+		if header := costOf(meter).Header(); header != "spaces=3; posts=120" {
+			t.Errorf("Unexpected header %q", header)
+		}
+	})
+
+	t.Run("no caps", func(t *testing.T) {
+		meter := NewService(true, 0, -1).NewMeter()
+		if err := meter.Charge(1000000, 1000000); err != nil {
+			t.Errorf("Expected no caps to refuse nothing, got %v", err)
+		}
+	})
+
+	t.Run("requests without a meter are not charged", func(t *testing.T) {
+		if err := services.ChargeSpaces(context.Background(), spaceCache, []int{1, 2, 3}); err != nil {
+			t.Errorf("Expected nothing to be refused, got %v", err)
+		}
+		var none *services.RequestCost
+		if spaces, _, _ := none.Totals(); spaces != 0 || none.Charge(10, 10) != nil {
+			t.Error("Expected a nil meter to count and refuse nothing")
+		}
+	})
+}
@@ -0,0 +1,15 @@
+package requestcost
+
+import "fmt"
+
+// Cost is the work done by the recursive operations of a request
+type Cost struct {
+	Spaces   int  // Spaces traversed
+	Posts    int  // Posts scanned
+	Exceeded bool // Whether a cap refused some of it
+}
+
+// Header formats the cost as the value of the request cost header
+func (c Cost) Header() string {
+	return fmt.Sprintf("spaces=%d; posts=%d", c.Spaces, c.Posts)
+}
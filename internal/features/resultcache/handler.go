@@ -0,0 +1,156 @@
+package resultcache
+
+import (
+	"backthynk/internal/config"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// cacheableRoutes are the route templates whose recursive results are worth keeping
+var cacheableRoutes = map[string]bool{
+	"/api/spaces/{id:[0-9]+}/posts":            true,
+	"/api/spaces/{id:[0-9]+}/activity/compare": true,
+	"/api/spaces/{id:[0-9]+}/stats/downloads":  true,
+	"/api/activity/{id}":                       true,
+	"/api/space-stats/{id}":                    true,
+}
+
+// replayedHeaders are the response headers kept with a cached result, so a hit answers like
+// the handler would have, e.g. with the data version ETag of activity endpoints
+var replayedHeaders = []string{"Content-Type", "ETag", config.DataVersionHeader}
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/admin/result-cache", h.GetStats).Methods("GET")
+	api.HandleFunc("/admin/result-cache/invalidate", h.Invalidate).Methods("POST")
+}
+
+// Middleware serves recursive aggregations from the cache. Clients wanting a fresh result
+// send "Cache-Control: no-cache"; every cacheable response carries the cache status header.
+func (h *Handler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := cacheKey(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+			h.service.RecordBypass()
+			w.Header().Set(config.ResultCacheStatusHeader, StatusBypass)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version := h.service.Version()
+		key = fmt.Sprintf("%s|%s|%d", key, h.service.Visibility(r.Context()), version)
+		if body, header, found := h.service.Get(key); found {
+			for name, values := range header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(config.ResultCacheStatusHeader, StatusHit)
+			w.Write(body)
+			return
+		}
+
+		w.Header().Set(config.ResultCacheStatusHeader, StatusMiss)
+		rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status == http.StatusOK && !rec.overflow {
+			header := make(http.Header)
+			for _, name := range replayedHeaders {
+				if value := w.Header().Get(name); value != "" {
+					header.Set(name, value)
+				}
+			}
+			h.service.Store(key, version, rec.body.Bytes(), header)
+		}
+	})
+}
+
+// GetStats handles GET /api/admin/result-cache
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.Stats())
+}
+
+// Invalidate handles POST /api/admin/result-cache/invalidate
+func (h *Handler) Invalidate(w http.ResponseWriter, r *http.Request) {
+	h.service.Invalidate()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.service.Stats())
+}
+
+// cacheKey builds the key of a cacheable request, without the viewer visibility and the data
+// version. Only recursive
+// requests and those on the virtual root space are cached, as direct ones are cheap.
+// Conditional requests are left to the handler, whose answer depends on the client's version.
+func cacheKey(r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet || r.Header.Get("If-None-Match") != "" || r.URL.Query().Has("since_version") {
+		return "", false
+	}
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "", false
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil || !cacheableRoutes[template] {
+		return "", false
+	}
+
+	id := mux.Vars(r)["id"]
+	query := r.URL.Query()
+	if query.Get("recursive") != "true" && id != "0" {
+		return "", false
+	}
+
+	// Encode sorts by key, so equivalent queries share an entry
+	return template + "|" + id + "|" + query.Encode(), true
+}
+
+// recorder passes a response through while keeping a copy of it
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	overflow    bool
+	wroteHeader bool
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if !rec.overflow {
+		if rec.body.Len()+len(b) > config.MaxResultCacheEntryBytes {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(b)
+		}
+	}
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,203 @@
+package resultcache
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(NewService(false, 0))
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/admin/result-cache", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected result cache routes NOT to be registered when disabled")
+	}
+}
+
+func setupCacheRouter() (*mux.Router, *Service, *int) {
+	service := NewService(true, 0)
+	calls := 0
+
+	handler := NewHandler(service)
+	router := mux.NewRouter()
+	router.Use(handler.Middleware)
+	handler.RegisterRoutes(router)
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/space-stats/{id}", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"7"`)
+		fmt.Fprintf(w, `{"calls":%d}`, calls)
+	}).Methods("GET")
+
+	return router, service, &calls
+}
+
+func TestMiddleware(t *testing.T) {
+	router, service, calls := setupCacheRouter()
+
+	tests := []struct {
+		name           string
+		path           string
+		noCache        bool
+		expectedStatus string
+		expectedCalls  int
+	}{
+		{"Direct not cached", "/api/space-stats/1", false, "", 1},
+		{"Recursive miss", "/api/space-stats/1?recursive=true", false, StatusMiss, 2},
+		{"Recursive hit", "/api/space-stats/1?recursive=true", false, StatusHit, 2},
+		{"Root space miss", "/api/space-stats/0", false, StatusMiss, 3},
+		{"Root space hit", "/api/space-stats/0", false, StatusHit, 3},
+		{"Bypass", "/api/space-stats/1?recursive=true", true, StatusBypass, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.noCache {
+				req.Header.Set("Cache-Control", "no-cache")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d", w.Code)
+			}
+			if got := w.Header().Get(config.ResultCacheStatusHeader); got != tt.expectedStatus {
+				t.Errorf("Expected cache status %q, got %q", tt.expectedStatus, got)
+			}
+			if *calls != tt.expectedCalls {
+				t.Errorf("Expected %d handler calls, got %d", tt.expectedCalls, *calls)
+			}
+		})
+	}
+
+	service.HandleEvent(events.Event{Type: events.FileUploaded})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/space-stats/0", nil))
+	if w.Header().Get(config.ResultCacheStatusHeader) != StatusMiss {
+		t.Error("Expected a miss after an event")
+	}
+}
+
+func TestMiddlewareConditional(t *testing.T) {
+	router, _, calls := setupCacheRouter()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/space-stats/0", nil))
+
+	// Hits answer with the headers of the cached response
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/space-stats/0", nil))
+	if w.Header().Get(config.ResultCacheStatusHeader) != StatusHit || w.Header().Get("ETag") != `"7"` {
+		t.Errorf("Expected a hit replaying the ETag, got headers %v", w.Header())
+	}
+
+	// Conditional requests always reach the handler
+	for _, path := range []string{"/api/space-stats/0?since_version=7", "/api/space-stats/0"} {
+		req := httptest.NewRequest("GET", path, nil)
+		if path == "/api/space-stats/0" {
+			req.Header.Set("If-None-Match", `"7"`)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Header().Get(config.ResultCacheStatusHeader) != "" {
+			t.Errorf("%s: expected conditional request not to be cached", path)
+		}
+	}
+	if *calls != 3 {
+		t.Errorf("Expected 3 handler calls, got %d", *calls)
+	}
+}
+
+func TestMiddlewareSeparatesViewers(t *testing.T) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+	tempDir, err := os.MkdirTemp("", "backthynk_resultcache_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	catCache := cache.NewSpaceCache()
+	private, _ := db.CreateSpace("Private", nil, "")
+	catCache.Set(private)
+	alice, _ := db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	bob, _ := db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(db, catCache)
+	if err := access.SetACL(private.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+
+	router, service, _ := setupCacheRouter()
+	service.SetSpaceAccess(access)
+
+	tests := []struct {
+		name           string
+		viewer         services.Viewer
+		expectedStatus string
+	}{
+		{"Listed viewer computes", services.Viewer{UserID: alice.ID}, StatusMiss},
+		{"Admin sees the same spaces", services.Viewer{UserID: 99, Admin: true}, StatusHit},
+		{"Restricted viewer gets its own result", services.Viewer{UserID: bob.ID}, StatusMiss},
+		{"Restricted viewer hits its own result", services.Viewer{UserID: bob.ID}, StatusHit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/space-stats/0", nil)
+			req = req.WithContext(services.WithViewer(context.Background(), tt.viewer))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if got := w.Header().Get(config.ResultCacheStatusHeader); got != tt.expectedStatus {
+				t.Errorf("Expected cache status %q, got %q", tt.expectedStatus, got)
+			}
+		})
+	}
+}
+
+func TestStatsAndInvalidate(t *testing.T) {
+	router, _, _ := setupCacheRouter()
+	for i := 0; i < 2; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/space-stats/0", nil))
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/admin/result-cache", nil))
+	var stats Stats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/admin/result-cache/invalidate", nil))
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if stats.Entries != 0 || stats.DataVersion != 1 {
+		t.Errorf("Expected an empty cache after invalidate, got %+v", stats)
+	}
+}
@@ -0,0 +1,201 @@
+package resultcache
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/services"
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	body    []byte
+	header  http.Header
+	expires time.Time
+}
+
+// Service keeps the responses of expensive recursive aggregations for a short time. Keys
+// include a data version bumped by every content event, so a change is never served stale
+// from before it happened; the TTL bounds staleness from changes made without an event,
+// e.g. link previews fetched in the background.
+type Service struct {
+	ttl     time.Duration
+	entries map[string]*entry
+	bytes   int64
+	version uint64
+	stats   Stats
+	mu      sync.Mutex
+	now     func() time.Time
+	access  *services.SpaceAccess
+	enabled bool
+}
+
+func NewService(enabled bool, ttlSeconds int) *Service {
+	if ttlSeconds <= 0 {
+		ttlSeconds = config.DefaultResultCacheTTLSeconds
+	}
+	ttlSeconds = min(ttlSeconds, config.MaxResultCacheTTLSeconds)
+
+	return &Service{
+		ttl:     time.Duration(ttlSeconds) * time.Second,
+		entries: make(map[string]*entry),
+		now:     time.Now,
+		enabled: enabled,
+	}
+}
+
+// SetSpaceAccess keeps results computed for one viewer from being served to viewers that see
+// other spaces
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// Visibility names the spaces hidden from the viewer of ctx, empty when it sees them all.
+// Viewers hiding the same spaces share cached results.
+func (s *Service) Visibility(ctx context.Context) string {
+	hidden := s.access.Hidden(ctx)
+	if len(hidden) == 0 {
+		return ""
+	}
+
+	ids := make([]int, 0, len(hidden))
+	for id := range hidden {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+// HandleEvent bumps the data version on any change to posts, spaces or files
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	s.Invalidate()
+	return nil
+}
+
+// Invalidate drops every cached result
+func (s *Service) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.version++
+	s.entries = make(map[string]*entry)
+	s.bytes = 0
+}
+
+// Version returns the current data version, to be captured before computing a result
+func (s *Service) Version() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.version
+}
+
+// Get returns the cached result of key if it has not expired
+func (s *Service) Get(key string) (body []byte, header http.Header, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, found := s.entries[key]
+	if found && s.now().After(e.expires) {
+		s.removeUnlocked(key, e)
+		found = false
+	}
+	if !found {
+		s.stats.Misses++
+		return nil, nil, false
+	}
+
+	s.stats.Hits++
+	return e.body, e.header, true
+}
+
+// Store keeps a result computed at data version, with the headers replayed on hits. Results
+// of an older version are dropped, as an event arrived while they were computed.
+func (s *Service) Store(key string, version uint64, body []byte, header http.Header) {
+	if len(body) > config.MaxResultCacheEntryBytes {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if version != s.version {
+		return
+	}
+	if old, ok := s.entries[key]; ok {
+		s.removeUnlocked(key, old)
+	}
+	if len(s.entries) >= config.MaxResultCacheEntries {
+		s.evictUnlocked()
+	}
+
+	s.entries[key] = &entry{body: body, header: header, expires: s.now().Add(s.ttl)}
+	s.bytes += int64(len(body))
+	s.stats.Stores++
+}
+
+// RecordBypass counts a request that skipped the cache
+func (s *Service) RecordBypass() {
+	s.mu.Lock()
+	s.stats.Bypasses++
+	s.mu.Unlock()
+}
+
+func (s *Service) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Entries = len(s.entries)
+	stats.Bytes = s.bytes
+	stats.DataVersion = s.version
+	stats.TTLSeconds = int(s.ttl / time.Second)
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
+// evictUnlocked drops expired entries, or the one expiring first when none has.
+// Caller must hold s.mu.
+func (s *Service) evictUnlocked() {
+	now := s.now()
+	var oldestKey string
+	var oldest *entry
+	evicted := false
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			s.removeUnlocked(key, e)
+			evicted = true
+			continue
+		}
+		if oldest == nil || e.expires.Before(oldest.expires) {
+			oldestKey, oldest = key, e
+		}
+	}
+
+	if !evicted && oldest != nil {
+		s.removeUnlocked(oldestKey, oldest)
+		s.stats.Evictions++
+	}
+}
+
+// removeUnlocked deletes an entry. Caller must hold s.mu.
+func (s *Service) removeUnlocked(key string, e *entry) {
+	delete(s.entries, key)
+	s.bytes -= int64(len(e.body))
+}
@@ -0,0 +1,90 @@
+package resultcache
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/events"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewServiceClampsTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      int
+		expected int
+	}{
+		{"Default", 0, config.DefaultResultCacheTTLSeconds},
+		{"Custom", 5, 5},
+		{"Above max", config.MaxResultCacheTTLSeconds + 1, config.MaxResultCacheTTLSeconds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewService(true, tt.ttl).Stats().TTLSeconds; got != tt.expected {
+				t.Errorf("Expected ttl %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetAndExpiry(t *testing.T) {
+	service := NewService(true, 10)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	service.Store("a", service.Version(), []byte("result"), http.Header{"Content-Type": {"application/json"}})
+	body, header, ok := service.Get("a")
+	if !ok || string(body) != "result" || header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected cached result, got %q %v %v", body, header, ok)
+	}
+
+	now = now.Add(11 * time.Second)
+	if _, _, ok := service.Get("a"); ok {
+		t.Error("Expected entry to expire after the ttl")
+	}
+
+	stats := service.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.HitRate != 0.5 || stats.Entries != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestEventInvalidates(t *testing.T) {
+	service := NewService(true, 10)
+	version := service.Version()
+	service.Store("a", version, []byte("result"), nil)
+
+	service.HandleEvent(events.Event{Type: events.PostCreated})
+	if _, _, ok := service.Get("a"); ok {
+		t.Error("Expected event to drop cached results")
+	}
+
+	// A result computed before the event must not be stored after it
+	service.Store("b", version, []byte("stale"), nil)
+	if _, _, ok := service.Get("b"); ok {
+		t.Error("Expected result of an older data version to be dropped")
+	}
+}
+
+func TestStoreEvictsWhenFull(t *testing.T) {
+	service := NewService(true, 10)
+	now := time.Now()
+	service.now = func() time.Time { return now }
+
+	for i := 0; i < config.MaxResultCacheEntries+1; i++ {
+		now = now.Add(time.Millisecond)
+		service.Store(fmt.Sprintf("key-%d", i), 0, []byte("x"), nil)
+	}
+
+	stats := service.Stats()
+	if stats.Entries != config.MaxResultCacheEntries || stats.Evictions != 1 {
+		t.Errorf("Expected a full cache with one eviction, got %+v", stats)
+	}
+
+	service.Store("big", 0, make([]byte, config.MaxResultCacheEntryBytes+1), nil)
+	if _, _, ok := service.Get("big"); ok {
+		t.Error("Expected oversized result not to be stored")
+	}
+}
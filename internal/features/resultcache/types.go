@@ -0,0 +1,22 @@
+package resultcache
+
+// Values of the cache status response header
+const (
+	StatusHit    = "hit"
+	StatusMiss   = "miss"
+	StatusBypass = "bypass"
+)
+
+// Stats counts cache lookups since startup
+type Stats struct {
+	Hits        int64   `json:"hits"`
+	Misses      int64   `json:"misses"`
+	Bypasses    int64   `json:"bypasses"` // Requests asking for a fresh result
+	Stores      int64   `json:"stores"`
+	Evictions   int64   `json:"evictions"` // Entries dropped to make room
+	Entries     int     `json:"entries"`
+	Bytes       int64   `json:"bytes"`
+	DataVersion uint64  `json:"data_version"`
+	HitRate     float64 `json:"hit_rate"` // Hits over hits and misses, 0 before any lookup
+	TTLSeconds  int     `json:"ttl_seconds"`
+}
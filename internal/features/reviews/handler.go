@@ -0,0 +1,132 @@
+package reviews
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/reviews/due", h.GetDue).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/review", h.GetSchedule).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/review", h.Schedule).Methods("PUT")
+	api.HandleFunc("/spaces/{id:[0-9]+}/review", h.Unschedule).Methods("DELETE")
+	api.HandleFunc("/spaces/{id:[0-9]+}/review/complete", h.Complete).Methods("POST")
+}
+
+// GetDue handles GET /api/reviews/due
+func (h *Handler) GetDue(w http.ResponseWriter, r *http.Request) {
+	due, err := h.service.GetDue(r.Context())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(due)
+}
+
+// GetSchedule handles GET /api/spaces/{id}/review
+func (h *Handler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.service.GetSchedule(r.Context(), spaceID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// Schedule handles PUT /api/spaces/{id}/review with {"interval_days": int}
+func (h *Handler) Schedule(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.service.Schedule(r.Context(), spaceID, req.IntervalDays)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// Unschedule handles DELETE /api/spaces/{id}/review
+func (h *Handler) Unschedule(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Unschedule(r.Context(), spaceID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Complete handles POST /api/spaces/{id}/review/complete
+func (h *Handler) Complete(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.service.Complete(r.Context(), spaceID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case config.ErrSpaceNotFound, config.ErrReviewNotScheduled:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case config.ErrInvalidReviewInterval:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case config.ErrSpaceAccessDenied:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,72 @@
+package reviews
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/reviews/due", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected review routes NOT to be registered when disabled")
+	}
+}
+
+func TestReviewsHandlers(t *testing.T) {
+	setup := setupReviewsTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Projects", nil, "")
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	path := fmt.Sprintf("/api/spaces/%d/review", space.ID)
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Get unscheduled", "GET", path, "", http.StatusNotFound, ""},
+		{"Schedule", "PUT", path, `{"interval_days":30}`, http.StatusOK, `"interval_days":30`},
+		{"Invalid interval", "PUT", path, `{"interval_days":-1}`, http.StatusBadRequest, ""},
+		{"Invalid JSON", "PUT", path, `nope`, http.StatusBadRequest, ""},
+		{"Unknown space", "PUT", "/api/spaces/999/review", `{"interval_days":30}`, http.StatusNotFound, ""},
+		{"Get scheduled", "GET", path, "", http.StatusOK, `"interval_days":30`},
+		{"Due", "GET", "/api/reviews/due", "", http.StatusOK, `"space_name":"Projects"`},
+		{"Complete", "POST", path + "/complete", "", http.StatusOK, `"interval_days":30`},
+		{"Nothing due", "GET", "/api/reviews/due", "", http.StatusOK, `[]`},
+		{"Unschedule", "DELETE", path, "", http.StatusNoContent, ""},
+		{"Complete unscheduled", "POST", path + "/complete", "", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.name == "Due" {
+				setup.clock = setup.clock.AddDate(0, 0, 31)
+			}
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !bytes.Contains(w.Body.Bytes(), []byte(tt.expectedBody)) {
+				t.Errorf("Expected body to contain %s, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package reviews
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const dayMillis = int64(24 * time.Hour / time.Millisecond)
+
+// Service schedules spaces for periodic review. A review falls due interval days after the
+// previous one was completed; due reviews raise a notification once and are listed with a
+// summary of what changed since the previous review, until marked complete.
+type Service struct {
+	db         *storage.DB
+	catCache   *cache.SpaceCache
+	access     *services.SpaceAccess
+	dispatcher *events.Dispatcher
+	stop       chan struct{}
+	now        func() time.Time
+	enabled    bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		now:      time.Now,
+		enabled:  enabled,
+	}
+}
+
+// SetDispatcher lets due reviews raise a notification
+func (s *Service) SetDispatcher(dispatcher *events.Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// SetSpaceAccess leaves the spaces hidden from the viewer of a request out of its due reviews,
+// and lets only the viewers that may change a space schedule or complete its reviews
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// GetSchedule returns the review schedule of a space
+func (s *Service) GetSchedule(ctx context.Context, spaceID int) (*models.SpaceReview, error) {
+	if err := s.checkSpace(ctx, spaceID, false); err != nil {
+		return nil, err
+	}
+	review, err := s.db.GetSpaceReview(spaceID)
+	if err != nil {
+		return nil, reviewLookupError(err)
+	}
+	return review, nil
+}
+
+// Schedule sets how often a space is reviewed. A new schedule starts now; changing the
+// interval of an existing one moves its due time but keeps the last review.
+func (s *Service) Schedule(ctx context.Context, spaceID int, intervalDays int) (*models.SpaceReview, error) {
+	if err := s.checkSpace(ctx, spaceID, true); err != nil {
+		return nil, err
+	}
+	if intervalDays == 0 {
+		intervalDays = config.DefaultReviewIntervalDays
+	}
+	if intervalDays < 1 || intervalDays > config.MaxReviewIntervalDays {
+		return nil, fmt.Errorf(config.ErrInvalidReviewInterval)
+	}
+
+	review := &models.SpaceReview{SpaceID: spaceID, IntervalDays: intervalDays, LastReviewed: s.now().UnixMilli()}
+	if existing, err := s.db.GetSpaceReview(spaceID); err == nil {
+		review.LastReviewed = existing.LastReviewed
+	} else if err.Error() != "space review not found" {
+		return nil, err
+	}
+	review.Due = review.LastReviewed + int64(intervalDays)*dayMillis
+
+	if err := s.db.SaveSpaceReview(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// Unschedule stops reviewing a space
+func (s *Service) Unschedule(ctx context.Context, spaceID int) error {
+	if err := s.checkSpace(ctx, spaceID, true); err != nil {
+		return err
+	}
+	return reviewLookupError(s.db.DeleteSpaceReview(spaceID))
+}
+
+// Complete marks the review of a space done now, due or not, so the next one falls due a
+// full interval later
+func (s *Service) Complete(ctx context.Context, spaceID int) (*models.SpaceReview, error) {
+	if err := s.checkSpace(ctx, spaceID, true); err != nil {
+		return nil, err
+	}
+	review, err := s.GetSchedule(ctx, spaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	review.LastReviewed = s.now().UnixMilli()
+	review.Due = review.LastReviewed + int64(review.IntervalDays)*dayMillis
+	if err := s.db.SaveSpaceReview(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// GetDue returns the reviews due now, the longest due first, with the changes of each space
+// and its descendants since its last review. Spaces hidden from the viewer of ctx are left out.
+func (s *Service) GetDue(ctx context.Context) ([]DueReview, error) {
+	reviews, err := s.db.GetDueSpaceReviews(s.now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+
+	due := []DueReview{}
+	for _, review := range reviews {
+		space, ok := s.catCache.Get(review.SpaceID)
+		if !ok || !s.access.CanRead(ctx, review.SpaceID) {
+			continue
+		}
+		spaceIDs := s.access.Scope(ctx, append([]int{review.SpaceID}, s.catCache.GetDescendants(review.SpaceID)...))
+		changes, err := s.db.GetReviewChanges(spaceIDs, review.LastReviewed, config.MaxReviewChangedPosts)
+		if err != nil {
+			return nil, err
+		}
+		due = append(due, DueReview{SpaceReview: review, SpaceName: space.Name, Changes: *changes})
+	}
+
+	return due, nil
+}
+
+// Check raises a notification for every review that fell due since the last check
+func (s *Service) Check() {
+	reviews, err := s.db.GetDueSpaceReviews(s.now().UnixMilli())
+	if err != nil {
+		logger.Warning("Failed to check due space reviews", zap.Error(err))
+		return
+	}
+
+	for _, review := range reviews {
+		if review.Notified == review.Due {
+			continue
+		}
+		space, ok := s.catCache.Get(review.SpaceID)
+		if !ok {
+			continue
+		}
+		s.dispatcher.Notify(events.NotificationEvent{
+			Kind:    models.NotificationReviewDue,
+			Title:   "Review due",
+			Message: fmt.Sprintf("%s is due for its review", space.Name),
+			SpaceID: review.SpaceID,
+			Key:     "review." + strconv.Itoa(review.SpaceID),
+		})
+		if err := s.db.MarkSpaceReviewNotified(review.SpaceID, review.Due); err != nil {
+			logger.Warning("Failed to mark space review notified", zap.Int("space_id", review.SpaceID), zap.Error(err))
+		}
+	}
+}
+
+// StartChecks checks for due reviews immediately and then once per interval until Stop is called
+func (s *Service) StartChecks(interval time.Duration) {
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	s.Check()
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.Check()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// Stop ends the periodic checks
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// checkSpace returns ErrSpaceNotFound for spaces unknown or hidden from the viewer of ctx, and
+// ErrSpaceAccessDenied when write is set on a space it may only read
+func (s *Service) checkSpace(ctx context.Context, spaceID int, write bool) error {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if write && !s.access.CanWrite(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+	return nil
+}
+
+// reviewLookupError turns the not found error of storage into the one of the API
+func reviewLookupError(err error) error {
+	if err != nil && err.Error() == "space review not found" {
+		return fmt.Errorf(config.ErrReviewNotScheduled)
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+package reviews
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+type reviewsTestSetup struct {
+	db            *storage.DB
+	catCache      *cache.SpaceCache
+	spaceService  *services.SpaceService
+	postService   *services.PostService
+	service       *Service
+	clock         time.Time
+	notifications []events.NotificationEvent
+	cleanup       func()
+}
+
+func setupReviewsTest(t *testing.T) *reviewsTestSetup {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+	config.SetOptionsConfigForTest(config.NewTestOptionsConfig().WithRetroactivePostingEnabled(true))
+
+	tempDir, err := os.MkdirTemp("", "backthynk_reviews_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	setup := &reviewsTestSetup{
+		db:           db,
+		catCache:     catCache,
+		spaceService: services.NewSpaceService(db, catCache, dispatcher),
+		postService:  services.NewPostService(db, catCache, dispatcher),
+		service:      NewService(db, catCache, true),
+		// Posts are stamped with the real time, so the schedule starts in the past
+		clock: time.Now().AddDate(0, 0, -100),
+		cleanup: func() {
+			db.Close()
+			os.RemoveAll(tempDir)
+		},
+	}
+	setup.service.now = func() time.Time { return setup.clock }
+	setup.service.SetDispatcher(dispatcher)
+	dispatcher.Subscribe(events.NotificationRaised, func(event events.Event) error {
+		setup.notifications = append(setup.notifications, event.Data.(events.NotificationEvent))
+		return nil
+	})
+
+	return setup
+}
+
+func TestScheduleValidation(t *testing.T) {
+	setup := setupReviewsTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Projects", nil, "")
+
+	for _, days := range []int{-1, config.MaxReviewIntervalDays + 1} {
+		if _, err := setup.service.Schedule(context.Background(), space.ID, days); err == nil || err.Error() != config.ErrInvalidReviewInterval {
+			t.Errorf("Expected interval %d to be rejected, got %v", days, err)
+		}
+	}
+	if _, err := setup.service.Schedule(context.Background(), 999, 30); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected unknown space to be rejected, got %v", err)
+	}
+	if _, err := setup.service.GetSchedule(context.Background(), space.ID); err == nil || err.Error() != config.ErrReviewNotScheduled {
+		t.Errorf("Expected no schedule yet, got %v", err)
+	}
+	if _, err := setup.service.Complete(context.Background(), space.ID); err == nil || err.Error() != config.ErrReviewNotScheduled {
+		t.Errorf("Expected completing an unscheduled review to fail, got %v", err)
+	}
+
+	review, err := setup.service.Schedule(context.Background(), space.ID, 0)
+	if err != nil || review.IntervalDays != config.DefaultReviewIntervalDays || review.LastReviewed != setup.clock.UnixMilli() {
+		t.Fatalf("Expected the default interval from now, got %+v, %v", review, err)
+	}
+
+	// A new interval keeps the start of the schedule
+	setup.clock = setup.clock.Add(time.Hour)
+	review, _ = setup.service.Schedule(context.Background(), space.ID, 7)
+	if review.LastReviewed != setup.clock.Add(-time.Hour).UnixMilli() || review.Due != review.LastReviewed+7*dayMillis {
+		t.Errorf("Expected the due time to follow the new interval, got %+v", review)
+	}
+
+	if err := setup.service.Unschedule(context.Background(), space.ID); err != nil {
+		t.Fatalf("Failed to unschedule: %v", err)
+	}
+	if err := setup.service.Unschedule(context.Background(), space.ID); err == nil || err.Error() != config.ErrReviewNotScheduled {
+		t.Errorf("Expected a second unschedule to fail, got %v", err)
+	}
+}
+
+func TestDueReviews(t *testing.T) {
+	setup := setupReviewsTest(t)
+	defer setup.cleanup()
+
+	projects, _ := setup.spaceService.Create(context.Background(), "Projects", nil, "")
+	active, _ := setup.spaceService.Create(context.Background(), "Active", &projects.ID, "")
+	notes, _ := setup.spaceService.Create(context.Background(), "Notes", nil, "")
+
+	old := setup.clock.Add(-24 * time.Hour).UnixMilli()
+	edited, _ := setup.postService.Create(context.Background(), projects.ID, "Written before the schedule", &old)
+	setup.postService.Create(context.Background(), projects.ID, "Untouched", &old)
+
+	setup.service.Schedule(context.Background(), projects.ID, 90)
+	setup.service.Schedule(context.Background(), notes.ID, 365)
+
+	// Changes after the schedule started
+	created, _ := setup.postService.Create(context.Background(), active.ID, "New in a subspace", nil)
+	setup.postService.Update(context.Background(), edited.ID, "Edited since", nil)
+
+	setup.clock = setup.clock.AddDate(0, 0, 89)
+	if due, _ := setup.service.GetDue(context.Background()); len(due) != 0 {
+		t.Fatalf("Expected nothing due yet, got %+v", due)
+	}
+	setup.service.Check()
+	if len(setup.notifications) != 0 {
+		t.Fatalf("Expected no notification yet, got %+v", setup.notifications)
+	}
+
+	setup.clock = setup.clock.AddDate(0, 0, 2)
+	due, err := setup.service.GetDue(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get due reviews: %v", err)
+	}
+	if len(due) != 1 || due[0].SpaceID != projects.ID || due[0].SpaceName != "Projects" {
+		t.Fatalf("Expected the Projects review to be due, got %+v", due)
+	}
+	changes := due[0].Changes
+	if changes.NewPosts != 1 || changes.UpdatedPosts != 1 || len(changes.PostIDs) != 2 || changes.LastActivity == 0 {
+		t.Errorf("Expected 1 new and 1 updated post, got %+v", changes)
+	}
+	if listed := fmt.Sprint(changes.PostIDs); listed != fmt.Sprint([]int{edited.ID, created.ID}) && listed != fmt.Sprint([]int{created.ID, edited.ID}) {
+		t.Errorf("Expected only the changed posts to be listed, got %v", changes.PostIDs)
+	}
+
+	// A due review is announced once
+	setup.service.Check()
+	setup.service.Check()
+	if len(setup.notifications) != 1 || setup.notifications[0].Kind != models.NotificationReviewDue || setup.notifications[0].SpaceID != projects.ID {
+		t.Fatalf("Expected one review due notification, got %+v", setup.notifications)
+	}
+
+	review, err := setup.service.Complete(context.Background(), projects.ID)
+	if err != nil || review.LastReviewed != setup.clock.UnixMilli() || review.Due != review.LastReviewed+90*dayMillis {
+		t.Fatalf("Expected the next review a full interval later, got %+v, %v", review, err)
+	}
+	if due, _ := setup.service.GetDue(context.Background()); len(due) != 0 {
+		t.Errorf("Expected no due review after completion, got %+v", due)
+	}
+
+	// The next due time is announced again
+	setup.clock = setup.clock.AddDate(0, 0, 91)
+	setup.service.Check()
+	if len(setup.notifications) != 2 {
+		t.Errorf("Expected the next due review to be announced, got %d notifications", len(setup.notifications))
+	}
+}
+
+func TestReviewsHidePrivateSpaces(t *testing.T) {
+	setup := setupReviewsTest(t)
+	defer setup.cleanup()
+
+	projects, _ := setup.spaceService.Create(context.Background(), "Projects", nil, "")
+	secret, _ := setup.spaceService.Create(context.Background(), "Secret", &projects.ID, "")
+	setup.service.Schedule(context.Background(), projects.ID, 30)
+	setup.postService.Create(context.Background(), projects.ID, "Shared", nil)
+	setup.postService.Create(context.Background(), secret.ID, "Hidden", nil)
+
+	alice, _ := setup.db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	bob, _ := setup.db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(setup.db, setup.catCache)
+	if err := access.SetACL(projects.ID, []models.SpaceACLEntry{
+		{UserID: alice.ID, Permission: models.SpacePermissionRead},
+		{UserID: bob.ID, Permission: models.SpacePermissionWrite},
+	}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+	if err := access.SetACL(secret.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+	setup.service.SetSpaceAccess(access)
+	asAlice := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	asBob := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+
+	if _, err := setup.service.Complete(asAlice, projects.ID); err == nil || err.Error() != config.ErrSpaceAccessDenied {
+		t.Errorf("Expected a reader to be refused completing the review, got %v", err)
+	}
+	if _, err := setup.service.Schedule(asBob, secret.ID, 30); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+
+	setup.clock = setup.clock.AddDate(0, 0, 31)
+	if due, _ := setup.service.GetDue(asAlice); len(due) != 1 || due[0].Changes.NewPosts != 2 {
+		t.Errorf("Expected both new posts for a listed viewer, got %+v", due)
+	}
+	if due, _ := setup.service.GetDue(asBob); len(due) != 1 || due[0].Changes.NewPosts != 1 {
+		t.Errorf("Expected the hidden post to be left out of the changes, got %+v", due)
+	}
+}
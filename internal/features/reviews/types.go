@@ -0,0 +1,17 @@
+package reviews
+
+import "backthynk/internal/core/models"
+
+// ScheduleRequest is the body of PUT /api/spaces/{id}/review. A zero interval uses the
+// default, quarterly one.
+type ScheduleRequest struct {
+	IntervalDays int `json:"interval_days"`
+}
+
+// DueReview is a space whose review is due, with what changed in it and its descendants
+// since the last review
+type DueReview struct {
+	models.SpaceReview
+	SpaceName string               `json:"space_name"`
+	Changes   models.ReviewChanges `json:"changes"`
+}
@@ -0,0 +1,206 @@
+package rules
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/rules", h.ListRules).Methods("GET")
+	api.HandleFunc("/rules", h.CreateRule).Methods("POST")
+	api.HandleFunc("/rules/executions", h.GetExecutions).Methods("GET")
+	api.HandleFunc("/rules/test", h.TestDefinition).Methods("POST")
+	api.HandleFunc("/rules/{id:[0-9]+}", h.GetRule).Methods("GET")
+	api.HandleFunc("/rules/{id:[0-9]+}", h.UpdateRule).Methods("PUT")
+	api.HandleFunc("/rules/{id:[0-9]+}", h.DeleteRule).Methods("DELETE")
+	api.HandleFunc("/rules/{id:[0-9]+}/executions", h.GetExecutions).Methods("GET")
+	api.HandleFunc("/rules/{id:[0-9]+}/test", h.TestRule).Methods("POST")
+}
+
+// ListRules handles GET /api/rules
+func (h *Handler) ListRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RulesResponse{Rules: h.service.List()})
+}
+
+// GetRule handles GET /api/rules/{id}
+func (h *Handler) GetRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidRuleID, http.StatusBadRequest)
+		return
+	}
+
+	rule, ok := h.service.Get(id)
+	if !ok {
+		http.Error(w, config.ErrRuleNotFound, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// CreateRule handles POST /api/rules
+func (h *Handler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var req RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.service.Create(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateRule handles PUT /api/rules/{id}
+func (h *Handler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidRuleID, http.StatusBadRequest)
+		return
+	}
+
+	var req RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.service.Update(r.Context(), id, req)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeleteRule handles DELETE /api/rules/{id}
+func (h *Handler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidRuleID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetExecutions handles GET /api/rules/executions and GET /api/rules/{id}/executions
+//
+// Query parameters:
+// - limit: number of executions returned, newest first (default: 50, max: 200)
+func (h *Handler) GetExecutions(w http.ResponseWriter, r *http.Request) {
+	ruleID := 0
+	if idStr, ok := mux.Vars(r)["id"]; ok {
+		var err error
+		if ruleID, err = strconv.Atoi(idStr); err != nil {
+			http.Error(w, config.ErrInvalidRuleID, http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := config.DefaultRuleLogLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > config.MaxRuleLogLimit {
+			http.Error(w, config.ErrInvalidRuleLogLimit, http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	executions, err := h.service.Executions(ruleID, limit)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ExecutionsResponse{Executions: executions})
+}
+
+// TestRule handles POST /api/rules/{id}/test, a dry run of a saved rule on a post
+func (h *Handler) TestRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidRuleID, http.StatusBadRequest)
+		return
+	}
+
+	var req TestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.Test(r.Context(), id, req.PostID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// TestDefinition handles POST /api/rules/test, a dry run of an unsaved rule on a post
+func (h *Handler) TestDefinition(w http.ResponseWriter, r *http.Request) {
+	var req TestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Rule == nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.TestDefinition(r.Context(), *req.Rule, req.PostID)
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func errorStatus(err error) int {
+	switch err.Error() {
+	case config.ErrRuleNotFound, config.ErrPostNotFound:
+		return http.StatusNotFound
+	case config.ErrRuleExists:
+		return http.StatusConflict
+	case config.ErrSpaceAccessDenied:
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
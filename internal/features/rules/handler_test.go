@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"backthynk/internal/core/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/rules", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected rule routes NOT to be registered when disabled")
+	}
+}
+
+func TestRuleHandlers(t *testing.T) {
+	setup := setupRulesTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Inbox", nil, "")
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	// The first rule acts on the post created after it
+	setup.service.Create(context.Background(), RuleRequest{
+		Name:       "Urgent",
+		Trigger:    "post.created",
+		Conditions: models.RuleConditions{SpaceID: space.ID, Contains: "urgent"},
+		Actions:    []models.RuleAction{{Type: models.RuleActionAddTag, Tag: "urgent"}},
+	})
+	setup.postService.Create(context.Background(), space.ID, "Urgent: call back", nil)
+
+	rule := fmt.Sprintf(`{"name":"Calls","trigger":"post.created","conditions":{"space_id":%d,"contains":"call"},"actions":[{"type":"reminder","after_hours":4}]}`, space.ID)
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"Create rule", "POST", "/api/rules", rule, http.StatusCreated},
+		{"Invalid JSON", "POST", "/api/rules", `nope`, http.StatusBadRequest},
+		{"Duplicate name", "POST", "/api/rules", rule, http.StatusConflict},
+		{"Invalid trigger", "POST", "/api/rules", `{"name":"Other","trigger":"file.uploaded","actions":[{"type":"add_tag","tag":"x"}]}`, http.StatusBadRequest},
+		{"List rules", "GET", "/api/rules", "", http.StatusOK},
+		{"Get rule", "GET", "/api/rules/1", "", http.StatusOK},
+		{"Get unknown rule", "GET", "/api/rules/999", "", http.StatusNotFound},
+		{"Executions", "GET", "/api/rules/1/executions", "", http.StatusOK},
+		{"All executions", "GET", "/api/rules/executions?limit=5", "", http.StatusOK},
+		{"Invalid limit", "GET", "/api/rules/executions?limit=0", "", http.StatusBadRequest},
+		{"Executions of unknown rule", "GET", "/api/rules/999/executions", "", http.StatusNotFound},
+		{"Test saved rule", "POST", "/api/rules/1/test", `{"post_id":1}`, http.StatusOK},
+		{"Test without post", "POST", "/api/rules/1/test", `{}`, http.StatusBadRequest},
+		{"Test on unknown post", "POST", "/api/rules/1/test", `{"post_id":999}`, http.StatusNotFound},
+		{"Test definition", "POST", "/api/rules/test", `{"post_id":1,"rule":{"trigger":"post.updated","conditions":{"contains":"nothing"},"actions":[{"type":"add_tag","tag":"x"}]}}`, http.StatusOK},
+		{"Test without definition", "POST", "/api/rules/test", `{"post_id":1}`, http.StatusBadRequest},
+		{"Update rule", "PUT", "/api/rules/2", `{"name":"Calls","enabled":false,"trigger":"post.updated","actions":[{"type":"reminder","after_hours":24}]}`, http.StatusOK},
+		{"Update unknown rule", "PUT", "/api/rules/999", rule, http.StatusNotFound},
+		{"Delete rule", "DELETE", "/api/rules/2", "", http.StatusNoContent},
+		{"Delete unknown rule", "DELETE", "/api/rules/2", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			switch tt.name {
+			case "List rules":
+				var response RulesResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+				if len(response.Rules) != 2 || response.Rules[0].Name != "Urgent" || response.Rules[1].Name != "Calls" {
+					t.Errorf("Unexpected rules: %+v", response)
+				}
+			case "Executions":
+				var response ExecutionsResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+				if len(response.Executions) != 1 || response.Executions[0].PostID != 1 {
+					t.Errorf("Unexpected executions: %+v", response)
+				}
+			case "Test saved rule":
+				var result TestResult
+				json.Unmarshal(w.Body.Bytes(), &result)
+				if !result.Matched || result.RuleID != 1 || len(result.Checks) != 2 {
+					t.Errorf("Unexpected dry run: %+v", result)
+				}
+			case "Test definition":
+				var result TestResult
+				json.Unmarshal(w.Body.Bytes(), &result)
+				if result.Matched || len(result.Actions) != 0 {
+					t.Errorf("Expected no match, got %+v", result)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,737 @@
+package rules
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// triggers are the post events a rule can react to
+var triggers = map[string]bool{
+	string(events.PostCreated): true,
+	string(events.PostUpdated): true,
+	string(events.PostMoved):   true,
+}
+
+// PostActor changes posts on behalf of rules, e.g. the core PostService. Both changes
+// dispatch their usual events, so other rules may react to them in turn.
+type PostActor interface {
+	Move(ctx context.Context, postID int, newSpaceID int) error
+	AddTag(ctx context.Context, postID int, tag string) (bool, error)
+}
+
+type compiledRule struct {
+	rule    models.Rule
+	pattern *regexp.Regexp // nil without a pattern condition
+}
+
+// Service evaluates the automation rules on every post event it is subscribed to and runs
+// the actions of the matching ones. Rules run in creation order; each execution is logged.
+// A post acted on too often within a short window is left alone, so rules undoing each
+// other cannot loop forever.
+type Service struct {
+	db         *storage.DB
+	catCache   *cache.SpaceCache
+	actor      PostActor
+	dispatcher *events.Dispatcher
+	deliveries *services.Deliveries
+	access     *services.SpaceAccess
+	client     *http.Client
+	rules      map[int]*compiledRule
+	runs       map[int][]time.Time // postID -> recent executions, for the loop guard
+	mu         sync.Mutex
+	stop       chan struct{}
+	now        func() time.Time
+	enabled    bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		client:   &http.Client{Timeout: config.HTTPTimeout(config.WebhookHTTPTimeout)},
+		rules:    make(map[int]*compiledRule),
+		runs:     make(map[int][]time.Time),
+		now:      time.Now,
+		enabled:  enabled,
+	}
+}
+
+func (s *Service) SetPostActor(actor PostActor) {
+	s.actor = actor
+}
+
+// SetDispatcher lets reminder actions raise notifications once due
+func (s *Service) SetDispatcher(dispatcher *events.Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// SetDeliveries skips the webhook actions of muted spaces and holds them during quiet hours
+func (s *Service) SetDeliveries(deliveries *services.Deliveries) {
+	s.deliveries = deliveries
+}
+
+// SetSpaceAccess keeps rule authors to the spaces they may read when saving or testing a rule,
+// and to the spaces they may change when moving posts
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	rules, err := s.db.GetRules()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rule := range rules {
+		compiled, err := compile(rule)
+		if err != nil {
+			// Patterns are validated on save, so this only happens after a manual edit
+			logger.Warning("Skipping rule with an invalid pattern", zap.Int("rule_id", rule.ID), zap.Error(err))
+			continue
+		}
+		s.rules[rule.ID] = compiled
+	}
+
+	return nil
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled || !triggers[string(event.Type)] {
+		return nil
+	}
+
+	data := event.Data.(events.PostEvent)
+	matching := s.rulesFor(string(event.Type))
+	if len(matching) == 0 {
+		return nil
+	}
+
+	post, err := s.db.GetPost(data.PostID)
+	if err != nil {
+		return err
+	}
+
+	for _, compiled := range matching {
+		if _, matched := s.evaluate(compiled, post); !matched {
+			continue
+		}
+		s.execute(compiled.rule, string(event.Type), post)
+	}
+
+	return nil
+}
+
+// List returns every rule in the order they run
+func (s *Service) List() []models.Rule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules := make([]models.Rule, 0, len(s.rules))
+	for _, compiled := range s.rules {
+		rules = append(rules, compiled.rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	return rules
+}
+
+func (s *Service) Get(id int) (*models.Rule, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	compiled, ok := s.rules[id]
+	if !ok {
+		return nil, false
+	}
+
+	rule := compiled.rule
+	return &rule, true
+}
+
+// Create saves a new rule, which reacts to events from then on
+func (s *Service) Create(ctx context.Context, req RuleRequest) (*models.Rule, error) {
+	compiled, err := s.validate(ctx, 0, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.CreateRule(&compiled.rule); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.rules[compiled.rule.ID] = compiled
+	s.mu.Unlock()
+
+	rule := compiled.rule
+	return &rule, nil
+}
+
+// Update replaces the definition of a rule
+func (s *Service) Update(ctx context.Context, id int, req RuleRequest) (*models.Rule, error) {
+	existing, ok := s.Get(id)
+	if !ok {
+		return nil, fmt.Errorf(config.ErrRuleNotFound)
+	}
+
+	compiled, err := s.validate(ctx, id, req)
+	if err != nil {
+		return nil, err
+	}
+	compiled.rule.ID = id
+	compiled.rule.Created = existing.Created
+
+	if err := s.db.UpdateRule(&compiled.rule); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.rules[id] = compiled
+	s.mu.Unlock()
+
+	rule := compiled.rule
+	return &rule, nil
+}
+
+// Delete removes a rule with its execution log and the reminders it has not raised yet
+func (s *Service) Delete(id int) error {
+	if _, ok := s.Get(id); !ok {
+		return fmt.Errorf(config.ErrRuleNotFound)
+	}
+
+	if err := s.db.DeleteRule(id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.rules, id)
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Executions returns the most recent executions of a rule, or of every rule when ruleID is 0
+func (s *Service) Executions(ruleID, limit int) ([]models.RuleExecution, error) {
+	if ruleID != 0 {
+		if _, ok := s.Get(ruleID); !ok {
+			return nil, fmt.Errorf(config.ErrRuleNotFound)
+		}
+	}
+	return s.db.GetRuleExecutions(ruleID, limit)
+}
+
+// Test reports whether a saved rule would act on a post and what it would do, without doing it
+func (s *Service) Test(ctx context.Context, ruleID, postID int) (*TestResult, error) {
+	s.mu.Lock()
+	compiled, ok := s.rules[ruleID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf(config.ErrRuleNotFound)
+	}
+
+	return s.dryRun(ctx, compiled, postID)
+}
+
+// TestDefinition is Test for a rule that is not saved yet
+func (s *Service) TestDefinition(ctx context.Context, req RuleRequest, postID int) (*TestResult, error) {
+	compiled, err := s.validate(ctx, -1, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.dryRun(ctx, compiled, postID)
+}
+
+// dryRun evaluates a rule against a post the viewer of ctx may read; posts of hidden spaces are
+// not found, so the checks cannot be used to probe their content
+func (s *Service) dryRun(ctx context.Context, compiled *compiledRule, postID int) (*TestResult, error) {
+	if postID <= 0 {
+		return nil, fmt.Errorf(config.ErrRuleTestPostRequired)
+	}
+	post, err := s.db.GetPost(postID)
+	if err != nil || !s.access.CanRead(ctx, post.SpaceID) {
+		return nil, fmt.Errorf(config.ErrPostNotFound)
+	}
+
+	checks, matched := s.evaluate(compiled, post)
+	result := &TestResult{
+		RuleID:  compiled.rule.ID,
+		PostID:  postID,
+		Matched: matched,
+		Checks:  checks,
+		Actions: []models.RuleAction{},
+	}
+	if matched {
+		result.Actions = compiled.rule.Actions
+	}
+
+	return result, nil
+}
+
+// StartReminders raises due reminders immediately and then once per interval until Stop is called
+func (s *Service) StartReminders(interval time.Duration) {
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	s.RaiseDueReminders()
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.RaiseDueReminders()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// RaiseDueReminders turns the reminders that are due into notifications
+func (s *Service) RaiseDueReminders() {
+	reminders, err := s.db.GetDueRuleReminders(s.now().UnixMilli())
+	if err != nil {
+		logger.Warning("Failed to load due rule reminders", zap.Error(err))
+		return
+	}
+
+	for _, reminder := range reminders {
+		title := "Reminder"
+		if rule, ok := s.Get(reminder.RuleID); ok {
+			title = "Reminder from rule " + rule.Name
+		}
+		s.dispatcher.Notify(events.NotificationEvent{
+			Kind:    models.NotificationReminderDue,
+			Title:   title,
+			Message: reminder.Message,
+			SpaceID: reminder.SpaceID,
+			PostID:  reminder.PostID,
+			Key:     fmt.Sprintf("rule-reminder-%d", reminder.ID),
+		})
+		if err := s.db.DeleteRuleReminder(reminder.ID); err != nil {
+			logger.Warning("Failed to clear raised rule reminder", zap.Int("reminder_id", reminder.ID), zap.Error(err))
+		}
+	}
+
+	s.pruneRuns()
+}
+
+// Stop ends the periodic reminder check
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// rulesFor returns the enabled rules reacting to trigger, in the order they run
+func (s *Service) rulesFor(trigger string) []*compiledRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matching []*compiledRule
+	for _, compiled := range s.rules {
+		if compiled.rule.Enabled && compiled.rule.Trigger == trigger {
+			matching = append(matching, compiled)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].rule.ID < matching[j].rule.ID })
+
+	return matching
+}
+
+// evaluate checks the conditions of a rule against a post; only conditions set are reported
+func (s *Service) evaluate(compiled *compiledRule, post *models.Post) ([]ConditionCheck, bool) {
+	conditions := compiled.rule.Conditions
+	checks := []ConditionCheck{}
+	matched := true
+	check := func(condition string, passed bool) {
+		checks = append(checks, ConditionCheck{Condition: condition, Passed: passed})
+		matched = matched && passed
+	}
+
+	if conditions.SpaceID != 0 {
+		inSpace := post.SpaceID == conditions.SpaceID
+		if !inSpace && conditions.Recursive {
+			inSpace = containsID(s.catCache.GetAncestors(post.SpaceID), conditions.SpaceID)
+		}
+		check(ConditionSpace, inSpace)
+	}
+
+	if len(conditions.Tags) > 0 {
+		tags := utils.ParseTags(post.Content)
+		hasAll := true
+		for _, tag := range conditions.Tags {
+			hasAll = hasAll && containsString(tags, tag)
+		}
+		check(ConditionTags, hasAll)
+	}
+
+	if conditions.Contains != "" {
+		check(ConditionContains, strings.Contains(strings.ToLower(post.Content), strings.ToLower(conditions.Contains)))
+	}
+
+	if compiled.pattern != nil {
+		check(ConditionPattern, compiled.pattern.MatchString(post.Content))
+	}
+
+	return checks, matched
+}
+
+// execute runs the actions of a matching rule in order and logs the execution
+func (s *Service) execute(rule models.Rule, trigger string, post *models.Post) {
+	execution := &models.RuleExecution{
+		RuleID:  rule.ID,
+		Created: s.now().UnixMilli(),
+		Trigger: trigger,
+		PostID:  post.ID,
+		Outcome: models.RuleOutcomeApplied,
+	}
+
+	if !s.allowRun(post.ID) {
+		execution.Outcome = models.RuleOutcomeSkipped
+		logger.Warning("Rule loop guard stopped a rule", zap.Int("rule_id", rule.ID), zap.Int("post_id", post.ID))
+	} else {
+		// Later actions see the post as earlier ones left it, e.g. a reminder after a move
+		current := *post
+		for _, action := range rule.Actions {
+			result := s.runAction(rule, trigger, &current, action)
+			if !result.OK {
+				execution.Outcome = models.RuleOutcomeFailed
+			}
+			execution.Results = append(execution.Results, result)
+		}
+	}
+
+	if err := s.db.AddRuleExecution(execution, config.MaxRuleExecutionsPerRule); err != nil {
+		logger.Warning("Failed to log rule execution", zap.Int("rule_id", rule.ID), zap.Error(err))
+	}
+}
+
+func (s *Service) runAction(rule models.Rule, trigger string, post *models.Post, action models.RuleAction) models.RuleActionResult {
+	result := models.RuleActionResult{Type: action.Type, OK: true}
+	fail := func(err error) models.RuleActionResult {
+		result.OK = false
+		result.Detail = err.Error()
+		return result
+	}
+
+	switch action.Type {
+	case models.RuleActionAddTag:
+		added, err := s.actor.AddTag(context.Background(), post.ID, action.Tag)
+		if err != nil {
+			return fail(err)
+		}
+		if added {
+			post.Content, _ = utils.AddTag(post.Content, action.Tag)
+		} else {
+			result.Detail = "tag already present"
+		}
+
+	case models.RuleActionMove:
+		if post.SpaceID == action.SpaceID {
+			result.Detail = "already in space"
+			return result
+		}
+		if err := s.actor.Move(context.Background(), post.ID, action.SpaceID); err != nil {
+			return fail(err)
+		}
+		post.SpaceID = action.SpaceID
+
+	case models.RuleActionWebhook:
+		if s.deliveries.Muted(post.SpaceID) {
+			result.Detail = "space muted"
+			return result
+		}
+		held, err := s.callWebhook(rule, trigger, post, action.URL)
+		if err != nil {
+			return fail(err)
+		}
+		if held {
+			result.Detail = "held until quiet hours end"
+		}
+
+	case models.RuleActionReminder:
+		reminder := &models.RuleReminder{
+			RuleID:  rule.ID,
+			PostID:  post.ID,
+			SpaceID: post.SpaceID,
+			Message: action.Message,
+			Due:     s.now().Add(time.Duration(action.AfterHours) * time.Hour).UnixMilli(),
+		}
+		if err := s.db.AddRuleReminder(reminder); err != nil {
+			return fail(err)
+		}
+		result.Detail = time.UnixMilli(reminder.Due).UTC().Format(time.RFC3339)
+	}
+
+	return result
+}
+
+// callWebhook posts the payload of a post to a webhook and reports whether it was held for
+// quiet hours instead
+func (s *Service) callWebhook(rule models.Rule, trigger string, post *models.Post, webhookURL string) (bool, error) {
+	body, err := json.Marshal(WebhookPayload{
+		RuleID:   rule.ID,
+		RuleName: rule.Name,
+		Trigger:  trigger,
+		PostID:   post.ID,
+		SpaceID:  post.SpaceID,
+		Content:  post.Content,
+		Tags:     utils.ParseTags(post.Content),
+		Created:  post.Created,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	if held, err := s.deliveries.HoldWebhook(webhookURL, body); held || err != nil {
+		return held, err
+	}
+
+	resp, err := s.client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return false, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// allowRun records an execution on a post, unless the post already had too many recently
+func (s *Service) allowRun(postID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	recent := recentRuns(s.runs[postID], now)
+	if len(recent) >= config.MaxRuleRunsPerPost {
+		s.runs[postID] = recent
+		return false
+	}
+	s.runs[postID] = append(recent, now)
+	return true
+}
+
+// pruneRuns forgets posts without a recent execution
+func (s *Service) pruneRuns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for postID, runs := range s.runs {
+		if recent := recentRuns(runs, now); len(recent) == 0 {
+			delete(s.runs, postID)
+		} else {
+			s.runs[postID] = recent
+		}
+	}
+}
+
+func recentRuns(runs []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-config.RuleLoopWindow)
+	recent := runs[:0]
+	for _, run := range runs {
+		if run.After(cutoff) {
+			recent = append(recent, run)
+		}
+	}
+	return recent
+}
+
+// validate checks a request and returns the normalized rule it describes; id is the rule
+// being updated, 0 on creation and -1 for dry runs of unsaved rules
+func (s *Service) validate(ctx context.Context, id int, req RuleRequest) (*compiledRule, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" && id >= 0 {
+		return nil, fmt.Errorf(config.ErrRuleNameRequired)
+	}
+	if len([]rune(name)) > config.MaxRuleNameLength {
+		return nil, fmt.Errorf(config.ErrRuleNameTooLong)
+	}
+	if !triggers[req.Trigger] {
+		return nil, fmt.Errorf(config.ErrInvalidRuleTrigger)
+	}
+
+	conditions, err := s.validateConditions(ctx, req.Conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Actions) == 0 {
+		return nil, fmt.Errorf(config.ErrRuleActionsRequired)
+	}
+	if len(req.Actions) > config.MaxRuleActions {
+		return nil, fmt.Errorf(config.ErrFmtTooManyRuleActions, config.MaxRuleActions)
+	}
+	actions := make([]models.RuleAction, len(req.Actions))
+	for i, action := range req.Actions {
+		if actions[i], err = s.validateAction(ctx, action); err != nil {
+			return nil, err
+		}
+	}
+
+	if id >= 0 {
+		s.mu.Lock()
+		for otherID, other := range s.rules {
+			if otherID != id && strings.EqualFold(other.rule.Name, name) {
+				s.mu.Unlock()
+				return nil, fmt.Errorf(config.ErrRuleExists)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return compile(models.Rule{
+		Name:       name,
+		Enabled:    enabled,
+		Trigger:    req.Trigger,
+		Conditions: conditions,
+		Actions:    actions,
+	})
+}
+
+func (s *Service) validateConditions(ctx context.Context, conditions models.RuleConditions) (models.RuleConditions, error) {
+	if conditions.SpaceID != 0 {
+		if _, ok := s.catCache.Get(conditions.SpaceID); !ok || !s.access.CanRead(ctx, conditions.SpaceID) {
+			return conditions, fmt.Errorf(config.ErrSpaceNotFound)
+		}
+	} else {
+		conditions.Recursive = false
+	}
+
+	if len(conditions.Tags) > config.MaxRuleTags {
+		return conditions, fmt.Errorf(config.ErrFmtTooManyRuleTags, config.MaxRuleTags)
+	}
+	var tags []string
+	for _, raw := range conditions.Tags {
+		tag, ok := utils.NormalizeTag(raw)
+		if !ok {
+			return conditions, fmt.Errorf(config.ErrInvalidTag)
+		}
+		if !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	conditions.Tags = tags
+
+	conditions.Contains = strings.TrimSpace(conditions.Contains)
+	if len(conditions.Pattern) > config.MaxRulePatternLength {
+		return conditions, fmt.Errorf(config.ErrInvalidRulePattern)
+	}
+	if conditions.Pattern != "" {
+		if _, err := regexp.Compile(conditions.Pattern); err != nil {
+			return conditions, fmt.Errorf(config.ErrInvalidRulePattern)
+		}
+	}
+
+	return conditions, nil
+}
+
+// validateAction checks an action and keeps only the fields its type uses
+func (s *Service) validateAction(ctx context.Context, action models.RuleAction) (models.RuleAction, error) {
+	switch action.Type {
+	case models.RuleActionAddTag:
+		tag, ok := utils.NormalizeTag(action.Tag)
+		if !ok {
+			return action, fmt.Errorf(config.ErrInvalidTag)
+		}
+		return models.RuleAction{Type: action.Type, Tag: tag}, nil
+
+	case models.RuleActionMove:
+		if _, ok := s.catCache.Get(action.SpaceID); !ok || !s.access.CanRead(ctx, action.SpaceID) {
+			return action, fmt.Errorf(config.ErrSpaceNotFound)
+		}
+		if !s.access.CanWrite(ctx, action.SpaceID) {
+			return action, fmt.Errorf(config.ErrSpaceAccessDenied)
+		}
+		return models.RuleAction{Type: action.Type, SpaceID: action.SpaceID}, nil
+
+	case models.RuleActionWebhook:
+		parsed, err := url.Parse(strings.TrimSpace(action.URL))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return action, fmt.Errorf(config.ErrInvalidRuleWebhookURL)
+		}
+		return models.RuleAction{Type: action.Type, URL: parsed.String()}, nil
+
+	case models.RuleActionReminder:
+		if action.AfterHours < 1 || action.AfterHours > config.MaxRuleReminderHours {
+			return action, fmt.Errorf(config.ErrInvalidRuleReminder)
+		}
+		message := strings.TrimSpace(action.Message)
+		if len([]rune(message)) > config.MaxRuleMessageLength {
+			return action, fmt.Errorf(config.ErrRuleMessageTooLong)
+		}
+		return models.RuleAction{Type: action.Type, AfterHours: action.AfterHours, Message: message}, nil
+	}
+
+	return action, fmt.Errorf(config.ErrInvalidRuleAction)
+}
+
+func compile(rule models.Rule) (*compiledRule, error) {
+	compiled := &compiledRule{rule: rule}
+	if rule.Conditions.Pattern != "" {
+		pattern, err := regexp.Compile(rule.Conditions.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf(config.ErrInvalidRulePattern)
+		}
+		compiled.pattern = pattern
+	}
+	return compiled, nil
+}
+
+func containsID(ids []int, id int) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
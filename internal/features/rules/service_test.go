@@ -0,0 +1,340 @@
+package rules
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type rulesTestSetup struct {
+	db            *storage.DB
+	catCache      *cache.SpaceCache
+	spaceService  *services.SpaceService
+	postService   *services.PostService
+	service       *Service
+	notifications []events.NotificationEvent
+	cleanup       func()
+}
+
+func setupRulesTest(t *testing.T) *rulesTestSetup {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_rules_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	setup := &rulesTestSetup{
+		db:           db,
+		catCache:     catCache,
+		spaceService: services.NewSpaceService(db, catCache, dispatcher),
+		postService:  services.NewPostService(db, catCache, dispatcher),
+		service:      NewService(db, catCache, true),
+		cleanup: func() {
+			db.Close()
+			os.RemoveAll(tempDir)
+		},
+	}
+	setup.service.SetPostActor(setup.postService)
+	setup.service.SetDispatcher(dispatcher)
+	for _, eventType := range []events.EventType{events.PostCreated, events.PostUpdated, events.PostMoved} {
+		dispatcher.Subscribe(eventType, setup.service.HandleEvent)
+	}
+	dispatcher.Subscribe(events.NotificationRaised, func(event events.Event) error {
+		setup.notifications = append(setup.notifications, event.Data.(events.NotificationEvent))
+		return nil
+	})
+
+	return setup
+}
+
+func TestRuleValidation(t *testing.T) {
+	setup := setupRulesTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.spaceService.Create(context.Background(), "Inbox", nil, "")
+	addTag := []models.RuleAction{{Type: models.RuleActionAddTag, Tag: "todo"}}
+
+	tests := []struct {
+		name          string
+		req           RuleRequest
+		expectedError string
+	}{
+		{"Missing name", RuleRequest{Trigger: "post.created", Actions: addTag}, config.ErrRuleNameRequired},
+		{"Unknown trigger", RuleRequest{Name: "r", Trigger: "space.created", Actions: addTag}, config.ErrInvalidRuleTrigger},
+		{"No actions", RuleRequest{Name: "r", Trigger: "post.created"}, config.ErrRuleActionsRequired},
+		{"Unknown action", RuleRequest{Name: "r", Trigger: "post.created", Actions: []models.RuleAction{{Type: "delete"}}}, config.ErrInvalidRuleAction},
+		{"Invalid tag", RuleRequest{Name: "r", Trigger: "post.created", Actions: []models.RuleAction{{Type: models.RuleActionAddTag, Tag: "two words"}}}, config.ErrInvalidTag},
+		{"Move to unknown space", RuleRequest{Name: "r", Trigger: "post.created", Actions: []models.RuleAction{{Type: models.RuleActionMove, SpaceID: 999}}}, config.ErrSpaceNotFound},
+		{"Webhook without scheme", RuleRequest{Name: "r", Trigger: "post.created", Actions: []models.RuleAction{{Type: models.RuleActionWebhook, URL: "example.com/hook"}}}, config.ErrInvalidRuleWebhookURL},
+		{"Reminder without delay", RuleRequest{Name: "r", Trigger: "post.created", Actions: []models.RuleAction{{Type: models.RuleActionReminder}}}, config.ErrInvalidRuleReminder},
+		{"Invalid pattern", RuleRequest{Name: "r", Trigger: "post.created", Conditions: models.RuleConditions{Pattern: "("}, Actions: addTag}, config.ErrInvalidRulePattern},
+		{"Condition on unknown space", RuleRequest{Name: "r", Trigger: "post.created", Conditions: models.RuleConditions{SpaceID: 999}, Actions: addTag}, config.ErrSpaceNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := setup.service.Create(context.Background(), tt.req); err == nil || err.Error() != tt.expectedError {
+				t.Errorf("Expected error %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+
+	created, err := setup.service.Create(context.Background(), RuleRequest{
+		Name:       " Todo ",
+		Trigger:    "post.created",
+		Conditions: models.RuleConditions{SpaceID: space.ID, Tags: []string{"#Work", "work"}},
+		Actions:    []models.RuleAction{{Type: models.RuleActionAddTag, Tag: "#Todo", URL: "ignored"}},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.Name != "Todo" || !created.Enabled || strings.Join(created.Conditions.Tags, ",") != "work" {
+		t.Errorf("Expected a normalized enabled rule, got %+v", created)
+	}
+	if created.Actions[0] != (models.RuleAction{Type: models.RuleActionAddTag, Tag: "todo"}) {
+		t.Errorf("Expected only the fields of the action type kept, got %+v", created.Actions[0])
+	}
+	if _, err := setup.service.Create(context.Background(), RuleRequest{Name: "todo", Trigger: "post.created", Actions: addTag}); err == nil || err.Error() != config.ErrRuleExists {
+		t.Errorf("Expected duplicate name to be rejected, got %v", err)
+	}
+
+	// Rules survive a restart
+	reloaded := NewService(setup.db, nil, true)
+	if err := reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if got, ok := reloaded.Get(created.ID); !ok || got.Conditions.SpaceID != space.ID || len(got.Actions) != 1 {
+		t.Errorf("Expected rule to be reloaded, got %+v", got)
+	}
+}
+
+func TestRuleExecution(t *testing.T) {
+	setup := setupRulesTest(t)
+	defer setup.cleanup()
+
+	now := time.Now()
+	setup.service.now = func() time.Time { return now }
+
+	inbox, _ := setup.spaceService.Create(context.Background(), "Inbox", nil, "")
+	finance, _ := setup.spaceService.Create(context.Background(), "Finance", nil, "")
+
+	var payload WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	rule, err := setup.service.Create(context.Background(), RuleRequest{
+		Name:       "File invoices",
+		Trigger:    "post.created",
+		Conditions: models.RuleConditions{SpaceID: inbox.ID, Contains: "INVOICE"},
+		Actions: []models.RuleAction{
+			{Type: models.RuleActionAddTag, Tag: "finance"},
+			{Type: models.RuleActionMove, SpaceID: finance.ID},
+			{Type: models.RuleActionWebhook, URL: server.URL},
+			{Type: models.RuleActionReminder, AfterHours: 2, Message: "Pay it"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	setup.postService.Create(context.Background(), inbox.ID, "Lunch notes", nil)
+	post, _ := setup.postService.Create(context.Background(), inbox.ID, "Invoice for March", nil)
+
+	stored, _ := setup.db.GetPost(post.ID)
+	if stored.SpaceID != finance.ID || !strings.Contains(stored.Content, "#finance") {
+		t.Errorf("Expected the post tagged and moved, got %+v", stored)
+	}
+	if payload.PostID != post.ID || payload.SpaceID != finance.ID || payload.RuleName != "File invoices" || len(payload.Tags) != 1 {
+		t.Errorf("Unexpected webhook payload %+v", payload)
+	}
+
+	executions, _ := setup.service.Executions(rule.ID, 10)
+	if len(executions) != 1 || executions[0].Outcome != models.RuleOutcomeApplied || executions[0].PostID != post.ID || len(executions[0].Results) != 4 {
+		t.Fatalf("Expected one applied execution, got %+v", executions)
+	}
+
+	// The reminder is raised once due, in the space the post was moved to
+	setup.service.RaiseDueReminders()
+	if len(setup.notifications) != 0 {
+		t.Fatal("Expected no reminder before it is due")
+	}
+	now = now.Add(3 * time.Hour)
+	setup.service.RaiseDueReminders()
+	setup.service.RaiseDueReminders()
+	if len(setup.notifications) != 1 {
+		t.Fatalf("Expected the reminder raised once, got %d", len(setup.notifications))
+	}
+	if n := setup.notifications[0]; n.Kind != models.NotificationReminderDue || n.PostID != post.ID || n.SpaceID != finance.ID || n.Message != "Pay it" {
+		t.Errorf("Unexpected reminder %+v", n)
+	}
+
+	// A failing action is logged without stopping the following ones
+	server.Close()
+	setup.postService.Create(context.Background(), inbox.ID, "Another invoice", nil)
+	executions, _ = setup.service.Executions(rule.ID, 10)
+	if latest := executions[0]; latest.Outcome != models.RuleOutcomeFailed || !latest.Results[1].OK || latest.Results[2].OK || !latest.Results[3].OK {
+		t.Errorf("Expected only the webhook to fail, got %+v", latest)
+	}
+}
+
+func TestRuleLoopGuard(t *testing.T) {
+	setup := setupRulesTest(t)
+	defer setup.cleanup()
+
+	a, _ := setup.spaceService.Create(context.Background(), "A", nil, "")
+	b, _ := setup.spaceService.Create(context.Background(), "B", nil, "")
+	for _, spaces := range [][2]int{{a.ID, b.ID}, {b.ID, a.ID}} {
+		if _, err := setup.service.Create(context.Background(), RuleRequest{
+			Name:       fmt.Sprintf("Bounce from %d", spaces[0]),
+			Trigger:    "post.moved",
+			Conditions: models.RuleConditions{SpaceID: spaces[0]},
+			Actions:    []models.RuleAction{{Type: models.RuleActionMove, SpaceID: spaces[1]}},
+		}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	post, _ := setup.postService.Create(context.Background(), a.ID, "Ping pong", nil)
+	if err := setup.postService.Move(context.Background(), post.ID, b.ID); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+
+	executions, _ := setup.service.Executions(0, 100)
+	if len(executions) != config.MaxRuleRunsPerPost+1 {
+		t.Fatalf("Expected %d executions before the guard stopped the loop, got %d", config.MaxRuleRunsPerPost+1, len(executions))
+	}
+	skipped := 0
+	for _, execution := range executions {
+		if execution.Outcome == models.RuleOutcomeSkipped {
+			skipped++
+		}
+	}
+	if skipped != 1 {
+		t.Errorf("Expected one skipped execution, got %d", skipped)
+	}
+}
+
+func TestRuleDryRun(t *testing.T) {
+	setup := setupRulesTest(t)
+	defer setup.cleanup()
+
+	parent, _ := setup.spaceService.Create(context.Background(), "Work", nil, "")
+	child, _ := setup.spaceService.Create(context.Background(), "Meetings", &parent.ID, "")
+	rule, _ := setup.service.Create(context.Background(), RuleRequest{
+		Name:       "Follow up",
+		Enabled:    new(bool),
+		Trigger:    "post.created",
+		Conditions: models.RuleConditions{SpaceID: parent.ID, Recursive: true, Tags: []string{"meeting"}, Pattern: `(?i)action items?`},
+		Actions:    []models.RuleAction{{Type: models.RuleActionAddTag, Tag: "followup"}},
+	})
+	post, _ := setup.postService.Create(context.Background(), child.ID, "#meeting\nAction items: none", nil)
+	other, _ := setup.postService.Create(context.Background(), child.ID, "#meeting without anything to do", nil)
+
+	result, err := setup.service.Test(context.Background(), rule.ID, post.ID)
+	if err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+	if !result.Matched || len(result.Checks) != 3 || len(result.Actions) != 1 {
+		t.Errorf("Expected a match with three checks, got %+v", result)
+	}
+
+	result, _ = setup.service.Test(context.Background(), rule.ID, other.ID)
+	if result.Matched || result.Checks[2] != (ConditionCheck{Condition: ConditionPattern, Passed: false}) || len(result.Actions) != 0 {
+		t.Errorf("Expected the pattern check to fail, got %+v", result)
+	}
+
+	// Neither dry runs nor disabled rules change anything
+	if stored, _ := setup.db.GetPost(post.ID); strings.Contains(stored.Content, "#followup") {
+		t.Error("Expected the post to be left unchanged")
+	}
+	if executions, _ := setup.service.Executions(rule.ID, 10); len(executions) != 0 {
+		t.Errorf("Expected no execution logged, got %d", len(executions))
+	}
+
+	if _, err := setup.service.Test(context.Background(), rule.ID, 999); err == nil || err.Error() != config.ErrPostNotFound {
+		t.Errorf("Expected post not found, got %v", err)
+	}
+	result, err = setup.service.TestDefinition(context.Background(), RuleRequest{
+		Trigger: "post.updated",
+		Actions: []models.RuleAction{{Type: models.RuleActionMove, SpaceID: parent.ID}},
+	}, other.ID)
+	if err != nil || !result.Matched || result.RuleID != 0 || len(result.Checks) != 0 {
+		t.Errorf("Expected an unsaved rule without conditions to match, got %+v, %v", result, err)
+	}
+}
+
+func TestRulesFollowSpaceAccess(t *testing.T) {
+	setup := setupRulesTest(t)
+	defer setup.cleanup()
+
+	shared, _ := setup.spaceService.Create(context.Background(), "Shared", nil, "")
+	secret, _ := setup.spaceService.Create(context.Background(), "Secret", nil, "")
+	hidden, _ := setup.postService.Create(context.Background(), secret.ID, "Salary review", nil)
+
+	alice, _ := setup.db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	bob, _ := setup.db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(setup.db, setup.catCache)
+	if err := access.SetACL(shared.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+	if err := access.SetACL(secret.ID, []models.SpaceACLEntry{{UserID: bob.ID, Permission: models.SpacePermissionWrite}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+	setup.service.SetSpaceAccess(access)
+	asAlice := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	addTag := []models.RuleAction{{Type: models.RuleActionAddTag, Tag: "todo"}}
+
+	tests := []struct {
+		name     string
+		req      RuleRequest
+		expected string
+	}{
+		{"Condition on a hidden space", RuleRequest{Name: "r", Trigger: "post.created", Conditions: models.RuleConditions{SpaceID: secret.ID}, Actions: addTag}, config.ErrSpaceNotFound},
+		{"Move into a hidden space", RuleRequest{Name: "r", Trigger: "post.created", Actions: []models.RuleAction{{Type: models.RuleActionMove, SpaceID: secret.ID}}}, config.ErrSpaceNotFound},
+		{"Move into a read-only space", RuleRequest{Name: "r", Trigger: "post.created", Actions: []models.RuleAction{{Type: models.RuleActionMove, SpaceID: shared.ID}}}, config.ErrSpaceAccessDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := setup.service.Create(asAlice, tt.req); err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	if _, err := setup.service.Create(asAlice, RuleRequest{Name: "Shared", Trigger: "post.created", Conditions: models.RuleConditions{SpaceID: shared.ID}, Actions: addTag}); err != nil {
+		t.Errorf("Expected a condition on a readable space to be accepted, got %v", err)
+	}
+	_, err := setup.service.TestDefinition(asAlice, RuleRequest{
+		Trigger:    "post.created",
+		Conditions: models.RuleConditions{Contains: "salary"},
+		Actions:    addTag,
+	}, hidden.ID)
+	if err == nil || err.Error() != config.ErrPostNotFound {
+		t.Errorf("Expected a post of a hidden space to be not found, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package rules
+
+import "backthynk/internal/core/models"
+
+// Conditions reported by dry runs
+const (
+	ConditionSpace    = "space"
+	ConditionTags     = "tags"
+	ConditionContains = "contains"
+	ConditionPattern  = "pattern"
+)
+
+// RuleRequest is the body of rule creation and update requests
+type RuleRequest struct {
+	Name       string                `json:"name"`
+	Enabled    *bool                 `json:"enabled"` // Defaults to true
+	Trigger    string                `json:"trigger"`
+	Conditions models.RuleConditions `json:"conditions"`
+	Actions    []models.RuleAction   `json:"actions"`
+}
+
+type RulesResponse struct {
+	Rules []models.Rule `json:"rules"`
+}
+
+type ExecutionsResponse struct {
+	Executions []models.RuleExecution `json:"executions"`
+}
+
+// TestRequest asks how a rule would handle a post. Saved rules are tested through their ID;
+// POST /api/rules/test takes the definition of a rule not saved yet.
+type TestRequest struct {
+	PostID int          `json:"post_id"`
+	Rule   *RuleRequest `json:"rule,omitempty"`
+}
+
+// ConditionCheck reports whether one condition of a rule holds for a post
+type ConditionCheck struct {
+	Condition string `json:"condition"`
+	Passed    bool   `json:"passed"`
+}
+
+// TestResult is the outcome of a dry run: nothing is changed, called or scheduled
+type TestResult struct {
+	RuleID  int                 `json:"rule_id,omitempty"` // 0 for an unsaved rule
+	PostID  int                 `json:"post_id"`
+	Matched bool                `json:"matched"`
+	Checks  []ConditionCheck    `json:"checks"`
+	Actions []models.RuleAction `json:"actions"` // Actions that would run, empty unless matched
+}
+
+// WebhookPayload is the JSON body posted by webhook actions
+type WebhookPayload struct {
+	RuleID   int      `json:"rule_id"`
+	RuleName string   `json:"rule_name"`
+	Trigger  string   `json:"trigger"`
+	PostID   int      `json:"post_id"`
+	SpaceID  int      `json:"space_id"`
+	Content  string   `json:"content"`
+	Tags     []string `json:"tags"`
+	Created  int64    `json:"created"`
+}
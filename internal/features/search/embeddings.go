@@ -0,0 +1,161 @@
+package search
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+)
+
+// Provider turns texts into vectors. Providers are pluggable through Service.SetProvider.
+type Provider interface {
+	// Model identifies the vectors; stored vectors of another model are recomputed
+	Model() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewProvider builds the provider named in the options
+func NewProvider(name, apiURL, apiKey, model string) (Provider, error) {
+	switch name {
+	case config.EmbeddingProviderLocal, "":
+		return NewLocalProvider(config.LocalEmbeddingDimensions), nil
+	case config.EmbeddingProviderAPI:
+		if apiURL == "" {
+			return nil, fmt.Errorf(config.ErrEmbeddingAPIURLRequired)
+		}
+		return NewAPIProvider(apiURL, apiKey, model), nil
+	default:
+		return nil, fmt.Errorf(config.ErrFmtUnknownEmbeddingProvider, name)
+	}
+}
+
+// trigramWeight scales character trigrams against whole words
+const trigramWeight = 0.5
+
+// LocalProvider hashes words and their character trigrams into a fixed number of dimensions.
+// It needs no model files, and trigrams let inflections and near spellings match.
+type LocalProvider struct {
+	dimensions int
+}
+
+func NewLocalProvider(dimensions int) *LocalProvider {
+	return &LocalProvider{dimensions: dimensions}
+}
+
+func (p *LocalProvider) Model() string {
+	return fmt.Sprintf("local-hash-%d", p.dimensions)
+}
+
+func (p *LocalProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = p.vector(text)
+	}
+	return vectors, nil
+}
+
+func (p *LocalProvider) vector(text string) []float32 {
+	vector := make([]float32, p.dimensions)
+	for term, tf := range utils.ContentTerms(text) {
+		weight := 1 + math.Log(float64(tf))
+		p.add(vector, term, weight)
+
+		padded := []rune(" " + term + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			p.add(vector, "#"+string(padded[i:i+3]), weight*trigramWeight)
+		}
+	}
+
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum > 0 {
+		norm := float32(math.Sqrt(sum))
+		for i := range vector {
+			vector[i] /= norm
+		}
+	}
+	return vector
+}
+
+// add hashes a feature to a dimension, with a hashed sign so collisions tend to cancel out
+func (p *LocalProvider) add(vector []float32, feature string, weight float64) {
+	h := fnv.New32a()
+	h.Write([]byte(feature))
+	sum := h.Sum32()
+	if sum&(1<<31) != 0 {
+		weight = -weight
+	}
+	vector[int(sum&0x7fffffff)%p.dimensions] += float32(weight)
+}
+
+// APIProvider asks an external embeddings API, sending {"model", "input"} and reading
+// {"data": [{"index", "embedding"}]}
+type APIProvider struct {
+	url    string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+func NewAPIProvider(url, apiKey, model string) *APIProvider {
+	return &APIProvider{
+		url:    url,
+		apiKey: apiKey,
+		model:  model,
+		client: &http.Client{Timeout: config.HTTPTimeout(config.EmbeddingHTTPTimeout)},
+	}
+}
+
+func (p *APIProvider) Model() string {
+	return config.EmbeddingProviderAPI + ":" + p.model
+}
+
+func (p *APIProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(apiRequest{Model: p.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embeddings request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call embeddings API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("embeddings API returned status %d", resp.StatusCode)
+	}
+
+	var result apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode embeddings response: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embeddings API returned %d vectors for %d texts", len(result.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embeddings API returned invalid index %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
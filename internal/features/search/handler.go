@@ -0,0 +1,228 @@
+package search
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/search", h.Search).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/search", h.SpaceSearch).Methods("GET")
+	api.HandleFunc("/admin/reindex", h.StartReindex).Methods("POST")
+	api.HandleFunc("/admin/reindex", h.GetReindex).Methods("GET")
+	api.HandleFunc("/admin/reindex/events", h.StreamReindex).Methods("GET")
+}
+
+// Search handles GET /api/search?q=...; keyword results hold every word of q in the post or
+// the titles of its link previews, most relevant first
+// Query parameters:
+// - mode: keyword (default) or semantic; semantic falls back to keyword when disabled
+// - space_id: restrict to a space (default: every space)
+// - recursive: include descendant spaces of space_id (default: false)
+// - limit: maximum results (default: 20, max: 100)
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, config.ErrSearchQueryRequired, http.StatusBadRequest)
+		return
+	}
+	if len([]rune(query)) > config.MaxSearchQueryLength {
+		http.Error(w, config.ErrSearchQueryTooLong, http.StatusBadRequest)
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	switch mode {
+	case "":
+		mode = config.SearchModeKeyword
+	case config.SearchModeKeyword, config.SearchModeSemantic:
+	default:
+		http.Error(w, config.ErrInvalidSearchMode, http.StatusBadRequest)
+		return
+	}
+
+	spaceID := 0
+	if spaceIDStr := r.URL.Query().Get("space_id"); spaceIDStr != "" {
+		id, err := strconv.Atoi(spaceIDStr)
+		if err != nil || id < 1 {
+			http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+			return
+		}
+		spaceID = id
+	}
+	recursive := r.URL.Query().Get("recursive") == "true"
+
+	limit := config.DefaultSearchLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > config.MaxSearchLimit {
+			http.Error(w, config.ErrInvalidSearchLimit, http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	response, err := h.service.Search(r.Context(), query, mode, spaceID, recursive, limit)
+	if err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err.Error() == config.ErrRequestCostExceeded {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// SpaceSearch handles GET /api/spaces/{id}/search?q=..., the quick search of a space and its
+// descendants where every word of q matches the start of a word
+// Query parameters:
+// - limit: maximum results (default: 20, max: 100)
+func (h *Handler) SpaceSearch(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, config.ErrSearchQueryRequired, http.StatusBadRequest)
+		return
+	}
+	if len([]rune(query)) > config.MaxSearchQueryLength {
+		http.Error(w, config.ErrSearchQueryTooLong, http.StatusBadRequest)
+		return
+	}
+
+	limit := config.DefaultSearchLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > config.MaxSearchLimit {
+			http.Error(w, config.ErrInvalidSearchLimit, http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	response, err := h.service.SpaceSearch(r.Context(), spaceID, query, limit)
+	if err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err.Error() == config.ErrRequestCostExceeded {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// StartReindex handles POST /api/admin/reindex, rebuilding the keyword index in the background.
+// An interrupted or failed rebuild is continued where it stopped.
+// Query parameters:
+// - restart: rebuild from the first post even when a previous rebuild did not finish
+func (h *Handler) StartReindex(w http.ResponseWriter, r *http.Request) {
+	progress, err := h.service.StartReindex(r.URL.Query().Get("restart") == "true")
+	if err != nil {
+		if err.Error() == config.ErrReindexRunning {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(progress)
+}
+
+// GetReindex handles GET /api/admin/reindex, the state of the latest rebuild
+func (h *Handler) GetReindex(w http.ResponseWriter, r *http.Request) {
+	progress, err := h.service.Reindex()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(progress)
+}
+
+// StreamReindex handles GET /api/admin/reindex/events as a server-sent event stream of the
+// progress of the rebuild. The current state is sent first; the stream ends once the rebuild
+// is done or failed.
+func (h *Handler) StreamReindex(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, config.ErrStreamingUnsupported, http.StatusInternalServerError)
+		return
+	}
+
+	changed := h.service.WatchReindex()
+	defer h.service.UnwatchReindex(changed)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(config.ReindexKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		progress, err := h.service.Reindex()
+		if err != nil {
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+			flusher.Flush()
+			return
+		}
+		data, _ := json.Marshal(progress)
+		fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
+		flusher.Flush()
+		if progress.Status != config.ReindexStatusRunning {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package search
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/search?q=notes", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected search routes NOT to be registered when disabled")
+	}
+}
+
+func TestSearchHandler(t *testing.T) {
+	db, cleanup := setupSearchTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	db.CreatePost(context.Background(), space.ID, "Weekly review of reading notes")
+	db.CreatePost(context.Background(), space.ID, "Reading list")
+
+	service := NewService(db, catCache, true)
+	service.SetProvider(NewLocalProvider(config.LocalEmbeddingDimensions))
+	service.Initialize()
+	drain(t, service)
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	id := strconv.Itoa(space.ID)
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedMode   string
+		expectedCount  int
+	}{
+		{"Keyword default", "/api/search?q=reading", http.StatusOK, config.SearchModeKeyword, 2},
+		{"Keyword all words", "/api/search?q=reading+notes", http.StatusOK, config.SearchModeKeyword, 1},
+		{"Semantic", "/api/search?q=read+note&mode=semantic", http.StatusOK, config.SearchModeSemantic, 2},
+		{"Space scope", "/api/search?q=reading&space_id=" + id + "&limit=1", http.StatusOK, config.SearchModeKeyword, 1},
+		{"Missing query", "/api/search?q=+", http.StatusBadRequest, "", 0},
+		{"Query too long", "/api/search?q=" + strings.Repeat("a", config.MaxSearchQueryLength+1), http.StatusBadRequest, "", 0},
+		{"Invalid mode", "/api/search?q=notes&mode=fuzzy", http.StatusBadRequest, "", 0},
+		{"Invalid limit", "/api/search?q=notes&limit=101", http.StatusBadRequest, "", 0},
+		{"Invalid space", "/api/search?q=notes&space_id=abc", http.StatusBadRequest, "", 0},
+		{"Unknown space", "/api/search?q=notes&space_id=999", http.StatusNotFound, "", 0},
+		{"Space quick search", "/api/spaces/" + id + "/search?q=read", http.StatusOK, config.SearchModeKeyword, 2},
+		{"Space quick search limit", "/api/spaces/" + id + "/search?q=read&limit=1", http.StatusOK, config.SearchModeKeyword, 1},
+		{"Space quick search without query", "/api/spaces/" + id + "/search", http.StatusBadRequest, "", 0},
+		{"Space quick search invalid limit", "/api/spaces/" + id + "/search?q=read&limit=0", http.StatusBadRequest, "", 0},
+		{"Space quick search unknown space", "/api/spaces/999/search?q=read", http.StatusNotFound, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response SearchResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if response.Mode != tt.expectedMode || len(response.Results) != tt.expectedCount {
+				t.Errorf("Unexpected response %+v", response)
+			}
+		})
+	}
+}
+
+func TestReindexHandlers(t *testing.T) {
+	db, cleanup := setupSearchTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	db.CreatePost(context.Background(), space.ID, "Weekly review")
+
+	service := NewService(db, catCache, true)
+	service.Initialize()
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	req := httptest.NewRequest("POST", "/api/admin/reindex?restart=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The stream ends once the reindex is done
+	req = httptest.NewRequest("GET", "/api/admin/reindex/events", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %q", w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), "event: progress") || !strings.Contains(w.Body.String(), `"status":"done"`) {
+		t.Errorf("Expected progress events ending with done, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/admin/reindex", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var progress ReindexProgress
+	json.Unmarshal(w.Body.Bytes(), &progress)
+	if w.Code != http.StatusOK || progress.Status != config.ReindexStatusDone || progress.Processed != 1 {
+		t.Errorf("Unexpected reindex state %d: %s", w.Code, w.Body.String())
+	}
+}
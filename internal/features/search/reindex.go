@@ -0,0 +1,216 @@
+package search
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/jobs"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// reindexRun is a rebuild of the search index running in the background
+type reindexRun struct {
+	cancel    context.CancelFunc
+	done      chan struct{}
+	started   time.Time
+	processed int // Posts reindexed by this run, for the ETA
+}
+
+// StartReindex rebuilds the search index in the background, in batches checkpointed in the
+// database. An interrupted or failed rebuild is continued from its checkpoint unless restart
+// is set. Only one rebuild runs at a time.
+func (s *Service) StartReindex(restart bool) (*ReindexProgress, error) {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+
+	if s.reindexRun != nil {
+		return nil, fmt.Errorf(config.ErrReindexRunning)
+	}
+
+	checkpoint, err := s.db.GetSearchReindex()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	progress := &ReindexProgress{SearchReindex: storage.SearchReindex{Started: now}}
+	if !restart && checkpoint != nil && checkpoint.Status != config.ReindexStatusDone {
+		progress.SearchReindex = *checkpoint
+		progress.Resumed = true
+	}
+	progress.Status = config.ReindexStatusRunning
+	progress.Updated = now
+	progress.Error = ""
+
+	remaining, err := s.db.CountPostsAfter(progress.LastPostID)
+	if err != nil {
+		return nil, err
+	}
+	progress.Total = progress.Processed + remaining
+
+	if err := s.db.SaveSearchReindex(progress.SearchReindex); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &reindexRun{cancel: cancel, done: make(chan struct{}), started: time.Now()}
+	s.reindexRun = run
+	s.reindex = progress
+	s.notifyReindexLocked()
+
+	logger.Info("Search reindex started", zap.Int("total", progress.Total), zap.Int("processed", progress.Processed), zap.Bool("resumed", progress.Resumed))
+	go s.runReindex(ctx, run)
+
+	snapshot := s.reindexSnapshotLocked()
+	return &snapshot, nil
+}
+
+// ResumeReindex continues a rebuild of the search index interrupted by a shutdown
+func (s *Service) ResumeReindex() error {
+	if !s.enabled {
+		return nil
+	}
+
+	checkpoint, err := s.db.GetSearchReindex()
+	if err != nil || checkpoint == nil || checkpoint.Status != config.ReindexStatusRunning {
+		return err
+	}
+	_, err = s.StartReindex(false)
+	return err
+}
+
+// Reindex returns the state of the latest rebuild of the search index
+func (s *Service) Reindex() (*ReindexProgress, error) {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+
+	if s.reindex == nil {
+		checkpoint, err := s.db.GetSearchReindex()
+		if err != nil {
+			return nil, err
+		}
+		if checkpoint == nil {
+			return &ReindexProgress{SearchReindex: storage.SearchReindex{Status: config.ReindexStatusIdle}}, nil
+		}
+		s.reindex = &ReindexProgress{SearchReindex: *checkpoint}
+	}
+
+	snapshot := s.reindexSnapshotLocked()
+	return &snapshot, nil
+}
+
+// WatchReindex returns a channel signaled whenever the state of the rebuild changes. Signals
+// are coalesced, so watchers read the state again with Reindex.
+func (s *Service) WatchReindex() chan struct{} {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	s.reindexWatchers[ch] = struct{}{}
+	return ch
+}
+
+// UnwatchReindex stops signaling ch
+func (s *Service) UnwatchReindex(ch chan struct{}) {
+	s.reindexMu.Lock()
+	defer s.reindexMu.Unlock()
+	delete(s.reindexWatchers, ch)
+}
+
+// stopReindex interrupts a running rebuild and waits for it; its checkpoint stays running so
+// the next start resumes it
+func (s *Service) stopReindex() {
+	s.reindexMu.Lock()
+	run := s.reindexRun
+	s.reindexMu.Unlock()
+
+	if run != nil {
+		run.cancel()
+		<-run.done
+	}
+}
+
+func (s *Service) runReindex(ctx context.Context, run *reindexRun) {
+	defer close(run.done)
+
+	for {
+		s.reindexMu.Lock()
+		state := s.reindex.SearchReindex
+		s.reindexMu.Unlock()
+
+		var lastID, count int
+		err := s.budget.Run(ctx, "search-reindex", func(job *jobs.Job) error {
+			var err error
+			lastID, count, err = s.db.ReindexSearchBatch(state.LastPostID, config.SearchReindexBatchSize)
+			return err
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		state.Updated = time.Now().UnixMilli()
+		switch {
+		case err != nil:
+			logger.Warning("Search reindex failed", zap.Int("last_post_id", state.LastPostID), zap.Error(err))
+			state.Status = config.ReindexStatusFailed
+			state.Error = err.Error()
+		case count == 0:
+			logger.Info("Search reindex done", zap.Int("processed", state.Processed))
+			state.Status = config.ReindexStatusDone
+			state.Total = state.Processed
+		default:
+			state.LastPostID = lastID
+			state.Processed += count
+			if state.Processed > state.Total {
+				state.Total = state.Processed
+			}
+		}
+
+		if err := s.db.SaveSearchReindex(state); err != nil {
+			state.Status = config.ReindexStatusFailed
+			state.Error = err.Error()
+		}
+
+		s.reindexMu.Lock()
+		run.processed += count
+		s.reindex.SearchReindex = state
+		if state.Status != config.ReindexStatusRunning {
+			s.reindexRun = nil
+		}
+		s.notifyReindexLocked()
+		s.reindexMu.Unlock()
+
+		if state.Status != config.ReindexStatusRunning {
+			return
+		}
+	}
+}
+
+// reindexSnapshotLocked copies the state of the rebuild with its ETA. Caller must hold
+// s.reindexMu.
+func (s *Service) reindexSnapshotLocked() ReindexProgress {
+	snapshot := *s.reindex
+	run := s.reindexRun
+	if run == nil || run.processed == 0 || snapshot.Status != config.ReindexStatusRunning {
+		return snapshot
+	}
+
+	perPost := time.Since(run.started) / time.Duration(run.processed)
+	eta := int64((perPost * time.Duration(snapshot.Total-snapshot.Processed)).Round(time.Second) / time.Second)
+	snapshot.ETASeconds = &eta
+	return snapshot
+}
+
+// notifyReindexLocked signals the watchers without blocking. Caller must hold s.reindexMu.
+func (s *Service) notifyReindexLocked() {
+	for ch := range s.reindexWatchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
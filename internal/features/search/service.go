@@ -0,0 +1,670 @@
+package search
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/flags"
+	"backthynk/internal/core/jobs"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unsafe"
+
+	"go.uber.org/zap"
+)
+
+// entry is the indexed form of a vectorized post
+type entry struct {
+	spaceID int
+	created int64
+	snippet string
+	vector  []float32
+	norm    float64
+}
+
+// Service answers keyword searches from the database and, when an embeddings provider is set,
+// semantic searches from an in-process vector index. Posts are vectorized by a background
+// worker, so writes never wait on the provider.
+type Service struct {
+	db         *storage.DB
+	catCache   *cache.SpaceCache
+	provider   Provider
+	flags      *flags.Registry
+	dispatcher *events.Dispatcher
+	budget     *jobs.Budget
+	access     *services.SpaceAccess
+	vectors    map[int]*entry // postID -> vectorized post
+	pending    map[int]bool   // posts waiting to be vectorized
+	inFlight   map[int]bool   // posts being vectorized; removed when the post goes away meanwhile
+	wake       chan struct{}
+	stop       chan struct{}
+	mu         sync.RWMutex
+	enabled    bool
+
+	// reindex is the latest known state of the rebuild of the keyword index, reindexRun the
+	// rebuild running now if any
+	reindex         *ReindexProgress
+	reindexRun      *reindexRun
+	reindexWatchers map[chan struct{}]struct{}
+	reindexMu       sync.Mutex
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		vectors:  make(map[int]*entry),
+		pending:  make(map[int]bool),
+		inFlight: make(map[int]bool),
+		wake:     make(chan struct{}, 1),
+		enabled:  enabled,
+
+		reindexWatchers: make(map[chan struct{}]struct{}),
+	}
+}
+
+// SetProvider enables semantic search; without a provider semantic queries fall back to keywords
+func (s *Service) SetProvider(provider Provider) {
+	s.provider = provider
+}
+
+// SetFlags makes semantic queries depend on the semantic search feature flag. Posts keep being
+// vectorized while the flag is off, so turning it on takes effect at once.
+func (s *Service) SetFlags(registry *flags.Registry) {
+	s.flags = registry
+}
+
+// SetDispatcher lets the worker raise a notification when vectorizing starts failing
+func (s *Service) SetDispatcher(dispatcher *events.Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// SetSpaceAccess keeps the posts of spaces hidden from the viewer of a request out of its results
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// Initialize brings the keyword index up to date with posts changed while search was off, then
+// loads the stored vectors of the provider's model and queues every post without an up to date
+// vector
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	indexed, dropped, err := s.db.ReconcileSearchIndex()
+	if err != nil {
+		return err
+	}
+	if indexed > 0 || dropped > 0 {
+		logger.Info("Search index reconciled", zap.Int64("indexed", indexed), zap.Int64("dropped", dropped))
+	}
+
+	if s.provider == nil {
+		return nil
+	}
+
+	var spaceIDs []int
+	for _, space := range s.catCache.GetAll() {
+		spaceIDs = append(spaceIDs, space.ID)
+	}
+	posts, err := s.db.GetPostsBySpaces(spaceIDs)
+	if err != nil {
+		return err
+	}
+
+	stored, err := s.db.GetPostEmbeddings(s.provider.Model())
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, post := range posts {
+		embedding, ok := stored[post.ID]
+		if !ok || embedding.ContentHash != contentHash(post.Content) {
+			s.pending[post.ID] = true
+			continue
+		}
+		s.vectors[post.ID] = newEntry(post.SpaceID, post.Created, post.Content, embedding.Vector)
+	}
+	s.signal()
+
+	return nil
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	if err := s.updateKeywordIndex(event); err != nil {
+		return err
+	}
+	if s.provider == nil {
+		return nil
+	}
+
+	switch event.Type {
+	case events.PostCreated, events.PostUpdated:
+		data := event.Data.(events.PostEvent)
+		s.queue(data.PostID)
+
+	case events.PostMoved:
+		data := event.Data.(events.PostEvent)
+		s.mu.Lock()
+		if e, ok := s.vectors[data.PostID]; ok {
+			e.spaceID = data.SpaceID
+		}
+		s.mu.Unlock()
+
+	case events.PostMerged:
+		data := event.Data.(events.PostEvent)
+		for _, merged := range data.MergedPosts {
+			s.remove(merged.PostID)
+		}
+		s.queue(data.PostID)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		s.queue(data.PostID)
+		for _, split := range data.SplitPosts {
+			s.queue(split.PostID)
+		}
+
+	case events.PostDeleted:
+		data := event.Data.(events.PostEvent)
+		s.remove(data.PostID)
+
+	case events.SpaceDeleted:
+		data := event.Data.(events.SpaceEvent)
+		for _, postID := range data.AffectedPosts {
+			s.remove(postID)
+		}
+	}
+
+	return nil
+}
+
+// updateKeywordIndex keeps the full-text index of keyword search in step with posts and their
+// link previews. Spaces are read from posts at query time, so moved posts need no reindexing.
+func (s *Service) updateKeywordIndex(event events.Event) error {
+	switch event.Type {
+	case events.PostCreated, events.PostUpdated, events.LinkPreviewSaved:
+		data := event.Data.(events.PostEvent)
+		return s.db.IndexPostForSearch(data.PostID)
+
+	case events.PostMerged:
+		data := event.Data.(events.PostEvent)
+		for _, merged := range data.MergedPosts {
+			if err := s.db.RemovePostFromSearch(merged.PostID); err != nil {
+				return err
+			}
+		}
+		return s.db.IndexPostForSearch(data.PostID)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		if err := s.db.IndexPostForSearch(data.PostID); err != nil {
+			return err
+		}
+		for _, split := range data.SplitPosts {
+			if err := s.db.IndexPostForSearch(split.PostID); err != nil {
+				return err
+			}
+		}
+
+	case events.PostDeleted:
+		data := event.Data.(events.PostEvent)
+		return s.db.RemovePostFromSearch(data.PostID)
+
+	case events.SpaceDeleted:
+		data := event.Data.(events.SpaceEvent)
+		for _, postID := range data.AffectedPosts {
+			if err := s.db.RemovePostFromSearch(postID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) queue(postID int) {
+	s.mu.Lock()
+	s.pending[postID] = true
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *Service) remove(postID int) {
+	s.mu.Lock()
+	delete(s.vectors, postID)
+	delete(s.pending, postID)
+	delete(s.inFlight, postID)
+	s.mu.Unlock()
+}
+
+// signal wakes the worker without blocking when it is already due to run
+func (s *Service) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SetBudget makes the worker share the resource budget of background jobs
+func (s *Service) SetBudget(budget *jobs.Budget) {
+	s.budget = budget
+}
+
+// StartWorker vectorizes queued posts in the background until Stop is called. A failing
+// provider is retried after retryInterval.
+func (s *Service) StartWorker(retryInterval time.Duration) {
+	if !s.enabled || s.provider == nil || s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		failing := false
+		for {
+			var processed int
+			err := s.budget.Run(context.Background(), "search-index", func(job *jobs.Job) error {
+				var err error
+				processed, err = s.ProcessPending(context.Background())
+				return err
+			})
+			var wait <-chan time.Time
+			switch {
+			case err != nil:
+				logger.Warning("Failed to vectorize posts", zap.Error(err))
+				if !failing {
+					s.dispatcher.Notify(events.NotificationEvent{
+						Kind:    models.NotificationSyncFailure,
+						Title:   "Semantic search indexing failed",
+						Message: fmt.Sprintf("The embeddings provider failed (%v). New and edited posts are searched by keyword until it recovers; retrying every %s.", err, retryInterval),
+						Key:     "search.embeddings",
+					})
+				}
+				failing = true
+				wait = time.After(retryInterval)
+			case processed > 0:
+				failing = false
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				continue
+			}
+
+			select {
+			case <-wait:
+			case <-s.wake:
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// Stop ends the background worker and interrupts a running reindex, which the next start
+// resumes
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	s.stopReindex()
+}
+
+// ProcessPending vectorizes one batch of queued posts and returns how many were handled. On
+// provider errors the batch stays queued.
+func (s *Service) ProcessPending(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	var batch []int
+	for postID := range s.pending {
+		if len(batch) == config.EmbeddingBatchSize {
+			break
+		}
+		batch = append(batch, postID)
+		delete(s.pending, postID)
+		s.inFlight[postID] = true
+	}
+	s.mu.Unlock()
+
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	var ids []int
+	var texts []string
+	var spaceIDs []int
+	var created []int64
+	for _, postID := range batch {
+		post, err := s.db.GetPost(postID)
+		if err != nil {
+			// Deleted since it was queued
+			s.mu.Lock()
+			delete(s.inFlight, postID)
+			s.mu.Unlock()
+			continue
+		}
+		ids = append(ids, post.ID)
+		texts = append(texts, post.Content)
+		spaceIDs = append(spaceIDs, post.SpaceID)
+		created = append(created, post.Created)
+	}
+
+	vectors, err := s.provider.Embed(ctx, texts)
+	if err != nil {
+		s.mu.Lock()
+		for _, postID := range ids {
+			if s.inFlight[postID] {
+				delete(s.inFlight, postID)
+				s.pending[postID] = true
+			}
+		}
+		s.mu.Unlock()
+		return 0, err
+	}
+
+	model := s.provider.Model()
+	for i, postID := range ids {
+		s.mu.Lock()
+		current := s.inFlight[postID]
+		if current {
+			delete(s.inFlight, postID)
+			s.vectors[postID] = newEntry(spaceIDs[i], created[i], texts[i], vectors[i])
+		}
+		s.mu.Unlock()
+
+		if !current {
+			continue
+		}
+		embedding := storage.PostEmbedding{ContentHash: contentHash(texts[i]), Vector: vectors[i]}
+		if err := s.db.SavePostEmbedding(postID, model, embedding); err != nil {
+			logger.Warning("Failed to store post embedding", zap.Int("post_id", postID), zap.Error(err))
+		}
+	}
+
+	return len(batch), nil
+}
+
+// Search answers query in the given mode. spaceID 0 searches every space; recursive includes
+// the descendants of spaceID. Semantic searches fall back to keywords when no provider is set,
+// the semantic search flag is off for the space or the provider fails.
+func (s *Service) Search(ctx context.Context, query, mode string, spaceID int, recursive bool, limit int) (*SearchResponse, error) {
+	var scope []int
+	if spaceID != 0 {
+		if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+			return nil, fmt.Errorf(config.ErrSpaceNotFound)
+		}
+		scope = []int{spaceID}
+		if recursive {
+			scope = append(scope, s.catCache.GetDescendants(spaceID)...)
+			if err := services.ChargeSpaces(ctx, s.catCache, scope); err != nil {
+				return nil, err
+			}
+		}
+	}
+	scope = s.access.Scope(ctx, scope)
+
+	response := &SearchResponse{Query: query, Mode: mode}
+	if mode == config.SearchModeSemantic {
+		if s.provider == nil || !s.semanticOn(spaceID) {
+			response.Fallback = true
+		} else if results, pending, err := s.semantic(ctx, query, scope, limit); err != nil {
+			logger.Warning("Semantic search failed, falling back to keywords", zap.Error(err))
+			response.Fallback = true
+		} else {
+			response.Results = results
+			response.Pending = pending
+			return response, nil
+		}
+		response.Mode = config.SearchModeKeyword
+	}
+
+	results, err := s.keyword(ctx, query, scope, limit)
+	if err != nil {
+		return nil, err
+	}
+	response.Results = results
+	return response, nil
+}
+
+// SpaceSearch is the quick search of the sidebar: posts of a space and its descendants with a
+// word starting with each word of query, best first. Matches come from the full-text index,
+// restricted to the subtree in the query itself; the most recent ones are ranked by how well
+// their words match, blended with their age so recent posts come first among similar matches.
+func (s *Service) SpaceSearch(ctx context.Context, spaceID int, query string, limit int) (*SearchResponse, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	scope := append([]int{spaceID}, s.catCache.GetDescendants(spaceID)...)
+	if err := services.ChargeSpaces(ctx, s.catCache, scope); err != nil {
+		return nil, err
+	}
+	scope = s.access.Scope(ctx, scope)
+
+	prefixes := quickSearchTerms(query)
+	posts, err := s.db.QuickSearchPosts(ctx, scope, prefixes, config.QuickSearchCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make([]SearchResult, len(posts))
+	for i, post := range posts {
+		results[i] = SearchResult{
+			PostID:  post.ID,
+			SpaceID: post.SpaceID,
+			Created: post.Created,
+			Score:   config.QuickSearchMatchWeight*matchQuality(post.Content, prefixes) + config.QuickSearchRecencyWeight*recency(post.Created, now),
+			Snippet: utils.Snippet(post.Content, config.SearchSnippetLength),
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return &SearchResponse{Query: query, Mode: config.SearchModeKeyword, Results: results}, nil
+}
+
+// quickSearchTerms returns the first words of query, as the full-text index splits them
+func quickSearchTerms(query string) []string {
+	terms := keywordTerms(query)
+	if len(terms) > config.QuickSearchMaxTerms {
+		terms = terms[:config.QuickSearchMaxTerms]
+	}
+	return terms
+}
+
+// matchQuality averages over prefixes how well content matches each: 1 when a word equals it,
+// 0.5 when words only start with it
+func matchQuality(content string, prefixes []string) float64 {
+	if len(prefixes) == 0 {
+		return 0
+	}
+
+	words := quickSearchTerms(content)
+	var total float64
+	for _, prefix := range prefixes {
+		best := 0.0
+		for _, word := range words {
+			if word == prefix {
+				best = 1
+				break
+			}
+			if strings.HasPrefix(word, prefix) {
+				best = 0.5
+			}
+		}
+		total += best
+	}
+	return total / float64(len(prefixes))
+}
+
+// recency decays from 1 for a post created at now to 0.5 after the configured half-life
+func recency(created int64, now time.Time) float64 {
+	days := float64(now.UnixMilli()-created) / float64(24*time.Hour/time.Millisecond)
+	if days < 0 {
+		days = 0
+	}
+	return math.Pow(0.5, days/config.QuickSearchRecencyHalfLifeDays)
+}
+
+// semanticOn reports whether the semantic search flag is on for spaceID, or for every space
+// when searching all of them
+func (s *Service) semanticOn(spaceID int) bool {
+	if s.flags == nil {
+		return true
+	}
+	if spaceID == 0 {
+		return s.flags.IsOn(config.FlagSemanticSearch)
+	}
+	return s.flags.IsOnFor(config.FlagSemanticSearch, spaceID)
+}
+
+// keyword ranks the posts holding every word of query, in their content or in the titles of
+// their link previews, by BM25 relevance
+func (s *Service) keyword(ctx context.Context, query string, scope []int, limit int) ([]SearchResult, error) {
+	matches, err := s.db.SearchPosts(ctx, scope, keywordTerms(query), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, len(matches))
+	for i, match := range matches {
+		results[i] = SearchResult{
+			PostID:  match.Post.ID,
+			SpaceID: match.Post.SpaceID,
+			Created: match.Post.Created,
+			Score:   match.Rank,
+			Snippet: strings.Join(strings.Fields(match.Snippet), " "),
+		}
+	}
+	return results, nil
+}
+
+// keywordTerms splits query into lowercase words of letters and digits, the way the full-text
+// index does
+func keywordTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// semantic ranks vectorized posts by cosine similarity to the query and also returns how many
+// posts are not searchable yet
+func (s *Service) semantic(ctx context.Context, query string, scope []int, limit int) ([]SearchResult, int, error) {
+	vectors, err := s.provider.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, 0, err
+	}
+	target := newEntry(0, 0, "", vectors[0])
+
+	var inScope map[int]bool
+	if scope != nil {
+		inScope = make(map[int]bool, len(scope))
+		for _, id := range scope {
+			inScope[id] = true
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := []SearchResult{}
+	if target.norm > 0 {
+		for postID, e := range s.vectors {
+			if inScope != nil && !inScope[e.spaceID] {
+				continue
+			}
+			score := similarity(target, e)
+			if score <= 0 {
+				continue
+			}
+			results = append(results, SearchResult{
+				PostID:  postID,
+				SpaceID: e.spaceID,
+				Created: e.created,
+				Score:   score,
+				Snippet: e.snippet,
+			})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Created > results[j].Created
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, len(s.pending) + len(s.inFlight), nil
+}
+
+// MemoryUsage estimates the memory held by the vector index; entries are vectorized posts
+func (s *Service) MemoryUsage() models.MemoryUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usage := models.MemoryUsage{Entries: len(s.vectors)}
+	for _, e := range s.vectors {
+		usage.Bytes += config.MemoryMapEntryOverhead + int64(unsafe.Sizeof(*e)) + int64(len(e.snippet)) + int64(cap(e.vector))*4
+	}
+	usage.Bytes += int64(len(s.pending)+len(s.inFlight)) * config.MemoryMapEntryOverhead
+	return usage
+}
+
+func newEntry(spaceID int, created int64, content string, vector []float32) *entry {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	return &entry{
+		spaceID: spaceID,
+		created: created,
+		snippet: utils.Snippet(content, config.SearchSnippetLength),
+		vector:  vector,
+		norm:    math.Sqrt(sum),
+	}
+}
+
+// similarity is the cosine similarity of two entries; vectors of different sizes never match
+func similarity(a, b *entry) float64 {
+	if len(a.vector) != len(b.vector) || a.norm == 0 || b.norm == 0 {
+		return 0
+	}
+	var dot float64
+	for i := range a.vector {
+		dot += float64(a.vector[i]) * float64(b.vector[i])
+	}
+	return dot / (a.norm * b.norm)
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
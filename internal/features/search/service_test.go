@@ -0,0 +1,584 @@
+package search
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/flags"
+	"backthynk/internal/core/jobs"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func setupSearchTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_search_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+// failingProvider always errors, standing in for an unreachable embeddings API
+type failingProvider struct{}
+
+func (failingProvider) Model() string { return "failing" }
+
+func (failingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, fmt.Errorf("provider unavailable")
+}
+
+func drain(t *testing.T, service *Service) {
+	for {
+		processed, err := service.ProcessPending(context.Background())
+		if err != nil {
+			t.Fatalf("ProcessPending failed: %v", err)
+		}
+		if processed == 0 {
+			return
+		}
+	}
+}
+
+func resultIDs(results []SearchResult) []int {
+	ids := make([]int, len(results))
+	for i, r := range results {
+		ids[i] = r.PostID
+	}
+	return ids
+}
+
+func TestLocalProviderSimilarity(t *testing.T) {
+	provider := NewLocalProvider(config.LocalEmbeddingDimensions)
+	vectors, _ := provider.Embed(context.Background(), []string{
+		"Kafka consumers lagging behind",
+		"consumer lag on kafka",
+		"Sourdough starter feeding schedule",
+		"",
+	})
+
+	query := newEntry(0, 0, "", vectors[0])
+	close := similarity(query, newEntry(0, 0, "", vectors[1]))
+	far := similarity(query, newEntry(0, 0, "", vectors[2]))
+	if close <= far {
+		t.Errorf("Expected related text to score higher, got %f <= %f", close, far)
+	}
+	if similarity(query, newEntry(0, 0, "", vectors[3])) != 0 {
+		t.Error("Expected empty text to match nothing")
+	}
+	if len(vectors[0]) != config.LocalEmbeddingDimensions {
+		t.Errorf("Expected %d dimensions, got %d", config.LocalEmbeddingDimensions, len(vectors[0]))
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	if p, err := NewProvider(config.EmbeddingProviderLocal, "", "", ""); err != nil || p.Model() != "local-hash-256" {
+		t.Errorf("Expected local provider, got %v, %v", p, err)
+	}
+	if _, err := NewProvider(config.EmbeddingProviderAPI, "", "", ""); err == nil {
+		t.Error("Expected api provider without URL to fail")
+	}
+	if _, err := NewProvider("onnx", "", "", ""); err == nil {
+		t.Error("Expected unknown provider to fail")
+	}
+}
+
+func TestAPIProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req apiRequest
+		json.NewDecoder(r.Body).Decode(&req)
+
+		// Answer in reverse order to check indexes are honored
+		var resp apiResponse
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			resp.Data = append(resp.Data, struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			}{i, []float32{float32(len(req.Input[i])), 1}})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	provider := NewAPIProvider(server.URL, "secret", "small")
+	if provider.Model() != "api:small" {
+		t.Errorf("Unexpected model %q", provider.Model())
+	}
+
+	vectors, err := provider.Embed(context.Background(), []string{"a", "abc"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if vectors[0][0] != 1 || vectors[1][0] != 3 {
+		t.Errorf("Expected vectors in input order, got %v", vectors)
+	}
+
+	if _, err := NewAPIProvider(server.URL, "wrong", "small").Embed(context.Background(), []string{"a"}); err == nil {
+		t.Error("Expected error status to fail")
+	}
+}
+
+func TestSemanticSearch(t *testing.T) {
+	db, cleanup := setupSearchTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	infra, _ := db.CreateSpace("Infra", &work.ID, "")
+	home, _ := db.CreateSpace("Home", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+	catCache.Set(infra)
+	catCache.Set(home)
+
+	lag, _ := db.CreatePost(context.Background(), infra.ID, "Kafka consumers lagging on the billing cluster")
+	bread, _ := db.CreatePost(context.Background(), home.ID, "Sourdough starter feeding schedule")
+
+	service := NewService(db, catCache, true)
+	service.SetProvider(NewLocalProvider(config.LocalEmbeddingDimensions))
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	response, _ := service.Search(context.Background(), "consumer lag", config.SearchModeSemantic, 0, false, 10)
+	if response.Pending != 2 || len(response.Results) != 0 {
+		t.Errorf("Expected posts pending before the worker ran, got %+v", response)
+	}
+
+	drain(t, service)
+
+	response, _ = service.Search(context.Background(), "consumer lag", config.SearchModeSemantic, 0, false, 10)
+	if response.Mode != config.SearchModeSemantic || response.Fallback || response.Pending != 0 {
+		t.Fatalf("Unexpected response %+v", response)
+	}
+	if len(response.Results) == 0 || response.Results[0].PostID != lag.ID {
+		t.Errorf("Expected lag post first, got %v", resultIDs(response.Results))
+	}
+
+	response, _ = service.Search(context.Background(), "consumer lag", config.SearchModeSemantic, work.ID, true, 10)
+	for _, r := range response.Results {
+		if r.PostID == bread.ID {
+			t.Errorf("Expected space scope to exclude home posts, got %v", resultIDs(response.Results))
+		}
+	}
+	if _, err := service.Search(context.Background(), "lag", config.SearchModeSemantic, 999, false, 10); err == nil {
+		t.Error("Expected unknown space to fail")
+	}
+
+	// Edits are re-vectorized, deletions leave the index
+	db.UpdatePostContent(bread.ID, "Kafka consumer lag runbook")
+	service.HandleEvent(events.Event{Type: events.PostUpdated, Data: events.PostEvent{PostID: bread.ID}})
+	drain(t, service)
+	response, _ = service.Search(context.Background(), "kafka consumer lag", config.SearchModeSemantic, home.ID, false, 10)
+	if len(response.Results) != 1 || response.Results[0].PostID != bread.ID {
+		t.Errorf("Expected edited post to match, got %v", resultIDs(response.Results))
+	}
+
+	service.HandleEvent(events.Event{Type: events.PostDeleted, Data: events.PostEvent{PostID: lag.ID}})
+	response, _ = service.Search(context.Background(), "kafka consumer lag", config.SearchModeSemantic, 0, false, 10)
+	for _, r := range response.Results {
+		if r.PostID == lag.ID {
+			t.Error("Expected deleted post to leave the index")
+		}
+	}
+
+	// Stored vectors are reused on restart, stale ones recomputed
+	restarted := NewService(db, catCache, true)
+	restarted.SetProvider(NewLocalProvider(config.LocalEmbeddingDimensions))
+	restarted.Initialize()
+	if len(restarted.vectors) != 2 || len(restarted.pending) != 0 {
+		t.Errorf("Expected stored vectors to be loaded, got %d vectors and %d pending", len(restarted.vectors), len(restarted.pending))
+	}
+}
+
+func TestSemanticFallback(t *testing.T) {
+	db, cleanup := setupSearchTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	post, _ := db.CreatePost(context.Background(), space.ID, "Notes about 100% uptime_targets")
+	db.CreatePost(context.Background(), space.ID, "Notes about budgets")
+
+	flagOff := flags.NewRegistry(db, flags.Known, map[string]config.FeatureFlag{
+		config.FlagSemanticSearch: {Enabled: false},
+	})
+
+	tests := []struct {
+		name     string
+		provider Provider
+		flags    *flags.Registry
+	}{
+		{"Disabled", nil, nil},
+		{"Provider failure", failingProvider{}, nil},
+		{"Flag off", NewLocalProvider(config.LocalEmbeddingDimensions), flagOff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(db, catCache, true)
+			if tt.provider != nil {
+				service.SetProvider(tt.provider)
+			}
+			if tt.flags != nil {
+				service.SetFlags(tt.flags)
+			}
+			service.Initialize()
+
+			response, err := service.Search(context.Background(), "NOTES uptime", config.SearchModeSemantic, 0, false, 10)
+			if err != nil {
+				t.Fatalf("Search failed: %v", err)
+			}
+			if response.Mode != config.SearchModeKeyword || !response.Fallback {
+				t.Errorf("Expected keyword fallback, got %+v", response)
+			}
+			if len(response.Results) != 1 || response.Results[0].PostID != post.ID {
+				t.Errorf("Expected keyword match, got %v", resultIDs(response.Results))
+			}
+		})
+	}
+
+	// Queries match whole words, so punctuation alone finds nothing
+	service := NewService(db, catCache, true)
+	service.Initialize()
+	for query, expected := range map[string]int{"100%": 1, "uptime_targets": 1, "_": 0, "%": 0, "notes": 2, "missing": 0} {
+		response, _ := service.Search(context.Background(), query, config.SearchModeKeyword, 0, false, 10)
+		if len(response.Results) != expected {
+			t.Errorf("Query %q: expected %d results, got %d", query, expected, len(response.Results))
+		}
+	}
+}
+
+func TestKeywordSearch(t *testing.T) {
+	db, cleanup := setupSearchTestDB(t)
+	defer cleanup()
+
+	root, _ := db.CreateSpace("Projects", nil, "")
+	child, _ := db.CreateSpace("Garden", &root.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(root)
+	catCache.Set(child)
+
+	indexed, _ := db.CreatePost(context.Background(), root.ID, "Compost notes")
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	created := func(spaceID int, content string) *models.Post {
+		post, _ := db.CreatePost(context.Background(), spaceID, content)
+		service.HandleEvent(events.Event{Type: events.PostCreated, Data: events.PostEvent{PostID: post.ID, SpaceID: spaceID}})
+		return post
+	}
+	dense := created(child.ID, "Compost compost compost")
+	linked := created(root.ID, "Read this later")
+	db.CreateLinkPreview(&models.LinkPreview{PostID: linked.ID, URL: "https://example.com", Title: "Home compost guide"})
+	service.HandleEvent(events.Event{Type: events.LinkPreviewSaved, Data: events.PostEvent{PostID: linked.ID}})
+	deleted := created(root.ID, "Compost bin to delete")
+	db.DeletePost(context.Background(), deleted.ID)
+	service.HandleEvent(events.Event{Type: events.PostDeleted, Data: events.PostEvent{PostID: deleted.ID, SpaceID: root.ID}})
+
+	// Denser matches rank first; titles of link previews match with a lower weight
+	response, err := service.Search(context.Background(), "COMPOST", config.SearchModeKeyword, 0, false, 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	expected := []int{dense.ID, indexed.ID, linked.ID}
+	if fmt.Sprint(resultIDs(response.Results)) != fmt.Sprint(expected) {
+		t.Errorf("Expected results %v, got %+v", expected, response.Results)
+	}
+	for i := 1; i < len(response.Results); i++ {
+		if response.Results[i].Score > response.Results[i-1].Score || response.Results[i].Score <= 0 {
+			t.Errorf("Expected decreasing positive scores, got %+v", response.Results)
+		}
+	}
+	if len(response.Results) == 3 && response.Results[2].Snippet != "Home compost guide" {
+		t.Errorf("Expected the snippet of the matching title, got %q", response.Results[2].Snippet)
+	}
+
+	response, _ = service.Search(context.Background(), "compost", config.SearchModeKeyword, root.ID, false, 10)
+	if fmt.Sprint(resultIDs(response.Results)) != fmt.Sprint([]int{indexed.ID, linked.ID}) {
+		t.Errorf("Expected only the posts of the space, got %v", resultIDs(response.Results))
+	}
+	response, _ = service.Search(context.Background(), "compost", config.SearchModeKeyword, root.ID, true, 10)
+	if len(response.Results) != 3 {
+		t.Errorf("Expected the posts of the subtree, got %v", resultIDs(response.Results))
+	}
+
+	// Moved posts are found in their new space without reindexing
+	db.UpdatePostSpace(context.Background(), dense.ID, root.ID)
+	service.HandleEvent(events.Event{Type: events.PostMoved, Data: events.PostEvent{PostID: dense.ID, SpaceID: root.ID}})
+	response, _ = service.Search(context.Background(), "compost", config.SearchModeKeyword, root.ID, false, 10)
+	if len(response.Results) != 3 {
+		t.Errorf("Expected the moved post in its new space, got %v", resultIDs(response.Results))
+	}
+
+	// Posts written behind the service's back are picked up on the next start
+	missed, _ := db.CreatePost(context.Background(), root.ID, "Compost heap")
+	NewService(db, catCache, true).Initialize()
+	response, _ = service.Search(context.Background(), "heap", config.SearchModeKeyword, 0, false, 10)
+	if len(response.Results) != 1 || response.Results[0].PostID != missed.ID {
+		t.Errorf("Expected the reconciled post, got %v", resultIDs(response.Results))
+	}
+}
+
+func TestSearchHidesPrivateSpaces(t *testing.T) {
+	db, cleanup := setupSearchTestDB(t)
+	defer cleanup()
+
+	shared, _ := db.CreateSpace("Shared", nil, "")
+	private, _ := db.CreateSpace("Private", &shared.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(shared)
+	catCache.Set(private)
+	open, _ := db.CreatePost(context.Background(), shared.ID, "Compost schedule")
+	db.CreatePost(context.Background(), private.ID, "Compost budget")
+
+	alice, _ := db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	bob, _ := db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(db, catCache)
+	if err := access.SetACL(private.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+
+	service := NewService(db, catCache, true)
+	service.SetSpaceAccess(access)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	listed := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	if response, _ := service.Search(listed, "compost", config.SearchModeKeyword, shared.ID, true, 10); len(response.Results) != 2 {
+		t.Errorf("Expected both posts for a listed viewer, got %v", resultIDs(response.Results))
+	}
+
+	other := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+	for _, recursive := range []bool{false, true} {
+		response, err := service.Search(other, "compost", config.SearchModeKeyword, shared.ID, recursive, 10)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if fmt.Sprint(resultIDs(response.Results)) != fmt.Sprint([]int{open.ID}) {
+			t.Errorf("Expected only the shared post (recursive %v), got %v", recursive, resultIDs(response.Results))
+		}
+	}
+	if response, _ := service.Search(other, "compost", config.SearchModeKeyword, 0, false, 10); fmt.Sprint(resultIDs(response.Results)) != fmt.Sprint([]int{open.ID}) {
+		t.Errorf("Expected only the shared post across spaces, got %v", resultIDs(response.Results))
+	}
+	if _, err := service.Search(other, "compost", config.SearchModeKeyword, private.ID, false, 10); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+}
+
+func TestProcessPendingRetriesOnFailure(t *testing.T) {
+	db, cleanup := setupSearchTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	db.CreatePost(context.Background(), space.ID, "First")
+
+	service := NewService(db, catCache, true)
+	service.SetProvider(failingProvider{})
+	service.Initialize()
+
+	if _, err := service.ProcessPending(context.Background()); err == nil {
+		t.Fatal("Expected provider failure")
+	}
+	if len(service.pending) != 1 || len(service.inFlight) != 0 {
+		t.Errorf("Expected the batch to stay queued, got %d pending and %d in flight", len(service.pending), len(service.inFlight))
+	}
+}
+
+func TestSpaceSearch(t *testing.T) {
+	db, cleanup := setupSearchTestDB(t)
+	defer cleanup()
+
+	root, _ := db.CreateSpace("Projects", nil, "")
+	child, _ := db.CreateSpace("Garden", &root.ID, "")
+	other, _ := db.CreateSpace("Journal", nil, "")
+	catCache := cache.NewSpaceCache()
+	for _, space := range []*models.Space{root, child, other} {
+		catCache.Set(space)
+	}
+
+	old := time.Now().AddDate(-1, 0, 0).UnixMilli()
+	oldExact, _ := db.CreatePostWithTimestamp(context.Background(), root.ID, "Plant tomatoes in spring", old)
+	recentExact, _ := db.CreatePost(context.Background(), child.ID, "Plant tomatoes along the fence")
+	recentPrefix, _ := db.CreatePost(context.Background(), child.ID, "Planting schedule for tomatoes")
+	db.CreatePost(context.Background(), other.ID, "Plant tomatoes on the balcony")
+	edited, _ := db.CreatePost(context.Background(), root.ID, "Nothing to see")
+	deleted, _ := db.CreatePost(context.Background(), root.ID, "Plant tomatoes, then delete")
+	db.UpdatePostContent(edited.ID, "Edited to plant tomatoes")
+	db.DeletePost(context.Background(), deleted.ID)
+
+	service := NewService(db, catCache, true)
+	response, err := service.SpaceSearch(context.Background(), root.ID, "plant Tomato", 10)
+	if err != nil {
+		t.Fatalf("SpaceSearch failed: %v", err)
+	}
+
+	// Posts outside the subtree or deleted never match; the edited post matches its new content.
+	// Recent exact matches rank above prefix-only and old ones.
+	var ids []int
+	for _, result := range response.Results {
+		ids = append(ids, result.PostID)
+	}
+	expected := []int{edited.ID, recentExact.ID, recentPrefix.ID, oldExact.ID}
+	if fmt.Sprint(ids) != fmt.Sprint(expected) {
+		t.Errorf("Expected results %v, got %v (%+v)", expected, ids, response.Results)
+	}
+
+	response, _ = service.SpaceSearch(context.Background(), child.ID, "plant", 1)
+	if len(response.Results) != 1 || response.Results[0].SpaceID != child.ID {
+		t.Errorf("Expected one result from the child space, got %+v", response.Results)
+	}
+
+	response, _ = service.SpaceSearch(context.Background(), root.ID, "!!", 10)
+	if len(response.Results) != 0 {
+		t.Errorf("Expected no results without words, got %+v", response.Results)
+	}
+
+	if _, err := service.SpaceSearch(context.Background(), 999, "plant", 10); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected space not found, got %v", err)
+	}
+}
+
+// waitReindex waits for the running reindex to finish and returns its final state
+func waitReindex(t *testing.T, service *Service) *ReindexProgress {
+	t.Helper()
+	changed := service.WatchReindex()
+	defer service.UnwatchReindex(changed)
+
+	deadline := time.After(5 * time.Second)
+	for {
+		progress, err := service.Reindex()
+		if err != nil {
+			t.Fatalf("Reindex failed: %v", err)
+		}
+		if progress.Status != config.ReindexStatusRunning {
+			return progress
+		}
+		select {
+		case <-changed:
+		case <-deadline:
+			t.Fatalf("Reindex did not finish: %+v", progress)
+		}
+	}
+}
+
+func TestSearchReindex(t *testing.T) {
+	db, cleanup := setupSearchTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	first, _ := db.CreatePost(context.Background(), space.ID, "alpha notes")
+	db.CreatePost(context.Background(), space.ID, "beta notes")
+	third, _ := db.CreatePost(context.Background(), space.ID, "gamma notes")
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	found := func(query string) int {
+		response, err := service.Search(context.Background(), query, config.SearchModeKeyword, 0, false, 10)
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		return len(response.Results)
+	}
+
+	if progress, _ := service.Reindex(); progress.Status != config.ReindexStatusIdle {
+		t.Errorf("Expected no reindex yet, got %+v", progress)
+	}
+
+	t.Run("rebuilds missing and stale entries", func(t *testing.T) {
+		db.RemovePostFromSearch(first.ID)
+		db.Exec("INSERT INTO search_fts (docid, content, titles) VALUES (999, 'ghost', '')")
+
+		if _, err := service.StartReindex(false); err != nil {
+			t.Fatalf("StartReindex failed: %v", err)
+		}
+		progress := waitReindex(t, service)
+		if progress.Status != config.ReindexStatusDone || progress.Processed != 3 || progress.Total != 3 || progress.Resumed {
+			t.Errorf("Unexpected progress %+v", progress)
+		}
+		if found("alpha") != 1 || found("ghost") != 0 {
+			t.Error("Expected the index to match the posts")
+		}
+	})
+
+	t.Run("interrupted reindex resumes from its checkpoint", func(t *testing.T) {
+		db.SaveSearchReindex(storage.SearchReindex{
+			Status:     config.ReindexStatusRunning,
+			LastPostID: third.ID - 1,
+			Processed:  2,
+			Total:      3,
+		})
+		db.RemovePostFromSearch(first.ID)
+		db.RemovePostFromSearch(third.ID)
+
+		if err := service.ResumeReindex(); err != nil {
+			t.Fatalf("ResumeReindex failed: %v", err)
+		}
+		progress := waitReindex(t, service)
+		if progress.Status != config.ReindexStatusDone || progress.Processed != 3 || !progress.Resumed {
+			t.Errorf("Unexpected progress %+v", progress)
+		}
+		// Posts before the checkpoint were done already
+		if found("gamma") != 1 || found("alpha") != 0 {
+			t.Error("Expected only the posts after the checkpoint to be reindexed")
+		}
+	})
+
+	t.Run("stopping leaves the reindex to resume", func(t *testing.T) {
+		budget := jobs.NewBudget(1, 0, 0)
+		held, _ := budget.Acquire(context.Background(), "other")
+		defer held.Release(nil)
+		service.SetBudget(budget)
+		defer service.SetBudget(nil)
+
+		if _, err := service.StartReindex(true); err != nil {
+			t.Fatalf("StartReindex failed: %v", err)
+		}
+		if _, err := service.StartReindex(false); err == nil || err.Error() != config.ErrReindexRunning {
+			t.Errorf("Expected a second reindex to be refused, got %v", err)
+		}
+
+		service.Stop()
+		checkpoint, _ := db.GetSearchReindex()
+		if checkpoint.Status != config.ReindexStatusRunning || checkpoint.Processed != 0 {
+			t.Errorf("Expected the checkpoint to stay running, got %+v", checkpoint)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package search
+
+import "backthynk/internal/storage"
+
+// SearchResult is a post matching a search query
+type SearchResult struct {
+	PostID  int     `json:"post_id"`
+	SpaceID int     `json:"space_id"`
+	Created int64   `json:"created"`
+	Score   float64 `json:"score,omitempty"` // Cosine similarity in semantic mode, match and recency blend in quick search
+	Snippet string  `json:"snippet"`
+}
+
+type SearchResponse struct {
+	Query    string         `json:"query"`
+	Mode     string         `json:"mode"`               // Mode used to answer, keyword when semantic search fell back
+	Fallback bool           `json:"fallback,omitempty"` // Semantic search was requested but unavailable
+	Pending  int            `json:"pending,omitempty"`  // Posts still waiting to be vectorized
+	Results  []SearchResult `json:"results"`
+}
+
+// ReindexProgress is the state of the latest rebuild of the search index, streamed while it
+// runs. ETASeconds is estimated from the pace of the current run.
+type ReindexProgress struct {
+	storage.SearchReindex
+	Resumed    bool   `json:"resumed,omitempty"` // Continued from an interrupted run
+	ETASeconds *int64 `json:"eta_seconds,omitempty"`
+}
+
+// apiRequest and apiResponse follow the common embeddings API shape
+type apiRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type apiResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
@@ -0,0 +1,51 @@
+package secretscan
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/admin/secret-findings", h.GetFindings).Methods("GET")
+}
+
+// GetFindings handles GET /api/admin/secret-findings
+// Query parameters:
+// - limit: number of findings to return, most recent first (default 100, max 1000)
+func (h *Handler) GetFindings(w http.ResponseWriter, r *http.Request) {
+	limit := config.DefaultSecretFindingsLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > config.MaxSecretFindingsLimit {
+			http.Error(w, config.ErrInvalidSecretFindingsLimit, http.StatusBadRequest)
+			return
+		}
+	}
+
+	findings, err := h.service.GetFindings(limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(findings)
+}
@@ -0,0 +1,63 @@
+package secretscan
+
+import (
+	"backthynk/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/admin/secret-findings", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected secret scan routes NOT to be registered when disabled")
+	}
+}
+
+func TestGetFindingsHandler(t *testing.T) {
+	db, cleanup := setupSecretScanTestDB(t)
+	defer cleanup()
+
+	service := NewService(db, true, config.SecretScanModeReject)
+	service.Screen(1, config.SecretSourcePost, "key "+testAWSKey)
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Default limit", "/api/admin/secret-findings", http.StatusOK, `"kind":"aws-access-key"`},
+		{"Masked excerpt", "/api/admin/secret-findings?limit=1", http.StatusOK, `"excerpt":"AKIA****`},
+		{"Invalid limit", "/api/admin/secret-findings?limit=abc", http.StatusBadRequest, ""},
+		{"Limit too large", "/api/admin/secret-findings?limit=1001", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedBody != "" && !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body to contain %s, got %s", tt.expectedBody, w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), testAWSKey) {
+				t.Error("Expected findings not to expose the secret")
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package secretscan
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service scans ingested posts and text attachments for secrets. Depending on its mode it
+// warns about them, redacts them or rejects the content.
+type Service struct {
+	db      *storage.DB
+	enabled bool
+	mode    string
+	now     func() time.Time
+}
+
+func NewService(db *storage.DB, enabled bool, mode string) *Service {
+	switch mode {
+	case config.SecretScanModeWarn, config.SecretScanModeRedact, config.SecretScanModeReject:
+	default:
+		logger.Warning("Unknown secret scan mode, falling back to warn", zap.String("mode", mode))
+		mode = config.SecretScanModeWarn
+	}
+
+	return &Service{
+		db:      db,
+		enabled: enabled,
+		mode:    mode,
+		now:     time.Now,
+	}
+}
+
+// Screen returns the content to store and the warnings to report for content ingested in a
+// space. In reject mode, content holding secrets is refused and the findings are recorded
+// right away since nothing will be saved.
+func (s *Service) Screen(spaceID int, source, content string) (string, []string, error) {
+	if !s.enabled {
+		return content, nil, nil
+	}
+
+	matches := utils.FindSecrets(content)
+	if len(matches) == 0 {
+		return content, nil, nil
+	}
+
+	kinds := matchKinds(matches)
+	switch s.mode {
+	case config.SecretScanModeReject:
+		if err := s.db.CreateSecretFindings(s.findings(spaceID, nil, nil, source, content, matches)); err != nil {
+			logger.Warning("Failed to record rejected secret findings", zap.String("source", source), zap.Error(err))
+		}
+		return "", nil, fmt.Errorf(config.ErrFmtContentContainsSecret, strings.Join(kinds, ", "))
+	case config.SecretScanModeRedact:
+		warnings := make([]string, len(kinds))
+		for i, kind := range kinds {
+			warnings[i] = fmt.Sprintf("Redacted %s", kind)
+		}
+		return utils.RedactSecrets(content, matches), warnings, nil
+	default:
+		warnings := make([]string, len(kinds))
+		for i, kind := range kinds {
+			warnings[i] = fmt.Sprintf("Possible %s detected", kind)
+		}
+		return content, warnings, nil
+	}
+}
+
+// RecordFindings stores the secrets of the original content of a saved post or attachment
+func (s *Service) RecordFindings(spaceID, postID int, attachmentID *int, source, content string) error {
+	matches := utils.FindSecrets(content)
+	if len(matches) == 0 {
+		return nil
+	}
+	return s.db.CreateSecretFindings(s.findings(spaceID, &postID, attachmentID, source, content, matches))
+}
+
+// GetFindings returns the most recent findings
+func (s *Service) GetFindings(limit int) ([]models.SecretFinding, error) {
+	return s.db.GetSecretFindings(limit)
+}
+
+func (s *Service) findings(spaceID int, postID, attachmentID *int, source, content string, matches []utils.SecretMatch) []models.SecretFinding {
+	created := s.now().UnixMilli()
+	findings := make([]models.SecretFinding, len(matches))
+	for i, m := range matches {
+		findings[i] = models.SecretFinding{
+			PostID:       postID,
+			AttachmentID: attachmentID,
+			SpaceID:      spaceID,
+			Source:       source,
+			Kind:         m.Kind,
+			Excerpt:      utils.MaskSecret(content[m.Start:m.End]),
+			Action:       s.mode,
+			Created:      created,
+		}
+	}
+	return findings
+}
+
+// matchKinds lists the distinct kinds of matches in order of appearance
+func matchKinds(matches []utils.SecretMatch) []string {
+	seen := make(map[string]bool)
+	var kinds []string
+	for _, m := range matches {
+		if !seen[m.Kind] {
+			seen[m.Kind] = true
+			kinds = append(kinds, m.Kind)
+		}
+	}
+	return kinds
+}
@@ -0,0 +1,199 @@
+package sharelinks
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/render"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+	guard   mux.MiddlewareFunc
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// SetGuard wraps the public share link endpoints, e.g. with the public endpoint guard
+func (h *Handler) SetGuard(guard mux.MiddlewareFunc) {
+	h.guard = guard
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/share-links", h.GetLinks).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/share-links", h.CreateLink).Methods("POST")
+	api.HandleFunc("/share-links/{id:[0-9]+}", h.RevokeLink).Methods("DELETE")
+	api.HandleFunc("/share-links/{id:[0-9]+}/contributions", h.GetContributions).Methods("GET")
+
+	public := api.PathPrefix("/shared").Subrouter()
+	if h.guard != nil {
+		public.Use(h.guard)
+	}
+	public.HandleFunc("/{token}", h.View).Methods("GET")
+	public.HandleFunc("/{token}/posts", h.Contribute).Methods("POST")
+}
+
+// GetLinks handles GET /api/spaces/{id}/share-links
+func (h *Handler) GetLinks(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	links, err := h.service.GetLinks(r.Context(), spaceID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+// CreateLink handles POST /api/spaces/{id}/share-links
+// The full token is only part of this response.
+func (h *Handler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req CreateLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	minted, err := h.service.Mint(r.Context(), spaceID, req.Name, req.ExpiresInHours, req.RateLimit, req.Signed)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(minted)
+}
+
+// RevokeLink handles DELETE /api/share-links/{id}
+// Posts made through a revoked link keep their attribution.
+func (h *Handler) RevokeLink(w http.ResponseWriter, r *http.Request) {
+	linkID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidShareLinkID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Revoke(r.Context(), linkID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetContributions handles GET /api/share-links/{id}/contributions
+func (h *Handler) GetContributions(w http.ResponseWriter, r *http.Request) {
+	linkID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidShareLinkID, http.StatusBadRequest)
+		return
+	}
+
+	contributions, err := h.service.GetContributions(r.Context(), linkID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contributions)
+}
+
+// View handles GET /api/shared/{token}?limit=50&offset=0
+// With format=html the space is returned as a themed page instead of JSON.
+func (h *Handler) View(w http.ResponseWriter, r *http.Request) {
+	limit := config.DefaultSharedPostsLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > config.MaxSharedPostsLimit {
+			http.Error(w, config.ErrInvalidSharedPostsLimit, http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	offset := 0
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	view, err := h.service.View(r.Context(), mux.Vars(r)["token"], limit, offset)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "html" {
+		render.Write(w, r, render.PageShare, view.Name, view)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// Contribute handles POST /api/shared/{token}/posts
+func (h *Handler) Contribute(w http.ResponseWriter, r *http.Request) {
+	var req ContributeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, config.MaxIngestBodyBytes)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, config.ErrIngestPayloadTooLarge, http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	post, err := h.service.Contribute(r.Context(), mux.Vars(r)["token"], req.Author, req.Content)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ContributeResponse{PostID: post.ID, Author: post.Author, Created: post.Created})
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case config.ErrSpaceNotFound, config.ErrShareLinkNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case config.ErrSpaceAccessDenied:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case config.ErrShareLinkRevoked, config.ErrShareLinkExpired:
+		http.Error(w, err.Error(), http.StatusGone)
+	case config.ErrShareLinkRateLimited:
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case config.ErrShareBlockedByModeration:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		// Validation errors of the link request or of the posted content
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
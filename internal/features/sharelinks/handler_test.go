@@ -0,0 +1,87 @@
+package sharelinks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	db, service, _, _, cleanup := setupShareLinksTest(t)
+	defer cleanup()
+
+	router := mux.NewRouter()
+	NewHandler(NewService(db, service.catCache, false)).RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/shared/bts_token", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected share link routes NOT to be registered when disabled")
+	}
+}
+
+func TestShareLinkHandlers(t *testing.T) {
+	_, service, _, space, cleanup := setupShareLinksTest(t)
+	defer cleanup()
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	w := serve("POST", fmt.Sprintf("/api/spaces/%d/share-links", space.ID), `{"name":"Retro","rate_limit":5}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var minted MintedLink
+	if err := json.Unmarshal(w.Body.Bytes(), &minted); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"Invalid link request", "POST", fmt.Sprintf("/api/spaces/%d/share-links", space.ID), `{"name":""}`, http.StatusBadRequest},
+		{"Unknown space", "GET", "/api/spaces/9999/share-links", "", http.StatusNotFound},
+		{"Contribute", "POST", minted.URL + "/posts", `{"author":"Ada","content":"Hello"}`, http.StatusCreated},
+		{"Missing author", "POST", minted.URL + "/posts", `{"content":"Hello"}`, http.StatusBadRequest},
+		{"Invalid JSON", "POST", minted.URL + "/posts", `{`, http.StatusBadRequest},
+		{"View", "GET", minted.URL, "", http.StatusOK},
+		{"View as page", "GET", minted.URL + "?format=html", "", http.StatusOK},
+		{"Invalid limit", "GET", minted.URL + "?limit=1000", "", http.StatusBadRequest},
+		{"Unknown token", "GET", "/api/shared/bts_unknown", "", http.StatusNotFound},
+		{"Contributions", "GET", fmt.Sprintf("/api/share-links/%d/contributions", minted.ID), "", http.StatusOK},
+		{"Revoke", "DELETE", fmt.Sprintf("/api/share-links/%d", minted.ID), "", http.StatusNoContent},
+		{"Revoked link", "POST", minted.URL + "/posts", `{"author":"Ada","content":"Hello"}`, http.StatusGone},
+		{"Revoke unknown", "DELETE", "/api/share-links/9999", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.method, tt.path, tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.name == "View as page" && (!strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") || !strings.Contains(w.Body.String(), "Hello")) {
+				t.Errorf("Expected the contributed post in an HTML page, got %s", w.Body.String())
+			}
+		})
+	}
+
+	w = serve("GET", fmt.Sprintf("/api/spaces/%d/share-links", space.ID), "")
+	if strings.Contains(w.Body.String(), minted.Token) {
+		t.Error("Expected the full token not to be listed")
+	}
+}
@@ -0,0 +1,449 @@
+package sharelinks
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Posts creates and lists posts with the validation of regular posting, e.g. the core PostService
+type Posts interface {
+	CreateWithSource(ctx context.Context, spaceID int, content string, customTimestamp *int64, source string) (*models.Post, error)
+	GetBySpace(ctx context.Context, spaceID int, recursive bool, limit, offset int, filter models.PostFilter) ([]models.PostWithAttachments, error)
+}
+
+// GateFunc reports whether the content of a space may be shared, e.g. the moderation gate
+type GateFunc func(spaceID int) (allowed bool, err error)
+
+// window counts the posts made through a link in the current rate limit window
+type window struct {
+	start  time.Time
+	count  int
+	warned bool // A quota warning was raised for this window
+}
+
+// Service mints share links letting people without an account read a space and post into
+// it for a limited time. Each link has its own expiry, rate limit and revocation, and posts
+// made through it are attributed to the name their author gave.
+type Service struct {
+	db         *storage.DB
+	catCache   *cache.SpaceCache
+	posts      Posts
+	gate       GateFunc
+	dispatcher *events.Dispatcher
+	access     *services.SpaceAccess
+	links      map[string]*models.ShareLink // token hash -> link
+	windows    map[int]*window              // link ID -> current window
+	mu         sync.Mutex
+	now        func() time.Time
+	enabled    bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		links:    make(map[string]*models.ShareLink),
+		windows:  make(map[int]*window),
+		now:      time.Now,
+		enabled:  enabled,
+	}
+}
+
+func (s *Service) SetPosts(posts Posts) {
+	s.posts = posts
+}
+
+// SetGate makes link creation depend on the space content being cleared for sharing
+func (s *Service) SetGate(gate GateFunc) {
+	s.gate = gate
+}
+
+// SetDispatcher lets the service raise a quota warning when a link hits its rate limit
+func (s *Service) SetDispatcher(dispatcher *events.Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// SetSpaceAccess keeps the viewer of a request from sharing spaces it may not change and from
+// seeing the links of spaces hidden from it
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	links, err := s.db.GetShareLinks()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range links {
+		s.links[links[i].Hash] = &links[i]
+	}
+
+	return nil
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	if event.Type == events.SpaceDeleted {
+		// Links of deleted spaces are removed by the database cascade
+		s.mu.Lock()
+		for hash, link := range s.links {
+			if _, ok := s.catCache.Get(link.SpaceID); !ok {
+				delete(s.windows, link.ID)
+				delete(s.links, hash)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Mint creates a link to spaceID valid for expiresInHours. Zero values use the defaults. A signed
+// link gets a secret its requests must be signed with, returned along with the token.
+func (s *Service) Mint(ctx context.Context, spaceID int, name string, expiresInHours, rateLimit int, signed bool) (*MintedLink, error) {
+	if err := s.checkSpace(ctx, spaceID, true); err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf(config.ErrShareLinkNameRequired)
+	}
+	if len([]rune(name)) > config.MaxShareLinkNameLength {
+		return nil, fmt.Errorf(config.ErrShareLinkNameTooLong)
+	}
+	if expiresInHours == 0 {
+		expiresInHours = config.DefaultShareLinkHours
+	}
+	if expiresInHours < 1 || expiresInHours > config.MaxShareLinkHours {
+		return nil, fmt.Errorf(config.ErrInvalidShareLinkExpiry)
+	}
+	if rateLimit == 0 {
+		rateLimit = config.DefaultShareLinkRateLimit
+	}
+	if rateLimit < 1 || rateLimit > config.MaxShareLinkRateLimit {
+		return nil, fmt.Errorf(config.ErrInvalidShareLinkRateLimit)
+	}
+
+	if s.gate != nil {
+		allowed, err := s.gate(spaceID)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, fmt.Errorf(config.ErrShareBlockedByModeration)
+		}
+	}
+
+	secret := make([]byte, config.ShareLinkTokenBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate share link token: %w", err)
+	}
+	value := config.ShareLinkTokenPrefix + hex.EncodeToString(secret)
+
+	link := &models.ShareLink{
+		SpaceID:   spaceID,
+		Name:      name,
+		Prefix:    value[:config.ShareLinkDisplayLength],
+		RateLimit: rateLimit,
+		Expires:   s.now().Add(time.Duration(expiresInHours) * time.Hour).UnixMilli(),
+		Hash:      hashToken(value),
+	}
+	if signed {
+		key := make([]byte, config.SigningSecretBytes)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate share link secret: %w", err)
+		}
+		link.Secret = hex.EncodeToString(key)
+	}
+	if err := s.db.CreateShareLink(link); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.links[link.Hash] = link
+	s.mu.Unlock()
+
+	return &MintedLink{ShareLink: *link, Token: value, URL: "/api/shared/" + value, SigningSecret: link.Secret}, nil
+}
+
+// GetLinks lists the links of a space, newest first
+func (s *Service) GetLinks(ctx context.Context, spaceID int) ([]models.ShareLink, error) {
+	if err := s.checkSpace(ctx, spaceID, false); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	links := []models.ShareLink{}
+	for _, link := range s.links {
+		if link.SpaceID == spaceID {
+			links = append(links, *link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].ID > links[j].ID })
+
+	return links, nil
+}
+
+// Revoke disables a link for good; revoking a revoked link is a no-op
+func (s *Service) Revoke(ctx context.Context, linkID int) error {
+	if err := s.checkLink(ctx, linkID, true); err != nil {
+		return err
+	}
+	return s.revoke(linkID)
+}
+
+func (s *Service) revoke(linkID int) error {
+	s.mu.Lock()
+	link := s.findUnlocked(linkID)
+	if link == nil {
+		s.mu.Unlock()
+		return fmt.Errorf(config.ErrShareLinkNotFound)
+	}
+	if link.Revoked != 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	revoked := s.now().UnixMilli()
+	s.mu.Unlock()
+
+	if err := s.db.RevokeShareLink(linkID, revoked); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	link.Revoked = revoked
+	delete(s.windows, linkID)
+	s.mu.Unlock()
+	return nil
+}
+
+// RevokeValue revokes a link from its full token, for revocations coming from the public
+// endpoint guard. Unknown values are ignored.
+func (s *Service) RevokeValue(value string) error {
+	s.mu.Lock()
+	link, ok := s.links[hashToken(value)]
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return s.revoke(link.ID)
+}
+
+// SigningSecret is the secret lookup of the public endpoint guard: it tells whether a link
+// token exists and returns its signing secret, empty for links accepting unsigned requests
+func (s *Service) SigningSecret(value string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, ok := s.links[hashToken(value)]
+	if !ok {
+		return "", false
+	}
+	return link.Secret, true
+}
+
+// GetContributions returns the attribution of the posts written through a link
+func (s *Service) GetContributions(ctx context.Context, linkID int) ([]models.PostAuthor, error) {
+	if err := s.checkLink(ctx, linkID, false); err != nil {
+		return nil, err
+	}
+
+	return s.db.GetShareLinkPosts(linkID)
+}
+
+// View returns the space of a link with a page of its posts, newest first
+func (s *Service) View(ctx context.Context, value string, limit, offset int) (*SharedSpace, error) {
+	link, err := s.resolve(value)
+	if err != nil {
+		return nil, err
+	}
+
+	space, ok := s.catCache.Get(link.SpaceID)
+	if !ok {
+		return nil, fmt.Errorf(config.ErrShareLinkNotFound)
+	}
+
+	posts, err := s.posts.GetBySpace(ctx, link.SpaceID, false, limit, offset, models.PostFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	view := &SharedSpace{
+		Name:        space.Name,
+		Description: space.Description,
+		LinkName:    link.Name,
+		Expires:     link.Expires,
+		Posts:       make([]SharedPost, len(posts)),
+	}
+	for i, post := range posts {
+		view.Posts[i] = SharedPost{ID: post.ID, Content: post.Content, Created: post.Created, Author: post.Author}
+	}
+
+	return view, nil
+}
+
+// Contribute posts content into the space of a link on behalf of author
+func (s *Service) Contribute(ctx context.Context, value, author, content string) (*models.Post, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	link, err := s.resolveUnlocked(value, now)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	linkID, spaceID, name, rateLimit := link.ID, link.SpaceID, link.Name, link.RateLimit
+
+	w, ok := s.windows[linkID]
+	if !ok || now.Sub(w.start) >= config.ShareLinkRateWindow {
+		w = &window{start: now}
+		s.windows[linkID] = w
+	}
+	if w.count >= rateLimit {
+		warn := !w.warned
+		w.warned = true
+		s.mu.Unlock()
+
+		if warn {
+			s.dispatcher.Notify(events.NotificationEvent{
+				Kind:    models.NotificationQuotaWarning,
+				Title:   "Share link rate limit reached",
+				Message: fmt.Sprintf("Share link %q reached its limit of %d posts per hour; further posts are refused until the hour is over.", name, rateLimit),
+				SpaceID: spaceID,
+				Key:     fmt.Sprintf("sharelinks.rate_limit.%d", linkID),
+			})
+		}
+		return nil, fmt.Errorf(config.ErrShareLinkRateLimited)
+	}
+	w.count++
+	s.mu.Unlock()
+
+	author = strings.TrimSpace(author)
+	if author == "" {
+		return nil, fmt.Errorf(config.ErrShareAuthorRequired)
+	}
+	if len([]rune(author)) > config.MaxShareAuthorLength {
+		return nil, fmt.Errorf(config.ErrShareAuthorTooLong)
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf(config.ErrContentRequired)
+	}
+
+	post, err := s.posts.CreateWithSource(ctx, spaceID, content, nil, models.PostSourceShareLink)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.SetPostAuthor(models.PostAuthor{PostID: post.ID, Author: author, ShareLinkID: linkID}); err != nil {
+		// The post exists; losing its attribution beats refusing content already accepted
+		logger.Warning("Failed to record share link author", zap.Int("post_id", post.ID), zap.Error(err))
+	} else {
+		post.Author = author
+	}
+
+	if err := s.db.TouchShareLink(linkID, now.UnixMilli()); err == nil {
+		s.mu.Lock()
+		link.LastUsed = now.UnixMilli()
+		s.mu.Unlock()
+	}
+
+	return post, nil
+}
+
+// resolve returns a copy of the usable link behind a token
+func (s *Service) resolve(value string) (models.ShareLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link, err := s.resolveUnlocked(value, s.now())
+	if err != nil {
+		return models.ShareLink{}, err
+	}
+	return *link, nil
+}
+
+// resolveUnlocked returns the link behind a token if it is neither revoked nor expired.
+// Caller must hold s.mu.
+func (s *Service) resolveUnlocked(value string, now time.Time) (*models.ShareLink, error) {
+	link, ok := s.links[hashToken(value)]
+	if !ok {
+		return nil, fmt.Errorf(config.ErrShareLinkNotFound)
+	}
+	if link.Revoked != 0 {
+		return nil, fmt.Errorf(config.ErrShareLinkRevoked)
+	}
+	if now.UnixMilli() >= link.Expires {
+		return nil, fmt.Errorf(config.ErrShareLinkExpired)
+	}
+	return link, nil
+}
+
+// findUnlocked returns the link with the given ID. Caller must hold s.mu.
+// checkSpace fails when the viewer of ctx may not read a space, or change it when write is set.
+// Hidden spaces are not found.
+func (s *Service) checkSpace(ctx context.Context, spaceID int, write bool) error {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if write && !s.access.CanWrite(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+	return nil
+}
+
+// checkLink is checkSpace for the space of a link; links of hidden spaces are not found
+func (s *Service) checkLink(ctx context.Context, linkID int, write bool) error {
+	s.mu.Lock()
+	link := s.findUnlocked(linkID)
+	s.mu.Unlock()
+	if link == nil || !s.access.CanRead(ctx, link.SpaceID) {
+		return fmt.Errorf(config.ErrShareLinkNotFound)
+	}
+	if write && !s.access.CanWrite(ctx, link.SpaceID) {
+		return fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+	return nil
+}
+
+func (s *Service) findUnlocked(linkID int) *models.ShareLink {
+	for _, link := range s.links {
+		if link.ID == linkID {
+			return link
+		}
+	}
+	return nil
+}
+
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,273 @@
+package sharelinks
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupShareLinksTest(t *testing.T) (*storage.DB, *Service, *services.PostService, *models.Space, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_sharelinks_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	space, _ := db.CreateSpace("Workshop", nil, "Notes from the retro")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	postService := services.NewPostService(db, catCache, events.NewDispatcher())
+
+	service := NewService(db, catCache, true)
+	service.SetPosts(postService)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	return db, service, postService, space, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestMintValidation(t *testing.T) {
+	_, service, _, space, cleanup := setupShareLinksTest(t)
+	defer cleanup()
+
+	tests := []struct {
+		name        string
+		spaceID     int
+		linkName    string
+		hours       int
+		rateLimit   int
+		expectedErr string
+	}{
+		{"Unknown space", 9999, "Retro", 0, 0, config.ErrSpaceNotFound},
+		{"Missing name", space.ID, "  ", 0, 0, config.ErrShareLinkNameRequired},
+		{"Name too long", space.ID, strings.Repeat("a", 101), 0, 0, config.ErrShareLinkNameTooLong},
+		{"Expiry too long", space.ID, "Retro", config.MaxShareLinkHours + 1, 0, config.ErrInvalidShareLinkExpiry},
+		{"Negative rate limit", space.ID, "Retro", 0, -1, config.ErrInvalidShareLinkRateLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Mint(context.Background(), tt.spaceID, tt.linkName, tt.hours, tt.rateLimit, false)
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Errorf("Expected %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	minted, err := service.Mint(context.Background(), space.ID, "Retro", 0, 0, false)
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	if !strings.HasPrefix(minted.Token, config.ShareLinkTokenPrefix) || minted.URL != "/api/shared/"+minted.Token {
+		t.Errorf("Unexpected minted link: %+v", minted)
+	}
+	if minted.RateLimit != config.DefaultShareLinkRateLimit || minted.Expires-minted.Created < int64(23*time.Hour/time.Millisecond) {
+		t.Errorf("Expected default rate limit and expiry, got %+v", minted.ShareLink)
+	}
+}
+
+func TestMintBlockedByGate(t *testing.T) {
+	_, service, _, space, cleanup := setupShareLinksTest(t)
+	defer cleanup()
+
+	service.SetGate(func(spaceID int) (bool, error) { return false, nil })
+	if _, err := service.Mint(context.Background(), space.ID, "Retro", 0, 0, false); err == nil || err.Error() != config.ErrShareBlockedByModeration {
+		t.Errorf("Expected moderation to block the link, got %v", err)
+	}
+
+	links, _ := service.GetLinks(context.Background(), space.ID)
+	if len(links) != 0 {
+		t.Errorf("Expected no link to be created, got %d", len(links))
+	}
+}
+
+func TestShareLinksFollowSpaceAccess(t *testing.T) {
+	db, service, _, space, cleanup := setupShareLinksTest(t)
+	defer cleanup()
+
+	minted, _ := service.Mint(context.Background(), space.ID, "Retro", 0, 0, false)
+
+	reader, _ := db.ProvisionUser("reader", "", "Reader", models.RoleEditor, time.Now().UnixMilli())
+	outsider, _ := db.ProvisionUser("outsider", "", "Outsider", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(db, service.catCache)
+	if err := access.SetACL(space.ID, []models.SpaceACLEntry{{UserID: reader.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+	service.SetSpaceAccess(access)
+
+	// Read access lists the links but may not share the space or revoke them
+	readOnly := services.WithViewer(context.Background(), services.Viewer{UserID: reader.ID})
+	if links, err := service.GetLinks(readOnly, space.ID); err != nil || len(links) != 1 {
+		t.Errorf("Expected the link to be listed, got %v, %v", links, err)
+	}
+	if _, err := service.Mint(readOnly, space.ID, "Leak", 0, 0, false); err == nil || err.Error() != config.ErrSpaceAccessDenied {
+		t.Errorf("Expected minting to be denied, got %v", err)
+	}
+	if err := service.Revoke(readOnly, minted.ID); err == nil || err.Error() != config.ErrSpaceAccessDenied {
+		t.Errorf("Expected revoking to be denied, got %v", err)
+	}
+
+	// The space and its links do not exist for a viewer missing from the list
+	hidden := services.WithViewer(context.Background(), services.Viewer{UserID: outsider.ID})
+	if _, err := service.GetLinks(hidden, space.ID); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected the space to be not found, got %v", err)
+	}
+	if _, err := service.Mint(hidden, space.ID, "Leak", 0, 0, false); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected the space to be not found, got %v", err)
+	}
+	if _, err := service.GetContributions(hidden, minted.ID); err == nil || err.Error() != config.ErrShareLinkNotFound {
+		t.Errorf("Expected the link to be not found, got %v", err)
+	}
+}
+
+func TestSigningSecret(t *testing.T) {
+	db, service, _, space, cleanup := setupShareLinksTest(t)
+	defer cleanup()
+
+	plain, _ := service.Mint(context.Background(), space.ID, "Retro", 0, 0, false)
+	signed, err := service.Mint(context.Background(), space.ID, "Board", 0, 0, true)
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+	if plain.Signed || plain.SigningSecret != "" || !signed.Signed || signed.SigningSecret == "" {
+		t.Fatalf("Expected only the signed link to get a secret, got %+v and %+v", plain, signed)
+	}
+
+	restarted := NewService(db, service.catCache, true)
+	if err := restarted.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if secret, known := restarted.SigningSecret(signed.Token); !known || secret != signed.SigningSecret {
+		t.Errorf("Expected the secret of the signed link, got %q, %v", secret, known)
+	}
+	if secret, known := restarted.SigningSecret(plain.Token); !known || secret != "" {
+		t.Errorf("Expected the unsigned link to be known without a secret, got %q, %v", secret, known)
+	}
+	if _, known := restarted.SigningSecret("bts_unknown"); known {
+		t.Error("Expected an unknown token not to be known")
+	}
+}
+
+func TestContributeAndView(t *testing.T) {
+	db, service, postService, space, cleanup := setupShareLinksTest(t)
+	defer cleanup()
+
+	if _, err := postService.Create(context.Background(), space.ID, "Agenda", nil); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	minted, _ := service.Mint(context.Background(), space.ID, "Retro", 0, 2, false)
+
+	if _, err := service.Contribute(context.Background(), minted.Token, " ", "Idea"); err == nil || err.Error() != config.ErrShareAuthorRequired {
+		t.Errorf("Expected author required error, got %v", err)
+	}
+
+	post, err := service.Contribute(context.Background(), minted.Token, " Ada ", "More breaks")
+	if err != nil {
+		t.Fatalf("Contribute failed: %v", err)
+	}
+	if post.Source != models.PostSourceShareLink || post.Author != "Ada" {
+		t.Errorf("Expected a share post by Ada, got source %q author %q", post.Source, post.Author)
+	}
+
+	// Rejected posts count against the limit like accepted ones
+	if _, err := service.Contribute(context.Background(), minted.Token, "Ada", "Again"); err == nil || err.Error() != config.ErrShareLinkRateLimited {
+		t.Errorf("Expected rate limit error, got %v", err)
+	}
+
+	view, err := service.View(context.Background(), minted.Token, 10, 0)
+	if err != nil {
+		t.Fatalf("View failed: %v", err)
+	}
+	if view.Name != "Workshop" || view.LinkName != "Retro" || len(view.Posts) != 2 {
+		t.Fatalf("Unexpected view: %+v", view)
+	}
+	authors := map[string]string{}
+	for _, p := range view.Posts {
+		authors[p.Content] = p.Author
+	}
+	if authors["More breaks"] != "Ada" || authors["Agenda"] != "" {
+		t.Errorf("Unexpected attribution: %v", authors)
+	}
+
+	// Owners see the attribution on regular post listings
+	posts, _ := postService.GetBySpace(context.Background(), space.ID, false, 10, 0, models.PostFilter{})
+	for _, p := range posts {
+		if p.ID == post.ID && p.Author != "Ada" {
+			t.Errorf("Expected author on listed post, got %q", p.Author)
+		}
+	}
+
+	contributions, err := service.GetContributions(context.Background(), minted.ID)
+	if err != nil || len(contributions) != 1 || contributions[0].PostID != post.ID {
+		t.Errorf("Expected one contribution, got %v (%v)", contributions, err)
+	}
+
+	// Attribution outlives the link
+	if err := service.Revoke(context.Background(), minted.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	authorsByPost, _ := db.GetPostAuthorsByPosts([]int{post.ID})
+	if authorsByPost[post.ID] != "Ada" {
+		t.Errorf("Expected attribution to be kept after revocation")
+	}
+}
+
+func TestExpiredAndRevokedLinks(t *testing.T) {
+	_, service, _, space, cleanup := setupShareLinksTest(t)
+	defer cleanup()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	minted, _ := service.Mint(context.Background(), space.ID, "Retro", 2, 0, false)
+	if _, err := service.View(context.Background(), minted.Token, 10, 0); err != nil {
+		t.Fatalf("Expected fresh link to work, got %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := service.Contribute(context.Background(), minted.Token, "Ada", "Late"); err == nil || err.Error() != config.ErrShareLinkExpired {
+		t.Errorf("Expected expired error, got %v", err)
+	}
+
+	other, _ := service.Mint(context.Background(), space.ID, "Other", 0, 0, false)
+	if err := service.RevokeValue(other.Token); err != nil {
+		t.Fatalf("RevokeValue failed: %v", err)
+	}
+	if _, err := service.View(context.Background(), other.Token, 10, 0); err == nil || err.Error() != config.ErrShareLinkRevoked {
+		t.Errorf("Expected revoked error, got %v", err)
+	}
+	if _, err := service.View(context.Background(), "bts_unknown", 10, 0); err == nil || err.Error() != config.ErrShareLinkNotFound {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+
+	// Links are loaded again on startup
+	reloaded := NewService(service.db, service.catCache, true)
+	if err := reloaded.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	links, _ := reloaded.GetLinks(context.Background(), space.ID)
+	if len(links) != 2 || links[0].Revoked == 0 {
+		t.Errorf("Expected both links with the revocation, got %+v", links)
+	}
+}
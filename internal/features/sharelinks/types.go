@@ -0,0 +1,48 @@
+package sharelinks
+
+import "backthynk/internal/core/models"
+
+type CreateLinkRequest struct {
+	Name           string `json:"name"`
+	ExpiresInHours int    `json:"expires_in_hours,omitempty"` // Defaults to 24
+	RateLimit      int    `json:"rate_limit,omitempty"`       // Posts per hour, defaults to 30
+	Signed         bool   `json:"signed,omitempty"`           // Only accept requests signed with the link secret
+}
+
+// MintedLink is returned once, when a link is created; neither the full token nor the signing
+// secret can be read again
+type MintedLink struct {
+	models.ShareLink
+	Token         string `json:"token"`
+	URL           string `json:"url"`
+	SigningSecret string `json:"signing_secret,omitempty"`
+}
+
+// ContributeRequest is the body of a post made through a share link
+type ContributeRequest struct {
+	Author  string `json:"author"`
+	Content string `json:"content"`
+}
+
+type ContributeResponse struct {
+	PostID  int    `json:"post_id"`
+	Author  string `json:"author"`
+	Created int64  `json:"created"`
+}
+
+// SharedSpace is what the holder of a share link sees of the space
+type SharedSpace struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	LinkName    string       `json:"link_name"`
+	Expires     int64        `json:"expires"`
+	Posts       []SharedPost `json:"posts"`
+}
+
+// SharedPost is a post as shown through a share link, without attachments or internal metadata
+type SharedPost struct {
+	ID      int    `json:"id"`
+	Content string `json:"content"`
+	Created int64  `json:"created"`
+	Author  string `json:"author,omitempty"`
+}
@@ -0,0 +1,74 @@
+package signedurls
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/files/{id:[0-9]+}/signed-url", h.GetSignedURL).Methods("GET")
+	api.HandleFunc("/admin/signed-urls/rotate", h.RotateKey).Methods("POST")
+}
+
+// GetSignedURL handles GET /api/files/{id}/signed-url
+//
+// Query parameters:
+// - ttl: seconds the URL stays valid (default: 3600, max: 30 days)
+func (h *Handler) GetSignedURL(w http.ResponseWriter, r *http.Request) {
+	attachmentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidAttachmentID, http.StatusBadRequest)
+		return
+	}
+
+	ttl := config.DefaultSignedURLTTLSeconds
+	if ttlStr := r.URL.Query().Get("ttl"); ttlStr != "" {
+		t, err := strconv.Atoi(ttlStr)
+		if err != nil || t < 1 || t > config.MaxSignedURLTTLSeconds {
+			http.Error(w, config.ErrInvalidSignedURLTTL, http.StatusBadRequest)
+			return
+		}
+		ttl = t
+	}
+
+	signed, err := h.service.Sign(r.Context(), attachmentID, time.Duration(ttl)*time.Second)
+	if err != nil {
+		if err.Error() == "attachment not found" {
+			http.Error(w, config.ErrAttachmentNotFound, http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(signed)
+}
+
+// RotateKey handles POST /api/admin/signed-urls/rotate, revoking every URL signed so far
+func (h *Handler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.RotateKey(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
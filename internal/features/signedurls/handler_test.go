@@ -0,0 +1,66 @@
+package signedurls
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/files/1/signed-url", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected signed URL routes NOT to be registered when disabled")
+	}
+}
+
+func TestSignedURLHandlers(t *testing.T) {
+	db, cleanup := setupSignedURLsTestDB(t)
+	defer cleanup()
+
+	attachmentID := createAttachment(t, db, "1700000000_diagram.png")
+	service := NewService(db, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{"Default ttl", "GET", fmt.Sprintf("/api/files/%d/signed-url", attachmentID), http.StatusOK},
+		{"Custom ttl", "GET", fmt.Sprintf("/api/files/%d/signed-url?ttl=60", attachmentID), http.StatusOK},
+		{"Zero ttl", "GET", fmt.Sprintf("/api/files/%d/signed-url?ttl=0", attachmentID), http.StatusBadRequest},
+		{"Ttl too long", "GET", fmt.Sprintf("/api/files/%d/signed-url?ttl=2592001", attachmentID), http.StatusBadRequest},
+		{"Unknown attachment", "GET", "/api/files/999/signed-url", http.StatusNotFound},
+		{"Rotate key", "POST", "/api/admin/signed-urls/rotate", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.name == "Custom ttl" {
+				var signed SignedURL
+				json.Unmarshal(w.Body.Bytes(), &signed)
+				if signed.AttachmentID != attachmentID || signed.URL == "" || signed.Expires == 0 {
+					t.Errorf("Unexpected signed URL: %+v", signed)
+				}
+			}
+		})
+	}
+}
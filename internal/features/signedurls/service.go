@@ -0,0 +1,134 @@
+package signedurls
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Query parameters carried by signed URLs
+const (
+	ParamExpires   = "expires"   // Unix seconds
+	ParamSignature = "signature" // Hex HMAC-SHA256 of the file path and expiry
+)
+
+// Service mints expiring URLs for single attachment files, so they can be embedded in
+// external documents without sharing their post. The signing key is read once at startup:
+// checking a URL costs an HMAC and no database access.
+type Service struct {
+	db      *storage.DB
+	access  *services.SpaceAccess
+	key     []byte
+	mu      sync.RWMutex
+	now     func() time.Time
+	enabled bool
+}
+
+func NewService(db *storage.DB, enabled bool) *Service {
+	return &Service{
+		db:      db,
+		now:     time.Now,
+		enabled: enabled,
+	}
+}
+
+// SetSpaceAccess keeps the viewer of a request from signing URLs to the files of spaces it
+// may not read
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// Initialize loads the signing key, creating it on first start
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	key, err := s.db.GetSigningKey(config.SignedURLKeyName)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return s.RotateKey()
+	}
+
+	s.mu.Lock()
+	s.key = key
+	s.mu.Unlock()
+	return nil
+}
+
+// RotateKey replaces the signing key, which invalidates every URL signed so far
+func (s *Service) RotateKey() error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	if err := s.db.SetSigningKey(config.SignedURLKeyName, key); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.key = key
+	s.mu.Unlock()
+	return nil
+}
+
+// Sign returns a URL to the file of an attachment valid for ttl. Attachments of spaces hidden
+// from the viewer are not found.
+func (s *Service) Sign(ctx context.Context, attachmentID int, ttl time.Duration) (*SignedURL, error) {
+	attachment, spaceID, err := s.db.GetAttachment(attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf("attachment not found")
+	}
+
+	expires := s.now().Add(ttl).Unix()
+	query := url.Values{}
+	query.Set(ParamExpires, strconv.FormatInt(expires, 10))
+	query.Set(ParamSignature, hex.EncodeToString(s.mac(attachment.FilePath, expires)))
+
+	return &SignedURL{
+		AttachmentID: attachmentID,
+		URL:          "/uploads/" + url.PathEscape(attachment.FilePath) + "?" + query.Encode(),
+		Expires:      expires * 1000,
+	}, nil
+}
+
+// Verify checks the signature and expiry of a signed URL to the file at filePath
+func (s *Service) Verify(filePath, expires, signature string) error {
+	seconds, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return fmt.Errorf(config.ErrSignedURLInvalid)
+	}
+	provided, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(provided, s.mac(filePath, seconds)) {
+		return fmt.Errorf(config.ErrSignedURLInvalid)
+	}
+	if s.now().Unix() >= seconds {
+		return fmt.Errorf(config.ErrSignedURLExpired)
+	}
+
+	return nil
+}
+
+func (s *Service) mac(filePath string, expires int64) []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(filePath + "\n" + strconv.FormatInt(expires, 10)))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,184 @@
+package signedurls
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupSignedURLsTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_signedurls_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+// createAttachment stores a post with one attachment and returns the attachment ID
+func createAttachment(t *testing.T, db *storage.DB, filePath string) int {
+	space, err := db.CreateSpace("Docs", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	post, err := db.CreatePost(context.Background(), space.ID, "Diagram")
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	attachment, err := db.CreateAttachment(post.ID, "diagram.png", filePath, "image/png", 10)
+	if err != nil {
+		t.Fatalf("Failed to create attachment: %v", err)
+	}
+	return attachment.ID
+}
+
+// splitURL returns the file path and the query of a signed URL
+func splitURL(t *testing.T, signed string) (string, url.Values) {
+	parsed, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("Failed to parse signed URL %q: %v", signed, err)
+	}
+	return strings.TrimPrefix(parsed.Path, "/uploads/"), parsed.Query()
+}
+
+func TestSignAndVerify(t *testing.T) {
+	db, cleanup := setupSignedURLsTestDB(t)
+	defer cleanup()
+
+	attachmentID := createAttachment(t, db, "1700000000_diagram v2.png")
+	service := NewService(db, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	current := time.Unix(1700000000, 0)
+	service.now = func() time.Time { return current }
+
+	signed, err := service.Sign(context.Background(), attachmentID, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	if signed.Expires != current.Add(time.Hour).UnixMilli() || !strings.HasPrefix(signed.URL, "/uploads/1700000000_diagram%20v2.png?") {
+		t.Fatalf("Unexpected signed URL: %+v", signed)
+	}
+
+	filePath, query := splitURL(t, signed.URL)
+	if filePath != "1700000000_diagram v2.png" {
+		t.Fatalf("Expected file path to round-trip, got %q", filePath)
+	}
+
+	tests := []struct {
+		name        string
+		filePath    string
+		expires     string
+		signature   string
+		expectedErr string
+	}{
+		{"Valid", filePath, query.Get(ParamExpires), query.Get(ParamSignature), ""},
+		{"Other file", "other.png", query.Get(ParamExpires), query.Get(ParamSignature), config.ErrSignedURLInvalid},
+		{"Extended expiry", filePath, "9999999999", query.Get(ParamSignature), config.ErrSignedURLInvalid},
+		{"Malformed expiry", filePath, "soon", query.Get(ParamSignature), config.ErrSignedURLInvalid},
+		{"Malformed signature", filePath, query.Get(ParamExpires), "zz", config.ErrSignedURLInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.Verify(tt.filePath, tt.expires, tt.signature)
+			if tt.expectedErr == "" && err != nil {
+				t.Errorf("Expected URL to verify, got %v", err)
+			}
+			if tt.expectedErr != "" && (err == nil || err.Error() != tt.expectedErr) {
+				t.Errorf("Expected %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	current = current.Add(time.Hour)
+	if err := service.Verify(filePath, query.Get(ParamExpires), query.Get(ParamSignature)); err == nil || err.Error() != config.ErrSignedURLExpired {
+		t.Errorf("Expected URL to expire, got %v", err)
+	}
+
+	if _, err := service.Sign(context.Background(), 999, time.Hour); err == nil {
+		t.Error("Expected signing an unknown attachment to fail")
+	}
+}
+
+func TestSignHiddenSpaces(t *testing.T) {
+	db, cleanup := setupSignedURLsTestDB(t)
+	defer cleanup()
+
+	attachmentID := createAttachment(t, db, "1700000000_diagram.png")
+	_, spaceID, _ := db.GetAttachment(attachmentID)
+	space, _ := db.GetSpace(spaceID)
+	spaceCache := cache.NewSpaceCache()
+	spaceCache.Set(space)
+
+	alice, _ := db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	bob, _ := db.ProvisionUser("bob", "", "Bob", models.RoleViewer, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(db, spaceCache)
+	if err := access.SetACL(spaceID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+	service := NewService(db, true)
+	service.SetSpaceAccess(access)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	if _, err := service.Sign(services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID}), attachmentID, time.Hour); err != nil {
+		t.Errorf("Expected a listed viewer to sign the URL, got %v", err)
+	}
+	if _, err := service.Sign(services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID}), attachmentID, time.Hour); err == nil || err.Error() != "attachment not found" {
+		t.Errorf("Expected the attachment to be hidden from an unlisted viewer, got %v", err)
+	}
+}
+
+func TestSigningKeyLifecycle(t *testing.T) {
+	db, cleanup := setupSignedURLsTestDB(t)
+	defer cleanup()
+
+	attachmentID := createAttachment(t, db, "1700000000_diagram.png")
+	service := NewService(db, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	signed, _ := service.Sign(context.Background(), attachmentID, time.Hour)
+	filePath, query := splitURL(t, signed.URL)
+
+	// The key survives a restart
+	restarted := NewService(db, true)
+	if err := restarted.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize after restart: %v", err)
+	}
+	if err := restarted.Verify(filePath, query.Get(ParamExpires), query.Get(ParamSignature)); err != nil {
+		t.Errorf("Expected URL to stay valid after a restart, got %v", err)
+	}
+
+	// Rotating the key revokes the URLs signed before
+	if err := restarted.RotateKey(); err != nil {
+		t.Fatalf("Failed to rotate key: %v", err)
+	}
+	if err := restarted.Verify(filePath, query.Get(ParamExpires), query.Get(ParamSignature)); err == nil {
+		t.Error("Expected URL to be revoked by the key rotation")
+	}
+}
@@ -0,0 +1,8 @@
+package signedurls
+
+// SignedURL is an expiring link to one attachment file
+type SignedURL struct {
+	AttachmentID int    `json:"attachment_id"`
+	URL          string `json:"url"`     // Path of the file under /uploads with its expiry and signature
+	Expires      int64  `json:"expires"` // Milliseconds
+}
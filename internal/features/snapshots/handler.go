@@ -0,0 +1,177 @@
+package snapshots
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/{id:[0-9]+}/snapshots", h.ListSnapshots).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/snapshots", h.CreateSnapshot).Methods("POST")
+	api.HandleFunc("/spaces/{id:[0-9]+}/snapshot-schedule", h.GetSchedule).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/snapshot-schedule", h.SetSchedule).Methods("PUT")
+	api.HandleFunc("/snapshots/{id:[0-9]+}", h.DeleteSnapshot).Methods("DELETE")
+	api.HandleFunc("/snapshots/{id:[0-9]+}/restore", h.RestoreSnapshot).Methods("POST")
+}
+
+// ListSnapshots handles GET /api/spaces/{id}/snapshots
+func (h *Handler) ListSnapshots(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	snapshots, err := h.service.List(r.Context(), spaceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshots)
+}
+
+// CreateSnapshot handles POST /api/spaces/{id}/snapshots
+func (h *Handler) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	snapshot, err := h.service.Create(r.Context(), spaceID, req.Name)
+	if err != nil {
+		h.writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// DeleteSnapshot handles DELETE /api/snapshots/{id}
+func (h *Handler) DeleteSnapshot(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSnapshotID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		h.writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreSnapshot handles POST /api/snapshots/{id}/restore
+func (h *Handler) RestoreSnapshot(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSnapshotID, http.StatusBadRequest)
+		return
+	}
+
+	var req RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	// Restores fail mostly on spaces that cannot be recreated, e.g. a name already in use
+	result, err := h.service.Restore(r.Context(), id, req)
+	if err != nil {
+		h.writeError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetSchedule handles GET /api/spaces/{id}/snapshot-schedule
+func (h *Handler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.service.GetSchedule(r.Context(), spaceID)
+	if err != nil {
+		h.writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// SetSchedule handles PUT /api/spaces/{id}/snapshot-schedule
+func (h *Handler) SetSchedule(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.service.SetSchedule(r.Context(), spaceID, req.IntervalHours, req.Keep)
+	if err != nil {
+		h.writeError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// writeError maps service errors to status codes; other errors get fallback
+func (h *Handler) writeError(w http.ResponseWriter, err error, fallback int) {
+	switch msg := err.Error(); msg {
+	case config.ErrSpaceNotFound, config.ErrSnapshotNotFound:
+		http.Error(w, msg, http.StatusNotFound)
+	case config.ErrSpaceAccessDenied:
+		http.Error(w, msg, http.StatusForbidden)
+	case config.ErrSnapshotSpaceGone:
+		http.Error(w, msg, http.StatusConflict)
+	case config.ErrSnapshotNameRequired, config.ErrInvalidSnapshotSchedule, config.ErrInvalidRestoreMode,
+		config.ErrRestoreNameRequired, config.ErrSnapshotTooDeep,
+		fmt.Sprintf(config.ErrFmtSnapshotLimitReached, h.service.maxPerSpace):
+		http.Error(w, msg, http.StatusBadRequest)
+	default:
+		http.Error(w, msg, fallback)
+	}
+}
@@ -0,0 +1,95 @@
+package snapshots
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	setup, cleanup := setupSnapshotTest(t)
+	defer cleanup()
+
+	service := NewService(setup.db, nil, setup.spaces, setup.posts, setup.files, false, 0)
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/spaces/1/snapshots", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected snapshot routes NOT to be registered when disabled")
+	}
+}
+
+func TestSnapshotHandlers(t *testing.T) {
+	setup, cleanup := setupSnapshotTest(t)
+	defer cleanup()
+
+	root := setup.seed(t)
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+	spacePath := fmt.Sprintf("/api/spaces/%d", root.ID)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"Create", "POST", spacePath + "/snapshots", `{"name":"One"}`, http.StatusCreated},
+		{"Create second", "POST", spacePath + "/snapshots", `{"name":"Two"}`, http.StatusCreated},
+		{"Limit reached", "POST", spacePath + "/snapshots", `{"name":"Three"}`, http.StatusBadRequest},
+		{"Missing name", "POST", spacePath + "/snapshots", `{}`, http.StatusBadRequest},
+		{"Invalid JSON", "POST", spacePath + "/snapshots", `{`, http.StatusBadRequest},
+		{"Unknown space", "POST", "/api/spaces/9999/snapshots", `{"name":"One"}`, http.StatusNotFound},
+		{"Invalid restore mode", "POST", "/api/snapshots/1/restore", `{"mode":"merge"}`, http.StatusBadRequest},
+		{"Restore unknown snapshot", "POST", "/api/snapshots/9999/restore", `{"mode":"new","name":"Copy"}`, http.StatusNotFound},
+		{"Restore", "POST", "/api/snapshots/1/restore", `{"mode":"new","name":"Copy"}`, http.StatusOK},
+		{"Restore name taken", "POST", "/api/snapshots/1/restore", `{"mode":"new","name":"Copy"}`, http.StatusBadRequest},
+		{"Set schedule", "PUT", spacePath + "/snapshot-schedule", `{"interval_hours":12}`, http.StatusOK},
+		{"Invalid schedule", "PUT", spacePath + "/snapshot-schedule", `{"interval_hours":100000}`, http.StatusBadRequest},
+		{"Schedule unknown space", "GET", "/api/spaces/9999/snapshot-schedule", "", http.StatusNotFound},
+		{"Delete", "DELETE", "/api/snapshots/2", "", http.StatusNoContent},
+		{"Delete again", "DELETE", "/api/snapshots/2", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.method, tt.path, tt.body)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := serve("GET", spacePath+"/snapshots", "")
+	var snapshots []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &snapshots); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0]["name"] != "One" {
+		t.Errorf("Expected the remaining snapshot, got %v", snapshots)
+	}
+	if _, ok := snapshots[0]["manifest"]; ok {
+		t.Error("Expected the manifest not to be listed")
+	}
+
+	w = serve("GET", spacePath+"/snapshot-schedule", "")
+	var schedule map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &schedule)
+	if schedule["interval_hours"] != float64(12) || schedule["keep"] != float64(config.DefaultScheduledSnapshotsKept) {
+		t.Errorf("Unexpected schedule: %v", schedule)
+	}
+}
@@ -0,0 +1,732 @@
+package snapshots
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/jobs"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service takes and restores point-in-time snapshots of space subtrees. Attachment files are
+// kept once per content hash in the snapshot store, shared by every snapshot referring to them.
+type Service struct {
+	db          *storage.DB
+	catCache    *cache.SpaceCache
+	spaces      *services.SpaceService
+	posts       *services.PostService
+	files       *services.FileService
+	dispatcher  *events.Dispatcher
+	access      *services.SpaceAccess
+	maxPerSpace int
+	uploadsDir  string
+	storeDir    string
+	mu          sync.Mutex // Serializes snapshots, deletions and restores
+	budget      *jobs.Budget
+	stop        chan struct{}
+	now         func() time.Time
+	enabled     bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, spaces *services.SpaceService, posts *services.PostService, files *services.FileService, enabled bool, maxPerSpace int) *Service {
+	if maxPerSpace <= 0 {
+		maxPerSpace = config.DefaultMaxSnapshotsPerSpace
+	}
+
+	return &Service{
+		db:          db,
+		catCache:    catCache,
+		spaces:      spaces,
+		posts:       posts,
+		files:       files,
+		maxPerSpace: maxPerSpace,
+		uploadsDir:  filepath.Join(config.GetServiceConfig().Files.StoragePath, config.GetServiceConfig().Files.UploadsSubdir),
+		storeDir:    filepath.Join(db.GetStoragePath(), config.SnapshotStoreSubdir),
+		now:         time.Now,
+		enabled:     enabled,
+	}
+}
+
+// SetDispatcher lets the service raise a notification when a scheduled snapshot fails
+func (s *Service) SetDispatcher(dispatcher *events.Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// SetSpaceAccess hides the snapshots of spaces hidden from the viewer of a request. Taking,
+// scheduling, deleting and restoring snapshots cover a whole subtree, so they need write access
+// to every space of it.
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// List returns the snapshots of a space, newest first. Snapshots of deleted spaces are still listed.
+func (s *Service) List(ctx context.Context, spaceID int) ([]models.SpaceSnapshot, error) {
+	if err := s.checkTree(ctx, spaceID, false); err != nil {
+		return nil, err
+	}
+	return s.db.GetSnapshotsBySpace(spaceID)
+}
+
+// Create snapshots a space and its descendants
+func (s *Service) Create(ctx context.Context, spaceID int, name string) (*models.SpaceSnapshot, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > config.MaxSnapshotNameLength {
+		return nil, fmt.Errorf(config.ErrSnapshotNameRequired)
+	}
+	if err := s.checkTree(ctx, spaceID, true); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.db.GetSnapshotsBySpace(spaceID)
+	if err != nil {
+		return nil, err
+	}
+	manual := 0
+	for _, snapshot := range existing {
+		if !snapshot.Scheduled {
+			manual++
+		}
+	}
+	if manual >= s.maxPerSpace {
+		return nil, fmt.Errorf(config.ErrFmtSnapshotLimitReached, s.maxPerSpace)
+	}
+
+	return s.take(ctx, spaceID, name, false)
+}
+
+// take reads the space subtree, copies its attachment files to the snapshot store and saves
+// the snapshot. The caller holds s.mu.
+func (s *Service) take(ctx context.Context, spaceID int, name string, scheduled bool) (*models.SpaceSnapshot, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	spaceIDs := append([]int{spaceID}, s.catCache.GetDescendants(spaceID)...)
+	manifest := models.SnapshotManifest{Spaces: []models.Space{}, Posts: []models.SnapshotPost{}}
+	for _, id := range spaceIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		space, err := s.db.GetSpace(id)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Spaces = append(manifest.Spaces, *space)
+	}
+	sort.SliceStable(manifest.Spaces, func(i, j int) bool {
+		return manifest.Spaces[i].Depth < manifest.Spaces[j].Depth
+	})
+
+	snapshot := &models.SpaceSnapshot{
+		SpaceID:    spaceID,
+		Name:       name,
+		Scheduled:  scheduled,
+		SpaceCount: len(manifest.Spaces),
+		Created:    s.now().UnixMilli(),
+	}
+
+	hashes := make(map[string]bool)
+	for _, space := range manifest.Spaces {
+		postIDs, err := s.db.GetPostIDsBySpace(space.ID)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := s.db.GetPostFieldsByPosts(postIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, postID := range postIDs {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			post, err := s.db.GetPost(postID)
+			if err != nil {
+				return nil, err
+			}
+			post.Fields = fields[postID]
+
+			linkPreviews, err := s.db.GetLinkPreviewsByPostID(postID)
+			if err != nil {
+				return nil, err
+			}
+			entry := models.SnapshotPost{Post: *post, LinkPreviews: linkPreviews}
+
+			attachments, err := s.db.GetAttachmentsByPost(postID)
+			if err != nil {
+				return nil, err
+			}
+			for _, attachment := range attachments {
+				kept, err := s.keepFile(attachment)
+				if err != nil {
+					// The upload is already gone; the snapshot goes on without it
+					logger.Warning("Skipping attachment missing from uploads", zap.Int("attachment_id", attachment.ID), zap.Error(err))
+					continue
+				}
+				entry.Attachments = append(entry.Attachments, *kept)
+				hashes[kept.SHA256] = true
+				snapshot.AttachmentCount++
+				snapshot.Size += kept.FileSize
+			}
+
+			manifest.Posts = append(manifest.Posts, entry)
+		}
+	}
+	sort.SliceStable(manifest.Posts, func(i, j int) bool {
+		return manifest.Posts[i].Created < manifest.Posts[j].Created
+	})
+	snapshot.PostCount = len(manifest.Posts)
+
+	payload, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Manifest = string(payload)
+
+	hashList := make([]string, 0, len(hashes))
+	for hash := range hashes {
+		hashList = append(hashList, hash)
+	}
+	if err := s.db.CreateSnapshot(snapshot, hashList); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Space snapshot taken", zap.Int("space_id", spaceID), zap.Int("snapshot_id", snapshot.ID),
+		zap.Int("posts", snapshot.PostCount), zap.Int("attachments", snapshot.AttachmentCount))
+	return snapshot, nil
+}
+
+// keepFile copies an attachment file to the snapshot store under its hash, unless a previous
+// snapshot already did
+func (s *Service) keepFile(attachment models.Attachment) (*models.SnapshotAttachment, error) {
+	withChecksum, err := s.files.GetDownload(attachment.FilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := &models.SnapshotAttachment{
+		Filename: attachment.Filename,
+		FileType: attachment.FileType,
+		FileSize: attachment.FileSize,
+		SHA256:   withChecksum.SHA256,
+	}
+
+	target := s.storePath(kept.SHA256)
+	if _, err := os.Stat(target); err == nil {
+		return kept, nil
+	}
+	if err := os.MkdirAll(s.storeDir, config.DirectoryPermissions); err != nil {
+		return nil, err
+	}
+
+	source := filepath.Join(s.uploadsDir, attachment.FilePath)
+	if err := os.Link(source, target); err == nil {
+		return kept, nil
+	}
+
+	// Hard links fail across filesystems; fall back to a copy
+	if err := copyFile(source, target); err != nil {
+		return nil, err
+	}
+	return kept, nil
+}
+
+func (s *Service) storePath(hash string) string {
+	return filepath.Join(s.storeDir, hash)
+}
+
+// copyFile copies src to dst through a temporary file so dst is never left half written
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// Delete removes a snapshot and the stored files no other snapshot refers to
+func (s *Service) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if snapshot, found, err := s.db.GetSnapshot(id); err != nil {
+		return err
+	} else if found {
+		if err := s.checkSnapshot(ctx, snapshot, true); err != nil {
+			return err
+		}
+	}
+	return s.delete(id)
+}
+
+func (s *Service) delete(id int) error {
+	if _, found, err := s.db.GetSnapshot(id); err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf(config.ErrSnapshotNotFound)
+	}
+
+	orphans, err := s.db.DeleteSnapshot(id)
+	if err != nil {
+		return err
+	}
+	for _, hash := range orphans {
+		if err := os.Remove(s.storePath(hash)); err != nil && !os.IsNotExist(err) {
+			logger.Warning("Failed to remove snapshot file", zap.String("sha256", hash), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// Restore recreates the content of a snapshot, either as a new space subtree or in place of
+// the current content of the snapshot space. Replaced posts and spaces go through the regular
+// deletion, so they end up in the trash when it is enabled.
+func (s *Service) Restore(ctx context.Context, id int, req RestoreRequest) (*RestoreResult, error) {
+	if req.Mode != RestoreModeNew && req.Mode != RestoreModeReplace {
+		return nil, fmt.Errorf(config.ErrInvalidRestoreMode)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot, found, err := s.db.GetSnapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf(config.ErrSnapshotNotFound)
+	}
+	if err := s.checkSnapshot(ctx, snapshot, req.Mode == RestoreModeReplace); err != nil {
+		return nil, err
+	}
+	if req.Mode == RestoreModeNew && req.ParentID != nil {
+		if !s.access.CanRead(ctx, *req.ParentID) {
+			return nil, fmt.Errorf(config.ErrSpaceNotFound)
+		}
+		if !s.access.CanWrite(ctx, *req.ParentID) {
+			return nil, fmt.Errorf(config.ErrSpaceAccessDenied)
+		}
+	}
+
+	var manifest models.SnapshotManifest
+	if err := json.Unmarshal([]byte(snapshot.Manifest), &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot manifest: %w", err)
+	}
+	if len(manifest.Spaces) == 0 {
+		return nil, fmt.Errorf("snapshot %d has no spaces", id)
+	}
+
+	// Check everything that could fail before touching existing content
+	for _, post := range manifest.Posts {
+		for _, attachment := range post.Attachments {
+			if _, err := os.Stat(s.storePath(attachment.SHA256)); err != nil {
+				return nil, fmt.Errorf(config.ErrFmtSnapshotFileMissing, attachment.Filename)
+			}
+		}
+	}
+
+	root := manifest.Spaces[0]
+	height := 0
+	for _, space := range manifest.Spaces {
+		height = max(height, space.Depth-root.Depth)
+	}
+
+	// A new copy can be dropped when ctx is cancelled halfway; replaced content cannot be put
+	// back, so once it starts being cleared the restore runs to the end
+	var target *models.Space
+	if req.Mode == RestoreModeNew {
+		target, err = s.createRoot(ctx, root, req, height)
+	} else {
+		ctx = context.WithoutCancel(ctx)
+		target, err = s.clearRoot(ctx, snapshot.SpaceID, root, height)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.recreate(ctx, target, manifest)
+	if err != nil && req.Mode == RestoreModeNew {
+		// Drop the partial copy; nothing existing was touched
+		if cleanupErr := s.spaces.Delete(context.WithoutCancel(ctx), target.ID); cleanupErr != nil {
+			logger.Warning("Failed to remove partially restored space", zap.Int("space_id", target.ID), zap.Error(cleanupErr))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Space snapshot restored", zap.Int("snapshot_id", id), zap.String("mode", req.Mode), zap.Int("space_id", target.ID))
+	return result, nil
+}
+
+// checkTree fails when the viewer of ctx may not read a space, or, when write is set, change it
+// and each of its descendants. Hidden spaces are not found.
+func (s *Service) checkTree(ctx context.Context, spaceID int, write bool) error {
+	if !s.access.CanRead(ctx, spaceID) {
+		return fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if !write {
+		return nil
+	}
+	for _, id := range append([]int{spaceID}, s.catCache.GetDescendants(spaceID)...) {
+		if !s.access.CanWrite(ctx, id) {
+			return fmt.Errorf(config.ErrSpaceAccessDenied)
+		}
+	}
+	return nil
+}
+
+// checkSnapshot is checkTree for the space of a snapshot; snapshots of hidden spaces are not
+// found. The access lists of a deleted space are gone with it, so its snapshots are not limited.
+func (s *Service) checkSnapshot(ctx context.Context, snapshot *models.SpaceSnapshot, write bool) error {
+	if err := s.checkTree(ctx, snapshot.SpaceID, write); err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			return fmt.Errorf(config.ErrSnapshotNotFound)
+		}
+		return err
+	}
+	return nil
+}
+
+// createRoot creates the root space of a restore into a new subtree
+func (s *Service) createRoot(ctx context.Context, root models.Space, req RestoreRequest, height int) (*models.Space, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf(config.ErrRestoreNameRequired)
+	}
+
+	depth := 0
+	if req.ParentID != nil {
+		parent, ok := s.catCache.Get(*req.ParentID)
+		if !ok {
+			return nil, fmt.Errorf(config.ErrSpaceNotFound)
+		}
+		depth = parent.Depth + 1
+	}
+	if depth+height > config.MaxSpaceDepth {
+		return nil, fmt.Errorf(config.ErrSnapshotTooDeep)
+	}
+
+	return s.spaces.Create(ctx, name, req.ParentID, root.Description)
+}
+
+// clearRoot restores the name and description of the snapshot space and deletes its current
+// posts and subspaces
+func (s *Service) clearRoot(ctx context.Context, spaceID int, root models.Space, height int) (*models.Space, error) {
+	current, ok := s.catCache.Get(spaceID)
+	if !ok {
+		return nil, fmt.Errorf(config.ErrSnapshotSpaceGone)
+	}
+	if current.Depth+height > config.MaxSpaceDepth {
+		return nil, fmt.Errorf(config.ErrSnapshotTooDeep)
+	}
+
+	target, err := s.spaces.Update(ctx, spaceID, root.Name, root.Description, current.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, childID := range s.catCache.GetDescendants(spaceID) {
+		child, ok := s.catCache.Get(childID)
+		if !ok || child.ParentID == nil || *child.ParentID != spaceID {
+			continue
+		}
+		if err := s.spaces.Delete(ctx, childID); err != nil {
+			return nil, err
+		}
+	}
+
+	postIDs, err := s.db.GetPostIDsBySpace(spaceID)
+	if err != nil {
+		return nil, err
+	}
+	for _, postID := range postIDs {
+		if err := s.posts.Delete(ctx, postID); err != nil {
+			return nil, err
+		}
+	}
+
+	return target, nil
+}
+
+// recreate creates the subspaces and posts of a manifest under target
+func (s *Service) recreate(ctx context.Context, target *models.Space, manifest models.SnapshotManifest) (*RestoreResult, error) {
+	result := &RestoreResult{SpaceID: target.ID, Spaces: 1}
+
+	spaceIDs := map[int]int{manifest.Spaces[0].ID: target.ID}
+	for _, space := range manifest.Spaces[1:] {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		parentID, ok := spaceIDs[*space.ParentID]
+		if !ok {
+			return nil, fmt.Errorf("snapshot space %d has no restored parent", space.ID)
+		}
+		created, err := s.spaces.Create(ctx, space.Name, &parentID, space.Description)
+		if err != nil {
+			return nil, err
+		}
+		spaceIDs[space.ID] = created.ID
+		result.Spaces++
+	}
+
+	for _, entry := range manifest.Posts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		spaceID := spaceIDs[entry.SpaceID]
+		created := entry.Created
+		source := entry.Source
+		if source == "" {
+			source = models.PostSourceManual
+		}
+
+		var post *models.Post
+		var err error
+		if entry.Type == models.PostTypeJournal {
+			post, _, err = s.posts.CreateJournal(ctx, spaceID, entry.Content, &created, source)
+		} else {
+			post, err = s.posts.CreateWithSource(ctx, spaceID, entry.Content, &created, source)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Posts++
+
+		if len(entry.Fields) > 0 {
+			if err := s.db.SetPostFields(post.ID, entry.Fields); err != nil {
+				return nil, err
+			}
+		}
+		for _, preview := range entry.LinkPreviews {
+			if err := s.files.SaveLinkPreview(post.ID, preview); err != nil {
+				logger.Warning("Failed to restore link preview", zap.Int("post_id", post.ID), zap.Error(err))
+			}
+		}
+		for _, attachment := range entry.Attachments {
+			if err := s.restoreFile(ctx, post.ID, attachment); err != nil {
+				return nil, err
+			}
+			result.Attachments++
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Service) restoreFile(ctx context.Context, postID int, attachment models.SnapshotAttachment) error {
+	file, err := os.Open(s.storePath(attachment.SHA256))
+	if err != nil {
+		return fmt.Errorf(config.ErrFmtSnapshotFileMissing, attachment.Filename)
+	}
+	defer file.Close()
+
+	_, err = s.files.UploadFile(ctx, postID, file, attachment.Filename, attachment.FileSize)
+	return err
+}
+
+// GetSchedule returns the snapshot schedule of a space; a zero interval means none
+func (s *Service) GetSchedule(ctx context.Context, spaceID int) (models.SnapshotSchedule, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok {
+		return models.SnapshotSchedule{}, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if err := s.checkTree(ctx, spaceID, false); err != nil {
+		return models.SnapshotSchedule{}, err
+	}
+
+	schedules, err := s.db.GetSnapshotSchedules()
+	if err != nil {
+		return models.SnapshotSchedule{}, err
+	}
+	for _, schedule := range schedules {
+		if schedule.SpaceID == spaceID {
+			return schedule, nil
+		}
+	}
+
+	return models.SnapshotSchedule{SpaceID: spaceID}, nil
+}
+
+// SetSchedule makes a space snapshot itself every intervalHours, keeping the last keep
+// scheduled snapshots; a zero interval removes the schedule
+func (s *Service) SetSchedule(ctx context.Context, spaceID, intervalHours, keep int) (models.SnapshotSchedule, error) {
+	if keep == 0 {
+		keep = config.DefaultScheduledSnapshotsKept
+	}
+	if intervalHours < 0 || intervalHours > config.MaxSnapshotIntervalHours || keep < 1 || keep > config.MaxScheduledSnapshotsKept {
+		return models.SnapshotSchedule{}, fmt.Errorf(config.ErrInvalidSnapshotSchedule)
+	}
+	if _, ok := s.catCache.Get(spaceID); !ok {
+		return models.SnapshotSchedule{}, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+	if err := s.checkTree(ctx, spaceID, true); err != nil {
+		return models.SnapshotSchedule{}, err
+	}
+
+	schedule := models.SnapshotSchedule{SpaceID: spaceID, IntervalHours: intervalHours, Keep: keep}
+	if err := s.db.SetSnapshotSchedule(schedule); err != nil {
+		return models.SnapshotSchedule{}, err
+	}
+
+	return s.GetSchedule(ctx, spaceID)
+}
+
+// RunSchedules takes the scheduled snapshots that are due and drops the scheduled snapshots
+// beyond what each schedule keeps. It returns how many snapshots were taken.
+func (s *Service) RunSchedules(ctx context.Context) (int, error) {
+	if !s.enabled {
+		return 0, nil
+	}
+
+	schedules, err := s.db.GetSnapshotSchedules()
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	taken := 0
+	for _, schedule := range schedules {
+		interval := time.Duration(schedule.IntervalHours) * time.Hour
+		if schedule.LastRun > 0 && now.Sub(time.UnixMilli(schedule.LastRun)) < interval {
+			continue
+		}
+
+		if err := s.db.MarkSnapshotScheduleRun(schedule.SpaceID, now.UnixMilli()); err != nil {
+			return taken, err
+		}
+
+		name := "Scheduled " + now.Format("2006-01-02 15:04")
+		if _, err := s.take(ctx, schedule.SpaceID, name, true); err != nil {
+			logger.Warning("Failed to take scheduled snapshot", zap.Int("space_id", schedule.SpaceID), zap.Error(err))
+			s.dispatcher.Notify(events.NotificationEvent{
+				Kind:    models.NotificationSyncFailure,
+				Title:   "Scheduled snapshot failed",
+				Message: fmt.Sprintf("The scheduled snapshot of the space could not be taken (%v). It is tried again at the next interval.", err),
+				SpaceID: schedule.SpaceID,
+				Key:     fmt.Sprintf("snapshots.schedule.%d", schedule.SpaceID),
+			})
+			continue
+		}
+		taken++
+
+		if err := s.prune(schedule.SpaceID, schedule.Keep); err != nil {
+			logger.Warning("Failed to prune scheduled snapshots", zap.Int("space_id", schedule.SpaceID), zap.Error(err))
+		}
+	}
+
+	return taken, nil
+}
+
+// prune deletes the oldest scheduled snapshots of a space beyond keep
+func (s *Service) prune(spaceID, keep int) error {
+	snapshots, err := s.db.GetSnapshotsBySpace(spaceID)
+	if err != nil {
+		return err
+	}
+
+	kept := 0
+	for _, snapshot := range snapshots {
+		if !snapshot.Scheduled {
+			continue
+		}
+		kept++
+		if kept > keep {
+			if err := s.delete(snapshot.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// StartScheduler runs the due schedules immediately and then once per interval until Stop is called
+func (s *Service) StartScheduler(interval time.Duration) {
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	s.runSchedules()
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.runSchedules()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// SetBudget makes the schedule loop share the resource budget of background jobs
+func (s *Service) SetBudget(budget *jobs.Budget) {
+	s.budget = budget
+}
+
+func (s *Service) runSchedules() {
+	ctx := context.Background()
+	var count int
+	err := s.budget.Run(ctx, "snapshot-schedules", func(job *jobs.Job) error {
+		var err error
+		count, err = s.RunSchedules(ctx)
+		return err
+	})
+	if err != nil {
+		logger.Warning("Failed to run snapshot schedules", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		logger.Info("Took scheduled space snapshots", zap.Int("count", count))
+	}
+}
+
+// Stop ends the schedule loop
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
@@ -0,0 +1,337 @@
+package snapshots
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type snapshotTestSetup struct {
+	db      *storage.DB
+	dir     string
+	spaces  *services.SpaceService
+	posts   *services.PostService
+	files   *services.FileService
+	service *Service
+}
+
+func setupSnapshotTest(t *testing.T) (*snapshotTestSetup, func()) {
+	tempDir, err := os.MkdirTemp("", "backthynk_snapshots_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	testConfig.Files.StoragePath = tempDir
+	testConfig.Files.UploadsSubdir = "uploads"
+	config.SetServiceConfigForTest(testConfig)
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	spaceCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	spaceService := services.NewSpaceService(db, spaceCache, dispatcher)
+	postService := services.NewPostService(db, spaceCache, dispatcher)
+	fileService := services.NewFileService(db, dispatcher)
+	if err := spaceService.InitializeCache(); err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to initialize cache: %v", err)
+	}
+
+	setup := &snapshotTestSetup{
+		db:      db,
+		dir:     tempDir,
+		spaces:  spaceService,
+		posts:   postService,
+		files:   fileService,
+		service: NewService(db, spaceCache, spaceService, postService, fileService, true, 2),
+	}
+
+	return setup, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+// seed creates a space with a subspace, two posts and one attachment
+func (setup *snapshotTestSetup) seed(t *testing.T) *models.Space {
+	root, err := setup.spaces.Create(context.Background(), "Research", nil, "Reading notes")
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	child, err := setup.spaces.Create(context.Background(), "Papers", &root.ID, "")
+	if err != nil {
+		t.Fatalf("Failed to create subspace: %v", err)
+	}
+
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC).UnixMilli()
+	if _, err := setup.posts.Create(context.Background(), root.ID, "First idea", &created); err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	post, err := setup.posts.Create(context.Background(), child.ID, "Paper summary", nil)
+	if err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	if _, err := setup.files.UploadFile(context.Background(), post.ID, strings.NewReader("pdf bytes"), "paper.pdf", 9); err != nil {
+		t.Fatalf("Failed to upload file: %v", err)
+	}
+
+	return root
+}
+
+func storedFiles(t *testing.T, dir string) int {
+	entries, err := os.ReadDir(filepath.Join(dir, config.SnapshotStoreSubdir))
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatalf("Failed to read snapshot store: %v", err)
+	}
+	return len(entries)
+}
+
+func TestCreateAndDeleteSnapshot(t *testing.T) {
+	setup, cleanup := setupSnapshotTest(t)
+	defer cleanup()
+
+	root := setup.seed(t)
+
+	if _, err := setup.service.Create(context.Background(), root.ID, "  "); err == nil || err.Error() != config.ErrSnapshotNameRequired {
+		t.Fatalf("Expected name required error, got %v", err)
+	}
+	if _, err := setup.service.Create(context.Background(), 9999, "Missing"); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Fatalf("Expected space not found error, got %v", err)
+	}
+
+	first, err := setup.service.Create(context.Background(), root.ID, "Before cleanup")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if first.SpaceCount != 2 || first.PostCount != 2 || first.AttachmentCount != 1 || first.Size != 9 {
+		t.Errorf("Unexpected snapshot counts: %+v", first)
+	}
+
+	second, err := setup.service.Create(context.Background(), root.ID, "Again")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if count := storedFiles(t, setup.dir); count != 1 {
+		t.Errorf("Expected the attachment to be stored once, got %d files", count)
+	}
+
+	if _, err := setup.service.Create(context.Background(), root.ID, "Over the limit"); err == nil || err.Error() != fmt.Sprintf(config.ErrFmtSnapshotLimitReached, 2) {
+		t.Errorf("Expected limit error, got %v", err)
+	}
+
+	list, err := setup.service.List(context.Background(), root.ID)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("Expected 2 snapshots, got %d (%v)", len(list), err)
+	}
+
+	if err := setup.service.Delete(context.Background(), first.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if count := storedFiles(t, setup.dir); count != 1 {
+		t.Errorf("Expected the shared file to be kept, got %d files", count)
+	}
+	if err := setup.service.Delete(context.Background(), second.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if count := storedFiles(t, setup.dir); count != 0 {
+		t.Errorf("Expected the orphan file to be removed, got %d files", count)
+	}
+
+	if err := setup.service.Delete(context.Background(), second.ID); err == nil || err.Error() != config.ErrSnapshotNotFound {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestRestoreAsNewSpace(t *testing.T) {
+	setup, cleanup := setupSnapshotTest(t)
+	defer cleanup()
+
+	root := setup.seed(t)
+	snapshot, err := setup.service.Create(context.Background(), root.ID, "Baseline")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if _, err := setup.service.Restore(context.Background(), snapshot.ID, RestoreRequest{Mode: "merge"}); err == nil || err.Error() != config.ErrInvalidRestoreMode {
+		t.Errorf("Expected invalid mode error, got %v", err)
+	}
+	if _, err := setup.service.Restore(context.Background(), snapshot.ID, RestoreRequest{Mode: RestoreModeNew}); err == nil || err.Error() != config.ErrRestoreNameRequired {
+		t.Errorf("Expected name required error, got %v", err)
+	}
+
+	result, err := setup.service.Restore(context.Background(), snapshot.ID, RestoreRequest{Mode: RestoreModeNew, Name: "Research copy"})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if result.Spaces != 2 || result.Posts != 2 || result.Attachments != 1 {
+		t.Errorf("Unexpected restore result: %+v", result)
+	}
+
+	restored, err := setup.spaces.Get(result.SpaceID)
+	if err != nil {
+		t.Fatalf("Failed to get restored space: %v", err)
+	}
+	if restored.Name != "Research copy" || restored.Description != "Reading notes" || restored.ParentID != nil {
+		t.Errorf("Unexpected restored space: %+v", restored)
+	}
+
+	postIDs, _ := setup.db.GetPostIDsBySpace(result.SpaceID)
+	if len(postIDs) != 1 {
+		t.Fatalf("Expected 1 post in the restored root, got %d", len(postIDs))
+	}
+	post, _ := setup.db.GetPost(postIDs[0])
+	if post.Content != "First idea" || post.Created != time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC).UnixMilli() {
+		t.Errorf("Expected original content and timestamp, got %q at %d", post.Content, post.Created)
+	}
+
+	// The original subtree is untouched
+	postIDs, _ = setup.db.GetPostIDsBySpace(root.ID)
+	if len(postIDs) != 1 {
+		t.Errorf("Expected the original space to keep its post, got %d", len(postIDs))
+	}
+}
+
+func TestRestoreReplace(t *testing.T) {
+	setup, cleanup := setupSnapshotTest(t)
+	defer cleanup()
+
+	root := setup.seed(t)
+	snapshot, err := setup.service.Create(context.Background(), root.ID, "Baseline")
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Change the space after the snapshot
+	if _, err := setup.spaces.Update(context.Background(), root.ID, "Renamed", "", nil); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, err := setup.spaces.Create(context.Background(), "Scratch", &root.ID, ""); err != nil {
+		t.Fatalf("Failed to create subspace: %v", err)
+	}
+	if _, err := setup.posts.Create(context.Background(), root.ID, "Later thought", nil); err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	result, err := setup.service.Restore(context.Background(), snapshot.ID, RestoreRequest{Mode: RestoreModeReplace})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if result.SpaceID != root.ID || result.Spaces != 2 || result.Posts != 2 || result.Attachments != 1 {
+		t.Errorf("Unexpected restore result: %+v", result)
+	}
+
+	restored, _ := setup.spaces.Get(root.ID)
+	if restored.Name != "Research" || restored.Description != "Reading notes" {
+		t.Errorf("Expected name and description to be restored, got %+v", restored)
+	}
+
+	spaces := setup.spaces.GetAll()
+	names := map[string]bool{}
+	for _, space := range spaces {
+		names[space.Name] = true
+	}
+	if names["Scratch"] || !names["Papers"] || len(spaces) != 2 {
+		t.Errorf("Expected only the snapshot subtree to remain, got %v", names)
+	}
+
+	postIDs, _ := setup.db.GetPostIDsBySpace(root.ID)
+	if len(postIDs) != 1 {
+		t.Fatalf("Expected 1 post after replace, got %d", len(postIDs))
+	}
+	post, _ := setup.db.GetPost(postIDs[0])
+	if post.Content != "First idea" {
+		t.Errorf("Expected the snapshot post, got %q", post.Content)
+	}
+
+	// Replace needs the space to exist
+	if err := setup.spaces.Delete(context.Background(), root.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := setup.service.Restore(context.Background(), snapshot.ID, RestoreRequest{Mode: RestoreModeReplace}); err == nil || err.Error() != config.ErrSnapshotSpaceGone {
+		t.Errorf("Expected space gone error, got %v", err)
+	}
+
+	// Snapshots survive their space and can still be restored as a new space
+	if _, err := setup.service.Restore(context.Background(), snapshot.ID, RestoreRequest{Mode: RestoreModeNew, Name: "Recovered"}); err != nil {
+		t.Errorf("Expected restore of a deleted space to succeed, got %v", err)
+	}
+}
+
+func TestRunSchedules(t *testing.T) {
+	setup, cleanup := setupSnapshotTest(t)
+	defer cleanup()
+
+	root := setup.seed(t)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	setup.service.now = func() time.Time { return now }
+
+	if _, err := setup.service.SetSchedule(context.Background(), root.ID, -1, 0); err == nil || err.Error() != config.ErrInvalidSnapshotSchedule {
+		t.Errorf("Expected invalid schedule error, got %v", err)
+	}
+	schedule, err := setup.service.SetSchedule(context.Background(), root.ID, 24, 2)
+	if err != nil {
+		t.Fatalf("SetSchedule failed: %v", err)
+	}
+	if schedule.IntervalHours != 24 || schedule.Keep != 2 {
+		t.Errorf("Unexpected schedule: %+v", schedule)
+	}
+
+	// A manual snapshot is never pruned
+	if _, err := setup.service.Create(context.Background(), root.ID, "Manual"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	expected := []int{1, 0, 1, 1}
+	for i, hours := range []int{0, 1, 24, 24} {
+		now = now.Add(time.Duration(hours) * time.Hour)
+		taken, err := setup.service.RunSchedules(context.Background())
+		if err != nil {
+			t.Fatalf("RunSchedules failed: %v", err)
+		}
+		if taken != expected[i] {
+			t.Errorf("Run %d: expected %d snapshots taken, got %d", i, expected[i], taken)
+		}
+	}
+
+	list, _ := setup.service.List(context.Background(), root.ID)
+	scheduled := 0
+	for _, snapshot := range list {
+		if snapshot.Scheduled {
+			scheduled++
+			if !strings.HasPrefix(snapshot.Name, "Scheduled ") {
+				t.Errorf("Unexpected scheduled snapshot name %q", snapshot.Name)
+			}
+		}
+	}
+	if scheduled != 2 || len(list) != 3 {
+		t.Errorf("Expected 2 scheduled and 1 manual snapshot, got %d of %d", scheduled, len(list))
+	}
+
+	// A zero interval removes the schedule
+	if _, err := setup.service.SetSchedule(context.Background(), root.ID, 0, 0); err != nil {
+		t.Fatalf("SetSchedule failed: %v", err)
+	}
+	schedule, _ = setup.service.GetSchedule(context.Background(), root.ID)
+	if schedule.IntervalHours != 0 {
+		t.Errorf("Expected schedule to be removed, got %+v", schedule)
+	}
+}
@@ -0,0 +1,33 @@
+package snapshots
+
+// Restore modes
+const (
+	RestoreModeNew     = "new"     // Restore into a new space subtree
+	RestoreModeReplace = "replace" // Replace the current content of the snapshot space
+)
+
+// RestoreRequest is the body of POST /api/snapshots/{id}/restore
+type RestoreRequest struct {
+	Mode     string `json:"mode"`
+	Name     string `json:"name,omitempty"`      // Name of the new root space, new mode only
+	ParentID *int   `json:"parent_id,omitempty"` // Parent of the new root space, new mode only
+}
+
+// RestoreResult describes what a restore created
+type RestoreResult struct {
+	SpaceID     int `json:"space_id"` // Root space holding the restored content
+	Spaces      int `json:"spaces"`
+	Posts       int `json:"posts"`
+	Attachments int `json:"attachments"`
+}
+
+// CreateRequest is the body of POST /api/spaces/{id}/snapshots
+type CreateRequest struct {
+	Name string `json:"name"`
+}
+
+// ScheduleRequest is the body of PUT /api/spaces/{id}/snapshot-schedule
+type ScheduleRequest struct {
+	IntervalHours int `json:"interval_hours"` // 0 removes the schedule
+	Keep          int `json:"keep"`           // Scheduled snapshots kept, default when 0
+}
@@ -0,0 +1,54 @@
+package stalespaces
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service     *Service
+	defaultDays int
+}
+
+func NewHandler(service *Service, defaultDays int) *Handler {
+	if defaultDays <= 0 {
+		defaultDays = config.DefaultStaleDays
+	}
+	return &Handler{service: service, defaultDays: defaultDays}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/stale", h.GetStaleSpaces).Methods("GET")
+}
+
+// GetStaleSpaces handles GET /api/spaces/stale
+// Query parameters:
+// - days: inactivity threshold in days (default: configured thresholdDays)
+func (h *Handler) GetStaleSpaces(w http.ResponseWriter, r *http.Request) {
+	days := h.defaultDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d < 1 || d > config.MaxStaleDays {
+			http.Error(w, config.ErrInvalidStaleDays, http.StatusBadRequest)
+			return
+		}
+		days = d
+	}
+
+	response := StaleSpacesResponse{
+		Days:   days,
+		Spaces: h.service.GetStaleSpaces(r.Context(), days),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
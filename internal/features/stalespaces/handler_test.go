@@ -0,0 +1,75 @@
+package stalespaces
+
+import (
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/models"
+	"backthynk/internal/storage"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false}, 30)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/spaces/stale", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected stale route NOT to be registered when disabled")
+	}
+}
+
+func TestGetStaleSpacesHandler(t *testing.T) {
+	catCache := cache.NewSpaceCache()
+	catCache.Set(&models.Space{ID: 1, Name: "Old", Created: time.Now().AddDate(0, 0, -10).UnixMilli()})
+
+	service := NewService(&storage.DB{}, catCache, true)
+	handler := NewHandler(service, 30)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedDays   int
+		expectedCount  int
+	}{
+		{"Default threshold", "", http.StatusOK, 30, 0},
+		{"Custom threshold", "?days=7", http.StatusOK, 7, 1},
+		{"Invalid days", "?days=abc", http.StatusBadRequest, 0, 0},
+		{"Zero days", "?days=0", http.StatusBadRequest, 0, 0},
+		{"Too many days", "?days=99999", http.StatusBadRequest, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/spaces/stale"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response StaleSpacesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if response.Days != tt.expectedDays {
+				t.Errorf("Expected days %d, got %d", tt.expectedDays, response.Days)
+			}
+			if len(response.Spaces) != tt.expectedCount {
+				t.Errorf("Expected %d stale spaces, got %d", tt.expectedCount, len(response.Spaces))
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package stalespaces
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const dayMillis = int64(24 * time.Hour / time.Millisecond)
+
+type Service struct {
+	db         *storage.DB
+	catCache   *cache.SpaceCache
+	access     *services.SpaceAccess
+	lastPost   map[int]int64 // spaceID -> most recent direct post timestamp
+	mu         sync.RWMutex
+	enabled    bool
+	webhookURL string
+	deliveries *services.Deliveries
+	stop       chan struct{}
+	now        func() time.Time
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		lastPost: make(map[int]int64),
+		enabled:  enabled,
+		now:      time.Now,
+	}
+}
+
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	lastPosts, err := s.db.GetLastPostTimes()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.lastPost = lastPosts
+	s.mu.Unlock()
+
+	return nil
+}
+
+// SetSpaceAccess keeps the spaces hidden from the viewer of a request out of its stale list,
+// and their posts out of the activity of their ancestors
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// SetNudgeWebhook configures the URL that receives periodic stale space nudges
+func (s *Service) SetNudgeWebhook(url string) {
+	s.webhookURL = url
+}
+
+// SetDeliveries leaves muted spaces out of the nudges and holds them during quiet hours
+func (s *Service) SetDeliveries(deliveries *services.Deliveries) {
+	s.deliveries = deliveries
+}
+
+// StartNudges periodically posts the stale space list to the nudge webhook, if one is configured
+func (s *Service) StartNudges(thresholdDays int, interval time.Duration) {
+	if !s.enabled || s.webhookURL == "" || s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.SendNudge(thresholdDays); err != nil {
+					logger.Warning("Failed to send stale space nudge", zap.Error(err))
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the periodic nudge loop
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// SendNudge posts the current stale spaces to the webhook, leaving muted ones out; nothing is
+// sent when no space is stale
+func (s *Service) SendNudge(thresholdDays int) error {
+	if s.webhookURL == "" {
+		return nil
+	}
+
+	var stale []StaleSpace
+	for _, space := range s.GetStaleSpaces(context.Background(), thresholdDays) {
+		if !s.deliveries.Muted(space.SpaceID) {
+			stale = append(stale, space)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(NudgePayload{
+		Event:  "spaces.stale",
+		Days:   thresholdDays,
+		Spaces: stale,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal nudge: %w", err)
+	}
+
+	if held, err := s.deliveries.HoldWebhook(s.webhookURL, body); held || err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: config.HTTPTimeout(config.WebhookHTTPTimeout)}
+	resp, err := client.Post(s.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post nudge: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("nudge webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GetStaleSpaces lists spaces whose whole subtree has had no post for at least the given number of days.
+// Spaces that never had a post are measured from their creation date.
+func (s *Service) GetStaleSpaces(ctx context.Context, days int) []StaleSpace {
+	if !s.enabled {
+		return []StaleSpace{}
+	}
+
+	now := s.now().UnixMilli()
+	cutoff := now - int64(days)*dayMillis
+	hidden := s.access.Hidden(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stale := []StaleSpace{}
+	for _, space := range s.catCache.GetAll() {
+		if hidden[space.ID] {
+			continue
+		}
+		last := s.lastPost[space.ID]
+		for _, descID := range s.catCache.GetDescendants(space.ID) {
+			if t := s.lastPost[descID]; t > last && !hidden[descID] {
+				last = t
+			}
+		}
+
+		reference := last
+		if reference == 0 {
+			reference = space.Created
+		}
+		if reference > cutoff {
+			continue
+		}
+
+		stale = append(stale, StaleSpace{
+			SpaceID:           space.ID,
+			Name:              space.Name,
+			ParentID:          space.ParentID,
+			LastPostTime:      last,
+			DaysSinceActivity: int((now - reference) / dayMillis),
+			NeverPosted:       last == 0,
+		})
+	}
+
+	// Most neglected first, then by ID for a stable order
+	sort.Slice(stale, func(i, j int) bool {
+		if stale[i].DaysSinceActivity != stale[j].DaysSinceActivity {
+			return stale[i].DaysSinceActivity > stale[j].DaysSinceActivity
+		}
+		return stale[i].SpaceID < stale[j].SpaceID
+	})
+
+	return stale
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	switch event.Type {
+	case events.PostCreated:
+		data := event.Data.(events.PostEvent)
+		s.mu.Lock()
+		if data.Timestamp > s.lastPost[data.SpaceID] {
+			s.lastPost[data.SpaceID] = data.Timestamp
+		}
+		s.mu.Unlock()
+
+	case events.PostDeleted, events.PostMerged, events.PostSplit, events.PostRetimed:
+		data := event.Data.(events.PostEvent)
+		return s.refreshSpace(data.SpaceID)
+
+	case events.PostMoved:
+		data := event.Data.(events.PostEvent)
+		if data.OldSpaceID != nil {
+			if err := s.refreshSpace(*data.OldSpaceID); err != nil {
+				return err
+			}
+		}
+		return s.refreshSpace(data.SpaceID)
+
+	case events.SpaceDeleted:
+		// Drop entries for spaces removed with the deleted subtree
+		s.mu.Lock()
+		for spaceID := range s.lastPost {
+			if _, ok := s.catCache.Get(spaceID); !ok {
+				delete(s.lastPost, spaceID)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+func (s *Service) refreshSpace(spaceID int) error {
+	last, err := s.db.GetLastPostTime(spaceID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if last == 0 {
+		delete(s.lastPost, spaceID)
+	} else {
+		s.lastPost[spaceID] = last
+	}
+	s.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package stalespaces
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func setupStaleTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_stale_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestGetStaleSpacesHidesPrivateSpaces(t *testing.T) {
+	db, cleanup := setupStaleTestDB(t)
+	defer cleanup()
+
+	root, _ := db.CreateSpace("Root", nil, "")
+	child, _ := db.CreateSpace("Child", &root.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(root)
+	catCache.Set(child)
+
+	alice, _ := db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	bob, _ := db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(db, catCache)
+	if err := access.SetACL(child.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+
+	now := time.Now()
+	service := NewService(db, catCache, true)
+	service.SetSpaceAccess(access)
+	service.now = func() time.Time { return now.AddDate(0, 0, 60) }
+	service.HandleEvent(events.Event{
+		Type: events.PostCreated,
+		Data: events.PostEvent{SpaceID: child.ID, Timestamp: now.AddDate(0, 0, 58).UnixMilli()},
+	})
+
+	asAlice := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	if stale := service.GetStaleSpaces(asAlice, 30); len(stale) != 0 {
+		t.Errorf("Expected the private activity to keep the root fresh for a listed viewer, got %+v", stale)
+	}
+
+	// The private child is left out, and so is its activity
+	asBob := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+	stale := service.GetStaleSpaces(asBob, 30)
+	if len(stale) != 1 || stale[0].SpaceID != root.ID || !stale[0].NeverPosted {
+		t.Errorf("Expected only the root, never posted to, got %+v", stale)
+	}
+}
+
+func TestGetStaleSpacesUsesSubtreeActivity(t *testing.T) {
+	catCache := cache.NewSpaceCache()
+	now := time.Now()
+	created := now.AddDate(0, 0, -200).UnixMilli()
+
+	catCache.Set(&models.Space{ID: 1, Name: "Root", Created: created})
+	catCache.Set(&models.Space{ID: 2, Name: "Child", ParentID: &[]int{1}[0], Created: created})
+	catCache.Set(&models.Space{ID: 3, Name: "Quiet", Created: created})
+	catCache.Set(&models.Space{ID: 4, Name: "Fresh", Created: now.UnixMilli()})
+
+	service := NewService(&storage.DB{}, catCache, true)
+	service.now = func() time.Time { return now }
+
+	// Child was active recently, so its parent is not stale either
+	service.HandleEvent(events.Event{
+		Type: events.PostCreated,
+		Data: events.PostEvent{SpaceID: 2, Timestamp: now.AddDate(0, 0, -2).UnixMilli()},
+	})
+	service.HandleEvent(events.Event{
+		Type: events.PostCreated,
+		Data: events.PostEvent{SpaceID: 3, Timestamp: now.Add(-45 * 24 * time.Hour).UnixMilli()},
+	})
+
+	stale := service.GetStaleSpaces(context.Background(), 30)
+	if len(stale) != 1 {
+		t.Fatalf("Expected 1 stale space, got %d: %+v", len(stale), stale)
+	}
+	if stale[0].SpaceID != 3 {
+		t.Errorf("Expected space 3 to be stale, got %d", stale[0].SpaceID)
+	}
+	if stale[0].DaysSinceActivity != 45 {
+		t.Errorf("Expected 45 days since activity, got %d", stale[0].DaysSinceActivity)
+	}
+	if stale[0].NeverPosted {
+		t.Error("Expected NeverPosted to be false")
+	}
+
+	// With a larger window nothing is stale
+	if stale := service.GetStaleSpaces(context.Background(), 60); len(stale) != 0 {
+		t.Errorf("Expected no stale spaces for 60 days, got %d", len(stale))
+	}
+}
+
+func TestGetStaleSpacesNeverPosted(t *testing.T) {
+	catCache := cache.NewSpaceCache()
+	now := time.Now()
+	catCache.Set(&models.Space{ID: 1, Name: "Empty", Created: now.AddDate(0, 0, -90).UnixMilli()})
+	catCache.Set(&models.Space{ID: 2, Name: "Older", Created: now.AddDate(0, 0, -120).UnixMilli()})
+
+	service := NewService(&storage.DB{}, catCache, true)
+	service.now = func() time.Time { return now }
+
+	stale := service.GetStaleSpaces(context.Background(), 30)
+	if len(stale) != 2 {
+		t.Fatalf("Expected 2 stale spaces, got %d", len(stale))
+	}
+	if stale[0].SpaceID != 2 || stale[1].SpaceID != 1 {
+		t.Errorf("Expected most neglected space first, got %d then %d", stale[0].SpaceID, stale[1].SpaceID)
+	}
+	if !stale[0].NeverPosted || stale[0].LastPostTime != 0 {
+		t.Error("Expected space without posts to be flagged as never posted")
+	}
+}
+
+func TestStaleSpacesRefreshOnDelete(t *testing.T) {
+	db, cleanup := setupStaleTestDB(t)
+	defer cleanup()
+
+	space, err := db.CreateSpace("Project", nil, "")
+	if err != nil {
+		t.Fatalf("Failed to create space: %v", err)
+	}
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	now := time.Now()
+	oldPost, _ := db.CreatePostWithTimestamp(context.Background(), space.ID, "old", now.AddDate(0, 0, -60).UnixMilli())
+	newPost, _ := db.CreatePostWithTimestamp(context.Background(), space.ID, "new", now.AddDate(0, 0, -1).UnixMilli())
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	if stale := service.GetStaleSpaces(context.Background(), 30); len(stale) != 0 {
+		t.Fatalf("Expected no stale spaces, got %d", len(stale))
+	}
+
+	// Deleting the recent post makes the space stale again
+	if err := db.DeletePost(context.Background(), newPost.ID); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+	service.HandleEvent(events.Event{
+		Type: events.PostDeleted,
+		Data: events.PostEvent{PostID: newPost.ID, SpaceID: space.ID, Timestamp: newPost.Created},
+	})
+
+	stale := service.GetStaleSpaces(context.Background(), 30)
+	if len(stale) != 1 {
+		t.Fatalf("Expected 1 stale space after deletion, got %d", len(stale))
+	}
+	if stale[0].LastPostTime != oldPost.Created {
+		t.Errorf("Expected last post time %d, got %d", oldPost.Created, stale[0].LastPostTime)
+	}
+}
+
+func TestSendNudge(t *testing.T) {
+	var received NudgePayload
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	catCache := cache.NewSpaceCache()
+	now := time.Now()
+	catCache.Set(&models.Space{ID: 1, Name: "Abandoned", Created: now.AddDate(0, 0, -40).UnixMilli()})
+
+	service := NewService(&storage.DB{}, catCache, true)
+	service.now = func() time.Time { return now }
+	service.SetNudgeWebhook(server.URL)
+
+	if err := service.SendNudge(30); err != nil {
+		t.Fatalf("Expected nudge to succeed, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("Expected 1 webhook call, got %d", calls)
+	}
+	if received.Event != "spaces.stale" || len(received.Spaces) != 1 {
+		t.Errorf("Unexpected nudge payload: %+v", received)
+	}
+
+	// No stale spaces means no webhook call
+	if err := service.SendNudge(60); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected no additional webhook call, got %d calls", calls)
+	}
+}
+
+func TestDisabledServiceReturnsNothing(t *testing.T) {
+	catCache := cache.NewSpaceCache()
+	catCache.Set(&models.Space{ID: 1, Name: "Old", Created: 1})
+
+	service := NewService(&storage.DB{}, catCache, false)
+	if stale := service.GetStaleSpaces(context.Background(), 1); len(stale) != 0 {
+		t.Errorf("Expected disabled service to return no spaces, got %d", len(stale))
+	}
+}
@@ -0,0 +1,22 @@
+package stalespaces
+
+type StaleSpace struct {
+	SpaceID           int    `json:"space_id"`
+	Name              string `json:"name"`
+	ParentID          *int   `json:"parent_id"`
+	LastPostTime      int64  `json:"last_post_time"` // 0 when the subtree never had a post
+	DaysSinceActivity int    `json:"days_since_activity"`
+	NeverPosted       bool   `json:"never_posted"`
+}
+
+type StaleSpacesResponse struct {
+	Days   int          `json:"days"`
+	Spaces []StaleSpace `json:"spaces"`
+}
+
+// NudgePayload is the JSON body posted to the configured nudge webhook
+type NudgePayload struct {
+	Event  string       `json:"event"`
+	Days   int          `json:"days"`
+	Spaces []StaleSpace `json:"spaces"`
+}
@@ -0,0 +1,96 @@
+package summaries
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/render"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/posts/{id:[0-9]+}/summary", h.GetPostSummary).Methods("GET")
+	api.HandleFunc("/spaces/{id:[0-9]+}/digest", h.GetSpaceDigest).Methods("GET")
+}
+
+// GetPostSummary handles GET /api/posts/{id}/summary
+func (h *Handler) GetPostSummary(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.service.PostSummary(r.Context(), postID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// GetSpaceDigest handles GET /api/spaces/{id}/digest
+// Query parameters:
+// - week: any day of the week to digest, YYYY-MM-DD (default: the previous week)
+// - format: html for the digest as a themed page instead of JSON
+func (h *Handler) GetSpaceDigest(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	day := WeekStart(time.Now()).AddDate(0, 0, -7)
+	if weekStr := r.URL.Query().Get("week"); weekStr != "" {
+		day, err = time.ParseInLocation("2006-01-02", weekStr, time.Local)
+		if err != nil || day.After(time.Now()) {
+			http.Error(w, config.ErrInvalidDigestWeek, http.StatusBadRequest)
+			return
+		}
+	}
+
+	digest, err := h.service.SpaceDigest(r.Context(), spaceID, day)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "html" {
+		page := DigestPayload{Week: digest.Week, Digests: []SpaceDigest{*digest}}
+		render.Write(w, r, render.PageDigest, digest.SpaceName+" digest", page)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(digest)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case config.ErrPostNotFound, config.ErrSpaceNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case config.ErrPostTooShortToSummarize:
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		// Summarizer failures are not the client's fault
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+}
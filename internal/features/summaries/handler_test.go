@@ -0,0 +1,91 @@
+package summaries
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	for _, path := range []string{"/api/posts/1/summary", "/api/spaces/1/digest"} {
+		req := httptest.NewRequest("GET", path, nil)
+		if router.Match(req, &mux.RouteMatch{}) {
+			t.Errorf("Expected %s NOT to be registered when disabled", path)
+		}
+	}
+}
+
+func TestSummaryHandlers(t *testing.T) {
+	db, cleanup := setupSummariesTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	long, _ := db.CreatePost(context.Background(), space.ID, strings.Repeat("notes ", config.DefaultSummaryMinPostLength))
+	short, _ := db.CreatePost(context.Background(), space.ID, "short")
+
+	service := NewService(db, catCache, true)
+	service.SetSummarizer(&stubSummarizer{})
+	failing := NewService(db, catCache, true)
+	failing.SetSummarizer(&stubSummarizer{err: fmt.Errorf("summarizer down")})
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+	failingRouter := mux.NewRouter()
+	NewHandler(failing).RegisterRoutes(failingRouter)
+
+	spaceID := strconv.Itoa(space.ID)
+	tomorrow := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+	tests := []struct {
+		name           string
+		router         *mux.Router
+		path           string
+		expectedStatus int
+	}{
+		{"Post summary", router, "/api/posts/" + strconv.Itoa(long.ID) + "/summary", http.StatusOK},
+		{"Short post", router, "/api/posts/" + strconv.Itoa(short.ID) + "/summary", http.StatusUnprocessableEntity},
+		{"Unknown post", router, "/api/posts/999/summary", http.StatusNotFound},
+		{"Summarizer failure", failingRouter, "/api/posts/" + strconv.Itoa(long.ID) + "/summary", http.StatusOK}, // Cached by the first request
+		{"Default week", router, "/api/spaces/" + spaceID + "/digest", http.StatusOK},
+		{"Current week", router, "/api/spaces/" + spaceID + "/digest?week=" + time.Now().Format("2006-01-02"), http.StatusOK},
+		{"Current week summarizer failure", failingRouter, "/api/spaces/" + spaceID + "/digest?week=" + time.Now().Format("2006-01-02"), http.StatusOK},
+		{"Future week", router, "/api/spaces/" + spaceID + "/digest?week=" + tomorrow, http.StatusBadRequest},
+		{"Invalid week", router, "/api/spaces/" + spaceID + "/digest?week=10-05", http.StatusBadRequest},
+		{"Unknown space", router, "/api/spaces/999/digest", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			tt.router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	db.UpdatePostContent(long.ID, long.Content+"edited")
+	req := httptest.NewRequest("GET", "/api/posts/"+strconv.Itoa(long.ID)+"/summary", nil)
+	w := httptest.NewRecorder()
+	failingRouter.ServeHTTP(w, req)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Expected summarizer failure to return 502, got %d", w.Code)
+	}
+}
@@ -0,0 +1,318 @@
+package summaries
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/render"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Service produces summaries of long posts and weekly space digests through a summarizer.
+// Results are cached with a hash of their input, so edits invalidate them on the next request.
+type Service struct {
+	db            *storage.DB
+	catCache      *cache.SpaceCache
+	access        *services.SpaceAccess
+	summarizer    Summarizer
+	minPostLength int
+	webhookURL    string
+	dispatcher    *events.Dispatcher
+	deliveries    *services.Deliveries
+	lastDigest    string // Week of the last digest delivered
+	stop          chan struct{}
+	mu            sync.Mutex
+	enabled       bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:            db,
+		catCache:      catCache,
+		minPostLength: config.DefaultSummaryMinPostLength,
+		enabled:       enabled,
+	}
+}
+
+func (s *Service) SetSummarizer(summarizer Summarizer) {
+	s.summarizer = summarizer
+}
+
+// SetMinPostLength sets the length in characters below which posts are not summarized
+func (s *Service) SetMinPostLength(length int) {
+	if length > 0 {
+		s.minPostLength = length
+	}
+}
+
+// SetDigestWebhook configures the URL that receives weekly space digests
+func (s *Service) SetDigestWebhook(url string) {
+	s.webhookURL = url
+}
+
+// SetDispatcher announces ready digests and webhook failures in the notification center
+func (s *Service) SetDispatcher(dispatcher *events.Dispatcher) {
+	s.dispatcher = dispatcher
+}
+
+// SetDeliveries leaves muted spaces out of the digests and holds the digest webhook call
+// during quiet hours
+func (s *Service) SetDeliveries(deliveries *services.Deliveries) {
+	s.deliveries = deliveries
+}
+
+// SetSpaceAccess keeps the posts of spaces hidden from the viewer of a request out of its
+// summaries and digests
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// PostSummary returns the summary of a long post, asking the summarizer when the cached one
+// is missing or outdated
+func (s *Service) PostSummary(ctx context.Context, postID int) (*PostSummary, error) {
+	post, err := s.db.GetPostContext(ctx, postID)
+	if err != nil {
+		if err.Error() == "post not found" {
+			return nil, fmt.Errorf(config.ErrPostNotFound)
+		}
+		return nil, err
+	}
+	if !s.access.CanRead(ctx, post.SpaceID) {
+		return nil, fmt.Errorf(config.ErrPostNotFound)
+	}
+	if len([]rune(post.Content)) < s.minPostLength {
+		return nil, fmt.Errorf(config.ErrPostTooShortToSummarize)
+	}
+
+	hash := contentHash(post.Content)
+	cached, ok, err := s.db.GetPostSummary(postID)
+	if err != nil {
+		return nil, err
+	}
+	if ok && cached.ContentHash == hash {
+		return &PostSummary{PostID: postID, Summary: cached.Summary, Cached: true, Created: cached.Created}, nil
+	}
+
+	text, err := s.summarizer.Summarize(ctx, SummaryRequest{Kind: config.SummaryKindPost, Content: post.Content})
+	if err != nil {
+		return nil, err
+	}
+	cached, err = s.db.SavePostSummary(postID, hash, text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostSummary{PostID: postID, Summary: cached.Summary, Created: cached.Created}, nil
+}
+
+// SpaceDigest returns the digest of the posts of a space and its descendants during the week
+// containing day. Weeks without posts get an empty digest without calling the summarizer.
+func (s *Service) SpaceDigest(ctx context.Context, spaceID int, day time.Time) (*SpaceDigest, error) {
+	space, ok := s.catCache.Get(spaceID)
+	if !ok || !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	start := WeekStart(day)
+	week := start.Format("2006-01-02")
+	digest := &SpaceDigest{SpaceID: spaceID, SpaceName: space.Name, Week: week}
+
+	filter := models.PostFilter{After: start.UnixMilli(), Before: start.AddDate(0, 0, 7).UnixMilli()}
+	// The space itself is readable, so it stays first of its visible subtree
+	descendants := s.access.Scope(ctx, append([]int{spaceID}, s.catCache.GetDescendants(spaceID)...))[1:]
+	posts, err := s.db.GetPostsBySpaceRecursive(ctx, spaceID, true, config.MaxDigestPosts, 0, descendants, filter)
+	if err != nil {
+		return nil, err
+	}
+	digest.PostCount = len(posts)
+	if len(posts) == 0 {
+		return digest, nil
+	}
+
+	// Posts come newest first; the summarizer reads them in order
+	var content strings.Builder
+	for i := len(posts) - 1; i >= 0; i-- {
+		fmt.Fprintf(&content, "## %s\n\n%s\n\n", time.UnixMilli(posts[i].Created).Format("Mon 2006-01-02 15:04"), posts[i].Content)
+	}
+	hash := contentHash(content.String())
+
+	cached, ok, err := s.db.GetSpaceDigest(spaceID, week)
+	if err != nil {
+		return nil, err
+	}
+	if ok && cached.ContentHash == hash {
+		digest.Summary = cached.Summary
+		digest.Cached = true
+		digest.Created = cached.Created
+		return digest, nil
+	}
+
+	text, err := s.summarizer.Summarize(ctx, SummaryRequest{
+		Kind:    config.SummaryKindDigest,
+		Title:   fmt.Sprintf("%s, week of %s", space.Name, week),
+		Content: content.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	cached, err = s.db.SaveSpaceDigest(spaceID, week, hash, len(posts), text)
+	if err != nil {
+		return nil, err
+	}
+
+	digest.Summary = cached.Summary
+	digest.Created = cached.Created
+	return digest, nil
+}
+
+// WeekStart returns local midnight of the Monday of the week containing t
+func WeekStart(t time.Time) time.Time {
+	t = t.Local()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.Local)
+}
+
+// StartDigests checks periodically whether the digest of the previous week was sent to the
+// webhook, if one is configured. The last sent week is kept in memory, so a restart during a
+// week sends its digest again.
+func (s *Service) StartDigests(interval time.Duration) {
+	if !s.enabled || (s.webhookURL == "" && s.dispatcher == nil) || s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.SendDigests(context.Background(), WeekStart(time.Now()).AddDate(0, 0, -7)); err != nil {
+					logger.Warning("Failed to send space digests", zap.Error(err))
+				}
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// Stop ends the periodic digest loop
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// SendDigests delivers the digests of every top-level space with posts during the week of day,
+// once per week: they are posted to the webhook when one is set and announced as a notification.
+// Nothing is delivered when no space had posts. Muted spaces are left out.
+func (s *Service) SendDigests(ctx context.Context, day time.Time) error {
+	if s.webhookURL == "" && s.dispatcher == nil {
+		return nil
+	}
+
+	week := WeekStart(day).Format("2006-01-02")
+	s.mu.Lock()
+	sent := s.lastDigest == week
+	s.mu.Unlock()
+	if sent {
+		return nil
+	}
+
+	digests := []SpaceDigest{}
+	for _, space := range s.catCache.GetAll() {
+		if space.ParentID != nil || s.deliveries.Muted(space.ID) {
+			continue
+		}
+		digest, err := s.SpaceDigest(ctx, space.ID, day)
+		if err != nil {
+			return err
+		}
+		if digest.PostCount > 0 {
+			digests = append(digests, *digest)
+		}
+	}
+
+	if len(digests) > 0 {
+		if s.webhookURL != "" {
+			if err := s.postDigests(week, digests); err != nil {
+				s.dispatcher.Notify(events.NotificationEvent{
+					Kind:    models.NotificationSyncFailure,
+					Title:   "Digest webhook failed",
+					Message: fmt.Sprintf("The digests of the week of %s could not be delivered (%v); they are retried on the next run.", week, err),
+					Key:     "summaries.digest_webhook",
+				})
+				return err
+			}
+		}
+
+		spaces := make([]string, len(digests))
+		for i, digest := range digests {
+			spaces[i] = digest.SpaceName
+		}
+		s.dispatcher.Notify(events.NotificationEvent{
+			Kind:    models.NotificationDigestReady,
+			Title:   "Weekly digest ready",
+			Message: fmt.Sprintf("Digests of the week of %s are ready for %s.", week, strings.Join(spaces, ", ")),
+			Key:     "digest." + week,
+		})
+	}
+
+	s.mu.Lock()
+	s.lastDigest = week
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Service) postDigests(week string, digests []SpaceDigest) error {
+	payload := DigestPayload{Event: "spaces.digest", Week: week, Digests: digests}
+	var page strings.Builder
+	if err := render.Render(&page, render.InstanceLocale(), render.PageDigest, "Weekly digest", payload); err != nil {
+		return fmt.Errorf("failed to render digest: %w", err)
+	}
+	payload.HTML = page.String()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest: %w", err)
+	}
+
+	if held, err := s.deliveries.HoldWebhook(s.webhookURL, body); held || err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: config.HTTPTimeout(config.WebhookHTTPTimeout)}
+	resp, err := client.Post(s.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post digest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("digest webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
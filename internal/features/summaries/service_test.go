@@ -0,0 +1,341 @@
+package summaries
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupSummariesTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_summaries_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+// stubSummarizer records requests and answers with a fixed prefix, or fails when err is set
+type stubSummarizer struct {
+	requests []SummaryRequest
+	err      error
+}
+
+func (s *stubSummarizer) Summarize(ctx context.Context, req SummaryRequest) (string, error) {
+	s.requests = append(s.requests, req)
+	if s.err != nil {
+		return "", s.err
+	}
+	return fmt.Sprintf("summary %d", len(s.requests)), nil
+}
+
+func TestWeekStart(t *testing.T) {
+	tests := []struct {
+		day      string
+		expected string
+	}{
+		{"2026-10-12", "2026-10-12"}, // Monday
+		{"2026-10-15", "2026-10-12"},
+		{"2026-10-18", "2026-10-12"}, // Sunday
+		{"2026-11-01", "2026-10-26"},
+	}
+
+	for _, tt := range tests {
+		day, _ := time.ParseInLocation("2006-01-02", tt.day, time.Local)
+		if got := WeekStart(day.Add(15 * time.Hour)).Format("2006-01-02"); got != tt.expected {
+			t.Errorf("WeekStart(%s) = %s, expected %s", tt.day, got, tt.expected)
+		}
+	}
+}
+
+func TestPostSummary(t *testing.T) {
+	db, cleanup := setupSummariesTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	long, _ := db.CreatePost(context.Background(), space.ID, strings.Repeat("long meeting notes ", 10))
+	short, _ := db.CreatePost(context.Background(), space.ID, "short")
+
+	summarizer := &stubSummarizer{}
+	service := NewService(db, catCache, true)
+	service.SetSummarizer(summarizer)
+	service.SetMinPostLength(100)
+
+	summary, err := service.PostSummary(context.Background(), long.ID)
+	if err != nil || summary.Summary != "summary 1" || summary.Cached {
+		t.Fatalf("Unexpected summary %+v, %v", summary, err)
+	}
+	if summarizer.requests[0].Kind != config.SummaryKindPost || summarizer.requests[0].Content != long.Content {
+		t.Errorf("Unexpected request %+v", summarizer.requests[0])
+	}
+
+	summary, _ = service.PostSummary(context.Background(), long.ID)
+	if !summary.Cached || summary.Summary != "summary 1" || len(summarizer.requests) != 1 {
+		t.Errorf("Expected cached summary, got %+v after %d requests", summary, len(summarizer.requests))
+	}
+
+	db.UpdatePostContent(long.ID, long.Content+"with a follow-up")
+	summary, _ = service.PostSummary(context.Background(), long.ID)
+	if summary.Cached || summary.Summary != "summary 2" {
+		t.Errorf("Expected edited post to be summarized again, got %+v", summary)
+	}
+
+	if _, err := service.PostSummary(context.Background(), short.ID); err == nil || err.Error() != config.ErrPostTooShortToSummarize {
+		t.Errorf("Expected short post to be refused, got %v", err)
+	}
+	if _, err := service.PostSummary(context.Background(), 999); err == nil || err.Error() != config.ErrPostNotFound {
+		t.Errorf("Expected unknown post to fail, got %v", err)
+	}
+
+	summarizer.err = fmt.Errorf("summarizer down")
+	db.UpdatePostContent(long.ID, long.Content+"again")
+	if _, err := service.PostSummary(context.Background(), long.ID); err == nil {
+		t.Error("Expected summarizer failure to surface")
+	}
+}
+
+func TestSpaceDigest(t *testing.T) {
+	db, cleanup := setupSummariesTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	infra, _ := db.CreateSpace("Infra", &work.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+	catCache.Set(infra)
+
+	monday, _ := time.ParseInLocation("2006-01-02", "2026-10-05", time.Local)
+	db.CreatePostWithTimestamp(context.Background(), work.ID, "Planned the migration", monday.Add(9*time.Hour).UnixMilli())
+	db.CreatePostWithTimestamp(context.Background(), infra.ID, "Migrated the database", monday.AddDate(0, 0, 2).UnixMilli())
+	db.CreatePostWithTimestamp(context.Background(), work.ID, "Next week", monday.AddDate(0, 0, 7).UnixMilli())
+
+	summarizer := &stubSummarizer{}
+	service := NewService(db, catCache, true)
+	service.SetSummarizer(summarizer)
+
+	digest, err := service.SpaceDigest(context.Background(), work.ID, monday.AddDate(0, 0, 4))
+	if err != nil {
+		t.Fatalf("SpaceDigest failed: %v", err)
+	}
+	if digest.Week != "2026-10-05" || digest.PostCount != 2 || digest.Summary != "summary 1" || digest.Cached {
+		t.Errorf("Unexpected digest %+v", digest)
+	}
+	content := summarizer.requests[0].Content
+	if summarizer.requests[0].Kind != config.SummaryKindDigest ||
+		strings.Index(content, "Planned") > strings.Index(content, "Migrated") || strings.Contains(content, "Next week") {
+		t.Errorf("Unexpected digest request %+v", summarizer.requests[0])
+	}
+
+	digest, _ = service.SpaceDigest(context.Background(), work.ID, monday)
+	if !digest.Cached || len(summarizer.requests) != 1 {
+		t.Errorf("Expected cached digest, got %+v", digest)
+	}
+
+	digest, _ = service.SpaceDigest(context.Background(), infra.ID, monday.AddDate(0, 0, -7))
+	if digest.PostCount != 0 || digest.Summary != "" || len(summarizer.requests) != 1 {
+		t.Errorf("Expected empty week to skip the summarizer, got %+v", digest)
+	}
+
+	if _, err := service.SpaceDigest(context.Background(), 999, monday); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected unknown space to fail, got %v", err)
+	}
+}
+
+func TestSummariesHidePrivateSpaces(t *testing.T) {
+	db, cleanup := setupSummariesTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	infra, _ := db.CreateSpace("Infra", &work.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+	catCache.Set(infra)
+
+	monday, _ := time.ParseInLocation("2006-01-02", "2026-10-05", time.Local)
+	db.CreatePostWithTimestamp(context.Background(), work.ID, "Planned the migration", monday.Add(9*time.Hour).UnixMilli())
+	private, _ := db.CreatePostWithTimestamp(context.Background(), infra.ID, strings.Repeat("Migrated the database. ", 200), monday.AddDate(0, 0, 2).UnixMilli())
+
+	alice, _ := db.ProvisionUser("alice", "", "Alice", models.RoleEditor, time.Now().UnixMilli())
+	bob, _ := db.ProvisionUser("bob", "", "Bob", models.RoleEditor, time.Now().UnixMilli())
+	access := services.NewSpaceAccess(db, catCache)
+	if err := access.SetACL(infra.ID, []models.SpaceACLEntry{{UserID: alice.ID, Permission: models.SpacePermissionRead}}); err != nil {
+		t.Fatalf("SetACL failed: %v", err)
+	}
+
+	summarizer := &stubSummarizer{}
+	service := NewService(db, catCache, true)
+	service.SetSummarizer(summarizer)
+	service.SetSpaceAccess(access)
+
+	asAlice := services.WithViewer(context.Background(), services.Viewer{UserID: alice.ID})
+	asBob := services.WithViewer(context.Background(), services.Viewer{UserID: bob.ID})
+
+	if digest, err := service.SpaceDigest(asAlice, work.ID, monday); err != nil || digest.PostCount != 2 {
+		t.Errorf("Expected both posts for a listed viewer, got %+v (%v)", digest, err)
+	}
+	digest, err := service.SpaceDigest(asBob, work.ID, monday)
+	if err != nil || digest.PostCount != 1 || strings.Contains(summarizer.requests[len(summarizer.requests)-1].Content, "Migrated") {
+		t.Errorf("Expected the private post to be left out, got %+v (%v)", digest, err)
+	}
+	if _, err := service.SpaceDigest(asBob, infra.ID, monday); err == nil || err.Error() != config.ErrSpaceNotFound {
+		t.Errorf("Expected a hidden space to be not found, got %v", err)
+	}
+
+	if _, err := service.PostSummary(asAlice, private.ID); err != nil {
+		t.Errorf("Expected a listed viewer to get the summary, got %v", err)
+	}
+	if _, err := service.PostSummary(asBob, private.ID); err == nil || err.Error() != config.ErrPostNotFound {
+		t.Errorf("Expected a hidden post to be not found, got %v", err)
+	}
+}
+
+func TestSendDigests(t *testing.T) {
+	db, cleanup := setupSummariesTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	infra, _ := db.CreateSpace("Infra", &work.ID, "")
+	home, _ := db.CreateSpace("Home", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+	catCache.Set(infra)
+	catCache.Set(home)
+
+	monday, _ := time.ParseInLocation("2006-01-02", "2026-10-05", time.Local)
+	db.CreatePostWithTimestamp(context.Background(), infra.ID, "Migrated the database", monday.Add(time.Hour).UnixMilli())
+
+	var payloads []DigestPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload DigestPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	service := NewService(db, catCache, true)
+	service.SetSummarizer(&stubSummarizer{})
+	service.SetDigestWebhook(server.URL)
+
+	if err := service.SendDigests(context.Background(), monday); err != nil {
+		t.Fatalf("SendDigests failed: %v", err)
+	}
+	service.SendDigests(context.Background(), monday.AddDate(0, 0, 3))
+
+	if len(payloads) != 1 {
+		t.Fatalf("Expected one digest per week, got %d", len(payloads))
+	}
+	if payloads[0].Event != "spaces.digest" || payloads[0].Week != "2026-10-05" ||
+		len(payloads[0].Digests) != 1 || payloads[0].Digests[0].SpaceID != work.ID {
+		t.Errorf("Unexpected payload %+v", payloads[0])
+	}
+}
+
+func TestSendDigestsNotifications(t *testing.T) {
+	db, cleanup := setupSummariesTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+
+	monday, _ := time.ParseInLocation("2006-01-02", "2026-10-05", time.Local)
+	db.CreatePostWithTimestamp(context.Background(), work.ID, "Shipped the release", monday.Add(time.Hour).UnixMilli())
+
+	var raised []events.NotificationEvent
+	dispatcher := events.NewDispatcher()
+	dispatcher.Subscribe(events.NotificationRaised, func(event events.Event) error {
+		raised = append(raised, event.Data.(events.NotificationEvent))
+		return nil
+	})
+
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	service := NewService(db, catCache, true)
+	service.SetSummarizer(&stubSummarizer{})
+	service.SetDigestWebhook(server.URL)
+	service.SetDispatcher(dispatcher)
+
+	if err := service.SendDigests(context.Background(), monday); err == nil {
+		t.Fatal("Expected the webhook failure to be returned")
+	}
+	failing = false
+	if err := service.SendDigests(context.Background(), monday); err != nil {
+		t.Fatalf("SendDigests failed: %v", err)
+	}
+	service.SendDigests(context.Background(), monday)
+
+	if len(raised) != 2 {
+		t.Fatalf("Expected a failure and a ready notification, got %+v", raised)
+	}
+	if raised[0].Kind != models.NotificationSyncFailure || raised[0].Key != "summaries.digest_webhook" {
+		t.Errorf("Unexpected failure notification %+v", raised[0])
+	}
+	if raised[1].Kind != models.NotificationDigestReady || raised[1].Key != "digest.2026-10-05" ||
+		!strings.Contains(raised[1].Message, "Work") {
+		t.Errorf("Unexpected digest notification %+v", raised[1])
+	}
+
+	// Without a webhook, digests are still announced
+	next := NewService(db, catCache, true)
+	next.SetSummarizer(&stubSummarizer{})
+	next.SetDispatcher(dispatcher)
+	if err := next.SendDigests(context.Background(), monday); err != nil || len(raised) != 3 {
+		t.Errorf("Expected a digest notification without webhook, got %d (%v)", len(raised), err)
+	}
+}
+
+func TestHTTPSummarizer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req SummaryRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(SummaryResponse{Summary: "  " + req.Kind + ": " + req.Content[:4] + " "})
+	}))
+	defer server.Close()
+
+	summary, err := NewHTTPSummarizer(server.URL, "secret").Summarize(context.Background(), SummaryRequest{Kind: "post", Content: "long text"})
+	if err != nil || summary != "post: long" {
+		t.Errorf("Unexpected summary %q, %v", summary, err)
+	}
+
+	if _, err := NewHTTPSummarizer(server.URL, "wrong").Summarize(context.Background(), SummaryRequest{Content: "text"}); err == nil {
+		t.Error("Expected error status to fail")
+	}
+}
@@ -0,0 +1,69 @@
+package summaries
+
+import (
+	"backthynk/internal/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Summarizer condenses text. Summarizers are pluggable through Service.SetSummarizer.
+type Summarizer interface {
+	Summarize(ctx context.Context, req SummaryRequest) (string, error)
+}
+
+// HTTPSummarizer forwards requests to an external summarization endpoint, typically a small
+// adapter in front of an LLM
+type HTTPSummarizer struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func NewHTTPSummarizer(url, apiKey string) *HTTPSummarizer {
+	return &HTTPSummarizer{
+		url:    url,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: config.HTTPTimeout(config.SummaryHTTPTimeout)},
+	}
+}
+
+func (s *HTTPSummarizer) Summarize(ctx context.Context, req SummaryRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal summary request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create summary request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to call summarizer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("summarizer returned status %d", resp.StatusCode)
+	}
+
+	var result SummaryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode summary response: %w", err)
+	}
+
+	summary := strings.TrimSpace(result.Summary)
+	if summary == "" {
+		return "", fmt.Errorf("summarizer returned an empty summary")
+	}
+	return summary, nil
+}
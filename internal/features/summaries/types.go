@@ -0,0 +1,39 @@
+package summaries
+
+type PostSummary struct {
+	PostID  int    `json:"post_id"`
+	Summary string `json:"summary"`
+	Cached  bool   `json:"cached"`
+	Created int64  `json:"created"`
+}
+
+// SpaceDigest summarizes the posts of a space and its descendants over one week
+type SpaceDigest struct {
+	SpaceID   int    `json:"space_id"`
+	SpaceName string `json:"space_name"`
+	Week      string `json:"week"` // Monday of the week, YYYY-MM-DD
+	PostCount int    `json:"post_count"`
+	Summary   string `json:"summary"` // Empty when the week has no posts
+	Cached    bool   `json:"cached"`
+	Created   int64  `json:"created,omitempty"`
+}
+
+// SummaryRequest is sent to the summarizer endpoint, which answers with a SummaryResponse
+type SummaryRequest struct {
+	Kind    string `json:"kind"` // post or digest
+	Title   string `json:"title,omitempty"`
+	Content string `json:"content"`
+}
+
+type SummaryResponse struct {
+	Summary string `json:"summary"`
+}
+
+// DigestPayload is posted to the digest webhook once a week. HTML holds the digests as a
+// themed page, ready to be sent as an email body.
+type DigestPayload struct {
+	Event   string        `json:"event"`
+	Week    string        `json:"week"`
+	Digests []SpaceDigest `json:"digests"`
+	HTML    string        `json:"html"`
+}
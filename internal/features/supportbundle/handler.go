@@ -0,0 +1,46 @@
+package supportbundle
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/admin/support-bundle", h.CreateBundle).Methods("POST")
+}
+
+// CreateBundle handles POST /api/admin/support-bundle, returning the bundle as a zip download
+func (h *Handler) CreateBundle(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	manifest, err := h.service.Build(&buf)
+	if err != nil {
+		logger.Error("Failed to build support bundle", zap.Error(err))
+		http.Error(w, config.ErrSupportBundleFailed, http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("backthynk-support-%s.zip", time.UnixMilli(manifest.Created).Format("2006-01-02-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,42 @@
+package supportbundle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("POST", "/api/admin/support-bundle", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected support bundle routes NOT to be registered when disabled")
+	}
+}
+
+func TestCreateBundle(t *testing.T) {
+	db, cleanup := setupSupportTestDB(t)
+	defer cleanup()
+
+	router := mux.NewRouter()
+	NewHandler(NewService(db, true)).RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/admin/support-bundle", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "application/zip" || !strings.Contains(w.Header().Get("Content-Disposition"), "backthynk-support-") {
+		t.Errorf("Unexpected headers: %v", w.Header())
+	}
+	if files := readBundle(t, w.Body.Bytes()); files["manifest.json"] == "" {
+		t.Error("Expected a manifest in the bundle")
+	}
+}
@@ -0,0 +1,254 @@
+package supportbundle
+
+import (
+	"archive/zip"
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// CounterSource returns the current counters of a subsystem, encoded as JSON in the bundle
+type CounterSource func() interface{}
+
+type source struct {
+	name     string
+	counters CounterSource
+}
+
+// Service builds support bundles: a zip archive of diagnostics a user can attach to a bug
+// report. Bundles never hold post content: logs are scrubbed, secrets in the configuration
+// are redacted and the database is only described by its schema, size and integrity.
+type Service struct {
+	db      *storage.DB
+	sources []source
+	started time.Time
+	now     func() time.Time
+	enabled bool
+}
+
+func NewService(db *storage.DB, enabled bool) *Service {
+	return &Service{
+		db:      db,
+		started: time.Now(),
+		now:     time.Now,
+		enabled: enabled,
+	}
+}
+
+// Register adds the counters of a subsystem to the bundles, in registration order
+func (s *Service) Register(name string, counters CounterSource) {
+	s.sources = append(s.sources, source{name: name, counters: counters})
+}
+
+// Build writes a bundle to w. A section that cannot be collected is left out and reported in
+// the manifest, since a bundle is most needed when something is broken.
+func (s *Service) Build(w io.Writer) (*Manifest, error) {
+	manifest := &Manifest{
+		Format:   config.SupportBundleFormat,
+		Version:  config.SupportBundleVersion,
+		Created:  s.now().UnixMilli(),
+		SafeMode: config.IsSafeMode(),
+		Files:    []string{},
+	}
+	if shared := config.GetSharedConfig(); shared != nil {
+		manifest.AppVersion = shared.App.Version
+	}
+
+	files := make(map[string][]byte)
+	var order []string
+	add := func(name string, value interface{}) {
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			manifest.Errors = append(manifest.Errors, fmt.Sprintf("%s: %v", name, err))
+			return
+		}
+		files[name] = data
+		order = append(order, name)
+	}
+
+	add("version.json", config.GetBuildInfo())
+
+	if report, err := s.configReport(); err != nil {
+		manifest.Errors = append(manifest.Errors, fmt.Sprintf("config.json: %v", err))
+	} else {
+		add("config.json", report)
+	}
+
+	if report, err := s.databaseReport(); err != nil {
+		manifest.Errors = append(manifest.Errors, fmt.Sprintf("database.json: %v", err))
+	} else {
+		add("database.json", report)
+	}
+
+	add("counters.json", s.countersReport())
+
+	if l := logger.GetLogger(); l == nil {
+		manifest.Errors = append(manifest.Errors, "logs: logger not initialized")
+	} else {
+		for _, logType := range []string{"warnings", "errors"} {
+			lines, err := l.ReadLogs(logType, config.SupportBundleLogLines)
+			if err != nil {
+				manifest.Errors = append(manifest.Errors, fmt.Sprintf("logs/%s.log: %v", logType, err))
+				continue
+			}
+			for i, line := range lines {
+				lines[i] = ScrubLogLine(line)
+			}
+			name := "logs/" + logType + ".log"
+			files[name] = []byte(strings.Join(lines, "\n"))
+			order = append(order, name)
+		}
+	}
+
+	manifest.Files = append(manifest.Files, order...)
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	archive := zip.NewWriter(w)
+	if err := writeFile(archive, "manifest.json", manifestData, manifest.Created); err != nil {
+		return nil, err
+	}
+	for _, name := range order {
+		if err := writeFile(archive, name, files[name], manifest.Created); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close support bundle: %w", err)
+	}
+
+	return manifest, nil
+}
+
+func writeFile(archive *zip.Writer, name string, data []byte, created int64) error {
+	file, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.UnixMilli(created),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to support bundle: %w", name, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to support bundle: %w", name, err)
+	}
+	return nil
+}
+
+func (s *Service) configReport() (*ConfigReport, error) {
+	report := &ConfigReport{}
+	var err error
+	if options := config.GetOptionsConfig(); options != nil {
+		if report.Options, err = redact(options); err != nil {
+			return nil, err
+		}
+	}
+	if service := config.GetServiceConfig(); service != nil {
+		if report.Service, err = redact(service); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+func (s *Service) databaseReport() (*DatabaseReport, error) {
+	schema, err := s.db.GetSchemaInfo()
+	if err != nil {
+		return nil, err
+	}
+	size, err := s.db.DatabaseSize()
+	if err != nil {
+		return nil, err
+	}
+	integrity, err := s.db.IntegrityCheck(config.SupportBundleIntegrityLimit)
+	if err != nil {
+		return nil, err
+	}
+	violations, err := s.db.CountForeignKeyViolations()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DatabaseReport{
+		Schema:               schema,
+		SizeBytes:            size,
+		IntegrityOK:          len(integrity) == 1 && integrity[0] == "ok",
+		Integrity:            integrity,
+		ForeignKeyViolations: violations,
+	}, nil
+}
+
+func (s *Service) countersReport() *CountersReport {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	report := &CountersReport{
+		Runtime: RuntimeCounters{
+			Goroutines:     runtime.NumGoroutine(),
+			HeapAllocBytes: mem.HeapAlloc,
+			HeapSysBytes:   mem.HeapSys,
+			NumGC:          mem.NumGC,
+			UptimeSeconds:  int64(s.now().Sub(s.started).Seconds()),
+		},
+		Sources: make(map[string]interface{}, len(s.sources)),
+	}
+	for _, source := range s.sources {
+		report.Sources[source.name] = source.counters()
+	}
+	return report
+}
+
+// secretKeyPattern matches configuration keys whose values may grant access somewhere
+var secretKeyPattern = regexp.MustCompile(`(?i)(key|secret|token|password|url)$`)
+
+// redact returns value as generic JSON with the values of secret keys replaced. Unset
+// secrets stay empty so the bundle still tells whether they are configured.
+func redact(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return redactValue(generic), nil
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if text, ok := item.(string); ok && text != "" && secretKeyPattern.MatchString(key) {
+				v[key] = "[REDACTED]"
+				continue
+			}
+			v[key] = redactValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// logFieldPattern matches the structured fields of a log line that may carry user data
+var logFieldPattern = regexp.MustCompile(`"(name|title|query|filename|path|url|content|author|email|description)":\s*"(?:[^"\\]|\\.)*"`)
+
+// ScrubLogLine removes user data from a log line: values of fields naming spaces, files or
+// searches, and anything that looks like a secret
+func ScrubLogLine(line string) string {
+	line = logFieldPattern.ReplaceAllString(line, `"$1": "[SCRUBBED]"`)
+	return utils.RedactSecrets(line, utils.FindSecrets(line))
+}
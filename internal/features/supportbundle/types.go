@@ -0,0 +1,44 @@
+package supportbundle
+
+import "backthynk/internal/core/models"
+
+// Manifest is the first file of a bundle, listing what it holds
+type Manifest struct {
+	Format     string   `json:"format"`
+	Version    int      `json:"version"`
+	AppVersion string   `json:"app_version"`
+	Created    int64    `json:"created"`
+	SafeMode   bool     `json:"safe_mode"`
+	Files      []string `json:"files"`
+	Errors     []string `json:"errors,omitempty"` // Sections that could not be collected
+}
+
+// ConfigReport is the configuration in use, with secret values redacted
+type ConfigReport struct {
+	Options interface{} `json:"options"`
+	Service interface{} `json:"service"`
+}
+
+// DatabaseReport describes the database without any of its content
+type DatabaseReport struct {
+	Schema               *models.SchemaInfo `json:"schema"`
+	SizeBytes            int64              `json:"size_bytes"`
+	IntegrityOK          bool               `json:"integrity_ok"`
+	Integrity            []string           `json:"integrity"`
+	ForeignKeyViolations int                `json:"foreign_key_violations"`
+}
+
+// RuntimeCounters are the process figures of the Go runtime
+type RuntimeCounters struct {
+	Goroutines     int    `json:"goroutines"`
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	HeapSysBytes   uint64 `json:"heap_sys_bytes"`
+	NumGC          uint32 `json:"num_gc"`
+	UptimeSeconds  int64  `json:"uptime_seconds"`
+}
+
+// CountersReport holds the runtime counters and those of the registered sources
+type CountersReport struct {
+	Runtime RuntimeCounters        `json:"runtime"`
+	Sources map[string]interface{} `json:"sources"`
+}
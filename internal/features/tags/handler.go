@@ -0,0 +1,192 @@
+package tags
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/tags", h.GetTags).Methods("GET")
+	api.HandleFunc("/tags/rename", h.RenameTag).Methods("POST")
+	api.HandleFunc("/tags/merge", h.MergeTags).Methods("POST")
+	api.HandleFunc("/tags/bulk", h.BulkTag).Methods("POST")
+	api.HandleFunc("/tags/audit", h.GetAuditLog).Methods("GET")
+}
+
+// GetTags handles GET /api/tags
+// Query parameters:
+// - space_id: only count posts of this space (default: all spaces)
+// - recursive: include descendant spaces of space_id (default: false)
+func (h *Handler) GetTags(w http.ResponseWriter, r *http.Request) {
+	var spaceID *int
+	if idStr := r.URL.Query().Get("space_id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+			return
+		}
+		spaceID = &id
+	}
+
+	recursive := r.URL.Query().Get("recursive") == "true"
+
+	tags, err := h.service.GetTags(r.Context(), spaceID, recursive)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TagsResponse{
+		SpaceID:   spaceID,
+		Recursive: recursive,
+		Tags:      tags,
+	})
+}
+
+// RenameTag handles POST /api/tags/rename; with dry_run=true it only reports the posts it would change
+func (h *Handler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	var req RenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	// Report the posts that would change without changing anything
+	if r.URL.Query().Get("dry_run") == "true" {
+		summary, err := h.service.PreviewRename(r.Context(), req.From, req.To)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+		return
+	}
+
+	result, err := h.service.Rename(r.Context(), req.From, req.To)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// MergeTags handles POST /api/tags/merge; with dry_run=true it only reports the posts it would change
+func (h *Handler) MergeTags(w http.ResponseWriter, r *http.Request) {
+	var req MergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	// Report the posts that would change without changing anything
+	if r.URL.Query().Get("dry_run") == "true" {
+		summary, err := h.service.PreviewMerge(r.Context(), req.Sources, req.Target)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+		return
+	}
+
+	result, err := h.service.Merge(r.Context(), req.Sources, req.Target)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// BulkTag handles POST /api/tags/bulk; with dry_run=true it only reports the posts it would change
+func (h *Handler) BulkTag(w http.ResponseWriter, r *http.Request) {
+	var req BulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidJSON, http.StatusBadRequest)
+		return
+	}
+
+	// Report the posts that would change without changing anything
+	if r.URL.Query().Get("dry_run") == "true" {
+		summary, err := h.service.PreviewBulk(r.Context(), req.Tag, req.Action, req.Filter)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+		return
+	}
+
+	result, err := h.service.Bulk(r.Context(), req.Tag, req.Action, req.Filter)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetAuditLog handles GET /api/tags/audit
+// Query parameters:
+// - limit: number of entries to return (default: 50, max: 500)
+func (h *Handler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit := config.DefaultTagAuditLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > config.MaxTagAuditLimit {
+			http.Error(w, config.ErrInvalidAuditLimit, http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := h.service.GetAuditLog(limit)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case config.ErrSpaceNotFound, config.ErrPostNotFound, config.ErrTagNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case config.ErrTagAlreadyExists:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case config.ErrInvalidTag, config.ErrTagMergeSourcesRequired, config.ErrInvalidBulkTagAction, config.ErrBulkTagFilterRequired:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
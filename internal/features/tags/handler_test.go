@@ -0,0 +1,83 @@
+package tags
+
+import (
+	"backthynk/internal/core/cache"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/tags", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected tags routes NOT to be registered when disabled")
+	}
+}
+
+func TestTagHandlers(t *testing.T) {
+	db, cleanup := setupTagsTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	db.CreatePost(context.Background(), space.ID, "Idea #draft")
+	db.CreatePost(context.Background(), space.ID, "Another #draft #wip")
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"List tags", "GET", "/api/tags", "", http.StatusOK},
+		{"List tags of space", "GET", "/api/tags?space_id=1&recursive=true", "", http.StatusOK},
+		{"List tags of unknown space", "GET", "/api/tags?space_id=999", "", http.StatusNotFound},
+		{"List tags invalid space", "GET", "/api/tags?space_id=abc", "", http.StatusBadRequest},
+		{"Rename invalid JSON", "POST", "/api/tags/rename", "{", http.StatusBadRequest},
+		{"Rename onto existing tag", "POST", "/api/tags/rename", `{"from":"wip","to":"draft"}`, http.StatusConflict},
+		{"Rename unknown tag", "POST", "/api/tags/rename", `{"from":"nope","to":"other"}`, http.StatusNotFound},
+		{"Rename", "POST", "/api/tags/rename", `{"from":"wip","to":"in-progress"}`, http.StatusOK},
+		{"Merge without sources", "POST", "/api/tags/merge", `{"sources":["draft"],"target":"draft"}`, http.StatusBadRequest},
+		{"Merge", "POST", "/api/tags/merge", `{"sources":["in-progress"],"target":"draft"}`, http.StatusOK},
+		{"Bulk without filter", "POST", "/api/tags/bulk", `{"tag":"x","action":"add"}`, http.StatusBadRequest},
+		{"Bulk unknown post", "POST", "/api/tags/bulk", `{"tag":"x","action":"add","filter":{"post_ids":[999]}}`, http.StatusNotFound},
+		{"Bulk", "POST", "/api/tags/bulk", `{"tag":"x","action":"add","filter":{"space_id":1}}`, http.StatusOK},
+		{"Audit", "GET", "/api/tags/audit?limit=10", "", http.StatusOK},
+		{"Audit invalid limit", "GET", "/api/tags/audit?limit=0", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	entries, _ := service.GetAuditLog(10)
+	if len(entries) != 3 {
+		t.Errorf("Expected 3 audit entries, got %d", len(entries))
+	}
+}
@@ -0,0 +1,472 @@
+package tags
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	access   *services.SpaceAccess
+	enabled  bool
+
+	// mu serializes tag operations so two rewrites never race on the same post
+	mu sync.Mutex
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		enabled:  enabled,
+	}
+}
+
+// SetSpaceAccess keeps tag operations to the posts the viewer of a request may reach: counts
+// leave out hidden spaces and rewrites only touch posts it may change
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// Initialize re-parses all posts so hashtags written before the feature was enabled are indexed
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	return s.db.RebuildPostTags(utils.ParseTags)
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	// Deleted posts cascade their tags, so only content changes need indexing
+	switch event.Type {
+	case events.PostCreated, events.PostMerged, events.PostUpdated:
+		data := event.Data.(events.PostEvent)
+		return s.indexPost(data.PostID)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		if err := s.indexPost(data.PostID); err != nil {
+			return err
+		}
+		for _, split := range data.SplitPosts {
+			if err := s.indexPost(split.PostID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetTags returns tag usage counts, for every post or for a space and optionally its descendants
+func (s *Service) GetTags(ctx context.Context, spaceID *int, recursive bool) ([]storage.TagCount, error) {
+	if spaceID == nil {
+		return s.db.GetTagCounts(s.access.Scope(ctx, nil))
+	}
+
+	spaceIDs, err := s.resolveSpaces(ctx, *spaceID, recursive)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.GetTagCounts(spaceIDs)
+}
+
+// Rename replaces a tag by a new one in every post. Renaming onto a tag already in use
+// is refused so near-duplicates are merged explicitly.
+func (s *Service) Rename(ctx context.Context, from, to string) (*OperationResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes, details, err := s.planRename(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(changes, AuditActionRename, details)
+}
+
+// PreviewRename reports the posts Rename would change without changing anything
+func (s *Service) PreviewRename(ctx context.Context, from, to string) (*models.DryRunSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes, _, err := s.planRename(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return preview("rename_tag", changes), nil
+}
+
+// planRename returns the content changes of a rename and its audit details; s.mu must be held
+func (s *Service) planRename(ctx context.Context, from, to string) ([]storage.PostContentChange, map[string]interface{}, error) {
+	from, ok := utils.NormalizeTag(from)
+	if !ok {
+		return nil, nil, fmt.Errorf(config.ErrInvalidTag)
+	}
+	to, ok = utils.NormalizeTag(to)
+	if !ok || from == to {
+		return nil, nil, fmt.Errorf(config.ErrInvalidTag)
+	}
+
+	posts, err := s.db.GetPostsByTag(from)
+	if err != nil {
+		return nil, nil, err
+	}
+	posts = s.reachable(ctx, posts, true)
+	if len(posts) == 0 {
+		return nil, nil, fmt.Errorf(config.ErrTagNotFound)
+	}
+
+	existing, err := s.db.GetPostsByTag(to)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(s.reachable(ctx, existing, false)) > 0 {
+		return nil, nil, fmt.Errorf(config.ErrTagAlreadyExists)
+	}
+
+	var changes []storage.PostContentChange
+	for _, post := range posts {
+		if content, changed := utils.ReplaceTag(post.Content, from, to); changed {
+			changes = append(changes, contentChange(post.ID, content))
+		}
+	}
+
+	return changes, map[string]interface{}{
+		"from": from,
+		"to":   to,
+	}, nil
+}
+
+// Merge folds the source tags into the target tag. Posts already carrying the target
+// simply lose the source tags.
+func (s *Service) Merge(ctx context.Context, sources []string, target string) (*OperationResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes, details, err := s.planMerge(ctx, sources, target)
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(changes, AuditActionMerge, details)
+}
+
+// PreviewMerge reports the posts Merge would change without changing anything
+func (s *Service) PreviewMerge(ctx context.Context, sources []string, target string) (*models.DryRunSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes, _, err := s.planMerge(ctx, sources, target)
+	if err != nil {
+		return nil, err
+	}
+	return preview("merge_tags", changes), nil
+}
+
+// planMerge returns the content changes of a merge and its audit details; s.mu must be held
+func (s *Service) planMerge(ctx context.Context, sources []string, target string) ([]storage.PostContentChange, map[string]interface{}, error) {
+	target, ok := utils.NormalizeTag(target)
+	if !ok {
+		return nil, nil, fmt.Errorf(config.ErrInvalidTag)
+	}
+
+	var normalized []string
+	seen := map[string]bool{target: true}
+	for _, source := range sources {
+		tag, ok := utils.NormalizeTag(source)
+		if !ok {
+			return nil, nil, fmt.Errorf(config.ErrInvalidTag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, nil, fmt.Errorf(config.ErrTagMergeSourcesRequired)
+	}
+
+	postsByID := make(map[int]models.Post)
+	for _, source := range normalized {
+		posts, err := s.db.GetPostsByTag(source)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, post := range s.reachable(ctx, posts, true) {
+			postsByID[post.ID] = post
+		}
+	}
+	if len(postsByID) == 0 {
+		return nil, nil, fmt.Errorf(config.ErrTagNotFound)
+	}
+
+	var changes []storage.PostContentChange
+	for _, id := range sortedPostIDs(postsByID) {
+		content := postsByID[id].Content
+		hasTarget := hasTag(content, target)
+		for _, source := range normalized {
+			var changed bool
+			if hasTarget {
+				content, changed = utils.RemoveTag(content, source)
+			} else {
+				content, changed = utils.ReplaceTag(content, source, target)
+				hasTarget = changed
+			}
+		}
+		if content != postsByID[id].Content {
+			changes = append(changes, contentChange(id, content))
+		}
+	}
+
+	return changes, map[string]interface{}{
+		"sources": normalized,
+		"target":  target,
+	}, nil
+}
+
+// Bulk adds or removes a tag on every post matching the filter
+func (s *Service) Bulk(ctx context.Context, tag, action string, filter BulkFilter) (*OperationResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes, details, err := s.planBulk(ctx, tag, action, filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(changes, AuditActionBulk, details)
+}
+
+// PreviewBulk reports the posts Bulk would change without changing anything
+func (s *Service) PreviewBulk(ctx context.Context, tag, action string, filter BulkFilter) (*models.DryRunSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changes, _, err := s.planBulk(ctx, tag, action, filter)
+	if err != nil {
+		return nil, err
+	}
+	return preview("bulk_tag", changes), nil
+}
+
+// planBulk returns the content changes of a bulk operation and its audit details; s.mu must be held
+func (s *Service) planBulk(ctx context.Context, tag, action string, filter BulkFilter) ([]storage.PostContentChange, map[string]interface{}, error) {
+	tag, ok := utils.NormalizeTag(tag)
+	if !ok {
+		return nil, nil, fmt.Errorf(config.ErrInvalidTag)
+	}
+	if action != BulkActionAdd && action != BulkActionRemove {
+		return nil, nil, fmt.Errorf(config.ErrInvalidBulkTagAction)
+	}
+	if filter.SpaceID == nil && len(filter.PostIDs) == 0 && strings.TrimSpace(filter.Contains) == "" {
+		return nil, nil, fmt.Errorf(config.ErrBulkTagFilterRequired)
+	}
+
+	posts, err := s.filterPosts(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	posts = s.reachable(ctx, posts, true)
+
+	var changes []storage.PostContentChange
+	for _, post := range posts {
+		var content string
+		var changed bool
+		if action == BulkActionAdd {
+			content, changed = utils.AddTag(post.Content, tag)
+		} else {
+			content, changed = utils.RemoveTag(post.Content, tag)
+		}
+		if changed {
+			changes = append(changes, contentChange(post.ID, content))
+		}
+	}
+
+	return changes, map[string]interface{}{
+		"tag":    tag,
+		"action": action,
+		"filter": filter,
+	}, nil
+}
+
+// GetAuditLog returns the most recent tag operations, newest first
+func (s *Service) GetAuditLog(limit int) ([]models.AuditEntry, error) {
+	return s.db.GetAuditEntries("tags.", limit)
+}
+
+func (s *Service) filterPosts(ctx context.Context, filter BulkFilter) ([]models.Post, error) {
+	var posts []models.Post
+	if filter.SpaceID != nil {
+		spaceIDs, err := s.resolveSpaces(ctx, *filter.SpaceID, filter.Recursive)
+		if err != nil {
+			return nil, err
+		}
+		if posts, err = s.db.GetPostsBySpaces(spaceIDs); err != nil {
+			return nil, err
+		}
+	} else if len(filter.PostIDs) == 0 {
+		var spaceIDs []int
+		for _, space := range s.catCache.GetAll() {
+			spaceIDs = append(spaceIDs, space.ID)
+		}
+		var err error
+		if posts, err = s.db.GetPostsBySpaces(s.access.Scope(ctx, spaceIDs)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(filter.PostIDs) > 0 {
+		wanted := make(map[int]bool, len(filter.PostIDs))
+		for _, id := range filter.PostIDs {
+			wanted[id] = true
+		}
+
+		if filter.SpaceID != nil {
+			kept := posts[:0]
+			for _, post := range posts {
+				if wanted[post.ID] {
+					kept = append(kept, post)
+				}
+			}
+			posts = kept
+		} else {
+			for id := range wanted {
+				post, err := s.db.GetPost(id)
+				if err != nil || !s.access.CanRead(ctx, post.SpaceID) {
+					return nil, fmt.Errorf(config.ErrPostNotFound)
+				}
+				posts = append(posts, *post)
+			}
+			sort.Slice(posts, func(i, j int) bool { return posts[i].ID < posts[j].ID })
+		}
+	}
+
+	if contains := strings.ToLower(strings.TrimSpace(filter.Contains)); contains != "" {
+		kept := posts[:0]
+		for _, post := range posts {
+			if strings.Contains(strings.ToLower(post.Content), contains) {
+				kept = append(kept, post)
+			}
+		}
+		posts = kept
+	}
+
+	return posts, nil
+}
+
+func (s *Service) apply(changes []storage.PostContentChange, action string, details map[string]interface{}) (*OperationResult, error) {
+	postIDs := make([]int, len(changes))
+	for i, change := range changes {
+		postIDs[i] = change.PostID
+	}
+	details["post_ids"] = postIDs
+
+	data, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.AuditEntry{
+		Action:  action,
+		Details: data,
+		Created: time.Now().UnixMilli(),
+	}
+	if err := s.db.ApplyPostContentChanges(changes, entry); err != nil {
+		return nil, err
+	}
+
+	return &OperationResult{
+		AuditID:      entry.ID,
+		PostsChanged: len(changes),
+		PostIDs:      postIDs,
+	}, nil
+}
+
+// preview summarizes the posts a tag operation would change
+func preview(operation string, changes []storage.PostContentChange) *models.DryRunSummary {
+	summary := &models.DryRunSummary{
+		Operation:        operation,
+		DryRun:           true,
+		DescendantSpaces: []int{},
+		PostCount:        len(changes),
+		PostIDs:          make([]int, len(changes)),
+	}
+	for i, change := range changes {
+		summary.PostIDs[i] = change.PostID
+	}
+	return summary
+}
+
+func (s *Service) resolveSpaces(ctx context.Context, spaceID int, recursive bool) ([]int, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	spaceIDs := []int{spaceID}
+	if recursive {
+		spaceIDs = append(spaceIDs, s.catCache.GetDescendants(spaceID)...)
+	}
+	return s.access.Scope(ctx, spaceIDs), nil
+}
+
+// reachable keeps the posts the viewer of ctx may read, or change when write is set
+func (s *Service) reachable(ctx context.Context, posts []models.Post, write bool) []models.Post {
+	kept := make([]models.Post, 0, len(posts))
+	for _, post := range posts {
+		if write && s.access.CanWrite(ctx, post.SpaceID) || !write && s.access.CanRead(ctx, post.SpaceID) {
+			kept = append(kept, post)
+		}
+	}
+	return kept
+}
+
+func (s *Service) indexPost(postID int) error {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.SetPostTags(postID, utils.ParseTags(post.Content))
+}
+
+func contentChange(postID int, content string) storage.PostContentChange {
+	return storage.PostContentChange{
+		PostID:  postID,
+		Content: content,
+		Tags:    utils.ParseTags(content),
+	}
+}
+
+func hasTag(content, tag string) bool {
+	for _, existing := range utils.ParseTags(content) {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedPostIDs(posts map[int]models.Post) []int {
+	ids := make([]int, 0, len(posts))
+	for id := range posts {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,275 @@
+package tags
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func setupTagsTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_tags_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func tagCounts(t *testing.T, service *Service, spaceID *int, recursive bool) map[string]int {
+	t.Helper()
+	counts, err := service.GetTags(context.Background(), spaceID, recursive)
+	if err != nil {
+		t.Fatalf("GetTags failed: %v", err)
+	}
+	result := make(map[string]int)
+	for _, c := range counts {
+		result[c.Tag] = c.Count
+	}
+	return result
+}
+
+func TestTagIndex(t *testing.T) {
+	db, cleanup := setupTagsTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	meetings, _ := db.CreateSpace("Meetings", &work.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+	catCache.Set(meetings)
+
+	// Posts written before startup are indexed by Initialize
+	db.CreatePost(context.Background(), work.ID, "Ship it #Release #todo")
+	db.CreatePost(context.Background(), meetings.ID, "Standup notes\n#todo")
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	post, _ := db.CreatePost(context.Background(), meetings.ID, "Retro #release")
+	service.HandleEvent(events.Event{
+		Type: events.PostCreated,
+		Data: events.PostEvent{PostID: post.ID, SpaceID: meetings.ID},
+	})
+
+	all := tagCounts(t, service, nil, false)
+	if all["todo"] != 2 || all["release"] != 2 {
+		t.Errorf("Unexpected tag counts: %v", all)
+	}
+
+	direct := tagCounts(t, service, &meetings.ID, false)
+	if len(direct) != 2 || direct["release"] != 1 {
+		t.Errorf("Unexpected direct tag counts: %v", direct)
+	}
+
+	recursive := tagCounts(t, service, &work.ID, true)
+	if recursive["todo"] != 2 {
+		t.Errorf("Unexpected recursive tag counts: %v", recursive)
+	}
+
+	missing := 999
+	if _, err := service.GetTags(context.Background(), &missing, false); err == nil {
+		t.Error("Expected error for unknown space")
+	}
+}
+
+func TestRenameAndMerge(t *testing.T) {
+	db, cleanup := setupTagsTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	first, _ := db.CreatePost(context.Background(), space.ID, "Read later #todo")
+	second, _ := db.CreatePost(context.Background(), space.ID, "Pay bills #to-do #todos")
+	third, _ := db.CreatePost(context.Background(), space.ID, "Call mom #todos")
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	if _, err := service.Rename(context.Background(), "todo", "todos"); err == nil || err.Error() != config.ErrTagAlreadyExists {
+		t.Errorf("Expected already exists error, got %v", err)
+	}
+	if _, err := service.Rename(context.Background(), "missing", "other"); err == nil || err.Error() != config.ErrTagNotFound {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+
+	result, err := service.Merge(context.Background(), []string{"#to-do", "TODOS"}, "todo")
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+	if result.PostsChanged != 2 {
+		t.Errorf("Expected 2 posts changed, got %d", result.PostsChanged)
+	}
+
+	updated, _ := db.GetPost(second.ID)
+	if updated.Content != "Pay bills #todo" {
+		t.Errorf("Unexpected merged content: %q", updated.Content)
+	}
+	updated, _ = db.GetPost(third.ID)
+	if updated.Content != "Call mom #todo" {
+		t.Errorf("Unexpected merged content: %q", updated.Content)
+	}
+
+	if counts := tagCounts(t, service, nil, false); len(counts) != 1 || counts["todo"] != 3 {
+		t.Errorf("Unexpected tag counts after merge: %v", counts)
+	}
+
+	if _, err := service.Rename(context.Background(), "todo", "Later"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	updated, _ = db.GetPost(first.ID)
+	if updated.Content != "Read later #later" {
+		t.Errorf("Unexpected renamed content: %q", updated.Content)
+	}
+
+	entries, err := service.GetAuditLog(10)
+	if err != nil {
+		t.Fatalf("GetAuditLog failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Action != AuditActionRename || entries[1].Action != AuditActionMerge {
+		t.Fatalf("Unexpected audit entries: %+v", entries)
+	}
+
+	var details struct {
+		From    string `json:"from"`
+		To      string `json:"to"`
+		PostIDs []int  `json:"post_ids"`
+	}
+	json.Unmarshal(entries[0].Details, &details)
+	if details.From != "todo" || details.To != "later" || len(details.PostIDs) != 3 {
+		t.Errorf("Unexpected rename audit details: %+v", details)
+	}
+}
+
+func TestBulk(t *testing.T) {
+	db, cleanup := setupTagsTestDB(t)
+	defer cleanup()
+
+	work, _ := db.CreateSpace("Work", nil, "")
+	meetings, _ := db.CreateSpace("Meetings", &work.ID, "")
+	home, _ := db.CreateSpace("Home", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(work)
+	catCache.Set(meetings)
+	catCache.Set(home)
+
+	standup, _ := db.CreatePost(context.Background(), meetings.ID, "Standup with the team")
+	review, _ := db.CreatePost(context.Background(), work.ID, "Code review\n\n#urgent")
+	garden, _ := db.CreatePost(context.Background(), home.ID, "Garden with the kids")
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		tag           string
+		action        string
+		filter        BulkFilter
+		expectedError string
+		expectedIDs   []int
+	}{
+		{"No filter", "q3", BulkActionAdd, BulkFilter{}, config.ErrBulkTagFilterRequired, nil},
+		{"Invalid action", "q3", "toggle", BulkFilter{PostIDs: []int{standup.ID}}, config.ErrInvalidBulkTagAction, nil},
+		{"Invalid tag", "3q", BulkActionAdd, BulkFilter{PostIDs: []int{standup.ID}}, config.ErrInvalidTag, nil},
+		{"Direct space", "q3", BulkActionAdd, BulkFilter{SpaceID: &work.ID}, "", []int{review.ID}},
+		{"Recursive space", "q3", BulkActionAdd, BulkFilter{SpaceID: &work.ID, Recursive: true}, "", []int{standup.ID}},
+		{"Contains across spaces", "people", BulkActionAdd, BulkFilter{Contains: "WITH THE"}, "", []int{standup.ID, garden.ID}},
+		{"Post IDs", "urgent", BulkActionRemove, BulkFilter{PostIDs: []int{review.ID, garden.ID}}, "", []int{review.ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.Bulk(context.Background(), tt.tag, tt.action, tt.filter)
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("Expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Bulk failed: %v", err)
+			}
+			if len(result.PostIDs) != len(tt.expectedIDs) {
+				t.Fatalf("Expected posts %v, got %v", tt.expectedIDs, result.PostIDs)
+			}
+			for i, id := range tt.expectedIDs {
+				if result.PostIDs[i] != id {
+					t.Errorf("Expected posts %v, got %v", tt.expectedIDs, result.PostIDs)
+				}
+			}
+		})
+	}
+
+	updated, _ := db.GetPost(standup.ID)
+	if updated.Content != "Standup with the team\n\n#q3 #people" {
+		t.Errorf("Unexpected content: %q", updated.Content)
+	}
+	updated, _ = db.GetPost(review.ID)
+	if updated.Content != "Code review\n\n#q3" {
+		t.Errorf("Unexpected content: %q", updated.Content)
+	}
+}
+
+func TestPreviewChangesNothing(t *testing.T) {
+	db, cleanup := setupTagsTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	idea, _ := db.CreatePost(context.Background(), space.ID, "Idea #draft")
+	plan, _ := db.CreatePost(context.Background(), space.ID, "Plan #wip")
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	rename, err := service.PreviewRename(context.Background(), "draft", "idea")
+	if err != nil || rename.Operation != "rename_tag" || !rename.DryRun || rename.PostCount != 1 || rename.PostIDs[0] != idea.ID {
+		t.Errorf("Unexpected rename preview %+v, %v", rename, err)
+	}
+	merge, err := service.PreviewMerge(context.Background(), []string{"wip"}, "draft")
+	if err != nil || merge.PostCount != 1 || merge.PostIDs[0] != plan.ID {
+		t.Errorf("Unexpected merge preview %+v, %v", merge, err)
+	}
+	bulk, err := service.PreviewBulk(context.Background(), "q3", BulkActionAdd, BulkFilter{SpaceID: &space.ID})
+	if err != nil || bulk.PostCount != 2 {
+		t.Errorf("Unexpected bulk preview %+v, %v", bulk, err)
+	}
+	if _, err := service.PreviewRename(context.Background(), "wip", "draft"); err == nil || err.Error() != config.ErrTagAlreadyExists {
+		t.Errorf("Expected previews to validate like the operation, got %v", err)
+	}
+
+	if stored, _ := db.GetPost(idea.ID); stored.Content != "Idea #draft" {
+		t.Errorf("Expected the post to be left unchanged, got %q", stored.Content)
+	}
+	if entries, _ := service.GetAuditLog(10); len(entries) != 0 {
+		t.Errorf("Expected no audit entry, got %d", len(entries))
+	}
+}
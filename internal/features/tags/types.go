@@ -0,0 +1,50 @@
+package tags
+
+import "backthynk/internal/storage"
+
+// Audit actions recorded for tag operations
+const (
+	AuditActionRename = "tags.rename"
+	AuditActionMerge  = "tags.merge"
+	AuditActionBulk   = "tags.bulk"
+
+	BulkActionAdd    = "add"
+	BulkActionRemove = "remove"
+)
+
+type TagsResponse struct {
+	SpaceID   *int               `json:"space_id,omitempty"`
+	Recursive bool               `json:"recursive"`
+	Tags      []storage.TagCount `json:"tags"`
+}
+
+type RenameRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type MergeRequest struct {
+	Sources []string `json:"sources"`
+	Target  string   `json:"target"`
+}
+
+// BulkFilter selects the posts a bulk tag operation applies to; criteria are combined
+type BulkFilter struct {
+	SpaceID   *int   `json:"space_id,omitempty"`
+	Recursive bool   `json:"recursive"`
+	PostIDs   []int  `json:"post_ids,omitempty"`
+	Contains  string `json:"contains,omitempty"`
+}
+
+type BulkRequest struct {
+	Tag    string     `json:"tag"`
+	Action string     `json:"action"`
+	Filter BulkFilter `json:"filter"`
+}
+
+// OperationResult reports the outcome of a tag operation
+type OperationResult struct {
+	AuditID      int   `json:"audit_id"`
+	PostsChanged int   `json:"posts_changed"`
+	PostIDs      []int `json:"post_ids"`
+}
@@ -0,0 +1,70 @@
+package tasks
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/spaces/tasks", h.GetSpaceTasks).Methods("GET")
+	api.HandleFunc("/posts/{id:[0-9]+}/tasks/{index}", h.ToggleTask).Methods("PATCH")
+}
+
+// GetSpaceTasks handles GET /api/spaces/tasks
+func (h *Handler) GetSpaceTasks(w http.ResponseWriter, r *http.Request) {
+	response := SpaceTasksResponse{
+		Spaces: h.service.GetSpaceTasks(r.Context()),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ToggleTask handles PATCH /api/posts/{id}/tasks/{index}
+func (h *Handler) ToggleTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	postID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidPostID, http.StatusBadRequest)
+		return
+	}
+
+	index, err := strconv.Atoi(vars["index"])
+	if err != nil || index < 0 {
+		http.Error(w, config.ErrInvalidTaskIndex, http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.service.ToggleTask(r.Context(), postID, index)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case config.ErrTaskNotFound, config.ErrPostNotFound, "post not found":
+			status = http.StatusNotFound
+		case config.ErrSpaceAccessDenied:
+			status = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
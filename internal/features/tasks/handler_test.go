@@ -0,0 +1,60 @@
+package tasks
+
+import (
+	"backthynk/internal/core/cache"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/spaces/tasks", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected tasks route NOT to be registered when disabled")
+	}
+}
+
+func TestToggleTaskHandler(t *testing.T) {
+	db, cleanup := setupTasksTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Todo", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+	db.CreatePost(context.Background(), space.ID, "- [ ] only")
+
+	handler := NewHandler(NewService(db, catCache, true))
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"Valid toggle", "/api/posts/1/tasks/0", http.StatusOK},
+		{"Invalid index", "/api/posts/1/tasks/abc", http.StatusBadRequest},
+		{"Negative index", "/api/posts/1/tasks/-1", http.StatusBadRequest},
+		{"Missing task", "/api/posts/1/tasks/3", http.StatusNotFound},
+		{"Missing post", "/api/posts/999/tasks/0", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
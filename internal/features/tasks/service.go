@@ -0,0 +1,238 @@
+package tasks
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/services"
+	"backthynk/internal/core/utils"
+	"backthynk/internal/storage"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	db       *storage.DB
+	catCache *cache.SpaceCache
+	counts   map[int]storage.TaskCounts // spaceID -> counts of direct posts
+	access   *services.SpaceAccess
+	mu       sync.RWMutex
+	postMu   sync.Mutex // serializes read-modify-write of post content on toggle
+	enabled  bool
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		counts:   make(map[int]storage.TaskCounts),
+		enabled:  enabled,
+	}
+}
+
+// SetSpaceAccess leaves the spaces hidden from the viewer of a request out of its task counts
+// and keeps it from toggling tasks of posts it may not change
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+func (s *Service) Initialize() error {
+	if !s.enabled {
+		return nil
+	}
+
+	// Count checklist items of posts created before the feature was enabled
+	postIDs, err := s.db.GetPostIDsWithoutTaskCounts()
+	if err != nil {
+		return err
+	}
+	for _, postID := range postIDs {
+		if err := s.indexPost(postID); err != nil {
+			return err
+		}
+	}
+
+	counts, err := s.db.GetTaskCountsBySpace()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.counts = counts
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Service) HandleEvent(event events.Event) error {
+	if !s.enabled {
+		return nil
+	}
+
+	switch event.Type {
+	case events.PostCreated, events.PostMerged, events.PostUpdated:
+		data := event.Data.(events.PostEvent)
+		if err := s.indexPost(data.PostID); err != nil {
+			return err
+		}
+		return s.refreshSpace(data.SpaceID)
+
+	case events.PostSplit:
+		data := event.Data.(events.PostEvent)
+		if err := s.indexPost(data.PostID); err != nil {
+			return err
+		}
+		for _, split := range data.SplitPosts {
+			if err := s.indexPost(split.PostID); err != nil {
+				return err
+			}
+		}
+		return s.refreshSpace(data.SpaceID)
+
+	case events.PostDeleted:
+		data := event.Data.(events.PostEvent)
+		return s.refreshSpace(data.SpaceID)
+
+	case events.PostMoved:
+		data := event.Data.(events.PostEvent)
+		if data.OldSpaceID != nil {
+			if err := s.refreshSpace(*data.OldSpaceID); err != nil {
+				return err
+			}
+		}
+		return s.refreshSpace(data.SpaceID)
+
+	case events.SpaceDeleted:
+		// Drop entries for spaces removed with the deleted subtree
+		s.mu.Lock()
+		for spaceID := range s.counts {
+			if _, ok := s.catCache.Get(spaceID); !ok {
+				delete(s.counts, spaceID)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	return nil
+}
+
+// ToggleTask flips the checklist item at index in a post and stores the updated content
+func (s *Service) ToggleTask(ctx context.Context, postID, index int) (*ToggleResponse, error) {
+	s.postMu.Lock()
+	defer s.postMu.Unlock()
+
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.access.CanRead(ctx, post.SpaceID) {
+		return nil, fmt.Errorf(config.ErrPostNotFound)
+	}
+	if !s.access.CanWrite(ctx, post.SpaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+
+	content, _, found := utils.ToggleTask(post.Content, index)
+	if !found {
+		return nil, fmt.Errorf(config.ErrTaskNotFound)
+	}
+
+	if err := s.db.UpdatePostContent(postID, content); err != nil {
+		return nil, err
+	}
+
+	tasks := utils.ParseTasks(content)
+	total, done := utils.CountTasks(content)
+	if err := s.db.SetPostTaskCounts(postID, total, done); err != nil {
+		return nil, err
+	}
+	if err := s.refreshSpace(post.SpaceID); err != nil {
+		return nil, err
+	}
+
+	return &ToggleResponse{
+		PostID: postID,
+		Task:   tasks[index],
+		Total:  total,
+		Done:   done,
+	}, nil
+}
+
+// GetSpaceTasks lists checklist counts for every space whose subtree contains tasks, as seen by
+// the viewer of ctx
+func (s *Service) GetSpaceTasks(ctx context.Context) []SpaceTasks {
+	if !s.enabled {
+		return []SpaceTasks{}
+	}
+	hidden := s.access.Hidden(ctx)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []SpaceTasks{}
+	for _, space := range s.catCache.GetAll() {
+		if hidden[space.ID] {
+			continue
+		}
+		direct := s.counts[space.ID]
+		entry := SpaceTasks{
+			SpaceID:       space.ID,
+			Open:          direct.Total - direct.Done,
+			Done:          direct.Done,
+			RecursiveOpen: direct.Total - direct.Done,
+			RecursiveDone: direct.Done,
+		}
+		for _, descID := range s.catCache.GetDescendants(space.ID) {
+			if hidden[descID] {
+				continue
+			}
+			c := s.counts[descID]
+			entry.RecursiveOpen += c.Total - c.Done
+			entry.RecursiveDone += c.Done
+		}
+
+		if entry.RecursiveOpen == 0 && entry.RecursiveDone == 0 {
+			continue
+		}
+		result = append(result, entry)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].SpaceID < result[j].SpaceID
+	})
+
+	return result
+}
+
+func (s *Service) indexPost(postID int) error {
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		logger.Warning("Failed to load post for task counting", zap.Int("post_id", postID), zap.Error(err))
+		return err
+	}
+
+	total, done := utils.CountTasks(post.Content)
+	return s.db.SetPostTaskCounts(postID, total, done)
+}
+
+func (s *Service) refreshSpace(spaceID int) error {
+	counts, err := s.db.GetSpaceTaskCounts(spaceID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if counts.Total == 0 {
+		delete(s.counts, spaceID)
+	} else {
+		s.counts[spaceID] = counts
+	}
+	s.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package tasks
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/storage"
+	"context"
+	"os"
+	"testing"
+)
+
+func setupTasksTestDB(t *testing.T) (*storage.DB, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_tasks_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestTaskRollupsFollowPostEvents(t *testing.T) {
+	db, cleanup := setupTasksTestDB(t)
+	defer cleanup()
+
+	root, _ := db.CreateSpace("Root", nil, "")
+	child, _ := db.CreateSpace("Child", &root.ID, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(root)
+	catCache.Set(child)
+
+	// A post created before the feature existed is picked up on startup
+	existing, _ := db.CreatePost(context.Background(), root.ID, "- [ ] old task\n- [x] old done")
+
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	post, _ := db.CreatePost(context.Background(), child.ID, "Todo\n- [ ] a\n- [ ] b")
+	service.HandleEvent(events.Event{
+		Type: events.PostCreated,
+		Data: events.PostEvent{PostID: post.ID, SpaceID: child.ID, Timestamp: post.Created},
+	})
+
+	summary := service.GetSpaceTasks(context.Background())
+	if len(summary) != 2 {
+		t.Fatalf("Expected 2 spaces with tasks, got %d", len(summary))
+	}
+	if summary[0].SpaceID != root.ID || summary[0].Open != 1 || summary[0].Done != 1 || summary[0].RecursiveOpen != 3 {
+		t.Errorf("Unexpected root summary: %+v", summary[0])
+	}
+	if summary[1].SpaceID != child.ID || summary[1].Open != 2 {
+		t.Errorf("Unexpected child summary: %+v", summary[1])
+	}
+
+	// Deleting the post removes its tasks from the rollup
+	db.DeletePost(context.Background(), existing.ID)
+	service.HandleEvent(events.Event{
+		Type: events.PostDeleted,
+		Data: events.PostEvent{PostID: existing.ID, SpaceID: root.ID},
+	})
+
+	summary = service.GetSpaceTasks(context.Background())
+	if summary[0].Open != 0 || summary[0].RecursiveOpen != 2 {
+		t.Errorf("Expected root to only roll up child tasks after delete, got %+v", summary[0])
+	}
+}
+
+func TestToggleTask(t *testing.T) {
+	db, cleanup := setupTasksTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Todo", nil, "")
+	catCache := cache.NewSpaceCache()
+	catCache.Set(space)
+
+	post, _ := db.CreatePost(context.Background(), space.ID, "- [ ] first\n- [ ] second")
+	service := NewService(db, catCache, true)
+	if err := service.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	response, err := service.ToggleTask(context.Background(), post.ID, 1)
+	if err != nil {
+		t.Fatalf("ToggleTask failed: %v", err)
+	}
+	if !response.Task.Done || response.Task.Text != "second" || response.Total != 2 || response.Done != 1 {
+		t.Errorf("Unexpected toggle response: %+v", response)
+	}
+
+	updated, _ := db.GetPost(post.ID)
+	if updated.Content != "- [ ] first\n- [x] second" {
+		t.Errorf("Unexpected content after toggle: %q", updated.Content)
+	}
+
+	summary := service.GetSpaceTasks(context.Background())
+	if len(summary) != 1 || summary[0].Open != 1 || summary[0].Done != 1 {
+		t.Errorf("Unexpected summary after toggle: %+v", summary)
+	}
+
+	if _, err := service.ToggleTask(context.Background(), post.ID, 2); err == nil || err.Error() != config.ErrTaskNotFound {
+		t.Errorf("Expected task not found error, got %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package tasks
+
+import "backthynk/internal/core/utils"
+
+// SpaceTasks summarizes checklist items of a space for the dashboard
+type SpaceTasks struct {
+	SpaceID       int `json:"space_id"`
+	Open          int `json:"open"`
+	Done          int `json:"done"`
+	RecursiveOpen int `json:"recursive_open"`
+	RecursiveDone int `json:"recursive_done"`
+}
+
+type SpaceTasksResponse struct {
+	Spaces []SpaceTasks `json:"spaces"`
+}
+
+type ToggleResponse struct {
+	PostID int            `json:"post_id"`
+	Task   utils.TaskItem `json:"task"`
+	Total  int            `json:"total"`
+	Done   int            `json:"done"`
+}
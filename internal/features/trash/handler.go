@@ -0,0 +1,184 @@
+package trash
+
+import (
+	"backthynk/internal/config"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/trash/upcoming", h.GetUpcomingPurges).Methods("GET")
+	api.HandleFunc("/trash/{id:[0-9]+}/restore", h.RestoreItem).Methods("POST")
+	api.HandleFunc("/trash/spaces/{id:[0-9]+}/restore", h.RestoreSpace).Methods("POST")
+	api.HandleFunc("/spaces/{id:[0-9]+}/trash", h.GetSpaceTrash).Methods("GET")
+	api.HandleFunc("/admin/trash/purge", h.Purge).Methods("POST")
+}
+
+// GetUpcomingPurges handles GET /api/trash/upcoming
+// Query parameters:
+// - days: look-ahead window in days (default: 7)
+func (h *Handler) GetUpcomingPurges(w http.ResponseWriter, r *http.Request) {
+	days := config.DefaultTrashUpcomingDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d < 1 || d > config.MaxTrashUpcomingDays {
+			http.Error(w, config.ErrInvalidTrashDays, http.StatusBadRequest)
+			return
+		}
+		days = d
+	}
+
+	items, err := h.service.GetUpcomingPurges(r.Context(), days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(UpcomingPurgesResponse{
+		Days:      days,
+		Retention: h.service.GetRetention(),
+		Items:     items,
+	})
+}
+
+// Purge handles POST /api/admin/trash/purge, a retention pass outside the purge schedule.
+// With dry_run=true it only reports what would be removed.
+func (h *Handler) Purge(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("dry_run") == "true" {
+		summary, err := h.service.PreviewPurge()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+		return
+	}
+
+	purged, err := h.service.Purge()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(PurgeResponse{Purged: purged})
+}
+
+// GetSpaceTrash handles GET /api/spaces/{id}/trash, the recently deleted posts of a space and
+// its descendants
+// Query parameters:
+// - limit: items per page (default: 20, max: 100)
+// - offset: items to skip (default: 0)
+func (h *Handler) GetSpaceTrash(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	limit := config.DefaultSpaceTrashLimit
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= config.MaxSpaceTrashLimit {
+		limit = l
+	}
+	offset := 0
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	items, total, err := h.service.GetSpaceTrash(r.Context(), spaceID, limit, offset)
+	if err != nil {
+		if err.Error() == config.ErrSpaceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(SpaceTrashResponse{
+		SpaceID:    spaceID,
+		Items:      items,
+		TotalCount: total,
+		Offset:     offset,
+		Limit:      limit,
+		HasMore:    offset+len(items) < total,
+	})
+}
+
+// RestoreItem handles POST /api/trash/{id}/restore: the deleted post is put back in place and
+// returned
+func (h *Handler) RestoreItem(w http.ResponseWriter, r *http.Request) {
+	trashID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidTrashItemID, http.StatusBadRequest)
+		return
+	}
+
+	post, err := h.service.Restore(r.Context(), trashID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case config.ErrTrashItemNotFound:
+			status = http.StatusNotFound
+		case config.ErrTrashItemNotPost:
+			status = http.StatusBadRequest
+		case config.ErrSpaceAccessDenied:
+			status = http.StatusForbidden
+		case config.ErrTrashSpaceGone:
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(post)
+}
+
+// RestoreSpace handles POST /api/trash/spaces/{id}/restore: the deleted space is put back under
+// its parent with its subspaces and posts, and returned
+func (h *Handler) RestoreSpace(w http.ResponseWriter, r *http.Request) {
+	spaceID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, config.ErrInvalidSpaceID, http.StatusBadRequest)
+		return
+	}
+
+	space, err := h.service.RestoreSpace(r.Context(), spaceID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case config.ErrTrashSpaceNotFound:
+			status = http.StatusNotFound
+		case config.ErrSpaceAccessDenied:
+			status = http.StatusForbidden
+		case config.ErrTrashParentGone, config.ErrTrashSpaceNameTaken, config.ErrTrashSpaceTooDeep:
+			status = http.StatusConflict
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(space)
+}
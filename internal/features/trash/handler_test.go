@@ -0,0 +1,249 @@
+package trash
+
+import (
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	db, _, cleanup := setupTrashTestDB(t)
+	defer cleanup()
+
+	router := mux.NewRouter()
+	NewHandler(NewService(db, nil, false)).RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/trash/upcoming", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected trash routes NOT to be registered when disabled")
+	}
+}
+
+func TestGetUpcomingPurges(t *testing.T) {
+	db, _, cleanup := setupTrashTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	post, _ := db.CreatePost(context.Background(), space.ID, "Deleted note")
+
+	service := NewService(db, nil, true)
+	service.SetRetention(3, 0, 0)
+	service.KeepPost(post.ID)
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedItems  int
+	}{
+		{"Default window", "", http.StatusOK, 1},
+		{"Short window", "?days=1", http.StatusOK, 0},
+		{"Invalid days", "?days=abc", http.StatusBadRequest, 0},
+		{"Days out of range", "?days=400", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/trash/upcoming"+tt.query, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response UpcomingPurgesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(response.Items) != tt.expectedItems {
+				t.Errorf("Expected %d items, got %d", tt.expectedItems, len(response.Items))
+			}
+			if response.Retention.PostDays != 3 || response.Retention.AttachmentDays != 7 {
+				t.Errorf("Unexpected retention: %+v", response.Retention)
+			}
+		})
+	}
+}
+
+func TestPurgeHandler(t *testing.T) {
+	db, _, cleanup := setupTrashTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	post, _ := db.CreatePost(context.Background(), space.ID, "Deleted note")
+
+	service := NewService(db, nil, true)
+	service.SetRetention(1, 0, 0)
+	service.KeepPost(post.ID)
+	service.now = func() time.Time { return time.Now().AddDate(0, 0, 2) }
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/admin/trash/purge?dry_run=true", nil))
+	var summary models.DryRunSummary
+	json.Unmarshal(w.Body.Bytes(), &summary)
+	if w.Code != http.StatusOK || !summary.DryRun || summary.PostCount != 1 || summary.PostIDs[0] != post.ID {
+		t.Fatalf("Unexpected dry run %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/admin/trash/purge", nil))
+	var response PurgeResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || response.Purged != 1 {
+		t.Errorf("Expected the post to be purged, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSpaceTrashAndRestore(t *testing.T) {
+	db, _, cleanup := setupTrashTestDB(t)
+	defer cleanup()
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	spaceService := services.NewSpaceService(db, catCache, dispatcher)
+	postService := services.NewPostService(db, catCache, dispatcher)
+	spaceService.InitializeCache()
+
+	service := NewService(db, catCache, true)
+	service.SetPostRestorer(postService)
+	postService.SetTrashKeeper(service)
+
+	parent, _ := spaceService.Create(context.Background(), "Projects", nil, "")
+	child, _ := spaceService.Create(context.Background(), "Backthynk", &parent.ID, "")
+	other, _ := spaceService.Create(context.Background(), "Other", nil, "")
+	childPost, _ := postService.Create(context.Background(), child.ID, "Child note", nil)
+	parentPost, _ := postService.Create(context.Background(), parent.ID, "Parent note", nil)
+	otherPost, _ := postService.Create(context.Background(), other.ID, "Other note", nil)
+	for _, id := range []int{childPost.ID, parentPost.ID, otherPost.ID} {
+		if err := postService.Delete(context.Background(), id); err != nil {
+			t.Fatalf("Failed to delete post %d: %v", id, err)
+		}
+	}
+
+	router := mux.NewRouter()
+	NewHandler(service).RegisterRoutes(router)
+
+	listTests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedIDs    []int
+		hasMore        bool
+	}{
+		{"Subtree, most recently deleted first", fmt.Sprintf("/api/spaces/%d/trash", parent.ID), http.StatusOK, []int{parentPost.ID, childPost.ID}, false},
+		{"Leaf space", fmt.Sprintf("/api/spaces/%d/trash", child.ID), http.StatusOK, []int{childPost.ID}, false},
+		{"Paginated", fmt.Sprintf("/api/spaces/%d/trash?limit=1", parent.ID), http.StatusOK, []int{parentPost.ID}, true},
+		{"Unknown space", "/api/spaces/999/trash", http.StatusNotFound, nil, false},
+	}
+
+	for _, tt := range listTests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response SpaceTrashResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(response.Items) != len(tt.expectedIDs) || response.HasMore != tt.hasMore {
+				t.Fatalf("Expected posts %v (has_more %v), got %+v", tt.expectedIDs, tt.hasMore, response)
+			}
+			for i, id := range tt.expectedIDs {
+				if response.Items[i].ItemID != id || response.Items[i].DeletedAt == 0 {
+					t.Errorf("Item %d: expected post %d with deletion time, got %+v", i, id, response.Items[i])
+				}
+			}
+		})
+	}
+
+	items, _, _ := service.GetSpaceTrash(context.Background(), child.ID, 10, 0)
+	childTrashID := items[0].ID
+	items, _, _ = service.GetSpaceTrash(context.Background(), other.ID, 10, 0)
+	otherTrashID := items[0].ID
+	if err := spaceService.Delete(context.Background(), other.ID); err != nil {
+		t.Fatalf("Failed to delete space: %v", err)
+	}
+
+	restoreTests := []struct {
+		name           string
+		trashID        int
+		expectedStatus int
+	}{
+		{"Restore in place", childTrashID, http.StatusOK},
+		{"Already restored", childTrashID, http.StatusNotFound},
+		{"Space deleted since", otherTrashID, http.StatusConflict},
+	}
+
+	for _, tt := range restoreTests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", fmt.Sprintf("/api/trash/%d/restore", tt.trashID), nil))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	if post, err := db.GetPost(childPost.ID); err != nil || post.SpaceID != child.ID {
+		t.Errorf("Expected post %d back in space %d, got %+v (%v)", childPost.ID, child.ID, post, err)
+	}
+
+	service.SetSpaceRestorer(spaceService)
+	spaceService.SetTrashKeeper(service)
+	if err := spaceService.Delete(context.Background(), parent.ID); err != nil {
+		t.Fatalf("Failed to delete space: %v", err)
+	}
+
+	spaceRestoreTests := []struct {
+		name           string
+		spaceID        int
+		expectedStatus int
+	}{
+		{"Subspace without its parent", child.ID, http.StatusNotFound},
+		{"Restore space", parent.ID, http.StatusOK},
+		{"Space already restored", parent.ID, http.StatusNotFound},
+	}
+
+	for _, tt := range spaceRestoreTests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", fmt.Sprintf("/api/trash/spaces/%d/restore", tt.spaceID), nil))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var space models.Space
+			if err := json.Unmarshal(w.Body.Bytes(), &space); err != nil || space.ID != tt.spaceID || space.RecursivePostCount != 1 {
+				t.Errorf("Expected space %d back with its post, got %+v (%v)", tt.spaceID, space, err)
+			}
+		})
+	}
+}
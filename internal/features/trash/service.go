@@ -0,0 +1,618 @@
+package trash
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/jobs"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const labelMaxLength = 80
+
+// PostRestorer puts a trashed post back in place, keeping the post caches and event
+// listeners of the core in step
+type PostRestorer interface {
+	RestorePost(ctx context.Context, trashed models.TrashedPost, attachments []models.Attachment) (*models.Post, error)
+}
+
+// SpaceRestorer puts a trashed space subtree back in place, keeping the space cache and event
+// listeners of the core in step
+type SpaceRestorer interface {
+	RestoreSpaces(ctx context.Context, snapshot models.TrashedSpace, attachments []models.Attachment) (*models.Space, error)
+}
+
+type Service struct {
+	db         *storage.DB
+	catCache   *cache.SpaceCache
+	restorer   PostRestorer
+	spaces     SpaceRestorer
+	access     *services.SpaceAccess
+	enabled    bool
+	retention  Retention
+	uploadsDir string
+	trashDir   string
+	budget     *jobs.Budget
+	stop       chan struct{}
+	now        func() time.Time
+}
+
+func NewService(db *storage.DB, catCache *cache.SpaceCache, enabled bool) *Service {
+	return &Service{
+		db:       db,
+		catCache: catCache,
+		enabled:  enabled,
+		retention: Retention{
+			PostDays:       config.DefaultPostRetentionDays,
+			AttachmentDays: config.DefaultAttachmentRetentionDays,
+			SpaceDays:      config.DefaultSpaceRetentionDays,
+		},
+		uploadsDir: filepath.Join(db.GetStoragePath(), "uploads"),
+		trashDir:   filepath.Join(db.GetStoragePath(), config.TrashSubdir),
+		now:        time.Now,
+	}
+}
+
+// SetRetention configures how long each kind of item stays in the trash; non-positive values keep the default
+func (s *Service) SetRetention(postDays, attachmentDays, spaceDays int) {
+	if postDays > 0 {
+		s.retention.PostDays = postDays
+	}
+	if attachmentDays > 0 {
+		s.retention.AttachmentDays = attachmentDays
+	}
+	if spaceDays > 0 {
+		s.retention.SpaceDays = spaceDays
+	}
+}
+
+// SetPostRestorer makes deleted posts restorable
+func (s *Service) SetPostRestorer(restorer PostRestorer) {
+	s.restorer = restorer
+}
+
+// SetSpaceRestorer makes deleted spaces restorable
+func (s *Service) SetSpaceRestorer(restorer SpaceRestorer) {
+	s.spaces = restorer
+}
+
+// SetSpaceAccess hides the trash of spaces hidden from the viewer of a request and keeps it from
+// restoring into spaces it may only read
+func (s *Service) SetSpaceAccess(access *services.SpaceAccess) {
+	s.access = access
+}
+
+// GetRetention returns the configured retention per item type
+func (s *Service) GetRetention() Retention {
+	return s.retention
+}
+
+// KeepPost moves a post about to be deleted to the trash, along with its attachments
+func (s *Service) KeepPost(postID int) error {
+	if !s.enabled {
+		return nil
+	}
+
+	post, err := s.db.GetPost(postID)
+	if err != nil {
+		return err
+	}
+	attachments, err := s.db.GetAttachmentsByPost(postID)
+	if err != nil {
+		return err
+	}
+	linkPreviews, err := s.db.GetLinkPreviewsByPostID(postID)
+	if err != nil {
+		return err
+	}
+
+	pins, err := s.db.GetPostPins([]int{postID})
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(models.TrashedPost{Post: *post, LinkPreviews: linkPreviews, Pinned: pins[postID]})
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	item := &models.TrashItem{
+		ItemType:  models.TrashItemPost,
+		ItemID:    post.ID,
+		SpaceID:   post.SpaceID,
+		Label:     truncateLabel(post.Content),
+		Payload:   string(payload),
+		DeletedAt: now.UnixMilli(),
+		PurgeAt:   purgeTime(now, s.retention.PostDays),
+	}
+
+	return s.keep(item, attachments, map[int]int{post.ID: post.SpaceID}, now)
+}
+
+// KeepSpaces moves a space subtree about to be deleted to the trash as a single item.
+// spaceIDs starts with the deleted space and lists its descendants parents first.
+func (s *Service) KeepSpaces(spaceIDs []int) error {
+	if !s.enabled || len(spaceIDs) == 0 {
+		return nil
+	}
+
+	root, err := s.db.GetSpace(spaceIDs[0])
+	if err != nil {
+		// Nothing to keep; the delete itself reports the missing space
+		return nil
+	}
+
+	snapshot := models.TrashedSpace{
+		Spaces:       []models.Space{*root},
+		Posts:        []models.Post{},
+		LinkPreviews: []models.LinkPreview{},
+	}
+	for _, id := range spaceIDs[1:] {
+		space, err := s.db.GetSpace(id)
+		if err != nil {
+			return err
+		}
+		snapshot.Spaces = append(snapshot.Spaces, *space)
+	}
+
+	acls, err := s.db.GetSpaceACLs()
+	if err != nil {
+		return err
+	}
+	snapshot.ACLs = make(map[int][]models.SpaceACLEntry)
+	for _, space := range snapshot.Spaces {
+		if entries, ok := acls[space.ID]; ok {
+			snapshot.ACLs[space.ID] = entries
+		}
+	}
+
+	var attachments []models.Attachment
+	postSpaces := make(map[int]int)
+	for _, space := range snapshot.Spaces {
+		postIDs, err := s.db.GetPostIDsBySpace(space.ID)
+		if err != nil {
+			return err
+		}
+		for _, postID := range postIDs {
+			post, err := s.db.GetPost(postID)
+			if err != nil {
+				return err
+			}
+			postAttachments, err := s.db.GetAttachmentsByPost(postID)
+			if err != nil {
+				return err
+			}
+			linkPreviews, err := s.db.GetLinkPreviewsByPostID(postID)
+			if err != nil {
+				return err
+			}
+
+			snapshot.Posts = append(snapshot.Posts, *post)
+			snapshot.LinkPreviews = append(snapshot.LinkPreviews, linkPreviews...)
+			attachments = append(attachments, postAttachments...)
+			postSpaces[postID] = space.ID
+		}
+	}
+
+	postIDs := make([]int, 0, len(snapshot.Posts))
+	for _, post := range snapshot.Posts {
+		postIDs = append(postIDs, post.ID)
+	}
+	if snapshot.Pins, err = s.db.GetPostPins(postIDs); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	parentID := 0
+	if root.ParentID != nil {
+		parentID = *root.ParentID
+	}
+
+	now := s.now()
+	item := &models.TrashItem{
+		ItemType:  models.TrashItemSpace,
+		ItemID:    root.ID,
+		SpaceID:   parentID,
+		Label:     root.Name,
+		Payload:   string(payload),
+		DeletedAt: now.UnixMilli(),
+		PurgeAt:   purgeTime(now, s.retention.SpaceDays),
+	}
+
+	return s.keep(item, attachments, postSpaces, now)
+}
+
+// keep stores item and one child item per attachment, then moves the attachment files to the trash directory
+func (s *Service) keep(item *models.TrashItem, attachments []models.Attachment, postSpaces map[int]int, now time.Time) error {
+	children := make([]*models.TrashItem, 0, len(attachments))
+	for _, attachment := range attachments {
+		payload, err := json.Marshal(attachment)
+		if err != nil {
+			return err
+		}
+		item.Size += attachment.FileSize
+		children = append(children, &models.TrashItem{
+			ItemType:  models.TrashItemAttachment,
+			ItemID:    attachment.ID,
+			SpaceID:   postSpaces[attachment.PostID],
+			Label:     attachment.Filename,
+			Payload:   string(payload),
+			Size:      attachment.FileSize,
+			DeletedAt: now.UnixMilli(),
+			PurgeAt:   purgeTime(now, s.retention.AttachmentDays),
+		})
+	}
+
+	if err := s.db.CreateTrashItems(item, children); err != nil {
+		return err
+	}
+
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(s.trashDir, config.DirectoryPermissions); err != nil {
+		logger.Warning("Failed to create trash directory, attachment files will be lost", zap.Error(err))
+		return nil
+	}
+
+	for _, attachment := range attachments {
+		from := filepath.Join(s.uploadsDir, attachment.FilePath)
+		to := filepath.Join(s.trashDir, attachment.FilePath)
+		if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+			logger.Warning("Failed to move attachment to trash", zap.String("file", attachment.FilePath), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// Purge permanently removes trash items whose retention has expired and returns how many were removed
+func (s *Service) Purge() (int, error) {
+	if !s.enabled {
+		return 0, nil
+	}
+
+	items, err := s.purgeable()
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+		s.removeFile(item)
+	}
+	if err := s.db.DeleteTrashItems(ids); err != nil {
+		return 0, err
+	}
+
+	return len(items), nil
+}
+
+// PreviewPurge reports what Purge would remove now without changing anything
+func (s *Service) PreviewPurge() (*models.DryRunSummary, error) {
+	items, err := s.purgeable()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.DryRunSummary{
+		Operation:        "purge_trash",
+		DryRun:           true,
+		DescendantSpaces: []int{},
+		PostIDs:          []int{},
+	}
+	for _, item := range items {
+		switch item.ItemType {
+		case models.TrashItemAttachment:
+			summary.AttachmentCount++
+			summary.AttachmentBytes += item.Size
+		case models.TrashItemPost:
+			summary.PostIDs = append(summary.PostIDs, item.ItemID)
+		case models.TrashItemSpace:
+			var payload models.TrashedSpace
+			if err := json.Unmarshal([]byte(item.Payload), &payload); err != nil {
+				return nil, err
+			}
+			summary.SpaceCount += len(payload.Spaces)
+			for _, post := range payload.Posts {
+				summary.PostIDs = append(summary.PostIDs, post.ID)
+			}
+		}
+	}
+	summary.PostCount = len(summary.PostIDs)
+
+	return summary, nil
+}
+
+// purgeable returns the trash items whose retention has expired, followed by the attachments
+// kept longer than their post or space, which go with it
+func (s *Service) purgeable() ([]models.TrashItem, error) {
+	due, err := s.db.GetTrashItemsDueBefore(s.now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.TrashItem
+	seen := make(map[int]bool)
+	for _, item := range due {
+		if seen[item.ID] {
+			continue
+		}
+		seen[item.ID] = true
+		items = append(items, item)
+
+		if item.ItemType == models.TrashItemAttachment {
+			continue
+		}
+
+		children, err := s.db.GetTrashItemsByParent(item.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			if !seen[child.ID] {
+				seen[child.ID] = true
+				items = append(items, child)
+			}
+		}
+	}
+
+	return items, nil
+}
+
+func (s *Service) removeFile(item models.TrashItem) {
+	if item.ItemType != models.TrashItemAttachment {
+		return
+	}
+
+	var attachment models.Attachment
+	if err := json.Unmarshal([]byte(item.Payload), &attachment); err != nil || attachment.FilePath == "" {
+		return
+	}
+	os.Remove(filepath.Join(s.trashDir, attachment.FilePath)) // Ignore errors like the regular delete
+}
+
+// StartPurge purges expired items immediately and then once per interval until Stop is called
+func (s *Service) StartPurge(interval time.Duration) {
+	if !s.enabled || s.stop != nil {
+		return
+	}
+
+	s.runPurge()
+
+	s.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.runPurge()
+			case <-stop:
+				return
+			}
+		}
+	}(s.stop)
+}
+
+// SetBudget makes the purge loop share the resource budget of background jobs
+func (s *Service) SetBudget(budget *jobs.Budget) {
+	s.budget = budget
+}
+
+func (s *Service) runPurge() {
+	var count int
+	err := s.budget.Run(context.Background(), "trash-purge", func(job *jobs.Job) error {
+		var err error
+		count, err = s.Purge()
+		return err
+	})
+	if err != nil {
+		logger.Warning("Failed to purge trash", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		logger.Info("Purged expired trash items", zap.Int("count", count))
+	}
+}
+
+// Stop ends the periodic purge loop
+func (s *Service) Stop() {
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// GetUpcomingPurges returns the trash items that will be purged within the next days days, soonest
+// first, leaving out those of spaces hidden from the viewer of ctx
+func (s *Service) GetUpcomingPurges(ctx context.Context, days int) ([]models.TrashItem, error) {
+	if !s.enabled {
+		return []models.TrashItem{}, nil
+	}
+
+	items, err := s.db.GetTrashItemsDueBefore(s.now().AddDate(0, 0, days).UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	hidden := s.access.Hidden(ctx)
+	if hidden == nil {
+		return items, nil
+	}
+	visible := make([]models.TrashItem, 0, len(items))
+	for _, item := range items {
+		if !hidden[item.SpaceID] {
+			visible = append(visible, item)
+		}
+	}
+	return visible, nil
+}
+
+// GetSpaceTrash returns the deleted posts of a space and its descendants, most recently
+// deleted first, along with how many there are in total. Descendants hidden from the viewer of
+// ctx are left out.
+func (s *Service) GetSpaceTrash(ctx context.Context, spaceID, limit, offset int) ([]models.TrashItem, int, error) {
+	if _, ok := s.catCache.Get(spaceID); !ok || !s.access.CanRead(ctx, spaceID) {
+		return nil, 0, fmt.Errorf(config.ErrSpaceNotFound)
+	}
+
+	spaceIDs := s.access.Scope(ctx, append([]int{spaceID}, s.catCache.GetDescendants(spaceID)...))
+	return s.db.GetTrashedPostsBySpaces(spaceIDs, limit, offset)
+}
+
+// Restore puts a deleted post back in the space it was deleted from, under its original ID,
+// with the attachments still in the trash, and removes it from the trash. The viewer of ctx must
+// be allowed to change that space.
+func (s *Service) Restore(ctx context.Context, trashID int) (*models.Post, error) {
+	item, found, err := s.db.GetTrashItem(trashID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf(config.ErrTrashItemNotFound)
+	}
+	if !s.access.CanRead(ctx, item.SpaceID) {
+		return nil, fmt.Errorf(config.ErrTrashItemNotFound)
+	}
+	if item.ItemType != models.TrashItemPost || item.ParentTrashID != nil || s.restorer == nil {
+		return nil, fmt.Errorf(config.ErrTrashItemNotPost)
+	}
+	if !s.access.CanWrite(ctx, item.SpaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+
+	var trashed models.TrashedPost
+	if err := json.Unmarshal([]byte(item.Payload), &trashed); err != nil {
+		return nil, fmt.Errorf("failed to read trashed post: %w", err)
+	}
+
+	ids, attachments, err := s.trashedAttachments(item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files are back before listeners of the restored post look for them
+	s.moveFiles(attachments, s.trashDir, s.uploadsDir)
+	post, err := s.restorer.RestorePost(ctx, trashed, attachments)
+	if err != nil {
+		s.moveFiles(attachments, s.uploadsDir, s.trashDir)
+		return nil, err
+	}
+
+	if err := s.db.DeleteTrashItems(ids); err != nil {
+		logger.Warning("Failed to remove restored post from the trash", zap.Int("trash_id", item.ID), zap.Error(err))
+	}
+
+	return post, nil
+}
+
+// RestoreSpace puts the most recently deleted space with the given ID back under its parent,
+// along with its subspaces, posts and the attachments still in the trash, and removes it from
+// the trash. Posts deleted from the space before it are left in the trash. The viewer of ctx
+// must be allowed to change the parent.
+func (s *Service) RestoreSpace(ctx context.Context, spaceID int) (*models.Space, error) {
+	item, found, err := s.db.GetTrashedSpace(spaceID)
+	if err != nil {
+		return nil, err
+	}
+	if !found || s.spaces == nil || (item.SpaceID != 0 && !s.access.CanRead(ctx, item.SpaceID)) {
+		return nil, fmt.Errorf(config.ErrTrashSpaceNotFound)
+	}
+	if item.SpaceID != 0 && !s.access.CanWrite(ctx, item.SpaceID) {
+		return nil, fmt.Errorf(config.ErrSpaceAccessDenied)
+	}
+
+	var trashed models.TrashedSpace
+	if err := json.Unmarshal([]byte(item.Payload), &trashed); err != nil {
+		return nil, fmt.Errorf("failed to read trashed space: %w", err)
+	}
+
+	ids, attachments, err := s.trashedAttachments(item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files are back before listeners of the restored posts look for them
+	s.moveFiles(attachments, s.trashDir, s.uploadsDir)
+	space, err := s.spaces.RestoreSpaces(ctx, trashed, attachments)
+	if err != nil {
+		s.moveFiles(attachments, s.uploadsDir, s.trashDir)
+		return nil, err
+	}
+
+	if err := s.db.DeleteTrashItems(ids); err != nil {
+		logger.Warning("Failed to remove restored space from the trash", zap.Int("trash_id", item.ID), zap.Error(err))
+	}
+
+	return space, nil
+}
+
+// trashedAttachments returns the attachments trashed with an item, along with the trash IDs of
+// the item and of those attachments. Attachments kept shorter than their item may be gone already.
+func (s *Service) trashedAttachments(trashID int) ([]int, []models.Attachment, error) {
+	children, err := s.db.GetTrashItemsByParent(trashID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := []int{trashID}
+	attachments := make([]models.Attachment, 0, len(children))
+	for _, child := range children {
+		var attachment models.Attachment
+		if err := json.Unmarshal([]byte(child.Payload), &attachment); err != nil {
+			return nil, nil, fmt.Errorf("failed to read trashed attachment: %w", err)
+		}
+		attachments = append(attachments, attachment)
+		ids = append(ids, child.ID)
+	}
+
+	return ids, attachments, nil
+}
+
+func (s *Service) moveFiles(attachments []models.Attachment, fromDir, toDir string) {
+	if len(attachments) == 0 {
+		return
+	}
+	if err := os.MkdirAll(toDir, config.DirectoryPermissions); err != nil {
+		logger.Warning("Failed to create directory for restored attachments", zap.String("path", toDir), zap.Error(err))
+		return
+	}
+
+	for _, attachment := range attachments {
+		from := filepath.Join(fromDir, attachment.FilePath)
+		to := filepath.Join(toDir, attachment.FilePath)
+		if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+			logger.Warning("Failed to move attachment file", zap.String("file", attachment.FilePath), zap.Error(err))
+		}
+	}
+}
+
+func purgeTime(deletedAt time.Time, days int) int64 {
+	return deletedAt.AddDate(0, 0, days).UnixMilli()
+}
+
+func truncateLabel(content string) string {
+	runes := []rune(content)
+	if len(runes) <= labelMaxLength {
+		return content
+	}
+	return string(runes[:labelMaxLength]) + "..."
+}
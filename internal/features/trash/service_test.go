@@ -0,0 +1,388 @@
+package trash
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/cache"
+	"backthynk/internal/core/events"
+	"backthynk/internal/core/models"
+	"backthynk/internal/core/services"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setupTrashTestDB(t *testing.T) (*storage.DB, string, func()) {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_trash_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	return db, tempDir, func() {
+		db.Close()
+		os.RemoveAll(tempDir)
+	}
+}
+
+func createUpload(t *testing.T, db *storage.DB, dir string, postID int, name string) *models.Attachment {
+	uploads := filepath.Join(dir, "uploads")
+	os.MkdirAll(uploads, config.DirectoryPermissions)
+	if err := os.WriteFile(filepath.Join(uploads, name), []byte("data"), config.FilePermissions); err != nil {
+		t.Fatalf("Failed to write upload: %v", err)
+	}
+
+	attachment, err := db.CreateAttachment(postID, name, name, "image/png", 4)
+	if err != nil {
+		t.Fatalf("Failed to create attachment: %v", err)
+	}
+	return attachment
+}
+
+func TestKeepPostAndPurge(t *testing.T) {
+	db, dir, cleanup := setupTrashTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	post, _ := db.CreatePost(context.Background(), space.ID, "Accidentally deleted note")
+	createUpload(t, db, dir, post.ID, "1_photo.png")
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	service := NewService(db, nil, true)
+	service.SetRetention(30, 7, 0)
+	service.now = func() time.Time { return now }
+
+	if err := service.KeepPost(post.ID); err != nil {
+		t.Fatalf("KeepPost failed: %v", err)
+	}
+	if err := db.DeletePost(context.Background(), post.ID); err != nil {
+		t.Fatalf("DeletePost failed: %v", err)
+	}
+
+	trashedFile := filepath.Join(dir, "trash", "1_photo.png")
+	if _, err := os.Stat(trashedFile); err != nil {
+		t.Fatalf("Expected attachment file to be moved to the trash: %v", err)
+	}
+
+	items, _ := service.GetUpcomingPurges(context.Background(), 30)
+	if len(items) != 2 {
+		t.Fatalf("Expected post and attachment in the trash, got %d items", len(items))
+	}
+	if items[0].ItemType != models.TrashItemAttachment || items[1].ItemType != models.TrashItemPost {
+		t.Errorf("Expected attachment to be purged before the post, got %s then %s", items[0].ItemType, items[1].ItemType)
+	}
+	if items[1].ItemID != post.ID || items[1].SpaceID != space.ID || items[1].Size != 4 || items[1].Label != post.Content {
+		t.Errorf("Unexpected post trash item: %+v", items[1])
+	}
+
+	var snapshot models.TrashedPost
+	if err := json.Unmarshal([]byte(items[1].Payload), &snapshot); err != nil || snapshot.Post.Content != post.Content {
+		t.Errorf("Expected payload to hold the post, got %q (%v)", items[1].Payload, err)
+	}
+
+	// Only the attachment is due after a week
+	upcoming, _ := service.GetUpcomingPurges(context.Background(), 7)
+	if len(upcoming) != 1 || upcoming[0].ItemType != models.TrashItemAttachment {
+		t.Errorf("Expected only the attachment within 7 days, got %+v", upcoming)
+	}
+
+	now = now.AddDate(0, 0, 8)
+	summary, err := service.PreviewPurge()
+	if err != nil || summary.AttachmentCount != 1 || summary.AttachmentBytes != 4 || summary.PostCount != 0 {
+		t.Errorf("Expected the preview to report the attachment alone, got %+v (%v)", summary, err)
+	}
+	if _, err := os.Stat(trashedFile); err != nil {
+		t.Errorf("Expected the preview to leave the file in place: %v", err)
+	}
+	if count, err := service.Purge(); err != nil || count != 1 {
+		t.Fatalf("Expected 1 purged item, got %d (%v)", count, err)
+	}
+	if _, err := os.Stat(trashedFile); !os.IsNotExist(err) {
+		t.Error("Expected purged attachment file to be removed")
+	}
+
+	now = now.AddDate(0, 0, 30)
+	if count, err := service.Purge(); err != nil || count != 1 {
+		t.Fatalf("Expected post to be purged, got %d (%v)", count, err)
+	}
+	if items, _ := service.GetUpcomingPurges(context.Background(), 365); len(items) != 0 {
+		t.Errorf("Expected empty trash, got %+v", items)
+	}
+}
+
+func TestKeepSpaces(t *testing.T) {
+	db, dir, cleanup := setupTrashTestDB(t)
+	defer cleanup()
+
+	parent, _ := db.CreateSpace("Parent", nil, "")
+	child, _ := db.CreateSpace("Child", &parent.ID, "")
+	grandchild, _ := db.CreateSpace("Grandchild", &child.ID, "")
+	db.CreatePost(context.Background(), child.ID, "Child post")
+	post, _ := db.CreatePost(context.Background(), grandchild.ID, "Grandchild post")
+	createUpload(t, db, dir, post.ID, "2_doc.png")
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	service := NewService(db, nil, true)
+	service.SetRetention(0, 60, 14)
+	service.now = func() time.Time { return now }
+
+	if err := service.KeepSpaces([]int{child.ID, grandchild.ID}); err != nil {
+		t.Fatalf("KeepSpaces failed: %v", err)
+	}
+
+	items, _ := service.GetUpcomingPurges(context.Background(), 365)
+	if len(items) != 2 {
+		t.Fatalf("Expected space and attachment in the trash, got %d items", len(items))
+	}
+
+	spaceItem := items[0]
+	if spaceItem.ItemType != models.TrashItemSpace || spaceItem.ItemID != child.ID || spaceItem.SpaceID != parent.ID || spaceItem.Label != "Child" {
+		t.Fatalf("Unexpected space trash item: %+v", spaceItem)
+	}
+	if items[1].SpaceID != grandchild.ID || items[1].ParentTrashID == nil || *items[1].ParentTrashID != spaceItem.ID {
+		t.Errorf("Expected attachment to be linked to the space item, got %+v", items[1])
+	}
+
+	var snapshot models.TrashedSpace
+	json.Unmarshal([]byte(spaceItem.Payload), &snapshot)
+	if len(snapshot.Spaces) != 2 || snapshot.Spaces[0].ID != child.ID || len(snapshot.Posts) != 2 {
+		t.Errorf("Unexpected space snapshot: %+v", snapshot)
+	}
+
+	// Attachments kept longer than their space are purged with it
+	now = now.AddDate(0, 0, 15)
+	if count, err := service.Purge(); err != nil || count != 2 {
+		t.Fatalf("Expected space and attachment to be purged, got %d (%v)", count, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "trash", "2_doc.png")); !os.IsNotExist(err) {
+		t.Error("Expected attachment file to be removed with its space")
+	}
+
+	// Missing spaces are left to the delete to report
+	if err := service.KeepSpaces([]int{999}); err != nil {
+		t.Errorf("Expected no error for a missing space, got %v", err)
+	}
+}
+
+func TestDisabledServiceKeepsNothing(t *testing.T) {
+	db, _, cleanup := setupTrashTestDB(t)
+	defer cleanup()
+
+	space, _ := db.CreateSpace("Notes", nil, "")
+	post, _ := db.CreatePost(context.Background(), space.ID, "Gone for good")
+
+	service := NewService(db, nil, false)
+	if err := service.KeepPost(post.ID); err != nil {
+		t.Fatalf("KeepPost failed: %v", err)
+	}
+
+	if items, _ := db.GetTrashItemsDueBefore(time.Now().AddDate(1, 0, 0).UnixMilli()); len(items) != 0 {
+		t.Errorf("Expected disabled trash to keep nothing, got %d items", len(items))
+	}
+}
+
+func TestRestorePost(t *testing.T) {
+	db, dir, cleanup := setupTrashTestDB(t)
+	defer cleanup()
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	spaceService := services.NewSpaceService(db, catCache, dispatcher)
+	postService := services.NewPostService(db, catCache, dispatcher)
+	if err := spaceService.InitializeCache(); err != nil {
+		t.Fatalf("Failed to initialize cache: %v", err)
+	}
+
+	var created []events.PostEvent
+	dispatcher.Subscribe(events.PostCreated, func(event events.Event) error {
+		created = append(created, event.Data.(events.PostEvent))
+		return nil
+	})
+
+	service := NewService(db, catCache, true)
+	service.SetPostRestorer(postService)
+	postService.SetTrashKeeper(service)
+
+	space, _ := spaceService.Create(context.Background(), "Notes", nil, "")
+	post, _ := db.CreatePostWithSource(context.Background(), space.ID, "Accidentally deleted note", 1714564800000, models.PostSourceEmail)
+	catCache.UpdatePostCount(space.ID, 1)
+	attachment := createUpload(t, db, dir, post.ID, "1_photo.png")
+	db.CreateLinkPreview(&models.LinkPreview{PostID: post.ID, URL: "https://example.com", Title: "Example"})
+	db.SetPostPinned(post.ID, true, 1714564900000, 10)
+
+	if err := postService.Delete(context.Background(), post.ID); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+	items, total, err := service.GetSpaceTrash(context.Background(), space.ID, 10, 0)
+	if err != nil || total != 1 || len(items) != 1 {
+		t.Fatalf("Expected the deleted post in the space trash, got %d items (%v)", total, err)
+	}
+
+	restored, err := service.Restore(context.Background(), items[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to restore post: %v", err)
+	}
+	if restored.ID != post.ID || restored.Content != post.Content || restored.Created != post.Created || restored.Source != models.PostSourceEmail {
+		t.Errorf("Expected the post back in place, got %+v", restored)
+	}
+
+	attachments, _ := db.GetAttachmentsByPost(post.ID)
+	if len(attachments) != 1 || attachments[0].ID != attachment.ID {
+		t.Errorf("Expected attachment %d restored, got %+v", attachment.ID, attachments)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "uploads", "1_photo.png")); err != nil {
+		t.Errorf("Expected the attachment file back in uploads: %v", err)
+	}
+	if previews, _ := db.GetLinkPreviewsByPostID(post.ID); len(previews) != 1 {
+		t.Errorf("Expected the link preview restored, got %d", len(previews))
+	}
+	if pins, _ := db.GetPostPins([]int{post.ID}); !restored.Pinned || pins[post.ID] != 1714564900000 {
+		t.Errorf("Expected the post pinned again as of its pin time, got %v", pins)
+	}
+	if cached, _ := catCache.Get(space.ID); cached.PostCount != 1 {
+		t.Errorf("Expected the post counted again, got %d", cached.PostCount)
+	}
+	if len(created) != 1 || created[0].PostID != post.ID {
+		t.Errorf("Expected a creation event for the restored post, got %+v", created)
+	}
+	if _, total, _ := service.GetSpaceTrash(context.Background(), space.ID, 10, 0); total != 0 {
+		t.Errorf("Expected the trash to be empty after restore, got %d items", total)
+	}
+
+	// Restoring twice fails, the item is gone
+	if _, err := service.Restore(context.Background(), items[0].ID); err == nil || err.Error() != config.ErrTrashItemNotFound {
+		t.Errorf("Expected %q, got %v", config.ErrTrashItemNotFound, err)
+	}
+}
+
+func TestRestoreSpace(t *testing.T) {
+	db, dir, cleanup := setupTrashTestDB(t)
+	defer cleanup()
+
+	catCache := cache.NewSpaceCache()
+	dispatcher := events.NewDispatcher()
+	spaceService := services.NewSpaceService(db, catCache, dispatcher)
+	postService := services.NewPostService(db, catCache, dispatcher)
+	if err := spaceService.InitializeCache(); err != nil {
+		t.Fatalf("Failed to initialize cache: %v", err)
+	}
+
+	access := services.NewSpaceAccess(db, catCache)
+	spaceService.SetSpaceAccess(access)
+
+	service := NewService(db, catCache, true)
+	service.SetSpaceRestorer(spaceService)
+	spaceService.SetTrashKeeper(service)
+
+	root, _ := spaceService.Create(context.Background(), "Work", nil, "")
+	parent, _ := spaceService.Create(context.Background(), "Projects", &root.ID, "Ongoing projects")
+	child, _ := spaceService.Create(context.Background(), "Backthynk", &parent.ID, "")
+	parentPost, _ := postService.Create(context.Background(), parent.ID, "Parent note", nil)
+	journal, _, _ := postService.CreateJournal(context.Background(), child.ID, "Today", nil, models.PostSourceManual)
+	attachment := createUpload(t, db, dir, journal.ID, "3_photo.png")
+	db.SetPostPinned(parentPost.ID, true, 1714564900000, 10)
+
+	kept, _ := db.ProvisionUser("kept", "kept@example.com", "Kept", models.RoleViewer, 0)
+	gone, _ := db.ProvisionUser("gone", "gone@example.com", "Gone", models.RoleViewer, 0)
+	acl := []models.SpaceACLEntry{{UserID: kept.ID, Permission: models.SpacePermissionWrite}, {UserID: gone.ID, Permission: models.SpacePermissionRead}}
+	if err := access.SetACL(child.ID, acl); err != nil {
+		t.Fatalf("Failed to set access list: %v", err)
+	}
+
+	if err := spaceService.Delete(context.Background(), parent.ID); err != nil {
+		t.Fatalf("Failed to delete space: %v", err)
+	}
+	if cached, _ := catCache.Get(root.ID); cached.RecursivePostCount != 0 {
+		t.Fatalf("Expected the posts uncounted after delete, got %d", cached.RecursivePostCount)
+	}
+
+	// A space created meanwhile under the same name is in the way
+	taken, _ := spaceService.Create(context.Background(), "projects", &root.ID, "")
+	if _, err := service.RestoreSpace(context.Background(), parent.ID); err == nil || err.Error() != config.ErrTrashSpaceNameTaken {
+		t.Errorf("Expected %q, got %v", config.ErrTrashSpaceNameTaken, err)
+	}
+	spaceService.Delete(context.Background(), taken.ID)
+
+	counts := make(map[events.EventType]int)
+	for _, eventType := range []events.EventType{events.SpaceCreated, events.PostCreated, events.FileUploaded} {
+		eventType := eventType
+		dispatcher.Subscribe(eventType, func(event events.Event) error {
+			counts[eventType]++
+			return nil
+		})
+	}
+
+	// Entries of users deleted meanwhile do not come back
+	if _, err := db.Exec("DELETE FROM users WHERE id = ?", gone.ID); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	restored, err := service.RestoreSpace(context.Background(), parent.ID)
+	if err != nil {
+		t.Fatalf("Failed to restore space: %v", err)
+	}
+	if restored.ID != parent.ID || restored.Description != parent.Description || restored.PostCount != 1 || restored.RecursivePostCount != 2 {
+		t.Errorf("Expected the space back in place with its posts counted, got %+v", restored)
+	}
+	if cached, _ := catCache.Get(root.ID); cached.RecursivePostCount != 2 {
+		t.Errorf("Expected the posts counted again in the parent, got %d", cached.RecursivePostCount)
+	}
+	if children := catCache.GetChildren(parent.ID); len(children) != 1 || children[0] != child.ID {
+		t.Errorf("Expected the subspace back in the hierarchy, got %v", children)
+	}
+
+	if post, err := db.GetPost(parentPost.ID); err != nil || post.SpaceID != parent.ID {
+		t.Errorf("Expected post %d back in space %d, got %+v (%v)", parentPost.ID, parent.ID, post, err)
+	}
+	if post, err := db.GetPost(journal.ID); err != nil || post.Type != models.PostTypeJournal {
+		t.Errorf("Expected the journal post back as the entry of its day, got %+v (%v)", post, err)
+	}
+	if attachments, _ := db.GetAttachmentsByPost(journal.ID); len(attachments) != 1 || attachments[0].ID != attachment.ID {
+		t.Errorf("Expected attachment %d restored, got %+v", attachment.ID, attachments)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "uploads", "3_photo.png")); err != nil {
+		t.Errorf("Expected the attachment file back in uploads: %v", err)
+	}
+	if pins, _ := db.GetPostPins([]int{parentPost.ID, journal.ID}); len(pins) != 1 || pins[parentPost.ID] != 1714564900000 {
+		t.Errorf("Expected the pinned post pinned again, got %v", pins)
+	}
+	if entries := access.GetACL(child.ID); len(entries) != 2 {
+		t.Errorf("Expected the access list of the subspace in effect again, got %+v", entries)
+	}
+	if acls, _ := db.GetSpaceACLs(); len(acls[child.ID]) != 1 || acls[child.ID][0].UserID != kept.ID {
+		t.Errorf("Expected the access list restored without the deleted user, got %+v", acls[child.ID])
+	}
+	if counts[events.SpaceCreated] != 2 || counts[events.PostCreated] != 2 || counts[events.FileUploaded] != 1 {
+		t.Errorf("Expected creation events for the restored spaces, posts and files, got %v", counts)
+	}
+
+	// Restoring twice fails, the item is gone
+	if _, err := service.RestoreSpace(context.Background(), parent.ID); err == nil || err.Error() != config.ErrTrashSpaceNotFound {
+		t.Errorf("Expected %q, got %v", config.ErrTrashSpaceNotFound, err)
+	}
+
+	// A subspace deleted before its parent waits for the parent to come back
+	spaceService.Delete(context.Background(), child.ID)
+	spaceService.Delete(context.Background(), parent.ID)
+	if _, err := service.RestoreSpace(context.Background(), child.ID); err == nil || err.Error() != config.ErrTrashParentGone {
+		t.Errorf("Expected %q, got %v", config.ErrTrashParentGone, err)
+	}
+	if _, err := service.RestoreSpace(context.Background(), parent.ID); err != nil {
+		t.Fatalf("Failed to restore parent: %v", err)
+	}
+	if _, err := service.RestoreSpace(context.Background(), child.ID); err != nil {
+		t.Errorf("Expected the subspace restorable once its parent is back, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package trash
+
+import "backthynk/internal/core/models"
+
+// Retention holds how many days each kind of trashed item is kept before being purged
+type Retention struct {
+	PostDays       int `json:"post_days"`
+	AttachmentDays int `json:"attachment_days"`
+	SpaceDays      int `json:"space_days"`
+}
+
+// UpcomingPurgesResponse lists trash items that will be purged within the next Days days
+type UpcomingPurgesResponse struct {
+	Days      int                `json:"days"`
+	Retention Retention          `json:"retention"`
+	Items     []models.TrashItem `json:"items"`
+}
+
+// PurgeResponse reports how many trash items a purge removed, attachments included
+type PurgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+// SpaceTrashResponse lists the deleted posts of a space subtree
+type SpaceTrashResponse struct {
+	SpaceID    int                `json:"space_id"`
+	Items      []models.TrashItem `json:"items"`
+	TotalCount int                `json:"total_count"`
+	Offset     int                `json:"offset"`
+	Limit      int                `json:"limit"`
+	HasMore    bool               `json:"has_more"`
+}
@@ -0,0 +1,250 @@
+package workspace
+
+import (
+	"backthynk/internal/config"
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted archives hold the zip archive sealed with AES-256-GCM under a key derived from a
+// passphrase with scrypt. A header carries a magic string, the scrypt cost and salt, and a
+// random nonce prefix; it is authenticated with every chunk. Chunks are written as their
+// sealed length followed by the sealed bytes, and each nonce carries the index of its chunk
+// and whether it is the last one, so chunks cannot be reordered or dropped, nor the archive
+// cut short, without failing to open.
+const (
+	encryptedMagic   = "BTKWSENC"
+	saltSize         = 16
+	noncePrefixSize  = 7
+	headerSize       = len(encryptedMagic) + 1 + saltSize + noncePrefixSize
+	chunkLengthSize  = 4
+	maxSealedChunk   = config.ArchiveChunkSize + 16 // GCM tag
+	lastChunkFlag    = 1
+	scryptBlockSize  = 8
+	scryptParallel   = 1
+	archiveKeyLength = 32
+)
+
+func archiveCipher(passphrase string, logN byte, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<logN, scryptBlockSize, scryptParallel, archiveKeyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive archive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, noncePrefixSize+5)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], index)
+	if last {
+		nonce[len(nonce)-1] = lastChunkFlag
+	}
+	return nonce
+}
+
+// encryptWriter seals what is written to it into an encrypted archive. Close seals the last
+// chunk and must be called for the archive to be readable.
+type encryptWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	buf    []byte
+	index  uint32
+}
+
+// NewEncryptWriter returns a writer sealing the archive written to it into w. Nothing is
+// written to w before the first chunk is sealed.
+func NewEncryptWriter(w io.Writer, passphrase string) (io.WriteCloser, error) {
+	header := make([]byte, headerSize)
+	copy(header, encryptedMagic)
+	header[len(encryptedMagic)] = config.ArchiveScryptLogN
+	if _, err := rand.Read(header[len(encryptedMagic)+1:]); err != nil {
+		return nil, fmt.Errorf("failed to generate archive salt: %w", err)
+	}
+
+	salt := header[len(encryptedMagic)+1 : len(encryptedMagic)+1+saltSize]
+	aead, err := archiveCipher(passphrase, config.ArchiveScryptLogN, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, header: header, buf: make([]byte, 0, config.ArchiveChunkSize)}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		// A full chunk is only sealed once more data follows, the last one is sealed by Close
+		if len(e.buf) == config.ArchiveChunkSize {
+			if err := e.seal(false); err != nil {
+				return written, err
+			}
+		}
+		n := copy(e.buf[len(e.buf):config.ArchiveChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (e *encryptWriter) Close() error {
+	return e.seal(true)
+}
+
+func (e *encryptWriter) seal(last bool) error {
+	if e.index == 0 {
+		if _, err := e.w.Write(e.header); err != nil {
+			return err
+		}
+	}
+
+	sealed := e.aead.Seal(nil, chunkNonce(e.header[headerSize-noncePrefixSize:], e.index, last), e.buf, e.header)
+	var length [chunkLengthSize]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(sealed)))
+	if _, err := e.w.Write(length[:]); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(sealed); err != nil {
+		return err
+	}
+	e.index++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+// decryptReader opens the chunks of an encrypted archive as they are read
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	header  []byte
+	plain   []byte
+	index   uint32
+	done    bool
+	invalid error
+}
+
+// NewDecryptReader reads the header of the encrypted archive held by r and returns a reader
+// of the archive it seals. A wrong passphrase fails on the first read.
+func NewDecryptReader(r io.Reader, passphrase string) (io.Reader, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.HasPrefix(header, []byte(encryptedMagic)) {
+		return nil, fmt.Errorf(config.ErrWorkspaceArchiveInvalid)
+	}
+
+	logN := header[len(encryptedMagic)]
+	if logN == 0 || logN > config.ArchiveScryptLogN+5 {
+		return nil, fmt.Errorf(config.ErrWorkspaceArchiveUnsupported)
+	}
+	aead, err := archiveCipher(passphrase, logN, header[len(encryptedMagic)+1:len(encryptedMagic)+1+saltSize])
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{r: bufio.NewReader(r), aead: aead, header: header}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.invalid != nil {
+			return 0, d.invalid
+		}
+		if d.done {
+			// Nothing may follow the last chunk
+			if _, err := d.r.ReadByte(); err != io.EOF {
+				return 0, fmt.Errorf(config.ErrWorkspaceArchivePassphrase)
+			}
+			return 0, io.EOF
+		}
+		if err := d.open(); err != nil {
+			d.invalid = err
+			return 0, err
+		}
+	}
+
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *decryptReader) open() error {
+	var length [chunkLengthSize]byte
+	if _, err := io.ReadFull(d.r, length[:]); err != nil {
+		return fmt.Errorf("%s: archive is cut short", config.ErrWorkspaceArchivePassphrase)
+	}
+	size := binary.BigEndian.Uint32(length[:])
+	if size > maxSealedChunk {
+		return fmt.Errorf(config.ErrWorkspaceArchivePassphrase)
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(d.r, sealed); err != nil {
+		return fmt.Errorf("%s: archive is cut short", config.ErrWorkspaceArchivePassphrase)
+	}
+
+	prefix := d.header[headerSize-noncePrefixSize:]
+	plain, err := d.aead.Open(nil, chunkNonce(prefix, d.index, false), sealed, d.header)
+	if err != nil {
+		if plain, err = d.aead.Open(nil, chunkNonce(prefix, d.index, true), sealed, d.header); err != nil {
+			return fmt.Errorf(config.ErrWorkspaceArchivePassphrase)
+		}
+		d.done = true
+	}
+	d.plain = plain
+	d.index++
+	return nil
+}
+
+// IsEncrypted reports whether the file at path is an encrypted archive
+func IsEncrypted(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(encryptedMagic))
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return false, nil // Too short for either kind, opening it as a zip archive fails
+	}
+	return string(magic) == encryptedMagic, nil
+}
+
+// decryptFile writes the archive sealed in the encrypted archive at src to dst
+func decryptFile(src, dst, passphrase string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	reader, err := NewDecryptReader(in, passphrase)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, config.FilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to create decrypted archive: %w", err)
+	}
+	if _, err := io.Copy(out, reader); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("failed to write decrypted archive: %w", err)
+	}
+	return nil
+}
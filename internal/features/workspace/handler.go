@@ -0,0 +1,92 @@
+package workspace
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	if !h.service.enabled {
+		return
+	}
+
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/admin/workspace/export", h.Export).Methods("GET")
+	api.HandleFunc("/admin/workspace/export", h.ExportEncrypted).Methods("POST")
+}
+
+// Export handles GET /api/admin/workspace/export, streaming the archive as a zip download.
+// A failure after streaming started can only cut the download short.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	h.export(r.Context(), w, "")
+}
+
+// ExportEncrypted handles POST /api/admin/workspace/export, streaming the archive encrypted
+// with the passphrase of the body, so it can be stored on drives that are not trusted. The
+// passphrase is posted rather than put in the URL to keep it out of logs and history.
+func (h *Handler) ExportEncrypted(w http.ResponseWriter, r *http.Request) {
+	var req ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, config.ErrInvalidWorkspaceExport, http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(req.Passphrase) < config.MinArchivePassphraseLength {
+		http.Error(w, config.ErrArchivePassphraseTooShort, http.StatusBadRequest)
+		return
+	}
+	h.export(r.Context(), w, req.Passphrase)
+}
+
+func (h *Handler) export(ctx context.Context, w http.ResponseWriter, passphrase string) {
+	export, err := h.service.Prepare(ctx)
+	if err != nil {
+		logger.Error("Failed to prepare workspace export", zap.Error(err))
+		http.Error(w, config.ErrWorkspaceExportFailed, http.StatusInternalServerError)
+		return
+	}
+	defer export.Cleanup()
+
+	filename := fmt.Sprintf("backthynk-workspace-%s.zip", time.UnixMilli(export.Manifest.Created).Format("2006-01-02-150405"))
+	if passphrase == "" {
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		if err := export.Write(ctx, w); err != nil {
+			logger.Error("Failed to write workspace export", zap.Error(err))
+		}
+		return
+	}
+
+	// Nothing is written before the headers below
+	encrypted, err := NewEncryptWriter(w, passphrase)
+	if err != nil {
+		logger.Error("Failed to encrypt workspace export", zap.Error(err))
+		http.Error(w, config.ErrWorkspaceExportFailed, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".enc"))
+	if err := export.Write(ctx, encrypted); err != nil {
+		logger.Error("Failed to write workspace export", zap.Error(err))
+		return
+	}
+	if err := encrypted.Close(); err != nil {
+		logger.Error("Failed to write workspace export", zap.Error(err))
+	}
+}
@@ -0,0 +1,81 @@
+package workspace
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRegisterRoutesDisabled(t *testing.T) {
+	handler := NewHandler(&Service{enabled: false})
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/admin/workspace/export", nil)
+	if router.Match(req, &mux.RouteMatch{}) {
+		t.Error("Expected workspace routes NOT to be registered when disabled")
+	}
+}
+
+func TestExportHandler(t *testing.T) {
+	setup := setupWorkspaceTest(t)
+	defer setup.cleanup()
+
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/admin/workspace/export", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "application/zip" {
+		t.Errorf("Expected a zip download, got %s", w.Header().Get("Content-Type"))
+	}
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil || len(archive.File) != 2 || archive.File[0].Name != "manifest.json" {
+		t.Fatalf("Expected an archive with the manifest and database, got %v", err)
+	}
+}
+
+func TestExportEncryptedHandler(t *testing.T) {
+	setup := setupWorkspaceTest(t)
+	defer setup.cleanup()
+
+	router := mux.NewRouter()
+	NewHandler(setup.service).RegisterRoutes(router)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Invalid body", `{`, http.StatusBadRequest},
+		{"Short passphrase", `{"passphrase":"short"}`, http.StatusBadRequest},
+		{"Encrypted export", `{"passphrase":"correct horse battery"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/admin/workspace/export", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK {
+				if !strings.HasSuffix(w.Header().Get("Content-Disposition"), `.zip.enc"`) || !bytes.HasPrefix(w.Body.Bytes(), []byte(encryptedMagic)) {
+					t.Errorf("Expected an encrypted archive download, got %s", w.Header().Get("Content-Disposition"))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package workspace
+
+import (
+	"archive/zip"
+	"backthynk/internal/config"
+	"backthynk/internal/storage"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// restoreFile is an archive entry to extract, with its destination relative to the staging
+// directory of its data directory
+type restoreFile struct {
+	entry *zip.File
+	dir   Directory
+	rel   string
+}
+
+// Restore puts the workspace held by the archive at archivePath in place: the database at
+// dbPath and the files of each data directory under its path. Everything is extracted next
+// to its destination and the database checked before anything is replaced. Existing data is
+// only replaced with force, and then moved aside next to where it was, never deleted. An
+// encrypted archive is opened with passphrase.
+func Restore(archivePath, dbPath string, dirs []Directory, force bool, passphrase string) (*RestoreResult, error) {
+	encrypted, err := IsEncrypted(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", config.ErrWorkspaceArchiveInvalid, err)
+	}
+	if encrypted {
+		if passphrase == "" {
+			return nil, fmt.Errorf(config.ErrWorkspaceArchiveEncrypted)
+		}
+
+		// Zip archives are read out of order, so it is decrypted next to the database first
+		if err := os.MkdirAll(filepath.Dir(dbPath), config.DirectoryPermissions); err != nil {
+			return nil, fmt.Errorf("failed to create storage directory: %w", err)
+		}
+		decrypted := dbPath + ".archive-" + time.Now().Format("20060102-150405")
+		if err := decryptFile(archivePath, decrypted, passphrase); err != nil {
+			return nil, err
+		}
+		defer os.Remove(decrypted)
+		archivePath = decrypted
+	}
+
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", config.ErrWorkspaceArchiveInvalid, err)
+	}
+	defer archive.Close()
+
+	result := &RestoreResult{}
+	database, files, err := readArchive(&archive.Reader, dirs, &result.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := existingData(dbPath, dirs)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 && !force {
+		return nil, fmt.Errorf(config.ErrWorkspaceNotEmpty)
+	}
+
+	// Staged next to their destination, so they are moved in place without copying
+	suffix := ".import-" + time.Now().Format("20060102-150405")
+	staged := []string{dbPath + suffix}
+	cleanup := func() {
+		for _, path := range staged {
+			os.RemoveAll(path)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dbPath), config.DirectoryPermissions); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	if err := extract(database, dbPath+suffix); err != nil {
+		cleanup()
+		return nil, err
+	}
+	messages, err := storage.CheckDatabaseFile(dbPath+suffix, config.SupportBundleIntegrityLimit)
+	if err != nil || len(messages) != 1 || messages[0] != "ok" {
+		cleanup()
+		if err == nil {
+			err = fmt.Errorf("%s", strings.Join(messages, "; "))
+		}
+		return nil, fmt.Errorf("%s: database check failed: %w", config.ErrWorkspaceArchiveInvalid, err)
+	}
+
+	stagedDirs := make(map[string]bool)
+	for _, file := range files {
+		if !stagedDirs[file.dir.Path] {
+			stagedDirs[file.dir.Path] = true
+			staged = append(staged, file.dir.Path+suffix)
+		}
+		if err := extract(file.entry, filepath.Join(file.dir.Path+suffix, file.rel)); err != nil {
+			cleanup()
+			return nil, err
+		}
+		result.Files++
+	}
+
+	aside := ".pre-import-" + time.Now().Format("20060102-150405")
+	for _, path := range existing {
+		if err := os.Rename(path, path+aside); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to move %s aside: %w", path, err)
+		}
+		result.MovedTo = append(result.MovedTo, path+aside)
+	}
+
+	if err := os.Rename(dbPath+suffix, dbPath); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to move database in place: %w", err)
+	}
+	for _, dir := range dirs {
+		if !stagedDirs[dir.Path] {
+			continue
+		}
+		if err := os.Rename(dir.Path+suffix, dir.Path); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("failed to move %s files in place: %w", dir.Name, err)
+		}
+	}
+
+	return result, nil
+}
+
+// readArchive checks the manifest and entries of an archive, returning the database entry
+// and the files to extract. Every entry must belong to a known data directory.
+func readArchive(archive *zip.Reader, dirs []Directory, manifest *Manifest) (*zip.File, []restoreFile, error) {
+	byName := make(map[string]Directory, len(dirs))
+	for _, dir := range dirs {
+		byName[dir.Name] = dir
+	}
+
+	var database *zip.File
+	var files []restoreFile
+	found := false
+	for _, entry := range archive.File {
+		if entry.Name == "manifest.json" {
+			if err := readManifest(entry, manifest); err != nil {
+				return nil, nil, err
+			}
+			found = true
+			continue
+		}
+		if entry.Name == config.WorkspaceArchiveDatabase {
+			database = entry
+			continue
+		}
+		if strings.HasSuffix(entry.Name, "/") {
+			continue
+		}
+
+		name, rel, _ := strings.Cut(entry.Name, "/")
+		dir, ok := byName[name]
+		rel = filepath.FromSlash(rel)
+		if !ok || rel == "" || !filepath.IsLocal(rel) {
+			return nil, nil, fmt.Errorf("%s: %s", config.ErrWorkspaceArchiveUnsafePath, entry.Name)
+		}
+		files = append(files, restoreFile{entry: entry, dir: dir, rel: rel})
+	}
+
+	if !found || manifest.Format != config.WorkspaceArchiveFormat || database == nil {
+		return nil, nil, fmt.Errorf(config.ErrWorkspaceArchiveInvalid)
+	}
+	if manifest.Version > config.WorkspaceArchiveVersion {
+		return nil, nil, fmt.Errorf(config.ErrWorkspaceArchiveUnsupported)
+	}
+	return database, files, nil
+}
+
+func readManifest(entry *zip.File, manifest *Manifest) error {
+	file, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("%s: %w", config.ErrWorkspaceArchiveInvalid, err)
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(manifest); err != nil {
+		return fmt.Errorf("%s: %w", config.ErrWorkspaceArchiveInvalid, err)
+	}
+	return nil
+}
+
+// existingData returns the database files and non-empty data directories a restore would replace
+func existingData(dbPath string, dirs []Directory) ([]string, error) {
+	var existing []string
+	for _, path := range []string{dbPath, dbPath + "-wal", dbPath + "-shm"} {
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, path)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir.Path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			existing = append(existing, dir.Path)
+		}
+	}
+	return existing, nil
+}
+
+func extract(entry *zip.File, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), config.DirectoryPermissions); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", entry.Name, err)
+	}
+
+	src, err := entry.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", entry.Name, err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, config.FilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", entry.Name, err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to extract %s: %w", entry.Name, err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", entry.Name, err)
+	}
+
+	os.Chtimes(path, entry.Modified, entry.Modified) // Keeps cold storage age checks meaningful
+	return nil
+}
@@ -0,0 +1,185 @@
+package workspace
+
+import (
+	"archive/zip"
+	"backthynk/internal/config"
+	"backthynk/internal/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// Service exports the whole workspace as a zip archive: a consistent copy of the database
+// along with the files of the data directories, so an instance can move to another machine
+// without copying storage paths around. The import side is Restore.
+type Service struct {
+	db      *storage.DB
+	dirs    []Directory
+	now     func() time.Time
+	enabled bool
+}
+
+func NewService(db *storage.DB, enabled bool) *Service {
+	return &Service{
+		db:      db,
+		now:     time.Now,
+		enabled: enabled,
+	}
+}
+
+// DataDirectories returns the directories holding files the database refers to: uploads,
+// trashed attachments, snapshot files and the cold storage tier
+func DataDirectories(service *config.ServiceConfig, options *config.OptionsConfig) []Directory {
+	coldPath := filepath.Join(service.Files.StoragePath, config.ColdStorageSubdir)
+	if options != nil && options.Features.ColdStorage.Path != "" {
+		coldPath = options.Features.ColdStorage.Path
+	}
+	return []Directory{
+		{Name: "uploads", Path: filepath.Join(service.Files.StoragePath, service.Files.UploadsSubdir)},
+		{Name: "trash", Path: filepath.Join(service.Files.StoragePath, config.TrashSubdir)},
+		{Name: "snapshots", Path: filepath.Join(service.Files.StoragePath, config.SnapshotStoreSubdir)},
+		{Name: "cold", Path: coldPath},
+	}
+}
+
+// SetDirectories sets the data directories carried by exports
+func (s *Service) SetDirectories(dirs []Directory) {
+	s.dirs = dirs
+}
+
+type exportFile struct {
+	name string // Entry name in the archive
+	path string
+	info fs.FileInfo
+}
+
+// Export is a workspace archive ready to be written, holding a copy of the database taken
+// when it was prepared. Cleanup removes that copy.
+type Export struct {
+	Manifest Manifest
+	dbCopy   string
+	files    []exportFile
+}
+
+// Prepare copies the database and lists the data files. Files changed after that are
+// written as they are then, so the database copy stays the reference.
+func (s *Service) Prepare(ctx context.Context) (*Export, error) {
+	created := s.now()
+	export := &Export{
+		Manifest: Manifest{
+			Format:      config.WorkspaceArchiveFormat,
+			Version:     config.WorkspaceArchiveVersion,
+			Created:     created.UnixMilli(),
+			Database:    config.WorkspaceArchiveDatabase,
+			Directories: []string{},
+		},
+		dbCopy: filepath.Join(s.db.GetStoragePath(), fmt.Sprintf("export-%d.db", created.UnixNano())),
+	}
+	if shared := config.GetSharedConfig(); shared != nil {
+		export.Manifest.AppVersion = shared.App.Version
+	}
+
+	if err := s.db.BackupTo(export.dbCopy); err != nil {
+		return nil, err
+	}
+
+	for _, dir := range s.dirs {
+		export.Manifest.Directories = append(export.Manifest.Directories, dir.Name)
+		err := filepath.WalkDir(dir.Path, func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && filePath == dir.Path {
+					return filepath.SkipDir // Created by the first file it gets
+				}
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir.Path, filePath)
+			if err != nil {
+				return err
+			}
+			export.files = append(export.files, exportFile{name: path.Join(dir.Name, filepath.ToSlash(rel)), path: filePath, info: info})
+			export.Manifest.Files++
+			export.Manifest.Bytes += info.Size()
+			return nil
+		})
+		if err != nil {
+			export.Cleanup()
+			return nil, fmt.Errorf("failed to list %s files: %w", dir.Name, err)
+		}
+	}
+
+	return export, nil
+}
+
+// Write writes the archive to w. It stops between files once ctx is cancelled.
+func (e *Export) Write(ctx context.Context, w io.Writer) error {
+	archive := zip.NewWriter(w)
+	modified := time.UnixMilli(e.Manifest.Created)
+
+	manifest, err := json.MarshalIndent(e.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: "manifest.json", Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("failed to add manifest to workspace archive: %w", err)
+	}
+	if _, err := entry.Write(manifest); err != nil {
+		return fmt.Errorf("failed to write manifest to workspace archive: %w", err)
+	}
+
+	if err := copyToArchive(archive, e.Manifest.Database, e.dbCopy, zip.Deflate, modified); err != nil {
+		return err
+	}
+	// Attachments are mostly compressed already, they are stored as they are
+	for _, file := range e.files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := copyToArchive(archive, file.name, file.path, zip.Store, file.info.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to close workspace archive: %w", err)
+	}
+	return nil
+}
+
+// Cleanup removes the database copy
+func (e *Export) Cleanup() {
+	os.Remove(e.dbCopy)
+}
+
+func copyToArchive(archive *zip.Writer, name, filePath string, method uint16, modified time.Time) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to workspace archive: %w", name, err)
+	}
+	if _, err := io.Copy(entry, file); err != nil {
+		return fmt.Errorf("failed to write %s to workspace archive: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package workspace
+
+import (
+	"archive/zip"
+	"backthynk/internal/config"
+	"backthynk/internal/storage"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type workspaceTestSetup struct {
+	db      *storage.DB
+	service *Service
+	dir     string
+	cleanup func()
+}
+
+func setupWorkspaceTest(t *testing.T) *workspaceTestSetup {
+	testConfig := &config.ServiceConfig{}
+	testConfig.Files.DatabaseFilename = "test.db"
+	testConfig.Files.UploadsSubdir = "uploads"
+	config.SetServiceConfigForTest(testConfig)
+
+	tempDir, err := os.MkdirTemp("", "backthynk_workspace_test_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	db, err := storage.NewDB(filepath.Join(tempDir, "source"))
+	if err != nil {
+		os.RemoveAll(tempDir)
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	setup := &workspaceTestSetup{
+		db:      db,
+		service: NewService(db, true),
+		dir:     tempDir,
+		cleanup: func() {
+			db.Close()
+			os.RemoveAll(tempDir)
+		},
+	}
+	setup.service.SetDirectories(setup.directories("source"))
+	return setup
+}
+
+// directories returns the data directories of a storage path under the test directory
+func (setup *workspaceTestSetup) directories(name string) []Directory {
+	service := &config.ServiceConfig{}
+	service.Files.StoragePath = filepath.Join(setup.dir, name)
+	service.Files.UploadsSubdir = "uploads"
+	return DataDirectories(service, nil)
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+// export writes an archive of the source workspace to the test directory
+func (setup *workspaceTestSetup) export(t *testing.T) string {
+	export, err := setup.service.Prepare(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to prepare export: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := export.Write(context.Background(), &buf); err != nil {
+		t.Fatalf("Failed to write export: %v", err)
+	}
+	export.Cleanup()
+	if _, err := os.Stat(export.dbCopy); !os.IsNotExist(err) {
+		t.Errorf("Expected the database copy to be removed")
+	}
+
+	path := filepath.Join(setup.dir, "workspace.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to save archive: %v", err)
+	}
+	return path
+}
+
+func TestExportAndRestore(t *testing.T) {
+	setup := setupWorkspaceTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.db.CreateSpace("Projects", nil, "")
+	post, _ := setup.db.CreatePost(context.Background(), space.ID, "Moving machines")
+	setup.db.CreateAttachment(post.ID, "plan.txt", "plan.txt", "text/plain", 4)
+	writeTestFile(t, filepath.Join(setup.dir, "source", "uploads", "plan.txt"), "plan")
+	writeTestFile(t, filepath.Join(setup.dir, "source", "trash", "old", "draft.txt"), "draft")
+
+	archivePath := setup.export(t)
+
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	var manifest Manifest
+	var names []string
+	for _, entry := range archive.File {
+		names = append(names, entry.Name)
+		if entry.Name == "manifest.json" {
+			file, _ := entry.Open()
+			json.NewDecoder(file).Decode(&manifest)
+			file.Close()
+		}
+	}
+	archive.Close()
+	if manifest.Format != config.WorkspaceArchiveFormat || manifest.Files != 2 || manifest.Bytes != 9 {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+	if got := strings.Join(names, ","); got != "manifest.json,database.db,uploads/plan.txt,trash/old/draft.txt" {
+		t.Errorf("Unexpected archive entries %s", got)
+	}
+
+	target := filepath.Join(setup.dir, "target")
+	result, err := Restore(archivePath, filepath.Join(target, "test.db"), setup.directories("target"), false, "")
+	if err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if result.Files != 2 || len(result.MovedTo) != 0 {
+		t.Errorf("Unexpected restore result %+v", result)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(target, "trash", "old", "draft.txt")); err != nil || string(data) != "draft" {
+		t.Errorf("Expected the trash file to be restored, got %q, %v", data, err)
+	}
+	restored, err := storage.NewDB(target)
+	if err != nil {
+		t.Fatalf("Failed to open restored database: %v", err)
+	}
+	defer restored.Close()
+	if got, err := restored.GetPost(post.ID); err != nil || got.Content != "Moving machines" {
+		t.Errorf("Expected the post to be restored, got %+v, %v", got, err)
+	}
+	if attachments, _ := restored.GetAttachmentsByPost(post.ID); len(attachments) != 1 {
+		t.Errorf("Expected the attachment metadata to be restored, got %+v", attachments)
+	}
+	entries, _ := os.ReadDir(target)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".import-") {
+			t.Errorf("Expected no staging leftovers, found %s", entry.Name())
+		}
+	}
+}
+
+func TestRestoreExistingData(t *testing.T) {
+	setup := setupWorkspaceTest(t)
+	defer setup.cleanup()
+
+	setup.db.CreateSpace("Projects", nil, "")
+	archivePath := setup.export(t)
+
+	target := filepath.Join(setup.dir, "target")
+	dbPath := filepath.Join(target, "test.db")
+	writeTestFile(t, dbPath, "existing database")
+	writeTestFile(t, filepath.Join(target, "uploads", "mine.txt"), "mine")
+
+	if _, err := Restore(archivePath, dbPath, setup.directories("target"), false, ""); err == nil || err.Error() != config.ErrWorkspaceNotEmpty {
+		t.Fatalf("Expected existing data to be kept, got %v", err)
+	}
+	if data, _ := os.ReadFile(dbPath); string(data) != "existing database" {
+		t.Fatalf("Expected the existing database untouched")
+	}
+
+	result, err := Restore(archivePath, dbPath, setup.directories("target"), true, "")
+	if err != nil {
+		t.Fatalf("Failed to restore with force: %v", err)
+	}
+	if len(result.MovedTo) != 2 {
+		t.Fatalf("Expected the database and uploads to be moved aside, got %v", result.MovedTo)
+	}
+	for _, path := range result.MovedTo {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("Expected previous data at %s: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(target, "uploads")); !os.IsNotExist(err) {
+		t.Errorf("Expected no uploads directory, the archive has no uploads")
+	}
+	if messages, err := storage.CheckDatabaseFile(dbPath, 10); err != nil || messages[0] != "ok" {
+		t.Errorf("Expected the archived database in place, got %v, %v", messages, err)
+	}
+}
+
+// buildArchive writes an archive with the given entries to the test directory
+func buildArchive(t *testing.T, dir string, entries map[string]string) string {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range entries {
+		file, _ := archive.Create(name)
+		file.Write([]byte(content))
+	}
+	archive.Close()
+
+	path := filepath.Join(dir, "crafted.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to save archive: %v", err)
+	}
+	return path
+}
+
+func TestRestoreRejectsInvalidArchives(t *testing.T) {
+	setup := setupWorkspaceTest(t)
+	defer setup.cleanup()
+
+	manifest := `{"format":"backthynk-workspace","version":1}`
+	tests := []struct {
+		name     string
+		entries  map[string]string
+		expected string
+	}{
+		{"No manifest", map[string]string{"database.db": "x"}, config.ErrWorkspaceArchiveInvalid},
+		{"Other format", map[string]string{"manifest.json": `{"format":"other","version":1}`, "database.db": "x"}, config.ErrWorkspaceArchiveInvalid},
+		{"Newer version", map[string]string{"manifest.json": `{"format":"backthynk-workspace","version":99}`, "database.db": "x"}, config.ErrWorkspaceArchiveUnsupported},
+		{"No database", map[string]string{"manifest.json": manifest}, config.ErrWorkspaceArchiveInvalid},
+		{"Path escape", map[string]string{"manifest.json": manifest, "database.db": "x", "uploads/../../evil": "x"}, config.ErrWorkspaceArchiveUnsafePath},
+		{"Unknown directory", map[string]string{"manifest.json": manifest, "database.db": "x", "etc/passwd": "x"}, config.ErrWorkspaceArchiveUnsafePath},
+		{"Corrupt database", map[string]string{"manifest.json": manifest, "database.db": "not a database"}, config.ErrWorkspaceArchiveInvalid},
+	}
+
+	target := filepath.Join(setup.dir, "target")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := buildArchive(t, setup.dir, tt.entries)
+			_, err := Restore(path, filepath.Join(target, "test.db"), setup.directories("target"), false, "")
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Fatalf("Expected %q, got %v", tt.expected, err)
+			}
+			if entries, _ := os.ReadDir(target); len(entries) != 0 {
+				t.Errorf("Expected nothing left behind, found %d entries", len(entries))
+			}
+		})
+	}
+}
+
+func TestEncryptedExportAndRestore(t *testing.T) {
+	setup := setupWorkspaceTest(t)
+	defer setup.cleanup()
+
+	space, _ := setup.db.CreateSpace("Projects", nil, "")
+	post, _ := setup.db.CreatePost(context.Background(), space.ID, "Stored on a shared drive")
+	// Larger than a chunk, so the archive is sealed in several
+	writeTestFile(t, filepath.Join(setup.dir, "source", "uploads", "big.txt"), strings.Repeat("backthynk", config.ArchiveChunkSize/4))
+
+	export, err := setup.service.Prepare(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to prepare export: %v", err)
+	}
+	var buf bytes.Buffer
+	encrypted, err := NewEncryptWriter(&buf, "correct horse battery")
+	if err != nil {
+		t.Fatalf("Failed to create encrypt writer: %v", err)
+	}
+	if err := export.Write(context.Background(), encrypted); err != nil || encrypted.Close() != nil {
+		t.Fatalf("Failed to write encrypted export: %v", err)
+	}
+	export.Cleanup()
+	if bytes.Contains(buf.Bytes(), []byte("manifest.json")) {
+		t.Fatal("Expected no plaintext in the encrypted archive")
+	}
+
+	save := func(name string, data []byte) string {
+		path := filepath.Join(setup.dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("Failed to save archive: %v", err)
+		}
+		return path
+	}
+	archivePath := save("workspace.zip.enc", buf.Bytes())
+	target := filepath.Join(setup.dir, "target")
+	dbPath := filepath.Join(target, "test.db")
+
+	failures := []struct {
+		name       string
+		path       string
+		passphrase string
+		expected   string
+	}{
+		{"No passphrase", archivePath, "", config.ErrWorkspaceArchiveEncrypted},
+		{"Wrong passphrase", archivePath, "incorrect horse battery", config.ErrWorkspaceArchivePassphrase},
+		{"Cut short", save("short.zip.enc", buf.Bytes()[:buf.Len()-100]), "correct horse battery", config.ErrWorkspaceArchivePassphrase},
+		{"Last chunk dropped", save("dropped.zip.enc", buf.Bytes()[:headerSize+chunkLengthSize+maxSealedChunk]), "correct horse battery", config.ErrWorkspaceArchivePassphrase},
+	}
+	for _, tt := range failures {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Restore(tt.path, dbPath, setup.directories("target"), false, tt.passphrase); err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+				t.Fatalf("Expected %q, got %v", tt.expected, err)
+			}
+			if entries, _ := os.ReadDir(target); len(entries) != 0 {
+				t.Errorf("Expected nothing left behind, found %d entries", len(entries))
+			}
+		})
+	}
+
+	result, err := Restore(archivePath, dbPath, setup.directories("target"), false, "correct horse battery")
+	if err != nil {
+		t.Fatalf("Failed to restore: %v", err)
+	}
+	if result.Files != 1 {
+		t.Errorf("Expected 1 file restored, got %d", result.Files)
+	}
+	restored, err := storage.NewDB(target)
+	if err != nil {
+		t.Fatalf("Failed to open restored database: %v", err)
+	}
+	defer restored.Close()
+	if got, err := restored.GetPost(post.ID); err != nil || got.Content != "Stored on a shared drive" {
+		t.Errorf("Expected the post to be restored, got %+v, %v", got, err)
+	}
+	entries, _ := os.ReadDir(target)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".archive-") {
+			t.Errorf("Expected the decrypted archive to be removed, found %s", entry.Name())
+		}
+	}
+}
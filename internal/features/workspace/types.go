@@ -0,0 +1,32 @@
+package workspace
+
+// Directory is a data directory carried by workspace archives, under Name in the archive
+type Directory struct {
+	Name string
+	Path string
+}
+
+// Manifest describes a workspace archive. The archive also holds the database under
+// Database and the files of every directory under its name.
+type Manifest struct {
+	Format      string   `json:"format"`
+	Version     int      `json:"version"`
+	AppVersion  string   `json:"app_version"`
+	Created     int64    `json:"created"`
+	Database    string   `json:"database"`
+	Directories []string `json:"directories"`
+	Files       int      `json:"files"` // Files of the data directories
+	Bytes       int64    `json:"bytes"`
+}
+
+// RestoreResult reports what an import put in place
+type RestoreResult struct {
+	Manifest Manifest
+	Files    int
+	MovedTo  []string // Where data found in the way was moved, with -force
+}
+
+// ExportRequest is the body of an encrypted workspace export
+type ExportRequest struct {
+	Passphrase string `json:"passphrase"`
+}
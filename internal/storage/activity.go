@@ -1 +1,28 @@
 package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// GetPostTimeBounds returns the earliest and latest creation time (ms) of the posts of a space
+// created within [from, to); found is false when there is none
+func (db *DB) GetPostTimeBounds(spaceID int, from, to int64) (int64, int64, bool, error) {
+	var first, last sql.NullInt64
+	err := db.QueryRow(
+		"SELECT MIN(created), MAX(created) FROM posts WHERE space_id = ? AND created >= ? AND created < ?",
+		spaceID, from, to,
+	).Scan(&first, &last)
+	if err != nil {
+		logger.Error("Failed to get post time bounds", zap.Int("space_id", spaceID), zap.Error(err))
+		return 0, 0, false, fmt.Errorf("failed to get post time bounds: %w", err)
+	}
+	if !first.Valid {
+		return 0, 0, false, nil
+	}
+
+	return first.Int64, last.Int64, true, nil
+}
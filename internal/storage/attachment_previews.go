@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// SaveAttachmentPreview records the preview generated for an attachment, replacing any previous one
+func (db *DB) SaveAttachmentPreview(preview *models.AttachmentPreview) error {
+	_, err := db.Exec(
+		"INSERT OR REPLACE INTO attachment_previews (attachment_id, kind, width, height, created) VALUES (?, ?, ?, ?, ?)",
+		preview.AttachmentID, preview.Kind, preview.Width, preview.Height, preview.Created,
+	)
+	if err != nil {
+		logger.Error("Failed to save attachment preview", zap.Int("attachment_id", preview.AttachmentID), zap.Error(err))
+		return fmt.Errorf("failed to save attachment preview: %w", err)
+	}
+	return nil
+}
+
+// GetAttachmentPreview returns the preview of an attachment, nil when none was generated
+func (db *DB) GetAttachmentPreview(attachmentID int) (*models.AttachmentPreview, error) {
+	var preview models.AttachmentPreview
+	err := db.QueryRow(
+		"SELECT attachment_id, kind, width, height, created FROM attachment_previews WHERE attachment_id = ?",
+		attachmentID,
+	).Scan(&preview.AttachmentID, &preview.Kind, &preview.Width, &preview.Height, &preview.Created)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error("Failed to get attachment preview", zap.Int("attachment_id", attachmentID), zap.Error(err))
+		return nil, fmt.Errorf("failed to get attachment preview: %w", err)
+	}
+	return &preview, nil
+}
+
+// DeleteAttachmentPreview removes the preview record of an attachment
+func (db *DB) DeleteAttachmentPreview(attachmentID int) error {
+	if _, err := db.Exec("DELETE FROM attachment_previews WHERE attachment_id = ?", attachmentID); err != nil {
+		logger.Error("Failed to delete attachment preview", zap.Int("attachment_id", attachmentID), zap.Error(err))
+		return fmt.Errorf("failed to delete attachment preview: %w", err)
+	}
+	return nil
+}
+
+// GetAttachmentPreviewIDs returns the IDs of the attachments that have a preview
+func (db *DB) GetAttachmentPreviewIDs() (map[int]bool, error) {
+	rows, err := db.Query("SELECT attachment_id FROM attachment_previews")
+	if err != nil {
+		logger.Error("Failed to query attachment previews", zap.Error(err))
+		return nil, fmt.Errorf("failed to query attachment previews: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			logger.Error("Failed to scan attachment preview", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan attachment preview: %w", err)
+		}
+		ids[id] = true
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// GetAttachment returns an attachment with its checksum along with the space of its post
+func (db *DB) GetAttachment(id int) (*models.Attachment, int, error) {
+	var attachment models.Attachment
+	var spaceID int
+	err := db.QueryRow(
+		`SELECT a.id, a.post_id, a.filename, a.file_path, a.file_type, a.file_size, COALESCE(c.sha256, ''), p.space_id
+		FROM attachments a JOIN posts p ON p.id = a.post_id
+		LEFT JOIN attachment_checksums c ON c.attachment_id = a.id
+		WHERE a.id = ?`,
+		id,
+	).Scan(&attachment.ID, &attachment.PostID, &attachment.Filename, &attachment.FilePath, &attachment.FileType, &attachment.FileSize, &attachment.SHA256, &spaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, fmt.Errorf("attachment not found")
+		}
+		logger.Error("Failed to get attachment", zap.Int("attachment_id", id), zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return &attachment, spaceID, nil
+}
+
+// GetAttachmentVersions returns the prior versions kept for an attachment, newest first
+func (db *DB) GetAttachmentVersions(attachmentID int) ([]models.AttachmentVersion, error) {
+	rows, err := db.Query(
+		`SELECT id, attachment_id, version, filename, file_path, file_type, file_size, sha256, replaced
+		FROM attachment_versions WHERE attachment_id = ? ORDER BY version DESC`,
+		attachmentID,
+	)
+	if err != nil {
+		logger.Error("Failed to query attachment versions", zap.Int("attachment_id", attachmentID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query attachment versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []models.AttachmentVersion{}
+	for rows.Next() {
+		var version models.AttachmentVersion
+		err := rows.Scan(
+			&version.ID, &version.AttachmentID, &version.Version, &version.Filename, &version.FilePath,
+			&version.FileType, &version.FileSize, &version.SHA256, &version.Replaced,
+		)
+		if err != nil {
+			logger.Error("Failed to scan attachment version", zap.Int("attachment_id", attachmentID), zap.Error(err))
+			return nil, fmt.Errorf("failed to scan attachment version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
+
+// ReplaceAttachmentFile keeps previous as a prior version of its attachment and points the
+// attachment to the file of current. Only the keep newest prior versions are retained; the
+// file paths of the versions dropped are returned so their files can be removed.
+func (db *DB) ReplaceAttachmentFile(previous models.AttachmentVersion, current *models.Attachment, keep int) ([]string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin attachment replacement", zap.Int("attachment_id", current.ID), zap.Error(err))
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`INSERT INTO attachment_versions (attachment_id, version, filename, file_path, file_type, file_size, sha256, replaced)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		current.ID, previous.Version, previous.Filename, previous.FilePath, previous.FileType, previous.FileSize, previous.SHA256, previous.Replaced,
+	)
+	if err != nil {
+		logger.Error("Failed to store attachment version", zap.Int("attachment_id", current.ID), zap.Int("version", previous.Version), zap.Error(err))
+		return nil, fmt.Errorf("failed to store attachment version: %w", err)
+	}
+
+	_, err = tx.Exec(
+		"UPDATE attachments SET filename = ?, file_path = ?, file_type = ?, file_size = ? WHERE id = ?",
+		current.Filename, current.FilePath, current.FileType, current.FileSize, current.ID,
+	)
+	if err != nil {
+		logger.Error("Failed to update attachment file", zap.Int("attachment_id", current.ID), zap.Error(err))
+		return nil, fmt.Errorf("failed to update attachment: %w", err)
+	}
+
+	if _, err := tx.Exec(touchPostQuery, current.PostID, time.Now().UnixMilli()); err != nil {
+		logger.Error("Failed to record post activity", zap.Int("post_id", current.PostID), zap.Error(err))
+		return nil, fmt.Errorf("failed to record post activity: %w", err)
+	}
+
+	// The checksum of the new file is stored by the caller, the old one went with the version
+	if _, err := tx.Exec("DELETE FROM attachment_checksums WHERE attachment_id = ?", current.ID); err != nil {
+		logger.Error("Failed to clear attachment checksum", zap.Int("attachment_id", current.ID), zap.Error(err))
+		return nil, fmt.Errorf("failed to clear attachment checksum: %w", err)
+	}
+
+	rows, err := tx.Query(
+		"SELECT id, file_path FROM attachment_versions WHERE attachment_id = ? ORDER BY version DESC LIMIT -1 OFFSET ?",
+		current.ID, keep,
+	)
+	if err != nil {
+		logger.Error("Failed to query attachment versions to drop", zap.Int("attachment_id", current.ID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query attachment versions: %w", err)
+	}
+	var ids []int
+	var dropped []string
+	for rows.Next() {
+		var id int
+		var filePath string
+		if err := rows.Scan(&id, &filePath); err != nil {
+			rows.Close()
+			logger.Error("Failed to scan attachment version to drop", zap.Int("attachment_id", current.ID), zap.Error(err))
+			return nil, fmt.Errorf("failed to scan attachment version: %w", err)
+		}
+		ids = append(ids, id)
+		dropped = append(dropped, filePath)
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if _, err := tx.Exec("DELETE FROM attachment_versions WHERE id = ?", id); err != nil {
+			logger.Error("Failed to drop attachment version", zap.Int("attachment_id", current.ID), zap.Int("version_id", id), zap.Error(err))
+			return nil, fmt.Errorf("failed to drop attachment version: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit attachment replacement", zap.Int("attachment_id", current.ID), zap.Error(err))
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return dropped, nil
+}
+
+// RewriteAttachmentFile points an attachment to the file of current without keeping the
+// previous file as a version, for rewrites that must not leave the original around
+func (db *DB) RewriteAttachmentFile(current *models.Attachment) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin attachment rewrite", zap.Int("attachment_id", current.ID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"UPDATE attachments SET filename = ?, file_path = ?, file_type = ?, file_size = ? WHERE id = ?",
+		current.Filename, current.FilePath, current.FileType, current.FileSize, current.ID,
+	)
+	if err != nil {
+		logger.Error("Failed to update attachment file", zap.Int("attachment_id", current.ID), zap.Error(err))
+		return fmt.Errorf("failed to update attachment: %w", err)
+	}
+
+	if _, err := tx.Exec(touchPostQuery, current.PostID, time.Now().UnixMilli()); err != nil {
+		logger.Error("Failed to record post activity", zap.Int("post_id", current.PostID), zap.Error(err))
+		return fmt.Errorf("failed to record post activity: %w", err)
+	}
+
+	// The checksum of the new file is stored by the caller
+	if _, err := tx.Exec("DELETE FROM attachment_checksums WHERE attachment_id = ?", current.ID); err != nil {
+		logger.Error("Failed to clear attachment checksum", zap.Int("attachment_id", current.ID), zap.Error(err))
+		return fmt.Errorf("failed to clear attachment checksum: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit attachment rewrite", zap.Int("attachment_id", current.ID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetAttachmentVersionPathsByPost returns the file paths of the prior versions of the
+// attachments of a post, so they can be removed with it
+func (db *DB) GetAttachmentVersionPathsByPost(postID int) ([]string, error) {
+	rows, err := db.Query(
+		`SELECT v.file_path FROM attachment_versions v
+		JOIN attachments a ON a.id = v.attachment_id
+		WHERE a.post_id = ?`,
+		postID,
+	)
+	if err != nil {
+		logger.Error("Failed to query attachment version paths", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query attachment version paths: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var filePath string
+		if err := rows.Scan(&filePath); err != nil {
+			logger.Error("Failed to scan attachment version path", zap.Int("post_id", postID), zap.Error(err))
+			return nil, fmt.Errorf("failed to scan attachment version path: %w", err)
+		}
+		paths = append(paths, filePath)
+	}
+
+	return paths, rows.Err()
+}
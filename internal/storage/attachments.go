@@ -3,7 +3,10 @@ package storage
 import (
 	"backthynk/internal/core/logger"
 	"backthynk/internal/core/models"
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -24,6 +27,11 @@ func (db *DB) CreateAttachment(postID int, filename, filePath, fileType string,
 		return nil, fmt.Errorf("failed to get last insert id: %w", err)
 	}
 
+	if _, err := db.Exec(touchPostQuery, postID, time.Now().UnixMilli()); err != nil {
+		logger.Error("Failed to record post activity", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to record post activity: %w", err)
+	}
+
 	return &models.Attachment{
 		ID:       int(id),
 		PostID:   postID,
@@ -34,8 +42,35 @@ func (db *DB) CreateAttachment(postID int, filename, filePath, fileType string,
 	}, nil
 }
 
+// FilePathInUse reports whether an attachment, a prior version, a cold file or a trashed
+// attachment refers to filePath, ignoring case for case-insensitive filesystems. Such files
+// may be away from the uploads directory and come back later.
+func (db *DB) FilePathInUse(filePath string) (bool, error) {
+	var inUse bool
+	err := db.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM attachments WHERE file_path = ? COLLATE NOCASE)
+		OR EXISTS(SELECT 1 FROM attachment_versions WHERE file_path = ? COLLATE NOCASE)
+		OR EXISTS(SELECT 1 FROM cold_attachments WHERE file_path = ? COLLATE NOCASE)
+		OR EXISTS(
+			SELECT 1 FROM trash_items
+			WHERE item_type = ? AND json_extract(payload, '$.file_path') = ? COLLATE NOCASE
+		)`,
+		filePath, filePath, filePath, models.TrashItemAttachment, filePath,
+	).Scan(&inUse)
+	if err != nil {
+		logger.Error("Failed to check attachment file path", zap.String("file_path", filePath), zap.Error(err))
+		return false, fmt.Errorf("failed to check file path: %w", err)
+	}
+	return inUse, nil
+}
+
 func (db *DB) GetAttachmentsByPost(postID int) ([]models.Attachment, error) {
-	rows, err := db.Query(
+	return db.GetAttachmentsByPostContext(context.Background(), postID)
+}
+
+// GetAttachmentsByPostContext is GetAttachmentsByPost, aborted when ctx is done
+func (db *DB) GetAttachmentsByPostContext(ctx context.Context, postID int) ([]models.Attachment, error) {
+	rows, err := db.QueryContext(ctx,
 		"SELECT id, post_id, filename, file_path, file_type, file_size FROM attachments WHERE post_id = ?",
 		postID,
 	)
@@ -56,7 +91,7 @@ func (db *DB) GetAttachmentsByPost(postID int) ([]models.Attachment, error) {
 		attachments = append(attachments, attachment)
 	}
 
-	return attachments, nil
+	return attachments, rows.Err()
 }
 
 func (db *DB) CreateLinkPreview(preview *models.LinkPreview) error {
@@ -79,10 +114,15 @@ func (db *DB) CreateLinkPreview(preview *models.LinkPreview) error {
 }
 
 func (db *DB) GetLinkPreviewsByPostID(postID int) ([]models.LinkPreview, error) {
+	return db.GetLinkPreviewsByPostIDContext(context.Background(), postID)
+}
+
+// GetLinkPreviewsByPostIDContext is GetLinkPreviewsByPostID, aborted when ctx is done
+func (db *DB) GetLinkPreviewsByPostIDContext(ctx context.Context, postID int) ([]models.LinkPreview, error) {
 	query := `SELECT id, post_id, url, title, description, image_url, site_name
 			  FROM link_previews WHERE post_id = ?`
 	
-	rows, err := db.Query(query, postID)
+	rows, err := db.QueryContext(ctx, query, postID)
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +149,11 @@ type FileStats struct {
 }
 
 func (db *DB) GetAllFileStats() (map[int]FileStats, error) {
+	return db.GetAllFileStatsContext(context.Background())
+}
+
+// GetAllFileStatsContext is GetAllFileStats, aborted when ctx is done
+func (db *DB) GetAllFileStatsContext(ctx context.Context) (map[int]FileStats, error) {
 	query := `
 		SELECT p.space_id, COUNT(a.id), COALESCE(SUM(a.file_size), 0)
 		FROM posts p
@@ -116,7 +161,7 @@ func (db *DB) GetAllFileStats() (map[int]FileStats, error) {
 		GROUP BY p.space_id
 	`
 	
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -138,7 +183,7 @@ func (db *DB) GetAllFileStats() (map[int]FileStats, error) {
 		}
 	}
 	
-	return stats, nil
+	return stats, rows.Err()
 }
 
 // PostFileStats represents file stats for a specific post
@@ -175,4 +220,68 @@ func (db *DB) GetAllPostFileStats() ([]PostFileStats, error) {
 	}
 
 	return postStats, nil
-}
\ No newline at end of file
+}
+// GetMediaAttachments returns attachments whose MIME type starts with one of typePrefixes,
+// newest upload first, together with the total number of matching attachments.
+// A nil spaceIDs slice matches attachments in every space.
+func (db *DB) GetMediaAttachments(ctx context.Context, spaceIDs []int, typePrefixes []string, limit, offset int) ([]models.MediaItem, int, error) {
+	var conditions []string
+	var args []interface{}
+
+	if spaceIDs != nil {
+		placeholders := make([]string, len(spaceIDs))
+		for i, id := range spaceIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		conditions = append(conditions, fmt.Sprintf("p.space_id IN (%s)", strings.Join(placeholders, ",")))
+	}
+
+	typeConditions := make([]string, len(typePrefixes))
+	for i, prefix := range typePrefixes {
+		typeConditions[i] = "a.file_type LIKE ?"
+		args = append(args, prefix+"%")
+	}
+	conditions = append(conditions, "("+strings.Join(typeConditions, " OR ")+")")
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	err := db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM attachments a JOIN posts p ON p.id = a.post_id WHERE "+where,
+		args...,
+	).Scan(&total)
+	if err != nil {
+		logger.Error("Failed to count media attachments", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to count media attachments: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT a.id, a.post_id, a.filename, a.file_path, a.file_type, a.file_size, p.space_id, p.created
+		FROM attachments a JOIN posts p ON p.id = a.post_id
+		WHERE `+where+`
+		ORDER BY a.id DESC LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...,
+	)
+	if err != nil {
+		logger.Error("Failed to query media attachments", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to query media attachments: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.MediaItem{}
+	for rows.Next() {
+		var item models.MediaItem
+		err := rows.Scan(
+			&item.ID, &item.PostID, &item.Filename, &item.FilePath, &item.FileType, &item.FileSize,
+			&item.SpaceID, &item.PostCreated,
+		)
+		if err != nil {
+			logger.Error("Failed to scan media attachment", zap.Error(err))
+			return nil, 0, fmt.Errorf("failed to scan media attachment: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, total, rows.Err()
+}
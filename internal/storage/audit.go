@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// insertAuditEntry stores entry as part of tx so it is only kept when the audited change commits
+func insertAuditEntry(tx *sql.Tx, entry *models.AuditEntry) error {
+	result, err := tx.Exec(
+		"INSERT INTO audit_entries (action, details, created) VALUES (?, ?, ?)",
+		entry.Action, string(entry.Details), entry.Created,
+	)
+	if err != nil {
+		logger.Error("Failed to create audit entry", zap.String("action", entry.Action), zap.Error(err))
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	entry.ID = int(id)
+
+	return nil
+}
+
+// GetAuditEntries returns the most recent audit entries whose action starts with prefix
+func (db *DB) GetAuditEntries(prefix string, limit int) ([]models.AuditEntry, error) {
+	rows, err := db.Query(
+		"SELECT id, action, details, created FROM audit_entries WHERE action LIKE ? ORDER BY created DESC, id DESC LIMIT ?",
+		prefix+"%", limit,
+	)
+	if err != nil {
+		logger.Error("Failed to query audit entries", zap.String("prefix", prefix), zap.Error(err))
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var details string
+		if err := rows.Scan(&entry.ID, &entry.Action, &details, &entry.Created); err != nil {
+			logger.Error("Failed to scan audit entry", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.Details = []byte(details)
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// BoardPin is a post pinned to the board of its space
+type BoardPin struct {
+	Post     models.Post
+	Position int
+	Pinned   int64
+}
+
+// GetBoardPins returns the posts of a space pinned to its board, in board order
+func (db *DB) GetBoardPins(spaceID int) ([]BoardPin, error) {
+	rows, err := db.Query(
+		`SELECT p.id, p.space_id, p.content, p.created, `+postHashColumn+`, b.position, b.pinned
+		FROM board_pins b JOIN posts p ON p.id = b.post_id `+postHashJoin+`
+		WHERE p.space_id = ? ORDER BY b.position, b.pinned`,
+		spaceID,
+	)
+	if err != nil {
+		logger.Error("Failed to query board pins", zap.Int("space_id", spaceID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query board pins: %w", err)
+	}
+	defer rows.Close()
+
+	pins := []BoardPin{}
+	for rows.Next() {
+		var pin BoardPin
+		err := rows.Scan(&pin.Post.ID, &pin.Post.SpaceID, &pin.Post.Content, &pin.Post.Created, &pin.Post.Hash, &pin.Position, &pin.Pinned)
+		if err != nil {
+			logger.Error("Failed to scan board pin", zap.Int("space_id", spaceID), zap.Error(err))
+			return nil, fmt.Errorf("failed to scan board pin: %w", err)
+		}
+		pins = append(pins, pin)
+	}
+
+	return pins, rows.Err()
+}
+
+// PinPost adds a post at the end of the board of its space, unless the board already holds
+// max posts. Pinning a pinned post changes nothing.
+func (db *DB) PinPost(postID int, pinned int64, max int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin board pin", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var spaceID int
+	if err := tx.QueryRow("SELECT space_id FROM posts WHERE id = ?", postID).Scan(&spaceID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("post not found")
+		}
+		logger.Error("Failed to get post to pin", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to get post: %w", err)
+	}
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM board_pins WHERE post_id = ?)", postID).Scan(&exists); err != nil {
+		logger.Error("Failed to check board pin", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to check board pin: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	var count, last int
+	err = tx.QueryRow(
+		`SELECT COUNT(*), COALESCE(MAX(b.position), 0) FROM board_pins b JOIN posts p ON p.id = b.post_id WHERE p.space_id = ?`,
+		spaceID,
+	).Scan(&count, &last)
+	if err != nil {
+		logger.Error("Failed to count board pins", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to count board pins: %w", err)
+	}
+	if count >= max {
+		return fmt.Errorf("board is full")
+	}
+
+	if _, err := tx.Exec("INSERT INTO board_pins (post_id, position, pinned) VALUES (?, ?, ?)", postID, last+1, pinned); err != nil {
+		logger.Error("Failed to pin post", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to pin post: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit board pin", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// UnpinPost removes a post from the board of its space
+func (db *DB) UnpinPost(postID int) error {
+	result, err := db.Exec("DELETE FROM board_pins WHERE post_id = ?", postID)
+	if err != nil {
+		logger.Error("Failed to unpin post", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to unpin post: %w", err)
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("board pin not found")
+	}
+	return nil
+}
+
+// SetBoardOrder gives the pinned posts of a space the positions of their index in postIDs
+func (db *DB) SetBoardOrder(postIDs []int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin board reorder", zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, postID := range postIDs {
+		if _, err := tx.Exec("UPDATE board_pins SET position = ? WHERE post_id = ?", i+1, postID); err != nil {
+			logger.Error("Failed to reorder board pin", zap.Int("post_id", postID), zap.Error(err))
+			return fmt.Errorf("failed to reorder board pin: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit board reorder", zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
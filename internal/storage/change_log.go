@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// RecordChange appends a change to the change log and sets its sequence number
+func (db *DB) RecordChange(change *models.Change) error {
+	postIDs := change.PostIDs
+	if postIDs == nil {
+		postIDs = []int{}
+	}
+	encoded, err := json.Marshal(postIDs)
+	if err != nil {
+		return fmt.Errorf("failed to encode change posts: %w", err)
+	}
+
+	result, err := db.Exec(
+		"INSERT INTO change_log (type, space_id, post_id, old_space_id, post_ids, created) VALUES (?, ?, ?, ?, ?, ?)",
+		change.Type, change.SpaceID, change.PostID, change.OldSpaceID, string(encoded), change.Created,
+	)
+	if err != nil {
+		logger.Error("Failed to record change", zap.String("type", change.Type), zap.Error(err))
+		return fmt.Errorf("failed to record change: %w", err)
+	}
+
+	seq, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Failed to get last insert ID after recording change", zap.Error(err))
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	change.Seq = seq
+	return nil
+}
+
+// GetChanges returns at most limit changes made after since, in order
+func (db *DB) GetChanges(since int64, limit int) ([]models.Change, error) {
+	rows, err := db.Query(
+		`SELECT seq, type, space_id, post_id, old_space_id, post_ids, created FROM change_log
+		WHERE seq > ? ORDER BY seq LIMIT ?`,
+		since, limit,
+	)
+	if err != nil {
+		logger.Error("Failed to query changes", zap.Int64("since", since), zap.Error(err))
+		return nil, fmt.Errorf("failed to query changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []models.Change{}
+	for rows.Next() {
+		var change models.Change
+		var postIDs string
+		if err := rows.Scan(&change.Seq, &change.Type, &change.SpaceID, &change.PostID, &change.OldSpaceID, &postIDs, &change.Created); err != nil {
+			logger.Error("Failed to scan change", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan change: %w", err)
+		}
+		if err := json.Unmarshal([]byte(postIDs), &change.PostIDs); err != nil {
+			return nil, fmt.Errorf("failed to decode change posts: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// GetChangeLogBounds returns the sequence number of the last change made, and the one up to
+// which changes were pruned; only changes after floor can still be listed
+func (db *DB) GetChangeLogBounds() (floor, head int64, err error) {
+	// The sequence outlives pruned rows, so head holds even once the log is emptied
+	err = db.QueryRow("SELECT COALESCE((SELECT seq FROM sqlite_sequence WHERE name = 'change_log'), 0)").Scan(&head)
+	if err != nil {
+		logger.Error("Failed to query change log head", zap.Error(err))
+		return 0, 0, fmt.Errorf("failed to query change log head: %w", err)
+	}
+	if err = db.QueryRow("SELECT COALESCE(MIN(seq) - 1, ?) FROM change_log", head).Scan(&floor); err != nil {
+		logger.Error("Failed to query change log floor", zap.Error(err))
+		return 0, 0, fmt.Errorf("failed to query change log floor: %w", err)
+	}
+	return floor, head, nil
+}
+
+// PruneChanges removes the changes made before a time and returns how many were removed
+func (db *DB) PruneChanges(before int64) (int64, error) {
+	result, err := db.Exec("DELETE FROM change_log WHERE created < ?", before)
+	if err != nil {
+		logger.Error("Failed to prune change log", zap.Int64("before", before), zap.Error(err))
+		return 0, fmt.Errorf("failed to prune change log: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// TouchAttachment records that an attachment was accessed at timestamp
+func (db *DB) TouchAttachment(attachmentID int, timestamp int64) error {
+	_, err := db.Exec(
+		`INSERT INTO attachment_access (attachment_id, last_access) VALUES (?, ?)
+		ON CONFLICT(attachment_id) DO UPDATE SET last_access = MAX(last_access, excluded.last_access)`,
+		attachmentID, timestamp,
+	)
+	if err != nil {
+		logger.Error("Failed to record attachment access", zap.Int("attachment_id", attachmentID), zap.Error(err))
+		return fmt.Errorf("failed to record attachment access: %w", err)
+	}
+
+	return nil
+}
+
+// TouchAttachmentFile records that every attachment stored under filePath was accessed at timestamp
+func (db *DB) TouchAttachmentFile(filePath string, timestamp int64) error {
+	_, err := db.Exec(
+		`INSERT INTO attachment_access (attachment_id, last_access)
+		SELECT id, ? FROM attachments WHERE file_path = ?
+		ON CONFLICT(attachment_id) DO UPDATE SET last_access = MAX(last_access, excluded.last_access)`,
+		timestamp, filePath,
+	)
+	if err != nil {
+		logger.Error("Failed to record attachment file access", zap.String("file_path", filePath), zap.Error(err))
+		return fmt.Errorf("failed to record attachment file access: %w", err)
+	}
+
+	return nil
+}
+
+// GetColdCandidates returns up to limit hot attachment files whose posts were created and
+// which were last accessed before cutoff (ms), oldest attachments first
+func (db *DB) GetColdCandidates(cutoff int64, limit int) ([]models.ColdAttachment, error) {
+	rows, err := db.Query(
+		`SELECT a.file_path, MAX(a.file_size)
+		FROM attachments a
+		JOIN posts p ON p.id = a.post_id
+		LEFT JOIN attachment_access x ON x.attachment_id = a.id
+		WHERE a.file_path NOT IN (SELECT file_path FROM cold_attachments)
+		GROUP BY a.file_path
+		HAVING MAX(p.created) < ? AND COALESCE(MAX(x.last_access), 0) < ?
+		ORDER BY MIN(a.id)
+		LIMIT ?`,
+		cutoff, cutoff, limit,
+	)
+	if err != nil {
+		logger.Error("Failed to query cold storage candidates", zap.Error(err))
+		return nil, fmt.Errorf("failed to query cold storage candidates: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := []models.ColdAttachment{}
+	for rows.Next() {
+		var candidate models.ColdAttachment
+		if err := rows.Scan(&candidate.FilePath, &candidate.FileSize); err != nil {
+			logger.Error("Failed to scan cold storage candidate", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan cold storage candidate: %w", err)
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, rows.Err()
+}
+
+// AddColdAttachment records that the file of an attachment was moved to the cold tier
+func (db *DB) AddColdAttachment(cold models.ColdAttachment) error {
+	_, err := db.Exec(
+		`INSERT INTO cold_attachments (file_path, file_size, archived_at) VALUES (?, ?, ?)
+		ON CONFLICT(file_path) DO UPDATE SET file_size = excluded.file_size, archived_at = excluded.archived_at`,
+		cold.FilePath, cold.FileSize, cold.ArchivedAt,
+	)
+	if err != nil {
+		logger.Error("Failed to add cold attachment", zap.String("file_path", cold.FilePath), zap.Error(err))
+		return fmt.Errorf("failed to add cold attachment: %w", err)
+	}
+
+	return nil
+}
+
+// GetColdAttachment returns the stub of a file in the cold tier, nil if the file is hot
+func (db *DB) GetColdAttachment(filePath string) (*models.ColdAttachment, error) {
+	var cold models.ColdAttachment
+	err := db.QueryRow(
+		"SELECT file_path, file_size, archived_at FROM cold_attachments WHERE file_path = ?",
+		filePath,
+	).Scan(&cold.FilePath, &cold.FileSize, &cold.ArchivedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error("Failed to get cold attachment", zap.String("file_path", filePath), zap.Error(err))
+		return nil, fmt.Errorf("failed to get cold attachment: %w", err)
+	}
+
+	return &cold, nil
+}
+
+// RemoveColdAttachment drops the stub of a file brought back from the cold tier or deleted
+func (db *DB) RemoveColdAttachment(filePath string) error {
+	if _, err := db.Exec("DELETE FROM cold_attachments WHERE file_path = ?", filePath); err != nil {
+		logger.Error("Failed to remove cold attachment", zap.String("file_path", filePath), zap.Error(err))
+		return fmt.Errorf("failed to remove cold attachment: %w", err)
+	}
+
+	return nil
+}
+
+// GetOrphanColdAttachments returns the cold files no attachment or trashed attachment refers to
+func (db *DB) GetOrphanColdAttachments() ([]string, error) {
+	rows, err := db.Query(
+		`SELECT c.file_path FROM cold_attachments c
+		WHERE NOT EXISTS (SELECT 1 FROM attachments a WHERE a.file_path = c.file_path)
+		AND NOT EXISTS (
+			SELECT 1 FROM trash_items t
+			WHERE t.item_type = ? AND json_extract(t.payload, '$.file_path') = c.file_path
+		)`,
+		models.TrashItemAttachment,
+	)
+	if err != nil {
+		logger.Error("Failed to query orphan cold attachments", zap.Error(err))
+		return nil, fmt.Errorf("failed to query orphan cold attachments: %w", err)
+	}
+	defer rows.Close()
+
+	paths := []string{}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			logger.Error("Failed to scan orphan cold attachment", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan orphan cold attachment: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, rows.Err()
+}
+
+// GetTierUsageBySpace returns the hot and cold attachment counts and sizes of every space
+// holding attachments
+func (db *DB) GetTierUsageBySpace() (map[int]models.TierUsage, error) {
+	rows, err := db.Query(
+		`SELECT p.space_id,
+			SUM(CASE WHEN c.file_path IS NULL THEN 1 ELSE 0 END),
+			SUM(CASE WHEN c.file_path IS NULL THEN a.file_size ELSE 0 END),
+			SUM(CASE WHEN c.file_path IS NULL THEN 0 ELSE 1 END),
+			SUM(CASE WHEN c.file_path IS NULL THEN 0 ELSE a.file_size END)
+		FROM attachments a
+		JOIN posts p ON p.id = a.post_id
+		LEFT JOIN cold_attachments c ON c.file_path = a.file_path
+		GROUP BY p.space_id`,
+	)
+	if err != nil {
+		logger.Error("Failed to query storage tier usage", zap.Error(err))
+		return nil, fmt.Errorf("failed to query storage tier usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := make(map[int]models.TierUsage)
+	for rows.Next() {
+		var u models.TierUsage
+		if err := rows.Scan(&u.SpaceID, &u.HotFiles, &u.HotBytes, &u.ColdFiles, &u.ColdBytes); err != nil {
+			logger.Error("Failed to scan storage tier usage", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan storage tier usage: %w", err)
+		}
+		usage[u.SpaceID] = u
+	}
+
+	return usage, rows.Err()
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// postScopeCondition matches the posts aliased p owned by one of spaceIDs or cross-posted
+// into one of them
+func postScopeCondition(spaceIDs []int) (string, []interface{}) {
+	placeholders := make([]string, len(spaceIDs))
+	args := make([]interface{}, 0, 2*len(spaceIDs))
+	for i, id := range spaceIDs {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+	for _, id := range spaceIDs {
+		args = append(args, id)
+	}
+
+	in := strings.Join(placeholders, ",")
+	return fmt.Sprintf("(p.space_id IN (%s) OR p.id IN (SELECT post_id FROM post_crossposts WHERE space_id IN (%s)))", in, in), args
+}
+
+func containsID(ids []int, id int) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AddCrosspost makes a post appear in another space; adding an existing reference does nothing
+func (db *DB) AddCrosspost(postID, spaceID int) error {
+	_, err := db.Exec(
+		"INSERT OR IGNORE INTO post_crossposts (post_id, space_id, created) VALUES (?, ?, ?)",
+		postID, spaceID, time.Now().UnixMilli(),
+	)
+	if err != nil {
+		logger.Error("Failed to add cross-post", zap.Int("post_id", postID), zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to add cross-post: %w", err)
+	}
+	return nil
+}
+
+// RemoveCrosspost removes a post from a space it was cross-posted to; found is false when
+// there was no such reference
+func (db *DB) RemoveCrosspost(postID, spaceID int) (bool, error) {
+	result, err := db.Exec("DELETE FROM post_crossposts WHERE post_id = ? AND space_id = ?", postID, spaceID)
+	if err != nil {
+		logger.Error("Failed to remove cross-post", zap.Int("post_id", postID), zap.Int("space_id", spaceID), zap.Error(err))
+		return false, fmt.Errorf("failed to remove cross-post: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// GetCrosspostSpaces returns the spaces a post is cross-posted to, oldest reference first
+func (db *DB) GetCrosspostSpaces(postID int) ([]int, error) {
+	rows, err := db.Query("SELECT space_id FROM post_crossposts WHERE post_id = ? ORDER BY created, space_id", postID)
+	if err != nil {
+		logger.Error("Failed to get cross-posts", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to get cross-posts: %w", err)
+	}
+	defer rows.Close()
+
+	spaceIDs := []int{}
+	for rows.Next() {
+		var spaceID int
+		if err := rows.Scan(&spaceID); err != nil {
+			return nil, fmt.Errorf("failed to scan cross-post: %w", err)
+		}
+		spaceIDs = append(spaceIDs, spaceID)
+	}
+
+	return spaceIDs, rows.Err()
+}
+
+// CountCrosspostsInto counts the distinct posts cross-posted into spaceIDs whose owner space
+// is not one of them
+func (db *DB) CountCrosspostsInto(ctx context.Context, spaceIDs []int) (int, error) {
+	if len(spaceIDs) == 0 {
+		return 0, nil
+	}
+
+	placeholders := make([]string, len(spaceIDs))
+	args := make([]interface{}, 0, 2*len(spaceIDs))
+	for i, id := range spaceIDs {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+	for _, id := range spaceIDs {
+		args = append(args, id)
+	}
+	in := strings.Join(placeholders, ",")
+
+	var count int
+	err := db.QueryRowContext(ctx, fmt.Sprintf(
+		"SELECT COUNT(DISTINCT c.post_id) FROM post_crossposts c JOIN posts p ON p.id = c.post_id WHERE c.space_id IN (%s) AND p.space_id NOT IN (%s)",
+		in, in,
+	), args...).Scan(&count)
+	if err != nil {
+		logger.Error("Failed to count cross-posts", zap.Error(err))
+		return 0, fmt.Errorf("failed to count cross-posts: %w", err)
+	}
+
+	return count, nil
+}
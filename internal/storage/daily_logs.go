@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// GetDailyLogPostID returns the daily log post of a space for a day (YYYY-MM-DD); found is
+// false when none was started yet
+func (db *DB) GetDailyLogPostID(spaceID int, day string) (int, bool, error) {
+	var postID int
+	err := db.QueryRow("SELECT post_id FROM daily_logs WHERE space_id = ? AND day = ?", spaceID, day).Scan(&postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		logger.Error("Failed to get daily log", zap.Int("space_id", spaceID), zap.String("day", day), zap.Error(err))
+		return 0, false, fmt.Errorf("failed to get daily log: %w", err)
+	}
+
+	return postID, true, nil
+}
+
+// SetDailyLogPost records postID as the daily log post of a space for a day
+func (db *DB) SetDailyLogPost(spaceID int, day string, postID int) error {
+	_, err := db.Exec(
+		`INSERT INTO daily_logs (space_id, day, post_id) VALUES (?, ?, ?)
+		ON CONFLICT(space_id, day) DO UPDATE SET post_id = excluded.post_id`,
+		spaceID, day, postID,
+	)
+	if err != nil {
+		logger.Error("Failed to set daily log", zap.Int("space_id", spaceID), zap.String("day", day), zap.Error(err))
+		return fmt.Errorf("failed to set daily log: %w", err)
+	}
+
+	return nil
+}
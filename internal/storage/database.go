@@ -8,7 +8,6 @@ import (
 	"os"
 	"path/filepath"
 
-	_ "github.com/mattn/go-sqlite3"
 	"go.uber.org/zap"
 )
 
@@ -24,7 +23,7 @@ func NewDB(storagePath string) (*DB, error) {
 	}
 
 	dbPath := filepath.Join(storagePath, config.GetServiceConfig().Files.DatabaseFilename)
-	db, err := sql.Open("sqlite3", dbPath+"?_fk=1")
+	db, err := sql.Open(driverName, dbPath+"?_fk=1")
 	if err != nil {
 		logger.Error("Failed to open database", zap.String("path", dbPath), zap.Error(err))
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -82,6 +81,110 @@ func (db *DB) createTables() error {
 			file_size INTEGER NOT NULL,
 			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
 		)`,
+		`CREATE TABLE IF NOT EXISTS secret_findings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER,
+			attachment_id INTEGER,
+			space_id INTEGER NOT NULL,
+			source TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			excerpt TEXT NOT NULL,
+			action TEXT NOT NULL,
+			created INTEGER NOT NULL,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+			FOREIGN KEY (attachment_id) REFERENCES attachments(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS moderation_flags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER NOT NULL,
+			rule TEXT NOT NULL,
+			source TEXT NOT NULL,
+			excerpt TEXT NOT NULL,
+			fingerprint TEXT NOT NULL,
+			status TEXT NOT NULL,
+			created INTEGER NOT NULL,
+			reviewed INTEGER,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_sources (
+			post_id INTEGER PRIMARY KEY,
+			source TEXT NOT NULL,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		// Posts pinned at the top of the listing of their space. A pin belongs to the post and
+		// follows it to another space.
+		`CREATE TABLE IF NOT EXISTS post_pins (
+			post_id INTEGER PRIMARY KEY,
+			pinned INTEGER NOT NULL,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS daily_logs (
+			space_id INTEGER NOT NULL,
+			day TEXT NOT NULL,
+			post_id INTEGER NOT NULL,
+			PRIMARY KEY (space_id, day),
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS journal_entries (
+			space_id INTEGER NOT NULL,
+			day TEXT NOT NULL,
+			post_id INTEGER NOT NULL UNIQUE,
+			PRIMARY KEY (space_id, day),
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS space_limits (
+			space_id INTEGER PRIMARY KEY,
+			max_content_length INTEGER NOT NULL,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS space_settings (
+			space_id INTEGER PRIMARY KEY,
+			default_sort TEXT NOT NULL,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_activity (
+			post_id INTEGER PRIMARY KEY,
+			updated INTEGER NOT NULL,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS space_field_schemas (
+			space_id INTEGER PRIMARY KEY,
+			fields TEXT NOT NULL,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_meta (
+			post_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			value TEXT NOT NULL,
+			PRIMARY KEY (post_id, name),
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS attachment_downloads (
+			attachment_id INTEGER PRIMARY KEY,
+			download_count INTEGER NOT NULL DEFAULT 0,
+			last_downloaded INTEGER NOT NULL,
+			FOREIGN KEY (attachment_id) REFERENCES attachments(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS attachment_checksums (
+			attachment_id INTEGER PRIMARY KEY,
+			sha256 TEXT NOT NULL,
+			FOREIGN KEY (attachment_id) REFERENCES attachments(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS attachment_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			attachment_id INTEGER NOT NULL,
+			version INTEGER NOT NULL,
+			filename TEXT NOT NULL,
+			file_path TEXT NOT NULL,
+			file_type TEXT NOT NULL,
+			file_size INTEGER NOT NULL,
+			sha256 TEXT NOT NULL,
+			replaced INTEGER NOT NULL,
+			UNIQUE (attachment_id, version),
+			FOREIGN KEY (attachment_id) REFERENCES attachments(id) ON DELETE CASCADE
+		)`,
 		`CREATE TABLE IF NOT EXISTS link_previews (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			post_id INTEGER NOT NULL,
@@ -92,11 +195,515 @@ func (db *DB) createTables() error {
 			site_name TEXT,
 			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
 		)`,
+		`CREATE TABLE IF NOT EXISTS post_tasks (
+			post_id INTEGER PRIMARY KEY,
+			total INTEGER NOT NULL DEFAULT 0,
+			done INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_metrics (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			value REAL NOT NULL,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS glossary_terms (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			space_id INTEGER NOT NULL,
+			term TEXT NOT NULL,
+			definition TEXT NOT NULL,
+			created INTEGER NOT NULL,
+			UNIQUE (space_id, term COLLATE NOCASE),
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS space_stats_snapshots (
+			space_id INTEGER NOT NULL,
+			day TEXT NOT NULL,
+			post_count INTEGER NOT NULL DEFAULT 0,
+			recursive_post_count INTEGER NOT NULL DEFAULT 0,
+			file_count INTEGER NOT NULL DEFAULT 0,
+			total_size INTEGER NOT NULL DEFAULT 0,
+			recursive_file_count INTEGER NOT NULL DEFAULT 0,
+			recursive_total_size INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (space_id, day),
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS trash_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			item_type TEXT NOT NULL,
+			item_id INTEGER NOT NULL,
+			space_id INTEGER NOT NULL DEFAULT 0,
+			parent_trash_id INTEGER,
+			label TEXT NOT NULL DEFAULT '',
+			payload TEXT NOT NULL,
+			size INTEGER NOT NULL DEFAULT 0,
+			deleted_at INTEGER NOT NULL,
+			purge_at INTEGER NOT NULL,
+			FOREIGN KEY (parent_trash_id) REFERENCES trash_items(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS space_snapshots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			space_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			scheduled INTEGER NOT NULL DEFAULT 0,
+			space_count INTEGER NOT NULL DEFAULT 0,
+			post_count INTEGER NOT NULL DEFAULT 0,
+			attachment_count INTEGER NOT NULL DEFAULT 0,
+			size INTEGER NOT NULL DEFAULT 0,
+			created INTEGER NOT NULL,
+			manifest TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS space_snapshot_files (
+			snapshot_id INTEGER NOT NULL,
+			sha256 TEXT NOT NULL,
+			PRIMARY KEY (snapshot_id, sha256),
+			FOREIGN KEY (snapshot_id) REFERENCES space_snapshots(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS space_snapshot_schedules (
+			space_id INTEGER PRIMARY KEY,
+			interval_hours INTEGER NOT NULL,
+			keep INTEGER NOT NULL,
+			last_run INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_tags (
+			post_id INTEGER NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (post_id, tag),
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS audit_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			action TEXT NOT NULL,
+			details TEXT NOT NULL,
+			created INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS saved_filters (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			space_id INTEGER,
+			recursive BOOLEAN NOT NULL DEFAULT 0,
+			tags TEXT NOT NULL DEFAULT '[]',
+			extensions TEXT NOT NULL DEFAULT '[]',
+			start_date TEXT NOT NULL DEFAULT '',
+			end_date TEXT NOT NULL DEFAULT '',
+			created INTEGER NOT NULL,
+			updated INTEGER NOT NULL,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_embeddings (
+			post_id INTEGER PRIMARY KEY,
+			model TEXT NOT NULL,
+			content_hash TEXT NOT NULL,
+			vector BLOB NOT NULL,
+			updated INTEGER NOT NULL,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_summaries (
+			post_id INTEGER PRIMARY KEY,
+			content_hash TEXT NOT NULL,
+			summary TEXT NOT NULL,
+			created INTEGER NOT NULL,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS space_digests (
+			space_id INTEGER NOT NULL,
+			week TEXT NOT NULL,
+			content_hash TEXT NOT NULL,
+			post_count INTEGER NOT NULL,
+			summary TEXT NOT NULL,
+			created INTEGER NOT NULL,
+			PRIMARY KEY (space_id, week),
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS ingest_tokens (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			space_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			prefix TEXT NOT NULL,
+			rate_limit INTEGER NOT NULL,
+			created INTEGER NOT NULL,
+			last_used INTEGER NOT NULL DEFAULT 0,
+			revoked INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS ingest_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			token_id INTEGER NOT NULL,
+			created INTEGER NOT NULL,
+			outcome TEXT NOT NULL,
+			post_id INTEGER,
+			detail TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY (token_id) REFERENCES ingest_tokens(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS share_links (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			space_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			prefix TEXT NOT NULL,
+			rate_limit INTEGER NOT NULL,
+			created INTEGER NOT NULL,
+			expires INTEGER NOT NULL,
+			last_used INTEGER NOT NULL DEFAULT 0,
+			revoked INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		// Signing secrets of the ingest tokens and share links that only accept signed requests;
+		// unlike the tokens, they are kept in clear to check the signatures
+		`CREATE TABLE IF NOT EXISTS ingest_token_secrets (
+			token_id INTEGER PRIMARY KEY,
+			secret TEXT NOT NULL,
+			FOREIGN KEY (token_id) REFERENCES ingest_tokens(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS share_link_secrets (
+			link_id INTEGER PRIMARY KEY,
+			secret TEXT NOT NULL,
+			FOREIGN KEY (link_id) REFERENCES share_links(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_authors (
+			post_id INTEGER PRIMARY KEY,
+			author TEXT NOT NULL,
+			share_link_id INTEGER,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+			FOREIGN KEY (share_link_id) REFERENCES share_links(id) ON DELETE SET NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS feature_flag_overrides (
+			name TEXT PRIMARY KEY,
+			enabled INTEGER NOT NULL,
+			rollout INTEGER NOT NULL DEFAULT 0,
+			updated INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS cold_attachments (
+			file_path TEXT PRIMARY KEY,
+			file_size INTEGER NOT NULL,
+			archived_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS attachment_access (
+			attachment_id INTEGER PRIMARY KEY,
+			last_access INTEGER NOT NULL,
+			FOREIGN KEY (attachment_id) REFERENCES attachments(id) ON DELETE CASCADE
+		)`,
+		// Preview images generated for attachments, the files are named by attachment ID in
+		// the previews directory
+		`CREATE TABLE IF NOT EXISTS attachment_previews (
+			attachment_id INTEGER PRIMARY KEY,
+			kind TEXT NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			created INTEGER NOT NULL,
+			FOREIGN KEY (attachment_id) REFERENCES attachments(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL,
+			title TEXT NOT NULL,
+			message TEXT NOT NULL DEFAULT '',
+			space_id INTEGER,
+			post_id INTEGER,
+			dedupe_key TEXT NOT NULL DEFAULT '',
+			count INTEGER NOT NULL DEFAULT 1,
+			created INTEGER NOT NULL,
+			updated INTEGER NOT NULL,
+			read INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		// Spaces whose notifications and webhook calls are dropped, with their descendants
+		`CREATE TABLE IF NOT EXISTS notification_mutes (
+			space_id INTEGER PRIMARY KEY,
+			created INTEGER NOT NULL,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		// Instance-wide quiet hours, as HH:MM server times; a single row when set
+		`CREATE TABLE IF NOT EXISTS notification_quiet_hours (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			start TEXT NOT NULL,
+			end TEXT NOT NULL
+		)`,
+		// Notifications and webhook calls held during quiet hours, released once they end
+		`CREATE TABLE IF NOT EXISTS held_deliveries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			channel TEXT NOT NULL,
+			target TEXT NOT NULL DEFAULT '',
+			payload BLOB NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			created INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_crossposts (
+			post_id INTEGER NOT NULL,
+			space_id INTEGER NOT NULL,
+			created INTEGER NOT NULL,
+			PRIMARY KEY (post_id, space_id),
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			enabled BOOLEAN NOT NULL DEFAULT 1,
+			trigger_event TEXT NOT NULL,
+			conditions TEXT NOT NULL DEFAULT '{}',
+			actions TEXT NOT NULL DEFAULT '[]',
+			created INTEGER NOT NULL,
+			updated INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS rule_executions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			rule_id INTEGER NOT NULL,
+			created INTEGER NOT NULL,
+			trigger_event TEXT NOT NULL,
+			post_id INTEGER NOT NULL,
+			outcome TEXT NOT NULL,
+			results TEXT NOT NULL DEFAULT '[]',
+			FOREIGN KEY (rule_id) REFERENCES rules(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS rule_reminders (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			rule_id INTEGER NOT NULL,
+			post_id INTEGER NOT NULL,
+			space_id INTEGER NOT NULL,
+			message TEXT NOT NULL DEFAULT '',
+			due INTEGER NOT NULL,
+			FOREIGN KEY (rule_id) REFERENCES rules(id) ON DELETE CASCADE,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS export_changes (
+			entity TEXT NOT NULL,
+			entity_id INTEGER NOT NULL,
+			deleted BOOLEAN NOT NULL DEFAULT 0,
+			changed INTEGER NOT NULL,
+			PRIMARY KEY (entity, entity_id)
+		)`,
+		// Changes of spaces and posts numbered in the order they were made, for clients catching up
+		`CREATE TABLE IF NOT EXISTS change_log (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			type TEXT NOT NULL,
+			space_id INTEGER NOT NULL DEFAULT 0,
+			post_id INTEGER NOT NULL DEFAULT 0,
+			old_space_id INTEGER NOT NULL DEFAULT 0,
+			post_ids TEXT NOT NULL DEFAULT '[]',
+			created INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS signing_keys (
+			name TEXT PRIMARY KEY,
+			secret BLOB NOT NULL,
+			created INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS published_spaces (
+			space_id INTEGER PRIMARY KEY,
+			created INTEGER NOT NULL,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		// Posts pinned to the board of their space, shown in position order
+		`CREATE TABLE IF NOT EXISTS board_pins (
+			post_id INTEGER PRIMARY KEY,
+			position INTEGER NOT NULL,
+			pinned INTEGER NOT NULL,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			subject TEXT NOT NULL UNIQUE,
+			email TEXT NOT NULL,
+			name TEXT NOT NULL,
+			role TEXT NOT NULL,
+			created INTEGER NOT NULL,
+			last_login INTEGER NOT NULL
+		)`,
+		// Password hashes of the users signing in with a password, bcrypt
+		`CREATE TABLE IF NOT EXISTS user_passwords (
+			user_id INTEGER PRIMARY KEY,
+			hash TEXT NOT NULL,
+			updated INTEGER NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS sessions (
+			token_hash TEXT PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			created INTEGER NOT NULL,
+			expires INTEGER NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS session_devices (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			token_hash TEXT NOT NULL UNIQUE,
+			user_agent TEXT NOT NULL,
+			ip TEXT NOT NULL,
+			last_seen INTEGER NOT NULL,
+			FOREIGN KEY (token_hash) REFERENCES sessions(token_hash) ON DELETE CASCADE
+		)`,
+		// Access lists of private spaces: only the users listed reach a space with entries,
+		// and its descendants. permission is read or write.
+		`CREATE TABLE IF NOT EXISTS space_acl (
+			space_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			permission TEXT NOT NULL,
+			PRIMARY KEY (space_id, user_id),
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		)`,
+		// Previous content and link previews of edited posts, link previews as JSON
+		`CREATE TABLE IF NOT EXISTS post_revisions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER NOT NULL,
+			content TEXT NOT NULL,
+			link_previews TEXT NOT NULL,
+			edited INTEGER NOT NULL,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		// Preferences of each user; user_id 0 holds the instance-wide ones
+		`CREATE TABLE IF NOT EXISTS preferences (
+			user_id INTEGER PRIMARY KEY,
+			landing_view TEXT NOT NULL,
+			landing_space_id INTEGER,
+			updated INTEGER NOT NULL
+		)`,
+		// Record keeping: spaces keeping an immutable copy of every prior version of their
+		// posts. The copies have no foreign keys so nothing deleted elsewhere takes them along.
+		`CREATE TABLE IF NOT EXISTS space_record_keeping (
+			space_id INTEGER PRIMARY KEY,
+			retention_days INTEGER NOT NULL,
+			since INTEGER NOT NULL,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS post_records (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			space_id INTEGER NOT NULL,
+			post_id INTEGER NOT NULL,
+			content TEXT NOT NULL,
+			link_previews TEXT NOT NULL,
+			recorded INTEGER NOT NULL,
+			retain_until INTEGER NOT NULL,
+			prev_hash TEXT NOT NULL,
+			hash TEXT NOT NULL
+		)`,
+		// Every change of content, whatever the code path, copies the prior version first,
+		// chained to the latest record of the space, see storage.PostRecordHash
+		`CREATE TRIGGER IF NOT EXISTS post_records_capture BEFORE UPDATE OF content ON posts BEGIN
+			INSERT INTO post_records (space_id, post_id, content, link_previews, recorded, retain_until, prev_hash, hash)
+			SELECT space_id, post_id, content, link_previews, recorded, retain_until, prev_hash,
+				record_hash(prev_hash, space_id, post_id, recorded, retain_until, link_previews, content)
+			FROM (
+				SELECT old.space_id AS space_id, old.id AS post_id, old.content AS content,
+					(SELECT json_group_array(json_object('url', url, 'title', title, 'description', description, 'image_url', image_url, 'site_name', site_name))
+						FROM (SELECT url, COALESCE(title, '') AS title, COALESCE(description, '') AS description,
+							COALESCE(image_url, '') AS image_url, COALESCE(site_name, '') AS site_name
+							FROM link_previews WHERE post_id = old.id ORDER BY id)) AS link_previews,
+					` + sqlNowMillis + ` AS recorded,
+					` + sqlNowMillis + ` + k.retention_days * 86400000 AS retain_until,
+					COALESCE((SELECT hash FROM post_records WHERE space_id = old.space_id ORDER BY id DESC LIMIT 1), '') AS prev_hash
+				FROM space_record_keeping k WHERE k.space_id = old.space_id
+			);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS post_records_immutable BEFORE UPDATE ON post_records BEGIN
+			SELECT RAISE(ABORT, 'post records cannot be changed');
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS post_records_retained BEFORE DELETE ON post_records
+		WHEN old.retain_until > ` + sqlNowMillis + ` BEGIN
+			SELECT RAISE(ABORT, 'post record is under retention');
+		END`,
+		// Spaces scheduled for periodic review. notified is the due time a notification was
+		// raised for, so each due review is announced once.
+		`CREATE TABLE IF NOT EXISTS space_reviews (
+			space_id INTEGER PRIMARY KEY,
+			interval_days INTEGER NOT NULL,
+			last_reviewed INTEGER NOT NULL,
+			due INTEGER NOT NULL,
+			notified INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		// URLs referenced by posts, normalized by utils.ParseLinks, so a link saved again
+		// points back at the posts already holding it
+		`CREATE TABLE IF NOT EXISTS post_links (
+			post_id INTEGER NOT NULL,
+			url TEXT NOT NULL,
+			PRIMARY KEY (post_id, url),
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		// Full-text index of post content, docid is the post ID
+		`CREATE VIRTUAL TABLE IF NOT EXISTS posts_fts USING fts4(content, tokenize=unicode61)`,
+		`CREATE TRIGGER IF NOT EXISTS posts_fts_insert AFTER INSERT ON posts BEGIN
+			INSERT INTO posts_fts (docid, content) VALUES (new.id, new.content);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS posts_fts_update AFTER UPDATE OF content ON posts BEGIN
+			UPDATE posts_fts SET content = new.content WHERE docid = new.id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS posts_fts_delete AFTER DELETE ON posts BEGIN
+			DELETE FROM posts_fts WHERE docid = old.id;
+		END`,
+		// Full-text index of the search endpoint over post content and link preview titles,
+		// docid is the post ID. The search feature keeps it current from post events.
+		`CREATE VIRTUAL TABLE IF NOT EXISTS search_fts USING fts4(content, titles, tokenize=unicode61)`,
+		// Checkpoint of the latest rebuild of search_fts, a single row. Posts up to
+		// last_post_id are reindexed, so an interrupted rebuild continues from there.
+		`CREATE TABLE IF NOT EXISTS search_reindex (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			status TEXT NOT NULL,
+			last_post_id INTEGER NOT NULL,
+			processed INTEGER NOT NULL,
+			total INTEGER NOT NULL,
+			started INTEGER NOT NULL,
+			updated INTEGER NOT NULL,
+			error TEXT NOT NULL DEFAULT ''
+		)`,
+		// Row hashes of posts and spaces, kept current by triggers so sync clients can
+		// detect divergence without comparing every field
+		`CREATE TABLE IF NOT EXISTS post_hashes (
+			post_id INTEGER PRIMARY KEY,
+			hash TEXT NOT NULL,
+			FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS space_hashes (
+			space_id INTEGER PRIMARY KEY,
+			hash TEXT NOT NULL,
+			FOREIGN KEY (space_id) REFERENCES spaces(id) ON DELETE CASCADE
+		)`,
+		`CREATE TRIGGER IF NOT EXISTS post_hashes_insert AFTER INSERT ON posts BEGIN
+			INSERT OR REPLACE INTO post_hashes (post_id, hash) VALUES (new.id, post_hash(new.space_id, new.created, new.content));
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS post_hashes_update AFTER UPDATE OF space_id, created, content ON posts BEGIN
+			INSERT OR REPLACE INTO post_hashes (post_id, hash) VALUES (new.id, post_hash(new.space_id, new.created, new.content));
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS space_hashes_insert AFTER INSERT ON spaces BEGIN
+			INSERT OR REPLACE INTO space_hashes (space_id, hash) VALUES (new.id, space_hash(COALESCE(new.parent_id, 0), new.name, COALESCE(new.description, '')));
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS space_hashes_update AFTER UPDATE OF parent_id, name, description ON spaces BEGIN
+			INSERT OR REPLACE INTO space_hashes (space_id, hash) VALUES (new.id, space_hash(COALESCE(new.parent_id, 0), new.name, COALESCE(new.description, '')));
+		END`,
 		`CREATE INDEX IF NOT EXISTS idx_spaces_parent ON spaces(parent_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_posts_space ON posts(space_id)`,
-		`CREATE INDEX IF NOT EXISTS idx_posts_created ON posts(created DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_created_id ON posts(created, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_space_created_id ON posts(space_id, created, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_activity_updated ON post_activity(updated DESC)`,
 		`CREATE INDEX IF NOT EXISTS idx_attachments_post ON attachments(post_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_link_previews_post ON link_previews(post_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_revisions_post ON post_revisions(post_id, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_records_space ON post_records(space_id, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_records_post ON post_records(post_id, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_records_retain ON post_records(retain_until)`,
+		`CREATE INDEX IF NOT EXISTS idx_space_reviews_due ON space_reviews(due)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_metrics_post ON post_metrics(post_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_metrics_name ON post_metrics(name)`,
+		`CREATE INDEX IF NOT EXISTS idx_trash_items_purge ON trash_items(purge_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_space_snapshots_space ON space_snapshots(space_id, created)`,
+		`CREATE INDEX IF NOT EXISTS idx_space_snapshot_files_sha ON space_snapshot_files(sha256)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_tags_tag ON post_tags(tag)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_sources_source ON post_sources(source)`,
+		`CREATE INDEX IF NOT EXISTS idx_ingest_events_token ON ingest_events(token_id, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_authors_link ON post_authors(share_link_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(read, dedupe_key)`,
+		`CREATE INDEX IF NOT EXISTS idx_moderation_flags_post ON moderation_flags(post_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_moderation_flags_status ON moderation_flags(status)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_entries_action ON audit_entries(action, created DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_crossposts_space ON post_crossposts(space_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_rule_executions_rule ON rule_executions(rule_id, id DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_rule_reminders_due ON rule_reminders(due)`,
+		`CREATE INDEX IF NOT EXISTS idx_export_changes_changed ON export_changes(changed)`,
+		`CREATE INDEX IF NOT EXISTS idx_change_log_created ON change_log(created)`,
+		`CREATE INDEX IF NOT EXISTS idx_post_links_url ON post_links(url)`,
 	}
 	
 	for _, query := range queries {
@@ -110,5 +717,38 @@ func (db *DB) createTables() error {
 }
 
 func (db *DB) runMigrations() error {
+	// Posts written before the full-text index existed are indexed once
+	_, err := db.Exec(`INSERT INTO posts_fts (docid, content)
+		SELECT id, content FROM posts WHERE id NOT IN (SELECT docid FROM posts_fts)`)
+	if err != nil {
+		return fmt.Errorf("failed to index posts for full-text search: %w", err)
+	}
+
+	// The (created, id) indexes serving cursor pagination replace the creation-only ones
+	for _, index := range []string{"idx_posts_created", "idx_posts_space_created"} {
+		if _, err := db.Exec("DROP INDEX IF EXISTS " + index); err != nil {
+			return fmt.Errorf("failed to drop index %s: %w", index, err)
+		}
+	}
+
+	// Sessions opened before devices were tracked get an unknown device
+	_, err = db.Exec(`INSERT INTO session_devices (token_hash, user_agent, ip, last_seen)
+		SELECT token_hash, '', '', created FROM sessions WHERE token_hash NOT IN (SELECT token_hash FROM session_devices)`)
+	if err != nil {
+		return fmt.Errorf("failed to record session devices: %w", err)
+	}
+
+	// Rows written before row hashes existed are hashed once
+	_, err = db.Exec(`INSERT INTO post_hashes (post_id, hash)
+		SELECT id, post_hash(space_id, created, content) FROM posts WHERE id NOT IN (SELECT post_id FROM post_hashes)`)
+	if err != nil {
+		return fmt.Errorf("failed to hash posts: %w", err)
+	}
+	_, err = db.Exec(`INSERT INTO space_hashes (space_id, hash)
+		SELECT id, space_hash(COALESCE(parent_id, 0), name, COALESCE(description, '')) FROM spaces WHERE id NOT IN (SELECT space_id FROM space_hashes)`)
+	if err != nil {
+		return fmt.Errorf("failed to hash spaces: %w", err)
+	}
+
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// GetSchemaInfo returns the version and shape of the database schema with row counts
+func (db *DB) GetSchemaInfo() (*models.SchemaInfo, error) {
+	info := &models.SchemaInfo{Tables: []models.TableInfo{}}
+	if err := db.QueryRow("SELECT sqlite_version()").Scan(&info.SQLiteVersion); err != nil {
+		logger.Error("Failed to read SQLite version", zap.Error(err))
+		return nil, fmt.Errorf("failed to read sqlite version: %w", err)
+	}
+	if err := db.QueryRow("PRAGMA user_version").Scan(&info.UserVersion); err != nil {
+		logger.Error("Failed to read schema version", zap.Error(err))
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	rows, err := db.Query(
+		`SELECT type, name, COALESCE(sql, '') FROM sqlite_master
+		WHERE name NOT LIKE 'sqlite_%' ORDER BY type, name`,
+	)
+	if err != nil {
+		logger.Error("Failed to query schema", zap.Error(err))
+		return nil, fmt.Errorf("failed to query schema: %w", err)
+	}
+	hash := sha256.New()
+	var tables []string
+	for rows.Next() {
+		var kind, name, sql string
+		if err := rows.Scan(&kind, &name, &sql); err != nil {
+			rows.Close()
+			logger.Error("Failed to scan schema entry", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan schema entry: %w", err)
+		}
+		hash.Write([]byte(sql + "\n"))
+		if kind == "table" {
+			tables = append(tables, name)
+		}
+	}
+	rows.Close()
+	info.SchemaHash = hex.EncodeToString(hash.Sum(nil))
+
+	for _, name := range tables {
+		table := models.TableInfo{Name: name}
+		query := `SELECT COUNT(*) FROM "` + strings.ReplaceAll(name, `"`, `""`) + `"`
+		if err := db.QueryRow(query).Scan(&table.Rows); err != nil {
+			logger.Error("Failed to count table rows", zap.String("table", name), zap.Error(err))
+			return nil, fmt.Errorf("failed to count rows of %s: %w", name, err)
+		}
+		info.Tables = append(info.Tables, table)
+	}
+
+	return info, nil
+}
+
+// IntegrityCheck runs the SQLite integrity check and returns up to limit problems found,
+// or a single "ok" when the database is sound
+func (db *DB) IntegrityCheck(limit int) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA integrity_check(%d)", limit))
+	if err != nil {
+		logger.Error("Failed to run integrity check", zap.Error(err))
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []string{}
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err != nil {
+			logger.Error("Failed to scan integrity check result", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan integrity check result: %w", err)
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+// CheckDatabaseFile runs the integrity check on the database file at path, e.g. one about to
+// be restored, opening it only for the duration of the check. The check of full-text indexes
+// needs write access.
+func CheckDatabaseFile(path string, limit int) ([]string, error) {
+	conn, err := sql.Open(driverName, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database file: %w", err)
+	}
+	defer conn.Close()
+
+	return (&DB{DB: conn}).IntegrityCheck(limit)
+}
+
+// CountForeignKeyViolations returns the number of rows referencing a missing parent
+func (db *DB) CountForeignKeyViolations() (int, error) {
+	rows, err := db.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		logger.Error("Failed to run foreign key check", zap.Error(err))
+		return 0, fmt.Errorf("failed to run foreign key check: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		count++
+	}
+
+	return count, rows.Err()
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// AttachmentDownloads is an attachment with the number of times it was downloaded
+type AttachmentDownloads struct {
+	models.Attachment
+	SpaceID        int   `json:"space_id"`
+	DownloadCount  int64 `json:"download_count"`
+	LastDownloaded int64 `json:"last_downloaded"`
+}
+
+// GetAttachmentByFilePath returns the attachment stored under filePath along with the space of its post
+func (db *DB) GetAttachmentByFilePath(filePath string) (*models.Attachment, int, error) {
+	var attachment models.Attachment
+	var spaceID int
+	err := db.QueryRow(
+		`SELECT a.id, a.post_id, a.filename, a.file_path, a.file_type, a.file_size, COALESCE(c.sha256, ''), p.space_id
+		FROM attachments a JOIN posts p ON p.id = a.post_id
+		LEFT JOIN attachment_checksums c ON c.attachment_id = a.id
+		WHERE a.file_path = ?`,
+		filePath,
+	).Scan(&attachment.ID, &attachment.PostID, &attachment.Filename, &attachment.FilePath, &attachment.FileType, &attachment.FileSize, &attachment.SHA256, &spaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, fmt.Errorf("attachment not found")
+		}
+		logger.Error("Failed to get attachment by file path", zap.String("file_path", filePath), zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return &attachment, spaceID, nil
+}
+
+// GetFilePostID returns the post a stored file belongs to, as the current file or a prior
+// version of one of its attachments
+func (db *DB) GetFilePostID(filePath string) (int, error) {
+	var postID int
+	err := db.QueryRow(
+		`SELECT post_id FROM attachments WHERE file_path = ?
+		UNION ALL
+		SELECT a.post_id FROM attachment_versions v JOIN attachments a ON a.id = v.attachment_id
+		WHERE v.file_path = ?
+		LIMIT 1`,
+		filePath, filePath,
+	).Scan(&postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("attachment not found")
+		}
+		logger.Error("Failed to get post of file", zap.String("file_path", filePath), zap.Error(err))
+		return 0, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return postID, nil
+}
+
+// SetAttachmentChecksum stores the hex SHA-256 of the file of an attachment
+func (db *DB) SetAttachmentChecksum(attachmentID int, sum string) error {
+	_, err := db.Exec(
+		"INSERT INTO attachment_checksums (attachment_id, sha256) VALUES (?, ?) ON CONFLICT(attachment_id) DO UPDATE SET sha256 = excluded.sha256",
+		attachmentID, sum,
+	)
+	if err != nil {
+		logger.Error("Failed to store attachment checksum", zap.Int("attachment_id", attachmentID), zap.Error(err))
+		return fmt.Errorf("failed to store attachment checksum: %w", err)
+	}
+
+	return nil
+}
+
+// IncrementAttachmentDownloads adds one download to the attachment counter
+func (db *DB) IncrementAttachmentDownloads(attachmentID int, timestamp int64) error {
+	_, err := db.Exec(
+		`INSERT INTO attachment_downloads (attachment_id, download_count, last_downloaded) VALUES (?, 1, ?)
+		ON CONFLICT(attachment_id) DO UPDATE SET
+			download_count = download_count + 1,
+			last_downloaded = MAX(last_downloaded, excluded.last_downloaded)`,
+		attachmentID, timestamp,
+	)
+	if err != nil {
+		logger.Error("Failed to increment attachment downloads", zap.Int("attachment_id", attachmentID), zap.Error(err))
+		return fmt.Errorf("failed to increment attachment downloads: %w", err)
+	}
+
+	return nil
+}
+
+// GetDownloadStats returns the total downloads of the attachments of the given spaces and
+// the most downloaded ones
+func (db *DB) GetDownloadStats(spaceIDs []int, limit int) (int64, []AttachmentDownloads, error) {
+	top := []AttachmentDownloads{}
+	if len(spaceIDs) == 0 {
+		return 0, top, nil
+	}
+
+	placeholders := make([]string, len(spaceIDs))
+	args := make([]interface{}, len(spaceIDs))
+	for i, id := range spaceIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	where := fmt.Sprintf("p.space_id IN (%s)", strings.Join(placeholders, ","))
+
+	var total int64
+	err := db.QueryRow(
+		`SELECT COALESCE(SUM(d.download_count), 0)
+		FROM attachment_downloads d
+		JOIN attachments a ON a.id = d.attachment_id
+		JOIN posts p ON p.id = a.post_id
+		WHERE `+where,
+		args...,
+	).Scan(&total)
+	if err != nil {
+		logger.Error("Failed to sum attachment downloads", zap.Error(err))
+		return 0, nil, fmt.Errorf("failed to sum attachment downloads: %w", err)
+	}
+
+	rows, err := db.Query(
+		`SELECT a.id, a.post_id, a.filename, a.file_path, a.file_type, a.file_size, p.space_id, d.download_count, d.last_downloaded
+		FROM attachment_downloads d
+		JOIN attachments a ON a.id = d.attachment_id
+		JOIN posts p ON p.id = a.post_id
+		WHERE `+where+`
+		ORDER BY d.download_count DESC, d.last_downloaded DESC
+		LIMIT ?`,
+		append(args, limit)...,
+	)
+	if err != nil {
+		logger.Error("Failed to query top attachment downloads", zap.Error(err))
+		return 0, nil, fmt.Errorf("failed to query top attachment downloads: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item AttachmentDownloads
+		err := rows.Scan(
+			&item.ID, &item.PostID, &item.Filename, &item.FilePath, &item.FileType, &item.FileSize,
+			&item.SpaceID, &item.DownloadCount, &item.LastDownloaded,
+		)
+		if err != nil {
+			logger.Error("Failed to scan attachment downloads", zap.Error(err))
+			return 0, nil, fmt.Errorf("failed to scan attachment downloads: %w", err)
+		}
+		top = append(top, item)
+	}
+
+	return total, top, rows.Err()
+}
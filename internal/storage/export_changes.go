@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// BackfillExportChanges records every existing space and post as changed when it was created.
+// It only runs on an empty change log, so databases created before delta exports are covered
+// while later calls are no-ops.
+func (db *DB) BackfillExportChanges() error {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM export_changes").Scan(&count); err != nil {
+		logger.Error("Failed to count export changes", zap.Error(err))
+		return fmt.Errorf("failed to count export changes: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err := db.Exec(
+		`INSERT INTO export_changes (entity, entity_id, deleted, changed)
+		SELECT ?, id, 0, created FROM spaces
+		UNION ALL
+		SELECT ?, id, 0, created FROM posts`,
+		models.ExportEntitySpace, models.ExportEntityPost,
+	)
+	if err != nil {
+		logger.Error("Failed to backfill export changes", zap.Error(err))
+		return fmt.Errorf("failed to backfill export changes: %w", err)
+	}
+
+	return nil
+}
+
+// RecordExportChange sets the last change of a space or post
+func (db *DB) RecordExportChange(entity string, id int, deleted bool, changed int64) error {
+	_, err := db.Exec(
+		`INSERT INTO export_changes (entity, entity_id, deleted, changed) VALUES (?, ?, ?, ?)
+		ON CONFLICT(entity, entity_id) DO UPDATE SET deleted = excluded.deleted, changed = excluded.changed`,
+		entity, id, deleted, changed,
+	)
+	if err != nil {
+		logger.Error("Failed to record export change", zap.String("entity", entity), zap.Int("id", id), zap.Error(err))
+		return fmt.Errorf("failed to record export change: %w", err)
+	}
+
+	return nil
+}
+
+// SweepExportTombstones turns the changes of spaces and posts that no longer exist into
+// tombstones, for deletions that did not raise an event of their own such as the
+// descendants of a deleted space
+func (db *DB) SweepExportTombstones(changed int64) error {
+	_, err := db.Exec(
+		`UPDATE export_changes SET deleted = 1, changed = ?
+		WHERE deleted = 0 AND (
+			(entity = ? AND entity_id NOT IN (SELECT id FROM spaces)) OR
+			(entity = ? AND entity_id NOT IN (SELECT id FROM posts))
+		)`,
+		changed, models.ExportEntitySpace, models.ExportEntityPost,
+	)
+	if err != nil {
+		logger.Error("Failed to sweep export tombstones", zap.Error(err))
+		return fmt.Errorf("failed to sweep export tombstones: %w", err)
+	}
+
+	return nil
+}
+
+// GetExportChanges returns the changes made from since up to until, both inclusive, oldest first
+func (db *DB) GetExportChanges(since, until int64) ([]models.ExportChange, error) {
+	rows, err := db.Query(
+		`SELECT entity, entity_id, deleted, changed FROM export_changes
+		WHERE changed >= ? AND changed <= ? ORDER BY changed, entity, entity_id`,
+		since, until,
+	)
+	if err != nil {
+		logger.Error("Failed to query export changes", zap.Int64("since", since), zap.Error(err))
+		return nil, fmt.Errorf("failed to query export changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []models.ExportChange{}
+	for rows.Next() {
+		var change models.ExportChange
+		if err := rows.Scan(&change.Entity, &change.EntityID, &change.Deleted, &change.Changed); err != nil {
+			logger.Error("Failed to scan export change", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan export change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// GetAttachmentManifest returns the attachments of a post with their checksums
+func (db *DB) GetAttachmentManifest(postID int) ([]models.Attachment, error) {
+	rows, err := db.Query(
+		`SELECT a.id, a.post_id, a.filename, a.file_path, a.file_type, a.file_size, COALESCE(c.sha256, '')
+		FROM attachments a LEFT JOIN attachment_checksums c ON c.attachment_id = a.id
+		WHERE a.post_id = ? ORDER BY a.id`,
+		postID,
+	)
+	if err != nil {
+		logger.Error("Failed to query attachment manifest", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query attachment manifest: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		var attachment models.Attachment
+		err := rows.Scan(&attachment.ID, &attachment.PostID, &attachment.Filename, &attachment.FilePath, &attachment.FileType, &attachment.FileSize, &attachment.SHA256)
+		if err != nil {
+			logger.Error("Failed to scan attachment manifest", zap.Int("post_id", postID), zap.Error(err))
+			return nil, fmt.Errorf("failed to scan attachment manifest: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// GetFlagOverrides returns the feature flags overridden at runtime
+func (db *DB) GetFlagOverrides() ([]models.FeatureFlag, error) {
+	rows, err := db.Query("SELECT name, enabled, rollout, updated FROM feature_flag_overrides ORDER BY name")
+	if err != nil {
+		logger.Error("Failed to query feature flag overrides", zap.Error(err))
+		return nil, fmt.Errorf("failed to query feature flag overrides: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []models.FeatureFlag{}
+	for rows.Next() {
+		flag := models.FeatureFlag{Source: models.FlagSourceOverride}
+		if err := rows.Scan(&flag.Name, &flag.Enabled, &flag.Rollout, &flag.Updated); err != nil {
+			logger.Error("Failed to scan feature flag override", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan feature flag override: %w", err)
+		}
+		flags = append(flags, flag)
+	}
+
+	return flags, rows.Err()
+}
+
+// SetFlagOverride stores the runtime override of a feature flag
+func (db *DB) SetFlagOverride(flag models.FeatureFlag) error {
+	_, err := db.Exec(
+		"INSERT OR REPLACE INTO feature_flag_overrides (name, enabled, rollout, updated) VALUES (?, ?, ?, ?)",
+		flag.Name, flag.Enabled, flag.Rollout, flag.Updated,
+	)
+	if err != nil {
+		logger.Error("Failed to set feature flag override", zap.String("flag", flag.Name), zap.Error(err))
+		return fmt.Errorf("failed to set feature flag override: %w", err)
+	}
+	return nil
+}
+
+// DeleteFlagOverride removes the runtime override of a feature flag
+func (db *DB) DeleteFlagOverride(name string) error {
+	if _, err := db.Exec("DELETE FROM feature_flag_overrides WHERE name = ?", name); err != nil {
+		logger.Error("Failed to delete feature flag override", zap.String("flag", name), zap.Error(err))
+		return fmt.Errorf("failed to delete feature flag override: %w", err)
+	}
+	return nil
+}
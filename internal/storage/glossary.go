@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func (db *DB) CreateGlossaryTerm(spaceID int, term, definition string) (*models.GlossaryTerm, error) {
+	created := time.Now().UnixMilli()
+	result, err := db.Exec(
+		"INSERT INTO glossary_terms (space_id, term, definition, created) VALUES (?, ?, ?, ?)",
+		spaceID, term, definition, created,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			logger.Warning("Glossary term already exists", zap.Int("space_id", spaceID), zap.String("term", term))
+			return nil, fmt.Errorf("glossary term '%s' already exists in this space", term)
+		}
+		logger.Error("Failed to create glossary term", zap.Int("space_id", spaceID), zap.Error(err))
+		return nil, fmt.Errorf("failed to create glossary term: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Failed to get last insert ID after glossary term creation", zap.Int("space_id", spaceID), zap.Error(err))
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return &models.GlossaryTerm{
+		ID:         int(id),
+		SpaceID:    spaceID,
+		Term:       term,
+		Definition: definition,
+		Created:    created,
+	}, nil
+}
+
+func (db *DB) GetAllGlossaryTerms() ([]models.GlossaryTerm, error) {
+	rows, err := db.Query("SELECT id, space_id, term, definition, created FROM glossary_terms ORDER BY id")
+	if err != nil {
+		logger.Error("Failed to query glossary terms", zap.Error(err))
+		return nil, fmt.Errorf("failed to query glossary terms: %w", err)
+	}
+	defer rows.Close()
+
+	terms := []models.GlossaryTerm{}
+	for rows.Next() {
+		var t models.GlossaryTerm
+		if err := rows.Scan(&t.ID, &t.SpaceID, &t.Term, &t.Definition, &t.Created); err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+
+	return terms, rows.Err()
+}
+
+func (db *DB) DeleteGlossaryTerm(id int) error {
+	result, err := db.Exec("DELETE FROM glossary_terms WHERE id = ?", id)
+	if err != nil {
+		logger.Error("Failed to delete glossary term", zap.Int("term_id", id), zap.Error(err))
+		return fmt.Errorf("failed to delete glossary term: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("glossary term not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CreateIngestToken stores a new ingest token, with its signing secret if it has one, and fills
+// in its ID and creation time
+func (db *DB) CreateIngestToken(token *models.IngestToken) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin ingest token creation", zap.Int("space_id", token.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UnixMilli()
+	result, err := tx.Exec(
+		`INSERT INTO ingest_tokens (space_id, name, token_hash, prefix, rate_limit, created) VALUES (?, ?, ?, ?, ?, ?)`,
+		token.SpaceID, token.Name, token.Hash, token.Prefix, token.RateLimit, now,
+	)
+	if err != nil {
+		logger.Error("Failed to create ingest token", zap.Int("space_id", token.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to create ingest token: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Failed to get last insert ID after ingest token creation", zap.Error(err))
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	if token.Secret != "" {
+		if _, err := tx.Exec("INSERT INTO ingest_token_secrets (token_id, secret) VALUES (?, ?)", id, token.Secret); err != nil {
+			logger.Error("Failed to store ingest token secret", zap.Int64("token_id", id), zap.Error(err))
+			return fmt.Errorf("failed to store ingest token secret: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit ingest token creation", zap.Int("space_id", token.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	token.ID = int(id)
+	token.Created = now
+	token.Signed = token.Secret != ""
+	return nil
+}
+
+// GetIngestTokens returns every ingest token, revoked ones included
+func (db *DB) GetIngestTokens() ([]models.IngestToken, error) {
+	rows, err := db.Query(
+		`SELECT t.id, t.space_id, t.name, t.token_hash, t.prefix, t.rate_limit, t.created, t.last_used, t.revoked, COALESCE(s.secret, '')
+		FROM ingest_tokens t LEFT JOIN ingest_token_secrets s ON s.token_id = t.id ORDER BY t.id`,
+	)
+	if err != nil {
+		logger.Error("Failed to query ingest tokens", zap.Error(err))
+		return nil, fmt.Errorf("failed to query ingest tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.IngestToken{}
+	for rows.Next() {
+		var token models.IngestToken
+		if err := rows.Scan(&token.ID, &token.SpaceID, &token.Name, &token.Hash, &token.Prefix,
+			&token.RateLimit, &token.Created, &token.LastUsed, &token.Revoked, &token.Secret); err != nil {
+			logger.Error("Failed to scan ingest token", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan ingest token: %w", err)
+		}
+		token.Signed = token.Secret != ""
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// RevokeIngestToken marks a token as revoked at the given time
+func (db *DB) RevokeIngestToken(id int, revoked int64) error {
+	if _, err := db.Exec("UPDATE ingest_tokens SET revoked = ? WHERE id = ?", revoked, id); err != nil {
+		logger.Error("Failed to revoke ingest token", zap.Int("token_id", id), zap.Error(err))
+		return fmt.Errorf("failed to revoke ingest token: %w", err)
+	}
+	return nil
+}
+
+// TouchIngestToken records the last accepted use of a token
+func (db *DB) TouchIngestToken(id int, used int64) error {
+	if _, err := db.Exec("UPDATE ingest_tokens SET last_used = ? WHERE id = ?", used, id); err != nil {
+		logger.Error("Failed to update ingest token", zap.Int("token_id", id), zap.Error(err))
+		return fmt.Errorf("failed to update ingest token: %w", err)
+	}
+	return nil
+}
+
+// AddIngestEvent appends to the activity log of a token, keeping its most recent keep entries
+func (db *DB) AddIngestEvent(event *models.IngestEvent, keep int) error {
+	var postID interface{}
+	if event.PostID != 0 {
+		postID = event.PostID
+	}
+
+	result, err := db.Exec(
+		"INSERT INTO ingest_events (token_id, created, outcome, post_id, detail) VALUES (?, ?, ?, ?, ?)",
+		event.TokenID, event.Created, event.Outcome, postID, event.Detail,
+	)
+	if err != nil {
+		logger.Error("Failed to add ingest event", zap.Int("token_id", event.TokenID), zap.Error(err))
+		return fmt.Errorf("failed to add ingest event: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	event.ID = int(id)
+
+	_, err = db.Exec(
+		`DELETE FROM ingest_events WHERE token_id = ? AND id NOT IN (
+			SELECT id FROM ingest_events WHERE token_id = ? ORDER BY id DESC LIMIT ?
+		)`,
+		event.TokenID, event.TokenID, keep,
+	)
+	if err != nil {
+		logger.Error("Failed to prune ingest events", zap.Int("token_id", event.TokenID), zap.Error(err))
+		return fmt.Errorf("failed to prune ingest events: %w", err)
+	}
+
+	return nil
+}
+
+// GetIngestEvents returns the most recent entries of the activity log of a token
+func (db *DB) GetIngestEvents(tokenID, limit int) ([]models.IngestEvent, error) {
+	rows, err := db.Query(
+		`SELECT id, token_id, created, outcome, post_id, detail FROM ingest_events
+		WHERE token_id = ? ORDER BY id DESC LIMIT ?`,
+		tokenID, limit,
+	)
+	if err != nil {
+		logger.Error("Failed to query ingest events", zap.Int("token_id", tokenID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query ingest events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.IngestEvent{}
+	for rows.Next() {
+		var event models.IngestEvent
+		var postID sql.NullInt64
+		if err := rows.Scan(&event.ID, &event.TokenID, &event.Created, &event.Outcome, &postID, &event.Detail); err != nil {
+			logger.Error("Failed to scan ingest event", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan ingest event: %w", err)
+		}
+		event.PostID = int(postID.Int64)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
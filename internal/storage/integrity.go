@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// GetOrphanAttachmentIDs returns the attachments whose post no longer exists
+func (db *DB) GetOrphanAttachmentIDs() ([]int, error) {
+	return db.queryIDs("attachments without post",
+		"SELECT id FROM attachments WHERE post_id NOT IN (SELECT id FROM posts) ORDER BY id")
+}
+
+// GetPostIDsWithoutSpace returns the posts whose space no longer exists
+func (db *DB) GetPostIDsWithoutSpace() ([]int, error) {
+	return db.queryIDs("posts without space",
+		"SELECT id FROM posts WHERE space_id NOT IN (SELECT id FROM spaces) ORDER BY id")
+}
+
+// GetPostIDsMissingFromIndex returns the posts without a row in the full-text index
+func (db *DB) GetPostIDsMissingFromIndex() ([]int, error) {
+	return db.queryIDs("posts missing from full-text index",
+		"SELECT id FROM posts WHERE id NOT IN (SELECT docid FROM posts_fts) ORDER BY id")
+}
+
+// GetIndexRowCounts returns the number of posts and of rows in their full-text index
+func (db *DB) GetIndexRowCounts() (posts, indexed int, err error) {
+	err = db.QueryRow("SELECT (SELECT COUNT(*) FROM posts), (SELECT COUNT(*) FROM posts_fts)").Scan(&posts, &indexed)
+	if err != nil {
+		logger.Error("Failed to count full-text index rows", zap.Error(err))
+		return 0, 0, fmt.Errorf("failed to count full-text index rows: %w", err)
+	}
+	return posts, indexed, nil
+}
+
+// GetAttachmentFilePaths returns the files referenced by attachments and their prior versions,
+// mapped to whether they were moved to cold storage
+func (db *DB) GetAttachmentFilePaths() (map[string]bool, error) {
+	rows, err := db.Query(
+		`SELECT f.file_path, EXISTS(SELECT 1 FROM cold_attachments c WHERE c.file_path = f.file_path)
+		FROM (SELECT file_path FROM attachments UNION SELECT file_path FROM attachment_versions) f`,
+	)
+	if err != nil {
+		logger.Error("Failed to query attachment file paths", zap.Error(err))
+		return nil, fmt.Errorf("failed to query attachment file paths: %w", err)
+	}
+	defer rows.Close()
+
+	paths := make(map[string]bool)
+	for rows.Next() {
+		var path string
+		var cold bool
+		if err := rows.Scan(&path, &cold); err != nil {
+			logger.Error("Failed to scan attachment file path", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan attachment file path: %w", err)
+		}
+		paths[path] = cold
+	}
+	return paths, rows.Err()
+}
+
+func (db *DB) queryIDs(what, query string) ([]int, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		logger.Error("Failed to query "+what, zap.Error(err))
+		return nil, fmt.Errorf("failed to query %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			logger.Error("Failed to scan "+what, zap.Error(err))
+			return nil, fmt.Errorf("failed to scan %s: %w", what, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
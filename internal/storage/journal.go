@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// postTypeJoin and postTypeColumn resolve the type of posts aliased p; posts recorded as a
+// journal entry are journal posts
+const (
+	postTypeJoin   = "LEFT JOIN journal_entries je ON je.post_id = p.id"
+	postTypeColumn = "CASE WHEN je.post_id IS NULL THEN '" + models.PostTypeNote + "' ELSE '" + models.PostTypeJournal + "' END"
+)
+
+// CreateJournalPost creates the journal post of a space for a day (YYYY-MM-DD); it fails when
+// the day already has one
+func (db *DB) CreateJournalPost(ctx context.Context, spaceID int, content string, timestampMillis int64, source, day string) (*models.Post, error) {
+	return db.createPost(ctx, spaceID, content, timestampMillis, source, day)
+}
+
+// GetJournalPostID returns the journal post of a space for a day (YYYY-MM-DD); found is false
+// when the day has none
+func (db *DB) GetJournalPostID(spaceID int, day string) (int, bool, error) {
+	var postID int
+	err := db.QueryRow("SELECT post_id FROM journal_entries WHERE space_id = ? AND day = ?", spaceID, day).Scan(&postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		logger.Error("Failed to get journal entry", zap.Int("space_id", spaceID), zap.String("day", day), zap.Error(err))
+		return 0, false, fmt.Errorf("failed to get journal entry: %w", err)
+	}
+
+	return postID, true, nil
+}
+
+// GetJournalDay returns the day a journal post stands for; found is false for other posts
+func (db *DB) GetJournalDay(postID int) (string, bool, error) {
+	var day string
+	err := db.QueryRow("SELECT day FROM journal_entries WHERE post_id = ?", postID).Scan(&day)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		logger.Error("Failed to get journal day", zap.Int("post_id", postID), zap.Error(err))
+		return "", false, fmt.Errorf("failed to get journal day: %w", err)
+	}
+
+	return day, true, nil
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// LinkCount is the number of posts referencing a link
+type LinkCount struct {
+	URL            string `json:"url"`
+	Count          int    `json:"count"`
+	LastReferenced int64  `json:"last_referenced"` // Creation time of the latest post holding the link
+}
+
+// SetPostLinks replaces the links stored for a post
+func (db *DB) SetPostLinks(postID int, links []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for post links", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM post_links WHERE post_id = ?", postID); err != nil {
+		logger.Error("Failed to clear post links", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to clear post links: %w", err)
+	}
+	if err := insertPostLinks(tx, postID, links); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post links", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func insertPostLinks(tx *sql.Tx, postID int, links []string) error {
+	for _, link := range links {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO post_links (post_id, url) VALUES (?, ?)", postID, link); err != nil {
+			logger.Error("Failed to insert post link", zap.Int("post_id", postID), zap.String("url", link), zap.Error(err))
+			return fmt.Errorf("failed to insert post link: %w", err)
+		}
+	}
+	return nil
+}
+
+// RebuildPostLinks re-parses every post with the given parser and replaces all stored links
+func (db *DB) RebuildPostLinks(parse func(content string) []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for links rebuild", zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, content FROM posts")
+	if err != nil {
+		logger.Error("Failed to query posts for links rebuild", zap.Error(err))
+		return fmt.Errorf("failed to query posts: %w", err)
+	}
+
+	parsed := make(map[int][]string)
+	for rows.Next() {
+		var id int
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		if links := parse(content); len(links) > 0 {
+			parsed[id] = links
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM post_links"); err != nil {
+		logger.Error("Failed to clear post links", zap.Error(err))
+		return fmt.Errorf("failed to clear post links: %w", err)
+	}
+	for postID, links := range parsed {
+		if err := insertPostLinks(tx, postID, links); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit links rebuild", zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetLinkReferences returns the posts other than postID holding any of links, newest first,
+// one entry per post and link
+func (db *DB) GetLinkReferences(links []string, postID int, limit int) ([]models.LinkReference, error) {
+	if len(links) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, len(links)+2)
+	for _, link := range links {
+		args = append(args, link)
+	}
+	args = append(args, postID, limit)
+
+	rows, err := db.Query(fmt.Sprintf(
+		`SELECT l.url, p.id, p.space_id, p.created
+		FROM post_links l JOIN posts p ON p.id = l.post_id
+		WHERE l.url IN (%s) AND l.post_id != ?
+		ORDER BY p.created DESC, p.id DESC LIMIT ?`,
+		placeholders(len(links)),
+	), args...)
+	if err != nil {
+		logger.Error("Failed to query link references", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query link references: %w", err)
+	}
+	defer rows.Close()
+
+	var references []models.LinkReference
+	for rows.Next() {
+		var ref models.LinkReference
+		if err := rows.Scan(&ref.URL, &ref.PostID, &ref.SpaceID, &ref.Created); err != nil {
+			return nil, err
+		}
+		references = append(references, ref)
+	}
+
+	return references, rows.Err()
+}
+
+// GetLinkCounts returns the links referenced by the most posts, most referenced first.
+// A nil spaceIDs slice counts posts of every space but those of excludeSpaces.
+func (db *DB) GetLinkCounts(spaceIDs, excludeSpaces []int, limit int) ([]LinkCount, error) {
+	query := "SELECT l.url, COUNT(*), MAX(p.created) FROM post_links l JOIN posts p ON p.id = l.post_id"
+	var conditions []string
+	var args []interface{}
+	if spaceIDs != nil {
+		conditions = append(conditions, fmt.Sprintf("p.space_id IN (%s)", placeholders(len(spaceIDs))))
+		for _, id := range spaceIDs {
+			args = append(args, id)
+		}
+	}
+	if len(excludeSpaces) > 0 {
+		conditions = append(conditions, fmt.Sprintf("p.space_id NOT IN (%s)", placeholders(len(excludeSpaces))))
+		for _, id := range excludeSpaces {
+			args = append(args, id)
+		}
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " GROUP BY l.url ORDER BY COUNT(*) DESC, MAX(p.created) DESC, l.url ASC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Error("Failed to query link counts", zap.Error(err))
+		return nil, fmt.Errorf("failed to query link counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []LinkCount{}
+	for rows.Next() {
+		var count LinkCount
+		if err := rows.Scan(&count.URL, &count.Count, &count.LastReferenced); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// DatabaseSize returns the size of the database file in bytes, as SQLite accounts for it
+func (db *DB) DatabaseSize() (int64, error) {
+	var pageCount, pageSize int64
+	if err := db.QueryRow("PRAGMA page_count").Scan(&pageCount); err != nil {
+		logger.Error("Failed to read page count", zap.Error(err))
+		return 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		logger.Error("Failed to read page size", zap.Error(err))
+		return 0, fmt.Errorf("failed to read page size: %w", err)
+	}
+	return pageCount * pageSize, nil
+}
+
+// Analyze refreshes the statistics used by the query planner
+func (db *DB) Analyze() error {
+	if _, err := db.Exec("ANALYZE"); err != nil {
+		logger.Error("Failed to analyze database", zap.Error(err))
+		return fmt.Errorf("failed to analyze database: %w", err)
+	}
+	return nil
+}
+
+// Vacuum rebuilds the database file, reclaiming the space left by deleted rows
+func (db *DB) Vacuum() error {
+	if _, err := db.Exec("VACUUM"); err != nil {
+		logger.Error("Failed to vacuum database", zap.Error(err))
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return nil
+}
+
+// GetFTSTables lists the full-text search tables of the database
+func (db *DB) GetFTSTables() ([]string, error) {
+	rows, err := db.Query(
+		`SELECT name FROM sqlite_master
+		WHERE type = 'table' AND (sql LIKE '%USING fts5%' OR sql LIKE '%USING fts4%')
+		ORDER BY name`,
+	)
+	if err != nil {
+		logger.Error("Failed to query full-text search tables", zap.Error(err))
+		return nil, fmt.Errorf("failed to query fts tables: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan fts table: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// OptimizeFTS merges the index segments of a full-text search table
+func (db *DB) OptimizeFTS(table string) error {
+	query := fmt.Sprintf(`INSERT INTO "%s"("%s") VALUES ('optimize')`, table, table)
+	if _, err := db.Exec(query); err != nil {
+		logger.Error("Failed to optimize full-text search table", zap.String("table", table), zap.Error(err))
+		return fmt.Errorf("failed to optimize fts table %s: %w", table, err)
+	}
+	return nil
+}
+
+// BackupTo writes a consistent copy of the database to path, which must not exist yet
+func (db *DB) BackupTo(path string) error {
+	if _, err := db.Exec("VACUUM INTO ?", path); err != nil {
+		logger.Error("Failed to back up database", zap.String("path", path), zap.Error(err))
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/utils"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// MetricPoint is a single metric value taken from a post
+type MetricPoint struct {
+	PostID    int     `json:"post_id"`
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+// SetPostMetrics replaces the metrics stored for a post
+func (db *DB) SetPostMetrics(postID int, metrics []utils.Metric) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for post metrics", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM post_metrics WHERE post_id = ?", postID); err != nil {
+		logger.Error("Failed to clear post metrics", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to clear post metrics: %w", err)
+	}
+
+	for _, m := range metrics {
+		if _, err := tx.Exec(
+			"INSERT INTO post_metrics (post_id, name, value) VALUES (?, ?, ?)",
+			postID, m.Name, m.Value,
+		); err != nil {
+			logger.Error("Failed to insert post metric", zap.Int("post_id", postID), zap.String("name", m.Name), zap.Error(err))
+			return fmt.Errorf("failed to insert post metric: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post metrics", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RebuildPostMetrics re-parses every post with the given parser and replaces all stored metrics
+func (db *DB) RebuildPostMetrics(parse func(content string) []utils.Metric) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for metrics rebuild", zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, content FROM posts")
+	if err != nil {
+		logger.Error("Failed to query posts for metrics rebuild", zap.Error(err))
+		return fmt.Errorf("failed to query posts: %w", err)
+	}
+
+	parsed := make(map[int][]utils.Metric)
+	for rows.Next() {
+		var id int
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		if metrics := parse(content); len(metrics) > 0 {
+			parsed[id] = metrics
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM post_metrics"); err != nil {
+		logger.Error("Failed to clear metrics for rebuild", zap.Error(err))
+		return fmt.Errorf("failed to clear metrics: %w", err)
+	}
+
+	for postID, metrics := range parsed {
+		for _, m := range metrics {
+			if _, err := tx.Exec(
+				"INSERT INTO post_metrics (post_id, name, value) VALUES (?, ?, ?)",
+				postID, m.Name, m.Value,
+			); err != nil {
+				logger.Error("Failed to insert post metric", zap.Int("post_id", postID), zap.Error(err))
+				return fmt.Errorf("failed to insert post metric: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit metrics rebuild", zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetMetricSeries returns the values of a metric in the given spaces ordered by post time
+func (db *DB) GetMetricSeries(name string, spaceIDs []int) ([]MetricPoint, error) {
+	if len(spaceIDs) == 0 {
+		return []MetricPoint{}, nil
+	}
+
+	placeholders := make([]string, len(spaceIDs))
+	args := make([]interface{}, 0, len(spaceIDs)+1)
+	args = append(args, name)
+	for i, id := range spaceIDs {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT m.post_id, p.created, m.value
+		FROM post_metrics m JOIN posts p ON p.id = m.post_id
+		WHERE m.name = ? AND p.space_id IN (%s)
+		ORDER BY p.created ASC, m.id ASC`,
+		strings.Join(placeholders, ","),
+	)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Error("Failed to query metric series", zap.String("name", name), zap.Error(err))
+		return nil, fmt.Errorf("failed to query metric series: %w", err)
+	}
+	defer rows.Close()
+
+	points := []MetricPoint{}
+	for rows.Next() {
+		var p MetricPoint
+		if err := rows.Scan(&p.PostID, &p.Timestamp, &p.Value); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
+
+// GetMetricNames returns the distinct metric names used in the given spaces
+func (db *DB) GetMetricNames(spaceIDs []int) ([]string, error) {
+	if len(spaceIDs) == 0 {
+		return []string{}, nil
+	}
+
+	placeholders := make([]string, len(spaceIDs))
+	args := make([]interface{}, len(spaceIDs))
+	for i, id := range spaceIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(
+		`SELECT DISTINCT m.name
+		FROM post_metrics m JOIN posts p ON p.id = m.post_id
+		WHERE p.space_id IN (%s)
+		ORDER BY m.name`,
+		strings.Join(placeholders, ","),
+	)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Error("Failed to query metric names", zap.Error(err))
+		return nil, fmt.Errorf("failed to query metric names: %w", err)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
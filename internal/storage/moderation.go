@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const moderationFlagColumns = `f.id, f.post_id, p.space_id, f.rule, f.source, f.excerpt, f.fingerprint, f.status, f.created, f.reviewed`
+
+func scanModerationFlags(rows *sql.Rows) ([]models.ModerationFlag, error) {
+	flags := []models.ModerationFlag{}
+	for rows.Next() {
+		var flag models.ModerationFlag
+		var reviewed sql.NullInt64
+		err := rows.Scan(
+			&flag.ID, &flag.PostID, &flag.SpaceID, &flag.Rule, &flag.Source, &flag.Excerpt,
+			&flag.Fingerprint, &flag.Status, &flag.Created, &reviewed,
+		)
+		if err != nil {
+			logger.Error("Failed to scan moderation flag", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan moderation flag: %w", err)
+		}
+		if reviewed.Valid {
+			flag.Reviewed = &reviewed.Int64
+		}
+		flags = append(flags, flag)
+	}
+	return flags, rows.Err()
+}
+
+// GetModerationFlagsByPost returns every flag raised on a post
+func (db *DB) GetModerationFlagsByPost(postID int) ([]models.ModerationFlag, error) {
+	rows, err := db.Query(
+		"SELECT "+moderationFlagColumns+" FROM moderation_flags f JOIN posts p ON p.id = f.post_id WHERE f.post_id = ? ORDER BY f.id",
+		postID,
+	)
+	if err != nil {
+		logger.Error("Failed to query moderation flags", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query moderation flags: %w", err)
+	}
+	defer rows.Close()
+
+	return scanModerationFlags(rows)
+}
+
+// GetModerationFlags returns flags with the given status (all when empty), optionally limited
+// to posts of the given spaces, newest first
+func (db *DB) GetModerationFlags(status string, spaceIDs []int) ([]models.ModerationFlag, error) {
+	var conditions []string
+	var args []interface{}
+	if status != "" {
+		conditions = append(conditions, "f.status = ?")
+		args = append(args, status)
+	}
+	if spaceIDs != nil {
+		if len(spaceIDs) == 0 {
+			return []models.ModerationFlag{}, nil
+		}
+		placeholders := make([]string, len(spaceIDs))
+		for i, id := range spaceIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		conditions = append(conditions, fmt.Sprintf("p.space_id IN (%s)", strings.Join(placeholders, ",")))
+	}
+
+	query := "SELECT " + moderationFlagColumns + " FROM moderation_flags f JOIN posts p ON p.id = f.post_id"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY f.created DESC, f.id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Error("Failed to query moderation flags", zap.Error(err))
+		return nil, fmt.Errorf("failed to query moderation flags: %w", err)
+	}
+	defer rows.Close()
+
+	return scanModerationFlags(rows)
+}
+
+// CreateModerationFlag queues a new flag
+func (db *DB) CreateModerationFlag(flag *models.ModerationFlag) error {
+	result, err := db.Exec(
+		"INSERT INTO moderation_flags (post_id, rule, source, excerpt, fingerprint, status, created) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		flag.PostID, flag.Rule, flag.Source, flag.Excerpt, flag.Fingerprint, flag.Status, flag.Created,
+	)
+	if err != nil {
+		logger.Error("Failed to create moderation flag", zap.Int("post_id", flag.PostID), zap.Error(err))
+		return fmt.Errorf("failed to create moderation flag: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	flag.ID = int(id)
+
+	return nil
+}
+
+// DeleteModerationFlag removes a flag whose finding no longer applies
+func (db *DB) DeleteModerationFlag(id int) error {
+	if _, err := db.Exec("DELETE FROM moderation_flags WHERE id = ?", id); err != nil {
+		logger.Error("Failed to delete moderation flag", zap.Int("flag_id", id), zap.Error(err))
+		return fmt.Errorf("failed to delete moderation flag: %w", err)
+	}
+	return nil
+}
+
+// UpdateModerationFlagStatus records the review of a flag
+func (db *DB) UpdateModerationFlagStatus(id int, status string, reviewed int64) error {
+	result, err := db.Exec("UPDATE moderation_flags SET status = ?, reviewed = ? WHERE id = ?", status, reviewed, id)
+	if err != nil {
+		logger.Error("Failed to update moderation flag", zap.Int("flag_id", id), zap.Error(err))
+		return fmt.Errorf("failed to update moderation flag: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("moderation flag not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// GetNotificationMutes returns the IDs of the spaces whose notifications are muted
+func (db *DB) GetNotificationMutes() ([]int, error) {
+	rows, err := db.Query("SELECT space_id FROM notification_mutes ORDER BY space_id")
+	if err != nil {
+		logger.Error("Failed to query notification mutes", zap.Error(err))
+		return nil, fmt.Errorf("failed to query notification mutes: %w", err)
+	}
+	defer rows.Close()
+
+	spaceIDs := []int{}
+	for rows.Next() {
+		var spaceID int
+		if err := rows.Scan(&spaceID); err != nil {
+			logger.Error("Failed to scan notification mute", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan notification mute: %w", err)
+		}
+		spaceIDs = append(spaceIDs, spaceID)
+	}
+
+	return spaceIDs, rows.Err()
+}
+
+// SetNotificationMute mutes or unmutes the notifications of a space
+func (db *DB) SetNotificationMute(spaceID int, muted bool, created int64) error {
+	var err error
+	if muted {
+		_, err = db.Exec("INSERT OR IGNORE INTO notification_mutes (space_id, created) VALUES (?, ?)", spaceID, created)
+	} else {
+		_, err = db.Exec("DELETE FROM notification_mutes WHERE space_id = ?", spaceID)
+	}
+	if err != nil {
+		logger.Error("Failed to set notification mute", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to set notification mute: %w", err)
+	}
+
+	return nil
+}
+
+// GetQuietHours returns the quiet hours of the instance, nil when none are set
+func (db *DB) GetQuietHours() (*models.QuietHours, error) {
+	var quiet models.QuietHours
+	err := db.QueryRow("SELECT start, end FROM notification_quiet_hours WHERE id = 1").Scan(&quiet.Start, &quiet.End)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error("Failed to query quiet hours", zap.Error(err))
+		return nil, fmt.Errorf("failed to query quiet hours: %w", err)
+	}
+
+	return &quiet, nil
+}
+
+// SetQuietHours stores the quiet hours of the instance; nil removes them
+func (db *DB) SetQuietHours(quiet *models.QuietHours) error {
+	var err error
+	if quiet == nil {
+		_, err = db.Exec("DELETE FROM notification_quiet_hours")
+	} else {
+		_, err = db.Exec("INSERT OR REPLACE INTO notification_quiet_hours (id, start, end) VALUES (1, ?, ?)", quiet.Start, quiet.End)
+	}
+	if err != nil {
+		logger.Error("Failed to set quiet hours", zap.Error(err))
+		return fmt.Errorf("failed to set quiet hours: %w", err)
+	}
+
+	return nil
+}
+
+// HoldDelivery stores a notification or webhook call until quiet hours end
+func (db *DB) HoldDelivery(delivery *models.HeldDelivery) error {
+	result, err := db.Exec(
+		"INSERT INTO held_deliveries (channel, target, payload, created) VALUES (?, ?, ?, ?)",
+		delivery.Channel, delivery.Target, delivery.Payload, delivery.Created,
+	)
+	if err != nil {
+		logger.Error("Failed to hold delivery", zap.String("channel", delivery.Channel), zap.Error(err))
+		return fmt.Errorf("failed to hold delivery: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Failed to get last insert ID after holding delivery", zap.Error(err))
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	delivery.ID = int(id)
+	return nil
+}
+
+// GetHeldDeliveries returns the held deliveries, oldest first
+func (db *DB) GetHeldDeliveries() ([]models.HeldDelivery, error) {
+	rows, err := db.Query("SELECT id, channel, target, payload, attempts, created FROM held_deliveries ORDER BY id")
+	if err != nil {
+		logger.Error("Failed to query held deliveries", zap.Error(err))
+		return nil, fmt.Errorf("failed to query held deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.HeldDelivery{}
+	for rows.Next() {
+		var d models.HeldDelivery
+		if err := rows.Scan(&d.ID, &d.Channel, &d.Target, &d.Payload, &d.Attempts, &d.Created); err != nil {
+			logger.Error("Failed to scan held delivery", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan held delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// CountHeldDeliveries counts the deliveries waiting for quiet hours to end
+func (db *DB) CountHeldDeliveries() (int, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM held_deliveries").Scan(&count); err != nil {
+		logger.Error("Failed to count held deliveries", zap.Error(err))
+		return 0, fmt.Errorf("failed to count held deliveries: %w", err)
+	}
+	return count, nil
+}
+
+// RecordHeldDeliveryAttempt counts a failed attempt to release a held delivery
+func (db *DB) RecordHeldDeliveryAttempt(id int) error {
+	if _, err := db.Exec("UPDATE held_deliveries SET attempts = attempts + 1 WHERE id = ?", id); err != nil {
+		logger.Error("Failed to record held delivery attempt", zap.Int("delivery_id", id), zap.Error(err))
+		return fmt.Errorf("failed to record held delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// DeleteHeldDelivery removes a released or abandoned held delivery
+func (db *DB) DeleteHeldDelivery(id int) error {
+	if _, err := db.Exec("DELETE FROM held_deliveries WHERE id = ?", id); err != nil {
+		logger.Error("Failed to delete held delivery", zap.Int("delivery_id", id), zap.Error(err))
+		return fmt.Errorf("failed to delete held delivery: %w", err)
+	}
+	return nil
+}
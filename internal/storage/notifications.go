@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+const notificationColumns = "id, kind, title, message, COALESCE(space_id, 0), COALESCE(post_id, 0), dedupe_key, count, created, updated, read"
+
+// nullableID stores a zero ID as NULL, e.g. filters spanning every space or notifications
+// about no particular post
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// RaiseNotification stores a notification. When it has a key and an unread notification with
+// the same key exists, that one is refreshed and its count increased instead.
+func (db *DB) RaiseNotification(n *models.Notification) error {
+	if n.Key != "" {
+		var id, count int
+		var created int64
+		err := db.QueryRow(
+			"SELECT id, count, created FROM notifications WHERE dedupe_key = ? AND read = 0 ORDER BY id DESC LIMIT 1", n.Key,
+		).Scan(&id, &count, &created)
+		if err != nil && err != sql.ErrNoRows {
+			logger.Error("Failed to look up notification", zap.String("key", n.Key), zap.Error(err))
+			return fmt.Errorf("failed to look up notification: %w", err)
+		}
+		if err == nil {
+			_, err = db.Exec(
+				"UPDATE notifications SET kind = ?, title = ?, message = ?, space_id = ?, post_id = ?, count = count + 1, updated = ? WHERE id = ?",
+				n.Kind, n.Title, n.Message, nullableID(n.SpaceID), nullableID(n.PostID), n.Updated, id,
+			)
+			if err != nil {
+				logger.Error("Failed to refresh notification", zap.Int("notification_id", id), zap.Error(err))
+				return fmt.Errorf("failed to refresh notification: %w", err)
+			}
+			n.ID = id
+			n.Count = count + 1
+			n.Created = created
+			return nil
+		}
+	}
+
+	result, err := db.Exec(
+		`INSERT INTO notifications (kind, title, message, space_id, post_id, dedupe_key, count, created, updated)
+		VALUES (?, ?, ?, ?, ?, ?, 1, ?, ?)`,
+		n.Kind, n.Title, n.Message, nullableID(n.SpaceID), nullableID(n.PostID), n.Key, n.Created, n.Updated,
+	)
+	if err != nil {
+		logger.Error("Failed to create notification", zap.String("kind", n.Kind), zap.Error(err))
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Failed to get last insert ID after notification creation", zap.Error(err))
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	n.ID = int(id)
+	n.Count = 1
+	return nil
+}
+
+// GetNotifications lists notifications newest first. beforeID > 0 pages past that notification.
+func (db *DB) GetNotifications(unreadOnly bool, beforeID, limit int) ([]models.Notification, error) {
+	query := "SELECT " + notificationColumns + " FROM notifications WHERE 1 = 1"
+	var args []interface{}
+	if unreadOnly {
+		query += " AND read = 0"
+	}
+	if beforeID > 0 {
+		query += " AND id < ?"
+		args = append(args, beforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Error("Failed to query notifications", zap.Error(err))
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.Kind, &n.Title, &n.Message, &n.SpaceID, &n.PostID, &n.Key,
+			&n.Count, &n.Created, &n.Updated, &n.Read); err != nil {
+			logger.Error("Failed to scan notification", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	return notifications, rows.Err()
+}
+
+// CountUnreadNotifications counts the notifications not marked read
+func (db *DB) CountUnreadNotifications() (int, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM notifications WHERE read = 0").Scan(&count); err != nil {
+		logger.Error("Failed to count unread notifications", zap.Error(err))
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkNotificationRead marks one notification read; it fails when the notification does not exist
+func (db *DB) MarkNotificationRead(id int, read int64) error {
+	result, err := db.Exec("UPDATE notifications SET read = ? WHERE id = ? AND read = 0", read, id)
+	if err != nil {
+		logger.Error("Failed to mark notification read", zap.Int("notification_id", id), zap.Error(err))
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		var exists int
+		if err := db.QueryRow("SELECT COUNT(*) FROM notifications WHERE id = ?", id).Scan(&exists); err != nil || exists == 0 {
+			return fmt.Errorf("notification not found")
+		}
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead marks every unread notification, of the given kind when not empty,
+// read and returns how many were changed
+func (db *DB) MarkAllNotificationsRead(kind string, read int64) (int, error) {
+	query := "UPDATE notifications SET read = ? WHERE read = 0"
+	args := []interface{}{read}
+	if kind != "" {
+		query += " AND kind = ?"
+		args = append(args, kind)
+	}
+
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		logger.Error("Failed to mark notifications read", zap.String("kind", kind), zap.Error(err))
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return int(affected), nil
+}
+
+// DeleteNotificationsBefore removes notifications last updated before the given time
+func (db *DB) DeleteNotificationsBefore(before int64) (int, error) {
+	result, err := db.Exec("DELETE FROM notifications WHERE updated < ?", before)
+	if err != nil {
+		logger.Error("Failed to purge notifications", zap.Error(err))
+		return 0, fmt.Errorf("failed to purge notifications: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return int(affected), nil
+}
@@ -0,0 +1,56 @@
+package storage
+
+import "backthynk/internal/core/models"
+
+// postActivityJoin and postActivityColumn resolve the last activity of posts aliased p; posts
+// without an activity row were never touched since their creation
+const (
+	postActivityJoin   = "LEFT JOIN post_activity pa ON pa.post_id = p.id"
+	postActivityColumn = "COALESCE(pa.updated, p.created)"
+)
+
+// touchPostQuery records the activity time of a post, taking the post ID and the time
+const touchPostQuery = `INSERT INTO post_activity (post_id, updated) VALUES (?, ?)
+	ON CONFLICT(post_id) DO UPDATE SET updated = excluded.updated`
+
+// postOrder returns the join needed by a listing order, if any, and its ORDER BY clause.
+// Unknown orders list newest first. With pinnedFirst the pinned posts, joined by
+// postPinScopeJoin, lead in that order.
+func postOrder(sort string, pinnedFirst bool) (string, string) {
+	order := " ORDER BY "
+	if pinnedFirst {
+		order += postPinnedColumn + " DESC, "
+	}
+
+	switch sort {
+	case models.PostSortCreatedAsc:
+		return "", order + "p.created ASC, p.id ASC"
+	case models.PostSortUpdatedDesc:
+		return " " + postActivityJoin, order + postActivityColumn + " DESC, p.id DESC"
+	default:
+		return "", order + "p.created DESC, p.id DESC"
+	}
+}
+
+// postCursorCondition returns the WHERE condition on posts aliased p that keeps the posts coming
+// after the cursor in a creation order, if any. The (created, id) indexes serve it. With
+// pinnedFirst the pinned posts come before the others, see postOrder.
+func postCursorCondition(filter models.PostFilter, pinnedFirst bool) (string, []interface{}) {
+	if filter.Cursor == nil || !models.IsCursorSort(filter.Sort) {
+		return "", nil
+	}
+
+	args := []interface{}{filter.Cursor.Created, filter.Cursor.ID}
+	after := "(p.created, p.id) < (?, ?)"
+	if filter.Sort == models.PostSortCreatedAsc {
+		after = "(p.created, p.id) > (?, ?)"
+	}
+	switch {
+	case !pinnedFirst:
+		return after, args
+	case filter.Cursor.Pinned:
+		return "((" + postPinnedColumn + " AND " + after + ") OR pp.post_id IS NULL)", args
+	default:
+		return "(pp.post_id IS NULL AND " + after + ")", args
+	}
+}
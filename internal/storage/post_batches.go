@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// GetLatestPostsByScopes lists the newest posts of several scopes in one query. scopes maps a
+// caller chosen key to the spaces it covers, for example a space and its descendants, and the
+// result holds, under the same key, the posts owned by or cross-posted into those spaces,
+// newest first, skipping offset and keeping at most limit of them. Attachments and link
+// previews are not loaded, see GetAttachmentsByPosts and GetLinkPreviewsByPosts.
+func (db *DB) GetLatestPostsByScopes(ctx context.Context, scopes map[int][]int, limit, offset int) (map[int][]models.Post, error) {
+	result := make(map[int][]models.Post, len(scopes))
+	var values []string
+	var args []interface{}
+	for key, spaceIDs := range scopes {
+		result[key] = []models.Post{}
+		for _, spaceID := range spaceIDs {
+			values = append(values, "(?, ?)")
+			args = append(args, key, spaceID)
+		}
+	}
+	if len(values) == 0 {
+		return result, nil
+	}
+
+	query := fmt.Sprintf(`WITH scope(scope_id, space_id) AS (VALUES %s),
+		candidates AS (
+			SELECT s.scope_id, p.id AS post_id FROM scope s JOIN posts p ON p.space_id = s.space_id
+			UNION
+			SELECT s.scope_id, c.post_id FROM scope s JOIN post_crossposts c ON c.space_id = s.space_id
+		),
+		ranked AS (
+			SELECT c.scope_id, p.id, p.space_id, p.content, p.created, %s AS source, %s AS type, %s AS hash,
+				ROW_NUMBER() OVER (PARTITION BY c.scope_id ORDER BY p.created DESC, p.id DESC) AS position
+			FROM candidates c JOIN posts p ON p.id = c.post_id %s %s %s
+		)
+		SELECT scope_id, id, space_id, content, created, source, type, hash FROM ranked
+		WHERE position > ? AND position <= ? ORDER BY scope_id, position`,
+		strings.Join(values, ", "), postSourceColumn, postTypeColumn, postHashColumn, postSourceJoin, postTypeJoin, postHashJoin,
+	)
+	args = append(args, offset, offset+limit)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error("Failed to query latest posts by scopes", zap.Int("scopes", len(scopes)), zap.Error(err))
+		return nil, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key int
+		var post models.Post
+		if err := rows.Scan(&key, &post.ID, &post.SpaceID, &post.Content, &post.Created, &post.Source, &post.Type, &post.Hash); err != nil {
+			logger.Error("Failed to scan post", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		if !containsID(scopes[key], post.SpaceID) {
+			ownerID := post.SpaceID
+			post.CrosspostedFrom = &ownerID
+		}
+		result[key] = append(result[key], post)
+	}
+
+	return result, rows.Err()
+}
+
+// GetAttachmentsByPosts returns the attachments of several posts in one query, keyed by post ID.
+// Posts without attachments are left out.
+func (db *DB) GetAttachmentsByPosts(ctx context.Context, postIDs []int) (map[int][]models.Attachment, error) {
+	attachments := make(map[int][]models.Attachment)
+	if len(postIDs) == 0 {
+		return attachments, nil
+	}
+
+	args := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		args[i] = id
+	}
+
+	rows, err := db.QueryContext(ctx,
+		fmt.Sprintf("SELECT id, post_id, filename, file_path, file_type, file_size FROM attachments WHERE post_id IN (%s) ORDER BY id", placeholders(len(postIDs))),
+		args...,
+	)
+	if err != nil {
+		logger.Error("Failed to query attachments by posts", zap.Int("posts", len(postIDs)), zap.Error(err))
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attachment models.Attachment
+		if err := rows.Scan(&attachment.ID, &attachment.PostID, &attachment.Filename, &attachment.FilePath, &attachment.FileType, &attachment.FileSize); err != nil {
+			logger.Error("Failed to scan attachment", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments[attachment.PostID] = append(attachments[attachment.PostID], attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+// GetLinkPreviewsByPosts returns the link previews of several posts in one query, keyed by post
+// ID. Posts without previews are left out.
+func (db *DB) GetLinkPreviewsByPosts(ctx context.Context, postIDs []int) (map[int][]models.LinkPreview, error) {
+	previews := make(map[int][]models.LinkPreview)
+	if len(postIDs) == 0 {
+		return previews, nil
+	}
+
+	args := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		args[i] = id
+	}
+
+	rows, err := db.QueryContext(ctx,
+		fmt.Sprintf(`SELECT id, post_id, url, title, description, image_url, site_name
+			FROM link_previews WHERE post_id IN (%s) ORDER BY id`, placeholders(len(postIDs))),
+		args...,
+	)
+	if err != nil {
+		logger.Error("Failed to query link previews by posts", zap.Int("posts", len(postIDs)), zap.Error(err))
+		return nil, fmt.Errorf("failed to query link previews: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var preview models.LinkPreview
+		if err := rows.Scan(&preview.ID, &preview.PostID, &preview.URL, &preview.Title, &preview.Description, &preview.ImageURL, &preview.SiteName); err != nil {
+			logger.Error("Failed to scan link preview", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan link preview: %w", err)
+		}
+		previews[preview.PostID] = append(previews[preview.PostID], preview)
+	}
+
+	return previews, rows.Err()
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// postFilterConditions turns a filter into WHERE conditions on posts aliased p, which must be
+// joined with postSourceJoin
+func postFilterConditions(filter models.PostFilter) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Source != "" {
+		conditions = append(conditions, postSourceColumn+" = ?")
+		args = append(args, filter.Source)
+	}
+
+	for _, tag := range filter.Tags {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM post_tags pt WHERE pt.post_id = p.id AND pt.tag = ?)")
+		args = append(args, tag)
+	}
+
+	if len(filter.Extensions) > 0 {
+		matches := make([]string, len(filter.Extensions))
+		for i, ext := range filter.Extensions {
+			matches[i] = "LOWER(a.filename) LIKE ?"
+			args = append(args, "%."+strings.ToLower(ext))
+		}
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM attachments a WHERE a.post_id = p.id AND (%s))", strings.Join(matches, " OR "),
+		))
+	}
+
+	if filter.After > 0 {
+		conditions = append(conditions, "p.created >= ?")
+		args = append(args, filter.After)
+	}
+	if filter.Before > 0 {
+		conditions = append(conditions, "p.created < ?")
+		args = append(args, filter.Before)
+	}
+
+	if len(filter.ExcludeSpaces) > 0 {
+		placeholders := make([]string, len(filter.ExcludeSpaces))
+		for i, id := range filter.ExcludeSpaces {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		conditions = append(conditions, fmt.Sprintf("p.space_id NOT IN (%s)", strings.Join(placeholders, ",")))
+	}
+
+	return conditions, args
+}
+
+// CountPosts counts the posts of the given spaces (every space when nil) matching filter,
+// including the posts cross-posted into them
+func (db *DB) CountPosts(ctx context.Context, spaceIDs []int, filter models.PostFilter) (int, error) {
+	conditions, args := postFilterConditions(filter)
+
+	if spaceIDs != nil {
+		if len(spaceIDs) == 0 {
+			return 0, nil
+		}
+		scope, scopeArgs := postScopeCondition(spaceIDs)
+		conditions = append(conditions, scope)
+		args = append(args, scopeArgs...)
+	}
+
+	query := "SELECT COUNT(*) FROM posts p " + postSourceJoin
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		logger.Error("Failed to count filtered posts", zap.String("source", filter.Source), zap.Error(err))
+		return 0, fmt.Errorf("failed to count posts: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// postPinJoin and postPinnedColumn resolve whether posts aliased p are pinned
+const (
+	postPinJoin      = "LEFT JOIN post_pins pp ON pp.post_id = p.id"
+	postPinnedColumn = "pp.post_id IS NOT NULL"
+)
+
+// postPinScopeJoin joins the pins of the posts aliased p owned by one of spaceIDs, so that
+// postPinnedColumn only holds for them. Posts cross-posted into those spaces stay pinned to
+// their own space.
+func postPinScopeJoin(spaceIDs []int) (string, []interface{}) {
+	placeholders := make([]string, len(spaceIDs))
+	args := make([]interface{}, len(spaceIDs))
+	for i, id := range spaceIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	return fmt.Sprintf("LEFT JOIN post_pins pp ON pp.post_id = p.id AND p.space_id IN (%s)", strings.Join(placeholders, ",")), args
+}
+
+// GetPostPins returns when the pinned posts among postIDs were pinned, by post ID
+func (db *DB) GetPostPins(postIDs []int) (map[int]int64, error) {
+	pins := make(map[int]int64)
+	if len(postIDs) == 0 {
+		return pins, nil
+	}
+
+	placeholders := make([]string, len(postIDs))
+	args := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	rows, err := db.Query("SELECT post_id, pinned FROM post_pins WHERE post_id IN ("+strings.Join(placeholders, ",")+")", args...)
+	if err != nil {
+		logger.Error("Failed to query post pins", zap.Error(err))
+		return nil, fmt.Errorf("failed to query post pins: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int
+		var pinned int64
+		if err := rows.Scan(&postID, &pinned); err != nil {
+			logger.Error("Failed to scan post pin", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan post pin: %w", err)
+		}
+		pins[postID] = pinned
+	}
+
+	return pins, rows.Err()
+}
+
+// SetPostPinned pins or unpins a post and returns its space, and whether anything changed.
+// Pinning fails when the space of the post already holds max pinned posts.
+func (db *DB) SetPostPinned(postID int, pinned bool, at int64, max int) (int, bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin post pin", zap.Int("post_id", postID), zap.Error(err))
+		return 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var spaceID int
+	var current bool
+	err = tx.QueryRow("SELECT p.space_id, "+postPinnedColumn+" FROM posts p "+postPinJoin+" WHERE p.id = ?", postID).Scan(&spaceID, &current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, fmt.Errorf("post not found")
+		}
+		logger.Error("Failed to get post to pin", zap.Int("post_id", postID), zap.Error(err))
+		return 0, false, fmt.Errorf("failed to get post: %w", err)
+	}
+	if current == pinned {
+		return spaceID, false, nil
+	}
+
+	if pinned {
+		var count int
+		err := tx.QueryRow("SELECT COUNT(*) FROM post_pins pp JOIN posts p ON p.id = pp.post_id WHERE p.space_id = ?", spaceID).Scan(&count)
+		if err != nil {
+			logger.Error("Failed to count pinned posts", zap.Int("space_id", spaceID), zap.Error(err))
+			return 0, false, fmt.Errorf("failed to count pinned posts: %w", err)
+		}
+		if count >= max {
+			return 0, false, fmt.Errorf("too many pinned posts")
+		}
+		_, err = tx.Exec("INSERT INTO post_pins (post_id, pinned) VALUES (?, ?)", postID, at)
+	} else {
+		_, err = tx.Exec("DELETE FROM post_pins WHERE post_id = ?", postID)
+	}
+	if err != nil {
+		logger.Error("Failed to set post pin", zap.Int("post_id", postID), zap.Bool("pinned", pinned), zap.Error(err))
+		return 0, false, fmt.Errorf("failed to set post pin: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post pin", zap.Int("post_id", postID), zap.Error(err))
+		return 0, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return spaceID, true, nil
+}
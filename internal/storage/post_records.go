@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// sqlNowMillis is the current time in milliseconds since the epoch as SQLite sees it. The
+// record keeping triggers and the purge of expired records share it, so a purge never asks
+// for a deletion the retention trigger refuses.
+const sqlNowMillis = "CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER)"
+
+// PostRecordHash is the hash of a post record, chaining it to the record before it in its
+// space. Changing, removing or reordering a record breaks the chain from there on.
+func PostRecordHash(record models.PostRecord) string {
+	return rowHash(
+		record.PrevHash,
+		strconv.Itoa(record.SpaceID),
+		strconv.Itoa(record.PostID),
+		strconv.FormatInt(record.Recorded, 10),
+		strconv.FormatInt(record.RetainUntil, 10),
+		string(record.LinkPreviews),
+		record.Content,
+	)
+}
+
+func sqlRecordHash(prevHash string, spaceID, postID, recorded, retainUntil int64, linkPreviews, content string) string {
+	return PostRecordHash(models.PostRecord{
+		SpaceID:      int(spaceID),
+		PostID:       int(postID),
+		Content:      content,
+		LinkPreviews: json.RawMessage(linkPreviews),
+		Recorded:     recorded,
+		RetainUntil:  retainUntil,
+		PrevHash:     prevHash,
+	})
+}
+
+// GetRecordKeeping returns the record keeping setting of a space, disabled unless turned on
+func (db *DB) GetRecordKeeping(spaceID int) (*models.RecordKeeping, error) {
+	setting := &models.RecordKeeping{SpaceID: spaceID}
+	err := db.QueryRow(
+		"SELECT retention_days, since FROM space_record_keeping WHERE space_id = ?", spaceID,
+	).Scan(&setting.RetentionDays, &setting.Since)
+	if err == sql.ErrNoRows {
+		return setting, nil
+	}
+	if err != nil {
+		logger.Error("Failed to get record keeping", zap.Int("space_id", spaceID), zap.Error(err))
+		return nil, fmt.Errorf("failed to get record keeping: %w", err)
+	}
+	setting.Enabled = true
+	return setting, nil
+}
+
+// SetRecordKeeping turns record keeping of a space on, or changes its retention, along with
+// entry in the audit log. The retention applies to records taken from then on; Since is
+// kept when record keeping was already on.
+func (db *DB) SetRecordKeeping(setting *models.RecordKeeping, entry *models.AuditEntry) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin record keeping change", zap.Int("space_id", setting.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		`INSERT INTO space_record_keeping (space_id, retention_days, since) VALUES (?, ?, ?)
+		ON CONFLICT(space_id) DO UPDATE SET retention_days = excluded.retention_days
+		RETURNING since`,
+		setting.SpaceID, setting.RetentionDays, setting.Since,
+	).Scan(&setting.Since)
+	if err != nil {
+		logger.Error("Failed to set record keeping", zap.Int("space_id", setting.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to set record keeping: %w", err)
+	}
+	if err := insertAuditEntry(tx, entry); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit record keeping change", zap.Int("space_id", setting.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DisableRecordKeeping stops taking records of a space, along with entry in the audit log.
+// Records already taken stay until their retention ends.
+func (db *DB) DisableRecordKeeping(spaceID int, entry *models.AuditEntry) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin record keeping change", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM space_record_keeping WHERE space_id = ?", spaceID); err != nil {
+		logger.Error("Failed to disable record keeping", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to disable record keeping: %w", err)
+	}
+	if err := insertAuditEntry(tx, entry); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit record keeping change", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+const postRecordColumns = "id, space_id, post_id, content, link_previews, recorded, retain_until, prev_hash, hash"
+
+// GetPostRecords returns the records taken of a post, most recent first
+func (db *DB) GetPostRecords(postID int) ([]models.PostRecord, error) {
+	rows, err := db.Query(
+		"SELECT "+postRecordColumns+" FROM post_records WHERE post_id = ? ORDER BY id DESC", postID,
+	)
+	if err != nil {
+		logger.Error("Failed to query post records", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query post records: %w", err)
+	}
+	return scanPostRecords(rows)
+}
+
+// GetSpaceRecords returns the records of a space in chain order, oldest first
+func (db *DB) GetSpaceRecords(spaceID int) ([]models.PostRecord, error) {
+	rows, err := db.Query(
+		"SELECT "+postRecordColumns+" FROM post_records WHERE space_id = ? ORDER BY id", spaceID,
+	)
+	if err != nil {
+		logger.Error("Failed to query space records", zap.Int("space_id", spaceID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query space records: %w", err)
+	}
+	return scanPostRecords(rows)
+}
+
+func scanPostRecords(rows *sql.Rows) ([]models.PostRecord, error) {
+	defer rows.Close()
+
+	records := []models.PostRecord{}
+	for rows.Next() {
+		var record models.PostRecord
+		var previews string
+		if err := rows.Scan(&record.ID, &record.SpaceID, &record.PostID, &record.Content, &previews,
+			&record.Recorded, &record.RetainUntil, &record.PrevHash, &record.Hash); err != nil {
+			logger.Error("Failed to scan post record", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan post record: %w", err)
+		}
+		record.LinkPreviews = json.RawMessage(previews)
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// PurgeExpiredPostRecords deletes the records whose retention has ended
+func (db *DB) PurgeExpiredPostRecords() (int64, error) {
+	result, err := db.Exec("DELETE FROM post_records WHERE retain_until <= " + sqlNowMillis)
+	if err != nil {
+		logger.Error("Failed to purge expired post records", zap.Error(err))
+		return 0, fmt.Errorf("failed to purge expired post records: %w", err)
+	}
+	return result.RowsAffected()
+}
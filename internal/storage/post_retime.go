@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// PostTimeChange is the new creation time of a post
+type PostTimeChange struct {
+	PostID  int
+	Created int64
+}
+
+// GetPostsForRetime returns the posts owned by the given spaces that match filter, restricted
+// to postIDs when not empty, oldest first. Content is left out.
+func (db *DB) GetPostsForRetime(spaceIDs, postIDs []int, filter models.PostFilter) ([]models.Post, error) {
+	conditions, args := postFilterConditions(filter)
+
+	if spaceIDs != nil {
+		if len(spaceIDs) == 0 {
+			return []models.Post{}, nil
+		}
+		conditions = append(conditions, "p.space_id IN ("+placeholders(len(spaceIDs))+")")
+		for _, id := range spaceIDs {
+			args = append(args, id)
+		}
+	}
+	if len(postIDs) > 0 {
+		conditions = append(conditions, "p.id IN ("+placeholders(len(postIDs))+")")
+		for _, id := range postIDs {
+			args = append(args, id)
+		}
+	}
+
+	query := "SELECT p.id, p.space_id, p.created, " + postSourceColumn + ", " + postTypeColumn +
+		" FROM posts p " + postSourceJoin + " " + postTypeJoin
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY p.created, p.id"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Error("Failed to query posts to retime", zap.Error(err))
+		return nil, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.ID, &post.SpaceID, &post.Created, &post.Source, &post.Type); err != nil {
+			logger.Error("Failed to scan post to retime", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// RetimePosts sets the creation time of posts and records the audit entry, all in one transaction
+func (db *DB) RetimePosts(changes []PostTimeChange, entry *models.AuditEntry) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for post retime", zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, change := range changes {
+		if _, err := tx.Exec("UPDATE posts SET created = ? WHERE id = ?", change.Created, change.PostID); err != nil {
+			logger.Error("Failed to update post timestamp", zap.Int("post_id", change.PostID), zap.Error(err))
+			return fmt.Errorf("failed to update post timestamp: %w", err)
+		}
+	}
+
+	if err := insertAuditEntry(tx, entry); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post retime", zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// PostUpdate is an edit of a post. LinkPreviews replace the previews of the post unless nil.
+// Revision, when set, records the replaced content; only the KeepRevisions most recent
+// revisions of the post are then kept.
+type PostUpdate struct {
+	Content       string
+	LinkPreviews  []models.LinkPreview
+	Edited        int64
+	Revision      *models.PostRevision
+	KeepRevisions int
+}
+
+// UpdatePost applies an edit to a post in one transaction
+func (db *DB) UpdatePost(postID int, update PostUpdate) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin post update", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE posts SET content = ? WHERE id = ?", update.Content, postID)
+	if err != nil {
+		logger.Error("Failed to update post content", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to update post content: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("post not found")
+	}
+
+	if revision := update.Revision; revision != nil {
+		data, err := json.Marshal(revision.LinkPreviews)
+		if err != nil {
+			return fmt.Errorf("failed to encode link previews: %w", err)
+		}
+		result, err := tx.Exec(
+			"INSERT INTO post_revisions (post_id, content, link_previews, edited) VALUES (?, ?, ?, ?)",
+			postID, revision.Content, string(data), revision.Edited,
+		)
+		if err != nil {
+			logger.Error("Failed to record post revision", zap.Int("post_id", postID), zap.Error(err))
+			return fmt.Errorf("failed to record post revision: %w", err)
+		}
+		id, _ := result.LastInsertId()
+		revision.ID = int(id)
+		revision.PostID = postID
+
+		_, err = tx.Exec(
+			`DELETE FROM post_revisions WHERE post_id = ? AND id NOT IN (
+				SELECT id FROM post_revisions WHERE post_id = ? ORDER BY id DESC LIMIT ?)`,
+			postID, postID, update.KeepRevisions,
+		)
+		if err != nil {
+			logger.Error("Failed to prune post revisions", zap.Int("post_id", postID), zap.Error(err))
+			return fmt.Errorf("failed to prune post revisions: %w", err)
+		}
+	}
+
+	if update.LinkPreviews != nil {
+		if _, err := tx.Exec("DELETE FROM link_previews WHERE post_id = ?", postID); err != nil {
+			logger.Error("Failed to clear link previews", zap.Int("post_id", postID), zap.Error(err))
+			return fmt.Errorf("failed to clear link previews: %w", err)
+		}
+		for _, preview := range update.LinkPreviews {
+			_, err := tx.Exec(
+				"INSERT INTO link_previews (post_id, url, title, description, image_url, site_name) VALUES (?, ?, ?, ?, ?, ?)",
+				postID, preview.URL, preview.Title, preview.Description, preview.ImageURL, preview.SiteName,
+			)
+			if err != nil {
+				logger.Error("Failed to save link preview", zap.Int("post_id", postID), zap.Error(err))
+				return fmt.Errorf("failed to save link preview: %w", err)
+			}
+		}
+	}
+
+	if _, err := tx.Exec(touchPostQuery, postID, update.Edited); err != nil {
+		logger.Error("Failed to record post activity", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to record post activity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post update", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetPostRevisions returns the revisions of a post, most recent first
+func (db *DB) GetPostRevisions(postID int) ([]models.PostRevision, error) {
+	rows, err := db.Query(
+		"SELECT id, post_id, content, link_previews, edited FROM post_revisions WHERE post_id = ? ORDER BY id DESC",
+		postID,
+	)
+	if err != nil {
+		logger.Error("Failed to query post revisions", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query post revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.PostRevision{}
+	for rows.Next() {
+		var revision models.PostRevision
+		var previews string
+		if err := rows.Scan(&revision.ID, &revision.PostID, &revision.Content, &previews, &revision.Edited); err != nil {
+			logger.Error("Failed to scan post revision", zap.Int("post_id", postID), zap.Error(err))
+			return nil, fmt.Errorf("failed to scan post revision: %w", err)
+		}
+		if err := json.Unmarshal([]byte(previews), &revision.LinkPreviews); err != nil {
+			return nil, fmt.Errorf("failed to decode link previews: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
+}
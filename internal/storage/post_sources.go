@@ -0,0 +1,10 @@
+package storage
+
+import "backthynk/internal/core/models"
+
+// postSourceJoin and postSourceColumn resolve the source of posts aliased p; posts without
+// a source row were written by hand
+const (
+	postSourceJoin   = "LEFT JOIN post_sources ps ON ps.post_id = p.id"
+	postSourceColumn = "COALESCE(ps.source, '" + models.PostSourceManual + "')"
+)
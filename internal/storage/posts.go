@@ -3,6 +3,7 @@ package storage
 import (
 	"backthynk/internal/core/logger"
 	"backthynk/internal/core/models"
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -19,12 +20,31 @@ type PostData struct {
 	Created    int64
 }
 
-func (db *DB) CreatePost(spaceID int, content string) (*models.Post, error) {
-	return db.CreatePostWithTimestamp(spaceID, content, time.Now().UnixMilli())
+func (db *DB) CreatePost(ctx context.Context, spaceID int, content string) (*models.Post, error) {
+	return db.CreatePostWithTimestamp(ctx, spaceID, content, time.Now().UnixMilli())
 }
 
-func (db *DB) CreatePostWithTimestamp(spaceID int, content string, timestampMillis int64) (*models.Post, error) {
-	result, err := db.Exec(
+func (db *DB) CreatePostWithTimestamp(ctx context.Context, spaceID int, content string, timestampMillis int64) (*models.Post, error) {
+	return db.CreatePostWithSource(ctx, spaceID, content, timestampMillis, models.PostSourceManual)
+}
+
+// CreatePostWithSource creates a post recording the ingestion path it came from.
+// Hand-written posts have no source row.
+func (db *DB) CreatePostWithSource(ctx context.Context, spaceID int, content string, timestampMillis int64, source string) (*models.Post, error) {
+	return db.createPost(ctx, spaceID, content, timestampMillis, source, "")
+}
+
+// createPost inserts a post with its source and, when journalDay is set, records it as the
+// journal entry of its space for that day
+func (db *DB) createPost(ctx context.Context, spaceID int, content string, timestampMillis int64, source, journalDay string) (*models.Post, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("Failed to begin transaction for post creation", zap.Int("space_id", spaceID), zap.Error(err))
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
 		"INSERT INTO posts (space_id, content, created) VALUES (?, ?, ?)",
 		spaceID, content, timestampMillis,
 	)
@@ -40,15 +60,40 @@ func (db *DB) CreatePostWithTimestamp(spaceID int, content string, timestampMill
 		return nil, fmt.Errorf("failed to get last insert id: %w", err)
 	}
 
-	return db.GetPost(int(id))
+	if source != "" && source != models.PostSourceManual {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO post_sources (post_id, source) VALUES (?, ?)", id, source); err != nil {
+			logger.Error("Failed to record post source", zap.Int64("post_id", id), zap.String("source", source), zap.Error(err))
+			return nil, fmt.Errorf("failed to record post source: %w", err)
+		}
+	}
+
+	if journalDay != "" {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO journal_entries (space_id, day, post_id) VALUES (?, ?, ?)", spaceID, journalDay, id); err != nil {
+			logger.Error("Failed to record journal entry", zap.Int("space_id", spaceID), zap.String("day", journalDay), zap.Error(err))
+			return nil, fmt.Errorf("failed to record journal entry: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post creation", zap.Int("space_id", spaceID), zap.Error(err))
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return db.GetPostContext(ctx, int(id))
 }
 
 func (db *DB) GetPost(id int) (*models.Post, error) {
+	return db.GetPostContext(context.Background(), id)
+}
+
+// GetPostContext is GetPost, aborted when ctx is done
+func (db *DB) GetPostContext(ctx context.Context, id int) (*models.Post, error) {
 	var post models.Post
-	err := db.QueryRow(
-		"SELECT id, space_id, content, created FROM posts WHERE id = ?",
+	err := db.QueryRowContext(ctx,
+		"SELECT p.id, p.space_id, p.content, p.created, "+postSourceColumn+", "+postTypeColumn+", "+postHashColumn+", "+postPinnedColumn+
+			" FROM posts p "+postSourceJoin+" "+postTypeJoin+" "+postHashJoin+" "+postPinJoin+" WHERE p.id = ?",
 		id,
-	).Scan(&post.ID, &post.SpaceID, &post.Content, &post.Created)
+	).Scan(&post.ID, &post.SpaceID, &post.Content, &post.Created, &post.Source, &post.Type, &post.Hash, &post.Pinned)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -81,32 +126,38 @@ func (db *DB) GetPostIDsBySpace(spaceID int) ([]int, error) {
 	return ids, nil
 }
 
-func (db *DB) GetPostsBySpaceRecursive(spaceID int, recursive bool, limit, offset int, descendants []int) ([]models.PostWithAttachments, error) {
-	var query string
-	var args []interface{}
+// GetPostsBySpaceRecursive lists the posts of a space matching filter
+func (db *DB) GetPostsBySpaceRecursive(ctx context.Context, spaceID int, recursive bool, limit, offset int, descendants []int, filter models.PostFilter) ([]models.PostWithAttachments, error) {
+	// Use provided descendants from cache instead of database query
+	spaceIDs := []int{spaceID}
 	if recursive {
-		// Use provided descendants from cache instead of database query
-		spaceIDs := append(descendants, spaceID)
-
-		placeholders := make([]string, len(spaceIDs))
-		args = make([]interface{}, len(spaceIDs)+2)
-		for i, id := range spaceIDs {
-			placeholders[i] = "?"
-			args[i] = id
-		}
-		args[len(spaceIDs)] = limit
-		args[len(spaceIDs)+1] = offset
+		spaceIDs = append(descendants, spaceID)
+	}
 
-		query = fmt.Sprintf(
-			"SELECT id, space_id, content, created FROM posts WHERE space_id IN (%s) ORDER BY created DESC LIMIT ? OFFSET ?",
-			strings.Join(placeholders, ","),
-		)
-	} else {
-		query = "SELECT id, space_id, content, created FROM posts WHERE space_id = ? ORDER BY created DESC LIMIT ? OFFSET ?"
-		args = []interface{}{spaceID, limit, offset}
+	// Posts cross-posted into the listed spaces appear next to their own posts, after the
+	// pinned posts of the listed spaces
+	pinJoin, args := postPinScopeJoin(spaceIDs)
+	scope, scopeArgs := postScopeCondition(spaceIDs)
+	args = append(args, scopeArgs...)
+	orderJoin, order := postOrder(filter.Sort, true)
+	query := fmt.Sprintf(
+		"SELECT p.id, p.space_id, p.content, p.created, %s, %s, %s, %s FROM posts p %s %s %s %s%s WHERE %s",
+		postSourceColumn, postTypeColumn, postHashColumn, postPinnedColumn, postSourceJoin, postTypeJoin, postHashJoin, pinJoin, orderJoin, scope,
+	)
+
+	conditions, filterArgs := postFilterConditions(filter)
+	if cursor, cursorArgs := postCursorCondition(filter, true); cursor != "" {
+		conditions = append(conditions, cursor)
+		filterArgs = append(filterArgs, cursorArgs...)
+	}
+	for _, condition := range conditions {
+		query += " AND " + condition
 	}
+	args = append(args, filterArgs...)
+	query += order + " LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
 
-	rows, err := db.Query(query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		logger.Error("Failed to query posts by space", zap.Int("space_id", spaceID), zap.Bool("recursive", recursive), zap.Error(err))
 		return nil, fmt.Errorf("failed to query posts: %w", err)
@@ -116,14 +167,23 @@ func (db *DB) GetPostsBySpaceRecursive(spaceID int, recursive bool, limit, offse
 	var posts []models.PostWithAttachments
 	for rows.Next() {
 		var post models.PostWithAttachments
-		err := rows.Scan(&post.ID, &post.SpaceID, &post.Content, &post.Created)
+		err := rows.Scan(&post.ID, &post.SpaceID, &post.Content, &post.Created, &post.Source, &post.Type, &post.Hash, &post.Pinned)
 		if err != nil {
 			logger.Error("Failed to scan post", zap.Error(err))
 			return nil, fmt.Errorf("failed to scan post: %w", err)
 		}
+		if !containsID(spaceIDs, post.SpaceID) {
+			ownerID := post.SpaceID
+			post.CrosspostedFrom = &ownerID
+		}
+
+		// Stop early when the client went away
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 
 		// Get attachments
-		attachments, err := db.GetAttachmentsByPost(post.ID)
+		attachments, err := db.GetAttachmentsByPostContext(ctx, post.ID)
 		if err != nil {
 			logger.Error("Failed to get attachments for post", zap.Int("post_id", post.ID), zap.Error(err))
 			return nil, fmt.Errorf("failed to get attachments: %w", err)
@@ -131,7 +191,7 @@ func (db *DB) GetPostsBySpaceRecursive(spaceID int, recursive bool, limit, offse
 		post.Attachments = attachments
 
 		// Get link previews
-		linkPreviews, err := db.GetLinkPreviewsByPostID(post.ID)
+		linkPreviews, err := db.GetLinkPreviewsByPostIDContext(ctx, post.ID)
 		if err != nil {
 			logger.Error("Failed to get link previews for post", zap.Int("post_id", post.ID), zap.Error(err))
 			return nil, fmt.Errorf("failed to get link previews: %w", err)
@@ -141,18 +201,84 @@ func (db *DB) GetPostsBySpaceRecursive(spaceID int, recursive bool, limit, offse
 		posts = append(posts, post)
 	}
 
-	return posts, nil
+	return posts, rows.Err()
+}
+
+// StreamPosts hands the posts of the given spaces to fn one at a time, newest first, reading
+// them from the database cursor as fn returns. Iteration stops at the first error of fn or when
+// ctx is done.
+func (db *DB) StreamPosts(ctx context.Context, spaceIDs []int, fn func(post models.PostWithAttachments) error) error {
+	if len(spaceIDs) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(spaceIDs))
+	args := make([]interface{}, len(spaceIDs))
+	for i, id := range spaceIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(
+		"SELECT p.id, p.space_id, p.content, p.created, %s, %s, %s FROM posts p %s %s %s WHERE p.space_id IN (%s) ORDER BY p.created DESC, p.id DESC",
+		postSourceColumn, postTypeColumn, postHashColumn, postSourceJoin, postTypeJoin, postHashJoin, strings.Join(placeholders, ","),
+	)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.Error("Failed to query posts for streaming", zap.Error(err))
+		return fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var post models.PostWithAttachments
+		if err := rows.Scan(&post.ID, &post.SpaceID, &post.Content, &post.Created, &post.Source, &post.Type, &post.Hash); err != nil {
+			logger.Error("Failed to scan post", zap.Error(err))
+			return fmt.Errorf("failed to scan post: %w", err)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		attachments, err := db.GetAttachmentsByPostContext(ctx, post.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get attachments: %w", err)
+		}
+		post.Attachments = attachments
+
+		linkPreviews, err := db.GetLinkPreviewsByPostIDContext(ctx, post.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get link previews: %w", err)
+		}
+		post.LinkPreviews = linkPreviews
+
+		if err := fn(post); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
 }
 
-func (db *DB) GetAllPosts(limit, offset int) ([]models.PostWithAttachments, error) {
-	query := `
-		SELECT p.id, p.space_id, p.content, p.created
-		FROM posts p
-		ORDER BY p.created DESC
-		LIMIT ? OFFSET ?
-	`
+// GetAllPosts lists the posts of every space matching filter
+func (db *DB) GetAllPosts(ctx context.Context, limit, offset int, filter models.PostFilter) ([]models.PostWithAttachments, error) {
+	orderJoin, order := postOrder(filter.Sort, false)
+	query := "SELECT p.id, p.space_id, p.content, p.created, " + postSourceColumn + ", " + postTypeColumn + ", " + postHashColumn +
+		" FROM posts p " + postSourceJoin + " " + postTypeJoin + " " + postHashJoin + orderJoin
+	conditions, args := postFilterConditions(filter)
+	if cursor, cursorArgs := postCursorCondition(filter, false); cursor != "" {
+		conditions = append(conditions, cursor)
+		args = append(args, cursorArgs...)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += order + " LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
 
-	rows, err := db.Query(query, limit, offset)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		logger.Error("Failed to query all posts", zap.Int("limit", limit), zap.Int("offset", offset), zap.Error(err))
 		return nil, fmt.Errorf("failed to query posts: %w", err)
@@ -162,20 +288,24 @@ func (db *DB) GetAllPosts(limit, offset int) ([]models.PostWithAttachments, erro
 	var posts []models.PostWithAttachments
 	for rows.Next() {
 		var post models.PostWithAttachments
-		err := rows.Scan(&post.ID, &post.SpaceID, &post.Content, &post.Created)
+		err := rows.Scan(&post.ID, &post.SpaceID, &post.Content, &post.Created, &post.Source, &post.Type, &post.Hash)
 		if err != nil {
 			logger.Error("Failed to scan post", zap.Error(err))
 			return nil, fmt.Errorf("failed to scan post: %w", err)
 		}
 
-		attachments, err := db.GetAttachmentsByPost(post.ID)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		attachments, err := db.GetAttachmentsByPostContext(ctx, post.ID)
 		if err != nil {
 			logger.Error("Failed to get attachments for post", zap.Int("post_id", post.ID), zap.Error(err))
 			return nil, fmt.Errorf("failed to get attachments: %w", err)
 		}
 		post.Attachments = attachments
 
-		linkPreviews, err := db.GetLinkPreviewsByPostID(post.ID)
+		linkPreviews, err := db.GetLinkPreviewsByPostIDContext(ctx, post.ID)
 		if err != nil {
 			logger.Error("Failed to get link previews for post", zap.Int("post_id", post.ID), zap.Error(err))
 			return nil, fmt.Errorf("failed to get link previews: %w", err)
@@ -185,36 +315,91 @@ func (db *DB) GetAllPosts(limit, offset int) ([]models.PostWithAttachments, erro
 		posts = append(posts, post)
 	}
 
-	return posts, nil
+	return posts, rows.Err()
 }
 
-func (db *DB) UpdatePostSpace(postID int, newSpaceID int) error {
-	_, err := db.Exec("UPDATE posts SET space_id = ? WHERE id = ?", newSpaceID, postID)
+func (db *DB) UpdatePostSpace(ctx context.Context, postID int, newSpaceID int) error {
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
+		logger.Error("Failed to begin transaction for post move", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE posts SET space_id = ? WHERE id = ?", newSpaceID, postID); err != nil {
 		logger.Error("Failed to update post space", zap.Int("post_id", postID), zap.Int("new_space_id", newSpaceID), zap.Error(err))
 		return fmt.Errorf("failed to update post space: %w", err)
 	}
 
+	if _, err := tx.ExecContext(ctx, touchPostQuery, postID, time.Now().UnixMilli()); err != nil {
+		logger.Error("Failed to record post activity", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to record post activity: %w", err)
+	}
+
+	// Journal entries follow their post
+	if _, err := tx.ExecContext(ctx, "UPDATE journal_entries SET space_id = ? WHERE post_id = ?", newSpaceID, postID); err != nil {
+		logger.Error("Failed to move journal entry", zap.Int("post_id", postID), zap.Int("new_space_id", newSpaceID), zap.Error(err))
+		return fmt.Errorf("failed to move journal entry: %w", err)
+	}
+
+	// The new owner no longer needs a cross-post reference
+	if _, err := tx.ExecContext(ctx, "DELETE FROM post_crossposts WHERE post_id = ? AND space_id = ?", postID, newSpaceID); err != nil {
+		logger.Error("Failed to drop cross-post of new owner", zap.Int("post_id", postID), zap.Int("new_space_id", newSpaceID), zap.Error(err))
+		return fmt.Errorf("failed to drop cross-post: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post move", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
-func (db *DB) DeletePost(id int) error {
+func (db *DB) UpdatePostContent(postID int, content string) error {
+	result, err := db.Exec("UPDATE posts SET content = ? WHERE id = ?", content, postID)
+	if err != nil {
+		logger.Error("Failed to update post content", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to update post content: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("post not found")
+	}
+
+	if _, err := db.Exec(touchPostQuery, postID, time.Now().UnixMilli()); err != nil {
+		logger.Error("Failed to record post activity", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to record post activity: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) DeletePost(ctx context.Context, id int) error {
 	// Get attachments first
-	attachments, err := db.GetAttachmentsByPost(id)
+	attachments, err := db.GetAttachmentsByPostContext(ctx, id)
 	if err != nil {
 		logger.Error("Failed to get attachments for post deletion", zap.Int("post_id", id), zap.Error(err))
 		return fmt.Errorf("failed to get attachments: %w", err)
 	}
 
-	// Delete physical files
+	versionPaths, err := db.GetAttachmentVersionPathsByPost(id)
+	if err != nil {
+		return err
+	}
+
+	// Delete physical files, prior versions included
 	uploadsDir := filepath.Join(db.storagePath, "uploads")
 	for _, attachment := range attachments {
 		fullPath := filepath.Join(uploadsDir, attachment.FilePath)
 		os.Remove(fullPath) // Ignore errors
 	}
+	for _, versionPath := range versionPaths {
+		os.Remove(filepath.Join(uploadsDir, versionPath)) // Ignore errors
+	}
 
 	// Delete post (CASCADE handles attachments and link previews)
-	result, err := db.Exec("DELETE FROM posts WHERE id = ?", id)
+	result, err := db.ExecContext(ctx, "DELETE FROM posts WHERE id = ?", id)
 	if err != nil {
 		logger.Error("Failed to delete post", zap.Int("post_id", id), zap.Error(err))
 		return fmt.Errorf("failed to delete post: %w", err)
@@ -267,4 +452,218 @@ func (db *DB) GetAllPostsHeader() ([]PostData, error) {
 	}
 	
 	return posts, nil
-}
\ No newline at end of file
+}
+
+// GetPostsBySpaces returns the posts (without attachments) of the given spaces
+func (db *DB) GetPostsBySpaces(spaceIDs []int) ([]models.Post, error) {
+	if len(spaceIDs) == 0 {
+		return []models.Post{}, nil
+	}
+
+	placeholders := make([]string, len(spaceIDs))
+	args := make([]interface{}, len(spaceIDs))
+	for i, id := range spaceIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(
+		"SELECT id, space_id, content, created FROM posts WHERE space_id IN (%s) ORDER BY id",
+		strings.Join(placeholders, ","),
+	)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Error("Failed to query posts by spaces", zap.Error(err))
+		return nil, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.ID, &post.SpaceID, &post.Content, &post.Created); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// GetLastPostTimes returns the most recent post timestamp for every space that has posts
+func (db *DB) GetLastPostTimes() (map[int]int64, error) {
+	rows, err := db.Query("SELECT space_id, MAX(created) FROM posts GROUP BY space_id")
+	if err != nil {
+		logger.Error("Failed to query last post times", zap.Error(err))
+		return nil, fmt.Errorf("failed to query last post times: %w", err)
+	}
+	defer rows.Close()
+
+	lastPosts := make(map[int]int64)
+	for rows.Next() {
+		var spaceID int
+		var created int64
+		if err := rows.Scan(&spaceID, &created); err != nil {
+			return nil, err
+		}
+		lastPosts[spaceID] = created
+	}
+
+	return lastPosts, rows.Err()
+}
+
+// CountPostsCreatedAfter returns how many posts every space got after since, leaving out
+// spaces with none
+func (db *DB) CountPostsCreatedAfter(since int64) (map[int]int, error) {
+	rows, err := db.Query("SELECT space_id, COUNT(*) FROM posts WHERE created > ? GROUP BY space_id", since)
+	if err != nil {
+		logger.Error("Failed to count recent posts", zap.Error(err))
+		return nil, fmt.Errorf("failed to count recent posts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int)
+	for rows.Next() {
+		var spaceID, count int
+		if err := rows.Scan(&spaceID, &count); err != nil {
+			return nil, err
+		}
+		counts[spaceID] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// GetLastPostTime returns the most recent post timestamp of a space, or 0 if it has no posts
+func (db *DB) GetLastPostTime(spaceID int) (int64, error) {
+	var created sql.NullInt64
+	err := db.QueryRow("SELECT MAX(created) FROM posts WHERE space_id = ?", spaceID).Scan(&created)
+	if err != nil {
+		logger.Error("Failed to get last post time", zap.Int("space_id", spaceID), zap.Error(err))
+		return 0, fmt.Errorf("failed to get last post time: %w", err)
+	}
+
+	return created.Int64, nil
+}
+
+// MergePosts replaces the surviving post content, moves attachments and link previews
+// of the merged posts onto it and deletes the merged posts in a single transaction
+func (db *DB) MergePosts(survivorID int, mergedIDs []int, content string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for post merge", zap.Int("post_id", survivorID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE posts SET content = ? WHERE id = ?", content, survivorID); err != nil {
+		logger.Error("Failed to update merged post content", zap.Int("post_id", survivorID), zap.Error(err))
+		return fmt.Errorf("failed to update post content: %w", err)
+	}
+
+	if _, err := tx.Exec(touchPostQuery, survivorID, time.Now().UnixMilli()); err != nil {
+		logger.Error("Failed to record merged post activity", zap.Int("post_id", survivorID), zap.Error(err))
+		return fmt.Errorf("failed to record post activity: %w", err)
+	}
+
+	placeholders := make([]string, len(mergedIDs))
+	args := make([]interface{}, len(mergedIDs)+1)
+	args[0] = survivorID
+	for i, id := range mergedIDs {
+		placeholders[i] = "?"
+		args[i+1] = id
+	}
+	inClause := strings.Join(placeholders, ",")
+
+	if _, err := tx.Exec(fmt.Sprintf("UPDATE attachments SET post_id = ? WHERE post_id IN (%s)", inClause), args...); err != nil {
+		logger.Error("Failed to move attachments during merge", zap.Int("post_id", survivorID), zap.Error(err))
+		return fmt.Errorf("failed to move attachments: %w", err)
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("UPDATE link_previews SET post_id = ? WHERE post_id IN (%s)", inClause), args...); err != nil {
+		logger.Error("Failed to move link previews during merge", zap.Int("post_id", survivorID), zap.Error(err))
+		return fmt.Errorf("failed to move link previews: %w", err)
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("DELETE FROM posts WHERE id IN (%s)", inClause), args[1:]...); err != nil {
+		logger.Error("Failed to delete merged posts", zap.Int("post_id", survivorID), zap.Error(err))
+		return fmt.Errorf("failed to delete merged posts: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post merge transaction", zap.Int("post_id", survivorID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SplitPost rewrites a post with the first part and inserts the remaining parts as new posts
+// in the same space. attachmentTargets maps attachment IDs to the index of the part receiving them.
+func (db *DB) SplitPost(postID, spaceID int, parts []string, timestamps []int64, attachmentTargets map[int]int) ([]int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for post split", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE posts SET content = ? WHERE id = ?", parts[0], postID); err != nil {
+		logger.Error("Failed to update split post content", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to update post content: %w", err)
+	}
+
+	if _, err := tx.Exec(touchPostQuery, postID, time.Now().UnixMilli()); err != nil {
+		logger.Error("Failed to record split post activity", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to record post activity: %w", err)
+	}
+
+	ids := []int{postID}
+	for i := 1; i < len(parts); i++ {
+		result, err := tx.Exec(
+			"INSERT INTO posts (space_id, content, created) VALUES (?, ?, ?)",
+			spaceID, parts[i], timestamps[i],
+		)
+		if err != nil {
+			logger.Error("Failed to create post from split", zap.Int("post_id", postID), zap.Error(err))
+			return nil, fmt.Errorf("failed to create post: %w", err)
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			logger.Error("Failed to get last insert ID after split", zap.Int("post_id", postID), zap.Error(err))
+			return nil, fmt.Errorf("failed to get last insert id: %w", err)
+		}
+		ids = append(ids, int(id))
+
+		// Parts keep the source of the original post
+		if _, err := tx.Exec(
+			"INSERT INTO post_sources (post_id, source) SELECT ?, source FROM post_sources WHERE post_id = ?",
+			id, postID,
+		); err != nil {
+			logger.Error("Failed to copy post source on split", zap.Int("post_id", postID), zap.Error(err))
+			return nil, fmt.Errorf("failed to copy post source: %w", err)
+		}
+	}
+
+	for attachmentID, partIndex := range attachmentTargets {
+		if partIndex == 0 {
+			continue
+		}
+		if _, err := tx.Exec(
+			"UPDATE attachments SET post_id = ? WHERE id = ? AND post_id = ?",
+			ids[partIndex], attachmentID, postID,
+		); err != nil {
+			logger.Error("Failed to move attachment during split", zap.Int("attachment_id", attachmentID), zap.Error(err))
+			return nil, fmt.Errorf("failed to move attachment: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post split transaction", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// InstancePreferencesID is the user ID the instance-wide preferences are stored under
+const InstancePreferencesID = 0
+
+// GetPreferences returns the preferences stored for a user, or for the instance with
+// InstancePreferencesID. Scope is left for the caller.
+func (db *DB) GetPreferences(userID int) (*models.Preferences, error) {
+	var prefs models.Preferences
+	var spaceID sql.NullInt64
+	err := db.QueryRow(
+		"SELECT landing_view, landing_space_id, updated FROM preferences WHERE user_id = ?", userID,
+	).Scan(&prefs.LandingView, &spaceID, &prefs.Updated)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("preferences not found")
+		}
+		logger.Error("Failed to get preferences", zap.Int("user_id", userID), zap.Error(err))
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	if spaceID.Valid {
+		id := int(spaceID.Int64)
+		prefs.LandingSpaceID = &id
+	}
+	return &prefs, nil
+}
+
+// SetPreferences stores the preferences of a user, or of the instance with InstancePreferencesID
+func (db *DB) SetPreferences(userID int, prefs models.Preferences) error {
+	_, err := db.Exec(
+		`INSERT INTO preferences (user_id, landing_view, landing_space_id, updated) VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET landing_view = excluded.landing_view,
+			landing_space_id = excluded.landing_space_id, updated = excluded.updated`,
+		userID, prefs.LandingView, prefs.LandingSpaceID, prefs.Updated,
+	)
+	if err != nil {
+		logger.Error("Failed to set preferences", zap.Int("user_id", userID), zap.Error(err))
+		return fmt.Errorf("failed to set preferences: %w", err)
+	}
+	return nil
+}
+
+// DeletePreferences drops the preferences of a user, or of the instance with InstancePreferencesID
+func (db *DB) DeletePreferences(userID int) error {
+	if _, err := db.Exec("DELETE FROM preferences WHERE user_id = ?", userID); err != nil {
+		logger.Error("Failed to delete preferences", zap.Int("user_id", userID), zap.Error(err))
+		return fmt.Errorf("failed to delete preferences: %w", err)
+	}
+	return nil
+}
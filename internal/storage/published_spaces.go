@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// GetPublishedSpaces returns the IDs of the spaces whose posts are sent to the publishing webhook
+func (db *DB) GetPublishedSpaces() ([]int, error) {
+	rows, err := db.Query("SELECT space_id FROM published_spaces ORDER BY space_id")
+	if err != nil {
+		logger.Error("Failed to query published spaces", zap.Error(err))
+		return nil, fmt.Errorf("failed to query published spaces: %w", err)
+	}
+	defer rows.Close()
+
+	spaceIDs := []int{}
+	for rows.Next() {
+		var spaceID int
+		if err := rows.Scan(&spaceID); err != nil {
+			logger.Error("Failed to scan published space", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan published space: %w", err)
+		}
+		spaceIDs = append(spaceIDs, spaceID)
+	}
+
+	return spaceIDs, rows.Err()
+}
+
+// SetSpacePublished turns publishing of a space on or off
+func (db *DB) SetSpacePublished(spaceID int, published bool) error {
+	var err error
+	if published {
+		_, err = db.Exec(
+			"INSERT INTO published_spaces (space_id, created) VALUES (?, ?) ON CONFLICT(space_id) DO NOTHING",
+			spaceID, time.Now().UnixMilli(),
+		)
+	} else {
+		_, err = db.Exec("DELETE FROM published_spaces WHERE space_id = ?", spaceID)
+	}
+	if err != nil {
+		logger.Error("Failed to set space publishing", zap.Int("space_id", spaceID), zap.Bool("published", published), zap.Error(err))
+		return fmt.Errorf("failed to set space publishing: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// driverName is the SQLite driver with the row hash functions used by the hash triggers, the
+// record hash of the record keeping trigger and the ranking function of full-text search
+const driverName = "sqlite3_backthynk"
+
+// postHashJoin and postHashColumn resolve the row hash of posts aliased p
+const (
+	postHashJoin   = "LEFT JOIN post_hashes ph ON ph.post_id = p.id"
+	postHashColumn = "COALESCE(ph.hash, '')"
+)
+
+// spaceHashJoin and spaceHashColumn resolve the row hash of spaces aliased s
+const (
+	spaceHashJoin   = "LEFT JOIN space_hashes sh ON sh.space_id = s.id"
+	spaceHashColumn = "COALESCE(sh.hash, '')"
+)
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("post_hash", sqlPostHash, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("space_hash", sqlSpaceHash, true); err != nil {
+				return err
+			}
+			if err := conn.RegisterFunc("record_hash", sqlRecordHash, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("search_rank", sqlSearchRank, true)
+		},
+	})
+}
+
+// PostHash is the row hash of a post, over the fields a sync client replicates. Two copies of
+// a post with the same hash hold the same space, timestamp and content.
+func PostHash(spaceID int, created int64, content string) string {
+	return rowHash(strconv.Itoa(spaceID), strconv.FormatInt(created, 10), content)
+}
+
+// SpaceHash is the row hash of a space; parentID is 0 for top-level spaces
+func SpaceHash(parentID int, name, description string) string {
+	return rowHash(strconv.Itoa(parentID), name, description)
+}
+
+func sqlPostHash(spaceID, created int64, content string) string {
+	return PostHash(int(spaceID), created, content)
+}
+
+func sqlSpaceHash(parentID int64, name, description string) string {
+	return SpaceHash(int(parentID), name, description)
+}
+
+// rowHash hashes fields separated by newlines; only the last field may hold a newline
+func rowHash(fields ...string) string {
+	h := sha256.New()
+	for i, field := range fields {
+		if i > 0 {
+			h.Write([]byte{'\n'})
+		}
+		h.Write([]byte(field))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
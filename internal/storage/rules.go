@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const ruleColumns = "id, name, enabled, trigger_event, conditions, actions, created, updated"
+
+// CreateRule stores a new automation rule and fills in its ID and timestamps
+func (db *DB) CreateRule(rule *models.Rule) error {
+	conditions, actions, err := encodeRule(rule)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	result, err := db.Exec(
+		`INSERT INTO rules (name, enabled, trigger_event, conditions, actions, created, updated)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		rule.Name, rule.Enabled, rule.Trigger, conditions, actions, now, now,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			logger.Warning("Rule already exists", zap.String("name", rule.Name))
+			return fmt.Errorf("rule '%s' already exists", rule.Name)
+		}
+		logger.Error("Failed to create rule", zap.String("name", rule.Name), zap.Error(err))
+		return fmt.Errorf("failed to create rule: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Failed to get last insert ID after rule creation", zap.Error(err))
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	rule.ID = int(id)
+	rule.Created = now
+	rule.Updated = now
+	return nil
+}
+
+// UpdateRule replaces the definition of an existing rule
+func (db *DB) UpdateRule(rule *models.Rule) error {
+	conditions, actions, err := encodeRule(rule)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	result, err := db.Exec(
+		`UPDATE rules SET name = ?, enabled = ?, trigger_event = ?, conditions = ?, actions = ?, updated = ?
+		WHERE id = ?`,
+		rule.Name, rule.Enabled, rule.Trigger, conditions, actions, now, rule.ID,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			logger.Warning("Rule already exists", zap.String("name", rule.Name))
+			return fmt.Errorf("rule '%s' already exists", rule.Name)
+		}
+		logger.Error("Failed to update rule", zap.Int("rule_id", rule.ID), zap.Error(err))
+		return fmt.Errorf("failed to update rule: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("rule not found")
+	}
+
+	rule.Updated = now
+	return nil
+}
+
+// GetRules lists every rule in creation order, the order in which rules run
+func (db *DB) GetRules() ([]models.Rule, error) {
+	rows, err := db.Query("SELECT " + ruleColumns + " FROM rules ORDER BY id")
+	if err != nil {
+		logger.Error("Failed to query rules", zap.Error(err))
+		return nil, fmt.Errorf("failed to query rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.Rule{}
+	for rows.Next() {
+		var rule models.Rule
+		var conditions, actions string
+		if err := rows.Scan(
+			&rule.ID, &rule.Name, &rule.Enabled, &rule.Trigger, &conditions, &actions, &rule.Created, &rule.Updated,
+		); err != nil {
+			logger.Error("Failed to scan rule", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan rule: %w", err)
+		}
+		if err := json.Unmarshal([]byte(conditions), &rule.Conditions); err != nil {
+			return nil, fmt.Errorf("failed to decode rule conditions: %w", err)
+		}
+		if err := json.Unmarshal([]byte(actions), &rule.Actions); err != nil {
+			return nil, fmt.Errorf("failed to decode rule actions: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// DeleteRule removes a rule along with its execution log and pending reminders
+func (db *DB) DeleteRule(id int) error {
+	result, err := db.Exec("DELETE FROM rules WHERE id = ?", id)
+	if err != nil {
+		logger.Error("Failed to delete rule", zap.Int("rule_id", id), zap.Error(err))
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("rule not found")
+	}
+
+	return nil
+}
+
+// AddRuleExecution appends to the execution log of a rule, keeping its most recent keep entries
+func (db *DB) AddRuleExecution(execution *models.RuleExecution, keep int) error {
+	if execution.Results == nil {
+		execution.Results = []models.RuleActionResult{}
+	}
+	results, err := json.Marshal(execution.Results)
+	if err != nil {
+		return fmt.Errorf("failed to encode rule results: %w", err)
+	}
+
+	result, err := db.Exec(
+		"INSERT INTO rule_executions (rule_id, created, trigger_event, post_id, outcome, results) VALUES (?, ?, ?, ?, ?, ?)",
+		execution.RuleID, execution.Created, execution.Trigger, execution.PostID, execution.Outcome, string(results),
+	)
+	if err != nil {
+		logger.Error("Failed to add rule execution", zap.Int("rule_id", execution.RuleID), zap.Error(err))
+		return fmt.Errorf("failed to add rule execution: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	execution.ID = int(id)
+
+	_, err = db.Exec(
+		`DELETE FROM rule_executions WHERE rule_id = ? AND id NOT IN (
+			SELECT id FROM rule_executions WHERE rule_id = ? ORDER BY id DESC LIMIT ?
+		)`,
+		execution.RuleID, execution.RuleID, keep,
+	)
+	if err != nil {
+		logger.Error("Failed to prune rule executions", zap.Int("rule_id", execution.RuleID), zap.Error(err))
+		return fmt.Errorf("failed to prune rule executions: %w", err)
+	}
+
+	return nil
+}
+
+// GetRuleExecutions returns the most recent executions of a rule, or of every rule when ruleID is 0
+func (db *DB) GetRuleExecutions(ruleID, limit int) ([]models.RuleExecution, error) {
+	query := "SELECT id, rule_id, created, trigger_event, post_id, outcome, results FROM rule_executions"
+	args := []interface{}{}
+	if ruleID != 0 {
+		query += " WHERE rule_id = ?"
+		args = append(args, ruleID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Error("Failed to query rule executions", zap.Int("rule_id", ruleID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query rule executions: %w", err)
+	}
+	defer rows.Close()
+
+	executions := []models.RuleExecution{}
+	for rows.Next() {
+		var execution models.RuleExecution
+		var results string
+		if err := rows.Scan(
+			&execution.ID, &execution.RuleID, &execution.Created, &execution.Trigger,
+			&execution.PostID, &execution.Outcome, &results,
+		); err != nil {
+			logger.Error("Failed to scan rule execution", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan rule execution: %w", err)
+		}
+		if err := json.Unmarshal([]byte(results), &execution.Results); err != nil {
+			return nil, fmt.Errorf("failed to decode rule results: %w", err)
+		}
+		executions = append(executions, execution)
+	}
+
+	return executions, rows.Err()
+}
+
+// AddRuleReminder stores a reminder to raise once due
+func (db *DB) AddRuleReminder(reminder *models.RuleReminder) error {
+	result, err := db.Exec(
+		"INSERT INTO rule_reminders (rule_id, post_id, space_id, message, due) VALUES (?, ?, ?, ?, ?)",
+		reminder.RuleID, reminder.PostID, reminder.SpaceID, reminder.Message, reminder.Due,
+	)
+	if err != nil {
+		logger.Error("Failed to add rule reminder", zap.Int("rule_id", reminder.RuleID), zap.Error(err))
+		return fmt.Errorf("failed to add rule reminder: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	reminder.ID = int(id)
+	return nil
+}
+
+// GetDueRuleReminders returns the reminders due at or before now, oldest first. The space is the
+// current space of the post, which a later move may have changed.
+func (db *DB) GetDueRuleReminders(now int64) ([]models.RuleReminder, error) {
+	rows, err := db.Query(
+		`SELECT r.id, r.rule_id, r.post_id, COALESCE(p.space_id, r.space_id), r.message, r.due
+		FROM rule_reminders r LEFT JOIN posts p ON p.id = r.post_id
+		WHERE r.due <= ? ORDER BY r.due, r.id`,
+		now,
+	)
+	if err != nil {
+		logger.Error("Failed to query due rule reminders", zap.Error(err))
+		return nil, fmt.Errorf("failed to query due rule reminders: %w", err)
+	}
+	defer rows.Close()
+
+	reminders := []models.RuleReminder{}
+	for rows.Next() {
+		var reminder models.RuleReminder
+		if err := rows.Scan(
+			&reminder.ID, &reminder.RuleID, &reminder.PostID, &reminder.SpaceID, &reminder.Message, &reminder.Due,
+		); err != nil {
+			logger.Error("Failed to scan rule reminder", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan rule reminder: %w", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+// DeleteRuleReminder removes a reminder once raised
+func (db *DB) DeleteRuleReminder(id int) error {
+	if _, err := db.Exec("DELETE FROM rule_reminders WHERE id = ?", id); err != nil {
+		logger.Error("Failed to delete rule reminder", zap.Int("reminder_id", id), zap.Error(err))
+		return fmt.Errorf("failed to delete rule reminder: %w", err)
+	}
+	return nil
+}
+
+func encodeRule(rule *models.Rule) (string, string, error) {
+	if rule.Actions == nil {
+		rule.Actions = []models.RuleAction{}
+	}
+
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode rule conditions: %w", err)
+	}
+	actions, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode rule actions: %w", err)
+	}
+
+	return string(conditions), string(actions), nil
+}
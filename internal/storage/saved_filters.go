@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const savedFilterColumns = "id, name, COALESCE(space_id, 0), recursive, tags, extensions, start_date, end_date, created, updated"
+
+// CreateSavedFilter stores a new saved filter and fills in its ID and timestamps
+func (db *DB) CreateSavedFilter(filter *models.SavedFilter) error {
+	tags, extensions, err := encodeSavedFilterLists(filter)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	result, err := db.Exec(
+		`INSERT INTO saved_filters (name, space_id, recursive, tags, extensions, start_date, end_date, created, updated)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		filter.Name, nullableID(filter.SpaceID), filter.Recursive, tags, extensions,
+		filter.StartDate, filter.EndDate, now, now,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			logger.Warning("Saved filter already exists", zap.String("name", filter.Name))
+			return fmt.Errorf("saved filter '%s' already exists", filter.Name)
+		}
+		logger.Error("Failed to create saved filter", zap.String("name", filter.Name), zap.Error(err))
+		return fmt.Errorf("failed to create saved filter: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Failed to get last insert ID after saved filter creation", zap.Error(err))
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	filter.ID = int(id)
+	filter.Created = now
+	filter.Updated = now
+	return nil
+}
+
+// UpdateSavedFilter replaces the criteria of an existing saved filter
+func (db *DB) UpdateSavedFilter(filter *models.SavedFilter) error {
+	tags, extensions, err := encodeSavedFilterLists(filter)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	result, err := db.Exec(
+		`UPDATE saved_filters SET name = ?, space_id = ?, recursive = ?, tags = ?, extensions = ?,
+		start_date = ?, end_date = ?, updated = ? WHERE id = ?`,
+		filter.Name, nullableID(filter.SpaceID), filter.Recursive, tags, extensions,
+		filter.StartDate, filter.EndDate, now, filter.ID,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			logger.Warning("Saved filter already exists", zap.String("name", filter.Name))
+			return fmt.Errorf("saved filter '%s' already exists", filter.Name)
+		}
+		logger.Error("Failed to update saved filter", zap.Int("filter_id", filter.ID), zap.Error(err))
+		return fmt.Errorf("failed to update saved filter: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("saved filter not found")
+	}
+
+	filter.Updated = now
+	return nil
+}
+
+// GetSavedFilters lists every saved filter sorted by name
+func (db *DB) GetSavedFilters() ([]models.SavedFilter, error) {
+	rows, err := db.Query("SELECT " + savedFilterColumns + " FROM saved_filters ORDER BY name COLLATE NOCASE")
+	if err != nil {
+		logger.Error("Failed to query saved filters", zap.Error(err))
+		return nil, fmt.Errorf("failed to query saved filters: %w", err)
+	}
+	defer rows.Close()
+
+	filters := []models.SavedFilter{}
+	for rows.Next() {
+		filter, err := scanSavedFilter(rows)
+		if err != nil {
+			logger.Error("Failed to scan saved filter", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan saved filter: %w", err)
+		}
+		filters = append(filters, *filter)
+	}
+
+	return filters, rows.Err()
+}
+
+// DeleteSavedFilter removes a saved filter
+func (db *DB) DeleteSavedFilter(id int) error {
+	result, err := db.Exec("DELETE FROM saved_filters WHERE id = ?", id)
+	if err != nil {
+		logger.Error("Failed to delete saved filter", zap.Int("filter_id", id), zap.Error(err))
+		return fmt.Errorf("failed to delete saved filter: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("saved filter not found")
+	}
+
+	return nil
+}
+
+func scanSavedFilter(rows *sql.Rows) (*models.SavedFilter, error) {
+	var filter models.SavedFilter
+	var tags, extensions string
+	if err := rows.Scan(
+		&filter.ID, &filter.Name, &filter.SpaceID, &filter.Recursive, &tags, &extensions,
+		&filter.StartDate, &filter.EndDate, &filter.Created, &filter.Updated,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(tags), &filter.Tags); err != nil {
+		return nil, fmt.Errorf("failed to decode saved filter tags: %w", err)
+	}
+	if err := json.Unmarshal([]byte(extensions), &filter.Extensions); err != nil {
+		return nil, fmt.Errorf("failed to decode saved filter extensions: %w", err)
+	}
+
+	return &filter, nil
+}
+
+func encodeSavedFilterLists(filter *models.SavedFilter) (string, string, error) {
+	if filter.Tags == nil {
+		filter.Tags = []string{}
+	}
+	if filter.Extensions == nil {
+		filter.Extensions = []string{}
+	}
+
+	tags, err := json.Marshal(filter.Tags)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode saved filter tags: %w", err)
+	}
+	extensions, err := json.Marshal(filter.Extensions)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode saved filter extensions: %w", err)
+	}
+
+	return string(tags), string(extensions), nil
+}
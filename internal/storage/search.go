@@ -0,0 +1,385 @@
+package storage
+
+import (
+	"backthynk/internal/config"
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// searchRankWeights weigh the columns of search_fts, in order, when ranking matches
+var searchRankWeights = []float64{config.SearchContentWeight, config.SearchLinkTitleWeight}
+
+// FullTextMatch is a post matching a full-text search, with the passage around the match
+type FullTextMatch struct {
+	Post    models.Post
+	Snippet string
+	Rank    float64
+}
+
+// SearchPosts returns the posts of the given spaces (every space when nil) whose content or
+// link preview titles hold all terms, best ranked first, through the search index. Terms must
+// only hold letters and digits.
+func (db *DB) SearchPosts(ctx context.Context, spaceIDs []int, terms []string, limit int) ([]FullTextMatch, error) {
+	if len(terms) == 0 {
+		return []FullTextMatch{}, nil
+	}
+
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + term + `"`
+	}
+	conditions := []string{"search_fts MATCH ?"}
+	args := []interface{}{config.SearchSnippetTokens, strings.Join(quoted, " ")}
+
+	if spaceIDs != nil {
+		if len(spaceIDs) == 0 {
+			return []FullTextMatch{}, nil
+		}
+		conditions = append(conditions, "p.space_id IN ("+placeholders(len(spaceIDs))+")")
+		for _, id := range spaceIDs {
+			args = append(args, id)
+		}
+	}
+	args = append(args, limit)
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT p.id, p.space_id, p.created, snippet(search_fts, '', '', '…', -1, ?),
+			search_rank(matchinfo(search_fts, 'pcnalx')) AS rank
+		FROM search_fts JOIN posts p ON p.id = search_fts.docid
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY rank DESC, p.created DESC LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		logger.Error("Failed to search posts", zap.Strings("terms", terms), zap.Error(err))
+		return nil, fmt.Errorf("failed to search posts: %w", err)
+	}
+	defer rows.Close()
+
+	matches := []FullTextMatch{}
+	for rows.Next() {
+		var match FullTextMatch
+		if err := rows.Scan(&match.Post.ID, &match.Post.SpaceID, &match.Post.Created, &match.Snippet, &match.Rank); err != nil {
+			logger.Error("Failed to scan post", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		matches = append(matches, match)
+	}
+
+	return matches, rows.Err()
+}
+
+// IndexPostForSearch writes the content and link preview titles of a post to the search index,
+// dropping it from the index when the post no longer exists
+func (db *DB) IndexPostForSearch(postID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin search indexing", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM search_fts WHERE docid = ?", postID); err != nil {
+		logger.Error("Failed to drop post from search index", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to drop post from search index: %w", err)
+	}
+	if _, err := tx.Exec(`INSERT INTO search_fts (docid, content, titles) `+searchIndexSelect+` WHERE p.id = ?`, postID); err != nil {
+		logger.Error("Failed to index post for search", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to index post for search: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit search indexing", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RemovePostFromSearch drops a post from the search index
+func (db *DB) RemovePostFromSearch(postID int) error {
+	if _, err := db.Exec("DELETE FROM search_fts WHERE docid = ?", postID); err != nil {
+		logger.Error("Failed to drop post from search index", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to drop post from search index: %w", err)
+	}
+	return nil
+}
+
+// ReconcileSearchIndex indexes the posts missing from the search index and drops the entries of
+// posts that no longer exist, such as changes made while search was disabled. It returns how
+// many posts were indexed and dropped.
+func (db *DB) ReconcileSearchIndex() (indexed, dropped int64, err error) {
+	result, err := db.Exec("DELETE FROM search_fts WHERE docid NOT IN (SELECT id FROM posts)")
+	if err != nil {
+		logger.Error("Failed to drop deleted posts from search index", zap.Error(err))
+		return 0, 0, fmt.Errorf("failed to drop deleted posts from search index: %w", err)
+	}
+	dropped, _ = result.RowsAffected()
+
+	result, err = db.Exec(`INSERT INTO search_fts (docid, content, titles) ` + searchIndexSelect +
+		` WHERE p.id NOT IN (SELECT docid FROM search_fts)`)
+	if err != nil {
+		logger.Error("Failed to index missing posts for search", zap.Error(err))
+		return 0, dropped, fmt.Errorf("failed to index missing posts for search: %w", err)
+	}
+	indexed, _ = result.RowsAffected()
+
+	return indexed, dropped, nil
+}
+
+// searchIndexSelect selects the rows of search_fts for posts aliased p
+const searchIndexSelect = `SELECT p.id, p.content,
+	COALESCE((SELECT group_concat(title, ' ') FROM link_previews WHERE post_id = p.id AND title <> ''), '')
+	FROM posts p`
+
+// sqlSearchRank is the Okapi BM25 score of a row from its matchinfo 'pcnalx' blob, summing
+// the weighted score of each column. FTS4 has no ranking function of its own.
+func sqlSearchRank(info []byte) float64 {
+	values := make([]float64, len(info)/4)
+	for i := range values {
+		values[i] = float64(binary.NativeEndian.Uint32(info[4*i:]))
+	}
+	if len(values) < 3 {
+		return 0
+	}
+
+	phrases, columns, rows := int(values[0]), int(values[1]), values[2]
+	if len(values) < 3+2*columns+3*phrases*columns {
+		return 0
+	}
+	averages, lengths, hits := values[3:3+columns], values[3+columns:3+2*columns], values[3+2*columns:]
+
+	const k1, b = 1.2, 0.75
+	var score float64
+	for phrase := 0; phrase < phrases; phrase++ {
+		for column := 0; column < columns && column < len(searchRankWeights); column++ {
+			x := hits[3*(phrase*columns+column):]
+			if x[0] == 0 {
+				continue
+			}
+			// Stays positive for terms found in most rows, unlike the classic formula
+			idf := math.Log(1 + (rows-x[2]+0.5)/(x[2]+0.5))
+			norm := 1 - b
+			if averages[column] > 0 {
+				norm += b * lengths[column] / averages[column]
+			}
+			score += searchRankWeights[column] * idf * x[0] * (k1 + 1) / (x[0] + k1*norm)
+		}
+	}
+	return score
+}
+
+// QuickSearchPosts returns the most recent posts of the given spaces whose content has a word
+// starting with each of prefixes, through the full-text index. Prefixes must only hold letters
+// and digits.
+func (db *DB) QuickSearchPosts(ctx context.Context, spaceIDs []int, prefixes []string, limit int) ([]models.Post, error) {
+	if len(spaceIDs) == 0 || len(prefixes) == 0 {
+		return []models.Post{}, nil
+	}
+
+	terms := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		terms[i] = prefix + "*"
+	}
+	args := []interface{}{strings.Join(terms, " ")}
+	placeholders := make([]string, len(spaceIDs))
+	for i, id := range spaceIDs {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+	args = append(args, limit)
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT p.id, p.space_id, p.content, p.created
+		FROM posts_fts JOIN posts p ON p.id = posts_fts.docid
+		WHERE posts_fts MATCH ? AND p.space_id IN (`+strings.Join(placeholders, ",")+`)
+		ORDER BY p.created DESC LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		logger.Error("Failed to quick search posts", zap.Strings("prefixes", prefixes), zap.Error(err))
+		return nil, fmt.Errorf("failed to quick search posts: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.ID, &post.SpaceID, &post.Content, &post.Created); err != nil {
+			logger.Error("Failed to scan post", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// PostEmbedding is the stored vector of a post, with a hash of the content it was computed from
+type PostEmbedding struct {
+	ContentHash string
+	Vector      []float32
+}
+
+// SavePostEmbedding stores the vector of a post computed by model, replacing any previous one
+func (db *DB) SavePostEmbedding(postID int, model string, embedding PostEmbedding) error {
+	_, err := db.Exec(
+		`INSERT INTO post_embeddings (post_id, model, content_hash, vector, updated) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(post_id) DO UPDATE SET model = excluded.model, content_hash = excluded.content_hash,
+			vector = excluded.vector, updated = excluded.updated`,
+		postID, model, embedding.ContentHash, encodeVector(embedding.Vector), time.Now().UnixMilli(),
+	)
+	if err != nil {
+		logger.Error("Failed to save post embedding", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to save post embedding: %w", err)
+	}
+	return nil
+}
+
+// GetPostEmbeddings returns the stored embeddings computed by model, by post ID
+func (db *DB) GetPostEmbeddings(model string) (map[int]PostEmbedding, error) {
+	rows, err := db.Query("SELECT post_id, content_hash, vector FROM post_embeddings WHERE model = ?", model)
+	if err != nil {
+		logger.Error("Failed to query post embeddings", zap.String("model", model), zap.Error(err))
+		return nil, fmt.Errorf("failed to query post embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	embeddings := make(map[int]PostEmbedding)
+	for rows.Next() {
+		var postID int
+		var embedding PostEmbedding
+		var blob []byte
+		if err := rows.Scan(&postID, &embedding.ContentHash, &blob); err != nil {
+			logger.Error("Failed to scan post embedding", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan post embedding: %w", err)
+		}
+		embedding.Vector = decodeVector(blob)
+		embeddings[postID] = embedding
+	}
+
+	return embeddings, rows.Err()
+}
+
+// encodeVector packs a vector as little-endian float32 values
+func encodeVector(vector []float32) []byte {
+	blob := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(v))
+	}
+	return blob
+}
+
+func decodeVector(blob []byte) []float32 {
+	vector := make([]float32, len(blob)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:]))
+	}
+	return vector
+}
+
+// SearchReindex is the checkpoint of a rebuild of the search index
+type SearchReindex struct {
+	Status     string `json:"status"`       // running, done or failed
+	LastPostID int    `json:"last_post_id"` // Posts up to this ID are reindexed
+	Processed  int    `json:"processed"`
+	Total      int    `json:"total"` // Posts to reindex, grows when posts are created meanwhile
+	Started    int64  `json:"started"`
+	Updated    int64  `json:"updated"`
+	Error      string `json:"error,omitempty"`
+}
+
+// GetSearchReindex returns the checkpoint of the latest rebuild of the search index, nil when
+// the index was never rebuilt
+func (db *DB) GetSearchReindex() (*SearchReindex, error) {
+	var state SearchReindex
+	err := db.QueryRow(
+		"SELECT status, last_post_id, processed, total, started, updated, error FROM search_reindex WHERE id = 1",
+	).Scan(&state.Status, &state.LastPostID, &state.Processed, &state.Total, &state.Started, &state.Updated, &state.Error)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error("Failed to get search reindex checkpoint", zap.Error(err))
+		return nil, fmt.Errorf("failed to get search reindex checkpoint: %w", err)
+	}
+	return &state, nil
+}
+
+// SaveSearchReindex stores the checkpoint of a rebuild of the search index
+func (db *DB) SaveSearchReindex(state SearchReindex) error {
+	_, err := db.Exec(
+		`INSERT OR REPLACE INTO search_reindex (id, status, last_post_id, processed, total, started, updated, error)
+		VALUES (1, ?, ?, ?, ?, ?, ?, ?)`,
+		state.Status, state.LastPostID, state.Processed, state.Total, state.Started, state.Updated, state.Error,
+	)
+	if err != nil {
+		logger.Error("Failed to save search reindex checkpoint", zap.Error(err))
+		return fmt.Errorf("failed to save search reindex checkpoint: %w", err)
+	}
+	return nil
+}
+
+// CountPostsAfter returns how many posts have an ID above postID
+func (db *DB) CountPostsAfter(postID int) (int, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM posts WHERE id > ?", postID).Scan(&count); err != nil {
+		logger.Error("Failed to count posts", zap.Int("after", postID), zap.Error(err))
+		return 0, fmt.Errorf("failed to count posts: %w", err)
+	}
+	return count, nil
+}
+
+// ReindexSearchBatch rewrites the search index entries of the next limit posts with an ID
+// above afterID, dropping the entries of deleted posts in that range. It returns the ID of
+// the last post reindexed and how many were; once none is left, the entries above afterID
+// are dropped and afterID is returned with 0.
+func (db *DB) ReindexSearchBatch(afterID, limit int) (int, int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin search reindex batch", zap.Error(err))
+		return afterID, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var lastID, count int
+	err = tx.QueryRow(
+		"SELECT COALESCE(MAX(id), 0), COUNT(*) FROM (SELECT id FROM posts WHERE id > ? ORDER BY id LIMIT ?)",
+		afterID, limit,
+	).Scan(&lastID, &count)
+	if err != nil {
+		logger.Error("Failed to select posts to reindex", zap.Int("after", afterID), zap.Error(err))
+		return afterID, 0, fmt.Errorf("failed to select posts to reindex: %w", err)
+	}
+
+	if count == 0 {
+		if _, err := tx.Exec("DELETE FROM search_fts WHERE docid > ?", afterID); err != nil {
+			logger.Error("Failed to drop deleted posts from search index", zap.Error(err))
+			return afterID, 0, fmt.Errorf("failed to drop deleted posts from search index: %w", err)
+		}
+		lastID = afterID
+	} else {
+		if _, err := tx.Exec("DELETE FROM search_fts WHERE docid > ? AND docid <= ?", afterID, lastID); err != nil {
+			logger.Error("Failed to clear search index batch", zap.Int("after", afterID), zap.Error(err))
+			return afterID, 0, fmt.Errorf("failed to clear search index batch: %w", err)
+		}
+		if _, err := tx.Exec(`INSERT INTO search_fts (docid, content, titles) `+searchIndexSelect+` WHERE p.id > ? AND p.id <= ?`, afterID, lastID); err != nil {
+			logger.Error("Failed to reindex search batch", zap.Int("after", afterID), zap.Error(err))
+			return afterID, 0, fmt.Errorf("failed to reindex search batch: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit search reindex batch", zap.Error(err))
+		return afterID, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return lastID, count, nil
+}
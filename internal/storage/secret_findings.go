@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// CreateSecretFindings stores the findings of one ingested content
+func (db *DB) CreateSecretFindings(findings []models.SecretFinding) error {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for secret findings", zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, f := range findings {
+		_, err := tx.Exec(
+			`INSERT INTO secret_findings (post_id, attachment_id, space_id, source, kind, excerpt, action, created)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			f.PostID, f.AttachmentID, f.SpaceID, f.Source, f.Kind, f.Excerpt, f.Action, f.Created,
+		)
+		if err != nil {
+			logger.Error("Failed to create secret finding", zap.String("kind", f.Kind), zap.Error(err))
+			return fmt.Errorf("failed to create secret finding: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit secret findings", zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetSecretFindings returns the most recent secret findings
+func (db *DB) GetSecretFindings(limit int) ([]models.SecretFinding, error) {
+	rows, err := db.Query(
+		`SELECT id, post_id, attachment_id, space_id, source, kind, excerpt, action, created
+		FROM secret_findings ORDER BY created DESC, id DESC LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		logger.Error("Failed to query secret findings", zap.Error(err))
+		return nil, fmt.Errorf("failed to query secret findings: %w", err)
+	}
+	defer rows.Close()
+
+	findings := []models.SecretFinding{}
+	for rows.Next() {
+		var f models.SecretFinding
+		var postID, attachmentID sql.NullInt64
+		err := rows.Scan(&f.ID, &postID, &attachmentID, &f.SpaceID, &f.Source, &f.Kind, &f.Excerpt, &f.Action, &f.Created)
+		if err != nil {
+			logger.Error("Failed to scan secret finding", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan secret finding: %w", err)
+		}
+		if postID.Valid {
+			id := int(postID.Int64)
+			f.PostID = &id
+		}
+		if attachmentID.Valid {
+			id := int(attachmentID.Int64)
+			f.AttachmentID = &id
+		}
+		findings = append(findings, f)
+	}
+
+	return findings, rows.Err()
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CreateShareLink stores a new share link, with its signing secret if it has one, and fills in
+// its ID and creation time
+func (db *DB) CreateShareLink(link *models.ShareLink) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin share link creation", zap.Int("space_id", link.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UnixMilli()
+	result, err := tx.Exec(
+		`INSERT INTO share_links (space_id, name, token_hash, prefix, rate_limit, created, expires) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		link.SpaceID, link.Name, link.Hash, link.Prefix, link.RateLimit, now, link.Expires,
+	)
+	if err != nil {
+		logger.Error("Failed to create share link", zap.Int("space_id", link.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Failed to get last insert ID after share link creation", zap.Error(err))
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	if link.Secret != "" {
+		if _, err := tx.Exec("INSERT INTO share_link_secrets (link_id, secret) VALUES (?, ?)", id, link.Secret); err != nil {
+			logger.Error("Failed to store share link secret", zap.Int64("link_id", id), zap.Error(err))
+			return fmt.Errorf("failed to store share link secret: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit share link creation", zap.Int("space_id", link.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	link.ID = int(id)
+	link.Created = now
+	link.Signed = link.Secret != ""
+	return nil
+}
+
+// GetShareLinks returns every share link, revoked and expired ones included
+func (db *DB) GetShareLinks() ([]models.ShareLink, error) {
+	rows, err := db.Query(
+		`SELECT l.id, l.space_id, l.name, l.token_hash, l.prefix, l.rate_limit, l.created, l.expires, l.last_used, l.revoked, COALESCE(s.secret, '')
+		FROM share_links l LEFT JOIN share_link_secrets s ON s.link_id = l.id ORDER BY l.id`,
+	)
+	if err != nil {
+		logger.Error("Failed to query share links", zap.Error(err))
+		return nil, fmt.Errorf("failed to query share links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []models.ShareLink{}
+	for rows.Next() {
+		var link models.ShareLink
+		if err := rows.Scan(&link.ID, &link.SpaceID, &link.Name, &link.Hash, &link.Prefix,
+			&link.RateLimit, &link.Created, &link.Expires, &link.LastUsed, &link.Revoked, &link.Secret); err != nil {
+			logger.Error("Failed to scan share link", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan share link: %w", err)
+		}
+		link.Signed = link.Secret != ""
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// RevokeShareLink marks a share link as revoked at the given time
+func (db *DB) RevokeShareLink(id int, revoked int64) error {
+	if _, err := db.Exec("UPDATE share_links SET revoked = ? WHERE id = ?", revoked, id); err != nil {
+		logger.Error("Failed to revoke share link", zap.Int("link_id", id), zap.Error(err))
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	return nil
+}
+
+// TouchShareLink records the last post made through a share link
+func (db *DB) TouchShareLink(id int, used int64) error {
+	if _, err := db.Exec("UPDATE share_links SET last_used = ? WHERE id = ?", used, id); err != nil {
+		logger.Error("Failed to update share link", zap.Int("link_id", id), zap.Error(err))
+		return fmt.Errorf("failed to update share link: %w", err)
+	}
+	return nil
+}
+
+// SetPostAuthor attributes a post to an author, optionally through a share link
+func (db *DB) SetPostAuthor(author models.PostAuthor) error {
+	var linkID interface{}
+	if author.ShareLinkID != 0 {
+		linkID = author.ShareLinkID
+	}
+
+	_, err := db.Exec(
+		"INSERT OR REPLACE INTO post_authors (post_id, author, share_link_id) VALUES (?, ?, ?)",
+		author.PostID, author.Author, linkID,
+	)
+	if err != nil {
+		logger.Error("Failed to set post author", zap.Int("post_id", author.PostID), zap.Error(err))
+		return fmt.Errorf("failed to set post author: %w", err)
+	}
+	return nil
+}
+
+// GetPostAuthorsByPosts returns the authors of the given posts that have one
+func (db *DB) GetPostAuthorsByPosts(postIDs []int) (map[int]string, error) {
+	authors := make(map[int]string)
+	if len(postIDs) == 0 {
+		return authors, nil
+	}
+
+	placeholders := make([]string, len(postIDs))
+	args := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := db.Query(
+		"SELECT post_id, author FROM post_authors WHERE post_id IN ("+strings.Join(placeholders, ",")+")",
+		args...,
+	)
+	if err != nil {
+		logger.Error("Failed to query post authors", zap.Error(err))
+		return nil, fmt.Errorf("failed to query post authors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int
+		var author string
+		if err := rows.Scan(&postID, &author); err != nil {
+			logger.Error("Failed to scan post author", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan post author: %w", err)
+		}
+		authors[postID] = author
+	}
+
+	return authors, rows.Err()
+}
+
+// GetShareLinkPosts returns the attribution of the posts written through a share link, newest first
+func (db *DB) GetShareLinkPosts(linkID int) ([]models.PostAuthor, error) {
+	rows, err := db.Query(
+		"SELECT post_id, author, share_link_id FROM post_authors WHERE share_link_id = ? ORDER BY post_id DESC",
+		linkID,
+	)
+	if err != nil {
+		logger.Error("Failed to query share link posts", zap.Int("link_id", linkID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query share link posts: %w", err)
+	}
+	defer rows.Close()
+
+	authors := []models.PostAuthor{}
+	for rows.Next() {
+		var author models.PostAuthor
+		var linkID sql.NullInt64
+		if err := rows.Scan(&author.PostID, &author.Author, &linkID); err != nil {
+			logger.Error("Failed to scan share link post", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan share link post: %w", err)
+		}
+		author.ShareLinkID = int(linkID.Int64)
+		authors = append(authors, author)
+	}
+
+	return authors, rows.Err()
+}
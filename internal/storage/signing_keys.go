@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// GetSigningKey returns the secret stored under name, or nil when there is none
+func (db *DB) GetSigningKey(name string) ([]byte, error) {
+	var secret []byte
+	err := db.QueryRow("SELECT secret FROM signing_keys WHERE name = ?", name).Scan(&secret)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error("Failed to get signing key", zap.String("name", name), zap.Error(err))
+		return nil, fmt.Errorf("failed to get signing key: %w", err)
+	}
+
+	return secret, nil
+}
+
+// SetSigningKey stores the secret under name, replacing the previous one
+func (db *DB) SetSigningKey(name string, secret []byte) error {
+	_, err := db.Exec(
+		`INSERT INTO signing_keys (name, secret, created) VALUES (?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET secret = excluded.secret, created = excluded.created`,
+		name, secret, time.Now().UnixMilli(),
+	)
+	if err != nil {
+		logger.Error("Failed to set signing key", zap.String("name", name), zap.Error(err))
+		return fmt.Errorf("failed to set signing key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+const snapshotColumns = "id, space_id, name, scheduled, space_count, post_count, attachment_count, size, created"
+
+// CreateSnapshot stores a snapshot along with the hashes of the attachment files it refers to
+func (db *DB) CreateSnapshot(snapshot *models.SpaceSnapshot, hashes []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for snapshot", zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`INSERT INTO space_snapshots (space_id, name, scheduled, space_count, post_count, attachment_count, size, created, manifest)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		snapshot.SpaceID, snapshot.Name, snapshot.Scheduled, snapshot.SpaceCount, snapshot.PostCount,
+		snapshot.AttachmentCount, snapshot.Size, snapshot.Created, snapshot.Manifest,
+	)
+	if err != nil {
+		logger.Error("Failed to create snapshot", zap.Int("space_id", snapshot.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	snapshot.ID = int(id)
+
+	for _, hash := range hashes {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO space_snapshot_files (snapshot_id, sha256) VALUES (?, ?)", snapshot.ID, hash); err != nil {
+			logger.Error("Failed to record snapshot file", zap.Int("snapshot_id", snapshot.ID), zap.Error(err))
+			return fmt.Errorf("failed to record snapshot file: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit snapshot", zap.Error(err))
+		return fmt.Errorf("failed to commit snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetSnapshot returns a snapshot with its manifest; found is false when there is none
+func (db *DB) GetSnapshot(id int) (*models.SpaceSnapshot, bool, error) {
+	var snapshot models.SpaceSnapshot
+	err := db.QueryRow("SELECT "+snapshotColumns+", manifest FROM space_snapshots WHERE id = ?", id).Scan(
+		&snapshot.ID, &snapshot.SpaceID, &snapshot.Name, &snapshot.Scheduled, &snapshot.SpaceCount,
+		&snapshot.PostCount, &snapshot.AttachmentCount, &snapshot.Size, &snapshot.Created, &snapshot.Manifest,
+	)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		logger.Error("Failed to get snapshot", zap.Int("id", id), zap.Error(err))
+		return nil, false, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	return &snapshot, true, nil
+}
+
+// GetSnapshotsBySpace lists the snapshots of a space without their manifest, newest first
+func (db *DB) GetSnapshotsBySpace(spaceID int) ([]models.SpaceSnapshot, error) {
+	rows, err := db.Query("SELECT "+snapshotColumns+" FROM space_snapshots WHERE space_id = ? ORDER BY created DESC, id DESC", spaceID)
+	if err != nil {
+		logger.Error("Failed to query snapshots", zap.Int("space_id", spaceID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []models.SpaceSnapshot{}
+	for rows.Next() {
+		var snapshot models.SpaceSnapshot
+		err := rows.Scan(
+			&snapshot.ID, &snapshot.SpaceID, &snapshot.Name, &snapshot.Scheduled, &snapshot.SpaceCount,
+			&snapshot.PostCount, &snapshot.AttachmentCount, &snapshot.Size, &snapshot.Created,
+		)
+		if err != nil {
+			logger.Error("Failed to scan snapshot", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// DeleteSnapshot removes a snapshot and returns the hashes of the files no snapshot refers to anymore
+func (db *DB) DeleteSnapshot(id int) ([]string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for snapshot deletion", zap.Error(err))
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT sha256 FROM space_snapshot_files WHERE snapshot_id = ?", id)
+	if err != nil {
+		logger.Error("Failed to query snapshot files", zap.Int("snapshot_id", id), zap.Error(err))
+		return nil, fmt.Errorf("failed to query snapshot files: %w", err)
+	}
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan snapshot file: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	rows.Close()
+
+	if _, err := tx.Exec("DELETE FROM space_snapshots WHERE id = ?", id); err != nil {
+		logger.Error("Failed to delete snapshot", zap.Int("id", id), zap.Error(err))
+		return nil, fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
+	var orphans []string
+	for _, hash := range hashes {
+		var used int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM space_snapshot_files WHERE sha256 = ?", hash).Scan(&used); err != nil {
+			return nil, fmt.Errorf("failed to count snapshot file references: %w", err)
+		}
+		if used == 0 {
+			orphans = append(orphans, hash)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit snapshot deletion", zap.Error(err))
+		return nil, fmt.Errorf("failed to commit snapshot deletion: %w", err)
+	}
+
+	return orphans, nil
+}
+
+// GetSnapshotSchedules returns every snapshot schedule
+func (db *DB) GetSnapshotSchedules() ([]models.SnapshotSchedule, error) {
+	rows, err := db.Query("SELECT space_id, interval_hours, keep, last_run FROM space_snapshot_schedules ORDER BY space_id")
+	if err != nil {
+		logger.Error("Failed to query snapshot schedules", zap.Error(err))
+		return nil, fmt.Errorf("failed to query snapshot schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []models.SnapshotSchedule{}
+	for rows.Next() {
+		var schedule models.SnapshotSchedule
+		if err := rows.Scan(&schedule.SpaceID, &schedule.IntervalHours, &schedule.Keep, &schedule.LastRun); err != nil {
+			logger.Error("Failed to scan snapshot schedule", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan snapshot schedule: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+// SetSnapshotSchedule creates or replaces the snapshot schedule of a space; a zero interval removes it
+func (db *DB) SetSnapshotSchedule(schedule models.SnapshotSchedule) error {
+	var err error
+	if schedule.IntervalHours == 0 {
+		_, err = db.Exec("DELETE FROM space_snapshot_schedules WHERE space_id = ?", schedule.SpaceID)
+	} else {
+		_, err = db.Exec(
+			`INSERT INTO space_snapshot_schedules (space_id, interval_hours, keep, last_run) VALUES (?, ?, ?, ?)
+			ON CONFLICT(space_id) DO UPDATE SET interval_hours = excluded.interval_hours, keep = excluded.keep`,
+			schedule.SpaceID, schedule.IntervalHours, schedule.Keep, schedule.LastRun,
+		)
+	}
+	if err != nil {
+		logger.Error("Failed to set snapshot schedule", zap.Int("space_id", schedule.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to set snapshot schedule: %w", err)
+	}
+
+	return nil
+}
+
+// MarkSnapshotScheduleRun records when the schedule of a space last ran
+func (db *DB) MarkSnapshotScheduleRun(spaceID int, at int64) error {
+	if _, err := db.Exec("UPDATE space_snapshot_schedules SET last_run = ? WHERE space_id = ?", at, spaceID); err != nil {
+		logger.Error("Failed to update snapshot schedule", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to update snapshot schedule: %w", err)
+	}
+	return nil
+}
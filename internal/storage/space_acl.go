@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// GetSpaceACLs returns the access list of every private space, by space ID
+func (db *DB) GetSpaceACLs() (map[int][]models.SpaceACLEntry, error) {
+	rows, err := db.Query("SELECT space_id, user_id, permission FROM space_acl ORDER BY space_id, user_id")
+	if err != nil {
+		logger.Error("Failed to query space access lists", zap.Error(err))
+		return nil, fmt.Errorf("failed to query space access lists: %w", err)
+	}
+	defer rows.Close()
+
+	acls := make(map[int][]models.SpaceACLEntry)
+	for rows.Next() {
+		var spaceID int
+		var entry models.SpaceACLEntry
+		if err := rows.Scan(&spaceID, &entry.UserID, &entry.Permission); err != nil {
+			logger.Error("Failed to scan space access entry", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan space access entry: %w", err)
+		}
+		acls[spaceID] = append(acls[spaceID], entry)
+	}
+
+	return acls, rows.Err()
+}
+
+// SetSpaceACL replaces the access list of a space; an empty list makes the space public again
+func (db *DB) SetSpaceACL(spaceID int, entries []models.SpaceACLEntry) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin space access update", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM space_acl WHERE space_id = ?", spaceID); err != nil {
+		logger.Error("Failed to clear space access list", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to clear space access list: %w", err)
+	}
+	for _, entry := range entries {
+		_, err := tx.Exec(
+			"INSERT INTO space_acl (space_id, user_id, permission) VALUES (?, ?, ?)",
+			spaceID, entry.UserID, entry.Permission,
+		)
+		if err != nil {
+			if strings.Contains(err.Error(), "FOREIGN KEY constraint failed") {
+				return fmt.Errorf("user not found")
+			}
+			logger.Error("Failed to store space access entry", zap.Int("space_id", spaceID), zap.Int("user_id", entry.UserID), zap.Error(err))
+			return fmt.Errorf("failed to store space access entry: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit space access update", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// GetSpaceFieldSchemas returns the custom field schemas keyed by space ID
+func (db *DB) GetSpaceFieldSchemas() (map[int][]models.FieldDef, error) {
+	rows, err := db.Query("SELECT space_id, fields FROM space_field_schemas")
+	if err != nil {
+		logger.Error("Failed to query field schemas", zap.Error(err))
+		return nil, fmt.Errorf("failed to query field schemas: %w", err)
+	}
+	defer rows.Close()
+
+	schemas := make(map[int][]models.FieldDef)
+	for rows.Next() {
+		var spaceID int
+		var raw string
+		if err := rows.Scan(&spaceID, &raw); err != nil {
+			logger.Error("Failed to scan field schema", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan field schema: %w", err)
+		}
+		var fields []models.FieldDef
+		if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+			logger.Error("Failed to decode field schema", zap.Int("space_id", spaceID), zap.Error(err))
+			return nil, fmt.Errorf("failed to decode field schema: %w", err)
+		}
+		schemas[spaceID] = fields
+	}
+
+	return schemas, rows.Err()
+}
+
+// SetSpaceFieldSchema stores the custom field schema of a space; an empty schema removes it.
+// Values already stored on posts are kept.
+func (db *DB) SetSpaceFieldSchema(spaceID int, fields []models.FieldDef) error {
+	var err error
+	if len(fields) == 0 {
+		_, err = db.Exec("DELETE FROM space_field_schemas WHERE space_id = ?", spaceID)
+	} else {
+		raw, marshalErr := json.Marshal(fields)
+		if marshalErr != nil {
+			return fmt.Errorf("failed to encode field schema: %w", marshalErr)
+		}
+		_, err = db.Exec(
+			`INSERT INTO space_field_schemas (space_id, fields) VALUES (?, ?)
+			ON CONFLICT(space_id) DO UPDATE SET fields = excluded.fields`,
+			spaceID, string(raw),
+		)
+	}
+	if err != nil {
+		logger.Error("Failed to set field schema", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to set field schema: %w", err)
+	}
+
+	return nil
+}
+
+// GetPostFieldsByPosts returns the custom field values of the given posts keyed by post ID;
+// posts without values are left out
+func (db *DB) GetPostFieldsByPosts(postIDs []int) (map[int]map[string]string, error) {
+	values := make(map[int]map[string]string)
+	if len(postIDs) == 0 {
+		return values, nil
+	}
+
+	placeholders := make([]string, len(postIDs))
+	args := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	rows, err := db.Query(
+		fmt.Sprintf("SELECT post_id, name, value FROM post_meta WHERE post_id IN (%s)", strings.Join(placeholders, ",")),
+		args...,
+	)
+	if err != nil {
+		logger.Error("Failed to query post fields", zap.Error(err))
+		return nil, fmt.Errorf("failed to query post fields: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int
+		var name, value string
+		if err := rows.Scan(&postID, &name, &value); err != nil {
+			logger.Error("Failed to scan post field", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan post field: %w", err)
+		}
+		if values[postID] == nil {
+			values[postID] = make(map[string]string)
+		}
+		values[postID][name] = value
+	}
+
+	return values, rows.Err()
+}
+
+// SetPostFields replaces the custom field values of a post
+func (db *DB) SetPostFields(postID int, values map[string]string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM post_meta WHERE post_id = ?", postID); err != nil {
+		logger.Error("Failed to clear post fields", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to clear post fields: %w", err)
+	}
+	for name, value := range values {
+		if _, err := tx.Exec("INSERT INTO post_meta (post_id, name, value) VALUES (?, ?, ?)", postID, name, value); err != nil {
+			logger.Error("Failed to set post field", zap.Int("post_id", postID), zap.String("name", name), zap.Error(err))
+			return fmt.Errorf("failed to set post field: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// GetSpaceContentLimits returns the max content length overrides keyed by space ID
+func (db *DB) GetSpaceContentLimits() (map[int]int, error) {
+	rows, err := db.Query("SELECT space_id, max_content_length FROM space_limits")
+	if err != nil {
+		logger.Error("Failed to query space limits", zap.Error(err))
+		return nil, fmt.Errorf("failed to query space limits: %w", err)
+	}
+	defer rows.Close()
+
+	limits := make(map[int]int)
+	for rows.Next() {
+		var spaceID, maxLength int
+		if err := rows.Scan(&spaceID, &maxLength); err != nil {
+			logger.Error("Failed to scan space limit", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan space limit: %w", err)
+		}
+		limits[spaceID] = maxLength
+	}
+
+	return limits, rows.Err()
+}
+
+// SetSpaceContentLimit stores the max content length override of a space; nil removes it
+func (db *DB) SetSpaceContentLimit(spaceID int, maxLength *int) error {
+	var err error
+	if maxLength == nil {
+		_, err = db.Exec("DELETE FROM space_limits WHERE space_id = ?", spaceID)
+	} else {
+		_, err = db.Exec(
+			`INSERT INTO space_limits (space_id, max_content_length) VALUES (?, ?)
+			ON CONFLICT(space_id) DO UPDATE SET max_content_length = excluded.max_content_length`,
+			spaceID, *maxLength,
+		)
+	}
+	if err != nil {
+		logger.Error("Failed to set space limit", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to set space limit: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+const spaceReviewColumns = "space_id, interval_days, last_reviewed, due, notified"
+
+func scanSpaceReview(row interface{ Scan(...interface{}) error }) (models.SpaceReview, error) {
+	var review models.SpaceReview
+	err := row.Scan(&review.SpaceID, &review.IntervalDays, &review.LastReviewed, &review.Due, &review.Notified)
+	return review, err
+}
+
+// GetSpaceReview returns the review schedule of a space
+func (db *DB) GetSpaceReview(spaceID int) (*models.SpaceReview, error) {
+	review, err := scanSpaceReview(db.QueryRow(
+		"SELECT "+spaceReviewColumns+" FROM space_reviews WHERE space_id = ?", spaceID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("space review not found")
+	}
+	if err != nil {
+		logger.Error("Failed to get space review", zap.Int("space_id", spaceID), zap.Error(err))
+		return nil, fmt.Errorf("failed to get space review: %w", err)
+	}
+	return &review, nil
+}
+
+// GetDueSpaceReviews returns the reviews due at now, the longest due first
+func (db *DB) GetDueSpaceReviews(now int64) ([]models.SpaceReview, error) {
+	rows, err := db.Query(
+		"SELECT "+spaceReviewColumns+" FROM space_reviews WHERE due <= ? ORDER BY due, space_id", now,
+	)
+	if err != nil {
+		logger.Error("Failed to query due space reviews", zap.Error(err))
+		return nil, fmt.Errorf("failed to query due space reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []models.SpaceReview{}
+	for rows.Next() {
+		review, err := scanSpaceReview(rows)
+		if err != nil {
+			logger.Error("Failed to scan space review", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan space review: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+// SaveSpaceReview creates or replaces the review schedule of a space. A pending
+// notification is kept only while the due time stays the same.
+func (db *DB) SaveSpaceReview(review *models.SpaceReview) error {
+	_, err := db.Exec(
+		`INSERT INTO space_reviews (space_id, interval_days, last_reviewed, due, notified) VALUES (?, ?, ?, ?, 0)
+		ON CONFLICT(space_id) DO UPDATE SET interval_days = excluded.interval_days,
+			last_reviewed = excluded.last_reviewed, due = excluded.due,
+			notified = CASE WHEN space_reviews.due = excluded.due THEN space_reviews.notified ELSE 0 END`,
+		review.SpaceID, review.IntervalDays, review.LastReviewed, review.Due,
+	)
+	if err != nil {
+		logger.Error("Failed to save space review", zap.Int("space_id", review.SpaceID), zap.Error(err))
+		return fmt.Errorf("failed to save space review: %w", err)
+	}
+	return nil
+}
+
+// DeleteSpaceReview removes the review schedule of a space
+func (db *DB) DeleteSpaceReview(spaceID int) error {
+	result, err := db.Exec("DELETE FROM space_reviews WHERE space_id = ?", spaceID)
+	if err != nil {
+		logger.Error("Failed to delete space review", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to delete space review: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("space review not found")
+	}
+	return nil
+}
+
+// MarkSpaceReviewNotified records that the review of a space due at due was announced
+func (db *DB) MarkSpaceReviewNotified(spaceID int, due int64) error {
+	if _, err := db.Exec("UPDATE space_reviews SET notified = ? WHERE space_id = ?", due, spaceID); err != nil {
+		logger.Error("Failed to mark space review notified", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to mark space review notified: %w", err)
+	}
+	return nil
+}
+
+// GetReviewChanges summarizes the posts of spaceIDs created or touched after since, listing
+// at most limit of them
+func (db *DB) GetReviewChanges(spaceIDs []int, since int64, limit int) (*models.ReviewChanges, error) {
+	changes := &models.ReviewChanges{PostIDs: []int{}}
+	if len(spaceIDs) == 0 {
+		return changes, nil
+	}
+
+	args := make([]interface{}, 0, len(spaceIDs)+3)
+	for _, id := range spaceIDs {
+		args = append(args, id)
+	}
+	where := " FROM posts p " + postActivityJoin + " WHERE p.space_id IN (" + placeholders(len(spaceIDs)) + ") AND " + postActivityColumn + " > ?"
+
+	var lastActivity sql.NullInt64
+	err := db.QueryRow(
+		"SELECT COUNT(CASE WHEN p.created > ? THEN 1 END), COUNT(CASE WHEN p.created <= ? THEN 1 END), MAX("+postActivityColumn+")"+where,
+		append([]interface{}{since, since}, append(args, since)...)...,
+	).Scan(&changes.NewPosts, &changes.UpdatedPosts, &lastActivity)
+	if err != nil {
+		logger.Error("Failed to count review changes", zap.Error(err))
+		return nil, fmt.Errorf("failed to count review changes: %w", err)
+	}
+	changes.LastActivity = lastActivity.Int64
+
+	rows, err := db.Query(
+		"SELECT p.id"+where+" ORDER BY "+postActivityColumn+" DESC, p.id DESC LIMIT ?",
+		append(args, since, limit)...,
+	)
+	if err != nil {
+		logger.Error("Failed to query review changes", zap.Error(err))
+		return nil, fmt.Errorf("failed to query review changes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int
+		if err := rows.Scan(&postID); err != nil {
+			logger.Error("Failed to scan review change", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan review change: %w", err)
+		}
+		changes.PostIDs = append(changes.PostIDs, postID)
+	}
+
+	return changes, rows.Err()
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// GetSpaceDefaultSorts returns the default listing orders keyed by space ID
+func (db *DB) GetSpaceDefaultSorts() (map[int]string, error) {
+	rows, err := db.Query("SELECT space_id, default_sort FROM space_settings")
+	if err != nil {
+		logger.Error("Failed to query space settings", zap.Error(err))
+		return nil, fmt.Errorf("failed to query space settings: %w", err)
+	}
+	defer rows.Close()
+
+	sorts := make(map[int]string)
+	for rows.Next() {
+		var spaceID int
+		var sort string
+		if err := rows.Scan(&spaceID, &sort); err != nil {
+			logger.Error("Failed to scan space setting", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan space setting: %w", err)
+		}
+		sorts[spaceID] = sort
+	}
+
+	return sorts, rows.Err()
+}
+
+// SetSpaceDefaultSort stores the default listing order of a space; an empty sort removes it
+func (db *DB) SetSpaceDefaultSort(spaceID int, sort string) error {
+	var err error
+	if sort == "" {
+		_, err = db.Exec("DELETE FROM space_settings WHERE space_id = ?", spaceID)
+	} else {
+		_, err = db.Exec(
+			`INSERT INTO space_settings (space_id, default_sort) VALUES (?, ?)
+			ON CONFLICT(space_id) DO UPDATE SET default_sort = excluded.default_sort`,
+			spaceID, sort,
+		)
+	}
+	if err != nil {
+		logger.Error("Failed to set space default sort", zap.Int("space_id", spaceID), zap.Error(err))
+		return fmt.Errorf("failed to set space default sort: %w", err)
+	}
+
+	return nil
+}
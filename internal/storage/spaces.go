@@ -5,6 +5,7 @@ import (
 	"backthynk/internal/core/logger"
 	"backthynk/internal/core/models"
 	"backthynk/internal/core/utils"
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -136,9 +137,10 @@ func (db *DB) CreateSpace(name string, parentID *int, description string) (*mode
 func (db *DB) GetSpace(id int) (*models.Space, error) {
 	var space models.Space
 	err := db.QueryRow(
-		"SELECT id, name, description, parent_id, depth, created FROM spaces WHERE id = ?",
+		"SELECT s.id, s.name, s.description, s.parent_id, s.depth, s.created, "+spaceHashColumn+
+			" FROM spaces s "+spaceHashJoin+" WHERE s.id = ?",
 		id,
-	).Scan(&space.ID, &space.Name, &space.Description, &space.ParentID, &space.Depth, &space.Created)
+	).Scan(&space.ID, &space.Name, &space.Description, &space.ParentID, &space.Depth, &space.Created, &space.Hash)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -154,7 +156,8 @@ func (db *DB) GetSpace(id int) (*models.Space, error) {
 
 func (db *DB) GetSpaces() ([]models.Space, error) {
 	rows, err := db.Query(
-		"SELECT id, name, description, parent_id, depth, created FROM spaces ORDER BY depth, name",
+		"SELECT s.id, s.name, s.description, s.parent_id, s.depth, s.created, "+spaceHashColumn+
+			" FROM spaces s "+spaceHashJoin+" ORDER BY s.depth, s.name",
 	)
 	if err != nil {
 		logger.Error("Failed to query spaces", zap.Error(err))
@@ -165,7 +168,7 @@ func (db *DB) GetSpaces() ([]models.Space, error) {
 	var spaces []models.Space
 	for rows.Next() {
 		var space models.Space
-		err := rows.Scan(&space.ID, &space.Name, &space.Description, &space.ParentID, &space.Depth, &space.Created)
+		err := rows.Scan(&space.ID, &space.Name, &space.Description, &space.ParentID, &space.Depth, &space.Created, &space.Hash)
 		if err != nil {
 			logger.Error("Failed to scan space", zap.Error(err))
 			return nil, fmt.Errorf("failed to scan space: %w", err)
@@ -353,10 +356,10 @@ func (db *DB) UpdateSpace(id int, name, description string, parentID *int) (*mod
 	return db.GetSpace(id)
 }
 
-func (db *DB) DeleteSpace(id int) error {
+func (db *DB) DeleteSpace(ctx context.Context, id int) error {
 	// Check if exists
 	var exists bool
-	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM spaces WHERE id = ?)", id).Scan(&exists)
+	err := db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM spaces WHERE id = ?)", id).Scan(&exists)
 	if err != nil {
 		logger.Error("Failed to check space existence for deletion", zap.Int("space_id", id), zap.Error(err))
 		return fmt.Errorf("failed to check existence: %w", err)
@@ -367,7 +370,7 @@ func (db *DB) DeleteSpace(id int) error {
 	}
 
 	// Delete (CASCADE will handle children and posts)
-	_, err = db.Exec("DELETE FROM spaces WHERE id = ?", id)
+	_, err = db.ExecContext(ctx, "DELETE FROM spaces WHERE id = ?", id)
 	if err != nil {
 		logger.Error("Failed to delete space", zap.Int("space_id", id), zap.Error(err))
 		return fmt.Errorf("failed to delete space: %w", err)
@@ -395,6 +398,17 @@ func (db *DB) GetAllSpacePostCounts() (map[int]int, error) {
 	return counts, nil
 }
 
+// GetSpacePostCount returns the number of posts directly in a space
+func (db *DB) GetSpacePostCount(spaceID int) (int, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM posts WHERE space_id = ?", spaceID).Scan(&count); err != nil {
+		logger.Error("Failed to count space posts", zap.Int("space_id", spaceID), zap.Error(err))
+		return 0, fmt.Errorf("failed to count space posts: %w", err)
+	}
+
+	return count, nil
+}
+
 func (db *DB) isDescendant(childID, parentID int) bool {
 	var actualParentID sql.NullInt64
 	err := db.QueryRow("SELECT parent_id FROM spaces WHERE id = ?", childID).Scan(&actualParentID)
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// StatsSnapshot is the size of a space recorded for a single day
+type StatsSnapshot struct {
+	SpaceID            int    `json:"space_id"`
+	Day                string `json:"day"` // YYYY-MM-DD in UTC
+	PostCount          int    `json:"post_count"`
+	RecursivePostCount int    `json:"recursive_post_count"`
+	FileCount          int64  `json:"file_count"`
+	TotalSize          int64  `json:"total_size"`
+	RecursiveFileCount int64  `json:"recursive_file_count"`
+	RecursiveTotalSize int64  `json:"recursive_total_size"`
+}
+
+// SaveStatsSnapshots stores the given snapshots, replacing any existing snapshot for the same space and day
+func (db *DB) SaveStatsSnapshots(snapshots []StatsSnapshot) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for stats snapshots", zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(
+		`INSERT INTO space_stats_snapshots
+			(space_id, day, post_count, recursive_post_count, file_count, total_size, recursive_file_count, recursive_total_size)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(space_id, day) DO UPDATE SET
+			post_count = excluded.post_count,
+			recursive_post_count = excluded.recursive_post_count,
+			file_count = excluded.file_count,
+			total_size = excluded.total_size,
+			recursive_file_count = excluded.recursive_file_count,
+			recursive_total_size = excluded.recursive_total_size`,
+	)
+	if err != nil {
+		logger.Error("Failed to prepare stats snapshot statement", zap.Error(err))
+		return fmt.Errorf("failed to prepare stats snapshot statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, snap := range snapshots {
+		if _, err := stmt.Exec(
+			snap.SpaceID, snap.Day, snap.PostCount, snap.RecursivePostCount,
+			snap.FileCount, snap.TotalSize, snap.RecursiveFileCount, snap.RecursiveTotalSize,
+		); err != nil {
+			logger.Error("Failed to store stats snapshot", zap.Int("space_id", snap.SpaceID), zap.String("day", snap.Day), zap.Error(err))
+			return fmt.Errorf("failed to store stats snapshot: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit stats snapshots", zap.Error(err))
+		return fmt.Errorf("failed to commit stats snapshots: %w", err)
+	}
+
+	return nil
+}
+
+// GetStatsSnapshots returns the snapshots of a space from the given day (inclusive), oldest first
+func (db *DB) GetStatsSnapshots(spaceID int, fromDay string) ([]StatsSnapshot, error) {
+	rows, err := db.Query(
+		`SELECT space_id, day, post_count, recursive_post_count, file_count, total_size, recursive_file_count, recursive_total_size
+		FROM space_stats_snapshots
+		WHERE space_id = ? AND day >= ?
+		ORDER BY day ASC`,
+		spaceID, fromDay,
+	)
+	if err != nil {
+		logger.Error("Failed to query stats snapshots", zap.Int("space_id", spaceID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query stats snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []StatsSnapshot{}
+	for rows.Next() {
+		var snap StatsSnapshot
+		if err := rows.Scan(
+			&snap.SpaceID, &snap.Day, &snap.PostCount, &snap.RecursivePostCount,
+			&snap.FileCount, &snap.TotalSize, &snap.RecursiveFileCount, &snap.RecursiveTotalSize,
+		); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snap)
+	}
+
+	return snapshots, rows.Err()
+}
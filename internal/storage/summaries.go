@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CachedSummary is a stored summary with a hash of the content it was produced from
+type CachedSummary struct {
+	ContentHash string
+	PostCount   int // Digests only
+	Summary     string
+	Created     int64
+}
+
+// GetPostSummary returns the cached summary of a post, if any
+func (db *DB) GetPostSummary(postID int) (*CachedSummary, bool, error) {
+	var cached CachedSummary
+	err := db.QueryRow(
+		"SELECT content_hash, summary, created FROM post_summaries WHERE post_id = ?", postID,
+	).Scan(&cached.ContentHash, &cached.Summary, &cached.Created)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		logger.Error("Failed to get post summary", zap.Int("post_id", postID), zap.Error(err))
+		return nil, false, fmt.Errorf("failed to get post summary: %w", err)
+	}
+	return &cached, true, nil
+}
+
+// SavePostSummary caches the summary of a post, replacing any previous one
+func (db *DB) SavePostSummary(postID int, contentHash, summary string) (*CachedSummary, error) {
+	cached := &CachedSummary{ContentHash: contentHash, Summary: summary, Created: time.Now().UnixMilli()}
+	_, err := db.Exec(
+		`INSERT INTO post_summaries (post_id, content_hash, summary, created) VALUES (?, ?, ?, ?)
+		ON CONFLICT(post_id) DO UPDATE SET content_hash = excluded.content_hash, summary = excluded.summary,
+			created = excluded.created`,
+		postID, cached.ContentHash, cached.Summary, cached.Created,
+	)
+	if err != nil {
+		logger.Error("Failed to save post summary", zap.Int("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("failed to save post summary: %w", err)
+	}
+	return cached, nil
+}
+
+// GetSpaceDigest returns the cached digest of a space for a week (YYYY-MM-DD of its Monday), if any
+func (db *DB) GetSpaceDigest(spaceID int, week string) (*CachedSummary, bool, error) {
+	var cached CachedSummary
+	err := db.QueryRow(
+		"SELECT content_hash, post_count, summary, created FROM space_digests WHERE space_id = ? AND week = ?",
+		spaceID, week,
+	).Scan(&cached.ContentHash, &cached.PostCount, &cached.Summary, &cached.Created)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		logger.Error("Failed to get space digest", zap.Int("space_id", spaceID), zap.String("week", week), zap.Error(err))
+		return nil, false, fmt.Errorf("failed to get space digest: %w", err)
+	}
+	return &cached, true, nil
+}
+
+// SaveSpaceDigest caches the digest of a space for a week, replacing any previous one
+func (db *DB) SaveSpaceDigest(spaceID int, week, contentHash string, postCount int, summary string) (*CachedSummary, error) {
+	cached := &CachedSummary{ContentHash: contentHash, PostCount: postCount, Summary: summary, Created: time.Now().UnixMilli()}
+	_, err := db.Exec(
+		`INSERT INTO space_digests (space_id, week, content_hash, post_count, summary, created) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(space_id, week) DO UPDATE SET content_hash = excluded.content_hash, post_count = excluded.post_count,
+			summary = excluded.summary, created = excluded.created`,
+		spaceID, week, cached.ContentHash, cached.PostCount, cached.Summary, cached.Created,
+	)
+	if err != nil {
+		logger.Error("Failed to save space digest", zap.Int("space_id", spaceID), zap.String("week", week), zap.Error(err))
+		return nil, fmt.Errorf("failed to save space digest: %w", err)
+	}
+	return cached, nil
+}
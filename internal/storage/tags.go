@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// TagCount is the number of posts carrying a tag
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// PostContentChange is the new content and tags of a post rewritten by a tag operation
+type PostContentChange struct {
+	PostID  int
+	Content string
+	Tags    []string
+}
+
+// SetPostTags replaces the tags stored for a post
+func (db *DB) SetPostTags(postID int, tags []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for post tags", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replacePostTags(tx, postID, tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post tags", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func replacePostTags(tx *sql.Tx, postID int, tags []string) error {
+	if _, err := tx.Exec("DELETE FROM post_tags WHERE post_id = ?", postID); err != nil {
+		logger.Error("Failed to clear post tags", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to clear post tags: %w", err)
+	}
+
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO post_tags (post_id, tag) VALUES (?, ?)", postID, tag); err != nil {
+			logger.Error("Failed to insert post tag", zap.Int("post_id", postID), zap.String("tag", tag), zap.Error(err))
+			return fmt.Errorf("failed to insert post tag: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RebuildPostTags re-parses every post with the given parser and replaces all stored tags
+func (db *DB) RebuildPostTags(parse func(content string) []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for tags rebuild", zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, content FROM posts")
+	if err != nil {
+		logger.Error("Failed to query posts for tags rebuild", zap.Error(err))
+		return fmt.Errorf("failed to query posts: %w", err)
+	}
+
+	parsed := make(map[int][]string)
+	for rows.Next() {
+		var id int
+		var content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		if tags := parse(content); len(tags) > 0 {
+			parsed[id] = tags
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM post_tags"); err != nil {
+		logger.Error("Failed to clear post tags", zap.Error(err))
+		return fmt.Errorf("failed to clear post tags: %w", err)
+	}
+
+	for postID, tags := range parsed {
+		for _, tag := range tags {
+			if _, err := tx.Exec("INSERT OR IGNORE INTO post_tags (post_id, tag) VALUES (?, ?)", postID, tag); err != nil {
+				logger.Error("Failed to insert post tag", zap.Int("post_id", postID), zap.String("tag", tag), zap.Error(err))
+				return fmt.Errorf("failed to insert post tag: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit tags rebuild", zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetTagCounts returns how many posts carry each tag, most used first.
+// A nil spaceIDs slice counts posts of every space.
+func (db *DB) GetTagCounts(spaceIDs []int) ([]TagCount, error) {
+	query := "SELECT t.tag, COUNT(*) FROM post_tags t"
+	var args []interface{}
+	if spaceIDs != nil {
+		placeholders := make([]string, len(spaceIDs))
+		for i, id := range spaceIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		query += fmt.Sprintf(" JOIN posts p ON p.id = t.post_id WHERE p.space_id IN (%s)", strings.Join(placeholders, ","))
+	}
+	query += " GROUP BY t.tag ORDER BY COUNT(*) DESC, t.tag ASC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		logger.Error("Failed to query tag counts", zap.Error(err))
+		return nil, fmt.Errorf("failed to query tag counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []TagCount{}
+	for rows.Next() {
+		var count TagCount
+		if err := rows.Scan(&count.Tag, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+// GetPostsByTag returns the posts carrying a tag
+func (db *DB) GetPostsByTag(tag string) ([]models.Post, error) {
+	rows, err := db.Query(
+		`SELECT p.id, p.space_id, p.content, p.created
+		FROM posts p JOIN post_tags t ON t.post_id = p.id
+		WHERE t.tag = ? ORDER BY p.id`,
+		tag,
+	)
+	if err != nil {
+		logger.Error("Failed to query posts by tag", zap.String("tag", tag), zap.Error(err))
+		return nil, fmt.Errorf("failed to query posts by tag: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.ID, &post.SpaceID, &post.Content, &post.Created); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
+
+// ApplyPostContentChanges rewrites posts and their tags and records the audit entry,
+// all in one transaction
+func (db *DB) ApplyPostContentChanges(changes []PostContentChange, entry *models.AuditEntry) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for post content changes", zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, change := range changes {
+		if _, err := tx.Exec("UPDATE posts SET content = ? WHERE id = ?", change.Content, change.PostID); err != nil {
+			logger.Error("Failed to update post content", zap.Int("post_id", change.PostID), zap.Error(err))
+			return fmt.Errorf("failed to update post content: %w", err)
+		}
+		if err := replacePostTags(tx, change.PostID, change.Tags); err != nil {
+			return err
+		}
+	}
+
+	if err := insertAuditEntry(tx, entry); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post content changes", zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
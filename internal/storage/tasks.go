@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// TaskCounts holds checklist item counts for a post or a space
+type TaskCounts struct {
+	Total int
+	Done  int
+}
+
+// SetPostTaskCounts stores the checklist item counts of a post
+func (db *DB) SetPostTaskCounts(postID, total, done int) error {
+	_, err := db.Exec(
+		`INSERT INTO post_tasks (post_id, total, done) VALUES (?, ?, ?)
+		ON CONFLICT(post_id) DO UPDATE SET total = excluded.total, done = excluded.done`,
+		postID, total, done,
+	)
+	if err != nil {
+		logger.Error("Failed to store post task counts", zap.Int("post_id", postID), zap.Error(err))
+		return fmt.Errorf("failed to store post task counts: %w", err)
+	}
+
+	return nil
+}
+
+// GetPostIDsWithoutTaskCounts returns posts whose checklist items have never been counted
+func (db *DB) GetPostIDsWithoutTaskCounts() ([]int, error) {
+	rows, err := db.Query(
+		"SELECT p.id FROM posts p LEFT JOIN post_tasks t ON t.post_id = p.id WHERE t.post_id IS NULL",
+	)
+	if err != nil {
+		logger.Error("Failed to query posts without task counts", zap.Error(err))
+		return nil, fmt.Errorf("failed to query posts without task counts: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetTaskCountsBySpace returns aggregated checklist counts for every space with tasks
+func (db *DB) GetTaskCountsBySpace() (map[int]TaskCounts, error) {
+	rows, err := db.Query(
+		`SELECT p.space_id, SUM(t.total), SUM(t.done)
+		FROM post_tasks t JOIN posts p ON p.id = t.post_id
+		WHERE t.total > 0
+		GROUP BY p.space_id`,
+	)
+	if err != nil {
+		logger.Error("Failed to query task counts by space", zap.Error(err))
+		return nil, fmt.Errorf("failed to query task counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int]TaskCounts)
+	for rows.Next() {
+		var spaceID int
+		var c TaskCounts
+		if err := rows.Scan(&spaceID, &c.Total, &c.Done); err != nil {
+			return nil, err
+		}
+		counts[spaceID] = c
+	}
+
+	return counts, rows.Err()
+}
+
+// GetSpaceTaskCounts returns aggregated checklist counts for the direct posts of a space
+func (db *DB) GetSpaceTaskCounts(spaceID int) (TaskCounts, error) {
+	var c TaskCounts
+	err := db.QueryRow(
+		`SELECT COALESCE(SUM(t.total), 0), COALESCE(SUM(t.done), 0)
+		FROM post_tasks t JOIN posts p ON p.id = t.post_id
+		WHERE p.space_id = ?`,
+		spaceID,
+	).Scan(&c.Total, &c.Done)
+	if err != nil {
+		logger.Error("Failed to get space task counts", zap.Int("space_id", spaceID), zap.Error(err))
+		return c, fmt.Errorf("failed to get space task counts: %w", err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// CreateTrashItems stores a trashed item together with the attachments that belonged to it.
+// Both the item and its children get their ID set; children are linked to the item.
+func (db *DB) CreateTrashItems(item *models.TrashItem, children []*models.TrashItem) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for trash items", zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertTrashItem(tx, item); err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		child.ParentTrashID = &item.ID
+		if err := insertTrashItem(tx, child); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit trash items", zap.Error(err))
+		return fmt.Errorf("failed to commit trash items: %w", err)
+	}
+
+	return nil
+}
+
+func insertTrashItem(tx *sql.Tx, item *models.TrashItem) error {
+	result, err := tx.Exec(
+		`INSERT INTO trash_items (item_type, item_id, space_id, parent_trash_id, label, payload, size, deleted_at, purge_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		item.ItemType, item.ItemID, item.SpaceID, item.ParentTrashID, item.Label, item.Payload, item.Size, item.DeletedAt, item.PurgeAt,
+	)
+	if err != nil {
+		logger.Error("Failed to create trash item", zap.String("item_type", item.ItemType), zap.Int("item_id", item.ItemID), zap.Error(err))
+		return fmt.Errorf("failed to create trash item: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	item.ID = int(id)
+
+	return nil
+}
+
+// GetTrashItemsDueBefore returns the trash items to be purged at or before the given time, soonest first
+func (db *DB) GetTrashItemsDueBefore(before int64) ([]models.TrashItem, error) {
+	return db.queryTrashItems("WHERE purge_at <= ? ORDER BY purge_at ASC, id ASC", before)
+}
+
+// GetTrashItem returns a single trash item; found is false when there is none
+func (db *DB) GetTrashItem(id int) (*models.TrashItem, bool, error) {
+	items, err := db.queryTrashItems("WHERE id = ?", id)
+	if err != nil || len(items) == 0 {
+		return nil, false, err
+	}
+	return &items[0], true, nil
+}
+
+// GetTrashedPostsBySpaces returns the deleted posts of the given spaces, most recently deleted
+// first, along with how many there are in total
+func (db *DB) GetTrashedPostsBySpaces(spaceIDs []int, limit, offset int) ([]models.TrashItem, int, error) {
+	if len(spaceIDs) == 0 {
+		return []models.TrashItem{}, 0, nil
+	}
+
+	placeholders := make([]string, len(spaceIDs))
+	args := []interface{}{models.TrashItemPost}
+	for i, id := range spaceIDs {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+	clause := fmt.Sprintf("WHERE item_type = ? AND parent_trash_id IS NULL AND space_id IN (%s)", strings.Join(placeholders, ","))
+
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM trash_items "+clause, args...).Scan(&total); err != nil {
+		logger.Error("Failed to count trashed posts", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to count trashed posts: %w", err)
+	}
+
+	items, err := db.queryTrashItems(clause+" ORDER BY deleted_at DESC, id DESC LIMIT ? OFFSET ?", append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// GetTrashedSpace returns the most recently trashed space with the given original ID; found is
+// false when there is none
+func (db *DB) GetTrashedSpace(spaceID int) (*models.TrashItem, bool, error) {
+	items, err := db.queryTrashItems(
+		"WHERE item_type = ? AND item_id = ? AND parent_trash_id IS NULL ORDER BY deleted_at DESC, id DESC LIMIT 1",
+		models.TrashItemSpace, spaceID,
+	)
+	if err != nil || len(items) == 0 {
+		return nil, false, err
+	}
+	return &items[0], true, nil
+}
+
+// RestorePost puts a trashed post back under its original ID, with its link previews and
+// attachments. journalDay, when set, makes it the journal entry of its space for that day, and
+// pinned, when set, pins it again as of that time.
+func (db *DB) RestorePost(post models.Post, journalDay string, pinned int64, linkPreviews []models.LinkPreview, attachments []models.Attachment) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for post restore", zap.Int("post_id", post.ID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := restorePost(tx, post, journalDay, pinned, linkPreviews, attachments); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit post restore", zap.Int("post_id", post.ID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreSpaces puts a trashed space subtree back under its original IDs, spaces parents first
+// with their access lists, then their posts. journalDays maps the restored journal posts to
+// their day and pins the pinned ones to their pin time. Access entries of users deleted since
+// are left out.
+func (db *DB) RestoreSpaces(spaces []models.Space, acls map[int][]models.SpaceACLEntry, posts []models.Post, journalDays map[int]string, pins map[int]int64, linkPreviews []models.LinkPreview, attachments []models.Attachment) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin transaction for space restore", zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, space := range spaces {
+		if _, err := tx.Exec(
+			"INSERT INTO spaces (id, name, description, parent_id, depth, created) VALUES (?, ?, ?, ?, ?, ?)",
+			space.ID, space.Name, space.Description, space.ParentID, space.Depth, space.Created,
+		); err != nil {
+			logger.Error("Failed to restore space", zap.Int("space_id", space.ID), zap.Error(err))
+			return fmt.Errorf("failed to restore space: %w", err)
+		}
+		for _, entry := range acls[space.ID] {
+			if _, err := tx.Exec(
+				"INSERT INTO space_acl (space_id, user_id, permission) SELECT ?, id, ? FROM users WHERE id = ?",
+				space.ID, entry.Permission, entry.UserID,
+			); err != nil {
+				logger.Error("Failed to restore space access entry", zap.Int("space_id", space.ID), zap.Int("user_id", entry.UserID), zap.Error(err))
+				return fmt.Errorf("failed to restore space access entry: %w", err)
+			}
+		}
+	}
+
+	previewsByPost := make(map[int][]models.LinkPreview)
+	for _, preview := range linkPreviews {
+		previewsByPost[preview.PostID] = append(previewsByPost[preview.PostID], preview)
+	}
+	attachmentsByPost := make(map[int][]models.Attachment)
+	for _, attachment := range attachments {
+		attachmentsByPost[attachment.PostID] = append(attachmentsByPost[attachment.PostID], attachment)
+	}
+
+	for _, post := range posts {
+		if err := restorePost(tx, post, journalDays[post.ID], pins[post.ID], previewsByPost[post.ID], attachmentsByPost[post.ID]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit space restore", zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func restorePost(tx *sql.Tx, post models.Post, journalDay string, pinned int64, linkPreviews []models.LinkPreview, attachments []models.Attachment) error {
+	if _, err := tx.Exec(
+		"INSERT INTO posts (id, space_id, content, created) VALUES (?, ?, ?, ?)",
+		post.ID, post.SpaceID, post.Content, post.Created,
+	); err != nil {
+		logger.Error("Failed to restore post", zap.Int("post_id", post.ID), zap.Error(err))
+		return fmt.Errorf("failed to restore post: %w", err)
+	}
+
+	if post.Source != "" && post.Source != models.PostSourceManual {
+		if _, err := tx.Exec("INSERT INTO post_sources (post_id, source) VALUES (?, ?)", post.ID, post.Source); err != nil {
+			logger.Error("Failed to restore post source", zap.Int("post_id", post.ID), zap.Error(err))
+			return fmt.Errorf("failed to restore post source: %w", err)
+		}
+	}
+
+	if journalDay != "" {
+		if _, err := tx.Exec("INSERT INTO journal_entries (space_id, day, post_id) VALUES (?, ?, ?)", post.SpaceID, journalDay, post.ID); err != nil {
+			logger.Error("Failed to restore journal entry", zap.Int("post_id", post.ID), zap.String("day", journalDay), zap.Error(err))
+			return fmt.Errorf("failed to restore journal entry: %w", err)
+		}
+	}
+
+	if pinned > 0 {
+		if _, err := tx.Exec("INSERT INTO post_pins (post_id, pinned) VALUES (?, ?)", post.ID, pinned); err != nil {
+			logger.Error("Failed to restore post pin", zap.Int("post_id", post.ID), zap.Error(err))
+			return fmt.Errorf("failed to restore post pin: %w", err)
+		}
+	}
+
+	for _, preview := range linkPreviews {
+		if _, err := tx.Exec(
+			"INSERT INTO link_previews (post_id, url, title, description, image_url, site_name) VALUES (?, ?, ?, ?, ?, ?)",
+			post.ID, preview.URL, preview.Title, preview.Description, preview.ImageURL, preview.SiteName,
+		); err != nil {
+			logger.Error("Failed to restore link preview", zap.Int("post_id", post.ID), zap.Error(err))
+			return fmt.Errorf("failed to restore link preview: %w", err)
+		}
+	}
+
+	for _, attachment := range attachments {
+		if _, err := tx.Exec(
+			"INSERT INTO attachments (id, post_id, filename, file_path, file_type, file_size) VALUES (?, ?, ?, ?, ?, ?)",
+			attachment.ID, post.ID, attachment.Filename, attachment.FilePath, attachment.FileType, attachment.FileSize,
+		); err != nil {
+			logger.Error("Failed to restore attachment", zap.Int("attachment_id", attachment.ID), zap.Error(err))
+			return fmt.Errorf("failed to restore attachment: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetTrashItemsByParent returns the attachments trashed together with a post or space
+func (db *DB) GetTrashItemsByParent(parentID int) ([]models.TrashItem, error) {
+	return db.queryTrashItems("WHERE parent_trash_id = ? ORDER BY id ASC", parentID)
+}
+
+func (db *DB) queryTrashItems(clause string, args ...interface{}) ([]models.TrashItem, error) {
+	rows, err := db.Query(
+		`SELECT id, item_type, item_id, space_id, parent_trash_id, label, payload, size, deleted_at, purge_at
+		FROM trash_items `+clause,
+		args...,
+	)
+	if err != nil {
+		logger.Error("Failed to query trash items", zap.Error(err))
+		return nil, fmt.Errorf("failed to query trash items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.TrashItem{}
+	for rows.Next() {
+		var item models.TrashItem
+		var parentID sql.NullInt64
+		err := rows.Scan(
+			&item.ID, &item.ItemType, &item.ItemID, &item.SpaceID, &parentID,
+			&item.Label, &item.Payload, &item.Size, &item.DeletedAt, &item.PurgeAt,
+		)
+		if err != nil {
+			logger.Error("Failed to scan trash item", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan trash item: %w", err)
+		}
+		if parentID.Valid {
+			id := int(parentID.Int64)
+			item.ParentTrashID = &id
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// DeleteTrashItems permanently removes trash items; attachments trashed with them are removed too
+func (db *DB) DeleteTrashItems(ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	_, err := db.Exec(
+		fmt.Sprintf("DELETE FROM trash_items WHERE id IN (%s)", strings.Join(placeholders, ",")),
+		args...,
+	)
+	if err != nil {
+		logger.Error("Failed to delete trash items", zap.Ints("ids", ids), zap.Error(err))
+		return fmt.Errorf("failed to delete trash items: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,291 @@
+package storage
+
+import (
+	"backthynk/internal/core/logger"
+	"backthynk/internal/core/models"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ProvisionUser creates the user of a provider subject on its first login, or refreshes its
+// email, name and role, and records the login
+func (db *DB) ProvisionUser(subject, email, name, role string, now int64) (*models.User, error) {
+	_, err := db.Exec(
+		`INSERT INTO users (subject, email, name, role, created, last_login) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(subject) DO UPDATE SET email = excluded.email, name = excluded.name,
+			role = excluded.role, last_login = excluded.last_login`,
+		subject, email, name, role, now, now,
+	)
+	if err != nil {
+		logger.Error("Failed to provision user", zap.String("subject", subject), zap.Error(err))
+		return nil, fmt.Errorf("failed to provision user: %w", err)
+	}
+
+	var user models.User
+	err = db.QueryRow(
+		"SELECT id, subject, email, name, role, created, last_login FROM users WHERE subject = ?",
+		subject,
+	).Scan(&user.ID, &user.Subject, &user.Email, &user.Name, &user.Role, &user.Created, &user.LastLogin)
+	if err != nil {
+		logger.Error("Failed to get provisioned user", zap.String("subject", subject), zap.Error(err))
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// GetUsers returns every provisioned user, in creation order
+func (db *DB) GetUsers() ([]models.User, error) {
+	rows, err := db.Query("SELECT id, subject, email, name, role, created, last_login FROM users ORDER BY id")
+	if err != nil {
+		logger.Error("Failed to query users", zap.Error(err))
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Subject, &user.Email, &user.Name, &user.Role, &user.Created, &user.LastLogin); err != nil {
+			logger.Error("Failed to scan user", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// CountUsers returns how many users exist
+func (db *DB) CountUsers() (int, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		logger.Error("Failed to count users", zap.Error(err))
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return count, nil
+}
+
+// CreateLocalUser creates a user signing in with a password, kept as passwordHash
+func (db *DB) CreateLocalUser(subject, name, role, passwordHash string, now int64) (*models.User, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin user creation", zap.String("subject", subject), zap.Error(err))
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"INSERT INTO users (subject, email, name, role, created, last_login) VALUES (?, '', ?, ?, ?, ?)",
+		subject, name, role, now, now,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("user already exists")
+		}
+		logger.Error("Failed to create user", zap.String("subject", subject), zap.Error(err))
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		logger.Error("Failed to get last insert ID after user creation", zap.String("subject", subject), zap.Error(err))
+		return nil, fmt.Errorf("failed to get user ID: %w", err)
+	}
+
+	_, err = tx.Exec("INSERT INTO user_passwords (user_id, hash, updated) VALUES (?, ?, ?)", id, passwordHash, now)
+	if err != nil {
+		logger.Error("Failed to store password", zap.Int64("user_id", id), zap.Error(err))
+		return nil, fmt.Errorf("failed to store password: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit user creation", zap.String("subject", subject), zap.Error(err))
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &models.User{ID: int(id), Subject: subject, Name: name, Role: role, Created: now, LastLogin: now}, nil
+}
+
+// GetUserPassword returns the user of a subject along with its password hash
+func (db *DB) GetUserPassword(subject string) (*models.User, string, error) {
+	var user models.User
+	var hash string
+	err := db.QueryRow(
+		`SELECT u.id, u.subject, u.email, u.name, u.role, u.created, u.last_login, p.hash
+		FROM users u JOIN user_passwords p ON p.user_id = u.id WHERE u.subject = ?`,
+		subject,
+	).Scan(&user.ID, &user.Subject, &user.Email, &user.Name, &user.Role, &user.Created, &user.LastLogin, &hash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", fmt.Errorf("user not found")
+		}
+		logger.Error("Failed to get user password", zap.String("subject", subject), zap.Error(err))
+		return nil, "", fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, hash, nil
+}
+
+// RecordLogin sets the last login time of a user
+func (db *DB) RecordLogin(userID int, now int64) error {
+	if _, err := db.Exec("UPDATE users SET last_login = ? WHERE id = ?", now, userID); err != nil {
+		logger.Error("Failed to record login", zap.Int("user_id", userID), zap.Error(err))
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+	return nil
+}
+
+// CreateSession records a login session of a user under the hash of its token, with the
+// device it was opened from
+func (db *DB) CreateSession(tokenHash string, userID int, userAgent, ip string, created, expires int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		logger.Error("Failed to begin session creation", zap.Int("user_id", userID), zap.Error(err))
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"INSERT INTO sessions (token_hash, user_id, created, expires) VALUES (?, ?, ?, ?)",
+		tokenHash, userID, created, expires,
+	)
+	if err != nil {
+		logger.Error("Failed to create session", zap.Int("user_id", userID), zap.Error(err))
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	_, err = tx.Exec(
+		"INSERT INTO session_devices (token_hash, user_agent, ip, last_seen) VALUES (?, ?, ?, ?)",
+		tokenHash, userAgent, ip, created,
+	)
+	if err != nil {
+		logger.Error("Failed to record session device", zap.Int("user_id", userID), zap.Error(err))
+		return fmt.Errorf("failed to record session device: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("Failed to commit session creation", zap.Int("user_id", userID), zap.Error(err))
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// sessionColumns are read by scanSession, from sessions aliased s joined to session_devices d
+const sessionColumns = "d.id, s.user_id, d.user_agent, d.ip, s.created, s.expires, d.last_seen"
+
+func scanSession(scanner interface{ Scan(...interface{}) error }, session *models.Session) error {
+	return scanner.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.Created, &session.Expires, &session.LastSeen)
+}
+
+// GetSession returns a session that has not expired at now along with its user
+func (db *DB) GetSession(tokenHash string, now int64) (*models.Session, *models.User, error) {
+	var session models.Session
+	var user models.User
+	err := db.QueryRow(
+		`SELECT `+sessionColumns+`, u.id, u.subject, u.email, u.name, u.role, u.created, u.last_login
+		FROM sessions s JOIN session_devices d ON d.token_hash = s.token_hash JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash = ? AND s.expires > ?`,
+		tokenHash, now,
+	).Scan(
+		&session.ID, &session.UserID, &session.UserAgent, &session.IP, &session.Created, &session.Expires, &session.LastSeen,
+		&user.ID, &user.Subject, &user.Email, &user.Name, &user.Role, &user.Created, &user.LastLogin,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("session not found")
+		}
+		logger.Error("Failed to get session", zap.Error(err))
+		return nil, nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return &session, &user, nil
+}
+
+// GetUserSessions returns the sessions of a user that have not expired at now, most recently
+// seen first
+func (db *DB) GetUserSessions(userID int, now int64) ([]models.Session, error) {
+	rows, err := db.Query(
+		`SELECT `+sessionColumns+` FROM sessions s JOIN session_devices d ON d.token_hash = s.token_hash
+		WHERE s.user_id = ? AND s.expires > ? ORDER BY d.last_seen DESC, d.id DESC`,
+		userID, now,
+	)
+	if err != nil {
+		logger.Error("Failed to query sessions", zap.Int("user_id", userID), zap.Error(err))
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := scanSession(rows, &session); err != nil {
+			logger.Error("Failed to scan session", zap.Int("user_id", userID), zap.Error(err))
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// TouchSession records that a session was used at now from the given device
+func (db *DB) TouchSession(id int, userAgent, ip string, now int64) error {
+	_, err := db.Exec(
+		"UPDATE session_devices SET user_agent = ?, ip = ?, last_seen = ? WHERE id = ?",
+		userAgent, ip, now, id,
+	)
+	if err != nil {
+		logger.Error("Failed to touch session", zap.Int("session_id", id), zap.Error(err))
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserSession ends one session of a user
+func (db *DB) DeleteUserSession(userID, id int) error {
+	result, err := db.Exec(
+		`DELETE FROM sessions WHERE user_id = ? AND token_hash = (SELECT token_hash FROM session_devices WHERE id = ?)`,
+		userID, id,
+	)
+	if err != nil {
+		logger.Error("Failed to delete session", zap.Int("user_id", userID), zap.Int("session_id", id), zap.Error(err))
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("session not found")
+	}
+	return nil
+}
+
+// DeleteOtherUserSessions ends every session of a user but keepID, returning how many ended
+func (db *DB) DeleteOtherUserSessions(userID, keepID int) (int, error) {
+	result, err := db.Exec(
+		`DELETE FROM sessions WHERE user_id = ? AND token_hash != (SELECT token_hash FROM session_devices WHERE id = ?)`,
+		userID, keepID,
+	)
+	if err != nil {
+		logger.Error("Failed to delete other sessions", zap.Int("user_id", userID), zap.Error(err))
+		return 0, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return int(affected), nil
+}
+
+// DeleteSession ends a session
+func (db *DB) DeleteSession(tokenHash string) error {
+	if _, err := db.Exec("DELETE FROM sessions WHERE token_hash = ?", tokenHash); err != nil {
+		logger.Error("Failed to delete session", zap.Error(err))
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredSessions removes the sessions expired at now
+func (db *DB) DeleteExpiredSessions(now int64) error {
+	if _, err := db.Exec("DELETE FROM sessions WHERE expires <= ?", now); err != nil {
+		logger.Error("Failed to delete expired sessions", zap.Error(err))
+		return fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return nil
+}
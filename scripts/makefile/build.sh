@@ -88,6 +88,10 @@ echo ""
 # Start build timer
 BUILD_START=$(date +%s)
 
+# Build info stamped into every binary (reported by --version and /api/version)
+BUILD_COMMIT=$(git -C "$PROJECT_ROOT" rev-parse --short HEAD 2>/dev/null || echo "")
+BUILD_DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+
 # Check if bundle exists
 BUNDLE_DIR="$PROJECT_ROOT/bundle"
 if [ ! -d "$BUNDLE_DIR" ]; then
@@ -196,50 +200,15 @@ for platform_config in "${PLATFORMS_TO_BUILD[@]}"; do
     cp -r "$BUNDLE_DIR" "$BUNDLE_COPY"
     cp "$PROJECT_ROOT/scripts/.config.json" "$CONFIG_COPY"
 
-    # Backup existing init_prod.go if it exists
-    INIT_PROD_FILE="$PROJECT_ROOT/cmd/server/init_prod.go"
-    INIT_PROD_BACKUP="$PROJECT_ROOT/cmd/server/init_prod.go.bak"
-    if [ -f "$INIT_PROD_FILE" ]; then
-        mv "$INIT_PROD_FILE" "$INIT_PROD_BACKUP"
-    fi
-
-    # Create temporary init_prod.go with embed directive
-    cat > "$INIT_PROD_FILE" << 'EMBED_EOF'
-//go:build production
-// +build production
-
-package main
-
-import (
-	"backthynk/internal/embedded"
-	"embed"
-)
-
-//go:embed all:bundle
-var bundleFS embed.FS
-
-//go:embed .config.json
-var configJSON []byte
-
-func init() {
-	embedded.SetBundleFS(bundleFS)
-	embedded.SetConfigJSON(configJSON)
-}
-EMBED_EOF
-
+    # cmd/server/init_prod.go embeds both copies under the production build tag
     echo -e "${YELLOW}Compiling Go binary with embedded assets...${NC}"
     CGO_ENABLED=1 go build \
-        -ldflags="-X main.Version=$APP_VERSION -s -w" \
+        -trimpath \
+        -ldflags="-X main.Version=$APP_VERSION -X main.Commit=$BUILD_COMMIT -X main.BuildDate=$BUILD_DATE -s -w" \
         -tags production \
         -o "$OUTPUT_BINARY" \
         ./cmd/server
 
-    # Restore original init_prod.go or remove temporary one
-    if [ -f "$INIT_PROD_BACKUP" ]; then
-        mv "$INIT_PROD_BACKUP" "$INIT_PROD_FILE"
-    else
-        rm -f "$INIT_PROD_FILE"
-    fi
     rm -rf "$BUNDLE_COPY"
     rm -f "$CONFIG_COPY"
 
@@ -266,6 +235,7 @@ printf "${GREEN}Total build time:${NC}        %02d:%02d\n" $((BUILD_TIME / 60))
 echo ""
 echo -e "${BOLD}${GREEN}✓ Build completed successfully!${NC}"
 echo -e "${GRAY}  • Binaries include embedded bundle assets${NC}"
+echo -e "${GRAY}  • Run a binary with --version to check its build info${NC}"
 echo -e "${GRAY}  • Production-optimized with brotli+gzip compression${NC}"
 echo -e "${GRAY}  • Built ${#PLATFORMS_TO_BUILD[@]} platform(s)${NC}"
 echo ""
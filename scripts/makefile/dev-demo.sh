@@ -0,0 +1,8 @@
+#!/bin/bash
+
+# Development server on seeded demo data - runs go run with --dev
+source "$(dirname "$0")/../common/load-config.sh"
+
+echo -e "${BLUE}▶${NC} Starting server in dev mode on demo data..."
+echo -e "${GRAY}  (throwaway database, live reload of web/ changes)${NC}"
+go run ./cmd/server --dev
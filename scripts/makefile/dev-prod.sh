@@ -11,4 +11,4 @@ fi
 
 echo -e "${BLUE}▶${NC} Starting server with bundled assets (pre-production mode)..."
 echo -e "${GRAY}  (using go run with bundle folder)${NC}"
-env APP_ENV=pre-production go run ./cmd/server/main.go
+env APP_ENV=pre-production go run ./cmd/server
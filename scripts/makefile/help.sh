@@ -23,6 +23,7 @@ echo -e ""
 echo -e "  ${YELLOW}Run:${NC}"
 echo -e "    ${GREEN}dev${NC}                      Run server in development mode (go run, no build)"
 echo -e "    ${GREEN}dev-prod${NC}                 Run server with production assets (go run + production env)"
+echo -e "    ${GREEN}dev-demo${NC}                 Run server with --dev (demo data, live reload, verbose logs)"
 echo -e "    ${GREEN}run${NC}                      Alias for 'dev'"
 echo ""
 echo -e "  ${YELLOW}Clean:${NC}"
@@ -26,7 +26,12 @@ async function loadSettingsInternal() {
     try {
         const response = await fetch('/api/settings');
         if (response.ok) {
-            return await response.json();
+            const settings = await response.json();
+            // Format dates with the locale negotiated by the server
+            if (settings.requestLocale) {
+                window.AppConstants.LOCALE_SETTINGS.default = settings.requestLocale;
+            }
+            return settings;
         } else {
             // Use defaults if settings can't be loaded
             return { ...window.AppConstants.DEFAULT_SETTINGS };
@@ -59,6 +64,11 @@ async function apiRequest(endpoint, options = {}) {
         ...options
     });
 
+    // The session ended while sign in is required, sign in again
+    if (response.status === 401) {
+        window.location.href = '/auth/login';
+    }
+
     if (!response.ok) {
         const error = await response.text();
         throw new Error(error);
@@ -102,8 +112,9 @@ async function fetchSpaces(skipRender = false) {
                 // This is a space URL, trigger router handling
                 router.handleRoute(window.location.pathname, false);
             } else if (router.checkCachedSpaceRedirect) {
-                // On root path, check for cached space redirect
-                if (!router.checkCachedSpaceRedirect()) {
+                // On root path, check for the landing space, missing preferences fall back to the cached space
+                const prefs = await fetchPreferences().catch(() => null);
+                if (!router.checkCachedSpaceRedirect(prefs)) {
                     // No redirect happened, show all spaces
                     await deselectSpace();
                 }
@@ -177,7 +188,22 @@ async function updateSpace(spaceId, name, description, parentId) {
     }
 }
 
-async function fetchPosts(spaceId, limit = window.AppConstants.UI_CONFIG.defaultPostLimit, offset = window.AppConstants.UI_CONFIG.defaultOffset, withMeta = false, recursive = false) {
+// Print views are standalone pages, opened in a new tab for the browser print dialog
+function openSpacePrintView(spaceId, recursive = false) {
+    window.open(`/api/spaces/${spaceId}/print${recursive ? '?recursive=true' : ''}`, '_blank');
+}
+
+function openPostPrintView(postId) {
+    window.open(`/api/posts/${postId}/print`, '_blank');
+}
+
+// Best matches first, recently active spaces rank higher among equal matches
+async function searchSpaces(query, limit = 10) {
+    const params = new URLSearchParams({ q: query, limit });
+    return apiRequest(`/spaces/search?${params}`);
+}
+
+async function fetchPosts(spaceId, limit = window.AppConstants.UI_CONFIG.defaultPostLimit, offset = window.AppConstants.UI_CONFIG.defaultOffset, withMeta = false, recursive = false, source = '', filterId = null, sort = '') {
     try {
         const params = new URLSearchParams({
             limit: limit.toString(),
@@ -192,6 +218,20 @@ async function fetchPosts(spaceId, limit = window.AppConstants.UI_CONFIG.default
             params.set('recursive', 'true');
         }
 
+        if (source) {
+            params.set('source', source);
+        }
+
+        // Saved filters carry their own space scope, spaceId is ignored by the server
+        if (filterId) {
+            params.set('filter_id', filterId.toString());
+        }
+
+        // Without a sort the space lists in its default order
+        if (sort) {
+            params.set('sort', sort);
+        }
+
         const response = await apiRequest(`/spaces/${spaceId}/posts?${params.toString()}`);
         return response || { posts: [], has_more: false };
     } catch (error) {
@@ -201,6 +241,235 @@ async function fetchPosts(spaceId, limit = window.AppConstants.UI_CONFIG.default
 }
 
 
+// Fetch the journal entry of a space for a day (YYYY-MM-DD), starting it when missing
+async function fetchJournalEntry(spaceId, date) {
+    return apiRequest(`/spaces/${spaceId}/journal/${date}`);
+}
+
+async function searchPosts(query, mode = 'keyword', spaceId = null, recursive = false, limit = null) {
+    const params = new URLSearchParams({ q: query, mode });
+    if (spaceId) {
+        params.append('space_id', spaceId);
+        params.append('recursive', recursive.toString());
+    }
+    if (limit) {
+        params.append('limit', limit);
+    }
+    return apiRequest(`/search?${params.toString()}`);
+}
+
+// Quick search of a space and its descendants for the sidebar, matching word prefixes
+async function quickSearchSpace(spaceId, query, limit = null) {
+    const params = new URLSearchParams({ q: query });
+    if (limit) params.set('limit', limit);
+    return apiRequest(`/spaces/${spaceId}/search?${params.toString()}`);
+}
+
+async function fetchPostSummary(postId) {
+    return apiRequest(`/posts/${postId}/summary`);
+}
+
+async function fetchSpaceDigest(spaceId, week = null) {
+    const query = week ? `?week=${week}` : '';
+    return apiRequest(`/spaces/${spaceId}/digest${query}`);
+}
+
+async function fetchIngestTokens(spaceId) {
+    return apiRequest(`/spaces/${spaceId}/ingest-tokens`);
+}
+
+// The full token is only returned here; it cannot be read again later
+async function createIngestToken(spaceId, name, rateLimit = null) {
+    const payload = { name };
+    if (rateLimit) {
+        payload.rate_limit = rateLimit;
+    }
+    return apiRequest(`/spaces/${spaceId}/ingest-tokens`, {
+        method: 'POST',
+        body: JSON.stringify(payload)
+    });
+}
+
+async function revokeIngestToken(tokenId) {
+    return apiRequest(`/ingest-tokens/${tokenId}`, {
+        method: 'DELETE'
+    });
+}
+
+async function fetchIngestActivity(tokenId, limit = 50) {
+    return apiRequest(`/ingest-tokens/${tokenId}/activity?limit=${limit}`);
+}
+
+async function fetchFeatureFlags() {
+    return apiRequest('/admin/flags');
+}
+
+// Overrides are kept across restarts until cleared; rollout 0 turns the flag on for every space
+async function setFeatureFlag(name, enabled, rollout = 0) {
+    return apiRequest(`/admin/flags/${encodeURIComponent(name)}`, {
+        method: 'PUT',
+        body: JSON.stringify({ enabled, rollout })
+    });
+}
+
+async function clearFeatureFlag(name) {
+    return apiRequest(`/admin/flags/${encodeURIComponent(name)}`, {
+        method: 'DELETE'
+    });
+}
+
+async function fetchShareLinks(spaceId) {
+    return apiRequest(`/spaces/${spaceId}/share-links`);
+}
+
+// The full link is only returned here; it cannot be read again later
+async function createShareLink(spaceId, name, expiresInHours = null, rateLimit = null) {
+    const payload = { name };
+    if (expiresInHours) {
+        payload.expires_in_hours = expiresInHours;
+    }
+    if (rateLimit) {
+        payload.rate_limit = rateLimit;
+    }
+    return apiRequest(`/spaces/${spaceId}/share-links`, {
+        method: 'POST',
+        body: JSON.stringify(payload)
+    });
+}
+
+async function revokeShareLink(linkId) {
+    return apiRequest(`/share-links/${linkId}`, {
+        method: 'DELETE'
+    });
+}
+
+async function fetchShareLinkContributions(linkId) {
+    return apiRequest(`/share-links/${linkId}/contributions`);
+}
+
+// Pass the next_before_id of a response to load the following page
+async function fetchNotifications(unreadOnly = false, beforeId = null, limit = 50) {
+    const params = new URLSearchParams({ limit });
+    if (unreadOnly) {
+        params.set('unread', 'true');
+    }
+    if (beforeId) {
+        params.set('before_id', beforeId);
+    }
+    return apiRequest(`/notifications?${params}`);
+}
+
+async function markNotificationRead(notificationId) {
+    return apiRequest(`/notifications/${notificationId}/read`, {
+        method: 'POST'
+    });
+}
+
+async function markAllNotificationsRead(kind = '') {
+    const query = kind ? `?kind=${encodeURIComponent(kind)}` : '';
+    return apiRequest(`/notifications/read-all${query}`, {
+        method: 'POST'
+    });
+}
+
+async function fetchMemoryReport() {
+    return apiRequest('/admin/memory');
+}
+
+async function fetchStorageTiers(spaceId, recursive = false) {
+    return apiRequest(`/spaces/${spaceId}/storage-tiers?recursive=${recursive}`);
+}
+
+async function fetchColdStorageStatus() {
+    return apiRequest('/admin/cold-storage');
+}
+
+async function runColdStorage() {
+    return apiRequest('/admin/cold-storage/run', {
+        method: 'POST'
+    });
+}
+
+// Recently deleted posts of a space and its descendants
+async function fetchSpaceTrash(spaceId, limit = 20, offset = 0) {
+    return apiRequest(`/spaces/${spaceId}/trash?limit=${limit}&offset=${offset}`);
+}
+
+async function restoreTrashItem(trashId) {
+    return apiRequest(`/trash/${trashId}/restore`, {
+        method: 'POST'
+    });
+}
+
+// Automation rules: { name, enabled, trigger, conditions, actions }, see the rules feature
+async function fetchRules() {
+    return apiRequest('/rules');
+}
+
+async function createRule(rule) {
+    return apiRequest('/rules', {
+        method: 'POST',
+        body: JSON.stringify(rule)
+    });
+}
+
+async function updateRule(ruleId, rule) {
+    return apiRequest(`/rules/${ruleId}`, {
+        method: 'PUT',
+        body: JSON.stringify(rule)
+    });
+}
+
+async function deleteRule(ruleId) {
+    return apiRequest(`/rules/${ruleId}`, {
+        method: 'DELETE'
+    });
+}
+
+// Execution log of one rule, or of every rule when ruleId is omitted
+async function fetchRuleExecutions(ruleId = null, limit = 50) {
+    const path = ruleId ? `/rules/${ruleId}/executions` : '/rules/executions';
+    return apiRequest(`${path}?limit=${limit}`);
+}
+
+// Dry run on a post: a saved rule by ID, or an unsaved rule definition
+async function testRule(postId, ruleId = null, rule = null) {
+    const path = ruleId ? `/rules/${ruleId}/test` : '/rules/test';
+    return apiRequest(path, {
+        method: 'POST',
+        body: JSON.stringify({ post_id: postId, rule: rule || undefined })
+    });
+}
+
+async function fetchResultCacheStats() {
+    return apiRequest('/admin/result-cache');
+}
+
+async function invalidateResultCache() {
+    return apiRequest('/admin/result-cache/invalidate', {
+        method: 'POST'
+    });
+}
+
+async function fetchSpaceCacheStats() {
+    return apiRequest('/admin/space-cache');
+}
+
+async function reconcileSpaceCache() {
+    return apiRequest('/admin/space-cache/reconcile', {
+        method: 'POST'
+    });
+}
+
+// Diagnostics archive to attach to a bug report, returned as a Blob; apiRequest expects JSON
+async function downloadSupportBundle() {
+    const response = await fetch('/api/admin/support-bundle', { method: 'POST' });
+    if (!response.ok) {
+        throw new Error(await response.text());
+    }
+    return response.blob();
+}
+
 async function fetchSpaceStats(spaceId, recursive = false) {
     try {
         // Check if detailed stats feature is enabled
@@ -227,6 +496,160 @@ async function fetchSpaceStats(spaceId, recursive = false) {
     }
 }
 
+async function fetchSpaceDownloadStats(spaceId, recursive = false, limit = 10) {
+    try {
+        const settings = await loadAppSettings();
+        if (!settings.fileStatsEnabled) {
+            return { total_downloads: 0, top_files: [] };
+        }
+
+        const params = new URLSearchParams({
+            recursive: recursive.toString(),
+            limit: limit.toString()
+        });
+
+        const response = await apiRequest(`/spaces/${spaceId}/stats/downloads?${params.toString()}`);
+
+        return {
+            total_downloads: response.total_downloads || 0,
+            top_files: response.top_files || []
+        };
+    } catch (error) {
+        console.error('Failed to fetch space download stats:', error);
+        return { total_downloads: 0, top_files: [] };
+    }
+}
+
+// Vocabulary of a space: unique words, new words and average post length per month
+async function fetchSpaceLanguageStats(spaceId, recursive = false, months = 12) {
+    const params = new URLSearchParams({
+        recursive: recursive.toString(),
+        months: months.toString()
+    });
+    return apiRequest(`/spaces/${spaceId}/stats/language?${params.toString()}`);
+}
+
+// Spaces, posts and tombstones changed since a unix timestamp in milliseconds; pass the
+// returned until as the next since to continue an incremental backup
+async function fetchExportDelta(since = 0) {
+    return apiRequest(`/export/delta?since=${since}`);
+}
+
+async function fetchSpaceLimits(spaceId) {
+    try {
+        return await apiRequest(`/limits?space_id=${spaceId}`);
+    } catch (error) {
+        console.error('Failed to fetch space limits:', error);
+        return null;
+    }
+}
+
+async function fetchSpaceFields(spaceId) {
+    try {
+        const response = await apiRequest(`/spaces/${spaceId}/fields`);
+        return response.fields || [];
+    } catch (error) {
+        console.error('Failed to fetch space fields:', error);
+        return [];
+    }
+}
+
+async function fetchSpaceSettings(spaceId) {
+    try {
+        return await apiRequest(`/spaces/${spaceId}/settings`);
+    } catch (error) {
+        console.error('Failed to fetch space settings:', error);
+        return null;
+    }
+}
+
+// Set the default listing order of a space: created_desc, created_asc or updated_desc
+async function updateSpaceSettings(spaceId, settings) {
+    return apiRequest(`/spaces/${spaceId}/settings`, {
+        method: 'PUT',
+        body: JSON.stringify(settings)
+    });
+}
+
+async function updatePostFields(postId, fields) {
+    try {
+        return await apiRequest(`/posts/${postId}/fields`, {
+            method: 'PUT',
+            body: JSON.stringify({ fields: fields })
+        });
+    } catch (error) {
+        console.error('Failed to update post fields:', error);
+        throw error;
+    }
+}
+
+async function fetchSpaceSnapshots(spaceId) {
+    try {
+        return await apiRequest(`/spaces/${spaceId}/snapshots`) || [];
+    } catch (error) {
+        console.error('Failed to fetch space snapshots:', error);
+        return [];
+    }
+}
+
+async function createSpaceSnapshot(spaceId, name) {
+    try {
+        return await apiRequest(`/spaces/${spaceId}/snapshots`, {
+            method: 'POST',
+            body: JSON.stringify({ name: name })
+        });
+    } catch (error) {
+        console.error('Failed to create space snapshot:', error);
+        throw error;
+    }
+}
+
+async function restoreSnapshot(snapshotId, mode, options = {}) {
+    try {
+        const body = { mode: mode };
+        if (options.name) body.name = options.name;
+        if (options.parentId) body.parent_id = options.parentId;
+
+        return await apiRequest(`/snapshots/${snapshotId}/restore`, {
+            method: 'POST',
+            body: JSON.stringify(body)
+        });
+    } catch (error) {
+        console.error('Failed to restore snapshot:', error);
+        throw error;
+    }
+}
+
+async function deleteSnapshot(snapshotId) {
+    try {
+        await apiRequest(`/snapshots/${snapshotId}`, { method: 'DELETE' });
+    } catch (error) {
+        console.error('Failed to delete snapshot:', error);
+        throw error;
+    }
+}
+
+async function fetchSnapshotSchedule(spaceId) {
+    try {
+        return await apiRequest(`/spaces/${spaceId}/snapshot-schedule`);
+    } catch (error) {
+        console.error('Failed to fetch snapshot schedule:', error);
+        return null;
+    }
+}
+
+async function updateSnapshotSchedule(spaceId, intervalHours, keep = 0) {
+    try {
+        return await apiRequest(`/spaces/${spaceId}/snapshot-schedule`, {
+            method: 'PUT',
+            body: JSON.stringify({ interval_hours: intervalHours, keep: keep })
+        });
+    } catch (error) {
+        console.error('Failed to update snapshot schedule:', error);
+        throw error;
+    }
+}
+
 async function createPost(spaceId, content, options = {}) {
     try {
         const payload = {
@@ -243,6 +666,14 @@ async function createPost(spaceId, content, options = {}) {
             payload.custom_timestamp = options.customTimestamp;
         }
 
+        if (options.fields && Object.keys(options.fields).length > 0) {
+            payload.fields = options.fields;
+        }
+
+        if (options.crosspostSpaceIds && options.crosspostSpaceIds.length > 0) {
+            payload.crosspost_space_ids = options.crosspostSpaceIds;
+        }
+
         return await apiRequest('/posts', {
             method: 'POST',
             body: JSON.stringify(payload)
@@ -276,6 +707,30 @@ async function movePost(postId, newSpaceId) {
     }
 }
 
+// Make a post also appear in another space, which does not count it in its stats
+async function crosspostPost(postId, spaceId) {
+    try {
+        return await apiRequest(`/posts/${postId}/crossposts`, {
+            method: 'POST',
+            body: JSON.stringify({ space_id: spaceId })
+        });
+    } catch (error) {
+        console.error('Failed to cross-post:', error);
+        throw error;
+    }
+}
+
+async function removeCrosspost(postId, spaceId) {
+    try {
+        await apiRequest(`/posts/${postId}/crossposts/${spaceId}`, {
+            method: 'DELETE'
+        });
+    } catch (error) {
+        console.error('Failed to remove cross-post:', error);
+        throw error;
+    }
+}
+
 async function deleteSpaceApi(spaceId) {
     try {
         await apiRequest(`/spaces/${spaceId}`, {
@@ -287,14 +742,34 @@ async function deleteSpaceApi(spaceId) {
     }
 }
 
-async function uploadFile(postId, file) {
+// onProgress, when given, receives the server-side progress of the upload
+// ({stage, bytes_received, total_bytes, ...}) streamed by the live feed
+async function uploadFile(postId, file, onProgress) {
+    let progressSource = null;
     try {
         const formData = new FormData();
         formData.append('post_id', postId);
         formData.append('file', file);
 
+        const headers = {};
+        if (onProgress && window.EventSource) {
+            const sessionId = `upload-${Date.now()}-${Math.random().toString(36).slice(2, 10)}`;
+            headers['X-Upload-Session'] = sessionId;
+            progressSource = new EventSource(`/api/uploads/${sessionId}/events`);
+            progressSource.addEventListener('upload.progress', (event) => {
+                const progress = JSON.parse(event.data);
+                onProgress(progress);
+                if (progress.stage === 'done' || progress.stage === 'failed') {
+                    progressSource.close();
+                }
+            });
+            // Live feed disabled or stream ended: stop retrying
+            progressSource.onerror = () => progressSource.close();
+        }
+
         const response = await fetch('/api/upload', {
             method: 'POST',
+            headers: headers,
             body: formData
         });
 
@@ -306,10 +781,141 @@ async function uploadFile(postId, file) {
     } catch (error) {
         console.error('Failed to load file:', error);
         throw error;
+    } finally {
+        if (progressSource) {
+            // Let the final state arrive before closing
+            setTimeout(() => progressSource.close(), 1000);
+        }
     }
 }
 
-async function fetchActivityData(spaceId, recursive, period) {
+// Upload a new version of an attachment, the replaced file stays listed in its versions
+async function uploadFileVersion(attachmentId, file) {
+    try {
+        const formData = new FormData();
+        formData.append('file', file);
+
+        const response = await fetch(`/api/files/${attachmentId}/versions`, {
+            method: 'POST',
+            body: formData
+        });
+
+        if (!response.ok) {
+            throw new Error(await response.text());
+        }
+
+        return response.json();
+    } catch (error) {
+        console.error('Failed to upload file version:', error);
+        throw error;
+    }
+}
+
+async function fetchFileVersions(attachmentId) {
+    return apiRequest(`/files/${attachmentId}/versions`);
+}
+
+// Mirror a directory of the server into spaces; { path, parent_id, include, exclude, dry_run }
+async function importDirectory(request) {
+    return apiRequest('/admin/import/directory', {
+        method: 'POST',
+        body: JSON.stringify(request)
+    });
+}
+
+// Mint an expiring link to an attachment file, ttl in seconds, for embedding elsewhere
+async function fetchSignedFileUrl(attachmentId, ttl = 3600) {
+    const signed = await apiRequest(`/files/${attachmentId}/signed-url?ttl=${ttl}`);
+    return { ...signed, url: window.location.origin + signed.url };
+}
+
+// Whether the posts of a space are sent to the publishing webhook
+async function fetchSpacePublishing(spaceId) {
+    return apiRequest(`/spaces/${spaceId}/publish`);
+}
+
+async function setSpacePublishing(spaceId, published) {
+    return apiRequest(`/spaces/${spaceId}/publish`, {
+        method: 'PUT',
+        body: JSON.stringify({ published: published })
+    });
+}
+
+// Pinned posts shown above the feed of a space, in board order
+async function fetchSpaceBoard(spaceId) {
+    return apiRequest(`/spaces/${spaceId}/board`);
+}
+
+async function reorderSpaceBoard(spaceId, postIds) {
+    return apiRequest(`/spaces/${spaceId}/board`, {
+        method: 'PUT',
+        body: JSON.stringify({ post_ids: postIds })
+    });
+}
+
+async function pinPostToBoard(postId) {
+    return apiRequest(`/posts/${postId}/board`, { method: 'PUT' });
+}
+
+async function unpinPostFromBoard(postId) {
+    return apiRequest(`/posts/${postId}/board`, { method: 'DELETE' });
+}
+
+// User signed in through single sign-on
+async function fetchCurrentUser() {
+    return apiRequest('/auth/me');
+}
+
+// Active sessions of the current user, and their revocation
+async function fetchSessions() {
+    return apiRequest('/sessions');
+}
+
+async function revokeSession(sessionId) {
+    return apiRequest(`/sessions/${sessionId}`, { method: 'DELETE' });
+}
+
+async function revokeOtherSessions() {
+    return apiRequest('/sessions/others', { method: 'DELETE' });
+}
+
+// Access of every role to each route, for admins
+async function fetchAuthzMatrix() {
+    return apiRequest('/admin/authz-matrix');
+}
+
+// Background jobs budget with running, waiting and recent jobs, for admins
+async function fetchJobs() {
+    return apiRequest('/admin/jobs');
+}
+
+async function logout() {
+    await fetch('/auth/logout', { method: 'POST' });
+    window.location.href = '/';
+}
+
+// Ask whether a file would be accepted before uploading it; target is { post_id } or { space_id }
+async function validateUpload(filename, size, target) {
+    return apiRequest('/upload/validate', {
+        method: 'POST',
+        body: JSON.stringify({ filename: filename, size: size, ...target })
+    });
+}
+
+// Let the server download a remote file and attach it to a post
+async function attachFileFromUrl(postId, url) {
+    try {
+        return await apiRequest(`/posts/${postId}/attach-url`, {
+            method: 'POST',
+            body: JSON.stringify({ url: url })
+        });
+    } catch (error) {
+        console.error('Failed to attach remote file:', error);
+        throw error;
+    }
+}
+
+async function fetchActivityData(spaceId, recursive, period, breakdownTop = 0) {
     try {
         const settings = window.currentSettings || await loadAppSettings();
         const periodMonths = settings.activityPeriodMonths || 4;
@@ -320,6 +926,12 @@ async function fetchActivityData(spaceId, recursive, period) {
             period_months: periodMonths.toString()
         });
 
+        // Per-space contributions are only returned for the global heatmap
+        if (breakdownTop > 0) {
+            params.set('breakdown', 'true');
+            params.set('top', breakdownTop.toString());
+        }
+
         const response = await fetch(`/api/activity/${spaceId}?${params}`, {
             headers: {
                 'Content-Type': 'application/json'
@@ -337,6 +949,18 @@ async function fetchActivityData(spaceId, recursive, period) {
     }
 }
 
+// Periods count back from the current one: 0 is the current period, 1 the previous one
+async function fetchActivityComparison(spaceId, recursive = false, periodA = 0, periodB = 1) {
+    const settings = window.currentSettings || await loadAppSettings();
+    const params = new URLSearchParams({
+        recursive: recursive.toString(),
+        period_a: periodA.toString(),
+        period_b: periodB.toString(),
+        period_months: (settings.activityPeriodMonths || 4).toString()
+    });
+    return apiRequest(`/spaces/${spaceId}/activity/compare?${params}`);
+}
+
 async function fetchLinkPreview(url) {
     try {
         const response = await fetch('/api/link-preview', {
@@ -386,3 +1010,122 @@ async function saveSettings(newSettings) {
     }
 }
 
+
+// Shifts the creation time of a selection of posts by deltaMs, or spreads them over a range.
+// selection holds space_id, recursive, post_ids, start_date, end_date, source and tags.
+async function bulkRetimePosts(selection, { deltaMs = 0, rangeStart = 0, rangeEnd = 0 } = {}) {
+    return apiRequest('/posts/bulk-retime', {
+        method: 'POST',
+        body: JSON.stringify({ ...selection, delta_ms: deltaMs, range_start: rangeStart, range_end: rangeEnd })
+    });
+}
+
+// Actions offered by the server, with their parameters, for command palettes
+async function fetchCommands() {
+    return apiRequest('/ui/commands');
+}
+
+// Outcome of the data integrity self-check run at startup
+async function fetchStartupReport() {
+    return apiRequest('/admin/startup-report');
+}
+
+// Landing view of the signed in user, or of the instance, with the scope it comes from
+async function fetchPreferences() {
+    return apiRequest('/preferences');
+}
+
+// Sets the landing view, landingSpaceId is only given for the space view
+async function updatePreferences(landingView, landingSpaceId = null) {
+    return apiRequest('/preferences', {
+        method: 'PUT',
+        body: JSON.stringify({ landing_view: landingView, landing_space_id: landingSpaceId })
+    });
+}
+
+// Drops the user's own preferences, falling back to the instance ones
+async function resetPreferences() {
+    return apiRequest('/preferences', { method: 'DELETE' });
+}
+
+// Sets the landing view of users who did not choose their own
+async function updateInstancePreferences(landingView, landingSpaceId = null) {
+    return apiRequest('/admin/preferences', {
+        method: 'PUT',
+        body: JSON.stringify({ landing_view: landingView, landing_space_id: landingSpaceId })
+    });
+}
+
+// Replaces the content of a post; linkPreviews replace its previews unless null
+async function updatePost(postId, content, linkPreviews = null) {
+    const body = { content };
+    if (linkPreviews !== null) {
+        body.link_previews = linkPreviews;
+    }
+    return apiRequest(`/posts/${postId}`, {
+        method: 'PUT',
+        body: JSON.stringify(body)
+    });
+}
+
+// Content and link previews of a post before each of its edits, most recent first
+async function fetchPostRevisions(postId) {
+    return apiRequest(`/posts/${postId}/revisions`);
+}
+
+// Record keeping setting of a space, immutable copies of every prior post version
+async function fetchRecordKeeping(spaceId) {
+    return apiRequest(`/spaces/${spaceId}/record-keeping`);
+}
+
+// Turns record keeping of a space on or off; a retention of 0 uses the default one
+async function updateRecordKeeping(spaceId, enabled, retentionDays = 0) {
+    return apiRequest(`/spaces/${spaceId}/record-keeping`, {
+        method: 'PUT',
+        body: JSON.stringify({ enabled, retention_days: retentionDays })
+    });
+}
+
+// Records taken of a post, most recent first
+async function fetchPostRecords(postId) {
+    return apiRequest(`/posts/${postId}/records`);
+}
+
+// Checks the hash chain of the records of a space
+async function verifySpaceRecords(spaceId) {
+    return apiRequest(`/spaces/${spaceId}/records/verify`);
+}
+
+// Spaces due for their periodic review, with what changed since the last one
+async function fetchDueReviews() {
+    return apiRequest('/reviews/due');
+}
+
+// Schedules a space for review every intervalDays days; 0 uses the default, quarterly one
+async function scheduleSpaceReview(spaceId, intervalDays = 0) {
+    return apiRequest(`/spaces/${spaceId}/review`, {
+        method: 'PUT',
+        body: JSON.stringify({ interval_days: intervalDays })
+    });
+}
+
+async function unscheduleSpaceReview(spaceId) {
+    return apiRequest(`/spaces/${spaceId}/review`, { method: 'DELETE' });
+}
+
+async function completeSpaceReview(spaceId) {
+    return apiRequest(`/spaces/${spaceId}/review/complete`, { method: 'POST' });
+}
+
+// Downloads the whole workspace as an archive to import on another machine
+function downloadWorkspaceExport() {
+    window.location.href = '/api/admin/workspace/export';
+}
+
+// Runs a GraphQL query, resolving to {data, errors}; needs the GraphQL feature enabled
+async function graphqlQuery(query, variables = {}) {
+    return apiRequest('/graphql', {
+        method: 'POST',
+        body: JSON.stringify({ query, variables })
+    });
+}
@@ -526,6 +526,12 @@ document.addEventListener('DOMContentLoaded', function() {
                 link_previews: getCurrentModalLinkPreviews ? getCurrentModalLinkPreviews() : []
             };
 
+            // Include the custom field values of the space schema
+            const fields = collectModalCustomFields();
+            if (Object.keys(fields).length > 0) {
+                postData.fields = fields;
+            }
+
             // Check if retroactive posting is enabled and add custom timestamp
             const dateTimeInput = document.getElementById('modal-post-datetime');
             if (dateTimeInput && dateTimeInput.value) {
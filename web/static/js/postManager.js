@@ -210,12 +210,34 @@ function createPostElement(post) {
         `<span class="text-xs font-medium text-blue-600 dark:text-blue-400 bg-blue-50 dark:bg-blue-900/20 px-2 py-1 rounded cursor-pointer hover:bg-blue-100 dark:hover:bg-blue-900/30 transition-colors" onclick="navigateToSpaceFromPost(${post.space_id})">${spaceBreadcrumb}</span>` :
         '';
 
+    // Flag posts that came from an automated ingestion path
+    const sourceBadge = post.source && post.source !== 'manual' ?
+        `<span class="text-xs font-medium text-gray-600 dark:text-gray-300 bg-gray-100 dark:bg-gray-700 px-2 py-1 rounded" title="Source">${escapeHtml(post.source)}</span>` :
+        '';
+
+    // Name given by the author of a post written through a share link
+    const authorBadge = post.author ?
+        `<span class="text-xs font-medium text-indigo-700 dark:text-indigo-300 bg-indigo-50 dark:bg-indigo-900/20 px-2 py-1 rounded" title="Shared link author">by ${escapeHtml(post.author)}</span>` :
+        '';
+
+    const journalBadge = post.type === 'journal' ?
+        `<span class="text-xs font-medium text-amber-700 dark:text-amber-300 bg-amber-50 dark:bg-amber-900/20 px-2 py-1 rounded" title="Journal entry">journal</span>` :
+        '';
+
+    // Custom field values set through the space schema
+    const fieldBadges = post.fields ? Object.entries(post.fields).map(([name, value]) =>
+        `<span class="text-xs font-medium text-emerald-700 dark:text-emerald-300 bg-emerald-50 dark:bg-emerald-900/20 px-2 py-1 rounded" title="${escapeHtml(name)}">${escapeHtml(name)}: ${escapeHtml(value)}</span>`
+    ).join('') : '';
+
     // Redesigned header
     const headerMargin = isTextOnly ? 'mb-3' : 'mb-4';
     const headerHtml = `
         <div class="flex items-center justify-between ${headerMargin}">
             <div class="flex items-center space-x-2">
                 ${clickableSpaceBreadcrumb}
+                ${sourceBadge}${authorBadge}
+                ${journalBadge}
+                ${fieldBadges}
                 <span class="relative group/time text-sm text-gray-600 dark:text-gray-400 font-sans cursor-default">
                     ${formatRelativeDate(post.created)}
                     <div class="absolute left-0 top-full mt-1 px-2 py-1 bg-gray-900 dark:bg-gray-700 text-white text-xs rounded-md shadow-lg opacity-0 group-hover/time:opacity-100 transition-opacity duration-200 pointer-events-none z-50 whitespace-nowrap">
@@ -285,7 +307,7 @@ function createPostElement(post) {
 
         // Combine all attachments for a unified display
         const allAttachments = [...images, ...otherFiles];
-        const imageData = images.map(img => ({url: '/uploads/' + img.file_path, filename: img.filename}));
+        const imageData = images.map(img => ({url: uploadUrl(img.file_path), filename: img.filename}));
 
         attachmentsHtml += `
             <div>
@@ -313,7 +335,7 @@ function createPostElement(post) {
                                 const imageIndex = images.findIndex(img => img.filename === attachment.filename);
                                 return `
                                     <div class="relative flex-shrink-0 w-20 h-20 group cursor-pointer" onclick="openImageGallery(${imageIndex})" data-images='${JSON.stringify(imageData)}' title="${tooltipText}">
-                                        <img src="/uploads/${attachment.file_path}"
+                                        <img src="${uploadUrl(attachment.file_path)}"
                                              alt="${attachment.filename}"
                                              class="w-full h-full object-cover rounded-lg border hover:opacity-90 transition-opacity">
                                         <div class="absolute inset-x-0 bottom-0 bg-gradient-to-t from-black/60 to-transparent p-1 rounded-b-lg opacity-0 hover:opacity-100 transition-opacity">
@@ -324,7 +346,7 @@ function createPostElement(post) {
                                 `;
                             } else {
                                 return `
-                                    <div class="relative flex-shrink-0 w-20 h-20 group cursor-pointer" onclick="window.open('/uploads/${attachment.file_path}', '_blank')" title="${tooltipText}">
+                                    <div class="relative flex-shrink-0 w-20 h-20 group cursor-pointer" onclick="window.open('${uploadUrl(attachment.file_path)}', '_blank')" title="${tooltipText}">
                                         <div class="w-full h-full bg-gray-100 dark:bg-gray-800 border dark:border-gray-700 rounded-lg flex flex-col items-center justify-center hover:bg-gray-200 dark:hover:bg-gray-700 transition-colors">
                                             <i class="fas ${getFileIcon(fileExtension)} text-2xl text-gray-600 dark:text-gray-400 mb-1"></i>
                                             <span class="text-xs text-gray-500 dark:text-gray-400 font-medium">${fileExtension.toUpperCase()}</span>
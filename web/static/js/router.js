@@ -203,11 +203,20 @@ class Router {
         this.navigate(path);
     }
 
-    // Check for cached space and redirect if needed
-    checkCachedSpaceRedirect() {
+    // Check for the landing space and redirect if needed. Preferences stored on the server win
+    // over the space last opened in this browser.
+    checkCachedSpaceRedirect(prefs = null) {
         // Only check if we're on the root path
         if (window.location.pathname !== '/') return false;
 
+        if (prefs && prefs.scope !== 'default') {
+            if (prefs.landing_view !== 'space') return false;
+            const space = spaces && spaces.find(cat => cat.id === prefs.landing_space_id);
+            if (!space) return false;
+            this.navigate(this.buildSpacePath(space), true);
+            return true;
+        }
+
         // Check if there's a cached space selection
         const lastSpaceId = localStorage.getItem(window.AppConstants.STORAGE_KEYS.lastSpace);
         if (lastSpaceId && spaces && spaces.length > 0) {
@@ -77,12 +77,60 @@ async function showCreatePost() {
         document.getElementById('modal-retroactive-section').style.setProperty('display', 'none', 'important');
     }
 
+    // Render the custom fields defined by the space, if any
+    renderModalCustomFields(currentSpace.id);
+
     // Initialize link preview after showing the modal
     setTimeout(() => {
         initializeModalLinkPreview();
     }, window.AppConstants.UI_CONFIG.settingsTransitionDelay);
 }
 
+// Render one input per field of the space schema in the composer
+async function renderModalCustomFields(spaceId) {
+    const container = document.getElementById('modal-custom-fields');
+    container.innerHTML = '';
+    container.style.display = 'none';
+
+    const fields = await fetchSpaceFields(spaceId);
+    if (fields.length === 0) {
+        return;
+    }
+
+    const inputClass = 'w-full text-sm border border-gray-300 dark:border-gray-600 rounded-md px-3 py-2 focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-blue-500 dark:bg-gray-700 dark:text-gray-100';
+    container.innerHTML = fields.map(field => {
+        const id = `modal-field-${field.name}`;
+        const label = escapeHtml(field.label || field.name);
+        const required = field.required ? 'required' : '';
+        let input;
+        if (field.type === 'select') {
+            const options = field.options.map(option =>
+                `<option value="${escapeHtml(option)}">${escapeHtml(option)}</option>`).join('');
+            input = `<select id="${id}" data-field="${field.name}" class="${inputClass}" ${required}><option value=""></option>${options}</select>`;
+        } else {
+            const type = field.type === 'number' ? 'number' : field.type === 'date' ? 'date' : 'text';
+            const step = field.type === 'number' ? 'step="any"' : '';
+            input = `<input type="${type}" ${step} id="${id}" data-field="${field.name}" class="${inputClass}" ${required}>`;
+        }
+        return `<div>
+            <label for="${id}" class="block text-sm font-medium text-gray-700 dark:text-gray-300 mb-1">${label}${field.required ? ' *' : ''}</label>
+            ${input}
+        </div>`;
+    }).join('');
+    container.style.display = 'block';
+}
+
+// Collect the non-empty custom field values entered in the composer
+function collectModalCustomFields() {
+    const fields = {};
+    document.querySelectorAll('#modal-custom-fields [data-field]').forEach(input => {
+        if (input.value.trim() !== '') {
+            fields[input.dataset.field] = input.value.trim();
+        }
+    });
+    return fields;
+}
+
 function hideCreatePost() {
     document.getElementById('create-post-modal').classList.add('hidden');
     document.body.style.overflow = '';
@@ -102,6 +150,11 @@ function hideCreatePost() {
     // Reset link previews when hiding modal
     resetModalLinkPreviews();
 
+    // Clear custom fields, they are rendered again for the next space
+    const customFields = document.getElementById('modal-custom-fields');
+    customFields.innerHTML = '';
+    customFields.style.display = 'none';
+
     // Reset character counter
     const counter = document.getElementById('modal-char-counter');
     if (counter && window.currentSettings) {
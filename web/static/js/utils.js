@@ -57,6 +57,12 @@ function escapeHtml(text) {
     return div.innerHTML;
 }
 
+// URL of a stored attachment file. Stored names keep the original filename, so they may hold
+// spaces, quotes and other characters to escape.
+function uploadUrl(filePath) {
+    return '/uploads/' + encodeURIComponent(filePath).replace(/'/g, '%27');
+}
+
 function formatFileSize(bytes) {
     if (bytes === 0) return window.AppConstants.UI_TEXT.zeroBytes;
     const k = window.AppConstants.UI_CONFIG.fileSizeUnit;